@@ -0,0 +1,33 @@
+package buffer
+
+// Arena is an append-only byte store that hands out stable copies of byte slices, amortizing the allocation of many small copies such as tokens. Copies are never moved or overwritten, so they remain valid after the buffer they were copied from is restored, freed, or reused.
+type Arena struct {
+	buf  []byte
+	size int
+}
+
+// NewArena returns a new Arena that allocates blocks of the given size when needed, or of the default buffer size when size is not positive.
+func NewArena(size int) *Arena {
+	if size <= 0 {
+		size = defaultBufSize
+	}
+	return &Arena{
+		size: size,
+	}
+}
+
+// Copy returns a copy of b that is stored in the arena. Slices larger than a quarter of the block size get their own allocation.
+func (a *Arena) Copy(b []byte) []byte {
+	if len(b) == 0 {
+		return []byte{}
+	} else if a.size/4 < len(b) {
+		c := make([]byte, len(b))
+		copy(c, b)
+		return c
+	} else if cap(a.buf)-len(a.buf) < len(b) {
+		a.buf = make([]byte, 0, a.size)
+	}
+	n := len(a.buf)
+	a.buf = append(a.buf, b...)
+	return a.buf[n:len(a.buf):len(a.buf)]
+}
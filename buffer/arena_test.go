@@ -0,0 +1,28 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestArena(t *testing.T) {
+	a := NewArena(8)
+	b := []byte("abcdef")
+
+	x := a.Copy(b[:2])
+	y := a.Copy(b[2:4])
+	test.Bytes(t, x, []byte("ab"))
+	test.Bytes(t, y, []byte("cd"))
+	test.T(t, cap(x), 2, "copies must not grow into each other")
+
+	z := a.Copy(b[4:]) // new block
+	copy(b, "uvwxyz")
+	test.Bytes(t, x, []byte("ab"), "copies are independent of the source")
+	test.Bytes(t, y, []byte("cd"))
+	test.Bytes(t, z, []byte("ef"))
+
+	test.Bytes(t, a.Copy(b), []byte("uvwxyz"), "large copies get their own allocation")
+	test.Bytes(t, a.Copy(nil), []byte{})
+	test.T(t, NewArena(0).size, defaultBufSize)
+}
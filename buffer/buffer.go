@@ -1,7 +1,10 @@
 // Package buffer contains buffer and wrapper types for byte slices. It is useful for writing lexers or other high-performance byte slice handling.
 // The `Reader` and `Writer` types implement the `io.Reader` and `io.Writer` respectively and provide a thinner and faster interface than `bytes.Buffer`.
+// The `IndentWriter` type indents the lines written to an `io.Writer` by a nesting depth and keeps track of the output position, for pretty-printers.
+// The `CountingWriter` and `LimitWriter` types count the bytes, lines, and column written to an `io.Writer` and limit the number of bytes written, respectively.
+// The `SourceMapWriter` type records the original source positions of the output written to an `io.Writer` and produces a source map.
 // The `Lexer` type is useful for building lexers because it keeps track of the start and end position of a byte selection, and shifts the bytes whenever a valid token is found.
-// The `StreamLexer` does the same, but keeps a buffer pool so that it reads a limited amount at a time, allowing to parse from streaming sources.
+// The `StreamLexer` does the same, but reads a limited amount at a time into a reused buffer, allowing to parse from streaming sources.
 package buffer
 
 // defaultBufSize specifies the default initial length of internal buffers.
@@ -0,0 +1,70 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+)
+
+// CountingWriter implements an io.Writer that counts the bytes and lines written to the underlying writer and keeps track of the current column, so that serializers can report output positions without wrapping the writer themselves.
+type CountingWriter struct {
+	w   io.Writer
+	err error
+
+	line, col int // zero-based position of the next byte
+	offset    int64
+}
+
+// NewCountingWriter returns a new CountingWriter that writes to w.
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	return &CountingWriter{
+		w: w,
+	}
+}
+
+// Write writes b and counts the bytes that were written. It returns the first error of the underlying writer, after which all writes fail.
+func (w *CountingWriter) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(b)
+	w.advance(b[:n])
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// WriteString writes s, see Write.
+func (w *CountingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Count returns the number of bytes written.
+func (w *CountingWriter) Count() int64 {
+	return w.offset
+}
+
+// Lines returns the number of newlines written.
+func (w *CountingWriter) Lines() int {
+	return w.line
+}
+
+// Position returns the zero-based line and column of the next byte in the output, where columns count bytes.
+func (w *CountingWriter) Position() (int, int) {
+	return w.line, w.col
+}
+
+// Err returns the first error of the underlying writer.
+func (w *CountingWriter) Err() error {
+	return w.err
+}
+
+func (w *CountingWriter) advance(b []byte) {
+	w.offset += int64(len(b))
+	if i := bytes.LastIndexByte(b, '\n'); i != -1 {
+		w.line += bytes.Count(b, []byte{'\n'})
+		w.col = len(b) - i - 1
+	} else {
+		w.col += len(b)
+	}
+}
@@ -0,0 +1,39 @@
+package buffer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestCountingWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewCountingWriter(buf)
+	w.WriteString("a {")
+	line, col := w.Position()
+	test.T(t, line, 0)
+	test.T(t, col, 3)
+	w.Write([]byte("\n  b: c;\n"))
+	test.T(t, w.Lines(), 2)
+	w.WriteString("}")
+	test.Error(t, w.Err())
+	test.String(t, buf.String(), "a {\n  b: c;\n}")
+	test.T(t, w.Count(), int64(buf.Len()))
+	line, col = w.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 1)
+}
+
+func TestCountingWriterError(t *testing.T) {
+	w := NewCountingWriter(test.NewErrorWriter(1))
+	w.WriteString("ab\nc")
+	n, err := w.WriteString("d")
+	test.T(t, n, 0)
+	test.T(t, err, test.ErrPlain)
+	_, err = w.WriteString("e")
+	test.T(t, err, test.ErrPlain)
+	test.T(t, w.Err(), test.ErrPlain)
+	test.T(t, w.Count(), int64(4))
+	test.T(t, w.Lines(), 1)
+}
@@ -0,0 +1,120 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+)
+
+// IndentWriter implements an io.Writer that indents lines by the current nesting depth and keeps track of the line and column of the output, so that pretty-printers can share the indentation logic and report output positions. The indentation is written before the first byte of each line, so that empty lines have no trailing whitespace.
+type IndentWriter struct {
+	w       io.Writer
+	indent  []byte
+	indents []byte // indent repeated for the deepest depth so far
+	depth   int
+	err     error
+
+	line, col int // zero-based position of the next byte
+	offset    int64
+}
+
+// NewIndentWriter returns a new IndentWriter that writes to w and indents each level of nesting by indent, such as two spaces or a tab.
+func NewIndentWriter(w io.Writer, indent string) *IndentWriter {
+	return &IndentWriter{
+		w:      w,
+		indent: []byte(indent),
+	}
+}
+
+// Indent increases the nesting depth by one, which applies from the next line.
+func (w *IndentWriter) Indent() {
+	w.depth++
+}
+
+// Dedent decreases the nesting depth by one, which applies from the next line. It does nothing at depth zero.
+func (w *IndentWriter) Dedent() {
+	if 0 < w.depth {
+		w.depth--
+	}
+}
+
+// Depth returns the nesting depth.
+func (w *IndentWriter) Depth() int {
+	return w.depth
+}
+
+// Write writes b and indents every line that it starts. It returns the number of bytes of b written, excluding the indentation, and the first error of the underlying writer, after which all writes fail.
+func (w *IndentWriter) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for 0 < len(b) {
+		if w.col == 0 && b[0] != '\n' && 0 < w.depth {
+			if err := w.writeIndent(); err != nil {
+				return n, err
+			}
+		}
+		end := len(b)
+		if i := bytes.IndexByte(b, '\n'); i != -1 {
+			end = i + 1
+		}
+		m, err := w.w.Write(b[:end])
+		w.advance(b[:m])
+		n += m
+		if err != nil {
+			w.err = err
+			return n, err
+		}
+		b = b[end:]
+	}
+	return n, nil
+}
+
+// WriteString writes s, see Write.
+func (w *IndentWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Newline writes a newline.
+func (w *IndentWriter) Newline() error {
+	_, err := w.Write([]byte{'\n'})
+	return err
+}
+
+// Position returns the zero-based line and column of the next byte in the output, where columns count bytes.
+func (w *IndentWriter) Position() (int, int) {
+	return w.line, w.col
+}
+
+// Offset returns the number of bytes written to the underlying writer, including the indentation.
+func (w *IndentWriter) Offset() int64 {
+	return w.offset
+}
+
+// Err returns the first error of the underlying writer.
+func (w *IndentWriter) Err() error {
+	return w.err
+}
+
+func (w *IndentWriter) writeIndent() error {
+	n := w.depth * len(w.indent)
+	for len(w.indents) < n {
+		w.indents = append(w.indents, w.indent...)
+	}
+	m, err := w.w.Write(w.indents[:n])
+	w.advance(w.indents[:m])
+	if err != nil {
+		w.err = err
+	}
+	return err
+}
+
+func (w *IndentWriter) advance(b []byte) {
+	w.offset += int64(len(b))
+	if i := bytes.LastIndexByte(b, '\n'); i != -1 {
+		w.line += bytes.Count(b, []byte{'\n'})
+		w.col = len(b) - i - 1
+	} else {
+		w.col += len(b)
+	}
+}
@@ -0,0 +1,61 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestIndentWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewIndentWriter(buf, "  ")
+	w.WriteString("a {")
+	w.Indent()
+	test.T(t, w.Depth(), 1)
+	w.WriteString("\nb: c;\n\nd {")
+	w.Indent()
+	w.Newline()
+	w.Write([]byte("e: f;"))
+	line, col := w.Position()
+	test.T(t, line, 4)
+	test.T(t, col, 9)
+	w.Dedent()
+	w.WriteString("\n}")
+	w.Dedent()
+	w.Dedent()
+	test.T(t, w.Depth(), 0)
+	w.WriteString("\n}\n")
+	test.Error(t, w.Err())
+	test.String(t, buf.String(), "a {\n  b: c;\n\n  d {\n    e: f;\n  }\n}\n")
+	test.T(t, w.Offset(), int64(buf.Len()))
+	line, col = w.Position()
+	test.T(t, line, 7)
+	test.T(t, col, 0)
+}
+
+func TestIndentWriterError(t *testing.T) {
+	w := NewIndentWriter(test.NewErrorWriter(2), "\t")
+	w.Indent()
+	n, err := w.WriteString("a\nb")
+	test.T(t, n, 2)
+	test.T(t, err, test.ErrPlain)
+	_, err = w.WriteString("c")
+	test.T(t, err, test.ErrPlain)
+	test.T(t, w.Err(), test.ErrPlain)
+}
+
+func ExampleIndentWriter() {
+	buf := &bytes.Buffer{}
+	w := NewIndentWriter(buf, "    ")
+	w.WriteString("if (a) {")
+	w.Indent()
+	w.WriteString("\nb();")
+	w.Dedent()
+	w.WriteString("\n}")
+	fmt.Println(buf.String())
+	// Output: if (a) {
+	//     b();
+	// }
+}
@@ -15,6 +15,7 @@ type Lexer struct {
 	err   error
 
 	restore func()
+	arena   *Arena
 }
 
 // NewLexer returns a new Lexer for a given io.Reader, and uses io.ReadAll to read it into a byte slice.
@@ -129,7 +130,7 @@ func (z *Lexer) Rewind(pos int) {
 	z.pos = z.start + pos
 }
 
-// Lexeme returns the bytes of the current selection.
+// Lexeme returns the bytes of the current selection, which is a slice of the underlying buffer also when an arena is set.
 func (z *Lexer) Lexeme() []byte {
 	return z.buf[z.start:z.pos:z.pos]
 }
@@ -142,10 +143,18 @@ func (z *Lexer) Skip() {
 // Shift returns the bytes of the current selection and collapses the position to the end of the selection.
 func (z *Lexer) Shift() []byte {
 	b := z.buf[z.start:z.pos:z.pos]
+	if z.arena != nil {
+		b = z.arena.Copy(b)
+	}
 	z.start = z.pos
 	return b
 }
 
+// SetArena makes Shift return copies stored in the arena instead of slices of the underlying buffer, so that they remain valid after Restore. Each token is copied once when it is shifted. Passing nil disables copying.
+func (z *Lexer) SetArena(a *Arena) {
+	z.arena = a
+}
+
 // Offset returns the character position in the buffer.
 func (z *Lexer) Offset() int {
 	return z.pos
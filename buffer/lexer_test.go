@@ -110,3 +110,24 @@ func TestLexerRestore(t *testing.T) {
 	z.Restore()
 	test.Bytes(t, b, []byte{'a', 'b', 'c', 'd'}, "terminating NULL has been restored")
 }
+
+func TestLexerArena(t *testing.T) {
+	b := []byte{'a', 'b', 'c', 'd'}
+	z := NewLexerBytes(b[:3])
+	z.SetArena(NewArena(0))
+
+	z.Move(2)
+	lexeme := z.Lexeme()
+	lexeme[1] = 'B'
+	z.Move(1)
+	shifted := z.Shift()
+	z.Restore()
+	b[0], b[2] = 'x', 'y'
+	test.Bytes(t, lexeme, []byte("xB"), "lexeme must be the buffer")
+	test.Bytes(t, shifted, []byte("aBc"), "shift must outlive the buffer and keep changes to the lexeme")
+
+	z.SetArena(nil)
+	z.Reset()
+	z.Move(1)
+	test.Bytes(t, z.Shift(), []byte("x"), "shift must return the buffer without arena")
+}
@@ -0,0 +1,62 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrLimitExceeded is returned by LimitWriter when a write exceeds the limit.
+var ErrLimitExceeded = errors.New("write limit exceeded")
+
+// LimitWriter implements an io.Writer that writes at most a limited number of bytes to the underlying writer, so that serializers can enforce an output budget. A write that exceeds the limit writes the bytes that fit and returns ErrLimitExceeded, after which all writes fail.
+type LimitWriter struct {
+	w   io.Writer
+	n   int64 // remaining bytes
+	err error
+}
+
+// NewLimitWriter returns a new LimitWriter that writes at most n bytes to w. A negative n is treated as zero.
+func NewLimitWriter(w io.Writer, n int64) *LimitWriter {
+	if n < 0 {
+		n = 0
+	}
+	return &LimitWriter{
+		w: w,
+		n: n,
+	}
+}
+
+// Write writes b, or the bytes of b that fit within the limit. It returns ErrLimitExceeded if not all of b fits, or the first error of the underlying writer.
+func (w *LimitWriter) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	exceeded := w.n < int64(len(b))
+	if exceeded {
+		b = b[:w.n]
+	}
+	n, err := w.w.Write(b)
+	w.n -= int64(n)
+	if err == nil && exceeded {
+		err = ErrLimitExceeded
+	}
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// WriteString writes s, see Write.
+func (w *LimitWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Remaining returns the number of bytes that can still be written.
+func (w *LimitWriter) Remaining() int64 {
+	return w.n
+}
+
+// Err returns ErrLimitExceeded when the limit was exceeded, or the first error of the underlying writer.
+func (w *LimitWriter) Err() error {
+	return w.err
+}
@@ -0,0 +1,59 @@
+package buffer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestLimitWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewLimitWriter(buf, 5)
+	n, err := w.WriteString("abc")
+	test.T(t, n, 3)
+	test.Error(t, err)
+	test.T(t, w.Remaining(), int64(2))
+
+	n, err = w.WriteString("de")
+	test.T(t, n, 2)
+	test.Error(t, err)
+	test.T(t, w.Remaining(), int64(0))
+
+	n, err = w.WriteString("")
+	test.T(t, n, 0)
+	test.Error(t, err)
+
+	n, err = w.WriteString("f")
+	test.T(t, n, 0)
+	test.T(t, err, ErrLimitExceeded)
+	test.String(t, buf.String(), "abcde")
+}
+
+func TestLimitWriterExceeded(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewLimitWriter(buf, 4)
+	n, err := w.WriteString("abcdef")
+	test.T(t, n, 4)
+	test.T(t, err, ErrLimitExceeded)
+	test.T(t, w.Err(), ErrLimitExceeded)
+
+	_, err = w.WriteString("")
+	test.T(t, err, ErrLimitExceeded)
+	test.String(t, buf.String(), "abcd")
+}
+
+func TestLimitWriterError(t *testing.T) {
+	w := NewLimitWriter(test.NewErrorWriter(0), 4)
+	_, err := w.WriteString("ab")
+	test.T(t, err, test.ErrPlain)
+	test.T(t, w.Err(), test.ErrPlain)
+	test.T(t, w.Remaining(), int64(4))
+}
+
+func TestLimitWriterNegative(t *testing.T) {
+	w := NewLimitWriter(&bytes.Buffer{}, -1)
+	n, err := w.WriteString("a")
+	test.T(t, n, 0)
+	test.T(t, err, ErrLimitExceeded)
+}
@@ -28,6 +28,20 @@ func (r *Reader) Read(b []byte) (n int, err error) {
 	return
 }
 
+// WriteTo writes the unread bytes to w and advances the read pointer, so that io.Copy writes them without an intermediate buffer. It implements io.WriterTo.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	if r.pos >= len(r.buf) {
+		return 0, nil
+	}
+	m := len(r.buf) - r.pos
+	n, err := w.Write(r.buf[r.pos:])
+	r.pos += n
+	if err == nil && n < m {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
 // Bytes returns the underlying byte slice.
 func (r *Reader) Bytes() []byte {
 	return r.buf
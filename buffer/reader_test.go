@@ -41,6 +41,33 @@ func TestReader(t *testing.T) {
 	test.Bytes(t, buf, []byte("abc"), "read after reset must match 'abc'")
 }
 
+func TestReaderWriteTo(t *testing.T) {
+	r := NewReader([]byte("abcde"))
+	buf := make([]byte, 2)
+	r.Read(buf)
+
+	w := &bytes.Buffer{}
+	n, err := r.WriteTo(w)
+	test.Error(t, err)
+	test.T(t, n, int64(3))
+	test.String(t, w.String(), "cde")
+
+	n, err = r.WriteTo(w)
+	test.Error(t, err)
+	test.T(t, n, int64(0))
+
+	r.Reset()
+	_, err = r.WriteTo(test.NewErrorWriter(0))
+	test.T(t, err, test.ErrPlain)
+
+	r.Reset()
+	n, err = r.WriteTo(shortWriter{2})
+	test.T(t, err, io.ErrShortWrite)
+	test.T(t, n, int64(2))
+	m, _ := r.Read(make([]byte, 5))
+	test.T(t, m, 3, "unwritten bytes are not read")
+}
+
 func ExampleNewReader() {
 	r := NewReader([]byte("Lorem ipsum"))
 	w := &bytes.Buffer{}
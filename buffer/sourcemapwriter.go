@@ -0,0 +1,181 @@
+package buffer
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// SourceMapWriter implements an io.Writer that records where the written output comes from in the original sources, and produces a source map version 3, see https://tc39.es/source-map/, so that minifiers and other tools that re-serialize CSS or JS can share the output layer. Generated and original columns are counted in UTF-16 code units as browsers do, and lines are separated by line feeds.
+type SourceMapWriter struct {
+	w   io.Writer
+	err error
+
+	line, col int // zero-based position of the next byte
+
+	sources     []string
+	sourceIndex map[string]int
+	names       []string
+	nameIndex   map[string]int
+
+	mappings []byte
+	segLine  int // generated line of the last segment
+	prevCol  int // generated column of the previous segment on the same line
+	prevSrc  int
+	prevLine int
+	prevOrig int // original column of the previous segment
+	prevName int
+}
+
+// NewSourceMapWriter returns a new SourceMapWriter that writes to w.
+func NewSourceMapWriter(w io.Writer) *SourceMapWriter {
+	return &SourceMapWriter{
+		w:           w,
+		sourceIndex: map[string]int{},
+		nameIndex:   map[string]int{},
+		mappings:    []byte{},
+	}
+}
+
+// Write writes b without mapping it, so that it belongs to the mapping of the previous WriteMapped on the same line, if any. It returns the first error of the underlying writer, after which all writes fail.
+func (w *SourceMapWriter) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(b)
+	w.advance(b[:n])
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// WriteString writes s without mapping it, see Write.
+func (w *SourceMapWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteMapped writes b and maps its start to the one-based line and column of the original source file, such as returned by the Position of a parse.Input with UTF16Columns.
+func (w *SourceMapWriter) WriteMapped(b []byte, file string, line, col int) (int, error) {
+	return w.WriteNamed(b, file, line, col, "")
+}
+
+// WriteNamed writes b and maps its start to the one-based line and column of the original source file, and to the original name of an identifier that was renamed, see WriteMapped. The name is omitted when empty.
+func (w *SourceMapWriter) WriteNamed(b []byte, file string, line, col int, name string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	} else if len(b) != 0 {
+		w.addSegment(file, line-1, col-1, name)
+	}
+	return w.Write(b)
+}
+
+// Position returns the zero-based line and column of the next byte in the output, where columns count UTF-16 code units.
+func (w *SourceMapWriter) Position() (int, int) {
+	return w.line, w.col
+}
+
+// Err returns the first error of the underlying writer.
+func (w *SourceMapWriter) Err() error {
+	return w.err
+}
+
+// Mappings returns the mappings field of the source map, which encodes the segments as Base64 VLQs.
+func (w *SourceMapWriter) Mappings() []byte {
+	return w.mappings
+}
+
+// Sources returns the original source files in the order of their first mapping.
+func (w *SourceMapWriter) Sources() []string {
+	return w.sources
+}
+
+// Names returns the original names in the order of their first mapping.
+func (w *SourceMapWriter) Names() []string {
+	return w.names
+}
+
+// SourceMap returns the source map as JSON, where file is the name of the generated file, or empty to omit it.
+func (w *SourceMapWriter) SourceMap(file string) []byte {
+	sm := struct {
+		Version  int      `json:"version"`
+		File     string   `json:"file,omitempty"`
+		Sources  []string `json:"sources"`
+		Names    []string `json:"names"`
+		Mappings string   `json:"mappings"`
+	}{3, file, w.sources, w.names, string(w.mappings)}
+	if sm.Sources == nil {
+		sm.Sources = []string{}
+	}
+	if sm.Names == nil {
+		sm.Names = []string{}
+	}
+	b, _ := json.Marshal(sm)
+	return b
+}
+
+func (w *SourceMapWriter) addSegment(file string, line, col int, name string) {
+	src, ok := w.sourceIndex[file]
+	if !ok {
+		src = len(w.sources)
+		w.sources = append(w.sources, file)
+		w.sourceIndex[file] = src
+	}
+
+	if w.segLine < w.line {
+		for ; w.segLine < w.line; w.segLine++ {
+			w.mappings = append(w.mappings, ';')
+		}
+		w.prevCol = 0
+	} else if 0 < len(w.mappings) && w.mappings[len(w.mappings)-1] != ';' {
+		w.mappings = append(w.mappings, ',')
+	}
+	w.mappings = appendVLQ(w.mappings, w.col-w.prevCol)
+	w.mappings = appendVLQ(w.mappings, src-w.prevSrc)
+	w.mappings = appendVLQ(w.mappings, line-w.prevLine)
+	w.mappings = appendVLQ(w.mappings, col-w.prevOrig)
+	w.prevCol, w.prevSrc, w.prevLine, w.prevOrig = w.col, src, line, col
+	if name != "" {
+		i, ok := w.nameIndex[name]
+		if !ok {
+			i = len(w.names)
+			w.names = append(w.names, name)
+			w.nameIndex[name] = i
+		}
+		w.mappings = appendVLQ(w.mappings, i-w.prevName)
+		w.prevName = i
+	}
+}
+
+func (w *SourceMapWriter) advance(b []byte) {
+	for _, c := range b {
+		if c == '\n' {
+			w.line++
+			w.col = 0
+		} else if c < 0x80 || 0xC0 <= c && c < 0xF0 {
+			w.col++
+		} else if 0xF0 <= c {
+			w.col += 2 // surrogate pair
+		}
+	}
+}
+
+// appendVLQ appends the Base64 VLQ encoding of i, where the least significant bit of the first digit is the sign.
+func appendVLQ(b []byte, i int) []byte {
+	v := uint(i) << 1
+	if i < 0 {
+		v = uint(-i)<<1 | 1
+	}
+	for {
+		digit := v & 31
+		v >>= 5
+		if v != 0 {
+			digit |= 32 // continuation bit
+		}
+		b = append(b, base64Digits[digit])
+		if v == 0 {
+			return b
+		}
+	}
+}
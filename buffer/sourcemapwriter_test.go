@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestSourceMapWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewSourceMapWriter(buf)
+	w.WriteMapped([]byte("a"), "a.css", 1, 1)
+	w.WriteString("{")
+	w.WriteMapped([]byte("b:c"), "a.css", 2, 3)
+	w.WriteString("}\n")
+	w.WriteMapped([]byte("d"), "b.css", 1, 1)
+	w.WriteNamed([]byte("x"), "b.css", 1, 20, "longName")
+	w.WriteString("\n\n")
+	w.WriteMapped([]byte("e"), "a.css", 3, 1)
+	w.WriteMapped(nil, "c.css", 1, 1) // empty writes are not mapped
+	test.Error(t, w.Err())
+	test.String(t, buf.String(), "a{b:c}\ndx\n\ne")
+	test.String(t, string(w.Mappings()), "AAAA,EACE;ACDF,CAAmBA;;ADEnB")
+	test.T(t, w.Sources(), []string{"a.css", "b.css"})
+	test.T(t, w.Names(), []string{"longName"})
+	test.String(t, string(w.SourceMap("out.css")), `{"version":3,"file":"out.css","sources":["a.css","b.css"],"names":["longName"],"mappings":"AAAA,EACE;ACDF,CAAmBA;;ADEnB"}`)
+	line, col := w.Position()
+	test.T(t, line, 3)
+	test.T(t, col, 1)
+}
+
+func TestSourceMapWriterEmpty(t *testing.T) {
+	w := NewSourceMapWriter(&bytes.Buffer{})
+	w.WriteString("a\nb")
+	test.String(t, string(w.Mappings()), "")
+	test.String(t, string(w.SourceMap("")), `{"version":3,"sources":[],"names":[],"mappings":""}`)
+}
+
+func TestSourceMapWriterUTF16(t *testing.T) {
+	w := NewSourceMapWriter(&bytes.Buffer{})
+	w.WriteString("\u00E9\U0001F600") // one and two UTF-16 code units
+	line, col := w.Position()
+	test.T(t, line, 0)
+	test.T(t, col, 3)
+	w.WriteMapped([]byte("a"), "a.js", 1, 1)
+	test.String(t, string(w.Mappings()), "GAAA")
+}
+
+func TestSourceMapWriterError(t *testing.T) {
+	w := NewSourceMapWriter(test.NewErrorWriter(1))
+	_, err := w.WriteMapped([]byte("ab"), "a.js", 1, 1)
+	test.Error(t, err)
+	n, err := w.WriteMapped([]byte("c"), "a.js", 1, 3)
+	test.T(t, n, 0)
+	test.T(t, err, test.ErrPlain)
+	_, err = w.WriteMapped([]byte("d"), "a.js", 1, 4)
+	test.T(t, err, test.ErrPlain)
+	test.T(t, w.Err(), test.ErrPlain)
+	test.String(t, string(w.Mappings()), "AAAA,EAAE")
+}
+
+func TestVLQ(t *testing.T) {
+	var vlqTests = []struct {
+		i        int
+		expected string
+	}{
+		{0, "A"},
+		{1, "C"},
+		{-1, "D"},
+		{15, "e"},
+		{16, "gB"},
+		{-16, "hB"},
+		{1000, "w+B"},
+	}
+	for _, tt := range vlqTests {
+		t.Run(fmt.Sprint(tt.i), func(t *testing.T) {
+			test.String(t, string(appendVLQ(nil, tt.i)), tt.expected)
+		})
+	}
+}
+
+func ExampleSourceMapWriter() {
+	buf := &bytes.Buffer{}
+	w := NewSourceMapWriter(buf)
+	w.WriteMapped([]byte("a{color:red}"), "style.css", 1, 1)
+	w.WriteString("\n")
+	w.WriteMapped([]byte("b{color:blue}"), "style.css", 5, 1)
+	fmt.Println(buf.String())
+	fmt.Println(string(w.SourceMap("style.min.css")))
+	// Output: a{color:red}
+	// b{color:blue}
+	// {"version":3,"file":"style.min.css","sources":["style.css"],"names":[],"mappings":"AAAA;AAIA"}
+}
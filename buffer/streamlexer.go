@@ -4,82 +4,28 @@ import (
 	"io"
 )
 
-type block struct {
-	buf    []byte
-	next   int // index in pool plus one
-	active bool
-}
-
-type bufferPool struct {
-	pool []block
-	head int // index in pool plus one
-	tail int // index in pool plus one
-
-	pos int // byte pos in tail
-}
-
-func (z *bufferPool) swap(oldBuf []byte, size int) []byte {
-	// find new buffer that can be reused
-	swap := -1
-	for i := 0; i < len(z.pool); i++ {
-		if !z.pool[i].active && size <= cap(z.pool[i].buf) {
-			swap = i
-			break
-		}
-	}
-	if swap == -1 { // no free buffer found for reuse
-		if z.tail == 0 && z.pos >= len(oldBuf) && size <= cap(oldBuf) { // but we can reuse the current buffer!
-			z.pos -= len(oldBuf)
-			return oldBuf[:0]
-		}
-		// allocate new
-		z.pool = append(z.pool, block{make([]byte, 0, size), 0, true})
-		swap = len(z.pool) - 1
-	}
-
-	newBuf := z.pool[swap].buf
-
-	// put current buffer into pool
-	z.pool[swap] = block{oldBuf, 0, true}
-	if z.head != 0 {
-		z.pool[z.head-1].next = swap + 1
-	}
-	z.head = swap + 1
-	if z.tail == 0 {
-		z.tail = swap + 1
-	}
-
-	return newBuf[:0]
-}
-
-func (z *bufferPool) free(n int) {
-	z.pos += n
-	// move the tail over to next buffers
-	for z.tail != 0 && z.pos >= len(z.pool[z.tail-1].buf) {
-		z.pos -= len(z.pool[z.tail-1].buf)
-		newTail := z.pool[z.tail-1].next
-		z.pool[z.tail-1].active = false // after this, any thread may pick up the inactive buffer, so it can't be used anymore
-		z.tail = newTail
-	}
-	if z.tail == 0 {
-		z.head = 0
-	}
+// chunk is a buffer that has been replaced by a new buffer while it still holds n shifted bytes that have not been freed.
+type chunk struct {
+	buf []byte
+	n   int
 }
 
 // StreamLexer is a buffered reader that allows peeking forward and shifting, taking an io.Reader.
 // It keeps data in-memory until Free, taking a byte length, is called to move beyond the data.
+// When all shifted bytes have been freed, the buffer is reused by moving the current token to its front, so that memory is bounded by twice the longest token plus lookahead regardless of the length of the stream. Buffers holding shifted bytes that have not been freed are kept as a list of chunks until they are freed.
 type StreamLexer struct {
 	r   io.Reader
 	err error
 
-	pool bufferPool
-
 	buf       []byte
 	start     int // index in buf
 	pos       int // index in buf
-	prevStart int
+	prevStart int // index in buf, may be negative
+	freed     int // index in buf up to which shifted bytes have been freed
 
-	free int
+	chunks []chunk // previous buffers with unfreed bytes, oldest first
+	spare  []byte  // previous buffer that has been freed, for reuse
+	arena  *Arena
 }
 
 // NewStreamLexer returns a new StreamLexer for a given io.Reader with a 4kB estimated buffer size.
@@ -111,35 +57,65 @@ func (z *StreamLexer) read(pos int) byte {
 		return 0
 	}
 
-	// free unused bytes
-	z.pool.free(z.free)
-	z.free = 0
-
-	// get new buffer
-	c := cap(z.buf)
-	p := pos - z.start + 1
-	if 2*p > c { // if the token is larger than half the buffer, increase buffer size
-		c = 2*c + p
+	// the new buffer holds the current token and the lookahead up to pos
+	n := pos - z.start + 1
+	size := cap(z.buf)
+	if size < 2*n { // if the token is larger than half the buffer, increase buffer size
+		size = 2*size + n
 	}
 	d := len(z.buf) - z.start
-	buf := z.pool.swap(z.buf[:z.start], c)
-	copy(buf[:d], z.buf[z.start:]) // copy the left-overs (unfinished token) from the old buffer
+	var buf []byte
+	if z.freed == z.start && size == cap(z.buf) {
+		// all shifted bytes have been freed, move the current token to the front
+		buf = z.buf[:d]
+		copy(buf, z.buf[z.start:])
+	} else {
+		if size <= cap(z.spare) {
+			buf = z.spare[:d]
+			z.spare = nil
+		} else {
+			buf = make([]byte, d, size)
+		}
+		copy(buf, z.buf[z.start:])
+		if z.freed < z.start {
+			z.chunks = append(z.chunks, chunk{z.buf, z.start - z.freed})
+		} else {
+			z.recycle(z.buf)
+		}
+	}
 
 	// read in new data for the rest of the buffer
-	var n int
-	for pos-z.start >= d && z.err == nil {
-		n, z.err = z.r.Read(buf[d:cap(buf)])
-		d += n
+	var m int
+	for d < n && z.err == nil {
+		m, z.err = z.r.Read(buf[d:cap(buf)])
+		d += m
 	}
 	pos -= z.start
 	z.pos -= z.start
-	z.start, z.buf = 0, buf[:d]
+	z.prevStart -= z.start
+	z.start, z.freed, z.buf = 0, 0, buf[:d]
 	if pos >= d {
 		return 0
 	}
 	return z.buf[pos]
 }
 
+// recycle keeps a freed buffer for reuse, keeping only the largest.
+func (z *StreamLexer) recycle(buf []byte) {
+	if cap(z.spare) < cap(buf) {
+		z.spare = buf
+	}
+}
+
+// size returns the total capacity of the buffers held in memory.
+func (z *StreamLexer) size() int {
+	n := cap(z.buf) + cap(z.spare)
+	for _, c := range z.chunks {
+		n += cap(c.buf)
+	}
+	return n
+}
+
 // Err returns the error returned from io.Reader. It may still return valid bytes for a while though.
 func (z *StreamLexer) Err() error {
 	if z.err == io.EOF && z.pos < len(z.buf) {
@@ -148,10 +124,26 @@ func (z *StreamLexer) Err() error {
 	return z.err
 }
 
-// Free frees up bytes of length n from previously shifted tokens.
+// Free frees up bytes of length n from previously shifted tokens, oldest first, after which the slices returned for them may be overwritten.
 // Each call to Shift should at one point be followed by a call to Free with a length returned by ShiftLen.
 func (z *StreamLexer) Free(n int) {
-	z.free += n
+	for 0 < n && 0 < len(z.chunks) {
+		if n < z.chunks[0].n {
+			z.chunks[0].n -= n
+			return
+		}
+		n -= z.chunks[0].n
+		z.recycle(z.chunks[0].buf)
+		z.chunks[0] = chunk{}
+		z.chunks = z.chunks[1:]
+	}
+	if len(z.chunks) == 0 {
+		z.chunks = nil
+	}
+	z.freed += n
+	if z.start < z.freed {
+		z.freed = z.start
+	}
 }
 
 // Peek returns the ith byte relative to the end position and possibly does an allocation.
@@ -194,7 +186,7 @@ func (z *StreamLexer) Rewind(pos int) {
 	z.pos = z.start + pos
 }
 
-// Lexeme returns the bytes of the current selection.
+// Lexeme returns the bytes of the current selection, which is a slice of the internal buffer also when an arena is set.
 func (z *StreamLexer) Lexeme() []byte {
 	return z.buf[z.start:z.pos]
 }
@@ -211,10 +203,18 @@ func (z *StreamLexer) Shift() []byte {
 		z.read(z.pos - 1)
 	}
 	b := z.buf[z.start:z.pos]
+	if z.arena != nil {
+		b = z.arena.Copy(b)
+	}
 	z.start = z.pos
 	return b
 }
 
+// SetArena makes Shift return copies stored in the arena instead of slices of the internal buffer, so that they remain valid after Free when the buffer is reused for new data. Each token is copied once when it is shifted. Passing nil disables copying.
+func (z *StreamLexer) SetArena(a *Arena) {
+	z.arena = a
+}
+
 // ShiftLen returns the number of bytes moved since the last call to ShiftLen. This can be used in calls to Free because it takes into account multiple Shifts or Skips.
 func (z *StreamLexer) ShiftLen() int {
 	n := z.start - z.prevStart
@@ -8,41 +8,43 @@ import (
 	"github.com/tdewolff/test"
 )
 
-func TestBufferPool(t *testing.T) {
-	z := &bufferPool{}
-
-	lorem := []byte("Lorem ipsum")
-	dolor := []byte("dolor sit amet")
-	consectetur := []byte("consectetur adipiscing elit")
-
-	// set lorem as first buffer and get new dolor buffer
-	b := z.swap(lorem, len(dolor))
-	test.That(t, len(b) == 0)
-	test.That(t, cap(b) == len(dolor))
-	b = append(b, dolor...)
-
-	// free first buffer so it will be reused
-	z.free(len(lorem))
-	b = z.swap(b, len(lorem))
-	b = b[:len(lorem)]
-	test.Bytes(t, b, lorem)
-
-	b = z.swap(b, len(consectetur))
-	b = append(b, consectetur...)
-
-	// free in advance to reuse the same buffer
-	z.free(len(dolor) + len(lorem) + len(consectetur))
-	test.That(t, z.head == 0)
-	b = z.swap(b, len(consectetur))
-	b = b[:len(consectetur)]
-	test.Bytes(t, b, consectetur)
-
-	// free in advance but request larger buffer
-	z.free(len(consectetur))
-	b = z.swap(b, len(consectetur)+1)
-	b = append(b, consectetur...)
-	b = append(b, '.')
-	test.That(t, cap(b) == len(consectetur)+1)
+// wordReader reads n bytes of words separated by spaces.
+type wordReader struct {
+	n, i int64
+}
+
+func (r *wordReader) Read(b []byte) (int, error) {
+	const words = "lorem ipsum dolor sit amet "
+	if r.n <= r.i {
+		return 0, io.EOF
+	}
+	if r.n-r.i < int64(len(b)) {
+		b = b[:r.n-r.i]
+	}
+	for k := range b {
+		b[k] = words[r.i%int64(len(words))]
+		r.i++
+	}
+	return len(b), nil
+}
+
+// lexWords shifts all words of the lexer and calls free after each shift with the shifted word.
+func lexWords(z *StreamLexer, free func([]byte)) (int, int) {
+	words, peak := 0, 0
+	for z.Peek(0) != 0 {
+		for c := z.Peek(0); c != ' ' && c != 0; c = z.Peek(0) {
+			z.Move(1)
+		}
+		if z.Peek(0) == ' ' {
+			z.Move(1)
+		}
+		free(z.Shift())
+		words++
+		if peak < z.size() {
+			peak = z.size()
+		}
+	}
+	return words, peak
 }
 
 func TestStreamLexer(t *testing.T) {
@@ -90,6 +92,27 @@ func TestStreamLexerShift(t *testing.T) {
 	test.That(t, z.ShiftLen() == len("Lorem "), "shifted length must equal last shift")
 }
 
+func TestStreamLexerArena(t *testing.T) {
+	s := `Lorem ipsum dolor sit amet, consectetur adipiscing elit.`
+	z := NewStreamLexerSize(test.NewPlainReader(bytes.NewBufferString(s)), 5)
+	z.SetArena(NewArena(0))
+
+	words := [][]byte{}
+	for z.Peek(0) != 0 {
+		for c := z.Peek(0); c != ' ' && c != 0; c = z.Peek(0) {
+			z.Move(1)
+		}
+		if z.Peek(0) == ' ' {
+			z.Move(1)
+		}
+		words = append(words, z.Shift())
+		z.Free(z.ShiftLen())
+	}
+	test.T(t, len(words), 8)
+	test.Bytes(t, words[0], []byte("Lorem "), "shifted bytes must not be overwritten by reused buffers")
+	test.Bytes(t, words[7], []byte("elit."))
+}
+
 func TestStreamLexerSmall(t *testing.T) {
 	s := `abcdefghijklm`
 	z := NewStreamLexerSize(test.NewPlainReader(bytes.NewBufferString(s)), 4)
@@ -146,3 +169,73 @@ func TestStreamLexerEmptyReader(t *testing.T) {
 	test.T(t, z.Err(), io.EOF, "error must be EOF")
 	test.That(t, z.Peek(0) == 0, "second peek must also yield error")
 }
+
+func TestStreamLexerBoundedMemory(t *testing.T) {
+	z := NewStreamLexerSize(&wordReader{n: 1 << 24}, 64)
+	words, peak := lexWords(z, func([]byte) {
+		z.Free(z.ShiftLen())
+	})
+	test.T(t, words, 1<<24/27*5+2)
+	test.That(t, peak <= 64, "buffer must be reused when everything is freed")
+	test.T(t, len(z.chunks), 0)
+}
+
+func TestStreamLexerDelayedFree(t *testing.T) {
+	z := NewStreamLexerSize(&wordReader{n: 1 << 20}, 16)
+	held := [][]byte{}
+	lens := []int{}
+	peak := 0
+	_, _ = lexWords(z, func(b []byte) {
+		held = append(held, b)
+		lens = append(lens, z.ShiftLen())
+		if len(held) == 100 {
+			// shifted words must not be overwritten before they are freed
+			for i, w := range held {
+				if string(w) != []string{"lorem ", "ipsum ", "dolor ", "sit ", "amet "}[i%5] {
+					t.Fatalf("word %d overwritten: %q", i, w)
+				}
+			}
+			for _, n := range lens[:50] {
+				z.Free(n)
+			}
+			held, lens = held[50:], lens[50:]
+		}
+		if peak < z.size() {
+			peak = z.size()
+		}
+	})
+	test.That(t, 0 < len(held))
+	test.That(t, peak < 16*1024, "memory must be bounded by the unfreed bytes")
+
+	// freeing everything releases the chunks
+	for _, n := range lens {
+		z.Free(n)
+	}
+	test.T(t, len(z.chunks), 0)
+}
+
+func TestStreamLexerLongToken(t *testing.T) {
+	s := "a " + string(bytes.Repeat([]byte("b"), 1000)) + " c"
+	z := NewStreamLexerSize(test.NewPlainReader(bytes.NewBufferString(s)), 8)
+	words := []string{}
+	_, peak := lexWords(z, func(b []byte) {
+		words = append(words, string(b))
+		z.Free(z.ShiftLen())
+	})
+	test.T(t, len(words), 3)
+	test.String(t, words[1], s[2:1003])
+	test.String(t, words[2], "c")
+	test.That(t, peak < 4*1000, "buffer must grow to the token size")
+}
+
+func BenchmarkStreamLexerMemory(b *testing.B) {
+	const size = 1 << 20
+	b.SetBytes(size)
+	z := NewStreamLexer(&wordReader{n: int64(b.N) * size})
+	peak := 0
+	b.ResetTimer()
+	_, peak = lexWords(z, func([]byte) {
+		z.Free(z.ShiftLen())
+	})
+	b.ReportMetric(float64(peak), "peak-B")
+}
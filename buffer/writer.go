@@ -44,6 +44,40 @@ func (w *Writer) Write(b []byte) (int, error) {
 	return copy(w.buf[end:], b), nil
 }
 
+// WriteTo writes the written bytes to dst in a single write and implements io.WriterTo. Like bytes.Buffer, it drains the buffer: the bytes written to dst are removed, so that the buffer is empty after a successful write and writing it again writes nothing. Use Bytes to read the buffer without draining it.
+func (w *Writer) WriteTo(dst io.Writer) (int64, error) {
+	n, err := dst.Write(w.buf)
+	if err == nil && n < len(w.buf) {
+		err = io.ErrShortWrite
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[n:])]
+	return int64(n), err
+}
+
+// ReadFrom reads from r until io.EOF or an error and appends the data to the buffer, so that io.Copy reads directly into the buffer. It implements io.ReaderFrom. A static writer returns io.EOF as soon as the buffer is full, as Write does, without reading from r so that no data is lost. This includes when r has exactly filled the buffer without returning io.EOF yet.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	for {
+		if len(w.buf) == cap(w.buf) {
+			if !w.expand {
+				w.err = io.EOF
+				return n, io.EOF
+			}
+			buf := make([]byte, len(w.buf), 2*cap(w.buf)+defaultBufSize)
+			copy(buf, w.buf)
+			w.buf = buf
+		}
+		m, err := r.Read(w.buf[len(w.buf):cap(w.buf)])
+		w.buf = w.buf[:len(w.buf)+m]
+		n += int64(m)
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+	}
+}
+
 // Len returns the length of the underlying byte slice.
 func (w *Writer) Len() int {
 	return len(w.buf)
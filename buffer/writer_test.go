@@ -1,7 +1,10 @@
 package buffer
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/tdewolff/test"
@@ -29,6 +32,87 @@ func TestWriter(t *testing.T) {
 	test.Bytes(t, w.Bytes(), []byte("ghijkl"), "third write must match 'ghijkl'")
 }
 
+func TestWriterWriteTo(t *testing.T) {
+	w := NewWriter(nil)
+	n, err := io.Copy(w, test.NewPlainReader(strings.NewReader("Lorem ipsum dolor sit amet")))
+	test.Error(t, err)
+	test.T(t, n, int64(26))
+	test.String(t, string(w.Bytes()), "Lorem ipsum dolor sit amet")
+
+	n, err = w.ReadFrom(strings.NewReader("!"))
+	test.Error(t, err)
+	test.T(t, n, int64(1))
+
+	buf := &bytes.Buffer{}
+	n, err = w.WriteTo(buf)
+	test.Error(t, err)
+	test.T(t, n, int64(27))
+	test.String(t, buf.String(), "Lorem ipsum dolor sit amet!")
+	test.T(t, w.Len(), 0)
+
+	// the buffer is drained so that writing it again writes nothing
+	n, err = w.WriteTo(buf)
+	test.Error(t, err)
+	test.T(t, n, int64(0))
+	test.String(t, buf.String(), "Lorem ipsum dolor sit amet!")
+
+	// short writes keep the remainder
+	_, _ = w.Write([]byte("abcde"))
+	n, err = w.WriteTo(shortWriter{2})
+	test.T(t, err, io.ErrShortWrite)
+	test.T(t, n, int64(2))
+	test.String(t, string(w.Bytes()), "cde")
+
+	_, err = w.WriteTo(test.NewErrorWriter(0))
+	test.T(t, err, test.ErrPlain)
+	test.String(t, string(w.Bytes()), "cde")
+
+	_, err = w.ReadFrom(test.NewErrorReader(0))
+	test.T(t, err, test.ErrPlain)
+}
+
+func TestStaticWriterReadFrom(t *testing.T) {
+	w := NewStaticWriter(make([]byte, 0, 4))
+	n, err := w.ReadFrom(strings.NewReader("abc"))
+	test.Error(t, err)
+	test.T(t, n, int64(3))
+	test.String(t, string(w.Bytes()), "abc")
+
+	// the remainder of r is not consumed when the buffer is full
+	w = NewStaticWriter(make([]byte, 0, 3))
+	r := strings.NewReader("abcd")
+	n, err = w.ReadFrom(r)
+	test.T(t, err, io.EOF)
+	test.T(t, n, int64(3))
+	test.T(t, w.Close(), io.EOF)
+	test.T(t, r.Len(), 1)
+
+	w2 := &bytes.Buffer{}
+	_, err = io.Copy(w2, r)
+	test.Error(t, err)
+	test.String(t, w2.String(), "d")
+}
+
+// shortWriter writes at most n bytes per call without returning an error.
+type shortWriter struct {
+	n int
+}
+
+func (w shortWriter) Write(b []byte) (int, error) {
+	if w.n < len(b) {
+		return w.n, nil
+	}
+	return len(b), nil
+}
+
+func TestWriterWriteToShort(t *testing.T) {
+	w := NewWriter(nil)
+	w.Write([]byte("abc"))
+	n, err := w.WriteTo(shortWriter{2})
+	test.T(t, err, io.ErrShortWrite)
+	test.T(t, n, int64(2))
+}
+
 func ExampleNewWriter() {
 	w := NewWriter(make([]byte, 0, 11)) // initial buffer length is 11
 	w.Write([]byte("Lorem ipsum"))
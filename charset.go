@@ -0,0 +1,240 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnknownCharset is returned by NewInputCharset when the declared charset is not UTF-8, UTF-16, or windows-1252, such as Shift_JIS or ISO-8859-2. Such inputs can be transcoded with a decoder passed to NewInputCharsetFunc.
+var ErrUnknownCharset = errors.New("unknown charset")
+
+// Charset names as returned by DetectCharset.
+const (
+	UTF8        = "utf-8"
+	UTF16LE     = "utf-16le"
+	UTF16BE     = "utf-16be"
+	Windows1252 = "windows-1252"
+)
+
+// charsetLabels maps charset labels to their canonical name, following the WHATWG Encoding Standard where ASCII and ISO-8859-1 are decoded as windows-1252.
+var charsetLabels = map[string]string{
+	"utf-8":             UTF8,
+	"utf8":              UTF8,
+	"unicode-1-1-utf-8": UTF8,
+	"utf-16":            UTF16LE,
+	"utf-16le":          UTF16LE,
+	"unicode":           UTF16LE,
+	"ucs-2":             UTF16LE,
+	"utf-16be":          UTF16BE,
+	"unicodefffe":       UTF16BE,
+	"windows-1252":      Windows1252,
+	"cp1252":            Windows1252,
+	"x-cp1252":          Windows1252,
+	"us-ascii":          Windows1252,
+	"ascii":             Windows1252,
+	"ansi_x3.4-1968":    Windows1252,
+	"iso-8859-1":        Windows1252,
+	"iso8859-1":         Windows1252,
+	"iso_8859-1":        Windows1252,
+	"iso-ir-100":        Windows1252,
+	"latin1":            Windows1252,
+	"l1":                Windows1252,
+	"cp819":             Windows1252,
+	"ibm819":            Windows1252,
+}
+
+// windows1252 maps the bytes 0x80 to 0x9F to their code points, all other bytes map to the code point of the same value.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// utf8BOM is the UTF-8 encoded byte order mark.
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+// DetectCharset returns the canonical name of the charset of b and the length of its byte order mark. A UTF-8, UTF-16LE, or UTF-16BE byte order mark takes precedence over the declared charset, such as from the Content-Type header or a meta tag, which may be empty. Without either, UTF-8 is assumed. An empty string is returned for unknown declared charsets.
+func DetectCharset(b []byte, declared string) (string, int) {
+	if bytes.HasPrefix(b, utf8BOM) {
+		return UTF8, len(utf8BOM)
+	} else if bytes.HasPrefix(b, []byte("\xFF\xFE")) {
+		return UTF16LE, 2
+	} else if bytes.HasPrefix(b, []byte("\xFE\xFF")) {
+		return UTF16BE, 2
+	} else if declared = strings.ToLower(strings.TrimSpace(declared)); declared == "" {
+		return UTF8, 0
+	}
+	return charsetLabels[declared], 0
+}
+
+// charsetRun is a run of runes that have the same length in the source and in the transcoded buffer.
+type charsetRun struct {
+	dst, src         int // offsets of the start of the run
+	dstSize, srcSize int // rune lengths
+}
+
+// Transformer transforms bytes from src into dst, such as a decoder that transcodes a charset to UTF-8. It has the same methods as transform.Transformer of golang.org/x/text/transform, so that the decoders of golang.org/x/text/encoding can be used directly.
+type Transformer interface {
+	Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error)
+	Reset()
+}
+
+// maxCharsetBytes is the maximum number of source bytes that a Transformer may need to decode a character.
+const maxCharsetBytes = 16
+
+// NewInputCharset returns a new Input for a given io.Reader like NewInput, and transcodes it to UTF-8 when it starts with a UTF-16 byte order mark or when the declared charset is UTF-16 or windows-1252 (including ASCII and ISO-8859-1), see DetectCharset. Byte order marks are removed. The declared charset may be empty. Offsets of the Input and of the returned tokens are in the transcoded buffer, use SourceOffset to map them to offsets in the original bytes. Line numbers and columns are the same as in the original bytes, where ByteColumns counts the original bytes, and errors created by NewError or NewErrorRange for the Input have their offsets mapped to the original bytes. Invalid and unpaired surrogates in UTF-16 are replaced by U+FFFD, while UTF-8 is not validated. It returns ErrUnknownCharset for other charsets, see NewInputCharsetFunc.
+func NewInputCharset(r io.Reader, declared string) (*Input, error) {
+	return NewInputCharsetFunc(r, declared, nil)
+}
+
+// NewInputCharsetFunc is like NewInputCharset, but transcodes other charsets with the decoder returned by decoder for the lowercased charset label. It returns ErrUnknownCharset when decoder is nil or returns nil. Offsets are mapped to the original bytes as for NewInputCharset, where offsets within the output of a character map to the start of that character. For example, to support all charsets of the WHATWG Encoding Standard:
+//
+//	z, err := parse.NewInputCharsetFunc(r, declared, func(label string) parse.Transformer {
+//		if e, err := htmlindex.Get(label); err == nil {
+//			return e.NewDecoder()
+//		}
+//		return nil
+//	})
+func NewInputCharsetFunc(r io.Reader, declared string, decoder func(label string) Transformer) (*Input, error) {
+	var b []byte
+	if buffer, ok := r.(interface {
+		Bytes() []byte
+	}); ok {
+		b = buffer.Bytes()
+	} else if r != nil {
+		var err error
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+
+	charset, bom := DetectCharset(b, declared)
+	if charset == "" {
+		var t Transformer
+		if decoder != nil {
+			t = decoder(strings.ToLower(strings.TrimSpace(declared)))
+		}
+		if t == nil {
+			return nil, ErrUnknownCharset
+		}
+		return newInputTransformer(b, t)
+	} else if charset == UTF8 {
+		z := NewInputBytes(b[bom:])
+		if bom != 0 {
+			z.bom = bom
+			z.runs = []charsetRun{{0, bom, 1, 1}}
+		}
+		return z, nil
+	}
+
+	dst := make([]byte, 0, len(b)+1)
+	runs := []charsetRun{}
+	for i := bom; i < len(b); {
+		var r rune
+		n := 1
+		switch charset {
+		case Windows1252:
+			r = rune(b[i])
+			if 0x80 <= r && r < 0xA0 {
+				r = windows1252[r-0x80]
+			}
+		case UTF16LE, UTF16BE:
+			r, n = decodeUTF16(b[i:], charset == UTF16BE)
+		}
+
+		runs = appendCharsetRun(runs, len(dst), i, utf8.RuneLen(r), n)
+		var buf [4]byte
+		dst = append(dst, buf[:utf8.EncodeRune(buf[:], r)]...)
+		i += n
+	}
+	z := NewInputBytes(dst)
+	z.bom = bom
+	z.runs = runs
+	return z, nil
+}
+
+// newInputTransformer transcodes b with t one character at a time, so that runs map the output of each character to its source bytes.
+func newInputTransformer(b []byte, t Transformer) (*Input, error) {
+	t.Reset()
+	dst := make([]byte, 0, len(b)+1)
+	runs := []charsetRun{}
+	var buf [64]byte
+	for i := 0; i < len(b); {
+		// feed one more byte until the transformer decodes a character
+		for k := 1; ; k++ {
+			end := i + k
+			atEOF := len(b) <= end
+			if atEOF {
+				end = len(b)
+			}
+			nDst, nSrc, err := t.Transform(buf[:], b[i:end], atEOF)
+			if 0 < nDst {
+				runs = appendCharsetRun(runs, len(dst), i, nDst, nSrc)
+				dst = append(dst, buf[:nDst]...)
+			}
+			if 0 < nDst || 0 < nSrc {
+				i += nSrc
+				break
+			} else if atEOF || maxCharsetBytes < k {
+				if err == nil {
+					err = io.ErrNoProgress
+				}
+				return nil, err
+			}
+		}
+	}
+	z := NewInputBytes(dst)
+	z.runs = runs
+	return z, nil
+}
+
+// appendCharsetRun appends a character at dst in the transcoded buffer and at src in the source to runs, extending the last run if the character follows it with the same lengths.
+func appendCharsetRun(runs []charsetRun, dst, src, dstSize, srcSize int) []charsetRun {
+	if 0 < len(runs) {
+		last := runs[len(runs)-1]
+		if last.dstSize == dstSize && last.srcSize == srcSize && last.src+(dst-last.dst)/dstSize*srcSize == src {
+			return runs
+		}
+	}
+	return append(runs, charsetRun{dst, src, dstSize, srcSize})
+}
+
+// decodeUTF16 decodes the first rune of b and returns its length, returning U+FFFD for unpaired surrogates and a trailing odd byte.
+func decodeUTF16(b []byte, bigEndian bool) (rune, int) {
+	if len(b) < 2 {
+		return utf8.RuneError, len(b)
+	}
+	u := func(i int) rune {
+		if bigEndian {
+			return rune(b[i])<<8 | rune(b[i+1])
+		}
+		return rune(b[i+1])<<8 | rune(b[i])
+	}
+	r := u(0)
+	if r < 0xD800 || 0xE000 <= r {
+		return r, 2
+	} else if r < 0xDC00 && 4 <= len(b) {
+		if r2 := u(2); 0xDC00 <= r2 && r2 < 0xE000 {
+			return 0x10000 + (r-0xD800)<<10 + (r2 - 0xDC00), 4
+		}
+	}
+	return utf8.RuneError, 2
+}
+
+// SourceOffset returns the offset in the original bytes for an offset in an Input returned by NewInputCharset or NewInputCharsetFunc, which differ when the input was transcoded or started with a byte order mark. Offsets within a transcoded rune map to the start of that rune. For other inputs it returns the offset unchanged.
+func (z *Input) SourceOffset(offset int) int {
+	if len(z.runs) == 0 {
+		return offset
+	}
+	i := sort.Search(len(z.runs), func(i int) bool {
+		return offset < z.runs[i].dst
+	}) - 1
+	if i < 0 {
+		return offset
+	}
+	run := z.runs[i]
+	return run.src + (offset-run.dst)/run.dstSize*run.srcSize
+}
@@ -0,0 +1,174 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/tdewolff/test"
+)
+
+func TestDetectCharset(t *testing.T) {
+	var tests = []struct {
+		b        string
+		declared string
+		charset  string
+		bom      int
+	}{
+		{"abc", "", UTF8, 0},
+		{"\xEF\xBB\xBFabc", "", UTF8, 3},
+		{"\xFF\xFEa\x00", "", UTF16LE, 2},
+		{"\xFE\xFF\x00a", "", UTF16BE, 2},
+		{"\xFF\xFEa\x00", "windows-1252", UTF16LE, 2},
+		{"abc", " ISO-8859-1 ", Windows1252, 0},
+		{"abc", "us-ascii", Windows1252, 0},
+		{"abc", "utf-16", UTF16LE, 0},
+		{"abc", "shift_jis", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.b, tt.declared), func(t *testing.T) {
+			charset, bom := DetectCharset([]byte(tt.b), tt.declared)
+			test.T(t, charset, tt.charset)
+			test.T(t, bom, tt.bom)
+		})
+	}
+}
+
+func TestInputCharset(t *testing.T) {
+	var tests = []struct {
+		b        string
+		declared string
+		expected string
+		offsets  []int // source offsets for every offset in expected
+	}{
+		{"ab", "", "ab", []int{0, 1, 2}},
+		{"\xEF\xBB\xBFab", "", "ab", []int{3, 4, 5}},
+		{"\xFF\xFEa\x00\xE9\x00\xAC\x20", "", "aé€", []int{2, 4, 4, 6, 6, 6, 8}},
+		{"\xFE\xFF\x00a\xD8\x3D\xDE\x00b", "", "a😀�", []int{2, 4, 4, 4, 4, 8, 8, 8, 9}},
+		{"<\x00/\x00", "utf-16le", "</", []int{0, 2, 4}},
+		{"\x00\xD8a\x00", "utf-16le", "�a", []int{0, 0, 0, 2, 4}},
+		{"a\xE9\x80", "latin1", "aé€", []int{0, 1, 1, 2, 2, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			z, err := NewInputCharset(bytes.NewReader([]byte(tt.b)), tt.declared)
+			test.Error(t, err)
+			test.String(t, string(z.Bytes()), tt.expected)
+			for i, offset := range tt.offsets {
+				test.T(t, z.SourceOffset(i), offset, fmt.Sprint("offset ", i))
+			}
+		})
+	}
+
+	_, err := NewInputCharset(bytes.NewReader([]byte("abc")), "shift_jis")
+	test.T(t, err, ErrUnknownCharset)
+}
+
+// testDecoder decodes a double-byte charset in which a byte of 0x80 or higher and the byte that follows it encode U+3040 plus the second byte, and in which escape bytes are dropped.
+type testDecoder struct{}
+
+var errTestShortSrc = errors.New("short source")
+
+func (testDecoder) Reset() {}
+
+func (testDecoder) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	nDst, nSrc := 0, 0
+	for nSrc < len(src) {
+		r, n := rune(src[nSrc]), 1
+		if r == 0x1B {
+			nSrc++
+			continue
+		} else if 0x80 <= r {
+			if nSrc+2 <= len(src) {
+				r, n = 0x3040+rune(src[nSrc+1]), 2
+			} else if !atEOF {
+				return nDst, nSrc, errTestShortSrc
+			} else {
+				r = utf8.RuneError
+			}
+		}
+		if len(dst) < nDst+utf8.RuneLen(r) {
+			return nDst, nSrc, errors.New("short destination")
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		nSrc += n
+	}
+	return nDst, nSrc, nil
+}
+
+// errorDecoder fails to decode any input.
+type errorDecoder struct{}
+
+func (errorDecoder) Reset() {}
+
+func (errorDecoder) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	return 0, 0, errTestShortSrc
+}
+
+func TestInputCharsetFunc(t *testing.T) {
+	labels := []string{}
+	decoder := func(label string) Transformer {
+		labels = append(labels, label)
+		if label == "x-test" {
+			return testDecoder{}
+		}
+		return nil
+	}
+
+	z, err := NewInputCharsetFunc(bytes.NewReader([]byte("a\x82\x01\x1Bb\n\x82")), " X-Test ", decoder)
+	test.Error(t, err)
+	test.String(t, string(z.Bytes()), "aぁb\n�")
+	for i, offset := range []int{0, 1, 1, 1, 4, 5, 6, 6, 6, 7} {
+		test.T(t, z.SourceOffset(i), offset, fmt.Sprint("offset ", i))
+	}
+	test.T(t, labels, []string{"x-test"})
+
+	z.SetColumnMode(ByteColumns)
+	line, col := z.PositionAt(4)
+	test.T(t, line, 1)
+	test.T(t, col, 5)
+	perr := NewError(z, 6, "unexpected")
+	test.T(t, perr.Line, 2)
+	test.T(t, perr.Column, 1)
+	test.T(t, perr.Range.Start.Offset, 6)
+
+	// byte order marks and supported charsets don't use the decoder
+	z, err = NewInputCharsetFunc(bytes.NewReader([]byte("\xFF\xFEa\x00")), "x-test", decoder)
+	test.Error(t, err)
+	test.String(t, string(z.Bytes()), "a")
+	_, err = NewInputCharsetFunc(bytes.NewReader([]byte("a")), "latin1", decoder)
+	test.Error(t, err)
+	test.T(t, labels, []string{"x-test"})
+
+	_, err = NewInputCharsetFunc(bytes.NewReader([]byte("a")), "shift_jis", decoder)
+	test.T(t, err, ErrUnknownCharset)
+	_, err = NewInputCharsetFunc(bytes.NewReader([]byte("a")), "shift_jis", nil)
+	test.T(t, err, ErrUnknownCharset)
+
+	_, err = NewInputCharsetFunc(bytes.NewReader([]byte("abc")), "x-error", func(string) Transformer {
+		return errorDecoder{}
+	})
+	test.T(t, err, errTestShortSrc)
+}
+
+func TestInputCharsetPosition(t *testing.T) {
+	// UTF-16LE for "é\n€x"
+	z, err := NewInputCharset(bytes.NewReader([]byte("\xFF\xFE\xE9\x00\n\x00\xAC\x20x\x00")), "")
+	test.Error(t, err)
+	line, col := z.PositionAt(6)
+	test.T(t, line, 2)
+	test.T(t, col, 2)
+
+	z.SetColumnMode(ByteColumns)
+	line, col = z.PositionAt(6)
+	test.T(t, line, 2)
+	test.T(t, col, 3)
+
+	perr := NewErrorRange(z, 3, 6, "unexpected")
+	test.T(t, perr.Line, 2)
+	test.T(t, perr.Column, 1)
+	test.T(t, perr.Range.Start.Offset, 6)
+	test.T(t, perr.Range.End.Offset, 8)
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"errors"
+	"math"
 	"strconv"
 )
 
@@ -17,11 +18,58 @@ var (
 // ErrBadDataURI is returned by DataURI when the byte slice does not start with 'data:' or is too short.
 var ErrBadDataURI = errors.New("not a data URI")
 
+// NumberFlags are the options of NumberValue for the number syntax of programming languages.
+type NumberFlags uint8
+
+// NumberFlags values.
+const (
+	NumberPrefixes   NumberFlags = 1 << iota // allow integers in hexadecimal, binary, and octal notation with the 0x, 0b, and 0o prefixes
+	NumberSeparators                         // allow underscores between digits, such as in 1_000
+)
+
 // Number returns the number of bytes that parse as a number of the regex format (+|-)?([0-9]+(\.[0-9]+)?|\.[0-9]+)((e|E)(+|-)?[0-9]+)?.
 func Number(b []byte) int {
+	return number(b, 0)
+}
+
+// NumberValue returns the number of bytes that parse as a number, see Number, and its value. Exact is true if the value is an integer within ±(2^53-1), which float64 represents exactly. With NumberPrefixes, integers may be written as 0x1F, 0b101, or 0o17 and the prefix letter may be uppercase. With NumberSeparators, single underscores may separate digits.
+func NumberValue(b []byte, flags NumberFlags) (n int, f float64, exact bool) {
+	n = number(b, flags)
+	if n == 0 {
+		return 0, 0, false
+	}
+	num := b[:n]
+	neg := false
+	if num[0] == '+' || num[0] == '-' {
+		neg = num[0] == '-'
+		num = num[1:]
+	}
+	if flags&NumberSeparators != 0 && bytes.IndexByte(num, '_') != -1 {
+		num = bytes.Replace(num, []byte("_"), nil, -1)
+	}
+	if base := numberBase(num, flags); base != 10 {
+		if u, err := strconv.ParseUint(string(num[2:]), base, 64); err == nil {
+			f = float64(u)
+		} else {
+			for _, c := range num[2:] {
+				f = f*float64(base) + float64(hexDigit(c))
+			}
+		}
+	} else {
+		f, _ = strconv.ParseFloat(string(num), 64)
+	}
+	if neg {
+		f = -f
+	}
+	exact = f == math.Trunc(f) && math.Abs(f) <= 1<<53-1
+	return n, f, exact
+}
+
+func number(b []byte, flags NumberFlags) int {
 	if len(b) == 0 {
 		return 0
 	}
+	sep := flags&NumberSeparators != 0
 	i := 0
 	if b[i] == '+' || b[i] == '-' {
 		i++
@@ -29,20 +77,17 @@ func Number(b []byte) int {
 			return 0
 		}
 	}
+	if base := numberBase(b[i:], flags); base != 10 {
+		return numberDigits(b, i+2, base, sep)
+	}
 	firstDigit := (b[i] >= '0' && b[i] <= '9')
 	if firstDigit {
-		i++
-		for i < len(b) && b[i] >= '0' && b[i] <= '9' {
-			i++
-		}
+		i = numberDigits(b, i, 10, sep)
 	}
 	if i < len(b) && b[i] == '.' {
 		i++
 		if i < len(b) && b[i] >= '0' && b[i] <= '9' {
-			i++
-			for i < len(b) && b[i] >= '0' && b[i] <= '9' {
-				i++
-			}
+			i = numberDigits(b, i, 10, sep)
 		} else if firstDigit {
 			// . could belong to the next token
 			i--
@@ -63,13 +108,58 @@ func Number(b []byte) int {
 			// e could belong to next token
 			return iOld
 		}
-		for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+		i = numberDigits(b, i, 10, sep)
+	}
+	return i
+}
+
+// numberBase returns the base of an integer with a 0x, 0b, or 0o prefix followed by a digit, or 10 otherwise.
+func numberBase(b []byte, flags NumberFlags) int {
+	if flags&NumberPrefixes == 0 || len(b) < 3 || b[0] != '0' {
+		return 10
+	}
+	base := 10
+	switch b[1] {
+	case 'x', 'X':
+		base = 16
+	case 'b', 'B':
+		base = 2
+	case 'o', 'O':
+		base = 8
+	}
+	if base == 10 || base <= hexDigit(b[2]) {
+		return 10
+	}
+	return base
+}
+
+// numberDigits returns the offset after the digits in base starting at i, where underscores may separate digits if sep is set.
+func numberDigits(b []byte, i, base int, sep bool) int {
+	start := i
+	for i < len(b) {
+		if hexDigit(b[i]) < base {
+			i++
+		} else if sep && b[i] == '_' && start < i && i+1 < len(b) && hexDigit(b[i+1]) < base {
 			i++
+		} else {
+			break
 		}
 	}
 	return i
 }
 
+// hexDigit returns the value of a hexadecimal digit, or 16 if it is not a digit.
+func hexDigit(c byte) int {
+	if '0' <= c && c <= '9' {
+		return int(c - '0')
+	} else if 'a' <= c && c <= 'f' {
+		return int(c-'a') + 10
+	} else if 'A' <= c && c <= 'F' {
+		return int(c-'A') + 10
+	}
+	return 16
+}
+
 // Dimension parses a byte-slice and returns the length of the number and its unit.
 func Dimension(b []byte) (int, int) {
 	num := Number(b)
@@ -88,7 +178,7 @@ func Dimension(b []byte) (int, int) {
 }
 
 // Mediatype parses a given mediatype and splits the mimetype from the parameters.
-// It works similar to mime.ParseMediaType but is faster.
+// It works similar to mime.ParseMediaType but is faster. Quoted values are unquoted, use ParseMediatype for continuations, extended values, and parameters in order.
 func Mediatype(b []byte) ([]byte, map[string]string) {
 	i := 0
 	for i < len(b) && b[i] == ' ' {
@@ -130,14 +220,18 @@ func Mediatype(b []byte) ([]byte, map[string]string) {
 				for i < n && s[i] == ' ' {
 					i++
 				}
-				start = i
-				for i < n && s[i] != ';' && s[i] != ' ' {
-					i++
+				if i < n && s[i] == '"' {
+					params[key], i = unquoteMediatypeValue(s, i)
+				} else {
+					start = i
+					for i < n && s[i] != ';' && s[i] != ' ' {
+						i++
+					}
+					params[key] = s[start:i]
 				}
 			} else {
-				start = i
+				params[key] = ""
 			}
-			params[key] = s[start:i]
 			for i < n && s[i] == ' ' {
 				i++
 			}
@@ -195,7 +289,7 @@ func DataURI(dataURI []byte) ([]byte, []byte, error) {
 	return nil, nil, ErrBadDataURI
 }
 
-// QuoteEntity parses the given byte slice and returns the quote that got matched (' or ") and its entity length.
+// QuoteEntity parses the given byte slice and returns the quote that got matched (' or ") and its entity length. Use DecodeEntity to decode any character reference.
 // TODO: deprecated
 func QuoteEntity(b []byte) (quote byte, n int) {
 	if len(b) < 5 || b[0] != '&' {
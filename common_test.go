@@ -2,6 +2,7 @@ package parse
 
 import (
 	"encoding/base64"
+	"math"
 	"mime"
 	"net/url"
 	"regexp"
@@ -45,6 +46,49 @@ func TestParseNumber(t *testing.T) {
 	}
 }
 
+func TestParseNumberValue(t *testing.T) {
+	var numberTests = []struct {
+		number string
+		flags  NumberFlags
+		n      int
+		f      float64
+		exact  bool
+	}{
+		{"5", 0, 1, 5.0, true},
+		{"-0.5e1", 0, 6, -5.0, true},
+		{"+.25", 0, 4, 0.25, false},
+		{"1e400", 0, 5, math.Inf(1), false},
+		{"9007199254740991", 0, 16, 9007199254740991.0, true},
+		{"9007199254740993", 0, 16, 9007199254740992.0, false},
+		{"0x1F", 0, 1, 0.0, true},
+		{"0x1F", NumberPrefixes, 4, 31.0, true},
+		{"-0X1fz", NumberPrefixes, 5, -31.0, true},
+		{"0b101", NumberPrefixes, 5, 5.0, true},
+		{"0b102", NumberPrefixes, 4, 2.0, true},
+		{"0o17", NumberPrefixes, 4, 15.0, true},
+		{"0o8", NumberPrefixes, 1, 0.0, true},
+		{"0x", NumberPrefixes, 1, 0.0, true},
+		{"0x1_0", NumberPrefixes, 3, 1.0, true},
+		{"0x1_0", NumberPrefixes | NumberSeparators, 5, 16.0, true},
+		{"0x1FFFFFFFFFFFFFFFF", NumberPrefixes, 19, 36893488147419103232.0, false},
+		{"1_000.0_1e1_0", NumberSeparators, 13, 1000.01e10, true},
+		{"1_000", 0, 1, 1.0, true},
+		{"1__0", NumberSeparators, 1, 1.0, true},
+		{"1_", NumberSeparators, 1, 1.0, true},
+		{"_1", NumberSeparators, 0, 0.0, false},
+		{"", 0, 0, 0.0, false},
+		{"a", 0, 0, 0.0, false},
+	}
+	for _, tt := range numberTests {
+		t.Run(tt.number, func(t *testing.T) {
+			n, f, exact := NumberValue([]byte(tt.number), tt.flags)
+			test.T(t, n, tt.n)
+			test.T(t, f, tt.f)
+			test.T(t, exact, tt.exact)
+		})
+	}
+}
+
 func TestParseDimension(t *testing.T) {
 	var dimensionTests = []struct {
 		dimension    string
@@ -82,6 +126,8 @@ func TestMediatype(t *testing.T) {
 		{"text/plain;inline=;base64", "text/plain", map[string]string{"inline": "", "base64": ""}},
 		{"ÿ   ", "ÿ ", nil}, // OSS-Fuzz; ÿ is two bytes in UTF8
 		{"ÿ  ;", "ÿ ", map[string]string{"": ""}},
+		{`text/plain; name="a; b\"c\\"; x=y`, "text/plain", map[string]string{"name": `a; b"c\`, "x": "y"}},
+		{`text/plain; name="a`, "text/plain", map[string]string{"name": "a"}},
 	}
 	for _, tt := range mediatypeTests {
 		t.Run(tt.mediatype, func(t *testing.T) {
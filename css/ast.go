@@ -0,0 +1,327 @@
+package css
+
+import (
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Grammar is a grammar item returned by Parser.Next, together with its values and offset.
+type Grammar struct {
+	Type      GrammarType
+	TokenType TokenType
+	Data      []byte
+	Values    []Token // at-rule prelude, ruleset selector, or declaration values, see Parser.Values
+	Offset    int     // end offset in the input, see Parser.Offset
+	Err       error   // parse error for ErrorGrammar
+}
+
+// Stylesheet is a parsed stylesheet, or a declaration list for inline styles, as the sequence of grammar items returned by the parser and as a tree of rules. A stylesheet returned by a StylesheetCache is shared between its callers and must not be modified.
+type Stylesheet struct {
+	Grammar     []Grammar
+	Rules       []Node            // top-level rules and at-rules, or declarations for inline styles
+	Diagnostics parse.Diagnostics // parse errors
+}
+
+// Node is a node of a stylesheet, which is a *Rule, *AtRule, or *Declaration.
+type Node interface {
+	// Parent returns the enclosing *Rule or *AtRule, or nil at the top level.
+	Parent() Node
+	// Span returns the byte offsets of the node in the input.
+	Span() (int, int)
+}
+
+// Rule is a qualified rule, such as a ruleset with its selector list. Style rules nested in a rule, see CSS Nesting, are children of the rule, where the selector list is as written and may contain the nesting selector &. Rules introduced by @nest are nested rules as well, whose selector list excludes @nest.
+type Rule struct {
+	Selectors  []Token
+	Children   []Node // declarations, nested rules, and nested at-rules such as @media
+	Start, End int    // byte offsets in the input, from the selector list up to and including the closing brace
+
+	parent Node
+}
+
+// AtRule is an at-rule, with a block of rules or declarations such as @media and @font-face, or without a block such as @import.
+type AtRule struct {
+	Name       []byte // lowercase at-keyword, such as @media
+	Prelude    []Token
+	Block      bool
+	Children   []Node // rules or declarations in the block
+	Start, End int    // byte offsets in the input, from the at-keyword up to and including the closing brace, excluding the terminating semicolon
+
+	parent Node
+}
+
+// Parent returns the enclosing *Rule or *AtRule, or nil at the top level.
+func (n *Rule) Parent() Node {
+	return n.parent
+}
+
+// Span returns the byte offsets of the rule in the input.
+func (n *Rule) Span() (int, int) {
+	return n.Start, n.End
+}
+
+// Declarations returns the declarations of the rule, excluding those of nested rules and at-rules.
+func (n *Rule) Declarations() []*Declaration {
+	return declarations(n.Children)
+}
+
+// Rules returns the nested rules and at-rules of the rule.
+func (n *Rule) Rules() []Node {
+	return rules(n.Children)
+}
+
+// Declaration returns the declaration that applies for a property, which is the last with !important if any, or otherwise the last declaration of the property. It returns nil if there is none.
+func (n *Rule) Declaration(property string) *Declaration {
+	return lookupDeclaration(n.Children, property)
+}
+
+// Parent returns the enclosing *Rule or *AtRule, or nil at the top level.
+func (n *AtRule) Parent() Node {
+	return n.parent
+}
+
+// Span returns the byte offsets of the at-rule in the input.
+func (n *AtRule) Span() (int, int) {
+	return n.Start, n.End
+}
+
+// Rules returns the rules and at-rules in the block of the at-rule.
+func (n *AtRule) Rules() []Node {
+	return rules(n.Children)
+}
+
+// Declarations returns the declarations in the block of the at-rule, such as those of @font-face.
+func (n *AtRule) Declarations() []*Declaration {
+	return declarations(n.Children)
+}
+
+// Declaration returns the declaration that applies for a property, see Rule.Declaration.
+func (n *AtRule) Declaration(property string) *Declaration {
+	return lookupDeclaration(n.Children, property)
+}
+
+// Parent returns the enclosing *Rule or *AtRule, or nil at the top level or when returned by ParseDeclarations.
+func (n *Declaration) Parent() Node {
+	return n.parent
+}
+
+// Span returns the byte offsets of the declaration in the input.
+func (n *Declaration) Span() (int, int) {
+	return n.Start, n.End
+}
+
+func rules(nodes []Node) []Node {
+	rules := []Node{}
+	for _, node := range nodes {
+		if _, ok := node.(*Declaration); !ok {
+			rules = append(rules, node)
+		}
+	}
+	return rules
+}
+
+func declarations(nodes []Node) []*Declaration {
+	decls := []*Declaration{}
+	for _, node := range nodes {
+		if decl, ok := node.(*Declaration); ok {
+			decls = append(decls, decl)
+		}
+	}
+	return decls
+}
+
+func lookupDeclaration(nodes []Node, property string) *Declaration {
+	var found *Declaration
+	for _, node := range nodes {
+		if decl, ok := node.(*Declaration); ok && string(decl.Property) == property && (found == nil || decl.Important || !found.Important) {
+			found = decl
+		}
+	}
+	return found
+}
+
+// Walk calls f for the nodes of the stylesheet in document order, and for the children of a node if f returns true.
+func (s *Stylesheet) Walk(f func(Node) bool) {
+	walkNodes(s.Rules, f)
+}
+
+func walkNodes(nodes []Node, f func(Node) bool) {
+	for _, node := range nodes {
+		if f(node) {
+			switch n := node.(type) {
+			case *Rule:
+				walkNodes(n.Children, f)
+			case *AtRule:
+				walkNodes(n.Children, f)
+			}
+		}
+	}
+}
+
+// ParseStylesheet parses a stylesheet, or a declaration list such as an inline style attribute when isInline is set, into a tree of rules, at-rules, and declarations. The parser recovers from parse errors, which are collected in Diagnostics, so that an error is returned only when reading fails. Comments are skipped. Declarations are as returned by ParseDeclarations, and the byte slices of the nodes may refer to the input.
+func ParseStylesheet(r *parse.Input, isInline bool) (*Stylesheet, error) {
+	s := &Stylesheet{}
+	p := NewParser(r, isInline)
+	p.SetDiagnostics(&s.Diagnostics)
+	var stack []Node // open rules and at-rules
+	prevEnd := 0
+	for {
+		gt, tt, data := p.Next()
+		end := p.Offset()
+		g := Grammar{
+			Type:      gt,
+			TokenType: tt,
+			Data:      data,
+			Offset:    end,
+		}
+		switch gt {
+		case ErrorGrammar:
+			if !p.HasParseError() {
+				if err := p.Err(); err != io.EOF {
+					return nil, err
+				}
+				return s, nil
+			}
+			g.Err = p.Err()
+		case AtRuleGrammar, BeginAtRuleGrammar, BeginRulesetGrammar, DeclarationGrammar, CustomPropertyGrammar:
+			g.Values = append([]Token{}, p.Values()...)
+		}
+		s.Grammar = append(s.Grammar, g)
+
+		var parent Node
+		if 0 < len(stack) {
+			parent = stack[len(stack)-1]
+		}
+		src := r.Bytes()
+		var node Node
+		switch gt {
+		case BeginRulesetGrammar:
+			rule := &Rule{Selectors: g.Values, Start: skipDeclarationPrefix(src, prevEnd), parent: parent}
+			stack = append(stack, rule)
+			node = rule
+		case AtRuleGrammar, BeginAtRuleGrammar:
+			atRule := &AtRule{Name: data, Prelude: trimWhitespace(g.Values), Start: skipDeclarationPrefix(src, prevEnd), parent: parent}
+			if gt == BeginAtRuleGrammar {
+				atRule.Block = true
+				stack = append(stack, atRule)
+			} else {
+				atRule.End = trimDeclarationSuffix(src, end)
+			}
+			node = atRule
+		case EndRulesetGrammar, EndAtRuleGrammar:
+			if 0 < len(stack) {
+				switch n := stack[len(stack)-1].(type) {
+				case *Rule:
+					n.End = end
+				case *AtRule:
+					n.End = end
+				}
+				stack = stack[:len(stack)-1]
+			}
+		case DeclarationGrammar, CustomPropertyGrammar:
+			decl := &Declaration{
+				Property: data,
+				Values:   g.Values,
+				Start:    skipDeclarationPrefix(src, prevEnd),
+				End:      trimDeclarationSuffix(src, end),
+				parent:   parent,
+			}
+			if gt == DeclarationGrammar {
+				decl.Values, decl.Important = splitImportant(decl.Values)
+			}
+			node = decl
+		}
+		if node != nil {
+			switch n := parent.(type) {
+			case nil:
+				s.Rules = append(s.Rules, node)
+			case *Rule:
+				n.Children = append(n.Children, node)
+			case *AtRule:
+				n.Children = append(n.Children, node)
+			}
+		}
+		prevEnd = end
+	}
+}
+
+// trimWhitespace trims whitespace tokens from both ends of values.
+func trimWhitespace(values []Token) []Token {
+	for 0 < len(values) && values[0].TokenType == WhitespaceToken {
+		values = values[1:]
+	}
+	for 0 < len(values) && values[len(values)-1].TokenType == WhitespaceToken {
+		values = values[:len(values)-1]
+	}
+	return values
+}
+
+////////////////////////////////////////////////////////////////
+
+// Value is a component value, which is a token, or a function or a parenthesis, bracket, or brace block with its contents.
+type Value struct {
+	Token
+	Args []Value // contents of a function or block, excluding the closing token
+}
+
+// String returns the value as written, including the closing token of functions and blocks.
+func (v Value) String() string {
+	s := string(v.Data)
+	if v.Args != nil || isOpeningToken(v.TokenType) {
+		for _, arg := range v.Args {
+			s += arg.String()
+		}
+		switch v.TokenType {
+		case FunctionToken, LeftParenthesisToken:
+			s += ")"
+		case LeftBracketToken:
+			s += "]"
+		case LeftBraceToken:
+			s += "}"
+		}
+	}
+	return s
+}
+
+// IsFunction returns true if the value is a function with the given lowercase name, such as calc.
+func (v Value) IsFunction(name string) bool {
+	return v.TokenType == FunctionToken && 0 < len(v.Data) && parse.EqualFold(v.Data[:len(v.Data)-1], []byte(name))
+}
+
+// ComponentValues returns the component values of tokens such as the values of a declaration or the prelude of an at-rule, where the tokens of functions and blocks are nested in their arguments. Unclosed functions and blocks contain the remaining tokens, and unmatched closing tokens are kept as tokens.
+func ComponentValues(tokens []Token) []Value {
+	values, _ := componentValues(tokens, ErrorToken)
+	return values
+}
+
+func componentValues(tokens []Token, closing TokenType) ([]Value, int) {
+	values := []Value{}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.TokenType == closing {
+			return values, i + 1
+		} else if isOpeningToken(t.TokenType) {
+			args, n := componentValues(tokens[i+1:], closingToken(t.TokenType))
+			values = append(values, Value{t, args})
+			i += n
+			continue
+		}
+		values = append(values, Value{Token: t})
+	}
+	return values, len(tokens)
+}
+
+func isOpeningToken(tt TokenType) bool {
+	return tt == FunctionToken || tt == LeftParenthesisToken || tt == LeftBracketToken || tt == LeftBraceToken
+}
+
+func closingToken(tt TokenType) TokenType {
+	switch tt {
+	case LeftBracketToken:
+		return RightBracketToken
+	case LeftBraceToken:
+		return RightBraceToken
+	}
+	return RightParenthesisToken
+}
@@ -0,0 +1,166 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+// astString writes the nodes with their spans in the input between angle brackets.
+func astString(src string, nodes []Node) string {
+	s := ""
+	for _, node := range nodes {
+		start, end := node.Span()
+		switch n := node.(type) {
+		case *Rule:
+			s += valuesString(n.Selectors) + "{" + astString(src, n.Children) + "}"
+		case *AtRule:
+			s += string(n.Name) + "[" + valuesString(n.Prelude) + "]"
+			if n.Block {
+				s += "{" + astString(src, n.Children) + "}"
+			}
+		case *Declaration:
+			s += string(n.Property) + ":" + valuesString(n.Values)
+			if n.Important {
+				s += "!"
+			}
+		}
+		s += "<" + src[start:end] + ">;"
+	}
+	return s
+}
+
+func TestParseStylesheet(t *testing.T) {
+	var tests = []struct {
+		css      string
+		isInline bool
+		expected string
+	}{
+		{"a { color: red; }", false, "a{color:red<color: red>;}<a { color: red; }>;"},
+		{"/* x */ a, b>c{color:red!important;--x: {y}} ", false, "a,b>c{color:red!<color:red!important>;--x: {y}<--x: {y}>;}<a, b>c{color:red!important;--x: {y}}>;"},
+		{"@import 'x.css' print;@media print { a { margin: 0 } }", false, "@import['x.css' print]<@import 'x.css' print>;@media[print]{a{margin:0<margin: 0>;}<a { margin: 0 }>;}<@media print { a { margin: 0 } }>;"},
+		{"@font-face{font-family:x;src:url(x.woff)}", false, "@font-face[]{font-family:x<font-family:x>;src:url(x.woff)<src:url(x.woff)>;}<@font-face{font-family:x;src:url(x.woff)}>;"},
+		{"color: red; margin: 0 !important", true, "color:red<color: red>;margin:0!<margin: 0 !important>;"},
+		{"a{color:red", false, "a{color:red<color:red>;}<a{color:red>;"},
+		{"a { color: red; &:hover { color: blue } }", false, "a{color:red<color: red>;&:hover{color:blue<color: blue>;}<&:hover { color: blue }>;}<a { color: red; &:hover { color: blue } }>;"},
+		{"a{@media print{x:y;b{x:z}}}", false, "a{@media[print]{x:y<x:y>;b{x:z<x:z>;}<b{x:z}>;}<@media print{x:y;b{x:z}}>;}<a{@media print{x:y;b{x:z}}}>;"},
+		{"a{x:y;@nest b &{x:z}}", false, "a{x:y<x:y>;b &{x:z<x:z>;}<@nest b &{x:z}>;}<a{x:y;@nest b &{x:z}}>;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			s, err := ParseStylesheet(parse.NewInputString(tt.css), tt.isInline)
+			test.Error(t, err)
+			test.String(t, astString(tt.css, s.Rules), tt.expected)
+		})
+	}
+}
+
+func TestParseStylesheetTree(t *testing.T) {
+	s, err := ParseStylesheet(parse.NewInputString("@media print{a{color:red;color:blue}b{color:red!important;color:blue}}c{x:1"), false)
+	test.Error(t, err)
+	test.T(t, len(s.Rules), 2)
+	test.T(t, s.Diagnostics.Len(), 0)
+
+	media := s.Rules[0].(*AtRule)
+	test.That(t, media.Parent() == nil, "top-level at-rule has no parent")
+	rules := media.Rules()
+	test.T(t, len(rules), 2)
+	test.T(t, len(media.Declarations()), 0)
+
+	a := rules[0].(*Rule)
+	test.That(t, a.Parent() == media, "parent of rule")
+	test.That(t, a.Children[0].Parent() == a, "parent of declaration")
+	test.T(t, len(a.Declarations()), 2)
+	test.String(t, valuesString(a.Declaration("color").Values), "blue")
+	test.String(t, valuesString(rules[1].(*Rule).Declaration("color").Values), "red")
+	test.That(t, a.Declaration("margin") == nil, "no declaration")
+
+	visited := ""
+	s.Walk(func(n Node) bool {
+		switch n := n.(type) {
+		case *AtRule:
+			visited += string(n.Name) + " "
+			return false
+		case *Rule:
+			visited += valuesString(n.Selectors) + " "
+		case *Declaration:
+			visited += string(n.Property) + " "
+		}
+		return true
+	})
+	test.String(t, visited, "@media c x ")
+}
+
+func TestParseStylesheetNesting(t *testing.T) {
+	s, err := ParseStylesheet(parse.NewInputString("a{color:red;b{color:blue}@media print{color:green}margin:0}"), false)
+	test.Error(t, err)
+	test.T(t, s.Diagnostics.Len(), 0)
+
+	a := s.Rules[0].(*Rule)
+	test.T(t, len(a.Declarations()), 2)
+	test.String(t, valuesString(a.Declaration("color").Values), "red")
+	rules := a.Rules()
+	test.T(t, len(rules), 2)
+
+	b := rules[0].(*Rule)
+	test.That(t, b.Parent() == a, "parent of nested rule")
+	test.String(t, valuesString(b.Selectors), "b")
+	test.String(t, valuesString(b.Declaration("color").Values), "blue")
+
+	media := rules[1].(*AtRule)
+	test.That(t, media.Parent() == a, "parent of nested at-rule")
+	test.String(t, valuesString(media.Declaration("color").Values), "green")
+}
+
+func TestParseStylesheetErrors(t *testing.T) {
+	s, err := ParseStylesheet(parse.NewInputString("a{color:red;:b;margin:0}"), false)
+	test.Error(t, err)
+	test.T(t, s.Diagnostics.Len(), 1)
+	test.T(t, s.Diagnostics.Errors[0].Column, 13)
+	test.String(t, astString("a{color:red;:b;margin:0}", s.Rules), "a{color:red<color:red>;margin:0<margin:0>;}<a{color:red;:b;margin:0}>;")
+}
+
+func TestComponentValues(t *testing.T) {
+	var tests = []struct {
+		css      string
+		expected string
+	}{
+		{"calc(1px + (2px * 3)) [a] {b}", "Function('calc(')[Dimension('1px') Whitespace(' ') Delim('+') Whitespace(' ') LeftParenthesis('(')[Dimension('2px') Whitespace(' ') Delim('*') Whitespace(' ') Number('3')]] Whitespace(' ') LeftBracket('[')[Ident('a')] Whitespace(' ') LeftBrace('{')[Ident('b')]"},
+		{"var(--x, rgb(1 2 3)) )", "Function('var(')[CustomPropertyName('--x') Comma(',') Whitespace(' ') Function('rgb(')[Number('1') Whitespace(' ') Number('2') Whitespace(' ') Number('3')]] Whitespace(' ') RightParenthesis(')')"},
+		{"f(a", "Function('f(')[Ident('a')]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			tokens := []Token{}
+			l := NewLexer(parse.NewInputString(tt.css))
+			for {
+				tt, data := l.Next()
+				if tt == ErrorToken {
+					break
+				}
+				tokens = append(tokens, Token{tt, data})
+			}
+			test.String(t, componentValuesString(ComponentValues(tokens)), tt.expected)
+		})
+	}
+
+	values := ComponentValues([]Token{{FunctionToken, []byte("CALC(")}, {NumberToken, []byte("1")}})
+	test.That(t, values[0].IsFunction("calc"), "calc function")
+	test.That(t, !values[0].IsFunction("min"), "not a min function")
+	test.String(t, values[0].String(), "CALC(1)")
+}
+
+func componentValuesString(values []Value) string {
+	s := ""
+	for i, v := range values {
+		if i != 0 {
+			s += " "
+		}
+		s += v.Token.String()
+		if v.Args != nil {
+			s += "[" + componentValuesString(v.Args) + "]"
+		}
+	}
+	return s
+}
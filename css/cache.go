@@ -0,0 +1,101 @@
+package css
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// StylesheetCache is a cache of parsed stylesheets keyed by the SHA-256 hash of their contents, so that servers that render many pages referencing the same stylesheet, such as the CSS of a framework, parse it only once. It is safe for concurrent use, and concurrent requests for the same contents wait for a single parse. The least recently used stylesheets are evicted when the total size of the cached sources exceeds the maximum.
+type StylesheetCache struct {
+	maxSize int
+
+	mu      sync.Mutex
+	size    int
+	entries map[cacheKey]*list.Element
+	lru     *list.List // of *cacheEntry, most recently used first
+}
+
+// DefaultStylesheetCache is a global cache of up to 32 MB of stylesheets, which is used only when requested explicitly.
+var DefaultStylesheetCache = NewStylesheetCache(32 << 20)
+
+type cacheKey struct {
+	hash     [sha256.Size]byte
+	isInline bool
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	size int
+	once sync.Once
+	s    *Stylesheet
+	err  error
+}
+
+// NewStylesheetCache returns a new cache that holds stylesheets up to a total source size of maxSize bytes.
+func NewStylesheetCache(maxSize int) *StylesheetCache {
+	return &StylesheetCache{
+		maxSize: maxSize,
+		entries: map[cacheKey]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Parse returns the parsed stylesheet for src, or the declaration list for inline styles when isInline is set, from the cache or by parsing a copy of src. The returned stylesheet is shared and must not be modified. Parse errors are collected in the stylesheet, see ParseStylesheet.
+func (c *StylesheetCache) Parse(src []byte, isInline bool) (*Stylesheet, error) {
+	key := cacheKey{sha256.Sum256(src), isInline}
+
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(elem)
+	} else {
+		elem = c.lru.PushFront(&cacheEntry{key: key, size: len(src)})
+		c.entries[key] = elem
+		c.size += len(src)
+		c.evict()
+	}
+	entry := elem.Value.(*cacheEntry)
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		b := make([]byte, len(src), len(src)+1) // room for the NULL of parse.Input
+		copy(b, src)
+		entry.s, entry.err = ParseStylesheet(parse.NewInputBytesOptions(b, parse.InputOptions{LazyPosition: true}), isInline)
+	})
+	return entry.s, entry.err
+}
+
+// Len returns the number of cached stylesheets.
+func (c *StylesheetCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the total size in bytes of the sources of the cached stylesheets.
+func (c *StylesheetCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Clear removes all stylesheets from the cache.
+func (c *StylesheetCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[cacheKey]*list.Element{}
+	c.lru.Init()
+	c.size = 0
+}
+
+// evict removes the least recently used entries until the size fits, except for the most recently used entry.
+func (c *StylesheetCache) evict() {
+	for c.maxSize < c.size && 1 < c.lru.Len() {
+		entry := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+	}
+}
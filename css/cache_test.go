@@ -0,0 +1,126 @@
+package css
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func grammarString(s *Stylesheet) string {
+	str := ""
+	for _, g := range s.Grammar {
+		if g.Type == ErrorGrammar {
+			str += "!"
+			continue
+		}
+		str += string(g.Data)
+		for _, val := range g.Values {
+			str += string(val.Data)
+		}
+		str += ";"
+	}
+	return str
+}
+
+func TestStylesheetCache(t *testing.T) {
+	c := NewStylesheetCache(1 << 10)
+	src := []byte("@media print{a{color:red}}b{margin:0;--x:{y}}")
+	s, err := c.Parse(src, false)
+	test.Error(t, err)
+	test.String(t, grammarString(s), "@media print;a;colorred;};};b;margin0;--x{y};};")
+	test.T(t, s.Grammar[0].Type, BeginAtRuleGrammar)
+	test.T(t, s.Grammar[0].Offset, 13)
+	test.T(t, len(s.Rules), 2)
+
+	// the stylesheet is shared and does not refer to src
+	src[8] = 'X'
+	s2, err := c.Parse([]byte("@media print{a{color:red}}b{margin:0;--x:{y}}"), false)
+	test.Error(t, err)
+	test.That(t, s == s2, "stylesheet must be shared")
+	test.String(t, grammarString(s2), "@media print;a;colorred;};};b;margin0;--x{y};};")
+	test.T(t, c.Len(), 1)
+
+	// inline styles are cached separately
+	s3, err := c.Parse([]byte("color:red"), true)
+	test.Error(t, err)
+	test.String(t, grammarString(s3), "colorred;")
+	s4, err := c.Parse([]byte("color:red"), false)
+	test.Error(t, err)
+	test.That(t, s3 != s4, "inline style must be cached separately")
+	test.T(t, c.Len(), 3)
+	test.T(t, c.Size(), 45+9+9)
+
+	c.Clear()
+	test.T(t, c.Len(), 0)
+	test.T(t, c.Size(), 0)
+}
+
+func TestStylesheetCacheParseError(t *testing.T) {
+	s, err := NewStylesheetCache(1<<10).Parse([]byte("a{color:red;:b}"), false)
+	test.Error(t, err)
+	test.String(t, grammarString(s), "a;colorred;!};")
+	test.That(t, s.Grammar[2].Err != nil, "parse error must be recorded")
+}
+
+func TestStylesheetCacheEvict(t *testing.T) {
+	c := NewStylesheetCache(20)
+	a, _ := c.Parse([]byte("a{color:red}"), false) // 12 bytes
+	c.Parse([]byte("b{color:red}"), false)
+	test.T(t, c.Len(), 1)
+	test.T(t, c.Size(), 12)
+
+	a2, _ := c.Parse([]byte("a{color:red}"), false)
+	test.That(t, a != a2, "least recently used stylesheet must be evicted")
+
+	c.Parse([]byte("c{}"), false)
+	c.Parse([]byte("a{color:red}"), false) // most recently used
+	c.Parse([]byte("d{}"), false)
+	test.T(t, c.Len(), 3)
+	test.T(t, c.Size(), 18)
+
+	c.Parse([]byte("f{}"), false)
+	test.T(t, c.Len(), 3)
+	test.T(t, c.Size(), 18) // c{} is evicted
+
+	// a stylesheet larger than the cache is kept until the next one
+	c.Parse([]byte("e{color:red;margin:0}"), false)
+	test.T(t, c.Len(), 1)
+	test.T(t, c.Size(), 21)
+}
+
+func TestStylesheetCacheConcurrent(t *testing.T) {
+	c := NewStylesheetCache(1 << 10)
+	var wg sync.WaitGroup
+	results := make([]*Stylesheet, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.Parse([]byte("a{color:red}"), false)
+		}(i)
+	}
+	wg.Wait()
+	for _, s := range results {
+		test.That(t, s == results[0], "concurrent requests must share the stylesheet")
+	}
+}
+
+func BenchmarkStylesheetCache(b *testing.B) {
+	src := []byte{}
+	for i := 0; i < 1000; i++ {
+		src = append(src, ".btn-primary:hover{color:#fff;background-color:#0b5ed7;border-color:#0a58ca}"...)
+	}
+	b.Run("parse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ParseStylesheet(parse.NewInputBytes(src), false)
+		}
+	})
+	b.Run("cache", func(b *testing.B) {
+		c := NewStylesheetCache(1 << 20)
+		for i := 0; i < b.N; i++ {
+			c.Parse(src, false)
+		}
+	})
+}
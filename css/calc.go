@@ -0,0 +1,309 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// MathType is the type of a numeric value or math function, see https://www.w3.org/TR/css-values-4/#css-type. It holds the exponent of each base type, that is length, angle, time, frequency, resolution, flex, and percentage, where all exponents are zero for numbers. Percentages added to another base type, such as in calc(10% + 1px), resolve against that base type, which is recorded as the percent hint.
+type MathType struct {
+	exponents [parse.FlexCategory + 1]int8 // indexed by UnitCategory, NoCategory is unused
+	hint      parse.UnitCategory           // base type that percentages resolve against, or NoCategory
+	unknown   bool
+}
+
+func newMathType(category parse.UnitCategory) MathType {
+	t := MathType{}
+	if category != parse.NoCategory {
+		t.exponents[category] = 1
+	}
+	return t
+}
+
+// Category returns the base type of the math type and true if it has a single base type with exponent one, such as LengthCategory for calc(1px * 2), or NoCategory and true for numbers. It returns false for other types, such as calc(1px * 1px), and for unknown types. Mixed types such as calc(10% + 1px) return the base type of the percent hint, see HasPercentage.
+func (t MathType) Category() (parse.UnitCategory, bool) {
+	if t.unknown {
+		return parse.NoCategory, false
+	}
+	category := parse.NoCategory
+	for c, exponent := range t.exponents {
+		if exponent == 0 {
+			continue
+		} else if exponent != 1 || category != parse.NoCategory {
+			return parse.NoCategory, false
+		}
+		category = parse.UnitCategory(c)
+	}
+	return category, true
+}
+
+// IsNumber returns true if the math type is a number, such as calc(1px / 2px).
+func (t MathType) IsNumber() bool {
+	category, ok := t.Category()
+	return ok && category == parse.NoCategory
+}
+
+// HasPercentage returns true if the math type contains percentages, either as a percentage such as calc(10% * 2) or mixed with another base type such as calc(10% + 1px), which matches <length-percentage>.
+func (t MathType) HasPercentage() bool {
+	return t.hint != parse.NoCategory || t.exponents[parse.PercentageCategory] != 0
+}
+
+// IsUnknown returns true if the math type cannot be determined because the expression contains substitution functions such as var().
+func (t MathType) IsUnknown() bool {
+	return t.unknown
+}
+
+// String returns the name of the type, such as number, length, length-percentage, or length^2*time^-1 for compound types.
+func (t MathType) String() string {
+	if t.unknown {
+		return "unknown"
+	} else if category, ok := t.Category(); ok {
+		if category == parse.NoCategory {
+			return "number"
+		} else if t.hint != parse.NoCategory {
+			return category.String() + "-percentage"
+		}
+		return category.String()
+	}
+	sb := strings.Builder{}
+	for c, exponent := range t.exponents {
+		if exponent == 0 {
+			continue
+		} else if sb.Len() != 0 {
+			sb.WriteByte('*')
+		}
+		sb.WriteString(parse.UnitCategory(c).String())
+		if exponent != 1 {
+			sb.WriteString("^" + strconv.Itoa(int(exponent)))
+		}
+	}
+	return sb.String()
+}
+
+// applyHint returns the type with its percentages resolved against the base type hint.
+func (t MathType) applyHint(hint parse.UnitCategory) MathType {
+	t.exponents[hint] += t.exponents[parse.PercentageCategory]
+	t.exponents[parse.PercentageCategory] = 0
+	t.hint = hint
+	return t
+}
+
+// addMathTypes returns the type of the sum of two types, see https://drafts.css-houdini.org/css-typed-om-1/#cssnumericvalue-add-two-types.
+func addMathTypes(a, b MathType) (MathType, bool) {
+	if a.unknown || b.unknown {
+		return MathType{unknown: true}, true
+	} else if a.hint != b.hint {
+		if a.hint != parse.NoCategory && b.hint != parse.NoCategory {
+			return MathType{}, false
+		} else if a.hint != parse.NoCategory {
+			b = b.applyHint(a.hint)
+		} else {
+			a = a.applyHint(b.hint)
+		}
+	}
+	if a.exponents == b.exponents {
+		return a, true
+	}
+	if a.exponents[parse.PercentageCategory] != 0 || b.exponents[parse.PercentageCategory] != 0 {
+		for hint := parse.LengthCategory; hint <= parse.FlexCategory; hint++ {
+			if c, d := a.applyHint(hint), b.applyHint(hint); c.exponents == d.exponents {
+				return c, true
+			}
+		}
+	}
+	return MathType{}, false
+}
+
+// multiplyMathTypes returns the type of the product of two types, see https://drafts.css-houdini.org/css-typed-om-1/#cssnumericvalue-multiply-two-types.
+func multiplyMathTypes(a, b MathType) (MathType, bool) {
+	if a.unknown || b.unknown {
+		return MathType{unknown: true}, true
+	} else if a.hint != b.hint {
+		if a.hint != parse.NoCategory && b.hint != parse.NoCategory {
+			return MathType{}, false
+		} else if a.hint != parse.NoCategory {
+			b = b.applyHint(a.hint)
+		} else {
+			a = a.applyHint(b.hint)
+		}
+	}
+	for c := range a.exponents {
+		a.exponents[c] += b.exponents[c]
+	}
+	return a, true
+}
+
+func invertMathType(t MathType) MathType {
+	for c := range t.exponents {
+		t.exponents[c] = -t.exponents[c]
+	}
+	return t
+}
+
+var mathFunctions = map[string]bool{
+	"calc": true, "min": true, "max": true, "clamp": true, "round": true, "mod": true, "rem": true, "abs": true, "sign": true,
+	"sin": true, "cos": true, "tan": true, "asin": true, "acos": true, "atan": true, "atan2": true,
+	"pow": true, "sqrt": true, "hypot": true, "log": true, "exp": true,
+}
+
+// IsMathFunction returns true if the function name, without the opening parenthesis, is a math function such as calc or clamp, compared case-insensitively.
+func IsMathFunction(name []byte) bool {
+	return mathFunctions[strings.ToLower(string(name))]
+}
+
+// ParseMathType returns the type of a math function, such as calc(), min(), or clamp(), or of a single number, percentage, or dimension, such as the values of a declaration. It returns false if the values are not a single math function or numeric token, if the expression is invalid, or if its type is invalid, such as calc(1px + 1s) or calc(1px * 1px) that mix incompatible types. The types of nested math functions are inferred as by css-values-4, such as angles for atan2() and numbers for sign(). Expressions containing substitution functions such as var() return an unknown type.
+func ParseMathType(values []Token) (MathType, bool) {
+	_, t, ok := parseMath(values, nil)
+	return t, ok
+}
+
+// NestedMathLimit is the maximum nesting of parentheses and functions in math expressions accepted by ParseMathType and ParseMathExpr.
+const NestedMathLimit = 256
+
+// mathType infers the type of the expression, see https://www.w3.org/TR/css-values-4/#calc-type-checking. Constants are numbers and substitution functions have an unknown type.
+func (n *MathNode) mathType() (MathType, bool) {
+	switch n.Type {
+	case MathNumber:
+		if n.Unit == parse.NoUnit {
+			return newMathType(parse.NoCategory), true
+		} else if n.Unit == parse.Percent {
+			return newMathType(parse.PercentageCategory), true
+		}
+		return newMathType(n.Unit.Category()), true
+	case MathConstant:
+		return newMathType(parse.NoCategory), true
+	case MathSubstitution:
+		return MathType{unknown: true}, true
+	case MathNegate:
+		return n.Args[0].mathType()
+	case MathInvert:
+		t, ok := n.Args[0].mathType()
+		return invertMathType(t), ok
+	case MathSum, MathProduct:
+		t, ok := n.Args[0].mathType()
+		for _, arg := range n.Args[1:] {
+			if !ok {
+				break
+			}
+			var u MathType
+			if u, ok = arg.mathType(); ok {
+				if n.Type == MathSum {
+					t, ok = addMathTypes(t, u)
+				} else {
+					t, ok = multiplyMathTypes(t, u)
+				}
+			}
+		}
+		return t, ok
+	case MathFunction:
+		return n.functionType()
+	}
+	return MathType{}, false
+}
+
+// functionType returns the type of a math function from the types of its arguments.
+func (n *MathNode) functionType() (MathType, bool) {
+	name := string(n.Name)
+	args := make([]*MathType, 0, len(n.Args))
+	for i, arg := range n.Args {
+		if arg.Type == MathKeyword {
+			if name == "round" && i == 0 {
+				continue // rounding strategy
+			} else if name != "clamp" || i == 1 {
+				return MathType{}, false
+			}
+			args = append(args, nil)
+			continue
+		}
+		t, ok := arg.mathType()
+		if !ok {
+			return MathType{}, false
+		}
+		args = append(args, &t)
+	}
+
+	// sum types of all arguments
+	sum := func(args []*MathType) (MathType, bool) {
+		var t *MathType
+		for _, arg := range args {
+			if arg == nil {
+				continue
+			} else if t == nil {
+				t = arg
+			} else if u, ok := addMathTypes(*t, *arg); !ok {
+				return MathType{}, false
+			} else {
+				t = &u
+			}
+		}
+		if t == nil {
+			return MathType{}, false
+		}
+		return *t, true
+	}
+	numbers := func(args []*MathType) bool {
+		for _, arg := range args {
+			if !arg.unknown && !arg.IsNumber() {
+				return false
+			}
+		}
+		return true
+	}
+
+	k := len(args)
+	switch name {
+	case "calc":
+		if k == 1 {
+			return *args[0], true
+		}
+	case "min", "max", "hypot":
+		return sum(args)
+	case "clamp":
+		if k == 3 {
+			return sum(args)
+		}
+	case "round":
+		if k == 1 || k == 2 {
+			return sum(args)
+		}
+	case "mod", "rem":
+		if k == 2 {
+			return sum(args)
+		}
+	case "abs":
+		if k == 1 {
+			return *args[0], true
+		}
+	case "sign":
+		if k == 1 {
+			return newMathType(parse.NoCategory), true
+		}
+	case "sin", "cos", "tan":
+		if category, ok := args[0].Category(); k == 1 && (args[0].unknown || ok && (category == parse.NoCategory || category == parse.AngleCategory)) {
+			return newMathType(parse.NoCategory), true
+		}
+	case "asin", "acos", "atan":
+		if k == 1 && numbers(args) {
+			return newMathType(parse.AngleCategory), true
+		}
+	case "atan2":
+		if _, ok := sum(args); k == 2 && ok {
+			return newMathType(parse.AngleCategory), true
+		}
+	case "pow":
+		if k == 2 && numbers(args) {
+			return newMathType(parse.NoCategory), true
+		}
+	case "sqrt", "exp":
+		if k == 1 && numbers(args) {
+			return newMathType(parse.NoCategory), true
+		}
+	case "log":
+		if (k == 1 || k == 2) && numbers(args) {
+			return newMathType(parse.NoCategory), true
+		}
+	}
+	return MathType{}, false
+}
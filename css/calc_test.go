@@ -0,0 +1,136 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseMathType(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string // type or invalid
+	}{
+		{"5", "number"},
+		{"5%", "percentage"},
+		{"5px", "length"},
+		{"calc(1px + 2em)", "length"},
+		{"calc(1px + 10%)", "length-percentage"},
+		{"calc(10% - 1px * 2)", "length-percentage"},
+		{"calc(10% * 2)", "percentage"},
+		{"calc(1deg + 1rad)", "angle"},
+		{"calc(1s / 2)", "time"},
+		{"calc(1px / 1em)", "number"},
+		{"calc(1px * 1px / 1px)", "length"},
+		{"calc( (1px + 2px) * 3 )", "length"},
+		{"calc(1kHz - 1Hz)", "frequency"},
+		{"calc(2dppx * 1)", "resolution"},
+		{"calc(1fr)", "flex"},
+		{"calc(pi * 1deg)", "angle"},
+		{"calc(-infinity * 1px)", "length"},
+		{"CALC(1PX + 1Px)", "length"},
+		{"calc(1px + var(--x))", "unknown"},
+		{"calc(var(--a, (1px)) * 2)", "unknown"},
+		{"min(1px, 10%, 2em)", "length-percentage"},
+		{"max(1s, 2ms)", "time"},
+		{"clamp(1px, 50%, 10px)", "length-percentage"},
+		{"clamp(none, 1px, none)", "length"},
+		{"round(up, 11px, 5px)", "length"},
+		{"round(1.5)", "number"},
+		{"mod(7deg, 2deg)", "angle"},
+		{"rem(7, 2)", "number"},
+		{"abs(-1s)", "time"},
+		{"sign(-1px)", "number"},
+		{"calc(1px * sign(-1em))", "length"},
+		{"sin(1deg)", "number"},
+		{"cos(1)", "number"},
+		{"calc(asin(1) + 1deg)", "angle"},
+		{"atan2(1px, 1em)", "angle"},
+		{"pow(2, 3)", "number"},
+		{"sqrt(4)", "number"},
+		{"hypot(3px, 4px)", "length"},
+		{"log(8, 2)", "number"},
+		{"exp(1)", "number"},
+		{"calc(1px + 1s)", "invalid"},
+		{"calc(1px + 1)", "invalid"},
+		{"calc(10% + 1)", "invalid"},
+		{"calc(1px * 1px)", "invalid"},
+		{"calc(1 / 1px)", "invalid"},
+		{"calc(1px+1px)", "invalid"},
+		{"calc(1px +1px)", "invalid"},
+		{"calc(1px 1px)", "invalid"},
+		{"calc(1foo)", "invalid"},
+		{"calc(1px, 2px)", "invalid"},
+		{"calc(1px + 10%) * 2", "invalid"},
+		{"calc(1px", "invalid"},
+		{"calc()", "invalid"},
+		{"calc(auto)", "invalid"},
+		{"calc(none)", "invalid"},
+		{"clamp(1px, none, 2px)", "invalid"},
+		{"clamp(1px, 2px)", "invalid"},
+		{"round(nearest)", "invalid"},
+		{"min(1px, 1s)", "invalid"},
+		{"sin(1px)", "invalid"},
+		{"pow(2px, 2)", "invalid"},
+		{"atan2(1px, 1s)", "invalid"},
+		{"foo(1px)", "invalid"},
+		{"var(--x)", "invalid"},
+		{"(1px)", "invalid"},
+		{"pi", "invalid"},
+		{"1px 2px", "invalid"},
+		{"", "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.value))
+			if tt.value != "" && tt.value != "calc(1px" {
+				test.Error(t, err)
+			}
+			mathType, ok := ParseMathType(values)
+			test.T(t, ok, tt.expected != "invalid")
+			if ok {
+				test.String(t, mathType.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestMathTypeCategory(t *testing.T) {
+	mathType, _ := ParseMathType([]Token{{NumberToken, []byte("1")}})
+	test.That(t, mathType.IsNumber())
+	test.That(t, !mathType.HasPercentage())
+
+	values, _ := ParseValue(parse.NewInputString("calc(10% + 1px)"))
+	mathType, _ = ParseMathType(values)
+	category, ok := mathType.Category()
+	test.That(t, ok)
+	test.T(t, category, parse.LengthCategory)
+	test.That(t, mathType.HasPercentage())
+	test.That(t, !mathType.IsUnknown())
+
+	values, _ = ParseValue(parse.NewInputString("calc(var(--x) * 2)"))
+	mathType, _ = ParseMathType(values)
+	test.That(t, mathType.IsUnknown())
+	_, ok = mathType.Category()
+	test.That(t, !ok)
+
+	mathType, _ = multiplyMathTypes(newMathType(parse.LengthCategory), invertMathType(newMathType(parse.TimeCategory)))
+	mathType, _ = multiplyMathTypes(mathType, newMathType(parse.LengthCategory))
+	test.String(t, mathType.String(), "length^2*time^-1")
+}
+
+func TestParseMathTypeNesting(t *testing.T) {
+	s := ""
+	for i := 0; i < NestedMathLimit+1; i++ {
+		s += "calc("
+	}
+	s += "1px"
+	for i := 0; i < NestedMathLimit+1; i++ {
+		s += ")"
+	}
+	values, err := ParseValue(parse.NewInputString(s))
+	test.Error(t, err)
+	_, ok := ParseMathType(values)
+	test.That(t, !ok)
+}
@@ -0,0 +1,765 @@
+package css
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css/internal/colorspace"
+	"github.com/politepixels/tdewolff-parse/v2/strconv"
+)
+
+// Color is a color in the sRGB color space with channels and alpha in the range [0,1]. Colors that were computed in a wider color space, such as by color-mix() in oklch, may have channels outside of this range.
+type Color struct {
+	R, G, B, A float64
+}
+
+// Hex returns the color in hexadecimal notation, such as #ff0000 or #ff000080 when not opaque. Channels are clipped to the sRGB gamut.
+func (c Color) Hex() string {
+	const hex = "0123456789abcdef"
+	b := []byte{'#'}
+	for i, v := range []float64{c.R, c.G, c.B, c.A} {
+		if i == 3 && 1.0 <= v {
+			break
+		}
+		n := int(math.Max(0.0, math.Min(1.0, v))*255.0 + 0.5 + 1e-9) // compensate for floating-point errors such as 127.5/255*255
+		b = append(b, hex[n>>4], hex[n&15])
+	}
+	return string(b)
+}
+
+// RelativeColor is a color function using the relative color syntax, such as rgb(from red r g calc(b + 20)), see https://www.w3.org/TR/css-color-5/#relative-colors.
+type RelativeColor struct {
+	Function []byte     // lowercase function name, such as rgb or oklch
+	From     []Token    // origin color
+	Channels [3][]Token // channel expressions, which may refer to the channels of the origin color by keyword
+	Alpha    []Token    // alpha expression, nil if omitted
+}
+
+// ColorMix is a color-mix() function, such as color-mix(in oklch, red 40%, blue), see https://www.w3.org/TR/css-color-5/#color-mix.
+type ColorMix struct {
+	Space       []byte     // lowercase interpolation color space
+	Hue         []byte     // lowercase hue interpolation method, nil if omitted
+	Colors      [2][]Token // colors to mix
+	Percentages [2][]Token // percentages of the colors, nil if omitted
+}
+
+// ColorFunction is a color function with absolute channels, such as rgb(255 0 0 / 50%), hsl(0, 100%, 50%), or color(display-p3 1 0 0), see https://www.w3.org/TR/css-color-4/#color-syntax.
+type ColorFunction struct {
+	Function []byte     // lowercase function name, such as rgb or oklch
+	Space    []byte     // lowercase predefined color space of color(), nil otherwise
+	Channels [3][]Token // channel values, which may be none or math functions such as calc()
+	Alpha    []Token    // alpha value, nil if omitted
+	Legacy   bool       // legacy syntax of rgb() and hsl() with commas, which does not allow none
+}
+
+// ParseRelativeColor parses a color function that uses the relative color syntax, such as the values of a declaration. It returns false if the values are not a single color function with an origin color.
+func ParseRelativeColor(values []Token) (RelativeColor, bool) {
+	name, args, ok := colorFunction(values)
+	if !ok || colorFunctions[string(name)] == "" {
+		return RelativeColor{}, false
+	}
+	comps := colorComponents(args)
+	if len(comps) != 5 && (len(comps) != 7 || !isColorSeparator(comps[5], '/')) || !isColorIdent(comps[0], "from") {
+		return RelativeColor{}, false
+	}
+	for i, comp := range comps {
+		if i != 5 && (isColorSeparator(comp, ',') || isColorSeparator(comp, '/')) {
+			return RelativeColor{}, false
+		}
+	}
+	rc := RelativeColor{
+		Function: name,
+		From:     comps[1],
+		Channels: [3][]Token{comps[2], comps[3], comps[4]},
+	}
+	if len(comps) == 7 {
+		rc.Alpha = comps[6]
+	}
+	return rc, true
+}
+
+// ParseColorFunction parses a color function with absolute channels, such as the values of a declaration. It returns false if the values are not a single color function with valid separators, such as relative colors or color-mix().
+func ParseColorFunction(values []Token) (ColorFunction, bool) {
+	name, args, ok := colorFunction(values)
+	if !ok || string(name) != "color" && colorFunctions[string(name)] == "" {
+		return ColorFunction{}, false
+	}
+	comps := colorComponents(args)
+	if 0 < len(comps) && isColorIdent(comps[0], "from") {
+		return ColorFunction{}, false
+	}
+
+	cf := ColorFunction{Function: name}
+	if string(name) == "color" {
+		if len(comps) == 0 || len(comps[0]) != 1 || comps[0][0].TokenType != IdentToken {
+			return ColorFunction{}, false
+		}
+		cf.Space = parse.ToLower(parse.Copy(comps[0][0].Data))
+		comps = comps[1:]
+	}
+
+	if 2 < len(comps) && isColorSeparator(comps[1], ',') {
+		spaceName := colorFunctions[string(name)]
+		if spaceName != "rgb" && spaceName != "hsl" || len(comps) != 5 && len(comps) != 7 {
+			return ColorFunction{}, false
+		}
+		for i, comp := range comps {
+			if (i%2 == 1) != isColorSeparator(comp, ',') || i%2 == 0 && (isColorSeparator(comp, '/') || isColorIdent(comp, "none")) {
+				return ColorFunction{}, false
+			}
+		}
+		cf.Channels = [3][]Token{comps[0], comps[2], comps[4]}
+		if len(comps) == 7 {
+			cf.Alpha = comps[6]
+		}
+		cf.Legacy = true
+		return cf, true
+	}
+
+	if len(comps) != 3 && len(comps) != 5 {
+		return ColorFunction{}, false
+	}
+	for i, comp := range comps {
+		if (i == 3) != isColorSeparator(comp, '/') || isColorSeparator(comp, ',') {
+			return ColorFunction{}, false
+		}
+	}
+	cf.Channels = [3][]Token{comps[0], comps[1], comps[2]}
+	if len(comps) == 5 {
+		cf.Alpha = comps[4]
+	}
+	return cf, true
+}
+
+// ParseColorMix parses a color-mix() function, such as the values of a declaration. It returns false if the values are not a single color-mix() function.
+func ParseColorMix(values []Token) (ColorMix, bool) {
+	name, args, ok := colorFunction(values)
+	if !ok || string(name) != "color-mix" {
+		return ColorMix{}, false
+	}
+
+	groups := [][][]Token{{}}
+	for _, comp := range colorComponents(args) {
+		if isColorSeparator(comp, ',') {
+			groups = append(groups, [][]Token{})
+		} else {
+			groups[len(groups)-1] = append(groups[len(groups)-1], comp)
+		}
+	}
+	if len(groups) != 3 {
+		return ColorMix{}, false
+	}
+
+	cm := ColorMix{}
+	if n := len(groups[0]); (n != 2 && n != 4) || !isColorIdent(groups[0][0], "in") || groups[0][1][0].TokenType != IdentToken || len(groups[0][1]) != 1 {
+		return ColorMix{}, false
+	} else if n == 4 {
+		if !isColorIdent(groups[0][3], "hue") || groups[0][2][0].TokenType != IdentToken || len(groups[0][2]) != 1 {
+			return ColorMix{}, false
+		}
+		switch hue := parse.ToLower(parse.Copy(groups[0][2][0].Data)); string(hue) {
+		case "shorter", "longer", "increasing", "decreasing":
+			cm.Hue = hue
+		default:
+			return ColorMix{}, false
+		}
+	}
+	cm.Space = parse.ToLower(parse.Copy(groups[0][1][0].Data))
+
+	for i, group := range groups[1:] {
+		if len(group) == 1 {
+			cm.Colors[i] = group[0]
+		} else if len(group) == 2 && isColorPercentage(group[1]) {
+			cm.Colors[i], cm.Percentages[i] = group[0], group[1]
+		} else if len(group) == 2 && isColorPercentage(group[0]) {
+			cm.Colors[i], cm.Percentages[i] = group[1], group[0]
+		} else {
+			return ColorMix{}, false
+		}
+	}
+	return cm, true
+}
+
+// EvalColor evaluates a color value, such as the values of a declaration, to an sRGB color. It supports hexadecimal and named colors, the rgb(), rgba(), hsl(), hsla(), hwb(), lab(), lch(), oklab(), and oklch() functions including the relative color syntax, and color-mix(). It returns false if the color is invalid or not literal, such as currentcolor or colors that depend on var().
+func EvalColor(values []Token) (Color, bool) {
+	values = trimColorWhitespace(values)
+	if len(values) == 1 {
+		if values[0].TokenType == HashToken {
+			return hexColor(values[0].Data[1:])
+		} else if values[0].TokenType == IdentToken {
+			name := parse.ToLower(parse.Copy(values[0].Data))
+			if string(name) == "transparent" {
+				return Color{}, true
+			} else if rgb, ok := namedColors[string(name)]; ok {
+				return Color{float64(rgb>>16) / 255.0, float64(rgb>>8&0xFF) / 255.0, float64(rgb&0xFF) / 255.0, 1.0}, true
+			}
+		}
+		return Color{}, false
+	}
+
+	name, _, ok := colorFunction(values)
+	if !ok {
+		return Color{}, false
+	} else if string(name) == "color-mix" {
+		if cm, ok := ParseColorMix(values); ok {
+			return evalColorMix(cm)
+		}
+		return Color{}, false
+	}
+
+	spaceName := colorFunctions[string(name)]
+	if spaceName == "" {
+		return Color{}, false
+	}
+	space := colorSpaces[spaceName]
+	if rc, ok := ParseRelativeColor(values); ok {
+		origin, ok := EvalColor(rc.From)
+		if !ok {
+			return Color{}, false
+		}
+		coords := space.coords(origin)
+		vars := map[string]float64{"alpha": coords.c[3]}
+		for i, channel := range space.channels {
+			vars[channel] = coords.c[i]
+		}
+		alpha := rc.Alpha
+		if alpha == nil {
+			alpha = []Token{{IdentToken, []byte("alpha")}}
+		}
+		return space.evalChannels(spaceName, [4][]Token{rc.Channels[0], rc.Channels[1], rc.Channels[2], alpha}, vars)
+	}
+
+	cf, ok := ParseColorFunction(values)
+	if !ok {
+		return Color{}, false
+	}
+	channels := [4][]Token{cf.Channels[0], cf.Channels[1], cf.Channels[2], cf.Alpha}
+	if channels[3] == nil {
+		channels[3] = []Token{{NumberToken, []byte("1")}}
+	}
+	return space.evalChannels(spaceName, channels, nil)
+}
+
+////////////////////////////////////////////////////////////////
+
+// colorFunctions maps color functions to their color space.
+var colorFunctions = map[string]string{
+	"rgb":   "rgb",
+	"rgba":  "rgb",
+	"hsl":   "hsl",
+	"hsla":  "hsl",
+	"hwb":   "hwb",
+	"lab":   "lab",
+	"lch":   "lch",
+	"oklab": "oklab",
+	"oklch": "oklch",
+}
+
+// colorSpace is a color space with its channel keywords and conversions to and from CIE XYZ with a D65 white point.
+type colorSpace struct {
+	channels [3]string
+	scales   [3]float64 // reference range of percentages
+	hue      int        // index of the hue channel, or -1
+	fromXYZ  func([3]float64) [3]float64
+	toXYZ    func([3]float64) [3]float64
+}
+
+// colorSpaces are the color spaces of color functions and the interpolation color spaces of color-mix(). The rgb color space is the sRGB color space with channels in the range [0,255] and is used by the rgb() function only.
+var colorSpaces = map[string]colorSpace{
+	"rgb": {[3]string{"r", "g", "b"}, [3]float64{255.0, 255.0, 255.0}, -1,
+		func(xyz [3]float64) [3]float64 { return scaleColor(colorspace.XYZToSRGB(xyz), 255.0) },
+		func(c [3]float64) [3]float64 { return colorspace.SRGBToXYZ(scaleColor(c, 1.0/255.0)) }},
+	"srgb":        {[3]string{"r", "g", "b"}, [3]float64{1.0, 1.0, 1.0}, -1, colorspace.XYZToSRGB, colorspace.SRGBToXYZ},
+	"srgb-linear": {[3]string{"r", "g", "b"}, [3]float64{1.0, 1.0, 1.0}, -1, colorspace.XYZToLinearSRGB, colorspace.LinearSRGBToXYZ},
+	"hsl":         {[3]string{"h", "s", "l"}, [3]float64{0.0, 100.0, 100.0}, 0, colorspace.XYZToHSL, colorspace.HSLToXYZ},
+	"hwb":         {[3]string{"h", "w", "b"}, [3]float64{0.0, 100.0, 100.0}, 0, colorspace.XYZToHWB, colorspace.HWBToXYZ},
+	"lab":         {[3]string{"l", "a", "b"}, [3]float64{100.0, 125.0, 125.0}, -1, colorspace.XYZToLab, colorspace.LabToXYZ},
+	"lch":         {[3]string{"l", "c", "h"}, [3]float64{100.0, 150.0, 0.0}, 2, colorspace.XYZToLCH, colorspace.LCHToXYZ},
+	"oklab":       {[3]string{"l", "a", "b"}, [3]float64{1.0, 0.4, 0.4}, -1, colorspace.XYZToOKLab, colorspace.OKLabToXYZ},
+	"oklch":       {[3]string{"l", "c", "h"}, [3]float64{1.0, 0.4, 0.0}, 2, colorspace.XYZToOKLCH, colorspace.OKLCHToXYZ},
+	"xyz":         {[3]string{"x", "y", "z"}, [3]float64{1.0, 1.0, 1.0}, -1, colorspace.Identity, colorspace.Identity},
+	"xyz-d65":     {[3]string{"x", "y", "z"}, [3]float64{1.0, 1.0, 1.0}, -1, colorspace.Identity, colorspace.Identity},
+	"xyz-d50":     {[3]string{"x", "y", "z"}, [3]float64{1.0, 1.0, 1.0}, -1, colorspace.XYZToXYZD50, colorspace.XYZD50ToXYZ},
+}
+
+// colorCoords are the channels and alpha of a color in a color space, where missing components are none.
+type colorCoords struct {
+	c    [4]float64
+	none [4]bool
+}
+
+// coords converts an sRGB color to the color space, the hue of achromatic colors is missing.
+func (space colorSpace) coords(color Color) colorCoords {
+	c := space.fromXYZ(colorspace.SRGBToXYZ([3]float64{color.R, color.G, color.B}))
+	coords := colorCoords{c: [4]float64{c[0], c[1], c[2], color.A}}
+	if 0 <= space.hue {
+		coords.c[space.hue] = colorspace.NormalizeHue(coords.c[space.hue])
+		if space.channels[1] == "s" && c[1] < 1e-4 || space.channels[1] == "w" && 100.0-1e-4 <= c[1]+c[2] || space.hue == 2 && c[1]/space.scales[1] < 1e-4 {
+			coords.none[space.hue] = true
+		}
+	}
+	return coords
+}
+
+// color converts the coordinates in the color space to an sRGB color, missing components are zero.
+func (space colorSpace) color(coords colorCoords) Color {
+	for i := range coords.c {
+		if coords.none[i] {
+			coords.c[i] = 0.0
+		}
+	}
+	c := colorspace.XYZToSRGB(space.toXYZ([3]float64{coords.c[0], coords.c[1], coords.c[2]}))
+	return Color{c[0], c[1], c[2], math.Max(0.0, math.Min(1.0, coords.c[3]))}
+}
+
+// evalChannels evaluates the channel and alpha expressions of a color function, where vars are the channel keywords of a relative color.
+func (space colorSpace) evalChannels(name string, channels [4][]Token, vars map[string]float64) (Color, bool) {
+	coords := colorCoords{}
+	for i, channel := range channels {
+		if isColorIdent(channel, "none") {
+			coords.none[i] = true
+			continue
+		}
+		scale, hue := 1.0, false
+		if i < 3 {
+			scale, hue = space.scales[i], i == space.hue
+		}
+		v, ok := evalColorChannel(channel, scale, hue, vars)
+		if !ok {
+			return Color{}, false
+		}
+		coords.c[i] = v
+	}
+
+	// clamp channels to their valid range
+	switch name {
+	case "rgb":
+		for i := 0; i < 3; i++ {
+			coords.c[i] = math.Max(0.0, math.Min(255.0, coords.c[i]))
+		}
+	case "hsl", "hwb":
+		coords.c[1] = math.Max(0.0, coords.c[1])
+		coords.c[2] = math.Max(0.0, coords.c[2])
+	case "lab", "lch", "oklab", "oklch":
+		coords.c[0] = math.Max(0.0, math.Min(space.scales[0], coords.c[0]))
+		if name == "lch" || name == "oklch" {
+			coords.c[1] = math.Max(0.0, coords.c[1])
+		}
+	}
+	return space.color(coords), true
+}
+
+// evalColorMix mixes two colors, see https://www.w3.org/TR/css-color-5/#color-mix-result.
+func evalColorMix(cm ColorMix) (Color, bool) {
+	space, ok := colorSpaces[string(cm.Space)]
+	if !ok || string(cm.Space) == "rgb" || cm.Hue != nil && space.hue == -1 {
+		return Color{}, false
+	}
+
+	colors := [2]colorCoords{}
+	ps := [2]float64{-1.0, -1.0}
+	for i := range cm.Colors {
+		color, ok := EvalColor(cm.Colors[i])
+		if !ok {
+			return Color{}, false
+		}
+		colors[i] = space.coords(color)
+		if cm.Percentages[i] != nil {
+			if ps[i], ok = parseColorNumber(cm.Percentages[i][0].Data[:len(cm.Percentages[i][0].Data)-1]); !ok || ps[i] < 0.0 || 100.0 < ps[i] {
+				return Color{}, false
+			}
+		}
+	}
+
+	// normalize percentages
+	if ps[0] < 0.0 && ps[1] < 0.0 {
+		ps = [2]float64{50.0, 50.0}
+	} else if ps[0] < 0.0 {
+		ps[0] = 100.0 - ps[1]
+	} else if ps[1] < 0.0 {
+		ps[1] = 100.0 - ps[0]
+	}
+	sum := ps[0] + ps[1]
+	if sum == 0.0 {
+		return Color{}, false
+	}
+	alphaMultiplier := math.Min(1.0, sum/100.0)
+	p0, p1 := ps[0]/sum, ps[1]/sum
+
+	// missing components take the value of the other color
+	a, b := colors[0], colors[1]
+	for i := range a.c {
+		if a.none[i] && !b.none[i] {
+			a.c[i], a.none[i] = b.c[i], false
+		} else if b.none[i] && !a.none[i] {
+			b.c[i], b.none[i] = a.c[i], false
+		}
+	}
+
+	if 0 <= space.hue {
+		h0, h1 := a.c[space.hue], b.c[space.hue]
+		switch string(cm.Hue) {
+		case "", "shorter":
+			if 180.0 < h1-h0 {
+				h0 += 360.0
+			} else if h1-h0 < -180.0 {
+				h1 += 360.0
+			}
+		case "longer":
+			if 0.0 < h1-h0 && h1-h0 < 180.0 {
+				h0 += 360.0
+			} else if -180.0 < h1-h0 && h1-h0 <= 0.0 {
+				h1 += 360.0
+			}
+		case "increasing":
+			if h1 < h0 {
+				h1 += 360.0
+			}
+		case "decreasing":
+			if h0 < h1 {
+				h0 += 360.0
+			}
+		}
+		a.c[space.hue], b.c[space.hue] = h0, h1
+	}
+
+	// interpolate with premultiplied alpha
+	mix := colorCoords{}
+	mix.c[3] = a.c[3]*p0 + b.c[3]*p1
+	mix.none[3] = a.none[3] && b.none[3]
+	for i := 0; i < 3; i++ {
+		mix.none[i] = a.none[i] && b.none[i]
+		if i == space.hue {
+			mix.c[i] = colorspace.NormalizeHue(a.c[i]*p0 + b.c[i]*p1)
+		} else if mix.c[3] != 0.0 {
+			mix.c[i] = (a.c[i]*a.c[3]*p0 + b.c[i]*b.c[3]*p1) / mix.c[3]
+		} else {
+			mix.c[i] = a.c[i]*p0 + b.c[i]*p1
+		}
+	}
+	mix.c[3] *= alphaMultiplier
+	return space.color(mix), true
+}
+
+////////////////////////////////////////////////////////////////
+
+// evalColorChannel evaluates a channel value, which is a number, percentage, angle for hue channels, channel keyword, or math function such as calc(). Percentages are relative to scale and angles are converted to degrees.
+func evalColorChannel(channel []Token, scale float64, hue bool, vars map[string]float64) (float64, bool) {
+	n, t, ok := parseMath(channel, vars)
+	if !ok {
+		return 0.0, false
+	} else if category, ok := t.Category(); !ok || t.hint != parse.NoCategory || category != parse.NoCategory && category != parse.PercentageCategory && (!hue || category != parse.AngleCategory) {
+		return 0.0, false
+	}
+	n = resolveColorChannel(n, scale, vars).Fold()
+	if n.Type != MathNumber {
+		return 0.0, false
+	}
+	return n.Value, true
+}
+
+// resolveColorChannel returns a copy of the expression where percentages, angles, channel keywords, and the constants e and pi are replaced by numbers, such that it folds into a single number.
+func resolveColorChannel(n *MathNode, scale float64, vars map[string]float64) *MathNode {
+	switch n.Type {
+	case MathNumber:
+		if n.Unit == parse.Percent {
+			return &MathNode{Type: MathNumber, Value: n.Value * scale / 100.0}
+		} else if v, ok := n.Unit.Convert(n.Value, parse.Deg); ok {
+			return &MathNode{Type: MathNumber, Value: v}
+		}
+		return n
+	case MathConstant:
+		if v, ok := vars[string(n.Name)]; ok {
+			return &MathNode{Type: MathNumber, Value: v}
+		} else if bytes.Equal(n.Name, []byte("pi")) {
+			return &MathNode{Type: MathNumber, Value: math.Pi}
+		} else if bytes.Equal(n.Name, []byte("e")) {
+			return &MathNode{Type: MathNumber, Value: math.E}
+		}
+		return n
+	}
+	m := *n
+	m.Args = make([]*MathNode, len(n.Args))
+	for i, arg := range n.Args {
+		m.Args[i] = resolveColorChannel(arg, scale, vars)
+	}
+	return &m
+}
+
+func parseColorNumber(b []byte) (float64, bool) {
+	f, n := strconv.ParseFloat(b)
+	return f, n == len(b) && 0 < n
+}
+
+////////////////////////////////////////////////////////////////
+
+// colorFunction returns the lowercase function name and its arguments if the values consist of a single function.
+func colorFunction(values []Token) ([]byte, []Token, bool) {
+	values = trimColorWhitespace(values)
+	if len(values) < 2 || values[0].TokenType != FunctionToken || values[len(values)-1].TokenType != RightParenthesisToken {
+		return nil, nil, false
+	}
+	level := 0
+	for i, t := range values {
+		if t.TokenType == FunctionToken || t.TokenType == LeftParenthesisToken {
+			level++
+		} else if t.TokenType == RightParenthesisToken {
+			level--
+			if level == 0 && i != len(values)-1 {
+				return nil, nil, false
+			}
+		}
+	}
+	name := parse.ToLower(parse.Copy(values[0].Data[:len(values[0].Data)-1]))
+	return name, values[1 : len(values)-1], true
+}
+
+// colorComponents splits function arguments into components separated by whitespace, commas, or slashes, where commas and slashes are components of their own. Functions and parenthesized groups are a single component.
+func colorComponents(args []Token) [][]Token {
+	comps := [][]Token{}
+	level, start := 0, 0
+	for i, t := range args {
+		if level == 0 {
+			if t.TokenType == WhitespaceToken || t.TokenType == CommentToken {
+				continue
+			} else if t.TokenType == CommaToken || t.TokenType == DelimToken && t.Data[0] == '/' {
+				comps = append(comps, args[i:i+1])
+				continue
+			}
+			start = i
+		}
+		if t.TokenType == FunctionToken || t.TokenType == LeftParenthesisToken {
+			level++
+		} else if t.TokenType == RightParenthesisToken && 0 < level {
+			level--
+		}
+		if level == 0 {
+			comps = append(comps, args[start:i+1])
+		}
+	}
+	if 0 < level {
+		comps = append(comps, args[start:])
+	}
+	return comps
+}
+
+func trimColorWhitespace(values []Token) []Token {
+	for 0 < len(values) && values[0].TokenType == WhitespaceToken {
+		values = values[1:]
+	}
+	for 0 < len(values) && values[len(values)-1].TokenType == WhitespaceToken {
+		values = values[:len(values)-1]
+	}
+	return values
+}
+
+func isColorIdent(comp []Token, ident string) bool {
+	return len(comp) == 1 && comp[0].TokenType == IdentToken && parse.EqualFold(comp[0].Data, []byte(ident))
+}
+
+func isColorSeparator(comp []Token, c byte) bool {
+	return len(comp) == 1 && (c == ',' && comp[0].TokenType == CommaToken || c != ',' && comp[0].TokenType == DelimToken && comp[0].Data[0] == c)
+}
+
+func isColorPercentage(comp []Token) bool {
+	return len(comp) == 1 && comp[0].TokenType == PercentageToken
+}
+
+// hexColor parses the hexadecimal notation of a color without the hash sign.
+func hexColor(b []byte) (Color, bool) {
+	if len(b) != 3 && len(b) != 4 && len(b) != 6 && len(b) != 8 {
+		return Color{}, false
+	}
+	v := [4]float64{0.0, 0.0, 0.0, 255.0}
+	for i := range v {
+		var n int
+		if len(b) <= 4 {
+			if len(b) <= i {
+				break
+			}
+			n = hexDigit(b[i]) * 17
+		} else {
+			if len(b) <= 2*i {
+				break
+			}
+			n = hexDigit(b[2*i])<<4 | hexDigit(b[2*i+1])
+		}
+		if n < 0 {
+			return Color{}, false
+		}
+		v[i] = float64(n)
+	}
+	return Color{v[0] / 255.0, v[1] / 255.0, v[2] / 255.0, v[3] / 255.0}, true
+}
+
+// hexDigit returns the value of a hexadecimal digit, or a large negative number if invalid.
+func hexDigit(c byte) int {
+	if '0' <= c && c <= '9' {
+		return int(c - '0')
+	} else if 'a' <= c && c <= 'f' {
+		return int(c-'a') + 10
+	} else if 'A' <= c && c <= 'F' {
+		return int(c-'A') + 10
+	}
+	return -1 << 8
+}
+
+// scaleColor multiplies the channels by f.
+func scaleColor(c [3]float64, f float64) [3]float64 {
+	return [3]float64{c[0] * f, c[1] * f, c[2] * f}
+}
+
+////////////////////////////////////////////////////////////////
+
+// namedColors are the named colors of CSS Color 4, see https://www.w3.org/TR/css-color-4/#named-colors.
+var namedColors = map[string]uint32{
+	"aliceblue":            0xf0f8ff,
+	"antiquewhite":         0xfaebd7,
+	"aqua":                 0x00ffff,
+	"aquamarine":           0x7fffd4,
+	"azure":                0xf0ffff,
+	"beige":                0xf5f5dc,
+	"bisque":               0xffe4c4,
+	"black":                0x000000,
+	"blanchedalmond":       0xffebcd,
+	"blue":                 0x0000ff,
+	"blueviolet":           0x8a2be2,
+	"brown":                0xa52a2a,
+	"burlywood":            0xdeb887,
+	"cadetblue":            0x5f9ea0,
+	"chartreuse":           0x7fff00,
+	"chocolate":            0xd2691e,
+	"coral":                0xff7f50,
+	"cornflowerblue":       0x6495ed,
+	"cornsilk":             0xfff8dc,
+	"crimson":              0xdc143c,
+	"cyan":                 0x00ffff,
+	"darkblue":             0x00008b,
+	"darkcyan":             0x008b8b,
+	"darkgoldenrod":        0xb8860b,
+	"darkgray":             0xa9a9a9,
+	"darkgreen":            0x006400,
+	"darkgrey":             0xa9a9a9,
+	"darkkhaki":            0xbdb76b,
+	"darkmagenta":          0x8b008b,
+	"darkolivegreen":       0x556b2f,
+	"darkorange":           0xff8c00,
+	"darkorchid":           0x9932cc,
+	"darkred":              0x8b0000,
+	"darksalmon":           0xe9967a,
+	"darkseagreen":         0x8fbc8f,
+	"darkslateblue":        0x483d8b,
+	"darkslategray":        0x2f4f4f,
+	"darkslategrey":        0x2f4f4f,
+	"darkturquoise":        0x00ced1,
+	"darkviolet":           0x9400d3,
+	"deeppink":             0xff1493,
+	"deepskyblue":          0x00bfff,
+	"dimgray":              0x696969,
+	"dimgrey":              0x696969,
+	"dodgerblue":           0x1e90ff,
+	"firebrick":            0xb22222,
+	"floralwhite":          0xfffaf0,
+	"forestgreen":          0x228b22,
+	"fuchsia":              0xff00ff,
+	"gainsboro":            0xdcdcdc,
+	"ghostwhite":           0xf8f8ff,
+	"gold":                 0xffd700,
+	"goldenrod":            0xdaa520,
+	"gray":                 0x808080,
+	"green":                0x008000,
+	"greenyellow":          0xadff2f,
+	"grey":                 0x808080,
+	"honeydew":             0xf0fff0,
+	"hotpink":              0xff69b4,
+	"indianred":            0xcd5c5c,
+	"indigo":               0x4b0082,
+	"ivory":                0xfffff0,
+	"khaki":                0xf0e68c,
+	"lavender":             0xe6e6fa,
+	"lavenderblush":        0xfff0f5,
+	"lawngreen":            0x7cfc00,
+	"lemonchiffon":         0xfffacd,
+	"lightblue":            0xadd8e6,
+	"lightcoral":           0xf08080,
+	"lightcyan":            0xe0ffff,
+	"lightgoldenrodyellow": 0xfafad2,
+	"lightgray":            0xd3d3d3,
+	"lightgreen":           0x90ee90,
+	"lightgrey":            0xd3d3d3,
+	"lightpink":            0xffb6c1,
+	"lightsalmon":          0xffa07a,
+	"lightseagreen":        0x20b2aa,
+	"lightskyblue":         0x87cefa,
+	"lightslategray":       0x778899,
+	"lightslategrey":       0x778899,
+	"lightsteelblue":       0xb0c4de,
+	"lightyellow":          0xffffe0,
+	"lime":                 0x00ff00,
+	"limegreen":            0x32cd32,
+	"linen":                0xfaf0e6,
+	"magenta":              0xff00ff,
+	"maroon":               0x800000,
+	"mediumaquamarine":     0x66cdaa,
+	"mediumblue":           0x0000cd,
+	"mediumorchid":         0xba55d3,
+	"mediumpurple":         0x9370db,
+	"mediumseagreen":       0x3cb371,
+	"mediumslateblue":      0x7b68ee,
+	"mediumspringgreen":    0x00fa9a,
+	"mediumturquoise":      0x48d1cc,
+	"mediumvioletred":      0xc71585,
+	"midnightblue":         0x191970,
+	"mintcream":            0xf5fffa,
+	"mistyrose":            0xffe4e1,
+	"moccasin":             0xffe4b5,
+	"navajowhite":          0xffdead,
+	"navy":                 0x000080,
+	"oldlace":              0xfdf5e6,
+	"olive":                0x808000,
+	"olivedrab":            0x6b8e23,
+	"orange":               0xffa500,
+	"orangered":            0xff4500,
+	"orchid":               0xda70d6,
+	"palegoldenrod":        0xeee8aa,
+	"palegreen":            0x98fb98,
+	"paleturquoise":        0xafeeee,
+	"palevioletred":        0xdb7093,
+	"papayawhip":           0xffefd5,
+	"peachpuff":            0xffdab9,
+	"peru":                 0xcd853f,
+	"pink":                 0xffc0cb,
+	"plum":                 0xdda0dd,
+	"powderblue":           0xb0e0e6,
+	"purple":               0x800080,
+	"rebeccapurple":        0x663399,
+	"red":                  0xff0000,
+	"rosybrown":            0xbc8f8f,
+	"royalblue":            0x4169e1,
+	"saddlebrown":          0x8b4513,
+	"salmon":               0xfa8072,
+	"sandybrown":           0xf4a460,
+	"seagreen":             0x2e8b57,
+	"seashell":             0xfff5ee,
+	"sienna":               0xa0522d,
+	"silver":               0xc0c0c0,
+	"skyblue":              0x87ceeb,
+	"slateblue":            0x6a5acd,
+	"slategray":            0x708090,
+	"slategrey":            0x708090,
+	"snow":                 0xfffafa,
+	"springgreen":          0x00ff7f,
+	"steelblue":            0x4682b4,
+	"tan":                  0xd2b48c,
+	"teal":                 0x008080,
+	"thistle":              0xd8bfd8,
+	"tomato":               0xff6347,
+	"turquoise":            0x40e0d0,
+	"violet":               0xee82ee,
+	"wheat":                0xf5deb3,
+	"white":                0xffffff,
+	"whitesmoke":           0xf5f5f5,
+	"yellow":               0xffff00,
+	"yellowgreen":          0x9acd32,
+}
@@ -0,0 +1,246 @@
+// Package color parses the colors of CSS Color Module Level 4 into a Color in their color space, such as lab(), oklch(), or color(display-p3 ...), keeping missing components, and converts, interpolates, and gamut maps them.
+package color
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+	"github.com/politepixels/tdewolff-parse/v2/css/internal/colorspace"
+)
+
+// Space is a color space, see https://www.w3.org/TR/css-color-4/#predefined.
+type Space uint8
+
+// Space values.
+const (
+	SRGB Space = iota
+	SRGBLinear
+	DisplayP3
+	A98RGB
+	ProPhotoRGB
+	Rec2020
+	XYZD50
+	XYZD65
+	HSL
+	HWB
+	Lab
+	LCH
+	OKLab
+	OKLCH
+)
+
+// String returns the name of the color space as used by color() and color-mix(), such as srgb or display-p3.
+func (s Space) String() string {
+	if int(s) < len(spaces) {
+		return spaces[s].name
+	}
+	return "Invalid(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ParseSpace returns the color space of its case-insensitive name, where xyz is xyz-d65. It returns false if the name is not a color space.
+func ParseSpace(b []byte) (Space, bool) {
+	if parse.EqualFold(b, []byte("xyz")) {
+		return XYZD65, true
+	}
+	for i, space := range spaces {
+		if parse.EqualFold(b, []byte(space.name)) {
+			return Space(i), true
+		}
+	}
+	return 0, false
+}
+
+// IsPolar returns true for color spaces with a hue channel, that is hsl, hwb, lch, and oklch.
+func (s Space) IsPolar() bool {
+	return 0 <= spaces[s].hue
+}
+
+// gamut returns the RGB color space that bounds the color space, or false if it is unbounded such as lab and xyz.
+func (s Space) gamut() (Space, bool) {
+	if s == HSL || s == HWB {
+		return SRGB, true
+	}
+	return s, s <= Rec2020
+}
+
+////////////////////////////////////////////////////////////////
+
+// Color is a color in a color space with its channels in the ranges used by CSS, such as [0,1] for the channels of srgb and display-p3, [0,100] for the lightness of lab, and degrees for hues. Missing components, written as none, are NaN. Alpha is in the range [0,1].
+type Color struct {
+	Space Space
+	C     [3]float64
+	Alpha float64
+}
+
+// None returns the value of a missing component.
+func None() float64 {
+	return math.NaN()
+}
+
+// IsNone returns true if the channel at index i is missing, where index 3 is alpha.
+func (c Color) IsNone(i int) bool {
+	if i == 3 {
+		return math.IsNaN(c.Alpha)
+	}
+	return math.IsNaN(c.C[i])
+}
+
+// Convert converts the color to another color space, where missing components are zero. The hue of achromatic colors in polar color spaces is missing.
+func (c Color) Convert(space Space) Color {
+	if c.Space == space {
+		return c
+	}
+	xyz := spaces[c.Space].toXYZ(c.channels())
+	d := Color{Space: space, C: spaces[space].fromXYZ(xyz), Alpha: c.Alpha}
+	if hue := spaces[space].hue; 0 <= hue {
+		d.C[hue] = colorspace.NormalizeHue(d.C[hue])
+		if space == HSL && d.C[1] < 1e-4 || space == HWB && 100.0-1e-4 <= d.C[1]+d.C[2] || hue == 2 && d.C[1]/spaces[space].scales[1] < 1e-4 {
+			d.C[hue] = math.NaN()
+		}
+	}
+	return d
+}
+
+// channels returns the channels where missing components are zero.
+func (c Color) channels() [3]float64 {
+	ch := c.C
+	for i, v := range ch {
+		if math.IsNaN(v) {
+			ch[i] = 0.0
+		}
+	}
+	return ch
+}
+
+// SRGB converts the color to sRGB, where missing components are zero. Colors outside of the sRGB gamut have channels outside the range [0,1], use MapToGamut to bring them into gamut first.
+func (c Color) SRGB() css.Color {
+	rgb := c.Convert(SRGB).channels()
+	alpha := c.Alpha
+	if math.IsNaN(alpha) {
+		alpha = 0.0
+	}
+	return css.Color{R: rgb[0], G: rgb[1], B: rgb[2], A: math.Max(0.0, math.Min(1.0, alpha))}
+}
+
+// InGamut returns true if the color is within the gamut of its color space, where the gamut of hsl and hwb is that of srgb. Colors in unbounded color spaces such as lab and xyz are always in gamut.
+func (c Color) InGamut() bool {
+	gamut, ok := c.Space.gamut()
+	if !ok {
+		return true
+	}
+	const epsilon = 1e-6
+	for _, v := range c.Convert(gamut).channels() {
+		if v < -epsilon || 1.0+epsilon < v {
+			return false
+		}
+	}
+	return true
+}
+
+// MapToGamut converts the color to a color space and brings it within its gamut by reducing the chroma in oklch until clipping the color changes it imperceptibly, see https://www.w3.org/TR/css-color-4/#binsearch.
+func (c Color) MapToGamut(space Space) Color {
+	gamut, ok := space.gamut()
+	if !ok {
+		return c.Convert(space)
+	}
+	const jnd, epsilon = 0.02, 0.0001
+	origin := c.Convert(OKLCH)
+	if math.IsNaN(origin.C[0]) {
+		// the conversion overflows for huge channels, in which case the luminance is infinite
+		if y := spaces[c.Space].toXYZ(c.channels())[1]; math.IsInf(y, 0) {
+			origin.C[0] = y
+		}
+	}
+	if 1.0 <= origin.C[0] {
+		return Color{Space: gamut, C: [3]float64{1.0, 1.0, 1.0}, Alpha: c.Alpha}.Convert(space)
+	} else if origin.C[0] <= 0.0 {
+		return Color{Space: gamut, C: [3]float64{0.0, 0.0, 0.0}, Alpha: c.Alpha}.Convert(space)
+	} else if d := c.Convert(gamut); d.InGamut() {
+		return d.Convert(space)
+	}
+
+	current := origin
+	clipped := current.clip(gamut)
+	if deltaEOK(clipped, current) < jnd {
+		return clipped.Convert(space)
+	}
+	min, max, minInGamut := 0.0, current.C[1], true
+	for epsilon < max-min {
+		current.C[1] = (min + max) / 2.0
+		if minInGamut && current.Convert(gamut).InGamut() {
+			min = current.C[1]
+			continue
+		}
+		clipped = current.clip(gamut)
+		if e := deltaEOK(clipped, current); e < jnd {
+			if jnd-e < epsilon {
+				break
+			}
+			minInGamut = false
+			min = current.C[1]
+		} else {
+			max = current.C[1]
+		}
+	}
+	return clipped.Convert(space)
+}
+
+// clip converts the color to an RGB color space and clamps its channels to the range [0,1].
+func (c Color) clip(space Space) Color {
+	d := c.Convert(space)
+	for i, v := range d.C {
+		d.C[i] = math.Max(0.0, math.Min(1.0, v))
+	}
+	return d
+}
+
+// deltaEOK returns the color difference as the Euclidean distance in oklab.
+func deltaEOK(a, b Color) float64 {
+	p, q := a.Convert(OKLab).channels(), b.Convert(OKLab).channels()
+	return math.Sqrt((p[0]-q[0])*(p[0]-q[0]) + (p[1]-q[1])*(p[1]-q[1]) + (p[2]-q[2])*(p[2]-q[2]))
+}
+
+// String returns the color in CSS syntax, such as rgb(255 0 0 / 0.5), hsl(120 100% 50%), oklch(0.7 0.1 none), or color(display-p3 1 0 0). Numbers are rounded to six decimals, and infinite numbers, including those that overflow when scaled to the range of rgb(), are written as calc(infinity) or calc(-infinity).
+func (c Color) String() string {
+	s := ""
+	switch c.Space {
+	case SRGB:
+		s = "rgb(" + formatNumber(c.C[0]*255.0) + " " + formatNumber(c.C[1]*255.0) + " " + formatNumber(c.C[2]*255.0)
+	case HSL, HWB:
+		s = c.Space.String() + "(" + formatNumber(c.C[0]) + " " + formatPercentage(c.C[1]) + " " + formatPercentage(c.C[2])
+	case Lab, LCH, OKLab, OKLCH:
+		s = c.Space.String() + "(" + formatNumber(c.C[0]) + " " + formatNumber(c.C[1]) + " " + formatNumber(c.C[2])
+	default:
+		s = "color(" + c.Space.String() + " " + formatNumber(c.C[0]) + " " + formatNumber(c.C[1]) + " " + formatNumber(c.C[2])
+	}
+	if c.Alpha != 1.0 {
+		s += " / " + formatNumber(c.Alpha)
+	}
+	return s + ")"
+}
+
+func formatNumber(f float64) string {
+	if math.IsNaN(f) {
+		return "none"
+	} else if math.IsInf(f, 1) {
+		return "calc(infinity)"
+	} else if math.IsInf(f, -1) {
+		return "calc(-infinity)"
+	} else if math.Abs(f) < 1e15 {
+		// larger numbers have no decimals, and would overflow
+		f = math.Round(f*1e6) / 1e6
+	}
+	if f == 0.0 {
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatPercentage(f float64) string {
+	if math.IsNaN(f) {
+		return "none"
+	}
+	return formatNumber(f) + "%"
+}
@@ -0,0 +1,175 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+	"github.com/tdewolff/test"
+)
+
+func parseColor(t *testing.T, s string) Color {
+	values, _ := css.ParseValue(parse.NewInputString(s))
+	c, ok := Parse(values)
+	test.That(t, ok, "must parse "+s)
+	return c
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		color    string
+		expected string
+		hex      string
+	}{
+		{"red", "rgb(255 0 0)", "#ff0000"},
+		{"#f008", "rgb(255 0 0 / 0.533333)", "#ff000088"},
+		{"transparent", "rgb(0 0 0 / 0)", "#00000000"},
+		{"rgb(255, 0, 0)", "rgb(255 0 0)", "#ff0000"},
+		{"rgba(100%, 50%, 0%, .5)", "rgb(255 127.5 0 / 0.5)", "#ff800080"},
+		{"rgb(10% 20 none / 50%)", "rgb(25.5 20 none / 0.5)", "#1a140080"},
+		{"rgb(300 -1 calc(255 / 2))", "rgb(255 0 127.5)", "#ff0080"},
+		{"RGB(0 0 0 / none)", "rgb(0 0 0 / none)", "#00000000"},
+		{"hsl(120, 100%, 50%, .5)", "hsl(120 100% 50% / 0.5)", "#00ff0080"},
+		{"hsl(0.5turn 100 50)", "hsl(180 100% 50%)", "#00ffff"},
+		{"hsl(-90deg 100% 50%)", "hsl(270 100% 50%)", "#8000ff"},
+		{"hsl(none none 50%)", "hsl(none none 50%)", "#808080"},
+		{"hwb(180 10% 20%)", "hwb(180 10% 20%)", "#1acccc"},
+		{"hwb(0 60% 60%)", "hwb(0 60% 60%)", "#808080"},
+		{"lab(50% 40 59.5)", "lab(50 40 59.5)", "#bf5700"},
+		{"lab(150 -100% 100%)", "lab(100 -125 125)", "#00ff00"},
+		{"lch(52.2345% 72.2 56.2)", "lch(52.2345 72.2 56.2)", "#c65d06"},
+		{"lch(50 -10 1rad)", "lch(50 0 57.29578)", "#777777"},
+		{"oklab(62.8% 0.22486 0.12585)", "oklab(0.628 0.22486 0.12585)", "#ff0000"},
+		{"oklch(62.8% 0.25768 29.234)", "oklch(0.628 0.25768 29.234)", "#ff0000"},
+		{"oklch(0.7 0.1 none / 25%)", "oklch(0.7 0.1 none / 0.25)", "#d2849c40"},
+		{"color(srgb 1 0.5 0)", "rgb(255 127.5 0)", "#ff8000"},
+		{"color(srgb-linear 1 0.5 0)", "color(srgb-linear 1 0.5 0)", "#ffbc00"},
+		{"color(display-p3 1 0 0)", "color(display-p3 1 0 0)", "#ff0000"},
+		{"color(display-p3 50% none 0 / .5)", "color(display-p3 0.5 none 0 / 0.5)", "#8c000080"},
+		{"color(a98-rgb 1 1 1)", "color(a98-rgb 1 1 1)", "#ffffff"},
+		{"color(prophoto-rgb 1 1 1)", "color(prophoto-rgb 1 1 1)", "#ffffff"},
+		{"color(rec2020 0 1 0)", "color(rec2020 0 1 0)", "#00ff00"},
+		{"color(xyz 0.5 0.5 0.5)", "color(xyz-d65 0.5 0.5 0.5)", "#ccb7b4"},
+		{"color(XYZ-D50 0.9642956764295677 1 0.8251046025104602)", "color(xyz-d50 0.964296 1 0.825105)", "#ffffff"},
+		{"color(srgb 1.5 -0.5 0)", "rgb(382.5 -127.5 0)", "#ff0000"},
+		{"color(srgb 1e308 -1e308 0)", "rgb(calc(infinity) calc(-infinity) 0)", "#ff0000"},
+		{"rgb(calc(infinity) 0 calc(-infinity))", "rgb(255 0 0)", "#ff0000"},
+		{"rgb(from red r g 255)", "rgb(255 0 255)", "#ff00ff"},
+		{"color-mix(in srgb, red, blue)", "rgb(127.5 0 127.5)", "#800080"},
+
+		// invalid
+		{"currentcolor", "", ""},
+		{"rgb(var(--r) 0 0)", "", ""},
+		{"rgb(255, 0 0)", "", ""},
+		{"rgb(255, 0%, 0)", "", ""},
+		{"rgb(none, 0, 0)", "", ""},
+		{"hsl(120, 100, 50)", "", ""},
+		{"hsl(120% 100% 50%)", "", ""},
+		{"hwb(120, 10%, 20%)", "", ""},
+		{"lab(50 1deg 0)", "", ""},
+		{"oklch(0.5 0.1 10 0.5)", "", ""},
+		{"rgb(1 2 3 / 1px)", "", ""},
+		{"color(hsl 0 0 0)", "", ""},
+		{"color(unknown 0 0 0)", "", ""},
+		{"color(srgb 1, 0, 0)", "", ""},
+		{"color(display-p3 1 0)", "", ""},
+		{"rgb(1 2 3) red", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color, func(t *testing.T) {
+			values, _ := css.ParseValue(parse.NewInputString(tt.color))
+			c, ok := Parse(values)
+			test.T(t, ok, tt.expected != "")
+			if ok {
+				test.String(t, c.String(), tt.expected)
+				test.String(t, c.SRGB().Hex(), tt.hex)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var tests = []struct {
+		color    string
+		space    Space
+		expected string
+	}{
+		{"red", OKLCH, "oklch(0.627955 0.257683 29.23388)"},
+		{"red", Lab, "lab(54.290543 80.80492 69.890988)"},
+		{"red", HWB, "hwb(0 0% 0%)"},
+		{"red", DisplayP3, "color(display-p3 0.917488 0.200287 0.138561)"},
+		{"red", XYZD65, "color(xyz-d65 0.412391 0.212639 0.019331)"},
+		{"color(display-p3 1 0 0)", SRGB, "rgb(278.731922 -57.819203 -38.284318)"},
+		{"color(rec2020 1 0 0)", XYZD65, "color(xyz-d65 0.636958 0.2627 0)"},
+		{"color(a98-rgb 1 0 0)", XYZD65, "color(xyz-d65 0.576669 0.297345 0.027031)"},
+		{"white", LCH, "lch(100.000001 0.00001 none)"},
+		{"gray", HSL, "hsl(none 0% 50.196078%)"},
+		{"gray", OKLCH, "oklch(0.599871 0 none)"},
+		{"oklch(0.7 0.1 none / none)", OKLab, "oklab(0.7 0.1 0 / none)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color+" to "+tt.space.String(), func(t *testing.T) {
+			c := parseColor(t, tt.color)
+			test.String(t, c.Convert(tt.space).String(), tt.expected)
+		})
+	}
+
+	// round trips
+	for space := SRGB; space <= OKLCH; space++ {
+		c := parseColor(t, "rgb(51 102 204)").Convert(space).Convert(SRGB)
+		test.String(t, c.String(), "rgb(51 102 204)", space.String())
+	}
+}
+
+func TestGamut(t *testing.T) {
+	var tests = []struct {
+		color    string
+		space    Space
+		inGamut  bool
+		expected string
+	}{
+		{"red", SRGB, true, "rgb(255 0 0)"},
+		{"hsl(120 100% 50%)", HSL, true, "hsl(120 100% 50%)"},
+		{"color(display-p3 1 0 0)", SRGB, true, "rgb(255 11.365227 11.712561)"},
+		{"color(display-p3 1 0 0)", HSL, true, "hsl(359.914462 100% 52.228476%)"},
+		{"color(rec2020 0 1 0)", DisplayP3, true, "color(display-p3 0 0.974072 0.374448)"},
+		{"color(srgb 1.01 -0.001 0)", SRGB, false, "rgb(255 0 0)"},
+		{"hsl(0 150% 50%)", HSL, false, "hsl(9.758299 100% 64.74285%)"},
+		{"lab(100 50 0)", SRGB, true, "rgb(255 255 255)"},
+		{"oklch(0 0.5 0)", SRGB, true, "rgb(0 0 0)"},
+		{"color(srgb 1e308 1e308 1e308)", SRGB, false, "rgb(255 255 255)"},
+		{"color(srgb -1e308 -1e308 -1e308)", SRGB, false, "rgb(0 0 0)"},
+		{"color(display-p3 1e308 1e308 1e308)", SRGB, false, "rgb(255 255 255)"},
+		{"oklch(0.5 0.4 200 / 0.5)", Lab, true, "lab(45.345821 -106.034546 -48.047977 / 0.5)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color+" to "+tt.space.String(), func(t *testing.T) {
+			c := parseColor(t, tt.color)
+			test.T(t, c.InGamut(), tt.inGamut)
+			mapped := c.MapToGamut(tt.space)
+			test.String(t, mapped.String(), tt.expected)
+			test.That(t, mapped.InGamut(), "must be in gamut")
+		})
+	}
+}
+
+func TestSpace(t *testing.T) {
+	for space := SRGB; space <= OKLCH; space++ {
+		s, ok := ParseSpace([]byte(space.String()))
+		test.That(t, ok, space.String())
+		test.T(t, s, space)
+	}
+	s, ok := ParseSpace([]byte("XYZ"))
+	test.That(t, ok)
+	test.T(t, s, XYZD65)
+	_, ok = ParseSpace([]byte("rgb"))
+	test.That(t, !ok)
+	test.String(t, Space(100).String(), "Invalid(100)")
+	test.That(t, OKLCH.IsPolar())
+	test.That(t, !OKLab.IsPolar())
+
+	c := Color{Space: Lab, C: [3]float64{50.0, None(), 0.0}, Alpha: 1.0}
+	test.That(t, c.IsNone(1))
+	test.That(t, !c.IsNone(0))
+	test.That(t, !c.IsNone(3))
+}
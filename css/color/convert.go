@@ -0,0 +1,52 @@
+package color
+
+import "github.com/politepixels/tdewolff-parse/v2/css/internal/colorspace"
+
+// component is the category of analogous components of color spaces, which carry missing components over when converting colors for interpolation, see https://www.w3.org/TR/css-color-4/#analogous-components.
+type component uint8
+
+const (
+	noComponent component = iota
+	redComponent
+	greenComponent
+	blueComponent
+	lightnessComponent
+	colorfulnessComponent
+	hueComponent
+	opponentAComponent
+	opponentBComponent
+)
+
+// space is a color space with the reference ranges of its percentages and its conversions to and from CIE XYZ with a D65 white point.
+type space struct {
+	name       string
+	scales     [3]float64 // reference range of percentages, zero for hues
+	hue        int        // index of the hue channel, or -1
+	components [3]component
+	toXYZ      func([3]float64) [3]float64
+	fromXYZ    func([3]float64) [3]float64
+}
+
+var (
+	rgbComponents = [3]component{redComponent, greenComponent, blueComponent}
+	labComponents = [3]component{lightnessComponent, opponentAComponent, opponentBComponent}
+	lchComponents = [3]component{lightnessComponent, colorfulnessComponent, hueComponent}
+)
+
+// spaces are the color spaces indexed by Space.
+var spaces = [...]space{
+	SRGB:        {"srgb", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.SRGBToXYZ, colorspace.XYZToSRGB},
+	SRGBLinear:  {"srgb-linear", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.LinearSRGBToXYZ, colorspace.XYZToLinearSRGB},
+	DisplayP3:   {"display-p3", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.DisplayP3ToXYZ, colorspace.XYZToDisplayP3},
+	A98RGB:      {"a98-rgb", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.A98RGBToXYZ, colorspace.XYZToA98RGB},
+	ProPhotoRGB: {"prophoto-rgb", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.ProPhotoRGBToXYZ, colorspace.XYZToProPhotoRGB},
+	Rec2020:     {"rec2020", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.Rec2020ToXYZ, colorspace.XYZToRec2020},
+	XYZD50:      {"xyz-d50", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.XYZD50ToXYZ, colorspace.XYZToXYZD50},
+	XYZD65:      {"xyz-d65", [3]float64{1.0, 1.0, 1.0}, -1, rgbComponents, colorspace.Identity, colorspace.Identity},
+	HSL:         {"hsl", [3]float64{0.0, 100.0, 100.0}, 0, [3]component{hueComponent, colorfulnessComponent, lightnessComponent}, colorspace.HSLToXYZ, colorspace.XYZToHSL},
+	HWB:         {"hwb", [3]float64{0.0, 100.0, 100.0}, 0, [3]component{hueComponent, noComponent, noComponent}, colorspace.HWBToXYZ, colorspace.XYZToHWB},
+	Lab:         {"lab", [3]float64{100.0, 125.0, 125.0}, -1, labComponents, colorspace.LabToXYZ, colorspace.XYZToLab},
+	LCH:         {"lch", [3]float64{100.0, 150.0, 0.0}, 2, lchComponents, colorspace.LCHToXYZ, colorspace.XYZToLCH},
+	OKLab:       {"oklab", [3]float64{1.0, 0.4, 0.4}, -1, labComponents, colorspace.OKLabToXYZ, colorspace.XYZToOKLab},
+	OKLCH:       {"oklch", [3]float64{1.0, 0.4, 0.0}, 2, lchComponents, colorspace.OKLCHToXYZ, colorspace.XYZToOKLCH},
+}
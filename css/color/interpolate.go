@@ -0,0 +1,132 @@
+package color
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css/internal/colorspace"
+)
+
+// HueInterpolation is a hue interpolation method, which determines in which direction hues are interpolated around the color wheel, see https://www.w3.org/TR/css-color-4/#hue-interpolation.
+type HueInterpolation uint8
+
+// HueInterpolation values.
+const (
+	ShorterHue HueInterpolation = iota // the default
+	LongerHue
+	IncreasingHue
+	DecreasingHue
+)
+
+var hueInterpolations = [...]string{"shorter", "longer", "increasing", "decreasing"}
+
+// String returns the keyword of the hue interpolation method, such as shorter.
+func (h HueInterpolation) String() string {
+	if int(h) < len(hueInterpolations) {
+		return hueInterpolations[h]
+	}
+	return "Invalid(" + strconv.Itoa(int(h)) + ")"
+}
+
+// ParseHueInterpolation returns the hue interpolation method of its case-insensitive keyword, such as longer for longer hue. It returns false if the keyword is not a hue interpolation method.
+func ParseHueInterpolation(b []byte) (HueInterpolation, bool) {
+	for i, name := range hueInterpolations {
+		if parse.EqualFold(b, []byte(name)) {
+			return HueInterpolation(i), true
+		}
+	}
+	return 0, false
+}
+
+// Interpolate interpolates between two colors in a color space at t in the range [0,1], where 0 returns a and 1 returns b, see https://www.w3.org/TR/css-color-4/#interpolation. Missing components of a color take the value of the other color and are carried over to analogous components when converting to the interpolation color space, such that the hue of an achromatic color does not affect the result. Colors are interpolated with premultiplied alpha, and hues are interpolated using the hue interpolation method.
+func Interpolate(a, b Color, space Space, hue HueInterpolation, t float64) Color {
+	p, q := a.carry(space), b.carry(space)
+	for i := 0; i < 3; i++ {
+		if math.IsNaN(p.C[i]) {
+			p.C[i] = q.C[i]
+		} else if math.IsNaN(q.C[i]) {
+			q.C[i] = p.C[i]
+		}
+	}
+	if math.IsNaN(p.Alpha) {
+		p.Alpha = q.Alpha
+	} else if math.IsNaN(q.Alpha) {
+		q.Alpha = p.Alpha
+	}
+	p.premultiply()
+	q.premultiply()
+
+	if h := spaces[space].hue; 0 <= h && !math.IsNaN(p.C[h]) && !math.IsNaN(q.C[h]) {
+		d := q.C[h] - p.C[h]
+		switch hue {
+		case ShorterHue:
+			if 180.0 < d {
+				p.C[h] += 360.0
+			} else if d < -180.0 {
+				q.C[h] += 360.0
+			}
+		case LongerHue:
+			if 0.0 < d && d < 180.0 {
+				p.C[h] += 360.0
+			} else if -180.0 < d && d <= 0.0 {
+				q.C[h] += 360.0
+			}
+		case IncreasingHue:
+			if d < 0.0 {
+				q.C[h] += 360.0
+			}
+		case DecreasingHue:
+			if 0.0 < d {
+				p.C[h] += 360.0
+			}
+		}
+	}
+
+	c := Color{Space: space, Alpha: p.Alpha + (q.Alpha-p.Alpha)*t}
+	for i := range c.C {
+		c.C[i] = p.C[i] + (q.C[i]-p.C[i])*t
+	}
+	if h := spaces[space].hue; 0 <= h {
+		c.C[h] = colorspace.NormalizeHue(c.C[h])
+	}
+	if !math.IsNaN(c.Alpha) && c.Alpha != 0.0 {
+		for i := range c.C {
+			if i != spaces[space].hue {
+				c.C[i] /= c.Alpha
+			}
+		}
+	}
+	return c
+}
+
+// carry converts the color to a color space for interpolation, where missing components of the color are missing in the analogous components of the color space.
+func (c Color) carry(space Space) Color {
+	d := c.Convert(space)
+	if c.Space == space {
+		return d
+	}
+	for i, comp := range spaces[c.Space].components {
+		if comp == noComponent || !math.IsNaN(c.C[i]) {
+			continue
+		}
+		for j, comp2 := range spaces[space].components {
+			if comp2 == comp {
+				d.C[j] = math.NaN()
+			}
+		}
+	}
+	return d
+}
+
+// premultiply multiplies the channels except the hue by alpha, where a missing alpha is one.
+func (c *Color) premultiply() {
+	if math.IsNaN(c.Alpha) {
+		return
+	}
+	for i := range c.C {
+		if i != spaces[c.Space].hue {
+			c.C[i] *= c.Alpha
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestInterpolate(t *testing.T) {
+	var tests = []struct {
+		a, b     string
+		space    Space
+		hue      HueInterpolation
+		t        float64
+		expected string
+	}{
+		{"red", "blue", SRGB, ShorterHue, 0.5, "rgb(127.5 0 127.5)"},
+		{"red", "blue", SRGB, ShorterHue, 0.0, "rgb(255 0 0)"},
+		{"red", "blue", SRGB, ShorterHue, 1.0, "rgb(0 0 255)"},
+		{"rgb(255 0 0 / 0.5)", "rgb(0 0 255)", SRGB, ShorterHue, 0.5, "rgb(85 0 170 / 0.75)"},
+		{"rgb(255 0 0 / 0)", "rgb(0 0 255 / 0)", SRGB, ShorterHue, 0.5, "rgb(0 0 0 / 0)"},
+
+		// hue interpolation methods
+		{"oklch(0.5 0.1 10)", "oklch(0.7 0.2 350)", OKLCH, ShorterHue, 0.5, "oklch(0.6 0.15 0)"},
+		{"oklch(0.5 0.1 10)", "oklch(0.7 0.2 350)", OKLCH, LongerHue, 0.5, "oklch(0.6 0.15 180)"},
+		{"oklch(0.5 0.1 10)", "oklch(0.7 0.2 350)", OKLCH, IncreasingHue, 0.5, "oklch(0.6 0.15 180)"},
+		{"oklch(0.5 0.1 10)", "oklch(0.7 0.2 350)", OKLCH, DecreasingHue, 0.5, "oklch(0.6 0.15 0)"},
+		{"hsl(30 50% 50%)", "hsl(90 50% 50%)", HSL, ShorterHue, 0.25, "hsl(45 50% 50%)"},
+		{"hsl(30 50% 50%)", "hsl(90 50% 50%)", HSL, LongerHue, 0.25, "hsl(315 50% 50%)"},
+		{"hsl(30 50% 50%)", "hsl(90 50% 50%)", HSL, IncreasingHue, 0.25, "hsl(45 50% 50%)"},
+		{"hsl(30 50% 50%)", "hsl(90 50% 50%)", HSL, DecreasingHue, 0.25, "hsl(315 50% 50%)"},
+		{"hsl(90 50% 50%)", "hsl(90 50% 50%)", HSL, LongerHue, 0.5, "hsl(270 50% 50%)"},
+
+		// missing components
+		{"oklch(none 0.1 120)", "oklch(0.6 none 180)", OKLCH, ShorterHue, 0.5, "oklch(0.6 0.1 150)"},
+		{"oklch(0.5 0.1 none)", "oklch(0.7 0.1 none)", OKLCH, ShorterHue, 0.5, "oklch(0.6 0.1 none)"},
+		{"rgb(0 0 0 / none)", "rgb(255 255 255 / 0.5)", SRGB, ShorterHue, 0.5, "rgb(127.5 127.5 127.5 / 0.5)"},
+		{"white", "blue", OKLCH, ShorterHue, 0.5, "oklch(0.726007 0.156607 264.052023)"},
+		{"hsl(none 50% 50%)", "lch(50 40 200)", LCH, ShorterHue, 0.5, "lch(48.210219 49.873196 200)"},
+		{"rgb(none 0 0)", "blue", OKLab, ShorterHue, 0.5, "oklab(0.226007 -0.016228 -0.155764)"},
+		{"color(srgb none 1 0)", "color(display-p3 1 0 0)", SRGBLinear, ShorterHue, 0.5, "color(srgb-linear 1.22494 0.478972 -0.009819)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b+" "+tt.space.String()+" "+tt.hue.String(), func(t *testing.T) {
+			a, b := parseColor(t, tt.a), parseColor(t, tt.b)
+			test.String(t, Interpolate(a, b, tt.space, tt.hue, tt.t).String(), tt.expected)
+		})
+	}
+}
+
+func TestHueInterpolation(t *testing.T) {
+	for _, hue := range []HueInterpolation{ShorterHue, LongerHue, IncreasingHue, DecreasingHue} {
+		h, ok := ParseHueInterpolation([]byte(hue.String()))
+		test.That(t, ok, hue.String())
+		test.T(t, h, hue)
+	}
+	h, ok := ParseHueInterpolation([]byte("LONGER"))
+	test.That(t, ok)
+	test.T(t, h, LongerHue)
+	_, ok = ParseHueInterpolation([]byte("hue"))
+	test.That(t, !ok)
+	test.String(t, HueInterpolation(10).String(), "Invalid(10)")
+}
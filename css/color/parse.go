@@ -0,0 +1,167 @@
+package color
+
+import (
+	"math"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+	"github.com/politepixels/tdewolff-parse/v2/css/internal/colorspace"
+)
+
+// functions maps color functions to their color space, where the channels of rgb() are stored in the range [0,1] of srgb.
+var functions = map[string]Space{
+	"rgb":   SRGB,
+	"rgba":  SRGB,
+	"hsl":   HSL,
+	"hsla":  HSL,
+	"hwb":   HWB,
+	"lab":   Lab,
+	"lch":   LCH,
+	"oklab": OKLab,
+	"oklch": OKLCH,
+}
+
+// Parse parses a color, such as the values of a declaration, into a Color in the color space of its function. It supports the rgb(), rgba(), hsl(), hsla(), hwb(), lab(), lch(), oklab(), oklch(), and color() functions with none components and math functions such as calc(), and hexadecimal and named colors which are in srgb. Relative colors and color-mix() are evaluated to srgb using css.EvalColor. It returns false if the color is invalid or not literal, such as currentcolor or colors that depend on var().
+func Parse(values []css.Token) (Color, bool) {
+	cf, ok := css.ParseColorFunction(values)
+	if !ok {
+		return evalColor(values)
+	}
+
+	var space Space
+	if cf.Space != nil {
+		if space, ok = ParseSpace(cf.Space); !ok || XYZD65 < space {
+			return Color{}, false
+		}
+	} else {
+		space = functions[string(cf.Function)]
+	}
+
+	c := Color{Space: space, Alpha: 1.0}
+	units := [3]parse.Unit{}
+	for i := 0; i < 3; i++ {
+		if c.C[i], units[i], ok = c.channel(cf.Function, i, cf.Channels[i]); !ok {
+			return Color{}, false
+		}
+	}
+	if cf.Alpha != nil {
+		if c.Alpha, ok = alpha(cf.Alpha); !ok {
+			return Color{}, false
+		}
+	}
+	if cf.Legacy && !c.validLegacy(units) {
+		return Color{}, false
+	}
+	c.clamp(cf.Function)
+	return c, true
+}
+
+// validLegacy returns true if the channels of the legacy syntax of rgb() and hsl() are valid, where math functions may not evaluate to none and the channels of rgb() are either all numbers or all percentages.
+func (c *Color) validLegacy(units [3]parse.Unit) bool {
+	if math.IsNaN(c.C[0]) || math.IsNaN(c.C[1]) || math.IsNaN(c.C[2]) || math.IsNaN(c.Alpha) {
+		return false
+	}
+	return c.Space == SRGB && units[0] == units[1] && units[1] == units[2] || c.Space == HSL && units[1] == parse.Percent && units[2] == parse.Percent
+}
+
+// channel parses the channel at index i of a color function, which is a number, a percentage relative to the reference range of the channel, an angle for hues, or none. It returns the unit of the channel, which is NoUnit for numbers and none.
+func (c *Color) channel(name []byte, i int, comp []css.Token) (float64, parse.Unit, bool) {
+	if isNone(comp) {
+		return math.NaN(), parse.NoUnit, true
+	}
+	v, unit, ok := number(comp)
+	if !ok {
+		return 0.0, parse.NoUnit, false
+	}
+	if i == spaces[c.Space].hue {
+		if unit.Category() == parse.AngleCategory {
+			v *= unit.Factor()
+		} else if unit != parse.NoUnit {
+			return 0.0, parse.NoUnit, false
+		}
+		return colorspace.NormalizeHue(v), unit, true
+	} else if unit == parse.Percent {
+		v = v / 100.0 * spaces[c.Space].scales[i]
+	} else if unit != parse.NoUnit {
+		return 0.0, parse.NoUnit, false
+	} else if c.Space == SRGB && string(name) != "color" {
+		v /= 255.0
+	}
+	return v, unit, true
+}
+
+// clamp clamps the channels and alpha to their valid range, see https://www.w3.org/TR/css-color-4/#rgb-functions and the sections of the other functions. The channels of color() are not clamped.
+func (c *Color) clamp(name []byte) {
+	if !math.IsNaN(c.Alpha) {
+		c.Alpha = math.Max(0.0, math.Min(1.0, c.Alpha))
+	}
+	if string(name) == "color" {
+		return
+	}
+	clamp := func(i int, min, max float64) {
+		if !math.IsNaN(c.C[i]) {
+			c.C[i] = math.Max(min, math.Min(max, c.C[i]))
+		}
+	}
+	switch c.Space {
+	case SRGB:
+		for i := 0; i < 3; i++ {
+			clamp(i, 0.0, 1.0)
+		}
+	case HSL, HWB:
+		clamp(1, 0.0, math.Inf(1))
+		clamp(2, 0.0, math.Inf(1))
+	case Lab, LCH, OKLab, OKLCH:
+		clamp(0, 0.0, spaces[c.Space].scales[0])
+		if c.Space == LCH || c.Space == OKLCH {
+			clamp(1, 0.0, math.Inf(1))
+		}
+	}
+}
+
+// alpha parses an alpha value, which is a number, a percentage, or none.
+func alpha(comp []css.Token) (float64, bool) {
+	if isNone(comp) {
+		return math.NaN(), true
+	}
+	v, unit, ok := number(comp)
+	if !ok || unit != parse.NoUnit && unit != parse.Percent {
+		return 0.0, false
+	} else if unit == parse.Percent {
+		v /= 100.0
+	}
+	return v, true
+}
+
+// number parses a number, percentage, or dimension, or a math function such as calc() that evaluates to one, including calc(infinity) and calc(-infinity) as written by Color.String.
+func number(comp []css.Token) (float64, parse.Unit, bool) {
+	n, ok := css.ParseMathExpr(comp)
+	if !ok {
+		return 0.0, parse.NoUnit, false
+	}
+	if n = n.Fold(); n.Type == css.MathFunction && len(n.Args) == 1 && n.Args[0].Type == css.MathConstant {
+		// Fold keeps infinite constants as written
+		if name := n.Args[0].Name; parse.EqualFold(name, []byte("infinity")) {
+			return math.Inf(1), parse.NoUnit, true
+		} else if parse.EqualFold(name, []byte("-infinity")) {
+			return math.Inf(-1), parse.NoUnit, true
+		}
+	}
+	if n.Type != css.MathNumber {
+		return 0.0, parse.NoUnit, false
+	}
+	return n.Value, n.Unit, true
+}
+
+// evalColor evaluates a color to srgb.
+func evalColor(values []css.Token) (Color, bool) {
+	rgb, ok := css.EvalColor(values)
+	if !ok {
+		return Color{}, false
+	}
+	return Color{Space: SRGB, C: [3]float64{rgb.R, rgb.G, rgb.B}, Alpha: rgb.A}, true
+}
+
+func isNone(comp []css.Token) bool {
+	return len(comp) == 1 && comp[0].TokenType == css.IdentToken && parse.EqualFold(comp[0].Data, []byte("none"))
+}
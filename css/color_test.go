@@ -0,0 +1,246 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseRelativeColor(t *testing.T) {
+	var tests = []struct {
+		css      string
+		function string
+		from     string
+		channels string
+		alpha    string
+	}{
+		{"rgb(from red r g b)", "rgb", "red", "r g b", ""},
+		{"RGB(from #fff r calc(g / 2) b / 50%)", "rgb", "#fff", "r calc(g/2) b", "50%"},
+		{"hsl(from var(--c) h s calc(l + 10))", "hsl", "var(--c)", "h s calc(l + 10)", ""},
+		{"oklch(from color-mix(in srgb, red, blue) l c h / alpha)", "oklch", "color-mix(in srgb,red,blue)", "l c h", "alpha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			rc, ok := ParseRelativeColor(values)
+			test.That(t, ok)
+			test.String(t, string(rc.Function), tt.function, "function")
+			test.String(t, valuesString(rc.From), tt.from, "from")
+			channels := valuesString(rc.Channels[0]) + " " + valuesString(rc.Channels[1]) + " " + valuesString(rc.Channels[2])
+			test.String(t, channels, tt.channels, "channels")
+			test.String(t, valuesString(rc.Alpha), tt.alpha, "alpha")
+		})
+	}
+
+	var errorTests = []string{
+		"red",
+		"rgb(255 0 0)",
+		"rgb(from red r g)",
+		"rgb(from red r, g, b)",
+		"rgb(from red r g b / alpha / 1)",
+		"rgb(from red r / g b)",
+		"color(from red srgb r g b)",
+	}
+	for _, css := range errorTests {
+		t.Run(css, func(t *testing.T) {
+			values, _ := ParseValue(parse.NewInputString(css))
+			_, ok := ParseRelativeColor(values)
+			test.That(t, !ok)
+		})
+	}
+}
+
+func TestParseColorFunction(t *testing.T) {
+	var tests = []struct {
+		css      string
+		function string
+		space    string
+		channels string
+		alpha    string
+		legacy   bool
+	}{
+		{"rgb(255 0 calc(1 + 2))", "rgb", "", "255 0 calc(1 + 2)", "", false},
+		{"HSLA(120, 50%, 50%, .5)", "hsla", "", "120 50% 50%", ".5", true},
+		{"oklch(0.5 none 120deg / 50%)", "oklch", "", "0.5 none 120deg", "50%", false},
+		{"color(Display-P3 1 0 0 / 0.5)", "color", "display-p3", "1 0 0", "0.5", false},
+		{"rgb(255/**/0 0)", "rgb", "", "255 0 0", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			cf, ok := ParseColorFunction(values)
+			test.That(t, ok)
+			test.String(t, string(cf.Function), tt.function, "function")
+			test.String(t, string(cf.Space), tt.space, "space")
+			channels := valuesString(cf.Channels[0]) + " " + valuesString(cf.Channels[1]) + " " + valuesString(cf.Channels[2])
+			test.String(t, channels, tt.channels, "channels")
+			test.String(t, valuesString(cf.Alpha), tt.alpha, "alpha")
+			test.T(t, cf.Legacy, tt.legacy, "legacy")
+		})
+	}
+
+	var errorTests = []string{
+		"red",
+		"rgb(from red r g b)",
+		"color-mix(in srgb, red, blue)",
+		"rgb(1 2)",
+		"rgb(1, 2 3)",
+		"rgb(1 2 3 4)",
+		"rgb(1 2 / 3)",
+		"rgb(1 2 3 / 4 / 5)",
+		"rgb(1, 2, none)",
+		"lab(1, 2, 3)",
+		"color(1 2 3)",
+		"color(srgb 1, 2, 3)",
+		"foo(1 2 3)",
+	}
+	for _, css := range errorTests {
+		t.Run(css, func(t *testing.T) {
+			values, _ := ParseValue(parse.NewInputString(css))
+			_, ok := ParseColorFunction(values)
+			test.That(t, !ok)
+		})
+	}
+}
+
+func TestParseColorMix(t *testing.T) {
+	var tests = []struct {
+		css         string
+		space       string
+		hue         string
+		colors      [2]string
+		percentages [2]string
+	}{
+		{"color-mix(in srgb, red, blue)", "srgb", "", [2]string{"red", "blue"}, [2]string{"", ""}},
+		{"color-mix(in OKLCH longer hue, red 40%, 10% var(--c))", "oklch", "longer", [2]string{"red", "var(--c)"}, [2]string{"40%", "10%"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			cm, ok := ParseColorMix(values)
+			test.That(t, ok)
+			test.String(t, string(cm.Space), tt.space, "space")
+			test.String(t, string(cm.Hue), tt.hue, "hue")
+			for i := range cm.Colors {
+				test.String(t, valuesString(cm.Colors[i]), tt.colors[i], "color")
+				test.String(t, valuesString(cm.Percentages[i]), tt.percentages[i], "percentage")
+			}
+		})
+	}
+
+	var errorTests = []string{
+		"color-mix(in srgb, red)",
+		"color-mix(srgb, red, blue)",
+		"color-mix(in srgb, red, blue, green)",
+		"color-mix(in hsl shorter, red, blue)",
+		"color-mix(in hsl sideways hue, red, blue)",
+		"color-mix(in srgb, red 10% 20%, blue)",
+	}
+	for _, css := range errorTests {
+		t.Run(css, func(t *testing.T) {
+			values, _ := ParseValue(parse.NewInputString(css))
+			_, ok := ParseColorMix(values)
+			test.That(t, !ok)
+		})
+	}
+}
+
+func TestEvalColor(t *testing.T) {
+	var tests = []struct {
+		css      string
+		expected string
+	}{
+		{"red", "#ff0000"},
+		{"RebeccaPurple", "#663399"},
+		{"transparent", "#00000000"},
+		{"#abc", "#aabbcc"},
+		{"#abcd", "#aabbccdd"},
+		{"#A0B0C0", "#a0b0c0"},
+		{"#a0b0c080", "#a0b0c080"},
+		{"rgb(255, 0, 0)", "#ff0000"},
+		{"rgba(255, 0, 0, 0.5)", "#ff000080"},
+		{"rgb(100% 50% 0% / 50%)", "#ff800080"},
+		{"rgb(300 none -10)", "#ff0000"},
+		{"rgb(calc(100 + 28) 0 0)", "#800000"},
+		{"rgb(min(128, 200) 0 clamp(0%, 75%, 50%))", "#800080"},
+		{"hsl(calc(0.25turn + 30deg) 100 50)", "#00ff00"},
+		{"hsl(120, 100%, 50%)", "#00ff00"},
+		{"hsl(0.5turn 100 25)", "#008080"},
+		{"hwb(0 0% 0%)", "#ff0000"},
+		{"hwb(0 60% 60%)", "#808080"},
+		{"lab(54.29 80.82 69.91)", "#ff0000"},
+		{"lch(54.29 106.84 40.85)", "#ff0000"},
+		{"oklab(62.8% 0.2249 0.1258)", "#ff0000"},
+		{"oklch(0.628 0.2577 29.23deg)", "#ff0000"},
+
+		// relative color syntax
+		{"rgb(from red r g b)", "#ff0000"},
+		{"rgb(from red b g r)", "#0000ff"},
+		{"rgb(from #123456 calc(r * 2) g b / calc(alpha / 2))", "#24345680"},
+		{"rgb(from rgb(from red 0 0 r) r g b / 25%)", "#0000ff40"},
+		{"hsl(from red calc(h + 120) s l)", "#00ff00"},
+		{"hsl(from hsl(120 100 25) h s calc(l * 2))", "#00ff00"},
+		{"hwb(from white h w b)", "#ffffff"},
+		{"oklch(from red l c h)", "#ff0000"},
+		{"lch(from blue l 0 h)", "#464646"},
+
+		// color-mix()
+		{"color-mix(in srgb, red, blue)", "#800080"},
+		{"color-mix(in srgb, red 25%, blue)", "#4000bf"},
+		{"color-mix(in srgb, 25% red, blue 25%)", "#80008080"},
+		{"color-mix(in srgb, red 150%, blue)", ""},
+		{"color-mix(in srgb, red 0%, blue 0%)", ""},
+		{"color-mix(in srgb, transparent, blue)", "#0000ff80"},
+		{"color-mix(in srgb-linear, red, blue)", "#bc00bc"},
+		{"color-mix(in hsl, red, blue)", "#ff00ff"},
+		{"color-mix(in hsl longer hue, red, blue)", "#00ff00"},
+		{"color-mix(in hsl increasing hue, red, blue)", "#00ff00"},
+		{"color-mix(in hsl decreasing hue, red, blue)", "#ff00ff"},
+		{"color-mix(in hsl, white, blue)", "#9f9fdf"},
+		{"color-mix(in hwb, red, lime)", "#ffff00"},
+		{"color-mix(in lab, white, black)", "#777777"},
+		{"color-mix(in oklab, white, black)", "#636363"},
+		{"color-mix(in xyz, white, black)", "#bcbcbc"},
+		{"color-mix(in rgb, red, blue)", ""},
+		{"color-mix(in srgb longer hue, red, blue)", ""},
+		{"color-mix(in srgb, red, currentcolor)", ""},
+
+		// not literal or invalid
+		{"currentcolor", ""},
+		{"var(--color)", ""},
+		{"rgb(var(--r) 0 0)", ""},
+		{"rgb(from var(--c) r g b)", ""},
+		{"#abcde", ""},
+		{"#ggg", ""},
+		{"unknown", ""},
+		{"rgb(1, 2)", ""},
+		{"rgb(1 2 3, 4)", ""},
+		{"rgb(1, 2, none)", ""},
+		{"rgb(1 2 3) red", ""},
+		{"rgb(1deg 0 0)", ""},
+		{"rgb(calc(1 / 0) 0 0)", ""},
+		{"rgb(calc(1+ 2) 0 0)", ""},
+		{"rgb(calc(50% + 10) 0 0)", ""},
+		{"rgb((1 + 2) 0 0)", ""},
+		{"rgb(calc(1px) 0 0)", ""},
+		{"hsl(1px 0 0)", ""},
+		{"lab(50, 0, 0)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			color, ok := EvalColor(values)
+			if tt.expected == "" {
+				test.That(t, !ok, "must fail")
+			} else {
+				test.That(t, ok, "must succeed")
+				test.String(t, color.Hex(), tt.expected)
+			}
+		})
+	}
+}
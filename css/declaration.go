@@ -0,0 +1,170 @@
+package css
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var importantBytes = []byte("important")
+
+// Declaration is a declaration in a declaration block.
+type Declaration struct {
+	Property   []byte  // lowercase property name, or the custom property name as written
+	Values     []Token // values without !important
+	Important  bool
+	Start, End int // byte offsets in the input, excluding the terminating semicolon
+
+	parent Node
+}
+
+// ParseDeclarations parses a declaration block, such as an inline style attribute or the contents of a ruleset between the braces. Declarations with parse errors are skipped since they have no effect, as are the declarations of nested at-rules.
+func ParseDeclarations(r *parse.Input) ([]Declaration, error) {
+	p := NewParser(r, true)
+	decls := []Declaration{}
+	prevEnd := 0
+	level := 0
+	for {
+		gt, _, data := p.Next()
+		end := p.Offset()
+		if gt == ErrorGrammar && !p.HasParseError() {
+			if err := p.Err(); err != io.EOF {
+				return nil, err
+			}
+			return decls, nil
+		} else if gt == BeginAtRuleGrammar || gt == BeginRulesetGrammar {
+			level++
+		} else if gt == EndAtRuleGrammar || gt == EndRulesetGrammar {
+			level--
+		} else if level == 0 && (gt == DeclarationGrammar || gt == CustomPropertyGrammar) {
+			decl := Declaration{
+				Property: data,
+				Values:   append([]Token{}, p.Values()...),
+				Start:    skipDeclarationPrefix(r.Bytes(), prevEnd),
+				End:      trimDeclarationSuffix(r.Bytes(), end),
+			}
+			if gt == DeclarationGrammar {
+				decl.Values, decl.Important = splitImportant(decl.Values)
+			}
+			decls = append(decls, decl)
+		}
+		prevEnd = end
+	}
+}
+
+// skipDeclarationPrefix skips whitespace, comments, and semicolons before a declaration.
+func skipDeclarationPrefix(b []byte, i int) int {
+	for i < len(b) {
+		if parse.IsWhitespace(b[i]) || b[i] == ';' {
+			i++
+		} else if b[i] == '/' && i+1 < len(b) && b[i+1] == '*' {
+			if j := bytes.Index(b[i+2:], []byte("*/")); j != -1 {
+				i += 2 + j + 2
+			} else {
+				return len(b)
+			}
+		} else {
+			break
+		}
+	}
+	return i
+}
+
+// trimDeclarationSuffix trims the terminating semicolon or closing brace and whitespace after a declaration.
+func trimDeclarationSuffix(b []byte, i int) int {
+	if 0 < i && (b[i-1] == ';' || b[i-1] == '}') {
+		i--
+	}
+	for 0 < i && parse.IsWhitespace(b[i-1]) {
+		i--
+	}
+	return i
+}
+
+////////////////////////////////////////////////////////////////
+
+// shorthands are the longhand properties that are reset by a shorthand property.
+var shorthands = map[string][]string{
+	"background":      {"background-color", "background-image", "background-repeat", "background-attachment", "background-position", "background-size", "background-origin", "background-clip"},
+	"border":          {"border-width", "border-style", "border-color", "border-top", "border-right", "border-bottom", "border-left", "border-top-width", "border-right-width", "border-bottom-width", "border-left-width", "border-top-style", "border-right-style", "border-bottom-style", "border-left-style", "border-top-color", "border-right-color", "border-bottom-color", "border-left-color"},
+	"border-width":    {"border-top-width", "border-right-width", "border-bottom-width", "border-left-width"},
+	"border-style":    {"border-top-style", "border-right-style", "border-bottom-style", "border-left-style"},
+	"border-color":    {"border-top-color", "border-right-color", "border-bottom-color", "border-left-color"},
+	"border-top":      {"border-top-width", "border-top-style", "border-top-color"},
+	"border-right":    {"border-right-width", "border-right-style", "border-right-color"},
+	"border-bottom":   {"border-bottom-width", "border-bottom-style", "border-bottom-color"},
+	"border-left":     {"border-left-width", "border-left-style", "border-left-color"},
+	"border-radius":   {"border-top-left-radius", "border-top-right-radius", "border-bottom-right-radius", "border-bottom-left-radius"},
+	"flex":            {"flex-grow", "flex-shrink", "flex-basis"},
+	"flex-flow":       {"flex-direction", "flex-wrap"},
+	"font":            {"font-style", "font-variant", "font-weight", "font-stretch", "font-size", "line-height", "font-family"},
+	"gap":             {"row-gap", "column-gap"},
+	"grid-area":       {"grid-row-start", "grid-column-start", "grid-row-end", "grid-column-end"},
+	"grid-column":     {"grid-column-start", "grid-column-end"},
+	"grid-row":        {"grid-row-start", "grid-row-end"},
+	"inset":           {"top", "right", "bottom", "left"},
+	"list-style":      {"list-style-type", "list-style-position", "list-style-image"},
+	"margin":          {"margin-top", "margin-right", "margin-bottom", "margin-left"},
+	"outline":         {"outline-color", "outline-style", "outline-width"},
+	"overflow":        {"overflow-x", "overflow-y"},
+	"padding":         {"padding-top", "padding-right", "padding-bottom", "padding-left"},
+	"text-decoration": {"text-decoration-line", "text-decoration-style", "text-decoration-color", "text-decoration-thickness"},
+	"transition":      {"transition-property", "transition-duration", "transition-timing-function", "transition-delay"},
+}
+
+// Override is a declaration that has no effect since another declaration in the same block overrides it.
+type Override struct {
+	Loser, Winner int  // indices of the declarations
+	Shorthand     bool // the winner is a shorthand property that resets the property of the loser, such as margin for margin-top
+	Equal         bool // the winner has the same property, value, and importance as the loser
+}
+
+// Overrides returns the declarations that are overridden by a later declaration of the same property or of a shorthand property, or by any such declaration with !important, in the order of the losing declarations. Vendor-prefixed values are often used as fallbacks for older browsers, such as display: -webkit-box followed by display: flex, so that overridden declarations with different values are not necessarily dead code.
+func Overrides(decls []Declaration) []Override {
+	overrides := []Override{}
+	for i, decl := range decls {
+		winner := i
+		for j, other := range decls {
+			if j == i || !bytes.Equal(other.Property, decl.Property) && !isLonghand(decl.Property, other.Property) {
+				continue
+			}
+			// importance takes precedence over order
+			if other.Important && !decls[winner].Important || other.Important == decls[winner].Important && winner < j {
+				winner = j
+			}
+		}
+		if winner != i {
+			shorthand := !bytes.Equal(decls[winner].Property, decl.Property)
+			overrides = append(overrides, Override{
+				Loser:     i,
+				Winner:    winner,
+				Shorthand: shorthand,
+				Equal:     !shorthand && decls[winner].Important == decl.Important && equalValues(decls[winner].Values, decl.Values),
+			})
+		}
+	}
+	return overrides
+}
+
+// isLonghand returns true if the property is reset by the shorthand property.
+func isLonghand(property, shorthand []byte) bool {
+	for _, longhand := range shorthands[string(shorthand)] {
+		if longhand == string(property) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a, b []Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].TokenType != b[i].TokenType || !bytes.Equal(a[i].Data, b[i].Data) {
+			return false
+		}
+	}
+	return true
+}
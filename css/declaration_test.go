@@ -0,0 +1,70 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseDeclarations(t *testing.T) {
+	css := " color: red ;/* c */ Margin:0 !IMPORTANT; --x: { a } ; bad ; width:1px}"
+	decls, err := ParseDeclarations(parse.NewInputString(css))
+	test.Error(t, err)
+	test.T(t, len(decls), 4)
+
+	test.String(t, string(decls[0].Property), "color")
+	test.String(t, css[decls[0].Start:decls[0].End], "color: red")
+	test.T(t, decls[0].Important, false)
+
+	test.String(t, string(decls[1].Property), "margin")
+	test.String(t, css[decls[1].Start:decls[1].End], "Margin:0 !IMPORTANT")
+	test.String(t, valuesString(decls[1].Values), "0")
+	test.T(t, decls[1].Important, true)
+
+	test.String(t, string(decls[2].Property), "--x")
+	test.String(t, css[decls[2].Start:decls[2].End], "--x: { a }")
+
+	test.String(t, string(decls[3].Property), "width")
+	test.String(t, css[decls[3].Start:decls[3].End], "width:1px")
+}
+
+func TestOverrides(t *testing.T) {
+	var overrideTests = []struct {
+		css       string
+		overrides string
+	}{
+		{"color:red; margin:0", ""},
+		{"color:red; color:blue", "color:red<color:blue"},
+		{"color:red; color:red", "color:red<color:red(equal)"},
+		{"color:red; color:blue; color:green", "color:red<color:green color:blue<color:green"},
+		{"color:red!important; color:blue", "color:blue<color:red!important"},
+		{"color:red!important; color:red", "color:red<color:red!important"},
+		{"color:red!important; color:blue!important", "color:red!important<color:blue!important"},
+		{"margin-top:1px; margin:0", "margin-top:1px<margin:0(shorthand)"},
+		{"margin:0; margin-top:1px", ""},
+		{"margin:0!important; margin-top:1px", "margin-top:1px<margin:0!important(shorthand)"},
+		{"border-top-color:red; border:none; border-top:0", "border-top-color:red<border-top:0(shorthand)"},
+		{"display:-webkit-box; display:flex", "display:-webkit-box<display:flex"},
+		{"--x:1; --X:2; --x:3", "--x:1<--x:3"},
+	}
+	for _, tt := range overrideTests {
+		t.Run(tt.css, func(t *testing.T) {
+			decls, err := ParseDeclarations(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			overrides := []string{}
+			for _, override := range Overrides(decls) {
+				loser, winner := decls[override.Loser], decls[override.Winner]
+				s := tt.css[loser.Start:loser.End] + "<" + tt.css[winner.Start:winner.End]
+				if override.Shorthand {
+					s += "(shorthand)"
+				} else if override.Equal {
+					s += "(equal)"
+				}
+				overrides = append(overrides, strings.Replace(s, " ", "", -1))
+			}
+			test.String(t, strings.Join(overrides, " "), tt.overrides)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package css
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// DirectiveType is the type of a comment directive.
+type DirectiveType uint32
+
+// DirectiveType values.
+const (
+	NoDirective        DirectiveType = iota
+	SourceMapDirective               // /*# sourceMappingURL=url */
+	SourceURLDirective               // /*# sourceURL=url */
+	LicenseDirective                 // /*! text */, or a comment containing @license or @preserve
+	LintDirective                    // /* stylelint-disable rules */ and the like, or /* csslint allow: rules */
+)
+
+// String returns the string representation of a DirectiveType.
+func (t DirectiveType) String() string {
+	switch t {
+	case NoDirective:
+		return "None"
+	case SourceMapDirective:
+		return "SourceMap"
+	case SourceURLDirective:
+		return "SourceURL"
+	case LicenseDirective:
+		return "License"
+	case LintDirective:
+		return "Lint"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Directive is a comment that instructs tools, such as a source map reference that minifiers must rewrite or drop, a license that must be preserved, or a pragma that disables lint rules.
+type Directive struct {
+	Type  DirectiveType
+	Name  []byte   // sourceMappingURL, sourceURL, the lint command such as stylelint-disable-next-line or csslint, or nil for licenses
+	Value []byte   // URL, license text, or the arguments of the lint command
+	Rules [][]byte // rule names of a lint directive, nil if it applies to all rules
+}
+
+var (
+	sourceMappingURLBytes = []byte("sourceMappingURL")
+	sourceURLBytes        = []byte("sourceURL")
+	licenseBytes          = []byte("@license")
+	preserveBytes         = []byte("@preserve")
+	stylelintBytes        = []byte("stylelint-")
+	csslintBytes          = []byte("csslint")
+	allowBytes            = []byte("allow:")
+	descriptionBytes      = []byte("--")
+)
+
+var stylelintCommands = map[string]bool{
+	"stylelint-disable":           true,
+	"stylelint-enable":            true,
+	"stylelint-disable-line":      true,
+	"stylelint-disable-next-line": true,
+}
+
+// ParseDirective parses the data of a CommentToken, including the /* and */ delimiters, and returns its directive. It returns false for comments that are not directives. Source map comments may also start with the legacy /*@ prefix. The rules of stylelint directives are separated by commas and may be followed by a description after --, and those of csslint allow directives by commas or spaces.
+func ParseDirective(comment []byte) (Directive, bool) {
+	if len(comment) < 4 || comment[0] != '/' || comment[1] != '*' {
+		return Directive{}, false
+	}
+	body := comment[2:]
+	if 2 <= len(body) && body[len(body)-2] == '*' && body[len(body)-1] == '/' {
+		body = body[:len(body)-2]
+	}
+
+	if 0 < len(body) && (body[0] == '#' || body[0] == '@') {
+		arg := parse.TrimWhitespace(body[1:])
+		if i := bytes.IndexByte(arg, '='); i != -1 {
+			name, value := arg[:i], parse.TrimWhitespace(arg[i+1:])
+			if bytes.Equal(name, sourceMappingURLBytes) {
+				return Directive{Type: SourceMapDirective, Name: name, Value: value}, true
+			} else if bytes.Equal(name, sourceURLBytes) {
+				return Directive{Type: SourceURLDirective, Name: name, Value: value}, true
+			}
+		}
+	}
+	if 0 < len(body) && body[0] == '!' {
+		return Directive{Type: LicenseDirective, Value: parse.TrimWhitespace(body[1:])}, true
+	} else if bytes.Contains(body, licenseBytes) || bytes.Contains(body, preserveBytes) {
+		return Directive{Type: LicenseDirective, Value: parse.TrimWhitespace(body)}, true
+	}
+
+	body = parse.TrimWhitespace(body)
+	if bytes.HasPrefix(body, stylelintBytes) {
+		i := 0
+		for i < len(body) && !parse.IsWhitespace(body[i]) {
+			i++
+		}
+		if !stylelintCommands[string(body[:i])] {
+			return Directive{}, false
+		}
+		directive := Directive{Type: LintDirective, Name: body[:i], Value: parse.TrimWhitespace(body[i:])}
+		rules := directive.Value
+		if j := bytes.Index(rules, descriptionBytes); j != -1 {
+			rules = rules[:j]
+		}
+		for _, rule := range bytes.Split(rules, []byte(",")) {
+			if rule = parse.TrimWhitespace(rule); 0 < len(rule) {
+				directive.Rules = append(directive.Rules, rule)
+			}
+		}
+		return directive, true
+	} else if bytes.HasPrefix(body, csslintBytes) && (len(body) == len(csslintBytes) || parse.IsWhitespace(body[len(csslintBytes)])) {
+		directive := Directive{Type: LintDirective, Name: body[:len(csslintBytes)], Value: parse.TrimWhitespace(body[len(csslintBytes):])}
+		if bytes.HasPrefix(directive.Value, allowBytes) {
+			rules := directive.Value[len(allowBytes):]
+			for _, rule := range bytes.FieldsFunc(rules, func(r rune) bool { return r == ',' || r < 0x80 && parse.IsWhitespace(byte(r)) }) {
+				directive.Rules = append(directive.Rules, rule)
+			}
+		}
+		return directive, true
+	}
+	return Directive{}, false
+}
@@ -0,0 +1,48 @@
+package css
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseDirective(t *testing.T) {
+	var tests = []struct {
+		comment string
+		typ     DirectiveType
+		name    string
+		value   string
+		rules   string
+	}{
+		{"/*# sourceMappingURL=style.css.map */", SourceMapDirective, "sourceMappingURL", "style.css.map", ""},
+		{"/*@ sourceMappingURL=data:application/json;base64,e30= */", SourceMapDirective, "sourceMappingURL", "data:application/json;base64,e30=", ""},
+		{"/*# sourceURL=a.css*/", SourceURLDirective, "sourceURL", "a.css", ""},
+		{"/*! Bootstrap v5 | MIT */", LicenseDirective, "", "Bootstrap v5 | MIT", ""},
+		{"/**\n * @license MIT\n */", LicenseDirective, "", "*\n * @license MIT", ""},
+		{"/* @preserve */", LicenseDirective, "", "@preserve", ""},
+		{"/* stylelint-disable */", LintDirective, "stylelint-disable", "", ""},
+		{"/* stylelint-disable-next-line color-no-hex, unit-allowed-list -- legacy */", LintDirective, "stylelint-disable-next-line", "color-no-hex, unit-allowed-list -- legacy", "color-no-hex unit-allowed-list"},
+		{"/*stylelint-enable block-no-empty*/", LintDirective, "stylelint-enable", "block-no-empty", "block-no-empty"},
+		{"/* csslint allow: box-model, important */", LintDirective, "csslint", "allow: box-model, important", "box-model important"},
+		{"/* csslint ignore:start */", LintDirective, "csslint", "ignore:start", ""},
+		{"/* comment */", NoDirective, "", "", ""},
+		{"/*# sourceMappingURL */", NoDirective, "", "", ""},
+		{"/* stylelint-config */", NoDirective, "", "", ""},
+		{"/* csslinter */", NoDirective, "", "", ""},
+		{"// comment", NoDirective, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			directive, ok := ParseDirective([]byte(tt.comment))
+			test.T(t, ok, tt.typ != NoDirective)
+			test.T(t, directive.Type, tt.typ)
+			test.String(t, string(directive.Name), tt.name)
+			test.String(t, string(directive.Value), tt.value)
+			test.String(t, string(bytes.Join(directive.Rules, []byte(" "))), tt.rules)
+		})
+	}
+
+	test.T(t, LintDirective.String(), "Lint")
+	test.T(t, DirectiveType(100).String(), "Invalid(100)")
+}
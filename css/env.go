@@ -0,0 +1,117 @@
+package css
+
+import (
+	"bytes"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/strconv"
+)
+
+// VarFunction is a var() function, such as var(--gap, 8px), see https://www.w3.org/TR/css-variables-1/#using-variables.
+type VarFunction struct {
+	Name     []byte  // custom property name, such as --gap
+	Fallback []Token // fallback value without surrounding whitespace, nil if there is no comma
+}
+
+// EnvFunction is an env() function, such as env(safe-area-inset-top, 20px), see https://drafts.csswg.org/css-env-1/#env-function.
+type EnvFunction struct {
+	Name     []byte  // environment variable name, such as safe-area-inset-top
+	Indices  []int   // indices of a multi-dimensional variable, such as those of viewport-segment-width, nil if omitted
+	Fallback []Token // fallback value without surrounding whitespace, nil if there is no comma
+}
+
+// IsSafeAreaInset returns true for the safe-area-inset-* and safe-area-max-inset-* variables that describe the area of the viewport not obscured by the display shape or by system UI.
+func (env EnvFunction) IsSafeAreaInset() bool {
+	return bytes.HasPrefix(env.Name, []byte("safe-area-inset-")) || bytes.HasPrefix(env.Name, []byte("safe-area-max-inset-"))
+}
+
+// EnvReference is an env() function in a list of values, which spans the tokens from Start up to End, including the function token and the closing parenthesis.
+type EnvReference struct {
+	EnvFunction
+	Start, End int
+}
+
+// ParseVar parses a var() function, such as the values of a declaration. It returns false if the values are not a single var() function with a custom property name. The fallback may contain any tokens, including other var() functions.
+func ParseVar(values []Token) (VarFunction, bool) {
+	name, args, ok := colorFunction(values)
+	if !ok || string(name) != "var" {
+		return VarFunction{}, false
+	}
+	ref, fallback := splitSubstitution(args)
+	if len(ref) != 1 || ref[0].TokenType != CustomPropertyNameToken {
+		return VarFunction{}, false
+	}
+	return VarFunction{
+		Name:     ref[0].Data,
+		Fallback: fallback,
+	}, true
+}
+
+// ParseEnv parses an env() function, such as the values of a declaration. It returns false if the values are not a single env() function with an environment variable name optionally followed by non-negative integer indices.
+func ParseEnv(values []Token) (EnvFunction, bool) {
+	name, args, ok := colorFunction(values)
+	if !ok || string(name) != "env" {
+		return EnvFunction{}, false
+	}
+	ref, fallback := splitSubstitution(args)
+	comps := colorComponents(ref)
+	if len(comps) == 0 || len(comps[0]) != 1 || comps[0][0].TokenType != IdentToken {
+		return EnvFunction{}, false
+	}
+	env := EnvFunction{
+		Name:     comps[0][0].Data,
+		Fallback: fallback,
+	}
+	for _, comp := range comps[1:] {
+		if len(comp) != 1 || comp[0].TokenType != NumberToken {
+			return EnvFunction{}, false
+		}
+		i, n := strconv.ParseUint(comp[0].Data)
+		if n != len(comp[0].Data) {
+			return EnvFunction{}, false
+		}
+		env.Indices = append(env.Indices, int(i))
+	}
+	return env, true
+}
+
+// EnvReferences returns the env() functions in values in order of appearance, including those nested in other functions such as max() or in the fallback of var() and env(), so that uses of safe-area insets can be detected and rewritten. Invalid env() functions are skipped.
+func EnvReferences(values []Token) []EnvReference {
+	refs := []EnvReference{}
+	for i, t := range values {
+		if t.TokenType != FunctionToken || !parse.EqualFold(t.Data, []byte("env(")) {
+			continue
+		}
+		level, end := 0, len(values)
+		for j := i; j < len(values); j++ {
+			if values[j].TokenType == FunctionToken || values[j].TokenType == LeftParenthesisToken {
+				level++
+			} else if values[j].TokenType == RightParenthesisToken {
+				level--
+				if level == 0 {
+					end = j + 1
+					break
+				}
+			}
+		}
+		if env, ok := ParseEnv(values[i:end]); ok {
+			refs = append(refs, EnvReference{env, i, end})
+		}
+	}
+	return refs
+}
+
+// splitSubstitution splits the arguments of a var() or env() function at the first top-level comma into the reference and the fallback, both without surrounding whitespace. The fallback is nil if there is no comma.
+func splitSubstitution(args []Token) ([]Token, []Token) {
+	level := 0
+	for i, t := range args {
+		if t.TokenType == FunctionToken || t.TokenType == LeftParenthesisToken {
+			level++
+		} else if t.TokenType == RightParenthesisToken {
+			level--
+		} else if t.TokenType == CommaToken && level == 0 {
+			return trimColorWhitespace(args[:i]), trimColorWhitespace(args[i+1:])
+		}
+	}
+	return trimColorWhitespace(args), nil
+}
@@ -0,0 +1,113 @@
+package css
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseVar(t *testing.T) {
+	var tests = []struct {
+		css      string
+		name     string
+		fallback string
+	}{
+		{"var(--gap)", "--gap", "<nil>"},
+		{"VAR( --gap , 8px )", "--gap", "8px"},
+		{"var(--gap,)", "--gap", ""},
+		{"var(--a, var(--b, 1px 2px))", "--a", "var(--b,1px 2px)"},
+		{"var(--a, a, b)", "--a", "a,b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			v, ok := ParseVar(values)
+			test.That(t, ok)
+			test.String(t, string(v.Name), tt.name, "name")
+			test.String(t, fallbackString(v.Fallback), tt.fallback, "fallback")
+		})
+	}
+
+	var errorTests = []string{
+		"var(gap)",
+		"var(--a --b)",
+		"var(--a) 1px",
+		"env(--a)",
+	}
+	for _, tt := range errorTests {
+		t.Run(tt, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt))
+			test.Error(t, err)
+			_, ok := ParseVar(values)
+			test.That(t, !ok)
+		})
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	var tests = []struct {
+		css      string
+		name     string
+		indices  string
+		fallback string
+		safeArea bool
+	}{
+		{"env(safe-area-inset-top)", "safe-area-inset-top", "[]", "<nil>", true},
+		{"env( safe-area-inset-left , 20px )", "safe-area-inset-left", "[]", "20px", true},
+		{"env(safe-area-max-inset-bottom, var(--b, 0px))", "safe-area-max-inset-bottom", "[]", "var(--b,0px)", true},
+		{"env(viewport-segment-width 0 1, 100vw)", "viewport-segment-width", "[0 1]", "100vw", false},
+		{"env(titlebar-area-x,)", "titlebar-area-x", "[]", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			env, ok := ParseEnv(values)
+			test.That(t, ok)
+			test.String(t, string(env.Name), tt.name, "name")
+			test.String(t, fmt.Sprint(env.Indices), tt.indices, "indices")
+			test.String(t, fallbackString(env.Fallback), tt.fallback, "fallback")
+			test.T(t, env.IsSafeAreaInset(), tt.safeArea, "safe area")
+		})
+	}
+
+	var errorTests = []string{
+		"env()",
+		"env(--a)",
+		"env(a b)",
+		"env(a -1)",
+		"env(a 1.5)",
+		"env(a / 1)",
+		"var(--a)",
+	}
+	for _, tt := range errorTests {
+		t.Run(tt, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt))
+			test.Error(t, err)
+			_, ok := ParseEnv(values)
+			test.That(t, !ok)
+		})
+	}
+}
+
+func TestEnvReferences(t *testing.T) {
+	values, err := ParseValue(parse.NewInputString("max(env(safe-area-inset-left), 1em) var(--p, env(safe-area-inset-right, 0px)) env(1) env(x"))
+	test.Error(t, err)
+
+	refs := []string{}
+	for _, ref := range EnvReferences(values) {
+		refs = append(refs, fmt.Sprintf("%s %d-%d %s", ref.Name, ref.Start, ref.End, valuesString(values[ref.Start:ref.End])))
+	}
+	test.String(t, strings.Join(refs, "; "), "safe-area-inset-left 1-4 env(safe-area-inset-left); safe-area-inset-right 11-16 env(safe-area-inset-right,0px)")
+}
+
+func fallbackString(values []Token) string {
+	if values == nil {
+		return "<nil>"
+	}
+	return valuesString(values)
+}
@@ -0,0 +1,129 @@
+package css
+
+import (
+	"unicode/utf8"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Unescape returns the code points of an identifier or of the contents of a string with their escapes decoded, see https://www.w3.org/TR/css-syntax-3/#consume-escaped-code-point. Escaped newlines, which continue a string on the next line, are removed. It returns b itself if it contains no escapes.
+func Unescape(b []byte) []byte {
+	i := 0
+	for i < len(b) && b[i] != '\\' {
+		i++
+	}
+	if i == len(b) {
+		return b
+	}
+
+	dst := append(make([]byte, 0, len(b)), b[:i]...)
+	for i < len(b) {
+		if b[i] != '\\' {
+			dst = append(dst, b[i])
+			i++
+			continue
+		}
+		i++
+		if i == len(b) {
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+		} else if b[i] == '\n' || b[i] == '\f' {
+			i++
+		} else if b[i] == '\r' {
+			i++
+			if i < len(b) && b[i] == '\n' {
+				i++
+			}
+		} else if 0 <= hexDigit(b[i]) {
+			r := 0
+			for j := 0; j < 6 && i < len(b) && 0 <= hexDigit(b[i]); j++ {
+				r = r<<4 | hexDigit(b[i])
+				i++
+			}
+			if i < len(b) && parse.IsWhitespace(b[i]) {
+				if b[i] == '\r' && i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+				i++
+			}
+			if r == 0 || 0xD800 <= r && r <= 0xDFFF || utf8.MaxRune < r {
+				r = utf8.RuneError
+			}
+			dst = utf8.AppendRune(dst, rune(r))
+		} else {
+			_, n := utf8.DecodeRune(b[i:])
+			dst = append(dst, b[i:i+n]...)
+			i += n
+		}
+	}
+	return dst
+}
+
+// AppendIdent appends the code points of an identifier to b with the minimal escaping that makes it lex as a single identifier, so that any name, such as a class name that starts with a digit, can be written in a selector. A leading digit and control characters are written as hexadecimal escapes, such as \31 for 1, and other characters that are not allowed in identifiers, such as . and :, are escaped with a backslash. NULL is written as U+FFFD as it cannot be escaped. The ident must not be empty.
+func AppendIdent(b, ident []byte) []byte {
+	for i := 0; i < len(ident); {
+		c := ident[i]
+		if c == 0 {
+			b = utf8.AppendRune(b, utf8.RuneError)
+		} else if c < 0x20 || c == 0x7F || '0' <= c && c <= '9' && (i == 0 || i == 1 && ident[0] == '-') {
+			// whitespace that follows is escaped as well
+			b = appendHexEscape(b, c, i+1 == len(ident) || 0 <= hexDigit(ident[i+1]))
+		} else if c == '-' && len(ident) == 1 {
+			b = append(b, '\\', '-')
+		} else if 0x80 <= c {
+			_, n := utf8.DecodeRune(ident[i:])
+			b = append(b, ident[i:i+n]...)
+			i += n
+			continue
+		} else if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' {
+			b = append(b, c)
+		} else {
+			b = append(b, '\\', c)
+		}
+		i++
+	}
+	return b
+}
+
+// AppendString appends s as a string token to b with the minimal escaping, where quote is the quote character, or zero to use double quotes unless s contains more double than single quotes. Backslashes and the quote character are escaped with a backslash, and newlines with hexadecimal escapes such as \a. NULL is written as U+FFFD as it cannot be escaped.
+func AppendString(b, s []byte, quote byte) []byte {
+	if quote == 0 {
+		quote = '"'
+		n := 0
+		for _, c := range s {
+			if c == '"' {
+				n++
+			} else if c == '\'' {
+				n--
+			}
+		}
+		if 0 < n {
+			quote = '\''
+		}
+	}
+	b = append(b, quote)
+	for i, c := range s {
+		if c == 0 {
+			b = utf8.AppendRune(b, utf8.RuneError)
+		} else if c == '\n' || c == '\r' || c == '\f' {
+			b = appendHexEscape(b, c, i+1 < len(s) && (0 <= hexDigit(s[i+1]) || s[i+1] == ' ' || s[i+1] == '\t'))
+		} else if c == quote || c == '\\' {
+			b = append(b, '\\', c)
+		} else {
+			b = append(b, c)
+		}
+	}
+	return append(b, quote)
+}
+
+// appendHexEscape appends the hexadecimal escape of c, followed by a space to terminate it if the escape would otherwise be followed by a hexadecimal digit or whitespace.
+func appendHexEscape(b []byte, c byte, terminate bool) []byte {
+	b = append(b, '\\')
+	if 0x10 <= c {
+		b = append(b, "0123456789abcdef"[c>>4])
+	}
+	b = append(b, "0123456789abcdef"[c&15])
+	if terminate {
+		b = append(b, ' ')
+	}
+	return b
+}
@@ -0,0 +1,104 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestUnescape(t *testing.T) {
+	var tests = []struct {
+		b        string
+		expected string
+	}{
+		{"abc", "abc"},
+		{`\31 0`, "10"},
+		{`\31  0`, "1 0"},
+		{"\\31\t0", "10"},
+		{"\\31\r\n0", "10"},
+		{`\000031a`, "1a"},
+		{`\1F600`, "😀"},
+		{`\0`, "\uFFFD"},
+		{`\D800`, "\uFFFD"},
+		{`\110000`, "\uFFFD"},
+		{`a\:b\.c`, "a:b.c"},
+		{`\\`, `\`},
+		{`\`, "\uFFFD"},
+		{"a\\\nb", "ab"},
+		{"a\\\r\nb", "ab"},
+		{`\é`, "é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			test.String(t, string(Unescape([]byte(tt.b))), tt.expected)
+		})
+	}
+}
+
+func TestAppendIdent(t *testing.T) {
+	var tests = []struct {
+		ident    string
+		expected string
+	}{
+		{"abc", "abc"},
+		{"a-b_c9", "a-b_c9"},
+		{"--x", "--x"},
+		{"-", `\-`},
+		{"-a", "-a"},
+		{"1", `\31 `},
+		{"10", `\31 0`},
+		{"1x", `\31x`},
+		{"1 ", `\31\ `},
+		{"1\t", `\31\9 `},
+		{"-1", `-\31 `},
+		{"--1", "--1"},
+		{"a1", "a1"},
+		{"a.b:c/d", `a\.b\:c\/d`},
+		{"a b", `a\ b`},
+		{"@media", `\@media`},
+		{"\x01a", `\1 a`},
+		{"\x1fz", `\1fz`},
+		{"\x7f", `\7f `},
+		{"a\x00", "a\uFFFD"},
+		{"😀é", "😀é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ident, func(t *testing.T) {
+			ident := AppendIdent(nil, []byte(tt.ident))
+			test.String(t, string(ident), tt.expected)
+			test.That(t, IsIdent(ident), "must be an identifier")
+			if tt.ident != "a\x00" {
+				test.String(t, string(Unescape(ident)), tt.ident)
+			}
+		})
+	}
+}
+
+func TestAppendString(t *testing.T) {
+	var tests = []struct {
+		s        string
+		quote    byte
+		expected string
+	}{
+		{"abc", 0, `"abc"`},
+		{`a"b`, 0, `'a"b'`},
+		{`a"b'c'`, 0, `"a\"b'c'"`},
+		{`a"b`, '"', `"a\"b"`},
+		{`a\b`, 0, `"a\\b"`},
+		{"a\nb", 0, `"a\a b"`},
+		{"a\n b", 0, `"a\a  b"`},
+		{"a\nx", 0, `"a\ax"`},
+		{"a\n", 0, `"a\a"`},
+		{"a\r\f", 0, `"a\d\c"`},
+		{"\x00", 0, "\"\uFFFD\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			s := AppendString(nil, []byte(tt.s), tt.quote)
+			test.String(t, string(s), tt.expected)
+			if tt.s != "\x00" {
+				test.String(t, string(Unescape(s[1:len(s)-1])), tt.s)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package css
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/strconv"
+)
+
+// Fingerprint is a hash of a rule or declaration that is the same for rules and declarations that differ only in whitespace, comments, and equivalent serializations of their values.
+type Fingerprint uint64
+
+// RuleFingerprint is the fingerprint of a ruleset, or of an at-rule with declarations such as @font-face, see Fingerprints.
+type RuleFingerprint struct {
+	Fingerprint             // hash of the enclosing at-rules, the selector or at-rule prelude, and the declarations
+	Body        Fingerprint // hash of the declarations only, which is the same for rules that can be merged by combining their selectors
+
+	Start, End int // byte offsets of the rule in the input, from the start of its selector or at-keyword up to and including the closing brace
+}
+
+// DeclarationFingerprint returns the fingerprint of a declaration, which includes its property, values, and importance.
+func DeclarationFingerprint(decl Declaration) Fingerprint {
+	h := fnv.New64a()
+	h.Write(decl.Property)
+	h.Write([]byte{0})
+	h.Write(canonicalValues(nil, decl.Values))
+	if decl.Important {
+		h.Write([]byte("!important"))
+	}
+	return Fingerprint(h.Sum64())
+}
+
+// Fingerprints returns the fingerprints of all rulesets in a stylesheet, and of the at-rules that contain declarations, in the order of their closing braces, so that duplicate rules can be found across files. Rules only have the same fingerprint when they are nested in equivalent at-rules, such as @media queries. Values are compared after removing comments, collapsing whitespace, and normalizing numbers, units, hexadecimal colors, function names, quotes of strings, and URLs, but identifiers are case-sensitive as for animation names. The order of declarations is significant.
+func Fingerprints(r *parse.Input) ([]RuleFingerprint, error) {
+	type frame struct {
+		header   []byte
+		start    int
+		ruleset  bool
+		decls    []Fingerprint
+		hasDecls bool
+	}
+
+	fingerprints := []RuleFingerprint{}
+	stack := []frame{}
+	p := NewParser(r, false)
+	prevEnd := 0
+	for {
+		gt, _, data := p.Next()
+		end := p.Offset()
+		switch gt {
+		case ErrorGrammar:
+			if !p.HasParseError() {
+				if err := p.Err(); err != io.EOF {
+					return nil, err
+				}
+				return fingerprints, nil
+			}
+		case BeginAtRuleGrammar, BeginRulesetGrammar:
+			header := []byte{}
+			if gt == BeginAtRuleGrammar {
+				header = append(parse.ToLower(parse.Copy(data)), ' ')
+			}
+			stack = append(stack, frame{
+				header:  canonicalValues(header, p.Values()),
+				start:   skipDeclarationPrefix(r.Bytes(), prevEnd),
+				ruleset: gt == BeginRulesetGrammar,
+			})
+		case DeclarationGrammar, CustomPropertyGrammar:
+			if 0 < len(stack) {
+				values, important := p.Values(), false
+				if gt == DeclarationGrammar {
+					values, important = splitImportant(values)
+				}
+				top := &stack[len(stack)-1]
+				top.decls = append(top.decls, DeclarationFingerprint(Declaration{Property: data, Values: values, Important: important}))
+				top.hasDecls = true
+			}
+		case EndAtRuleGrammar, EndRulesetGrammar:
+			if len(stack) == 0 {
+				break
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !top.ruleset && !top.hasDecls {
+				break
+			}
+
+			body := fnv.New64a()
+			writeFingerprints(body, top.decls)
+			h := fnv.New64a()
+			for _, f := range stack {
+				h.Write(f.header)
+				h.Write([]byte{'{'})
+			}
+			h.Write(top.header)
+			h.Write([]byte{'{'})
+			writeFingerprints(h, top.decls)
+			fingerprints = append(fingerprints, RuleFingerprint{
+				Fingerprint: Fingerprint(h.Sum64()),
+				Body:        Fingerprint(body.Sum64()),
+				Start:       top.start,
+				End:         end,
+			})
+		}
+		prevEnd = end
+	}
+}
+
+func writeFingerprints(h hash.Hash64, fingerprints []Fingerprint) {
+	var buf [8]byte
+	for _, f := range fingerprints {
+		binary.LittleEndian.PutUint64(buf[:], uint64(f))
+		h.Write(buf[:])
+	}
+}
+
+// canonicalValues appends the canonical serialization of the values to dst, which is only used for hashing and is not necessarily valid CSS.
+func canonicalValues(dst []byte, values []Token) []byte {
+	space := false
+	for _, t := range values {
+		if t.TokenType == CommentToken {
+			continue
+		} else if t.TokenType == WhitespaceToken {
+			space = true
+			continue
+		}
+		if space {
+			dst = append(dst, ' ')
+			space = false
+		}
+
+		switch t.TokenType {
+		case NumberToken, PercentageToken, DimensionToken:
+			f, n := strconv.ParseFloat(t.Data)
+			if t.TokenType == NumberToken && n == len(t.Data) || t.TokenType == PercentageToken && n == len(t.Data)-1 || t.TokenType == DimensionToken && 0 < n {
+				if f == 0 {
+					f = 0 // positive zero
+				}
+				var buf [8]byte
+				binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+				dst = append(dst, buf[:]...)
+				dst = append(dst, parse.ToLower(parse.Copy(t.Data[n:]))...)
+				continue
+			}
+		case HashToken:
+			if color, ok := canonicalHexColor(t.Data[1:]); ok {
+				dst = append(append(dst, '#'), color...)
+				continue
+			}
+		case FunctionToken:
+			dst = append(dst, parse.ToLower(parse.Copy(t.Data))...)
+			continue
+		case StringToken:
+			dst = append(dst, '"')
+			dst = appendUnquoted(dst, trimQuotes(t.Data))
+			dst = append(dst, '"')
+			continue
+		case URLToken:
+			url := t.Data[4:]
+			if 0 < len(url) && url[len(url)-1] == ')' {
+				url = url[:len(url)-1] // unterminated at EOF otherwise
+			}
+			url = parse.TrimWhitespace(url)
+			if 0 < len(url) && (url[0] == '"' || url[0] == '\'') {
+				url = appendUnquoted(nil, trimQuotes(url))
+			}
+			dst = append(append(append(dst, "url("...), url...), ')')
+			continue
+		}
+		dst = append(dst, t.Data...)
+	}
+	return dst
+}
+
+// canonicalHexColor returns the lowercase hexadecimal color in its shortest notation, or false if b is not a hexadecimal color.
+func canonicalHexColor(b []byte) ([]byte, bool) {
+	if _, ok := hexColor(b); !ok {
+		return nil, false
+	}
+	b = parse.ToLower(parse.Copy(b))
+	if len(b) == 6 && b[0] == b[1] && b[2] == b[3] && b[4] == b[5] {
+		b = []byte{b[0], b[2], b[4]}
+	} else if len(b) == 8 && b[0] == b[1] && b[2] == b[3] && b[4] == b[5] && b[6] == b[7] {
+		b = []byte{b[0], b[2], b[4], b[6]}
+	}
+	if len(b) == 4 && b[3] == 'f' {
+		b = b[:3] // opaque
+	} else if len(b) == 8 && b[6] == 'f' && b[7] == 'f' {
+		b = b[:6]
+	}
+	return b, true
+}
+
+// appendUnquoted appends the contents of a string without the escapes of quotes, so that strings with different quotes compare equal.
+// trimQuotes returns the contents of a quoted string, where the closing quote may be missing at EOF.
+func trimQuotes(b []byte) []byte {
+	quote := b[0]
+	b = b[1:]
+	if n := len(b); 0 < n && b[n-1] == quote {
+		escapes := 0
+		for escapes < n-1 && b[n-2-escapes] == '\\' {
+			escapes++
+		}
+		if escapes%2 == 0 {
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+func appendUnquoted(dst, b []byte) []byte {
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) && (b[i+1] == '"' || b[i+1] == '\'') {
+			i++
+		}
+		dst = append(dst, b[i])
+	}
+	return dst
+}
+
+// splitImportant returns the values without a trailing !important, and whether it was present.
+func splitImportant(values []Token) ([]Token, bool) {
+	if n := len(values); 2 <= n && values[n-2].TokenType == DelimToken && values[n-2].Data[0] == '!' && parse.EqualFold(values[n-1].Data, importantBytes) {
+		return values[:n-2], true
+	}
+	return values, false
+}
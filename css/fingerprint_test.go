@@ -0,0 +1,74 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestFingerprints(t *testing.T) {
+	var tests = []struct {
+		a, b  string
+		equal bool
+	}{
+		{"a{color:red}", "a { color : red ; }", true},
+		{"a{color:red}", "a/* x */{/* y */color:red/* z */}", true},
+		{"a{color:#FFF}", "a{color:#ffffff}", true},
+		{"a{color:#ff0000ff}", "a{color:#F00}", true},
+		{"a{margin:0.50PX 1.0em}", "a{margin:.5px 1em}", true},
+		{"a{margin:-0}", "a{margin:0}", true},
+		{"a{width:50%}", "a{width:50.0%}", true},
+		{"a{transform:ROTATE(45deg)}", "a{transform:rotate(45DEG)}", true},
+		{"a{content:'a\"b'}", "a{content:\"a\\\"b\"}", true},
+		{"a{background:url( 'x.png' )}", "a{background:url(x.png)}", true},
+		{"a{color:red!IMPORTANT}", "a{color:red ! important}", true},
+		{"a , b>c{x:y}", "a,b > c{x:y}", true},
+		{"@media (min-width:10px){a{x:y}}", "@MEDIA (min-width: 10PX) { a { x: y } }", true},
+
+		{"a{content:\"xy", "a{content:'xy'}", true},
+		{"a{content:'x\\'", "a{content:\"x'\"}", true},
+		{"a{b:url(", "a{b:url()}", true},
+		{"a{b:url(x.png", "a{b:url( 'x.png' )}", true},
+		{"a{b:url('x.png", "a{b:url(x.png)}", true},
+
+		{"a{color:red}", "b{color:red}", false},
+		{"a{content:\"xy", "a{content:'x'}", false},
+		{"a{color:red}", "a{color:blue}", false},
+		{"a{color:red}", "a{color:red!important}", false},
+		{"a{x:1;y:2}", "a{y:2;x:1}", false},
+		{"a{animation-name:Foo}", "a{animation-name:foo}", false},
+		{"a{margin:0}", "a{margin:0px}", false},
+		{"a{x:y}", "@media print{a{x:y}}", false},
+		{"a b{x:y}", "a>b{x:y}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := Fingerprints(parse.NewInputString(tt.a))
+			test.Error(t, err)
+			b, err := Fingerprints(parse.NewInputString(tt.b))
+			test.Error(t, err)
+			test.T(t, a[len(a)-1].Fingerprint == b[len(b)-1].Fingerprint, tt.equal)
+		})
+	}
+}
+
+func TestFingerprintsRules(t *testing.T) {
+	css := "a { x: y }\n/* c */ @font-face { font-family: f }\n@media print { b { x: y } }\n@media screen {}"
+	rules, err := Fingerprints(parse.NewInputString(css))
+	test.Error(t, err)
+	test.T(t, len(rules), 3)
+	test.String(t, css[rules[0].Start:rules[0].End], "a { x: y }")
+	test.String(t, css[rules[1].Start:rules[1].End], "@font-face { font-family: f }")
+	test.String(t, css[rules[2].Start:rules[2].End], "b { x: y }")
+	test.That(t, rules[0].Fingerprint != rules[2].Fingerprint, "different selectors")
+	test.T(t, rules[0].Body, rules[2].Body, "same declarations")
+}
+
+func TestDeclarationFingerprint(t *testing.T) {
+	decls, err := ParseDeclarations(parse.NewInputString("color: #F00; color:#ff0000 ; color: red !important; --x: #F00"))
+	test.Error(t, err)
+	test.T(t, DeclarationFingerprint(decls[0]), DeclarationFingerprint(decls[1]))
+	test.That(t, DeclarationFingerprint(decls[0]) != DeclarationFingerprint(decls[2]))
+	test.That(t, DeclarationFingerprint(decls[0]) != DeclarationFingerprint(decls[3]))
+}
@@ -10,13 +10,17 @@ type Hash uint32
 
 // Identifiers for the hashes associated with the text in the comments.
 const (
-	Document  Hash = 0x8    // document
-	Font_Face Hash = 0x809  // font-face
-	Keyframes Hash = 0x1109 // keyframes
-	Layer     Hash = 0x2105 // layer
-	Media     Hash = 0x2605 // media
-	Page      Hash = 0x2b04 // page
-	Supports  Hash = 0x1908 // supports
+	Container      Hash = 0xe09  // container
+	Document       Hash = 0x2908 // document
+	Font_Face      Hash = 0x1709 // font-face
+	Keyframes      Hash = 0x2009 // keyframes
+	Layer          Hash = 0x3905 // layer
+	Media          Hash = 0x3e05 // media
+	Nest           Hash = 0x4804 // nest
+	Page           Hash = 0x4c04 // page
+	Scope          Hash = 0x4305 // scope
+	Starting_Style Hash = 0xe    // starting-style
+	Supports       Hash = 0x3108 // supports
 )
 
 // String returns the text associated with the hash.
@@ -66,18 +70,22 @@ NEXT:
 	return 0
 }
 
-const _Hash_hash0 = 0x5aebcbca
-const _Hash_maxLen = 9
+const _Hash_hash0 = 0x9acb0442
+const _Hash_maxLen = 14
 
 var _Hash_text = []byte("" +
-	"documentfont-facekeyframesupportslayermediapage")
+	"starting-stylecontainerfont-facekeyframesdocumentsupportslayermediascopenestpage")
 
-var _Hash_table = [1 << 3]Hash{
-	0x0: 0x1109, // keyframes
-	0x1: 0x2b04, // page
-	0x2: 0x2605, // media
-	0x3: 0x809,  // font-face
-	0x4: 0x8,    // document
-	0x6: 0x1908, // supports
-	0x7: 0x2105, // layer
+var _Hash_table = [1 << 4]Hash{
+	0x1: 0xe09,  // container
+	0x2: 0xe,    // starting-style
+	0x3: 0x1709, // font-face
+	0x6: 0x4305, // scope
+	0x8: 0x2908, // document
+	0x9: 0x4c04, // page
+	0xb: 0x4804, // nest
+	0xc: 0x3e05, // media
+	0xd: 0x2009, // keyframes
+	0xe: 0x3108, // supports
+	0xf: 0x3905, // layer
 }
@@ -0,0 +1,381 @@
+// Package colorspace converts colors between the color spaces of CSS Color 4 by way of CIE XYZ with a D65 white point, see https://www.w3.org/TR/css-color-4/#color-conversion-code. It is shared by the css and css/color packages.
+package colorspace
+
+import "math"
+
+var linearSRGBToXYZMatrix = [3][3]float64{
+	{0.41239079926595934, 0.357584339383878, 0.1804807884018343},
+	{0.21263900587151027, 0.715168678767756, 0.07219231536073371},
+	{0.01933081871559182, 0.11919477979462598, 0.9505321522496607},
+}
+
+var xyzToLinearSRGBMatrix = [3][3]float64{
+	{3.2409699419045226, -1.537383177570094, -0.4986107602930034},
+	{-0.9692436362808796, 1.8759675015077202, 0.04155505740717559},
+	{0.05563007969699366, -0.20397695888897652, 1.0569715142428786},
+}
+
+var linearP3ToXYZMatrix = [3][3]float64{
+	{608311.0 / 1250200.0, 189793.0 / 714400.0, 198249.0 / 1000160.0},
+	{35783.0 / 156275.0, 247089.0 / 357200.0, 198249.0 / 2500400.0},
+	{0.0, 32229.0 / 714400.0, 5220557.0 / 5000800.0},
+}
+
+var xyzToLinearP3Matrix = [3][3]float64{
+	{446124.0 / 178915.0, -333277.0 / 357830.0, -72051.0 / 178915.0},
+	{-14852.0 / 17905.0, 63121.0 / 35810.0, 423.0 / 17905.0},
+	{11844.0 / 330415.0, -50337.0 / 660830.0, 316169.0 / 330415.0},
+}
+
+var linearA98RGBToXYZMatrix = [3][3]float64{
+	{573536.0 / 994567.0, 263643.0 / 1420810.0, 187206.0 / 994567.0},
+	{591459.0 / 1989134.0, 6239551.0 / 9945670.0, 374412.0 / 4972835.0},
+	{53769.0 / 1989134.0, 351524.0 / 4972835.0, 4929758.0 / 4972835.0},
+}
+
+var xyzToLinearA98RGBMatrix = [3][3]float64{
+	{1829569.0 / 896150.0, -506331.0 / 896150.0, -308931.0 / 896150.0},
+	{-851781.0 / 878810.0, 1648619.0 / 878810.0, 36519.0 / 878810.0},
+	{16779.0 / 1248040.0, -147721.0 / 1248040.0, 1266979.0 / 1248040.0},
+}
+
+// ProPhoto RGB has a D50 white point
+var linearProPhotoRGBToXYZD50Matrix = [3][3]float64{
+	{0.7977666449006423, 0.13518129740053308, 0.0313477341283922},
+	{0.2880748288194013, 0.711835234241873, 0.00008993693872564},
+	{0.0, 0.0, 0.8251046025104602},
+}
+
+var xyzD50ToLinearProPhotoRGBMatrix = [3][3]float64{
+	{1.3457868816471583, -0.25557208737979464, -0.05110186497554526},
+	{-0.5446307051249019, 1.5082477428451468, 0.02052744743642139},
+	{0.0, 0.0, 1.2119675456389452},
+}
+
+var linearRec2020ToXYZMatrix = [3][3]float64{
+	{63426534.0 / 99577255.0, 20160776.0 / 139408157.0, 47086771.0 / 278816314.0},
+	{26158966.0 / 99577255.0, 472592308.0 / 697040785.0, 8267143.0 / 139408157.0},
+	{0.0, 19567812.0 / 697040785.0, 295819943.0 / 278816314.0},
+}
+
+var xyzToLinearRec2020Matrix = [3][3]float64{
+	{30757411.0 / 17917100.0, -6372589.0 / 17917100.0, -4539589.0 / 17917100.0},
+	{-19765991.0 / 29648200.0, 47925759.0 / 29648200.0, 467509.0 / 29648200.0},
+	{792561.0 / 44930125.0, -1921689.0 / 44930125.0, 42328811.0 / 44930125.0},
+}
+
+// Bradford chromatic adaptation between the D65 and D50 white points
+var d65ToD50Matrix = [3][3]float64{
+	{1.0479298208405488, 0.022946793341019088, -0.05019222954313557},
+	{0.029627815688159344, 0.990434484573249, -0.01707382502938514},
+	{-0.009243058152591178, 0.015055144896577895, 0.7518742899580008},
+}
+
+// d50ToD65Matrix is the exact inverse of d65ToD50Matrix, so that colors survive round trips through xyz-d50, lab, and prophoto-rgb
+var d50ToD65Matrix = [3][3]float64{
+	{0.9554733942048977, -0.02309837472603865, 0.06325919498911496},
+	{-0.02836971286639444, 1.0099953374555604, 0.02104147560735432},
+	{0.012314034948960153, -0.02050758481440557, 1.3303659126444372},
+}
+
+var xyzToLMSMatrix = [3][3]float64{
+	{0.8190224379967030, 0.3619062600528904, -0.1288737815209879},
+	{0.0329836539323885, 0.9292868615863434, 0.0361446663506424},
+	{0.0481771893596242, 0.2642395317527308, 0.6335478284694309},
+}
+
+var lmsToXYZMatrix = [3][3]float64{
+	{1.2268798758459243, -0.5578149944602171, 0.2813910456659647},
+	{-0.0405757452148008, 1.1122868032803170, -0.0717110580655164},
+	{-0.0763729366746601, -0.4214933324022432, 1.5869240198367816},
+}
+
+var lmsToOKLabMatrix = [3][3]float64{
+	{0.2104542683093140, 0.7936177747023054, -0.0040720430116193},
+	{1.9779985324311684, -2.4285922420485799, 0.4505937096174110},
+	{0.0259040424655478, 0.7827717124575296, -0.8086757549788036},
+}
+
+var oklabToLMSMatrix = [3][3]float64{
+	{1.0, 0.3963377773761749, 0.2158037573099136},
+	{1.0, -0.1055613458156586, -0.0638541728258133},
+	{1.0, -0.0894841775298119, -1.2914855480194092},
+}
+
+// d50White is the D50 reference white in CIE XYZ.
+var d50White = [3]float64{0.3457 / 0.3585, 1.0, (1.0 - 0.3457 - 0.3585) / 0.3585}
+
+func mulMatrix(m [3][3]float64, c [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*c[0] + m[0][1]*c[1] + m[0][2]*c[2],
+		m[1][0]*c[0] + m[1][1]*c[1] + m[1][2]*c[2],
+		m[2][0]*c[0] + m[2][1]*c[1] + m[2][2]*c[2],
+	}
+}
+
+// Identity returns the channels unchanged, which is the conversion of xyz-d65.
+func Identity(c [3]float64) [3]float64 {
+	return c
+}
+
+// NormalizeHue returns the hue in degrees in the range [0,360).
+func NormalizeHue(h float64) float64 {
+	h = math.Mod(h, 360.0)
+	if h < 0.0 {
+		h += 360.0
+	}
+	return h
+}
+
+// transfer applies a transfer function to the magnitude of each channel, keeping its sign, to convert between gamma-encoded and linear-light channels.
+func transfer(c [3]float64, f func(float64) float64) [3]float64 {
+	for i, v := range c {
+		c[i] = math.Copysign(f(math.Abs(v)), v)
+	}
+	return c
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1.0/2.4) - 0.055
+}
+
+func SRGBToXYZ(c [3]float64) [3]float64 {
+	return mulMatrix(linearSRGBToXYZMatrix, transfer(c, srgbToLinear))
+}
+
+func XYZToSRGB(xyz [3]float64) [3]float64 {
+	return transfer(mulMatrix(xyzToLinearSRGBMatrix, xyz), linearToSRGB)
+}
+
+func LinearSRGBToXYZ(c [3]float64) [3]float64 {
+	return mulMatrix(linearSRGBToXYZMatrix, c)
+}
+
+func XYZToLinearSRGB(xyz [3]float64) [3]float64 {
+	return mulMatrix(xyzToLinearSRGBMatrix, xyz)
+}
+
+// DisplayP3ToXYZ converts from Display P3, which uses the transfer function of sRGB.
+func DisplayP3ToXYZ(c [3]float64) [3]float64 {
+	return mulMatrix(linearP3ToXYZMatrix, transfer(c, srgbToLinear))
+}
+
+func XYZToDisplayP3(xyz [3]float64) [3]float64 {
+	return transfer(mulMatrix(xyzToLinearP3Matrix, xyz), linearToSRGB)
+}
+
+func A98RGBToXYZ(c [3]float64) [3]float64 {
+	return mulMatrix(linearA98RGBToXYZMatrix, transfer(c, func(v float64) float64 {
+		return math.Pow(v, 563.0/256.0)
+	}))
+}
+
+func XYZToA98RGB(xyz [3]float64) [3]float64 {
+	return transfer(mulMatrix(xyzToLinearA98RGBMatrix, xyz), func(v float64) float64 {
+		return math.Pow(v, 256.0/563.0)
+	})
+}
+
+func ProPhotoRGBToXYZ(c [3]float64) [3]float64 {
+	c = transfer(c, func(v float64) float64 {
+		if v <= 16.0/512.0 {
+			return v / 16.0
+		}
+		return math.Pow(v, 1.8)
+	})
+	return mulMatrix(d50ToD65Matrix, mulMatrix(linearProPhotoRGBToXYZD50Matrix, c))
+}
+
+func XYZToProPhotoRGB(xyz [3]float64) [3]float64 {
+	c := mulMatrix(xyzD50ToLinearProPhotoRGBMatrix, mulMatrix(d65ToD50Matrix, xyz))
+	return transfer(c, func(v float64) float64 {
+		if v < 1.0/512.0 {
+			return v * 16.0
+		}
+		return math.Pow(v, 1.0/1.8)
+	})
+}
+
+// Rec. 2020 transfer function constants
+const (
+	rec2020Alpha = 1.09929682680944
+	rec2020Beta  = 0.018053968510807
+)
+
+func Rec2020ToXYZ(c [3]float64) [3]float64 {
+	return mulMatrix(linearRec2020ToXYZMatrix, transfer(c, func(v float64) float64 {
+		if v < rec2020Beta*4.5 {
+			return v / 4.5
+		}
+		return math.Pow((v+rec2020Alpha-1.0)/rec2020Alpha, 1.0/0.45)
+	}))
+}
+
+func XYZToRec2020(xyz [3]float64) [3]float64 {
+	return transfer(mulMatrix(xyzToLinearRec2020Matrix, xyz), func(v float64) float64 {
+		if v <= rec2020Beta {
+			return v * 4.5
+		}
+		return rec2020Alpha*math.Pow(v, 0.45) - (rec2020Alpha - 1.0)
+	})
+}
+
+func XYZD50ToXYZ(c [3]float64) [3]float64 {
+	return mulMatrix(d50ToD65Matrix, c)
+}
+
+func XYZToXYZD50(xyz [3]float64) [3]float64 {
+	return mulMatrix(d65ToD50Matrix, xyz)
+}
+
+// HSLToSRGB converts a hue in degrees, and saturation and lightness in the range [0,100] to sRGB, see https://www.w3.org/TR/css-color-4/#hsl-to-rgb.
+func HSLToSRGB(c [3]float64) [3]float64 {
+	h, s, l := NormalizeHue(c[0]), c[1]/100.0, c[2]/100.0
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/30.0, 12.0)
+		a := s * math.Min(l, 1.0-l)
+		return l - a*math.Max(-1.0, math.Min(k-3.0, math.Min(9.0-k, 1.0)))
+	}
+	return [3]float64{f(0.0), f(8.0), f(4.0)}
+}
+
+// SRGBToHSL returns the hue in degrees, and saturation and lightness in the range [0,100].
+func SRGBToHSL(c [3]float64) [3]float64 {
+	max := math.Max(c[0], math.Max(c[1], c[2]))
+	min := math.Min(c[0], math.Min(c[1], c[2]))
+	h, s, l := 0.0, 0.0, (min+max)/2.0
+	if d := max - min; d != 0.0 {
+		if l != 0.0 && l != 1.0 {
+			s = (max - l) / math.Min(l, 1.0-l)
+		}
+		switch max {
+		case c[0]:
+			h = (c[1]-c[2])/d + 0.0
+			if c[1] < c[2] {
+				h += 6.0
+			}
+		case c[1]:
+			h = (c[2]-c[0])/d + 2.0
+		case c[2]:
+			h = (c[0]-c[1])/d + 4.0
+		}
+		h *= 60.0
+	}
+	return [3]float64{h, s * 100.0, l * 100.0}
+}
+
+func HSLToXYZ(c [3]float64) [3]float64 {
+	return SRGBToXYZ(HSLToSRGB(c))
+}
+
+func XYZToHSL(xyz [3]float64) [3]float64 {
+	return SRGBToHSL(XYZToSRGB(xyz))
+}
+
+func HWBToXYZ(c [3]float64) [3]float64 {
+	w, b := c[1]/100.0, c[2]/100.0
+	if 1.0 <= w+b {
+		gray := w / (w + b)
+		return SRGBToXYZ([3]float64{gray, gray, gray})
+	}
+	rgb := HSLToSRGB([3]float64{c[0], 100.0, 50.0})
+	for i := range rgb {
+		rgb[i] = rgb[i]*(1.0-w-b) + w
+	}
+	return SRGBToXYZ(rgb)
+}
+
+// XYZToHWB returns the hue in degrees, and whiteness and blackness in the range [0,100].
+func XYZToHWB(xyz [3]float64) [3]float64 {
+	c := XYZToSRGB(xyz)
+	hsl := SRGBToHSL(c)
+	min := math.Min(c[0], math.Min(c[1], c[2]))
+	max := math.Max(c[0], math.Max(c[1], c[2]))
+	return [3]float64{hsl[0], min * 100.0, (1.0 - max) * 100.0}
+}
+
+func XYZToLab(xyz [3]float64) [3]float64 {
+	const epsilon, kappa = 216.0 / 24389.0, 24389.0 / 27.0
+	xyz = mulMatrix(d65ToD50Matrix, xyz)
+	f := [3]float64{}
+	for i := range xyz {
+		if v := xyz[i] / d50White[i]; epsilon < v {
+			f[i] = math.Cbrt(v)
+		} else {
+			f[i] = (kappa*v + 16.0) / 116.0
+		}
+	}
+	return [3]float64{116.0*f[1] - 16.0, 500.0 * (f[0] - f[1]), 200.0 * (f[1] - f[2])}
+}
+
+func LabToXYZ(lab [3]float64) [3]float64 {
+	const epsilon, kappa = 216.0 / 24389.0, 24389.0 / 27.0
+	fy := (lab[0] + 16.0) / 116.0
+	fx := lab[1]/500.0 + fy
+	fz := fy - lab[2]/200.0
+	xyz := [3]float64{(116.0*fx - 16.0) / kappa, lab[0] / kappa, (116.0*fz - 16.0) / kappa}
+	if epsilon < fx*fx*fx {
+		xyz[0] = fx * fx * fx
+	}
+	if kappa*epsilon < lab[0] {
+		xyz[1] = fy * fy * fy
+	}
+	if epsilon < fz*fz*fz {
+		xyz[2] = fz * fz * fz
+	}
+	for i := range xyz {
+		xyz[i] *= d50White[i]
+	}
+	return mulMatrix(d50ToD65Matrix, xyz)
+}
+
+func XYZToOKLab(xyz [3]float64) [3]float64 {
+	lms := mulMatrix(xyzToLMSMatrix, xyz)
+	for i, v := range lms {
+		lms[i] = math.Cbrt(v)
+	}
+	return mulMatrix(lmsToOKLabMatrix, lms)
+}
+
+func OKLabToXYZ(lab [3]float64) [3]float64 {
+	lms := mulMatrix(oklabToLMSMatrix, lab)
+	for i, v := range lms {
+		lms[i] = v * v * v
+	}
+	return mulMatrix(lmsToXYZMatrix, lms)
+}
+
+func LabToLCH(lab [3]float64) [3]float64 {
+	return [3]float64{lab[0], math.Hypot(lab[1], lab[2]), NormalizeHue(math.Atan2(lab[2], lab[1]) * 180.0 / math.Pi)}
+}
+
+func LCHToLab(lch [3]float64) [3]float64 {
+	h := lch[2] * math.Pi / 180.0
+	return [3]float64{lch[0], lch[1] * math.Cos(h), lch[1] * math.Sin(h)}
+}
+
+func LCHToXYZ(c [3]float64) [3]float64 {
+	return LabToXYZ(LCHToLab(c))
+}
+
+func XYZToLCH(xyz [3]float64) [3]float64 {
+	return LabToLCH(XYZToLab(xyz))
+}
+
+func OKLCHToXYZ(c [3]float64) [3]float64 {
+	return OKLabToXYZ(LCHToLab(c))
+}
+
+func XYZToOKLCH(xyz [3]float64) [3]float64 {
+	return LabToLCH(XYZToOKLab(xyz))
+}
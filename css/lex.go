@@ -147,6 +147,11 @@ func NewLexer(r *parse.Input) *Lexer {
 	}
 }
 
+// Reset resets the lexer to lex r.
+func (l *Lexer) Reset(r *parse.Input) {
+	l.r = r
+}
+
 // Err returns the error encountered during lexing, this is often io.EOF but also other errors can be returned.
 func (l *Lexer) Err() error {
 	return l.r.Err()
@@ -154,6 +154,40 @@ func TestOffset(t *testing.T) {
 	test.T(t, z.Offset(), 26) // }
 }
 
+func FuzzLexer(f *testing.F) {
+	f.Add("a { color: red !important; }")
+	f.Add("@media (min-width: 10px) { .b { background: url(c) } }")
+	f.Add("/* comment */ 'string' #id 1.5e3em u+26")
+	f.Fuzz(func(t *testing.T, s string) {
+		z := parse.NewInputString(s)
+		peeked := false
+		z.SetHooks(&parse.Hooks{
+			OnMove: func(from, to int) {
+				test.That(t, to <= len(s), "must not move beyond the input")
+			},
+			OnRewind: func(from, to int) {
+				test.That(t, to <= from, "must not rewind forward")
+			},
+			OnPeekEOF: func(offset int) {
+				peeked = true
+			},
+		})
+		l := NewLexer(z)
+		for i := 0; i <= len(s); i++ {
+			offset := z.Offset()
+			if tt, _ := l.Next(); tt == ErrorToken {
+				if l.Err() == io.EOF {
+					test.T(t, z.Offset(), len(s), "must consume the input")
+					test.That(t, peeked, "must peek at EOF")
+				}
+				return
+			}
+			test.That(t, offset < z.Offset(), "must make progress")
+		}
+		t.Fatal("must stop")
+	})
+}
+
 ////////////////////////////////////////////////////////////////
 
 func ExampleNewLexer() {
@@ -0,0 +1,213 @@
+// Package lint is a rule engine to lint CSS stylesheets using the css parser. Rules visit the grammar nodes of the parser in document order and report diagnostics with byte offsets into the input.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+)
+
+// Diagnostic is a problem reported by a rule.
+type Diagnostic struct {
+	Rule       string // name of the rule, or syntax for parse errors
+	Message    string
+	Start, End int // byte offsets in the input
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d-%d %s: %s", d.Start, d.End, d.Rule, d.Message)
+}
+
+// Node is a grammar node returned by the css parser, see css.Parser.Next.
+type Node struct {
+	Type   css.GrammarType
+	Data   []byte      // at-rule name or property name, lowercase for declarations
+	Values []css.Token // at-rule prelude, selector list, or declaration values
+
+	// Start and End are the byte offsets of the node in the input, excluding the opening brace of at-rules and rulesets and the terminating semicolon of declarations
+	Start, End int
+
+	offsets []int // byte offsets of Values
+}
+
+// ValueSpan returns the byte offsets in the input of the value token at index i.
+func (n Node) ValueSpan(i int) (int, int) {
+	if n.Values[i].TokenType == css.WhitespaceToken {
+		return n.offsets[i], n.offsets[i] // whitespace is normalized by the parser
+	}
+	return n.offsets[i], n.offsets[i] + len(n.Values[i].Data)
+}
+
+// Context is passed to the rules while visiting nodes.
+type Context struct {
+	// Parents are the enclosing at-rules and rulesets of the current node, the innermost last.
+	Parents []Node
+
+	rule  Rule
+	diags []Diagnostic
+}
+
+// Report reports a diagnostic for the current rule.
+func (c *Context) Report(start, end int, format string, args ...interface{}) {
+	c.diags = append(c.diags, Diagnostic{
+		Rule:    c.rule.Name(),
+		Message: fmt.Sprintf(format, args...),
+		Start:   start,
+		End:     end,
+	})
+}
+
+// Rule is a lint rule. Begin is called before linting a stylesheet to reset the state of the rule, and Visit is called for all nodes of the stylesheet in document order. The end of an at-rule or ruleset is visited as a node with the EndAtRuleGrammar or EndRulesetGrammar type, whose Start and End are those of the closing brace.
+type Rule interface {
+	Name() string
+	Begin()
+	Visit(c *Context, n Node)
+}
+
+// Linter lints stylesheets with a set of rules.
+type Linter struct {
+	Rules []Rule
+}
+
+// NewLinter returns a new Linter with the given rules.
+func NewLinter(rules ...Rule) *Linter {
+	return &Linter{rules}
+}
+
+// Lint lints a stylesheet, or a declaration list such as a style attribute when isInline is set. It returns the diagnostics of all rules ordered by their start offset, including those of parse errors. The error is only set for read errors.
+func (l *Linter) Lint(r *parse.Input, isInline bool) ([]Diagnostic, error) {
+	c := &Context{}
+	for _, rule := range l.Rules {
+		rule.Begin()
+	}
+
+	p := css.NewParser(r, isInline)
+	prevEnd := 0
+	for {
+		gt, _, data := p.Next()
+		end := p.Offset()
+		if gt == css.ErrorGrammar {
+			if !p.HasParseError() {
+				if err := p.Err(); err != io.EOF {
+					return nil, err
+				}
+				break
+			}
+			msg := p.Err().Error()
+			if err, ok := p.Err().(*parse.Error); ok {
+				msg = err.Message
+			}
+			c.diags = append(c.diags, Diagnostic{"syntax", msg, end, end})
+			prevEnd = end
+			continue
+		}
+
+		src := r.Bytes()
+		n := Node{
+			Type:   gt,
+			Data:   data,
+			Values: p.Values(),
+			Start:  skipPrefix(src, prevEnd),
+			End:    trimSuffix(src, end),
+		}
+		if gt == css.EndAtRuleGrammar || gt == css.EndRulesetGrammar {
+			n.Values = nil
+			n.Start, n.End = end, end
+			if 0 < end && src[end-1] == '}' {
+				n.Start--
+			}
+		} else if gt == css.CommentGrammar || gt == css.TokenGrammar {
+			n.Values = nil
+			n.Start, n.End = end-len(data), end
+		}
+		if n.End < n.Start {
+			n.End = n.Start
+		}
+		n.offsets = valueOffsets(src, n)
+
+		for _, rule := range l.Rules {
+			c.rule = rule
+			rule.Visit(c, n)
+		}
+
+		if gt == css.BeginAtRuleGrammar || gt == css.BeginRulesetGrammar {
+			n.Values = append([]css.Token{}, n.Values...)
+			c.Parents = append(c.Parents, n)
+		} else if (gt == css.EndAtRuleGrammar || gt == css.EndRulesetGrammar) && 0 < len(c.Parents) {
+			c.Parents = c.Parents[:len(c.Parents)-1]
+		}
+		prevEnd = end
+	}
+	sortDiagnostics(c.diags)
+	return c.diags, nil
+}
+
+// Lint lints a stylesheet with the given rules, see Linter.Lint.
+func Lint(r *parse.Input, rules ...Rule) ([]Diagnostic, error) {
+	return NewLinter(rules...).Lint(r, false)
+}
+
+func sortDiagnostics(diags []Diagnostic) {
+	// insertion sort keeps diagnostics at the same offset in the order of the rules, and they are mostly sorted already
+	for i := 1; i < len(diags); i++ {
+		for j := i; 0 < j && diags[j].Start < diags[j-1].Start; j-- {
+			diags[j], diags[j-1] = diags[j-1], diags[j]
+		}
+	}
+}
+
+// skipPrefix skips whitespace, comments, and semicolons before a node.
+func skipPrefix(b []byte, i int) int {
+	for i < len(b) {
+		if parse.IsWhitespace(b[i]) || b[i] == ';' {
+			i++
+		} else if b[i] == '/' && i+1 < len(b) && b[i+1] == '*' {
+			if j := bytes.Index(b[i+2:], []byte("*/")); j != -1 {
+				i += 2 + j + 2
+			} else {
+				return len(b)
+			}
+		} else {
+			break
+		}
+	}
+	return i
+}
+
+// trimSuffix trims the opening brace, the terminating semicolon or closing brace, and whitespace after a node.
+func trimSuffix(b []byte, i int) int {
+	if 0 < i && (b[i-1] == ';' || b[i-1] == '{' || b[i-1] == '}') {
+		i--
+	}
+	for 0 < i && parse.IsWhitespace(b[i-1]) {
+		i--
+	}
+	return i
+}
+
+// valueOffsets returns the byte offsets of the value tokens of a node by finding them in order in the input. Tokens that are not found, such as those normalized by the parser, get the offset where the search continues.
+func valueOffsets(b []byte, n Node) []int {
+	offsets := make([]int, len(n.Values))
+	i := n.Start
+	if n.Type == css.DeclarationGrammar || n.Type == css.CustomPropertyGrammar {
+		if j := bytes.IndexByte(b[i:n.End], ':'); j != -1 {
+			i += j + 1
+		}
+	} else if n.Type == css.AtRuleGrammar || n.Type == css.BeginAtRuleGrammar {
+		i += len(n.Data)
+	}
+	for k, t := range n.Values {
+		if t.TokenType != css.WhitespaceToken && i <= n.End {
+			if j := bytes.Index(b[i:n.End], t.Data); j != -1 {
+				offsets[k] = i + j
+				i += j + len(t.Data)
+				continue
+			}
+		}
+		offsets[k] = i
+	}
+	return offsets
+}
@@ -0,0 +1,74 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+// recorder records the visited nodes and their source.
+type recorder struct {
+	src   string
+	nodes []string
+}
+
+func (r *recorder) Name() string {
+	return "recorder"
+}
+
+func (r *recorder) Begin() {
+	r.nodes = []string{}
+}
+
+func (r *recorder) Visit(c *Context, n Node) {
+	s := n.Type.String() + " " + r.src[n.Start:n.End]
+	for i := range n.Values {
+		start, end := n.ValueSpan(i)
+		s += "|" + r.src[start:end]
+	}
+	if 0 < len(c.Parents) {
+		s += " in " + string(c.Parents[len(c.Parents)-1].Type.String())
+	}
+	r.nodes = append(r.nodes, s)
+}
+
+func TestLinter(t *testing.T) {
+	src := "@import 'a.css';\n@media (min-width: 10px) {\n  a, b { color : red ; margin: 0 1px }\n}\n/* c */ p{}"
+	r := &recorder{src: src}
+	diags, err := NewLinter(r).Lint(parse.NewInputString(src), false)
+	test.Error(t, err)
+	test.T(t, len(diags), 0)
+	test.T(t, r.nodes, []string{
+		"AtRule @import 'a.css'||'a.css'",
+		"BeginAtRule @media (min-width: 10px)|(|min-width|:|10px|)",
+		"BeginRuleset a, b|a|,|b in BeginAtRule",
+		"Declaration color : red|red in BeginRuleset",
+		"Declaration margin: 0 1px|0||1px in BeginRuleset",
+		"EndRuleset } in BeginRuleset",
+		"EndAtRule } in BeginAtRule",
+		"Comment /* c */",
+		"BeginRuleset p|p",
+		"EndRuleset } in BeginRuleset",
+	})
+}
+
+func TestLinterInline(t *testing.T) {
+	src := "color: #ab; width: 1px"
+	diags, err := NewLinter(NoInvalidHex()).Lint(parse.NewInputString(src), true)
+	test.Error(t, err)
+	test.T(t, len(diags), 1)
+	test.String(t, src[diags[0].Start:diags[0].End], "#ab")
+}
+
+func TestLinterSyntaxError(t *testing.T) {
+	src := "a { color: #12; } b } c { color: #34 }"
+	diags, err := Lint(parse.NewInputString(src), NoInvalidHex())
+	test.Error(t, err)
+	rules := []string{}
+	for _, diag := range diags {
+		rules = append(rules, diag.Rule)
+	}
+	test.T(t, rules, []string{"no-invalid-hex", "syntax", "no-invalid-hex"})
+	test.String(t, src[diags[2].Start:diags[2].End], "#34")
+}
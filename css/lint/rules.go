@@ -0,0 +1,293 @@
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+)
+
+// NoDuplicateSelectors reports rulesets whose selector list was used before in the same block, such as twice at the top level or twice in the same @media rule. Selector lists are equal when they have the same selectors in any order.
+func NoDuplicateSelectors() Rule {
+	return &noDuplicateSelectors{}
+}
+
+type noDuplicateSelectors struct {
+	blocks []map[string]bool // selector lists per block, the innermost last
+}
+
+func (r *noDuplicateSelectors) Name() string {
+	return "no-duplicate-selectors"
+}
+
+func (r *noDuplicateSelectors) Begin() {
+	r.blocks = []map[string]bool{{}}
+}
+
+func (r *noDuplicateSelectors) Visit(c *Context, n Node) {
+	switch n.Type {
+	case css.BeginRulesetGrammar:
+		key := selectorListKey(n.Values)
+		if r.blocks[len(r.blocks)-1][key] {
+			c.Report(n.Start, n.End, "duplicate selector %s", key)
+		}
+		r.blocks[len(r.blocks)-1][key] = true
+		r.blocks = append(r.blocks, map[string]bool{})
+	case css.BeginAtRuleGrammar:
+		r.blocks = append(r.blocks, map[string]bool{})
+	case css.EndRulesetGrammar, css.EndAtRuleGrammar:
+		if 1 < len(r.blocks) {
+			r.blocks = r.blocks[:len(r.blocks)-1]
+		}
+	}
+}
+
+// selectorListKey returns the sorted selectors of a selector list separated by commas.
+func selectorListKey(values []css.Token) string {
+	selectors := []string{}
+	sb := strings.Builder{}
+	level := 0
+	for _, t := range values {
+		switch t.TokenType {
+		case css.LeftParenthesisToken, css.LeftBracketToken, css.FunctionToken:
+			level++
+		case css.RightParenthesisToken, css.RightBracketToken:
+			level--
+		case css.CommaToken:
+			if level == 0 {
+				selectors = append(selectors, strings.TrimSpace(sb.String()))
+				sb.Reset()
+				continue
+			}
+		}
+		sb.Write(t.Data)
+	}
+	selectors = append(selectors, strings.TrimSpace(sb.String()))
+	sort.Strings(selectors)
+	return strings.Join(selectors, ",")
+}
+
+// NoInvalidHex reports hex colors in declaration values that do not have 3, 4, 6, or 8 hexadecimal digits, such as #ab or #ggg.
+func NoInvalidHex() Rule {
+	return noInvalidHex{}
+}
+
+type noInvalidHex struct{}
+
+func (noInvalidHex) Name() string {
+	return "no-invalid-hex"
+}
+
+func (noInvalidHex) Begin() {}
+
+func (noInvalidHex) Visit(c *Context, n Node) {
+	if n.Type != css.DeclarationGrammar {
+		return
+	}
+	for i, t := range n.Values {
+		if t.TokenType == css.HashToken && !isHexColor(t.Data[1:]) {
+			start, end := n.ValueSpan(i)
+			c.Report(start, end, "invalid hex color %s", t.Data)
+		}
+	}
+}
+
+func isHexColor(b []byte) bool {
+	if len(b) != 3 && len(b) != 4 && len(b) != 6 && len(b) != 8 {
+		return false
+	}
+	for _, c := range b {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// NoInvalidMath reports math functions in declaration values that are invalid or mix incompatible types, such as calc(1px + 1s) or calc(1px * 1px), see css.ParseMathType. Nested math functions are reported as part of the outermost function.
+func NoInvalidMath() Rule {
+	return noInvalidMath{}
+}
+
+type noInvalidMath struct{}
+
+func (noInvalidMath) Name() string {
+	return "no-invalid-math"
+}
+
+func (noInvalidMath) Begin() {}
+
+func (noInvalidMath) Visit(c *Context, n Node) {
+	if n.Type != css.DeclarationGrammar {
+		return
+	}
+	for i := 0; i < len(n.Values); i++ {
+		t := n.Values[i]
+		if t.TokenType != css.FunctionToken || !css.IsMathFunction(t.Data[:len(t.Data)-1]) {
+			continue
+		}
+		j, level := i+1, 1
+		for ; j < len(n.Values) && 0 < level; j++ {
+			if tt := n.Values[j].TokenType; tt == css.FunctionToken || tt == css.LeftParenthesisToken {
+				level++
+			} else if tt == css.RightParenthesisToken {
+				level--
+			}
+		}
+		if _, ok := css.ParseMathType(n.Values[i:j]); !ok {
+			start, _ := n.ValueSpan(i)
+			_, end := n.ValueSpan(j - 1)
+			c.Report(start, end, "invalid math function %s)", t.Data)
+		}
+		i = j - 1
+	}
+}
+
+// UnitAllowlist reports units in declaration values and at-rule preludes that are not in the list of allowed units, compared case-insensitively. Percentages have the unit %.
+func UnitAllowlist(units ...string) Rule {
+	r := unitAllowlist{map[string]bool{}}
+	for _, unit := range units {
+		r.units[strings.ToLower(unit)] = true
+	}
+	return r
+}
+
+type unitAllowlist struct {
+	units map[string]bool
+}
+
+func (unitAllowlist) Name() string {
+	return "unit-allowlist"
+}
+
+func (unitAllowlist) Begin() {}
+
+func (r unitAllowlist) Visit(c *Context, n Node) {
+	if n.Type != css.DeclarationGrammar && n.Type != css.AtRuleGrammar && n.Type != css.BeginAtRuleGrammar {
+		return
+	}
+	for i, t := range n.Values {
+		if t.TokenType != css.DimensionToken && t.TokenType != css.PercentageToken {
+			continue
+		}
+		num, _ := parse.Dimension(t.Data)
+		if unit := t.Data[num:]; !r.units[strings.ToLower(string(unit))] {
+			start, end := n.ValueSpan(i)
+			c.Report(start+num, end, "unit %s is not allowed", unit)
+		}
+	}
+}
+
+// NoUnknownProperties reports declarations of properties that are not standard CSS properties, with a suggestion for the closest known property. Custom properties, vendor-prefixed properties, and descriptors in at-rules such as @font-face are not reported.
+func NoUnknownProperties() Rule {
+	return noUnknownProperties{}
+}
+
+type noUnknownProperties struct{}
+
+func (noUnknownProperties) Name() string {
+	return "no-unknown-properties"
+}
+
+func (noUnknownProperties) Begin() {}
+
+func (noUnknownProperties) Visit(c *Context, n Node) {
+	if n.Type != css.DeclarationGrammar || css.IsProperty(n.Data) {
+		return
+	}
+	for _, parent := range c.Parents {
+		if parent.Type == css.BeginAtRuleGrammar && !isConditionalAtRule(parent.Data) {
+			return // descriptor
+		}
+	}
+	reportUnknown(c, n.Start, n.Start+len(n.Data), "property", n.Data, css.SuggestProperty)
+}
+
+// NoUnknownUnits reports units in declaration values and at-rule preludes that are not CSS units, with a suggestion for the closest known unit.
+func NoUnknownUnits() Rule {
+	return noUnknownUnits{}
+}
+
+type noUnknownUnits struct{}
+
+func (noUnknownUnits) Name() string {
+	return "no-unknown-units"
+}
+
+func (noUnknownUnits) Begin() {}
+
+func (noUnknownUnits) Visit(c *Context, n Node) {
+	if n.Type != css.DeclarationGrammar && n.Type != css.AtRuleGrammar && n.Type != css.BeginAtRuleGrammar {
+		return
+	}
+	for i, t := range n.Values {
+		if t.TokenType != css.DimensionToken {
+			continue
+		}
+		num, _ := parse.Dimension(t.Data)
+		if unit := t.Data[num:]; !css.IsUnit(unit) {
+			start, end := n.ValueSpan(i)
+			reportUnknown(c, start+num, end, "unit", unit, css.SuggestUnit)
+		}
+	}
+}
+
+// NoUnknownMediaFeatures reports media features in the media queries of @media and @import rules that are not known, with a suggestion for the closest known media feature. Vendor-prefixed media features are not reported.
+func NoUnknownMediaFeatures() Rule {
+	return noUnknownMediaFeatures{}
+}
+
+type noUnknownMediaFeatures struct{}
+
+func (noUnknownMediaFeatures) Name() string {
+	return "no-unknown-media-features"
+}
+
+func (noUnknownMediaFeatures) Begin() {}
+
+func (noUnknownMediaFeatures) Visit(c *Context, n Node) {
+	if n.Type != css.AtRuleGrammar && n.Type != css.BeginAtRuleGrammar || !parse.EqualFold(n.Data, []byte("@media")) && !parse.EqualFold(n.Data, []byte("@import")) {
+		return
+	}
+	// a media feature is an identifier in parentheses that is followed by a colon, a closing parenthesis, or a comparison, or that follows a comparison
+	prev := css.Token{}
+	for i, t := range n.Values {
+		if t.TokenType == css.WhitespaceToken {
+			continue
+		}
+		next := css.Token{}
+		for j := i + 1; j < len(n.Values); j++ {
+			if n.Values[j].TokenType != css.WhitespaceToken {
+				next = n.Values[j]
+				break
+			}
+		}
+		if t.TokenType == css.IdentToken && prev.TokenType != css.ColonToken && (prev.TokenType == css.LeftParenthesisToken && (next.TokenType == css.ColonToken || next.TokenType == css.RightParenthesisToken || isComparison(next)) || isComparison(prev)) && !css.IsMediaFeature(t.Data) {
+			start, end := n.ValueSpan(i)
+			reportUnknown(c, start, end, "media feature", t.Data, css.SuggestMediaFeature)
+		}
+		prev = t
+	}
+}
+
+func isComparison(t css.Token) bool {
+	return t.TokenType == css.DelimToken && len(t.Data) == 1 && (t.Data[0] == '<' || t.Data[0] == '>' || t.Data[0] == '=')
+}
+
+// isConditionalAtRule returns true for at-rules that contain style rules rather than descriptors.
+func isConditionalAtRule(name []byte) bool {
+	switch strings.ToLower(string(name)) {
+	case "@media", "@supports", "@container", "@document", "@-moz-document", "@layer", "@scope", "@starting-style":
+		return true
+	}
+	return false
+}
+
+func reportUnknown(c *Context, start, end int, kind string, name []byte, suggest func([]byte) (string, bool)) {
+	if suggestion, ok := suggest(name); ok {
+		c.Report(start, end, "unknown %s %s, did you mean %s?", kind, name, suggestion)
+	} else {
+		c.Report(start, end, "unknown %s %s", kind, name)
+	}
+}
@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestRules(t *testing.T) {
+	var tests = []struct {
+		rule     Rule
+		css      string
+		expected []string
+	}{
+		{NoDuplicateSelectors(), "a{} b{} a{}", []string{"a: duplicate selector a"}},
+		{NoDuplicateSelectors(), "a, b{} b,a{}", []string{"b,a: duplicate selector a,b"}},
+		{NoDuplicateSelectors(), "a{} @media print { a{} a{} } @media print { a{} }", []string{"a: duplicate selector a"}},
+		{NoDuplicateSelectors(), ":is(a, b){} :is(b, a){} A{} a{}", []string{}},
+		{NoInvalidHex(), "a { color: #fff; background: #12345 url(#x) }", []string{"#12345: invalid hex color #12345"}},
+		{NoInvalidHex(), "a { color: #GGG; border: 1px solid #abcd } #xyz {}", []string{"#GGG: invalid hex color #GGG"}},
+		{NoInvalidMath(), "a { width: calc(100% - 2rem); height: calc(1px + 1s); margin: min(1px, 2em) max(1px*1px) }", []string{"calc(1px + 1s): invalid math function calc()", "max(1px*1px): invalid math function max()"}},
+		{NoInvalidMath(), "a { top: calc(var(--x) + 1s); transform: rotate(calc(1turn / 2)); width: calc(1px + }", []string{"calc(1px + : invalid math function calc()"}},
+		{UnitAllowlist("px", "EM", "%"), "a { width: calc(100% - 2rem); margin: 1Px 2em 0 }", []string{"rem: unit rem is not allowed"}},
+		{UnitAllowlist("px"), "@media (min-width: 40em) { a { top: 5vh } }", []string{"em: unit em is not allowed", "vh: unit vh is not allowed"}},
+		{NoUnknownProperties(), "a { colr: red; Backgrond: none; color: red; --x: 1; -webkit-foo: 0; xyzzy: 1 }", []string{"colr: unknown property colr, did you mean color?", "Backgrond: unknown property backgrond, did you mean background?", "xyzzy: unknown property xyzzy"}},
+		{NoUnknownProperties(), "@font-face { src: url(a); font-display: swap } @media print { a { widht: 0 } }", []string{"widht: unknown property widht, did you mean width?"}},
+		{NoUnknownUnits(), "a { width: 10pz; margin: 1PX 2rme 3% 4foo } @media (min-width: 3em) {}", []string{"pz: unknown unit pz, did you mean px?", "rme: unknown unit rme, did you mean rem?", "foo: unknown unit foo"}},
+		{NoUnknownMediaFeatures(), "@media (min-widht: 40em) and (orientation: landscape), not (hover), (400px <= wdith < 800px) {} @import 'a.css' (prefers-color-schema: dark);", []string{"min-widht: unknown media feature min-widht, did you mean min-width?", "wdith: unknown media feature wdith, did you mean width?", "prefers-color-schema: unknown media feature prefers-color-schema, did you mean prefers-color-scheme?"}},
+		{NoUnknownMediaFeatures(), "@media screen and (-webkit-min-device-pixel-ratio: 2), (display-mode: standalone) {} @supports (foo: bar) {}", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			diags, err := Lint(parse.NewInputString(tt.css), tt.rule)
+			test.Error(t, err)
+			messages := []string{}
+			for _, diag := range diags {
+				test.String(t, diag.Rule, tt.rule.Name())
+				messages = append(messages, tt.css[diag.Start:diag.End]+": "+diag.Message)
+			}
+			test.T(t, messages, tt.expected)
+		})
+	}
+}
+
+func TestRuleReuse(t *testing.T) {
+	l := NewLinter(NoDuplicateSelectors())
+	for i := 0; i < 2; i++ {
+		diags, err := l.Lint(parse.NewInputString("a{} a{}"), false)
+		test.Error(t, err)
+		test.T(t, len(diags), 1)
+	}
+}
@@ -0,0 +1,667 @@
+package css
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// MathNodeType determines the type of a MathNode.
+type MathNodeType uint32
+
+// MathNodeType values.
+const (
+	MathNumber       MathNodeType = iota // number, percentage, or dimension
+	MathConstant                         // e, pi, infinity, -infinity, or nan
+	MathKeyword                          // none of clamp() or the rounding strategy of round()
+	MathSum                              // operands added together, where subtracted operands are negated
+	MathProduct                          // operands multiplied together, where divisors are inverted
+	MathNegate                           // negated operand of a sum, as in a - b
+	MathInvert                           // inverted operand of a product, as in a / b
+	MathFunction                         // math function with its arguments, such as calc() or clamp()
+	MathSubstitution                     // substitution function such as var(), which is kept as written
+)
+
+// String returns the string representation of a MathNodeType.
+func (t MathNodeType) String() string {
+	switch t {
+	case MathNumber:
+		return "Number"
+	case MathConstant:
+		return "Constant"
+	case MathKeyword:
+		return "Keyword"
+	case MathSum:
+		return "Sum"
+	case MathProduct:
+		return "Product"
+	case MathNegate:
+		return "Negate"
+	case MathInvert:
+		return "Invert"
+	case MathFunction:
+		return "Function"
+	case MathSubstitution:
+		return "Substitution"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// MathNode is a node of a math expression, see https://www.w3.org/TR/css-values-4/#calc-internal. Parenthesized expressions are nested in their parent.
+type MathNode struct {
+	Type  MathNodeType
+	Name  []byte      // lowercase name of a function, constant, or keyword
+	Value float64     // value of a number
+	Unit  parse.Unit  // unit of a number, which is Percent for percentages and NoUnit for numbers
+	Args  []*MathNode // arguments of a function, operands of a sum or product, or the operand of negate and invert
+	// Tokens are the tokens of a substitution function, including the function and the closing parenthesis.
+	Tokens []Token
+}
+
+// ParseMathExpr parses a math function, such as calc(), min(), or clamp(), or a single number, percentage, or dimension, such as the values of a declaration, into an expression tree. It returns false for the values for which ParseMathType returns false, such as invalid expressions or calc(1px + 1s) that mixes incompatible types.
+func ParseMathExpr(values []Token) (*MathNode, bool) {
+	n, _, ok := parseMath(values, nil)
+	return n, ok
+}
+
+// parseMath parses a math function or a single numeric token into an expression tree and infers its type, see https://www.w3.org/TR/css-values-4/#calc-syntax. The identifiers in vars are accepted as constants that are numbers, including as the entire value, such as the channel keywords of relative colors. It returns false if the expression is invalid or if its type is invalid.
+func parseMath(values []Token, vars map[string]float64) (*MathNode, MathType, bool) {
+	values = trimWhitespace(values)
+	if len(values) == 0 || values[0].TokenType != NumberToken && values[0].TokenType != PercentageToken && values[0].TokenType != DimensionToken && !isMathFunction(values[0]) && !(len(values) == 1 && isMathVar(values[0], vars)) {
+		return nil, MathType{}, false
+	}
+	p := mathParser{tokens: values, vars: vars}
+	n, ok := p.value()
+	if !ok || p.i != len(p.tokens) {
+		return nil, MathType{}, false
+	}
+	t, ok := n.mathType()
+	if !ok {
+		return nil, MathType{}, false
+	} else if _, ok := t.Category(); !ok && !t.unknown {
+		return nil, MathType{}, false
+	}
+	return n, t, true
+}
+
+// isMathVar returns true if the token is an identifier in vars.
+func isMathVar(t Token, vars map[string]float64) bool {
+	if t.TokenType != IdentToken || vars == nil {
+		return false
+	}
+	_, ok := vars[string(parse.ToLower(parse.Copy(t.Data)))]
+	return ok
+}
+
+// mathParser parses a math expression into an expression tree, see https://www.w3.org/TR/css-values-4/#calc-syntax.
+type mathParser struct {
+	tokens []Token
+	i      int
+	level  int
+	vars   map[string]float64
+}
+
+// skipWhitespace skips whitespace and returns true if there was any.
+func (p *mathParser) skipWhitespace() bool {
+	ws := false
+	for p.i < len(p.tokens) && p.tokens[p.i].TokenType == WhitespaceToken {
+		p.i++
+		ws = true
+	}
+	return ws
+}
+
+// delim returns the delimiter at the current position, or zero.
+func (p *mathParser) delim() byte {
+	if p.i < len(p.tokens) && p.tokens[p.i].TokenType == DelimToken {
+		return p.tokens[p.i].Data[0]
+	}
+	return 0
+}
+
+// closing consumes a closing parenthesis.
+func (p *mathParser) closing() bool {
+	if p.i < len(p.tokens) && p.tokens[p.i].TokenType == RightParenthesisToken {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// sum parses products separated by + and -, which must be surrounded by whitespace.
+func (p *mathParser) sum() (*MathNode, bool) {
+	n, ok := p.product()
+	if !ok {
+		return nil, false
+	}
+	var sum *MathNode
+	for {
+		i := p.i
+		ws := p.skipWhitespace()
+		op := p.delim()
+		if op != '+' && op != '-' {
+			p.i = i
+			break
+		} else if !ws {
+			return nil, false
+		}
+		p.i++
+		if !p.skipWhitespace() {
+			return nil, false
+		}
+		m, ok := p.product()
+		if !ok {
+			return nil, false
+		} else if op == '-' {
+			m = &MathNode{Type: MathNegate, Args: []*MathNode{m}}
+		}
+		if sum == nil {
+			sum = &MathNode{Type: MathSum, Args: []*MathNode{n}}
+		}
+		sum.Args = append(sum.Args, m)
+	}
+	if sum != nil {
+		return sum, true
+	}
+	return n, true
+}
+
+// product parses values separated by * and /.
+func (p *mathParser) product() (*MathNode, bool) {
+	n, ok := p.value()
+	if !ok {
+		return nil, false
+	}
+	var product *MathNode
+	for {
+		i := p.i
+		p.skipWhitespace()
+		op := p.delim()
+		if op != '*' && op != '/' {
+			p.i = i
+			break
+		}
+		p.i++
+		p.skipWhitespace()
+		m, ok := p.value()
+		if !ok {
+			return nil, false
+		} else if op == '/' {
+			m = &MathNode{Type: MathInvert, Args: []*MathNode{m}}
+		}
+		if product == nil {
+			product = &MathNode{Type: MathProduct, Args: []*MathNode{n}}
+		}
+		product.Args = append(product.Args, m)
+	}
+	if product != nil {
+		return product, true
+	}
+	return n, true
+}
+
+// value parses a numeric token, a constant, a parenthesized sum, or a math or substitution function.
+func (p *mathParser) value() (*MathNode, bool) {
+	if len(p.tokens) <= p.i {
+		return nil, false
+	}
+	t := p.tokens[p.i]
+	p.i++
+	switch t.TokenType {
+	case NumberToken, PercentageToken, DimensionToken:
+		_, f, unit := parse.DimensionValue(t.Data)
+		if t.TokenType == DimensionToken && (unit == parse.UnknownUnit || unit == parse.NoUnit) {
+			return nil, false
+		}
+		return &MathNode{Type: MathNumber, Value: f, Unit: unit}, true
+	case IdentToken:
+		name := parse.ToLower(parse.Copy(t.Data))
+		switch string(name) {
+		case "e", "pi", "infinity", "-infinity", "nan":
+			return &MathNode{Type: MathConstant, Name: name}, true
+		}
+		if _, ok := p.vars[string(name)]; ok {
+			return &MathNode{Type: MathConstant, Name: name}, true
+		}
+	case LeftParenthesisToken:
+		if NestedMathLimit <= p.level {
+			return nil, false
+		}
+		p.level++
+		p.skipWhitespace()
+		n, ok := p.sum()
+		p.skipWhitespace()
+		p.level--
+		if !ok || !p.closing() {
+			return nil, false
+		}
+		return n, true
+	case FunctionToken:
+		if NestedMathLimit <= p.level {
+			return nil, false
+		}
+		p.level++
+		n, ok := p.function(parse.ToLower(parse.Copy(t.Data[:len(t.Data)-1])))
+		p.level--
+		return n, ok
+	}
+	return nil, false
+}
+
+// function parses the arguments of a math or substitution function up to and including the closing parenthesis. Substitution functions are kept as written.
+func (p *mathParser) function(name []byte) (*MathNode, bool) {
+	switch string(name) {
+	case "var", "env", "attr", "if":
+		start := p.i - 1
+		for level := 1; p.i < len(p.tokens); p.i++ {
+			switch p.tokens[p.i].TokenType {
+			case FunctionToken, LeftParenthesisToken:
+				level++
+			case RightParenthesisToken:
+				if level--; level == 0 {
+					p.i++
+					return &MathNode{Type: MathSubstitution, Name: name, Tokens: p.tokens[start:p.i]}, true
+				}
+			}
+		}
+		return nil, false
+	}
+
+	var keywords []string
+	switch string(name) {
+	case "clamp":
+		keywords = []string{"none"}
+	case "round":
+		keywords = []string{"nearest", "up", "down", "to-zero"}
+	}
+	n := &MathNode{Type: MathFunction, Name: name}
+	for {
+		p.skipWhitespace()
+		if keyword := p.keyword(keywords); keyword != nil {
+			n.Args = append(n.Args, &MathNode{Type: MathKeyword, Name: keyword})
+		} else if arg, ok := p.sum(); ok {
+			n.Args = append(n.Args, arg)
+		} else {
+			return nil, false
+		}
+		p.skipWhitespace()
+		if p.closing() {
+			return n, true
+		} else if len(p.tokens) <= p.i || p.tokens[p.i].TokenType != CommaToken {
+			return nil, false
+		}
+		p.i++
+	}
+}
+
+// keyword consumes and returns a lowercase identifier if it is one of keywords.
+func (p *mathParser) keyword(keywords []string) []byte {
+	if p.i < len(p.tokens) && p.tokens[p.i].TokenType == IdentToken {
+		for _, keyword := range keywords {
+			if parse.EqualFold(p.tokens[p.i].Data, []byte(keyword)) {
+				p.i++
+				return []byte(keyword)
+			}
+		}
+	}
+	return nil
+}
+
+// String returns the expression as CSS, where numbers are written with up to 15 significant digits and units in their canonical case, such as calc(1px + 2Q). Sums and products that are not an argument of a function are written without calc().
+func (n *MathNode) String() string {
+	switch n.Type {
+	case MathNumber:
+		return formatMathNumber(n.Value) + n.Unit.String()
+	case MathConstant, MathKeyword:
+		return string(n.Name)
+	case MathSum:
+		s := n.Args[0].operandString(MathSum)
+		for _, arg := range n.Args[1:] {
+			if arg.Type == MathNegate {
+				s += " - " + arg.Args[0].operandString(MathNegate)
+			} else if arg.Type == MathNumber && arg.Value < 0.0 {
+				s += " - " + formatMathNumber(-arg.Value) + arg.Unit.String()
+			} else {
+				s += " + " + arg.operandString(MathSum)
+			}
+		}
+		return s
+	case MathProduct:
+		s := n.Args[0].operandString(MathProduct)
+		for _, arg := range n.Args[1:] {
+			if arg.Type == MathInvert {
+				s += " / " + arg.Args[0].operandString(MathInvert)
+			} else {
+				s += " * " + arg.operandString(MathProduct)
+			}
+		}
+		return s
+	case MathNegate:
+		return "-1 * " + n.Args[0].operandString(MathNegate)
+	case MathInvert:
+		return "1 / " + n.Args[0].operandString(MathInvert)
+	case MathFunction:
+		s := string(n.Name) + "("
+		for i, arg := range n.Args {
+			if i != 0 {
+				s += ", "
+			}
+			s += arg.String()
+		}
+		return s + ")"
+	case MathSubstitution:
+		s := ""
+		for _, t := range n.Tokens {
+			s += string(t.Data)
+		}
+		return s
+	}
+	return ""
+}
+
+// operandString returns the node as an operand of a sum, product, negation, or inversion, which parenthesizes operands that would otherwise change the meaning, such as sums in products and products in divisors.
+func (n *MathNode) operandString(parent MathNodeType) string {
+	switch n.Type {
+	case MathSum:
+		if parent != MathSum {
+			return "(" + n.String() + ")"
+		}
+	case MathProduct:
+		if parent == MathInvert {
+			return "(" + n.String() + ")"
+		}
+	case MathNegate, MathInvert:
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+func formatMathNumber(f float64) string {
+	if f == 0.0 {
+		return "0" // also for -0
+	}
+	return strconv.FormatFloat(f, 'g', 15, 64)
+}
+
+// Fold returns the expression with its constant subexpressions evaluated, such as 15px for calc(10px + 5px), calc(10% + 5px) for calc(5% * 2 + 5px), or 2px for max(1px, 2px). Operands are only combined when they have the same unit, such that 1px and 1em as well as 1px and 1in are kept separate, and constants such as pi are kept as written. Sums and products next to a substitution function such as var() are not combined because the substituted tokens may change the precedence of the operators. The expression tree is not modified.
+func (n *MathNode) Fold() *MathNode {
+	m := n.fold()
+	if m.Type != MathNumber && m.Type != MathFunction {
+		m = &MathNode{Type: MathFunction, Name: []byte("calc"), Args: []*MathNode{m}}
+	}
+	return m
+}
+
+func (n *MathNode) fold() *MathNode {
+	switch n.Type {
+	case MathSum:
+		return foldMathSum(n)
+	case MathProduct:
+		return foldMathProduct(n)
+	case MathNegate:
+		arg := n.Args[0].fold()
+		if arg.Type == MathNumber {
+			return &MathNode{Type: MathNumber, Value: -arg.Value, Unit: arg.Unit}
+		}
+		return &MathNode{Type: MathNegate, Args: []*MathNode{arg}}
+	case MathInvert:
+		arg := n.Args[0].fold()
+		if arg.Type == MathNumber && arg.Unit == parse.NoUnit && arg.Value != 0.0 {
+			return &MathNode{Type: MathNumber, Value: 1.0 / arg.Value}
+		}
+		return &MathNode{Type: MathInvert, Args: []*MathNode{arg}}
+	case MathFunction:
+		if bytes.Equal(n.Name, []byte("calc")) {
+			return n.Args[0].fold() // calc() is only needed at the top level
+		}
+		m := &MathNode{Type: MathFunction, Name: n.Name, Args: make([]*MathNode, len(n.Args))}
+		for i, arg := range n.Args {
+			m.Args[i] = arg.fold()
+		}
+		if f := foldMathFunction(m); f != nil {
+			return f
+		}
+		return m
+	}
+	return n
+}
+
+// hasMathSubstitution returns true if any of the nodes is a substitution function.
+func hasMathSubstitution(nodes []*MathNode) bool {
+	for _, n := range nodes {
+		if n.Type == MathSubstitution {
+			return true
+		}
+	}
+	return false
+}
+
+// foldMathSum adds the numbers of the same unit together, where nested sums are flattened.
+func foldMathSum(n *MathNode) *MathNode {
+	args := make([]*MathNode, 0, len(n.Args))
+	for _, arg := range n.Args {
+		args = append(args, arg.fold())
+	}
+	if hasMathSubstitution(args) {
+		return &MathNode{Type: MathSum, Args: args}
+	}
+
+	terms := []*MathNode{}
+	for _, arg := range args {
+		if arg.Type == MathSum && !hasMathSubstitution(arg.Args) {
+			terms = append(terms, arg.Args...)
+		} else {
+			terms = append(terms, arg)
+		}
+	}
+	sum := &MathNode{Type: MathSum}
+	for _, term := range terms {
+		if term.Type == MathNumber {
+			if m := findMathNumber(sum.Args, term.Unit); m != nil {
+				if f := m.Value + term.Value; !math.IsInf(f, 0) {
+					m.Value = f
+					continue
+				}
+			}
+			term = &MathNode{Type: MathNumber, Value: term.Value, Unit: term.Unit}
+		}
+		sum.Args = append(sum.Args, term)
+	}
+	if len(sum.Args) == 1 {
+		return sum.Args[0]
+	}
+	return sum
+}
+
+// findMathNumber returns the first number of the unit in nodes, or nil.
+func findMathNumber(nodes []*MathNode, unit parse.Unit) *MathNode {
+	for _, n := range nodes {
+		if n.Type == MathNumber && n.Unit == unit {
+			return n
+		}
+	}
+	return nil
+}
+
+// foldMathProduct multiplies the numbers together, where nested products are flattened. Dimensions are divided by dimensions of the same unit, such as calc(4px / 2px) that is 2.
+func foldMathProduct(n *MathNode) *MathNode {
+	args := make([]*MathNode, 0, len(n.Args))
+	for _, arg := range n.Args {
+		args = append(args, arg.fold())
+	}
+	if hasMathSubstitution(args) {
+		return &MathNode{Type: MathProduct, Args: args}
+	}
+
+	terms := []*MathNode{}
+	for _, arg := range args {
+		if arg.Type == MathProduct && !hasMathSubstitution(arg.Args) {
+			terms = append(terms, arg.Args...)
+		} else {
+			terms = append(terms, arg)
+		}
+	}
+
+	// multiply numbers and divide dimensions of the same unit
+	factor := 1.0
+	var dividends, divisors []*MathNode
+	rest := []*MathNode{}
+	for _, term := range terms {
+		if term.Type == MathNumber && term.Unit == parse.NoUnit {
+			factor *= term.Value
+		} else if term.Type == MathNumber {
+			dividends = append(dividends, term)
+		} else if term.Type == MathInvert && term.Args[0].Type == MathNumber && term.Args[0].Unit != parse.NoUnit {
+			divisors = append(divisors, term.Args[0])
+		} else {
+			rest = append(rest, term)
+		}
+	}
+	for i := 0; i < len(divisors); i++ {
+		for j, dividend := range dividends {
+			if dividend.Unit == divisors[i].Unit && divisors[i].Value != 0.0 {
+				factor *= dividend.Value / divisors[i].Value
+				dividends = append(dividends[:j:j], dividends[j+1:]...)
+				divisors = append(divisors[:i:i], divisors[i+1:]...)
+				i--
+				break
+			}
+		}
+	}
+	if math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return &MathNode{Type: MathProduct, Args: terms}
+	}
+
+	product := &MathNode{Type: MathProduct}
+	if 0 < len(dividends) {
+		product.Args = append(product.Args, &MathNode{Type: MathNumber, Value: factor * dividends[0].Value, Unit: dividends[0].Unit})
+		product.Args = append(product.Args, dividends[1:]...)
+	} else if factor != 1.0 || len(rest) == 0 && len(divisors) == 0 {
+		product.Args = append(product.Args, &MathNode{Type: MathNumber, Value: factor})
+	}
+	product.Args = append(product.Args, rest...)
+	for _, divisor := range divisors {
+		product.Args = append(product.Args, &MathNode{Type: MathInvert, Args: []*MathNode{divisor}})
+	}
+	if len(product.Args) == 1 {
+		return product.Args[0]
+	} else if product.Args[0].Type == MathInvert {
+		product.Args = append([]*MathNode{{Type: MathNumber, Value: 1.0}}, product.Args...)
+	}
+	return product
+}
+
+// foldMathFunction evaluates a math function of which the arguments are numbers of the same unit, or returns nil. Percentages are not compared, since the value they resolve against may be negative. Trigonometric and exponential functions other than pow() and sqrt() are not evaluated, since their results are rarely shorter than the expression.
+func foldMathFunction(n *MathNode) *MathNode {
+	args := n.Args
+	if hasMathSubstitution(args) {
+		return nil
+	}
+	name := string(n.Name)
+	strategy := "nearest"
+	if name == "round" && args[0].Type == MathKeyword {
+		strategy = string(args[0].Name)
+		args = args[1:]
+	} else if name == "clamp" {
+		if args[0].Type == MathKeyword && args[2].Type == MathKeyword {
+			return args[1]
+		} else if args[0].Type == MathKeyword {
+			return foldMathFunction(&MathNode{Type: MathFunction, Name: []byte("min"), Args: args[1:]})
+		} else if args[2].Type == MathKeyword {
+			return foldMathFunction(&MathNode{Type: MathFunction, Name: []byte("max"), Args: args[:2]})
+		}
+	}
+
+	if name == "min" || name == "max" {
+		// keep the smallest or largest number of each unit
+		m := &MathNode{Type: MathFunction, Name: n.Name}
+		for _, arg := range args {
+			if arg.Type == MathNumber && arg.Unit != parse.Percent {
+				if prev := findMathNumber(m.Args, arg.Unit); prev != nil {
+					if name == "min" && arg.Value < prev.Value || name == "max" && prev.Value < arg.Value {
+						prev.Value = arg.Value
+					}
+					continue
+				}
+				arg = &MathNode{Type: MathNumber, Value: arg.Value, Unit: arg.Unit}
+			}
+			m.Args = append(m.Args, arg)
+		}
+		if len(m.Args) == 1 && m.Args[0].Type == MathNumber {
+			return m.Args[0]
+		} else if len(m.Args) < len(args) {
+			return m
+		}
+		return nil
+	}
+
+	unit := args[0].Unit
+	for _, arg := range args {
+		if arg.Type != MathNumber || arg.Unit != unit || unit == parse.Percent {
+			return nil
+		}
+	}
+	var f float64
+	switch name {
+	case "clamp":
+		f = math.Max(args[0].Value, math.Min(args[1].Value, args[2].Value))
+	case "abs":
+		f = math.Abs(args[0].Value)
+	case "sign":
+		if args[0].Value == 0.0 {
+			return nil
+		}
+		f, unit = 1.0, parse.NoUnit
+		if args[0].Value < 0.0 {
+			f = -1.0
+		}
+	case "round":
+		a, b := args[0].Value, 1.0
+		if len(args) == 2 {
+			b = args[1].Value
+		} else if unit != parse.NoUnit {
+			return nil
+		}
+		if b == 0.0 {
+			return nil
+		}
+		switch strategy {
+		case "nearest":
+			f = math.Floor(a/b+0.5) * b
+		case "up":
+			f = math.Ceil(a/b) * b
+		case "down":
+			f = math.Floor(a/b) * b
+		case "to-zero":
+			f = math.Trunc(a/b) * b
+		}
+	case "mod", "rem":
+		a, b := args[0].Value, args[1].Value
+		if b == 0.0 {
+			return nil
+		}
+		f = math.Mod(a, b)
+		if name == "mod" && f != 0.0 && (f < 0.0) != (b < 0.0) {
+			f += b
+		}
+	case "hypot":
+		for _, arg := range args {
+			f = math.Hypot(f, arg.Value)
+		}
+	case "pow":
+		f = math.Pow(args[0].Value, args[1].Value)
+	case "sqrt":
+		if args[0].Value < 0.0 {
+			return nil
+		}
+		f = math.Sqrt(args[0].Value)
+	default:
+		return nil
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil
+	}
+	return &MathNode{Type: MathNumber, Value: f, Unit: unit}
+}
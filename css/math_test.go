@@ -0,0 +1,111 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseMathExpr(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string
+		folded   string
+	}{
+		{"5px", "5px", "5px"},
+		{"-2.5E1%", "-25%", "-25%"},
+		{"calc(10px + 5px)", "calc(10px + 5px)", "15px"},
+		{"CALC(1PX + 1Px)", "calc(1px + 1px)", "2px"},
+		{"calc( 10px - 5px )", "calc(10px - 5px)", "5px"},
+		{"calc(1px - 2px)", "calc(1px - 2px)", "-1px"},
+		{"calc(1px + 1em + 2px)", "calc(1px + 1em + 2px)", "calc(3px + 1em)"},
+		{"calc(1px - 2em - 3px)", "calc(1px - 2em - 3px)", "calc(-2px - 2em)"},
+		{"calc(1px + 1in)", "calc(1px + 1in)", "calc(1px + 1in)"},
+		{"calc(100% - 2rem)", "calc(100% - 2rem)", "calc(100% - 2rem)"},
+		{"calc(5% * 2 + 5px)", "calc(5% * 2 + 5px)", "calc(10% + 5px)"},
+		{"calc(1px * 2 * 3)", "calc(1px * 2 * 3)", "6px"},
+		{"calc(2 * 3px)", "calc(2 * 3px)", "6px"},
+		{"calc(1s / 4)", "calc(1s / 4)", "0.25s"},
+		{"calc(4px / 2px)", "calc(4px / 2px)", "2"},
+		{"calc(1px * 1em / 1px)", "calc(1px * 1em / 1px)", "1em"},
+		{"calc(1px / 0)", "calc(1px / 0)", "calc(1px / 0)"},
+		{"calc(1px / 3)", "calc(1px / 3)", "0.333333333333333px"},
+		{"calc(0.1px + 0.2px)", "calc(0.1px + 0.2px)", "0.3px"},
+		{"calc((1px + 2px) * 3)", "calc((1px + 2px) * 3)", "9px"},
+		{"calc((10% + 1px) * 2)", "calc((10% + 1px) * 2)", "calc(2 * (10% + 1px))"},
+		{"calc(1px - (2em + 3%))", "calc(1px - (2em + 3%))", "calc(1px - (2em + 3%))"},
+		{"calc(1px - (2px + 3px))", "calc(1px - (2px + 3px))", "-4px"},
+		{"calc(1em + (2px + 3em))", "calc(1em + 2px + 3em)", "calc(4em + 2px)"},
+		{"calc(1em / (2 * 4))", "calc(1em / (2 * 4))", "0.125em"},
+		{"calc(1em / (2 * 1px) * 1px)", "calc(1em / (2 * 1px) * 1px)", "0.5em"},
+		{"calc(calc(1px + 2px) * 2)", "calc(calc(1px + 2px) * 2)", "6px"},
+		{"calc(pi * 1deg)", "calc(pi * 1deg)", "calc(1deg * pi)"},
+		{"calc(1px + 2px + var(--x))", "calc(1px + 2px + var(--x))", "calc(1px + 2px + var(--x))"},
+		{"calc(var(--x) * (1px + 2px))", "calc(var(--x) * (1px + 2px))", "calc(var(--x) * 3px)"},
+		{"calc(var( --x , 1px ) * 2)", "calc(var( --x,1px ) * 2)", "calc(var( --x,1px ) * 2)"},
+		{"min(1px, 2px)", "min(1px, 2px)", "1px"},
+		{"max(1px, 10%, 2px, 2em)", "max(1px, 10%, 2px, 2em)", "max(2px, 10%, 2em)"},
+		{"min(10%, 20%)", "min(10%, 20%)", "min(10%, 20%)"},
+		{"calc(min(1px, 2px) + max(3px, 4px))", "calc(min(1px, 2px) + max(3px, 4px))", "5px"},
+		{"min(calc(1px + 2%), 5em)", "min(calc(1px + 2%), 5em)", "min(1px + 2%, 5em)"},
+		{"clamp(1px, 5px, 3px)", "clamp(1px, 5px, 3px)", "3px"},
+		{"clamp(4px, 1px, 10px)", "clamp(4px, 1px, 10px)", "4px"},
+		{"clamp(1rem, 2.5vw, 2rem)", "clamp(1rem, 2.5vw, 2rem)", "clamp(1rem, 2.5vw, 2rem)"},
+		{"clamp(none, 5px, 3px)", "clamp(none, 5px, 3px)", "3px"},
+		{"clamp(none, 1px + 1em, none)", "clamp(none, 1px + 1em, none)", "calc(1px + 1em)"},
+		{"round(up, 11px, 5px)", "round(up, 11px, 5px)", "15px"},
+		{"round(2.5)", "round(2.5)", "3"},
+		{"round(-2.5)", "round(-2.5)", "-2"},
+		{"round(to-zero, -7, 2)", "round(to-zero, -7, 2)", "-6"},
+		{"mod(-7deg, 2deg)", "mod(-7deg, 2deg)", "1deg"},
+		{"rem(-7deg, 2deg)", "rem(-7deg, 2deg)", "-1deg"},
+		{"abs(-1s)", "abs(-1s)", "1s"},
+		{"sign(-1px)", "sign(-1px)", "-1"},
+		{"sign(-10%)", "sign(-10%)", "sign(-10%)"},
+		{"hypot(3px, 4px)", "hypot(3px, 4px)", "5px"},
+		{"pow(2, 10)", "pow(2, 10)", "1024"},
+		{"sqrt(-1)", "sqrt(-1)", "sqrt(-1)"},
+		{"sin(90deg)", "sin(90deg)", "sin(90deg)"},
+		{"calc(2 * -1 * (2em - 1px))", "calc(2 * -1 * (2em - 1px))", "calc(-2 * (2em - 1px))"},
+		{"calc(1px + 1s)", "", ""},
+		{"calc(1px+1px)", "", ""},
+		{"var(--x)", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			values, _ := ParseValue(parse.NewInputString(tt.value))
+			n, ok := ParseMathExpr(values)
+			test.T(t, ok, tt.expected != "")
+			if ok {
+				test.String(t, n.String(), tt.expected)
+				test.String(t, n.Fold().String(), tt.folded)
+				test.String(t, n.String(), tt.expected) // unmodified
+			}
+		})
+	}
+}
+
+func TestMathNode(t *testing.T) {
+	values, _ := ParseValue(parse.NewInputString("calc(1px - 2em / 3)"))
+	n, _ := ParseMathExpr(values)
+	test.T(t, n.Type, MathFunction)
+	test.String(t, string(n.Name), "calc")
+	sum := n.Args[0]
+	test.T(t, sum.Type, MathSum)
+	test.T(t, sum.Args[0].Unit, parse.Px)
+	test.T(t, sum.Args[1].Type, MathNegate)
+	product := sum.Args[1].Args[0]
+	test.T(t, product.Type, MathProduct)
+	test.T(t, product.Args[1].Type, MathInvert)
+	test.T(t, product.Args[1].Args[0].Value, 3.0)
+	test.String(t, MathSubstitution.String(), "Substitution")
+
+	// operands that are parenthesized when written
+	n = &MathNode{Type: MathProduct, Args: []*MathNode{
+		{Type: MathNumber, Value: 1.0, Unit: parse.Px},
+		{Type: MathInvert, Args: []*MathNode{{Type: MathProduct, Args: []*MathNode{{Type: MathNumber, Value: 2.0}, {Type: MathConstant, Name: []byte("pi")}}}}},
+	}}
+	test.String(t, n.String(), "1px / (2 * pi)")
+	test.String(t, (&MathNode{Type: MathNegate, Args: []*MathNode{{Type: MathConstant, Name: []byte("e")}}}).String(), "-1 * e")
+}
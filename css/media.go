@@ -0,0 +1,433 @@
+package css
+
+import (
+	"math"
+	"strings"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Device is a profile of the environment in which a document is rendered, such as a screen of a given viewport size or a printed page, against which media queries are evaluated by MatchMedia, so that static-rendering tools can decide which rules apply for a target. Lengths are in CSS pixels. Discrete features that are empty do not match any value, see ScreenDevice and PrintDevice for profiles with all features set.
+type Device struct {
+	Type string // media type in lowercase, such as screen or print
+
+	Width, Height             float64 // viewport size, or the page area for paged media
+	DeviceWidth, DeviceHeight float64 // screen or page size, equal to the viewport size when zero
+	FontSize                  float64 // initial font size for em and rem units, 16 when zero
+
+	Resolution float64 // pixel density in dppx, 1 when zero
+	Color      int     // bits per color component, zero for monochrome devices
+	ColorIndex int     // number of entries in the color lookup table
+	Monochrome int     // bits per pixel of a monochrome device
+	Grid       bool    // grid-based device such as a terminal
+
+	Scan                       string // interlace or progressive
+	Update                     string // none, slow, or fast
+	OverflowBlock              string // none, scroll, or paged
+	OverflowInline             string // none or scroll
+	ColorGamut                 string // srgb, p3, or rec2020
+	DynamicRange               string // standard or high
+	Pointer, AnyPointer        string // none, coarse, or fine, where AnyPointer equals Pointer when empty
+	Hover, AnyHover            string // none or hover, where AnyHover equals Hover when empty
+	PrefersColorScheme         string // light or dark
+	PrefersContrast            string // no-preference, more, less, or custom
+	PrefersReducedMotion       string // no-preference or reduce
+	PrefersReducedTransparency string // no-preference or reduce
+	PrefersReducedData         string // no-preference or reduce
+	ForcedColors               string // none or active
+	InvertedColors             string // none or inverted
+	DisplayMode                string // fullscreen, standalone, minimal-ui, browser, or picture-in-picture
+	Scripting                  string // none, initial-only, or enabled
+}
+
+// ScreenDevice returns the profile of a desktop browser with a viewport of the given size in CSS pixels, with a fine pointer that can hover and the light color scheme.
+func ScreenDevice(width, height float64) Device {
+	return Device{
+		Type:                       "screen",
+		Width:                      width,
+		Height:                     height,
+		Resolution:                 1.0,
+		Color:                      8,
+		Scan:                       "progressive",
+		Update:                     "fast",
+		OverflowBlock:              "scroll",
+		OverflowInline:             "scroll",
+		ColorGamut:                 "srgb",
+		DynamicRange:               "standard",
+		Pointer:                    "fine",
+		Hover:                      "hover",
+		PrefersColorScheme:         "light",
+		PrefersContrast:            "no-preference",
+		PrefersReducedMotion:       "no-preference",
+		PrefersReducedTransparency: "no-preference",
+		PrefersReducedData:         "no-preference",
+		ForcedColors:               "none",
+		InvertedColors:             "none",
+		DisplayMode:                "browser",
+		Scripting:                  "enabled",
+	}
+}
+
+// PrintDevice returns the profile of a color printer with a page area of the given size in CSS pixels, such as 794 by 1123 for A4 paper without margins, at 300 dots per inch. Paged media cannot scroll, update, or be pointed at, and scripts only run before printing.
+func PrintDevice(width, height float64) Device {
+	return Device{
+		Type:                       "print",
+		Width:                      width,
+		Height:                     height,
+		Resolution:                 300.0 / 96.0,
+		Color:                      8,
+		Update:                     "none",
+		OverflowBlock:              "paged",
+		OverflowInline:             "none",
+		ColorGamut:                 "srgb",
+		DynamicRange:               "standard",
+		Pointer:                    "none",
+		Hover:                      "none",
+		PrefersColorScheme:         "light",
+		PrefersContrast:            "no-preference",
+		PrefersReducedMotion:       "no-preference",
+		PrefersReducedTransparency: "no-preference",
+		PrefersReducedData:         "no-preference",
+		ForcedColors:               "none",
+		InvertedColors:             "none",
+		DisplayMode:                "browser",
+		Scripting:                  "initial-only",
+	}
+}
+
+// MatchMedia returns true if the device matches the media query list, such as the prelude of @media or the media queries of @import, see ParseMediaQueryList. An empty list matches all devices. Media queries that are invalid do not match, and neither do media queries whose result depends on unknown media features.
+func (d Device) MatchMedia(query []Token) bool {
+	return ParseMediaQueryList(query).Evaluate(d) == MediaTrue
+}
+
+// MatchMediaType evaluates a lowercase media type, where deprecated media types such as tv never match.
+func (d Device) MatchMediaType(mediaType string) MediaResult {
+	switch mediaType {
+	case "all":
+		return MediaTrue
+	case "screen", "print", "speech":
+		return mediaResult(mediaType == d.Type)
+	}
+	return MediaFalse
+}
+
+// MatchMediaFeature evaluates a media feature, which returns MediaUnknown if the feature or its value is unknown or invalid.
+func (d Device) MatchMediaFeature(feature *MediaFeature) MediaResult {
+	name := string(feature.Name)
+	if len(feature.Comparisons) == 0 {
+		match, ok := d.evalMediaFeature(name, feature.Value)
+		if !ok {
+			return MediaUnknown
+		}
+		return mediaResult(match)
+	}
+
+	f, typ, ok := d.rangeMediaFeature(name)
+	if !ok {
+		return MediaUnknown
+	}
+	match := true
+	for _, cmp := range feature.Comparisons {
+		v, ok := d.mediaValue(cmp.Value, typ)
+		if !ok {
+			return MediaUnknown
+		} else if cmp.Reversed {
+			match = match && cmp.Operator.Compare(v, f)
+		} else {
+			match = match && cmp.Operator.Compare(f, v)
+		}
+	}
+	return mediaResult(match)
+}
+
+// PartialDevice is a Device of which only some media features are known, such as the viewport width of a target, so that media queries that always or never match can be pruned while the others evaluate to MediaUnknown. The media type is unknown when it is empty.
+type PartialDevice struct {
+	Device   Device
+	Features []string // names of the known media features without min- and max- prefixes
+}
+
+// MatchMediaType evaluates a lowercase media type, see Device.MatchMediaType.
+func (d PartialDevice) MatchMediaType(mediaType string) MediaResult {
+	if d.Device.Type == "" && mediaType != "all" {
+		return MediaUnknown
+	}
+	return d.Device.MatchMediaType(mediaType)
+}
+
+// MatchMediaFeature evaluates a media feature if it is known, see Device.MatchMediaFeature.
+func (d PartialDevice) MatchMediaFeature(feature *MediaFeature) MediaResult {
+	name := string(feature.Name)
+	if len(feature.Comparisons) == 0 && (strings.HasPrefix(name, "min-") || strings.HasPrefix(name, "max-")) {
+		name = name[4:]
+	}
+	for _, known := range d.Features {
+		if known == name {
+			return d.Device.MatchMediaFeature(feature)
+		}
+	}
+	return MediaUnknown
+}
+
+type mediaValueType int
+
+const (
+	lengthMediaValue mediaValueType = iota
+	resolutionMediaValue
+	ratioMediaValue
+	integerMediaValue
+)
+
+// rangeMediaFeature returns the value of a range feature in px, dppx, or as a ratio or integer, and its value type.
+func (d Device) rangeMediaFeature(name string) (float64, mediaValueType, bool) {
+	switch name {
+	case "width":
+		return d.Width, lengthMediaValue, true
+	case "height":
+		return d.Height, lengthMediaValue, true
+	case "device-width":
+		w, _ := d.deviceSize()
+		return w, lengthMediaValue, true
+	case "device-height":
+		_, h := d.deviceSize()
+		return h, lengthMediaValue, true
+	case "aspect-ratio":
+		return ratio(d.Width, d.Height), ratioMediaValue, true
+	case "device-aspect-ratio":
+		return ratio(d.deviceSize()), ratioMediaValue, true
+	case "resolution":
+		if d.Resolution == 0.0 {
+			return 1.0, resolutionMediaValue, true
+		}
+		return d.Resolution, resolutionMediaValue, true
+	case "color":
+		return float64(d.Color), integerMediaValue, true
+	case "color-index":
+		return float64(d.ColorIndex), integerMediaValue, true
+	case "monochrome":
+		return float64(d.Monochrome), integerMediaValue, true
+	}
+	return 0.0, 0, false
+}
+
+func (d Device) deviceSize() (float64, float64) {
+	if d.DeviceWidth == 0.0 && d.DeviceHeight == 0.0 {
+		return d.Width, d.Height
+	}
+	return d.DeviceWidth, d.DeviceHeight
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0.0 {
+		return 0.0
+	}
+	return a / b
+}
+
+// mediaKeywords are the values of the discrete media features, in ascending order for color-gamut.
+var mediaKeywords = map[string][]string{
+	"orientation":                  {"portrait", "landscape"},
+	"scan":                         {"interlace", "progressive"},
+	"update":                       {"none", "slow", "fast"},
+	"overflow-block":               {"none", "scroll", "paged"},
+	"overflow-inline":              {"none", "scroll"},
+	"color-gamut":                  {"srgb", "p3", "rec2020"},
+	"dynamic-range":                {"standard", "high"},
+	"pointer":                      {"none", "coarse", "fine"},
+	"any-pointer":                  {"none", "coarse", "fine"},
+	"hover":                        {"none", "hover"},
+	"any-hover":                    {"none", "hover"},
+	"prefers-color-scheme":         {"light", "dark"},
+	"prefers-contrast":             {"no-preference", "more", "less", "custom"},
+	"prefers-reduced-motion":       {"no-preference", "reduce"},
+	"prefers-reduced-transparency": {"no-preference", "reduce"},
+	"prefers-reduced-data":         {"no-preference", "reduce"},
+	"forced-colors":                {"none", "active"},
+	"inverted-colors":              {"none", "inverted"},
+	"display-mode":                 {"fullscreen", "standalone", "minimal-ui", "browser", "picture-in-picture"},
+	"scripting":                    {"none", "initial-only", "enabled"},
+}
+
+// discreteMediaFeature returns the keyword of a discrete feature, or the empty string if it does not match any value.
+func (d Device) discreteMediaFeature(name string) string {
+	switch name {
+	case "orientation":
+		if d.Width <= d.Height {
+			return "portrait"
+		}
+		return "landscape"
+	case "scan":
+		return d.Scan
+	case "update":
+		return d.Update
+	case "overflow-block":
+		return d.OverflowBlock
+	case "overflow-inline":
+		return d.OverflowInline
+	case "color-gamut":
+		return d.ColorGamut
+	case "dynamic-range":
+		return d.DynamicRange
+	case "pointer":
+		return d.Pointer
+	case "any-pointer":
+		if d.AnyPointer == "" {
+			return d.Pointer
+		}
+		return d.AnyPointer
+	case "hover":
+		return d.Hover
+	case "any-hover":
+		if d.AnyHover == "" {
+			return d.Hover
+		}
+		return d.AnyHover
+	case "prefers-color-scheme":
+		return d.PrefersColorScheme
+	case "prefers-contrast":
+		return d.PrefersContrast
+	case "prefers-reduced-motion":
+		return d.PrefersReducedMotion
+	case "prefers-reduced-transparency":
+		return d.PrefersReducedTransparency
+	case "prefers-reduced-data":
+		return d.PrefersReducedData
+	case "forced-colors":
+		return d.ForcedColors
+	case "inverted-colors":
+		return d.InvertedColors
+	case "display-mode":
+		return d.DisplayMode
+	case "scripting":
+		return d.Scripting
+	}
+	return ""
+}
+
+// evalMediaFeature evaluates a media feature with its value, or in a boolean context when value is nil. It returns false for ok if the feature or its value is invalid.
+func (d Device) evalMediaFeature(name string, value []Token) (bool, bool) {
+	prefix := 0
+	if strings.HasPrefix(name, "min-") {
+		name, prefix = name[4:], 1
+	} else if strings.HasPrefix(name, "max-") {
+		name, prefix = name[4:], -1
+	}
+
+	if f, typ, ok := d.rangeMediaFeature(name); ok {
+		if value == nil {
+			return f != 0.0, prefix == 0
+		}
+		v, ok := d.mediaValue(value, typ)
+		if !ok {
+			return false, false
+		} else if prefix == 1 {
+			return v <= f, true
+		} else if prefix == -1 {
+			return f <= v, true
+		}
+		return f == v, true
+	} else if prefix != 0 {
+		return false, false
+	} else if name == "grid" {
+		if value == nil {
+			return d.Grid, true
+		} else if len(value) != 1 || value[0].TokenType != NumberToken || string(value[0].Data) != "0" && string(value[0].Data) != "1" {
+			return false, false
+		}
+		return d.Grid == (value[0].Data[0] == '1'), true
+	}
+
+	keywords, ok := mediaKeywords[name]
+	if !ok {
+		return false, false
+	}
+	keyword := d.discreteMediaFeature(name)
+	if value == nil {
+		return keyword != "" && keyword != "none" && keyword != "no-preference", true
+	} else if len(value) != 1 || value[0].TokenType != IdentToken {
+		return false, false
+	}
+	index := -1
+	for i, k := range keywords {
+		if parse.EqualFold(value[0].Data, []byte(k)) {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return false, false
+	} else if name == "color-gamut" {
+		// a device with a wider gamut also covers the narrower gamuts
+		for i, k := range keywords {
+			if k == keyword {
+				return index <= i, true
+			}
+		}
+		return false, true
+	} else if name == "dynamic-range" && index == 0 {
+		return keyword != "", true
+	}
+	return keyword == keywords[index], true
+}
+
+// mediaValue returns the value of a media feature in px for lengths or in dppx for resolutions.
+func (d Device) mediaValue(value []Token, typ mediaValueType) (float64, bool) {
+	if typ == ratioMediaValue {
+		// <number> [/ <number>]?
+		if value[0].TokenType != NumberToken {
+			return 0.0, false
+		}
+		_, a, _ := parse.DimensionValue(value[0].Data)
+		if len(value) == 1 {
+			return a, true
+		} else if len(value) != 3 || value[1].TokenType != DelimToken || value[1].Data[0] != '/' || value[2].TokenType != NumberToken {
+			return 0.0, false
+		}
+		_, b, _ := parse.DimensionValue(value[2].Data)
+		if a < 0.0 || b < 0.0 {
+			return 0.0, false
+		}
+		return ratio(a, b), true
+	} else if len(value) != 1 || value[0].TokenType != NumberToken && value[0].TokenType != DimensionToken {
+		return 0.0, false
+	}
+
+	_, f, unit := parse.DimensionValue(value[0].Data)
+	switch typ {
+	case integerMediaValue:
+		return f, unit == parse.NoUnit && f == float64(int(f))
+	case resolutionMediaValue:
+		return f * unit.Factor(), unit.Category() == parse.ResolutionCategory
+	}
+	if unit == parse.NoUnit {
+		return 0.0, f == 0.0
+	} else if unit.IsAbsolute() && unit.Category() == parse.LengthCategory {
+		return f * unit.Factor(), true
+	}
+	fontSize := d.FontSize
+	if fontSize == 0.0 {
+		fontSize = 16.0
+	}
+	switch unit {
+	case parse.Em, parse.Rem:
+		return f * fontSize, true
+	case parse.Vw:
+		return f * d.Width / 100.0, true
+	case parse.Vh:
+		return f * d.Height / 100.0, true
+	case parse.Vmin:
+		return f * math.Min(d.Width, d.Height) / 100.0, true
+	case parse.Vmax:
+		return f * math.Max(d.Width, d.Height) / 100.0, true
+	}
+	return 0.0, false
+}
+
+func trimMediaWhitespace(tokens []Token) []Token {
+	for 0 < len(tokens) && (tokens[0].TokenType == WhitespaceToken || tokens[0].TokenType == CommentToken) {
+		tokens = tokens[1:]
+	}
+	for 0 < len(tokens) && (tokens[len(tokens)-1].TokenType == WhitespaceToken || tokens[len(tokens)-1].TokenType == CommentToken) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
@@ -0,0 +1,113 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func mediaPrelude(t *testing.T, query string) []Token {
+	s, err := ParseStylesheet(parse.NewInputString("@media "+query+" {}"), false)
+	test.Error(t, err)
+	test.T(t, len(s.Rules), 1)
+	return s.Rules[0].(*AtRule).Prelude
+}
+
+func TestMatchMedia(t *testing.T) {
+	screen := ScreenDevice(1024, 768)
+	print := PrintDevice(794, 1123)
+	var tests = []struct {
+		query  string
+		screen bool
+		print  bool
+	}{
+		{"", true, true},
+		{"all", true, true},
+		{"screen", true, false},
+		{"print", false, true},
+		{"PRINT", false, true},
+		{"only screen", true, false},
+		{"not print", true, false},
+		{"speech", false, false},
+		{"tv", false, false},
+		{"screen, print", true, true},
+		{"(min-width: 800px)", true, false},
+		{"(max-width: 800px)", false, true},
+		{"(width: 1024px)", true, false},
+		{"(width)", true, true},
+		{"(width: 0)", false, false},
+		{"(min-width: 50em)", true, false},
+		{"(min-width: 20cm)", true, true},
+		{"(min-height: 100vw)", false, true},
+		{"screen and (min-width: 600px) and (max-width: 1200px)", true, false},
+		{"not screen and (min-width: 600px)", false, true},
+		{"(orientation: landscape)", true, false},
+		{"(orientation: portrait)", false, true},
+		{"(min-aspect-ratio: 4/3)", true, false},
+		{"(aspect-ratio: 4 / 3)", true, false},
+		{"(max-aspect-ratio: 1)", false, true},
+		{"(min-resolution: 2dppx)", false, true},
+		{"(min-resolution: 96dpi)", true, true},
+		{"(color)", true, true},
+		{"(min-color: 8)", true, true},
+		{"(monochrome)", false, false},
+		{"(monochrome: 0)", true, true},
+		{"(grid)", false, false},
+		{"(grid: 0)", true, true},
+		{"(hover)", true, false},
+		{"(hover: hover)", true, false},
+		{"(pointer: fine)", true, false},
+		{"(any-pointer: none)", false, true},
+		{"(overflow-block: paged)", false, true},
+		{"(update)", true, false},
+		{"(scripting: initial-only)", false, true},
+		{"(prefers-color-scheme: dark)", false, false},
+		{"(prefers-reduced-motion)", false, false},
+		{"(prefers-reduced-motion: no-preference)", true, true},
+		{"(color-gamut: srgb)", true, true},
+		{"(color-gamut: p3)", false, false},
+		{"(dynamic-range: standard)", true, true},
+		{"(dynamic-range: high)", false, false},
+		{"print and (min-width: 600px), (hover: hover)", true, true},
+
+		// invalid or unknown
+		{"(unknown)", false, false},
+		{"not all and (unknown)", false, false},
+		{"not screen and (hover: hovering)", false, true}, // not (false and unknown)
+		{"(min-orientation: portrait)", false, false},
+		{"(min-color)", false, false},
+		{"(width: 10)", false, false},
+		{"(width: 10deg)", false, false},
+		{"(color: 1.5)", false, false},
+		{"(grid: 2)", false, false},
+		{"screen and", false, false},
+		{"screen or print", false, false},
+		{"not only screen", false, false},
+		{"and", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query := mediaPrelude(t, tt.query)
+			test.T(t, screen.MatchMedia(query), tt.screen, "screen")
+			test.T(t, print.MatchMedia(query), tt.print, "print")
+		})
+	}
+}
+
+func TestMatchMediaDevice(t *testing.T) {
+	d := Device{Type: "screen", Width: 400, Height: 800, DeviceWidth: 1080, DeviceHeight: 2160, FontSize: 20, Resolution: 2.625, Pointer: "coarse", AnyPointer: "fine", ColorGamut: "p3"}
+	test.That(t, d.MatchMedia(mediaPrelude(t, "(max-width: 20em)")))
+	test.That(t, !d.MatchMedia(mediaPrelude(t, "(max-width: 19em)")))
+	test.That(t, d.MatchMedia(mediaPrelude(t, "(min-device-width: 1000px)")))
+	test.That(t, d.MatchMedia(mediaPrelude(t, "(device-aspect-ratio: 1/2)")))
+	test.That(t, d.MatchMedia(mediaPrelude(t, "(min-resolution: 2x)")))
+	test.That(t, d.MatchMedia(mediaPrelude(t, "(pointer: coarse) and (any-pointer: fine)")))
+	test.That(t, d.MatchMedia(mediaPrelude(t, "(color-gamut: srgb) and (color-gamut: p3)")))
+	test.That(t, !d.MatchMedia(mediaPrelude(t, "(color-gamut: rec2020)")))
+	test.That(t, !d.MatchMedia(mediaPrelude(t, "(hover: none)")), "empty features match no value")
+	test.That(t, !d.MatchMedia(mediaPrelude(t, "(color)")))
+
+	tv := Device{Type: "tv"}
+	test.That(t, !tv.MatchMedia(mediaPrelude(t, "tv")), "deprecated media types match nothing")
+}
@@ -0,0 +1,603 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// MediaResult is the result of evaluating a media query, which is unknown when it depends on media features of which the value is not known, see https://www.w3.org/TR/mediaqueries-4/#evaluating.
+type MediaResult uint32
+
+// MediaResult values.
+const (
+	MediaFalse MediaResult = iota
+	MediaTrue
+	MediaUnknown
+)
+
+// String returns the string representation of a MediaResult.
+func (r MediaResult) String() string {
+	switch r {
+	case MediaFalse:
+		return "false"
+	case MediaTrue:
+		return "true"
+	case MediaUnknown:
+		return "unknown"
+	}
+	return "Invalid(" + strconv.Itoa(int(r)) + ")"
+}
+
+func mediaResult(b bool) MediaResult {
+	if b {
+		return MediaTrue
+	}
+	return MediaFalse
+}
+
+func (r MediaResult) and(s MediaResult) MediaResult {
+	if r == MediaFalse || s == MediaFalse {
+		return MediaFalse
+	} else if r == MediaUnknown || s == MediaUnknown {
+		return MediaUnknown
+	}
+	return MediaTrue
+}
+
+func (r MediaResult) or(s MediaResult) MediaResult {
+	if r == MediaTrue || s == MediaTrue {
+		return MediaTrue
+	} else if r == MediaUnknown || s == MediaUnknown {
+		return MediaUnknown
+	}
+	return MediaFalse
+}
+
+func (r MediaResult) not() MediaResult {
+	switch r {
+	case MediaFalse:
+		return MediaTrue
+	case MediaTrue:
+		return MediaFalse
+	}
+	return MediaUnknown
+}
+
+// MediaFeatures provides the media type and the media features of an environment against which media queries are evaluated, such as a Device.
+type MediaFeatures interface {
+	// MatchMediaType evaluates a lowercase media type, such as all or print.
+	MatchMediaType(mediaType string) MediaResult
+	// MatchMediaFeature evaluates a media feature, which returns MediaUnknown if the feature or its value is unknown or invalid.
+	MatchMediaFeature(feature *MediaFeature) MediaResult
+}
+
+////////////////////////////////////////////////////////////////
+
+// MediaQueryList is a comma-separated list of media queries, which matches if any of its media queries matches. An empty list matches all environments.
+type MediaQueryList []MediaQuery
+
+// MediaQuery is a media query, which is a media type optionally followed by and with a condition, or only a condition.
+type MediaQuery struct {
+	Not       bool            // negated with not
+	Only      bool            // preceded by only
+	Type      []byte          // lowercase media type, or nil
+	Condition *MediaCondition // condition following the media type, or nil
+
+	// Invalid is set for media queries that fail to parse, which never match as if they were not all.
+	Invalid bool
+}
+
+// MediaConditionType determines the type of a media condition.
+type MediaConditionType uint32
+
+// MediaConditionType values.
+const (
+	MediaFeatureCondition    MediaConditionType = iota // (width >= 600px)
+	MediaNotCondition                                  // not (hover)
+	MediaAndCondition                                  // (a) and (b)
+	MediaOrCondition                                   // (a) or (b)
+	GeneralEnclosedCondition                           // (unknown syntax) or function(...), which evaluates to unknown
+)
+
+// String returns the string representation of a MediaConditionType.
+func (t MediaConditionType) String() string {
+	switch t {
+	case MediaFeatureCondition:
+		return "Feature"
+	case MediaNotCondition:
+		return "Not"
+	case MediaAndCondition:
+		return "And"
+	case MediaOrCondition:
+		return "Or"
+	case GeneralEnclosedCondition:
+		return "GeneralEnclosed"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// MediaCondition is a media feature, a general enclosed expression, or a combination of media conditions with not, and, or or. Parenthesized conditions are nested in their parent.
+type MediaCondition struct {
+	Type       MediaConditionType
+	Conditions []MediaCondition // operand of not, or the operands of and and or
+	Feature    MediaFeature     // for MediaFeatureCondition
+	Tokens     []Token          // for GeneralEnclosedCondition, including the opening function or parenthesis and the closing parenthesis
+}
+
+// MediaOperator is the comparison operator of a media feature in range syntax.
+type MediaOperator uint32
+
+// MediaOperator values.
+const (
+	MediaEqual        MediaOperator = iota // =
+	MediaLess                              // <
+	MediaLessEqual                         // <=
+	MediaGreater                           // >
+	MediaGreaterEqual                      // >=
+)
+
+// String returns the string representation of a MediaOperator.
+func (op MediaOperator) String() string {
+	switch op {
+	case MediaEqual:
+		return "="
+	case MediaLess:
+		return "<"
+	case MediaLessEqual:
+		return "<="
+	case MediaGreater:
+		return ">"
+	case MediaGreaterEqual:
+		return ">="
+	}
+	return "Invalid(" + strconv.Itoa(int(op)) + ")"
+}
+
+// Compare returns true if a op b holds.
+func (op MediaOperator) Compare(a, b float64) bool {
+	switch op {
+	case MediaEqual:
+		return a == b
+	case MediaLess:
+		return a < b
+	case MediaLessEqual:
+		return a <= b
+	case MediaGreater:
+		return a > b
+	case MediaGreaterEqual:
+		return a >= b
+	}
+	return false
+}
+
+// MediaFeature is a media feature in a boolean context such as (hover), a plain media feature such as (min-width: 600px), or a media feature in range syntax such as (400px < width <= 700px).
+type MediaFeature struct {
+	Name  []byte  // lowercase name, including the min- or max- prefix of plain media features
+	Value []Token // value of a plain media feature without whitespace, or nil
+	// Comparisons are the comparisons of a media feature in range syntax, in the order as written.
+	Comparisons []MediaComparison
+}
+
+// MediaComparison is a comparison of a media feature in range syntax with a value.
+type MediaComparison struct {
+	Operator MediaOperator
+	Value    []Token // value without whitespace
+	Reversed bool    // value is written before the name, as in 600px <= width, which compares as value Operator feature
+}
+
+// ParseMediaQueryList parses a media query list, such as the prelude of @media or the media queries of @import, following Media Queries Level 4 including the range syntax, not, and, and or conditions, and general enclosed expressions. Media queries that fail to parse are marked invalid while the others are kept.
+func ParseMediaQueryList(tokens []Token) MediaQueryList {
+	list := MediaQueryList{}
+	level, start := 0, 0
+	for i, t := range tokens {
+		switch t.TokenType {
+		case FunctionToken, LeftParenthesisToken, LeftBracketToken, LeftBraceToken:
+			level++
+		case RightParenthesisToken, RightBracketToken, RightBraceToken:
+			level--
+		case CommaToken:
+			if level == 0 {
+				list = append(list, parseMediaQuery(tokens[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if start != 0 || len(trimMediaWhitespace(tokens)) != 0 {
+		list = append(list, parseMediaQuery(tokens[start:]))
+	}
+	return list
+}
+
+// Evaluate evaluates the media query list, which is true if any media query is true, and unknown if none is true but some are unknown.
+func (l MediaQueryList) Evaluate(features MediaFeatures) MediaResult {
+	if len(l) == 0 {
+		return MediaTrue
+	}
+	r := MediaFalse
+	for _, q := range l {
+		r = r.or(q.Evaluate(features))
+	}
+	return r
+}
+
+// String returns the media query list in a normalized form.
+func (l MediaQueryList) String() string {
+	sb := strings.Builder{}
+	for i, q := range l {
+		if i != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(q.String())
+	}
+	return sb.String()
+}
+
+// Evaluate evaluates the media query, which is false for invalid media queries.
+func (q MediaQuery) Evaluate(features MediaFeatures) MediaResult {
+	if q.Invalid {
+		return MediaFalse
+	}
+	r := MediaTrue
+	if q.Type != nil {
+		r = features.MatchMediaType(string(q.Type))
+	}
+	if q.Condition != nil {
+		r = r.and(q.Condition.Evaluate(features))
+	}
+	if q.Not {
+		r = r.not()
+	}
+	return r
+}
+
+// String returns the media query in a normalized form, where invalid media queries are written as not all.
+func (q MediaQuery) String() string {
+	if q.Invalid {
+		return "not all"
+	}
+	s := ""
+	if q.Not {
+		s = "not "
+	} else if q.Only {
+		s = "only "
+	}
+	if q.Type != nil {
+		s += string(q.Type)
+		if q.Condition != nil {
+			s += " and "
+		}
+	}
+	if q.Condition != nil {
+		s += q.Condition.String()
+	}
+	return s
+}
+
+// Evaluate evaluates the media condition, where general enclosed expressions are unknown.
+func (c *MediaCondition) Evaluate(features MediaFeatures) MediaResult {
+	switch c.Type {
+	case MediaFeatureCondition:
+		return features.MatchMediaFeature(&c.Feature)
+	case MediaNotCondition:
+		return c.Conditions[0].Evaluate(features).not()
+	case MediaAndCondition:
+		r := MediaTrue
+		for i := range c.Conditions {
+			r = r.and(c.Conditions[i].Evaluate(features))
+		}
+		return r
+	case MediaOrCondition:
+		r := MediaFalse
+		for i := range c.Conditions {
+			r = r.or(c.Conditions[i].Evaluate(features))
+		}
+		return r
+	}
+	return MediaUnknown
+}
+
+// String returns the media condition in a normalized form.
+func (c *MediaCondition) String() string {
+	switch c.Type {
+	case MediaFeatureCondition:
+		return c.Feature.String()
+	case MediaNotCondition:
+		return "not " + c.Conditions[0].operandString()
+	case MediaAndCondition, MediaOrCondition:
+		sep := " and "
+		if c.Type == MediaOrCondition {
+			sep = " or "
+		}
+		s := ""
+		for i := range c.Conditions {
+			if i != 0 {
+				s += sep
+			}
+			s += c.Conditions[i].operandString()
+		}
+		return s
+	}
+	s := ""
+	for _, t := range c.Tokens {
+		s += string(t.Data)
+	}
+	return s
+}
+
+// operandString returns the condition as an operand of not, and, or or, which parenthesizes nested combinations.
+func (c *MediaCondition) operandString() string {
+	if c.Type == MediaNotCondition || c.Type == MediaAndCondition || c.Type == MediaOrCondition {
+		return "(" + c.String() + ")"
+	}
+	return c.String()
+}
+
+// String returns the media feature in a normalized form.
+func (f MediaFeature) String() string {
+	s := "(" + string(f.Name)
+	if f.Value != nil {
+		s += ":" + mediaValueString(f.Value)
+	} else if 0 < len(f.Comparisons) {
+		s = "("
+		for i, cmp := range f.Comparisons {
+			if cmp.Reversed {
+				s += mediaValueString(cmp.Value) + cmp.Operator.String()
+			}
+			if i == 0 {
+				s += string(f.Name)
+			}
+			if !cmp.Reversed {
+				s += cmp.Operator.String() + mediaValueString(cmp.Value)
+			}
+		}
+	}
+	return s + ")"
+}
+
+func mediaValueString(value []Token) string {
+	s := ""
+	for _, t := range value {
+		s += string(t.Data)
+	}
+	return s
+}
+
+////////////////////////////////////////////////////////////////
+
+type mediaParser struct {
+	tokens []Token
+	pos    int
+}
+
+func (p *mediaParser) skipWhitespace() {
+	for p.pos < len(p.tokens) && (p.tokens[p.pos].TokenType == WhitespaceToken || p.tokens[p.pos].TokenType == CommentToken) {
+		p.pos++
+	}
+}
+
+// peek returns the next token after whitespace, or an ErrorToken at the end.
+func (p *mediaParser) peek() Token {
+	p.skipWhitespace()
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return Token{ErrorToken, nil}
+}
+
+// keyword consumes the next token if it is the given case-insensitive identifier.
+func (p *mediaParser) keyword(name string) bool {
+	if t := p.peek(); t.TokenType == IdentToken && parse.EqualFold(t.Data, []byte(name)) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// block returns the index after the closing parenthesis of the block or function at the position, or -1 if it is not closed.
+func (p *mediaParser) block() int {
+	level := 0
+	for i := p.pos; i < len(p.tokens); i++ {
+		switch p.tokens[i].TokenType {
+		case FunctionToken, LeftParenthesisToken, LeftBracketToken, LeftBraceToken:
+			level++
+		case RightParenthesisToken, RightBracketToken, RightBraceToken:
+			level--
+			if level == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// parseMediaQuery parses [not|only]? <media-type> [and <media-condition-without-or>]? or <media-condition>.
+func parseMediaQuery(tokens []Token) MediaQuery {
+	p := &mediaParser{tokens: tokens}
+	q := MediaQuery{}
+	if t := p.peek(); t.TokenType == IdentToken {
+		pos := p.pos
+		if p.keyword("not") {
+			q.Not = true
+		} else if p.keyword("only") {
+			q.Only = true
+		}
+		if t = p.peek(); q.Not && t.TokenType != IdentToken {
+			// not <media-in>
+			p.pos = pos
+			q.Not = false
+		} else if t.TokenType != IdentToken {
+			return MediaQuery{Invalid: true}
+		} else {
+			mediaType := parse.ToLower(parse.Copy(t.Data))
+			switch string(mediaType) {
+			case "not", "only", "and", "or", "layer":
+				return MediaQuery{Invalid: true}
+			}
+			q.Type = mediaType
+			p.pos++
+			if p.peek().TokenType == ErrorToken {
+				return q
+			} else if !p.keyword("and") {
+				return MediaQuery{Invalid: true}
+			}
+			c, ok := p.parseCondition(false)
+			if !ok || p.peek().TokenType != ErrorToken {
+				return MediaQuery{Invalid: true}
+			}
+			q.Condition = &c
+			return q
+		}
+	}
+	c, ok := p.parseCondition(true)
+	if !ok || p.peek().TokenType != ErrorToken {
+		return MediaQuery{Invalid: true}
+	}
+	q.Condition = &c
+	return q
+}
+
+// parseCondition parses not <media-in>, or <media-in> followed by any number of and <media-in> or of or <media-in>.
+func (p *mediaParser) parseCondition(allowOr bool) (MediaCondition, bool) {
+	if p.keyword("not") {
+		in, ok := p.parseIn()
+		if !ok {
+			return MediaCondition{}, false
+		}
+		return MediaCondition{Type: MediaNotCondition, Conditions: []MediaCondition{in}}, true
+	}
+
+	in, ok := p.parseIn()
+	if !ok {
+		return MediaCondition{}, false
+	}
+	c := MediaCondition{Type: MediaAndCondition, Conditions: []MediaCondition{in}}
+	if p.keyword("and") {
+	} else if allowOr && p.keyword("or") {
+		c.Type = MediaOrCondition
+	} else {
+		return in, true
+	}
+	op := "and"
+	if c.Type == MediaOrCondition {
+		op = "or"
+	}
+	for {
+		in, ok := p.parseIn()
+		if !ok {
+			return MediaCondition{}, false
+		}
+		c.Conditions = append(c.Conditions, in)
+		if !p.keyword(op) {
+			return c, true
+		}
+	}
+}
+
+// parseIn parses a parenthesized media condition, a media feature, or a general enclosed expression.
+func (p *mediaParser) parseIn() (MediaCondition, bool) {
+	t := p.peek()
+	if t.TokenType != LeftParenthesisToken && t.TokenType != FunctionToken {
+		return MediaCondition{}, false
+	}
+	end := p.block()
+	if end == -1 {
+		return MediaCondition{}, false
+	}
+	block := p.tokens[p.pos:end]
+	p.pos = end
+
+	if t.TokenType == LeftParenthesisToken {
+		inner := block[1 : len(block)-1]
+		sub := &mediaParser{tokens: inner}
+		if c, ok := sub.parseCondition(true); ok && sub.peek().TokenType == ErrorToken {
+			return c, true
+		} else if f, ok := parseMediaFeature(inner); ok {
+			return MediaCondition{Type: MediaFeatureCondition, Feature: f}, true
+		}
+	}
+	return MediaCondition{Type: GeneralEnclosedCondition, Tokens: block}, true
+}
+
+// parseMediaFeature parses the contents of a media feature, which is <name>, <name>: <value>, or range syntax such as <name> >= <value> or <value> < <name> < <value>.
+func parseMediaFeature(tokens []Token) (MediaFeature, bool) {
+	segments := [][]Token{{}}
+	ops := []MediaOperator{}
+	colon := false
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.TokenType {
+		case WhitespaceToken, CommentToken:
+			continue
+		case ColonToken:
+			if colon || len(ops) != 0 {
+				return MediaFeature{}, false
+			}
+			colon = true
+			segments = append(segments, []Token{})
+			continue
+		case DelimToken:
+			if c := t.Data[0]; c == '<' || c == '>' || c == '=' {
+				if colon {
+					return MediaFeature{}, false
+				}
+				op := MediaEqual
+				if c != '=' {
+					op = MediaLess
+					if c == '>' {
+						op = MediaGreater
+					}
+					if i+1 < len(tokens) && tokens[i+1].TokenType == DelimToken && tokens[i+1].Data[0] == '=' {
+						op++ // MediaLessEqual or MediaGreaterEqual
+						i++
+					}
+				}
+				ops = append(ops, op)
+				segments = append(segments, []Token{})
+				continue
+			}
+		case NumberToken, DimensionToken, IdentToken:
+		default:
+			return MediaFeature{}, false
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], t)
+	}
+	for _, segment := range segments {
+		if len(segment) == 0 {
+			return MediaFeature{}, false
+		}
+	}
+
+	isName := func(segment []Token) bool {
+		return len(segment) == 1 && segment[0].TokenType == IdentToken
+	}
+	name := func(segment []Token) []byte {
+		return parse.ToLower(parse.Copy(segment[0].Data))
+	}
+	switch {
+	case colon:
+		if !isName(segments[0]) {
+			return MediaFeature{}, false
+		}
+		return MediaFeature{Name: name(segments[0]), Value: segments[1]}, true
+	case len(ops) == 0:
+		if !isName(segments[0]) {
+			return MediaFeature{}, false
+		}
+		return MediaFeature{Name: name(segments[0])}, true
+	case len(ops) == 1:
+		if isName(segments[0]) {
+			return MediaFeature{Name: name(segments[0]), Comparisons: []MediaComparison{{ops[0], segments[1], false}}}, true
+		} else if isName(segments[1]) {
+			return MediaFeature{Name: name(segments[1]), Comparisons: []MediaComparison{{ops[0], segments[0], true}}}, true
+		}
+	case len(ops) == 2:
+		less := (ops[0] == MediaLess || ops[0] == MediaLessEqual) && (ops[1] == MediaLess || ops[1] == MediaLessEqual)
+		greater := (ops[0] == MediaGreater || ops[0] == MediaGreaterEqual) && (ops[1] == MediaGreater || ops[1] == MediaGreaterEqual)
+		if isName(segments[1]) && (less || greater) {
+			return MediaFeature{Name: name(segments[1]), Comparisons: []MediaComparison{{ops[0], segments[0], true}, {ops[1], segments[2], false}}}, true
+		}
+	}
+	return MediaFeature{}, false
+}
@@ -0,0 +1,114 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseMediaQueryList(t *testing.T) {
+	var tests = []struct {
+		query    string
+		expected string
+	}{
+		{"", ""},
+		{"screen", "screen"},
+		{"ONLY Screen", "only screen"},
+		{"not print", "not print"},
+		{"screen and (min-width: 600px)", "screen and (min-width:600px)"},
+		{"screen and (color) and (hover)", "screen and (color) and (hover)"},
+		{"(width >= 600px)", "(width>=600px)"},
+		{"(600px <= width)", "(600px<=width)"},
+		{"(400px < width <= 700px)", "(400px<width<=700px)"},
+		{"(700px > WIDTH > 400px)", "(700px>width>400px)"},
+		{"(width = 600px)", "(width=600px)"},
+		{"(aspect-ratio > 16 / 9)", "(aspect-ratio>16/9)"},
+		{"not (hover)", "not (hover)"},
+		{"(hover) or (pointer: coarse)", "(hover) or (pointer:coarse)"},
+		{"not ((hover) and (pointer: fine))", "not ((hover) and (pointer:fine))"},
+		{"((a) or (b)) and (c)", "((a) or (b)) and (c)"},
+		{"((hover))", "(hover)"},
+		{"screen and not (hover)", "screen and not (hover)"},
+		{"(unknown syntax!)", "(unknown syntax!)"},
+		{"(hover) and script(enabled)", "(hover) and script(enabled)"},
+		{"screen, (hover)", "screen,(hover)"},
+
+		// invalid
+		{"screen and (a) or (b)", "not all"},
+		{"(a) and (b) or (c)", "not all"},
+		{"screen or print", "not all"},
+		{"only (hover)", "not all"},
+		{"not only screen", "not all"},
+		{"and", "not all"},
+		{"screen and", "not all"},
+		{"(hover", "not all"},
+		{"screen,", "screen,not all"},
+		{"(hover) and(color)", "not all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			test.String(t, ParseMediaQueryList(mediaPrelude(t, tt.query)).String(), tt.expected)
+		})
+	}
+
+	list := ParseMediaQueryList(mediaPrelude(t, "(400px < width <= 700px)"))
+	feature := list[0].Condition.Feature
+	test.String(t, string(feature.Name), "width")
+	test.T(t, len(feature.Comparisons), 2)
+	test.T(t, feature.Comparisons[0].Operator, MediaLess)
+	test.That(t, feature.Comparisons[0].Reversed)
+	test.T(t, feature.Comparisons[1].Operator, MediaLessEqual)
+	test.That(t, !feature.Comparisons[1].Reversed)
+
+	list = ParseMediaQueryList(mediaPrelude(t, "(a:b) or fn(x)"))
+	test.T(t, list[0].Condition.Type, MediaOrCondition)
+	test.T(t, list[0].Condition.Conditions[0].Type, MediaFeatureCondition)
+	test.T(t, list[0].Condition.Conditions[1].Type, GeneralEnclosedCondition)
+}
+
+func TestMediaQueryEvaluate(t *testing.T) {
+	screen := ScreenDevice(1024, 768)
+	viewport := PartialDevice{Device: Device{Width: 1024, Height: 768}, Features: []string{"width", "height"}}
+	var tests = []struct {
+		query    string
+		screen   MediaResult
+		viewport MediaResult
+	}{
+		{"", MediaTrue, MediaTrue},
+		{"all", MediaTrue, MediaTrue},
+		{"print", MediaFalse, MediaUnknown},
+		{"(width >= 600px)", MediaTrue, MediaTrue},
+		{"(width < 600px)", MediaFalse, MediaFalse},
+		{"(400px <= width <= 1024px)", MediaTrue, MediaTrue},
+		{"(1024px < width < 2000px)", MediaFalse, MediaFalse},
+		{"(width > 400px) and (height > 800px)", MediaFalse, MediaFalse},
+		{"(min-width: 600px)", MediaTrue, MediaTrue},
+		{"(max-height: 600px)", MediaFalse, MediaFalse},
+		{"(aspect-ratio > 1)", MediaTrue, MediaUnknown},
+		{"(resolution >= 2x)", MediaFalse, MediaUnknown},
+		{"(color > 4)", MediaTrue, MediaUnknown},
+		{"(hover)", MediaTrue, MediaUnknown},
+		{"(hover) and (width < 600px)", MediaFalse, MediaFalse},
+		{"(hover) or (width >= 600px)", MediaTrue, MediaTrue},
+		{"not (width < 600px)", MediaTrue, MediaTrue},
+		{"not (hover)", MediaFalse, MediaUnknown},
+		{"screen and (width >= 600px)", MediaTrue, MediaUnknown},
+		{"not print and (width >= 600px)", MediaTrue, MediaUnknown},
+		{"(unknown)", MediaUnknown, MediaUnknown},
+		{"not (unknown)", MediaUnknown, MediaUnknown},
+		{"(unknown) or (width > 600px)", MediaTrue, MediaTrue},
+		{"(unknown) and (width < 600px)", MediaFalse, MediaFalse},
+		{"(width >= 600deg)", MediaUnknown, MediaUnknown},
+		{"(min-width >= 600px)", MediaUnknown, MediaUnknown},
+		{"(hover: hover) and fn(x)", MediaUnknown, MediaUnknown},
+		{"(width >= 600px), print", MediaTrue, MediaTrue},
+		{"screen or print", MediaFalse, MediaFalse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			list := ParseMediaQueryList(mediaPrelude(t, tt.query))
+			test.T(t, list.Evaluate(screen), tt.screen, "screen")
+			test.T(t, list.Evaluate(viewport), tt.viewport, "viewport")
+		})
+	}
+}
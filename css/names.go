@@ -0,0 +1,158 @@
+package css
+
+import (
+	"sort"
+	"strings"
+)
+
+// properties are the standard CSS properties, excluding vendor-prefixed and custom properties.
+var properties = []string{
+	"accent-color", "align-content", "align-items", "align-self", "alignment-baseline", "all", "anchor-name", "animation", "animation-composition", "animation-delay", "animation-direction", "animation-duration", "animation-fill-mode", "animation-iteration-count", "animation-name", "animation-play-state", "animation-range", "animation-range-end", "animation-range-start", "animation-timeline", "animation-timing-function", "appearance", "aspect-ratio",
+	"backdrop-filter", "backface-visibility", "background", "background-attachment", "background-blend-mode", "background-clip", "background-color", "background-image", "background-origin", "background-position", "background-position-x", "background-position-y", "background-repeat", "background-size", "baseline-shift", "block-size", "border", "border-block", "border-block-color", "border-block-end", "border-block-end-color", "border-block-end-style", "border-block-end-width", "border-block-start", "border-block-start-color", "border-block-start-style", "border-block-start-width", "border-block-style", "border-block-width", "border-bottom", "border-bottom-color", "border-bottom-left-radius", "border-bottom-right-radius", "border-bottom-style", "border-bottom-width", "border-collapse", "border-color", "border-end-end-radius", "border-end-start-radius", "border-image", "border-image-outset", "border-image-repeat", "border-image-slice", "border-image-source", "border-image-width", "border-inline", "border-inline-color", "border-inline-end", "border-inline-end-color", "border-inline-end-style", "border-inline-end-width", "border-inline-start", "border-inline-start-color", "border-inline-start-style", "border-inline-start-width", "border-inline-style", "border-inline-width", "border-left", "border-left-color", "border-left-style", "border-left-width", "border-radius", "border-right", "border-right-color", "border-right-style", "border-right-width", "border-spacing", "border-start-end-radius", "border-start-start-radius", "border-style", "border-top", "border-top-color", "border-top-left-radius", "border-top-right-radius", "border-top-style", "border-top-width", "border-width", "bottom", "box-decoration-break", "box-shadow", "box-sizing", "break-after", "break-before", "break-inside",
+	"caption-side", "caret", "caret-color", "caret-shape", "clear", "clip", "clip-path", "clip-rule", "color", "color-interpolation", "color-interpolation-filters", "color-scheme", "column-count", "column-fill", "column-gap", "column-rule", "column-rule-color", "column-rule-style", "column-rule-width", "column-span", "column-width", "columns", "contain", "contain-intrinsic-block-size", "contain-intrinsic-height", "contain-intrinsic-inline-size", "contain-intrinsic-size", "contain-intrinsic-width", "container", "container-name", "container-type", "content", "content-visibility", "counter-increment", "counter-reset", "counter-set", "cursor", "cx", "cy",
+	"d", "direction", "display", "dominant-baseline", "empty-cells", "field-sizing", "fill", "fill-opacity", "fill-rule", "filter", "flex", "flex-basis", "flex-direction", "flex-flow", "flex-grow", "flex-shrink", "flex-wrap", "float", "flood-color", "flood-opacity", "font", "font-family", "font-feature-settings", "font-kerning", "font-language-override", "font-optical-sizing", "font-palette", "font-size", "font-size-adjust", "font-stretch", "font-style", "font-synthesis", "font-synthesis-position", "font-synthesis-small-caps", "font-synthesis-style", "font-synthesis-weight", "font-variant", "font-variant-alternates", "font-variant-caps", "font-variant-east-asian", "font-variant-emoji", "font-variant-ligatures", "font-variant-numeric", "font-variant-position", "font-variation-settings", "font-weight", "forced-color-adjust",
+	"gap", "grid", "grid-area", "grid-auto-columns", "grid-auto-flow", "grid-auto-rows", "grid-column", "grid-column-end", "grid-column-gap", "grid-column-start", "grid-gap", "grid-row", "grid-row-end", "grid-row-gap", "grid-row-start", "grid-template", "grid-template-areas", "grid-template-columns", "grid-template-rows", "hanging-punctuation", "height", "hyphenate-character", "hyphenate-limit-chars", "hyphens", "image-orientation", "image-rendering", "image-resolution", "initial-letter", "inline-size", "inset", "inset-block", "inset-block-end", "inset-block-start", "inset-inline", "inset-inline-end", "inset-inline-start", "interpolate-size", "isolation",
+	"justify-content", "justify-items", "justify-self", "left", "letter-spacing", "lighting-color", "line-break", "line-clamp", "line-height", "line-height-step", "list-style", "list-style-image", "list-style-position", "list-style-type", "margin", "margin-block", "margin-block-end", "margin-block-start", "margin-bottom", "margin-inline", "margin-inline-end", "margin-inline-start", "margin-left", "margin-right", "margin-top", "marker", "marker-end", "marker-mid", "marker-start", "mask", "mask-border", "mask-border-mode", "mask-border-outset", "mask-border-repeat", "mask-border-slice", "mask-border-source", "mask-border-width", "mask-clip", "mask-composite", "mask-image", "mask-mode", "mask-origin", "mask-position", "mask-repeat", "mask-size", "mask-type", "math-depth", "math-shift", "math-style", "max-block-size", "max-height", "max-inline-size", "max-width", "min-block-size", "min-height", "min-inline-size", "min-width", "mix-blend-mode",
+	"object-fit", "object-position", "offset", "offset-anchor", "offset-distance", "offset-path", "offset-position", "offset-rotate", "opacity", "order", "orphans", "outline", "outline-color", "outline-offset", "outline-style", "outline-width", "overflow", "overflow-anchor", "overflow-block", "overflow-clip-margin", "overflow-inline", "overflow-wrap", "overflow-x", "overflow-y", "overlay", "overscroll-behavior", "overscroll-behavior-block", "overscroll-behavior-inline", "overscroll-behavior-x", "overscroll-behavior-y",
+	"padding", "padding-block", "padding-block-end", "padding-block-start", "padding-bottom", "padding-inline", "padding-inline-end", "padding-inline-start", "padding-left", "padding-right", "padding-top", "page", "page-break-after", "page-break-before", "page-break-inside", "paint-order", "perspective", "perspective-origin", "place-content", "place-items", "place-self", "pointer-events", "position", "position-anchor", "position-area", "position-try", "position-try-fallbacks", "position-try-order", "position-visibility", "print-color-adjust", "quotes", "r", "resize", "right", "rotate", "row-gap", "ruby-align", "ruby-position", "rx", "ry",
+	"scale", "scroll-behavior", "scroll-margin", "scroll-margin-block", "scroll-margin-block-end", "scroll-margin-block-start", "scroll-margin-bottom", "scroll-margin-inline", "scroll-margin-inline-end", "scroll-margin-inline-start", "scroll-margin-left", "scroll-margin-right", "scroll-margin-top", "scroll-padding", "scroll-padding-block", "scroll-padding-block-end", "scroll-padding-block-start", "scroll-padding-bottom", "scroll-padding-inline", "scroll-padding-inline-end", "scroll-padding-inline-start", "scroll-padding-left", "scroll-padding-right", "scroll-padding-top", "scroll-snap-align", "scroll-snap-stop", "scroll-snap-type", "scroll-timeline", "scroll-timeline-axis", "scroll-timeline-name", "scrollbar-color", "scrollbar-gutter", "scrollbar-width", "shape-image-threshold", "shape-margin", "shape-outside", "shape-rendering", "speak", "stop-color", "stop-opacity", "stroke", "stroke-dasharray", "stroke-dashoffset", "stroke-linecap", "stroke-linejoin", "stroke-miterlimit", "stroke-opacity", "stroke-width",
+	"tab-size", "table-layout", "text-align", "text-align-last", "text-anchor", "text-box", "text-box-edge", "text-box-trim", "text-combine-upright", "text-decoration", "text-decoration-color", "text-decoration-line", "text-decoration-skip", "text-decoration-skip-ink", "text-decoration-style", "text-decoration-thickness", "text-emphasis", "text-emphasis-color", "text-emphasis-position", "text-emphasis-style", "text-indent", "text-justify", "text-orientation", "text-overflow", "text-rendering", "text-shadow", "text-size-adjust", "text-spacing-trim", "text-transform", "text-underline-offset", "text-underline-position", "text-wrap", "text-wrap-mode", "text-wrap-style", "timeline-scope", "top", "touch-action", "transform", "transform-box", "transform-origin", "transform-style", "transition", "transition-behavior", "transition-delay", "transition-duration", "transition-property", "transition-timing-function", "translate",
+	"unicode-bidi", "user-select", "vector-effect", "vertical-align", "view-timeline", "view-timeline-axis", "view-timeline-inset", "view-timeline-name", "view-transition-class", "view-transition-name", "visibility", "white-space", "white-space-collapse", "widows", "width", "will-change", "word-break", "word-spacing", "word-wrap", "writing-mode", "x", "y", "z-index", "zoom",
+}
+
+// units are the CSS dimension units in lowercase, including % for percentages.
+var units = []string{
+	"%",
+	"px", "cm", "mm", "q", "in", "pt", "pc",
+	"em", "rem", "ex", "rex", "cap", "rcap", "ch", "rch", "ic", "ric", "lh", "rlh",
+	"vw", "vh", "vi", "vb", "vmin", "vmax", "svw", "svh", "svi", "svb", "svmin", "svmax", "lvw", "lvh", "lvi", "lvb", "lvmin", "lvmax", "dvw", "dvh", "dvi", "dvb", "dvmin", "dvmax",
+	"cqw", "cqh", "cqi", "cqb", "cqmin", "cqmax",
+	"deg", "grad", "rad", "turn",
+	"s", "ms",
+	"hz", "khz",
+	"dpi", "dpcm", "dppx", "x",
+	"fr",
+}
+
+// mediaFeatures are the media features of Media Queries Level 5, where range features may have a min- or max- prefix.
+var mediaFeatures = []string{
+	"any-hover", "any-pointer", "color-gamut", "display-mode", "dynamic-range", "environment-blending", "forced-colors", "grid", "hover", "inverted-colors", "nav-controls", "orientation", "overflow-block", "overflow-inline", "pointer", "prefers-color-scheme", "prefers-contrast", "prefers-reduced-data", "prefers-reduced-motion", "prefers-reduced-transparency", "scan", "scripting", "update", "video-color-gamut", "video-dynamic-range",
+}
+
+// rangeMediaFeatures are the media features that accept a min- or max- prefix.
+var rangeMediaFeatures = []string{
+	"aspect-ratio", "color", "color-index", "device-aspect-ratio", "device-height", "device-width", "height", "horizontal-viewport-segments", "monochrome", "resolution", "vertical-viewport-segments", "width",
+}
+
+var (
+	propertySet      = newNameSet(properties)
+	unitSet          = newNameSet(units)
+	allMediaFeatures = allMediaFeatureNames()
+	mediaFeatureSet  = newNameSet(allMediaFeatures)
+)
+
+func newNameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// allMediaFeatureNames returns the sorted media features including the min- and max- prefixed range features.
+func allMediaFeatureNames() []string {
+	names := append([]string{}, mediaFeatures...)
+	for _, name := range rangeMediaFeatures {
+		names = append(names, name, "min-"+name, "max-"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsProperty returns true if the property name is a standard CSS property, a custom property, or vendor-prefixed, compared case-insensitively.
+func IsProperty(name []byte) bool {
+	return 0 < len(name) && name[0] == '-' || propertySet[strings.ToLower(string(name))]
+}
+
+// IsUnit returns true if the unit of a dimension is a CSS unit, compared case-insensitively.
+func IsUnit(unit []byte) bool {
+	return unitSet[strings.ToLower(string(unit))]
+}
+
+// IsMediaFeature returns true if the name is a media feature, optionally with a min- or max- prefix for range features, or if it is vendor-prefixed, compared case-insensitively.
+func IsMediaFeature(name []byte) bool {
+	return 0 < len(name) && name[0] == '-' || mediaFeatureSet[strings.ToLower(string(name))]
+}
+
+// SuggestProperty returns the standard CSS property that is closest to an unknown property name by edit distance, for did-you-mean diagnostics. It returns false if the property is known, see IsProperty, or if no property is close enough.
+func SuggestProperty(name []byte) (string, bool) {
+	if IsProperty(name) {
+		return "", false
+	}
+	return suggest(strings.ToLower(string(name)), properties)
+}
+
+// SuggestUnit returns the CSS unit that is closest to an unknown unit by edit distance, for did-you-mean diagnostics. It returns false if the unit is known or if no unit is close enough.
+func SuggestUnit(unit []byte) (string, bool) {
+	if IsUnit(unit) {
+		return "", false
+	}
+	return suggest(strings.ToLower(string(unit)), units)
+}
+
+// SuggestMediaFeature returns the media feature that is closest to an unknown media feature by edit distance, for did-you-mean diagnostics. It returns false if the media feature is known, see IsMediaFeature, or if no media feature is close enough.
+func SuggestMediaFeature(name []byte) (string, bool) {
+	if IsMediaFeature(name) {
+		return "", false
+	}
+	return suggest(strings.ToLower(string(name)), allMediaFeatures)
+}
+
+// suggest returns the first candidate with the smallest edit distance to name, if the distance is at most one plus one per five characters of name.
+func suggest(name string, candidates []string) (string, bool) {
+	best, bestDist := "", len(name)/5+2
+	for _, candidate := range candidates {
+		if d := len(candidate) - len(name); d < -bestDist || bestDist < d {
+			continue
+		}
+		if dist := editDistance(name, candidate); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the optimal string alignment distance between a and b, which counts insertions, deletions, substitutions, and transpositions of adjacent characters.
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if 1 < i && 1 < j && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+1 < cur[j] {
+				cur[j] = prev2[j-2] + 1
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
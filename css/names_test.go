@@ -0,0 +1,45 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestSuggest(t *testing.T) {
+	var tests = []struct {
+		suggest  func([]byte) (string, bool)
+		name     string
+		expected string
+	}{
+		{SuggestProperty, "colr", "color"},
+		{SuggestProperty, "Backgrond-Color", "background-color"},
+		{SuggestProperty, "wdith", "width"},
+		{SuggestProperty, "marginn-top", "margin-top"},
+		{SuggestProperty, "color", ""},
+		{SuggestProperty, "-webkit-foo", ""},
+		{SuggestProperty, "--my-var", ""},
+		{SuggestProperty, "xyzzy", ""},
+		{SuggestUnit, "pz", "px"},
+		{SuggestUnit, "PX", ""},
+		{SuggestUnit, "rme", "rem"},
+		{SuggestUnit, "dge", "deg"},
+		{SuggestUnit, "foo", ""},
+		{SuggestMediaFeature, "min-widht", "min-width"},
+		{SuggestMediaFeature, "prefers-color-schema", "prefers-color-scheme"},
+		{SuggestMediaFeature, "max-resolution", ""},
+		{SuggestMediaFeature, "device-pixel-ratio", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestion, ok := tt.suggest([]byte(tt.name))
+			test.String(t, suggestion, tt.expected)
+			test.T(t, ok, tt.expected != "")
+		})
+	}
+
+	test.That(t, IsProperty([]byte("Grid-Template-Areas")))
+	test.That(t, IsUnit([]byte("%")))
+	test.That(t, IsMediaFeature([]byte("max-device-width")))
+	test.That(t, !IsMediaFeature([]byte("min-orientation")))
+}
@@ -6,7 +6,6 @@ import (
 	"strconv"
 
 	"github.com/politepixels/tdewolff-parse/v2"
-	"github.com/politepixels/tdewolff-parse/v2/buffer"
 )
 
 var wsBytes = []byte(" ")
@@ -75,16 +74,27 @@ func (t Token) String() string {
 	return t.TokenType.String() + "('" + string(t.Data) + "')"
 }
 
+type aheadToken struct {
+	tt     TokenType
+	data   []byte
+	offset int // offset after the token
+}
+
 // Parser is the state for the parser.
 type Parser struct {
 	l      *Lexer
 	state  []State
 	err    string
 	errPos int
+	diag   *parse.Diagnostics
 
 	buf   []Token
 	level int
 
+	ahead    []aheadToken // tokens read ahead by isNestedRule
+	aheadPos int
+	offset   int
+
 	data        []byte
 	tt          TokenType
 	keepWS      bool
@@ -95,18 +105,31 @@ type Parser struct {
 
 // NewParser returns a new CSS parser from an io.Reader. isInline specifies whether this is an inline style attribute.
 func NewParser(r *parse.Input, isInline bool) *Parser {
-	l := NewLexer(r)
 	p := &Parser{
-		l:     l,
+		l:     &Lexer{},
 		state: make([]State, 0, 4),
 	}
+	p.Reset(r, isInline)
+	return p
+}
 
+// Reset resets the parser to parse r as returned by NewParser, while keeping its allocated buffers. The diagnostics collector is removed.
+func (p *Parser) Reset(r *parse.Input, isInline bool) {
+	p.l.Reset(r)
+	*p = Parser{
+		l:     p.l,
+		state: p.state[:0],
+		buf:   p.buf[:0],
+		ahead: p.ahead[:0],
+	}
+	if r != nil {
+		p.offset = r.Offset()
+	}
 	if isInline {
 		p.state = append(p.state, (*Parser).parseDeclarationList)
 	} else {
 		p.state = append(p.state, (*Parser).parseStylesheet)
 	}
-	return p
 }
 
 // HasParseError returns true if there is a parse error (and not a read error).
@@ -114,11 +137,15 @@ func (p *Parser) HasParseError() bool {
 	return p.err != ""
 }
 
+// SetDiagnostics sets a collector to which all parse errors are added as they are encountered, so that a single pass over a stylesheet reports every problem. Parsing continues after parse errors regardless.
+func (p *Parser) SetDiagnostics(d *parse.Diagnostics) {
+	p.diag = d
+}
+
 // Err returns the error encountered during parsing, this is often io.EOF but also other errors can be returned.
 func (p *Parser) Err() error {
 	if p.err != "" {
-		r := buffer.NewReader(p.l.r.Bytes())
-		return parse.NewError(r, p.errPos, p.err)
+		return parse.NewError(p.l.r, p.errPos, p.err)
 	}
 	return p.l.Err()
 }
@@ -134,12 +161,15 @@ func (p *Parser) Next() (GrammarType, TokenType, []byte) {
 		p.tt, p.data = p.popToken(true)
 	}
 	gt := p.state[len(p.state)-1](p)
+	if p.err != "" && p.diag != nil {
+		p.diag.Add(p.Err().(*parse.Error))
+	}
 	return gt, p.tt, p.data
 }
 
 // Offset return offset for current Grammar
 func (p *Parser) Offset() int {
-	return p.l.r.Offset()
+	return p.offset
 }
 
 // Values returns a slice of Tokens for the last Grammar. Only AtRuleGrammar, BeginAtRuleGrammar, BeginRulesetGrammar and Declaration will return the at-rule components, ruleset selector and declaration values respectively.
@@ -147,10 +177,35 @@ func (p *Parser) Values() []Token {
 	return p.buf
 }
 
+// lex returns the next token from the tokens read ahead, or otherwise from the lexer.
+func (p *Parser) lex() (TokenType, []byte) {
+	if p.aheadPos < len(p.ahead) {
+		t := p.ahead[p.aheadPos]
+		p.aheadPos++
+		if p.aheadPos == len(p.ahead) {
+			p.ahead, p.aheadPos = p.ahead[:0], 0
+		}
+		p.offset = t.offset
+		return t.tt, t.data
+	}
+	tt, data := p.l.Next()
+	p.offset = p.l.r.Offset()
+	return tt, data
+}
+
+// peek returns the i-th token after the current token without consuming it, including whitespace and comments.
+func (p *Parser) peek(i int) TokenType {
+	for len(p.ahead) <= p.aheadPos+i {
+		tt, data := p.l.Next()
+		p.ahead = append(p.ahead, aheadToken{tt, data, p.l.r.Offset()})
+	}
+	return p.ahead[p.aheadPos+i].tt
+}
+
 func (p *Parser) popToken(allowComment bool) (TokenType, []byte) {
 	p.prevWS = false
 	p.prevComment = false
-	tt, data := p.l.Next()
+	tt, data := p.lex()
 	for !p.keepWS && tt == WhitespaceToken || tt == CommentToken {
 		if tt == WhitespaceToken {
 			p.prevWS = true
@@ -160,7 +215,7 @@ func (p *Parser) popToken(allowComment bool) (TokenType, []byte) {
 				break
 			}
 		}
-		tt, data = p.l.Next()
+		tt, data = p.lex()
 	}
 	return tt, data
 }
@@ -179,7 +234,7 @@ func (p *Parser) parseStylesheet() GrammarType {
 	if p.tt == CDOToken || p.tt == CDCToken {
 		return TokenGrammar
 	} else if p.tt == AtKeywordToken {
-		return p.parseAtRule()
+		return p.parseAtRule(false)
 	} else if p.tt == CommentToken {
 		return CommentGrammar
 	} else if p.tt == ErrorToken {
@@ -206,7 +261,7 @@ func (p *Parser) parseDeclarationList() GrammarType {
 	if p.tt == ErrorToken {
 		return ErrorGrammar
 	} else if p.tt == AtKeywordToken {
-		return p.parseAtRule()
+		return p.parseAtRule(false)
 	} else if p.tt == IdentToken || p.tt == DelimToken {
 		return p.parseDeclaration()
 	} else if p.tt == CustomPropertyNameToken {
@@ -215,9 +270,7 @@ func (p *Parser) parseDeclarationList() GrammarType {
 
 	// parse error
 	p.initBuf()
-	p.l.r.Move(-len(p.data))
-	p.err, p.errPos = fmt.Sprintf("unexpected token '%s' in declaration", string(p.data)), p.l.r.Offset()
-	p.l.r.Move(len(p.data))
+	p.err, p.errPos = fmt.Sprintf("unexpected token '%s' in declaration", string(p.data)), p.offset-len(p.data)
 
 	if p.tt == RightBraceToken {
 		// right brace token will occur when we've had a decl error that ended in a right brace token
@@ -230,7 +283,8 @@ func (p *Parser) parseDeclarationList() GrammarType {
 
 ////////////////////////////////////////////////////////////////
 
-func (p *Parser) parseAtRule() GrammarType {
+// parseAtRule parses an at-rule, where nested is set for at-rules in the block of a style rule. Conditional group rules such as @media then contain declarations and nested style rules, and @nest introduces a nested style rule from the draft of CSS Nesting.
+func (p *Parser) parseAtRule(nested bool) GrammarType {
 	p.initBuf()
 	p.data = parse.ToLower(parse.Copy(p.data))
 	atRuleName := p.data
@@ -240,6 +294,10 @@ func (p *Parser) parseAtRule() GrammarType {
 		}
 	}
 	atRule := ToHash(atRuleName[1:])
+	if nested && atRule == Nest {
+		p.tt, p.data = p.popToken(false)
+		return p.parseQualifiedRule()
+	}
 
 	first := true
 	skipWS := false
@@ -248,7 +306,9 @@ func (p *Parser) parseAtRule() GrammarType {
 		if tt == LeftBraceToken && p.level == 0 {
 			if atRule == Font_Face || atRule == Page {
 				p.state = append(p.state, (*Parser).parseAtRuleDeclarationList)
-			} else if atRule == Document || atRule == Keyframes || atRule == Layer || atRule == Media || atRule == Supports {
+			} else if nested && (atRule == Container || atRule == Document || atRule == Layer || atRule == Media || atRule == Scope || atRule == Starting_Style || atRule == Supports) {
+				p.state = append(p.state, (*Parser).parseNestedAtRuleList)
+			} else if atRule == Container || atRule == Document || atRule == Keyframes || atRule == Layer || atRule == Media || atRule == Scope || atRule == Starting_Style || atRule == Supports {
 				p.state = append(p.state, (*Parser).parseAtRuleRuleList)
 			} else {
 				p.state = append(p.state, (*Parser).parseAtRuleUnknown)
@@ -266,7 +326,7 @@ func (p *Parser) parseAtRule() GrammarType {
 				if 1 < len(p.state) {
 					p.state = p.state[:len(p.state)-1]
 				}
-				p.err, p.errPos = "unexpected ending in at rule", p.l.r.Offset()
+				p.err, p.errPos = "unexpected ending in at rule", p.offset
 				return ErrorGrammar
 			}
 			p.level--
@@ -296,12 +356,16 @@ func (p *Parser) parseAtRuleRuleList() GrammarType {
 		p.state = p.state[:len(p.state)-1]
 		return EndAtRuleGrammar
 	} else if p.tt == AtKeywordToken {
-		return p.parseAtRule()
+		return p.parseAtRule(false)
 	} else {
 		return p.parseQualifiedRule()
 	}
 }
 
+func (p *Parser) parseNestedAtRuleList() GrammarType {
+	return p.parseStyleBlock(EndAtRuleGrammar)
+}
+
 func (p *Parser) parseAtRuleDeclarationList() GrammarType {
 	for p.tt == SemicolonToken {
 		p.tt, p.data = p.popToken(false)
@@ -348,7 +412,7 @@ func (p *Parser) parseQualifiedRule() GrammarType {
 			p.state = append(p.state, (*Parser).parseQualifiedRuleDeclarationList)
 			return BeginRulesetGrammar
 		} else if tt == ErrorToken {
-			p.err, p.errPos = "unexpected ending in qualified rule", p.l.r.Offset()
+			p.err, p.errPos = "unexpected ending in qualified rule", p.offset
 			return ErrorGrammar
 		} else if tt == LeftParenthesisToken || tt == LeftBraceToken || tt == LeftBracketToken || tt == FunctionToken {
 			p.level++
@@ -359,7 +423,7 @@ func (p *Parser) parseQualifiedRule() GrammarType {
 				if 1 < len(p.state) {
 					p.state = p.state[:len(p.state)-1]
 				}
-				p.err, p.errPos = "unexpected ending in qualified rule", p.l.r.Offset()
+				p.err, p.errPos = "unexpected ending in qualified rule", p.offset
 				return ErrorGrammar
 			}
 			p.level--
@@ -381,16 +445,54 @@ func (p *Parser) parseQualifiedRule() GrammarType {
 }
 
 func (p *Parser) parseQualifiedRuleDeclarationList() GrammarType {
+	return p.parseStyleBlock(EndRulesetGrammar)
+}
+
+// parseStyleBlock parses the block of a style rule, or of a conditional group rule nested in a style rule, which contains declarations, nested style rules, and nested at-rules. It returns end at the closing brace.
+func (p *Parser) parseStyleBlock(end GrammarType) GrammarType {
 	for p.tt == SemicolonToken {
 		p.tt, p.data = p.popToken(false)
 	}
 	if p.tt == RightBraceToken || p.tt == ErrorToken {
 		p.state = p.state[:len(p.state)-1]
-		return EndRulesetGrammar
+		return end
+	} else if p.tt == AtKeywordToken {
+		return p.parseAtRule(true)
+	} else if p.tt != CustomPropertyNameToken && p.isNestedRule() {
+		return p.parseQualifiedRule()
 	}
 	return p.parseDeclarationList()
 }
 
+// isNestedRule returns true if the current token starts a nested style rule rather than a declaration, which is when a left brace follows before the semicolon or right brace that ends a declaration. A declaration whose value is only a block, such as x:{y}, is not a nested rule, while a:hover{} is.
+func (p *Parser) isNestedRule() bool {
+	decl := p.tt == IdentToken // whether the tokens so far may be a property name and a colon
+	colon := false
+	value := false
+	level := 0
+	for i := 0; ; i++ {
+		tt := p.peek(i)
+		if tt == WhitespaceToken || tt == CommentToken {
+			continue
+		} else if tt == ErrorToken || level == 0 && (tt == SemicolonToken || tt == RightBraceToken) {
+			return false
+		} else if level == 0 && tt == LeftBraceToken {
+			return !colon || value
+		} else if tt == LeftParenthesisToken || tt == LeftBraceToken || tt == LeftBracketToken || tt == FunctionToken {
+			level++
+		} else if (tt == RightParenthesisToken || tt == RightBraceToken || tt == RightBracketToken) && 0 < level {
+			level--
+		}
+		if colon {
+			value = true
+		} else if decl && tt == ColonToken {
+			colon = true
+		} else {
+			decl = false
+		}
+	}
+}
+
 func (p *Parser) parseDeclaration() GrammarType {
 	p.initBuf()
 	p.data = parse.ToLower(parse.Copy(p.data))
@@ -398,9 +500,7 @@ func (p *Parser) parseDeclaration() GrammarType {
 	ttName, dataName := p.tt, p.data
 	tt, data := p.popToken(false)
 	if tt != ColonToken {
-		p.l.r.Move(-len(data))
-		p.err, p.errPos = "expected colon in declaration", p.l.r.Offset()
-		p.l.r.Move(len(data))
+		p.err, p.errPos = "expected colon in declaration", p.offset-len(data)
 		p.pushBuf(ttName, dataName)
 		return p.parseDeclarationError(tt, data)
 	}
@@ -416,7 +516,7 @@ func (p *Parser) parseDeclaration() GrammarType {
 		} else if tt == RightParenthesisToken || tt == RightBraceToken || tt == RightBracketToken {
 			if p.level == 0 {
 				// TODO: buggy
-				p.err, p.errPos = "unexpected ending in declaration", p.l.r.Offset()
+				p.err, p.errPos = "unexpected ending in declaration", p.offset
 				p.pushBuf(ttName, dataName)
 				p.pushBuf(ColonToken, []byte{':'})
 				return p.parseDeclarationError(tt, data)
@@ -462,14 +562,12 @@ func (p *Parser) parseDeclarationError(tt TokenType, data []byte) GrammarType {
 func (p *Parser) parseCustomProperty() GrammarType {
 	p.initBuf()
 	if tt, data := p.popToken(false); tt != ColonToken {
-		p.l.r.Move(-len(data))
-		p.err, p.errPos = "expected colon in custom property", p.l.r.Offset()
-		p.l.r.Move(len(data))
+		p.err, p.errPos = "expected colon in custom property", p.offset-len(data)
 		return ErrorGrammar
 	}
 	val := []byte{}
 	for {
-		tt, data := p.l.Next()
+		tt, data := p.lex()
 		if (tt == SemicolonToken || tt == RightBraceToken) && p.level == 0 || tt == ErrorToken {
 			p.prevEnd = (tt == RightBraceToken)
 			p.pushBuf(CustomPropertyValueToken, val)
@@ -480,7 +578,7 @@ func (p *Parser) parseCustomProperty() GrammarType {
 			if p.level == 0 {
 				// TODO: buggy
 				p.pushBuf(tt, data)
-				p.err, p.errPos = "unexpected ending in custom property", p.l.r.Offset()
+				p.err, p.errPos = "unexpected ending in custom property", p.offset
 				return ErrorGrammar
 			}
 			p.level--
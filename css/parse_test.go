@@ -89,7 +89,24 @@ func TestParse(t *testing.T) {
 		{false, "[class*=\"column\"]+[class*=\"column\"]:last-child{a:b;}", "[class*=\"column\"]+[class*=\"column\"]:last-child{a:b;}"},
 		{false, "@media { @viewport }", "@media{@viewport;}"},
 		{false, "table { @unknown }", "table{@unknown;}"},
-		{false, "a{@media{width:70%;} b{width:60%;}}", "a{@media{ERROR(width:70%;})ERROR(b{width:60%;})}"},
+		{false, "a{@media{width:70%;} b{width:60%;}}", "a{@media{width:70%;}b{width:60%;}}"},
+
+		// nesting
+		{false, ".a { color: red; .b { color: blue } }", ".a{color:red;.b{color:blue;}}"},
+		{false, ".a { & > .b { x:y } &:hover { x:y } }", ".a{&>.b{x:y;}&:hover{x:y;}}"},
+		{false, "a { b:hover { x:y } x:y }", "a{b:hover{x:y;}x:y;}"},
+		{false, "a { > b, + c { x:y } }", "a{>b,+c{x:y;}}"},
+		{false, "a { #b, [c], :d, * { x:y } }", "a{#b,[c],:d,*{x:y;}}"},
+		{false, "a { x: {y}; --z: {w} }", "a{x:{y};--z: {w} ;}"},
+		{false, "a { x: url(b) }", "a{x:url(b);}"},
+		{false, "a { b { c { x:y } } }", "a{b{c{x:y;}}}"},
+		{false, "a { @media (min-width: 1px) { x:y; & b { x:y } } }", "a{@media(min-width:1px){x:y;& b{x:y;}}}"},
+		{false, "a { @supports (x:y) { @layer z { x:y } } }", "a{@supports(x:y){@layer z{x:y;}}}"},
+		{false, "a { @container (width > 1px) { x:y } @scope (b) { x:y } @starting-style { x:y } }", "a{@container(width > 1px){x:y;}@scope(b){x:y;}@starting-style{x:y;}}"},
+		{false, "a { @nest .b & { x:y } }", "a{.b &{x:y;}}"},
+		{false, "@container (width > 1px) { a { x:y } }", "@container(width > 1px){a{x:y;}}"},
+		{false, "@media print { a { b { x:y } } }", "@media print{a{b{x:y;}}}"},
+		{false, "a { b { x:y }", "a{b{x:y;}"},
 
 		// early endings
 		{false, "selector{", "selector{"},
@@ -201,6 +218,29 @@ func TestParseError(t *testing.T) {
 	}
 }
 
+func TestParseDiagnostics(t *testing.T) {
+	d := parse.NewDiagnostics(0)
+	p := NewParser(parse.NewInputString("a{color red;margin:0}\nb{x y;z}"), false)
+	p.SetDiagnostics(d)
+	for {
+		if gt, _, _ := p.Next(); gt == ErrorGrammar && !p.HasParseError() {
+			break
+		}
+	}
+	test.String(t, d.Error(), "expected colon in declaration on line 1 and column 9\nexpected colon in declaration on line 2 and column 5\nexpected colon in declaration on line 2 and column 8")
+
+	d = parse.NewDiagnostics(1)
+	p = NewParser(parse.NewInputString("a{color red;margin:0}\nb{x y;z}"), false)
+	p.SetDiagnostics(d)
+	for {
+		if gt, _, _ := p.Next(); gt == ErrorGrammar && !p.HasParseError() {
+			break
+		}
+	}
+	test.T(t, len(d.Errors), 1)
+	test.T(t, d.Omitted, 2)
+}
+
 func TestParseOffset(t *testing.T) {
 	z := parse.NewInputString(`div{background:url(link);}`)
 	p := NewParser(z, false)
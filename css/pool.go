@@ -0,0 +1,37 @@
+package css
+
+import (
+	"sync"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var parserPool = sync.Pool{
+	New: func() interface{} {
+		return &Parser{
+			l:     &Lexer{},
+			state: make([]State, 0, 4),
+		}
+	},
+}
+
+// AcquireParser returns a parser for r like NewParser, taken from a pool of parsers that were released with ReleaseParser so that their buffers are reused. This saves allocations when parsing many small style sheets, such as style attributes.
+func AcquireParser(r *parse.Input, isInline bool) *Parser {
+	p := parserPool.Get().(*Parser)
+	p.Reset(r, isInline)
+	return p
+}
+
+// ReleaseParser returns a parser to the pool. Neither the parser nor the slices returned by its Values may be used afterwards.
+func ReleaseParser(p *Parser) {
+	p.Reset(nil, false)
+	buf := p.buf[:cap(p.buf)]
+	for i := range buf {
+		buf[i] = Token{} // don't retain the input
+	}
+	ahead := p.ahead[:cap(p.ahead)]
+	for i := range ahead {
+		ahead[i] = aheadToken{}
+	}
+	parserPool.Put(p)
+}
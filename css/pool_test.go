@@ -0,0 +1,61 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func parseString(p *Parser) string {
+	s := ""
+	for {
+		gt, _, data := p.Next()
+		if gt == ErrorGrammar {
+			return s
+		}
+		s += string(data)
+		if gt == DeclarationGrammar || gt == BeginRulesetGrammar {
+			for _, val := range p.Values() {
+				s += string(val.Data)
+			}
+		}
+		s += ";"
+	}
+}
+
+func TestPool(t *testing.T) {
+	// the state of a released parser must not leak into the next parser
+	p := AcquireParser(parse.NewInputString("a{color:red"), false)
+	gt, _, _ := p.Next()
+	test.T(t, gt, BeginRulesetGrammar)
+	ReleaseParser(p)
+
+	for i := 0; i < 3; i++ {
+		p = AcquireParser(parse.NewInputString("color:red;margin:0"), true)
+		test.String(t, parseString(p), "colorred;margin0;")
+		ReleaseParser(p)
+
+		p = AcquireParser(parse.NewInputString("a{color:red}"), false)
+		test.String(t, parseString(p), "a;colorred;};")
+		ReleaseParser(p)
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	p := NewParser(parse.NewInputString("a{color"), false)
+	p.SetDiagnostics(&parse.Diagnostics{})
+	_, _, _ = p.Next()
+	p.Reset(parse.NewInputString("color:red"), true)
+	test.That(t, p.diag == nil)
+	test.String(t, parseString(p), "colorred;")
+}
+
+func BenchmarkPool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p := AcquireParser(parse.NewInputString("color:red;margin:0 auto;font:12px/1.5 sans-serif"), true)
+		for gt, _, _ := p.Next(); gt != ErrorGrammar; gt, _, _ = p.Next() {
+		}
+		ReleaseParser(p)
+	}
+}
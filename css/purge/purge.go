@@ -0,0 +1,441 @@
+// Package purge finds the rulesets, selectors, and declarations of a CSS stylesheet that are provably unused, given the selectors that are used by a set of documents. The cascade is taken into account, including cascade layers and !important.
+package purge
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+	"github.com/politepixels/tdewolff-parse/v2/css/lint"
+	"github.com/politepixels/tdewolff-parse/v2/css/selector"
+)
+
+// Span is a byte range of the input that can be deleted.
+type Span struct {
+	Start, End int
+}
+
+// Options determines what is considered unused.
+type Options struct {
+	// Used returns true when a complex selector matches an element in any of the documents, for example using a selector matcher on an HTML corpus. All selectors are used when Used is nil.
+	Used func(selector.Complex) bool
+
+	// AssumeSupported assumes that the user agent supports all declarations, so that a declaration that is overridden by another for the same property is unused. Otherwise the overridden declaration is kept as a fallback unless both have the same value.
+	AssumeSupported bool
+}
+
+// UsedSelectors returns a function for Options.Used that returns true for the given selectors, which are compared in their normalized form. Invalid selectors are ignored.
+func UsedSelectors(selectors ...string) func(selector.Complex) bool {
+	used := map[string]bool{}
+	for _, s := range selectors {
+		list, err := selector.Parse(parse.NewInputString(s))
+		if err != nil {
+			continue
+		}
+		for _, sel := range list {
+			used[sel.String()] = true
+		}
+	}
+	return func(sel selector.Complex) bool {
+		return used[sel.String()]
+	}
+}
+
+// Unused returns the spans of the stylesheet that are unused in ascending order and without overlap. These are:
+//   - rulesets of which no selector is used, and selectors that are not used in a selector list with used selectors,
+//   - declarations that lose the cascade against a declaration for the same property in a ruleset with the same selectors, in the same conditional rules such as @media, see Options.AssumeSupported,
+//   - rulesets of which all declarations are unused, and conditional rules such as @media of which all rules are unused.
+//
+// Rulesets inside other at-rules, such as @keyframes and @font-face, are always kept. Blocks of @layer rules are kept since they declare the order of layers, even when all their rules are unused.
+func Unused(r *parse.Input, o Options) ([]Span, error) {
+	c := &collector{}
+	if _, err := lint.NewLinter(c).Lint(r, false); err != nil {
+		return nil, err
+	}
+
+	p := &purger{Options: o, src: r.Bytes(), decls: map[string][]*declaration{}}
+	root := &item{kind: layerItem}
+	unlayered := &layer{}
+	for i := 0; i < len(c.nodes); {
+		i += p.build(root, c.nodes[i:], unlayered, "", "") // continues after stray closing braces
+	}
+	p.overrides()
+	p.prune(root)
+
+	sort.Slice(p.spans, func(i, j int) bool {
+		return p.spans[i].Start < p.spans[j].Start
+	})
+	spans := []Span{}
+	for _, span := range p.spans {
+		if 0 < len(spans) && span.Start <= spans[len(spans)-1].End {
+			if spans[len(spans)-1].End < span.End {
+				spans[len(spans)-1].End = span.End
+			}
+			continue
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// Purge writes the stylesheet to w without its unused spans, see Unused.
+func Purge(w io.Writer, r *parse.Input, o Options) error {
+	spans, err := Unused(r, o)
+	if err != nil {
+		return err
+	}
+	b := r.Bytes()
+	prev := 0
+	for _, span := range spans {
+		if _, err := w.Write(b[prev:span.Start]); err != nil {
+			return err
+		}
+		prev = span.End
+	}
+	_, err = w.Write(b[prev:])
+	return err
+}
+
+////////////////////////////////////////////////////////////////
+
+// collector is a lint rule that records all nodes.
+type collector struct {
+	nodes []lint.Node
+}
+
+func (c *collector) Name() string {
+	return "purge"
+}
+
+func (c *collector) Begin() {
+	c.nodes = c.nodes[:0]
+}
+
+func (c *collector) Visit(_ *lint.Context, n lint.Node) {
+	n.Values = append([]css.Token{}, n.Values...) // the parser reuses its buffer
+	c.nodes = append(c.nodes, n)
+}
+
+type itemKind int
+
+const (
+	statementItem   itemKind = iota // at-rule statement or unknown token
+	commentItem                     // comment, which is not counted as content of a block
+	declarationItem                 // declaration in a ruleset
+	rulesetItem                     // ruleset that can be purged
+	conditionalItem                 // conditional group rule such as @media
+	layerItem                       // @layer block or the stylesheet
+	opaqueItem                      // at-rule or ruleset whose contents are kept
+)
+
+// item is a node of the stylesheet with the span it occupies, including braces and the terminating semicolon.
+type item struct {
+	kind       itemKind
+	start, end int
+	children   []*item
+
+	unused    bool   // for rulesets without used selectors and for overridden declarations
+	selectors []Span // spans of the selectors of rulesets
+	used      []bool // whether the selectors are used
+}
+
+// declaration is a declaration in a ruleset that can be overridden.
+type declaration struct {
+	item      *item
+	important bool
+	layer     []int // cascade order of the layer
+	value     string
+}
+
+// layer is a cascade layer, or the stylesheet for unlayered rules.
+type layer struct {
+	parent *layer
+	rank   int // order among the sublayers of the parent
+	names  map[string]*layer
+	n      int // number of sublayers
+}
+
+// sublayer returns the sublayer with the given name, which is created when it does not exist. A new sublayer is created for each anonymous layer.
+func (l *layer) sublayer(name string) *layer {
+	if sub, ok := l.names[name]; ok && name != "" {
+		return sub
+	}
+	sub := &layer{parent: l, rank: l.n}
+	l.n++
+	if name != "" {
+		if l.names == nil {
+			l.names = map[string]*layer{}
+		}
+		l.names[name] = sub
+	}
+	return sub
+}
+
+// order returns the cascade order of rules in the layer, which compares lexicographically. Rules that are directly in a layer come after the rules in its sublayers, and unlayered rules come last.
+func (l *layer) order() []int {
+	order := []int{int(^uint(0) >> 1)}
+	for ; l.parent != nil; l = l.parent {
+		order = append([]int{l.rank}, order...)
+	}
+	return order
+}
+
+func compareOrder(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+type purger struct {
+	Options
+	src   []byte
+	keys  []string // keys of decls in order of appearance
+	decls map[string][]*declaration
+	spans []Span
+}
+
+// build appends the items of nodes to parent until the end of the parent's block and returns the number of nodes consumed. The context holds the conditional rules that enclose the items, and selectorsKey the used selectors of a parent ruleset.
+func (p *purger) build(parent *item, nodes []lint.Node, l *layer, context, selectorsKey string) int {
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		switch n.Type {
+		case css.EndAtRuleGrammar, css.EndRulesetGrammar:
+			parent.end = n.End
+			return i + 1
+		case css.CommentGrammar:
+			parent.children = append(parent.children, &item{kind: commentItem, start: n.Start, end: n.End})
+		case css.AtRuleGrammar:
+			name := strings.ToLower(string(n.Data))
+			if parent.kind == layerItem && name == "@layer" {
+				for _, names := range layerNames(n.Values) {
+					declareLayer(l, names)
+				}
+			} else if parent.kind == layerItem && name == "@import" {
+				for j, t := range n.Values {
+					if t.TokenType == css.FunctionToken && parse.EqualFold(t.Data, []byte("layer(")) {
+						if names := layerNames(n.Values[j+1:]); 0 < len(names) {
+							declareLayer(l, names[0])
+						}
+					}
+				}
+			}
+			parent.children = append(parent.children, &item{kind: statementItem, start: n.Start, end: p.declarationEnd(n.End)})
+		case css.DeclarationGrammar, css.CustomPropertyGrammar:
+			it := &item{kind: declarationItem, start: n.Start, end: p.declarationEnd(n.End)}
+			parent.children = append(parent.children, it)
+			if parent.kind == rulesetItem && !parent.unused {
+				key := context + "\x00" + selectorsKey + "\x00" + string(n.Data)
+				if _, ok := p.decls[key]; !ok {
+					p.keys = append(p.keys, key)
+				}
+				d := declaration{item: it, layer: l.order()}
+				d.value, d.important = declarationValue(n)
+				p.decls[key] = append(p.decls[key], &d)
+			}
+		case css.BeginAtRuleGrammar:
+			it := &item{kind: opaqueItem, start: n.Start}
+			name := strings.ToLower(string(n.Data))
+			sublayer, subcontext := l, context
+			if parent.kind == layerItem || parent.kind == conditionalItem {
+				switch name {
+				case "@media", "@supports", "@container", "@document", "@-moz-document", "@scope", "@starting-style":
+					it.kind = conditionalItem
+					subcontext += name + " " + string(bytes.TrimSpace(p.src[n.Start+len(n.Data):n.End])) + "{"
+				case "@layer":
+					it.kind = layerItem
+					names := layerNames(n.Values)
+					if len(names) == 0 {
+						sublayer = l.sublayer("")
+					} else {
+						sublayer = declareLayer(l, names[0])
+					}
+				}
+			}
+			i += p.build(it, nodes[i+1:], sublayer, subcontext, "")
+			parent.children = append(parent.children, it)
+		case css.BeginRulesetGrammar:
+			it := &item{kind: opaqueItem, start: n.Start}
+			key := ""
+			if parent.kind == layerItem || parent.kind == conditionalItem {
+				it.kind = rulesetItem
+				key = p.selectors(it, n)
+			}
+			i += p.build(it, nodes[i+1:], l, context, key)
+			parent.children = append(parent.children, it)
+		default:
+			parent.children = append(parent.children, &item{kind: statementItem, start: n.Start, end: n.End})
+		}
+	}
+	if 0 < len(parent.children) {
+		parent.end = parent.children[len(parent.children)-1].end
+	}
+	return len(nodes)
+}
+
+// selectors sets the selectors of a ruleset and whether they are used, and returns the key of the used selectors which is equal for rulesets with the same used selectors in any order.
+func (p *purger) selectors(it *item, n lint.Node) string {
+	list, err := selector.Parse(parse.NewInputBytes(parse.Copy(p.src[n.Start:n.End]))) // copy since the input writes a NULL terminator
+	if err != nil {
+		// unknown selectors are used
+		return string(bytes.Join(bytes.Fields(p.src[n.Start:n.End]), []byte(" ")))
+	}
+	keys := []string{}
+	for _, sel := range list {
+		used := p.Used == nil || p.Used(sel)
+		it.selectors = append(it.selectors, Span{n.Start + sel.Start, n.Start + sel.End})
+		it.used = append(it.used, used)
+		if used {
+			keys = append(keys, sel.String())
+		}
+	}
+	it.unused = len(keys) == 0
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// declarationEnd returns the end of a declaration or at-rule statement including its terminating semicolon.
+func (p *purger) declarationEnd(end int) int {
+	i := end
+	for i < len(p.src) && parse.IsWhitespace(p.src[i]) {
+		i++
+	}
+	if i < len(p.src) && p.src[i] == ';' {
+		return i + 1
+	}
+	return end
+}
+
+// overrides marks the declarations that lose the cascade against another declaration with the same key.
+func (p *purger) overrides() {
+	for _, key := range p.keys {
+		decls := p.decls[key]
+		winner := decls[0]
+		for _, d := range decls[1:] {
+			if cmp := compareOrder(d.layer, winner.layer); d.important != winner.important {
+				if d.important {
+					winner = d
+				}
+			} else if !d.important && 0 <= cmp || d.important && cmp <= 0 {
+				winner = d // later declarations win within the same layer
+			}
+		}
+		for _, d := range decls {
+			if d != winner && (p.AssumeSupported || d.value == winner.value) {
+				d.item.unused = true
+			}
+		}
+	}
+}
+
+// prune adds the spans of the unused children of an item, and returns true when the item itself is unused.
+func (p *purger) prune(it *item) bool {
+	switch it.kind {
+	case declarationItem:
+		return it.unused
+	case rulesetItem, conditionalItem, layerItem:
+		if it.unused {
+			return true
+		}
+		unused := make([]bool, len(it.children))
+		content, used := 0, 0
+		for i, child := range it.children {
+			if child.kind == commentItem {
+				continue
+			}
+			content++
+			if unused[i] = p.prune(child); !unused[i] {
+				used++
+			}
+		}
+		if it.kind != layerItem && 0 < content && used == 0 {
+			return true
+		}
+		for i, child := range it.children {
+			if unused[i] {
+				p.spans = append(p.spans, Span{child.start, child.end})
+			}
+		}
+		p.pruneSelectors(it)
+	}
+	return false
+}
+
+// pruneSelectors adds the spans of the unused selectors of a ruleset together with their comma.
+func (p *purger) pruneSelectors(it *item) {
+	for i, sel := range it.selectors {
+		if it.used[i] {
+			continue
+		}
+		prev := -1
+		for j := i - 1; 0 <= j; j-- {
+			if it.used[j] {
+				prev = j
+				break
+			}
+		}
+		if prev != -1 {
+			p.spans = append(p.spans, Span{it.selectors[prev].End, sel.End})
+			continue
+		}
+		for j := i + 1; j < len(it.selectors); j++ {
+			if it.used[j] {
+				p.spans = append(p.spans, Span{sel.Start, it.selectors[j].Start})
+				break
+			}
+		}
+	}
+}
+
+// declareLayer declares a layer with a possibly dotted name in l and returns it.
+func declareLayer(l *layer, names []string) *layer {
+	for _, name := range names {
+		l = l.sublayer(name)
+	}
+	return l
+}
+
+// layerNames returns the comma-separated layer names of an @layer rule or the layer() function of an @import rule, with each name split at its dots.
+func layerNames(values []css.Token) [][]string {
+	names := [][]string{}
+	name := []string{}
+	for _, t := range values {
+		switch t.TokenType {
+		case css.IdentToken:
+			name = append(name, string(t.Data))
+		case css.CommaToken, css.RightParenthesisToken:
+			if 0 < len(name) {
+				names = append(names, name)
+			}
+			name = []string{}
+			if t.TokenType == css.RightParenthesisToken {
+				return names
+			}
+		}
+	}
+	if 0 < len(name) {
+		names = append(names, name)
+	}
+	return names
+}
+
+// declarationValue returns the value of a declaration as a string without !important, and whether it is important.
+func declarationValue(n lint.Node) (string, bool) {
+	sb := strings.Builder{}
+	for _, t := range n.Values {
+		sb.Write(t.Data)
+	}
+	value := strings.TrimSpace(sb.String())
+	if i := strings.LastIndexByte(value, '!'); i != -1 && strings.EqualFold(strings.TrimSpace(value[i+1:]), "important") {
+		return strings.TrimSpace(value[:i]), true
+	}
+	return value, false
+}
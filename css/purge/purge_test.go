@@ -0,0 +1,94 @@
+package purge
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestPurge(t *testing.T) {
+	var tests = []struct {
+		css      string
+		used     []string
+		expected string
+	}{
+		{`.a{color:red}.b{color:blue}`, []string{".a"}, `.a{color:red}`},
+		{`.a, .b, .c{color:red}`, []string{".a"}, `.a{color:red}`},
+		{`.a, .b, .c{color:red}`, []string{".c"}, `.c{color:red}`},
+		{`.a, .b, .c{color:red}`, []string{".b"}, `.b{color:red}`},
+		{`.a, .b, .c{color:red}`, []string{".a", ".c"}, `.a, .c{color:red}`},
+		{`div > p:hover, span{color:red}`, []string{"div>p:hover"}, `div > p:hover{color:red}`},
+		{`@media (min-width: 1px) { .a{color:red} .b{color:blue} } .c{}`, []string{".c"}, ` .c{}`},
+		{`@media (min-width: 1px) { .a{color:red} .b{color:blue} }`, []string{".a"}, `@media (min-width: 1px) { .a{color:red}  }`},
+		{`@supports (display: grid) { @media print { .a{} } }`, []string{}, ``},
+		{`@layer base { .a{color:red} }`, []string{}, `@layer base {  }`},
+		{`@keyframes k { from{top:0} to{top:1px} } @font-face { src: url(a); src: url(b) }`, []string{}, `@keyframes k { from{top:0} to{top:1px} } @font-face { src: url(a); src: url(b) }`},
+		{`/* a */ .a{ /* b */ color:red}`, []string{}, `/* a */ `},
+		{`::selection{color:red} a::before{content:''}`, []string{"::selection"}, `::selection{color:red} `},
+
+		// overridden declarations
+		{`.a{color:red; color:red}`, nil, `.a{ color:red}`},
+		{`.a{color:red; color:blue}`, nil, `.a{color:red; color:blue}`},
+		{`.a{color:red} .a{color:red}`, nil, ` .a{color:red}`},
+		{`.a{color:red;top:0} .a{color:red}`, nil, `.a{top:0} .a{color:red}`},
+		{`.a, .b{color:red} .b, .a{color:red}`, nil, ` .b, .a{color:red}`},
+		{`.a, .b{color:red} .a{color:red}`, nil, `.a, .b{color:red} .a{color:red}`},
+		{`.a, .b{color:red} .a{color:red}`, []string{".a"}, ` .a{color:red}`},
+		{`.a{color:red !important} .a{color:red}`, nil, `.a{color:red !important} `},
+		{`.a{color:red!IMPORTANT} .a{color:red !important}`, nil, ` .a{color:red !important}`},
+		{`.a{--x:1 !important} .a{--x: 1}`, nil, `.a{--x:1 !important} `},
+		{`@media print{.a{color:red}} .a{color:red}`, nil, `@media print{.a{color:red}} .a{color:red}`},
+		{`@media print{.a{color:red}} @media print{.a{color:red}}`, nil, ` @media print{.a{color:red}}`},
+		{`@media print{.a{color:red}} @media screen{.a{color:red}}`, nil, `@media print{.a{color:red}} @media screen{.a{color:red}}`},
+
+		// layers
+		{`@layer a{.a{color:red}} .a{color:red}`, nil, `@layer a{} .a{color:red}`},
+		{`.a{color:red} @layer a{.a{color:red}}`, nil, `.a{color:red} @layer a{}`},
+		{`.a{color:red !important} @layer a{.a{color:red !important}}`, nil, ` @layer a{.a{color:red !important}}`},
+		{`@layer b, a; @layer a{.a{color:red}} @layer b{.a{color:red}}`, nil, `@layer b, a; @layer a{.a{color:red}} @layer b{}`},
+		{`@layer a{.a{color:red}} @layer b{.a{color:red}} @layer a{.a{top:0}}`, nil, `@layer a{} @layer b{.a{color:red}} @layer a{.a{top:0}}`},
+		{`@layer a{.a{color:red}} @layer a.b{.a{color:red}}`, nil, `@layer a{.a{color:red}} @layer a.b{}`},
+		{`@layer a{@layer b{.a{color:red}} .a{color:red}}`, nil, `@layer a{@layer b{} .a{color:red}}`},
+		{`@layer{.a{color:red}} @layer{.a{color:red}}`, nil, `@layer{} @layer{.a{color:red}}`},
+		{`@import url(x) layer(b); @layer a{.a{color:red}} @layer b{.a{color:red}}`, nil, `@import url(x) layer(b); @layer a{.a{color:red}} @layer b{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			o := Options{}
+			if tt.used != nil {
+				o.Used = UsedSelectors(tt.used...)
+			}
+			w := &bytes.Buffer{}
+			test.Error(t, Purge(w, parse.NewInputString(tt.css), o))
+			test.String(t, w.String(), tt.expected)
+		})
+	}
+}
+
+func TestPurgeAssumeSupported(t *testing.T) {
+	var tests = []struct {
+		css      string
+		expected string
+	}{
+		{`.a{color:red; color:blue}`, `.a{ color:blue}`},
+		{`.a{color:red; color:blue; top:0}`, `.a{ color:blue; top:0}`},
+		{`.a{display:grid!important} .a{display:flex}`, `.a{display:grid!important} `},
+		{`@layer a{.a{color:red}} .a{color:blue}`, `@layer a{} .a{color:blue}`},
+		{`@font-face{src:url(a);src:url(b)}`, `@font-face{src:url(a);src:url(b)}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			test.Error(t, Purge(w, parse.NewInputString(tt.css), Options{AssumeSupported: true}))
+			test.String(t, w.String(), tt.expected)
+		})
+	}
+}
+
+func TestUnused(t *testing.T) {
+	spans, err := Unused(parse.NewInputString(`.a, .b{color:red} .c{top:0}`), Options{Used: UsedSelectors(".a")})
+	test.Error(t, err)
+	test.T(t, spans, []Span{{2, 6}, {18, 27}})
+}
@@ -0,0 +1,58 @@
+package css
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Redact returns a copy of src where the contents of strings, URLs, and comments are replaced by placeholders of the same length, see parse.Redact. Escape sequences are kept so that the result tokenizes the same as src with all tokens at the same offsets. Identifiers, selectors, and numbers are kept.
+func Redact(src []byte) []byte {
+	dst := parse.Copy(src)
+	r := parse.NewInputBytes(parse.Copy(src))
+	l := NewLexer(r)
+	prevEnd := 0
+	for {
+		tt, data := l.Next()
+		if tt == ErrorToken {
+			if l.Err() != io.EOF {
+				// redact the remainder that could not be tokenized
+				parse.Redact(dst[prevEnd:], nil)
+			}
+			break
+		}
+
+		end := r.Offset()
+		b := dst[end-len(data) : end]
+		prevEnd = end
+		switch tt {
+		case StringToken, BadStringToken:
+			parse.Redact(b, redactSkipEscape)
+		case URLToken, BadURLToken:
+			if i := bytes.IndexByte(b, '('); i != -1 {
+				parse.Redact(b[i+1:], redactSkipEscape)
+			}
+		case CommentToken:
+			parse.Redact(b, nil)
+		}
+	}
+	return dst
+}
+
+// redactSkipEscape returns the length of an escape sequence at the start of b.
+func redactSkipEscape(b []byte) int {
+	if b[0] != '\\' || len(b) < 2 {
+		return 0
+	}
+	n := 1
+	for ; n < len(b) && n < 7; n++ {
+		if c := b[n]; (c < '0' || '9' < c) && (c < 'a' || 'f' < c) && (c < 'A' || 'F' < c) {
+			break
+		}
+	}
+	if n == 1 {
+		return 2
+	}
+	return n
+}
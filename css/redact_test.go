@@ -0,0 +1,25 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		css      string
+		expected string
+	}{
+		{`a::before{content:"Secret 42"}`, `a::before{content:"Xxxxxx 00"}`},
+		{`.a{content:'\201C Hi\'s'}`, `.a{content:'\201C Xx\'x'}`},
+		{`.a{background:url(img/Logo.png)} .b{background:URL( "a.png" )}`, `.a{background:url(xxx/Xxxx.xxx)} .b{background:URL( "x.xxx" )}`},
+		{`/* Copyright ACME */ .a{color:red}`, `/* Xxxxxxxxx XXXX */ .a{color:red}`},
+		{`.a{font-family:"Café"}`, `.a{font-family:"Xxxé"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			test.String(t, string(Redact([]byte(tt.css))), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package selector
+
+import (
+	"bytes"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// MatchLang returns true if the language tag is matched by the language range using extended filtering as defined by RFC 4647 section 3.3.2, which is how :lang() matches. The range * and wildcard subtags such as in *-CH match any subtag, and comparison is case-insensitive.
+func MatchLang(langRange, tag []byte) bool {
+	if len(langRange) == 0 || len(tag) == 0 {
+		return false
+	}
+	ranges := bytes.Split(langRange, []byte("-"))
+	subtags := bytes.Split(tag, []byte("-"))
+	if !isWildcard(ranges[0]) && !parse.EqualFold(ranges[0], subtags[0]) {
+		return false
+	}
+
+	i, j := 1, 1
+	for i < len(ranges) {
+		if isWildcard(ranges[i]) {
+			i++
+		} else if len(subtags) <= j {
+			return false
+		} else if parse.EqualFold(ranges[i], subtags[j]) {
+			i++
+			j++
+		} else if len(subtags[j]) == 1 {
+			return false // singletons such as x in en-x-private cannot be skipped
+		} else {
+			j++
+		}
+	}
+	return true
+}
+
+// MatchLangBasic returns true if the language tag is matched by the language range using basic filtering as defined by RFC 4647 section 3.3.1, that is when the range equals the tag or a prefix of the tag followed by a hyphen. The range * matches any tag and comparison is case-insensitive.
+func MatchLangBasic(langRange, tag []byte) bool {
+	if isWildcard(langRange) {
+		return true
+	} else if len(tag) < len(langRange) || !parse.EqualFold(langRange, tag[:len(langRange)]) {
+		return false
+	}
+	return len(tag) == len(langRange) || tag[len(langRange)] == '-'
+}
+
+func isWildcard(b []byte) bool {
+	return len(b) == 1 && b[0] == '*'
+}
+
+// MatchesLang returns true if the language tag of an element is matched by any of the language ranges of a :lang() selector.
+func (s Simple) MatchesLang(tag []byte) bool {
+	for _, langRange := range s.Langs {
+		if MatchLang(langRange, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestMatchLang(t *testing.T) {
+	var langTests = []struct {
+		langRange string
+		tag       string
+		extended  bool
+		basic     bool
+	}{
+		// from RFC 4647
+		{"de-DE", "de-DE", true, true},
+		{"de-DE", "de-de", true, true},
+		{"de-DE", "de-Latn-DE", true, false},
+		{"de-DE", "de-Latf-DE", true, false},
+		{"de-DE", "de-DE-x-goethe", true, true},
+		{"de-DE", "de-Latn-DE-1996", true, false},
+		{"de-DE", "de-Deva-DE", true, false},
+		{"de-DE", "de", false, false},
+		{"de-DE", "de-x-DE", false, false},
+		{"de-DE", "de-Deva", false, false},
+		{"de-*-DE", "de-Latn-DE", true, false},
+		{"*-CH", "de-CH", true, false},
+		{"*-CH", "fr-Latn-CH", true, false},
+		{"*-CH", "fr-FR", false, false},
+		{"*", "fr", true, true},
+		{"fr", "fr-BE", true, true},
+		{"fr", "fro", false, false},
+		{"", "fr", false, false},
+		{"fr", "", false, false},
+	}
+	for _, tt := range langTests {
+		t.Run(tt.langRange+" "+tt.tag, func(t *testing.T) {
+			test.T(t, MatchLang([]byte(tt.langRange), []byte(tt.tag)), tt.extended, "extended filtering")
+			test.T(t, MatchLangBasic([]byte(tt.langRange), []byte(tt.tag)), tt.basic, "basic filtering")
+		})
+	}
+}
+
+func TestMatchesLang(t *testing.T) {
+	list, err := Parse(parse.NewInputString(`:lang(en, "*-CH")`))
+	test.Error(t, err)
+	s := list[0].Compounds[0].Selectors[0]
+	test.T(t, s.MatchesLang([]byte("en-US")), true)
+	test.T(t, s.MatchesLang([]byte("de-CH")), true)
+	test.T(t, s.MatchesLang([]byte("de-DE")), false)
+}
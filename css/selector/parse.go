@@ -0,0 +1,629 @@
+package selector
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/buffer"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+)
+
+// argument kinds of functional pseudo-classes and pseudo-elements
+const (
+	rawArg = iota
+	listArg
+	relativeListArg
+	compoundArg
+	identListArg
+	identArg
+	langArg
+	nthArg
+	nthOfArg
+)
+
+var pseudoClassArgs = map[string]int{
+	"not":              listArg,
+	"is":               listArg,
+	"where":            listArg,
+	"matches":          listArg,
+	"-webkit-any":      listArg,
+	"-moz-any":         listArg,
+	"has":              relativeListArg,
+	"host":             compoundArg,
+	"host-context":     compoundArg,
+	"state":            identArg,
+	"dir":              identArg,
+	"lang":             langArg,
+	"nth-child":        nthOfArg,
+	"nth-last-child":   nthOfArg,
+	"nth-of-type":      nthArg,
+	"nth-last-of-type": nthArg,
+	"nth-col":          nthArg,
+	"nth-last-col":     nthArg,
+}
+
+var pseudoElementArgs = map[string]int{
+	"slotted":    compoundArg,
+	"cue":        listArg,
+	"cue-region": listArg,
+	"part":       identListArg,
+	"highlight":  identArg,
+}
+
+// legacyPseudoElements are pseudo-elements that may be written with a single colon.
+var legacyPseudoElements = map[string]bool{
+	"before":       true,
+	"after":        true,
+	"first-line":   true,
+	"first-letter": true,
+}
+
+type token struct {
+	css.Token
+	Start, End int
+}
+
+type parser struct {
+	b      []byte
+	tokens []token
+	pos    int
+	end    int // offset of the end of the tokens
+}
+
+// Parse parses a comma-separated list of complex selectors. Comments are ignored.
+func Parse(r *parse.Input) (List, error) {
+	p := &parser{b: r.Bytes(), end: r.Len()}
+	l := css.NewLexer(r)
+	for {
+		start := r.Offset()
+		tt, data := l.Next()
+		if tt == css.ErrorToken {
+			if l.Err() != io.EOF {
+				return nil, l.Err()
+			}
+			break
+		} else if tt == css.CommentToken {
+			continue
+		} else if tt == css.WhitespaceToken && 0 < len(p.tokens) && p.tokens[len(p.tokens)-1].TokenType == css.WhitespaceToken {
+			p.tokens[len(p.tokens)-1].End = r.Offset()
+			continue
+		}
+		p.tokens = append(p.tokens, token{css.Token{TokenType: tt, Data: data}, start, r.Offset()})
+	}
+
+	p.skipWhitespace()
+	list, err := p.parseList(false)
+	if err != nil {
+		return nil, err
+	} else if t := p.peek(); t.TokenType != css.ErrorToken {
+		return nil, p.errorf(t, "unexpected '%s' in selector", string(t.Data))
+	}
+	return list, nil
+}
+
+func (p *parser) errorf(t token, message string, a ...interface{}) error {
+	return parse.NewError(buffer.NewReader(p.b), t.Start, message, a...)
+}
+
+// peek returns the current token, or an error token at the end of the tokens.
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{css.Token{TokenType: css.ErrorToken}, p.end, p.end}
+}
+
+func (p *parser) skipWhitespace() bool {
+	if p.peek().TokenType == css.WhitespaceToken {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseList(relative bool) (List, error) {
+	list := List{}
+	for {
+		c, err := p.parseComplex(relative)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+		if p.peek().TokenType != css.CommaToken {
+			return list, nil
+		}
+		p.pos++
+		p.skipWhitespace()
+	}
+}
+
+func (p *parser) parseCombinator() Combinator {
+	t := p.peek()
+	if t.TokenType == css.ColumnToken {
+		p.pos++
+		return ColumnCombinator
+	} else if t.TokenType == css.DelimToken {
+		switch t.Data[0] {
+		case '>':
+			p.pos++
+			return ChildCombinator
+		case '+':
+			p.pos++
+			return NextSiblingCombinator
+		case '~':
+			p.pos++
+			return SubsequentSiblingCombinator
+		}
+	}
+	return NoCombinator
+}
+
+// parseComplex parses a complex selector including trailing whitespace.
+func (p *parser) parseComplex(relative bool) (Complex, error) {
+	c := Complex{Start: p.peek().Start}
+	combinator := NoCombinator
+	if relative {
+		if combinator = p.parseCombinator(); combinator != NoCombinator {
+			p.skipWhitespace()
+		}
+	}
+	for {
+		compound, err := p.parseCompound(combinator)
+		if err != nil {
+			return c, err
+		}
+		c.Compounds = append(c.Compounds, compound)
+		c.End = compound.End
+
+		ws := p.skipWhitespace()
+		if combinator = p.parseCombinator(); combinator != NoCombinator {
+			p.skipWhitespace()
+		} else if tt := p.peek().TokenType; !ws || tt == css.CommaToken || tt == css.RightParenthesisToken || tt == css.ErrorToken {
+			return c, nil
+		} else {
+			combinator = DescendantCombinator
+		}
+	}
+}
+
+func (p *parser) parseCompound(combinator Combinator) (Compound, error) {
+	c := Compound{Combinator: combinator, Start: p.peek().Start}
+	if s, ok := p.parseTypeSelector(); ok {
+		c.Selectors = append(c.Selectors, s)
+	}
+
+	pseudoElement := false
+	for {
+		t := p.peek()
+		s := Simple{Start: t.Start, End: t.End}
+		if t.TokenType == css.ColonToken {
+			if err := p.parsePseudo(&s); err != nil {
+				return c, err
+			}
+			pseudoElement = pseudoElement || s.Type == PseudoElementSelector
+		} else if pseudoElement && (t.TokenType == css.HashToken || t.TokenType == css.LeftBracketToken || t.TokenType == css.DelimToken && (t.Data[0] == '.' || t.Data[0] == '&')) {
+			return c, p.errorf(t, "unexpected '%s' after pseudo-element", string(t.Data))
+		} else if t.TokenType == css.HashToken {
+			s.Type = IDSelector
+			s.Name = t.Data[1:]
+			p.pos++
+		} else if t.TokenType == css.DelimToken && t.Data[0] == '.' {
+			p.pos++
+			name := p.peek()
+			if name.TokenType != css.IdentToken {
+				return c, p.errorf(name, "expected class name after '.'")
+			}
+			s.Type = ClassSelector
+			s.Name = name.Data
+			s.End = name.End
+			p.pos++
+		} else if t.TokenType == css.DelimToken && t.Data[0] == '&' {
+			s.Type = NestingSelector
+			p.pos++
+		} else if t.TokenType == css.LeftBracketToken {
+			if err := p.parseAttribute(&s); err != nil {
+				return c, err
+			}
+		} else {
+			break
+		}
+		c.Selectors = append(c.Selectors, s)
+	}
+	if len(c.Selectors) == 0 {
+		t := p.peek()
+		if t.TokenType == css.ErrorToken {
+			return c, p.errorf(t, "unexpected end of selector")
+		}
+		return c, p.errorf(t, "unexpected '%s' in selector", string(t.Data))
+	}
+	c.End = c.Selectors[len(c.Selectors)-1].End
+	return c, nil
+}
+
+// parseQualifiedName parses an optional namespace prefix and a name, where a name of * is allowed when universal is set.
+func (p *parser) parseQualifiedName(universal bool) (namespace, name []byte, end int, ok bool) {
+	isName := func(t token) bool {
+		return t.TokenType == css.IdentToken || universal && t.TokenType == css.DelimToken && t.Data[0] == '*'
+	}
+	isPrefix := func(t token) bool {
+		return t.TokenType == css.IdentToken || t.TokenType == css.DelimToken && t.Data[0] == '*'
+	}
+
+	t := p.peek()
+	if t.TokenType == css.DelimToken && t.Data[0] == '|' {
+		if p.pos+1 < len(p.tokens) && isName(p.tokens[p.pos+1]) {
+			p.pos += 2
+			return []byte{}, p.tokens[p.pos-1].Data, p.tokens[p.pos-1].End, true
+		}
+		return nil, nil, 0, false
+	} else if !isPrefix(t) {
+		return nil, nil, 0, false
+	}
+	if p.pos+2 < len(p.tokens) && p.tokens[p.pos+1].TokenType == css.DelimToken && p.tokens[p.pos+1].Data[0] == '|' && isName(p.tokens[p.pos+2]) {
+		p.pos += 3
+		return t.Data, p.tokens[p.pos-1].Data, p.tokens[p.pos-1].End, true
+	} else if isName(t) {
+		p.pos++
+		return nil, t.Data, t.End, true
+	}
+	return nil, nil, 0, false
+}
+
+func (p *parser) parseTypeSelector() (Simple, bool) {
+	start := p.peek().Start
+	namespace, name, end, ok := p.parseQualifiedName(true)
+	if !ok {
+		return Simple{}, false
+	}
+	s := Simple{Type: TypeSelector, Namespace: namespace, Name: name, Start: start, End: end}
+	if len(name) == 1 && name[0] == '*' {
+		s.Type = UniversalSelector
+		s.Name = nil
+	}
+	return s, true
+}
+
+// unquote returns the data of an ident token, or the contents of a string token without quotes.
+func unquote(t token) []byte {
+	if t.TokenType != css.StringToken {
+		return t.Data
+	}
+	b := t.Data[1:]
+	if 0 < len(b) && b[len(b)-1] == t.Data[0] {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+func (p *parser) parseAttribute(s *Simple) error {
+	s.Type = AttributeSelector
+	p.pos++ // [
+	p.skipWhitespace()
+
+	var ok bool
+	if s.Namespace, s.Name, _, ok = p.parseQualifiedName(false); !ok {
+		return p.errorf(p.peek(), "expected attribute name")
+	}
+	p.skipWhitespace()
+
+	t := p.peek()
+	switch t.TokenType {
+	case css.RightBracketToken:
+		p.pos++
+		s.End = t.End
+		return nil
+	case css.IncludeMatchToken:
+		s.Matcher = IncludeMatcher
+	case css.DashMatchToken:
+		s.Matcher = DashMatcher
+	case css.PrefixMatchToken:
+		s.Matcher = PrefixMatcher
+	case css.SuffixMatchToken:
+		s.Matcher = SuffixMatcher
+	case css.SubstringMatchToken:
+		s.Matcher = SubstringMatcher
+	case css.DelimToken:
+		if t.Data[0] == '=' {
+			s.Matcher = EqualMatcher
+		}
+	}
+	if s.Matcher == ExistsMatcher {
+		return p.errorf(t, "expected attribute matcher or ']'")
+	}
+	p.pos++
+	p.skipWhitespace()
+
+	t = p.peek()
+	if t.TokenType == css.IdentToken || t.TokenType == css.StringToken {
+		s.Value = unquote(t)
+	} else {
+		return p.errorf(t, "expected attribute value")
+	}
+	p.pos++
+	p.skipWhitespace()
+
+	t = p.peek()
+	if t.TokenType == css.IdentToken && len(t.Data) == 1 && (t.Data[0]|0x20 == 'i' || t.Data[0]|0x20 == 's') {
+		s.Modifier = t.Data[0] | 0x20
+		p.pos++
+		p.skipWhitespace()
+		t = p.peek()
+	}
+	if t.TokenType != css.RightBracketToken {
+		return p.errorf(t, "expected ']' in attribute selector")
+	}
+	p.pos++
+	s.End = t.End
+	return nil
+}
+
+func (p *parser) parsePseudo(s *Simple) error {
+	s.Type = PseudoClassSelector
+	p.pos++ // :
+	if p.peek().TokenType == css.ColonToken {
+		s.Type = PseudoElementSelector
+		p.pos++
+	}
+
+	t := p.peek()
+	fn := t
+	if t.TokenType == css.IdentToken {
+		s.Name = parse.ToLower(parse.Copy(t.Data))
+		s.End = t.End
+		p.pos++
+	} else if t.TokenType == css.FunctionToken {
+		s.Name = parse.ToLower(parse.Copy(t.Data[:len(t.Data)-1]))
+		s.Function = true
+		p.pos++
+	} else if s.Type == PseudoElementSelector {
+		return p.errorf(t, "expected pseudo-element name")
+	} else {
+		return p.errorf(t, "expected pseudo-class name")
+	}
+	if s.Type == PseudoClassSelector && !s.Function && legacyPseudoElements[string(s.Name)] {
+		s.Type = PseudoElementSelector
+		s.Legacy = true
+	}
+	if !s.Function {
+		return nil
+	}
+
+	// collect argument tokens up to the matching parenthesis
+	start, level := p.pos, 0
+	for {
+		t = p.peek()
+		if t.TokenType == css.ErrorToken {
+			return p.errorf(t, "expected ')' in :%s()", string(s.Name))
+		} else if t.TokenType == css.FunctionToken || t.TokenType == css.LeftParenthesisToken {
+			level++
+		} else if t.TokenType == css.RightParenthesisToken {
+			if level == 0 {
+				break
+			}
+			level--
+		}
+		p.pos++
+	}
+	args := p.tokens[start:p.pos]
+	p.pos++
+	s.End = t.End
+
+	kind := pseudoClassArgs[string(s.Name)]
+	if s.Type == PseudoElementSelector {
+		kind = pseudoElementArgs[string(s.Name)]
+	}
+	sub := &parser{b: p.b, tokens: args, end: t.Start}
+	if 0 < len(args) {
+		fn = args[0] // report errors at the arguments unless there are none
+	}
+	sub.skipWhitespace()
+	switch kind {
+	case rawArg:
+		if 0 < len(args) && args[0].TokenType == css.WhitespaceToken {
+			args = args[1:]
+		}
+		if 0 < len(args) && args[len(args)-1].TokenType == css.WhitespaceToken {
+			args = args[:len(args)-1]
+		}
+		s.Args = make([]css.Token, len(args))
+		for i, arg := range args {
+			s.Args[i] = arg.Token
+		}
+		return nil
+	case listArg, relativeListArg, compoundArg:
+		list, err := sub.parseList(kind == relativeListArg)
+		if err != nil {
+			return err
+		} else if t := sub.peek(); t.TokenType != css.ErrorToken {
+			return p.errorf(t, "unexpected '%s' in :%s()", string(t.Data), string(s.Name))
+		} else if kind == compoundArg && (len(list) != 1 || len(list[0].Compounds) != 1) {
+			return p.errorf(fn, "expected compound selector in :%s()", string(s.Name))
+		}
+		s.Selectors = list
+	case identListArg, identArg:
+		for {
+			t := sub.peek()
+			if t.TokenType != css.IdentToken {
+				return p.errorf(t, "expected identifier in :%s()", string(s.Name))
+			}
+			s.Idents = append(s.Idents, t.Data)
+			sub.pos++
+			sub.skipWhitespace()
+			if sub.peek().TokenType == css.ErrorToken {
+				break
+			} else if kind == identArg {
+				t = sub.peek()
+				return p.errorf(t, "unexpected '%s' in :%s()", string(t.Data), string(s.Name))
+			}
+		}
+	case langArg:
+		for {
+			t := sub.peek()
+			if t.TokenType != css.IdentToken && t.TokenType != css.StringToken {
+				return p.errorf(t, "expected language range in :%s()", string(s.Name))
+			}
+			s.Langs = append(s.Langs, unquote(t))
+			sub.pos++
+			sub.skipWhitespace()
+			if t = sub.peek(); t.TokenType == css.ErrorToken {
+				break
+			} else if t.TokenType != css.CommaToken {
+				return p.errorf(t, "unexpected '%s' in :%s()", string(t.Data), string(s.Name))
+			}
+			sub.pos++
+			sub.skipWhitespace()
+		}
+	case nthArg, nthOfArg:
+		anb := []css.Token{}
+		for {
+			t := sub.peek()
+			if t.TokenType == css.ErrorToken || kind == nthOfArg && t.TokenType == css.IdentToken && parse.EqualFold(t.Data, ofBytes) {
+				break
+			}
+			anb = append(anb, t.Token)
+			sub.pos++
+		}
+		var ok bool
+		if s.Nth, ok = parseNth(anb); !ok {
+			return p.errorf(fn, "bad An+B expression in :%s()", string(s.Name))
+		} else if t := sub.peek(); t.TokenType != css.ErrorToken {
+			sub.pos++
+			sub.skipWhitespace()
+			list, err := sub.parseList(false)
+			if err != nil {
+				return err
+			} else if t := sub.peek(); t.TokenType != css.ErrorToken {
+				return p.errorf(t, "unexpected '%s' in :%s()", string(t.Data), string(s.Name))
+			}
+			s.Selectors = list
+		}
+	}
+	return nil
+}
+
+var ofBytes = []byte("of")
+
+// parseNth parses an An+B expression from its tokens, see https://www.w3.org/TR/css-syntax-3/#anb-microsyntax. Whitespace is allowed around the sign of B and between A and a signed B, but not after the sign of +n.
+func parseNth(ts []css.Token) (Nth, bool) {
+	ts = trimNthWhitespace(ts)
+	if len(ts) == 0 {
+		return Nth{}, false
+	}
+
+	// A and the rest of the identifier or dimension after n, such as -1 for n-1
+	nth := Nth{}
+	var rest []byte
+	t := ts[0]
+	ts = ts[1:]
+	switch t.TokenType {
+	case css.NumberToken:
+		b, ok := nthInteger(t.Data, 0)
+		return Nth{0, b}, ok && len(ts) == 0
+	case css.DimensionToken:
+		num, _ := parse.Dimension(t.Data)
+		a, ok := nthInteger(t.Data[:num], 0)
+		unit := parse.ToLower(parse.Copy(t.Data[num:]))
+		if !ok || len(unit) == 0 || unit[0] != 'n' {
+			return nth, false
+		}
+		nth.A, rest = a, unit[1:]
+	case css.DelimToken:
+		if t.Data[0] != '+' || len(ts) == 0 || ts[0].TokenType != css.IdentToken {
+			return nth, false
+		}
+		ident := parse.ToLower(parse.Copy(ts[0].Data))
+		ts = ts[1:]
+		if ident[0] != 'n' {
+			return nth, false
+		}
+		nth.A, rest = 1, ident[1:]
+	case css.IdentToken:
+		ident := parse.ToLower(parse.Copy(t.Data))
+		if string(ident) == "odd" || string(ident) == "even" {
+			if string(ident) == "odd" {
+				nth = Nth{2, 1}
+			} else {
+				nth = Nth{2, 0}
+			}
+			return nth, len(ts) == 0
+		} else if ident[0] == 'n' {
+			nth.A, rest = 1, ident[1:]
+		} else if bytes.HasPrefix(ident, []byte("-n")) {
+			nth.A, rest = -1, ident[2:]
+		} else {
+			return nth, false
+		}
+	default:
+		return nth, false
+	}
+
+	// B, which is signed when it follows A directly
+	ts = trimNthWhitespace(ts)
+	if len(rest) != 0 {
+		if rest[0] != '-' {
+			return nth, false
+		} else if len(rest) == 1 {
+			// n- followed by a signless integer
+			if len(ts) != 1 || ts[0].TokenType != css.NumberToken {
+				return nth, false
+			}
+			b, ok := nthInteger(ts[0].Data, -1)
+			nth.B = -b
+			return nth, ok
+		}
+		b, ok := nthInteger(rest[1:], -1)
+		nth.B = -b
+		return nth, ok && len(ts) == 0
+	} else if len(ts) == 0 {
+		return nth, true
+	} else if ts[0].TokenType == css.NumberToken {
+		b, ok := nthInteger(ts[0].Data, 1)
+		nth.B = b
+		return nth, ok && len(ts) == 1
+	} else if ts[0].TokenType != css.DelimToken || ts[0].Data[0] != '+' && ts[0].Data[0] != '-' {
+		return nth, false
+	}
+	neg := ts[0].Data[0] == '-'
+	ts = trimNthWhitespace(ts[1:])
+	if len(ts) != 1 || ts[0].TokenType != css.NumberToken {
+		return nth, false
+	}
+	b, ok := nthInteger(ts[0].Data, -1)
+	if nth.B = b; neg {
+		nth.B = -b
+	}
+	return nth, ok
+}
+
+// trimNthWhitespace removes leading and trailing whitespace tokens.
+func trimNthWhitespace(ts []css.Token) []css.Token {
+	for 0 < len(ts) && ts[0].TokenType == css.WhitespaceToken {
+		ts = ts[1:]
+	}
+	for 0 < len(ts) && ts[len(ts)-1].TokenType == css.WhitespaceToken {
+		ts = ts[:len(ts)-1]
+	}
+	return ts
+}
+
+// nthInteger parses an integer, which must be signless if sign is negative, signed if sign is positive, and may be either if sign is zero.
+func nthInteger(b []byte, sign int) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	} else if signed := b[0] == '+' || b[0] == '-'; sign < 0 && signed || 0 < sign && !signed {
+		return 0, false
+	}
+	digits := b
+	if digits[0] == '+' || digits[0] == '-' {
+		digits = digits[1:]
+	}
+	for _, c := range digits {
+		if c < '0' || '9' < c {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(string(b))
+	return n, err == nil
+}
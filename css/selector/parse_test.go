@@ -0,0 +1,307 @@
+package selector
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+	"github.com/tdewolff/test"
+)
+
+func TestParse(t *testing.T) {
+	var selectorTests = []struct {
+		sel      string
+		expected string
+	}{
+		{"a", "a"},
+		{"*", "*"},
+		{"ns|a", "ns|a"},
+		{"*|*", "*|*"},
+		{"|a", "|a"},
+		{"a.b#c", "a.b#c"},
+		{"  a  ,  b  ", "a,b"},
+		{"a b", "a b"},
+		{"a > b + c ~ d || e", "a>b+c~d||e"},
+		{"a>b", "a>b"},
+		{"a /* comment */ b", "a b"},
+		{"[href]", "[href]"},
+		{"[ ns|href ]", "[ns|href]"},
+		{"[|href]", "[|href]"},
+		{"[lang|=en]", `[lang|="en"]`},
+		{"[a='b' i]", `[a="b" i]`},
+		{`[a='"' S]`, `[a='"' s]`},
+		{"[a~=b][a^=b][a$=b][a*=b]", `[a~="b"][a^="b"][a$="b"][a*="b"]`},
+		{"a:hover", "a:hover"},
+		{"a:HOVER", "a:hover"},
+		{"a::before", "a::before"},
+		{"a:before", "a:before"},
+		{"p::first-line:hover", "p::first-line:hover"},
+		{":not(a, .b)", ":not(a,.b)"},
+		{":is( a > b )", ":is(a>b)"},
+		{":has(> img, + p)", ":has(>img,+p)"},
+		{":host(.dark)", ":host(.dark)"},
+		{"::slotted( span.x )", "::slotted(span.x)"},
+		{"::part(label  icon)", "::part(label icon)"},
+		{"::highlight(search)", "::highlight(search)"},
+		{"::PART(x)", "::part(x)"},
+		{":nth-child( 2n + 1 )", ":nth-child(2n+1)"},
+		{":nth-child(odd):nth-child(EVEN)", ":nth-child(2n+1):nth-child(2n)"},
+		{":nth-last-child(-n+3)", ":nth-last-child(-n+3)"},
+		{":nth-of-type(+n - 2)", ":nth-of-type(n-2)"},
+		{":nth-child(5)", ":nth-child(5)"},
+		{":nth-child(2N- 1)", ":nth-child(2n-1)"},
+		{":nth-child(-n-2)", ":nth-child(-n-2)"},
+		{":nth-child(+n+1)", ":nth-child(n+1)"},
+		{":nth-child(2n -1)", ":nth-child(2n-1)"},
+		{":nth-child(n- 3)", ":nth-child(n-3)"},
+		{"li:nth-child(2n OF .important, b)", "li:nth-child(2n of .important,b)"},
+		{":lang(en, \"*-CH\" , 'de-DE')", `:lang(en,"*-CH",de-DE)`},
+		{":dir(rtl)", ":dir(rtl)"},
+		{":future(a b)", ":future(a b)"},
+		{"&.a", "&.a"},
+		{"& > b", "&>b"},
+		{"x-foo::part(thumb):hover", "x-foo::part(thumb):hover"},
+	}
+	for _, tt := range selectorTests {
+		t.Run(tt.sel, func(t *testing.T) {
+			list, err := Parse(parse.NewInputString(tt.sel))
+			test.Error(t, err)
+			test.String(t, list.String(), tt.expected)
+		})
+	}
+}
+
+func TestRoundtrip(t *testing.T) {
+	var tests = []string{
+		`.\31 0`,
+		`.\31 a`,
+		`.-\31`,
+		`#\#id`,
+		`.a\:hover:hover`,
+		`.sm\:p-4>.w-1\/2`,
+		`.\@media`,
+		`.\-`,
+		`.a\ b`,
+		`.\1F600`,
+		`.😀`,
+		`[a="x\"y'"]`,
+		`[a='x"y\'']`,
+		`[a="\\"]`,
+		`:lang("a\"b'")`,
+		`:lang('a"b')`,
+	}
+	for _, sel := range tests {
+		t.Run(sel, func(t *testing.T) {
+			list, err := Parse(parse.NewInputString(sel))
+			test.Error(t, err)
+			test.String(t, list.String(), sel)
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	list, err := Parse(parse.NewInputString(`.\31 0.a\.b, #x`))
+	test.Error(t, err)
+	names := map[string]string{"10": "2col", "a.b": "sm:p-4", "x": "-"}
+	for _, compound := range list[0].Compounds {
+		for i, s := range compound.Selectors {
+			compound.Selectors[i].Name = css.AppendIdent(nil, []byte(names[string(css.Unescape(s.Name))]))
+		}
+	}
+	s := list[1].Compounds[0].Selectors[0]
+	list[1].Compounds[0].Selectors[0].Name = css.AppendIdent(nil, []byte(names[string(css.Unescape(s.Name))]))
+	test.String(t, list.String(), `.\32 col.sm\:p-4,#\-`)
+
+	list2, err := Parse(parse.NewInputString(list.String()))
+	test.Error(t, err)
+	test.String(t, list2.String(), list.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	var errorTests = []struct {
+		sel string
+		col int
+	}{
+		{"", 1},
+		{"a,", 3},
+		{"a >", 4},
+		{"a{", 2},
+		{".", 2},
+		{"[", 2},
+		{"[a", 3},
+		{"[a=]", 4},
+		{"[a=b x]", 6},
+		{":", 2},
+		{"::", 3},
+		{":not(", 6},
+		{":not()", 6},
+		{":not(a b,)", 10},
+		{"::part()", 8},
+		{"::part(a,b)", 9},
+		{"::highlight(a b)", 15},
+		{"::slotted(a b)", 11},
+		{"::slotted(a, b)", 11},
+		{"::before.a", 9},
+		{"::before#a", 9},
+		{"::before[a]", 9},
+		{"a/**/b", 6},
+		{":nth-child(n2)", 12},
+		{":nth-child(2n 1)", 12},
+		{":nth-child(2n+)", 12},
+		{":nth-child(x)", 12},
+		{":nth-child(1 2)", 12},
+		{":nth-child(2 n)", 12},
+		{":nth-child(+ n)", 12},
+		{":nth-child(- n)", 12},
+		{":nth-child(- 3)", 12},
+		{":nth-child(2n + +1)", 12},
+		{":nth-child(2n- -1)", 12},
+		{":nth-child(2n+1.5)", 12},
+		{":nth-child(odd 1)", 12},
+		{":nth-child(2n of)", 17},
+		{":nth-of-type(2n of a)", 14},
+		{":lang()", 7},
+		{":lang(en de)", 10},
+		{":lang(en,)", 10},
+		{":dir(1)", 6},
+		{":dir()", 6},
+		{":state()", 8},
+		{":has()", 6},
+		{":is()", 5},
+		{":where()", 8},
+		{":host()", 7},
+		{":host-context()", 15},
+		{"a:nth-child()", 3},
+		{":nth-last-child()", 2},
+		{":nth-of-type()", 2},
+		{":nth-last-of-type()", 2},
+		{":nth-col()", 2},
+		{":nth-last-col()", 2},
+		{"::slotted()", 11},
+		{"::cue()", 7},
+		{"::cue-region()", 14},
+		{"::highlight()", 13},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.sel, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.sel))
+			if perr, ok := err.(*parse.Error); ok {
+				_, col, _ := perr.Position()
+				test.T(t, col, tt.col)
+			} else {
+				test.Fail(t, "bad error:", err)
+			}
+		})
+	}
+}
+
+func TestPseudoElements(t *testing.T) {
+	list, err := Parse(parse.NewInputString("x-slider::part(track thumb), ::slotted(img.icon), ::highlight(spell), a:before, :host(.x)::part(y)"))
+	test.Error(t, err)
+	test.T(t, len(list), 5)
+
+	s := list[0].Compounds[0].Selectors[1]
+	test.T(t, s.Type, PseudoElementSelector)
+	test.String(t, string(s.Name), "part")
+	test.T(t, len(s.Idents), 2)
+	test.String(t, string(s.Idents[0]), "track")
+	test.String(t, string(s.Idents[1]), "thumb")
+
+	s = list[1].Compounds[0].Selectors[0]
+	test.T(t, s.Type, PseudoElementSelector)
+	test.String(t, string(s.Name), "slotted")
+	test.T(t, len(s.Selectors), 1)
+	test.T(t, len(s.Selectors[0].Compounds), 1)
+	slotted := s.Selectors[0].Compounds[0].Selectors
+	test.T(t, len(slotted), 2)
+	test.T(t, slotted[0].Type, TypeSelector)
+	test.T(t, slotted[1].Type, ClassSelector)
+	test.String(t, string(slotted[1].Name), "icon")
+
+	s = list[2].Compounds[0].Selectors[0]
+	test.T(t, s.Type, PseudoElementSelector)
+	test.String(t, string(s.Name), "highlight")
+	test.T(t, len(s.Idents), 1)
+	test.String(t, string(s.Idents[0]), "spell")
+
+	s = list[3].Compounds[0].Selectors[1]
+	test.T(t, s.Type, PseudoElementSelector)
+	test.T(t, s.Legacy, true)
+
+	pseudos := list[4].Compounds[0].PseudoElements()
+	test.T(t, len(pseudos), 1)
+	test.String(t, string(pseudos[0].Name), "part")
+	s = list[4].Compounds[0].Selectors[0]
+	test.T(t, s.Type, PseudoClassSelector)
+	test.String(t, s.Selectors.String(), ".x")
+}
+
+func TestNth(t *testing.T) {
+	var nthTests = []struct {
+		nth     string
+		matches []int
+	}{
+		{"odd", []int{1, 3, 5, 7}},
+		{"2n", []int{2, 4, 6}},
+		{"3", []int{3}},
+		{"-n+3", []int{1, 2, 3}},
+		{"n+5", []int{5, 6, 7}},
+		{"-2n+5", []int{1, 3, 5}},
+		{"0n+0", []int{}},
+	}
+	for _, tt := range nthTests {
+		t.Run(tt.nth, func(t *testing.T) {
+			list, err := Parse(parse.NewInputString(":nth-child(" + tt.nth + ")"))
+			test.Error(t, err)
+			nth := list[0].Compounds[0].Selectors[0].Nth
+			matches := []int{}
+			for i := 1; i <= 7; i++ {
+				if nth.Matches(i) {
+					matches = append(matches, i)
+				}
+			}
+			test.T(t, matches, tt.matches)
+		})
+	}
+}
+
+func TestRelative(t *testing.T) {
+	list, err := Parse(parse.NewInputString("a:has(> img, p)"))
+	test.Error(t, err)
+	has := list[0].Compounds[0].Selectors[1]
+	test.T(t, has.Selectors[0].Compounds[0].Combinator, ChildCombinator)
+	test.T(t, has.Selectors[1].Compounds[0].Combinator, NoCombinator)
+}
+
+func TestOffsets(t *testing.T) {
+	sel := "ul > li::part(a), b"
+	list, err := Parse(parse.NewInputString(sel))
+	test.Error(t, err)
+	test.T(t, list[0].Start, 0)
+	test.T(t, list[0].End, 16)
+	test.T(t, list[0].Compounds[1].Start, 5)
+	s := list[0].Compounds[1].Selectors[1]
+	test.String(t, sel[s.Start:s.End], "::part(a)")
+	test.T(t, list[1].Start, 18)
+	test.T(t, list[1].End, 19)
+}
+
+func TestStrings(t *testing.T) {
+	// coverage
+	for i := 0; ; i++ {
+		if Combinator(i).String() == fmt.Sprintf("Invalid(%d)", i) {
+			break
+		}
+	}
+	for i := 0; ; i++ {
+		if SimpleType(i).String() == fmt.Sprintf("Invalid(%d)", i) {
+			break
+		}
+	}
+	for i := 0; ; i++ {
+		if Matcher(i).String() == fmt.Sprintf("Invalid(%d)", i) {
+			break
+		}
+	}
+}
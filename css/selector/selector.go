@@ -0,0 +1,349 @@
+// Package selector is a CSS selector parser following the specifications at https://www.w3.org/TR/selectors-4/ and https://www.w3.org/TR/css-shadow-parts-1/.
+package selector
+
+import (
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2/css"
+)
+
+// Combinator determines how two compound selectors are related.
+type Combinator uint32
+
+// Combinator values.
+const (
+	NoCombinator                Combinator = iota
+	DescendantCombinator                   // whitespace
+	ChildCombinator                        // >
+	NextSiblingCombinator                  // +
+	SubsequentSiblingCombinator            // ~
+	ColumnCombinator                       // ||
+)
+
+// String returns the string representation of a Combinator.
+func (c Combinator) String() string {
+	switch c {
+	case NoCombinator:
+		return ""
+	case DescendantCombinator:
+		return " "
+	case ChildCombinator:
+		return ">"
+	case NextSiblingCombinator:
+		return "+"
+	case SubsequentSiblingCombinator:
+		return "~"
+	case ColumnCombinator:
+		return "||"
+	}
+	return "Invalid(" + strconv.Itoa(int(c)) + ")"
+}
+
+// SimpleType determines the type of simple selector.
+type SimpleType uint32
+
+// SimpleType values.
+const (
+	TypeSelector          SimpleType = iota // E, ns|E
+	UniversalSelector                       // *, ns|*
+	IDSelector                              // #id
+	ClassSelector                           // .class
+	AttributeSelector                       // [attr=val]
+	PseudoClassSelector                     // :hover, :not(...)
+	PseudoElementSelector                   // ::before, ::part(...)
+	NestingSelector                         // &
+)
+
+// String returns the string representation of a SimpleType.
+func (t SimpleType) String() string {
+	switch t {
+	case TypeSelector:
+		return "Type"
+	case UniversalSelector:
+		return "Universal"
+	case IDSelector:
+		return "ID"
+	case ClassSelector:
+		return "Class"
+	case AttributeSelector:
+		return "Attribute"
+	case PseudoClassSelector:
+		return "PseudoClass"
+	case PseudoElementSelector:
+		return "PseudoElement"
+	case NestingSelector:
+		return "Nesting"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Matcher determines how an attribute selector matches the attribute value.
+type Matcher uint32
+
+// Matcher values.
+const (
+	ExistsMatcher    Matcher = iota // [attr]
+	EqualMatcher                    // [attr=val]
+	IncludeMatcher                  // [attr~=val]
+	DashMatcher                     // [attr|=val]
+	PrefixMatcher                   // [attr^=val]
+	SuffixMatcher                   // [attr$=val]
+	SubstringMatcher                // [attr*=val]
+)
+
+// String returns the string representation of a Matcher.
+func (m Matcher) String() string {
+	switch m {
+	case ExistsMatcher:
+		return ""
+	case EqualMatcher:
+		return "="
+	case IncludeMatcher:
+		return "~="
+	case DashMatcher:
+		return "|="
+	case PrefixMatcher:
+		return "^="
+	case SuffixMatcher:
+		return "$="
+	case SubstringMatcher:
+		return "*="
+	}
+	return "Invalid(" + strconv.Itoa(int(m)) + ")"
+}
+
+////////////////////////////////////////////////////////////////
+
+// List is a comma-separated list of complex selectors.
+type List []Complex
+
+// Complex is a sequence of compound selectors separated by combinators.
+type Complex struct {
+	Compounds  []Compound
+	Start, End int // byte offsets in the input
+}
+
+// Compound is a sequence of simple selectors that are not separated by combinators.
+type Compound struct {
+	Combinator Combinator // combinator that precedes the compound selector, NoCombinator for the first compound selector unless it is a relative selector such as in :has(> img)
+	Selectors  []Simple
+	Start, End int // byte offsets in the input
+}
+
+// Simple is a simple selector. Names and values are as written in the input, with escapes intact.
+type Simple struct {
+	Type SimpleType
+
+	// Namespace is the namespace prefix of type, universal, and attribute selectors. It is nil when no namespace is given, empty for |E (no namespace), and * for any namespace.
+	Namespace []byte
+	// Name is the element name, the ID without #, the class name without ., the attribute name, or the pseudo-class or pseudo-element name without colons and parenthesis in lowercase.
+	Name []byte
+
+	Matcher  Matcher
+	Value    []byte // attribute value without quotes
+	Modifier byte   // attribute case-sensitivity modifier, i or s, or zero when absent
+
+	Legacy   bool // pseudo-element written with a single colon, such as :before
+	Function bool // functional pseudo-class or pseudo-element
+
+	// Selectors are the parsed selector arguments of :not(), :is(), :where(), :matches(), :has(), :host(), :host-context(), ::slotted(), ::cue(), and the "of S" part of :nth-child() and :nth-last-child(). For :has() the compound selectors may start with a combinator.
+	Selectors List
+	// Idents are the parsed identifier arguments of ::part(), ::highlight(), :state(), and :dir().
+	Idents [][]byte
+	// Langs are the language ranges of :lang() without quotes.
+	Langs [][]byte
+	// Nth is the An+B argument of :nth-child() and related pseudo-classes. For :nth-child() and :nth-last-child() the selector after "of" is stored in Selectors.
+	Nth Nth
+	// Args are the raw argument tokens of other functional pseudo-classes and pseudo-elements, without leading and trailing whitespace.
+	Args []css.Token
+
+	Start, End int // byte offsets in the input
+}
+
+// Nth is an An+B expression that matches the elements at index A*n+B for any n >= 0, where indices start at 1.
+type Nth struct {
+	A, B int
+}
+
+// Matches returns true if the 1-based index is matched by the expression.
+func (nth Nth) Matches(index int) bool {
+	if nth.A == 0 {
+		return index == nth.B
+	}
+	d := index - nth.B
+	return d%nth.A == 0 && 0 <= d/nth.A
+}
+
+// String returns the expression in its shortest form.
+func (nth Nth) String() string {
+	if nth.A == 0 {
+		return strconv.Itoa(nth.B)
+	}
+	s := ""
+	if nth.A == -1 {
+		s = "-"
+	} else if nth.A != 1 {
+		s = strconv.Itoa(nth.A)
+	}
+	s += "n"
+	if 0 < nth.B {
+		s += "+" + strconv.Itoa(nth.B)
+	} else if nth.B < 0 {
+		s += strconv.Itoa(nth.B)
+	}
+	return s
+}
+
+// PseudoElements returns the pseudo-element selectors of the compound selector.
+func (c Compound) PseudoElements() []Simple {
+	var pseudos []Simple
+	for _, s := range c.Selectors {
+		if s.Type == PseudoElementSelector {
+			pseudos = append(pseudos, s)
+		}
+	}
+	return pseudos
+}
+
+////////////////////////////////////////////////////////////////
+
+// String returns the selector list in a normalized form.
+func (l List) String() string {
+	return string(l.appendTo(nil))
+}
+
+func (l List) appendTo(b []byte) []byte {
+	for i, c := range l {
+		if 0 < i {
+			b = append(b, ',')
+		}
+		b = c.appendTo(b)
+	}
+	return b
+}
+
+// String returns the complex selector in a normalized form.
+func (c Complex) String() string {
+	return string(c.appendTo(nil))
+}
+
+func (c Complex) appendTo(b []byte) []byte {
+	for _, compound := range c.Compounds {
+		b = append(b, compound.Combinator.String()...)
+		b = compound.appendTo(b)
+	}
+	return b
+}
+
+// String returns the compound selector in a normalized form, without its preceding combinator.
+func (c Compound) String() string {
+	return string(c.appendTo(nil))
+}
+
+func (c Compound) appendTo(b []byte) []byte {
+	for _, s := range c.Selectors {
+		b = s.appendTo(b)
+	}
+	return b
+}
+
+// String returns the simple selector in a normalized form.
+func (s Simple) String() string {
+	return string(s.appendTo(nil))
+}
+
+func (s Simple) appendTo(b []byte) []byte {
+	switch s.Type {
+	case TypeSelector, UniversalSelector:
+		if s.Namespace != nil {
+			b = append(b, s.Namespace...)
+			b = append(b, '|')
+		}
+		if s.Type == UniversalSelector {
+			return append(b, '*')
+		}
+		return append(b, s.Name...)
+	case IDSelector:
+		b = append(b, '#')
+		return append(b, s.Name...)
+	case ClassSelector:
+		b = append(b, '.')
+		return append(b, s.Name...)
+	case AttributeSelector:
+		b = append(b, '[')
+		if s.Namespace != nil {
+			b = append(b, s.Namespace...)
+			b = append(b, '|')
+		}
+		b = append(b, s.Name...)
+		if s.Matcher != ExistsMatcher {
+			b = append(b, s.Matcher.String()...)
+			b = appendQuoted(b, s.Value)
+			if s.Modifier != 0 {
+				b = append(b, ' ', s.Modifier)
+			}
+		}
+		return append(b, ']')
+	case NestingSelector:
+		return append(b, '&')
+	}
+
+	// pseudo-classes and pseudo-elements
+	b = append(b, ':')
+	if s.Type == PseudoElementSelector && !s.Legacy {
+		b = append(b, ':')
+	}
+	b = append(b, s.Name...)
+	if s.Function {
+		b = append(b, '(')
+		if pseudoClassArgs[string(s.Name)] == nthArg || pseudoClassArgs[string(s.Name)] == nthOfArg {
+			b = append(b, s.Nth.String()...)
+			if s.Selectors != nil {
+				b = append(b, " of "...)
+				b = s.Selectors.appendTo(b)
+			}
+		} else if s.Langs != nil {
+			for i, lang := range s.Langs {
+				if 0 < i {
+					b = append(b, ',')
+				}
+				if css.IsIdent(lang) {
+					b = append(b, lang...)
+				} else {
+					b = appendQuoted(b, lang)
+				}
+			}
+		} else if s.Selectors != nil {
+			b = s.Selectors.appendTo(b)
+		} else if s.Idents != nil {
+			for i, ident := range s.Idents {
+				if 0 < i {
+					b = append(b, ' ')
+				}
+				b = append(b, ident...)
+			}
+		} else {
+			for _, t := range s.Args {
+				b = append(b, t.Data...)
+			}
+		}
+		b = append(b, ')')
+	}
+	return b
+}
+
+// appendQuoted appends the contents of a string token as written, with escapes intact, between double quotes unless it contains an unescaped double quote, in which case it was written between single quotes.
+func appendQuoted(b, value []byte) []byte {
+	quote := byte('"')
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' {
+			i++
+		} else if value[i] == '"' {
+			quote = '\''
+			break
+		}
+	}
+	b = append(b, quote)
+	b = append(b, value...)
+	return append(b, quote)
+}
@@ -0,0 +1,98 @@
+package selector
+
+import (
+	"strconv"
+)
+
+// Specificity is the specificity of a selector, see https://www.w3.org/TR/selectors-4/#specificity-rules, where A counts ID selectors, B counts class selectors, attribute selectors, and pseudo-classes, and C counts type selectors and pseudo-elements.
+type Specificity struct {
+	A, B, C int
+}
+
+// Add returns the sum of both specificities.
+func (s Specificity) Add(t Specificity) Specificity {
+	return Specificity{s.A + t.A, s.B + t.B, s.C + t.C}
+}
+
+// Compare returns -1, 0, or 1 when s is less than, equal to, or greater than t, by comparing A, then B, and then C.
+func (s Specificity) Compare(t Specificity) int {
+	if s.A != t.A {
+		return compareInt(s.A, t.A)
+	} else if s.B != t.B {
+		return compareInt(s.B, t.B)
+	}
+	return compareInt(s.C, t.C)
+}
+
+// Less returns true if s is less than t.
+func (s Specificity) Less(t Specificity) bool {
+	return s.Compare(t) < 0
+}
+
+// String returns the specificity as A,B,C.
+func (s Specificity) String() string {
+	return strconv.Itoa(s.A) + "," + strconv.Itoa(s.B) + "," + strconv.Itoa(s.C)
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	} else if b < a {
+		return 1
+	}
+	return 0
+}
+
+// Specificity returns the greatest specificity of the complex selectors, which is the specificity of the list as an argument of :is(), :not(), and :has(). The specificity of each complex selector should be used when matching an element, as the list applies with the specificity of the complex selector that matches.
+func (l List) Specificity() Specificity {
+	max := Specificity{}
+	for _, c := range l {
+		if s := c.Specificity(); max.Less(s) {
+			max = s
+		}
+	}
+	return max
+}
+
+// Specificity returns the specificity of the complex selector, which is the sum of the specificities of its compound selectors.
+func (c Complex) Specificity() Specificity {
+	s := Specificity{}
+	for _, compound := range c.Compounds {
+		s = s.Add(compound.Specificity())
+	}
+	return s
+}
+
+// Specificity returns the specificity of the compound selector, which is the sum of the specificities of its simple selectors.
+func (c Compound) Specificity() Specificity {
+	s := Specificity{}
+	for _, simple := range c.Selectors {
+		s = s.Add(simple.Specificity())
+	}
+	return s
+}
+
+// Specificity returns the specificity of the simple selector. Universal selectors count zero, and so does the nesting selector, whose specificity is that of the selector list of the parent rule. :is(), :not(), and :has() count as their most specific argument and :where() counts zero, while :nth-child() and :nth-last-child() with an "of S" argument, :host(), :host-context(), and ::slotted() add the specificity of their argument to that of a pseudo-class or pseudo-element.
+func (s Simple) Specificity() Specificity {
+	switch s.Type {
+	case IDSelector:
+		return Specificity{1, 0, 0}
+	case ClassSelector, AttributeSelector:
+		return Specificity{0, 1, 0}
+	case TypeSelector:
+		return Specificity{0, 0, 1}
+	case PseudoClassSelector:
+		switch string(s.Name) {
+		case "where":
+			return Specificity{}
+		case "is", "matches", "not", "has", "-webkit-any", "-moz-any":
+			if s.Function {
+				return s.Selectors.Specificity()
+			}
+		}
+		return Specificity{0, 1, 0}.Add(s.Selectors.Specificity())
+	case PseudoElementSelector:
+		return Specificity{0, 0, 1}.Add(s.Selectors.Specificity())
+	}
+	return Specificity{}
+}
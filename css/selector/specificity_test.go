@@ -0,0 +1,65 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestSpecificity(t *testing.T) {
+	var specificityTests = []struct {
+		sel      string
+		expected string
+	}{
+		{"*", "0,0,0"},
+		{"a", "0,0,1"},
+		{"ns|a", "0,0,1"},
+		{"#a", "1,0,0"},
+		{".a", "0,1,0"},
+		{"[href]", "0,1,0"},
+		{"a:hover", "0,1,1"},
+		{"a::before", "0,0,2"},
+		{"a:before", "0,0,2"},
+		{"ul li.a > #b + p ~ *", "1,1,3"},
+		{"a, #b, .c", "1,0,0"},
+		{":is(a, #b)", "1,0,0"},
+		{":matches(.a, b)", "0,1,0"},
+		{":not(.a.b, c)", "0,2,0"},
+		{":has(> img, + #a)", "1,0,0"},
+		{":where(#a, .b)", "0,0,0"},
+		{"a:where(#b):is(.c)", "0,1,1"},
+		{":nth-child(2n)", "0,1,0"},
+		{":nth-child(2n of #a, .b)", "1,1,0"},
+		{":nth-of-type(2n)", "0,1,0"},
+		{":host", "0,1,0"},
+		{":host(.a)", "0,2,0"},
+		{":host-context(body.dark)", "0,2,1"},
+		{"::slotted(span.a)", "0,1,2"},
+		{"::part(label)", "0,0,1"},
+		{":lang(en)", "0,1,0"},
+		{"&", "0,0,0"},
+		{"& > .a", "0,1,0"},
+	}
+	for _, tt := range specificityTests {
+		t.Run(tt.sel, func(t *testing.T) {
+			list, err := Parse(parse.NewInputString(tt.sel))
+			test.Error(t, err)
+			test.String(t, list.Specificity().String(), tt.expected)
+		})
+	}
+}
+
+func TestSpecificityCompare(t *testing.T) {
+	test.T(t, Specificity{1, 0, 0}.Compare(Specificity{0, 10, 10}), 1)
+	test.T(t, Specificity{0, 1, 0}.Compare(Specificity{0, 1, 5}), -1)
+	test.T(t, Specificity{0, 1, 2}.Compare(Specificity{0, 1, 2}), 0)
+	test.That(t, Specificity{0, 0, 9}.Less(Specificity{0, 1, 0}))
+	test.That(t, !Specificity{0, 1, 0}.Less(Specificity{0, 1, 0}))
+
+	list, err := Parse(parse.NewInputString("#a, .b"))
+	test.Error(t, err)
+	test.String(t, list[0].Specificity().String(), "1,0,0")
+	test.String(t, list[1].Specificity().String(), "0,1,0")
+	test.String(t, list[0].Compounds[0].Selectors[0].Specificity().String(), "1,0,0")
+}
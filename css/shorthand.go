@@ -0,0 +1,289 @@
+package css
+
+import (
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var (
+	autoBytes   = []byte("auto")
+	normalBytes = []byte("normal")
+)
+
+// ShorthandComponent is a longhand property of a shorthand property value.
+type ShorthandComponent struct {
+	Property []byte  // longhand property name
+	Values   []Token // value of the longhand property, which is implied when omitted from the shorthand
+}
+
+// SplitSlash splits values at the slash delimiters that are not nested in functions or blocks, such as the slash between the font size and the line height in 12px/1.5 Arial but not the one in calc(1px/2). Whitespace around each segment is trimmed.
+func SplitSlash(values []Token) [][]Token {
+	segments := [][]Token{}
+	level, start := 0, 0
+	for i, t := range values {
+		switch t.TokenType {
+		case FunctionToken, LeftParenthesisToken, LeftBracketToken, LeftBraceToken:
+			level++
+		case RightParenthesisToken, RightBracketToken, RightBraceToken:
+			if 0 < level {
+				level--
+			}
+		case DelimToken:
+			if level == 0 && t.Data[0] == '/' {
+				segments = append(segments, trimColorWhitespace(values[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, trimColorWhitespace(values[start:]))
+}
+
+// SplitShorthand splits the value of a font, border-radius, grid-area, grid-row, or grid-column shorthand property into its longhand properties, taking into account the slash-separated segments of the value. All longhand properties are returned in a fixed order, where omitted values are implied: font resets them to normal, border-radius repeats the given radii, and the grid shorthands repeat custom identifiers or use auto. It returns false for other properties, for invalid values, for system fonts such as caption, and for values with var() since these may expand to slashes.
+func SplitShorthand(property []byte, values []Token) ([]ShorthandComponent, bool) {
+	values = trimColorWhitespace(values)
+	if len(values) == 0 {
+		return nil, false
+	}
+	for _, t := range values {
+		if t.TokenType == FunctionToken && (parse.EqualFold(t.Data, []byte("var(")) || parse.EqualFold(t.Data, []byte("env(")) || parse.EqualFold(t.Data, []byte("attr("))) {
+			return nil, false
+		}
+	}
+
+	name := string(parse.ToLower(parse.Copy(property)))
+	longhands := shorthands[name]
+	var comps [][]Token
+	ok := false
+	if len(values) == 1 && values[0].TokenType == IdentToken && isCSSWideKeyword(values[0].Data) {
+		if longhands == nil {
+			return nil, false
+		}
+		comps = make([][]Token, len(longhands))
+		for i := range comps {
+			comps[i] = values
+		}
+		ok = true
+	} else {
+		switch name {
+		case "font":
+			comps, ok = splitFont(values)
+		case "border-radius":
+			comps, ok = splitBorderRadius(values)
+		case "grid-area", "grid-row", "grid-column":
+			comps, ok = splitGridLines(values, len(longhands))
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+
+	components := make([]ShorthandComponent, len(longhands))
+	for i, longhand := range longhands {
+		components[i] = ShorthandComponent{[]byte(longhand), comps[i]}
+	}
+	return components, true
+}
+
+// splitFont splits a font value into font-style, font-variant, font-weight, font-stretch, font-size, line-height, and font-family.
+func splitFont(values []Token) ([][]Token, bool) {
+	segments := SplitSlash(values)
+	if 2 < len(segments) {
+		return nil, false
+	}
+	normal := []Token{{IdentToken, normalBytes}}
+	comps := [][]Token{normal, normal, normal, normal, nil, normal, nil}
+
+	// the font-style, font-variant, font-weight, and font-stretch values precede the font size in any order
+	items := valueComponents(segments[0])
+	n := 0
+	for ; n < len(items); n++ {
+		item := items[n]
+		longhand := -1
+		if item[0].TokenType == IdentToken {
+			switch string(parse.ToLower(parse.Copy(item[0].Data))) {
+			case "normal":
+				continue
+			case "italic", "oblique":
+				longhand = 0
+				if n+1 < len(items) && isAngle(items[n+1]) {
+					item = segments[0][indexComponent(segments[0], item) : indexComponent(segments[0], items[n+1])+1]
+					n++
+				}
+			case "small-caps":
+				longhand = 1
+			case "bold", "bolder", "lighter":
+				longhand = 2
+			case "ultra-condensed", "extra-condensed", "condensed", "semi-condensed", "semi-expanded", "expanded", "extra-expanded", "ultra-expanded":
+				longhand = 3
+			case "xx-small", "x-small", "small", "medium", "large", "x-large", "xx-large", "xxx-large", "larger", "smaller":
+			default:
+				return nil, false // system font or font family without size
+			}
+		} else if item[0].TokenType == NumberToken {
+			longhand = 2
+		} else if item[0].TokenType != DimensionToken && item[0].TokenType != PercentageToken && !isMathFunction(item[0]) {
+			return nil, false
+		}
+		if longhand == -1 {
+			break // font size
+		} else if &comps[longhand][0] != &normal[0] {
+			return nil, false // specified twice
+		}
+		comps[longhand] = item
+	}
+	if len(items) <= n {
+		return nil, false
+	}
+	comps[4] = items[n]
+	family := rest(segments[0], items[n])
+	if len(segments) == 2 {
+		if len(family) != 0 || len(segments[1]) == 0 {
+			return nil, false
+		}
+		lineHeight := valueComponents(segments[1])[0]
+		comps[5] = lineHeight
+		family = rest(segments[1], lineHeight)
+	}
+	if len(family) == 0 {
+		return nil, false
+	}
+	comps[6] = family
+	return comps, true
+}
+
+// splitBorderRadius splits a border-radius value into the radii of the top-left, top-right, bottom-right, and bottom-left corners, each of which has a horizontal and optionally a vertical radius.
+func splitBorderRadius(values []Token) ([][]Token, bool) {
+	segments := SplitSlash(values)
+	if 2 < len(segments) {
+		return nil, false
+	}
+	radii := [2][4][]Token{}
+	for i, segment := range segments {
+		items := valueComponents(segment)
+		if len(items) == 0 || 4 < len(items) {
+			return nil, false
+		}
+		for _, item := range items {
+			if t := item[0].TokenType; t != DimensionToken && t != PercentageToken && t != NumberToken && !isMathFunction(item[0]) {
+				return nil, false
+			}
+		}
+		// top-left, top-right, bottom-right, bottom-left
+		radii[i][0] = items[0]
+		radii[i][1], radii[i][2], radii[i][3] = items[0], items[0], items[0]
+		if 1 < len(items) {
+			radii[i][1], radii[i][3] = items[1], items[1]
+		}
+		if 2 < len(items) {
+			radii[i][2] = items[2]
+		}
+		if 3 < len(items) {
+			radii[i][3] = items[3]
+		}
+	}
+
+	comps := make([][]Token, 4)
+	for i := range comps {
+		comps[i] = radii[0][i]
+		if len(segments) == 2 {
+			comps[i] = append(append(append([]Token{}, radii[0][i]...), Token{WhitespaceToken, wsBytes}), radii[1][i]...)
+		}
+	}
+	return comps, true
+}
+
+// splitGridLines splits a grid-area, grid-row, or grid-column value into n grid lines. The start lines come before the end lines, and an omitted line repeats its corresponding start line if that is a custom identifier and is auto otherwise.
+func splitGridLines(values []Token, n int) ([][]Token, bool) {
+	segments := SplitSlash(values)
+	if n < len(segments) {
+		return nil, false
+	}
+	comps := make([][]Token, n)
+	for i := range comps {
+		if i < len(segments) {
+			if len(segments[i]) == 0 {
+				return nil, false
+			}
+			comps[i] = segments[i]
+		} else if j := i - n/2; 0 <= j && isCustomIdent(comps[j]) {
+			comps[i] = comps[j]
+		} else if i == 1 && isCustomIdent(comps[0]) {
+			comps[i] = comps[0] // grid-column-start of grid-area
+		} else {
+			comps[i] = []Token{{IdentToken, autoBytes}}
+		}
+	}
+	return comps, true
+}
+
+// valueComponents splits values into its whitespace-separated components, where a function or block and its arguments is a single component. Commas are separate components.
+func valueComponents(values []Token) [][]Token {
+	items := [][]Token{}
+	level, start := 0, 0
+	for i, t := range values {
+		if level == 0 {
+			if t.TokenType == WhitespaceToken {
+				continue
+			}
+			start = i
+		}
+		switch t.TokenType {
+		case FunctionToken, LeftParenthesisToken, LeftBracketToken, LeftBraceToken:
+			level++
+		case RightParenthesisToken, RightBracketToken, RightBraceToken:
+			if 0 < level {
+				level--
+			}
+		}
+		if level == 0 {
+			items = append(items, values[start:i+1])
+		}
+	}
+	if 0 < level {
+		items = append(items, values[start:])
+	}
+	return items
+}
+
+// indexComponent returns the index in values of the first token of a component, which must be a subslice of values.
+func indexComponent(values, comp []Token) int {
+	for i := range values {
+		if &values[i] == &comp[0] {
+			return i
+		}
+	}
+	return -1
+}
+
+// rest returns the values that follow a component, which must be a subslice of values.
+func rest(values, comp []Token) []Token {
+	return trimColorWhitespace(values[indexComponent(values, comp)+len(comp):])
+}
+
+func isCSSWideKeyword(b []byte) bool {
+	switch string(parse.ToLower(parse.Copy(b))) {
+	case "inherit", "initial", "unset", "revert", "revert-layer":
+		return true
+	}
+	return false
+}
+
+// isCustomIdent returns true if the grid line is a single custom identifier.
+func isCustomIdent(comp []Token) bool {
+	return len(comp) == 1 && comp[0].TokenType == IdentToken && !parse.EqualFold(comp[0].Data, autoBytes) && !parse.EqualFold(comp[0].Data, []byte("span")) && !isCSSWideKeyword(comp[0].Data)
+}
+
+func isAngle(comp []Token) bool {
+	if len(comp) != 1 || comp[0].TokenType != DimensionToken {
+		return false
+	}
+	num := parse.Number(comp[0].Data)
+	switch string(parse.ToLower(parse.Copy(comp[0].Data[num:]))) {
+	case "deg", "grad", "rad", "turn":
+		return true
+	}
+	return false
+}
+
+func isMathFunction(t Token) bool {
+	return t.TokenType == FunctionToken && IsMathFunction(t.Data[:len(t.Data)-1])
+}
@@ -0,0 +1,82 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestSplitSlash(t *testing.T) {
+	var tests = []struct {
+		css      string
+		expected []string
+	}{
+		{"12px/1.5 Arial", []string{"12px", "1.5 Arial"}},
+		{"calc(1px/2) / 3px", []string{"calc(1px/2)", "3px"}},
+		{"a / [b / c] / d", []string{"a", "[b/c]", "d"}},
+		{"1px 2px", []string{"1px 2px"}},
+		{"/", []string{"", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			segments := []string{}
+			for _, segment := range SplitSlash(values) {
+				segments = append(segments, valuesString(segment))
+			}
+			test.T(t, segments, tt.expected)
+		})
+	}
+}
+
+func TestSplitShorthand(t *testing.T) {
+	var tests = []struct {
+		property string
+		css      string
+		expected string
+	}{
+		{"font", "12px Arial", "font-style:normal; font-variant:normal; font-weight:normal; font-stretch:normal; font-size:12px; line-height:normal; font-family:Arial"},
+		{"font", "italic bold 12px/30px Georgia, serif", "font-style:italic; font-variant:normal; font-weight:bold; font-stretch:normal; font-size:12px; line-height:30px; font-family:Georgia,serif"},
+		{"font", "normal small-caps 700 condensed large / calc(1em/2) \"Open Sans\"", "font-style:normal; font-variant:small-caps; font-weight:700; font-stretch:condensed; font-size:large; line-height:calc(1em/2); font-family:\"Open Sans\""},
+		{"FONT", "oblique 10deg calc(2em/3) serif", "font-style:oblique 10deg; font-variant:normal; font-weight:normal; font-stretch:normal; font-size:calc(2em/3); line-height:normal; font-family:serif"},
+		{"font", "inherit", "font-style:inherit; font-variant:inherit; font-weight:inherit; font-stretch:inherit; font-size:inherit; line-height:inherit; font-family:inherit"},
+		{"font", "caption", ""},
+		{"font", "12px", ""},
+		{"font", "bold bold 12px serif", ""},
+		{"font", "12px/1.5", ""},
+		{"font", "12px serif/1.5", ""},
+		{"font", "var(--font)", ""},
+		{"border-radius", "10px", "border-top-left-radius:10px; border-top-right-radius:10px; border-bottom-right-radius:10px; border-bottom-left-radius:10px"},
+		{"border-radius", "1px 2px 3px / 4px 5%", "border-top-left-radius:1px 4px; border-top-right-radius:2px 5%; border-bottom-right-radius:3px 4px; border-bottom-left-radius:2px 5%"},
+		{"border-radius", "1px 2px 3px 4px / calc(1px/2)", "border-top-left-radius:1px calc(1px/2); border-top-right-radius:2px calc(1px/2); border-bottom-right-radius:3px calc(1px/2); border-bottom-left-radius:4px calc(1px/2)"},
+		{"border-radius", "1px 2px 3px 4px 5px", ""},
+		{"border-radius", "1px / 2px / 3px", ""},
+		{"border-radius", "1px / ", ""},
+		{"grid-area", "a", "grid-row-start:a; grid-column-start:a; grid-row-end:a; grid-column-end:a"},
+		{"grid-area", "1 / b", "grid-row-start:1; grid-column-start:b; grid-row-end:auto; grid-column-end:b"},
+		{"grid-area", "span 2 / 1 / 3 / -1", "grid-row-start:span 2; grid-column-start:1; grid-row-end:3; grid-column-end:-1"},
+		{"grid-area", "auto", "grid-row-start:auto; grid-column-start:auto; grid-row-end:auto; grid-column-end:auto"},
+		{"grid-row", "2", "grid-row-start:2; grid-row-end:auto"},
+		{"grid-column", "main / span 2", "grid-column-start:main; grid-column-end:span 2"},
+		{"grid-column", "main", "grid-column-start:main; grid-column-end:main"},
+		{"grid-column", "1 / 2 / 3", ""},
+		{"grid-row", "1 / ", ""},
+		{"margin", "1px", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.property+":"+tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			components, ok := SplitShorthand([]byte(tt.property), values)
+			test.T(t, ok, tt.expected != "")
+			decls := []string{}
+			for _, comp := range components {
+				decls = append(decls, string(comp.Property)+":"+valuesString(comp.Values))
+			}
+			test.String(t, strings.Join(decls, "; "), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package css
+
+import (
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// presentationAttributes are the SVG presentation attributes that map to a CSS property of the same name, see https://www.w3.org/TR/SVG2/styling.html#PresentationAttributes.
+var presentationAttributes = map[string]bool{
+	"alignment-baseline":           true,
+	"baseline-shift":               true,
+	"clip":                         true,
+	"clip-path":                    true,
+	"clip-rule":                    true,
+	"color":                        true,
+	"color-interpolation":          true,
+	"color-interpolation-filters":  true,
+	"color-rendering":              true,
+	"cursor":                       true,
+	"cx":                           true,
+	"cy":                           true,
+	"d":                            true,
+	"direction":                    true,
+	"display":                      true,
+	"dominant-baseline":            true,
+	"fill":                         true,
+	"fill-opacity":                 true,
+	"fill-rule":                    true,
+	"filter":                       true,
+	"flood-color":                  true,
+	"flood-opacity":                true,
+	"font":                         true,
+	"font-family":                  true,
+	"font-size":                    true,
+	"font-size-adjust":             true,
+	"font-stretch":                 true,
+	"font-style":                   true,
+	"font-variant":                 true,
+	"font-weight":                  true,
+	"glyph-orientation-horizontal": true,
+	"glyph-orientation-vertical":   true,
+	"height":                       true,
+	"image-rendering":              true,
+	"letter-spacing":               true,
+	"lighting-color":               true,
+	"marker":                       true,
+	"marker-end":                   true,
+	"marker-mid":                   true,
+	"marker-start":                 true,
+	"mask":                         true,
+	"mask-type":                    true,
+	"opacity":                      true,
+	"overflow":                     true,
+	"paint-order":                  true,
+	"pointer-events":               true,
+	"r":                            true,
+	"rx":                           true,
+	"ry":                           true,
+	"shape-rendering":              true,
+	"stop-color":                   true,
+	"stop-opacity":                 true,
+	"stroke":                       true,
+	"stroke-dasharray":             true,
+	"stroke-dashoffset":            true,
+	"stroke-linecap":               true,
+	"stroke-linejoin":              true,
+	"stroke-miterlimit":            true,
+	"stroke-opacity":               true,
+	"stroke-width":                 true,
+	"text-anchor":                  true,
+	"text-decoration":              true,
+	"text-overflow":                true,
+	"text-rendering":               true,
+	"transform":                    true,
+	"transform-origin":             true,
+	"unicode-bidi":                 true,
+	"vector-effect":                true,
+	"visibility":                   true,
+	"white-space":                  true,
+	"width":                        true,
+	"word-spacing":                 true,
+	"writing-mode":                 true,
+	"x":                            true,
+	"y":                            true,
+
+	// attributes that map to the transform property
+	"gradientTransform": true,
+	"patternTransform":  true,
+}
+
+// transformArgs are the number of arguments of the SVG 1.1 transform functions, see https://www.w3.org/TR/SVG11/coords.html#TransformAttribute.
+var transformArgs = map[string][]int{
+	"matrix":    {6},
+	"translate": {1, 2},
+	"scale":     {1, 2},
+	"rotate":    {1, 3},
+	"skewX":     {1},
+	"skewY":     {1},
+}
+
+// IsPresentationAttribute returns true if name is an SVG presentation attribute, whose value can be parsed with ParsePresentationAttribute.
+func IsPresentationAttribute(name []byte) bool {
+	return presentationAttributes[string(name)]
+}
+
+// ParseValue parses a single property value outside of a declaration context, such as a value set through the CSSOM. The tokens are returned in the same normalized form as the values of a DeclarationGrammar, and the value may not contain a semicolon or unbalanced closing brackets.
+func ParseValue(r *parse.Input) ([]Token, error) {
+	p := NewParser(r, true)
+	return p.parseValue(nil)
+}
+
+// ParsePresentationAttribute parses the value of an SVG presentation attribute such as fill or transform, see IsPresentationAttribute. Presentation attributes do not accept !important. The transform, gradientTransform, and patternTransform attributes accept the SVG 1.1 transform list syntax in addition to the CSS transform property syntax, that is unitless lengths and angles, arguments separated by whitespace instead of commas, and rotate with a center point.
+func ParsePresentationAttribute(name []byte, r *parse.Input) ([]Token, error) {
+	p := NewParser(r, true)
+	return p.parseValue(name)
+}
+
+func (p *Parser) parseValue(attr []byte) ([]Token, error) {
+	p.initBuf()
+	offsets := []int{}
+	skipWS := true
+	for {
+		tt, data := p.popToken(false)
+		if tt == ErrorToken {
+			break
+		} else if tt == SemicolonToken && p.level == 0 {
+			return nil, p.valueError(len(data), "unexpected semicolon in value")
+		} else if tt == DelimToken && data[0] == '!' && attr != nil {
+			return nil, p.valueError(len(data), "unexpected !important in presentation attribute")
+		} else if tt == LeftParenthesisToken || tt == LeftBraceToken || tt == LeftBracketToken || tt == FunctionToken {
+			p.level++
+		} else if tt == RightParenthesisToken || tt == RightBraceToken || tt == RightBracketToken {
+			if p.level == 0 {
+				return nil, p.valueError(len(data), "unexpected %s in value", string(data))
+			}
+			p.level--
+		}
+		if len(data) == 1 && (data[0] == ',' || data[0] == '/' || data[0] == ':' || data[0] == '!' || data[0] == '=') {
+			skipWS = true
+		} else if (p.prevWS || p.prevComment) && !skipWS {
+			p.pushBuf(WhitespaceToken, wsBytes)
+			offsets = append(offsets, p.l.r.Offset()-len(data))
+		} else {
+			skipWS = false
+		}
+		p.pushBuf(tt, data)
+		offsets = append(offsets, p.l.r.Offset()-len(data))
+	}
+	if err := p.l.Err(); err != io.EOF {
+		return nil, err
+	}
+
+	if s := string(attr); s == "transform" || s == "gradientTransform" || s == "patternTransform" {
+		if i, msg := validateTransformList(p.buf); msg != "" {
+			offset := len(p.l.r.Bytes())
+			if i < len(offsets) {
+				offset = offsets[i]
+			}
+			return nil, parse.NewError(p.l.r, offset, msg)
+		}
+	}
+	return p.buf, nil
+}
+
+func (p *Parser) valueError(n int, format string, args ...interface{}) error {
+	return parse.NewError(p.l.r, p.l.r.Offset()-n, format, args...)
+}
+
+// validateTransformList validates the SVG 1.1 transform functions in a transform list and returns the index of the offending token and an error message. Other functions, such as those of CSS Transforms, are not validated.
+func validateTransformList(values []Token) (int, string) {
+	if len(values) == 1 && values[0].TokenType == IdentToken && parse.EqualFold(values[0].Data, []byte("none")) {
+		return 0, ""
+	}
+
+	i := 0
+	for i < len(values) {
+		if values[i].TokenType == WhitespaceToken || values[i].TokenType == CommaToken {
+			i++
+			continue
+		} else if values[i].TokenType != FunctionToken {
+			return i, "expected transform function"
+		}
+		name := values[i].Data[:len(values[i].Data)-1]
+		counts, ok := transformArgs[string(name)]
+		if !ok {
+			// skip other transform functions
+			level := 0
+			for i++; i < len(values); i++ {
+				if tt := values[i].TokenType; tt == RightParenthesisToken && level == 0 {
+					break
+				} else if tt == LeftParenthesisToken || tt == FunctionToken {
+					level++
+				} else if tt == RightParenthesisToken {
+					level--
+				}
+			}
+			i++
+			continue
+		}
+
+		n := 0
+		for i++; i < len(values); i++ {
+			if tt := values[i].TokenType; tt == RightParenthesisToken {
+				break
+			} else if tt == NumberToken || tt == DimensionToken || tt == PercentageToken {
+				n++
+			} else if tt != WhitespaceToken && tt != CommaToken {
+				return i, "unexpected " + tt.String() + " in " + string(name) + " transform"
+			}
+		}
+		valid := false
+		for _, count := range counts {
+			if n == count {
+				valid = true
+			}
+		}
+		if !valid {
+			return i, "bad number of arguments for " + string(name) + " transform"
+		}
+		i++
+	}
+	return 0, ""
+}
@@ -0,0 +1,99 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func valuesString(values []Token) string {
+	s := ""
+	for _, val := range values {
+		s += string(val.Data)
+	}
+	return s
+}
+
+func TestParseValue(t *testing.T) {
+	var valueTests = []struct {
+		css      string
+		expected string
+	}{
+		{"", ""},
+		{"  red  ", "red"},
+		{"1px  solid /* comment */ red", "1px solid red"},
+		{"rgb( 1 , 2 , 3 )", "rgb( 1,2,3 )"},
+		{"1px / 2px", "1px/2px"},
+		{"red !important", "red!important"},
+		{"url(a;b.png) no-repeat", "url(a;b.png) no-repeat"},
+		{"calc(1px + (2px", "calc(1px + (2px"},
+	}
+	for _, tt := range valueTests {
+		t.Run(tt.css, func(t *testing.T) {
+			values, err := ParseValue(parse.NewInputString(tt.css))
+			test.Error(t, err)
+			test.String(t, valuesString(values), tt.expected)
+		})
+	}
+}
+
+func TestParsePresentationAttribute(t *testing.T) {
+	var attrTests = []struct {
+		name     string
+		css      string
+		expected string
+	}{
+		{"fill", "url(#grad) red", "url(#grad) red"},
+		{"stroke-width", " 2 ", "2"},
+		{"font-family", "'Open Sans', serif", "'Open Sans',serif"},
+		{"transform", "none", "none"},
+		{"transform", "translate(10) rotate(45 10 10)", "translate(10) rotate(45 10 10)"},
+		{"transform", "matrix(1,0, 0 1,0,0),scale(2)", "matrix(1,0,0 1,0,0),scale(2)"},
+		{"transform", "translateX(10px) rotate(45deg)", "translateX(10px) rotate(45deg)"},
+		{"transform", "skewX(30)skewY(-30)", "skewX(30)skewY(-30)"},
+		{"gradientTransform", "rotate(90 .5 .5)", "rotate(90 .5 .5)"},
+	}
+	for _, tt := range attrTests {
+		t.Run(tt.name+"="+tt.css, func(t *testing.T) {
+			test.That(t, IsPresentationAttribute([]byte(tt.name)))
+			values, err := ParsePresentationAttribute([]byte(tt.name), parse.NewInputString(tt.css))
+			test.Error(t, err)
+			test.String(t, valuesString(values), tt.expected)
+		})
+	}
+	test.That(t, !IsPresentationAttribute([]byte("href")))
+}
+
+func TestParseValueError(t *testing.T) {
+	var errorTests = []struct {
+		name string
+		css  string
+		col  int
+	}{
+		{"", "red; color: blue", 4},
+		{"", "a)", 2},
+		{"fill", "red !important", 5},
+		{"transform", "translate(1 2 3)", 16},
+		{"transform", "rotate(1 2)", 11},
+		{"transform", "scale(a)", 7},
+		{"transform", "10", 1},
+		{"transform", "rotate(1) 10", 11},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.css, func(t *testing.T) {
+			var err error
+			if tt.name == "" {
+				_, err = ParseValue(parse.NewInputString(tt.css))
+			} else {
+				_, err = ParsePresentationAttribute([]byte(tt.name), parse.NewInputString(tt.css))
+			}
+			if perr, ok := err.(*parse.Error); ok {
+				_, col, _ := perr.Position()
+				test.T(t, col, tt.col)
+			} else {
+				test.Fail(t, "bad error:", err)
+			}
+		})
+	}
+}
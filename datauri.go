@@ -0,0 +1,140 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrBadBase64 is returned when decoding a data URI in lenient mode if the base64 data is invalid even for browsers.
+var ErrBadBase64 = errors.New("invalid base64 data")
+
+// DataURIHeader is the parsed header of a data URI, see https://fetch.spec.whatwg.org/#data-url-processor.
+type DataURIHeader struct {
+	Mimetype []byte            // mimetype in lowercase, text/plain if omitted
+	Params   map[string]string // parameters of the mediatype with lowercase keys, such as charset, nil if there are none
+	Base64   bool              // the data is base64 encoded
+	Data     []byte            // encoded data after the comma
+}
+
+// ParseDataURI parses the header of a data URI without decoding its data, which can be decoded with Decode. It returns ErrBadDataURI if the data URI does not start with data: or has no comma.
+func ParseDataURI(dataURI []byte) (DataURIHeader, error) {
+	if len(dataURI) < 5 || !EqualFold(dataURI[:5], dataSchemeBytes) {
+		return DataURIHeader{}, ErrBadDataURI
+	}
+	comma := bytes.IndexByte(dataURI, ',')
+	if comma == -1 {
+		return DataURIHeader{}, ErrBadDataURI
+	}
+	header := DataURIHeader{
+		Data: dataURI[comma+1:],
+	}
+
+	mediatype := TrimWhitespace(dataURI[5:comma])
+	if semicolon := bytes.LastIndexByte(mediatype, ';'); semicolon != -1 && EqualFold(TrimWhitespace(mediatype[semicolon+1:]), base64Bytes) {
+		header.Base64 = true
+		mediatype = TrimWhitespace(mediatype[:semicolon])
+	}
+	if len(mediatype) == 0 || mediatype[0] == ';' {
+		mediatype = append(Copy(textMimeBytes), mediatype...)
+	}
+	mimetype, params := Mediatype(mediatype)
+	header.Mimetype = ToLower(Copy(mimetype))
+	for key, val := range params {
+		if header.Params == nil {
+			header.Params = map[string]string{}
+		}
+		header.Params[strings.ToLower(key)] = val
+	}
+	return header, nil
+}
+
+// Charset returns the charset parameter, or US-ASCII if it is omitted for a text/plain data URI without mediatype, as for browsers.
+func (h DataURIHeader) Charset() string {
+	if charset, ok := h.Params["charset"]; ok {
+		return charset
+	} else if bytes.Equal(h.Mimetype, textMimeBytes) {
+		return "US-ASCII"
+	}
+	return ""
+}
+
+// Decode writes the decoded data to w without holding the decoded data in memory, so that large inline images can be decoded into a file or a hash. It returns the number of bytes written. Percent-encoded characters are decoded first unless strict is set. In strict mode, base64 data must be padded and its unused bits must be zero, although line breaks are ignored. In lenient mode, base64 data is decoded as by browsers, which ignore ASCII whitespace and accept missing padding, and invalid data returns ErrBadBase64.
+func (h DataURIHeader) Decode(w io.Writer, strict bool) (int64, error) {
+	var r io.Reader = bytes.NewReader(h.Data)
+	if !strict {
+		r = &percentReader{b: h.Data}
+	}
+	if h.Base64 {
+		if strict {
+			r = base64.NewDecoder(base64.StdEncoding.Strict(), r)
+		} else {
+			r = base64.NewDecoder(base64.RawStdEncoding, &forgivingBase64Reader{r: r})
+		}
+	}
+	n, err := io.Copy(w, r)
+	if _, ok := err.(base64.CorruptInputError); ok && !strict {
+		err = ErrBadBase64
+	}
+	return n, err
+}
+
+// percentReader decodes percent-encoded characters, keeping invalid escapes as is, see DecodeURL.
+type percentReader struct {
+	b []byte
+}
+
+func (r *percentReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && 0 < len(r.b) {
+		if r.b[0] == '%' && 2 < len(r.b) && hexDigit(r.b[1]) < 16 && hexDigit(r.b[2]) < 16 {
+			p[n] = byte(hexDigit(r.b[1])<<4 | hexDigit(r.b[2]))
+			r.b = r.b[3:]
+		} else {
+			p[n] = r.b[0]
+			r.b = r.b[1:]
+		}
+		n++
+	}
+	if n == 0 && len(r.b) == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// forgivingBase64Reader removes ASCII whitespace and trailing padding from base64 data, following the forgiving-base64 decode algorithm of browsers, see https://infra.spec.whatwg.org/#forgiving-base64-decode. Padding in the middle of the data, more than two padding characters, or padding that doesn't fill the last quantum return ErrBadBase64.
+type forgivingBase64Reader struct {
+	r       io.Reader
+	n       int // number of characters without padding
+	padding int
+}
+
+func (r *forgivingBase64Reader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.r.Read(p)
+		m := 0
+		for _, c := range p[:n] {
+			if c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r' {
+				continue
+			} else if c == '=' {
+				r.padding++
+				if 2 < r.padding {
+					return 0, ErrBadBase64
+				}
+				continue
+			} else if 0 < r.padding {
+				return 0, ErrBadBase64
+			}
+			p[m] = c
+			m++
+		}
+		r.n += m
+		if err == io.EOF && 0 < r.padding && (r.n+r.padding)%4 != 0 {
+			return 0, ErrBadBase64
+		} else if 0 < m || err != nil {
+			return m, err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseDataURIHeader(t *testing.T) {
+	var tests = []struct {
+		dataURI  string
+		mimetype string
+		charset  string
+		base64   bool
+		data     string
+	}{
+		{"data:,a", "text/plain", "US-ASCII", false, "a"},
+		{"DATA:Image/PNG;base64,iVBO", "image/png", "", true, "iVBO"},
+		{"data:text/html;Charset=utf-8,<p>", "text/html", "utf-8", false, "<p>"},
+		{"data:;charset=utf-8;BASE64 ,YQ==", "text/plain", "utf-8", true, "YQ=="},
+		{"data:image/svg+xml;utf8,<svg/>", "image/svg+xml", "", false, "<svg/>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataURI, func(t *testing.T) {
+			header, err := ParseDataURI([]byte(tt.dataURI))
+			test.Error(t, err)
+			test.String(t, string(header.Mimetype), tt.mimetype)
+			test.String(t, header.Charset(), tt.charset)
+			test.T(t, header.Base64, tt.base64)
+			test.String(t, string(header.Data), tt.data)
+		})
+	}
+
+	_, err := ParseDataURI([]byte("data:text/plain"))
+	test.T(t, err, ErrBadDataURI)
+	_, err = ParseDataURI([]byte("http://a,b"))
+	test.T(t, err, ErrBadDataURI)
+	header, _ := ParseDataURI([]byte("data:image/svg+xml;utf8,"))
+	_, ok := header.Params["utf8"]
+	test.That(t, ok)
+}
+
+func TestDataURIDecode(t *testing.T) {
+	var tests = []struct {
+		dataURI string
+		strict  string // decoded data or error in strict mode
+		lenient string // decoded data or error in lenient mode
+	}{
+		{"data:,a%20b%zz", "a%20b%zz", "a b%zz"},
+		{"data:;base64,dGV4dA==", "text", "text"},
+		{"data:;base64,dGV4dA", "error", "text"},
+		{"data:;base64,dGV4\r\ndA==", "text", "text"},
+		{"data:;base64,dG V4 dA =\t=", "error", "text"},
+		{"data:;base64,dGV4dA%3D%3D", "error", "text"},
+		{"data:;base64,dGV4dB==", "error", "text"},
+		{"data:;base64,dGV4dA=", "error", "error"},
+		{"data:;base64,dGV4d", "error", "error"},
+		{"data:;base64,dG=V4", "error", "error"},
+		{"data:;base64,dGV4dA===", "error", "error"},
+		{"data:;base64,()", "error", "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataURI, func(t *testing.T) {
+			header, err := ParseDataURI([]byte(tt.dataURI))
+			test.Error(t, err)
+			for _, strict := range []bool{true, false} {
+				expected := tt.lenient
+				if strict {
+					expected = tt.strict
+				}
+				buf := &bytes.Buffer{}
+				n, err := header.Decode(buf, strict)
+				if expected == "error" {
+					test.That(t, err != nil, "strict", strict)
+					if !strict {
+						test.T(t, err, ErrBadBase64)
+					}
+				} else {
+					test.Error(t, err)
+					test.String(t, buf.String(), expected, "strict", strict)
+					test.T(t, n, int64(len(expected)))
+				}
+			}
+		})
+	}
+}
+
+func TestDataURIDecodeLarge(t *testing.T) {
+	data := bytes.Repeat([]byte("abc"), 100000)
+	dataURI := append([]byte("data:application/octet-stream;base64,"), base64.StdEncoding.EncodeToString(data)...)
+	header, err := ParseDataURI(dataURI)
+	test.Error(t, err)
+	buf := &bytes.Buffer{}
+	_, err = header.Decode(buf, false)
+	test.Error(t, err)
+	test.That(t, bytes.Equal(buf.Bytes(), data))
+}
@@ -0,0 +1,305 @@
+package parse
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// ContentType is the type of content as detected by DetectContentType.
+type ContentType uint32
+
+// ContentType values.
+const (
+	UnknownContentType ContentType = iota
+	HTMLContentType
+	XMLContentType
+	SVGContentType
+	CSSContentType
+	JSContentType
+	JSONContentType
+)
+
+// String returns the string representation of a ContentType.
+func (ct ContentType) String() string {
+	switch ct {
+	case UnknownContentType:
+		return "Unknown"
+	case HTMLContentType:
+		return "HTML"
+	case XMLContentType:
+		return "XML"
+	case SVGContentType:
+		return "SVG"
+	case CSSContentType:
+		return "CSS"
+	case JSContentType:
+		return "JS"
+	case JSONContentType:
+		return "JSON"
+	}
+	return "Invalid(" + strconv.Itoa(int(ct)) + ")"
+}
+
+// Mimetype returns the mimetype of a ContentType, or an empty string for UnknownContentType.
+func (ct ContentType) Mimetype() string {
+	switch ct {
+	case HTMLContentType:
+		return "text/html"
+	case XMLContentType:
+		return "text/xml"
+	case SVGContentType:
+		return "image/svg+xml"
+	case CSSContentType:
+		return "text/css"
+	case JSContentType:
+		return "text/javascript"
+	case JSONContentType:
+		return "application/json"
+	}
+	return ""
+}
+
+// detectLen is the number of bytes considered by DetectContentType.
+const detectLen = 1024
+
+var htmlElements = map[string]bool{}
+
+func init() {
+	for _, name := range bytes.Fields([]byte("a abbr address area article aside audio b base bdi bdo blockquote body br button canvas caption cite code col colgroup data datalist dd del details dfn dialog div dl dt em embed fieldset figcaption figure font footer form frame frameset h1 h2 h3 h4 h5 h6 head header hgroup hr html i iframe img input ins kbd label legend li link main map mark menu meta meter nav noscript object ol optgroup option output p param picture pre progress q rp rt ruby s samp script search section select slot small source span strong style sub summary sup table tbody td template textarea tfoot th thead time title tr track u ul var video wbr")) {
+		htmlElements[string(name)] = true
+	}
+}
+
+// jsKeywords are keywords that may start a statement, var is omitted since it is also an HTML element and thus a CSS selector.
+var jsKeywords = map[string]bool{
+	"async": true, "await": true, "break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true, "debugger": true, "delete": true, "do": true, "else": true, "export": true, "for": true, "function": true, "if": true, "import": true, "let": true, "new": true, "return": true, "switch": true, "this": true, "throw": true, "try": true, "typeof": true, "void": true, "while": true, "with": true, "yield": true,
+}
+
+// DetectContentType heuristically classifies the first bytes of b as HTML, XML, SVG, CSS, JS, or JSON, so that mislabeled or unlabeled input can be routed to the right parser. It only looks at the leading markup, or at the tokens before the first block or statement, and returns UnknownContentType when no decision can be made. It does not validate the input.
+func DetectContentType(b []byte) ContentType {
+	if detectLen < len(b) {
+		b = b[:detectLen]
+	}
+	b = bytes.TrimPrefix(b, []byte("\xEF\xBB\xBF"))
+	b = skipWhitespace(b)
+	if len(b) == 0 {
+		return UnknownContentType
+	} else if b[0] == '<' {
+		return detectMarkup(b)
+	} else if (b[0] == '{' || b[0] == '[') && isJSON(b) {
+		return JSONContentType
+	}
+	return detectStylesheetOrScript(b)
+}
+
+func skipWhitespace(b []byte) []byte {
+	for 0 < len(b) && IsWhitespace(b[0]) {
+		b = b[1:]
+	}
+	return b
+}
+
+func isNameByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':' || 0x80 <= c
+}
+
+// detectMarkup classifies input starting with < by its XML declaration, doctype, or root element.
+func detectMarkup(b []byte) ContentType {
+	xmlDecl := false
+	for {
+		b = skipWhitespace(b)
+		if len(b) < 2 || b[0] != '<' {
+			break
+		} else if b[1] == '?' {
+			xmlDecl = xmlDecl || bytes.HasPrefix(b, []byte("<?xml"))
+			if i := bytes.Index(b, []byte("?>")); i != -1 {
+				b = b[i+2:]
+				continue
+			}
+			break
+		} else if bytes.HasPrefix(b, []byte("<!--")) {
+			if i := bytes.Index(b[4:], []byte("-->")); i != -1 {
+				b = b[4+i+3:]
+				continue
+			}
+			break
+		} else if 9 <= len(b) && EqualFold(b[:9], []byte("<!doctype")) {
+			name := skipWhitespace(b[9:])
+			n := 0
+			for n < len(name) && isNameByte(name[n]) {
+				n++
+			}
+			if EqualFold(name[:n], []byte("html")) {
+				if xmlDecl {
+					return XMLContentType // XHTML
+				}
+				return HTMLContentType
+			} else if EqualFold(name[:n], []byte("svg")) {
+				return SVGContentType
+			}
+			return XMLContentType
+		} else if c := b[1]; 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+			n := 2
+			for n < len(b) && isNameByte(b[n]) {
+				n++
+			}
+			name := ToLower(Copy(b[1:n]))
+			if i := bytes.IndexByte(name, ':'); i != -1 {
+				name = name[i+1:]
+			}
+			if bytes.Equal(name, []byte("svg")) {
+				return SVGContentType
+			} else if xmlDecl {
+				return XMLContentType
+			} else if htmlElements[string(name)] || bytes.IndexByte(name, '-') != -1 && bytes.IndexByte(b[1:n], ':') == -1 {
+				// HTML elements and custom elements
+				return HTMLContentType
+			}
+			return XMLContentType
+		}
+		break
+	}
+	if xmlDecl {
+		return XMLContentType
+	}
+	return UnknownContentType
+}
+
+// isJSON returns true if b consists only of JSON tokens and object keys are strings. Literals may be truncated at the end of b.
+func isJSON(b []byte) bool {
+	objectStart := false
+	for {
+		b = skipWhitespace(b)
+		if len(b) == 0 {
+			return true
+		}
+		if objectStart && b[0] != '"' && b[0] != '}' {
+			return false
+		}
+		objectStart = b[0] == '{'
+
+		switch c := b[0]; {
+		case c == '{' || c == '}' || c == '[' || c == ']' || c == ',' || c == ':':
+			b = b[1:]
+		case c == '"':
+			i := 1
+			for ; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' {
+					i++
+				} else if b[i] == '\n' {
+					return false
+				}
+			}
+			if len(b) <= i {
+				return true
+			}
+			b = b[i+1:]
+		case '0' <= c && c <= '9' || c == '-':
+			for 0 < len(b) && ('0' <= b[0] && b[0] <= '9' || b[0] == '-' || b[0] == '+' || b[0] == '.' || b[0] == 'e' || b[0] == 'E') {
+				b = b[1:]
+			}
+		default:
+			ok := false
+			for _, literal := range []string{"true", "false", "null"} {
+				if bytes.HasPrefix(b, []byte(literal)) {
+					b, ok = b[len(literal):], true
+					break
+				} else if len(b) < len(literal) && literal[:len(b)] == string(b) {
+					return true
+				}
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+}
+
+// detectStylesheetOrScript tells CSS and JS apart by inspecting the prelude before the first block or statement.
+func detectStylesheetOrScript(b []byte) ContentType {
+	if b[0] == '@' {
+		// at-rule
+		return CSSContentType
+	} else if c := b[0]; !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '-' || c == '_' || c == '.' || c == '#' || c == '*' || c == '[' || c == ':' || c == '&' || c == '/' || 0x80 <= c) {
+		// strings, parentheses, operators, and numbers cannot start a CSS selector or declaration
+		return JSContentType
+	}
+
+	first := []byte(nil)    // first identifier
+	colon := false          // identifier followed by colon, such as in a declaration
+	assign := false         // equal sign outside of brackets
+	depth, brackets := 0, 0 // parentheses and brackets
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; c {
+		case '/':
+			if i+1 < len(b) && b[i+1] == '/' {
+				return JSContentType
+			} else if i+1 < len(b) && b[i+1] == '*' {
+				if j := bytes.Index(b[i+2:], []byte("*/")); j != -1 {
+					i += 2 + j + 1
+					continue
+				}
+				return UnknownContentType
+			} else if first == nil {
+				return JSContentType
+			}
+		case '"', '\'', '`':
+			if c == '`' {
+				return JSContentType
+			}
+			for i++; i < len(b) && b[i] != c && b[i] != '\n'; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+		case '(':
+			if 3 <= i && EqualFold(b[i-3:i], []byte("url")) {
+				// skip unquoted URLs which may contain //
+				if j := bytes.IndexByte(b[i:], ')'); j != -1 {
+					i += j
+					continue
+				}
+			}
+			depth++
+		case ')':
+			depth--
+		case '[':
+			brackets++
+		case ']':
+			brackets--
+		case '=':
+			if brackets == 0 {
+				assign = true
+			}
+		case ':':
+			if depth == 0 && brackets == 0 && first != nil && bytes.Equal(bytes.TrimSpace(b[:i]), first) {
+				colon = true
+			}
+		case ';', '{', '}':
+			if depth != 0 {
+				continue
+			}
+			if c == '{' && !assign && !jsKeywords[string(first)] {
+				// selector followed by a declaration block
+				return CSSContentType
+			} else if c == ';' && colon && !assign {
+				// declaration list, such as in a style attribute
+				return CSSContentType
+			}
+			return JSContentType
+		default:
+			if first == nil && ('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '-' || c == '_' || 0x80 <= c) {
+				j := i + 1
+				for j < len(b) && (isNameByte(b[j]) && b[j] != '.' && b[j] != ':') {
+					j++
+				}
+				first = b[i:j]
+				i = j - 1
+			}
+		}
+	}
+	if colon && !assign && !jsKeywords[string(first)] {
+		return CSSContentType
+	}
+	return JSContentType
+}
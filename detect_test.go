@@ -0,0 +1,73 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestDetectContentType(t *testing.T) {
+	var tests = []struct {
+		b        string
+		expected ContentType
+	}{
+		{"", UnknownContentType},
+		{"  \n", UnknownContentType},
+		{"<!DOCTYPE html><html>", HTMLContentType},
+		{"\xEF\xBB\xBF<!doctype HTML>", HTMLContentType},
+		{"<!-- comment --><div class=x>", HTMLContentType},
+		{"<p>text", HTMLContentType},
+		{"<my-element></my-element>", HTMLContentType},
+		{"<?xml version=\"1.0\"?><note><to>Tove</to></note>", XMLContentType},
+		{"<?xml version=\"1.0\"?><!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\"><html>", XMLContentType},
+		{"<note><to>Tove</to></note>", XMLContentType},
+		{"<soap:Envelope xmlns:soap=\"x\">", XMLContentType},
+		{"<?xml version=\"1.0\"?>\n<!-- x -->\n<svg xmlns=\"http://www.w3.org/2000/svg\">", SVGContentType},
+		{"<svg viewBox=\"0 0 1 1\">", SVGContentType},
+		{"<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\">", SVGContentType},
+		{"<3", UnknownContentType},
+		{"{\"a\": [1, -2.5e3, true, null], \"b\": {}}", JSONContentType},
+		{"[1, 2, \"x\\\"y\"]", JSONContentType},
+		{"[tru", JSONContentType},
+		{"{\"unterminated", JSONContentType},
+		{"{a: 1}", JSContentType},
+		{"[a=b] { color: red }", CSSContentType},
+		{"{ let a = 1 }", JSContentType},
+		{"@charset \"utf-8\";", CSSContentType},
+		{"@media (min-width: 100px) { a { color: red } }", CSSContentType},
+		{"body { margin: 0 }", CSSContentType},
+		{".a > .b:not(.c), #d { color: red; }", CSSContentType},
+		{"/* comment */ :root { --x: 1 }", CSSContentType},
+		{"a { background: url(//example.com/a.png) }", CSSContentType},
+		{"var { font-style: normal }", CSSContentType},
+		{"color: red; margin: 0", CSSContentType},
+		{"color: red", CSSContentType},
+		{"\"use strict\"; var a = 1;", JSContentType},
+		{"(function() {})()", JSContentType},
+		{"!function() {}()", JSContentType},
+		{"var a = 5;", JSContentType},
+		{"function f(a) { return a }", JSContentType},
+		{"if (a) { b() }", JSContentType},
+		{"class A { x = 1 }", JSContentType},
+		{"// comment\nfoo()", JSContentType},
+		{"document.querySelector('a').addEventListener('click', f)", JSContentType},
+		{"x = {a: 1}", JSContentType},
+		{"a = `b`", JSContentType},
+		{"/* unterminated", UnknownContentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			test.T(t, DetectContentType([]byte(tt.b)), tt.expected)
+		})
+	}
+
+	// coverage
+	for i := 0; ; i++ {
+		ct := ContentType(i)
+		if ct.String() == fmt.Sprintf("Invalid(%d)", i) {
+			break
+		}
+		test.T(t, ct.Mimetype() == "", ct == UnknownContentType)
+	}
+}
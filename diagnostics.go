@@ -0,0 +1,73 @@
+package parse
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Diagnostics collects the errors that a parser reports while it recovers from them, so that a single pass over a broken input reports all problems instead of only the first. The CSS, JS, and JSON parsers and the XML decoder recover from errors when given a Diagnostics, see css.Parser.SetDiagnostics, js.Options.Diagnostics, json.Parser.SetDiagnostics, and xml.Decoder.SetDiagnostics.
+type Diagnostics struct {
+	Errors  []*Error
+	Omitted int // number of errors that were reported after the maximum was reached
+
+	max int
+}
+
+// NewDiagnostics returns a new Diagnostics that keeps at most max errors, or all errors if max is zero or negative.
+func NewDiagnostics(max int) *Diagnostics {
+	return &Diagnostics{
+		max: max,
+	}
+}
+
+// Add adds an error. It returns false if the error is omitted because the maximum number of errors has been reached.
+func (d *Diagnostics) Add(err *Error) bool {
+	if d.Full() {
+		d.Omitted++
+		return false
+	}
+	d.Errors = append(d.Errors, err)
+	return true
+}
+
+// Full returns true if the maximum number of errors has been reached, in which case a parser may stop early.
+func (d *Diagnostics) Full() bool {
+	return 0 < d.max && d.max <= len(d.Errors)
+}
+
+// Len returns the number of errors that were reported, including omitted errors.
+func (d *Diagnostics) Len() int {
+	return len(d.Errors) + d.Omitted
+}
+
+// Err returns nil if no errors were reported, or the Diagnostics itself as an error otherwise.
+func (d *Diagnostics) Err() error {
+	if d.Len() == 0 {
+		return nil
+	}
+	return d
+}
+
+// Error returns the messages of the errors with their line and column numbers, one per line, and the number of omitted errors.
+func (d *Diagnostics) Error() string {
+	sb := strings.Builder{}
+	for i, err := range d.Errors {
+		if i != 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(err.Message)
+		sb.WriteString(" on line ")
+		sb.WriteString(strconv.Itoa(err.Line))
+		sb.WriteString(" and column ")
+		sb.WriteString(strconv.Itoa(err.Column))
+	}
+	if 0 < d.Omitted {
+		if 0 < len(d.Errors) {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString("and ")
+		sb.WriteString(strconv.Itoa(d.Omitted))
+		sb.WriteString(" more errors")
+	}
+	return sb.String()
+}
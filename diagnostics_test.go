@@ -0,0 +1,30 @@
+package parse
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestDiagnostics(t *testing.T) {
+	d := NewDiagnostics(2)
+	test.Error(t, d.Err())
+	test.T(t, d.Add(NewError(bytes.NewBufferString("a\nbc"), 3, "first")), true)
+	test.T(t, d.Full(), false)
+	test.T(t, d.Add(NewError(bytes.NewBufferString("a\nbc"), 1, "second")), true)
+	test.T(t, d.Full(), true)
+	test.T(t, d.Add(NewError(bytes.NewBufferString("a\nbc"), 0, "third")), false)
+	test.T(t, len(d.Errors), 2)
+	test.T(t, d.Omitted, 1)
+	test.T(t, d.Len(), 3)
+	test.T(t, d.Err(), error(d))
+	test.String(t, d.Error(), "first on line 2 and column 2\nsecond on line 1 and column 2\nand 1 more errors")
+
+	d = NewDiagnostics(0)
+	for i := 0; i < 10; i++ {
+		test.T(t, d.Add(NewError(bytes.NewBufferString("a"), 0, "error")), true)
+	}
+	test.T(t, d.Full(), false)
+	test.T(t, d.Len(), 10)
+}
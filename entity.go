@@ -0,0 +1,118 @@
+package parse
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:generate go run ./internal/entitygen -o entity_table.go
+
+// EntityTable is a table of named character references, such as HTMLEntities and XMLEntities, with a trie to decode references and a reverse map to encode text as references. Names are as in the specification without the leading ampersand and including the trailing semicolon, so that legacy HTML names without a semicolon, such as amp, are separate entries.
+type EntityTable struct {
+	nodes  []entityNode      // trie nodes, where the root is the first node
+	edges  []entityEdge      // edges to child nodes sorted by byte, contiguous for each node
+	values []string          // replacement texts
+	names  map[string]string // preferred name of each replacement text
+}
+
+type entityNode struct {
+	edge  uint16 // index of the first edge
+	n     uint8  // number of edges
+	value uint16 // index plus one of the replacement text, or zero if no name ends at this node
+}
+
+type entityEdge struct {
+	c    byte
+	node uint16
+}
+
+// child returns the child node of i for byte c, or zero if there is none.
+func (t *EntityTable) child(i int, c byte) int {
+	edges := t.edges[t.nodes[i].edge : int(t.nodes[i].edge)+int(t.nodes[i].n)]
+	lo, hi := 0, len(edges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if edges[mid].c < c {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(edges) && edges[lo].c == c {
+		return int(edges[lo].node)
+	}
+	return 0
+}
+
+// Lookup returns the replacement text of the longest name that is a prefix of b, where b starts after the ampersand, and the length of the name. It returns zero if no name matches. For example, &notin; matches notin; and &notit; matches the legacy name not for HTMLEntities.
+func (t *EntityTable) Lookup(b []byte) (string, int) {
+	value, n := "", 0
+	i := 0
+	for j, c := range b {
+		if i = t.child(i, c); i == 0 {
+			break
+		} else if t.nodes[i].value != 0 {
+			value, n = t.values[t.nodes[i].value-1], j+1
+		}
+	}
+	return value, n
+}
+
+// Get returns the replacement text of name, such as amp;, and whether it is in the table.
+func (t *EntityTable) Get(name []byte) (string, bool) {
+	value, n := t.Lookup(name)
+	return value, n != 0 && n == len(name)
+}
+
+// Name returns the preferred name of the reference for the replacement text s, such as amp; for &, and whether there is one. The preferred name is the shortest name that ends in a semicolon, preferring lowercase names.
+func (t *EntityTable) Name(s string) (string, bool) {
+	name, ok := t.names[s]
+	return name, ok
+}
+
+// DecodeEntity decodes the character reference at the start of b, which starts with an ampersand, as by the HTML tokenizer, see https://html.spec.whatwg.org/multipage/parsing.html#character-reference-state. It returns the replacement text, which may consist of two code points such as for &NotEqualTilde;, and the length of the reference, or zero if b does not start with a character reference. Named references are matched against HTMLEntities by their longest name, so that &notit; decodes the legacy name &not without a semicolon. In attribute values, such legacy names are not decoded when followed by an alphanumeric character or =, such as in ?a=1&copy=2. Numeric references may omit the semicolon, and references to NULL, surrogates, and code points outside of Unicode decode to U+FFFD, while C1 control characters decode as windows-1252.
+func DecodeEntity(b []byte, inAttr bool) (string, int) {
+	if len(b) < 2 || b[0] != '&' {
+		return "", 0
+	} else if b[1] != '#' {
+		value, n := HTMLEntities.Lookup(b[1:])
+		if n == 0 || b[n] != ';' && inAttr && n+1 < len(b) && (b[n+1] == '=' || isAlphanumeric(b[n+1])) {
+			return "", 0
+		}
+		return value, n + 1
+	}
+
+	i, hex := 2, false
+	if i < len(b) && (b[i] == 'x' || b[i] == 'X') {
+		i, hex = 3, true
+	}
+	start := i
+	r := 0
+	for ; i < len(b); i++ {
+		d := hexDigit(b[i])
+		if !hex && 10 <= d || 16 <= d {
+			break
+		} else if r <= unicode.MaxRune {
+			if hex {
+				r = r*16 + d
+			} else {
+				r = r*10 + d
+			}
+		}
+	}
+	if i == start {
+		return "", 0
+	} else if i < len(b) && b[i] == ';' {
+		i++
+	}
+	if r == 0 || unicode.MaxRune < r || 0xD800 <= r && r <= 0xDFFF {
+		r = utf8.RuneError
+	} else if 0x80 <= r && r < 0xA0 {
+		r = int(windows1252[r-0x80])
+	}
+	return string(rune(r)), i
+}
+
+func isAlphanumeric(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,4203 @@
+// Code generated by entitygen from https://html.spec.whatwg.org/entities.json; DO NOT EDIT.
+
+package parse
+
+// HTMLEntities are the named character references of HTML, see https://html.spec.whatwg.org/multipage/named-characters.html.
+var HTMLEntities = &EntityTable{
+	nodes: []entityNode{
+		{0, 52, 0}, {52, 16, 0}, {68, 8, 0}, {76, 14, 0}, {90, 11, 0}, {101, 16, 0}, {117, 5, 0}, {122, 12, 0},
+		{134, 8, 0}, {142, 14, 0}, {156, 5, 0}, {161, 7, 0}, {168, 11, 0}, {179, 8, 0}, {187, 9, 0}, {196, 14, 0},
+		{210, 9, 0}, {219, 4, 0}, {223, 12, 0}, {235, 13, 0}, {248, 11, 0}, {259, 14, 0}, {273, 9, 0}, {282, 5, 0},
+		{287, 4, 0}, {291, 9, 0}, {300, 8, 0}, {308, 16, 0}, {324, 16, 0}, {340, 15, 0}, {355, 19, 0}, {374, 18, 0},
+		{392, 12, 0}, {404, 17, 0}, {421, 10, 0}, {431, 15, 0}, {446, 6, 0}, {452, 8, 0}, {460, 23, 0}, {483, 14, 0},
+		{497, 24, 0}, {521, 18, 0}, {539, 12, 0}, {551, 6, 0}, {557, 21, 0}, {578, 19, 0}, {597, 13, 0}, {610, 18, 0},
+		{628, 15, 0}, {643, 7, 0}, {650, 14, 0}, {664, 8, 0}, {672, 10, 0}, {682, 1, 0}, {683, 1, 0}, {684, 1, 0},
+		{685, 1, 0}, {686, 2, 0}, {688, 1, 0}, {689, 1, 0}, {690, 1, 0}, {691, 1, 0}, {692, 1, 0}, {693, 2, 0},
+		{695, 1, 0}, {696, 1, 0}, {697, 2, 0}, {699, 1, 0}, {700, 1, 0}, {701, 2, 0}, {703, 1, 0}, {704, 3, 0},
+		{707, 1, 0}, {708, 1, 0}, {709, 1, 0}, {710, 1, 0}, {711, 1, 0}, {712, 1, 0}, {713, 1, 0}, {714, 3, 0},
+		{717, 4, 0}, {721, 1, 0}, {722, 2, 0}, {724, 1, 0}, {725, 1, 0}, {726, 1, 0}, {727, 1, 0}, {728, 4, 0},
+		{732, 1, 0}, {733, 1, 0}, {734, 1, 0}, {735, 2, 0}, {737, 1, 0}, {738, 1, 0}, {739, 1, 0}, {740, 3, 0},
+		{743, 2, 0}, {745, 1, 0}, {746, 1, 0}, {747, 2, 0}, {749, 4, 0}, {753, 2, 0}, {755, 1, 0}, {756, 1, 0},
+		{757, 1, 0}, {758, 3, 0}, {761, 1, 0}, {762, 1, 0}, {763, 1, 0}, {764, 1, 0}, {765, 2, 0}, {767, 2, 0},
+		{769, 1, 0}, {770, 1, 0}, {771, 2, 0}, {773, 1, 0}, {774, 1, 0}, {775, 2, 0}, {777, 1, 0}, {778, 1, 0},
+		{779, 1, 0}, {780, 3, 0}, {783, 1, 0}, {784, 1, 0}, {785, 1, 146}, {786, 1, 0}, {787, 1, 0}, {788, 3, 0},
+		{791, 1, 0}, {792, 1, 0}, {793, 1, 0}, {794, 1, 0}, {795, 1, 0}, {796, 1, 0}, {797, 1, 0}, {798, 1, 0},
+		{799, 2, 0}, {801, 1, 0}, {802, 1, 0}, {803, 1, 0}, {804, 2, 0}, {806, 2, 0}, {808, 1, 0}, {809, 1, 0},
+		{810, 1, 0}, {811, 1, 0}, {812, 1, 0}, {813, 2, 0}, {815, 1, 0}, {816, 1, 0}, {817, 1, 0}, {818, 3, 0},
+		{821, 2, 0}, {823, 3, 0}, {826, 1, 0}, {827, 1, 0}, {828, 2, 0}, {830, 2, 0}, {832, 1, 0}, {833, 1, 0},
+		{834, 2, 0}, {836, 1, 0}, {837, 1, 0}, {838, 1, 0}, {839, 1, 0}, {840, 2, 0}, {842, 1, 0}, {843, 1, 0},
+		{844, 1, 0}, {845, 1, 0}, {846, 1, 215}, {847, 5, 0}, {852, 3, 0}, {855, 2, 0}, {857, 1, 0}, {858, 2, 0},
+		{860, 1, 0}, {861, 3, 0}, {864, 3, 0}, {867, 1, 0}, {868, 1, 0}, {869, 1, 0}, {870, 2, 0}, {872, 1, 0},
+		{873, 1, 0}, {874, 1, 0}, {875, 1, 0}, {876, 1, 0}, {877, 1, 0}, {878, 1, 0}, {879, 3, 0}, {882, 3, 0},
+		{885, 1, 0}, {886, 4, 0}, {890, 1, 0}, {891, 1, 0}, {892, 1, 0}, {893, 1, 0}, {894, 1, 0}, {895, 2, 0},
+		{897, 1, 0}, {898, 1, 0}, {899, 1, 0}, {900, 3, 0}, {903, 1, 0}, {904, 1, 0}, {905, 1, 0}, {906, 2, 0},
+		{908, 1, 0}, {909, 1, 0}, {910, 1, 0}, {911, 1, 0}, {912, 1, 0}, {913, 1, 0}, {914, 1, 0}, {915, 1, 0},
+		{916, 1, 0}, {917, 2, 0}, {919, 4, 0}, {923, 2, 0}, {925, 1, 0}, {926, 1, 0}, {927, 1, 0}, {928, 1, 0},
+		{929, 1, 0}, {930, 1, 0}, {931, 3, 0}, {934, 3, 0}, {937, 2, 0}, {939, 1, 0}, {940, 1, 0}, {941, 1, 0},
+		{942, 2, 0}, {944, 1, 0}, {945, 2, 0}, {947, 1, 0}, {948, 2, 0}, {950, 1, 0}, {951, 1, 0}, {952, 5, 0},
+		{957, 1, 0}, {958, 1, 0}, {959, 1, 0}, {960, 1, 0}, {961, 1, 0}, {962, 2, 0}, {964, 1, 0}, {965, 1, 0},
+		{966, 4, 0}, {970, 1, 0}, {971, 1, 0}, {972, 2, 0}, {974, 2, 0}, {976, 3, 0}, {979, 1, 0}, {980, 2, 0},
+		{982, 1, 0}, {983, 1, 0}, {984, 1, 0}, {985, 2, 0}, {987, 2, 0}, {989, 1, 0}, {990, 2, 0}, {992, 1, 0},
+		{993, 1, 0}, {994, 1, 0}, {995, 1, 0}, {996, 2, 0}, {998, 2, 0}, {1000, 8, 0}, {1008, 1, 0}, {1009, 1, 0},
+		{1010, 1, 0}, {1011, 1, 0}, {1012, 1, 0}, {1013, 1, 0}, {1014, 1, 0}, {1015, 1, 0}, {1016, 2, 0}, {1018, 1, 0},
+		{1019, 1, 0}, {1020, 1, 0}, {1021, 1, 0}, {1022, 1, 0}, {1023, 1, 0}, {1024, 1, 0}, {1025, 1, 0}, {1026, 1, 0},
+		{1027, 1, 0}, {1028, 1, 0}, {1029, 1, 0}, {1030, 1, 0}, {1031, 1, 0}, {1032, 1, 0}, {1033, 1, 0}, {1034, 1, 0},
+		{1035, 2, 0}, {1037, 1, 0}, {1038, 1, 0}, {1039, 1, 0}, {1040, 1, 0}, {1041, 1, 0}, {1042, 1, 0}, {1043, 2, 0},
+		{1045, 1, 0}, {1046, 2, 0}, {1048, 1, 0}, {1049, 1, 0}, {1050, 1, 0}, {1051, 1, 0}, {1052, 1, 0}, {1053, 6, 0},
+		{1059, 1, 0}, {1060, 2, 0}, {1062, 1, 0}, {1063, 2, 0}, {1065, 2, 0}, {1067, 2, 0}, {1069, 2, 0}, {1071, 7, 0},
+		{1078, 1, 0}, {1079, 3, 0}, {1082, 1, 0}, {1083, 1, 0}, {1084, 2, 0}, {1086, 1, 0}, {1087, 2, 0}, {1089, 1, 0},
+		{1090, 2, 0}, {1092, 1, 0}, {1093, 5, 0}, {1098, 1, 0}, {1099, 1, 0}, {1100, 1, 0}, {1101, 3, 0}, {1104, 2, 0},
+		{1106, 4, 0}, {1110, 1, 0}, {1111, 2, 0}, {1113, 4, 0}, {1117, 2, 0}, {1119, 3, 0}, {1122, 4, 0}, {1126, 1, 0},
+		{1127, 3, 0}, {1130, 1, 0}, {1131, 3, 0}, {1134, 1, 0}, {1135, 1, 0}, {1136, 4, 0}, {1140, 2, 0}, {1142, 2, 0},
+		{1144, 1, 0}, {1145, 7, 0}, {1152, 2, 0}, {1154, 1, 0}, {1155, 1, 0}, {1156, 1, 0}, {1157, 4, 0}, {1161, 2, 0},
+		{1163, 2, 0}, {1165, 3, 0}, {1168, 3, 0}, {1171, 2, 0}, {1173, 1, 0}, {1174, 5, 0}, {1179, 1, 0}, {1180, 1, 0},
+		{1181, 5, 0}, {1186, 2, 0}, {1188, 3, 0}, {1191, 2, 0}, {1193, 2, 0}, {1195, 1, 0}, {1196, 2, 0}, {1198, 2, 0},
+		{1200, 2, 0}, {1202, 4, 0}, {1206, 1, 0}, {1207, 1, 0}, {1208, 2, 0}, {1210, 3, 0}, {1213, 4, 0}, {1217, 3, 0},
+		{1220, 2, 0}, {1222, 2, 0}, {1224, 3, 0}, {1227, 4, 0}, {1231, 2, 0}, {1233, 3, 0}, {1236, 2, 0}, {1238, 2, 0},
+		{1240, 3, 0}, {1243, 1, 0}, {1244, 1, 0}, {1245, 1, 0}, {1246, 3, 0}, {1249, 1, 0}, {1250, 1, 0}, {1251, 3, 0},
+		{1254, 1, 0}, {1255, 2, 0}, {1257, 1, 0}, {1258, 2, 0}, {1260, 1, 0}, {1261, 2, 0}, {1263, 3, 0}, {1266, 1, 0},
+		{1267, 2, 0}, {1269, 1, 0}, {1270, 4, 0}, {1274, 1, 0}, {1275, 2, 0}, {1277, 1, 0}, {1278, 1, 0}, {1279, 4, 0},
+		{1283, 4, 0}, {1287, 1, 0}, {1288, 1, 0}, {1289, 2, 0}, {1291, 6, 146}, {1297, 2, 0}, {1299, 1, 0}, {1300, 4, 0},
+		{1304, 1, 0}, {1305, 1, 0}, {1306, 3, 0}, {1309, 1, 0}, {1310, 1, 0}, {1311, 5, 0}, {1316, 3, 0}, {1319, 2, 0},
+		{1321, 1, 0}, {1322, 3, 0}, {1325, 2, 0}, {1327, 2, 0}, {1329, 1, 0}, {1330, 4, 0}, {1334, 1, 0}, {1335, 3, 0},
+		{1338, 5, 0}, {1343, 4, 0}, {1347, 1, 0}, {1348, 1, 0}, {1349, 2, 0}, {1351, 2, 0}, {1353, 2, 0}, {1355, 2, 0},
+		{1357, 1, 0}, {1358, 1, 0}, {1359, 1, 0}, {1360, 2, 0}, {1362, 1, 0}, {1363, 1, 0}, {1364, 2, 0}, {1366, 1, 0},
+		{1367, 1, 0}, {1368, 1, 0}, {1369, 1, 0}, {1370, 1, 0}, {1371, 1, 0}, {1372, 3, 0}, {1375, 1, 0}, {1376, 2, 0},
+		{1378, 1, 0}, {1379, 9, 0}, {1388, 3, 0}, {1391, 4, 0}, {1395, 4, 0}, {1399, 5, 0}, {1404, 3, 0}, {1407, 2, 0},
+		{1409, 2, 0}, {1411, 1, 0}, {1412, 5, 0}, {1417, 2, 0}, {1419, 4, 0}, {1423, 8, 0}, {1431, 1, 0}, {1432, 5, 0},
+		{1437, 6, 0}, {1443, 8, 215}, {1451, 1, 0}, {1452, 2, 0}, {1454, 1, 0}, {1455, 4, 0}, {1459, 2, 0}, {1461, 1, 0},
+		{1462, 1, 0}, {1463, 1, 0}, {1464, 1, 0}, {1465, 3, 0}, {1468, 2, 0}, {1470, 1, 0}, {1471, 2, 0}, {1473, 1, 0},
+		{1474, 2, 0}, {1476, 3, 0}, {1479, 2, 0}, {1481, 3, 0}, {1484, 1, 0}, {1485, 2, 0}, {1487, 5, 0}, {1492, 2, 0},
+		{1494, 5, 0}, {1499, 1, 0}, {1500, 7, 0}, {1507, 1, 0}, {1508, 4, 0}, {1512, 3, 0}, {1515, 3, 0}, {1518, 1, 0},
+		{1519, 7, 0}, {1526, 1, 0}, {1527, 2, 0}, {1529, 3, 0}, {1532, 4, 0}, {1536, 7, 0}, {1543, 4, 0}, {1547, 2, 0},
+		{1549, 9, 0}, {1558, 3, 0}, {1561, 1, 0}, {1562, 2, 0}, {1564, 2, 0}, {1566, 5, 0}, {1571, 1, 0}, {1572, 2, 0},
+		{1574, 3, 0}, {1577, 2, 0}, {1579, 1, 0}, {1580, 4, 0}, {1584, 3, 0}, {1587, 1, 0}, {1588, 3, 0}, {1591, 7, 0},
+		{1598, 3, 0}, {1601, 1, 0}, {1602, 1, 0}, {1603, 1, 0}, {1604, 1, 0}, {1605, 1, 0}, {1606, 1, 0}, {1607, 1, 0},
+		{1608, 3, 0}, {1611, 3, 0}, {1614, 2, 0}, {1616, 1, 0}, {1617, 3, 0}, {1620, 10, 0}, {1630, 2, 0}, {1632, 1, 0},
+		{1633, 1, 0}, {1634, 1, 0}, {1635, 1, 0}, {1636, 1, 0}, {1637, 1, 0}, {1638, 3, 0}, {1641, 3, 0}, {1644, 1, 0},
+		{1645, 1, 0}, {1646, 7, 0}, {1653, 3, 0}, {1656, 4, 0}, {1660, 4, 0}, {1664, 3, 0}, {1667, 3, 0}, {1670, 2, 0},
+		{1672, 3, 0}, {1675, 3, 0}, {1678, 1, 0}, {1679, 1, 0}, {1680, 4, 0}, {1684, 2, 0}, {1686, 1, 0}, {1687, 4, 0},
+		{1691, 3, 0}, {1694, 1, 0}, {1695, 1, 0}, {1696, 1, 0}, {1697, 1, 0}, {1698, 10, 0}, {1708, 1, 0}, {1709, 7, 0},
+		{1716, 1, 0}, {1717, 4, 0}, {1721, 2, 0}, {1723, 1, 0}, {1724, 4, 0}, {1728, 3, 0}, {1731, 1, 0}, {1732, 3, 0},
+		{1735, 1, 0}, {1736, 4, 0}, {1740, 2, 0}, {1742, 5, 0}, {1747, 3, 0}, {1750, 1, 0}, {1751, 2, 0}, {1753, 1, 0},
+		{1754, 3, 0}, {1757, 1, 0}, {1758, 1, 0}, {1759, 1, 0}, {1760, 4, 0}, {1764, 3, 0}, {1767, 3, 0}, {1770, 1, 0},
+		{1771, 3, 0}, {1774, 3, 0}, {1777, 2, 0}, {1779, 1, 0}, {1780, 1, 0}, {1781, 2, 0}, {1783, 1, 0}, {1784, 2, 0},
+		{1786, 3, 0}, {1789, 2, 0}, {1791, 1, 0}, {1792, 2, 0}, {1794, 2, 0}, {1796, 2, 0}, {1798, 2, 0}, {1800, 6, 0},
+		{1806, 3, 0}, {1809, 1, 0}, {1810, 3, 0}, {1813, 2, 0}, {1815, 1, 0}, {1816, 1, 0}, {1817, 1, 0}, {1818, 1, 0},
+		{1819, 2, 0}, {1821, 1, 0}, {1822, 1, 0}, {1823, 3, 0}, {1826, 1, 0}, {1827, 1, 0}, {1828, 1, 0}, {1829, 1, 0},
+		{1830, 1, 0}, {1831, 1, 0}, {1832, 2, 0}, {1834, 1, 0}, {1835, 1, 0}, {1836, 2, 0}, {1838, 1, 0}, {1839, 1, 0},
+		{1840, 1, 0}, {1841, 2, 0}, {1843, 1, 0}, {1844, 3, 0}, {1847, 1, 0}, {1848, 1, 0}, {1849, 2, 0}, {1851, 1, 0},
+		{1852, 2, 0}, {1854, 1, 0}, {1855, 1, 0}, {1856, 3, 0}, {1859, 2, 0}, {1861, 2, 0}, {1863, 2, 0}, {1865, 1, 0},
+		{1866, 1, 0}, {1867, 1, 0}, {1868, 2, 0}, {1870, 1, 0}, {1871, 1, 0}, {1872, 1, 0}, {1873, 1, 0}, {1874, 1, 0},
+		{1875, 2, 0}, {1877, 1, 0}, {1878, 2, 0}, {1880, 1, 0}, {1881, 2, 0}, {1883, 1, 0}, {1884, 1, 0}, {1885, 1, 0},
+		{1886, 1, 0}, {1887, 1, 0}, {1888, 2, 0}, {1890, 1, 0}, {1891, 1, 2}, {1892, 1, 0}, {1893, 1, 0}, {1894, 1, 0},
+		{1895, 1, 0}, {1896, 1, 0}, {1897, 1, 0}, {1898, 1, 0}, {1899, 1, 0}, {1900, 1, 0}, {1901, 1, 0}, {1902, 1, 0},
+		{1903, 1, 0}, {1904, 1, 0}, {1905, 1, 0}, {1906, 1, 0}, {1907, 1, 0}, {1908, 1, 0}, {1909, 1, 0}, {1910, 2, 0},
+		{1912, 1, 0}, {1913, 1, 0}, {1914, 1, 0}, {1915, 1, 0}, {1916, 1, 0}, {1917, 1, 0}, {1918, 1, 0}, {1919, 1, 0},
+		{1920, 1, 0}, {1921, 1, 0}, {1922, 1, 0}, {1923, 1, 0}, {1924, 2, 0}, {1926, 1, 0}, {1927, 1, 0}, {1928, 1, 0},
+		{1929, 1, 0}, {1930, 1, 0}, {1931, 1, 0}, {1932, 1, 0}, {1933, 1, 0}, {1934, 1, 0}, {1935, 1, 0}, {1936, 1, 0},
+		{1937, 2, 0}, {1939, 1, 0}, {1940, 3, 0}, {1943, 2, 0}, {1945, 1, 0}, {1946, 1, 0}, {1947, 1, 0}, {1948, 2, 0},
+		{1950, 0, 35}, {1950, 1, 0}, {1951, 1, 0}, {1952, 1, 0}, {1953, 1, 0}, {1954, 1, 0}, {1955, 1, 0}, {1956, 1, 0},
+		{1957, 1, 0}, {1958, 1, 0}, {1959, 2, 0}, {1961, 1, 0}, {1962, 2, 0}, {1964, 1, 0}, {1965, 1, 0}, {1966, 3, 0},
+		{1969, 1, 0}, {1970, 1, 0}, {1971, 1, 0}, {1972, 1, 0}, {1973, 1, 0}, {1974, 1, 114}, {1975, 1, 0}, {1976, 1, 0},
+		{1977, 1, 0}, {1978, 1, 0}, {1979, 1, 0}, {1980, 1, 0}, {1981, 1, 0}, {1982, 1, 0}, {1983, 1, 0}, {1984, 1, 0},
+		{1985, 1, 0}, {1986, 1, 0}, {1987, 1, 0}, {1988, 2, 0}, {1990, 1, 0}, {1991, 1, 0}, {1992, 1, 0}, {1993, 1, 0},
+		{1994, 1, 0}, {1995, 1, 0}, {1996, 1, 0}, {1997, 1, 0}, {1998, 1, 0}, {1999, 1, 0}, {2000, 1, 0}, {2001, 1, 0},
+		{2002, 1, 0}, {2003, 1, 0}, {2004, 0, 146}, {2004, 1, 0}, {2005, 1, 0}, {2006, 1, 0}, {2007, 1, 0}, {2008, 1, 0},
+		{2009, 1, 0}, {2010, 1, 0}, {2011, 0, 155}, {2011, 1, 0}, {2012, 1, 0}, {2013, 1, 0}, {2014, 0, 165}, {2014, 1, 0},
+		{2015, 1, 0}, {2016, 1, 0}, {2017, 1, 0}, {2018, 1, 0}, {2019, 1, 0}, {2020, 1, 0}, {2021, 1, 0}, {2022, 1, 0},
+		{2023, 1, 0}, {2024, 1, 0}, {2025, 1, 0}, {2026, 1, 0}, {2027, 1, 0}, {2028, 1, 0}, {2029, 1, 0}, {2030, 1, 0},
+		{2031, 1, 0}, {2032, 1, 0}, {2033, 1, 0}, {2034, 0, 183}, {2034, 2, 0}, {2036, 1, 0}, {2037, 2, 0}, {2039, 1, 0},
+		{2040, 1, 0}, {2041, 1, 0}, {2042, 1, 0}, {2043, 1, 0}, {2044, 1, 0}, {2045, 1, 0}, {2046, 1, 0}, {2047, 1, 0},
+		{2048, 1, 0}, {2049, 1, 0}, {2050, 1, 0}, {2051, 1, 0}, {2052, 1, 0}, {2053, 1, 0}, {2054, 1, 0}, {2055, 1, 0},
+		{2056, 1, 0}, {2057, 1, 0}, {2058, 1, 0}, {2059, 1, 0}, {2060, 1, 0}, {2061, 1, 0}, {2062, 1, 0}, {2063, 0, 215},
+		{2063, 1, 0}, {2064, 1, 0}, {2065, 1, 0}, {2066, 1, 0}, {2067, 1, 0}, {2068, 1, 0}, {2069, 1, 0}, {2070, 1, 0},
+		{2071, 1, 0}, {2072, 1, 0}, {2073, 1, 0}, {2074, 0, 255}, {2074, 1, 0}, {2075, 1, 0}, {2076, 1, 0}, {2077, 1, 0},
+		{2078, 1, 0}, {2079, 1, 0}, {2080, 1, 0}, {2081, 1, 0}, {2082, 0, 266}, {2082, 1, 0}, {2083, 1, 0}, {2084, 1, 0},
+		{2085, 1, 0}, {2086, 1, 0}, {2087, 1, 0}, {2088, 1, 0}, {2089, 1, 0}, {2090, 0, 274}, {2090, 1, 0}, {2091, 1, 0},
+		{2092, 1, 0}, {2093, 1, 0}, {2094, 1, 0}, {2095, 1, 0}, {2096, 1, 0}, {2097, 1, 0}, {2098, 1, 0}, {2099, 1, 0},
+		{2100, 1, 0}, {2101, 1, 0}, {2102, 13, 0}, {2115, 1, 0}, {2116, 1, 0}, {2117, 0, 340}, {2117, 1, 0}, {2118, 1, 0},
+		{2119, 1, 0}, {2120, 1, 0}, {2121, 1, 0}, {2122, 1, 0}, {2123, 1, 0}, {2124, 1, 0}, {2125, 1, 0}, {2126, 1, 0},
+		{2127, 1, 0}, {2128, 1, 0}, {2129, 0, 354}, {2129, 1, 0}, {2130, 1, 0}, {2131, 2, 0}, {2133, 1, 0}, {2134, 1, 0},
+		{2135, 1, 0}, {2136, 1, 0}, {2137, 1, 0}, {2138, 1, 0}, {2139, 0, 368}, {2139, 1, 0}, {2140, 1, 0}, {2141, 1, 0},
+		{2142, 0, 371}, {2142, 1, 0}, {2143, 1, 0}, {2144, 2, 0}, {2146, 1, 0}, {2147, 1, 0}, {2148, 1, 0}, {2149, 1, 0},
+		{2150, 1, 0}, {2151, 1, 0}, {2152, 1, 0}, {2153, 1, 386}, {2154, 1, 0}, {2155, 1, 0}, {2156, 1, 0}, {2157, 1, 0},
+		{2158, 1, 0}, {2159, 1, 0}, {2160, 0, 394}, {2160, 1, 0}, {2161, 1, 0}, {2162, 1, 0}, {2163, 1, 0}, {2164, 1, 0},
+		{2165, 1, 0}, {2166, 1, 0}, {2167, 1, 0}, {2168, 1, 0}, {2169, 1, 0}, {2170, 1, 0}, {2171, 1, 0}, {2172, 1, 0},
+		{2173, 1, 0}, {2174, 0, 431}, {2174, 1, 0}, {2175, 1, 0}, {2176, 1, 0}, {2177, 1, 0}, {2178, 1, 0}, {2179, 1, 0},
+		{2180, 1, 0}, {2181, 1, 0}, {2182, 1, 0}, {2183, 1, 0}, {2184, 1, 0}, {2185, 1, 0}, {2186, 1, 0}, {2187, 2, 0},
+		{2189, 2, 0}, {2191, 1, 0}, {2192, 3, 0}, {2195, 1, 0}, {2196, 1, 0}, {2197, 1, 0}, {2198, 1, 0}, {2199, 1, 0},
+		{2200, 1, 0}, {2201, 1, 0}, {2202, 1, 0}, {2203, 1, 0}, {2204, 1, 0}, {2205, 2, 0}, {2207, 2, 0}, {2209, 1, 0},
+		{2210, 1, 0}, {2211, 1, 0}, {2212, 1, 0}, {2213, 1, 0}, {2214, 1, 0}, {2215, 1, 0}, {2216, 2, 0}, {2218, 1, 0},
+		{2219, 1, 0}, {2220, 1, 0}, {2221, 1, 0}, {2222, 1, 0}, {2223, 1, 0}, {2224, 1, 0}, {2225, 1, 0}, {2226, 1, 0},
+		{2227, 1, 0}, {2228, 1, 0}, {2229, 1, 0}, {2230, 1, 0}, {2231, 1, 0}, {2232, 1, 0}, {2233, 1, 0}, {2234, 1, 0},
+		{2235, 1, 0}, {2236, 1, 0}, {2237, 1, 0}, {2238, 1, 0}, {2239, 1, 0}, {2240, 1, 0}, {2241, 1, 0}, {2242, 1, 0},
+		{2243, 1, 0}, {2244, 1, 0}, {2245, 3, 0}, {2248, 1, 0}, {2249, 1, 0}, {2250, 1, 0}, {2251, 1, 0}, {2252, 1, 0},
+		{2253, 1, 0}, {2254, 1, 0}, {2255, 1, 0}, {2256, 1, 0}, {2257, 1, 0}, {2258, 0, 538}, {2258, 1, 0}, {2259, 1, 0},
+		{2260, 1, 0}, {2261, 1, 0}, {2262, 1, 0}, {2263, 1, 0}, {2264, 1, 0}, {2265, 1, 0}, {2266, 1, 0}, {2267, 1, 0},
+		{2268, 1, 0}, {2269, 1, 0}, {2270, 1, 0}, {2271, 1, 0}, {2272, 1, 0}, {2273, 1, 0}, {2274, 1, 0}, {2275, 1, 0},
+		{2276, 1, 0}, {2277, 1, 0}, {2278, 1, 0}, {2279, 1, 0}, {2280, 1, 0}, {2281, 1, 0}, {2282, 0, 562}, {2282, 1, 0},
+		{2283, 1, 0}, {2284, 1, 0}, {2285, 1, 0}, {2286, 1, 0}, {2287, 1, 0}, {2288, 0, 14}, {2288, 1, 0}, {2289, 1, 0},
+		{2290, 2, 0}, {2292, 1, 0}, {2293, 2, 0}, {2295, 1, 2}, {2296, 5, 0}, {2301, 7, 0}, {2308, 1, 0}, {2309, 1, 0},
+		{2310, 0, 478}, {2310, 1, 0}, {2311, 1, 0}, {2312, 1, 0}, {2313, 1, 0}, {2314, 1, 0}, {2315, 1, 0}, {2316, 1, 0},
+		{2317, 1, 0}, {2318, 1, 0}, {2319, 1, 0}, {2320, 1, 0}, {2321, 1, 0}, {2322, 1, 0}, {2323, 1, 0}, {2324, 1, 0},
+		{2325, 1, 0}, {2326, 2, 0}, {2328, 1, 0}, {2329, 1, 0}, {2330, 1, 0}, {2331, 1, 0}, {2332, 1, 0}, {2333, 1, 0},
+		{2334, 1, 0}, {2335, 1, 0}, {2336, 3, 0}, {2339, 1, 0}, {2340, 7, 0}, {2347, 1, 0}, {2348, 2, 0}, {2350, 2, 0},
+		{2352, 1, 0}, {2353, 2, 0}, {2355, 1, 0}, {2356, 1, 0}, {2357, 2, 0}, {2359, 1, 0}, {2360, 12, 0}, {2372, 1, 0},
+		{2373, 1, 0}, {2374, 1, 0}, {2375, 1, 0}, {2376, 1, 0}, {2377, 1, 0}, {2378, 1, 0}, {2379, 1, 0}, {2380, 1, 0},
+		{2381, 1, 0}, {2382, 6, 0}, {2388, 2, 0}, {2390, 2, 0}, {2392, 1, 0}, {2393, 1, 0}, {2394, 1, 0}, {2395, 1, 0},
+		{2396, 1, 0}, {2397, 1, 0}, {2398, 1, 0}, {2399, 1, 0}, {2400, 1, 0}, {2401, 1, 0}, {2402, 1, 0}, {2403, 7, 0},
+		{2410, 1, 0}, {2411, 1, 0}, {2412, 2, 0}, {2414, 2, 0}, {2416, 3, 0}, {2419, 1, 0}, {2420, 1, 0}, {2421, 1, 0},
+		{2422, 2, 0}, {2424, 1, 0}, {2425, 1, 0}, {2426, 2, 0}, {2428, 1, 0}, {2429, 6, 0}, {2435, 4, 0}, {2439, 1, 0},
+		{2440, 1, 0}, {2441, 1, 0}, {2442, 1, 0}, {2443, 1, 0}, {2444, 1, 0}, {2445, 1, 0}, {2446, 1, 0}, {2447, 1, 0},
+		{2448, 1, 0}, {2449, 1, 0}, {2450, 1, 0}, {2451, 1, 0}, {2452, 1, 0}, {2453, 1, 0}, {2454, 0, 82}, {2454, 2, 0},
+		{2456, 1, 0}, {2457, 1, 780}, {2458, 1, 0}, {2459, 1, 0}, {2460, 1, 0}, {2461, 1, 0}, {2462, 1, 0}, {2463, 1, 0},
+		{2464, 1, 0}, {2465, 1, 0}, {2466, 1, 0}, {2467, 3, 0}, {2470, 1, 0}, {2471, 2, 0}, {2473, 1, 0}, {2474, 1, 0},
+		{2475, 5, 0}, {2480, 1, 0}, {2481, 1, 0}, {2482, 1, 0}, {2483, 2, 0}, {2485, 2, 0}, {2487, 1, 0}, {2488, 1, 0},
+		{2489, 1, 0}, {2490, 1, 0}, {2491, 1, 0}, {2492, 1, 0}, {2493, 1, 0}, {2494, 1, 0}, {2495, 1, 0}, {2496, 1, 0},
+		{2497, 1, 0}, {2498, 1, 0}, {2499, 1, 0}, {2500, 1, 0}, {2501, 1, 0}, {2502, 1, 0}, {2503, 1, 0}, {2504, 1, 0},
+		{2505, 0, 137}, {2505, 1, 0}, {2506, 1, 0}, {2507, 0, 820}, {2507, 1, 0}, {2508, 2, 0}, {2510, 0, 824}, {2510, 1, 0},
+		{2511, 1, 0}, {2512, 2, 0}, {2514, 1, 0}, {2515, 1, 0}, {2516, 1, 0}, {2517, 1, 0}, {2518, 1, 0}, {2519, 1, 0},
+		{2520, 1, 0}, {2521, 1, 0}, {2522, 1, 0}, {2523, 1, 0}, {2524, 2, 0}, {2526, 2, 0}, {2528, 3, 0}, {2531, 1, 0},
+		{2532, 1, 0}, {2533, 1, 0}, {2534, 1, 0}, {2535, 1, 0}, {2536, 1, 0}, {2537, 1, 0}, {2538, 1, 851}, {2539, 1, 0},
+		{2540, 1, 0}, {2541, 1, 0}, {2542, 1, 0}, {2543, 2, 0}, {2545, 1, 0}, {2546, 1, 0}, {2547, 1, 0}, {2548, 1, 0},
+		{2549, 2, 0}, {2551, 1, 0}, {2552, 1, 0}, {2553, 1, 0}, {2554, 1, 0}, {2555, 1, 0}, {2556, 1, 0}, {2557, 1, 0},
+		{2558, 1, 0}, {2559, 2, 0}, {2561, 1, 0}, {2562, 2, 0}, {2564, 1, 0}, {2565, 1, 0}, {2566, 0, 159}, {2566, 1, 0},
+		{2567, 1, 0}, {2568, 1, 0}, {2569, 1, 0}, {2570, 1, 0}, {2571, 1, 0}, {2572, 1, 0}, {2573, 1, 0}, {2574, 0, 157},
+		{2574, 1, 0}, {2575, 3, 0}, {2578, 4, 0}, {2582, 1, 0}, {2583, 0, 165}, {2583, 1, 0}, {2584, 1, 0}, {2585, 1, 0},
+		{2586, 0, 161}, {2586, 1, 0}, {2587, 1, 0}, {2588, 1, 0}, {2589, 1, 0}, {2590, 1, 0}, {2591, 2, 0}, {2593, 1, 0},
+		{2594, 1, 0}, {2595, 1, 0}, {2596, 1, 0}, {2597, 1, 0}, {2598, 0, 146}, {2598, 2, 0}, {2600, 1, 0}, {2601, 1, 0},
+		{2602, 1, 0}, {2603, 5, 0}, {2608, 1, 0}, {2609, 1, 0}, {2610, 1, 0}, {2611, 1, 0}, {2612, 1, 0}, {2613, 1, 0},
+		{2614, 2, 0}, {2616, 1, 0}, {2617, 1, 0}, {2618, 1, 0}, {2619, 1, 0}, {2620, 1, 0}, {2621, 1, 0}, {2622, 2, 0},
+		{2624, 1, 0}, {2625, 1, 0}, {2626, 1, 0}, {2627, 1, 0}, {2628, 1, 0}, {2629, 1, 0}, {2630, 1, 0}, {2631, 1, 0},
+		{2632, 1, 0}, {2633, 1, 0}, {2634, 1, 0}, {2635, 0, 190}, {2635, 1, 0}, {2636, 1, 0}, {2637, 1, 0}, {2638, 1, 0},
+		{2639, 1, 0}, {2640, 1, 0}, {2641, 1, 0}, {2642, 0, 186}, {2642, 2, 0}, {2644, 1, 0}, {2645, 1, 0}, {2646, 1, 0},
+		{2647, 3, 0}, {2650, 1, 0}, {2651, 1, 0}, {2652, 0, 122}, {2652, 1, 0}, {2653, 1, 0}, {2654, 1, 0}, {2655, 5, 0},
+		{2660, 1, 0}, {2661, 1, 0}, {2662, 1, 0}, {2663, 1, 0}, {2664, 1, 0}, {2665, 1, 0}, {2666, 1, 0}, {2667, 1, 0},
+		{2668, 0, 191}, {2668, 1, 0}, {2669, 1, 0}, {2670, 1, 0}, {2671, 1, 0}, {2672, 1, 0}, {2673, 1, 0}, {2674, 1, 0},
+		{2675, 1, 0}, {2676, 1, 0}, {2677, 1, 0}, {2678, 1, 0}, {2679, 1, 0}, {2680, 1, 0}, {2681, 1, 0}, {2682, 1, 0},
+		{2683, 1, 0}, {2684, 1, 0}, {2685, 1, 0}, {2686, 1, 0}, {2687, 1, 0}, {2688, 1, 0}, {2689, 1, 0}, {2690, 1, 0},
+		{2691, 1, 0}, {2692, 0, 249}, {2692, 1, 0}, {2693, 1, 0}, {2694, 1, 0}, {2695, 1, 0}, {2696, 1, 0}, {2697, 1, 0},
+		{2698, 1, 0}, {2699, 1, 0}, {2700, 1, 0}, {2701, 1, 0}, {2702, 3, 0}, {2705, 1, 0}, {2706, 1, 0}, {2707, 2, 0},
+		{2709, 1, 0}, {2710, 2, 0}, {2712, 1, 0}, {2713, 1, 0}, {2714, 1, 0}, {2715, 1, 0}, {2716, 2, 0}, {2718, 1, 0},
+		{2719, 0, 1031}, {2719, 1, 0}, {2720, 1, 0}, {2721, 3, 0}, {2724, 5, 0}, {2729, 1, 0}, {2730, 1, 0}, {2731, 1, 0},
+		{2732, 0, 250}, {2732, 1, 0}, {2733, 1, 0}, {2734, 1, 0}, {2735, 1, 0}, {2736, 0, 266}, {2736, 1, 0}, {2737, 1, 0},
+		{2738, 1, 0}, {2739, 1, 0}, {2740, 1, 0}, {2741, 1, 0}, {2742, 1, 0}, {2743, 1, 0}, {2744, 2, 0}, {2746, 1, 0},
+		{2747, 2, 0}, {2749, 1, 0}, {2750, 1, 0}, {2751, 1, 0}, {2752, 3, 0}, {2755, 1, 0}, {2756, 2, 0}, {2758, 3, 0},
+		{2761, 1, 0}, {2762, 1, 0}, {2763, 1, 0}, {2764, 1, 0}, {2765, 1, 0}, {2766, 1, 0}, {2767, 1, 0}, {2768, 1, 0},
+		{2769, 1, 0}, {2770, 1, 0}, {2771, 2, 0}, {2773, 1, 0}, {2774, 0, 215}, {2774, 2, 0}, {2776, 1, 0}, {2777, 1, 0},
+		{2778, 1, 0}, {2779, 1, 0}, {2780, 1, 0}, {2781, 2, 0}, {2783, 2, 0}, {2785, 1, 0}, {2786, 1, 0}, {2787, 1, 0},
+		{2788, 1, 0}, {2789, 2, 0}, {2791, 2, 0}, {2793, 1, 0}, {2794, 1, 0}, {2795, 1, 0}, {2796, 1, 0}, {2797, 1, 0},
+		{2798, 1, 0}, {2799, 1, 0}, {2800, 1, 0}, {2801, 4, 0}, {2805, 1, 0}, {2806, 1, 0}, {2807, 1, 0}, {2808, 1, 0},
+		{2809, 1, 0}, {2810, 1, 0}, {2811, 0, 272}, {2811, 1, 0}, {2812, 1, 0}, {2813, 0, 1104}, {2813, 1, 0}, {2814, 1, 0},
+		{2815, 1, 0}, {2816, 2, 0}, {2818, 1, 0}, {2819, 1, 0}, {2820, 2, 0}, {2822, 1, 0}, {2823, 1, 0}, {2824, 1, 0},
+		{2825, 1, 0}, {2826, 1, 0}, {2827, 1, 0}, {2828, 5, 0}, {2833, 1, 0}, {2834, 1, 0}, {2835, 1, 0}, {2836, 2, 0},
+		{2838, 1, 0}, {2839, 1, 0}, {2840, 1, 0}, {2841, 1, 0}, {2842, 1, 0}, {2843, 0, 291}, {2843, 1, 0}, {2844, 1, 0},
+		{2845, 1, 0}, {2846, 1, 0}, {2847, 2, 0}, {2849, 1, 0}, {2850, 1, 0}, {2851, 1, 0}, {2852, 3, 0}, {2855, 1, 0},
+		{2856, 2, 0}, {2858, 1, 0}, {2859, 1, 0}, {2860, 1, 0}, {2861, 0, 395}, {2861, 2, 0}, {2863, 1, 0}, {2864, 1, 0},
+		{2865, 1, 0}, {2866, 1, 0}, {2867, 1, 0}, {2868, 1, 0}, {2869, 4, 0}, {2873, 1, 0}, {2874, 2, 0}, {2876, 1, 0},
+		{2877, 1, 0}, {2878, 3, 1142}, {2881, 1, 0}, {2882, 1, 0}, {2883, 3, 0}, {2886, 1, 0}, {2887, 1, 0}, {2888, 1, 0},
+		{2889, 1, 0}, {2890, 4, 0}, {2894, 1, 0}, {2895, 1, 0}, {2896, 1, 0}, {2897, 1, 0}, {2898, 1, 0}, {2899, 3, 0},
+		{2902, 1, 0}, {2903, 1, 0}, {2904, 1, 0}, {2905, 1, 0}, {2906, 0, 1161}, {2906, 3, 0}, {2909, 1, 0}, {2910, 1, 0},
+		{2911, 1, 0}, {2912, 1, 0}, {2913, 2, 0}, {2915, 1, 0}, {2916, 3, 0}, {2919, 2, 0}, {2921, 1, 0}, {2922, 1, 0},
+		{2923, 1, 0}, {2924, 1, 0}, {2925, 0, 728}, {2925, 1, 0}, {2926, 1, 0}, {2927, 1, 0}, {2928, 1, 0}, {2929, 1, 0},
+		{2930, 1, 0}, {2931, 1, 0}, {2932, 1, 0}, {2933, 1, 0}, {2934, 1, 0}, {2935, 1, 0}, {2936, 1, 0}, {2937, 1, 0},
+		{2938, 1, 0}, {2939, 1, 0}, {2940, 1, 0}, {2941, 1, 0}, {2942, 1, 0}, {2943, 1, 0}, {2944, 2, 0}, {2946, 1, 0},
+		{2947, 1, 0}, {2948, 1, 0}, {2949, 1, 0}, {2950, 3, 0}, {2953, 1, 0}, {2954, 1, 0}, {2955, 1, 0}, {2956, 1, 0},
+		{2957, 0, 1205}, {2957, 1, 0}, {2958, 4, 0}, {2962, 1, 0}, {2963, 1, 0}, {2964, 1, 0}, {2965, 1, 0}, {2966, 1, 0},
+		{2967, 1, 0}, {2968, 1, 0}, {2969, 2, 0}, {2971, 1, 0}, {2972, 1, 0}, {2973, 4, 0}, {2977, 1, 0}, {2978, 5, 0},
+		{2983, 1, 0}, {2984, 2, 0}, {2986, 1, 0}, {2987, 1, 0}, {2988, 0, 1232}, {2988, 1, 0}, {2989, 1, 0}, {2990, 1, 0},
+		{2991, 1, 0}, {2992, 0, 369}, {2992, 1, 0}, {2993, 1, 0}, {2994, 1, 0}, {2995, 0, 372}, {2995, 1, 0}, {2996, 1, 0},
+		{2997, 1, 0}, {2998, 2, 0}, {3000, 1, 0}, {3001, 3, 0}, {3004, 3, 0}, {3007, 1, 0}, {3008, 1, 0}, {3009, 1, 0},
+		{3010, 1, 0}, {3011, 1, 0}, {3012, 1, 0}, {3013, 1, 0}, {3014, 1, 0}, {3015, 1, 0}, {3016, 1, 0}, {3017, 1, 0},
+		{3018, 1, 0}, {3019, 1, 0}, {3020, 1, 0}, {3021, 1, 0}, {3022, 1, 0}, {3023, 1, 0}, {3024, 1, 0}, {3025, 2, 0},
+		{3027, 1, 0}, {3028, 1, 0}, {3029, 1, 0}, {3030, 1, 0}, {3031, 1, 0}, {3032, 2, 0}, {3034, 1, 0}, {3035, 1, 0},
+		{3036, 2, 0}, {3038, 1, 0}, {3039, 2, 0}, {3041, 1, 0}, {3042, 1, 0}, {3043, 1, 0}, {3044, 1, 0}, {3045, 1, 0},
+		{3046, 1, 0}, {3047, 1, 0}, {3048, 1, 0}, {3049, 1, 386}, {3050, 1, 0}, {3051, 1, 0}, {3052, 1, 0}, {3053, 1, 0},
+		{3054, 2, 0}, {3056, 1, 0}, {3057, 1, 0}, {3058, 1, 0}, {3059, 1, 0}, {3060, 1, 0}, {3061, 1, 0}, {3062, 1, 0},
+		{3063, 1, 0}, {3064, 2, 0}, {3066, 1, 0}, {3067, 3, 0}, {3070, 1, 0}, {3071, 1, 0}, {3072, 1, 0}, {3073, 1, 0},
+		{3074, 1, 0}, {3075, 1, 0}, {3076, 1, 0}, {3077, 2, 0}, {3079, 1, 0}, {3080, 1, 0}, {3081, 1, 0}, {3082, 1, 0},
+		{3083, 0, 1321}, {3083, 1, 0}, {3084, 1, 0}, {3085, 0, 453}, {3085, 1, 0}, {3086, 2, 0}, {3088, 1, 0}, {3089, 2, 0},
+		{3091, 1, 0}, {3092, 3, 0}, {3095, 1, 0}, {3096, 1, 0}, {3097, 1, 0}, {3098, 1, 0}, {3099, 1, 0}, {3100, 1, 0},
+		{3101, 1, 0}, {3102, 1, 0}, {3103, 1, 0}, {3104, 1, 0}, {3105, 1, 0}, {3106, 2, 0}, {3108, 1, 0}, {3109, 2, 0},
+		{3111, 1, 0}, {3112, 1, 1344}, {3113, 1, 0}, {3114, 8, 0}, {3122, 1, 0}, {3123, 2, 0}, {3125, 1, 0}, {3126, 2, 0},
+		{3128, 2, 0}, {3130, 1, 0}, {3131, 2, 0}, {3133, 1, 0}, {3134, 2, 0}, {3136, 2, 0}, {3138, 1, 0}, {3139, 3, 0},
+		{3142, 1, 0}, {3143, 1, 0}, {3144, 1, 0}, {3145, 1, 0}, {3146, 1, 0}, {3147, 1, 0}, {3148, 2, 0}, {3150, 9, 0},
+		{3159, 1, 0}, {3160, 1, 0}, {3161, 1, 0}, {3162, 13, 0}, {3175, 1, 0}, {3176, 1, 0}, {3177, 1, 0}, {3178, 1, 0},
+		{3179, 1, 0}, {3180, 1, 0}, {3181, 1, 0}, {3182, 1, 0}, {3183, 1, 0}, {3184, 1, 0}, {3185, 1, 0}, {3186, 1, 0},
+		{3187, 1, 0}, {3188, 2, 0}, {3190, 2, 0}, {3192, 2, 0}, {3194, 1, 0}, {3195, 1, 0}, {3196, 1, 0}, {3197, 1, 0},
+		{3198, 1, 0}, {3199, 4, 0}, {3203, 1, 0}, {3204, 1, 0}, {3205, 1, 0}, {3206, 7, 0}, {3213, 1, 0}, {3214, 2, 0},
+		{3216, 1, 0}, {3217, 1, 0}, {3218, 1, 0}, {3219, 1, 0}, {3220, 1, 0}, {3221, 1, 0}, {3222, 1, 0}, {3223, 1, 0},
+		{3224, 2, 0}, {3226, 1, 0}, {3227, 1, 0}, {3228, 1, 0}, {3229, 1, 0}, {3230, 1, 0}, {3231, 1, 0}, {3232, 1, 0},
+		{3233, 1, 0}, {3234, 1, 0}, {3235, 1, 0}, {3236, 2, 0}, {3238, 1, 0}, {3239, 1, 0}, {3240, 1, 84}, {3241, 1, 0},
+		{3242, 1, 0}, {3243, 1, 0}, {3244, 1, 0}, {3245, 1, 0}, {3246, 1, 0}, {3247, 1, 0}, {3248, 1, 0}, {3249, 2, 0},
+		{3251, 1, 0}, {3252, 1, 0}, {3253, 1, 0}, {3254, 1, 0}, {3255, 1, 0}, {3256, 1, 0}, {3257, 1, 0}, {3258, 1, 0},
+		{3259, 1, 0}, {3260, 1, 0}, {3261, 1, 0}, {3262, 1, 0}, {3263, 1, 0}, {3264, 7, 0}, {3271, 1, 0}, {3272, 1, 0},
+		{3273, 3, 0}, {3276, 1, 0}, {3277, 2, 0}, {3279, 1, 0}, {3280, 1, 0}, {3281, 1, 0}, {3282, 1, 0}, {3283, 1, 0},
+		{3284, 1, 0}, {3285, 1, 0}, {3286, 2, 0}, {3288, 1, 0}, {3289, 1, 0}, {3290, 2, 0}, {3292, 1, 0}, {3293, 1, 0},
+		{3294, 1, 0}, {3295, 0, 1480}, {3295, 0, 526}, {3295, 1, 0}, {3296, 1, 0}, {3297, 1, 0}, {3298, 1, 0}, {3299, 1, 0},
+		{3300, 1, 0}, {3301, 1, 0}, {3302, 1, 0}, {3303, 1, 0}, {3304, 0, 1485}, {3304, 1, 0}, {3305, 1, 0}, {3306, 1, 0},
+		{3307, 1, 0}, {3308, 1, 0}, {3309, 2, 0}, {3311, 1, 0}, {3312, 1, 0}, {3313, 1, 0}, {3314, 1, 0}, {3315, 1, 0},
+		{3316, 1, 0}, {3317, 1, 0}, {3318, 1, 0}, {3319, 1, 0}, {3320, 2, 0}, {3322, 1, 0}, {3323, 1, 0}, {3324, 1, 1493},
+		{3325, 1, 0}, {3326, 1, 0}, {3327, 1, 0}, {3328, 1, 0}, {3329, 1, 0}, {3330, 1, 0}, {3331, 1, 0}, {3332, 1, 0},
+		{3333, 1, 0}, {3334, 1, 0}, {3335, 1, 0}, {3336, 1, 0}, {3337, 1, 0}, {3338, 1, 0}, {3339, 1, 0}, {3340, 1, 0},
+		{3341, 1, 0}, {3342, 1, 0}, {3343, 1, 0}, {3344, 1, 0}, {3345, 0, 2}, {3345, 1, 0}, {3346, 1, 0}, {3347, 1, 0},
+		{3348, 0, 6}, {3348, 0, 7}, {3348, 1, 0}, {3349, 1, 0}, {3350, 1, 0}, {3351, 0, 11}, {3351, 1, 0}, {3352, 1, 0},
+		{3353, 1, 0}, {3354, 1, 0}, {3355, 1, 0}, {3356, 1, 0}, {3357, 1, 0}, {3358, 1, 19}, {3359, 1, 0}, {3360, 1, 0},
+		{3361, 1, 0}, {3362, 0, 23}, {3362, 1, 0}, {3363, 1, 0}, {3364, 1, 0}, {3365, 0, 27}, {3365, 1, 0}, {3366, 1, 0},
+		{3367, 1, 0}, {3368, 1, 0}, {3369, 1, 0}, {3370, 1, 32}, {3371, 1, 0}, {3372, 0, 34}, {3372, 1, 0}, {3373, 1, 0},
+		{3374, 1, 0}, {3375, 1, 0}, {3376, 1, 0}, {3377, 1, 0}, {3378, 1, 0}, {3379, 1, 0}, {3380, 1, 0}, {3381, 0, 36},
+		{3381, 0, 44}, {3381, 1, 0}, {3382, 1, 0}, {3383, 1, 0}, {3384, 1, 0}, {3385, 1, 0}, {3386, 1, 0}, {3387, 1, 0},
+		{3388, 1, 0}, {3389, 1, 0}, {3390, 1, 0}, {3391, 1, 0}, {3392, 1, 0}, {3393, 0, 62}, {3393, 1, 0}, {3394, 1, 0},
+		{3395, 1, 0}, {3396, 1, 0}, {3397, 1, 0}, {3398, 1, 0}, {3399, 1, 0}, {3400, 1, 0}, {3401, 1, 0}, {3402, 0, 72},
+		{3402, 0, 73}, {3402, 1, 0}, {3403, 0, 75}, {3403, 1, 0}, {3404, 1, 0}, {3405, 1, 0}, {3406, 1, 0}, {3407, 0, 84},
+		{3407, 1, 0}, {3408, 1, 0}, {3409, 1, 0}, {3410, 6, 0}, {3416, 1, 0}, {3417, 1, 0}, {3418, 0, 113}, {3418, 0, 114},
+		{3418, 1, 0}, {3419, 1, 0}, {3420, 1, 0}, {3421, 0, 118}, {3421, 1, 0}, {3422, 0, 120}, {3422, 1, 0}, {3423, 1, 0},
+		{3424, 1, 0}, {3425, 1, 0}, {3426, 1, 0}, {3427, 1, 0}, {3428, 1, 0}, {3429, 1, 0}, {3430, 1, 0}, {3431, 1, 0},
+		{3432, 1, 0}, {3433, 0, 134}, {3433, 1, 135}, {3434, 1, 0}, {3435, 1, 0}, {3436, 0, 138}, {3436, 0, 139}, {3436, 1, 0},
+		{3437, 1, 0}, {3438, 1, 0}, {3439, 1, 0}, {3440, 1, 0}, {3441, 1, 0}, {3442, 1, 0}, {3443, 1, 0}, {3444, 1, 0},
+		{3445, 1, 0}, {3446, 0, 152}, {3446, 1, 0}, {3447, 0, 154}, {3447, 1, 0}, {3448, 1, 0}, {3449, 1, 0}, {3450, 1, 0},
+		{3451, 1, 0}, {3452, 0, 168}, {3452, 1, 0}, {3453, 0, 170}, {3453, 1, 0}, {3454, 1, 0}, {3455, 1, 0}, {3456, 1, 0},
+		{3457, 1, 0}, {3458, 2, 0}, {3460, 1, 0}, {3461, 1, 0}, {3462, 1, 0}, {3463, 1, 0}, {3464, 1, 0}, {3465, 0, 181},
+		{3465, 1, 0}, {3466, 0, 183}, {3466, 1, 0}, {3467, 1, 0}, {3468, 1, 0}, {3469, 1, 0}, {3470, 0, 187}, {3470, 2, 0},
+		{3472, 1, 0}, {3473, 1, 0}, {3474, 1, 0}, {3475, 1, 0}, {3476, 1, 0}, {3477, 1, 0}, {3478, 1, 0}, {3479, 1, 198},
+		{3480, 1, 0}, {3481, 0, 200}, {3481, 0, 201}, {3481, 1, 0}, {3482, 1, 0}, {3483, 1, 0}, {3484, 1, 0}, {3485, 1, 0},
+		{3486, 1, 0}, {3487, 1, 0}, {3488, 1, 0}, {3489, 0, 210}, {3489, 0, 211}, {3489, 1, 0}, {3490, 1, 0}, {3491, 1, 0},
+		{3492, 1, 0}, {3493, 1, 0}, {3494, 1, 0}, {3495, 1, 0}, {3496, 1, 0}, {3497, 1, 0}, {3498, 1, 0}, {3499, 0, 223},
+		{3499, 10, 0}, {3509, 6, 0}, {3515, 0, 254}, {3515, 1, 0}, {3516, 1, 0}, {3517, 4, 0}, {3521, 1, 0}, {3522, 1, 0},
+		{3523, 1, 0}, {3524, 0, 264}, {3524, 1, 0}, {3525, 0, 267}, {3525, 0, 268}, {3525, 1, 0}, {3526, 1, 0}, {3527, 0, 271},
+		{3527, 1, 0}, {3528, 1, 0}, {3529, 1, 0}, {3530, 1, 0}, {3531, 1, 0}, {3532, 1, 0}, {3533, 1, 0}, {3534, 0, 279},
+		{3534, 1, 0}, {3535, 1, 0}, {3536, 1, 0}, {3537, 0, 282}, {3537, 1, 0}, {3538, 1, 0}, {3539, 1, 0}, {3540, 0, 286},
+		{3540, 2, 0}, {3542, 1, 0}, {3543, 3, 0}, {3546, 1, 0}, {3547, 1, 0}, {3548, 1, 0}, {3549, 1, 0}, {3550, 1, 0},
+		{3551, 2, 0}, {3553, 2, 0}, {3555, 1, 0}, {3556, 1, 0}, {3557, 1, 0}, {3558, 1, 0}, {3559, 1, 0}, {3560, 1, 0},
+		{3561, 1, 0}, {3562, 0, 344}, {3562, 1, 0}, {3563, 0, 346}, {3563, 1, 0}, {3564, 1, 0}, {3565, 1, 0}, {3566, 1, 0},
+		{3567, 1, 0}, {3568, 1, 0}, {3569, 1, 0}, {3570, 1, 0}, {3571, 1, 0}, {3572, 1, 0}, {3573, 1, 359}, {3574, 2, 0},
+		{3576, 1, 0}, {3577, 0, 365}, {3577, 0, 366}, {3577, 0, 367}, {3577, 1, 0}, {3578, 1, 0}, {3579, 1, 0}, {3580, 1, 0},
+		{3581, 1, 0}, {3582, 1, 0}, {3583, 1, 0}, {3584, 1, 0}, {3585, 0, 380}, {3585, 1, 381}, {3586, 0, 382}, {3586, 1, 0},
+		{3587, 1, 0}, {3588, 1, 0}, {3589, 0, 386}, {3589, 1, 0}, {3590, 1, 0}, {3591, 2, 0}, {3593, 1, 0}, {3594, 1, 0},
+		{3595, 0, 393}, {3595, 1, 0}, {3596, 0, 394}, {3596, 0, 398}, {3596, 1, 0}, {3597, 1, 0}, {3598, 1, 0}, {3599, 1, 0},
+		{3600, 1, 0}, {3601, 0, 425}, {3601, 1, 0}, {3602, 1, 0}, {3603, 1, 0}, {3604, 1, 0}, {3605, 1, 0}, {3606, 1, 0},
+		{3607, 1, 0}, {3608, 1, 0}, {3609, 0, 435}, {3609, 0, 436}, {3609, 1, 0}, {3610, 1, 0}, {3611, 1, 0}, {3612, 1, 0},
+		{3613, 1, 0}, {3614, 1, 0}, {3615, 1, 0}, {3616, 1, 0}, {3617, 0, 451}, {3617, 1, 0}, {3618, 1, 0}, {3619, 1, 0},
+		{3620, 0, 457}, {3620, 0, 458}, {3620, 1, 0}, {3621, 1, 0}, {3622, 1, 0}, {3623, 1, 0}, {3624, 1, 0}, {3625, 1, 0},
+		{3626, 0, 465}, {3626, 0, 466}, {3626, 1, 0}, {3627, 1, 0}, {3628, 0, 469}, {3628, 0, 470}, {3628, 1, 0}, {3629, 1, 0},
+		{3630, 1, 0}, {3631, 1, 0}, {3632, 1, 0}, {3633, 1, 0}, {3634, 1, 0}, {3635, 1, 0}, {3636, 1, 0}, {3637, 1, 0},
+		{3638, 2, 0}, {3640, 1, 0}, {3641, 1, 0}, {3642, 1, 0}, {3643, 0, 489}, {3643, 1, 0}, {3644, 0, 491}, {3644, 1, 0},
+		{3645, 1, 0}, {3646, 1, 0}, {3647, 1, 0}, {3648, 1, 0}, {3649, 1, 0}, {3650, 1, 0}, {3651, 1, 0}, {3652, 1, 0},
+		{3653, 1, 0}, {3654, 1, 0}, {3655, 1, 0}, {3656, 1, 0}, {3657, 2, 0}, {3659, 1, 0}, {3660, 1, 0}, {3661, 1, 0},
+		{3662, 1, 515}, {3663, 1, 0}, {3664, 1, 0}, {3665, 0, 518}, {3665, 1, 0}, {3666, 0, 521}, {3666, 1, 0}, {3667, 2, 0},
+		{3669, 1, 0}, {3670, 0, 528}, {3670, 1, 0}, {3671, 1, 0}, {3672, 1, 0}, {3673, 1, 0}, {3674, 1, 0}, {3675, 0, 534},
+		{3675, 1, 0}, {3676, 1, 0}, {3677, 0, 537}, {3677, 1, 0}, {3678, 1, 0}, {3679, 1, 0}, {3680, 1, 0}, {3681, 1, 0},
+		{3682, 1, 0}, {3683, 1, 0}, {3684, 0, 546}, {3684, 0, 547}, {3684, 1, 0}, {3685, 1, 0}, {3686, 1, 0}, {3687, 1, 0},
+		{3688, 1, 0}, {3689, 1, 0}, {3690, 0, 554}, {3690, 1, 0}, {3691, 1, 0}, {3692, 1, 0}, {3693, 0, 557}, {3693, 1, 0},
+		{3694, 1, 0}, {3695, 1, 0}, {3696, 1, 0}, {3697, 0, 563}, {3697, 0, 564}, {3697, 1, 0}, {3698, 1, 0}, {3699, 0, 566},
+		{3699, 1, 0}, {3700, 0, 568}, {3700, 1, 0}, {3701, 1, 0}, {3702, 1, 0}, {3703, 1, 0}, {3704, 1, 0}, {3705, 1, 0},
+		{3706, 0, 2}, {3706, 0, 574}, {3706, 1, 0}, {3707, 1, 0}, {3708, 1, 0}, {3709, 1, 0}, {3710, 0, 579}, {3710, 1, 0},
+		{3711, 1, 0}, {3712, 1, 0}, {3713, 1, 0}, {3714, 2, 0}, {3716, 1, 0}, {3717, 1, 0}, {3718, 1, 0}, {3719, 0, 597},
+		{3719, 1, 0}, {3720, 0, 599}, {3720, 1, 0}, {3721, 1, 0}, {3722, 1, 0}, {3723, 1, 0}, {3724, 1, 0}, {3725, 0, 604},
+		{3725, 1, 0}, {3726, 1, 0}, {3727, 1, 606}, {3728, 1, 0}, {3729, 1, 0}, {3730, 1, 0}, {3731, 4, 0}, {3735, 1, 0},
+		{3736, 1, 0}, {3737, 2, 0}, {3739, 1, 0}, {3740, 0, 617}, {3740, 1, 0}, {3741, 1, 0}, {3742, 1, 0}, {3743, 1, 0},
+		{3744, 1, 0}, {3745, 1, 0}, {3746, 1, 0}, {3747, 1, 0}, {3748, 0, 623}, {3748, 3, 0}, {3751, 3, 0}, {3754, 2, 0},
+		{3756, 1, 0}, {3757, 1, 0}, {3758, 1, 0}, {3759, 1, 0}, {3760, 1, 0}, {3761, 1, 0}, {3762, 1, 0}, {3763, 2, 0},
+		{3765, 1, 0}, {3766, 1, 0}, {3767, 0, 644}, {3767, 1, 0}, {3768, 1, 0}, {3769, 1, 0}, {3770, 0, 506}, {3770, 1, 0},
+		{3771, 1, 0}, {3772, 4, 0}, {3776, 5, 0}, {3781, 4, 0}, {3785, 7, 0}, {3792, 1, 0}, {3793, 4, 0}, {3797, 5, 0},
+		{3802, 1, 0}, {3803, 1, 0}, {3804, 1, 0}, {3805, 4, 0}, {3809, 7, 0}, {3816, 1, 0}, {3817, 1, 0}, {3818, 1, 0},
+		{3819, 1, 0}, {3820, 1, 0}, {3821, 2, 0}, {3823, 3, 0}, {3826, 2, 0}, {3828, 3, 0}, {3831, 1, 0}, {3832, 0, 701},
+		{3832, 1, 0}, {3833, 1, 0}, {3834, 2, 0}, {3836, 1, 0}, {3837, 1, 0}, {3838, 1, 0}, {3839, 1, 0}, {3840, 1, 0},
+		{3841, 1, 0}, {3842, 1, 0}, {3843, 1, 0}, {3844, 1, 0}, {3845, 1, 0}, {3846, 1, 0}, {3847, 1, 0}, {3848, 2, 717},
+		{3850, 0, 718}, {3850, 1, 0}, {3851, 1, 0}, {3852, 0, 721}, {3852, 0, 722}, {3852, 1, 0}, {3853, 3, 0}, {3856, 1, 0},
+		{3857, 1, 0}, {3858, 1, 0}, {3859, 1, 0}, {3860, 1, 0}, {3861, 1, 0}, {3862, 1, 0}, {3863, 3, 0}, {3866, 2, 0},
+		{3868, 1, 0}, {3869, 1, 0}, {3870, 1, 0}, {3871, 2, 32}, {3873, 1, 0}, {3874, 1, 0}, {3875, 1, 0}, {3876, 2, 0},
+		{3878, 2, 0}, {3880, 1, 0}, {3881, 1, 0}, {3882, 1, 0}, {3883, 1, 0}, {3884, 1, 0}, {3885, 0, 757}, {3885, 1, 0},
+		{3886, 2, 0}, {3888, 1, 0}, {3889, 1, 0}, {3890, 1, 0}, {3891, 1, 0}, {3892, 1, 0}, {3893, 1, 0}, {3894, 1, 0},
+		{3895, 1, 0}, {3896, 1, 0}, {3897, 1, 0}, {3898, 1, 0}, {3899, 1, 0}, {3900, 1, 0}, {3901, 1, 0}, {3902, 1, 0},
+		{3903, 1, 0}, {3904, 1, 0}, {3905, 2, 0}, {3907, 1, 0}, {3908, 1, 0}, {3909, 1, 0}, {3910, 0, 777}, {3910, 1, 0},
+		{3911, 1, 0}, {3912, 1, 0}, {3913, 0, 780}, {3913, 1, 0}, {3914, 1, 0}, {3915, 1, 0}, {3916, 0, 784}, {3916, 2, 0},
+		{3918, 3, 0}, {3921, 0, 84}, {3921, 1, 0}, {3922, 1, 0}, {3923, 0, 788}, {3923, 1, 0}, {3924, 1, 0}, {3925, 1, 0},
+		{3926, 1, 0}, {3927, 1, 0}, {3928, 1, 0}, {3929, 1, 0}, {3930, 0, 77}, {3930, 1, 0}, {3931, 1, 0}, {3932, 1, 0},
+		{3933, 1, 0}, {3934, 1, 0}, {3935, 3, 0}, {3938, 1, 0}, {3939, 1, 0}, {3940, 1, 0}, {3941, 1, 0}, {3942, 1, 0},
+		{3943, 1, 0}, {3944, 1, 0}, {3945, 1, 0}, {3946, 2, 0}, {3948, 1, 0}, {3949, 1, 0}, {3950, 1, 0}, {3951, 1, 0},
+		{3952, 1, 0}, {3953, 1, 0}, {3954, 1, 0}, {3955, 1, 0}, {3956, 1, 0}, {3957, 1, 0}, {3958, 2, 0}, {3960, 1, 0},
+		{3961, 0, 816}, {3961, 1, 0}, {3962, 1, 0}, {3963, 0, 819}, {3963, 1, 0}, {3964, 0, 822}, {3964, 1, 0}, {3965, 1, 0},
+		{3966, 0, 826}, {3966, 0, 827}, {3966, 1, 0}, {3967, 1, 0}, {3968, 1, 0}, {3969, 2, 0}, {3971, 0, 834}, {3971, 1, 0},
+		{3972, 1, 0}, {3973, 1, 0}, {3974, 2, 0}, {3976, 1, 0}, {3977, 3, 0}, {3980, 1, 0}, {3981, 1, 0}, {3982, 1, 0},
+		{3983, 1, 0}, {3984, 1, 0}, {3985, 1, 0}, {3986, 1, 0}, {3987, 1, 0}, {3988, 1, 0}, {3989, 1, 0}, {3990, 1, 0},
+		{3991, 1, 0}, {3992, 1, 0}, {3993, 0, 850}, {3993, 0, 851}, {3993, 1, 852}, {3994, 1, 0}, {3995, 1, 0}, {3996, 1, 0},
+		{3997, 1, 0}, {3998, 1, 0}, {3999, 1, 0}, {4000, 0, 855}, {4000, 1, 0}, {4001, 1, 0}, {4002, 1, 0}, {4003, 1, 0},
+		{4004, 0, 860}, {4004, 1, 0}, {4005, 1, 0}, {4006, 1, 0}, {4007, 1, 0}, {4008, 1, 0}, {4009, 1, 0}, {4010, 1, 0},
+		{4011, 1, 0}, {4012, 2, 0}, {4014, 1, 0}, {4015, 6, 0}, {4021, 1, 0}, {4022, 1, 0}, {4023, 1, 0}, {4024, 0, 889},
+		{4024, 1, 0}, {4025, 1, 0}, {4026, 0, 892}, {4026, 1, 0}, {4027, 1, 0}, {4028, 0, 895}, {4028, 1, 0}, {4029, 0, 158},
+		{4029, 0, 157}, {4029, 1, 0}, {4030, 1, 0}, {4031, 0, 162}, {4031, 1, 0}, {4032, 1, 0}, {4033, 2, 0}, {4035, 0, 903},
+		{4035, 0, 155}, {4035, 1, 0}, {4036, 1, 0}, {4037, 0, 906}, {4037, 0, 907}, {4037, 0, 908}, {4037, 0, 909}, {4037, 2, 0},
+		{4039, 0, 911}, {4039, 2, 0}, {4041, 1, 0}, {4042, 1, 0}, {4043, 1, 0}, {4044, 1, 0}, {4045, 3, 0}, {4048, 1, 0},
+		{4049, 1, 0}, {4050, 1, 0}, {4051, 1, 0}, {4052, 1, 0}, {4053, 2, 0}, {4055, 1, 0}, {4056, 1, 0}, {4057, 1, 0},
+		{4058, 1, 0}, {4059, 1, 0}, {4060, 1, 0}, {4061, 1, 0}, {4062, 1, 0}, {4063, 1, 0}, {4064, 1, 0}, {4065, 1, 0},
+		{4066, 3, 0}, {4069, 1, 0}, {4070, 1, 0}, {4071, 1, 0}, {4072, 1, 0}, {4073, 1, 0}, {4074, 0, 932}, {4074, 1, 0},
+		{4075, 1, 0}, {4076, 1, 0}, {4077, 1, 0}, {4078, 2, 0}, {4080, 1, 0}, {4081, 1, 0}, {4082, 1, 0}, {4083, 1, 0},
+		{4084, 1, 0}, {4085, 1, 0}, {4086, 1, 0}, {4087, 1, 0}, {4088, 1, 0}, {4089, 0, 946}, {4089, 1, 0}, {4090, 1, 0},
+		{4091, 0, 89}, {4091, 0, 949}, {4091, 1, 0}, {4092, 1, 0}, {4093, 1, 0}, {4094, 1, 0}, {4095, 1, 0}, {4096, 1, 0},
+		{4097, 1, 0}, {4098, 3, 0}, {4101, 1, 0}, {4102, 1, 0}, {4103, 1, 0}, {4104, 1, 0}, {4105, 1, 0}, {4106, 1, 0},
+		{4107, 0, 188}, {4107, 1, 0}, {4108, 2, 0}, {4110, 1, 0}, {4111, 1, 0}, {4112, 1, 0}, {4113, 1, 0}, {4114, 1, 0},
+		{4115, 1, 0}, {4116, 1, 0}, {4117, 1, 0}, {4118, 1, 0}, {4119, 5, 0}, {4124, 1, 0}, {4125, 1, 0}, {4126, 1, 978},
+		{4127, 1, 0}, {4128, 0, 980}, {4128, 0, 981}, {4128, 1, 0}, {4129, 1, 0}, {4130, 1, 0}, {4131, 1, 0}, {4132, 1, 0},
+		{4133, 1, 0}, {4134, 1, 0}, {4135, 0, 990}, {4135, 0, 991}, {4135, 1, 0}, {4136, 1, 0}, {4137, 1, 0}, {4138, 1, 0},
+		{4139, 1, 0}, {4140, 1, 0}, {4141, 1, 0}, {4142, 1, 0}, {4143, 1, 0}, {4144, 0, 999}, {4144, 1, 0}, {4145, 1, 0},
+		{4146, 1, 0}, {4147, 1, 0}, {4148, 1, 0}, {4149, 3, 0}, {4152, 0, 1005}, {4152, 1, 0}, {4153, 8, 0}, {4161, 0, 1013},
+		{4161, 1, 0}, {4162, 2, 0}, {4164, 1, 0}, {4165, 1, 0}, {4166, 1, 0}, {4167, 2, 0}, {4169, 1, 0}, {4170, 1, 0},
+		{4171, 1, 0}, {4172, 1, 0}, {4173, 0, 1026}, {4173, 1, 0}, {4174, 1, 0}, {4175, 1, 0}, {4176, 1, 0}, {4177, 1, 0},
+		{4178, 5, 0}, {4183, 0, 248}, {4183, 0, 1031}, {4183, 1, 0}, {4184, 1, 0}, {4185, 0, 252}, {4185, 1, 0}, {4186, 1, 0},
+		{4187, 2, 0}, {4189, 5, 0}, {4194, 1, 0}, {4195, 1, 0}, {4196, 0, 1042}, {4196, 0, 1043}, {4196, 2, 0}, {4198, 1, 0},
+		{4199, 1, 0}, {4200, 1, 0}, {4201, 1, 0}, {4202, 1, 0}, {4203, 1, 0}, {4204, 1, 0}, {4205, 1, 0}, {4206, 0, 1051},
+		{4206, 2, 0}, {4208, 0, 1053}, {4208, 2, 0}, {4210, 1, 0}, {4211, 1, 0}, {4212, 1, 0}, {4213, 1, 0}, {4214, 3, 0},
+		{4217, 1, 0}, {4218, 1, 0}, {4219, 1, 0}, {4220, 1, 0}, {4221, 1, 0}, {4222, 1, 0}, {4223, 1, 0}, {4224, 0, 1064},
+		{4224, 1, 0}, {4225, 1, 0}, {4226, 2, 0}, {4228, 1, 0}, {4229, 1, 0}, {4230, 1, 0}, {4231, 0, 1068}, {4231, 1, 0},
+		{4232, 1, 0}, {4233, 1, 0}, {4234, 0, 264}, {4234, 3, 0}, {4237, 1, 0}, {4238, 1, 0}, {4239, 1, 0}, {4240, 1, 0},
+		{4241, 1, 0}, {4242, 1, 0}, {4243, 1, 0}, {4244, 1, 0}, {4245, 1, 0}, {4246, 1, 0}, {4247, 1, 0}, {4248, 3, 0},
+		{4251, 1, 0}, {4252, 1, 0}, {4253, 1, 0}, {4254, 1, 0}, {4255, 1, 0}, {4256, 1, 1087}, {4257, 1, 0}, {4258, 2, 0},
+		{4260, 0, 410}, {4260, 1, 0}, {4261, 1, 0}, {4262, 1, 0}, {4263, 0, 1092}, {4263, 1, 0}, {4264, 1, 0}, {4265, 0, 1094},
+		{4265, 0, 1095}, {4265, 1, 0}, {4266, 0, 523}, {4266, 1, 0}, {4267, 1, 0}, {4268, 1, 0}, {4269, 1, 0}, {4270, 1, 0},
+		{4271, 1, 0}, {4272, 1, 0}, {4273, 1, 0}, {4274, 1, 0}, {4275, 1, 0}, {4276, 1, 0}, {4277, 1, 0}, {4278, 1, 0},
+		{4279, 0, 1106}, {4279, 0, 1107}, {4279, 1, 0}, {4280, 1, 0}, {4281, 0, 1110}, {4281, 0, 1111}, {4281, 1, 0}, {4282, 1, 0},
+		{4283, 1, 0}, {4284, 1, 0}, {4285, 1, 0}, {4286, 1, 0}, {4287, 1, 0}, {4288, 0, 336}, {4288, 1, 0}, {4289, 1, 0},
+		{4290, 1, 0}, {4291, 1, 0}, {4292, 1, 0}, {4293, 1, 284}, {4294, 1, 0}, {4295, 1, 0}, {4296, 1, 0}, {4297, 1, 0},
+		{4298, 1, 0}, {4299, 1, 0}, {4300, 0, 1126}, {4300, 1, 0}, {4301, 1, 0}, {4302, 2, 0}, {4304, 1, 0}, {4305, 1, 0},
+		{4306, 1, 0}, {4307, 1, 0}, {4308, 1, 0}, {4309, 0, 1132}, {4309, 0, 296}, {4309, 0, 295}, {4309, 3, 0}, {4312, 1, 0},
+		{4313, 1, 0}, {4314, 0, 294}, {4314, 1, 0}, {4315, 1, 0}, {4316, 1, 0}, {4317, 1, 0}, {4318, 0, 1135}, {4318, 1, 0},
+		{4319, 0, 395}, {4319, 1, 0}, {4320, 1, 0}, {4321, 0, 1138}, {4321, 1, 0}, {4322, 1, 0}, {4323, 0, 307}, {4323, 1, 0},
+		{4324, 3, 0}, {4327, 2, 0}, {4329, 1, 0}, {4330, 0, 306}, {4330, 1, 0}, {4331, 1, 0}, {4332, 1, 0}, {4333, 0, 1142},
+		{4333, 1, 0}, {4334, 1, 0}, {4335, 4, 0}, {4339, 1, 0}, {4340, 0, 314}, {4340, 1, 0}, {4341, 2, 0}, {4343, 1, 0},
+		{4344, 1, 0}, {4345, 1, 0}, {4346, 1, 0}, {4347, 0, 327}, {4347, 1, 0}, {4348, 1, 0}, {4349, 1, 0}, {4350, 1, 0},
+		{4351, 2, 0}, {4353, 1, 0}, {4354, 1, 0}, {4355, 1, 0}, {4356, 4, 0}, {4360, 1, 0}, {4361, 4, 0}, {4365, 1, 0},
+		{4366, 1, 0}, {4367, 1, 0}, {4368, 1, 0}, {4369, 0, 1162}, {4369, 1, 0}, {4370, 1, 0}, {4371, 1, 0}, {4372, 1, 0},
+		{4373, 1, 0}, {4374, 1, 0}, {4375, 1, 0}, {4376, 1, 0}, {4377, 1, 0}, {4378, 1, 0}, {4379, 1, 0}, {4380, 2, 0},
+		{4382, 1, 0}, {4383, 1, 0}, {4384, 1, 0}, {4385, 1, 0}, {4386, 2, 0}, {4388, 1, 0}, {4389, 1, 0}, {4390, 1, 0},
+		{4391, 2, 0}, {4393, 0, 1184}, {4393, 1, 0}, {4394, 1, 0}, {4395, 1, 0}, {4396, 1, 0}, {4397, 1, 0}, {4398, 1, 0},
+		{4399, 1, 0}, {4400, 0, 1190}, {4400, 1, 0}, {4401, 1, 0}, {4402, 0, 1193}, {4402, 1, 0}, {4403, 0, 349}, {4403, 1, 0},
+		{4404, 1, 0}, {4405, 1, 0}, {4406, 1, 0}, {4407, 1, 0}, {4408, 0, 1197}, {4408, 1, 0}, {4409, 1, 0}, {4410, 1, 0},
+		{4411, 1, 0}, {4412, 1, 0}, {4413, 1, 0}, {4414, 1, 0}, {4415, 1, 0}, {4416, 1, 0}, {4417, 1, 0}, {4418, 0, 1206},
+		{4418, 1, 0}, {4419, 1, 1208}, {4420, 1, 1209}, {4421, 1, 0}, {4422, 1, 0}, {4423, 1, 0}, {4424, 0, 1213}, {4424, 1, 0},
+		{4425, 1, 0}, {4426, 1, 0}, {4427, 1, 0}, {4428, 1, 0}, {4429, 1, 1218}, {4430, 1, 0}, {4431, 0, 97}, {4431, 2, 1220},
+		{4433, 2, 0}, {4435, 1, 0}, {4436, 0, 1223}, {4436, 1, 0}, {4437, 1, 0}, {4438, 1, 0}, {4439, 1, 0}, {4440, 1, 0},
+		{4441, 0, 1228}, {4441, 0, 1229}, {4441, 1, 0}, {4442, 1, 0}, {4443, 1, 0}, {4444, 1, 0}, {4445, 0, 1233}, {4445, 2, 0},
+		{4447, 9, 0}, {4456, 1, 0}, {4457, 1, 0}, {4458, 1, 0}, {4459, 0, 1245}, {4459, 1, 0}, {4460, 1, 0}, {4461, 0, 373},
+		{4461, 6, 0}, {4467, 1, 0}, {4468, 1, 0}, {4469, 1, 0}, {4470, 1, 0}, {4471, 1, 0}, {4472, 3, 0}, {4475, 2, 0},
+		{4477, 1, 0}, {4478, 1, 0}, {4479, 1, 0}, {4480, 0, 1256}, {4480, 1, 0}, {4481, 0, 1258}, {4481, 1, 0}, {4482, 1, 0},
+		{4483, 1, 0}, {4484, 1, 0}, {4485, 2, 0}, {4487, 1, 0}, {4488, 1, 381}, {4489, 1, 0}, {4490, 1, 0}, {4491, 1, 0},
+		{4492, 1, 0}, {4493, 1, 0}, {4494, 1, 0}, {4495, 1, 0}, {4496, 1, 0}, {4497, 1, 0}, {4498, 4, 0}, {4502, 1, 0},
+		{4503, 11, 0}, {4514, 1, 0}, {4515, 1, 0}, {4516, 1, 0}, {4517, 1, 0}, {4518, 1, 0}, {4519, 2, 0}, {4521, 1, 0},
+		{4522, 1, 0}, {4523, 1, 0}, {4524, 1, 0}, {4525, 0, 1290}, {4525, 1, 0}, {4526, 1, 0}, {4527, 1, 0}, {4528, 1, 0},
+		{4529, 4, 0}, {4533, 1, 0}, {4534, 0, 386}, {4534, 1, 0}, {4535, 1, 0}, {4536, 0, 1296}, {4536, 2, 0}, {4538, 0, 1298},
+		{4538, 1, 0}, {4539, 1, 0}, {4540, 1, 0}, {4541, 1, 0}, {4542, 1, 0}, {4543, 1, 0}, {4544, 0, 1303}, {4544, 1, 0},
+		{4545, 1, 0}, {4546, 1, 0}, {4547, 1, 0}, {4548, 1, 0}, {4549, 1, 0}, {4550, 1, 0}, {4551, 1, 0}, {4552, 1, 0},
+		{4553, 2, 0}, {4555, 1, 0}, {4556, 1, 0}, {4557, 1, 0}, {4558, 1, 0}, {4559, 0, 425}, {4559, 1, 0}, {4560, 1, 0},
+		{4561, 1, 0}, {4562, 1, 0}, {4563, 4, 0}, {4567, 1, 0}, {4568, 1, 0}, {4569, 1, 0}, {4570, 0, 1323}, {4570, 1, 0},
+		{4571, 1, 0}, {4572, 1, 0}, {4573, 0, 454}, {4573, 1, 0}, {4574, 1, 0}, {4575, 1, 0}, {4576, 1, 0}, {4577, 1, 0},
+		{4578, 1, 0}, {4579, 1, 0}, {4580, 0, 1332}, {4580, 3, 0}, {4583, 1, 0}, {4584, 2, 0}, {4586, 1, 1336}, {4587, 1, 0},
+		{4588, 1, 0}, {4589, 2, 0}, {4591, 1, 0}, {4592, 0, 1340}, {4592, 1, 0}, {4593, 1, 0}, {4594, 1, 0}, {4595, 1, 0},
+		{4596, 1, 0}, {4597, 0, 1344}, {4597, 1, 0}, {4598, 0, 475}, {4598, 1, 0}, {4599, 2, 0}, {4601, 2, 0}, {4603, 2, 0},
+		{4605, 1, 0}, {4606, 1, 0}, {4607, 1, 0}, {4608, 1, 0}, {4609, 1, 0}, {4610, 1, 0}, {4611, 1, 0}, {4612, 1, 0},
+		{4613, 1, 0}, {4614, 0, 1358}, {4614, 2, 0}, {4616, 1, 0}, {4617, 0, 1362}, {4617, 2, 0}, {4619, 1, 0}, {4620, 1, 0},
+		{4621, 1, 0}, {4622, 1, 0}, {4623, 1, 0}, {4624, 2, 0}, {4626, 0, 442}, {4626, 1, 0}, {4627, 1, 0}, {4628, 1, 0},
+		{4629, 1, 0}, {4630, 1, 0}, {4631, 1, 0}, {4632, 1, 0}, {4633, 2, 0}, {4635, 1, 0}, {4636, 1, 0}, {4637, 0, 1371},
+		{4637, 1, 0}, {4638, 1, 0}, {4639, 2, 0}, {4641, 1, 0}, {4642, 2, 0}, {4644, 1, 0}, {4645, 1, 0}, {4646, 3, 0},
+		{4649, 6, 0}, {4655, 0, 457}, {4655, 1, 0}, {4656, 1, 1384}, {4657, 1, 1385}, {4658, 1, 1386}, {4659, 0, 459}, {4659, 1, 0},
+		{4660, 2, 0}, {4662, 2, 0}, {4664, 1, 0}, {4665, 1, 0}, {4666, 1, 0}, {4667, 2, 0}, {4669, 1, 0}, {4670, 3, 0},
+		{4673, 1, 0}, {4674, 2, 0}, {4676, 1, 0}, {4677, 1, 0}, {4678, 1, 0}, {4679, 0, 1405}, {4679, 1, 0}, {4680, 1, 0},
+		{4681, 1, 0}, {4682, 0, 1408}, {4682, 1, 0}, {4683, 1, 0}, {4684, 0, 1410}, {4684, 1, 0}, {4685, 1, 0}, {4686, 1, 0},
+		{4687, 1, 0}, {4688, 1, 0}, {4689, 1, 0}, {4690, 1, 0}, {4691, 1, 0}, {4692, 1, 0}, {4693, 1, 0}, {4694, 1, 0},
+		{4695, 0, 109}, {4695, 1, 0}, {4696, 1, 0}, {4697, 2, 0}, {4699, 1, 0}, {4700, 1, 0}, {4701, 1, 0}, {4702, 1, 0},
+		{4703, 1, 0}, {4704, 1, 0}, {4705, 1, 0}, {4706, 1, 0}, {4707, 1, 0}, {4708, 1, 0}, {4709, 1, 0}, {4710, 1, 0},
+		{4711, 1, 0}, {4712, 1, 0}, {4713, 1, 0}, {4714, 1, 0}, {4715, 1, 0}, {4716, 1, 0}, {4717, 1, 0}, {4718, 1, 0},
+		{4719, 1, 0}, {4720, 1, 0}, {4721, 1, 0}, {4722, 1, 0}, {4723, 0, 1439}, {4723, 1, 0}, {4724, 1, 0}, {4725, 1, 0},
+		{4726, 1, 0}, {4727, 0, 1442}, {4727, 1, 0}, {4728, 2, 0}, {4730, 1, 0}, {4731, 1, 0}, {4732, 1, 0}, {4733, 1, 0},
+		{4734, 1, 0}, {4735, 0, 84}, {4735, 1, 0}, {4736, 1, 0}, {4737, 1, 0}, {4738, 1, 0}, {4739, 1, 0}, {4740, 1, 0},
+		{4741, 3, 0}, {4744, 1, 0}, {4745, 1, 0}, {4746, 1, 0}, {4747, 1, 0}, {4748, 1, 0}, {4749, 1, 0}, {4750, 1, 0},
+		{4751, 1, 0}, {4752, 2, 0}, {4754, 1, 0}, {4755, 1, 1460}, {4756, 1, 0}, {4757, 1, 0}, {4758, 2, 0}, {4760, 1, 0},
+		{4761, 1, 0}, {4762, 1, 0}, {4763, 1, 0}, {4764, 1, 0}, {4765, 3, 0}, {4768, 2, 0}, {4770, 2, 0}, {4772, 2, 0},
+		{4774, 0, 1469}, {4774, 1, 0}, {4775, 0, 1205}, {4775, 1, 0}, {4776, 1, 0}, {4777, 1, 0}, {4778, 1, 0}, {4779, 1, 0},
+		{4780, 0, 1473}, {4780, 1, 0}, {4781, 2, 0}, {4783, 1, 0}, {4784, 1, 0}, {4785, 1, 0}, {4786, 1, 0}, {4787, 1, 0},
+		{4788, 1, 0}, {4789, 1, 0}, {4790, 1, 0}, {4791, 1, 0}, {4792, 1, 0}, {4793, 1, 0}, {4794, 0, 1481}, {4794, 1, 0},
+		{4795, 1, 0}, {4796, 1, 0}, {4797, 1, 0}, {4798, 1, 0}, {4799, 1, 0}, {4800, 1, 0}, {4801, 0, 1484}, {4801, 1, 0},
+		{4802, 1, 0}, {4803, 1, 0}, {4804, 1, 0}, {4805, 1, 0}, {4806, 1, 0}, {4807, 1, 0}, {4808, 1, 0}, {4809, 1, 0},
+		{4810, 1, 0}, {4811, 1, 0}, {4812, 1, 0}, {4813, 1, 0}, {4814, 1, 0}, {4815, 1, 0}, {4816, 1, 0}, {4817, 1, 0},
+		{4818, 1, 0}, {4819, 1, 0}, {4820, 1, 0}, {4821, 1, 0}, {4822, 0, 1492}, {4822, 0, 1493}, {4822, 0, 1494}, {4822, 1, 0},
+		{4823, 1, 0}, {4824, 1, 0}, {4825, 1, 0}, {4826, 1, 1499}, {4827, 1, 0}, {4828, 1, 0}, {4829, 0, 1502}, {4829, 1, 0},
+		{4830, 1, 0}, {4831, 1, 0}, {4832, 0, 1505}, {4832, 1, 0}, {4833, 1, 0}, {4834, 1, 0}, {4835, 1, 0}, {4836, 0, 1510},
+		{4836, 1, 0}, {4837, 1, 1}, {4838, 1, 0}, {4839, 1, 0}, {4840, 1, 5}, {4841, 1, 0}, {4842, 1, 0}, {4843, 1, 0},
+		{4844, 1, 0}, {4845, 0, 13}, {4845, 1, 0}, {4846, 1, 15}, {4847, 0, 16}, {4847, 1, 0}, {4848, 1, 0}, {4849, 0, 19},
+		{4849, 1, 0}, {4850, 0, 21}, {4850, 1, 0}, {4851, 1, 0}, {4852, 1, 0}, {4853, 0, 26}, {4853, 0, 28}, {4853, 1, 0},
+		{4854, 0, 25}, {4854, 1, 0}, {4855, 0, 31}, {4855, 0, 32}, {4855, 1, 0}, {4856, 1, 0}, {4857, 1, 0}, {4858, 1, 0},
+		{4859, 1, 0}, {4860, 1, 0}, {4861, 1, 0}, {4862, 0, 41}, {4862, 1, 0}, {4863, 1, 0}, {4864, 1, 0}, {4865, 1, 0},
+		{4866, 1, 0}, {4867, 2, 0}, {4869, 1, 0}, {4870, 1, 0}, {4871, 1, 0}, {4872, 0, 57}, {4872, 1, 0}, {4873, 1, 0},
+		{4874, 1, 0}, {4875, 0, 61}, {4875, 1, 0}, {4876, 1, 0}, {4877, 0, 65}, {4877, 0, 66}, {4877, 0, 67}, {4877, 1, 0},
+		{4878, 0, 69}, {4878, 1, 0}, {4879, 1, 0}, {4880, 1, 0}, {4881, 1, 0}, {4882, 1, 0}, {4883, 1, 0}, {4884, 0, 83},
+		{4884, 1, 0}, {4885, 1, 0}, {4886, 1, 0}, {4887, 1, 0}, {4888, 1, 0}, {4889, 1, 0}, {4890, 1, 0}, {4891, 1, 0},
+		{4892, 1, 0}, {4893, 0, 111}, {4893, 1, 0}, {4894, 1, 0}, {4895, 1, 0}, {4896, 1, 117}, {4897, 0, 119}, {4897, 1, 0},
+		{4898, 1, 0}, {4899, 1, 0}, {4900, 2, 0}, {4902, 1, 0}, {4903, 0, 127}, {4903, 1, 0}, {4904, 2, 0}, {4906, 1, 0},
+		{4907, 0, 132}, {4907, 0, 133}, {4907, 0, 135}, {4907, 1, 0}, {4908, 1, 0}, {4909, 1, 0}, {4910, 0, 142}, {4910, 1, 0},
+		{4911, 1, 0}, {4912, 0, 144}, {4912, 0, 145}, {4912, 2, 0}, {4914, 1, 0}, {4915, 1, 0}, {4916, 1, 0}, {4917, 0, 153},
+		{4917, 0, 156}, {4917, 1, 0}, {4918, 0, 164}, {4918, 1, 0}, {4919, 1, 0}, {4920, 1, 0}, {4921, 1, 0}, {4922, 0, 172},
+		{4922, 1, 0}, {4923, 0, 171}, {4923, 1, 0}, {4924, 1, 0}, {4925, 1, 0}, {4926, 0, 176}, {4926, 1, 0}, {4927, 0, 178},
+		{4927, 1, 0}, {4928, 1, 180}, {4929, 0, 182}, {4929, 1, 0}, {4930, 1, 0}, {4931, 1, 0}, {4932, 1, 0}, {4933, 1, 0},
+		{4934, 1, 0}, {4935, 1, 0}, {4936, 1, 0}, {4937, 0, 193}, {4937, 0, 194}, {4937, 0, 195}, {4937, 1, 0}, {4938, 1, 0},
+		{4939, 0, 198}, {4939, 1, 0}, {4940, 0, 202}, {4940, 0, 203}, {4940, 1, 0}, {4941, 1, 0}, {4942, 0, 206}, {4942, 0, 207},
+		{4942, 1, 0}, {4943, 1, 0}, {4944, 0, 212}, {4944, 0, 213}, {4944, 0, 214}, {4944, 1, 0}, {4945, 1, 0}, {4946, 0, 218},
+		{4946, 1, 0}, {4947, 0, 220}, {4947, 1, 0}, {4948, 1, 0}, {4949, 2, 0}, {4951, 1, 0}, {4952, 1, 0}, {4953, 1, 0},
+		{4954, 1, 0}, {4955, 2, 0}, {4957, 1, 0}, {4958, 1, 0}, {4959, 1, 0}, {4960, 1, 0}, {4961, 1, 0}, {4962, 1, 0},
+		{4963, 1, 0}, {4964, 1, 0}, {4965, 1, 0}, {4966, 1, 0}, {4967, 1, 0}, {4968, 1, 0}, {4969, 1, 0}, {4970, 1, 0},
+		{4971, 1, 0}, {4972, 1, 0}, {4973, 0, 261}, {4973, 2, 0}, {4975, 0, 219}, {4975, 1, 0}, {4976, 1, 0}, {4977, 1, 0},
+		{4978, 1, 0}, {4979, 0, 273}, {4979, 0, 270}, {4979, 0, 275}, {4979, 1, 0}, {4980, 1, 0}, {4981, 1, 0}, {4982, 1, 0},
+		{4983, 1, 0}, {4984, 1, 0}, {4985, 1, 0}, {4986, 1, 0}, {4987, 0, 285}, {4987, 1, 0}, {4988, 1, 0}, {4989, 1, 0},
+		{4990, 1, 0}, {4991, 1, 0}, {4992, 1, 0}, {4993, 1, 0}, {4994, 1, 0}, {4995, 2, 0}, {4997, 1, 0}, {4998, 1, 0},
+		{4999, 1, 0}, {5000, 1, 0}, {5001, 1, 0}, {5002, 3, 0}, {5005, 1, 0}, {5006, 1, 0}, {5007, 0, 338}, {5007, 1, 0},
+		{5008, 1, 0}, {5009, 1, 0}, {5010, 1, 343}, {5011, 1, 0}, {5012, 1, 0}, {5013, 1, 0}, {5014, 1, 0}, {5015, 1, 0},
+		{5016, 0, 351}, {5016, 1, 0}, {5017, 0, 355}, {5017, 1, 0}, {5018, 1, 0}, {5019, 1, 0}, {5020, 0, 359}, {5020, 2, 0},
+		{5022, 1, 0}, {5023, 1, 0}, {5024, 1, 0}, {5025, 1, 0}, {5026, 0, 370}, {5026, 1, 0}, {5027, 1, 0}, {5028, 1, 0},
+		{5029, 1, 0}, {5030, 0, 379}, {5030, 0, 381}, {5030, 0, 383}, {5030, 0, 384}, {5030, 1, 0}, {5031, 1, 0}, {5032, 0, 388},
+		{5032, 0, 389}, {5032, 1, 0}, {5033, 1, 0}, {5034, 1, 0}, {5035, 1, 0}, {5036, 8, 0}, {5044, 0, 421}, {5044, 1, 0},
+		{5045, 1, 0}, {5046, 0, 424}, {5046, 1, 0}, {5047, 1, 0}, {5048, 0, 428}, {5048, 1, 0}, {5049, 1, 0}, {5050, 1, 0},
+		{5051, 1, 0}, {5052, 1, 0}, {5053, 4, 0}, {5057, 1, 0}, {5058, 1, 0}, {5059, 0, 440}, {5059, 0, 441}, {5059, 1, 0},
+		{5060, 0, 449}, {5060, 0, 450}, {5060, 1, 0}, {5061, 1, 0}, {5062, 1, 0}, {5063, 1, 0}, {5064, 1, 0}, {5065, 1, 461},
+		{5066, 1, 0}, {5067, 1, 0}, {5068, 0, 464}, {5068, 1, 0}, {5069, 1, 0}, {5070, 1, 0}, {5071, 1, 0}, {5072, 1, 0},
+		{5073, 1, 0}, {5074, 4, 0}, {5078, 0, 479}, {5078, 1, 0}, {5079, 0, 481}, {5079, 1, 0}, {5080, 1, 0}, {5081, 0, 484},
+		{5081, 1, 0}, {5082, 1, 0}, {5083, 1, 0}, {5084, 1, 488}, {5085, 1, 0}, {5086, 1, 0}, {5087, 1, 0}, {5088, 2, 0},
+		{5090, 2, 0}, {5092, 1, 0}, {5093, 0, 501}, {5093, 1, 0}, {5094, 1, 0}, {5095, 1, 0}, {5096, 2, 0}, {5098, 1, 0},
+		{5099, 1, 0}, {5100, 2, 0}, {5102, 0, 510}, {5102, 1, 0}, {5103, 1, 0}, {5104, 0, 513}, {5104, 1, 0}, {5105, 0, 515},
+		{5105, 1, 0}, {5106, 0, 517}, {5106, 2, 0}, {5108, 1, 0}, {5109, 0, 522}, {5109, 1, 0}, {5110, 1, 0}, {5111, 0, 529},
+		{5111, 0, 530}, {5111, 1, 0}, {5112, 1, 0}, {5113, 1, 0}, {5114, 0, 535}, {5114, 0, 536}, {5114, 0, 539}, {5114, 0, 540},
+		{5114, 0, 541}, {5114, 0, 542}, {5114, 0, 543}, {5114, 1, 0}, {5115, 1, 0}, {5116, 0, 548}, {5116, 0, 549}, {5116, 0, 550},
+		{5116, 0, 551}, {5116, 1, 0}, {5117, 1, 0}, {5118, 0, 555}, {5118, 1, 0}, {5119, 0, 556}, {5119, 0, 558}, {5119, 0, 559},
+		{5119, 1, 0}, {5120, 1, 0}, {5121, 1, 565}, {5122, 1, 76}, {5123, 1, 567}, {5124, 1, 0}, {5125, 1, 0}, {5126, 1, 0},
+		{5127, 1, 0}, {5128, 1, 0}, {5129, 1, 0}, {5130, 1, 0}, {5131, 0, 576}, {5131, 1, 0}, {5132, 0, 578}, {5132, 0, 580},
+		{5132, 1, 0}, {5133, 1, 0}, {5134, 2, 0}, {5136, 1, 0}, {5137, 1, 0}, {5138, 1, 0}, {5139, 1, 0}, {5140, 0, 596},
+		{5140, 1, 0}, {5141, 0, 600}, {5141, 0, 601}, {5141, 1, 0}, {5142, 1, 602}, {5143, 0, 603}, {5143, 2, 0}, {5145, 1, 0},
+		{5146, 0, 606}, {5146, 1, 0}, {5147, 1, 0}, {5148, 0, 608}, {5148, 1, 0}, {5149, 1, 0}, {5150, 1, 0}, {5151, 1, 0},
+		{5152, 1, 0}, {5153, 1, 0}, {5154, 0, 496}, {5154, 1, 0}, {5155, 1, 0}, {5156, 1, 0}, {5157, 1, 0}, {5158, 1, 0},
+		{5159, 1, 0}, {5160, 1, 0}, {5161, 0, 620}, {5161, 0, 621}, {5161, 1, 0}, {5162, 1, 0}, {5163, 1, 0}, {5164, 1, 0},
+		{5165, 1, 0}, {5166, 1, 0}, {5167, 1, 0}, {5168, 1, 0}, {5169, 1, 0}, {5170, 1, 0}, {5171, 1, 0}, {5172, 1, 0},
+		{5173, 1, 0}, {5174, 1, 0}, {5175, 3, 0}, {5178, 1, 0}, {5179, 1, 0}, {5180, 1, 0}, {5181, 1, 0}, {5182, 1, 0},
+		{5183, 1, 0}, {5184, 0, 646}, {5184, 0, 647}, {5184, 1, 0}, {5185, 1, 0}, {5186, 1, 0}, {5187, 1, 0}, {5188, 1, 0},
+		{5189, 1, 0}, {5190, 0, 653}, {5190, 1, 0}, {5191, 1, 0}, {5192, 1, 0}, {5193, 1, 0}, {5194, 1, 0}, {5195, 1, 0},
+		{5196, 1, 0}, {5197, 1, 0}, {5198, 0, 662}, {5198, 1, 0}, {5199, 1, 0}, {5200, 1, 0}, {5201, 1, 0}, {5202, 1, 0},
+		{5203, 1, 0}, {5204, 1, 0}, {5205, 1, 0}, {5206, 1, 0}, {5207, 1, 0}, {5208, 1, 0}, {5209, 0, 173}, {5209, 1, 0},
+		{5210, 1, 0}, {5211, 1, 0}, {5212, 1, 0}, {5213, 1, 0}, {5214, 1, 0}, {5215, 1, 0}, {5216, 1, 0}, {5217, 1, 0},
+		{5218, 1, 0}, {5219, 1, 0}, {5220, 0, 685}, {5220, 1, 0}, {5221, 1, 0}, {5222, 1, 0}, {5223, 1, 0}, {5224, 1, 0},
+		{5225, 1, 0}, {5226, 1, 0}, {5227, 1, 0}, {5228, 1, 0}, {5229, 0, 693}, {5229, 1, 0}, {5230, 0, 612}, {5230, 1, 0},
+		{5231, 0, 695}, {5231, 1, 0}, {5232, 1, 0}, {5233, 0, 698}, {5233, 1, 0}, {5234, 0, 30}, {5234, 1, 0}, {5235, 2, 0},
+		{5237, 1, 0}, {5238, 1, 0}, {5239, 1, 0}, {5240, 1, 0}, {5241, 1, 0}, {5242, 1, 0}, {5243, 0, 707}, {5243, 1, 0},
+		{5244, 1, 0}, {5245, 1, 0}, {5246, 1, 0}, {5247, 1, 0}, {5248, 1, 0}, {5249, 2, 0}, {5251, 0, 715}, {5251, 1, 42},
+		{5252, 1, 0}, {5253, 0, 717}, {5253, 1, 0}, {5254, 0, 719}, {5254, 2, 0}, {5256, 0, 723}, {5256, 0, 724}, {5256, 1, 0},
+		{5257, 1, 0}, {5258, 0, 725}, {5258, 1, 0}, {5259, 1, 0}, {5260, 1, 0}, {5261, 2, 0}, {5263, 2, 0}, {5265, 2, 0},
+		{5267, 0, 739}, {5267, 1, 0}, {5268, 1, 0}, {5269, 0, 477}, {5269, 1, 0}, {5270, 1, 0}, {5271, 0, 741}, {5271, 1, 0},
+		{5272, 0, 32}, {5272, 1, 0}, {5273, 1, 0}, {5274, 1, 0}, {5275, 0, 745}, {5275, 0, 746}, {5275, 1, 0}, {5276, 0, 748},
+		{5276, 1, 0}, {5277, 1, 0}, {5278, 1, 0}, {5279, 1, 0}, {5280, 1, 0}, {5281, 1, 0}, {5282, 1, 0}, {5283, 1, 0},
+		{5284, 1, 0}, {5285, 1, 0}, {5286, 1, 0}, {5287, 0, 763}, {5287, 1, 0}, {5288, 3, 0}, {5291, 1, 0}, {5292, 1, 0},
+		{5293, 1, 0}, {5294, 1, 0}, {5295, 1, 0}, {5296, 1, 0}, {5297, 1, 0}, {5298, 0, 87}, {5298, 0, 771}, {5298, 1, 0},
+		{5299, 1, 0}, {5300, 0, 98}, {5300, 0, 774}, {5300, 1, 0}, {5301, 1, 0}, {5302, 1, 0}, {5303, 1, 0}, {5304, 1, 0},
+		{5305, 1, 0}, {5306, 1, 0}, {5307, 1, 0}, {5308, 1, 0}, {5309, 1, 0}, {5310, 1, 0}, {5311, 1, 0}, {5312, 0, 81},
+		{5312, 1, 0}, {5313, 1, 0}, {5314, 1, 0}, {5315, 1, 0}, {5316, 1, 0}, {5317, 1, 0}, {5318, 0, 790}, {5318, 1, 0},
+		{5319, 1, 0}, {5320, 1, 0}, {5321, 0, 794}, {5321, 2, 0}, {5323, 1, 0}, {5324, 1, 0}, {5325, 1, 0}, {5326, 1, 0},
+		{5327, 1, 0}, {5328, 1, 0}, {5329, 1, 0}, {5330, 1, 0}, {5331, 1, 0}, {5332, 1, 0}, {5333, 0, 801}, {5333, 0, 802},
+		{5333, 0, 803}, {5333, 1, 0}, {5334, 1, 0}, {5335, 0, 806}, {5335, 1, 0}, {5336, 1, 0}, {5337, 1, 0}, {5338, 1, 0},
+		{5339, 0, 808}, {5339, 1, 0}, {5340, 1, 0}, {5341, 0, 795}, {5341, 1, 0}, {5342, 1, 0}, {5343, 1, 0}, {5344, 0, 813},
+		{5344, 1, 814}, {5345, 1, 0}, {5346, 0, 817}, {5346, 1, 0}, {5347, 1, 0}, {5348, 1, 0}, {5349, 1, 0}, {5350, 1, 0},
+		{5351, 1, 0}, {5352, 3, 0}, {5355, 2, 0}, {5357, 0, 833}, {5357, 0, 835}, {5357, 1, 0}, {5358, 0, 837}, {5358, 0, 838},
+		{5358, 1, 0}, {5359, 1, 0}, {5360, 0, 841}, {5360, 1, 0}, {5361, 1, 0}, {5362, 1, 0}, {5363, 1, 0}, {5364, 1, 0},
+		{5365, 1, 0}, {5366, 1, 0}, {5367, 1, 0}, {5368, 2, 0}, {5370, 1, 0}, {5371, 1, 0}, {5372, 1, 0}, {5373, 0, 849},
+		{5373, 1, 0}, {5374, 0, 130}, {5374, 0, 852}, {5374, 0, 853}, {5374, 0, 854}, {5374, 1, 0}, {5375, 1, 0}, {5376, 1, 0},
+		{5377, 1, 0}, {5378, 1, 0}, {5379, 1, 0}, {5380, 1, 0}, {5381, 1, 0}, {5382, 1, 0}, {5383, 1, 0}, {5384, 0, 863},
+		{5384, 1, 0}, {5385, 1, 0}, {5386, 0, 866}, {5386, 0, 867}, {5386, 1, 0}, {5387, 0, 868}, {5387, 1, 0}, {5388, 1, 0},
+		{5389, 6, 0}, {5395, 2, 0}, {5397, 3, 0}, {5400, 1, 0}, {5401, 2, 0}, {5403, 1, 0}, {5404, 1, 0}, {5405, 1, 0},
+		{5406, 0, 888}, {5406, 1, 0}, {5407, 2, 0}, {5409, 1, 0}, {5410, 1, 0}, {5411, 0, 896}, {5411, 0, 159}, {5411, 1, 0},
+		{5412, 1, 0}, {5413, 1, 0}, {5414, 0, 901}, {5414, 1, 0}, {5415, 1, 0}, {5416, 0, 905}, {5416, 0, 910}, {5416, 1, 0},
+		{5417, 0, 911}, {5417, 1, 0}, {5418, 1, 0}, {5419, 0, 913}, {5419, 1, 0}, {5420, 0, 914}, {5420, 0, 163}, {5420, 1, 0},
+		{5421, 1, 0}, {5422, 0, 917}, {5422, 1, 0}, {5423, 1, 0}, {5424, 1, 0}, {5425, 1, 0}, {5426, 1, 0}, {5427, 1, 0},
+		{5428, 1, 0}, {5429, 2, 0}, {5431, 1, 0}, {5432, 1, 0}, {5433, 1, 0}, {5434, 0, 923}, {5434, 0, 89}, {5434, 1, 0},
+		{5435, 0, 871}, {5435, 1, 0}, {5436, 1, 0}, {5437, 0, 234}, {5437, 1, 0}, {5438, 1, 0}, {5439, 0, 927}, {5439, 1, 0},
+		{5440, 1, 0}, {5441, 1, 0}, {5442, 1, 0}, {5443, 1, 0}, {5444, 1, 0}, {5445, 1, 0}, {5446, 1, 0}, {5447, 1, 0},
+		{5448, 1, 0}, {5449, 0, 939}, {5449, 1, 0}, {5450, 0, 941}, {5450, 1, 0}, {5451, 1, 0}, {5452, 1, 0}, {5453, 1, 0},
+		{5454, 1, 0}, {5455, 1, 945}, {5456, 0, 947}, {5456, 1, 948}, {5457, 1, 0}, {5458, 1, 0}, {5459, 1, 0}, {5460, 1, 0},
+		{5461, 1, 0}, {5462, 1, 0}, {5463, 1, 0}, {5464, 1, 0}, {5465, 1, 0}, {5466, 1, 0}, {5467, 1, 0}, {5468, 0, 958},
+		{5468, 1, 0}, {5469, 1, 0}, {5470, 2, 0}, {5472, 1, 0}, {5473, 1, 0}, {5474, 1, 0}, {5475, 1, 0}, {5476, 1, 0},
+		{5477, 1, 0}, {5478, 0, 966}, {5478, 1, 0}, {5479, 0, 968}, {5479, 0, 969}, {5479, 1, 0}, {5480, 1, 0}, {5481, 0, 971},
+		{5481, 0, 122}, {5481, 1, 0}, {5482, 1, 0}, {5483, 2, 0}, {5485, 1, 0}, {5486, 1, 0}, {5487, 1, 0}, {5488, 0, 978},
+		{5488, 1, 0}, {5489, 1, 0}, {5490, 0, 983}, {5490, 0, 984}, {5490, 1, 0}, {5491, 1, 0}, {5492, 2, 0}, {5494, 1, 0},
+		{5495, 1, 0}, {5496, 0, 993}, {5496, 0, 994}, {5496, 0, 995}, {5496, 0, 996}, {5496, 1, 0}, {5497, 0, 88}, {5497, 1, 0},
+		{5498, 1, 0}, {5499, 0, 1000}, {5499, 1, 0}, {5500, 1, 0}, {5501, 1, 0}, {5502, 1, 0}, {5503, 0, 224}, {5503, 1, 0},
+		{5504, 1, 0}, {5505, 1, 1006}, {5506, 0, 225}, {5506, 2, 0}, {5508, 1, 0}, {5509, 1, 0}, {5510, 1, 0}, {5511, 1, 0},
+		{5512, 1, 0}, {5513, 1, 0}, {5514, 1, 0}, {5515, 0, 1015}, {5515, 1, 0}, {5516, 1, 0}, {5517, 1, 0}, {5518, 2, 0},
+		{5520, 1, 0}, {5521, 1, 0}, {5522, 1, 0}, {5523, 1, 0}, {5524, 1, 0}, {5525, 0, 1019}, {5525, 0, 1027}, {5525, 2, 0},
+		{5527, 1, 0}, {5528, 1, 0}, {5529, 0, 1030}, {5529, 1, 0}, {5530, 1, 0}, {5531, 1, 0}, {5532, 1, 0}, {5533, 1, 0},
+		{5534, 0, 249}, {5534, 1, 0}, {5535, 1, 0}, {5536, 1, 0}, {5537, 0, 1038}, {5537, 1, 0}, {5538, 1, 0}, {5539, 1, 0},
+		{5540, 1, 0}, {5541, 1, 0}, {5542, 1, 0}, {5543, 1, 0}, {5544, 1, 0}, {5545, 1, 0}, {5546, 2, 0}, {5548, 1, 0},
+		{5549, 0, 1046}, {5549, 1, 0}, {5550, 1, 0}, {5551, 1, 0}, {5552, 1, 0}, {5553, 1, 0}, {5554, 1, 0}, {5555, 0, 1052},
+		{5555, 1, 0}, {5556, 0, 1053}, {5556, 1, 0}, {5557, 1, 0}, {5558, 1, 0}, {5559, 1, 0}, {5560, 1, 0}, {5561, 1, 0},
+		{5562, 1, 0}, {5563, 1, 0}, {5564, 1, 0}, {5565, 1, 0}, {5566, 0, 1060}, {5566, 1, 0}, {5567, 1, 0}, {5568, 1, 0},
+		{5569, 1, 0}, {5570, 1, 0}, {5571, 0, 634}, {5571, 0, 1065}, {5571, 1, 0}, {5572, 1, 0}, {5573, 1, 0}, {5574, 2, 0},
+		{5576, 1, 0}, {5577, 1, 0}, {5578, 0, 1071}, {5578, 0, 253}, {5578, 1, 0}, {5579, 1, 0}, {5580, 0, 1020}, {5580, 2, 0},
+		{5582, 1, 0}, {5583, 0, 1076}, {5583, 1, 0}, {5584, 1, 0}, {5585, 1, 0}, {5586, 1, 0}, {5587, 1, 0}, {5588, 1, 0},
+		{5589, 1, 0}, {5590, 0, 1082}, {5590, 1, 0}, {5591, 1, 0}, {5592, 1, 0}, {5593, 1, 0}, {5594, 1, 0}, {5595, 0, 1085},
+		{5595, 1, 0}, {5596, 0, 1087}, {5596, 0, 1088}, {5596, 0, 1089}, {5596, 1, 0}, {5597, 1, 0}, {5598, 1, 0}, {5599, 1, 0},
+		{5600, 1, 0}, {5601, 1, 0}, {5602, 1, 1096}, {5603, 1, 0}, {5604, 1, 0}, {5605, 1, 0}, {5606, 3, 0}, {5609, 0, 1100},
+		{5609, 0, 930}, {5609, 1, 0}, {5610, 1, 0}, {5611, 0, 1102}, {5611, 0, 1103}, {5611, 1, 0}, {5612, 1, 0}, {5613, 1, 0},
+		{5614, 0, 297}, {5614, 2, 0}, {5616, 0, 309}, {5616, 1, 0}, {5617, 1, 0}, {5618, 1, 0}, {5619, 1, 0}, {5620, 1, 0},
+		{5621, 0, 1116}, {5621, 0, 1117}, {5621, 1, 0}, {5622, 1, 0}, {5623, 1, 0}, {5624, 2, 0}, {5626, 0, 284}, {5626, 2, 0},
+		{5628, 0, 1121}, {5628, 1, 0}, {5629, 1, 0}, {5630, 2, 0}, {5632, 0, 1125}, {5632, 1, 0}, {5633, 1, 0}, {5634, 1, 0},
+		{5635, 2, 0}, {5637, 1, 0}, {5638, 1, 0}, {5639, 1, 0}, {5640, 1, 0}, {5641, 1, 0}, {5642, 0, 295}, {5642, 1, 0},
+		{5643, 1, 0}, {5644, 0, 299}, {5644, 1, 0}, {5645, 0, 294}, {5645, 1, 0}, {5646, 1, 0}, {5647, 1, 0}, {5648, 0, 1136},
+		{5648, 0, 1137}, {5648, 1, 0}, {5649, 1, 0}, {5650, 0, 1140}, {5650, 2, 0}, {5652, 0, 307}, {5652, 1, 0}, {5653, 1, 0},
+		{5654, 0, 310}, {5654, 1, 0}, {5655, 1, 0}, {5656, 2, 0}, {5658, 0, 337}, {5658, 0, 1141}, {5658, 4, 0}, {5662, 2, 0},
+		{5664, 0, 289}, {5664, 1, 0}, {5665, 1, 0}, {5666, 1, 0}, {5667, 1, 0}, {5668, 1, 0}, {5669, 0, 315}, {5669, 2, 0},
+		{5671, 1, 0}, {5672, 3, 0}, {5675, 1, 0}, {5676, 2, 0}, {5678, 1, 0}, {5679, 0, 328}, {5679, 0, 1155}, {5679, 1, 0},
+		{5680, 0, 333}, {5680, 2, 0}, {5682, 1, 0}, {5683, 1, 0}, {5684, 2, 0}, {5686, 0, 1156}, {5686, 1, 0}, {5687, 1, 0},
+		{5688, 1, 0}, {5689, 2, 0}, {5691, 0, 1158}, {5691, 1, 0}, {5692, 1, 0}, {5693, 1, 0}, {5694, 0, 298}, {5694, 1, 0},
+		{5695, 0, 308}, {5695, 1, 0}, {5696, 1, 0}, {5697, 1, 0}, {5698, 1, 0}, {5699, 1, 0}, {5700, 0, 1167}, {5700, 1, 0},
+		{5701, 0, 1169}, {5701, 0, 1170}, {5701, 1, 0}, {5702, 1, 0}, {5703, 0, 1173}, {5703, 0, 1174}, {5703, 1, 0}, {5704, 1, 0},
+		{5705, 1, 0}, {5706, 1, 0}, {5707, 1, 0}, {5708, 1, 0}, {5709, 2, 0}, {5711, 1, 0}, {5712, 1, 0}, {5713, 0, 729},
+		{5713, 0, 730}, {5713, 1, 1183}, {5714, 1, 0}, {5715, 1, 0}, {5716, 0, 1186}, {5716, 0, 45}, {5716, 1, 0}, {5717, 1, 0},
+		{5718, 1, 0}, {5719, 0, 1191}, {5719, 1, 0}, {5720, 1, 0}, {5721, 0, 56}, {5721, 1, 0}, {5722, 1, 0}, {5723, 1, 0},
+		{5724, 1, 0}, {5725, 1, 0}, {5726, 1, 0}, {5727, 1, 0}, {5728, 0, 1201}, {5728, 1, 0}, {5729, 0, 1202}, {5729, 0, 1203},
+		{5729, 1, 0}, {5730, 1, 0}, {5731, 1, 0}, {5732, 2, 0}, {5734, 0, 1208}, {5734, 0, 1209}, {5734, 1, 0}, {5735, 0, 1211},
+		{5735, 1, 0}, {5736, 0, 1207}, {5736, 1, 0}, {5737, 0, 1215}, {5737, 1, 0}, {5738, 1, 0}, {5739, 0, 1218}, {5739, 1, 0},
+		{5740, 0, 1220}, {5740, 1, 0}, {5741, 1, 0}, {5742, 1, 0}, {5743, 0, 364}, {5743, 1, 0}, {5744, 1, 0}, {5745, 1, 0},
+		{5746, 0, 506}, {5746, 1, 0}, {5747, 0, 1230}, {5747, 1, 0}, {5748, 1, 0}, {5749, 1, 0}, {5750, 1, 0}, {5751, 1, 0},
+		{5752, 0, 1235}, {5752, 1, 0}, {5753, 1, 0}, {5754, 1, 0}, {5755, 2, 0}, {5757, 1, 0}, {5758, 1, 0}, {5759, 1, 0},
+		{5760, 1, 0}, {5761, 1, 0}, {5762, 0, 1243}, {5762, 1, 1244}, {5763, 0, 1246}, {5763, 1, 0}, {5764, 0, 372}, {5764, 1, 0},
+		{5765, 1, 0}, {5766, 1, 0}, {5767, 3, 0}, {5770, 1, 0}, {5771, 2, 0}, {5773, 0, 1248}, {5773, 1, 0}, {5774, 1, 0},
+		{5775, 0, 377}, {5775, 1, 0}, {5776, 1, 0}, {5777, 1, 0}, {5778, 0, 378}, {5778, 1, 0}, {5779, 1, 0}, {5780, 1, 0},
+		{5781, 0, 1255}, {5781, 1, 0}, {5782, 0, 951}, {5782, 0, 1259}, {5782, 1, 0}, {5783, 0, 1261}, {5783, 1, 0}, {5784, 1, 0},
+		{5785, 2, 0}, {5787, 0, 381}, {5787, 1, 0}, {5788, 0, 93}, {5788, 1, 0}, {5789, 1, 0}, {5790, 0, 1265}, {5790, 0, 1266},
+		{5790, 1, 0}, {5791, 1, 0}, {5792, 1, 0}, {5793, 0, 399}, {5793, 1, 0}, {5794, 1, 0}, {5795, 1, 0}, {5796, 1, 1271},
+		{5797, 0, 400}, {5797, 1, 0}, {5798, 2, 0}, {5800, 1, 0}, {5801, 1, 0}, {5802, 1, 0}, {5803, 1, 0}, {5804, 1, 0},
+		{5805, 1, 0}, {5806, 1, 0}, {5807, 1, 0}, {5808, 1, 0}, {5809, 2, 0}, {5811, 1, 0}, {5812, 1, 0}, {5813, 2, 0},
+		{5815, 1, 0}, {5816, 1, 0}, {5817, 1, 0}, {5818, 1, 0}, {5819, 1, 0}, {5820, 0, 1283}, {5820, 0, 1291}, {5820, 1, 0},
+		{5821, 2, 0}, {5823, 0, 1293}, {5823, 0, 394}, {5823, 1, 0}, {5824, 1, 0}, {5825, 1, 0}, {5826, 0, 1294}, {5826, 1, 0},
+		{5827, 1, 0}, {5828, 1, 0}, {5829, 2, 0}, {5831, 0, 1299}, {5831, 6, 0}, {5837, 0, 1302}, {5837, 1, 0}, {5838, 1, 0},
+		{5839, 1, 0}, {5840, 1, 0}, {5841, 1, 0}, {5842, 1, 0}, {5843, 1, 0}, {5844, 1, 0}, {5845, 1, 0}, {5846, 0, 1309},
+		{5846, 1, 0}, {5847, 1, 0}, {5848, 0, 1312}, {5848, 1, 0}, {5849, 1, 0}, {5850, 1, 0}, {5851, 1, 0}, {5852, 0, 1316},
+		{5852, 0, 1284}, {5852, 2, 0}, {5854, 1, 0}, {5855, 1, 0}, {5856, 0, 1318}, {5856, 1, 0}, {5857, 1, 0}, {5858, 1, 0},
+		{5859, 1, 0}, {5860, 1, 0}, {5861, 1, 0}, {5862, 0, 1324}, {5862, 1, 0}, {5863, 1, 0}, {5864, 1, 0}, {5865, 1, 0},
+		{5866, 0, 1328}, {5866, 1, 0}, {5867, 1, 0}, {5868, 1, 0}, {5869, 1, 0}, {5870, 0, 1333}, {5870, 1, 0}, {5871, 1, 0},
+		{5872, 1, 0}, {5873, 1, 0}, {5874, 2, 0}, {5876, 0, 1336}, {5876, 0, 1337}, {5876, 1, 0}, {5877, 1, 0}, {5878, 1, 0},
+		{5879, 0, 1339}, {5879, 1, 0}, {5880, 1, 0}, {5881, 1, 0}, {5882, 0, 1343}, {5882, 2, 0}, {5884, 3, 0}, {5887, 1, 0},
+		{5888, 0, 476}, {5888, 1, 0}, {5889, 0, 1348}, {5889, 1, 0}, {5890, 0, 1350}, {5890, 1, 0}, {5891, 1, 0}, {5892, 1, 0},
+		{5893, 1, 0}, {5894, 1, 0}, {5895, 1, 0}, {5896, 1, 0}, {5897, 1, 0}, {5898, 0, 523}, {5898, 1, 0}, {5899, 0, 1359},
+		{5899, 1, 0}, {5900, 1, 0}, {5901, 0, 1363}, {5901, 1, 0}, {5902, 0, 1365}, {5902, 1, 0}, {5903, 0, 97}, {5903, 2, 0},
+		{5905, 2, 0}, {5907, 3, 0}, {5910, 3, 0}, {5913, 2, 0}, {5915, 0, 141}, {5915, 1, 0}, {5916, 0, 1369}, {5916, 1, 0},
+		{5917, 1, 0}, {5918, 1, 0}, {5919, 0, 1370}, {5919, 1, 0}, {5920, 1, 0}, {5921, 1, 0}, {5922, 0, 1372}, {5922, 1, 0},
+		{5923, 0, 452}, {5923, 1, 0}, {5924, 1, 0}, {5925, 1, 0}, {5926, 1, 0}, {5927, 1, 0}, {5928, 1, 0}, {5929, 1, 0},
+		{5930, 1, 0}, {5931, 2, 0}, {5933, 0, 453}, {5933, 1, 0}, {5934, 1, 0}, {5935, 1, 0}, {5936, 3, 0}, {5939, 1, 0},
+		{5940, 0, 1383}, {5940, 0, 1384}, {5940, 0, 1385}, {5940, 0, 1386}, {5940, 0, 1387}, {5940, 1, 0}, {5941, 1, 0}, {5942, 0, 460},
+		{5942, 1, 0}, {5943, 2, 0}, {5945, 1, 0}, {5946, 1, 0}, {5947, 1, 0}, {5948, 1, 0}, {5949, 1, 0}, {5950, 1, 0},
+		{5951, 1, 0}, {5952, 2, 0}, {5954, 1, 0}, {5955, 1, 0}, {5956, 2, 0}, {5958, 1, 0}, {5959, 1, 1403}, {5960, 1, 0},
+		{5961, 0, 362}, {5961, 1, 0}, {5962, 1, 0}, {5963, 0, 480}, {5963, 1, 0}, {5964, 2, 0}, {5966, 3, 0}, {5969, 2, 0},
+		{5971, 1, 0}, {5972, 1, 0}, {5973, 1, 0}, {5974, 1, 1413}, {5975, 1, 0}, {5976, 3, 1414}, {5979, 0, 952}, {5979, 0, 1131},
+		{5979, 1, 0}, {5980, 1, 0}, {5981, 0, 1419}, {5981, 1, 0}, {5982, 0, 1338}, {5982, 1, 0}, {5983, 1, 0}, {5984, 1, 0},
+		{5985, 1, 0}, {5986, 0, 1423}, {5986, 1, 0}, {5987, 1, 0}, {5988, 1, 0}, {5989, 1, 0}, {5990, 1, 0}, {5991, 0, 1430},
+		{5991, 0, 1431}, {5991, 1, 0}, {5992, 1, 0}, {5993, 1, 0}, {5994, 1, 0}, {5995, 0, 95}, {5995, 0, 1434}, {5995, 1, 0},
+		{5996, 0, 437}, {5996, 1, 0}, {5997, 1, 0}, {5998, 1, 1438}, {5999, 1, 0}, {6000, 1, 0}, {6001, 1, 0}, {6002, 1, 0},
+		{6003, 1, 0}, {6004, 1, 0}, {6005, 1, 0}, {6006, 1, 0}, {6007, 1, 0}, {6008, 1, 0}, {6009, 1, 0}, {6010, 1, 0},
+		{6011, 1, 0}, {6012, 0, 1450}, {6012, 1, 0}, {6013, 1, 0}, {6014, 1, 0}, {6015, 1, 0}, {6016, 0, 1451}, {6016, 1, 0},
+		{6017, 1, 0}, {6018, 1, 0}, {6019, 1, 0}, {6020, 1, 0}, {6021, 1, 0}, {6022, 1, 0}, {6023, 0, 1457}, {6023, 1, 0},
+		{6024, 1, 0}, {6025, 0, 1422}, {6025, 1, 0}, {6026, 1, 0}, {6027, 0, 1460}, {6027, 1, 0}, {6028, 0, 96}, {6028, 0, 1462},
+		{6028, 1, 0}, {6029, 1, 0}, {6030, 1, 0}, {6031, 1, 0}, {6032, 1, 0}, {6033, 1, 0}, {6034, 1, 0}, {6035, 1, 0},
+		{6036, 1, 0}, {6037, 0, 504}, {6037, 1, 0}, {6038, 1, 0}, {6039, 2, 0}, {6041, 1, 0}, {6042, 1, 0}, {6043, 1, 0},
+		{6044, 1, 0}, {6045, 1, 0}, {6046, 1, 0}, {6047, 1, 0}, {6048, 0, 524}, {6048, 1, 0}, {6049, 1, 0}, {6050, 1, 0},
+		{6051, 0, 1474}, {6051, 1, 0}, {6052, 1, 0}, {6053, 0, 1475}, {6053, 2, 0}, {6055, 2, 0}, {6057, 1, 0}, {6058, 1, 0},
+		{6059, 1, 0}, {6060, 2, 0}, {6062, 1, 0}, {6063, 0, 1482}, {6063, 1, 0}, {6064, 0, 1483}, {6064, 0, 189}, {6064, 1, 0},
+		{6065, 0, 498}, {6065, 1, 0}, {6066, 1, 0}, {6067, 1, 0}, {6068, 1, 0}, {6069, 1, 0}, {6070, 0, 1057}, {6070, 0, 1486},
+		{6070, 1, 0}, {6071, 0, 1487}, {6071, 1, 0}, {6072, 1, 0}, {6073, 1, 0}, {6074, 1, 0}, {6075, 0, 1488}, {6075, 1, 0},
+		{6076, 1, 0}, {6077, 1, 0}, {6078, 0, 521}, {6078, 1, 0}, {6079, 1, 0}, {6080, 0, 1490}, {6080, 1, 0}, {6081, 0, 1495},
+		{6081, 0, 1496}, {6081, 0, 1497}, {6081, 0, 1498}, {6081, 0, 1499}, {6081, 1, 0}, {6082, 1, 0}, {6083, 0, 1503}, {6083, 1, 0},
+		{6084, 0, 1504}, {6084, 0, 1506}, {6084, 1, 0}, {6085, 0, 1508}, {6085, 0, 1509}, {6085, 0, 1511}, {6085, 0, 1}, {6085, 1, 3},
+		{6086, 1, 0}, {6087, 0, 5}, {6087, 1, 8}, {6088, 0, 9}, {6088, 0, 10}, {6088, 0, 12}, {6088, 1, 0}, {6089, 0, 15},
+		{6089, 1, 0}, {6090, 1, 18}, {6091, 1, 0}, {6092, 1, 0}, {6093, 1, 0}, {6094, 1, 0}, {6095, 0, 29}, {6095, 1, 0},
+		{6096, 1, 0}, {6097, 1, 0}, {6098, 1, 0}, {6099, 1, 0}, {6100, 1, 38}, {6101, 0, 39}, {6101, 1, 0}, {6102, 1, 0},
+		{6103, 1, 0}, {6104, 4, 0}, {6108, 1, 0}, {6109, 1, 0}, {6110, 0, 52}, {6110, 1, 0}, {6111, 1, 0}, {6112, 1, 0},
+		{6113, 1, 0}, {6114, 1, 0}, {6115, 1, 0}, {6116, 0, 60}, {6116, 1, 0}, {6117, 1, 0}, {6118, 1, 0}, {6119, 0, 70},
+		{6119, 1, 0}, {6120, 0, 74}, {6120, 1, 0}, {6121, 1, 0}, {6122, 1, 0}, {6123, 1, 0}, {6124, 1, 0}, {6125, 6, 0},
+		{6131, 1, 0}, {6132, 1, 0}, {6133, 1, 0}, {6134, 1, 0}, {6135, 1, 0}, {6136, 1, 0}, {6137, 1, 0}, {6138, 1, 115},
+		{6139, 1, 0}, {6140, 0, 117}, {6140, 1, 121}, {6141, 1, 0}, {6142, 0, 123}, {6142, 1, 0}, {6143, 1, 0}, {6144, 0, 126},
+		{6144, 1, 0}, {6145, 0, 129}, {6145, 1, 0}, {6146, 1, 0}, {6147, 1, 0}, {6148, 1, 0}, {6149, 2, 0}, {6151, 1, 0},
+		{6152, 1, 0}, {6153, 0, 147}, {6153, 1, 0}, {6154, 1, 0}, {6155, 1, 0}, {6156, 0, 151}, {6156, 1, 0}, {6157, 1, 0},
+		{6158, 0, 167}, {6158, 0, 169}, {6158, 1, 0}, {6159, 1, 0}, {6160, 1, 0}, {6161, 1, 0}, {6162, 1, 0}, {6163, 0, 177},
+		{6163, 1, 179}, {6164, 0, 180}, {6164, 1, 184}, {6165, 0, 185}, {6165, 1, 0}, {6166, 1, 0}, {6167, 1, 0}, {6168, 1, 0},
+		{6169, 1, 0}, {6170, 0, 192}, {6170, 1, 0}, {6171, 0, 197}, {6171, 0, 199}, {6171, 1, 0}, {6172, 0, 205}, {6172, 0, 208},
+		{6172, 1, 0}, {6173, 1, 0}, {6174, 1, 0}, {6175, 1, 0}, {6176, 1, 0}, {6177, 1, 0}, {6178, 1, 0}, {6179, 1, 0},
+		{6180, 1, 0}, {6181, 2, 0}, {6183, 1, 0}, {6184, 1, 0}, {6185, 1, 0}, {6186, 1, 0}, {6187, 3, 0}, {6190, 1, 0},
+		{6191, 1, 0}, {6192, 1, 0}, {6193, 1, 0}, {6194, 1, 0}, {6195, 1, 0}, {6196, 1, 0}, {6197, 1, 0}, {6198, 1, 0},
+		{6199, 1, 0}, {6200, 1, 0}, {6201, 1, 0}, {6202, 1, 0}, {6203, 1, 0}, {6204, 1, 0}, {6205, 1, 0}, {6206, 1, 0},
+		{6207, 1, 0}, {6208, 1, 0}, {6209, 1, 0}, {6210, 1, 0}, {6211, 1, 0}, {6212, 1, 0}, {6213, 1, 0}, {6214, 1, 0},
+		{6215, 2, 0}, {6217, 1, 0}, {6218, 1, 0}, {6219, 1, 0}, {6220, 1, 0}, {6221, 1, 0}, {6222, 1, 0}, {6223, 1, 0},
+		{6224, 1, 0}, {6225, 1, 0}, {6226, 1, 0}, {6227, 1, 0}, {6228, 1, 0}, {6229, 1, 0}, {6230, 1, 0}, {6231, 1, 0},
+		{6232, 1, 0}, {6233, 1, 0}, {6234, 1, 0}, {6235, 1, 0}, {6236, 1, 0}, {6237, 1, 0}, {6238, 1, 0}, {6239, 1, 0},
+		{6240, 1, 339}, {6241, 0, 341}, {6241, 1, 342}, {6242, 0, 343}, {6242, 1, 0}, {6243, 1, 347}, {6244, 0, 348}, {6244, 0, 349},
+		{6244, 1, 0}, {6245, 1, 0}, {6246, 1, 356}, {6247, 1, 357}, {6248, 1, 0}, {6249, 1, 0}, {6250, 1, 0}, {6251, 1, 0},
+		{6252, 1, 0}, {6253, 1, 0}, {6254, 1, 0}, {6255, 1, 0}, {6256, 0, 376}, {6256, 1, 0}, {6257, 1, 0}, {6258, 0, 385},
+		{6258, 1, 0}, {6259, 1, 0}, {6260, 1, 0}, {6261, 1, 0}, {6262, 1, 0}, {6263, 2, 0}, {6265, 1, 0}, {6266, 1, 0},
+		{6267, 1, 0}, {6268, 2, 0}, {6270, 1, 0}, {6271, 1, 0}, {6272, 1, 0}, {6273, 1, 0}, {6274, 1, 0}, {6275, 1, 0},
+		{6276, 1, 0}, {6277, 1, 0}, {6278, 1, 0}, {6279, 1, 0}, {6280, 1, 0}, {6281, 0, 434}, {6281, 1, 0}, {6282, 1, 0},
+		{6283, 1, 0}, {6284, 1, 0}, {6285, 0, 438}, {6285, 1, 0}, {6286, 4, 0}, {6290, 2, 0}, {6292, 1, 0}, {6293, 1, 0},
+		{6294, 1, 0}, {6295, 1, 0}, {6296, 0, 461}, {6296, 0, 462}, {6296, 0, 463}, {6296, 1, 0}, {6297, 1, 0}, {6298, 1, 0},
+		{6299, 0, 472}, {6299, 1, 0}, {6300, 1, 0}, {6301, 0, 475}, {6301, 1, 0}, {6302, 1, 0}, {6303, 1, 0}, {6304, 1, 0},
+		{6305, 1, 0}, {6306, 1, 483}, {6307, 1, 0}, {6308, 0, 486}, {6308, 1, 0}, {6309, 0, 488}, {6309, 1, 0}, {6310, 1, 492},
+		{6311, 0, 493}, {6311, 2, 0}, {6313, 1, 0}, {6314, 0, 498}, {6314, 1, 0}, {6315, 0, 500}, {6315, 1, 0}, {6316, 1, 0},
+		{6317, 1, 0}, {6318, 0, 506}, {6318, 1, 0}, {6319, 1, 0}, {6320, 1, 0}, {6321, 1, 0}, {6322, 1, 0}, {6323, 1, 0},
+		{6324, 0, 512}, {6324, 1, 0}, {6325, 0, 516}, {6325, 0, 519}, {6325, 1, 0}, {6326, 1, 0}, {6327, 1, 0}, {6328, 1, 0},
+		{6329, 1, 0}, {6330, 0, 532}, {6330, 0, 533}, {6330, 1, 544}, {6331, 0, 545}, {6331, 1, 0}, {6332, 1, 0}, {6333, 1, 0},
+		{6334, 1, 560}, {6335, 1, 0}, {6336, 0, 565}, {6336, 0, 76}, {6336, 0, 567}, {6336, 1, 569}, {6337, 1, 0}, {6338, 0, 570},
+		{6338, 0, 571}, {6338, 0, 572}, {6338, 0, 573}, {6338, 1, 0}, {6339, 1, 0}, {6340, 0, 579}, {6340, 2, 0}, {6342, 0, 590},
+		{6342, 1, 0}, {6343, 1, 0}, {6344, 0, 15}, {6344, 1, 0}, {6345, 0, 595}, {6345, 1, 0}, {6346, 2, 0}, {6348, 0, 602},
+		{6348, 0, 478}, {6348, 1, 0}, {6349, 1, 605}, {6350, 1, 0}, {6351, 0, 607}, {6351, 1, 0}, {6352, 1, 0}, {6353, 1, 0},
+		{6354, 1, 0}, {6355, 1, 0}, {6356, 2, 0}, {6358, 1, 0}, {6359, 0, 609}, {6359, 0, 618}, {6359, 2, 0}, {6361, 1, 0},
+		{6362, 0, 610}, {6362, 1, 0}, {6363, 1, 0}, {6364, 1, 0}, {6365, 1, 0}, {6366, 1, 0}, {6367, 1, 0}, {6368, 1, 0},
+		{6369, 1, 0}, {6370, 1, 0}, {6371, 1, 0}, {6372, 1, 0}, {6373, 1, 0}, {6374, 1, 0}, {6375, 1, 0}, {6376, 1, 0},
+		{6377, 1, 0}, {6378, 1, 0}, {6379, 1, 0}, {6380, 0, 639}, {6380, 0, 640}, {6380, 0, 641}, {6380, 0, 642}, {6380, 0, 643},
+		{6380, 1, 0}, {6381, 1, 0}, {6382, 1, 0}, {6383, 0, 649}, {6383, 0, 650}, {6383, 0, 651}, {6383, 0, 652}, {6383, 0, 654},
+		{6383, 0, 655}, {6383, 0, 656}, {6383, 0, 657}, {6383, 0, 658}, {6383, 0, 659}, {6383, 0, 660}, {6383, 0, 661}, {6383, 0, 663},
+		{6383, 0, 664}, {6383, 0, 665}, {6383, 0, 666}, {6383, 0, 667}, {6383, 0, 668}, {6383, 1, 0}, {6384, 0, 670}, {6384, 0, 671},
+		{6384, 0, 672}, {6384, 0, 673}, {6384, 0, 674}, {6384, 0, 675}, {6384, 0, 676}, {6384, 0, 677}, {6384, 1, 0}, {6385, 1, 0},
+		{6386, 1, 0}, {6387, 0, 681}, {6387, 0, 682}, {6387, 0, 683}, {6387, 0, 684}, {6387, 0, 686}, {6387, 0, 687}, {6387, 0, 688},
+		{6387, 0, 689}, {6387, 0, 690}, {6387, 0, 691}, {6387, 1, 0}, {6388, 0, 29}, {6388, 1, 692}, {6389, 0, 694}, {6389, 0, 613},
+		{6389, 0, 696}, {6389, 1, 0}, {6390, 1, 0}, {6391, 0, 699}, {6391, 0, 175}, {6391, 1, 0}, {6392, 1, 0}, {6393, 1, 0},
+		{6394, 1, 0}, {6395, 1, 0}, {6396, 1, 0}, {6397, 1, 0}, {6398, 0, 708}, {6398, 0, 167}, {6398, 0, 709}, {6398, 1, 0},
+		{6399, 1, 711}, {6400, 0, 712}, {6400, 0, 713}, {6400, 1, 0}, {6401, 0, 42}, {6401, 1, 0}, {6402, 1, 0}, {6403, 0, 720},
+		{6403, 1, 0}, {6404, 1, 0}, {6405, 2, 0}, {6407, 1, 0}, {6408, 1, 0}, {6409, 1, 0}, {6410, 0, 735}, {6410, 1, 0},
+		{6411, 0, 736}, {6411, 2, 0}, {6413, 0, 737}, {6413, 1, 0}, {6414, 1, 0}, {6415, 2, 0}, {6417, 1, 0}, {6418, 1, 0},
+		{6419, 1, 0}, {6420, 1, 0}, {6421, 0, 743}, {6421, 0, 744}, {6421, 0, 747}, {6421, 0, 749}, {6421, 0, 750}, {6421, 2, 0},
+		{6423, 0, 753}, {6423, 0, 754}, {6423, 2, 0}, {6425, 1, 0}, {6426, 1, 0}, {6427, 1, 0}, {6428, 1, 0}, {6429, 0, 762},
+		{6429, 2, 0}, {6431, 1, 0}, {6432, 1, 0}, {6433, 1, 0}, {6434, 1, 768}, {6435, 1, 0}, {6436, 0, 766}, {6436, 0, 767},
+		{6436, 1, 0}, {6437, 0, 769}, {6437, 1, 0}, {6438, 1, 0}, {6439, 1, 0}, {6440, 0, 236}, {6440, 1, 0}, {6441, 0, 78},
+		{6441, 1, 0}, {6442, 1, 0}, {6443, 0, 778}, {6443, 1, 0}, {6444, 0, 781}, {6444, 1, 0}, {6445, 1, 0}, {6446, 0, 231},
+		{6446, 0, 406}, {6446, 1, 0}, {6447, 0, 785}, {6447, 1, 0}, {6448, 0, 787}, {6448, 2, 788}, {6450, 1, 0}, {6451, 1, 0},
+		{6452, 1, 0}, {6453, 1, 0}, {6454, 0, 86}, {6454, 1, 0}, {6455, 1, 0}, {6456, 1, 0}, {6457, 1, 0}, {6458, 1, 0},
+		{6459, 1, 0}, {6460, 1, 0}, {6461, 1, 0}, {6462, 1, 0}, {6463, 1, 0}, {6464, 1, 0}, {6465, 1, 0}, {6466, 0, 805},
+		{6466, 0, 636}, {6466, 0, 100}, {6466, 0, 397}, {6466, 1, 0}, {6467, 1, 0}, {6468, 0, 779}, {6468, 1, 810}, {6469, 1, 0},
+		{6470, 1, 0}, {6471, 0, 814}, {6471, 1, 0}, {6472, 0, 818}, {6472, 1, 821}, {6473, 1, 0}, {6474, 1, 0}, {6475, 1, 0},
+		{6476, 0, 829}, {6476, 0, 830}, {6476, 1, 0}, {6477, 1, 0}, {6478, 1, 0}, {6479, 1, 0}, {6480, 0, 836}, {6480, 1, 0},
+		{6481, 0, 840}, {6481, 1, 0}, {6482, 0, 842}, {6482, 1, 0}, {6483, 1, 0}, {6484, 0, 130}, {6484, 1, 0}, {6485, 1, 0},
+		{6486, 1, 0}, {6487, 0, 54}, {6487, 1, 0}, {6488, 1, 0}, {6489, 0, 847}, {6489, 0, 848}, {6489, 0, 86}, {6489, 0, 136},
+		{6489, 1, 0}, {6490, 1, 0}, {6491, 1, 0}, {6492, 1, 0}, {6493, 1, 0}, {6494, 0, 858}, {6494, 1, 0}, {6495, 0, 861},
+		{6495, 0, 862}, {6495, 0, 864}, {6495, 0, 865}, {6495, 1, 0}, {6496, 0, 869}, {6496, 1, 0}, {6497, 1, 871}, {6498, 1, 0},
+		{6499, 1, 873}, {6500, 1, 0}, {6501, 1, 0}, {6502, 1, 0}, {6503, 1, 0}, {6504, 1, 0}, {6505, 1, 879}, {6506, 1, 0},
+		{6507, 1, 0}, {6508, 1, 0}, {6509, 1, 0}, {6510, 1, 0}, {6511, 1, 0}, {6512, 0, 886}, {6512, 0, 887}, {6512, 1, 0},
+		{6513, 0, 891}, {6513, 1, 0}, {6514, 1, 0}, {6515, 0, 894}, {6515, 1, 0}, {6516, 0, 897}, {6516, 2, 0}, {6518, 1, 0},
+		{6519, 0, 904}, {6519, 1, 0}, {6520, 0, 909}, {6520, 0, 912}, {6520, 0, 79}, {6520, 0, 915}, {6520, 0, 916}, {6520, 0, 918},
+		{6520, 0, 919}, {6520, 1, 0}, {6521, 1, 0}, {6522, 1, 0}, {6523, 1, 0}, {6524, 1, 0}, {6525, 1, 0}, {6526, 1, 0},
+		{6527, 1, 0}, {6528, 1, 0}, {6529, 1, 0}, {6530, 1, 0}, {6531, 1, 0}, {6532, 1, 0}, {6533, 1, 0}, {6534, 0, 926},
+		{6534, 0, 928}, {6534, 2, 0}, {6536, 1, 0}, {6537, 1, 0}, {6538, 1, 0}, {6539, 1, 0}, {6540, 0, 935}, {6540, 1, 0},
+		{6541, 1, 0}, {6542, 1, 0}, {6543, 1, 0}, {6544, 1, 0}, {6545, 1, 0}, {6546, 1, 0}, {6547, 1, 0}, {6548, 1, 944},
+		{6549, 0, 945}, {6549, 0, 948}, {6549, 1, 950}, {6550, 1, 0}, {6551, 0, 952}, {6551, 1, 0}, {6552, 0, 954}, {6552, 0, 955},
+		{6552, 0, 956}, {6552, 0, 183}, {6552, 1, 0}, {6553, 1, 0}, {6554, 0, 957}, {6554, 0, 959}, {6554, 1, 0}, {6555, 0, 961},
+		{6555, 1, 0}, {6556, 1, 0}, {6557, 1, 0}, {6558, 1, 0}, {6559, 1, 0}, {6560, 1, 0}, {6561, 1, 0}, {6562, 0, 967},
+		{6562, 0, 965}, {6562, 1, 970}, {6563, 0, 972}, {6563, 1, 0}, {6564, 0, 974}, {6564, 1, 0}, {6565, 0, 122}, {6565, 1, 0},
+		{6566, 0, 977}, {6566, 0, 979}, {6566, 0, 982}, {6566, 1, 0}, {6567, 0, 986}, {6567, 0, 987}, {6567, 1, 0}, {6568, 1, 0},
+		{6569, 1, 0}, {6570, 0, 256}, {6570, 1, 0}, {6571, 0, 998}, {6571, 1, 0}, {6572, 1, 0}, {6573, 1, 0}, {6574, 1, 0},
+		{6575, 0, 1004}, {6575, 1, 0}, {6576, 0, 1006}, {6576, 0, 226}, {6576, 1, 0}, {6577, 1, 0}, {6578, 1, 0}, {6579, 1, 0},
+		{6580, 1, 0}, {6581, 1, 0}, {6582, 1, 0}, {6583, 1, 0}, {6584, 0, 1016}, {6584, 0, 1017}, {6584, 0, 1018}, {6584, 1, 0},
+		{6585, 1, 0}, {6586, 0, 1021}, {6586, 2, 0}, {6588, 1, 0}, {6589, 1, 0}, {6590, 0, 228}, {6590, 0, 352}, {6590, 1, 0},
+		{6591, 1, 0}, {6592, 1, 0}, {6593, 1, 0}, {6594, 1, 0}, {6595, 1, 0}, {6596, 1, 0}, {6597, 1, 0}, {6598, 1, 0},
+		{6599, 0, 1034}, {6599, 2, 0}, {6601, 1, 0}, {6602, 1, 0}, {6603, 1, 0}, {6604, 2, 0}, {6606, 1, 0}, {6607, 1, 0},
+		{6608, 1, 0}, {6609, 1, 0}, {6610, 0, 104}, {6610, 0, 246}, {6610, 1, 0}, {6611, 0, 1045}, {6611, 0, 1032}, {6611, 1, 0},
+		{6612, 1, 0}, {6613, 0, 1048}, {6613, 1, 0}, {6614, 2, 0}, {6616, 1, 0}, {6617, 0, 1051}, {6617, 0, 1054}, {6617, 0, 1055},
+		{6617, 0, 1056}, {6617, 0, 229}, {6617, 1, 0}, {6618, 1, 0}, {6619, 1, 0}, {6620, 1, 0}, {6621, 0, 1059}, {6621, 1, 0},
+		{6622, 1, 0}, {6623, 1, 0}, {6624, 1, 0}, {6625, 1, 0}, {6626, 1, 0}, {6627, 0, 227}, {6627, 1, 0}, {6628, 0, 396},
+		{6628, 1, 0}, {6629, 0, 1069}, {6629, 1, 0}, {6630, 0, 1072}, {6630, 0, 1073}, {6630, 0, 353}, {6630, 1, 0}, {6631, 1, 0},
+		{6632, 0, 1077}, {6632, 0, 1040}, {6632, 1, 0}, {6633, 1, 0}, {6634, 1, 0}, {6635, 1, 0}, {6636, 1, 0}, {6637, 0, 241},
+		{6637, 0, 637}, {6637, 1, 0}, {6638, 1, 0}, {6639, 1, 0}, {6640, 0, 1086}, {6640, 1, 0}, {6641, 4, 0}, {6645, 1, 0},
+		{6646, 1, 0}, {6647, 0, 1093}, {6647, 1, 0}, {6648, 0, 1096}, {6648, 1, 0}, {6649, 1, 0}, {6650, 1, 43}, {6651, 0, 1098},
+		{6651, 1, 0}, {6652, 2, 0}, {6654, 1, 0}, {6655, 1, 0}, {6656, 1, 0}, {6657, 1, 0}, {6658, 0, 1105}, {6658, 1, 0},
+		{6659, 1, 0}, {6660, 1, 0}, {6661, 1, 0}, {6662, 1, 0}, {6663, 0, 73}, {6663, 1, 0}, {6664, 0, 1118}, {6664, 0, 1119},
+		{6664, 1, 0}, {6665, 0, 1120}, {6665, 1, 0}, {6666, 0, 301}, {6666, 1, 0}, {6667, 1, 0}, {6668, 1, 0}, {6669, 0, 335},
+		{6669, 1, 0}, {6670, 0, 1127}, {6670, 0, 1128}, {6670, 1, 0}, {6671, 0, 509}, {6671, 1, 0}, {6672, 0, 1130}, {6672, 1, 0},
+		{6673, 1, 0}, {6674, 0, 292}, {6674, 2, 0}, {6676, 0, 296}, {6676, 1, 0}, {6677, 0, 300}, {6677, 0, 1109}, {6677, 0, 1133},
+		{6677, 0, 1134}, {6677, 0, 1108}, {6677, 0, 1139}, {6677, 1, 0}, {6678, 1, 0}, {6679, 0, 1138}, {6679, 1, 0}, {6680, 0, 306},
+		{6680, 0, 311}, {6680, 0, 303}, {6680, 1, 0}, {6681, 0, 290}, {6681, 1, 0}, {6682, 1, 0}, {6683, 3, 0}, {6686, 0, 317},
+		{6686, 3, 0}, {6689, 1, 0}, {6690, 1, 0}, {6691, 0, 1150}, {6691, 1, 0}, {6692, 1, 0}, {6693, 0, 314}, {6693, 1, 0},
+		{6694, 0, 1112}, {6694, 0, 1152}, {6694, 1, 0}, {6695, 1, 0}, {6696, 1, 0}, {6697, 0, 318}, {6697, 1, 0}, {6698, 1, 0},
+		{6699, 2, 0}, {6701, 0, 334}, {6701, 1, 0}, {6702, 0, 337}, {6702, 0, 289}, {6702, 1, 0}, {6703, 1, 0}, {6704, 0, 1157},
+		{6704, 0, 326}, {6704, 1, 0}, {6705, 0, 327}, {6705, 1, 0}, {6706, 0, 1159}, {6706, 0, 332}, {6706, 1, 0}, {6707, 1, 1160},
+		{6708, 1, 0}, {6709, 1, 0}, {6710, 0, 1164}, {6710, 1, 0}, {6711, 1, 0}, {6712, 1, 0}, {6713, 1, 0}, {6714, 1, 0},
+		{6715, 1, 0}, {6716, 1, 0}, {6717, 1, 0}, {6718, 0, 1178}, {6718, 0, 1179}, {6718, 1, 0}, {6719, 0, 508}, {6719, 1, 0},
+		{6720, 1, 0}, {6721, 1, 1182}, {6722, 0, 1183}, {6722, 0, 731}, {6722, 1, 0}, {6723, 1, 0}, {6724, 0, 1188}, {6724, 0, 1189},
+		{6724, 1, 1192}, {6725, 0, 1194}, {6725, 0, 726}, {6725, 0, 1195}, {6725, 1, 0}, {6726, 0, 360}, {6726, 0, 1198}, {6726, 0, 1199},
+		{6726, 1, 0}, {6727, 1, 0}, {6728, 0, 1204}, {6728, 0, 47}, {6728, 0, 727}, {6728, 0, 1207}, {6728, 1, 0}, {6729, 1, 0},
+		{6730, 1, 0}, {6731, 1, 1214}, {6732, 1, 1216}, {6733, 2, 0}, {6735, 0, 1219}, {6735, 1, 0}, {6736, 1, 0}, {6737, 0, 1222},
+		{6737, 1, 0}, {6738, 1, 0}, {6739, 1, 0}, {6740, 1, 0}, {6741, 1, 0}, {6742, 0, 1231}, {6742, 1, 0}, {6743, 2, 0},
+		{6745, 1, 0}, {6746, 1, 0}, {6747, 0, 679}, {6747, 1, 0}, {6748, 1, 0}, {6749, 1, 0}, {6750, 0, 1239}, {6750, 1, 369},
+		{6751, 1, 0}, {6752, 1, 0}, {6753, 1, 0}, {6754, 0, 1244}, {6754, 0, 374}, {6754, 1, 0}, {6755, 1, 0}, {6756, 1, 0},
+		{6757, 1, 0}, {6758, 1, 0}, {6759, 1, 0}, {6760, 1, 0}, {6761, 0, 1250}, {6761, 1, 0}, {6762, 0, 1247}, {6762, 1, 0},
+		{6763, 1, 0}, {6764, 1, 0}, {6765, 1, 0}, {6766, 1, 0}, {6767, 0, 375}, {6767, 1, 0}, {6768, 1, 0}, {6769, 1, 0},
+		{6770, 1, 0}, {6771, 1, 0}, {6772, 0, 1263}, {6772, 1, 0}, {6773, 0, 423}, {6773, 1, 0}, {6774, 0, 775}, {6774, 1, 0},
+		{6775, 0, 441}, {6775, 1, 0}, {6776, 0, 1269}, {6776, 0, 1270}, {6776, 1, 0}, {6777, 0, 1271}, {6777, 1, 0}, {6778, 0, 401},
+		{6778, 1, 0}, {6779, 0, 1274}, {6779, 1, 0}, {6780, 1, 0}, {6781, 1, 0}, {6782, 1, 0}, {6783, 1, 0}, {6784, 1, 0},
+		{6785, 0, 1279}, {6785, 1, 0}, {6786, 0, 1281}, {6786, 1, 0}, {6787, 0, 633}, {6787, 0, 1282}, {6787, 1, 0}, {6788, 1, 0},
+		{6789, 0, 1285}, {6789, 2, 0}, {6791, 1, 0}, {6792, 1, 0}, {6793, 0, 403}, {6793, 1, 0}, {6794, 0, 50}, {6794, 1, 0},
+		{6795, 1, 0}, {6796, 1, 0}, {6797, 0, 421}, {6797, 1, 0}, {6798, 1, 0}, {6799, 0, 107}, {6799, 0, 419}, {6799, 1, 0},
+		{6800, 1, 0}, {6801, 1, 0}, {6802, 1, 0}, {6803, 1, 0}, {6804, 1, 0}, {6805, 1, 0}, {6806, 1, 0}, {6807, 0, 402},
+		{6807, 0, 131}, {6807, 2, 0}, {6809, 0, 1305}, {6809, 0, 1306}, {6809, 0, 1307}, {6809, 0, 404}, {6809, 0, 1308}, {6809, 1, 0},
+		{6810, 1, 0}, {6811, 1, 0}, {6812, 1, 0}, {6813, 0, 1300}, {6813, 1, 0}, {6814, 0, 51}, {6814, 1, 0}, {6815, 1, 0},
+		{6816, 1, 0}, {6817, 0, 414}, {6817, 0, 638}, {6817, 1, 0}, {6818, 1, 0}, {6819, 1, 0}, {6820, 0, 1074}, {6820, 1, 0},
+		{6821, 0, 455}, {6821, 1, 0}, {6822, 0, 1327}, {6822, 0, 1329}, {6822, 1, 0}, {6823, 1, 0}, {6824, 0, 456}, {6824, 0, 798},
+		{6824, 0, 1334}, {6824, 0, 1335}, {6824, 1, 0}, {6825, 0, 263}, {6825, 1, 0}, {6826, 1, 0}, {6827, 1, 0}, {6828, 0, 20},
+		{6828, 1, 0}, {6829, 0, 1341}, {6829, 1, 0}, {6830, 1, 0}, {6831, 1, 0}, {6832, 0, 1345}, {6832, 1, 0}, {6833, 1, 0},
+		{6834, 1, 0}, {6835, 0, 476}, {6835, 0, 1349}, {6835, 0, 1351}, {6835, 0, 1352}, {6835, 1, 0}, {6836, 1, 0}, {6837, 0, 225},
+		{6837, 1, 0}, {6838, 1, 0}, {6839, 1, 0}, {6840, 0, 1357}, {6840, 0, 1360}, {6840, 1, 0}, {6841, 1, 0}, {6842, 2, 0},
+		{6844, 0, 443}, {6844, 1, 0}, {6845, 0, 448}, {6845, 1, 0}, {6846, 0, 444}, {6846, 1, 0}, {6847, 1, 0}, {6848, 0, 446},
+		{6848, 1, 0}, {6849, 1, 0}, {6850, 1, 0}, {6851, 1, 0}, {6852, 0, 400}, {6852, 1, 0}, {6853, 1, 0}, {6854, 1, 0},
+		{6855, 0, 629}, {6855, 1, 0}, {6856, 0, 1087}, {6856, 1, 0}, {6857, 1, 0}, {6858, 1, 0}, {6859, 0, 1376}, {6859, 0, 1377},
+		{6859, 1, 0}, {6860, 1, 0}, {6861, 3, 0}, {6864, 1, 0}, {6865, 1, 0}, {6866, 1, 0}, {6867, 1, 0}, {6868, 1, 0},
+		{6869, 1, 0}, {6870, 1, 0}, {6871, 1, 0}, {6872, 1, 0}, {6873, 1, 0}, {6874, 1, 0}, {6875, 1, 0}, {6876, 1, 0},
+		{6877, 1, 0}, {6878, 1, 0}, {6879, 1, 0}, {6880, 1, 0}, {6881, 0, 1395}, {6881, 0, 1396}, {6881, 1, 0}, {6882, 3, 0},
+		{6885, 1, 0}, {6886, 1, 0}, {6887, 1, 0}, {6888, 0, 1401}, {6888, 1, 0}, {6889, 0, 262}, {6889, 1, 0}, {6890, 1, 0},
+		{6891, 0, 1403}, {6891, 1, 0}, {6892, 1, 0}, {6893, 1, 0}, {6894, 1, 0}, {6895, 1, 0}, {6896, 1, 0}, {6897, 0, 1411},
+		{6897, 1, 0}, {6898, 1, 0}, {6899, 1, 0}, {6900, 1, 0}, {6901, 1, 0}, {6902, 0, 478}, {6902, 1, 0}, {6903, 0, 1413},
+		{6903, 0, 80}, {6903, 0, 1414}, {6903, 2, 0}, {6905, 1, 0}, {6906, 1, 0}, {6907, 1, 0}, {6908, 1, 0}, {6909, 1, 0},
+		{6910, 0, 462}, {6910, 1, 0}, {6911, 1, 0}, {6912, 1, 0}, {6913, 1, 0}, {6914, 0, 1427}, {6914, 1, 0}, {6915, 1, 0},
+		{6916, 0, 1432}, {6916, 1, 0}, {6917, 0, 622}, {6917, 1, 0}, {6918, 1, 1435}, {6919, 0, 1436}, {6919, 1, 0}, {6920, 0, 1438},
+		{6920, 0, 503}, {6920, 1, 0}, {6921, 0, 505}, {6921, 1, 0}, {6922, 1, 1443}, {6923, 0, 244}, {6923, 0, 417}, {6923, 0, 1444},
+		{6923, 2, 0}, {6925, 1, 0}, {6926, 0, 1447}, {6926, 0, 1448}, {6926, 0, 1449}, {6926, 1, 0}, {6927, 1, 0}, {6928, 1, 0},
+		{6929, 0, 499}, {6929, 0, 510}, {6929, 1, 0}, {6930, 1, 0}, {6931, 2, 0}, {6933, 1, 0}, {6934, 0, 1455}, {6934, 0, 1456},
+		{6934, 0, 1458}, {6934, 1, 0}, {6935, 0, 635}, {6935, 0, 1452}, {6935, 1, 0}, {6936, 0, 1463}, {6936, 0, 94}, {6936, 1, 0},
+		{6937, 1, 0}, {6938, 1, 0}, {6939, 1, 0}, {6940, 1, 0}, {6941, 0, 1233}, {6941, 1, 0}, {6942, 1, 0}, {6943, 1, 0},
+		{6944, 1, 0}, {6945, 1, 0}, {6946, 1, 0}, {6947, 1, 0}, {6948, 0, 409}, {6948, 1, 0}, {6949, 0, 1471}, {6949, 1, 0},
+		{6950, 1, 0}, {6951, 0, 239}, {6951, 0, 325}, {6951, 0, 331}, {6951, 0, 378}, {6951, 0, 412}, {6951, 1, 0}, {6952, 1, 0},
+		{6953, 1, 0}, {6954, 1, 0}, {6955, 1, 0}, {6956, 0, 1477}, {6956, 1, 0}, {6957, 0, 574}, {6957, 1, 0}, {6958, 1, 0},
+		{6959, 1, 0}, {6960, 0, 624}, {6960, 0, 630}, {6960, 0, 91}, {6960, 0, 259}, {6960, 0, 90}, {6960, 0, 258}, {6960, 0, 625},
+		{6960, 1, 0}, {6961, 1, 0}, {6962, 0, 92}, {6962, 0, 260}, {6962, 1, 0}, {6963, 1, 0}, {6964, 0, 631}, {6964, 1, 0},
+		{6965, 1, 1489}, {6966, 0, 1491}, {6966, 1, 0}, {6967, 1, 0}, {6968, 1, 0}, {6969, 1, 0}, {6970, 0, 3}, {6970, 0, 4},
+		{6970, 0, 8}, {6970, 1, 0}, {6971, 0, 17}, {6971, 0, 18}, {6971, 1, 0}, {6972, 0, 22}, {6972, 1, 0}, {6973, 1, 0},
+		{6974, 0, 30}, {6974, 0, 33}, {6974, 1, 0}, {6975, 1, 0}, {6976, 0, 37}, {6976, 0, 38}, {6976, 1, 0}, {6977, 1, 0},
+		{6978, 1, 0}, {6979, 1, 0}, {6980, 1, 0}, {6981, 1, 0}, {6982, 1, 0}, {6983, 1, 0}, {6984, 1, 0}, {6985, 0, 53},
+		{6985, 1, 0}, {6986, 0, 55}, {6986, 1, 0}, {6987, 1, 0}, {6988, 1, 0}, {6989, 0, 63}, {6989, 1, 0}, {6990, 0, 68},
+		{6990, 0, 71}, {6990, 1, 0}, {6991, 1, 0}, {6992, 1, 0}, {6993, 0, 85}, {6993, 1, 0}, {6994, 1, 0}, {6995, 1, 0},
+		{6996, 2, 0}, {6998, 1, 0}, {6999, 1, 0}, {7000, 1, 0}, {7001, 1, 0}, {7002, 1, 0}, {7003, 1, 0}, {7004, 1, 0},
+		{7005, 2, 0}, {7007, 1, 0}, {7008, 0, 112}, {7008, 0, 115}, {7008, 0, 116}, {7008, 0, 121}, {7008, 1, 0}, {7009, 1, 0},
+		{7010, 1, 0}, {7011, 1, 0}, {7012, 1, 0}, {7013, 1, 0}, {7014, 0, 136}, {7014, 1, 0}, {7015, 1, 0}, {7016, 1, 0},
+		{7017, 0, 143}, {7017, 1, 0}, {7018, 0, 148}, {7018, 0, 149}, {7018, 0, 150}, {7018, 6, 0}, {7024, 0, 166}, {7024, 1, 0},
+		{7025, 1, 0}, {7026, 0, 174}, {7026, 1, 0}, {7027, 1, 0}, {7028, 0, 179}, {7028, 0, 184}, {7028, 1, 0}, {7029, 1, 0},
+		{7030, 1, 0}, {7031, 1, 0}, {7032, 1, 0}, {7033, 0, 196}, {7033, 0, 204}, {7033, 0, 209}, {7033, 0, 216}, {7033, 0, 217},
+		{7033, 1, 0}, {7034, 0, 221}, {7034, 0, 222}, {7034, 1, 0}, {7035, 1, 0}, {7036, 1, 0}, {7037, 1, 0}, {7038, 1, 0},
+		{7039, 1, 0}, {7040, 1, 0}, {7041, 3, 0}, {7044, 1, 0}, {7045, 1, 0}, {7046, 1, 0}, {7047, 1, 0}, {7048, 1, 0},
+		{7049, 1, 0}, {7050, 1, 0}, {7051, 1, 0}, {7052, 1, 0}, {7053, 1, 0}, {7054, 1, 0}, {7055, 1, 0}, {7056, 1, 0},
+		{7057, 1, 0}, {7058, 0, 257}, {7058, 1, 0}, {7059, 1, 0}, {7060, 1, 0}, {7061, 1, 0}, {7062, 1, 0}, {7063, 1, 0},
+		{7064, 0, 265}, {7064, 1, 0}, {7065, 1, 0}, {7066, 1, 0}, {7067, 0, 276}, {7067, 0, 277}, {7067, 0, 278}, {7067, 1, 0},
+		{7068, 1, 0}, {7069, 1, 0}, {7070, 1, 0}, {7071, 1, 0}, {7072, 1, 0}, {7073, 1, 0}, {7074, 1, 0}, {7075, 1, 0},
+		{7076, 1, 0}, {7077, 1, 0}, {7078, 1, 0}, {7079, 1, 0}, {7080, 2, 0}, {7082, 1, 0}, {7083, 6, 0}, {7089, 1, 0},
+		{7090, 1, 0}, {7091, 1, 0}, {7092, 1, 0}, {7093, 1, 0}, {7094, 1, 0}, {7095, 1, 0}, {7096, 1, 0}, {7097, 1, 0},
+		{7098, 1, 0}, {7099, 0, 339}, {7099, 0, 342}, {7099, 0, 345}, {7099, 0, 347}, {7099, 1, 0}, {7100, 1, 0}, {7101, 0, 356},
+		{7101, 0, 357}, {7101, 0, 358}, {7101, 1, 0}, {7102, 1, 0}, {7103, 1, 0}, {7104, 1, 0}, {7105, 1, 0}, {7106, 1, 0},
+		{7107, 1, 0}, {7108, 1, 0}, {7109, 1, 0}, {7110, 0, 387}, {7110, 0, 390}, {7110, 0, 391}, {7110, 0, 392}, {7110, 2, 0},
+		{7112, 1, 0}, {7113, 1, 0}, {7114, 1, 0}, {7115, 2, 0}, {7117, 1, 0}, {7118, 1, 0}, {7119, 1, 0}, {7120, 3, 0},
+		{7123, 1, 0}, {7124, 1, 0}, {7125, 1, 0}, {7126, 1, 0}, {7127, 1, 0}, {7128, 0, 427}, {7128, 0, 429}, {7128, 0, 430},
+		{7128, 0, 432}, {7128, 0, 433}, {7128, 1, 0}, {7129, 1, 0}, {7130, 1, 0}, {7131, 1, 0}, {7132, 1, 0}, {7133, 0, 442},
+		{7133, 1, 0}, {7134, 1, 0}, {7135, 1, 0}, {7136, 0, 451}, {7136, 1, 0}, {7137, 1, 0}, {7138, 1, 0}, {7139, 1, 0},
+		{7140, 0, 458}, {7140, 0, 467}, {7140, 0, 468}, {7140, 1, 0}, {7141, 1, 0}, {7142, 1, 0}, {7143, 1, 0}, {7144, 1, 0},
+		{7145, 1, 0}, {7146, 1, 0}, {7147, 0, 482}, {7147, 0, 483}, {7147, 1, 0}, {7148, 0, 487}, {7148, 0, 490}, {7148, 0, 492},
+		{7148, 1, 0}, {7149, 1, 0}, {7150, 1, 0}, {7151, 1, 0}, {7152, 3, 0}, {7155, 1, 0}, {7156, 1, 0}, {7157, 1, 0},
+		{7158, 1, 0}, {7159, 1, 0}, {7160, 1, 0}, {7161, 1, 0}, {7162, 1, 0}, {7163, 0, 514}, {7163, 0, 520}, {7163, 0, 522},
+		{7163, 1, 0}, {7164, 1, 0}, {7165, 0, 531}, {7165, 0, 544}, {7165, 0, 552}, {7165, 0, 553}, {7165, 1, 0}, {7166, 0, 560},
+		{7166, 0, 561}, {7166, 0, 569}, {7166, 1, 0}, {7167, 0, 575}, {7167, 1, 0}, {7168, 0, 581}, {7168, 8, 0}, {7176, 2, 0},
+		{7178, 0, 593}, {7178, 1, 0}, {7179, 0, 598}, {7179, 0, 478}, {7179, 1, 0}, {7180, 1, 0}, {7181, 0, 605}, {7181, 1, 0},
+		{7182, 1, 0}, {7183, 1, 0}, {7184, 1, 0}, {7185, 2, 0}, {7187, 0, 614}, {7187, 0, 615}, {7187, 1, 0}, {7188, 1, 0},
+		{7189, 0, 24}, {7189, 1, 0}, {7190, 1, 0}, {7191, 0, 25}, {7191, 1, 0}, {7192, 0, 189}, {7192, 1, 0}, {7193, 0, 498},
+		{7193, 1, 0}, {7194, 1, 0}, {7195, 1, 0}, {7196, 1, 0}, {7197, 1, 0}, {7198, 1, 0}, {7199, 1, 0}, {7200, 0, 521},
+		{7200, 1, 0}, {7201, 0, 633}, {7201, 1, 0}, {7202, 1, 0}, {7203, 1, 0}, {7204, 1, 0}, {7205, 0, 506}, {7205, 0, 648},
+		{7205, 0, 669}, {7205, 1, 0}, {7206, 1, 0}, {7207, 1, 0}, {7208, 0, 611}, {7208, 0, 692}, {7208, 1, 0}, {7209, 0, 698},
+		{7209, 0, 175}, {7209, 0, 700}, {7209, 0, 702}, {7209, 1, 0}, {7210, 0, 704}, {7210, 0, 705}, {7210, 0, 706}, {7210, 0, 710},
+		{7210, 0, 711}, {7210, 1, 0}, {7211, 1, 0}, {7212, 1, 0}, {7213, 1, 0}, {7214, 0, 725}, {7214, 1, 0}, {7215, 5, 0},
+		{7220, 1, 0}, {7221, 0, 733}, {7221, 1, 0}, {7222, 1, 0}, {7223, 0, 17}, {7223, 1, 0}, {7224, 0, 738}, {7224, 0, 439},
+		{7224, 1, 0}, {7225, 1, 0}, {7226, 1, 0}, {7227, 0, 56}, {7227, 0, 58}, {7227, 0, 742}, {7227, 1, 0}, {7228, 1, 0},
+		{7229, 0, 755}, {7229, 1, 0}, {7230, 1, 0}, {7231, 0, 759}, {7231, 0, 760}, {7231, 0, 761}, {7231, 0, 764}, {7231, 1, 0},
+		{7232, 2, 0}, {7234, 1, 0}, {7235, 1, 0}, {7236, 0, 768}, {7236, 1, 0}, {7237, 1, 0}, {7238, 0, 770}, {7238, 0, 772},
+		{7238, 0, 773}, {7238, 1, 0}, {7239, 0, 776}, {7239, 1, 0}, {7240, 1, 0}, {7241, 1, 0}, {7242, 0, 783}, {7242, 2, 0},
+		{7244, 1, 0}, {7245, 0, 788}, {7245, 1, 0}, {7246, 0, 789}, {7246, 0, 791}, {7246, 0, 792}, {7246, 0, 793}, {7246, 1, 0},
+		{7247, 1, 0}, {7248, 1, 0}, {7249, 1, 0}, {7250, 1, 0}, {7251, 1, 0}, {7252, 1, 0}, {7253, 1, 0}, {7254, 1, 0},
+		{7255, 0, 799}, {7255, 0, 800}, {7255, 0, 804}, {7255, 1, 0}, {7256, 1, 0}, {7257, 0, 810}, {7257, 0, 811}, {7257, 0, 812},
+		{7257, 0, 815}, {7257, 0, 821}, {7257, 0, 823}, {7257, 1, 0}, {7258, 0, 828}, {7258, 1, 0}, {7259, 0, 830}, {7259, 0, 831},
+		{7259, 0, 832}, {7259, 0, 839}, {7259, 1, 0}, {7260, 0, 813}, {7260, 1, 0}, {7261, 2, 0}, {7263, 0, 843}, {7263, 0, 844},
+		{7263, 1, 0}, {7264, 1, 0}, {7265, 1, 0}, {7266, 1, 0}, {7267, 1, 0}, {7268, 0, 856}, {7268, 0, 857}, {7268, 0, 859},
+		{7268, 0, 143}, {7268, 1, 0}, {7269, 0, 871}, {7269, 0, 872}, {7269, 0, 873}, {7269, 0, 874}, {7269, 0, 875}, {7269, 0, 876},
+		{7269, 0, 877}, {7269, 0, 878}, {7269, 0, 879}, {7269, 0, 880}, {7269, 0, 881}, {7269, 0, 882}, {7269, 0, 883}, {7269, 0, 884},
+		{7269, 0, 885}, {7269, 0, 890}, {7269, 0, 786}, {7269, 0, 893}, {7269, 1, 0}, {7270, 0, 898}, {7270, 2, 0}, {7272, 0, 902},
+		{7272, 1, 0}, {7273, 0, 920}, {7273, 1, 0}, {7274, 1, 0}, {7275, 0, 922}, {7275, 0, 919}, {7275, 1, 0}, {7276, 1, 0},
+		{7277, 1, 0}, {7278, 0, 163}, {7278, 1, 0}, {7279, 0, 527}, {7279, 0, 171}, {7279, 0, 924}, {7279, 1, 0}, {7280, 0, 929},
+		{7280, 1, 0}, {7281, 0, 930}, {7281, 0, 931}, {7281, 1, 0}, {7282, 1, 0}, {7283, 0, 936}, {7283, 1, 0}, {7284, 1, 0},
+		{7285, 0, 940}, {7285, 0, 927}, {7285, 0, 942}, {7285, 0, 943}, {7285, 0, 774}, {7285, 0, 944}, {7285, 0, 950}, {7285, 0, 951},
+		{7285, 0, 953}, {7285, 1, 0}, {7286, 1, 0}, {7287, 0, 960}, {7287, 1, 0}, {7288, 0, 957}, {7288, 0, 963}, {7288, 1, 0},
+		{7289, 1, 0}, {7290, 1, 0}, {7291, 1, 0}, {7292, 0, 970}, {7292, 1, 0}, {7293, 0, 975}, {7293, 0, 976}, {7293, 0, 985},
+		{7293, 0, 988}, {7293, 0, 989}, {7293, 0, 992}, {7293, 0, 997}, {7293, 0, 1001}, {7293, 1, 0}, {7294, 0, 219}, {7294, 0, 1003},
+		{7294, 0, 224}, {7294, 1, 0}, {7295, 0, 1008}, {7295, 0, 937}, {7295, 0, 1009}, {7295, 0, 1010}, {7295, 1, 0}, {7296, 0, 1012},
+		{7296, 0, 1014}, {7296, 0, 1019}, {7296, 0, 1020}, {7296, 1, 0}, {7297, 1, 0}, {7298, 0, 1024}, {7298, 0, 1025}, {7298, 0, 618},
+		{7298, 1, 0}, {7299, 1, 0}, {7300, 1, 0}, {7301, 1, 0}, {7302, 1, 0}, {7303, 1, 0}, {7304, 1, 0}, {7305, 1, 0},
+		{7306, 0, 1035}, {7306, 2, 0}, {7308, 0, 1039}, {7308, 1, 0}, {7309, 1, 0}, {7310, 1, 0}, {7311, 1, 0}, {7312, 1, 0},
+		{7313, 1, 0}, {7314, 0, 1041}, {7314, 0, 233}, {7314, 0, 1044}, {7314, 1, 0}, {7315, 0, 1047}, {7315, 0, 1049}, {7315, 0, 1050},
+		{7315, 1, 0}, {7316, 1, 0}, {7317, 1, 0}, {7318, 1, 0}, {7319, 1, 0}, {7320, 1, 0}, {7321, 0, 1061}, {7321, 1, 0},
+		{7322, 0, 1063}, {7322, 0, 494}, {7322, 1, 0}, {7323, 0, 1066}, {7323, 1, 0}, {7324, 0, 1067}, {7324, 0, 1070}, {7324, 0, 1074},
+		{7324, 0, 1075}, {7324, 0, 1033}, {7324, 0, 1078}, {7324, 0, 1079}, {7324, 1, 0}, {7325, 0, 1081}, {7325, 1, 0}, {7326, 1, 0},
+		{7327, 1, 0}, {7328, 1, 0}, {7329, 0, 410}, {7329, 1, 0}, {7330, 1, 0}, {7331, 1, 0}, {7332, 0, 1090}, {7332, 0, 1091},
+		{7332, 1, 0}, {7333, 0, 604}, {7333, 0, 1097}, {7333, 0, 43}, {7333, 0, 678}, {7333, 0, 796}, {7333, 1, 0}, {7334, 0, 272},
+		{7334, 0, 1101}, {7334, 0, 562}, {7334, 1, 0}, {7335, 1, 0}, {7336, 1, 0}, {7337, 1, 0}, {7338, 0, 1113}, {7338, 0, 1114},
+		{7338, 0, 1115}, {7338, 1, 0}, {7339, 2, 0}, {7341, 0, 302}, {7341, 0, 1122}, {7341, 0, 1123}, {7341, 1, 0}, {7342, 0, 1129},
+		{7342, 1, 0}, {7343, 0, 287}, {7343, 0, 1131}, {7343, 0, 293}, {7343, 1, 0}, {7344, 1, 0}, {7345, 1, 0}, {7346, 1, 0},
+		{7347, 1, 0}, {7348, 0, 305}, {7348, 0, 1143}, {7348, 1, 0}, {7349, 1, 0}, {7350, 1, 0}, {7351, 1, 0}, {7352, 1, 0},
+		{7353, 1, 0}, {7354, 1, 0}, {7355, 1, 0}, {7356, 0, 1149}, {7356, 1, 0}, {7357, 0, 316}, {7357, 1, 0}, {7358, 0, 1153},
+		{7358, 0, 1154}, {7358, 1, 0}, {7359, 0, 320}, {7359, 0, 329}, {7359, 1, 0}, {7360, 1, 0}, {7361, 0, 334}, {7361, 1, 0},
+		{7362, 1, 0}, {7363, 2, 0}, {7365, 1, 0}, {7366, 2, 0}, {7368, 0, 1160}, {7368, 1, 0}, {7369, 0, 1163}, {7369, 0, 1165},
+		{7369, 0, 1166}, {7369, 0, 1168}, {7369, 1, 0}, {7370, 0, 1172}, {7370, 1, 0}, {7371, 0, 1176}, {7371, 1, 0}, {7372, 0, 1180},
+		{7372, 1, 0}, {7373, 0, 1181}, {7373, 0, 1182}, {7373, 0, 1185}, {7373, 0, 1187}, {7373, 0, 1192}, {7373, 1, 0}, {7374, 1, 0},
+		{7375, 0, 46}, {7375, 1, 0}, {7376, 0, 1210}, {7376, 1, 0}, {7377, 0, 1214}, {7377, 0, 1216}, {7377, 0, 48}, {7377, 1, 0},
+		{7378, 1, 0}, {7379, 0, 1221}, {7379, 0, 1224}, {7379, 0, 1225}, {7379, 0, 1226}, {7379, 1, 0}, {7380, 0, 270}, {7380, 1, 0},
+		{7381, 0, 927}, {7381, 1, 0}, {7382, 0, 927}, {7382, 1, 0}, {7383, 1, 0}, {7384, 0, 797}, {7384, 0, 1238}, {7384, 0, 369},
+		{7384, 1, 0}, {7385, 1, 0}, {7386, 1, 0}, {7387, 1, 0}, {7388, 1, 0}, {7389, 0, 373}, {7389, 1, 0}, {7390, 1, 0},
+		{7391, 1, 0}, {7392, 1, 0}, {7393, 0, 370}, {7393, 0, 1249}, {7393, 1, 0}, {7394, 1, 0}, {7395, 1, 0}, {7396, 0, 378},
+		{7396, 0, 1254}, {7396, 0, 1257}, {7396, 0, 1260}, {7396, 1, 0}, {7397, 1, 0}, {7398, 1, 0}, {7399, 0, 1264}, {7399, 0, 1267},
+		{7399, 1, 0}, {7400, 0, 399}, {7400, 0, 1272}, {7400, 1, 0}, {7401, 0, 1275}, {7401, 0, 938}, {7401, 0, 1058}, {7401, 0, 1276},
+		{7401, 1, 0}, {7402, 0, 1278}, {7402, 0, 1280}, {7402, 1, 0}, {7403, 0, 1283}, {7403, 0, 1284}, {7403, 1, 0}, {7404, 1, 0},
+		{7405, 0, 1288}, {7405, 0, 1289}, {7405, 1, 0}, {7406, 0, 50}, {7406, 1, 0}, {7407, 1, 0}, {7408, 0, 1295}, {7408, 0, 408},
+		{7408, 0, 1297}, {7408, 1, 0}, {7409, 1, 0}, {7410, 1, 0}, {7411, 1, 0}, {7412, 1, 0}, {7413, 1, 0}, {7414, 1, 0},
+		{7415, 0, 1304}, {7415, 1, 0}, {7416, 0, 1310}, {7416, 1, 0}, {7417, 0, 1313}, {7417, 1, 0}, {7418, 0, 1315}, {7418, 0, 51},
+		{7418, 0, 1301}, {7418, 0, 1317}, {7418, 1, 0}, {7419, 1, 0}, {7420, 0, 1322}, {7420, 0, 1325}, {7420, 0, 1326}, {7420, 0, 1330},
+		{7420, 1, 0}, {7421, 0, 933}, {7421, 1, 0}, {7422, 0, 1338}, {7422, 1, 0}, {7423, 0, 887}, {7423, 0, 1342}, {7423, 1, 0},
+		{7424, 1, 0}, {7425, 0, 1346}, {7425, 0, 1346}, {7425, 0, 1347}, {7425, 1, 0}, {7426, 1, 0}, {7427, 1, 0}, {7428, 0, 1355},
+		{7428, 1, 0}, {7429, 0, 1361}, {7429, 0, 1364}, {7429, 0, 1366}, {7429, 1, 0}, {7430, 0, 1367}, {7430, 0, 1368}, {7430, 0, 445},
+		{7430, 1, 0}, {7431, 0, 447}, {7431, 1, 0}, {7432, 0, 442}, {7432, 0, 141}, {7432, 0, 20}, {7432, 0, 1357}, {7432, 0, 450},
+		{7432, 1, 0}, {7433, 0, 1373}, {7433, 1, 0}, {7434, 1, 0}, {7435, 1, 0}, {7436, 1, 0}, {7437, 0, 1371}, {7437, 1, 0},
+		{7438, 1, 0}, {7439, 0, 1380}, {7439, 0, 1381}, {7439, 0, 1382}, {7439, 1, 0}, {7440, 1, 0}, {7441, 0, 454}, {7441, 1, 0},
+		{7442, 1, 0}, {7443, 1, 0}, {7444, 1, 0}, {7445, 0, 1388}, {7445, 1, 0}, {7446, 1, 0}, {7447, 1, 0}, {7448, 1, 0},
+		{7449, 1, 0}, {7450, 1, 0}, {7451, 1, 0}, {7452, 0, 459}, {7452, 1, 0}, {7453, 1, 0}, {7454, 0, 1398}, {7454, 0, 1399},
+		{7454, 0, 1400}, {7454, 0, 934}, {7454, 1, 0}, {7455, 0, 1402}, {7455, 0, 1404}, {7455, 0, 1406}, {7455, 0, 1407}, {7455, 0, 1409},
+		{7455, 0, 471}, {7455, 1, 0}, {7456, 1, 0}, {7457, 0, 1412}, {7457, 1, 0}, {7458, 1, 0}, {7459, 0, 474}, {7459, 0, 475},
+		{7459, 0, 680}, {7459, 1, 0}, {7460, 0, 1416}, {7460, 0, 1417}, {7460, 0, 1418}, {7460, 1, 0}, {7461, 0, 1421}, {7461, 1, 0},
+		{7462, 0, 1424}, {7462, 1, 0}, {7463, 1, 0}, {7464, 1, 0}, {7465, 1, 0}, {7466, 0, 1433}, {7466, 2, 0}, {7468, 0, 1435},
+		{7468, 0, 1437}, {7468, 0, 1440}, {7468, 0, 1441}, {7468, 0, 1443}, {7468, 0, 1445}, {7468, 1, 0}, {7469, 0, 1446}, {7469, 1, 0},
+		{7470, 1, 0}, {7471, 1, 0}, {7472, 1, 0}, {7473, 1, 0}, {7474, 0, 1453}, {7474, 1, 0}, {7475, 0, 1454}, {7475, 0, 1459},
+		{7475, 1, 0}, {7476, 0, 1464}, {7476, 1, 0}, {7477, 1, 0}, {7478, 1, 0}, {7479, 0, 1230}, {7479, 1, 0}, {7480, 0, 1299},
+		{7480, 1, 0}, {7481, 1, 0}, {7482, 1, 0}, {7483, 1, 0}, {7484, 1, 0}, {7485, 0, 1470}, {7485, 0, 1472}, {7485, 0, 524},
+		{7485, 0, 1466}, {7485, 0, 1465}, {7485, 0, 1468}, {7485, 0, 1467}, {7485, 1, 0}, {7486, 0, 1478}, {7486, 0, 1479}, {7486, 0, 1480},
+		{7486, 0, 526}, {7486, 0, 626}, {7486, 0, 627}, {7486, 0, 628}, {7486, 0, 632}, {7486, 0, 533}, {7486, 0, 1489}, {7486, 0, 1500},
+		{7486, 0, 1501}, {7486, 0, 557}, {7486, 1, 0}, {7487, 1, 0}, {7488, 1, 0}, {7489, 0, 24}, {7489, 1, 0}, {7490, 1, 0},
+		{7491, 0, 36}, {7491, 0, 40}, {7491, 0, 42}, {7491, 1, 0}, {7492, 1, 0}, {7493, 1, 0}, {7494, 1, 0}, {7495, 1, 0},
+		{7496, 1, 0}, {7497, 1, 0}, {7498, 1, 0}, {7499, 1, 0}, {7500, 1, 0}, {7501, 1, 0}, {7502, 1, 0}, {7503, 1, 0},
+		{7504, 0, 81}, {7504, 1, 0}, {7505, 1, 0}, {7506, 1, 0}, {7507, 2, 0}, {7509, 1, 0}, {7510, 1, 0}, {7511, 1, 0},
+		{7512, 2, 0}, {7514, 1, 0}, {7515, 1, 0}, {7516, 1, 0}, {7517, 3, 0}, {7520, 1, 0}, {7521, 0, 109}, {7521, 1, 0},
+		{7522, 1, 0}, {7523, 0, 122}, {7523, 1, 0}, {7524, 1, 0}, {7525, 0, 128}, {7525, 1, 0}, {7526, 1, 0}, {7527, 1, 0},
+		{7528, 1, 0}, {7529, 1, 0}, {7530, 1, 0}, {7531, 1, 0}, {7532, 1, 0}, {7533, 1, 0}, {7534, 1, 0}, {7535, 1, 0},
+		{7536, 1, 0}, {7537, 1, 0}, {7538, 1, 0}, {7539, 1, 0}, {7540, 1, 0}, {7541, 1, 0}, {7542, 0, 93}, {7542, 1, 0},
+		{7543, 1, 0}, {7544, 1, 0}, {7545, 1, 0}, {7546, 1, 0}, {7547, 1, 0}, {7548, 1, 0}, {7549, 1, 0}, {7550, 2, 0},
+		{7552, 1, 0}, {7553, 1, 0}, {7554, 0, 236}, {7554, 1, 0}, {7555, 1, 0}, {7556, 1, 0}, {7557, 1, 0}, {7558, 1, 0},
+		{7559, 1, 0}, {7560, 1, 0}, {7561, 1, 0}, {7562, 1, 0}, {7563, 1, 0}, {7564, 1, 0}, {7565, 1, 0}, {7566, 1, 0},
+		{7567, 1, 0}, {7568, 1, 0}, {7569, 1, 0}, {7570, 2, 0}, {7572, 1, 0}, {7573, 2, 0}, {7575, 1, 0}, {7576, 1, 0},
+		{7577, 1, 0}, {7578, 1, 0}, {7579, 1, 0}, {7580, 1, 0}, {7581, 3, 0}, {7584, 1, 0}, {7585, 1, 0}, {7586, 0, 281},
+		{7586, 0, 283}, {7586, 1, 0}, {7587, 1, 0}, {7588, 1, 0}, {7589, 1, 0}, {7590, 1, 0}, {7591, 2, 0}, {7593, 1, 0},
+		{7594, 1, 0}, {7595, 1, 0}, {7596, 1, 0}, {7597, 1, 0}, {7598, 0, 306}, {7598, 1, 0}, {7599, 1, 0}, {7600, 1, 0},
+		{7601, 1, 0}, {7602, 1, 0}, {7603, 1, 0}, {7604, 1, 0}, {7605, 1, 0}, {7606, 1, 0}, {7607, 1, 0}, {7608, 1, 0},
+		{7609, 1, 0}, {7610, 1, 0}, {7611, 4, 0}, {7615, 1, 0}, {7616, 0, 350}, {7616, 1, 0}, {7617, 0, 360}, {7617, 2, 0},
+		{7619, 1, 0}, {7620, 1, 0}, {7621, 1, 0}, {7622, 1, 0}, {7623, 4, 0}, {7627, 0, 377}, {7627, 1, 0}, {7628, 2, 0},
+		{7630, 1, 0}, {7631, 1, 0}, {7632, 1, 0}, {7633, 1, 0}, {7634, 1, 0}, {7635, 1, 0}, {7636, 1, 0}, {7637, 3, 0},
+		{7640, 1, 0}, {7641, 1, 0}, {7642, 1, 0}, {7643, 1, 0}, {7644, 1, 0}, {7645, 1, 0}, {7646, 1, 0}, {7647, 1, 0},
+		{7648, 1, 0}, {7649, 1, 0}, {7650, 1, 0}, {7651, 1, 0}, {7652, 1, 0}, {7653, 1, 0}, {7654, 1, 0}, {7655, 2, 0},
+		{7657, 1, 0}, {7658, 1, 0}, {7659, 4, 0}, {7663, 1, 0}, {7664, 2, 0}, {7666, 1, 0}, {7667, 1, 0}, {7668, 1, 0},
+		{7669, 1, 0}, {7670, 1, 0}, {7671, 1, 0}, {7672, 1, 0}, {7673, 1, 0}, {7674, 1, 0}, {7675, 1, 0}, {7676, 1, 0},
+		{7677, 1, 0}, {7678, 0, 437}, {7678, 1, 0}, {7679, 1, 0}, {7680, 1, 0}, {7681, 1, 0}, {7682, 1, 0}, {7683, 0, 95},
+		{7683, 1, 0}, {7684, 1, 0}, {7685, 1, 0}, {7686, 0, 511}, {7686, 4, 0}, {7690, 1, 0}, {7691, 1, 0}, {7692, 0, 570},
+		{7692, 1, 0}, {7693, 1, 0}, {7694, 1, 0}, {7695, 1, 0}, {7696, 1, 0}, {7697, 1, 0}, {7698, 1, 0}, {7699, 1, 0},
+		{7700, 1, 0}, {7701, 0, 591}, {7701, 1, 0}, {7702, 0, 594}, {7702, 1, 0}, {7703, 0, 63}, {7703, 1, 0}, {7704, 1, 0},
+		{7705, 1, 0}, {7706, 1, 0}, {7707, 0, 612}, {7707, 1, 0}, {7708, 1, 0}, {7709, 1, 0}, {7710, 0, 24}, {7710, 0, 619},
+		{7710, 0, 622}, {7710, 0, 624}, {7710, 0, 625}, {7710, 1, 0}, {7711, 1, 0}, {7712, 1, 0}, {7713, 0, 629}, {7713, 1, 0},
+		{7714, 1, 0}, {7715, 1, 0}, {7716, 1, 0}, {7717, 1, 0}, {7718, 1, 0}, {7719, 0, 645}, {7719, 1, 0}, {7720, 0, 679},
+		{7720, 1, 0}, {7721, 1, 0}, {7722, 1, 0}, {7723, 0, 714}, {7723, 0, 716}, {7723, 1, 0}, {7724, 1, 0}, {7725, 1, 0},
+		{7726, 1, 0}, {7727, 1, 0}, {7728, 1, 0}, {7729, 1, 0}, {7730, 1, 0}, {7731, 1, 0}, {7732, 0, 734}, {7732, 1, 0},
+		{7733, 0, 17}, {7733, 1, 0}, {7734, 1, 0}, {7735, 0, 740}, {7735, 0, 751}, {7735, 0, 752}, {7735, 0, 756}, {7735, 1, 0},
+		{7736, 0, 765}, {7736, 1, 0}, {7737, 1, 0}, {7738, 1, 0}, {7739, 1, 0}, {7740, 1, 0}, {7741, 1, 0}, {7742, 0, 775},
+		{7742, 0, 68}, {7742, 0, 779}, {7742, 0, 782}, {7742, 0, 81}, {7742, 1, 0}, {7743, 0, 786}, {7743, 1, 0}, {7744, 1, 0},
+		{7745, 1, 0}, {7746, 0, 797}, {7746, 1, 0}, {7747, 1, 0}, {7748, 1, 0}, {7749, 1, 0}, {7750, 1, 0}, {7751, 1, 0},
+		{7752, 0, 807}, {7752, 1, 0}, {7753, 1, 0}, {7754, 1, 0}, {7755, 0, 841}, {7755, 0, 815}, {7755, 1, 0}, {7756, 1, 0},
+		{7757, 0, 845}, {7757, 1, 0}, {7758, 1, 0}, {7759, 1, 0}, {7760, 1, 0}, {7761, 1, 0}, {7762, 1, 0}, {7763, 0, 899},
+		{7763, 1, 0}, {7764, 1, 0}, {7765, 0, 921}, {7765, 1, 0}, {7766, 1, 0}, {7767, 1, 0}, {7768, 0, 161}, {7768, 1, 0},
+		{7769, 0, 925}, {7769, 1, 0}, {7770, 1, 0}, {7771, 1, 0}, {7772, 1, 0}, {7773, 1, 0}, {7774, 1, 0}, {7775, 1, 0},
+		{7776, 1, 0}, {7777, 1, 0}, {7778, 1, 0}, {7779, 1, 0}, {7780, 0, 965}, {7780, 0, 973}, {7780, 1, 0}, {7781, 0, 1007},
+		{7781, 0, 1011}, {7781, 0, 1022}, {7781, 0, 1023}, {7781, 0, 1028}, {7781, 1, 0}, {7782, 1, 0}, {7783, 1, 0}, {7784, 1, 0},
+		{7785, 1, 0}, {7786, 1, 0}, {7787, 1, 0}, {7788, 0, 1036}, {7788, 1, 0}, {7789, 1, 0}, {7790, 0, 1040}, {7790, 1, 0},
+		{7791, 1, 0}, {7792, 0, 250}, {7792, 0, 253}, {7792, 1, 0}, {7793, 1, 0}, {7794, 1, 0}, {7795, 2, 0}, {7797, 1, 0},
+		{7798, 1, 0}, {7799, 1, 0}, {7800, 0, 1062}, {7800, 0, 1064}, {7800, 1, 0}, {7801, 0, 1080}, {7801, 1, 0}, {7802, 0, 1084},
+		{7802, 1, 0}, {7803, 0, 1089}, {7803, 1, 0}, {7804, 1, 0}, {7805, 1, 0}, {7806, 1, 0}, {7807, 0, 1099}, {7807, 1, 0},
+		{7808, 1, 0}, {7809, 1, 0}, {7810, 1, 0}, {7811, 0, 336}, {7811, 0, 1120}, {7811, 1, 0}, {7812, 1, 0}, {7813, 0, 509},
+		{7813, 0, 293}, {7813, 1, 0}, {7814, 1, 0}, {7815, 1, 0}, {7816, 1, 0}, {7817, 1, 0}, {7818, 0, 290}, {7818, 0, 1145},
+		{7818, 0, 1146}, {7818, 0, 317}, {7818, 0, 1147}, {7818, 0, 1148}, {7818, 1, 0}, {7819, 0, 1151}, {7819, 0, 315}, {7819, 1, 0},
+		{7820, 1, 0}, {7821, 1, 0}, {7822, 0, 322}, {7822, 0, 324}, {7822, 0, 325}, {7822, 1, 0}, {7823, 0, 328}, {7823, 0, 331},
+		{7823, 1, 0}, {7824, 1, 0}, {7825, 0, 1171}, {7825, 0, 1175}, {7825, 0, 1177}, {7825, 0, 508}, {7825, 0, 1196}, {7825, 0, 1200},
+		{7825, 0, 1207}, {7825, 0, 1212}, {7825, 1, 0}, {7826, 1, 0}, {7827, 0, 1227}, {7827, 1, 0}, {7828, 0, 1234}, {7828, 1, 0},
+		{7829, 0, 1237}, {7829, 0, 1240}, {7829, 0, 1241}, {7829, 1, 0}, {7830, 1, 0}, {7831, 1, 0}, {7832, 1, 0}, {7833, 1, 0},
+		{7834, 1, 0}, {7835, 0, 375}, {7835, 1, 0}, {7836, 1, 0}, {7837, 1, 0}, {7838, 1, 0}, {7839, 0, 1262}, {7839, 0, 844},
+		{7839, 1, 0}, {7840, 0, 1273}, {7840, 0, 1277}, {7840, 1, 0}, {7841, 0, 1286}, {7841, 0, 1287}, {7841, 0, 1292}, {7841, 0, 424},
+		{7841, 1, 0}, {7842, 1, 0}, {7843, 1, 0}, {7844, 1, 0}, {7845, 1, 0}, {7846, 1, 0}, {7847, 1, 0}, {7848, 1, 0},
+		{7849, 1, 0}, {7850, 0, 1311}, {7850, 1, 0}, {7851, 1, 0}, {7852, 0, 1320}, {7852, 1, 0}, {7853, 0, 263}, {7853, 1, 0},
+		{7854, 1, 0}, {7855, 1, 0}, {7856, 0, 1353}, {7856, 0, 1354}, {7856, 1, 0}, {7857, 1, 0}, {7858, 1, 0}, {7859, 2, 0},
+		{7861, 2, 0}, {7863, 2, 0}, {7865, 0, 1374}, {7865, 0, 1375}, {7865, 0, 1378}, {7865, 0, 1379}, {7865, 2, 0}, {7867, 1, 0},
+		{7868, 1, 0}, {7869, 1, 0}, {7870, 1, 0}, {7871, 1, 0}, {7872, 1, 0}, {7873, 0, 456}, {7873, 0, 1389}, {7873, 0, 1390},
+		{7873, 0, 1391}, {7873, 0, 1392}, {7873, 0, 1393}, {7873, 0, 1394}, {7873, 0, 1397}, {7873, 2, 0}, {7875, 1, 0}, {7876, 0, 262},
+		{7876, 1, 0}, {7877, 1, 0}, {7878, 1, 0}, {7879, 1, 0}, {7880, 1, 0}, {7881, 0, 1420}, {7881, 5, 0}, {7886, 1, 0},
+		{7887, 0, 1426}, {7887, 0, 1428}, {7887, 1, 0}, {7888, 1, 0}, {7889, 1, 0}, {7890, 1, 0}, {7891, 0, 437}, {7891, 1, 0},
+		{7892, 1, 0}, {7893, 0, 1451}, {7893, 1, 0}, {7894, 1, 0}, {7895, 0, 1461}, {7895, 1, 0}, {7896, 1, 0}, {7897, 1, 0},
+		{7898, 1, 0}, {7899, 1, 0}, {7900, 1, 0}, {7901, 1, 0}, {7902, 1, 0}, {7903, 1, 0}, {7904, 0, 1476}, {7904, 0, 1507},
+		{7904, 1, 0}, {7905, 1, 0}, {7906, 1, 0}, {7907, 1, 0}, {7908, 1, 0}, {7909, 1, 0}, {7910, 1, 0}, {7911, 1, 0},
+		{7912, 1, 0}, {7913, 1, 0}, {7914, 1, 0}, {7915, 1, 0}, {7916, 1, 0}, {7917, 1, 0}, {7918, 1, 0}, {7919, 0, 64},
+		{7919, 1, 0}, {7920, 1, 0}, {7921, 0, 86}, {7921, 1, 0}, {7922, 1, 0}, {7923, 1, 0}, {7924, 1, 0}, {7925, 1, 0},
+		{7926, 1, 0}, {7927, 1, 0}, {7928, 1, 0}, {7929, 1, 0}, {7930, 3, 0}, {7933, 1, 0}, {7934, 1, 0}, {7935, 1, 0},
+		{7936, 1, 0}, {7937, 2, 0}, {7939, 1, 0}, {7940, 1, 0}, {7941, 1, 0}, {7942, 1, 0}, {7943, 1, 0}, {7944, 1, 0},
+		{7945, 1, 0}, {7946, 1, 0}, {7947, 1, 0}, {7948, 1, 0}, {7949, 1, 0}, {7950, 1, 0}, {7951, 1, 0}, {7952, 1, 0},
+		{7953, 1, 0}, {7954, 1, 0}, {7955, 1, 0}, {7956, 1, 0}, {7957, 1, 0}, {7958, 1, 0}, {7959, 1, 0}, {7960, 0, 188},
+		{7960, 1, 0}, {7961, 2, 0}, {7963, 1, 0}, {7964, 1, 0}, {7965, 3, 0}, {7968, 1, 0}, {7969, 1, 0}, {7970, 1, 0},
+		{7971, 1, 0}, {7972, 1, 0}, {7973, 2, 0}, {7975, 1, 0}, {7976, 1, 0}, {7977, 1, 0}, {7978, 1, 0}, {7979, 1, 0},
+		{7980, 1, 0}, {7981, 1, 0}, {7982, 1, 0}, {7983, 1, 0}, {7984, 1, 0}, {7985, 1, 0}, {7986, 1, 0}, {7987, 0, 251},
+		{7987, 1, 0}, {7988, 1, 0}, {7989, 1, 0}, {7990, 1, 0}, {7991, 1, 0}, {7992, 1, 0}, {7993, 1, 0}, {7994, 1, 0},
+		{7995, 1, 0}, {7996, 1, 0}, {7997, 1, 0}, {7998, 1, 0}, {7999, 1, 0}, {8000, 1, 0}, {8001, 1, 0}, {8002, 1, 0},
+		{8003, 1, 0}, {8004, 1, 0}, {8005, 1, 0}, {8006, 1, 0}, {8007, 1, 0}, {8008, 1, 0}, {8009, 1, 0}, {8010, 1, 0},
+		{8011, 0, 291}, {8011, 1, 0}, {8012, 1, 0}, {8013, 1, 0}, {8014, 1, 0}, {8015, 1, 0}, {8016, 1, 0}, {8017, 1, 0},
+		{8018, 1, 0}, {8019, 1, 0}, {8020, 1, 0}, {8021, 1, 0}, {8022, 2, 0}, {8024, 1, 0}, {8025, 1, 0}, {8026, 1, 0},
+		{8027, 1, 0}, {8028, 2, 0}, {8030, 1, 0}, {8031, 1, 0}, {8032, 0, 333}, {8032, 1, 0}, {8033, 1, 0}, {8034, 1, 0},
+		{8035, 1, 0}, {8036, 2, 0}, {8038, 1, 0}, {8039, 1, 0}, {8040, 1, 0}, {8041, 0, 364}, {8041, 1, 0}, {8042, 1, 0},
+		{8043, 0, 372}, {8043, 1, 0}, {8044, 1, 0}, {8045, 1, 0}, {8046, 1, 0}, {8047, 1, 0}, {8048, 1, 0}, {8049, 1, 0},
+		{8050, 1, 0}, {8051, 1, 0}, {8052, 1, 0}, {8053, 1, 0}, {8054, 2, 0}, {8056, 1, 0}, {8057, 0, 409}, {8057, 1, 0},
+		{8058, 1, 0}, {8059, 1, 0}, {8060, 1, 0}, {8061, 1, 0}, {8062, 1, 0}, {8063, 1, 0}, {8064, 1, 0}, {8065, 1, 0},
+		{8066, 1, 0}, {8067, 1, 0}, {8068, 1, 0}, {8069, 1, 0}, {8070, 1, 0}, {8071, 1, 0}, {8072, 1, 0}, {8073, 1, 0},
+		{8074, 1, 0}, {8075, 1, 0}, {8076, 1, 0}, {8077, 1, 0}, {8078, 0, 453}, {8078, 1, 0}, {8079, 1, 0}, {8080, 1, 0},
+		{8081, 0, 395}, {8081, 0, 459}, {8081, 1, 0}, {8082, 1, 0}, {8083, 1, 0}, {8084, 1, 0}, {8085, 1, 0}, {8086, 1, 0},
+		{8087, 1, 0}, {8088, 1, 0}, {8089, 0, 485}, {8089, 0, 494}, {8089, 2, 0}, {8091, 1, 0}, {8092, 1, 0}, {8093, 1, 0},
+		{8094, 1, 0}, {8095, 1, 0}, {8096, 1, 0}, {8097, 1, 0}, {8098, 1, 0}, {8099, 1, 0}, {8100, 1, 0}, {8101, 1, 0},
+		{8102, 1, 0}, {8103, 1, 0}, {8104, 1, 0}, {8105, 1, 0}, {8106, 1, 0}, {8107, 0, 577}, {8107, 0, 582}, {8107, 0, 583},
+		{8107, 0, 584}, {8107, 0, 585}, {8107, 0, 586}, {8107, 0, 587}, {8107, 0, 588}, {8107, 0, 589}, {8107, 0, 592}, {8107, 0, 599},
+		{8107, 0, 59}, {8107, 0, 609}, {8107, 1, 0}, {8108, 1, 0}, {8109, 1, 0}, {8110, 0, 615}, {8110, 0, 616}, {8110, 0, 626},
+		{8110, 1, 0}, {8111, 0, 628}, {8111, 1, 0}, {8112, 0, 632}, {8112, 0, 533}, {8112, 1, 0}, {8113, 1, 0}, {8114, 1, 0},
+		{8115, 0, 678}, {8115, 0, 680}, {8115, 0, 697}, {8115, 0, 703}, {8115, 1, 0}, {8116, 1, 0}, {8117, 1, 0}, {8118, 0, 386},
+		{8118, 0, 728}, {8118, 1, 0}, {8119, 1, 0}, {8120, 1, 0}, {8121, 0, 732}, {8121, 0, 735}, {8121, 1, 0}, {8122, 1, 0},
+		{8123, 0, 758}, {8123, 1, 0}, {8124, 1, 0}, {8125, 0, 766}, {8125, 1, 0}, {8126, 1, 0}, {8127, 0, 49}, {8127, 1, 0},
+		{8128, 1, 0}, {8129, 0, 795}, {8129, 0, 796}, {8129, 1, 0}, {8130, 1, 0}, {8131, 1, 0}, {8132, 1, 0}, {8133, 1, 0},
+		{8134, 0, 385}, {8134, 0, 809}, {8134, 0, 825}, {8134, 0, 830}, {8134, 1, 0}, {8135, 1, 0}, {8136, 0, 846}, {8136, 1, 0},
+		{8137, 1, 0}, {8138, 1, 0}, {8139, 0, 870}, {8139, 0, 162}, {8139, 0, 900}, {8139, 0, 910}, {8139, 1, 0}, {8140, 1, 0},
+		{8141, 1, 0}, {8142, 1, 0}, {8143, 1, 0}, {8144, 0, 933}, {8144, 0, 934}, {8144, 1, 0}, {8145, 1, 0}, {8146, 0, 195},
+		{8146, 0, 183}, {8146, 0, 962}, {8146, 0, 558}, {8146, 0, 963}, {8146, 0, 964}, {8146, 0, 1002}, {8146, 0, 1029}, {8146, 2, 0},
+		{8148, 1, 0}, {8149, 1, 0}, {8150, 3, 0}, {8153, 1, 0}, {8154, 0, 252}, {8154, 0, 1037}, {8154, 1, 0}, {8155, 1, 0},
+		{8156, 1, 0}, {8157, 0, 791}, {8157, 1, 0}, {8158, 0, 1052}, {8158, 1, 0}, {8159, 1, 0}, {8160, 1, 0}, {8161, 1, 0},
+		{8162, 2, 0}, {8164, 0, 799}, {8164, 0, 1083}, {8164, 1, 0}, {8165, 1, 0}, {8166, 1, 0}, {8167, 0, 507}, {8167, 1, 0},
+		{8168, 0, 1105}, {8168, 1, 0}, {8169, 1, 0}, {8170, 1, 0}, {8171, 0, 285}, {8171, 0, 1124}, {8171, 1, 0}, {8172, 1, 0},
+		{8173, 1, 0}, {8174, 1, 0}, {8175, 0, 1144}, {8175, 1, 0}, {8176, 1, 0}, {8177, 1, 0}, {8178, 1, 0}, {8179, 2, 0},
+		{8181, 2, 0}, {8183, 2, 0}, {8185, 0, 1217}, {8185, 0, 97}, {8185, 1, 0}, {8186, 0, 1236}, {8186, 0, 1242}, {8186, 1, 0},
+		{8187, 1, 0}, {8188, 1, 0}, {8189, 0, 1248}, {8189, 0, 1249}, {8189, 0, 1251}, {8189, 0, 1252}, {8189, 0, 1253}, {8189, 1, 0},
+		{8190, 0, 1268}, {8190, 1, 0}, {8191, 0, 394}, {8191, 1, 0}, {8192, 1, 0}, {8193, 2, 0}, {8195, 1, 0}, {8196, 1, 0},
+		{8197, 1, 0}, {8198, 1, 0}, {8199, 1, 0}, {8200, 0, 1314}, {8200, 0, 1319}, {8200, 0, 1331}, {8200, 0, 20}, {8200, 0, 523},
+		{8200, 1, 0}, {8201, 1, 0}, {8202, 0, 1356}, {8202, 1, 0}, {8203, 0, 444}, {8203, 1, 0}, {8204, 0, 446}, {8204, 1, 0},
+		{8205, 1, 0}, {8206, 1, 0}, {8207, 0, 452}, {8207, 1, 0}, {8208, 2, 0}, {8210, 1, 0}, {8211, 1, 0}, {8212, 1, 0},
+		{8213, 0, 1328}, {8213, 0, 1330}, {8213, 0, 460}, {8213, 1, 0}, {8214, 2, 0}, {8216, 1, 0}, {8217, 0, 1412}, {8217, 1, 0},
+		{8218, 0, 475}, {8218, 0, 1415}, {8218, 0, 1422}, {8218, 1, 0}, {8219, 1, 0}, {8220, 1, 0}, {8221, 1, 0}, {8222, 0, 1425},
+		{8222, 0, 1429}, {8222, 1, 0}, {8223, 1, 0}, {8224, 0, 1445}, {8224, 1, 0}, {8225, 2, 0}, {8227, 1, 0}, {8228, 0, 1453},
+		{8228, 1, 0}, {8229, 0, 988}, {8229, 1, 0}, {8230, 1, 0}, {8231, 0, 1346}, {8231, 1, 0}, {8232, 1, 0}, {8233, 0, 1412},
+		{8233, 1, 0}, {8234, 1, 0}, {8235, 0, 20}, {8235, 1, 0}, {8236, 1, 0}, {8237, 0, 43}, {8237, 0, 45}, {8237, 1, 0},
+		{8238, 1, 0}, {8239, 1, 0}, {8240, 1, 0}, {8241, 2, 0}, {8243, 0, 54}, {8243, 1, 0}, {8244, 0, 58}, {8244, 1, 0},
+		{8245, 1, 0}, {8246, 1, 0}, {8247, 1, 0}, {8248, 0, 84}, {8248, 1, 0}, {8249, 3, 0}, {8252, 2, 0}, {8254, 1, 0},
+		{8255, 1, 0}, {8256, 1, 0}, {8257, 1, 0}, {8258, 0, 98}, {8258, 1, 0}, {8259, 1, 0}, {8260, 0, 101}, {8260, 1, 0},
+		{8261, 1, 0}, {8262, 1, 0}, {8263, 1, 0}, {8264, 1, 0}, {8265, 1, 0}, {8266, 0, 87}, {8266, 1, 0}, {8267, 1, 0},
+		{8268, 1, 0}, {8269, 1, 0}, {8270, 1, 0}, {8271, 1, 0}, {8272, 1, 0}, {8273, 1, 0}, {8274, 1, 0}, {8275, 1, 0},
+		{8276, 1, 0}, {8277, 1, 0}, {8278, 1, 0}, {8279, 1, 0}, {8280, 1, 0}, {8281, 1, 0}, {8282, 1, 0}, {8283, 0, 175},
+		{8283, 1, 0}, {8284, 1, 0}, {8285, 1, 0}, {8286, 1, 0}, {8287, 1, 0}, {8288, 1, 0}, {8289, 0, 225}, {8289, 1, 0},
+		{8290, 1, 0}, {8291, 1, 0}, {8292, 1, 0}, {8293, 1, 0}, {8294, 1, 0}, {8295, 0, 233}, {8295, 1, 0}, {8296, 1, 0},
+		{8297, 1, 0}, {8298, 1, 0}, {8299, 1, 0}, {8300, 1, 0}, {8301, 1, 0}, {8302, 1, 0}, {8303, 2, 0}, {8305, 0, 88},
+		{8305, 1, 0}, {8306, 1, 0}, {8307, 1, 0}, {8308, 1, 0}, {8309, 1, 0}, {8310, 0, 253}, {8310, 1, 0}, {8311, 1, 0},
+		{8312, 1, 0}, {8313, 1, 0}, {8314, 1, 0}, {8315, 1, 0}, {8316, 1, 0}, {8317, 1, 0}, {8318, 1, 0}, {8319, 1, 0},
+		{8320, 0, 270}, {8320, 0, 272}, {8320, 1, 0}, {8321, 1, 0}, {8322, 1, 0}, {8323, 1, 0}, {8324, 1, 0}, {8325, 1, 0},
+		{8326, 1, 0}, {8327, 0, 288}, {8327, 1, 0}, {8328, 1, 0}, {8329, 1, 0}, {8330, 0, 293}, {8330, 7, 0}, {8337, 1, 0},
+		{8338, 1, 0}, {8339, 1, 0}, {8340, 1, 0}, {8341, 1, 0}, {8342, 1, 0}, {8343, 1, 0}, {8344, 1, 0}, {8345, 1, 0},
+		{8346, 1, 0}, {8347, 1, 0}, {8348, 1, 0}, {8349, 1, 0}, {8350, 1, 0}, {8351, 0, 325}, {8351, 1, 0}, {8352, 1, 0},
+		{8353, 1, 0}, {8354, 1, 0}, {8355, 1, 0}, {8356, 1, 0}, {8357, 1, 0}, {8358, 1, 0}, {8359, 1, 0}, {8360, 0, 361},
+		{8360, 1, 0}, {8361, 1, 0}, {8362, 0, 369}, {8362, 1, 0}, {8363, 1, 0}, {8364, 1, 0}, {8365, 1, 0}, {8366, 2, 0},
+		{8368, 1, 0}, {8369, 1, 0}, {8370, 1, 0}, {8371, 1, 0}, {8372, 3, 0}, {8375, 1, 0}, {8376, 1, 0}, {8377, 1, 0},
+		{8378, 1, 0}, {8379, 1, 0}, {8380, 1, 0}, {8381, 1, 0}, {8382, 1, 0}, {8383, 1, 0}, {8384, 1, 0}, {8385, 1, 0},
+		{8386, 1, 0}, {8387, 1, 0}, {8388, 1, 0}, {8389, 1, 0}, {8390, 1, 0}, {8391, 1, 0}, {8392, 1, 0}, {8393, 1, 0},
+		{8394, 1, 0}, {8395, 1, 0}, {8396, 1, 0}, {8397, 1, 0}, {8398, 1, 0}, {8399, 1, 0}, {8400, 1, 0}, {8401, 1, 0},
+		{8402, 1, 0}, {8403, 1, 0}, {8404, 1, 0}, {8405, 0, 471}, {8405, 1, 0}, {8406, 0, 474}, {8406, 1, 0}, {8407, 1, 0},
+		{8408, 1, 0}, {8409, 0, 480}, {8409, 1, 0}, {8410, 1, 0}, {8411, 1, 0}, {8412, 0, 499}, {8412, 1, 0}, {8413, 1, 0},
+		{8414, 1, 0}, {8415, 1, 0}, {8416, 1, 0}, {8417, 1, 0}, {8418, 1, 0}, {8419, 1, 0}, {8420, 1, 0}, {8421, 1, 0},
+		{8422, 1, 0}, {8423, 1, 0}, {8424, 1, 0}, {8425, 1, 0}, {8426, 1, 0}, {8427, 0, 611}, {8427, 0, 613}, {8427, 0, 627},
+		{8427, 1, 0}, {8428, 1, 0}, {8429, 1, 0}, {8430, 1, 0}, {8431, 0, 43}, {8431, 0, 720}, {8431, 1, 0}, {8432, 1, 0},
+		{8433, 1, 0}, {8434, 1, 0}, {8435, 1, 0}, {8436, 0, 57}, {8436, 1, 0}, {8437, 1, 0}, {8438, 1, 0}, {8439, 2, 0},
+		{8441, 1, 0}, {8442, 1, 0}, {8443, 0, 798}, {8443, 1, 0}, {8444, 0, 98}, {8444, 1, 0}, {8445, 1, 0}, {8446, 1, 0},
+		{8447, 1, 0}, {8448, 1, 0}, {8449, 1, 0}, {8450, 1, 0}, {8451, 0, 892}, {8451, 0, 158}, {8451, 1, 0}, {8452, 0, 923},
+		{8452, 0, 929}, {8452, 1, 0}, {8453, 1, 0}, {8454, 0, 225}, {8454, 1, 0}, {8455, 1, 0}, {8456, 1, 0}, {8457, 1, 0},
+		{8458, 1, 0}, {8459, 1, 0}, {8460, 1, 0}, {8461, 1, 0}, {8462, 0, 248}, {8462, 1, 0}, {8463, 1, 0}, {8464, 1, 0},
+		{8465, 1, 0}, {8466, 1, 0}, {8467, 1, 0}, {8468, 1, 0}, {8469, 1, 0}, {8470, 0, 1085}, {8470, 1, 0}, {8471, 1, 0},
+		{8472, 1, 0}, {8473, 1, 0}, {8474, 1, 0}, {8475, 1, 0}, {8476, 0, 299}, {8476, 1, 0}, {8477, 1, 0}, {8478, 0, 310},
+		{8478, 0, 289}, {8478, 1, 0}, {8479, 0, 337}, {8479, 1, 0}, {8480, 0, 326}, {8480, 1, 0}, {8481, 0, 332}, {8481, 1, 0},
+		{8482, 1, 0}, {8483, 1, 0}, {8484, 0, 868}, {8484, 1, 0}, {8485, 1, 0}, {8486, 1, 0}, {8487, 1, 0}, {8488, 0, 383},
+		{8488, 2, 0}, {8490, 1, 0}, {8491, 1, 0}, {8492, 1, 0}, {8493, 1, 0}, {8494, 1, 0}, {8495, 1, 0}, {8496, 1, 0},
+		{8497, 1, 0}, {8498, 1, 0}, {8499, 1, 0}, {8500, 0, 1366}, {8500, 1, 0}, {8501, 1, 0}, {8502, 1, 0}, {8503, 1, 0},
+		{8504, 0, 1372}, {8504, 0, 1377}, {8504, 1, 0}, {8505, 1, 0}, {8506, 1, 0}, {8507, 1, 0}, {8508, 0, 1387}, {8508, 0, 1396},
+		{8508, 1, 0}, {8509, 0, 471}, {8509, 1, 0}, {8510, 1, 0}, {8511, 1, 0}, {8512, 0, 1423}, {8512, 1, 0}, {8513, 1, 0},
+		{8514, 1, 0}, {8515, 1, 0}, {8516, 1, 0}, {8517, 1, 0}, {8518, 1, 0}, {8519, 1, 0}, {8520, 1, 0}, {8521, 0, 378},
+		{8521, 1, 0}, {8522, 1, 0}, {8523, 1, 0}, {8524, 1, 0}, {8525, 0, 25}, {8525, 1, 0}, {8526, 1, 0}, {8527, 0, 47},
+		{8527, 1, 0}, {8528, 1, 0}, {8529, 1, 0}, {8530, 1, 0}, {8531, 1, 0}, {8532, 1, 0}, {8533, 4, 0}, {8537, 1, 0},
+		{8538, 1, 0}, {8539, 1, 0}, {8540, 1, 0}, {8541, 1, 0}, {8542, 1, 0}, {8543, 1, 0}, {8544, 1, 0}, {8545, 2, 0},
+		{8547, 1, 0}, {8548, 1, 0}, {8549, 1, 0}, {8550, 1, 0}, {8551, 1, 0}, {8552, 1, 0}, {8553, 1, 0}, {8554, 1, 0},
+		{8555, 1, 0}, {8556, 1, 0}, {8557, 1, 0}, {8558, 1, 0}, {8559, 1, 0}, {8560, 0, 130}, {8560, 1, 0}, {8561, 1, 0},
+		{8562, 1, 0}, {8563, 1, 0}, {8564, 0, 144}, {8564, 1, 0}, {8565, 1, 0}, {8566, 1, 0}, {8567, 1, 0}, {8568, 1, 0},
+		{8569, 1, 0}, {8570, 1, 0}, {8571, 1, 0}, {8572, 1, 0}, {8573, 0, 186}, {8573, 1, 0}, {8574, 1, 0}, {8575, 1, 0},
+		{8576, 0, 219}, {8576, 1, 0}, {8577, 1, 0}, {8578, 1, 0}, {8579, 1, 0}, {8580, 1, 0}, {8581, 1, 0}, {8582, 1, 0},
+		{8583, 1, 0}, {8584, 1, 0}, {8585, 1, 0}, {8586, 1, 0}, {8587, 1, 0}, {8588, 1, 0}, {8589, 1, 0}, {8590, 1, 0},
+		{8591, 0, 246}, {8591, 1, 0}, {8592, 1, 0}, {8593, 1, 0}, {8594, 1, 0}, {8595, 1, 0}, {8596, 1, 0}, {8597, 0, 256},
+		{8597, 1, 0}, {8598, 1, 0}, {8599, 1, 0}, {8600, 1, 0}, {8601, 1, 0}, {8602, 1, 0}, {8603, 1, 0}, {8604, 1, 0},
+		{8605, 1, 0}, {8606, 1, 0}, {8607, 2, 0}, {8609, 1, 0}, {8610, 1, 0}, {8611, 1, 0}, {8612, 1, 0}, {8613, 1, 0},
+		{8614, 1, 0}, {8615, 0, 290}, {8615, 1, 0}, {8616, 0, 294}, {8616, 1, 0}, {8617, 1, 0}, {8618, 1, 0}, {8619, 1, 0},
+		{8620, 1, 0}, {8621, 1, 0}, {8622, 1, 0}, {8623, 1, 0}, {8624, 1, 0}, {8625, 1, 0}, {8626, 1, 0}, {8627, 1, 0},
+		{8628, 1, 0}, {8629, 1, 0}, {8630, 1, 0}, {8631, 1, 0}, {8632, 3, 0}, {8635, 1, 0}, {8636, 1, 0}, {8637, 2, 0},
+		{8639, 1, 0}, {8640, 4, 0}, {8644, 2, 0}, {8646, 1, 0}, {8647, 1, 0}, {8648, 1, 0}, {8649, 1, 0}, {8650, 1, 0},
+		{8651, 1, 0}, {8652, 1, 0}, {8653, 1, 0}, {8654, 1, 0}, {8655, 1, 0}, {8656, 1, 0}, {8657, 1, 0}, {8658, 0, 52},
+		{8658, 1, 0}, {8659, 1, 0}, {8660, 1, 0}, {8661, 1, 0}, {8662, 1, 0}, {8663, 0, 400}, {8663, 1, 0}, {8664, 1, 0},
+		{8665, 1, 0}, {8666, 1, 0}, {8667, 1, 0}, {8668, 1, 0}, {8669, 0, 408}, {8669, 1, 0}, {8670, 1, 0}, {8671, 1, 0},
+		{8672, 1, 0}, {8673, 1, 0}, {8674, 1, 0}, {8675, 2, 0}, {8677, 0, 93}, {8677, 1, 0}, {8678, 1, 0}, {8679, 1, 0},
+		{8680, 1, 0}, {8681, 1, 0}, {8682, 1, 0}, {8683, 1, 0}, {8684, 1, 0}, {8685, 1, 0}, {8686, 1, 0}, {8687, 1, 0},
+		{8688, 1, 0}, {8689, 1, 0}, {8690, 1, 0}, {8691, 1, 0}, {8692, 1, 0}, {8693, 1, 0}, {8694, 0, 473}, {8694, 0, 476},
+		{8694, 1, 0}, {8695, 0, 478}, {8695, 0, 495}, {8695, 1, 0}, {8696, 1, 0}, {8697, 0, 502}, {8697, 1, 0}, {8698, 1, 0},
+		{8699, 1, 0}, {8700, 0, 507}, {8700, 1, 0}, {8701, 1, 0}, {8702, 1, 0}, {8703, 1, 0}, {8704, 1, 0}, {8705, 1, 0},
+		{8706, 1, 0}, {8707, 1, 0}, {8708, 1, 0}, {8709, 1, 0}, {8710, 2, 0}, {8712, 1, 0}, {8713, 1, 0}, {8714, 1, 0},
+		{8715, 2, 0}, {8717, 0, 729}, {8717, 1, 0}, {8718, 1, 0}, {8719, 0, 739}, {8719, 1, 0}, {8720, 1, 0}, {8721, 0, 767},
+		{8721, 1, 0}, {8722, 1, 0}, {8723, 1, 0}, {8724, 1, 0}, {8725, 1, 0}, {8726, 1, 0}, {8727, 2, 0}, {8729, 0, 822},
+		{8729, 1, 0}, {8730, 1, 0}, {8731, 1, 0}, {8732, 1, 0}, {8733, 0, 889}, {8733, 1, 0}, {8734, 1, 0}, {8735, 1, 0},
+		{8736, 2, 0}, {8738, 1, 0}, {8739, 1, 0}, {8740, 1, 0}, {8741, 1, 0}, {8742, 1, 0}, {8743, 0, 1005}, {8743, 0, 999},
+		{8743, 0, 1050}, {8743, 1, 0}, {8744, 1, 0}, {8745, 0, 1057}, {8745, 1, 0}, {8746, 1, 0}, {8747, 1, 0}, {8748, 0, 110},
+		{8748, 0, 237}, {8748, 1, 0}, {8749, 0, 1108}, {8749, 1, 0}, {8750, 1, 0}, {8751, 0, 1139}, {8751, 1, 0}, {8752, 1, 0},
+		{8753, 1, 0}, {8754, 0, 1157}, {8754, 0, 1159}, {8754, 1, 0}, {8755, 1, 0}, {8756, 0, 1246}, {8756, 1, 0}, {8757, 1, 0},
+		{8758, 1, 0}, {8759, 0, 400}, {8759, 1, 0}, {8760, 1, 0}, {8761, 1, 0}, {8762, 1, 0}, {8763, 1, 0}, {8764, 1, 0},
+		{8765, 1, 0}, {8766, 1, 0}, {8767, 0, 1304}, {8767, 1, 0}, {8768, 1, 0}, {8769, 0, 445}, {8769, 0, 447}, {8769, 1, 0},
+		{8770, 1, 0}, {8771, 0, 1376}, {8771, 0, 1324}, {8771, 1, 0}, {8772, 1, 0}, {8773, 0, 1395}, {8773, 1, 0}, {8774, 1, 0},
+		{8775, 1, 0}, {8776, 1, 0}, {8777, 1, 0}, {8778, 1, 0}, {8779, 1, 0}, {8780, 1, 0}, {8781, 1, 0}, {8782, 0, 1452},
+		{8782, 0, 842}, {8782, 0, 830}, {8782, 1, 0}, {8783, 1, 0}, {8784, 2, 0}, {8786, 1, 0}, {8787, 1, 0}, {8788, 0, 46},
+		{8788, 0, 48}, {8788, 1, 0}, {8789, 1, 0}, {8790, 1, 0}, {8791, 1, 0}, {8792, 1, 0}, {8793, 1, 0}, {8794, 1, 0},
+		{8795, 1, 0}, {8796, 1, 0}, {8797, 1, 0}, {8798, 1, 0}, {8799, 1, 0}, {8800, 1, 0}, {8801, 1, 0}, {8802, 1, 0},
+		{8803, 1, 0}, {8804, 1, 0}, {8805, 1, 0}, {8806, 1, 0}, {8807, 1, 0}, {8808, 1, 0}, {8809, 1, 0}, {8810, 1, 0},
+		{8811, 1, 0}, {8812, 1, 0}, {8813, 1, 0}, {8814, 1, 0}, {8815, 1, 0}, {8816, 1, 0}, {8817, 1, 0}, {8818, 1, 0},
+		{8819, 1, 0}, {8820, 0, 131}, {8820, 1, 0}, {8821, 1, 0}, {8822, 1, 0}, {8823, 2, 0}, {8825, 1, 0}, {8826, 1, 0},
+		{8827, 0, 161}, {8827, 1, 0}, {8828, 1, 0}, {8829, 1, 0}, {8830, 1, 0}, {8831, 1, 0}, {8832, 1, 0}, {8833, 1, 0},
+		{8834, 1, 0}, {8835, 1, 0}, {8836, 1, 0}, {8837, 1, 0}, {8838, 0, 228}, {8838, 1, 0}, {8839, 1, 0}, {8840, 1, 0},
+		{8841, 1, 0}, {8842, 1, 0}, {8843, 1, 0}, {8844, 1, 0}, {8845, 3, 0}, {8848, 1, 0}, {8849, 1, 0}, {8850, 2, 0},
+		{8852, 1, 0}, {8853, 1, 0}, {8854, 1, 0}, {8855, 1, 0}, {8856, 0, 250}, {8856, 1, 0}, {8857, 1, 0}, {8858, 1, 0},
+		{8859, 1, 0}, {8860, 1, 0}, {8861, 1, 0}, {8862, 1, 0}, {8863, 1, 0}, {8864, 1, 0}, {8865, 0, 269}, {8865, 1, 0},
+		{8866, 1, 0}, {8867, 1, 0}, {8868, 1, 0}, {8869, 1, 0}, {8870, 1, 0}, {8871, 1, 0}, {8872, 1, 0}, {8873, 1, 0},
+		{8874, 1, 0}, {8875, 1, 0}, {8876, 1, 0}, {8877, 1, 0}, {8878, 1, 0}, {8879, 1, 0}, {8880, 1, 0}, {8881, 1, 0},
+		{8882, 1, 0}, {8883, 1, 0}, {8884, 1, 0}, {8885, 1, 0}, {8886, 0, 309}, {8886, 1, 0}, {8887, 1, 0}, {8888, 1, 0},
+		{8889, 1, 0}, {8890, 0, 314}, {8890, 1, 0}, {8891, 1, 0}, {8892, 1, 0}, {8893, 1, 0}, {8894, 1, 0}, {8895, 1, 0},
+		{8896, 1, 0}, {8897, 0, 327}, {8897, 1, 0}, {8898, 1, 0}, {8899, 1, 0}, {8900, 0, 331}, {8900, 1, 0}, {8901, 1, 0},
+		{8902, 1, 0}, {8903, 1, 0}, {8904, 1, 0}, {8905, 1, 0}, {8906, 1, 0}, {8907, 0, 362}, {8907, 1, 0}, {8908, 1, 0},
+		{8909, 1, 0}, {8910, 1, 0}, {8911, 1, 0}, {8912, 1, 0}, {8913, 1, 0}, {8914, 1, 0}, {8915, 1, 0}, {8916, 1, 0},
+		{8917, 1, 0}, {8918, 1, 0}, {8919, 1, 0}, {8920, 1, 0}, {8921, 1, 0}, {8922, 1, 0}, {8923, 1, 0}, {8924, 1, 0},
+		{8925, 1, 0}, {8926, 1, 0}, {8927, 1, 0}, {8928, 1, 0}, {8929, 0, 419}, {8929, 1, 0}, {8930, 1, 0}, {8931, 0, 423},
+		{8931, 0, 426}, {8931, 1, 0}, {8932, 1, 0}, {8933, 1, 0}, {8934, 1, 0}, {8935, 0, 439}, {8935, 1, 0}, {8936, 2, 0},
+		{8938, 1, 0}, {8939, 0, 448}, {8939, 0, 452}, {8939, 1, 0}, {8940, 1, 0}, {8941, 1, 0}, {8942, 1, 0}, {8943, 1, 0},
+		{8944, 1, 0}, {8945, 1, 0}, {8946, 1, 0}, {8947, 0, 504}, {8947, 1, 0}, {8948, 0, 96}, {8948, 1, 0}, {8949, 1, 0},
+		{8950, 0, 523}, {8950, 1, 0}, {8951, 1, 0}, {8952, 1, 0}, {8953, 1, 0}, {8954, 1, 0}, {8955, 0, 610}, {8955, 1, 0},
+		{8956, 1, 0}, {8957, 1, 0}, {8958, 0, 141}, {8958, 1, 0}, {8959, 1, 0}, {8960, 1, 0}, {8961, 0, 730}, {8961, 0, 731},
+		{8961, 0, 753}, {8961, 0, 754}, {8961, 1, 0}, {8962, 1, 0}, {8963, 0, 785}, {8963, 1, 0}, {8964, 1, 0}, {8965, 1, 0},
+		{8966, 1, 0}, {8967, 1, 0}, {8968, 0, 827}, {8968, 0, 132}, {8968, 1, 0}, {8969, 1, 0}, {8970, 1, 0}, {8971, 1, 0},
+		{8972, 1, 0}, {8973, 1, 0}, {8974, 1, 0}, {8975, 1, 0}, {8976, 1, 0}, {8977, 1, 0}, {8978, 1, 0}, {8979, 1, 0},
+		{8980, 1, 0}, {8981, 1, 0}, {8982, 1, 0}, {8983, 1, 0}, {8984, 1, 0}, {8985, 1, 0}, {8986, 1, 0}, {8987, 0, 1112},
+		{8987, 1, 0}, {8988, 0, 1152}, {8988, 1, 0}, {8989, 1, 0}, {8990, 1, 0}, {8991, 0, 374}, {8991, 0, 1247}, {8991, 0, 172},
+		{8991, 1, 0}, {8992, 2, 0}, {8994, 1, 0}, {8995, 1, 0}, {8996, 1, 0}, {8997, 1, 0}, {8998, 1, 0}, {8999, 1, 0},
+		{9000, 1, 0}, {9001, 1, 0}, {9002, 1, 0}, {9003, 0, 1230}, {9003, 0, 455}, {9003, 0, 1329}, {9003, 0, 478}, {9003, 1, 0},
+		{9004, 2, 0}, {9006, 1, 0}, {9007, 1, 0}, {9008, 1, 0}, {9009, 0, 504}, {9009, 1, 0}, {9010, 1, 0}, {9011, 2, 0},
+		{9013, 2, 0}, {9015, 1, 0}, {9016, 1, 0}, {9017, 1, 0}, {9018, 1, 0}, {9019, 1, 0}, {9020, 1, 0}, {9021, 1, 0},
+		{9022, 1, 0}, {9023, 1, 0}, {9024, 1, 0}, {9025, 2, 0}, {9027, 1, 0}, {9028, 1, 0}, {9029, 1, 0}, {9030, 1, 0},
+		{9031, 1, 0}, {9032, 1, 0}, {9033, 1, 0}, {9034, 1, 0}, {9035, 1, 0}, {9036, 1, 0}, {9037, 1, 0}, {9038, 1, 0},
+		{9039, 1, 0}, {9040, 1, 0}, {9041, 1, 0}, {9042, 0, 99}, {9042, 1, 0}, {9043, 1, 0}, {9044, 1, 0}, {9045, 1, 0},
+		{9046, 1, 0}, {9047, 1, 0}, {9048, 0, 110}, {9048, 1, 0}, {9049, 1, 0}, {9050, 0, 137}, {9050, 1, 0}, {9051, 1, 0},
+		{9052, 0, 157}, {9052, 1, 0}, {9053, 1, 0}, {9054, 1, 0}, {9055, 1, 0}, {9056, 0, 163}, {9056, 0, 171}, {9056, 1, 0},
+		{9057, 0, 30}, {9057, 0, 189}, {9057, 1, 0}, {9058, 1, 0}, {9059, 1, 0}, {9060, 0, 226}, {9060, 1, 0}, {9061, 1, 0},
+		{9062, 1, 0}, {9063, 1, 0}, {9064, 1, 0}, {9065, 1, 0}, {9066, 0, 237}, {9066, 1, 0}, {9067, 0, 239}, {9067, 1, 0},
+		{9068, 1, 0}, {9069, 1, 0}, {9070, 1, 0}, {9071, 0, 244}, {9071, 1, 0}, {9072, 1, 0}, {9073, 1, 0}, {9074, 1, 0},
+		{9075, 1, 0}, {9076, 1, 0}, {9077, 1, 0}, {9078, 1, 0}, {9079, 1, 0}, {9080, 1, 0}, {9081, 1, 0}, {9082, 1, 0},
+		{9083, 1, 0}, {9084, 1, 0}, {9085, 1, 0}, {9086, 1, 0}, {9087, 1, 0}, {9088, 1, 0}, {9089, 1, 0}, {9090, 1, 0},
+		{9091, 1, 0}, {9092, 0, 287}, {9092, 1, 0}, {9093, 1, 0}, {9094, 1, 0}, {9095, 1, 0}, {9096, 1, 0}, {9097, 1, 0},
+		{9098, 1, 0}, {9099, 1, 0}, {9100, 1, 0}, {9101, 0, 302}, {9101, 1, 0}, {9102, 0, 307}, {9102, 1, 0}, {9103, 1, 0},
+		{9104, 0, 311}, {9104, 1, 0}, {9105, 1, 0}, {9106, 1, 0}, {9107, 1, 0}, {9108, 1, 0}, {9109, 1, 0}, {9110, 1, 0},
+		{9111, 1, 0}, {9112, 1, 0}, {9113, 1, 0}, {9114, 1, 0}, {9115, 1, 0}, {9116, 1, 0}, {9117, 1, 0}, {9118, 1, 0},
+		{9119, 1, 0}, {9120, 1, 0}, {9121, 1, 0}, {9122, 1, 0}, {9123, 1, 0}, {9124, 1, 0}, {9125, 1, 0}, {9126, 1, 0},
+		{9127, 1, 0}, {9128, 0, 378}, {9128, 1, 0}, {9129, 1, 0}, {9130, 1, 0}, {9131, 1, 0}, {9132, 1, 0}, {9133, 1, 0},
+		{9134, 0, 403}, {9134, 1, 0}, {9135, 1, 0}, {9136, 1, 0}, {9137, 1, 0}, {9138, 1, 0}, {9139, 3, 0}, {9142, 1, 0},
+		{9143, 1, 0}, {9144, 2, 0}, {9146, 1, 0}, {9147, 0, 422}, {9147, 1, 0}, {9148, 1, 0}, {9149, 1, 0}, {9150, 0, 437},
+		{9150, 1, 0}, {9151, 0, 444}, {9151, 1, 0}, {9152, 1, 0}, {9153, 1, 0}, {9154, 1, 0}, {9155, 1, 0}, {9156, 1, 0},
+		{9157, 1, 0}, {9158, 0, 496}, {9158, 1, 0}, {9159, 1, 0}, {9160, 1, 0}, {9161, 1, 0}, {9162, 1, 0}, {9163, 0, 524},
+		{9163, 1, 0}, {9164, 1, 0}, {9165, 1, 0}, {9166, 1, 0}, {9167, 1, 0}, {9168, 1, 0}, {9169, 0, 634}, {9169, 4, 0},
+		{9173, 1, 0}, {9174, 1, 0}, {9175, 1, 0}, {9176, 1, 0}, {9177, 1, 0}, {9178, 1, 0}, {9179, 1, 0}, {9180, 1, 0},
+		{9181, 1, 0}, {9182, 0, 137}, {9182, 1, 0}, {9183, 1, 0}, {9184, 1, 0}, {9185, 1, 0}, {9186, 1, 0}, {9187, 1, 0},
+		{9188, 1, 0}, {9189, 1, 0}, {9190, 1, 0}, {9191, 1, 0}, {9192, 1, 0}, {9193, 1, 0}, {9194, 1, 0}, {9195, 1, 0},
+		{9196, 1, 0}, {9197, 1, 0}, {9198, 1, 0}, {9199, 1, 0}, {9200, 1, 0}, {9201, 1, 0}, {9202, 2, 0}, {9204, 1, 0},
+		{9205, 1, 0}, {9206, 1, 0}, {9207, 1, 0}, {9208, 1, 0}, {9209, 1, 0}, {9210, 1, 0}, {9211, 1, 0}, {9212, 1, 0},
+		{9213, 0, 847}, {9213, 1, 0}, {9214, 1, 0}, {9215, 1, 0}, {9216, 0, 806}, {9216, 0, 1082}, {9216, 1, 0}, {9217, 2, 0},
+		{9219, 1, 0}, {9220, 1, 0}, {9221, 1, 0}, {9222, 1, 0}, {9223, 0, 1465}, {9223, 1, 0}, {9224, 0, 1467}, {9224, 1, 0},
+		{9225, 1, 0}, {9226, 1, 0}, {9227, 0, 14}, {9227, 1, 0}, {9228, 1, 0}, {9229, 1, 0}, {9230, 1, 0}, {9231, 1, 0},
+		{9232, 1, 0}, {9233, 1, 0}, {9234, 1, 0}, {9235, 1, 0}, {9236, 1, 0}, {9237, 1, 0}, {9238, 0, 82}, {9238, 1, 0},
+		{9239, 1, 0}, {9240, 1, 0}, {9241, 1, 0}, {9242, 0, 70}, {9242, 2, 0}, {9244, 1, 0}, {9245, 1, 0}, {9246, 1, 0},
+		{9247, 0, 95}, {9247, 1, 0}, {9248, 1, 0}, {9249, 1, 0}, {9250, 1, 0}, {9251, 1, 0}, {9252, 2, 0}, {9254, 1, 0},
+		{9255, 1, 0}, {9256, 1, 0}, {9257, 1, 0}, {9258, 1, 0}, {9259, 1, 0}, {9260, 1, 0}, {9261, 1, 0}, {9262, 1, 0},
+		{9263, 1, 0}, {9264, 1, 0}, {9265, 1, 0}, {9266, 1, 0}, {9267, 1, 0}, {9268, 1, 0}, {9269, 1, 0}, {9270, 1, 0},
+		{9271, 2, 0}, {9273, 1, 0}, {9274, 1, 0}, {9275, 0, 238}, {9275, 1, 0}, {9276, 1, 0}, {9277, 1, 0}, {9278, 1, 0},
+		{9279, 1, 0}, {9280, 0, 247}, {9280, 1, 0}, {9281, 1, 0}, {9282, 0, 249}, {9282, 1, 0}, {9283, 0, 258}, {9283, 1, 0},
+		{9284, 1, 0}, {9285, 0, 90}, {9285, 1, 0}, {9286, 1, 0}, {9287, 1, 0}, {9288, 1, 0}, {9289, 1, 0}, {9290, 1, 0},
+		{9291, 1, 0}, {9292, 1, 0}, {9293, 1, 0}, {9294, 1, 0}, {9295, 1, 0}, {9296, 1, 0}, {9297, 0, 292}, {9297, 1, 0},
+		{9298, 1, 0}, {9299, 1, 0}, {9300, 1, 0}, {9301, 1, 0}, {9302, 1, 0}, {9303, 1, 0}, {9304, 1, 0}, {9305, 1, 0},
+		{9306, 1, 0}, {9307, 1, 0}, {9308, 1, 0}, {9309, 1, 0}, {9310, 1, 0}, {9311, 1, 0}, {9312, 1, 0}, {9313, 1, 0},
+		{9314, 1, 0}, {9315, 1, 0}, {9316, 1, 0}, {9317, 1, 0}, {9318, 1, 0}, {9319, 1, 0}, {9320, 0, 334}, {9320, 1, 0},
+		{9321, 0, 336}, {9321, 1, 0}, {9322, 1, 0}, {9323, 1, 0}, {9324, 1, 0}, {9325, 0, 170}, {9325, 0, 373}, {9325, 1, 0},
+		{9326, 0, 375}, {9326, 1, 0}, {9327, 1, 0}, {9328, 1, 0}, {9329, 1, 0}, {9330, 0, 401}, {9330, 1, 0}, {9331, 1, 0},
+		{9332, 1, 0}, {9333, 1, 0}, {9334, 0, 410}, {9334, 1, 0}, {9335, 0, 412}, {9335, 1, 0}, {9336, 1, 0}, {9337, 1, 0},
+		{9338, 1, 0}, {9339, 0, 417}, {9339, 1, 0}, {9340, 1, 0}, {9341, 1, 0}, {9342, 1, 0}, {9343, 1, 0}, {9344, 1, 0},
+		{9345, 1, 0}, {9346, 2, 0}, {9348, 0, 454}, {9348, 1, 0}, {9349, 0, 456}, {9349, 0, 460}, {9349, 1, 0}, {9350, 1, 0},
+		{9351, 1, 0}, {9352, 0, 505}, {9352, 1, 0}, {9353, 1, 0}, {9354, 1, 0}, {9355, 0, 526}, {9355, 0, 527}, {9355, 1, 0},
+		{9356, 1, 0}, {9357, 0, 631}, {9357, 0, 635}, {9357, 1, 0}, {9358, 1, 0}, {9359, 1, 0}, {9360, 1, 0}, {9361, 1, 0},
+		{9362, 1, 0}, {9363, 1, 0}, {9364, 0, 789}, {9364, 1, 0}, {9365, 1, 0}, {9366, 1, 0}, {9367, 1, 0}, {9368, 0, 818},
+		{9368, 0, 937}, {9368, 1, 0}, {9369, 0, 1012}, {9369, 1, 0}, {9370, 0, 246}, {9370, 1, 0}, {9371, 2, 0}, {9373, 1, 0},
+		{9374, 1, 0}, {9375, 1, 0}, {9376, 0, 258}, {9376, 1, 0}, {9377, 1, 0}, {9378, 0, 1009}, {9378, 1, 0}, {9379, 0, 581},
+		{9379, 1, 0}, {9380, 1, 0}, {9381, 1, 0}, {9382, 0, 303}, {9382, 1, 0}, {9383, 2, 0}, {9385, 1, 0}, {9386, 1, 0},
+		{9387, 1, 0}, {9388, 1, 0}, {9389, 1, 0}, {9390, 1, 0}, {9391, 1, 0}, {9392, 1, 0}, {9393, 0, 97}, {9393, 0, 20},
+		{9393, 1, 0}, {9394, 1, 0}, {9395, 0, 1318}, {9395, 1, 0}, {9396, 1, 0}, {9397, 1, 0}, {9398, 0, 244}, {9398, 1, 0},
+		{9399, 0, 1466}, {9399, 0, 1468}, {9399, 1, 0}, {9400, 1, 0}, {9401, 1, 0}, {9402, 1, 0}, {9403, 1, 0}, {9404, 1, 0},
+		{9405, 1, 0}, {9406, 1, 0}, {9407, 1, 0}, {9408, 0, 77}, {9408, 1, 0}, {9409, 1, 0}, {9410, 1, 0}, {9411, 1, 0},
+		{9412, 1, 0}, {9413, 1, 0}, {9414, 1, 0}, {9415, 1, 0}, {9416, 1, 0}, {9417, 1, 0}, {9418, 1, 0}, {9419, 0, 94},
+		{9419, 1, 0}, {9420, 1, 0}, {9421, 1, 0}, {9422, 1, 0}, {9423, 1, 0}, {9424, 0, 104}, {9424, 1, 0}, {9425, 1, 0},
+		{9426, 2, 0}, {9428, 1, 0}, {9429, 1, 0}, {9430, 1, 0}, {9431, 1, 0}, {9432, 1, 0}, {9433, 1, 0}, {9434, 0, 160},
+		{9434, 1, 0}, {9435, 0, 173}, {9435, 0, 190}, {9435, 0, 191}, {9435, 1, 0}, {9436, 1, 0}, {9437, 1, 0}, {9438, 1, 0},
+		{9439, 0, 231}, {9439, 1, 0}, {9440, 0, 234}, {9440, 1, 0}, {9441, 1, 0}, {9442, 1, 0}, {9443, 1, 0}, {9444, 1, 0},
+		{9445, 1, 0}, {9446, 0, 89}, {9446, 1, 0}, {9447, 0, 252}, {9447, 1, 0}, {9448, 0, 260}, {9448, 1, 0}, {9449, 0, 92},
+		{9449, 0, 262}, {9449, 1, 0}, {9450, 1, 0}, {9451, 1, 0}, {9452, 1, 0}, {9453, 1, 0}, {9454, 1, 0}, {9455, 0, 266},
+		{9455, 1, 0}, {9456, 1, 0}, {9457, 1, 0}, {9458, 1, 0}, {9459, 1, 0}, {9460, 0, 298}, {9460, 1, 0}, {9461, 1, 0},
+		{9462, 1, 0}, {9463, 3, 0}, {9466, 0, 308}, {9466, 1, 0}, {9467, 1, 0}, {9468, 1, 0}, {9469, 1, 0}, {9470, 1, 0},
+		{9471, 1, 0}, {9472, 1, 0}, {9473, 2, 0}, {9475, 1, 0}, {9476, 0, 326}, {9476, 1, 0}, {9477, 1, 0}, {9478, 1, 0},
+		{9479, 1, 0}, {9480, 1, 0}, {9481, 0, 337}, {9481, 1, 0}, {9482, 0, 353}, {9482, 1, 0}, {9483, 1, 0}, {9484, 0, 395},
+		{9484, 1, 0}, {9485, 1, 0}, {9486, 1, 0}, {9487, 1, 0}, {9488, 1, 0}, {9489, 1, 0}, {9490, 2, 0}, {9492, 0, 411},
+		{9492, 1, 0}, {9493, 1, 0}, {9494, 1, 0}, {9495, 1, 0}, {9496, 1, 0}, {9497, 0, 420}, {9497, 0, 98}, {9497, 0, 225},
+		{9497, 1, 0}, {9498, 1, 0}, {9499, 1, 0}, {9500, 0, 446}, {9500, 1, 0}, {9501, 1, 0}, {9502, 0, 477}, {9502, 1, 0},
+		{9503, 1, 0}, {9504, 0, 508}, {9504, 1, 0}, {9505, 1, 0}, {9506, 0, 280}, {9506, 1, 0}, {9507, 1, 0}, {9508, 1, 0},
+		{9509, 1, 0}, {9510, 1, 0}, {9511, 1, 0}, {9512, 0, 755}, {9512, 1, 0}, {9513, 0, 22}, {9513, 0, 778}, {9513, 1, 0},
+		{9514, 1, 0}, {9515, 0, 938}, {9515, 1, 0}, {9516, 0, 1032}, {9516, 0, 234}, {9516, 1, 0}, {9517, 1, 0}, {9518, 1, 0},
+		{9519, 0, 1033}, {9519, 1, 0}, {9520, 0, 260}, {9520, 0, 1058}, {9520, 1, 0}, {9521, 1, 0}, {9522, 0, 289}, {9522, 1, 0},
+		{9523, 0, 318}, {9523, 1, 0}, {9524, 0, 1278}, {9524, 1, 0}, {9525, 0, 419}, {9525, 1, 0}, {9526, 1, 0}, {9527, 1, 0},
+		{9528, 1, 0}, {9529, 1, 0}, {9530, 1, 0}, {9531, 0, 241}, {9531, 1, 0}, {9532, 1, 0}, {9533, 1, 0}, {9534, 0, 417},
+		{9534, 1, 0}, {9535, 1, 0}, {9536, 1, 0}, {9537, 1, 0}, {9538, 1, 0}, {9539, 0, 51}, {9539, 0, 56}, {9539, 1, 0},
+		{9540, 1, 0}, {9541, 1, 0}, {9542, 1, 0}, {9543, 1, 0}, {9544, 1, 0}, {9545, 0, 87}, {9545, 0, 88}, {9545, 1, 0},
+		{9546, 1, 0}, {9547, 1, 0}, {9548, 1, 0}, {9549, 1, 0}, {9550, 1, 0}, {9551, 1, 0}, {9552, 1, 0}, {9553, 1, 0},
+		{9554, 1, 0}, {9555, 1, 0}, {9556, 1, 0}, {9557, 0, 107}, {9557, 1, 0}, {9558, 1, 0}, {9559, 1, 0}, {9560, 1, 0},
+		{9561, 1, 0}, {9562, 1, 0}, {9563, 1, 0}, {9564, 1, 0}, {9565, 1, 0}, {9566, 1, 0}, {9567, 1, 0}, {9568, 1, 0},
+		{9569, 1, 0}, {9570, 0, 235}, {9570, 0, 240}, {9570, 1, 0}, {9571, 1, 0}, {9572, 0, 243}, {9572, 0, 245}, {9572, 1, 0},
+		{9573, 1, 0}, {9574, 1, 0}, {9575, 0, 263}, {9575, 1, 0}, {9576, 1, 0}, {9577, 1, 0}, {9578, 1, 0}, {9579, 1, 0},
+		{9580, 1, 0}, {9581, 1, 0}, {9582, 0, 295}, {9582, 1, 0}, {9583, 1, 0}, {9584, 1, 0}, {9585, 0, 300}, {9585, 0, 301},
+		{9585, 0, 303}, {9585, 1, 0}, {9586, 1, 0}, {9587, 1, 0}, {9588, 1, 0}, {9589, 1, 0}, {9590, 1, 0}, {9591, 1, 0},
+		{9592, 1, 0}, {9593, 3, 0}, {9596, 0, 321}, {9596, 1, 0}, {9597, 1, 0}, {9598, 1, 0}, {9599, 1, 0}, {9600, 1, 0},
+		{9601, 1, 0}, {9602, 1, 0}, {9603, 1, 0}, {9604, 0, 363}, {9604, 1, 0}, {9605, 1, 0}, {9606, 1, 0}, {9607, 1, 0},
+		{9608, 1, 0}, {9609, 1, 0}, {9610, 1, 0}, {9611, 0, 406}, {9611, 1, 0}, {9612, 1, 0}, {9613, 1, 0}, {9614, 1, 0},
+		{9615, 1, 0}, {9616, 1, 0}, {9617, 0, 400}, {9617, 1, 0}, {9618, 1, 0}, {9619, 1, 0}, {9620, 1, 0}, {9621, 1, 0},
+		{9622, 1, 0}, {9623, 0, 509}, {9623, 1, 0}, {9624, 0, 630}, {9624, 1, 0}, {9625, 1, 0}, {9626, 1, 0}, {9627, 0, 726},
+		{9627, 1, 0}, {9628, 0, 764}, {9628, 0, 231}, {9628, 1, 0}, {9629, 0, 104}, {9629, 0, 227}, {9629, 1, 0}, {9630, 1, 0},
+		{9631, 1, 0}, {9632, 0, 1109}, {9632, 0, 1133}, {9632, 0, 305}, {9632, 1, 0}, {9633, 1, 0}, {9634, 0, 402}, {9634, 1, 0},
+		{9635, 1, 0}, {9636, 0, 1279}, {9636, 0, 1301}, {9636, 0, 842}, {9636, 0, 414}, {9636, 1, 0}, {9637, 1, 0}, {9638, 0, 239},
+		{9638, 1, 0}, {9639, 1, 0}, {9640, 1, 0}, {9641, 1, 0}, {9642, 1, 0}, {9643, 0, 76}, {9643, 1, 0}, {9644, 0, 79},
+		{9644, 0, 80}, {9644, 1, 0}, {9645, 1, 0}, {9646, 1, 0}, {9647, 1, 0}, {9648, 1, 0}, {9649, 0, 93}, {9649, 1, 0},
+		{9650, 1, 0}, {9651, 0, 100}, {9651, 1, 0}, {9652, 1, 0}, {9653, 1, 0}, {9654, 1, 0}, {9655, 1, 0}, {9656, 0, 124},
+		{9656, 1, 0}, {9657, 1, 0}, {9658, 1, 0}, {9659, 0, 158}, {9659, 0, 159}, {9659, 1, 0}, {9660, 0, 224}, {9660, 1, 0},
+		{9661, 1, 0}, {9662, 1, 0}, {9663, 1, 0}, {9664, 1, 0}, {9665, 0, 242}, {9665, 0, 248}, {9665, 1, 0}, {9666, 1, 0},
+		{9667, 1, 0}, {9668, 1, 0}, {9669, 1, 0}, {9670, 1, 0}, {9671, 1, 0}, {9672, 0, 284}, {9672, 1, 0}, {9673, 1, 0},
+		{9674, 1, 0}, {9675, 1, 0}, {9676, 1, 0}, {9677, 1, 0}, {9678, 1, 0}, {9679, 1, 0}, {9680, 1, 0}, {9681, 0, 315},
+		{9681, 1, 0}, {9682, 1, 0}, {9683, 0, 318}, {9683, 1, 0}, {9684, 1, 0}, {9685, 1, 0}, {9686, 2, 0}, {9688, 0, 328},
+		{9688, 1, 0}, {9689, 0, 330}, {9689, 0, 332}, {9689, 1, 0}, {9690, 1, 0}, {9691, 1, 0}, {9692, 1, 0}, {9693, 1, 0},
+		{9694, 1, 0}, {9695, 1, 0}, {9696, 1, 0}, {9697, 1, 0}, {9698, 1, 0}, {9699, 0, 413}, {9699, 1, 0}, {9700, 1, 0},
+		{9701, 0, 416}, {9701, 0, 418}, {9701, 1, 0}, {9702, 1, 0}, {9703, 1, 0}, {9704, 1, 0}, {9705, 0, 497}, {9705, 0, 503},
+		{9705, 1, 0}, {9706, 1, 0}, {9707, 1, 0}, {9708, 1, 0}, {9709, 0, 727}, {9709, 0, 406}, {9709, 1, 0}, {9710, 1, 0},
+		{9711, 1, 0}, {9712, 0, 320}, {9712, 0, 107}, {9712, 1, 0}, {9713, 0, 1300}, {9713, 0, 220}, {9713, 1, 0}, {9714, 0, 412},
+		{9714, 1, 0}, {9715, 1, 0}, {9716, 1, 0}, {9717, 1, 0}, {9718, 1, 0}, {9719, 1, 0}, {9720, 1, 0}, {9721, 1, 0},
+		{9722, 1, 0}, {9723, 1, 0}, {9724, 0, 96}, {9724, 0, 97}, {9724, 1, 0}, {9725, 0, 103}, {9725, 0, 105}, {9725, 1, 0},
+		{9726, 1, 0}, {9727, 1, 0}, {9728, 0, 140}, {9728, 1, 0}, {9729, 0, 162}, {9729, 1, 0}, {9730, 0, 229}, {9730, 0, 230},
+		{9730, 0, 232}, {9730, 0, 241}, {9730, 1, 0}, {9731, 1, 0}, {9732, 1, 0}, {9733, 1, 0}, {9734, 0, 280}, {9734, 1, 0},
+		{9735, 1, 0}, {9736, 1, 0}, {9737, 1, 0}, {9738, 0, 297}, {9738, 1, 0}, {9739, 1, 0}, {9740, 1, 0}, {9741, 0, 310},
+		{9741, 1, 0}, {9742, 0, 313}, {9742, 1, 0}, {9743, 0, 317}, {9743, 1, 0}, {9744, 1, 0}, {9745, 1, 0}, {9746, 0, 323},
+		{9746, 1, 0}, {9747, 1, 0}, {9748, 0, 335}, {9748, 1, 0}, {9749, 1, 0}, {9750, 1, 0}, {9751, 1, 0}, {9752, 0, 399},
+		{9752, 1, 0}, {9753, 1, 0}, {9754, 1, 0}, {9755, 1, 0}, {9756, 1, 0}, {9757, 0, 415}, {9757, 1, 0}, {9758, 0, 445},
+		{9758, 1, 0}, {9759, 1, 0}, {9760, 0, 525}, {9760, 0, 636}, {9760, 0, 637}, {9760, 1, 0}, {9761, 0, 396}, {9761, 1, 0},
+		{9762, 1, 0}, {9763, 0, 131}, {9763, 0, 389}, {9763, 1, 0}, {9764, 1, 0}, {9765, 1, 0}, {9766, 1, 0}, {9767, 1, 0},
+		{9768, 1, 0}, {9769, 1, 0}, {9770, 1, 0}, {9771, 1, 0}, {9772, 1, 0}, {9773, 1, 0}, {9774, 0, 106}, {9774, 0, 108},
+		{9774, 1, 0}, {9775, 1, 0}, {9776, 1, 0}, {9777, 0, 259}, {9777, 0, 91}, {9777, 1, 0}, {9778, 0, 280}, {9778, 1, 0},
+		{9779, 1, 0}, {9780, 1, 0}, {9781, 1, 0}, {9782, 1, 0}, {9783, 0, 304}, {9783, 1, 0}, {9784, 1, 0}, {9785, 1, 0},
+		{9786, 1, 0}, {9787, 1, 0}, {9788, 1, 0}, {9789, 1, 0}, {9790, 1, 0}, {9791, 1, 0}, {9792, 0, 374}, {9792, 0, 396},
+		{9792, 1, 0}, {9793, 1, 0}, {9794, 0, 404}, {9794, 0, 405}, {9794, 0, 407}, {9794, 0, 414}, {9794, 0, 443}, {9794, 1, 0},
+		{9795, 0, 455}, {9795, 0, 638}, {9795, 1, 0}, {9796, 0, 259}, {9796, 1, 0}, {9797, 1, 0}, {9798, 1, 0}, {9799, 1, 0},
+		{9800, 1, 0}, {9801, 1, 0}, {9802, 1, 0}, {9803, 0, 90}, {9803, 1, 0}, {9804, 1, 0}, {9805, 0, 102}, {9805, 1, 0},
+		{9806, 1, 0}, {9807, 0, 227}, {9807, 0, 280}, {9807, 1, 0}, {9808, 1, 0}, {9809, 1, 0}, {9810, 0, 296}, {9810, 1, 0},
+		{9811, 1, 0}, {9812, 1, 0}, {9813, 1, 0}, {9814, 0, 319}, {9814, 1, 0}, {9815, 1, 0}, {9816, 1, 0}, {9817, 1, 0},
+		{9818, 1, 0}, {9819, 1, 0}, {9820, 0, 402}, {9820, 0, 447}, {9820, 0, 926}, {9820, 0, 35}, {9820, 1, 0}, {9821, 1, 0},
+		{9822, 1, 0}, {9823, 1, 0}, {9824, 1, 0}, {9825, 0, 89}, {9825, 1, 0}, {9826, 0, 92}, {9826, 0, 125}, {9826, 1, 0},
+		{9827, 1, 0}, {9828, 0, 165}, {9828, 0, 289}, {9828, 0, 299}, {9828, 0, 305}, {9828, 1, 0}, {9829, 1, 0}, {9830, 1, 0},
+		{9831, 0, 322}, {9831, 1, 0}, {9832, 1, 0}, {9833, 0, 352}, {9833, 0, 397}, {9833, 1, 0}, {9834, 0, 50}, {9834, 1, 0},
+		{9835, 1, 0}, {9836, 0, 55}, {9836, 1, 0}, {9837, 0, 141}, {9837, 0, 280}, {9837, 1, 0}, {9838, 0, 316}, {9838, 0, 320},
+		{9838, 1, 0}, {9839, 0, 329}, {9839, 1, 0}, {9840, 1, 0}, {9841, 0, 78}, {9841, 1, 0}, {9842, 1, 0}, {9843, 0, 324},
+		{9843, 1, 0}, {9844, 1, 0}, {9845, 1, 0}, {9846, 0, 312}, {9846, 0, 49}, {9846, 1, 0}, {9847, 0, 91}, {9847, 1, 0},
+		{9848, 1, 0}, {9849, 1, 0}, {9850, 1, 0}, {9851, 1, 0}, {9852, 1, 0}, {9853, 0, 59},
+	},
+	edges: []entityEdge{
+		{'A', 1}, {'B', 2}, {'C', 3}, {'D', 4}, {'E', 5}, {'F', 6}, {'G', 7}, {'H', 8},
+		{'I', 9}, {'J', 10}, {'K', 11}, {'L', 12}, {'M', 13}, {'N', 14}, {'O', 15}, {'P', 16},
+		{'Q', 17}, {'R', 18}, {'S', 19}, {'T', 20}, {'U', 21}, {'V', 22}, {'W', 23}, {'X', 24},
+		{'Y', 25}, {'Z', 26}, {'a', 27}, {'b', 28}, {'c', 29}, {'d', 30}, {'e', 31}, {'f', 32},
+		{'g', 33}, {'h', 34}, {'i', 35}, {'j', 36}, {'k', 37}, {'l', 38}, {'m', 39}, {'n', 40},
+		{'o', 41}, {'p', 42}, {'q', 43}, {'r', 44}, {'s', 45}, {'t', 46}, {'u', 47}, {'v', 48},
+		{'w', 49}, {'x', 50}, {'y', 51}, {'z', 52}, {'E', 53}, {'M', 54}, {'a', 55}, {'b', 56},
+		{'c', 57}, {'f', 58}, {'g', 59}, {'l', 60}, {'m', 61}, {'n', 62}, {'o', 63}, {'p', 64},
+		{'r', 65}, {'s', 66}, {'t', 67}, {'u', 68}, {'a', 69}, {'c', 70}, {'e', 71}, {'f', 72},
+		{'o', 73}, {'r', 74}, {'s', 75}, {'u', 76}, {'H', 77}, {'O', 78}, {'a', 79}, {'c', 80},
+		{'d', 81}, {'e', 82}, {'f', 83}, {'h', 84}, {'i', 85}, {'l', 86}, {'o', 87}, {'r', 88},
+		{'s', 89}, {'u', 90}, {'D', 91}, {'J', 92}, {'S', 93}, {'Z', 94}, {'a', 95}, {'c', 96},
+		{'e', 97}, {'f', 98}, {'i', 99}, {'o', 100}, {'s', 101}, {'N', 102}, {'T', 103}, {'a', 104},
+		{'c', 105}, {'d', 106}, {'f', 107}, {'g', 108}, {'l', 109}, {'m', 110}, {'o', 111}, {'p', 112},
+		{'q', 113}, {'s', 114}, {'t', 115}, {'u', 116}, {'x', 117}, {'c', 118}, {'f', 119}, {'i', 120},
+		{'o', 121}, {'s', 122}, {'J', 123}, {'T', 124}, {'a', 125}, {'b', 126}, {'c', 127}, {'d', 128},
+		{'f', 129}, {'g', 130}, {'o', 131}, {'r', 132}, {'s', 133}, {'t', 134}, {'A', 135}, {'a', 136},
+		{'c', 137}, {'f', 138}, {'i', 139}, {'o', 140}, {'s', 141}, {'u', 142}, {'E', 143}, {'J', 144},
+		{'O', 145}, {'a', 146}, {'c', 147}, {'d', 148}, {'f', 149}, {'g', 150}, {'m', 151}, {'n', 152},
+		{'o', 153}, {'s', 154}, {'t', 155}, {'u', 156}, {'c', 157}, {'f', 158}, {'o', 159}, {'s', 160},
+		{'u', 161}, {'H', 162}, {'J', 163}, {'a', 164}, {'c', 165}, {'f', 166}, {'o', 167}, {'s', 168},
+		{'J', 169}, {'T', 170}, {'a', 171}, {'c', 172}, {'e', 173}, {'f', 174}, {'l', 175}, {'m', 176},
+		{'o', 177}, {'s', 178}, {'t', 179}, {'a', 180}, {'c', 181}, {'e', 182}, {'f', 183}, {'i', 184},
+		{'o', 185}, {'s', 186}, {'u', 187}, {'J', 188}, {'a', 189}, {'c', 190}, {'e', 191}, {'f', 192},
+		{'o', 193}, {'s', 194}, {'t', 195}, {'u', 196}, {'E', 197}, {'a', 198}, {'c', 199}, {'d', 200},
+		{'f', 201}, {'g', 202}, {'m', 203}, {'o', 204}, {'p', 205}, {'r', 206}, {'s', 207}, {'t', 208},
+		{'u', 209}, {'v', 210}, {'a', 211}, {'c', 212}, {'f', 213}, {'h', 214}, {'i', 215}, {'l', 216},
+		{'o', 217}, {'r', 218}, {'s', 219}, {'U', 220}, {'f', 221}, {'o', 222}, {'s', 223}, {'B', 224},
+		{'E', 225}, {'a', 226}, {'c', 227}, {'e', 228}, {'f', 229}, {'h', 230}, {'i', 231}, {'o', 232},
+		{'r', 233}, {'s', 234}, {'u', 235}, {'H', 236}, {'O', 237}, {'a', 238}, {'c', 239}, {'f', 240},
+		{'h', 241}, {'i', 242}, {'m', 243}, {'o', 244}, {'q', 245}, {'s', 246}, {'t', 247}, {'u', 248},
+		{'H', 249}, {'R', 250}, {'S', 251}, {'a', 252}, {'c', 253}, {'f', 254}, {'h', 255}, {'i', 256},
+		{'o', 257}, {'r', 258}, {'s', 259}, {'a', 260}, {'b', 261}, {'c', 262}, {'d', 263}, {'f', 264},
+		{'g', 265}, {'m', 266}, {'n', 267}, {'o', 268}, {'p', 269}, {'r', 270}, {'s', 271}, {'t', 272},
+		{'u', 273}, {'D', 274}, {'b', 275}, {'c', 276}, {'d', 277}, {'e', 278}, {'f', 279}, {'o', 280},
+		{'s', 281}, {'v', 282}, {'c', 283}, {'e', 284}, {'f', 285}, {'o', 286}, {'s', 287}, {'f', 288},
+		{'i', 289}, {'o', 290}, {'s', 291}, {'A', 292}, {'I', 293}, {'U', 294}, {'a', 295}, {'c', 296},
+		{'f', 297}, {'o', 298}, {'s', 299}, {'u', 300}, {'H', 301}, {'a', 302}, {'c', 303}, {'d', 304},
+		{'e', 305}, {'f', 306}, {'o', 307}, {'s', 308}, {'a', 309}, {'b', 310}, {'c', 311}, {'e', 312},
+		{'f', 313}, {'g', 314}, {'l', 315}, {'m', 316}, {'n', 317}, {'o', 318}, {'p', 319}, {'r', 320},
+		{'s', 321}, {'t', 322}, {'u', 323}, {'w', 324}, {'N', 325}, {'a', 326}, {'b', 327}, {'c', 328},
+		{'d', 329}, {'e', 330}, {'f', 331}, {'i', 332}, {'k', 333}, {'l', 334}, {'n', 335}, {'o', 336},
+		{'p', 337}, {'r', 338}, {'s', 339}, {'u', 340}, {'a', 341}, {'c', 342}, {'d', 343}, {'e', 344},
+		{'f', 345}, {'h', 346}, {'i', 347}, {'l', 348}, {'o', 349}, {'r', 350}, {'s', 351}, {'t', 352},
+		{'u', 353}, {'w', 354}, {'y', 355}, {'A', 356}, {'H', 357}, {'a', 358}, {'b', 359}, {'c', 360},
+		{'d', 361}, {'e', 362}, {'f', 363}, {'h', 364}, {'i', 365}, {'j', 366}, {'l', 367}, {'o', 368},
+		{'r', 369}, {'s', 370}, {'t', 371}, {'u', 372}, {'w', 373}, {'z', 374}, {'D', 375}, {'a', 376},
+		{'c', 377}, {'d', 378}, {'e', 379}, {'f', 380}, {'g', 381}, {'l', 382}, {'m', 383}, {'n', 384},
+		{'o', 385}, {'p', 386}, {'q', 387}, {'r', 388}, {'s', 389}, {'t', 390}, {'u', 391}, {'x', 392},
+		{'a', 393}, {'c', 394}, {'e', 395}, {'f', 396}, {'i', 397}, {'j', 398}, {'l', 399}, {'n', 400},
+		{'o', 401}, {'p', 402}, {'r', 403}, {'s', 404}, {'E', 405}, {'a', 406}, {'b', 407}, {'c', 408},
+		{'d', 409}, {'e', 410}, {'f', 411}, {'g', 412}, {'i', 413}, {'j', 414}, {'l', 415}, {'n', 416},
+		{'o', 417}, {'r', 418}, {'s', 419}, {'t', 420}, {'v', 421}, {'A', 422}, {'a', 423}, {'b', 424},
+		{'c', 425}, {'e', 426}, {'f', 427}, {'k', 428}, {'o', 429}, {'s', 430}, {'y', 431}, {'a', 432},
+		{'c', 433}, {'e', 434}, {'f', 435}, {'g', 436}, {'i', 437}, {'j', 438}, {'m', 439}, {'n', 440},
+		{'o', 441}, {'p', 442}, {'q', 443}, {'s', 444}, {'t', 445}, {'u', 446}, {'c', 447}, {'f', 448},
+		{'m', 449}, {'o', 450}, {'s', 451}, {'u', 452}, {'a', 453}, {'c', 454}, {'f', 455}, {'g', 456},
+		{'h', 457}, {'j', 458}, {'o', 459}, {'s', 460}, {'A', 461}, {'B', 462}, {'E', 463}, {'H', 464},
+		{'a', 465}, {'b', 466}, {'c', 467}, {'d', 468}, {'e', 469}, {'f', 470}, {'g', 471}, {'h', 472},
+		{'j', 473}, {'l', 474}, {'m', 475}, {'n', 476}, {'o', 477}, {'p', 478}, {'r', 479}, {'s', 480},
+		{'t', 481}, {'u', 482}, {'v', 483}, {'D', 484}, {'a', 485}, {'c', 486}, {'d', 487}, {'e', 488},
+		{'f', 489}, {'h', 490}, {'i', 491}, {'l', 492}, {'n', 493}, {'o', 494}, {'p', 495}, {'s', 496},
+		{'u', 497}, {'G', 498}, {'L', 499}, {'R', 500}, {'V', 501}, {'a', 502}, {'b', 503}, {'c', 504},
+		{'d', 505}, {'e', 506}, {'f', 507}, {'g', 508}, {'h', 509}, {'i', 510}, {'j', 511}, {'l', 512},
+		{'m', 513}, {'o', 514}, {'p', 515}, {'r', 516}, {'s', 517}, {'t', 518}, {'u', 519}, {'v', 520},
+		{'w', 521}, {'S', 522}, {'a', 523}, {'c', 524}, {'d', 525}, {'e', 526}, {'f', 527}, {'g', 528},
+		{'h', 529}, {'i', 530}, {'l', 531}, {'m', 532}, {'o', 533}, {'p', 534}, {'r', 535}, {'s', 536},
+		{'t', 537}, {'u', 538}, {'v', 539}, {'a', 540}, {'c', 541}, {'e', 542}, {'f', 543}, {'h', 544},
+		{'i', 545}, {'l', 546}, {'m', 547}, {'o', 548}, {'r', 549}, {'s', 550}, {'u', 551}, {'f', 552},
+		{'i', 553}, {'o', 554}, {'p', 555}, {'s', 556}, {'u', 557}, {'A', 558}, {'B', 559}, {'H', 560},
+		{'a', 561}, {'b', 562}, {'c', 563}, {'d', 564}, {'e', 565}, {'f', 566}, {'h', 567}, {'i', 568},
+		{'l', 569}, {'m', 570}, {'n', 571}, {'o', 572}, {'p', 573}, {'r', 574}, {'s', 575}, {'t', 576},
+		{'u', 577}, {'x', 578}, {'a', 579}, {'b', 580}, {'c', 581}, {'d', 582}, {'e', 583}, {'f', 584},
+		{'h', 585}, {'i', 586}, {'l', 587}, {'m', 588}, {'o', 589}, {'p', 590}, {'q', 591}, {'r', 592},
+		{'s', 593}, {'t', 594}, {'u', 595}, {'w', 596}, {'z', 597}, {'a', 598}, {'b', 599}, {'c', 600},
+		{'d', 601}, {'e', 602}, {'f', 603}, {'h', 604}, {'i', 605}, {'o', 606}, {'p', 607}, {'r', 608},
+		{'s', 609}, {'w', 610}, {'A', 611}, {'H', 612}, {'a', 613}, {'b', 614}, {'c', 615}, {'d', 616},
+		{'f', 617}, {'g', 618}, {'h', 619}, {'l', 620}, {'m', 621}, {'o', 622}, {'p', 623}, {'r', 624},
+		{'s', 625}, {'t', 626}, {'u', 627}, {'w', 628}, {'A', 629}, {'B', 630}, {'D', 631}, {'a', 632},
+		{'c', 633}, {'d', 634}, {'e', 635}, {'f', 636}, {'l', 637}, {'n', 638}, {'o', 639}, {'p', 640},
+		{'r', 641}, {'s', 642}, {'z', 643}, {'c', 644}, {'e', 645}, {'f', 646}, {'o', 647}, {'p', 648},
+		{'r', 649}, {'s', 650}, {'c', 651}, {'d', 652}, {'f', 653}, {'h', 654}, {'i', 655}, {'l', 656},
+		{'m', 657}, {'n', 658}, {'o', 659}, {'r', 660}, {'s', 661}, {'u', 662}, {'v', 663}, {'w', 664},
+		{'a', 665}, {'c', 666}, {'e', 667}, {'f', 668}, {'i', 669}, {'o', 670}, {'s', 671}, {'u', 672},
+		{'a', 673}, {'c', 674}, {'d', 675}, {'e', 676}, {'f', 677}, {'h', 678}, {'i', 679}, {'o', 680},
+		{'s', 681}, {'w', 682}, {'l', 683}, {'P', 684}, {'c', 685}, {'r', 686}, {'i', 687}, {'y', 688},
+		{'r', 689}, {'r', 690}, {'p', 691}, {'a', 692}, {'d', 693}, {'g', 694}, {'p', 695}, {'p', 696},
+		{'i', 697}, {'c', 698}, {'s', 699}, {'i', 700}, {'m', 701}, {'c', 702}, {'r', 703}, {'y', 704},
+		{'c', 705}, {'r', 706}, {'t', 707}, {'r', 708}, {'p', 709}, {'e', 710}, {'c', 711}, {'m', 712},
+		{'c', 713}, {'P', 714}, {'c', 715}, {'p', 716}, {'y', 717}, {'a', 718}, {'e', 719}, {'i', 720},
+		{'o', 721}, {'o', 722}, {'d', 723}, {'n', 724}, {'r', 725}, {'i', 726}, {'r', 727}, {'o', 728},
+		{'l', 729}, {'n', 730}, {'p', 731}, {'u', 732}, {'o', 733}, {'c', 734}, {'p', 735}, {';', 736},
+		{'o', 737}, {'c', 738}, {'c', 739}, {'c', 740}, {'g', 741}, {'r', 742}, {'s', 743}, {'a', 744},
+		{'y', 745}, {'l', 746}, {'r', 747}, {'a', 748}, {'f', 749}, {'p', 750}, {'t', 751}, {'u', 752},
+		{'w', 753}, {'c', 754}, {'t', 755}, {'G', 756}, {'H', 757}, {'c', 758}, {'a', 759}, {'i', 760},
+		{'y', 761}, {'o', 762}, {'r', 763}, {'r', 764}, {'e', 765}, {'a', 766}, {'p', 767}, {'g', 768},
+		{'p', 769}, {'s', 770}, {'u', 771}, {'c', 772}, {'i', 773}, {'a', 774}, {'m', 775}, {'i', 776},
+		{'p', 777}, {'y', 778}, {'r', 779}, {'l', 780}, {'p', 781}, {'r', 782}, {'u', 783}, {'c', 784},
+		{'c', 785}, {';', 786}, {'m', 787}, {'r', 788}, {'e', 789}, {'i', 790}, {'y', 791}, {'o', 792},
+		{'r', 793}, {';', 794}, {'p', 795}, {'e', 796}, {'c', 797}, {';', 798}, {'R', 799}, {'c', 800},
+		{'t', 801}, {'i', 802}, {'r', 803}, {'l', 804}, {'p', 805}, {'r', 806}, {'c', 807}, {'t', 808},
+		{'m', 809}, {'c', 810}, {'l', 811}, {'c', 812}, {'c', 813}, {'i', 814}, {'y', 815}, {'o', 816},
+		{'r', 817}, {'r', 818}, {';', 819}, {'a', 820}, {'p', 821}, {'t', 822}, {'v', 823}, {'g', 824},
+		{'p', 825}, {'t', 826}, {'c', 827}, {'i', 828}, {'k', 829}, {'m', 830}, {'i', 831}, {'y', 832},
+		{'r', 833}, {'p', 834}, {'c', 835}, {'e', 836}, {'k', 837}, {'c', 838}, {'c', 839}, {'p', 840},
+		{'e', 841}, {'y', 842}, {'r', 843}, {'p', 844}, {'c', 845}, {'c', 846}, {';', 847}, {'c', 848},
+		{'m', 849}, {'n', 850}, {'p', 851}, {'r', 852}, {'a', 853}, {'e', 854}, {'y', 855}, {'f', 856},
+		{'s', 857}, {'r', 858}, {';', 859}, {'e', 860}, {'i', 861}, {'n', 862}, {'p', 863}, {'w', 864},
+		{'c', 865}, {'h', 866}, {'t', 867}, {';', 868}, {'p', 869}, {'y', 870}, {'d', 871}, {'l', 872},
+		{'r', 873}, {'n', 874}, {'p', 875}, {'c', 876}, {';', 877}, {'c', 878}, {'c', 879}, {'a', 880},
+		{'e', 881}, {'y', 882}, {'g', 883}, {'s', 884}, {'w', 885}, {'r', 886}, {'B', 887}, {'n', 888},
+		{'p', 889}, {'t', 890}, {'c', 891}, {'i', 892}, {';', 893}, {'l', 894}, {'c', 895}, {'i', 896},
+		{'y', 897}, {'b', 898}, {'r', 899}, {'r', 900}, {'a', 901}, {'e', 902}, {'i', 903}, {'p', 904},
+		{'e', 905}, {';', 906}, {'c', 907}, {'l', 908}, {'i', 909}, {'m', 910}, {'e', 911}, {'r', 912},
+		{'y', 913}, {'r', 914}, {'i', 915}, {';', 916}, {'u', 917}, {'i', 918}, {'p', 919}, {';', 920},
+		{'e', 921}, {'i', 922}, {'o', 923}, {'c', 924}, {'i', 925}, {'O', 926}, {'r', 927}, {'p', 928},
+		{'c', 929}, {'a', 930}, {'G', 931}, {'c', 932}, {'n', 933}, {'r', 934}, {'a', 935}, {'e', 936},
+		{'y', 937}, {';', 938}, {'v', 939}, {'r', 940}, {'o', 941}, {'g', 942}, {'p', 943}, {'u', 944},
+		{'i', 945}, {'c', 946}, {'h', 947}, {'l', 948}, {'C', 949}, {'c', 950}, {'F', 951}, {'c', 952},
+		{';', 953}, {'a', 954}, {'e', 955}, {'i', 956}, {'y', 957}, {'r', 958}, {'o', 959}, {'g', 960},
+		{'a', 961}, {'p', 962}, {'r', 963}, {'u', 964}, {'c', 965}, {'a', 966}, {'b', 967}, {'c', 968},
+		{'m', 969}, {'p', 970}, {'O', 971}, {'A', 972}, {'H', 973}, {'c', 974}, {'b', 975}, {'u', 976},
+		{'a', 977}, {'e', 978}, {'y', 979}, {'r', 980}, {'e', 981}, {'i', 982}, {'l', 983}, {'p', 984},
+		{'i', 985}, {'c', 986}, {'t', 987}, {'c', 988}, {'r', 989}, {'r', 990}, {'i', 991}, {'y', 992},
+		{'b', 993}, {'r', 994}, {'r', 995}, {'a', 996}, {'d', 997}, {'i', 998}, {'g', 999}, {'p', 1000},
+		{'A', 1001}, {'D', 1002}, {'E', 1003}, {'T', 1004}, {'a', 1005}, {'d', 1006}, {'p', 1007}, {'s', 1008},
+		{'i', 1009}, {'c', 1010}, {'i', 1011}, {'m', 1012}, {'a', 1013}, {'a', 1014}, {'y', 1015}, {'a', 1016},
+		{'e', 1017}, {'r', 1018}, {'r', 1019}, {'p', 1020}, {'c', 1021}, {'d', 1022}, {'i', 1023}, {'d', 1024},
+		{'r', 1025}, {'p', 1026}, {'c', 1027}, {'r', 1028}, {';', 1029}, {'p', 1030}, {'c', 1031}, {'c', 1032},
+		{'c', 1033}, {'c', 1034}, {'c', 1035}, {'i', 1036}, {'y', 1037}, {'r', 1038}, {'p', 1039}, {'c', 1040},
+		{'m', 1041}, {'c', 1042}, {'c', 1043}, {'a', 1044}, {'y', 1045}, {'o', 1046}, {'r', 1047}, {'t', 1048},
+		{'r', 1049}, {'p', 1050}, {'c', 1051}, {'c', 1052}, {'r', 1053}, {';', 1054}, {'E', 1055}, {'d', 1056},
+		{'i', 1057}, {'u', 1058}, {'y', 1059}, {'l', 1060}, {';', 1061}, {'r', 1062}, {'r', 1063}, {'e', 1064},
+		{'p', 1065}, {'a', 1066}, {'p', 1067}, {'d', 1068}, {'g', 1069}, {'g', 1070}, {'p', 1071}, {';', 1072},
+		{'E', 1073}, {'a', 1074}, {'e', 1075}, {'i', 1076}, {'o', 1077}, {'p', 1078}, {'i', 1079}, {'c', 1080},
+		{'t', 1081}, {'y', 1082}, {'i', 1083}, {'m', 1084}, {'c', 1085}, {'i', 1086}, {'o', 1087}, {'c', 1088},
+		{'r', 1089}, {'r', 1090}, {'o', 1091}, {'y', 1092}, {'q', 1093}, {'c', 1094}, {'m', 1095}, {'p', 1096},
+		{'r', 1097}, {'t', 1098}, {'r', 1099}, {'g', 1100}, {'a', 1101}, {'a', 1102}, {'k', 1103}, {'o', 1104},
+		{'e', 1105}, {'o', 1106}, {'p', 1107}, {'t', 1108}, {'w', 1109}, {'x', 1110}, {'r', 1111}, {'e', 1112},
+		{'v', 1113}, {'c', 1114}, {'e', 1115}, {'i', 1116}, {'o', 1117}, {'l', 1118}, {'m', 1119}, {'c', 1120},
+		{'p', 1121}, {'r', 1122}, {'a', 1123}, {'e', 1124}, {'i', 1125}, {'u', 1126}, {'o', 1127}, {'d', 1128},
+		{'m', 1129}, {'n', 1130}, {'r', 1131}, {'c', 1132}, {'e', 1133}, {'i', 1134}, {'r', 1135}, {'u', 1136},
+		{'l', 1137}, {'m', 1138}, {'n', 1139}, {'p', 1140}, {'a', 1141}, {'o', 1142}, {'c', 1143}, {'u', 1144},
+		{'d', 1145}, {'d', 1146}, {'e', 1147}, {'l', 1148}, {'p', 1149}, {'r', 1150}, {'v', 1151}, {'w', 1152},
+		{'c', 1153}, {'i', 1154}, {'l', 1155}, {'r', 1156}, {'a', 1157}, {'g', 1158}, {'l', 1159}, {'r', 1160},
+		{'s', 1161}, {'k', 1162}, {'l', 1163}, {'a', 1164}, {'y', 1165}, {';', 1166}, {'a', 1167}, {'o', 1168},
+		{'g', 1169}, {'l', 1170}, {'m', 1171}, {'i', 1172}, {'r', 1173}, {'a', 1174}, {'a', 1175}, {'e', 1176},
+		{'g', 1177}, {'s', 1178}, {'v', 1179}, {'c', 1180}, {'c', 1181}, {'l', 1182}, {'p', 1183}, {'t', 1184},
+		{'u', 1185}, {'w', 1186}, {'b', 1187}, {'c', 1188}, {'c', 1189}, {'o', 1190}, {'t', 1191}, {'d', 1192},
+		{'r', 1193}, {'a', 1194}, {'h', 1195}, {'a', 1196}, {'c', 1197}, {'i', 1198}, {'D', 1199}, {'o', 1200},
+		{'c', 1201}, {'s', 1202}, {'a', 1203}, {'i', 1204}, {'o', 1205}, {'y', 1206}, {'o', 1207}, {';', 1208},
+		{'D', 1209}, {'r', 1210}, {';', 1211}, {'r', 1212}, {'s', 1213}, {';', 1214}, {'i', 1215}, {'l', 1216},
+		{'s', 1217}, {'a', 1218}, {'p', 1219}, {'s', 1220}, {'g', 1221}, {'s', 1222}, {'g', 1223}, {'p', 1224},
+		{'a', 1225}, {'l', 1226}, {'s', 1227}, {'c', 1228}, {'s', 1229}, {'u', 1230}, {'v', 1231}, {'D', 1232},
+		{'a', 1233}, {'c', 1234}, {'d', 1235}, {'i', 1236}, {'a', 1237}, {'h', 1238}, {'m', 1239}, {'r', 1240},
+		{'c', 1241}, {'i', 1242}, {'p', 1243}, {'l', 1244}, {'y', 1245}, {'m', 1246}, {'i', 1247}, {'l', 1248},
+		{'r', 1249}, {'l', 1250}, {'l', 1251}, {'a', 1252}, {'l', 1253}, {'t', 1254}, {'o', 1255}, {'p', 1256},
+		{'r', 1257}, {'a', 1258}, {'a', 1259}, {'o', 1260}, {'c', 1261}, {';', 1262}, {'l', 1263}, {'c', 1264},
+		{'m', 1265}, {'p', 1266}, {'r', 1267}, {'i', 1268}, {'y', 1269}, {'o', 1270}, {';', 1271}, {'l', 1272},
+		{'q', 1273}, {'s', 1274}, {'r', 1275}, {';', 1276}, {'g', 1277}, {'m', 1278}, {'c', 1279}, {';', 1280},
+		{'E', 1281}, {'a', 1282}, {'j', 1283}, {'E', 1284}, {'a', 1285}, {'e', 1286}, {'s', 1287}, {'p', 1288},
+		{'a', 1289}, {'c', 1290}, {'i', 1291}, {';', 1292}, {'c', 1293}, {'d', 1294}, {'l', 1295}, {'q', 1296},
+		{'r', 1297}, {'e', 1298}, {'n', 1299}, {'r', 1300}, {'i', 1301}, {'l', 1302}, {'m', 1303}, {'r', 1304},
+		{'a', 1305}, {'i', 1306}, {'a', 1307}, {'l', 1308}, {'r', 1309}, {'r', 1310}, {'s', 1311}, {'a', 1312},
+		{'m', 1313}, {'o', 1314}, {'p', 1315}, {'r', 1316}, {'c', 1317}, {'l', 1318}, {'t', 1319}, {'b', 1320},
+		{'p', 1321}, {'c', 1322}, {';', 1323}, {'i', 1324}, {'y', 1325}, {'c', 1326}, {'x', 1327}, {'f', 1328},
+		{'r', 1329}, {'r', 1330}, {';', 1331}, {'i', 1332}, {'n', 1333}, {'o', 1334}, {'l', 1335}, {'a', 1336},
+		{'o', 1337}, {'p', 1338}, {';', 1339}, {'c', 1340}, {'f', 1341}, {'o', 1342}, {'t', 1343}, {'c', 1344},
+		{'g', 1345}, {'p', 1346}, {'t', 1347}, {'r', 1348}, {'u', 1349}, {'c', 1350}, {'i', 1351}, {';', 1352},
+		{'i', 1353}, {'k', 1354}, {'m', 1355}, {'i', 1356}, {'y', 1357}, {'r', 1358}, {'a', 1359}, {'p', 1360},
+		{'c', 1361}, {'e', 1362}, {'k', 1363}, {'p', 1364}, {'e', 1365}, {'y', 1366}, {'r', 1367}, {'r', 1368},
+		{'c', 1369}, {'c', 1370}, {'p', 1371}, {'c', 1372}, {'a', 1373}, {'r', 1374}, {'t', 1375}, {'a', 1376},
+		{';', 1377}, {'g', 1378}, {'a', 1379}, {'c', 1380}, {'e', 1381}, {'g', 1382}, {'m', 1383}, {'n', 1384},
+		{'p', 1385}, {'q', 1386}, {'r', 1387}, {'t', 1388}, {'a', 1389}, {'b', 1390}, {'r', 1391}, {'a', 1392},
+		{'e', 1393}, {'u', 1394}, {'y', 1395}, {'c', 1396}, {'q', 1397}, {'r', 1398}, {'s', 1399}, {';', 1400},
+		{'f', 1401}, {'g', 1402}, {'q', 1403}, {'s', 1404}, {'i', 1405}, {'l', 1406}, {'r', 1407}, {';', 1408},
+		{'E', 1409}, {'a', 1410}, {'b', 1411}, {'c', 1412}, {';', 1413}, {'a', 1414}, {'c', 1415}, {'h', 1416},
+		{'t', 1417}, {'i', 1418}, {'o', 1419}, {'E', 1420}, {'a', 1421}, {'e', 1422}, {'s', 1423}, {'a', 1424},
+		{'b', 1425}, {'n', 1426}, {'o', 1427}, {'p', 1428}, {'t', 1429}, {'w', 1430}, {'z', 1431}, {'a', 1432},
+		{'a', 1433}, {'c', 1434}, {'h', 1435}, {'m', 1436}, {'t', 1437}, {'a', 1438}, {'c', 1439}, {'h', 1440},
+		{'i', 1441}, {'q', 1442}, {'t', 1443}, {';', 1444}, {'c', 1445}, {'d', 1446}, {'h', 1447}, {'i', 1448},
+		{'l', 1449}, {'q', 1450}, {'r', 1451}, {'r', 1452}, {'e', 1453}, {'n', 1454}, {'D', 1455}, {'c', 1456},
+		{'l', 1457}, {'p', 1458}, {'r', 1459}, {'o', 1460}, {'y', 1461}, {'a', 1462}, {'a', 1463}, {'r', 1464},
+		{'o', 1465}, {'c', 1466}, {'d', 1467}, {'n', 1468}, {'c', 1469}, {'d', 1470}, {'p', 1471}, {'d', 1472},
+		{'p', 1473}, {';', 1474}, {'c', 1475}, {'t', 1476}, {';', 1477}, {'l', 1478}, {'m', 1479}, {'g', 1480},
+		{'t', 1481}, {'e', 1482}, {'l', 1483}, {'t', 1484}, {'i', 1485}, {'D', 1486}, {'d', 1487}, {'b', 1488},
+		{'c', 1489}, {'n', 1490}, {'p', 1491}, {'t', 1492}, {'s', 1493}, {'u', 1494}, {'a', 1495}, {'e', 1496},
+		{'o', 1497}, {'u', 1498}, {'y', 1499}, {'a', 1500}, {';', 1501}, {'A', 1502}, {'a', 1503}, {'d', 1504},
+		{'q', 1505}, {'s', 1506}, {'x', 1507}, {'r', 1508}, {'E', 1509}, {'e', 1510}, {'s', 1511}, {'t', 1512},
+		{'A', 1513}, {'a', 1514}, {'p', 1515}, {';', 1516}, {'s', 1517}, {'v', 1518}, {'c', 1519}, {'A', 1520},
+		{'E', 1521}, {'a', 1522}, {'d', 1523}, {'e', 1524}, {'s', 1525}, {'t', 1526}, {'i', 1527}, {'p', 1528},
+		{'t', 1529}, {'a', 1530}, {'o', 1531}, {'r', 1532}, {'A', 1533}, {'a', 1534}, {'i', 1535}, {'t', 1536},
+		{'c', 1537}, {'h', 1538}, {'i', 1539}, {'m', 1540}, {'p', 1541}, {'q', 1542}, {'u', 1543}, {'g', 1544},
+		{'i', 1545}, {'l', 1546}, {'r', 1547}, {';', 1548}, {'m', 1549}, {'D', 1550}, {'H', 1551}, {'a', 1552},
+		{'d', 1553}, {'g', 1554}, {'i', 1555}, {'l', 1556}, {'r', 1557}, {'s', 1558}, {'A', 1559}, {'a', 1560},
+		{'n', 1561}, {';', 1562}, {'c', 1563}, {'s', 1564}, {'i', 1565}, {'y', 1566}, {'a', 1567}, {'b', 1568},
+		{'i', 1569}, {'o', 1570}, {'s', 1571}, {'l', 1572}, {'c', 1573}, {'r', 1574}, {'o', 1575}, {'r', 1576},
+		{'t', 1577}, {'b', 1578}, {'m', 1579}, {'n', 1580}, {'a', 1581}, {'c', 1582}, {'i', 1583}, {'t', 1584},
+		{'a', 1585}, {'e', 1586}, {'i', 1587}, {'p', 1588}, {'a', 1589}, {'e', 1590}, {'l', 1591}, {';', 1592},
+		{'a', 1593}, {'d', 1594}, {'i', 1595}, {'o', 1596}, {'s', 1597}, {'v', 1598}, {'c', 1599}, {'l', 1600},
+		{'o', 1601}, {'i', 1602}, {'m', 1603}, {'b', 1604}, {'r', 1605}, {'y', 1606}, {'r', 1607}, {'r', 1608},
+		{'i', 1609}, {'m', 1610}, {'o', 1611}, {';', 1612}, {'t', 1613}, {'v', 1614}, {'a', 1615}, {'u', 1616},
+		{';', 1617}, {'i', 1618}, {'p', 1619}, {'u', 1620}, {';', 1621}, {'E', 1622}, {'a', 1623}, {'c', 1624},
+		{'e', 1625}, {'i', 1626}, {'n', 1627}, {'o', 1628}, {'s', 1629}, {'u', 1630}, {'c', 1631}, {'i', 1632},
+		{'n', 1633}, {'r', 1634}, {'n', 1635}, {'p', 1636}, {'r', 1637}, {'c', 1638}, {'a', 1639}, {'e', 1640},
+		{'o', 1641}, {'a', 1642}, {'r', 1643}, {'t', 1644}, {'a', 1645}, {'a', 1646}, {'c', 1647}, {'d', 1648},
+		{'e', 1649}, {'n', 1650}, {'q', 1651}, {'r', 1652}, {'t', 1653}, {'a', 1654}, {'b', 1655}, {'r', 1656},
+		{'a', 1657}, {'e', 1658}, {'u', 1659}, {'y', 1660}, {'c', 1661}, {'l', 1662}, {'q', 1663}, {'s', 1664},
+		{'a', 1665}, {'c', 1666}, {'g', 1667}, {'i', 1668}, {'l', 1669}, {'r', 1670}, {'a', 1671}, {'o', 1672},
+		{'g', 1673}, {'n', 1674}, {'s', 1675}, {'a', 1676}, {'h', 1677}, {'m', 1678}, {'o', 1679}, {'m', 1680},
+		{'a', 1681}, {'b', 1682}, {'p', 1683}, {'t', 1684}, {'a', 1685}, {'p', 1686}, {'a', 1687}, {'a', 1688},
+		{'c', 1689}, {'h', 1690}, {'q', 1691}, {'h', 1692}, {'i', 1693}, {'r', 1694}, {'l', 1695}, {';', 1696},
+		{'c', 1697}, {'q', 1698}, {';', 1699}, {'E', 1700}, {'a', 1701}, {'c', 1702}, {'e', 1703}, {'i', 1704},
+		{'n', 1705}, {'p', 1706}, {'s', 1707}, {'y', 1708}, {'o', 1709}, {'A', 1710}, {'a', 1711}, {'c', 1712},
+		{'m', 1713}, {'s', 1714}, {'t', 1715}, {'x', 1716}, {'r', 1717}, {'a', 1718}, {'c', 1719}, {'o', 1720},
+		{'y', 1721}, {'g', 1722}, {'m', 1723}, {'a', 1724}, {'a', 1725}, {'e', 1726}, {'i', 1727}, {'t', 1728},
+		{'f', 1729}, {'l', 1730}, {'p', 1731}, {'a', 1732}, {'c', 1733}, {'s', 1734}, {'u', 1735}, {'a', 1736},
+		{'c', 1737}, {'e', 1738}, {'m', 1739}, {'t', 1740}, {'a', 1741}, {'r', 1742}, {'b', 1743}, {'c', 1744},
+		{'m', 1745}, {'n', 1746}, {'p', 1747}, {'A', 1748}, {'a', 1749}, {'n', 1750}, {'l', 1751}, {'r', 1752},
+		{'u', 1753}, {'r', 1754}, {'a', 1755}, {'e', 1756}, {'y', 1757}, {'o', 1758}, {'l', 1759}, {'r', 1760},
+		{'e', 1761}, {'i', 1762}, {'k', 1763}, {'o', 1764}, {'l', 1765}, {'m', 1766}, {'n', 1767}, {'e', 1768},
+		{'p', 1769}, {'s', 1770}, {'r', 1771}, {'a', 1772}, {'i', 1773}, {'p', 1774}, {'c', 1775}, {'h', 1776},
+		{'t', 1777}, {'i', 1778}, {'o', 1779}, {'r', 1780}, {'a', 1781}, {'c', 1782}, {'r', 1783}, {'r', 1784},
+		{'i', 1785}, {'y', 1786}, {'a', 1787}, {'b', 1788}, {'h', 1789}, {'i', 1790}, {'r', 1791}, {'r', 1792},
+		{'a', 1793}, {'b', 1794}, {'c', 1795}, {'t', 1796}, {'a', 1797}, {'l', 1798}, {'g', 1799}, {'p', 1800},
+		{'a', 1801}, {'d', 1802}, {'h', 1803}, {'l', 1804}, {'s', 1805}, {'u', 1806}, {'c', 1807}, {'i', 1808},
+		{'t', 1809}, {'c', 1810}, {'d', 1811}, {'i', 1812}, {'r', 1813}, {'a', 1814}, {'m', 1815}, {'a', 1816},
+		{'r', 1817}, {'a', 1818}, {'a', 1819}, {'n', 1820}, {'r', 1821}, {'y', 1822}, {'a', 1823}, {'e', 1824},
+		{'l', 1825}, {'r', 1826}, {'r', 1827}, {'t', 1828}, {'s', 1829}, {'p', 1830}, {'r', 1831}, {'t', 1832},
+		{'c', 1833}, {'u', 1834}, {'i', 1835}, {'i', 1836}, {'d', 1837}, {'i', 1838}, {'r', 1839}, {'p', 1840},
+		{';', 1841}, {';', 1842}, {'e', 1843}, {'c', 1844}, {'a', 1845}, {'i', 1846}, {'u', 1847}, {'t', 1848},
+		{'r', 1849}, {'A', 1850}, {'a', 1851}, {';', 1852}, {'A', 1853}, {'a', 1854}, {'a', 1855}, {'i', 1856},
+		{'d', 1857}, {'p', 1858}, {'t', 1859}, {'A', 1860}, {'a', 1861}, {'c', 1862}, {'q', 1863}, {'p', 1864},
+		{'t', 1865}, {'e', 1866}, {'e', 1867}, {'c', 1868}, {'i', 1869}, {'y', 1870}, {'n', 1871}, {'r', 1872},
+		{'c', 1873}, {'p', 1874}, {'c', 1875}, {'c', 1876}, {'m', 1877}, {'c', 1878}, {'a', 1879}, {'y', 1880},
+		{'o', 1881}, {'e', 1882}, {'t', 1883}, {'r', 1884}, {'c', 1885}, {'g', 1886}, {'p', 1887}, {'c', 1888},
+		{'j', 1889}, {'n', 1890}, {'i', 1891}, {';', 1892}, {'u', 1893}, {'e', 1894}, {'r', 1895}, {';', 1896},
+		{';', 1897}, {'a', 1898}, {'h', 1899}, {'c', 1900}, {';', 1901}, {'o', 1902}, {'f', 1903}, {'l', 1904},
+		{'n', 1905}, {'r', 1906}, {'i', 1907}, {'l', 1908}, {'l', 1909}, {'k', 1910}, {'v', 1911}, {'w', 1912},
+		{';', 1913}, {'a', 1914}, {'n', 1915}, {'a', 1916}, {';', 1917}, {'f', 1918}, {'v', 1919}, {'r', 1920},
+		{'p', 1921}, {'y', 1922}, {'Y', 1923}, {'u', 1924}, {';', 1925}, {'i', 1926}, {'l', 1927}, {'r', 1928},
+		{'d', 1929}, {'r', 1930}, {'n', 1931}, {'t', 1932}, {'i', 1933}, {'t', 1934}, {';', 1935}, {';', 1936},
+		{'c', 1937}, {'c', 1938}, {'s', 1939}, {'o', 1940}, {'g', 1941}, {'i', 1942}, {'t', 1943}, {'f', 1944},
+		{'r', 1945}, {'n', 1946}, {'s', 1947}, {'r', 1948}, {';', 1949}, {'C', 1950}, {'t', 1951}, {'y', 1952},
+		{'y', 1953}, {'y', 1954}, {'g', 1955}, {'r', 1956}, {'h', 1957}, {'r', 1958}, {';', 1959}, {';', 1960},
+		{'t', 1961}, {';', 1962}, {'c', 1963}, {'m', 1964}, {'f', 1965}, {'f', 1966}, {';', 1967}, {'D', 1968},
+		{'E', 1969}, {'b', 1970}, {'n', 1971}, {'r', 1972}, {'r', 1973}, {';', 1974}, {';', 1975}, {'u', 1976},
+		{'r', 1977}, {'r', 1978}, {';', 1979}, {'t', 1980}, {';', 1981}, {'a', 1982}, {'m', 1983}, {'c', 1984},
+		{'t', 1985}, {'o', 1986}, {'f', 1987}, {'i', 1988}, {'a', 1989}, {'i', 1990}, {'r', 1991}, {'m', 1992},
+		{';', 1993}, {'l', 1994}, {'s', 1995}, {'o', 1996}, {';', 1997}, {';', 1998}, {'l', 1999}, {'f', 2000},
+		{'A', 2001}, {'r', 2002}, {'r', 2003}, {'y', 2004}, {'m', 2005}, {'e', 2006}, {'d', 2007}, {'r', 2008},
+		{';', 2009}, {'t', 2010}, {';', 2011}, {'f', 2012}, {'a', 2013}, {'r', 2014}, {'D', 2015}, {'e', 2016},
+		{';', 2017}, {'r', 2018}, {';', 2019}, {'b', 2020}, {'f', 2021}, {'i', 2022}, {'r', 2023}, {'r', 2024},
+		{'p', 2025}, {'y', 2026}, {'i', 2027}, {'y', 2028}, {'u', 2029}, {'r', 2030}, {';', 2031}, {'t', 2032},
+		{';', 2033}, {'a', 2034}, {'c', 2035}, {'g', 2036}, {'l', 2037}, {';', 2038}, {'e', 2039}, {'i', 2040},
+		{'o', 2041}, {'f', 2042}, {'a', 2043}, {'r', 2044}, {'l', 2045}, {'c', 2046}, {'l', 2047}, {'r', 2048},
+		{';', 2049}, {';', 2050}, {'f', 2051}, {'r', 2052}, {'r', 2053}, {'c', 2054}, {'y', 2055}, {'y', 2056},
+		{'p', 2057}, {'d', 2058}, {';', 2059}, {';', 2060}, {'f', 2061}, {'r', 2062}, {'y', 2063}, {'u', 2064},
+		{'b', 2065}, {'g', 2066}, {'l', 2067}, {'r', 2068}, {'r', 2069}, {'d', 2070}, {';', 2071}, {'t', 2072},
+		{'s', 2073}, {';', 2074}, {'f', 2075}, {'d', 2076}, {'g', 2077}, {'f', 2078}, {'e', 2079}, {'r', 2080},
+		{';', 2081}, {'r', 2082}, {';', 2083}, {';', 2084}, {'i', 2085}, {'l', 2086}, {';', 2087}, {'u', 2088},
+		{'f', 2089}, {'r', 2090}, {'y', 2091}, {'u', 2092}, {'r', 2093}, {'d', 2094}, {';', 2095}, {'a', 2096},
+		{'t', 2097}, {'L', 2098}, {';', 2099}, {'r', 2100}, {'B', 2101}, {'f', 2102}, {';', 2103}, {'C', 2104},
+		{'D', 2105}, {'E', 2106}, {'G', 2107}, {'H', 2108}, {'L', 2109}, {'N', 2110}, {'P', 2111}, {'R', 2112},
+		{'S', 2113}, {'T', 2114}, {'V', 2115}, {'r', 2116}, {'l', 2117}, {'i', 2118}, {'u', 2119}, {'r', 2120},
+		{';', 2121}, {'l', 2122}, {';', 2123}, {'a', 2124}, {'c', 2125}, {'g', 2126}, {'c', 2127}, {'f', 2128},
+		{'n', 2129}, {'r', 2130}, {'a', 2131}, {'l', 2132}, {'m', 2133}, {'l', 2134}, {'r', 2135}, {'t', 2136},
+		{';', 2137}, {';', 2138}, {';', 2139}, {'s', 2140}, {'n', 2141}, {'f', 2142}, {'c', 2143}, {'m', 2144},
+		{'d', 2145}, {'p', 2146}, {'r', 2147}, {';', 2148}, {'T', 2149}, {';', 2150}, {'f', 2151}, {'r', 2152},
+		{'r', 2153}, {';', 2154}, {'u', 2155}, {'g', 2156}, {'r', 2157}, {'r', 2158}, {'d', 2159}, {';', 2160},
+		{'e', 2161}, {';', 2162}, {';', 2163}, {'h', 2164}, {'f', 2165}, {'n', 2166}, {'g', 2167}, {'r', 2168},
+		{';', 2169}, {'e', 2170}, {'H', 2171}, {'y', 2172}, {'T', 2173}, {'u', 2174}, {'r', 2175}, {'d', 2176},
+		{'r', 2177}, {';', 2178}, {';', 2179}, {'r', 2180}, {'m', 2181}, {'l', 2182}, {'f', 2183}, {'t', 2184},
+		{'a', 2185}, {'r', 2186}, {'r', 2187}, {';', 2188}, {'s', 2189}, {'c', 2190}, {'h', 2191}, {';', 2192},
+		{';', 2193}, {'e', 2194}, {'s', 2195}, {'R', 2196}, {'D', 2197}, {'c', 2198}, {'y', 2199}, {';', 2200},
+		{';', 2201}, {'r', 2202}, {'d', 2203}, {';', 2204}, {';', 2205}, {'r', 2206}, {'t', 2207}, {'c', 2208},
+		{'n', 2209}, {'d', 2210}, {'f', 2211}, {'p', 2212}, {'r', 2213}, {'r', 2214}, {'u', 2215}, {'r', 2216},
+		{'c', 2217}, {'e', 2218}, {'r', 2219}, {';', 2220}, {'l', 2221}, {';', 2222}, {'a', 2223}, {'c', 2224},
+		{'e', 2225}, {'o', 2226}, {'o', 2227}, {'f', 2228}, {'r', 2229}, {'o', 2230}, {'q', 2231}, {'e', 2232},
+		{'r', 2233}, {'o', 2234}, {'e', 2235}, {'i', 2236}, {'n', 2237}, {'r', 2238}, {'l', 2239}, {'l', 2240},
+		{'s', 2241}, {'r', 2242}, {';', 2243}, {'s', 2244}, {';', 2245}, {'b', 2246}, {'t', 2247}, {'y', 2248},
+		{';', 2249}, {'f', 2250}, {'r', 2251}, {'a', 2252}, {'r', 2253}, {'g', 2254}, {';', 2255}, {'f', 2256},
+		{'r', 2257}, {';', 2258}, {'f', 2259}, {'r', 2260}, {'y', 2261}, {'y', 2262}, {'y', 2263}, {'u', 2264},
+		{'r', 2265}, {';', 2266}, {';', 2267}, {'f', 2268}, {'r', 2269}, {'l', 2270}, {'y', 2271}, {'u', 2272},
+		{'r', 2273}, {';', 2274}, {'t', 2275}, {'o', 2276}, {'a', 2277}, {';', 2278}, {'f', 2279}, {'r', 2280},
+		{'u', 2281}, {'e', 2282}, {';', 2283}, {';', 2284}, {'r', 2285}, {'t', 2286}, {';', 2287}, {'i', 2288},
+		{';', 2289}, {'a', 2290}, {'f', 2291}, {'p', 2292}, {'h', 2293}, {'c', 2294}, {'l', 2295}, {';', 2296},
+		{';', 2297}, {'a', 2298}, {'d', 2299}, {'s', 2300}, {'v', 2301}, {';', 2302}, {'e', 2303}, {'l', 2304},
+		{'m', 2305}, {'r', 2306}, {'s', 2307}, {'z', 2308}, {'o', 2309}, {'f', 2310}, {';', 2311}, {'c', 2312},
+		{';', 2313}, {'d', 2314}, {'s', 2315}, {'r', 2316}, {'n', 2317}, {'r', 2318}, {';', 2319}, {'m', 2320},
+		{'l', 2321}, {'l', 2322}, {'o', 2323}, {'n', 2324}, {'t', 2325}, {'k', 2326}, {'v', 2327}, {'w', 2328},
+		{'k', 2329}, {'n', 2330}, {';', 2331}, {'u', 2332}, {'a', 2333}, {'p', 2334}, {'s', 2335}, {'n', 2336},
+		{'a', 2337}, {'h', 2338}, {'w', 2339}, {';', 2340}, {'c', 2341}, {'o', 2342}, {'s', 2343}, {'t', 2344},
+		{'u', 2345}, {'v', 2346}, {'w', 2347}, {'r', 2348}, {'c', 2349}, {'n', 2350}, {'1', 2351}, {'3', 2352},
+		{'c', 2353}, {';', 2354}, {'q', 2355}, {'t', 2356}, {'f', 2357}, {';', 2358}, {'t', 2359}, {'t', 2360},
+		{'D', 2361}, {'H', 2362}, {'U', 2363}, {'V', 2364}, {'b', 2365}, {'d', 2366}, {'h', 2367}, {'m', 2368},
+		{'p', 2369}, {'t', 2370}, {'u', 2371}, {'v', 2372}, {'i', 2373}, {'v', 2374}, {'b', 2375}, {'r', 2376},
+		{'m', 2377}, {'m', 2378}, {'l', 2379}, {'l', 2380}, {'p', 2381}, {'u', 2382}, {';', 2383}, {'a', 2384},
+		{'b', 2385}, {'c', 2386}, {'d', 2387}, {'s', 2388}, {'e', 2389}, {'o', 2390}, {'p', 2391}, {'r', 2392},
+		{'d', 2393}, {'r', 2394}, {'p', 2395}, {'t', 2396}, {'i', 2397}, {'p', 2398}, {'t', 2399}, {';', 2400},
+		{'y', 2401}, {'c', 2402}, {';', 2403}, {';', 2404}, {'E', 2405}, {'c', 2406}, {'e', 2407}, {'f', 2408},
+		{'m', 2409}, {'s', 2410}, {'b', 2411}, {'o', 2412}, {'m', 2413}, {'p', 2414}, {'g', 2415}, {'i', 2416},
+		{'f', 2417}, {'r', 2418}, {'y', 2419}, {'r', 2420}, {'s', 2421}, {'r', 2422}, {'b', 2423}, {'p', 2424},
+		{'o', 2425}, {'a', 2426}, {'p', 2427}, {'s', 2428}, {'a', 2429}, {';', 2430}, {'b', 2431}, {'c', 2432},
+		{'d', 2433}, {'o', 2434}, {'s', 2435}, {'a', 2436}, {'l', 2437}, {'r', 2438}, {'v', 2439}, {'e', 2440},
+		{'e', 2441}, {'o', 2442}, {'n', 2443}, {'c', 2444}, {'r', 2445}, {'r', 2446}, {'g', 2447}, {'e', 2448},
+		{'r', 2449}, {'h', 2450}, {'a', 2451}, {'a', 2452}, {'r', 2453}, {';', 2454}, {'g', 2455}, {'r', 2456},
+		{'t', 2457}, {';', 2458}, {'t', 2459}, {'p', 2460}, {'s', 2461}, {';', 2462}, {'r', 2463}, {'m', 2464},
+		{';', 2465}, {'a', 2466}, {'i', 2467}, {';', 2468}, {'i', 2469}, {'o', 2470}, {'y', 2471}, {'o', 2472},
+		{'r', 2473}, {'l', 2474}, {'f', 2475}, {';', 2476}, {'e', 2477}, {'m', 2478}, {'p', 2479}, {'s', 2480},
+		{'b', 2481}, {'n', 2482}, {'k', 2483}, {'o', 2484}, {'r', 2485}, {'r', 2486}, {'y', 2487}, {'l', 2488},
+		{'r', 2489}, {'o', 2490}, {'i', 2491}, {'r', 2492}, {'a', 2493}, {'n', 2494}, {'y', 2495}, {'g', 2496},
+		{'o', 2497}, {'t', 2498}, {'u', 2499}, {'t', 2500}, {'r', 2501}, {'r', 2502}, {'l', 2503}, {';', 2504},
+		{'t', 2505}, {'o', 2506}, {';', 2507}, {'a', 2508}, {';', 2509}, {'d', 2510}, {'n', 2511}, {';', 2512},
+		{';', 2513}, {'d', 2514}, {'c', 2515}, {'t', 2516}, {'p', 2517}, {';', 2518}, {'p', 2519}, {'o', 2520},
+		{'f', 2521}, {'r', 2522}, {'u', 2523}, {'i', 2524}, {'i', 2525}, {'o', 2526}, {'i', 2527}, {'l', 2528},
+		{'a', 2529}, {'e', 2530}, {'i', 2531}, {'p', 2532}, {'o', 2533}, {'r', 2534}, {'r', 2535}, {'o', 2536},
+		{'m', 2537}, {';', 2538}, {';', 2539}, {'l', 2540}, {'o', 2541}, {'l', 2542}, {'s', 2543}, {'e', 2544},
+		{'o', 2545}, {'l', 2546}, {';', 2547}, {'a', 2548}, {'l', 2549}, {'i', 2550}, {'l', 2551}, {';', 2552},
+		{'i', 2553}, {'i', 2554}, {'t', 2555}, {'i', 2556}, {'n', 2557}, {'f', 2558}, {'f', 2559}, {'a', 2560},
+		{'k', 2561}, {'r', 2562}, {'c', 2563}, {'s', 2564}, {'w', 2565}, {'r', 2566}, {';', 2567}, {'u', 2568},
+		{'m', 2569}, {';', 2570}, {'e', 2571}, {'r', 2572}, {';', 2573}, {'t', 2574}, {';', 2575}, {';', 2576},
+		{'q', 2577}, {'s', 2578}, {';', 2579}, {'c', 2580}, {'d', 2581}, {'l', 2582}, {';', 2583}, {';', 2584},
+		{'e', 2585}, {'y', 2586}, {';', 2587}, {';', 2588}, {';', 2589}, {';', 2590}, {'p', 2591}, {';', 2592},
+		{'q', 2593}, {'i', 2594}, {'f', 2595}, {'v', 2596}, {'r', 2597}, {'m', 2598}, {'c', 2599}, {'i', 2600},
+		{'o', 2601}, {'P', 2602}, {'u', 2603}, {'a', 2604}, {'d', 2605}, {'e', 2606}, {'l', 2607}, {'s', 2608},
+		{'r', 2609}, {'E', 2610}, {'r', 2611}, {'r', 2612}, {'f', 2613}, {'i', 2614}, {'d', 2615}, {'r', 2616},
+		{'r', 2617}, {'r', 2618}, {'r', 2619}, {'l', 2620}, {'c', 2621}, {';', 2622}, {'e', 2623}, {'w', 2624},
+		{'r', 2625}, {'t', 2626}, {'k', 2627}, {'f', 2628}, {'b', 2629}, {'r', 2630}, {'a', 2631}, {'r', 2632},
+		{'u', 2633}, {'h', 2634}, {'u', 2635}, {'r', 2636}, {';', 2637}, {'y', 2638}, {'c', 2639}, {';', 2640},
+		{';', 2641}, {'a', 2642}, {'i', 2643}, {'n', 2644}, {'f', 2645}, {'t', 2646}, {'i', 2647}, {'c', 2648},
+		{'g', 2649}, {'t', 2650}, {'f', 2651}, {'e', 2652}, {'a', 2653}, {'i', 2654}, {'d', 2655}, {';', 2656},
+		{'c', 2657}, {'e', 2658}, {'l', 2659}, {'p', 2660}, {'y', 2661}, {'o', 2662}, {'f', 2663}, {'a', 2664},
+		{'o', 2665}, {'e', 2666}, {'r', 2667}, {'n', 2668}, {'l', 2669}, {'c', 2670}, {'l', 2671}, {'r', 2672},
+		{';', 2673}, {';', 2674}, {'t', 2675}, {'f', 2676}, {'r', 2677}, {'r', 2678}, {'c', 2679}, {'p', 2680},
+		{'d', 2681}, {';', 2682}, {';', 2683}, {'e', 2684}, {'y', 2685}, {'y', 2686}, {'f', 2687}, {'r', 2688},
+		{'r', 2689}, {'r', 2690}, {'a', 2691}, {'r', 2692}, {';', 2693}, {'r', 2694}, {'u', 2695}, {'m', 2696},
+		{'r', 2697}, {'b', 2698}, {'g', 2699}, {';', 2700}, {'u', 2701}, {'r', 2702}, {';', 2703}, {'a', 2704},
+		{'e', 2705}, {'r', 2706}, {'r', 2707}, {'a', 2708}, {'k', 2709}, {'r', 2710}, {'d', 2711}, {'i', 2712},
+		{'b', 2713}, {';', 2714}, {'a', 2715}, {'u', 2716}, {'d', 2717}, {'u', 2718}, {'h', 2719}, {'t', 2720},
+		{';', 2721}, {';', 2722}, {'q', 2723}, {'s', 2724}, {';', 2725}, {'c', 2726}, {'d', 2727}, {'g', 2728},
+		{'s', 2729}, {'s', 2730}, {'o', 2731}, {';', 2732}, {';', 2733}, {'r', 2734}, {'l', 2735}, {'y', 2736},
+		{'r', 2737}, {'o', 2738}, {'a', 2739}, {'r', 2740}, {'d', 2741}, {'u', 2742}, {';', 2743}, {'p', 2744},
+		{';', 2745}, {'q', 2746}, {'i', 2747}, {'n', 2748}, {'r', 2749}, {'r', 2750}, {'g', 2751}, {'p', 2752},
+		{'a', 2753}, {'f', 2754}, {'l', 2755}, {'i', 2756}, {'a', 2757}, {'b', 2758}, {';', 2759}, {'e', 2760},
+		{'f', 2761}, {'r', 2762}, {'r', 2763}, {'o', 2764}, {'a', 2765}, {';', 2766}, {'r', 2767}, {'q', 2768},
+		{'r', 2769}, {';', 2770}, {'m', 2771}, {'b', 2772}, {'u', 2773}, {'r', 2774}, {'c', 2775}, {'i', 2776},
+		{'o', 2777}, {'r', 2778}, {'m', 2779}, {'a', 2780}, {'u', 2781}, {'P', 2782}, {'i', 2783}, {'d', 2784},
+		{'u', 2785}, {'r', 2786}, {'E', 2787}, {'o', 2788}, {'r', 2789}, {'e', 2790}, {'t', 2791}, {';', 2792},
+		{'s', 2793}, {'k', 2794}, {'m', 2795}, {';', 2796}, {'s', 2797}, {'s', 2798}, {';', 2799}, {';', 2800},
+		{'r', 2801}, {';', 2802}, {'a', 2803}, {'c', 2804}, {'d', 2805}, {'u', 2806}, {'p', 2807}, {'r', 2808},
+		{'l', 2809}, {'e', 2810}, {'f', 2811}, {'r', 2812}, {'p', 2813}, {'t', 2814}, {'a', 2815}, {';', 2816},
+		{';', 2817}, {'v', 2818}, {'f', 2819}, {';', 2820}, {';', 2821}, {'v', 2822}, {'g', 2823}, {'a', 2824},
+		{'a', 2825}, {'l', 2826}, {'u', 2827}, {'g', 2828}, {';', 2829}, {'E', 2830}, {'i', 2831}, {'o', 2832},
+		{'p', 2833}, {'u', 2834}, {'p', 2835}, {'m', 2836}, {'p', 2837}, {'r', 2838}, {'d', 2839}, {'n', 2840},
+		{'p', 2841}, {';', 2842}, {'s', 2843}, {'r', 2844}, {'r', 2845}, {'o', 2846}, {'u', 2847}, {'e', 2848},
+		{'i', 2849}, {'i', 2850}, {';', 2851}, {';', 2852}, {';', 2853}, {'q', 2854}, {'s', 2855}, {'i', 2856},
+		{';', 2857}, {'r', 2858}, {'r', 2859}, {'r', 2860}, {'a', 2861}, {';', 2862}, {'d', 2863}, {';', 2864},
+		{'y', 2865}, {'r', 2866}, {';', 2867}, {'r', 2868}, {'r', 2869}, {';', 2870}, {'f', 2871}, {'q', 2872},
+		{'s', 2873}, {'i', 2874}, {';', 2875}, {'r', 2876}, {'d', 2877}, {'f', 2878}, {';', 2879}, {'i', 2880},
+		{'n', 2881}, {'r', 2882}, {'l', 2883}, {';', 2884}, {'c', 2885}, {'e', 2886}, {'r', 2887}, {'r', 2888},
+		{'g', 2889}, {'r', 2890}, {';', 2891}, {'c', 2892}, {'e', 2893}, {'r', 2894}, {'o', 2895}, {'m', 2896},
+		{'i', 2897}, {'a', 2898}, {'s', 2899}, {'b', 2900}, {'c', 2901}, {'p', 2902}, {'l', 2903}, {'l', 2904},
+		{'g', 2905}, {'i', 2906}, {';', 2907}, {'e', 2908}, {'s', 2909}, {'a', 2910}, {'a', 2911}, {'p', 2912},
+		{'a', 2913}, {'e', 2914}, {'t', 2915}, {'n', 2916}, {'A', 2917}, {'e', 2918}, {'t', 2919}, {'A', 2920},
+		{'t', 2921}, {'i', 2922}, {'r', 2923}, {'r', 2924}, {'e', 2925}, {'u', 2926}, {'t', 2927}, {'r', 2928},
+		{';', 2929}, {'s', 2930}, {'l', 2931}, {'v', 2932}, {'t', 2933}, {'o', 2934}, {'i', 2935}, {'i', 2936},
+		{';', 2937}, {'n', 2938}, {'a', 2939}, {';', 2940}, {'a', 2941}, {';', 2942}, {'t', 2943}, {'r', 2944},
+		{'i', 2945}, {'r', 2946}, {'n', 2947}, {';', 2948}, {'c', 2949}, {'g', 2950}, {'c', 2951}, {'d', 2952},
+		{'n', 2953}, {'f', 2954}, {'r', 2955}, {'r', 2956}, {'u', 2957}, {'r', 2958}, {';', 2959}, {'e', 2960},
+		{'f', 2961}, {'m', 2962}, {'g', 2963}, {'r', 2964}, {'l', 2965}, {';', 2966}, {'r', 2967}, {'a', 2968},
+		{'l', 2969}, {'l', 2970}, {'m', 2971}, {'l', 2972}, {'a', 2973}, {';', 2974}, {'a', 2975}, {'s', 2976},
+		{'t', 2977}, {';', 2978}, {'c', 2979}, {'i', 2980}, {'m', 2981}, {'p', 2982}, {'t', 2983}, {';', 2984},
+		{';', 2985}, {'v', 2986}, {'m', 2987}, {'n', 2988}, {'c', 2989}, {';', 2990}, {'n', 2991}, {'s', 2992},
+		{'n', 2993}, {'f', 2994}, {'n', 2995}, {';', 2996}, {'p', 2997}, {'u', 2998}, {';', 2999}, {'c', 3000},
+		{'m', 3001}, {'E', 3002}, {'a', 3003}, {'s', 3004}, {'d', 3005}, {'f', 3006}, {'p', 3007}, {'i', 3008},
+		{'r', 3009}, {'r', 3010}, {';', 3011}, {'c', 3012}, {';', 3013}, {'t', 3014}, {'f', 3015}, {'i', 3016},
+		{'r', 3017}, {'t', 3018}, {'s', 3019}, {'t', 3020}, {'r', 3021}, {'r', 3022}, {'a', 3023}, {'r', 3024},
+		{'r', 3025}, {'e', 3026}, {'u', 3027}, {'i', 3028}, {'m', 3029}, {'g', 3030}, {'u', 3031}, {'r', 3032},
+		{'a', 3033}, {'i', 3034}, {'r', 3035}, {'r', 3036}, {'a', 3037}, {'k', 3038}, {'r', 3039}, {'d', 3040},
+		{'i', 3041}, {'b', 3042}, {';', 3043}, {'a', 3044}, {'d', 3045}, {'u', 3046}, {'h', 3047}, {'l', 3048},
+		{'t', 3049}, {';', 3050}, {'s', 3051}, {'o', 3052}, {';', 3053}, {'r', 3054}, {';', 3055}, {'v', 3056},
+		{'h', 3057}, {'g', 3058}, {'i', 3059}, {'r', 3060}, {'a', 3061}, {';', 3062}, {'u', 3063}, {'i', 3064},
+		{'n', 3065}, {'r', 3066}, {'r', 3067}, {'a', 3068}, {'f', 3069}, {'l', 3070}, {'i', 3071}, {'r', 3072},
+		{'o', 3073}, {'r', 3074}, {'q', 3075}, {'r', 3076}, {';', 3077}, {'b', 3078}, {'u', 3079}, {'r', 3080},
+		{'m', 3081}, {'i', 3082}, {'u', 3083}, {'u', 3084}, {'u', 3085}, {';', 3086}, {'p', 3087}, {'r', 3088},
+		{'u', 3089}, {';', 3090}, {'d', 3091}, {'r', 3092}, {'E', 3093}, {'a', 3094}, {'s', 3095}, {'o', 3096},
+		{'i', 3097}, {';', 3098}, {'t', 3099}, {'r', 3100}, {'r', 3101}, {'t', 3102}, {'i', 3103}, {'w', 3104},
+		{'m', 3105}, {'t', 3106}, {';', 3107}, {'o', 3108}, {'r', 3109}, {'h', 3110}, {'y', 3111}, {'r', 3112},
+		{';', 3113}, {'m', 3114}, {';', 3115}, {'d', 3116}, {'e', 3117}, {'g', 3118}, {'l', 3119}, {'n', 3120},
+		{'p', 3121}, {'r', 3122}, {'r', 3123}, {'l', 3124}, {'s', 3125}, {'p', 3126}, {'d', 3127}, {'l', 3128},
+		{';', 3129}, {'e', 3130}, {'t', 3131}, {';', 3132}, {'b', 3133}, {'f', 3134}, {'d', 3135}, {'r', 3136},
+		{'a', 3137}, {'u', 3138}, {'u', 3139}, {';', 3140}, {'a', 3141}, {'f', 3142}, {'r', 3143}, {'r', 3144},
+		{'t', 3145}, {'i', 3146}, {'a', 3147}, {'r', 3148}, {'a', 3149}, {'n', 3150}, {';', 3151}, {'E', 3152},
+		{'d', 3153}, {'e', 3154}, {'m', 3155}, {'n', 3156}, {'p', 3157}, {'r', 3158}, {'s', 3159}, {'c', 3160},
+		{';', 3161}, {'g', 3162}, {'1', 3163}, {'2', 3164}, {'3', 3165}, {';', 3166}, {'E', 3167}, {'d', 3168},
+		{'e', 3169}, {'h', 3170}, {'l', 3171}, {'m', 3172}, {'n', 3173}, {'p', 3174}, {'s', 3175}, {'r', 3176},
+		{'r', 3177}, {'w', 3178}, {'i', 3179}, {'g', 3180}, {';', 3181}, {'k', 3182}, {'r', 3183}, {'d', 3184},
+		{';', 3185}, {'t', 3186}, {'r', 3187}, {';', 3188}, {'r', 3189}, {'t', 3190}, {'c', 3191}, {'n', 3192},
+		{'a', 3193}, {'s', 3194}, {'r', 3195}, {'d', 3196}, {'e', 3197}, {'t', 3198}, {'a', 3199}, {';', 3200},
+		{'b', 3201}, {'c', 3202}, {'f', 3203}, {'a', 3204}, {'i', 3205}, {'d', 3206}, {'a', 3207}, {'d', 3208},
+		{'e', 3209}, {'m', 3210}, {'p', 3211}, {'s', 3212}, {'t', 3213}, {'e', 3214}, {'r', 3215}, {'y', 3216},
+		{'c', 3217}, {'r', 3218}, {'x', 3219}, {'h', 3220}, {'r', 3221}, {'r', 3222}, {'u', 3223}, {'r', 3224},
+		{'c', 3225}, {'e', 3226}, {'r', 3227}, {';', 3228}, {'r', 3229}, {'l', 3230}, {'a', 3231}, {'s', 3232},
+		{';', 3233}, {'a', 3234}, {'r', 3235}, {'l', 3236}, {'o', 3237}, {'r', 3238}, {'r', 3239}, {'c', 3240},
+		{';', 3241}, {'o', 3242}, {'f', 3243}, {'r', 3244}, {'o', 3245}, {'a', 3246}, {'u', 3247}, {'i', 3248},
+		{'p', 3249}, {'o', 3250}, {'r', 3251}, {'n', 3252}, {'r', 3253}, {'r', 3254}, {'o', 3255}, {'l', 3256},
+		{'i', 3257}, {'r', 3258}, {'l', 3259}, {'n', 3260}, {'r', 3261}, {'r', 3262}, {'s', 3263}, {'g', 3264},
+		{'e', 3265}, {'k', 3266}, {'n', 3267}, {'p', 3268}, {'r', 3269}, {'s', 3270}, {'t', 3271}, {';', 3272},
+		{'s', 3273}, {';', 3274}, {'b', 3275}, {'e', 3276}, {'l', 3277}, {'b', 3278}, {'t', 3279}, {';', 3280},
+		{'r', 3281}, {'u', 3282}, {'f', 3283}, {'o', 3284}, {'r', 3285}, {'r', 3286}, {'b', 3287}, {'p', 3288},
+		{'g', 3289}, {'r', 3290}, {'b', 3291}, {'g', 3292}, {'e', 3293}, {';', 3294}, {'f', 3295}, {'a', 3296},
+		{'r', 3297}, {'p', 3298}, {'r', 3299}, {'p', 3300}, {'r', 3301}, {';', 3302}, {'r', 3303}, {'r', 3304},
+		{'r', 3305}, {'r', 3306}, {'p', 3307}, {'s', 3308}, {'o', 3309}, {'f', 3310}, {'l', 3311}, {'i', 3312},
+		{'r', 3313}, {'r', 3314}, {'r', 3315}, {'c', 3316}, {'l', 3317}, {'r', 3318}, {'e', 3319}, {'d', 3320},
+		{'u', 3321}, {'y', 3322}, {'r', 3323}, {';', 3324}, {';', 3325}, {';', 3326}, {'y', 3327}, {'f', 3328},
+		{'r', 3329}, {'y', 3330}, {'l', 3331}, {'u', 3332}, {'r', 3333}, {';', 3334}, {'t', 3335}, {'t', 3336},
+		{'a', 3337}, {';', 3338}, {'y', 3339}, {'r', 3340}, {'f', 3341}, {'r', 3342}, {';', 3343}, {'j', 3344},
+		{'g', 3345}, {'t', 3346}, {'v', 3347}, {'c', 3348}, {'v', 3349}, {'a', 3350}, {'r', 3351}, {'n', 3352},
+		{';', 3353}, {'y', 3354}, {'g', 3355}, {';', 3356}, {'g', 3357}, {'d', 3358}, {';', 3359}, {'s', 3360},
+		{';', 3361}, {'e', 3362}, {'u', 3363}, {'o', 3364}, {';', 3365}, {';', 3366}, {'e', 3367}, {';', 3368},
+		{'e', 3369}, {';', 3370}, {';', 3371}, {'t', 3372}, {'t', 3373}, {'e', 3374}, {'o', 3375}, {'i', 3376},
+		{'c', 3377}, {'i', 3378}, {';', 3379}, {'l', 3380}, {'e', 3381}, {'l', 3382}, {'k', 3383}, {'e', 3384},
+		{'n', 3385}, {'r', 3386}, {'n', 3387}, {'o', 3388}, {';', 3389}, {'o', 3390}, {'t', 3391}, {'s', 3392},
+		{';', 3393}, {'a', 3394}, {'r', 3395}, {';', 3396}, {';', 3397}, {';', 3398}, {'e', 3399}, {';', 3400},
+		{'v', 3401}, {'o', 3402}, {'a', 3403}, {'r', 3404}, {'o', 3405}, {'e', 3406}, {';', 3407}, {'o', 3408},
+		{'q', 3409}, {'l', 3410}, {'A', 3411}, {'B', 3412}, {'L', 3413}, {'R', 3414}, {'T', 3415}, {'a', 3416},
+		{';', 3417}, {'o', 3418}, {'t', 3419}, {'o', 3420}, {'c', 3421}, {';', 3422}, {'v', 3423}, {'e', 3424},
+		{'r', 3425}, {'y', 3426}, {'n', 3427}, {';', 3428}, {'l', 3429}, {'l', 3430}, {'l', 3431}, {';', 3432},
+		{';', 3433}, {';', 3434}, {'t', 3435}, {'n', 3436}, {'e', 3437}, {';', 3438}, {'l', 3439}, {'i', 3440},
+		{';', 3441}, {';', 3442}, {'a', 3443}, {'v', 3444}, {'i', 3445}, {'c', 3446}, {';', 3447}, {';', 3448},
+		{'t', 3449}, {';', 3450}, {'c', 3451}, {'k', 3452}, {'c', 3453}, {'e', 3454}, {';', 3455}, {'z', 3456},
+		{';', 3457}, {'o', 3458}, {'D', 3459}, {'E', 3460}, {';', 3461}, {'g', 3462}, {';', 3463}, {'t', 3464},
+		{'c', 3465}, {';', 3466}, {'v', 3467}, {'r', 3468}, {'i', 3469}, {'i', 3470}, {'g', 3471}, {'r', 3472},
+		{'s', 3473}, {'n', 3474}, {';', 3475}, {';', 3476}, {';', 3477}, {'d', 3478}, {'y', 3479}, {';', 3480},
+		{'c', 3481}, {';', 3482}, {';', 3483}, {'c', 3484}, {'y', 3485}, {';', 3486}, {';', 3487}, {'a', 3488},
+		{'i', 3489}, {';', 3490}, {';', 3491}, {';', 3492}, {'t', 3493}, {'d', 3494}, {';', 3495}, {'a', 3496},
+		{';', 3497}, {'o', 3498}, {'i', 3499}, {'A', 3500}, {'C', 3501}, {'D', 3502}, {'F', 3503}, {'R', 3504},
+		{'T', 3505}, {'U', 3506}, {'V', 3507}, {'a', 3508}, {'r', 3509}, {'E', 3510}, {'F', 3511}, {'G', 3512},
+		{'L', 3513}, {'S', 3514}, {'T', 3515}, {'t', 3516}, {'o', 3517}, {'L', 3518}, {'R', 3519}, {'l', 3520},
+		{'r', 3521}, {';', 3522}, {'r', 3523}, {';', 3524}, {'o', 3525}, {'u', 3526}, {'i', 3527}, {'s', 3528},
+		{';', 3529}, {';', 3530}, {';', 3531}, {'t', 3532}, {'o', 3533}, {'i', 3534}, {'t', 3535}, {'e', 3536},
+		{'i', 3537}, {'e', 3538}, {'r', 3539}, {';', 3540}, {'o', 3541}, {'u', 3542}, {'o', 3543}, {'l', 3544},
+		{'q', 3545}, {'x', 3546}, {'r', 3547}, {'u', 3548}, {'e', 3549}, {'e', 3550}, {'r', 3551}, {'e', 3552},
+		{'i', 3553}, {'q', 3554}, {'u', 3555}, {'i', 3556}, {'e', 3557}, {';', 3558}, {'d', 3559}, {'g', 3560},
+		{'t', 3561}, {'c', 3562}, {'a', 3563}, {'v', 3564}, {'r', 3565}, {'a', 3566}, {'r', 3567}, {';', 3568},
+		{'C', 3569}, {';', 3570}, {'s', 3571}, {'d', 3572}, {'e', 3573}, {';', 3574}, {'B', 3575}, {'P', 3576},
+		{'i', 3577}, {'M', 3578}, {'c', 3579}, {';', 3580}, {'e', 3581}, {'e', 3582}, {'u', 3583}, {'o', 3584},
+		{';', 3585}, {';', 3586}, {';', 3587}, {';', 3588}, {'r', 3589}, {'t', 3590}, {';', 3591}, {';', 3592},
+		{'t', 3593}, {'o', 3594}, {'i', 3595}, {'r', 3596}, {'t', 3597}, {';', 3598}, {'d', 3599}, {'h', 3600},
+		{';', 3601}, {'D', 3602}, {'c', 3603}, {';', 3604}, {'c', 3605}, {'t', 3606}, {'o', 3607}, {'i', 3608},
+		{'c', 3609}, {'t', 3610}, {'a', 3611}, {'l', 3612}, {';', 3613}, {';', 3614}, {'r', 3615}, {';', 3616},
+		{';', 3617}, {'e', 3618}, {'e', 3619}, {'T', 3620}, {'r', 3621}, {'e', 3622}, {'N', 3623}, {'E', 3624},
+		{'y', 3625}, {';', 3626}, {'o', 3627}, {'i', 3628}, {'e', 3629}, {'a', 3630}, {'k', 3631}, {'S', 3632},
+		{'e', 3633}, {';', 3634}, {'l', 3635}, {';', 3636}, {'o', 3637}, {'t', 3638}, {';', 3639}, {'o', 3640},
+		{'y', 3641}, {'v', 3642}, {'c', 3643}, {'a', 3644}, {'v', 3645}, {'r', 3646}, {'r', 3647}, {'n', 3648},
+		{'n', 3649}, {';', 3650}, {'r', 3651}, {'w', 3652}, {'u', 3653}, {'e', 3654}, {'r', 3655}, {'w', 3656},
+		{'r', 3657}, {';', 3658}, {'l', 3659}, {'g', 3660}, {';', 3661}, {'d', 3662}, {';', 3663}, {'h', 3664},
+		{';', 3665}, {'h', 3666}, {'a', 3667}, {';', 3668}, {'i', 3669}, {'T', 3670}, {';', 3671}, {';', 3672},
+		{'s', 3673}, {'c', 3674}, {'e', 3675}, {';', 3676}, {';', 3677}, {';', 3678}, {';', 3679}, {';', 3680},
+		{';', 3681}, {';', 3682}, {'t', 3683}, {'c', 3684}, {';', 3685}, {';', 3686}, {';', 3687}, {';', 3688},
+		{'t', 3689}, {'o', 3690}, {';', 3691}, {'W', 3692}, {';', 3693}, {';', 3694}, {';', 3695}, {'t', 3696},
+		{'v', 3697}, {'c', 3698}, {'e', 3699}, {'g', 3700}, {'v', 3701}, {'s', 3702}, {'h', 3703}, {'a', 3704},
+		{'r', 3705}, {'g', 3706}, {'n', 3707}, {';', 3708}, {'l', 3709}, {';', 3710}, {';', 3711}, {'e', 3712},
+		{'s', 3713}, {'t', 3714}, {'p', 3715}, {'t', 3716}, {'a', 3717}, {'n', 3718}, {';', 3719}, {'i', 3720},
+		{';', 3721}, {';', 3722}, {'o', 3723}, {'g', 3724}, {';', 3725}, {'p', 3726}, {'d', 3727}, {';', 3728},
+		{'n', 3729}, {'t', 3730}, {';', 3731}, {'c', 3732}, {'e', 3733}, {'p', 3734}, {'s', 3735}, {'e', 3736},
+		{'e', 3737}, {';', 3738}, {'t', 3739}, {'g', 3740}, {'o', 3741}, {'u', 3742}, {'t', 3743}, {'i', 3744},
+		{'o', 3745}, {';', 3746}, {';', 3747}, {'e', 3748}, {'a', 3749}, {'i', 3750}, {'u', 3751}, {'d', 3752},
+		{'p', 3753}, {'t', 3754}, {'q', 3755}, {'t', 3756}, {'r', 3757}, {'p', 3758}, {'e', 3759}, {'e', 3760},
+		{'o', 3761}, {'k', 3762}, {'k', 3763}, {'2', 3764}, {'4', 3765}, {'4', 3766}, {'k', 3767}, {'u', 3768},
+		{';', 3769}, {';', 3770}, {'o', 3771}, {'i', 3772}, {'L', 3773}, {'R', 3774}, {'l', 3775}, {'r', 3776},
+		{';', 3777}, {'D', 3778}, {'U', 3779}, {'d', 3780}, {'u', 3781}, {'L', 3782}, {'R', 3783}, {'l', 3784},
+		{'r', 3785}, {';', 3786}, {'H', 3787}, {'L', 3788}, {'R', 3789}, {'h', 3790}, {'l', 3791}, {'r', 3792},
+		{'o', 3793}, {'L', 3794}, {'R', 3795}, {'l', 3796}, {'r', 3797}, {';', 3798}, {'D', 3799}, {'U', 3800},
+		{'d', 3801}, {'u', 3802}, {'i', 3803}, {'l', 3804}, {'i', 3805}, {'L', 3806}, {'R', 3807}, {'l', 3808},
+		{'r', 3809}, {';', 3810}, {'H', 3811}, {'L', 3812}, {'R', 3813}, {'h', 3814}, {'l', 3815}, {'r', 3816},
+		{'m', 3817}, {'e', 3818}, {'a', 3819}, {';', 3820}, {'i', 3821}, {';', 3822}, {'e', 3823}, {';', 3824},
+		{'b', 3825}, {'h', 3826}, {';', 3827}, {'e', 3828}, {';', 3829}, {'E', 3830}, {'e', 3831}, {'t', 3832},
+		{'n', 3833}, {'r', 3834}, {'a', 3835}, {'u', 3836}, {'o', 3837}, {';', 3838}, {'t', 3839}, {'n', 3840},
+		{'s', 3841}, {'o', 3842}, {'i', 3843}, {'c', 3844}, {'s', 3845}, {';', 3846}, {'l', 3847}, {'t', 3848},
+		{';', 3849}, {'e', 3850}, {';', 3851}, {'k', 3852}, {';', 3853}, {';', 3854}, {'e', 3855}, {'l', 3856},
+		{';', 3857}, {'n', 3858}, {'i', 3859}, {'c', 3860}, {'s', 3861}, {'n', 3862}, {'a', 3863}, {';', 3864},
+		{'f', 3865}, {'l', 3866}, {';', 3867}, {'d', 3868}, {'n', 3869}, {';', 3870}, {'o', 3871}, {';', 3872},
+		{'s', 3873}, {'r', 3874}, {'s', 3875}, {';', 3876}, {';', 3877}, {'e', 3878}, {';', 3879}, {'e', 3880},
+		{'t', 3881}, {'r', 3882}, {'r', 3883}, {'c', 3884}, {'r', 3885}, {'r', 3886}, {'a', 3887}, {'u', 3888},
+		{'o', 3889}, {'r', 3890}, {';', 3891}, {'r', 3892}, {'y', 3893}, {'e', 3894}, {'e', 3895}, {'e', 3896},
+		{'d', 3897}, {'n', 3898}, {'t', 3899}, {'t', 3900}, {';', 3901}, {';', 3902}, {'e', 3903}, {'t', 3904},
+		{';', 3905}, {';', 3906}, {'v', 3907}, {'r', 3908}, {'c', 3909}, {'o', 3910}, {'g', 3911}, {'r', 3912},
+		{'s', 3913}, {'a', 3914}, {'t', 3915}, {'h', 3916}, {'l', 3917}, {'r', 3918}, {';', 3919}, {'o', 3920},
+		{'s', 3921}, {'m', 3922}, {'n', 3923}, {'d', 3924}, {'n', 3925}, {';', 3926}, {'r', 3927}, {'o', 3928},
+		{'a', 3929}, {';', 3930}, {'q', 3931}, {'i', 3932}, {'l', 3933}, {'q', 3934}, {'l', 3935}, {'a', 3936},
+		{'d', 3937}, {'h', 3938}, {'a', 3939}, {'r', 3940}, {'o', 3941}, {';', 3942}, {';', 3943}, {';', 3944},
+		{'o', 3945}, {'t', 3946}, {';', 3947}, {'f', 3948}, {'r', 3949}, {'r', 3950}, {'g', 3951}, {';', 3952},
+		{'r', 3953}, {'t', 3954}, {';', 3955}, {'t', 3956}, {'e', 3957}, {'o', 3958}, {';', 3959}, {'c', 3960},
+		{'o', 3961}, {';', 3962}, {'t', 3963}, {'v', 3964}, {'o', 3965}, {'t', 3966}, {'o', 3967}, {'r', 3968},
+		{'y', 3969}, {'1', 3970}, {';', 3971}, {';', 3972}, {'n', 3973}, {';', 3974}, {';', 3975}, {'s', 3976},
+		{'s', 3977}, {';', 3978}, {'l', 3979}, {'v', 3980}, {'r', 3981}, {'l', 3982}, {'m', 3983}, {'a', 3984},
+		{'l', 3985}, {'s', 3986}, {'v', 3987}, {'a', 3988}, {'t', 3989}, {'r', 3990}, {';', 3991}, {'t', 3992},
+		{';', 3993}, {';', 3994}, {';', 3995}, {';', 3996}, {'t', 3997}, {'c', 3998}, {'n', 3999}, {'i', 4000},
+		{'l', 4001}, {'i', 4002}, {'g', 4003}, {'i', 4004}, {'g', 4005}, {'g', 4006}, {';', 4007}, {'g', 4008},
+		{'s', 4009}, {';', 4010}, {';', 4011}, {'l', 4012}, {';', 4013}, {'v', 4014}, {'t', 4015}, {'1', 4016},
+		{'2', 4017}, {'3', 4018}, {'4', 4019}, {'5', 4020}, {'7', 4021}, {'l', 4022}, {'n', 4023}, {';', 4024},
+		{'t', 4025}, {'a', 4026}, {'v', 4027}, {'c', 4028}, {';', 4029}, {';', 4030}, {'l', 4031}, {'c', 4032},
+		{'o', 4033}, {';', 4034}, {'e', 4035}, {'l', 4036}, {';', 4037}, {';', 4038}, {'p', 4039}, {';', 4040},
+		{'q', 4041}, {'m', 4042}, {';', 4043}, {'e', 4044}, {';', 4045}, {';', 4046}, {'e', 4047}, {'l', 4048},
+		{';', 4049}, {'r', 4050}, {'t', 4051}, {'a', 4052}, {'e', 4053}, {'p', 4054}, {'r', 4055}, {'o', 4056},
+		{'q', 4057}, {'e', 4058}, {'i', 4059}, {'t', 4060}, {';', 4061}, {';', 4062}, {'s', 4063}, {';', 4064},
+		{'l', 4065}, {'c', 4066}, {';', 4067}, {'c', 4068}, {'w', 4069}, {';', 4070}, {'c', 4071}, {'t', 4072},
+		{'i', 4073}, {'o', 4074}, {'a', 4075}, {'a', 4076}, {'r', 4077}, {'h', 4078}, {'l', 4079}, {'r', 4080},
+		{';', 4081}, {'a', 4082}, {';', 4083}, {'s', 4084}, {'o', 4085}, {'l', 4086}, {'e', 4087}, {'t', 4088},
+		{'c', 4089}, {';', 4090}, {'l', 4091}, {'v', 4092}, {'n', 4093}, {'t', 4094}, {'i', 4095}, {'a', 4096},
+		{'g', 4097}, {'r', 4098}, {'e', 4099}, {'l', 4100}, {'p', 4101}, {'h', 4102}, {';', 4103}, {'d', 4104},
+		{'r', 4105}, {'n', 4106}, {'o', 4107}, {'a', 4108}, {'g', 4109}, {'r', 4110}, {'a', 4111}, {'r', 4112},
+		{';', 4113}, {'n', 4114}, {';', 4115}, {';', 4116}, {'d', 4117}, {'s', 4118}, {';', 4119}, {';', 4120},
+		{'E', 4121}, {'d', 4122}, {'s', 4123}, {'v', 4124}, {'d', 4125}, {'y', 4126}, {';', 4127}, {'c', 4128},
+		{'h', 4129}, {';', 4130}, {';', 4131}, {'c', 4132}, {'y', 4133}, {'a', 4134}, {'i', 4135}, {'e', 4136},
+		{';', 4137}, {';', 4138}, {';', 4139}, {';', 4140}, {'r', 4141}, {';', 4142}, {'i', 4143}, {'r', 4144},
+		{';', 4145}, {'t', 4146}, {'p', 4147}, {'a', 4148}, {'d', 4149}, {';', 4150}, {'d', 4151}, {'l', 4152},
+		{'o', 4153}, {';', 4154}, {'b', 4155}, {'f', 4156}, {'h', 4157}, {'l', 4158}, {'p', 4159}, {'s', 4160},
+		{'t', 4161}, {'i', 4162}, {';', 4163}, {'s', 4164}, {'r', 4165}, {'k', 4166}, {'c', 4167}, {'e', 4168},
+		{'s', 4169}, {'o', 4170}, {'i', 4171}, {'l', 4172}, {';', 4173}, {';', 4174}, {'o', 4175}, {'h', 4176},
+		{'s', 4177}, {';', 4178}, {'a', 4179}, {'h', 4180}, {'l', 4181}, {'r', 4182}, {'t', 4183}, {';', 4184},
+		{'l', 4185}, {'c', 4186}, {'o', 4187}, {';', 4188}, {'e', 4189}, {'a', 4190}, {'d', 4191}, {'e', 4192},
+		{'g', 4193}, {'s', 4194}, {'h', 4195}, {'o', 4196}, {'d', 4197}, {'u', 4198}, {'k', 4199}, {';', 4200},
+		{'r', 4201}, {'r', 4202}, {'r', 4203}, {'i', 4204}, {'o', 4205}, {'s', 4206}, {';', 4207}, {'p', 4208},
+		{';', 4209}, {'q', 4210}, {'m', 4211}, {'g', 4212}, {'r', 4213}, {'k', 4214}, {'l', 4215}, {'m', 4216},
+		{'r', 4217}, {'a', 4218}, {'r', 4219}, {';', 4220}, {'u', 4221}, {'m', 4222}, {'s', 4223}, {'a', 4224},
+		{'n', 4225}, {';', 4226}, {';', 4227}, {'l', 4228}, {'r', 4229}, {'r', 4230}, {'r', 4231}, {'i', 4232},
+		{'u', 4233}, {';', 4234}, {';', 4235}, {'e', 4236}, {'g', 4237}, {';', 4238}, {'o', 4239}, {'o', 4240},
+		{';', 4241}, {'r', 4242}, {'t', 4243}, {'e', 4244}, {'e', 4245}, {'r', 4246}, {'e', 4247}, {'a', 4248},
+		{';', 4249}, {'e', 4250}, {'f', 4251}, {'s', 4252}, {'h', 4253}, {'t', 4254}, {';', 4255}, {'t', 4256},
+		{';', 4257}, {';', 4258}, {';', 4259}, {'e', 4260}, {'t', 4261}, {'e', 4262}, {'m', 4263}, {'h', 4264},
+		{'u', 4265}, {'o', 4266}, {'s', 4267}, {'i', 4268}, {'o', 4269}, {'s', 4270}, {';', 4271}, {';', 4272},
+		{'u', 4273}, {'l', 4274}, {';', 4275}, {';', 4276}, {'o', 4277}, {'i', 4278}, {'p', 4279}, {';', 4280},
+		{'t', 4281}, {';', 4282}, {'h', 4283}, {'s', 4284}, {'s', 4285}, {'a', 4286}, {'t', 4287}, {';', 4288},
+		{';', 4289}, {'d', 4290}, {'s', 4291}, {'r', 4292}, {'r', 4293}, {';', 4294}, {'p', 4295}, {';', 4296},
+		{'o', 4297}, {'i', 4298}, {'g', 4299}, {';', 4300}, {'h', 4301}, {'r', 4302}, {'h', 4303}, {'r', 4304},
+		{'t', 4305}, {'i', 4306}, {'a', 4307}, {'m', 4308}, {'s', 4309}, {';', 4310}, {'q', 4311}, {'s', 4312},
+		{';', 4313}, {'m', 4314}, {';', 4315}, {'r', 4316}, {'r', 4317}, {'r', 4318}, {';', 4319}, {';', 4320},
+		{'r', 4321}, {'r', 4322}, {';', 4323}, {'t', 4324}, {';', 4325}, {'q', 4326}, {'s', 4327}, {';', 4328},
+		{'s', 4329}, {'m', 4330}, {'i', 4331}, {';', 4332}, {';', 4333}, {'n', 4334}, {'i', 4335}, {';', 4336},
+		{'a', 4337}, {'s', 4338}, {'t', 4339}, {'i', 4340}, {'u', 4341}, {';', 4342}, {'c', 4343}, {'r', 4344},
+		{'r', 4345}, {'h', 4346}, {'i', 4347}, {'u', 4348}, {';', 4349}, {';', 4350}, {'r', 4351}, {';', 4352},
+		{'e', 4353}, {'d', 4354}, {'r', 4355}, {'u', 4356}, {';', 4357}, {'E', 4358}, {'e', 4359}, {'s', 4360},
+		{'c', 4361}, {';', 4362}, {'E', 4363}, {'e', 4364}, {'s', 4365}, {';', 4366}, {'d', 4367}, {';', 4368},
+		{'a', 4369}, {'r', 4370}, {'p', 4371}, {'s', 4372}, {'r', 4373}, {';', 4374}, {'s', 4375}, {';', 4376},
+		{';', 4377}, {'f', 4378}, {'r', 4379}, {';', 4380}, {';', 4381}, {'r', 4382}, {'r', 4383}, {'r', 4384},
+		{'m', 4385}, {'r', 4386}, {'h', 4387}, {'r', 4388}, {'a', 4389}, {'t', 4390}, {';', 4391}, {';', 4392},
+		{'c', 4393}, {'h', 4394}, {'a', 4395}, {';', 4396}, {';', 4397}, {'l', 4398}, {'g', 4399}, {'r', 4400},
+		{';', 4401}, {'v', 4402}, {'r', 4403}, {';', 4404}, {'r', 4405}, {'r', 4406}, {'o', 4407}, {'e', 4408},
+		{'r', 4409}, {'a', 4410}, {'r', 4411}, {';', 4412}, {'u', 4413}, {';', 4414}, {';', 4415}, {'p', 4416},
+		{'s', 4417}, {'r', 4418}, {'r', 4419}, {';', 4420}, {';', 4421}, {'o', 4422}, {';', 4423}, {'o', 4424},
+		{';', 4425}, {'s', 4426}, {';', 4427}, {'d', 4428}, {'e', 4429}, {';', 4430}, {'r', 4431}, {';', 4432},
+		{'l', 4433}, {'i', 4434}, {'l', 4435}, {';', 4436}, {'n', 4437}, {'o', 4438}, {'i', 4439}, {';', 4440},
+		{'e', 4441}, {';', 4442}, {'a', 4443}, {'e', 4444}, {'h', 4445}, {'c', 4446}, {'k', 4447}, {';', 4448},
+		{'a', 4449}, {'b', 4450}, {'c', 4451}, {'d', 4452}, {'e', 4453}, {'m', 4454}, {'s', 4455}, {'t', 4456},
+		{'t', 4457}, {';', 4458}, {'d', 4459}, {';', 4460}, {'e', 4461}, {';', 4462}, {'a', 4463}, {'c', 4464},
+		{'e', 4465}, {'n', 4466}, {'s', 4467}, {'e', 4468}, {';', 4469}, {'p', 4470}, {'i', 4471}, {';', 4472},
+		{'a', 4473}, {'l', 4474}, {'s', 4475}, {';', 4476}, {'t', 4477}, {'m', 4478}, {'e', 4479}, {';', 4480},
+		{'s', 4481}, {';', 4482}, {';', 4483}, {'m', 4484}, {';', 4485}, {'e', 4486}, {'i', 4487}, {'t', 4488},
+		{';', 4489}, {'r', 4490}, {';', 4491}, {'i', 4492}, {'r', 4493}, {';', 4494}, {';', 4495}, {'t', 4496},
+		{'c', 4497}, {'p', 4498}, {';', 4499}, {'d', 4500}, {'e', 4501}, {'l', 4502}, {'o', 4503}, {';', 4504},
+		{'a', 4505}, {'b', 4506}, {'c', 4507}, {'f', 4508}, {'h', 4509}, {'l', 4510}, {'p', 4511}, {'s', 4512},
+		{'t', 4513}, {'w', 4514}, {'i', 4515}, {'o', 4516}, {'r', 4517}, {'k', 4518}, {'c', 4519}, {'e', 4520},
+		{'s', 4521}, {'o', 4522}, {'i', 4523}, {'l', 4524}, {';', 4525}, {';', 4526}, {'h', 4527}, {'o', 4528},
+		{';', 4529}, {';', 4530}, {'i', 4531}, {'p', 4532}, {'s', 4533}, {';', 4534}, {'h', 4535}, {'o', 4536},
+		{'d', 4537}, {'u', 4538}, {';', 4539}, {'t', 4540}, {';', 4541}, {'n', 4542}, {'r', 4543}, {'r', 4544},
+		{'s', 4545}, {'d', 4546}, {'g', 4547}, {'r', 4548}, {'k', 4549}, {'r', 4550}, {';', 4551}, {'u', 4552},
+		{'m', 4553}, {';', 4554}, {'g', 4555}, {'l', 4556}, {'r', 4557}, {'u', 4558}, {';', 4559}, {';', 4560},
+		{'o', 4561}, {'e', 4562}, {'e', 4563}, {';', 4564}, {'e', 4565}, {'f', 4566}, {'l', 4567}, {'h', 4568},
+		{'t', 4569}, {'o', 4570}, {';', 4571}, {'o', 4572}, {'e', 4573}, {'i', 4574}, {'c', 4575}, {';', 4576},
+		{'p', 4577}, {'i', 4578}, {'l', 4579}, {'m', 4580}, {';', 4581}, {'b', 4582}, {'e', 4583}, {'r', 4584},
+		{'h', 4585}, {'r', 4586}, {';', 4587}, {';', 4588}, {'a', 4589}, {'i', 4590}, {'n', 4591}, {';', 4592},
+		{'w', 4593}, {'p', 4594}, {'c', 4595}, {';', 4596}, {'t', 4597}, {'a', 4598}, {'o', 4599}, {';', 4600},
+		{'q', 4601}, {';', 4602}, {'E', 4603}, {';', 4604}, {'E', 4605}, {'e', 4606}, {'l', 4607}, {'a', 4608},
+		{'r', 4609}, {'l', 4610}, {'h', 4611}, {'a', 4612}, {';', 4613}, {'e', 4614}, {';', 4615}, {'s', 4616},
+		{'c', 4617}, {';', 4618}, {'a', 4619}, {';', 4620}, {'e', 4621}, {';', 4622}, {'p', 4623}, {'p', 4624},
+		{'b', 4625}, {'p', 4626}, {'r', 4627}, {';', 4628}, {'r', 4629}, {';', 4630}, {'m', 4631}, {'l', 4632},
+		{'r', 4633}, {';', 4634}, {'f', 4635}, {'i', 4636}, {'s', 4637}, {';', 4638}, {'o', 4639}, {';', 4640},
+		{'d', 4641}, {'u', 4642}, {'E', 4643}, {'e', 4644}, {'l', 4645}, {'a', 4646}, {'e', 4647}, {'i', 4648},
+		{'u', 4649}, {';', 4650}, {'a', 4651}, {'c', 4652}, {'e', 4653}, {'n', 4654}, {'s', 4655}, {';', 4656},
+		{';', 4657}, {';', 4658}, {';', 4659}, {';', 4660}, {'o', 4661}, {'s', 4662}, {';', 4663}, {'d', 4664},
+		{'s', 4665}, {'a', 4666}, {'u', 4667}, {'E', 4668}, {'e', 4669}, {'l', 4670}, {'e', 4671}, {'i', 4672},
+		{'u', 4673}, {'r', 4674}, {'h', 4675}, {'r', 4676}, {'a', 4677}, {'g', 4678}, {'e', 4679}, {';', 4680},
+		{'o', 4681}, {'i', 4682}, {';', 4683}, {'e', 4684}, {'e', 4685}, {'a', 4686}, {'k', 4687}, {'s', 4688},
+		{'p', 4689}, {'i', 4690}, {'n', 4691}, {'e', 4692}, {'s', 4693}, {';', 4694}, {';', 4695}, {'o', 4696},
+		{'i', 4697}, {';', 4698}, {'o', 4699}, {';', 4700}, {'m', 4701}, {'e', 4702}, {'n', 4703}, {'o', 4704},
+		{';', 4705}, {'i', 4706}, {'l', 4707}, {'b', 4708}, {'i', 4709}, {'z', 4710}, {';', 4711}, {';', 4712},
+		{'y', 4713}, {'o', 4714}, {'t', 4715}, {'e', 4716}, {';', 4717}, {';', 4718}, {'t', 4719}, {';', 4720},
+		{'y', 4721}, {'v', 4722}, {'c', 4723}, {'r', 4724}, {'a', 4725}, {'r', 4726}, {'h', 4727}, {'v', 4728},
+		{'l', 4729}, {'r', 4730}, {'k', 4731}, {'r', 4732}, {'o', 4733}, {'i', 4734}, {'r', 4735}, {'n', 4736},
+		{';', 4737}, {'r', 4738}, {'w', 4739}, {'r', 4740}, {'s', 4741}, {';', 4742}, {'h', 4743}, {'l', 4744},
+		{'a', 4745}, {'r', 4746}, {'o', 4747}, {'g', 4748}, {'i', 4749}, {';', 4750}, {'t', 4751}, {'d', 4752},
+		{';', 4753}, {'f', 4754}, {'r', 4755}, {';', 4756}, {'g', 4757}, {';', 4758}, {';', 4759}, {'v', 4760},
+		{'h', 4761}, {'r', 4762}, {'p', 4763}, {'a', 4764}, {'o', 4765}, {'h', 4766}, {'i', 4767}, {'r', 4768},
+		{';', 4769}, {'h', 4770}, {'i', 4771}, {'u', 4772}, {'h', 4773}, {'r', 4774}, {'h', 4775}, {'a', 4776},
+		{'q', 4777}, {'i', 4778}, {'a', 4779}, {';', 4780}, {'i', 4781}, {'b', 4782}, {'p', 4783}, {';', 4784},
+		{'p', 4785}, {'i', 4786}, {';', 4787}, {'n', 4788}, {'n', 4789}, {'z', 4790}, {'c', 4791}, {'a', 4792},
+		{'e', 4793}, {'r', 4794}, {';', 4795}, {'t', 4796}, {';', 4797}, {';', 4798}, {'c', 4799}, {';', 4800},
+		{'i', 4801}, {'r', 4802}, {'r', 4803}, {'r', 4804}, {'r', 4805}, {';', 4806}, {';', 4807}, {'t', 4808},
+		{';', 4809}, {'u', 4810}, {'m', 4811}, {'r', 4812}, {'r', 4813}, {';', 4814}, {'u', 4815}, {'u', 4816},
+		{'i', 4817}, {';', 4818}, {'g', 4819}, {'t', 4820}, {';', 4821}, {'c', 4822}, {';', 4823}, {';', 4824},
+		{';', 4825}, {';', 4826}, {';', 4827}, {'t', 4828}, {'o', 4829}, {';', 4830}, {'r', 4831}, {';', 4832},
+		{';', 4833}, {'a', 4834}, {';', 4835}, {';', 4836}, {';', 4837}, {';', 4838}, {'e', 4839}, {'e', 4840},
+		{';', 4841}, {'e', 4842}, {';', 4843}, {';', 4844}, {';', 4845}, {'F', 4846}, {';', 4847}, {'n', 4848},
+		{'e', 4849}, {'l', 4850}, {'d', 4851}, {'s', 4852}, {'u', 4853}, {';', 4854}, {'q', 4855}, {'e', 4856},
+		{'a', 4857}, {'y', 4858}, {'n', 4859}, {'l', 4860}, {';', 4861}, {'n', 4862}, {'l', 4863}, {'r', 4864},
+		{'e', 4865}, {'w', 4866}, {'C', 4867}, {';', 4868}, {'e', 4869}, {'u', 4870}, {'t', 4871}, {'u', 4872},
+		{'d', 4873}, {'e', 4874}, {';', 4875}, {'p', 4876}, {'a', 4877}, {'r', 4878}, {';', 4879}, {'n', 4880},
+		{';', 4881}, {'i', 4882}, {'n', 4883}, {'r', 4884}, {'t', 4885}, {'u', 4886}, {'e', 4887}, {'r', 4888},
+		{'r', 4889}, {'e', 4890}, {'i', 4891}, {'e', 4892}, {'r', 4893}, {'k', 4894}, {'e', 4895}, {'n', 4896},
+		{';', 4897}, {'e', 4898}, {'n', 4899}, {';', 4900}, {'S', 4901}, {'V', 4902}, {';', 4903}, {'o', 4904},
+		{';', 4905}, {'T', 4906}, {'i', 4907}, {'s', 4908}, {'e', 4909}, {'d', 4910}, {'l', 4911}, {'e', 4912},
+		{';', 4913}, {'d', 4914}, {'e', 4915}, {'l', 4916}, {';', 4917}, {'e', 4918}, {'y', 4919}, {';', 4920},
+		{';', 4921}, {'r', 4922}, {'o', 4923}, {'k', 4924}, {'o', 4925}, {'q', 4926}, {';', 4927}, {'e', 4928},
+		{';', 4929}, {'e', 4930}, {';', 4931}, {'n', 4932}, {'e', 4933}, {'r', 4934}, {'s', 4935}, {'i', 4936},
+		{';', 4937}, {'e', 4938}, {';', 4939}, {';', 4940}, {'y', 4941}, {';', 4942}, {';', 4943}, {'l', 4944},
+		{'e', 4945}, {'a', 4946}, {'c', 4947}, {'n', 4948}, {'l', 4949}, {'n', 4950}, {'r', 4951}, {'e', 4952},
+		{'o', 4953}, {'l', 4954}, {'i', 4955}, {'e', 4956}, {'r', 4957}, {'p', 4958}, {'e', 4959}, {'r', 4960},
+		{'i', 4961}, {'q', 4962}, {'u', 4963}, {'r', 4964}, {'e', 4965}, {'l', 4966}, {'i', 4967}, {'a', 4968},
+		{'t', 4969}, {'e', 4970}, {'i', 4971}, {'e', 4972}, {'i', 4973}, {'L', 4974}, {'R', 4975}, {'k', 4976},
+		{'m', 4977}, {'n', 4978}, {'P', 4979}, {'e', 4980}, {'n', 4981}, {'l', 4982}, {'i', 4983}, {'d', 4984},
+		{'n', 4985}, {'a', 4986}, {'e', 4987}, {'n', 4988}, {'p', 4989}, {'u', 4990}, {'e', 4991}, {'u', 4992},
+		{'i', 4993}, {'e', 4994}, {'m', 4995}, {'f', 4996}, {'s', 4997}, {'s', 4998}, {'e', 4999}, {'v', 5000},
+		{'g', 5001}, {'u', 5002}, {'b', 5003}, {'c', 5004}, {'p', 5005}, {'l', 5006}, {'r', 5007}, {'e', 5008},
+		{';', 5009}, {'e', 5010}, {';', 5011}, {'c', 5012}, {'e', 5013}, {';', 5014}, {';', 5015}, {'o', 5016},
+		{'u', 5017}, {'h', 5018}, {'e', 5019}, {'s', 5020}, {'a', 5021}, {'r', 5022}, {'a', 5023}, {'a', 5024},
+		{'i', 5025}, {'a', 5026}, {'d', 5027}, {';', 5028}, {'c', 5029}, {'r', 5030}, {';', 5031}, {'e', 5032},
+		{'l', 5033}, {'n', 5034}, {'l', 5035}, {'s', 5036}, {'A', 5037}, {'C', 5038}, {'D', 5039}, {'F', 5040},
+		{'T', 5041}, {'U', 5042}, {'V', 5043}, {'a', 5044}, {'I', 5045}, {'t', 5046}, {'e', 5047}, {'y', 5048},
+		{'y', 5049}, {'e', 5050}, {'n', 5051}, {'l', 5052}, {';', 5053}, {'D', 5054}, {'L', 5055}, {'R', 5056},
+		{'U', 5057}, {';', 5058}, {'C', 5059}, {'e', 5060}, {'t', 5061}, {'e', 5062}, {'h', 5063}, {'s', 5064},
+		{'t', 5065}, {';', 5066}, {';', 5067}, {';', 5068}, {'n', 5069}, {'l', 5070}, {'f', 5071}, {';', 5072},
+		{'S', 5073}, {'p', 5074}, {';', 5075}, {'E', 5076}, {'F', 5077}, {'T', 5078}, {'e', 5079}, {'k', 5080},
+		{'e', 5081}, {'c', 5082}, {';', 5083}, {'e', 5084}, {';', 5085}, {'c', 5086}, {'e', 5087}, {';', 5088},
+		{'B', 5089}, {'P', 5090}, {';', 5091}, {'P', 5092}, {';', 5093}, {'o', 5094}, {'n', 5095}, {'i', 5096},
+		{';', 5097}, {'A', 5098}, {'o', 5099}, {'n', 5100}, {'L', 5101}, {'R', 5102}, {'o', 5103}, {';', 5104},
+		{'e', 5105}, {';', 5106}, {';', 5107}, {'l', 5108}, {'r', 5109}, {'c', 5110}, {'h', 5111}, {'h', 5112},
+		{';', 5113}, {';', 5114}, {'e', 5115}, {';', 5116}, {'e', 5117}, {'n', 5118}, {'i', 5119}, {'e', 5120},
+		{'e', 5121}, {';', 5122}, {';', 5123}, {';', 5124}, {'e', 5125}, {'y', 5126}, {';', 5127}, {';', 5128},
+		{';', 5129}, {';', 5130}, {'d', 5131}, {'o', 5132}, {';', 5133}, {'d', 5134}, {';', 5135}, {'v', 5136},
+		{'h', 5137}, {';', 5138}, {'r', 5139}, {';', 5140}, {'r', 5141}, {'x', 5142}, {';', 5143}, {';', 5144},
+		{'e', 5145}, {'e', 5146}, {'i', 5147}, {';', 5148}, {'o', 5149}, {'p', 5150}, {'r', 5151}, {'i', 5152},
+		{'e', 5153}, {'d', 5154}, {'b', 5155}, {';', 5156}, {';', 5157}, {'s', 5158}, {'y', 5159}, {';', 5160},
+		{'u', 5161}, {'e', 5162}, {'p', 5163}, {'r', 5164}, {'p', 5165}, {'o', 5166}, {'l', 5167}, {'i', 5168},
+		{'c', 5169}, {'a', 5170}, {'i', 5171}, {'l', 5172}, {'e', 5173}, {'d', 5174}, {'w', 5175}, {'l', 5176},
+		{'s', 5177}, {'t', 5178}, {';', 5179}, {';', 5180}, {';', 5181}, {';', 5182}, {';', 5183}, {'i', 5184},
+		{'m', 5185}, {'e', 5186}, {';', 5187}, {';', 5188}, {';', 5189}, {';', 5190}, {';', 5191}, {';', 5192},
+		{';', 5193}, {';', 5194}, {';', 5195}, {';', 5196}, {';', 5197}, {';', 5198}, {';', 5199}, {';', 5200},
+		{';', 5201}, {';', 5202}, {';', 5203}, {';', 5204}, {'x', 5205}, {';', 5206}, {';', 5207}, {';', 5208},
+		{';', 5209}, {';', 5210}, {';', 5211}, {';', 5212}, {';', 5213}, {'n', 5214}, {'u', 5215}, {'m', 5216},
+		{';', 5217}, {';', 5218}, {';', 5219}, {';', 5220}, {';', 5221}, {';', 5222}, {';', 5223}, {';', 5224},
+		{';', 5225}, {';', 5226}, {'e', 5227}, {';', 5228}, {'r', 5229}, {';', 5230}, {';', 5231}, {';', 5232},
+		{'s', 5233}, {'t', 5234}, {';', 5235}, {';', 5236}, {'q', 5237}, {'e', 5238}, {'d', 5239}, {'c', 5240},
+		{'p', 5241}, {'p', 5242}, {'t', 5243}, {';', 5244}, {';', 5245}, {';', 5246}, {'n', 5247}, {'l', 5248},
+		{';', 5249}, {';', 5250}, {'s', 5251}, {';', 5252}, {'y', 5253}, {'r', 5254}, {';', 5255}, {'m', 5256},
+		{'q', 5257}, {'e', 5258}, {'i', 5259}, {'d', 5260}, {'i', 5261}, {';', 5262}, {'u', 5263}, {';', 5264},
+		{'e', 5265}, {';', 5266}, {'t', 5267}, {'n', 5268}, {'e', 5269}, {'o', 5270}, {'t', 5271}, {'d', 5272},
+		{'r', 5273}, {';', 5274}, {';', 5275}, {';', 5276}, {';', 5277}, {';', 5278}, {'r', 5279}, {';', 5280},
+		{';', 5281}, {'r', 5282}, {'c', 5283}, {'p', 5284}, {'p', 5285}, {'t', 5286}, {';', 5287}, {'r', 5288},
+		{'e', 5289}, {'v', 5290}, {'w', 5291}, {'n', 5292}, {'a', 5293}, {';', 5294}, {';', 5295}, {'i', 5296},
+		{';', 5297}, {'y', 5298}, {'r', 5299}, {'h', 5300}, {';', 5301}, {'o', 5302}, {';', 5303}, {'n', 5304},
+		{'e', 5305}, {';', 5306}, {'e', 5307}, {';', 5308}, {'y', 5309}, {'t', 5310}, {';', 5311}, {';', 5312},
+		{'n', 5313}, {';', 5314}, {'m', 5315}, {';', 5316}, {'e', 5317}, {'x', 5318}, {'n', 5319}, {'p', 5320},
+		{'r', 5321}, {';', 5322}, {'d', 5323}, {'n', 5324}, {'u', 5325}, {'u', 5326}, {'e', 5327}, {'r', 5328},
+		{'o', 5329}, {'a', 5330}, {'r', 5331}, {'n', 5332}, {'p', 5333}, {'k', 5334}, {';', 5335}, {';', 5336},
+		{';', 5337}, {';', 5338}, {'l', 5339}, {'a', 5340}, {';', 5341}, {'e', 5342}, {'r', 5343}, {'n', 5344},
+		{';', 5345}, {'n', 5346}, {';', 5347}, {'e', 5348}, {'t', 5349}, {'e', 5350}, {'t', 5351}, {';', 5352},
+		{';', 5353}, {'s', 5354}, {'v', 5355}, {'3', 5356}, {'4', 5357}, {';', 5358}, {'l', 5359}, {';', 5360},
+		{'o', 5361}, {';', 5362}, {'c', 5363}, {'o', 5364}, {';', 5365}, {'n', 5366}, {'s', 5367}, {'t', 5368},
+		{';', 5369}, {'D', 5370}, {'r', 5371}, {';', 5372}, {';', 5373}, {';', 5374}, {';', 5375}, {'t', 5376},
+		{'e', 5377}, {'n', 5378}, {'e', 5379}, {'g', 5380}, {';', 5381}, {'g', 5382}, {';', 5383}, {';', 5384},
+		{';', 5385}, {';', 5386}, {'l', 5387}, {';', 5388}, {'i', 5389}, {'2', 5390}, {'3', 5391}, {'4', 5392},
+		{'5', 5393}, {'6', 5394}, {'8', 5395}, {'3', 5396}, {'5', 5397}, {'4', 5398}, {'5', 5399}, {'8', 5400},
+		{'5', 5401}, {'6', 5402}, {'8', 5403}, {'8', 5404}, {';', 5405}, {';', 5406}, {'e', 5407}, {';', 5408},
+		{'d', 5409}, {'e', 5410}, {';', 5411}, {'a', 5412}, {';', 5413}, {'t', 5414}, {'s', 5415}, {';', 5416},
+		{'r', 5417}, {';', 5418}, {';', 5419}, {';', 5420}, {';', 5421}, {';', 5422}, {';', 5423}, {';', 5424},
+		{'r', 5425}, {'s', 5426}, {'p', 5427}, {'r', 5428}, {'t', 5429}, {'l', 5430}, {'q', 5431}, {'s', 5432},
+		{'m', 5433}, {'n', 5434}, {'p', 5435}, {'t', 5436}, {'y', 5437}, {'i', 5438}, {';', 5439}, {';', 5440},
+		{'s', 5441}, {'p', 5442}, {'n', 5443}, {'r', 5444}, {'r', 5445}, {';', 5446}, {'t', 5447}, {'e', 5448},
+		{'i', 5449}, {'r', 5450}, {'h', 5451}, {'k', 5452}, {'l', 5453}, {'n', 5454}, {'e', 5455}, {';', 5456},
+		{';', 5457}, {'e', 5458}, {'t', 5459}, {';', 5460}, {'n', 5461}, {';', 5462}, {';', 5463}, {';', 5464},
+		{';', 5465}, {'i', 5466}, {'a', 5467}, {';', 5468}, {';', 5469}, {'e', 5470}, {';', 5471}, {'t', 5472},
+		{'t', 5473}, {'l', 5474}, {'e', 5475}, {'c', 5476}, {'r', 5477}, {'o', 5478}, {';', 5479}, {';', 5480},
+		{'t', 5481}, {';', 5482}, {'o', 5483}, {';', 5484}, {'v', 5485}, {';', 5486}, {'e', 5487}, {';', 5488},
+		{';', 5489}, {';', 5490}, {'y', 5491}, {';', 5492}, {';', 5493}, {'v', 5494}, {'l', 5495}, {'n', 5496},
+		{';', 5497}, {'l', 5498}, {';', 5499}, {'e', 5500}, {'t', 5501}, {'n', 5502}, {'a', 5503}, {';', 5504},
+		{'e', 5505}, {';', 5506}, {';', 5507}, {'f', 5508}, {'s', 5509}, {'k', 5510}, {'p', 5511}, {'l', 5512},
+		{'i', 5513}, {'l', 5514}, {'l', 5515}, {';', 5516}, {';', 5517}, {';', 5518}, {'e', 5519}, {'k', 5520},
+		{';', 5521}, {'l', 5522}, {'n', 5523}, {'l', 5524}, {';', 5525}, {';', 5526}, {'r', 5527}, {'a', 5528},
+		{'h', 5529}, {'r', 5530}, {'a', 5531}, {'e', 5532}, {'i', 5533}, {'h', 5534}, {'a', 5535}, {';', 5536},
+		{'t', 5537}, {'s', 5538}, {'p', 5539}, {'o', 5540}, {'q', 5541}, {'t', 5542}, {'i', 5543}, {'t', 5544},
+		{'r', 5545}, {';', 5546}, {';', 5547}, {'l', 5548}, {';', 5549}, {';', 5550}, {'n', 5551}, {'d', 5552},
+		{';', 5553}, {'t', 5554}, {'t', 5555}, {'r', 5556}, {';', 5557}, {';', 5558}, {';', 5559}, {';', 5560},
+		{';', 5561}, {'e', 5562}, {'a', 5563}, {'i', 5564}, {'r', 5565}, {';', 5566}, {'s', 5567}, {'e', 5568},
+		{'t', 5569}, {'r', 5570}, {'g', 5571}, {'t', 5572}, {';', 5573}, {'n', 5574}, {';', 5575}, {'d', 5576},
+		{';', 5577}, {'o', 5578}, {';', 5579}, {';', 5580}, {';', 5581}, {'r', 5582}, {'k', 5583}, {';', 5584},
+		{';', 5585}, {'e', 5586}, {'s', 5587}, {'r', 5588}, {'s', 5589}, {'r', 5590}, {';', 5591}, {';', 5592},
+		{'h', 5593}, {'a', 5594}, {'n', 5595}, {';', 5596}, {'s', 5597}, {'o', 5598}, {'r', 5599}, {'a', 5600},
+		{';', 5601}, {'r', 5602}, {';', 5603}, {'t', 5604}, {'r', 5605}, {'t', 5606}, {';', 5607}, {'b', 5608},
+		{'d', 5609}, {'s', 5610}, {'s', 5611}, {'s', 5612}, {'m', 5613}, {';', 5614}, {'a', 5615}, {'r', 5616},
+		{'t', 5617}, {'h', 5618}, {'h', 5619}, {';', 5620}, {'e', 5621}, {';', 5622}, {';', 5623}, {'o', 5624},
+		{';', 5625}, {'a', 5626}, {';', 5627}, {'e', 5628}, {'n', 5629}, {'l', 5630}, {';', 5631}, {'d', 5632},
+		{';', 5633}, {';', 5634}, {'k', 5635}, {';', 5636}, {'o', 5637}, {';', 5638}, {'v', 5639}, {'r', 5640},
+		{';', 5641}, {'t', 5642}, {';', 5643}, {'l', 5644}, {';', 5645}, {';', 5646}, {';', 5647}, {';', 5648},
+		{';', 5649}, {';', 5650}, {'a', 5651}, {'r', 5652}, {';', 5653}, {'l', 5654}, {';', 5655}, {';', 5656},
+		{';', 5657}, {'e', 5658}, {';', 5659}, {'E', 5660}, {'d', 5661}, {'v', 5662}, {';', 5663}, {'v', 5664},
+		{'l', 5665}, {'l', 5666}, {';', 5667}, {'n', 5668}, {'e', 5669}, {';', 5670}, {'e', 5671}, {';', 5672},
+		{';', 5673}, {'c', 5674}, {'w', 5675}, {'t', 5676}, {';', 5677}, {'e', 5678}, {'e', 5679}, {'t', 5680},
+		{';', 5681}, {'q', 5682}, {';', 5683}, {';', 5684}, {'b', 5685}, {'p', 5686}, {';', 5687}, {';', 5688},
+		{'e', 5689}, {';', 5690}, {'e', 5691}, {';', 5692}, {';', 5693}, {'e', 5694}, {'e', 5695}, {'n', 5696},
+		{'o', 5697}, {';', 5698}, {'h', 5699}, {'r', 5700}, {'h', 5701}, {'i', 5702}, {'r', 5703}, {'i', 5704},
+		{'r', 5705}, {'i', 5706}, {';', 5707}, {';', 5708}, {'k', 5709}, {';', 5710}, {'o', 5711}, {'r', 5712},
+		{'e', 5713}, {';', 5714}, {';', 5715}, {'c', 5716}, {'d', 5717}, {';', 5718}, {';', 5719}, {'e', 5720},
+		{';', 5721}, {';', 5722}, {';', 5723}, {'s', 5724}, {';', 5725}, {';', 5726}, {';', 5727}, {'o', 5728},
+		{'s', 5729}, {';', 5730}, {';', 5731}, {';', 5732}, {';', 5733}, {'o', 5734}, {'f', 5735}, {'p', 5736},
+		{'h', 5737}, {'e', 5738}, {'s', 5739}, {';', 5740}, {'l', 5741}, {'m', 5742}, {';', 5743}, {'t', 5744},
+		{'d', 5745}, {'l', 5746}, {'n', 5747}, {'t', 5748}, {';', 5749}, {'f', 5750}, {'k', 5751}, {'v', 5752},
+		{'c', 5753}, {';', 5754}, {'i', 5755}, {'o', 5756}, {'u', 5757}, {';', 5758}, {'n', 5759}, {'i', 5760},
+		{'w', 5761}, {'i', 5762}, {';', 5763}, {';', 5764}, {'p', 5765}, {'u', 5766}, {'q', 5767}, {'a', 5768},
+		{'e', 5769}, {'s', 5770}, {'i', 5771}, {';', 5772}, {'s', 5773}, {';', 5774}, {'m', 5775}, {'l', 5776},
+		{'i', 5777}, {'u', 5778}, {'o', 5779}, {';', 5780}, {'l', 5781}, {'p', 5782}, {'e', 5783}, {'r', 5784},
+		{'n', 5785}, {';', 5786}, {'e', 5787}, {';', 5788}, {'l', 5789}, {';', 5790}, {'e', 5791}, {';', 5792},
+		{'t', 5793}, {';', 5794}, {';', 5795}, {'e', 5796}, {';', 5797}, {'p', 5798}, {';', 5799}, {'f', 5800},
+		{';', 5801}, {'s', 5802}, {'k', 5803}, {'p', 5804}, {'l', 5805}, {'i', 5806}, {'l', 5807}, {';', 5808},
+		{'l', 5809}, {';', 5810}, {'n', 5811}, {';', 5812}, {';', 5813}, {'e', 5814}, {'k', 5815}, {';', 5816},
+		{'l', 5817}, {'n', 5818}, {'l', 5819}, {';', 5820}, {'a', 5821}, {';', 5822}, {'r', 5823}, {'n', 5824},
+		{'a', 5825}, {';', 5826}, {'t', 5827}, {'r', 5828}, {';', 5829}, {';', 5830}, {'l', 5831}, {'a', 5832},
+		{'h', 5833}, {'l', 5834}, {'r', 5835}, {'s', 5836}, {'t', 5837}, {'g', 5838}, {';', 5839}, {';', 5840},
+		{'t', 5841}, {';', 5842}, {';', 5843}, {';', 5844}, {';', 5845}, {';', 5846}, {'s', 5847}, {'e', 5848},
+		{'t', 5849}, {'i', 5850}, {';', 5851}, {'o', 5852}, {';', 5853}, {'r', 5854}, {'e', 5855}, {'s', 5856},
+		{';', 5857}, {';', 5858}, {'t', 5859}, {'a', 5860}, {'e', 5861}, {';', 5862}, {'n', 5863}, {';', 5864},
+		{'l', 5865}, {';', 5866}, {';', 5867}, {'m', 5868}, {'i', 5869}, {';', 5870}, {';', 5871}, {';', 5872},
+		{';', 5873}, {'k', 5874}, {';', 5875}, {'o', 5876}, {'r', 5877}, {'n', 5878}, {';', 5879}, {'n', 5880},
+		{';', 5881}, {'y', 5882}, {'m', 5883}, {'p', 5884}, {';', 5885}, {'f', 5886}, {'v', 5887}, {'t', 5888},
+		{';', 5889}, {';', 5890}, {';', 5891}, {';', 5892}, {'u', 5893}, {'r', 5894}, {';', 5895}, {'s', 5896},
+		{'p', 5897}, {'r', 5898}, {';', 5899}, {';', 5900}, {'y', 5901}, {'r', 5902}, {'s', 5903}, {';', 5904},
+		{'s', 5905}, {';', 5906}, {'s', 5907}, {';', 5908}, {'e', 5909}, {'s', 5910}, {';', 5911}, {'e', 5912},
+		{'s', 5913}, {'e', 5914}, {'f', 5915}, {';', 5916}, {'n', 5917}, {'e', 5918}, {'f', 5919}, {';', 5920},
+		{'g', 5921}, {';', 5922}, {'t', 5923}, {'o', 5924}, {'l', 5925}, {';', 5926}, {';', 5927}, {'u', 5928},
+		{'r', 5929}, {'t', 5930}, {'m', 5931}, {'b', 5932}, {'p', 5933}, {'p', 5934}, {'u', 5935}, {'q', 5936},
+		{'a', 5937}, {'e', 5938}, {'s', 5939}, {'i', 5940}, {'t', 5941}, {'u', 5942}, {'o', 5943}, {'o', 5944},
+		{'u', 5945}, {'r', 5946}, {'l', 5947}, {';', 5948}, {';', 5949}, {'u', 5950}, {'t', 5951}, {'m', 5952},
+		{'b', 5953}, {'p', 5954}, {';', 5955}, {'k', 5956}, {';', 5957}, {'o', 5958}, {'r', 5959}, {';', 5960},
+		{'t', 5961}, {'n', 5962}, {'l', 5963}, {'c', 5964}, {'4', 5965}, {'f', 5966}, {';', 5967}, {'s', 5968},
+		{'v', 5969}, {'a', 5970}, {'s', 5971}, {'p', 5972}, {';', 5973}, {'m', 5974}, {';', 5975}, {';', 5976},
+		{';', 5977}, {'b', 5978}, {'d', 5979}, {'t', 5980}, {'r', 5981}, {'r', 5982}, {'e', 5983}, {';', 5984},
+		{'g', 5985}, {'t', 5986}, {'n', 5987}, {'u', 5988}, {';', 5989}, {'m', 5990}, {'i', 5991}, {';', 5992},
+		{'k', 5993}, {';', 5994}, {'a', 5995}, {'e', 5996}, {';', 5997}, {'e', 5998}, {';', 5999}, {';', 6000},
+		{'c', 6001}, {';', 6002}, {'t', 6003}, {'e', 6004}, {';', 6005}, {';', 6006}, {';', 6007}, {'n', 6008},
+		{'p', 6009}, {';', 6010}, {';', 6011}, {';', 6012}, {'o', 6013}, {'n', 6014}, {'p', 6015}, {';', 6016},
+		{';', 6017}, {'o', 6018}, {'r', 6019}, {'n', 6020}, {'p', 6021}, {';', 6022}, {';', 6023}, {';', 6024},
+		{'e', 6025}, {';', 6026}, {';', 6027}, {'l', 6028}, {';', 6029}, {';', 6030}, {'t', 6031}, {'s', 6032},
+		{'p', 6033}, {'t', 6034}, {'i', 6035}, {';', 6036}, {'o', 6037}, {'o', 6038}, {'g', 6039}, {'b', 6040},
+		{'p', 6041}, {'e', 6042}, {'i', 6043}, {';', 6044}, {'r', 6045}, {';', 6046}, {'p', 6047}, {'r', 6048},
+		{';', 6049}, {';', 6050}, {';', 6051}, {';', 6052}, {';', 6053}, {'E', 6054}, {'e', 6055}, {'E', 6056},
+		{'e', 6057}, {'a', 6058}, {';', 6059}, {'r', 6060}, {';', 6061}, {'q', 6062}, {'p', 6063}, {'h', 6064},
+		{';', 6065}, {';', 6066}, {';', 6067}, {';', 6068}, {';', 6069}, {';', 6070}, {';', 6071}, {'s', 6072},
+		{'e', 6073}, {';', 6074}, {';', 6075}, {'p', 6076}, {'s', 6077}, {';', 6078}, {'e', 6079}, {'e', 6080},
+		{';', 6081}, {'e', 6082}, {'n', 6083}, {'f', 6084}, {'r', 6085}, {';', 6086}, {';', 6087}, {';', 6088},
+		{'u', 6089}, {';', 6090}, {';', 6091}, {'a', 6092}, {';', 6093}, {'e', 6094}, {'l', 6095}, {';', 6096},
+		{';', 6097}, {'l', 6098}, {'s', 6099}, {';', 6100}, {';', 6101}, {'t', 6102}, {'a', 6103}, {'D', 6104},
+		{'D', 6105}, {'M', 6106}, {'P', 6107}, {'T', 6108}, {'i', 6109}, {'u', 6110}, {';', 6111}, {'e', 6112},
+		{';', 6113}, {'r', 6114}, {'u', 6115}, {'r', 6116}, {';', 6117}, {'h', 6118}, {';', 6119}, {';', 6120},
+		{'t', 6121}, {'d', 6122}, {'e', 6123}, {';', 6124}, {'a', 6125}, {'C', 6126}, {'D', 6127}, {'L', 6128},
+		{'R', 6129}, {'U', 6130}, {'V', 6131}, {'r', 6132}, {'e', 6133}, {'f', 6134}, {'g', 6135}, {'e', 6136},
+		{'r', 6137}, {';', 6138}, {';', 6139}, {';', 6140}, {';', 6141}, {'t', 6142}, {'m', 6143}, {'e', 6144},
+		{'n', 6145}, {'i', 6146}, {'b', 6147}, {';', 6148}, {'n', 6149}, {'S', 6150}, {'V', 6151}, {';', 6152},
+		{'r', 6153}, {';', 6154}, {';', 6155}, {';', 6156}, {'r', 6157}, {';', 6158}, {'t', 6159}, {'n', 6160},
+		{';', 6161}, {'w', 6162}, {'u', 6163}, {';', 6164}, {';', 6165}, {'a', 6166}, {'s', 6167}, {'a', 6168},
+		{'e', 6169}, {'b', 6170}, {';', 6171}, {';', 6172}, {';', 6173}, {';', 6174}, {';', 6175}, {'e', 6176},
+		{';', 6177}, {';', 6178}, {'g', 6179}, {'r', 6180}, {'i', 6181}, {'u', 6182}, {'w', 6183}, {'o', 6184},
+		{'g', 6185}, {'e', 6186}, {'i', 6187}, {'D', 6188}, {'T', 6189}, {'V', 6190}, {'c', 6191}, {'r', 6192},
+		{'g', 6193}, {'u', 6194}, {'l', 6195}, {'e', 6196}, {'s', 6197}, {'a', 6198}, {'l', 6199}, {'r', 6200},
+		{';', 6201}, {'f', 6202}, {'g', 6203}, {'f', 6204}, {'g', 6205}, {'e', 6206}, {'i', 6207}, {';', 6208},
+		{'S', 6209}, {'t', 6210}, {'l', 6211}, {';', 6212}, {';', 6213}, {';', 6214}, {'v', 6215}, {'G', 6216},
+		{'L', 6217}, {'e', 6218}, {'k', 6219}, {'a', 6220}, {'g', 6221}, {'C', 6222}, {'b', 6223}, {'m', 6224},
+		{'a', 6225}, {'s', 6226}, {'a', 6227}, {'p', 6228}, {'t', 6229}, {'s', 6230}, {'t', 6231}, {'c', 6232},
+		{'e', 6233}, {'h', 6234}, {'a', 6235}, {'s', 6236}, {'c', 6237}, {'e', 6238}, {'d', 6239}, {'t', 6240},
+		{';', 6241}, {';', 6242}, {';', 6243}, {';', 6244}, {'n', 6245}, {'r', 6246}, {';', 6247}, {';', 6248},
+		{';', 6249}, {'r', 6250}, {'a', 6251}, {'r', 6252}, {'l', 6253}, {'n', 6254}, {'r', 6255}, {'e', 6256},
+		{'t', 6257}, {'t', 6258}, {';', 6259}, {';', 6260}, {';', 6261}, {';', 6262}, {'e', 6263}, {'n', 6264},
+		{'r', 6265}, {'e', 6266}, {'o', 6267}, {'l', 6268}, {'e', 6269}, {'r', 6270}, {'p', 6271}, {'e', 6272},
+		{'r', 6273}, {'m', 6274}, {'a', 6275}, {'l', 6276}, {';', 6277}, {';', 6278}, {';', 6279}, {';', 6280},
+		{';', 6281}, {'o', 6282}, {'e', 6283}, {'i', 6284}, {'p', 6285}, {'i', 6286}, {';', 6287}, {'I', 6288},
+		{'S', 6289}, {'U', 6290}, {';', 6291}, {'E', 6292}, {'d', 6293}, {'a', 6294}, {'e', 6295}, {';', 6296},
+		{';', 6297}, {';', 6298}, {'o', 6299}, {'p', 6300}, {'a', 6301}, {'q', 6302}, {'u', 6303}, {'i', 6304},
+		{'D', 6305}, {';', 6306}, {';', 6307}, {'i', 6308}, {';', 6309}, {';', 6310}, {';', 6311}, {'a', 6312},
+		{'r', 6313}, {'a', 6314}, {'l', 6315}, {'w', 6316}, {'A', 6317}, {'l', 6318}, {'r', 6319}, {'w', 6320},
+		{'a', 6321}, {'e', 6322}, {'i', 6323}, {'n', 6324}, {';', 6325}, {';', 6326}, {';', 6327}, {'a', 6328},
+		{'i', 6329}, {';', 6330}, {';', 6331}, {';', 6332}, {';', 6333}, {'d', 6334}, {';', 6335}, {';', 6336},
+		{';', 6337}, {'m', 6338}, {';', 6339}, {'p', 6340}, {';', 6341}, {'a', 6342}, {'b', 6343}, {';', 6344},
+		{'r', 6345}, {';', 6346}, {';', 6347}, {'e', 6348}, {'q', 6349}, {';', 6350}, {'n', 6351}, {'n', 6352},
+		{'s', 6353}, {'i', 6354}, {'m', 6355}, {';', 6356}, {';', 6357}, {'g', 6358}, {'r', 6359}, {';', 6360},
+		{'e', 6361}, {'v', 6362}, {';', 6363}, {'n', 6364}, {';', 6365}, {'c', 6366}, {';', 6367}, {'t', 6368},
+		{'u', 6369}, {'m', 6370}, {'u', 6371}, {'r', 6372}, {'a', 6373}, {'u', 6374}, {';', 6375}, {'g', 6376},
+		{';', 6377}, {'o', 6378}, {'q', 6379}, {'r', 6380}, {'v', 6381}, {';', 6382}, {';', 6383}, {';', 6384},
+		{'u', 6385}, {'s', 6386}, {'e', 6387}, {';', 6388}, {';', 6389}, {'u', 6390}, {';', 6391}, {';', 6392},
+		{';', 6393}, {';', 6394}, {'u', 6395}, {';', 6396}, {';', 6397}, {';', 6398}, {';', 6399}, {';', 6400},
+		{'m', 6401}, {'v', 6402}, {'d', 6403}, {'a', 6404}, {';', 6405}, {'a', 6406}, {'d', 6407}, {'n', 6408},
+		{';', 6409}, {'r', 6410}, {'i', 6411}, {';', 6412}, {'q', 6413}, {';', 6414}, {';', 6415}, {'m', 6416},
+		{'x', 6417}, {'t', 6418}, {';', 6419}, {';', 6420}, {';', 6421}, {'l', 6422}, {'r', 6423}, {';', 6424},
+		{'p', 6425}, {'a', 6426}, {';', 6427}, {';', 6428}, {';', 6429}, {';', 6430}, {'m', 6431}, {'q', 6432},
+		{'e', 6433}, {'e', 6434}, {';', 6435}, {'r', 6436}, {'n', 6437}, {';', 6438}, {';', 6439}, {';', 6440},
+		{'w', 6441}, {';', 6442}, {'r', 6443}, {'q', 6444}, {'v', 6445}, {';', 6446}, {'d', 6447}, {'a', 6448},
+		{';', 6449}, {'o', 6450}, {';', 6451}, {';', 6452}, {';', 6453}, {';', 6454}, {'o', 6455}, {'u', 6456},
+		{'s', 6457}, {'a', 6458}, {'b', 6459}, {'r', 6460}, {'w', 6461}, {'r', 6462}, {'o', 6463}, {';', 6464},
+		{';', 6465}, {';', 6466}, {'e', 6467}, {'r', 6468}, {';', 6469}, {';', 6470}, {';', 6471}, {';', 6472},
+		{';', 6473}, {';', 6474}, {'r', 6475}, {';', 6476}, {'e', 6477}, {';', 6478}, {';', 6479}, {';', 6480},
+		{';', 6481}, {'n', 6482}, {';', 6483}, {'n', 6484}, {'t', 6485}, {';', 6486}, {';', 6487}, {'D', 6488},
+		{'s', 6489}, {'a', 6490}, {'n', 6491}, {'g', 6492}, {';', 6493}, {';', 6494}, {';', 6495}, {';', 6496},
+		{'n', 6497}, {';', 6498}, {';', 6499}, {';', 6500}, {';', 6501}, {';', 6502}, {';', 6503}, {';', 6504},
+		{';', 6505}, {';', 6506}, {';', 6507}, {';', 6508}, {';', 6509}, {';', 6510}, {';', 6511}, {';', 6512},
+		{';', 6513}, {';', 6514}, {';', 6515}, {'n', 6516}, {';', 6517}, {'o', 6518}, {';', 6519}, {'o', 6520},
+		{';', 6521}, {'t', 6522}, {'r', 6523}, {';', 6524}, {';', 6525}, {'e', 6526}, {'l', 6527}, {'s', 6528},
+		{';', 6529}, {'e', 6530}, {';', 6531}, {';', 6532}, {';', 6533}, {'r', 6534}, {';', 6535}, {'u', 6536},
+		{';', 6537}, {';', 6538}, {'o', 6539}, {'o', 6540}, {';', 6541}, {'f', 6542}, {'g', 6543}, {';', 6544},
+		{';', 6545}, {';', 6546}, {';', 6547}, {';', 6548}, {';', 6549}, {';', 6550}, {';', 6551}, {';', 6552},
+		{'n', 6553}, {'r', 6554}, {';', 6555}, {'i', 6556}, {';', 6557}, {';', 6558}, {'r', 6559}, {'a', 6560},
+		{'h', 6561}, {'d', 6562}, {';', 6563}, {'t', 6564}, {';', 6565}, {';', 6566}, {';', 6567}, {';', 6568},
+		{';', 6569}, {';', 6570}, {';', 6571}, {';', 6572}, {'y', 6573}, {';', 6574}, {';', 6575}, {';', 6576},
+		{'s', 6577}, {';', 6578}, {';', 6579}, {';', 6580}, {';', 6581}, {'m', 6582}, {';', 6583}, {';', 6584},
+		{';', 6585}, {';', 6586}, {'d', 6587}, {'u', 6588}, {';', 6589}, {';', 6590}, {';', 6591}, {'r', 6592},
+		{'a', 6593}, {'r', 6594}, {'r', 6595}, {'f', 6596}, {'g', 6597}, {'r', 6598}, {'n', 6599}, {';', 6600},
+		{'o', 6601}, {';', 6602}, {'p', 6603}, {'t', 6604}, {'g', 6605}, {'q', 6606}, {'r', 6607}, {'m', 6608},
+		{';', 6609}, {';', 6610}, {';', 6611}, {'e', 6612}, {';', 6613}, {';', 6614}, {';', 6615}, {'a', 6616},
+		{'o', 6617}, {'f', 6618}, {'p', 6619}, {'g', 6620}, {'r', 6621}, {';', 6622}, {'s', 6623}, {';', 6624},
+		{';', 6625}, {'e', 6626}, {';', 6627}, {'e', 6628}, {';', 6629}, {';', 6630}, {';', 6631}, {';', 6632},
+		{';', 6633}, {';', 6634}, {';', 6635}, {'t', 6636}, {';', 6637}, {'a', 6638}, {'r', 6639}, {'e', 6640},
+		{'e', 6641}, {';', 6642}, {'d', 6643}, {'l', 6644}, {'u', 6645}, {';', 6646}, {';', 6647}, {'e', 6648},
+		{';', 6649}, {';', 6650}, {';', 6651}, {';', 6652}, {';', 6653}, {'u', 6654}, {';', 6655}, {';', 6656},
+		{';', 6657}, {'a', 6658}, {'r', 6659}, {'i', 6660}, {'a', 6661}, {';', 6662}, {';', 6663}, {';', 6664},
+		{'x', 6665}, {'l', 6666}, {';', 6667}, {';', 6668}, {';', 6669}, {'o', 6670}, {';', 6671}, {'w', 6672},
+		{';', 6673}, {';', 6674}, {';', 6675}, {'s', 6676}, {'a', 6677}, {'r', 6678}, {'i', 6679}, {'a', 6680},
+		{';', 6681}, {';', 6682}, {'o', 6683}, {'a', 6684}, {'b', 6685}, {'c', 6686}, {'a', 6687}, {'b', 6688},
+		{'c', 6689}, {'l', 6690}, {';', 6691}, {'t', 6692}, {';', 6693}, {'q', 6694}, {';', 6695}, {';', 6696},
+		{'a', 6697}, {';', 6698}, {';', 6699}, {'m', 6700}, {'p', 6701}, {';', 6702}, {'e', 6703}, {'e', 6704},
+		{'t', 6705}, {'q', 6706}, {'t', 6707}, {';', 6708}, {'g', 6709}, {';', 6710}, {';', 6711}, {';', 6712},
+		{';', 6713}, {'n', 6714}, {';', 6715}, {'e', 6716}, {';', 6717}, {'e', 6718}, {';', 6719}, {'w', 6720},
+		{';', 6721}, {';', 6722}, {';', 6723}, {';', 6724}, {';', 6725}, {'s', 6726}, {'n', 6727}, {';', 6728},
+		{'f', 6729}, {';', 6730}, {'e', 6731}, {';', 6732}, {';', 6733}, {';', 6734}, {'a', 6735}, {'e', 6736},
+		{';', 6737}, {';', 6738}, {';', 6739}, {';', 6740}, {'k', 6741}, {';', 6742}, {'o', 6743}, {';', 6744},
+		{'h', 6745}, {';', 6746}, {'i', 6747}, {'r', 6748}, {';', 6749}, {';', 6750}, {';', 6751}, {'m', 6752},
+		{'o', 6753}, {'n', 6754}, {'p', 6755}, {'r', 6756}, {';', 6757}, {'p', 6758}, {'q', 6759}, {'i', 6760},
+		{'m', 6761}, {';', 6762}, {';', 6763}, {'a', 6764}, {'n', 6765}, {'r', 6766}, {';', 6767}, {';', 6768},
+		{';', 6769}, {';', 6770}, {'n', 6771}, {'t', 6772}, {'q', 6773}, {';', 6774}, {';', 6775}, {'y', 6776},
+		{';', 6777}, {';', 6778}, {'s', 6779}, {';', 6780}, {';', 6781}, {';', 6782}, {';', 6783}, {'m', 6784},
+		{';', 6785}, {';', 6786}, {'a', 6787}, {';', 6788}, {';', 6789}, {'d', 6790}, {'u', 6791}, {';', 6792},
+		{';', 6793}, {'r', 6794}, {';', 6795}, {'e', 6796}, {'r', 6797}, {';', 6798}, {';', 6799}, {';', 6800},
+		{'r', 6801}, {'a', 6802}, {'e', 6803}, {'i', 6804}, {'q', 6805}, {'h', 6806}, {'d', 6807}, {';', 6808},
+		{'a', 6809}, {';', 6810}, {'s', 6811}, {';', 6812}, {'n', 6813}, {';', 6814}, {';', 6815}, {';', 6816},
+		{';', 6817}, {'r', 6818}, {'r', 6819}, {';', 6820}, {';', 6821}, {';', 6822}, {';', 6823}, {'n', 6824},
+		{';', 6825}, {'w', 6826}, {';', 6827}, {'u', 6828}, {';', 6829}, {';', 6830}, {'i', 6831}, {'a', 6832},
+		{';', 6833}, {';', 6834}, {';', 6835}, {'s', 6836}, {'r', 6837}, {'e', 6838}, {';', 6839}, {'s', 6840},
+		{';', 6841}, {';', 6842}, {';', 6843}, {'u', 6844}, {';', 6845}, {';', 6846}, {';', 6847}, {'e', 6848},
+		{';', 6849}, {'e', 6850}, {';', 6851}, {';', 6852}, {';', 6853}, {';', 6854}, {';', 6855}, {'h', 6856},
+		{';', 6857}, {'t', 6858}, {'t', 6859}, {'s', 6860}, {'r', 6861}, {';', 6862}, {'e', 6863}, {'n', 6864},
+		{';', 6865}, {';', 6866}, {';', 6867}, {'p', 6868}, {'r', 6869}, {';', 6870}, {'p', 6871}, {'q', 6872},
+		{'i', 6873}, {'m', 6874}, {';', 6875}, {'b', 6876}, {'t', 6877}, {'l', 6878}, {'b', 6879}, {'r', 6880},
+		{'t', 6881}, {'s', 6882}, {';', 6883}, {'e', 6884}, {'n', 6885}, {';', 6886}, {';', 6887}, {';', 6888},
+		{';', 6889}, {'w', 6890}, {';', 6891}, {';', 6892}, {';', 6893}, {';', 6894}, {';', 6895}, {';', 6896},
+		{'o', 6897}, {'y', 6898}, {';', 6899}, {'p', 6900}, {'i', 6901}, {';', 6902}, {';', 6903}, {';', 6904},
+		{'a', 6905}, {';', 6906}, {';', 6907}, {';', 6908}, {'k', 6909}, {';', 6910}, {'l', 6911}, {';', 6912},
+		{'u', 6913}, {'s', 6914}, {'e', 6915}, {'u', 6916}, {';', 6917}, {'d', 6918}, {';', 6919}, {';', 6920},
+		{';', 6921}, {';', 6922}, {';', 6923}, {';', 6924}, {'e', 6925}, {';', 6926}, {'w', 6927}, {'a', 6928},
+		{'o', 6929}, {'n', 6930}, {'r', 6931}, {';', 6932}, {'e', 6933}, {';', 6934}, {';', 6935}, {'e', 6936},
+		{';', 6937}, {'i', 6938}, {'p', 6939}, {'h', 6940}, {';', 6941}, {'p', 6942}, {';', 6943}, {'m', 6944},
+		{'s', 6945}, {'s', 6946}, {'t', 6947}, {'a', 6948}, {';', 6949}, {';', 6950}, {';', 6951}, {';', 6952},
+		{';', 6953}, {';', 6954}, {';', 6955}, {'g', 6956}, {';', 6957}, {';', 6958}, {';', 6959}, {';', 6960},
+		{';', 6961}, {';', 6962}, {';', 6963}, {';', 6964}, {';', 6965}, {';', 6966}, {';', 6967}, {';', 6968},
+		{';', 6969}, {'r', 6970}, {'n', 6971}, {'s', 6972}, {';', 6973}, {'l', 6974}, {'D', 6975}, {';', 6976},
+		{';', 6977}, {';', 6978}, {'o', 6979}, {'o', 6980}, {'i', 6981}, {'l', 6982}, {'i', 6983}, {'s', 6984},
+		{'r', 6985}, {'n', 6986}, {'I', 6987}, {'c', 6988}, {'C', 6989}, {'d', 6990}, {'i', 6991}, {';', 6992},
+		{'n', 6993}, {'l', 6994}, {'o', 6995}, {'o', 6996}, {'e', 6997}, {'o', 6998}, {'i', 6999}, {'p', 7000},
+		{'e', 7001}, {'o', 7002}, {'v', 7003}, {'t', 7004}, {'h', 7005}, {';', 7006}, {'A', 7007}, {'o', 7008},
+		{';', 7009}, {'a', 7010}, {'r', 7011}, {';', 7012}, {'l', 7013}, {'r', 7014}, {'t', 7015}, {'m', 7016},
+		{'e', 7017}, {'t', 7018}, {'E', 7019}, {'F', 7020}, {'G', 7021}, {'L', 7022}, {'S', 7023}, {'T', 7024},
+		{'S', 7025}, {'t', 7026}, {'n', 7027}, {'a', 7028}, {'r', 7029}, {';', 7030}, {'l', 7031}, {'c', 7032},
+		{'l', 7033}, {'t', 7034}, {'l', 7035}, {'o', 7036}, {'l', 7037}, {'b', 7038}, {'n', 7039}, {'o', 7040},
+		{'h', 7041}, {';', 7042}, {'A', 7043}, {'V', 7044}, {'a', 7045}, {'o', 7046}, {'e', 7047}, {'e', 7048},
+		{'t', 7049}, {'o', 7050}, {'h', 7051}, {'a', 7052}, {'l', 7053}, {'a', 7054}, {'s', 7055}, {'n', 7056},
+		{'d', 7057}, {'r', 7058}, {'t', 7059}, {'h', 7060}, {'t', 7061}, {'h', 7062}, {'f', 7063}, {'g', 7064},
+		{'p', 7065}, {'r', 7066}, {'u', 7067}, {'e', 7068}, {'r', 7069}, {'e', 7070}, {';', 7071}, {';', 7072},
+		{'k', 7073}, {'r', 7074}, {'a', 7075}, {'l', 7076}, {'e', 7077}, {'l', 7078}, {'t', 7079}, {'t', 7080},
+		{'D', 7081}, {'E', 7082}, {'T', 7083}, {';', 7084}, {'E', 7085}, {'G', 7086}, {'L', 7087}, {'S', 7088},
+		{'T', 7089}, {'e', 7090}, {'e', 7091}, {'r', 7092}, {'t', 7093}, {'r', 7094}, {'e', 7095}, {'e', 7096},
+		{'r', 7097}, {'e', 7098}, {'i', 7099}, {';', 7100}, {'l', 7101}, {';', 7102}, {'c', 7103}, {'e', 7104},
+		{'D', 7105}, {'u', 7106}, {'e', 7107}, {'s', 7108}, {';', 7109}, {'i', 7110}, {'E', 7111}, {'U', 7112},
+		{'g', 7113}, {'r', 7114}, {'i', 7115}, {'u', 7116}, {'w', 7117}, {'o', 7118}, {'e', 7119}, {'i', 7120},
+		{'D', 7121}, {'T', 7122}, {'V', 7123}, {'c', 7124}, {'r', 7125}, {'p', 7126}, {'r', 7127}, {'a', 7128},
+		{'w', 7129}, {'f', 7130}, {'g', 7131}, {'A', 7132}, {'r', 7133}, {'n', 7134}, {'u', 7135}, {'n', 7136},
+		{'q', 7137}, {'s', 7138}, {'t', 7139}, {'t', 7140}, {'r', 7141}, {'a', 7142}, {'c', 7143}, {'u', 7144},
+		{'l', 7145}, {'l', 7146}, {'o', 7147}, {'r', 7148}, {'r', 7149}, {'a', 7150}, {'r', 7151}, {'u', 7152},
+		{';', 7153}, {'B', 7154}, {'D', 7155}, {'r', 7156}, {'i', 7157}, {'r', 7158}, {';', 7159}, {'r', 7160},
+		{'f', 7161}, {'g', 7162}, {';', 7163}, {'l', 7164}, {'n', 7165}, {'t', 7166}, {';', 7167}, {'e', 7168},
+		{'a', 7169}, {'b', 7170}, {'c', 7171}, {'d', 7172}, {'e', 7173}, {'f', 7174}, {'g', 7175}, {'h', 7176},
+		{';', 7177}, {'d', 7178}, {';', 7179}, {'q', 7180}, {';', 7181}, {'t', 7182}, {'g', 7183}, {'i', 7184},
+		{'m', 7185}, {';', 7186}, {'e', 7187}, {'e', 7188}, {'k', 7189}, {';', 7190}, {';', 7191}, {';', 7192},
+		{';', 7193}, {';', 7194}, {'s', 7195}, {'e', 7196}, {'p', 7197}, {';', 7198}, {'n', 7199}, {'s', 7200},
+		{'e', 7201}, {'z', 7202}, {'u', 7203}, {'i', 7204}, {';', 7205}, {'s', 7206}, {';', 7207}, {'s', 7208},
+		{'b', 7209}, {'p', 7210}, {';', 7211}, {';', 7212}, {'o', 7213}, {'r', 7214}, {'r', 7215}, {'R', 7216},
+		{'S', 7217}, {'a', 7218}, {'c', 7219}, {'d', 7220}, {'t', 7221}, {';', 7222}, {'t', 7223}, {';', 7224},
+		{'e', 7225}, {'e', 7226}, {';', 7227}, {';', 7228}, {';', 7229}, {';', 7230}, {'p', 7231}, {';', 7232},
+		{'p', 7233}, {'s', 7234}, {'e', 7235}, {'d', 7236}, {'r', 7237}, {'t', 7238}, {';', 7239}, {';', 7240},
+		{';', 7241}, {';', 7242}, {';', 7243}, {'s', 7244}, {';', 7245}, {'n', 7246}, {'t', 7247}, {'s', 7248},
+		{';', 7249}, {'r', 7250}, {'a', 7251}, {'o', 7252}, {'n', 7253}, {'p', 7254}, {'w', 7255}, {';', 7256},
+		{'r', 7257}, {'s', 7258}, {'t', 7259}, {';', 7260}, {';', 7261}, {'g', 7262}, {'l', 7263}, {';', 7264},
+		{'l', 7265}, {'t', 7266}, {'t', 7267}, {'d', 7268}, {'t', 7269}, {'t', 7270}, {';', 7271}, {'l', 7272},
+		{'x', 7273}, {';', 7274}, {'o', 7275}, {'s', 7276}, {'e', 7277}, {';', 7278}, {'q', 7279}, {';', 7280},
+		{'i', 7281}, {'w', 7282}, {'w', 7283}, {'t', 7284}, {'h', 7285}, {'e', 7286}, {'t', 7287}, {'e', 7288},
+		{'s', 7289}, {'l', 7290}, {'k', 7291}, {';', 7292}, {';', 7293}, {'v', 7294}, {';', 7295}, {';', 7296},
+		{';', 7297}, {';', 7298}, {';', 7299}, {'r', 7300}, {'o', 7301}, {'p', 7302}, {'t', 7303}, {'h', 7304},
+		{'e', 7305}, {'t', 7306}, {';', 7307}, {'r', 7308}, {'r', 7309}, {';', 7310}, {'t', 7311}, {'g', 7312},
+		{';', 7313}, {';', 7314}, {'r', 7315}, {'c', 7316}, {'x', 7317}, {'t', 7318}, {'s', 7319}, {'h', 7320},
+		{'o', 7321}, {';', 7322}, {';', 7323}, {'r', 7324}, {';', 7325}, {'r', 7326}, {';', 7327}, {'q', 7328},
+		{';', 7329}, {'o', 7330}, {'e', 7331}, {'p', 7332}, {'d', 7333}, {';', 7334}, {'p', 7335}, {'r', 7336},
+		{'g', 7337}, {'r', 7338}, {';', 7339}, {';', 7340}, {'s', 7341}, {'t', 7342}, {';', 7343}, {';', 7344},
+		{'n', 7345}, {'r', 7346}, {'g', 7347}, {'n', 7348}, {'t', 7349}, {';', 7350}, {';', 7351}, {';', 7352},
+		{';', 7353}, {';', 7354}, {';', 7355}, {'e', 7356}, {';', 7357}, {';', 7358}, {'r', 7359}, {'i', 7360},
+		{'a', 7361}, {';', 7362}, {';', 7363}, {';', 7364}, {'e', 7365}, {';', 7366}, {';', 7367}, {'e', 7368},
+		{'l', 7369}, {';', 7370}, {';', 7371}, {';', 7372}, {';', 7373}, {';', 7374}, {';', 7375}, {';', 7376},
+		{';', 7377}, {'s', 7378}, {'l', 7379}, {';', 7380}, {'r', 7381}, {';', 7382}, {'r', 7383}, {';', 7384},
+		{';', 7385}, {';', 7386}, {'t', 7387}, {'r', 7388}, {'l', 7389}, {'p', 7390}, {'q', 7391}, {'m', 7392},
+		{';', 7393}, {'r', 7394}, {'e', 7395}, {'f', 7396}, {'i', 7397}, {';', 7398}, {';', 7399}, {'v', 7400},
+		{';', 7401}, {';', 7402}, {'l', 7403}, {';', 7404}, {';', 7405}, {';', 7406}, {';', 7407}, {'t', 7408},
+		{'r', 7409}, {'r', 7410}, {'f', 7411}, {'g', 7412}, {'u', 7413}, {'r', 7414}, {'o', 7415}, {'c', 7416},
+		{';', 7417}, {'t', 7418}, {'i', 7419}, {';', 7420}, {'t', 7421}, {';', 7422}, {'s', 7423}, {'d', 7424},
+		{'r', 7425}, {';', 7426}, {';', 7427}, {'t', 7428}, {'l', 7429}, {'i', 7430}, {'t', 7431}, {'t', 7432},
+		{'t', 7433}, {';', 7434}, {';', 7435}, {';', 7436}, {';', 7437}, {'q', 7438}, {'e', 7439}, {'r', 7440},
+		{'l', 7441}, {'p', 7442}, {'q', 7443}, {'m', 7444}, {';', 7445}, {';', 7446}, {';', 7447}, {';', 7448},
+		{';', 7449}, {';', 7450}, {';', 7451}, {';', 7452}, {'q', 7453}, {'e', 7454}, {';', 7455}, {'r', 7456},
+		{'m', 7457}, {'p', 7458}, {'m', 7459}, {'r', 7460}, {';', 7461}, {'e', 7462}, {'s', 7463}, {';', 7464},
+		{';', 7465}, {'m', 7466}, {'l', 7467}, {'r', 7468}, {'r', 7469}, {';', 7470}, {'r', 7471}, {'o', 7472},
+		{';', 7473}, {'o', 7474}, {'r', 7475}, {';', 7476}, {'l', 7477}, {'a', 7478}, {'i', 7479}, {'t', 7480},
+		{'a', 7481}, {'e', 7482}, {'e', 7483}, {'a', 7484}, {'n', 7485}, {';', 7486}, {';', 7487}, {'c', 7488},
+		{'h', 7489}, {'i', 7490}, {'i', 7491}, {'t', 7492}, {'t', 7493}, {'n', 7494}, {'u', 7495}, {'m', 7496},
+		{'e', 7497}, {'l', 7498}, {'t', 7499}, {'n', 7500}, {'t', 7501}, {'l', 7502}, {';', 7503}, {'c', 7504},
+		{'t', 7505}, {';', 7506}, {'n', 7507}, {'t', 7508}, {'w', 7509}, {'f', 7510}, {'n', 7511}, {'g', 7512},
+		{'A', 7513}, {'D', 7514}, {'r', 7515}, {'w', 7516}, {'e', 7517}, {'R', 7518}, {'T', 7519}, {'V', 7520},
+		{'t', 7521}, {'r', 7522}, {'w', 7523}, {'l', 7524}, {'y', 7525}, {'d', 7526}, {'i', 7527}, {'i', 7528},
+		{'a', 7529}, {'r', 7530}, {'r', 7531}, {'q', 7532}, {'u', 7533}, {'r', 7534}, {'e', 7535}, {'l', 7536},
+		{'i', 7537}, {'p', 7538}, {'a', 7539}, {'H', 7540}, {'l', 7541}, {'y', 7542}, {';', 7543}, {'t', 7544},
+		{'e', 7545}, {'r', 7546}, {'e', 7547}, {'w', 7548}, {'i', 7549}, {'l', 7550}, {'T', 7551}, {'V', 7552},
+		{'r', 7553}, {'t', 7554}, {'r', 7555}, {'e', 7556}, {'n', 7557}, {'w', 7558}, {'e', 7559}, {'c', 7560},
+		{'o', 7561}, {'w', 7562}, {'t', 7563}, {'l', 7564}, {'E', 7565}, {'t', 7566}, {';', 7567}, {'t', 7568},
+		{'e', 7569}, {'o', 7570}, {'A', 7571}, {'R', 7572}, {'t', 7573}, {'a', 7574}, {'r', 7575}, {'t', 7576},
+		{'t', 7577}, {'h', 7578}, {'a', 7579}, {'f', 7580}, {'s', 7581}, {'M', 7582}, {'T', 7583}, {'V', 7584},
+		{'e', 7585}, {'s', 7586}, {'i', 7587}, {'u', 7588}, {'p', 7589}, {'e', 7590}, {'n', 7591}, {';', 7592},
+		{'T', 7593}, {'s', 7594}, {'e', 7595}, {'o', 7596}, {'q', 7597}, {'r', 7598}, {'q', 7599}, {'r', 7600},
+		{'e', 7601}, {'l', 7602}, {'i', 7603}, {'d', 7604}, {'d', 7605}, {'s', 7606}, {'T', 7607}, {'e', 7608},
+		{'t', 7609}, {'e', 7610}, {'s', 7611}, {';', 7612}, {'E', 7613}, {'F', 7614}, {'T', 7615}, {'c', 7616},
+		{'y', 7617}, {'e', 7618}, {'k', 7619}, {'n', 7620}, {';', 7621}, {'s', 7622}, {'p', 7623}, {';', 7624},
+		{'E', 7625}, {'S', 7626}, {'T', 7627}, {'o', 7628}, {'l', 7629}, {'q', 7630}, {'p', 7631}, {'l', 7632},
+		{'o', 7633}, {'l', 7634}, {'b', 7635}, {'n', 7636}, {'o', 7637}, {';', 7638}, {'A', 7639}, {'V', 7640},
+		{'a', 7641}, {'o', 7642}, {'e', 7643}, {'e', 7644}, {'t', 7645}, {'o', 7646}, {'l', 7647}, {'r', 7648},
+		{'y', 7649}, {'n', 7650}, {'t', 7651}, {'h', 7652}, {'r', 7653}, {'c', 7654}, {'t', 7655}, {'b', 7656},
+		{'p', 7657}, {'i', 7658}, {'u', 7659}, {';', 7660}, {'E', 7661}, {'S', 7662}, {'T', 7663}, {';', 7664},
+		{';', 7665}, {'E', 7666}, {'e', 7667}, {'c', 7668}, {'e', 7669}, {'a', 7670}, {'l', 7671}, {'d', 7672},
+		{'t', 7673}, {';', 7674}, {';', 7675}, {'c', 7676}, {'e', 7677}, {'s', 7678}, {'a', 7679}, {'o', 7680},
+		{'r', 7681}, {'b', 7682}, {'o', 7683}, {'r', 7684}, {'t', 7685}, {'h', 7686}, {'B', 7687}, {'L', 7688},
+		{'S', 7689}, {'T', 7690}, {'S', 7691}, {'h', 7692}, {';', 7693}, {';', 7694}, {';', 7695}, {';', 7696},
+		{';', 7697}, {';', 7698}, {';', 7699}, {';', 7700}, {';', 7701}, {';', 7702}, {';', 7703}, {';', 7704},
+		{';', 7705}, {'l', 7706}, {'e', 7707}, {'q', 7708}, {';', 7709}, {';', 7710}, {';', 7711}, {'s', 7712},
+		{';', 7713}, {'g', 7714}, {';', 7715}, {';', 7716}, {'e', 7717}, {'a', 7718}, {'a', 7719}, {';', 7720},
+		{';', 7721}, {';', 7722}, {';', 7723}, {'t', 7724}, {'k', 7725}, {'r', 7726}, {';', 7727}, {';', 7728},
+		{'s', 7729}, {'i', 7730}, {'a', 7731}, {';', 7732}, {';', 7733}, {'n', 7734}, {'s', 7735}, {';', 7736},
+		{'r', 7737}, {'u', 7738}, {';', 7739}, {'g', 7740}, {'o', 7741}, {';', 7742}, {'u', 7743}, {'t', 7744},
+		{';', 7745}, {';', 7746}, {'e', 7747}, {'r', 7748}, {'w', 7749}, {'a', 7750}, {'o', 7751}, {';', 7752},
+		{';', 7753}, {';', 7754}, {';', 7755}, {'t', 7756}, {'e', 7757}, {';', 7758}, {'i', 7759}, {'i', 7760},
+		{'o', 7761}, {';', 7762}, {';', 7763}, {';', 7764}, {';', 7765}, {'x', 7766}, {'s', 7767}, {'s', 7768},
+		{'q', 7769}, {'t', 7770}, {';', 7771}, {';', 7772}, {'a', 7773}, {'t', 7774}, {';', 7775}, {';', 7776},
+		{';', 7777}, {';', 7778}, {';', 7779}, {';', 7780}, {';', 7781}, {';', 7782}, {'w', 7783}, {'o', 7784},
+		{'a', 7785}, {'t', 7786}, {'e', 7787}, {';', 7788}, {';', 7789}, {'o', 7790}, {'r', 7791}, {'t', 7792},
+		{';', 7793}, {'h', 7794}, {';', 7795}, {'a', 7796}, {'r', 7797}, {'t', 7798}, {'t', 7799}, {'w', 7800},
+		{';', 7801}, {';', 7802}, {'q', 7803}, {'w', 7804}, {'f', 7805}, {';', 7806}, {'a', 7807}, {';', 7808},
+		{'o', 7809}, {'h', 7810}, {'r', 7811}, {';', 7812}, {';', 7813}, {'t', 7814}, {'o', 7815}, {'h', 7816},
+		{'t', 7817}, {';', 7818}, {'l', 7819}, {'r', 7820}, {'d', 7821}, {'r', 7822}, {'q', 7823}, {'q', 7824},
+		{'e', 7825}, {';', 7826}, {';', 7827}, {'k', 7828}, {';', 7829}, {';', 7830}, {'o', 7831}, {'y', 7832},
+		{'r', 7833}, {';', 7834}, {';', 7835}, {';', 7836}, {';', 7837}, {';', 7838}, {'o', 7839}, {';', 7840},
+		{'s', 7841}, {';', 7842}, {'o', 7843}, {'p', 7844}, {'t', 7845}, {'h', 7846}, {'i', 7847}, {'e', 7848},
+		{'t', 7849}, {'h', 7850}, {';', 7851}, {';', 7852}, {';', 7853}, {';', 7854}, {';', 7855}, {'a', 7856},
+		{'m', 7857}, {';', 7858}, {'t', 7859}, {';', 7860}, {'e', 7861}, {';', 7862}, {'e', 7863}, {'e', 7864},
+		{'p', 7865}, {';', 7866}, {'q', 7867}, {'q', 7868}, {'o', 7869}, {'y', 7870}, {'r', 7871}, {';', 7872},
+		{';', 7873}, {';', 7874}, {'q', 7875}, {'q', 7876}, {'e', 7877}, {';', 7878}, {'r', 7879}, {';', 7880},
+		{';', 7881}, {';', 7882}, {'d', 7883}, {'l', 7884}, {'q', 7885}, {'r', 7886}, {';', 7887}, {';', 7888},
+		{'e', 7889}, {'i', 7890}, {';', 7891}, {'r', 7892}, {'n', 7893}, {'w', 7894}, {';', 7895}, {'o', 7896},
+		{';', 7897}, {'n', 7898}, {'o', 7899}, {';', 7900}, {'t', 7901}, {'t', 7902}, {';', 7903}, {'g', 7904},
+		{'t', 7905}, {';', 7906}, {'s', 7907}, {'f', 7908}, {';', 7909}, {';', 7910}, {'u', 7911}, {'s', 7912},
+		{'e', 7913}, {'C', 7914}, {'y', 7915}, {';', 7916}, {'t', 7917}, {';', 7918}, {'o', 7919}, {'a', 7920},
+		{'i', 7921}, {'t', 7922}, {';', 7923}, {'n', 7924}, {'t', 7925}, {'g', 7926}, {'h', 7927}, {'r', 7928},
+		{'o', 7929}, {'t', 7930}, {';', 7931}, {'B', 7932}, {'U', 7933}, {';', 7934}, {'i', 7935}, {'e', 7936},
+		{'e', 7937}, {'T', 7938}, {'V', 7939}, {'r', 7940}, {';', 7941}, {'l', 7942}, {'S', 7943}, {'e', 7944},
+		{'u', 7945}, {'a', 7946}, {'l', 7947}, {'y', 7948}, {'f', 7949}, {'u', 7950}, {'l', 7951}, {'e', 7952},
+		{'s', 7953}, {'a', 7954}, {'l', 7955}, {'a', 7956}, {'l', 7957}, {'u', 7958}, {';', 7959}, {'I', 7960},
+		{'i', 7961}, {'C', 7962}, {'T', 7963}, {'f', 7964}, {'B', 7965}, {';', 7966}, {'B', 7967}, {'R', 7968},
+		{'n', 7969}, {'e', 7970}, {'e', 7971}, {'e', 7972}, {';', 7973}, {'A', 7974}, {'V', 7975}, {'r', 7976},
+		{'c', 7977}, {'g', 7978}, {'n', 7979}, {'V', 7980}, {'t', 7981}, {'r', 7982}, {';', 7983}, {'a', 7984},
+		{'G', 7985}, {'q', 7986}, {'e', 7987}, {'E', 7988}, {';', 7989}, {'w', 7990}, {'r', 7991}, {'i', 7992},
+		{'A', 7993}, {'r', 7994}, {'i', 7995}, {'a', 7996}, {'A', 7997}, {'t', 7998}, {'c', 7999}, {';', 8000},
+		{';', 8001}, {'e', 8002}, {'h', 8003}, {'e', 8004}, {'a', 8005}, {'s', 8006}, {'n', 8007}, {'e', 8008},
+		{';', 8009}, {'V', 8010}, {'t', 8011}, {'i', 8012}, {';', 8013}, {'r', 8014}, {'w', 8015}, {'u', 8016},
+		{'i', 8017}, {'u', 8018}, {'e', 8019}, {'s', 8020}, {'a', 8021}, {'l', 8022}, {'G', 8023}, {'L', 8024},
+		{'e', 8025}, {'e', 8026}, {'r', 8027}, {'S', 8028}, {';', 8029}, {'E', 8030}, {'d', 8031}, {'e', 8032},
+		{'q', 8033}, {'u', 8034}, {'i', 8035}, {'a', 8036}, {'D', 8037}, {'Q', 8038}, {';', 8039}, {'e', 8040},
+		{'t', 8041}, {';', 8042}, {'l', 8043}, {'q', 8044}, {'l', 8045}, {'i', 8046}, {'n', 8047}, {'e', 8048},
+		{'u', 8049}, {'E', 8050}, {'e', 8051}, {'w', 8052}, {'i', 8053}, {'l', 8054}, {'T', 8055}, {'V', 8056},
+		{'r', 8057}, {'r', 8058}, {'e', 8059}, {'n', 8060}, {'w', 8061}, {'e', 8062}, {'c', 8063}, {'o', 8064},
+		{'w', 8065}, {'i', 8066}, {'o', 8067}, {'e', 8068}, {'A', 8069}, {'A', 8070}, {'t', 8071}, {'r', 8072},
+		{'l', 8073}, {'e', 8074}, {'s', 8075}, {'e', 8076}, {'o', 8077}, {'a', 8078}, {'q', 8079}, {'l', 8080},
+		{'i', 8081}, {'q', 8082}, {';', 8083}, {'e', 8084}, {';', 8085}, {'l', 8086}, {'E', 8087}, {'e', 8088},
+		{';', 8089}, {'e', 8090}, {'k', 8091}, {'n', 8092}, {';', 8093}, {'r', 8094}, {'w', 8095}, {'o', 8096},
+		{'r', 8097}, {'w', 8098}, {'o', 8099}, {'A', 8100}, {'t', 8101}, {'a', 8102}, {'i', 8103}, {'e', 8104},
+		{'i', 8105}, {'p', 8106}, {'S', 8107}, {'o', 8108}, {';', 8109}, {';', 8110}, {';', 8111}, {'l', 8112},
+		{'n', 8113}, {'r', 8114}, {'n', 8115}, {';', 8116}, {';', 8117}, {'o', 8118}, {'t', 8119}, {'r', 8120},
+		{'s', 8121}, {'t', 8122}, {';', 8123}, {'e', 8124}, {'c', 8125}, {'e', 8126}, {'w', 8127}, {'i', 8128},
+		{'i', 8129}, {';', 8130}, {'w', 8131}, {';', 8132}, {'r', 8133}, {'o', 8134}, {'r', 8135}, {'s', 8136},
+		{'o', 8137}, {'a', 8138}, {'t', 8139}, {';', 8140}, {';', 8141}, {'s', 8142}, {';', 8143}, {';', 8144},
+		{'r', 8145}, {'a', 8146}, {';', 8147}, {'t', 8148}, {'o', 8149}, {'r', 8150}, {'a', 8151}, {'h', 8152},
+		{'s', 8153}, {'t', 8154}, {'x', 8155}, {';', 8156}, {'r', 8157}, {'e', 8158}, {'r', 8159}, {'i', 8160},
+		{'o', 8161}, {'a', 8162}, {'l', 8163}, {'r', 8164}, {';', 8165}, {'n', 8166}, {'t', 8167}, {'n', 8168},
+		{'w', 8169}, {'t', 8170}, {'o', 8171}, {';', 8172}, {'w', 8173}, {'t', 8174}, {';', 8175}, {';', 8176},
+		{'o', 8177}, {';', 8178}, {'a', 8179}, {';', 8180}, {'q', 8181}, {';', 8182}, {'q', 8183}, {'l', 8184},
+		{'r', 8185}, {';', 8186}, {'x', 8187}, {'e', 8188}, {'o', 8189}, {'n', 8190}, {';', 8191}, {'w', 8192},
+		{'o', 8193}, {'a', 8194}, {'h', 8195}, {'t', 8196}, {'g', 8197}, {'e', 8198}, {'s', 8199}, {'e', 8200},
+		{'l', 8201}, {'i', 8202}, {';', 8203}, {'q', 8204}, {'q', 8205}, {'p', 8206}, {'h', 8207}, {';', 8208},
+		{';', 8209}, {'q', 8210}, {'x', 8211}, {'e', 8212}, {'o', 8213}, {';', 8214}, {';', 8215}, {'q', 8216},
+		{';', 8217}, {'o', 8218}, {'o', 8219}, {'e', 8220}, {';', 8221}, {'i', 8222}, {'f', 8223}, {'g', 8224},
+		{'o', 8225}, {'l', 8226}, {'r', 8227}, {'s', 8228}, {'n', 8229}, {'g', 8230}, {';', 8231}, {'n', 8232},
+		{'n', 8233}, {'l', 8234}, {'i', 8235}, {';', 8236}, {'f', 8237}, {'s', 8238}, {';', 8239}, {'s', 8240},
+		{'o', 8241}, {'D', 8242}, {'Q', 8243}, {'e', 8244}, {'c', 8245}, {'l', 8246}, {'a', 8247}, {'o', 8248},
+		{'A', 8249}, {'A', 8250}, {'R', 8251}, {'T', 8252}, {'L', 8253}, {'R', 8254}, {'t', 8255}, {'r', 8256},
+		{'w', 8257}, {'i', 8258}, {'a', 8259}, {'p', 8260}, {'g', 8261}, {'e', 8262}, {'c', 8263}, {'e', 8264},
+		{'e', 8265}, {'o', 8266}, {'S', 8267}, {'m', 8268}, {';', 8269}, {'m', 8270}, {'l', 8271}, {'l', 8272},
+		{'S', 8273}, {';', 8274}, {'a', 8275}, {'l', 8276}, {'a', 8277}, {'s', 8278}, {'n', 8279}, {'d', 8280},
+		{'c', 8281}, {'L', 8282}, {'m', 8283}, {';', 8284}, {'o', 8285}, {'o', 8286}, {'i', 8287}, {';', 8288},
+		{'r', 8289}, {'a', 8290}, {'i', 8291}, {'g', 8292}, {'B', 8293}, {'e', 8294}, {'c', 8295}, {'r', 8296},
+		{'e', 8297}, {'o', 8298}, {'t', 8299}, {'l', 8300}, {'V', 8301}, {'e', 8302}, {'o', 8303}, {';', 8304},
+		{'B', 8305}, {'r', 8306}, {'r', 8307}, {'u', 8308}, {'r', 8309}, {'q', 8310}, {';', 8311}, {'r', 8312},
+		{'g', 8313}, {'r', 8314}, {'r', 8315}, {'g', 8316}, {'r', 8317}, {'r', 8318}, {'A', 8319}, {'e', 8320},
+		{'d', 8321}, {'i', 8322}, {'r', 8323}, {'t', 8324}, {'L', 8325}, {'g', 8326}, {'n', 8327}, {'e', 8328},
+		{';', 8329}, {'l', 8330}, {';', 8331}, {'E', 8332}, {'F', 8333}, {'G', 8334}, {'L', 8335}, {'S', 8336},
+		{'T', 8337}, {'n', 8338}, {'a', 8339}, {'a', 8340}, {'a', 8341}, {'a', 8342}, {'s', 8343}, {'n', 8344},
+		{'d', 8345}, {'r', 8346}, {'e', 8347}, {'s', 8348}, {'E', 8349}, {'i', 8350}, {'u', 8351}, {'q', 8352},
+		{'s', 8353}, {'t', 8354}, {'u', 8355}, {'l', 8356}, {'l', 8357}, {'l', 8358}, {'o', 8359}, {'u', 8360},
+		{'t', 8361}, {'h', 8362}, {'a', 8363}, {'u', 8364}, {'a', 8365}, {'l', 8366}, {';', 8367}, {'a', 8368},
+		{'m', 8369}, {'i', 8370}, {'q', 8371}, {'B', 8372}, {';', 8373}, {'B', 8374}, {'L', 8375}, {'n', 8376},
+		{'e', 8377}, {'e', 8378}, {'e', 8379}, {';', 8380}, {'r', 8381}, {'c', 8382}, {'g', 8383}, {'n', 8384},
+		{'V', 8385}, {'t', 8386}, {'r', 8387}, {';', 8388}, {'e', 8389}, {'w', 8390}, {'d', 8391}, {'r', 8392},
+		{'r', 8393}, {'A', 8394}, {'o', 8395}, {'e', 8396}, {'r', 8397}, {'e', 8398}, {'r', 8399}, {'n', 8400},
+		{'l', 8401}, {'u', 8402}, {'a', 8403}, {'l', 8404}, {'u', 8405}, {';', 8406}, {';', 8407}, {'q', 8408},
+		{';', 8409}, {';', 8410}, {'e', 8411}, {'t', 8412}, {';', 8413}, {'n', 8414}, {'w', 8415}, {'i', 8416},
+		{';', 8417}, {'w', 8418}, {'r', 8419}, {'A', 8420}, {'r', 8421}, {'n', 8422}, {'p', 8423}, {'l', 8424},
+		{'a', 8425}, {'p', 8426}, {'n', 8427}, {'e', 8428}, {'g', 8429}, {'e', 8430}, {'g', 8431}, {'w', 8432},
+		{';', 8433}, {'c', 8434}, {'h', 8435}, {';', 8436}, {'c', 8437}, {'c', 8438}, {';', 8439}, {'l', 8440},
+		{'r', 8441}, {'t', 8442}, {'m', 8443}, {'e', 8444}, {'r', 8445}, {'n', 8446}, {';', 8447}, {'s', 8448},
+		{'n', 8449}, {'l', 8450}, {'s', 8451}, {';', 8452}, {'r', 8453}, {'r', 8454}, {'a', 8455}, {'n', 8456},
+		{'r', 8457}, {'r', 8458}, {'a', 8459}, {'q', 8460}, {'i', 8461}, {';', 8462}, {';', 8463}, {';', 8464},
+		{'r', 8465}, {'g', 8466}, {';', 8467}, {'r', 8468}, {'e', 8469}, {'i', 8470}, {';', 8471}, {';', 8472},
+		{'g', 8473}, {';', 8474}, {'a', 8475}, {'w', 8476}, {';', 8477}, {'a', 8478}, {'w', 8479}, {'l', 8480},
+		{';', 8481}, {';', 8482}, {'e', 8483}, {'i', 8484}, {';', 8485}, {'q', 8486}, {'x', 8487}, {'s', 8488},
+		{';', 8489}, {'t', 8490}, {'o', 8491}, {'r', 8492}, {'a', 8493}, {'a', 8494}, {'a', 8495}, {'t', 8496},
+		{'e', 8497}, {';', 8498}, {'l', 8499}, {'n', 8500}, {';', 8501}, {';', 8502}, {'s', 8503}, {'i', 8504},
+		{';', 8505}, {';', 8506}, {'q', 8507}, {'x', 8508}, {';', 8509}, {'x', 8510}, {'w', 8511}, {'f', 8512},
+		{'g', 8513}, {'t', 8514}, {'h', 8515}, {'w', 8516}, {'e', 8517}, {'i', 8518}, {';', 8519}, {';', 8520},
+		{';', 8521}, {'e', 8522}, {'e', 8523}, {'e', 8524}, {'o', 8525}, {'e', 8526}, {';', 8527}, {';', 8528},
+		{'n', 8529}, {'o', 8530}, {'u', 8531}, {'g', 8532}, {'k', 8533}, {'A', 8534}, {'D', 8535}, {'G', 8536},
+		{'T', 8537}, {'l', 8538}, {'u', 8539}, {'r', 8540}, {'r', 8541}, {'i', 8542}, {'e', 8543}, {'e', 8544},
+		{'i', 8545}, {'A', 8546}, {'T', 8547}, {'o', 8548}, {'n', 8549}, {'c', 8550}, {'r', 8551}, {'A', 8552},
+		{'h', 8553}, {'V', 8554}, {'t', 8555}, {'e', 8556}, {'c', 8557}, {'w', 8558}, {'q', 8559}, {'a', 8560},
+		{';', 8561}, {'E', 8562}, {'S', 8563}, {'m', 8564}, {'l', 8565}, {'E', 8566}, {'t', 8567}, {';', 8568},
+		{'t', 8569}, {'e', 8570}, {'e', 8571}, {'i', 8572}, {'p', 8573}, {'n', 8574}, {'m', 8575}, {'m', 8576},
+		{'a', 8577}, {'r', 8578}, {'g', 8579}, {';', 8580}, {'r', 8581}, {'V', 8582}, {'t', 8583}, {'r', 8584},
+		{'c', 8585}, {'w', 8586}, {'o', 8587}, {'e', 8588}, {'e', 8589}, {'c', 8590}, {'r', 8591}, {'a', 8592},
+		{'r', 8593}, {'e', 8594}, {'a', 8595}, {';', 8596}, {'u', 8597}, {'o', 8598}, {'h', 8599}, {'r', 8600},
+		{'o', 8601}, {'h', 8602}, {'r', 8603}, {'r', 8604}, {'r', 8605}, {';', 8606}, {'i', 8607}, {'c', 8608},
+		{'n', 8609}, {'y', 8610}, {'e', 8611}, {'e', 8612}, {'S', 8613}, {'t', 8614}, {'r', 8615}, {'d', 8616},
+		{'q', 8617}, {'u', 8618}, {'r', 8619}, {'e', 8620}, {'l', 8621}, {'i', 8622}, {'H', 8623}, {'l', 8624},
+		{'n', 8625}, {'l', 8626}, {'t', 8627}, {';', 8628}, {'t', 8629}, {'e', 8630}, {'e', 8631}, {'s', 8632},
+		{';', 8633}, {'E', 8634}, {'S', 8635}, {'l', 8636}, {'a', 8637}, {'b', 8638}, {'p', 8639}, {'u', 8640},
+		{';', 8641}, {'E', 8642}, {'S', 8643}, {'T', 8644}, {';', 8645}, {'E', 8646}, {'a', 8647}, {'l', 8648},
+		{'d', 8649}, {'B', 8650}, {'u', 8651}, {'o', 8652}, {';', 8653}, {'e', 8654}, {'n', 8655}, {'a', 8656},
+		{'n', 8657}, {'d', 8658}, {'l', 8659}, {'e', 8660}, {'l', 8661}, {'u', 8662}, {'r', 8663}, {'a', 8664},
+		{'e', 8665}, {'g', 8666}, {'B', 8667}, {'e', 8668}, {'c', 8669}, {'o', 8670}, {'t', 8671}, {'l', 8672},
+		{'V', 8673}, {'e', 8674}, {'o', 8675}, {';', 8676}, {'B', 8677}, {'s', 8678}, {';', 8679}, {';', 8680},
+		{'r', 8681}, {'r', 8682}, {'r', 8683}, {'w', 8684}, {';', 8685}, {'s', 8686}, {'t', 8687}, {'s', 8688},
+		{';', 8689}, {';', 8690}, {'a', 8691}, {'n', 8692}, {'d', 8693}, {'a', 8694}, {'u', 8695}, {'t', 8696},
+		{'h', 8697}, {'A', 8698}, {';', 8699}, {'u', 8700}, {';', 8701}, {'r', 8702}, {'r', 8703}, {';', 8704},
+		{'e', 8705}, {'a', 8706}, {'d', 8707}, {'c', 8708}, {'a', 8709}, {';', 8710}, {'d', 8711}, {'u', 8712},
+		{'e', 8713}, {';', 8714}, {'l', 8715}, {'l', 8716}, {'r', 8717}, {';', 8718}, {';', 8719}, {';', 8720},
+		{';', 8721}, {'e', 8722}, {'i', 8723}, {';', 8724}, {'e', 8725}, {'d', 8726}, {'o', 8727}, {'l', 8728},
+		{'r', 8729}, {';', 8730}, {';', 8731}, {'e', 8732}, {'e', 8733}, {'o', 8734}, {'r', 8735}, {'i', 8736},
+		{'d', 8737}, {'u', 8738}, {'o', 8739}, {'r', 8740}, {'r', 8741}, {'u', 8742}, {'m', 8743}, {'o', 8744},
+		{'h', 8745}, {'r', 8746}, {'f', 8747}, {'g', 8748}, {'l', 8749}, {'r', 8750}, {';', 8751}, {'r', 8752},
+		{';', 8753}, {'l', 8754}, {'f', 8755}, {'g', 8756}, {';', 8757}, {';', 8758}, {';', 8759}, {'a', 8760},
+		{'n', 8761}, {'r', 8762}, {'r', 8763}, {'r', 8764}, {'r', 8765}, {'i', 8766}, {'q', 8767}, {'e', 8768},
+		{'u', 8769}, {'i', 8770}, {';', 8771}, {';', 8772}, {';', 8773}, {';', 8774}, {'n', 8775}, {'t', 8776},
+		{'h', 8777}, {'a', 8778}, {'t', 8779}, {';', 8780}, {'f', 8781}, {'g', 8782}, {'q', 8783}, {'q', 8784},
+		{'l', 8785}, {'r', 8786}, {'n', 8787}, {'r', 8788}, {'t', 8789}, {'u', 8790}, {'o', 8791}, {'r', 8792},
+		{'w', 8793}, {'c', 8794}, {'o', 8795}, {'r', 8796}, {'i', 8797}, {'D', 8798}, {'r', 8799}, {'r', 8800},
+		{'r', 8801}, {'g', 8802}, {'e', 8803}, {'f', 8804}, {'g', 8805}, {'r', 8806}, {'e', 8807}, {'w', 8808},
+		{'A', 8809}, {'a', 8810}, {';', 8811}, {'r', 8812}, {'t', 8813}, {'e', 8814}, {'o', 8815}, {'V', 8816},
+		{'t', 8817}, {';', 8818}, {'u', 8819}, {'l', 8820}, {';', 8821}, {'q', 8822}, {'a', 8823}, {';', 8824},
+		{'L', 8825}, {'q', 8826}, {'e', 8827}, {'E', 8828}, {';', 8829}, {';', 8830}, {'n', 8831}, {';', 8832},
+		{';', 8833}, {'m', 8834}, {'e', 8835}, {'c', 8836}, {';', 8837}, {'h', 8838}, {'a', 8839}, {'e', 8840},
+		{'o', 8841}, {'o', 8842}, {'t', 8843}, {';', 8844}, {'r', 8845}, {';', 8846}, {'B', 8847}, {'E', 8848},
+		{'c', 8849}, {'t', 8850}, {';', 8851}, {'B', 8852}, {'r', 8853}, {'o', 8854}, {'a', 8855}, {'l', 8856},
+		{'a', 8857}, {'w', 8858}, {'t', 8859}, {'o', 8860}, {'w', 8861}, {'t', 8862}, {'o', 8863}, {'o', 8864},
+		{'r', 8865}, {'u', 8866}, {'k', 8867}, {'S', 8868}, {'T', 8869}, {'r', 8870}, {'s', 8871}, {'p', 8872},
+		{';', 8873}, {'t', 8874}, {'e', 8875}, {'u', 8876}, {'l', 8877}, {'e', 8878}, {'s', 8879}, {'a', 8880},
+		{'l', 8881}, {'u', 8882}, {';', 8883}, {'g', 8884}, {';', 8885}, {'e', 8886}, {'E', 8887}, {';', 8888},
+		{'a', 8889}, {'s', 8890}, {'q', 8891}, {'l', 8892}, {'e', 8893}, {'n', 8894}, {'s', 8895}, {'e', 8896},
+		{'a', 8897}, {'q', 8898}, {'l', 8899}, {'i', 8900}, {'q', 8901}, {'l', 8902}, {'E', 8903}, {'e', 8904},
+		{'a', 8905}, {'b', 8906}, {'t', 8907}, {'s', 8908}, {'e', 8909}, {'l', 8910}, {'t', 8911}, {'e', 8912},
+		{';', 8913}, {'n', 8914}, {'i', 8915}, {'i', 8916}, {'a', 8917}, {'r', 8918}, {'f', 8919}, {';', 8920},
+		{'r', 8921}, {'V', 8922}, {'t', 8923}, {'w', 8924}, {'o', 8925}, {'e', 8926}, {'e', 8927}, {'c', 8928},
+		{'r', 8929}, {'a', 8930}, {';', 8931}, {'o', 8932}, {'o', 8933}, {'r', 8934}, {';', 8935}, {'e', 8936},
+		{';', 8937}, {'E', 8938}, {'e', 8939}, {'l', 8940}, {'t', 8941}, {'e', 8942}, {'l', 8943}, {'a', 8944},
+		{';', 8945}, {'e', 8946}, {'r', 8947}, {'m', 8948}, {'o', 8949}, {'r', 8950}, {';', 8951}, {'r', 8952},
+		{'e', 8953}, {'e', 8954}, {'c', 8955}, {'o', 8956}, {'p', 8957}, {';', 8958}, {'e', 8959}, {'e', 8960},
+		{'i', 8961}, {'f', 8962}, {'g', 8963}, {'s', 8964}, {'g', 8965}, {'w', 8966}, {'e', 8967}, {'i', 8968},
+		{';', 8969}, {'q', 8970}, {'w', 8971}, {'o', 8972}, {'l', 8973}, {'o', 8974}, {'p', 8975}, {'w', 8976},
+		{'o', 8977}, {'p', 8978}, {'i', 8979}, {'e', 8980}, {'w', 8981}, {'t', 8982}, {'o', 8983}, {'t', 8984},
+		{'h', 8985}, {'e', 8986}, {'r', 8987}, {'r', 8988}, {'e', 8989}, {'t', 8990}, {'h', 8991}, {'i', 8992},
+		{'d', 8993}, {'u', 8994}, {'o', 8995}, {'p', 8996}, {'r', 8997}, {'r', 8998}, {'m', 8999}, {';', 9000},
+		{'l', 9001}, {'s', 9002}, {'l', 9003}, {';', 9004}, {';', 9005}, {'e', 9006}, {'t', 9007}, {'r', 9008},
+		{'a', 9009}, {'t', 9010}, {'h', 9011}, {';', 9012}, {'q', 9013}, {';', 9014}, {'q', 9015}, {'e', 9016},
+		{'i', 9017}, {';', 9018}, {'e', 9019}, {'o', 9020}, {'b', 9021}, {'t', 9022}, {'a', 9023}, {'i', 9024},
+		{'u', 9025}, {'t', 9026}, {'u', 9027}, {'a', 9028}, {'l', 9029}, {';', 9030}, {'I', 9031}, {'o', 9032},
+		{'o', 9033}, {'h', 9034}, {';', 9035}, {'t', 9036}, {'h', 9037}, {'r', 9038}, {'e', 9039}, {';', 9040},
+		{'r', 9041}, {'l', 9042}, {'r', 9043}, {'V', 9044}, {'c', 9045}, {'r', 9046}, {'e', 9047}, {'o', 9048},
+		{'a', 9049}, {'l', 9050}, {'u', 9051}, {'l', 9052}, {'e', 9053}, {'u', 9054}, {'r', 9055}, {'q', 9056},
+		{'e', 9057}, {'a', 9058}, {'s', 9059}, {'k', 9060}, {'t', 9061}, {'c', 9062}, {'c', 9063}, {'r', 9064},
+		{'w', 9065}, {'o', 9066}, {';', 9067}, {'a', 9068}, {'q', 9069}, {'t', 9070}, {'o', 9071}, {'a', 9072},
+		{';', 9073}, {'w', 9074}, {'t', 9075}, {';', 9076}, {'l', 9077}, {';', 9078}, {'A', 9079}, {'w', 9080},
+		{';', 9081}, {'a', 9082}, {'w', 9083}, {'w', 9084}, {'o', 9085}, {'m', 9086}, {'S', 9087}, {'p', 9088},
+		{'h', 9089}, {'G', 9090}, {'s', 9091}, {'a', 9092}, {'i', 9093}, {';', 9094}, {'a', 9095}, {'l', 9096},
+		{'a', 9097}, {'s', 9098}, {'n', 9099}, {'d', 9100}, {'m', 9101}, {'l', 9102}, {'r', 9103}, {'q', 9104},
+		{'t', 9105}, {'L', 9106}, {'u', 9107}, {'a', 9108}, {'m', 9109}, {'g', 9110}, {'e', 9111}, {'r', 9112},
+		{'l', 9113}, {'u', 9114}, {'a', 9115}, {'l', 9116}, {'u', 9117}, {';', 9118}, {'q', 9119}, {';', 9120},
+		{'r', 9121}, {'l', 9122}, {'e', 9123}, {'i', 9124}, {';', 9125}, {';', 9126}, {'E', 9127}, {';', 9128},
+		{'t', 9129}, {'b', 9130}, {'l', 9131}, {'c', 9132}, {';', 9133}, {'t', 9134}, {'a', 9135}, {'e', 9136},
+		{'o', 9137}, {';', 9138}, {'r', 9139}, {';', 9140}, {'B', 9141}, {'E', 9142}, {'c', 9143}, {'t', 9144},
+		{';', 9145}, {'B', 9146}, {'r', 9147}, {'w', 9148}, {'w', 9149}, {'o', 9150}, {'c', 9151}, {'q', 9152},
+		{'t', 9153}, {';', 9154}, {'E', 9155}, {';', 9156}, {';', 9157}, {'l', 9158}, {'s', 9159}, {'r', 9160},
+		{';', 9161}, {'w', 9162}, {'o', 9163}, {'a', 9164}, {';', 9165}, {';', 9166}, {'e', 9167}, {'w', 9168},
+		{';', 9169}, {';', 9170}, {'d', 9171}, {'l', 9172}, {'r', 9173}, {'f', 9174}, {'g', 9175}, {'t', 9176},
+		{'h', 9177}, {';', 9178}, {'e', 9179}, {'s', 9180}, {'f', 9181}, {'g', 9182}, {';', 9183}, {';', 9184},
+		{'w', 9185}, {';', 9186}, {'w', 9187}, {';', 9188}, {'s', 9189}, {'w', 9190}, {'o', 9191}, {'g', 9192},
+		{'s', 9193}, {';', 9194}, {'a', 9195}, {'w', 9196}, {';', 9197}, {'t', 9198}, {';', 9199}, {'o', 9200},
+		{'o', 9201}, {'l', 9202}, {';', 9203}, {'e', 9204}, {'t', 9205}, {'l', 9206}, {'o', 9207}, {'p', 9208},
+		{'w', 9209}, {'o', 9210}, {'o', 9211}, {'o', 9212}, {'e', 9213}, {';', 9214}, {';', 9215}, {'o', 9216},
+		{'q', 9217}, {';', 9218}, {'e', 9219}, {'r', 9220}, {'r', 9221}, {';', 9222}, {'t', 9223}, {';', 9224},
+		{';', 9225}, {'f', 9226}, {'g', 9227}, {'n', 9228}, {'u', 9229}, {'l', 9230}, {'e', 9231}, {'l', 9232},
+		{'s', 9233}, {'t', 9234}, {';', 9235}, {'b', 9236}, {'v', 9237}, {'d', 9238}, {'n', 9239}, {'w', 9240},
+		{'w', 9241}, {'t', 9242}, {'A', 9243}, {'R', 9244}, {'t', 9245}, {'o', 9246}, {';', 9247}, {'r', 9248},
+		{'B', 9249}, {'o', 9250}, {'e', 9251}, {'t', 9252}, {';', 9253}, {'B', 9254}, {'c', 9255}, {'r', 9256},
+		{'r', 9257}, {'S', 9258}, {'a', 9259}, {'l', 9260}, {'s', 9261}, {'a', 9262}, {';', 9263}, {'u', 9264},
+		{';', 9265}, {';', 9266}, {';', 9267}, {'e', 9268}, {'A', 9269}, {'k', 9270}, {'t', 9271}, {';', 9272},
+		{'B', 9273}, {';', 9274}, {'r', 9275}, {'r', 9276}, {'u', 9277}, {'o', 9278}, {'r', 9279}, {'r', 9280},
+		{';', 9281}, {'e', 9282}, {';', 9283}, {'r', 9284}, {';', 9285}, {'r', 9286}, {';', 9287}, {';', 9288},
+		{'w', 9289}, {'S', 9290}, {'p', 9291}, {'a', 9292}, {'i', 9293}, {'r', 9294}, {';', 9295}, {'c', 9296},
+		{'c', 9297}, {'l', 9298}, {'E', 9299}, {'t', 9300}, {';', 9301}, {'t', 9302}, {'e', 9303}, {'p', 9304},
+		{'e', 9305}, {';', 9306}, {'u', 9307}, {'e', 9308}, {'e', 9309}, {'a', 9310}, {'n', 9311}, {'e', 9312},
+		{'l', 9313}, {'t', 9314}, {'s', 9315}, {';', 9316}, {'a', 9317}, {'n', 9318}, {'d', 9319}, {'a', 9320},
+		{'u', 9321}, {';', 9322}, {'e', 9323}, {';', 9324}, {'s', 9325}, {'q', 9326}, {';', 9327}, {'r', 9328},
+		{'i', 9329}, {'k', 9330}, {'A', 9331}, {'c', 9332}, {'c', 9333}, {'r', 9334}, {';', 9335}, {'a', 9336},
+		{'q', 9337}, {'t', 9338}, {'o', 9339}, {'a', 9340}, {';', 9341}, {';', 9342}, {';', 9343}, {'w', 9344},
+		{'t', 9345}, {'u', 9346}, {';', 9347}, {'E', 9348}, {'q', 9349}, {';', 9350}, {'i', 9351}, {'o', 9352},
+		{';', 9353}, {'w', 9354}, {'t', 9355}, {';', 9356}, {'n', 9357}, {'o', 9358}, {'e', 9359}, {'i', 9360},
+		{'t', 9361}, {'h', 9362}, {';', 9363}, {'t', 9364}, {';', 9365}, {';', 9366}, {'t', 9367}, {'h', 9368},
+		{';', 9369}, {'n', 9370}, {';', 9371}, {';', 9372}, {'s', 9373}, {'o', 9374}, {'a', 9375}, {';', 9376},
+		{'r', 9377}, {';', 9378}, {';', 9379}, {'w', 9380}, {'w', 9381}, {';', 9382}, {'q', 9383}, {';', 9384},
+		{'e', 9385}, {';', 9386}, {'w', 9387}, {';', 9388}, {'s', 9389}, {'o', 9390}, {'w', 9391}, {'w', 9392},
+		{'s', 9393}, {'n', 9394}, {';', 9395}, {'q', 9396}, {'o', 9397}, {'r', 9398}, {';', 9399}, {'t', 9400},
+		{'h', 9401}, {'t', 9402}, {'r', 9403}, {'e', 9404}, {';', 9405}, {';', 9406}, {'e', 9407}, {'e', 9408},
+		{'l', 9409}, {'e', 9410}, {'e', 9411}, {'t', 9412}, {';', 9413}, {';', 9414}, {'A', 9415}, {'r', 9416},
+		{'i', 9417}, {'A', 9418}, {'w', 9419}, {'o', 9420}, {'a', 9421}, {'w', 9422}, {'c', 9423}, {'o', 9424},
+		{'a', 9425}, {'t', 9426}, {';', 9427}, {'B', 9428}, {'e', 9429}, {'q', 9430}, {'r', 9431}, {'S', 9432},
+		{'s', 9433}, {'l', 9434}, {'a', 9435}, {'t', 9436}, {'r', 9437}, {'e', 9438}, {'o', 9439}, {'a', 9440},
+		{';', 9441}, {';', 9442}, {'a', 9443}, {'r', 9444}, {';', 9445}, {';', 9446}, {'r', 9447}, {'r', 9448},
+		{'r', 9449}, {';', 9450}, {'p', 9451}, {'a', 9452}, {'c', 9453}, {'n', 9454}, {'e', 9455}, {'e', 9456},
+		{'a', 9457}, {';', 9458}, {'q', 9459}, {'e', 9460}, {'E', 9461}, {';', 9462}, {';', 9463}, {';', 9464},
+		{'B', 9465}, {'E', 9466}, {'a', 9467}, {'r', 9468}, {'s', 9469}, {'l', 9470}, {'t', 9471}, {'n', 9472},
+		{'e', 9473}, {';', 9474}, {'E', 9475}, {'e', 9476}, {'l', 9477}, {'t', 9478}, {'e', 9479}, {'l', 9480},
+		{'a', 9481}, {'Q', 9482}, {';', 9483}, {'u', 9484}, {'i', 9485}, {'b', 9486}, {'e', 9487}, {'r', 9488},
+		{'k', 9489}, {'t', 9490}, {';', 9491}, {'B', 9492}, {'r', 9493}, {'u', 9494}, {'o', 9495}, {'r', 9496},
+		{'r', 9497}, {';', 9498}, {'i', 9499}, {'a', 9500}, {'q', 9501}, {'u', 9502}, {'s', 9503}, {'w', 9504},
+		{';', 9505}, {'o', 9506}, {';', 9507}, {'w', 9508}, {'f', 9509}, {'g', 9510}, {';', 9511}, {'t', 9512},
+		{';', 9513}, {';', 9514}, {'t', 9515}, {';', 9516}, {';', 9517}, {'n', 9518}, {'r', 9519}, {'r', 9520},
+		{';', 9521}, {';', 9522}, {';', 9523}, {'q', 9524}, {'n', 9525}, {';', 9526}, {'n', 9527}, {'s', 9528},
+		{';', 9529}, {';', 9530}, {';', 9531}, {';', 9532}, {'w', 9533}, {'o', 9534}, {';', 9535}, {'t', 9536},
+		{'i', 9537}, {'I', 9538}, {'Q', 9539}, {'C', 9540}, {';', 9541}, {'e', 9542}, {';', 9543}, {';', 9544},
+		{'e', 9545}, {'r', 9546}, {'r', 9547}, {'g', 9548}, {'r', 9549}, {';', 9550}, {'w', 9551}, {'r', 9552},
+		{';', 9553}, {'t', 9554}, {'r', 9555}, {'r', 9556}, {'o', 9557}, {'a', 9558}, {';', 9559}, {'u', 9560},
+		{'e', 9561}, {'q', 9562}, {';', 9563}, {';', 9564}, {'l', 9565}, {';', 9566}, {'r', 9567}, {'t', 9568},
+		{'r', 9569}, {'r', 9570}, {'l', 9571}, {';', 9572}, {';', 9573}, {'o', 9574}, {'o', 9575}, {'a', 9576},
+		{'c', 9577}, {'e', 9578}, {'S', 9579}, {'a', 9580}, {';', 9581}, {'l', 9582}, {'u', 9583}, {'r', 9584},
+		{'q', 9585}, {'a', 9586}, {'q', 9587}, {'l', 9588}, {'G', 9589}, {'s', 9590}, {';', 9591}, {'E', 9592},
+		{'t', 9593}, {';', 9594}, {'B', 9595}, {'E', 9596}, {'q', 9597}, {'t', 9598}, {';', 9599}, {'E', 9600},
+		{';', 9601}, {';', 9602}, {'l', 9603}, {'u', 9604}, {'a', 9605}, {'u', 9606}, {'r', 9607}, {'t', 9608},
+		{'r', 9609}, {'e', 9610}, {'o', 9611}, {'a', 9612}, {';', 9613}, {'a', 9614}, {'r', 9615}, {';', 9616},
+		{';', 9617}, {'o', 9618}, {'l', 9619}, {'u', 9620}, {'a', 9621}, {';', 9622}, {';', 9623}, {'r', 9624},
+		{'n', 9625}, {'t', 9626}, {'h', 9627}, {';', 9628}, {';', 9629}, {'s', 9630}, {'r', 9631}, {'o', 9632},
+		{';', 9633}, {';', 9634}, {'s', 9635}, {';', 9636}, {';', 9637}, {'w', 9638}, {';', 9639}, {'a', 9640},
+		{'n', 9641}, {'u', 9642}, {'o', 9643}, {'A', 9644}, {'g', 9645}, {'r', 9646}, {'o', 9647}, {'h', 9648},
+		{'r', 9649}, {';', 9650}, {';', 9651}, {'o', 9652}, {';', 9653}, {';', 9654}, {'r', 9655}, {'r', 9656},
+		{'a', 9657}, {';', 9658}, {'u', 9659}, {';', 9660}, {'o', 9661}, {';', 9662}, {';', 9663}, {';', 9664},
+		{';', 9665}, {'w', 9666}, {'w', 9667}, {'c', 9668}, {'e', 9669}, {';', 9670}, {'p', 9671}, {'t', 9672},
+		{'B', 9673}, {'a', 9674}, {';', 9675}, {'u', 9676}, {'r', 9677}, {'u', 9678}, {';', 9679}, {'r', 9680},
+		{';', 9681}, {'q', 9682}, {';', 9683}, {'a', 9684}, {'q', 9685}, {'u', 9686}, {';', 9687}, {'E', 9688},
+		{'q', 9689}, {';', 9690}, {'o', 9691}, {'l', 9692}, {'m', 9693}, {'i', 9694}, {';', 9695}, {'o', 9696},
+		{'t', 9697}, {'r', 9698}, {'r', 9699}, {'l', 9700}, {';', 9701}, {'n', 9702}, {';', 9703}, {'a', 9704},
+		{'l', 9705}, {';', 9706}, {';', 9707}, {';', 9708}, {'t', 9709}, {';', 9710}, {'o', 9711}, {'w', 9712},
+		{';', 9713}, {';', 9714}, {'l', 9715}, {'t', 9716}, {'o', 9717}, {'n', 9718}, {'c', 9719}, {'r', 9720},
+		{'o', 9721}, {'w', 9722}, {'t', 9723}, {'o', 9724}, {'r', 9725}, {';', 9726}, {';', 9727}, {'r', 9728},
+		{'a', 9729}, {'w', 9730}, {';', 9731}, {';', 9732}, {'e', 9733}, {';', 9734}, {'a', 9735}, {'e', 9736},
+		{'a', 9737}, {'l', 9738}, {'a', 9739}, {';', 9740}, {'a', 9741}, {'e', 9742}, {'u', 9743}, {'r', 9744},
+		{'u', 9745}, {'a', 9746}, {'q', 9747}, {'u', 9748}, {'t', 9749}, {';', 9750}, {';', 9751}, {'u', 9752},
+		{'w', 9753}, {';', 9754}, {';', 9755}, {';', 9756}, {';', 9757}, {';', 9758}, {'l', 9759}, {';', 9760},
+		{';', 9761}, {'w', 9762}, {';', 9763}, {'D', 9764}, {'e', 9765}, {'t', 9766}, {'t', 9767}, {'u', 9768},
+		{'a', 9769}, {'w', 9770}, {';', 9771}, {'A', 9772}, {'w', 9773}, {';', 9774}, {'e', 9775}, {'r', 9776},
+		{';', 9777}, {';', 9778}, {'c', 9779}, {'r', 9780}, {'r', 9781}, {';', 9782}, {'l', 9783}, {'l', 9784},
+		{'a', 9785}, {'a', 9786}, {';', 9787}, {'a', 9788}, {'l', 9789}, {'u', 9790}, {'a', 9791}, {'e', 9792},
+		{'m', 9793}, {';', 9794}, {';', 9795}, {';', 9796}, {';', 9797}, {'g', 9798}, {'e', 9799}, {'o', 9800},
+		{'t', 9801}, {'l', 9802}, {';', 9803}, {'r', 9804}, {';', 9805}, {';', 9806}, {'e', 9807}, {'e', 9808},
+		{';', 9809}, {';', 9810}, {';', 9811}, {';', 9812}, {'t', 9813}, {'l', 9814}, {'l', 9815}, {';', 9816},
+		{'a', 9817}, {'l', 9818}, {';', 9819}, {';', 9820}, {'r', 9821}, {';', 9822}, {'u', 9823}, {'e', 9824},
+		{';', 9825}, {'r', 9826}, {';', 9827}, {';', 9828}, {'e', 9829}, {';', 9830}, {';', 9831}, {'l', 9832},
+		{';', 9833}, {'a', 9834}, {'r', 9835}, {';', 9836}, {'o', 9837}, {'r', 9838}, {';', 9839}, {'l', 9840},
+		{'I', 9841}, {'w', 9842}, {';', 9843}, {';', 9844}, {'n', 9845}, {';', 9846}, {'t', 9847}, {'e', 9848},
+		{'g', 9849}, {'r', 9850}, {'a', 9851}, {'l', 9852}, {';', 9853},
+	},
+	values: []string{
+		"\u00c6", "&", "\u00c1", "\u0102", "\u00c2", "\u0410", "\U0001d504", "\u00c0",
+		"\u0391", "\u0100", "\u2a53", "\u0104", "\U0001d538", "\u2061", "\u00c5", "\U0001d49c",
+		"\u2254", "\u00c3", "\u00c4", "\u2216", "\u2ae7", "\u2306", "\u0411", "\u2235",
+		"\u212c", "\u0392", "\U0001d505", "\U0001d539", "\u02d8", "\u224e", "\u0427", "\u00a9",
+		"\u0106", "\u22d2", "\u2145", "\u212d", "\u010c", "\u00c7", "\u0108", "\u2230",
+		"\u010a", "\u00b8", "\u00b7", "\u03a7", "\u2299", "\u2296", "\u2295", "\u2297",
+		"\u2232", "\u201d", "\u2019", "\u2237", "\u2a74", "\u2261", "\u222f", "\u222e",
+		"\u2102", "\u2210", "\u2233", "\u2a2f", "\U0001d49e", "\u22d3", "\u224d", "\u2911",
+		"\u0402", "\u0405", "\u040f", "\u2021", "\u21a1", "\u2ae4", "\u010e", "\u0414",
+		"\u2207", "\u0394", "\U0001d507", "\u00b4", "\u02d9", "\u02dd", "`", "\u02dc",
+		"\u22c4", "\u2146", "\U0001d53b", "\u00a8", "\u20dc", "\u2250", "\u21d3", "\u21d0",
+		"\u21d4", "\u27f8", "\u27fa", "\u27f9", "\u21d2", "\u22a8", "\u21d1", "\u21d5",
+		"\u2225", "\u2193", "\u2913", "\u21f5", "\u0311", "\u2950", "\u295e", "\u21bd",
+		"\u2956", "\u295f", "\u21c1", "\u2957", "\u22a4", "\u21a7", "\U0001d49f", "\u0110",
+		"\u014a", "\u00d0", "\u00c9", "\u011a", "\u00ca", "\u042d", "\u0116", "\U0001d508",
+		"\u00c8", "\u2208", "\u0112", "\u25fb", "\u25ab", "\u0118", "\U0001d53c", "\u0395",
+		"\u2a75", "\u2242", "\u21cc", "\u2130", "\u2a73", "\u0397", "\u00cb", "\u2203",
+		"\u2147", "\u0424", "\U0001d509", "\u25fc", "\u25aa", "\U0001d53d", "\u2200", "\u2131",
+		"\u0403", ">", "\u0393", "\u03dc", "\u011e", "\u0122", "\u011c", "\u0413",
+		"\u0120", "\U0001d50a", "\u22d9", "\U0001d53e", "\u2265", "\u22db", "\u2267", "\u2aa2",
+		"\u2277", "\u2a7e", "\u2273", "\U0001d4a2", "\u226b", "\u042a", "\u02c7", "^",
+		"\u0124", "\u210c", "\u210b", "\u210d", "\u2500", "\u0126", "\u224f", "\u0415",
+		"\u0132", "\u0401", "\u00cd", "\u00ce", "\u0418", "\u0130", "\u2111", "\u00cc",
+		"\u012a", "\u2148", "\u222c", "\u222b", "\u22c2", "\u2063", "\u2062", "\u012e",
+		"\U0001d540", "\u0399", "\u2110", "\u0128", "\u0406", "\u00cf", "\u0134", "\u0419",
+		"\U0001d50d", "\U0001d541", "\U0001d4a5", "\u0408", "\u0404", "\u0425", "\u040c", "\u039a",
+		"\u0136", "\u041a", "\U0001d50e", "\U0001d542", "\U0001d4a6", "\u0409", "<", "\u0139",
+		"\u039b", "\u27ea", "\u2112", "\u219e", "\u013d", "\u013b", "\u041b", "\u27e8",
+		"\u2190", "\u21e4", "\u21c6", "\u2308", "\u27e6", "\u2961", "\u21c3", "\u2959",
+		"\u230a", "\u2194", "\u294e", "\u22a3", "\u21a4", "\u295a", "\u22b2", "\u29cf",
+		"\u22b4", "\u2951", "\u2960", "\u21bf", "\u2958", "\u21bc", "\u2952", "\u22da",
+		"\u2266", "\u2276", "\u2aa1", "\u2a7d", "\u2272", "\U0001d50f", "\u22d8", "\u21da",
+		"\u013f", "\u27f5", "\u27f7", "\u27f6", "\U0001d543", "\u2199", "\u2198", "\u21b0",
+		"\u0141", "\u226a", "\u2905", "\u041c", "\u205f", "\u2133", "\U0001d510", "\u2213",
+		"\U0001d544", "\u039c", "\u040a", "\u0143", "\u0147", "\u0145", "\u041d", "\u200b",
+		"\n", "\U0001d511", "\u2060", "\u00a0", "\u2115", "\u2aec", "\u2262", "\u226d",
+		"\u2226", "\u2209", "\u2260", "\u2242\u0338", "\u2204", "\u226f", "\u2271", "\u2267\u0338",
+		"\u226b\u0338", "\u2279", "\u2a7e\u0338", "\u2275", "\u224e\u0338", "\u224f\u0338", "\u22ea", "\u29cf\u0338",
+		"\u22ec", "\u226e", "\u2270", "\u2278", "\u226a\u0338", "\u2a7d\u0338", "\u2274", "\u2aa2\u0338",
+		"\u2aa1\u0338", "\u2280", "\u2aaf\u0338", "\u22e0", "\u220c", "\u22eb", "\u29d0\u0338", "\u22ed",
+		"\u228f\u0338", "\u22e2", "\u2290\u0338", "\u22e3", "\u2282\u20d2", "\u2288", "\u2281", "\u2ab0\u0338",
+		"\u22e1", "\u227f\u0338", "\u2283\u20d2", "\u2289", "\u2241", "\u2244", "\u2247", "\u2249",
+		"\u2224", "\U0001d4a9", "\u00d1", "\u039d", "\u0152", "\u00d3", "\u00d4", "\u041e",
+		"\u0150", "\U0001d512", "\u00d2", "\u014c", "\u03a9", "\u039f", "\U0001d546", "\u201c",
+		"\u2018", "\u2a54", "\U0001d4aa", "\u00d8", "\u00d5", "\u2a37", "\u00d6", "\u203e",
+		"\u23de", "\u23b4", "\u23dc", "\u2202", "\u041f", "\U0001d513", "\u03a6", "\u03a0",
+		"\u00b1", "\u2119", "\u2abb", "\u227a", "\u2aaf", "\u227c", "\u227e", "\u2033",
+		"\u220f", "\u221d", "\U0001d4ab", "\u03a8", "\"", "\U0001d514", "\u211a", "\U0001d4ac",
+		"\u2910", "\u00ae", "\u0154", "\u27eb", "\u21a0", "\u2916", "\u0158", "\u0156",
+		"\u0420", "\u211c", "\u220b", "\u21cb", "\u296f", "\u03a1", "\u27e9", "\u2192",
+		"\u21e5", "\u21c4", "\u2309", "\u27e7", "\u295d", "\u21c2", "\u2955", "\u230b",
+		"\u22a2", "\u21a6", "\u295b", "\u22b3", "\u29d0", "\u22b5", "\u294f", "\u295c",
+		"\u21be", "\u2954", "\u21c0", "\u2953", "\u211d", "\u2970", "\u21db", "\u211b",
+		"\u21b1", "\u29f4", "\u0429", "\u0428", "\u042c", "\u015a", "\u2abc", "\u0160",
+		"\u015e", "\u015c", "\u0421", "\U0001d516", "\u2191", "\u03a3", "\u2218", "\U0001d54a",
+		"\u221a", "\u25a1", "\u2293", "\u228f", "\u2291", "\u2290", "\u2292", "\u2294",
+		"\U0001d4ae", "\u22c6", "\u22d0", "\u2286", "\u227b", "\u2ab0", "\u227d", "\u227f",
+		"\u2211", "\u22d1", "\u2283", "\u2287", "\u00de", "\u2122", "\u040b", "\u0426",
+		"\t", "\u03a4", "\u0164", "\u0162", "\u0422", "\U0001d517", "\u2234", "\u0398",
+		"\u205f\u200a", "\u2009", "\u223c", "\u2243", "\u2245", "\u2248", "\U0001d54b", "\u20db",
+		"\U0001d4af", "\u0166", "\u00da", "\u219f", "\u2949", "\u040e", "\u016c", "\u00db",
+		"\u0423", "\u0170", "\U0001d518", "\u00d9", "\u016a", "_", "\u23df", "\u23b5",
+		"\u23dd", "\u22c3", "\u228e", "\u0172", "\U0001d54c", "\u2912", "\u21c5", "\u2195",
+		"\u296e", "\u22a5", "\u21a5", "\u2196", "\u2197", "\u03d2", "\u03a5", "\u016e",
+		"\U0001d4b0", "\u0168", "\u00dc", "\u22ab", "\u2aeb", "\u0412", "\u22a9", "\u2ae6",
+		"\u22c1", "\u2016", "\u2223", "|", "\u2758", "\u2240", "\u200a", "\U0001d519",
+		"\U0001d54d", "\U0001d4b1", "\u22aa", "\u0174", "\u22c0", "\U0001d51a", "\U0001d54e", "\U0001d4b2",
+		"\U0001d51b", "\u039e", "\U0001d54f", "\U0001d4b3", "\u042f", "\u0407", "\u042e", "\u00dd",
+		"\u0176", "\u042b", "\U0001d51c", "\U0001d550", "\U0001d4b4", "\u0178", "\u0416", "\u0179",
+		"\u017d", "\u0417", "\u017b", "\u0396", "\u2128", "\u2124", "\U0001d4b5", "\u00e1",
+		"\u0103", "\u223e", "\u223e\u0333", "\u223f", "\u00e2", "\u0430", "\u00e6", "\U0001d51e",
+		"\u00e0", "\u2135", "\u03b1", "\u0101", "\u2a3f", "\u2227", "\u2a55", "\u2a5c",
+		"\u2a58", "\u2a5a", "\u2220", "\u29a4", "\u2221", "\u29a8", "\u29a9", "\u29aa",
+		"\u29ab", "\u29ac", "\u29ad", "\u29ae", "\u29af", "\u221f", "\u22be", "\u299d",
+		"\u2222", "\u237c", "\u0105", "\U0001d552", "\u2a70", "\u2a6f", "\u224a", "\u224b",
+		"'", "\u00e5", "\U0001d4b6", "*", "\u00e3", "\u00e4", "\u2a11", "\u2aed",
+		"\u224c", "\u03f6", "\u2035", "\u223d", "\u22cd", "\u22bd", "\u2305", "\u23b6",
+		"\u0431", "\u201e", "\u29b0", "\u03b2", "\u2136", "\u226c", "\U0001d51f", "\u25ef",
+		"\u2a00", "\u2a01", "\u2a02", "\u2a06", "\u2605", "\u25bd", "\u25b3", "\u2a04",
+		"\u290d", "\u29eb", "\u25b4", "\u25be", "\u25c2", "\u25b8", "\u2423", "\u2592",
+		"\u2591", "\u2593", "\u2588", "=\u20e5", "\u2261\u20e5", "\u2310", "\U0001d553", "\u22c8",
+		"\u2557", "\u2554", "\u2556", "\u2553", "\u2550", "\u2566", "\u2569", "\u2564",
+		"\u2567", "\u255d", "\u255a", "\u255c", "\u2559", "\u2551", "\u256c", "\u2563",
+		"\u2560", "\u256b", "\u2562", "\u255f", "\u29c9", "\u2555", "\u2552", "\u2510",
+		"\u250c", "\u2565", "\u2568", "\u252c", "\u2534", "\u229f", "\u229e", "\u22a0",
+		"\u255b", "\u2558", "\u2518", "\u2514", "\u2502", "\u256a", "\u2561", "\u255e",
+		"\u253c", "\u2524", "\u251c", "\u00a6", "\U0001d4b7", "\u204f", "\\", "\u29c5",
+		"\u27c8", "\u2022", "\u2aae", "\u0107", "\u2229", "\u2a44", "\u2a49", "\u2a4b",
+		"\u2a47", "\u2a40", "\u2229\ufe00", "\u2041", "\u2a4d", "\u010d", "\u00e7", "\u0109",
+		"\u2a4c", "\u2a50", "\u010b", "\u29b2", "\u00a2", "\U0001d520", "\u0447", "\u2713",
+		"\u03c7", "\u25cb", "\u29c3", "\u02c6", "\u2257", "\u21ba", "\u21bb", "\u24c8",
+		"\u229b", "\u229a", "\u229d", "\u2a10", "\u2aef", "\u29c2", "\u2663", ":",
+		",", "@", "\u2201", "\u2a6d", "\U0001d554", "\u2117", "\u21b5", "\u2717",
+		"\U0001d4b8", "\u2acf", "\u2ad1", "\u2ad0", "\u2ad2", "\u22ef", "\u2938", "\u2935",
+		"\u22de", "\u22df", "\u21b6", "\u293d", "\u222a", "\u2a48", "\u2a46", "\u2a4a",
+		"\u228d", "\u2a45", "\u222a\ufe00", "\u21b7", "\u293c", "\u22ce", "\u22cf", "\u00a4",
+		"\u2231", "\u232d", "\u2965", "\u2020", "\u2138", "\u2010", "\u290f", "\u010f",
+		"\u0434", "\u21ca", "\u2a77", "\u00b0", "\u03b4", "\u29b1", "\u297f", "\U0001d521",
+		"\u2666", "\u03dd", "\u22f2", "\u00f7", "\u22c7", "\u0452", "\u231e", "\u230d",
+		"$", "\U0001d555", "\u2251", "\u2238", "\u2214", "\u22a1", "\u231f", "\u230c",
+		"\U0001d4b9", "\u0455", "\u29f6", "\u0111", "\u22f1", "\u25bf", "\u29a6", "\u045f",
+		"\u27ff", "\u00e9", "\u2a6e", "\u011b", "\u2256", "\u00ea", "\u2255", "\u044d",
+		"\u0117", "\u2252", "\U0001d522", "\u2a9a", "\u00e8", "\u2a96", "\u2a98", "\u2a99",
+		"\u23e7", "\u2113", "\u2a95", "\u2a97", "\u0113", "\u2205", "\u2004", "\u2005",
+		"\u2003", "\u014b", "\u2002", "\u0119", "\U0001d556", "\u22d5", "\u29e3", "\u2a71",
+		"\u03b5", "\u03f5", "=", "\u225f", "\u2a78", "\u29e5", "\u2253", "\u2971",
+		"\u212f", "\u03b7", "\u00f0", "\u00eb", "\u20ac", "!", "\u0444", "\u2640",
+		"\ufb03", "\ufb00", "\ufb04", "\U0001d523", "\ufb01", "fj", "\u266d", "\ufb02",
+		"\u25b1", "\u0192", "\U0001d557", "\u22d4", "\u2ad9", "\u2a0d", "\u00bd", "\u2153",
+		"\u00bc", "\u2155", "\u2159", "\u215b", "\u2154", "\u2156", "\u00be", "\u2157",
+		"\u215c", "\u2158", "\u215a", "\u215d", "\u215e", "\u2044", "\u2322", "\U0001d4bb",
+		"\u2a8c", "\u01f5", "\u03b3", "\u2a86", "\u011f", "\u011d", "\u0433", "\u0121",
+		"\u2aa9", "\u2a80", "\u2a82", "\u2a84", "\u22db\ufe00", "\u2a94", "\U0001d524", "\u2137",
+		"\u0453", "\u2a92", "\u2aa5", "\u2aa4", "\u2269", "\u2a8a", "\u2a88", "\u22e7",
+		"\U0001d558", "\u210a", "\u2a8e", "\u2a90", "\u2aa7", "\u2a7a", "\u22d7", "\u2995",
+		"\u2a7c", "\u2978", "\u2269\ufe00", "\u044a", "\u2948", "\u21ad", "\u210f", "\u0125",
+		"\u2665", "\u2026", "\u22b9", "\U0001d525", "\u2925", "\u2926", "\u21ff", "\u223b",
+		"\u21a9", "\u21aa", "\U0001d559", "\u2015", "\U0001d4bd", "\u0127", "\u2043", "\u00ed",
+		"\u00ee", "\u0438", "\u0435", "\u00a1", "\U0001d526", "\u00ec", "\u2a0c", "\u222d",
+		"\u29dc", "\u2129", "\u0133", "\u012b", "\u0131", "\u22b7", "\u01b5", "\u2105",
+		"\u221e", "\u29dd", "\u22ba", "\u2a17", "\u2a3c", "\u0451", "\u012f", "\U0001d55a",
+		"\u03b9", "\u00bf", "\U0001d4be", "\u22f9", "\u22f5", "\u22f4", "\u22f3", "\u0129",
+		"\u0456", "\u00ef", "\u0135", "\u0439", "\U0001d527", "\u0237", "\U0001d55b", "\U0001d4bf",
+		"\u0458", "\u0454", "\u03ba", "\u03f0", "\u0137", "\u043a", "\U0001d528", "\u0138",
+		"\u0445", "\u045c", "\U0001d55c", "\U0001d4c0", "\u291b", "\u290e", "\u2a8b", "\u2962",
+		"\u013a", "\u29b4", "\u03bb", "\u2991", "\u2a85", "\u00ab", "\u291f", "\u291d",
+		"\u21ab", "\u2939", "\u2973", "\u21a2", "\u2aab", "\u2919", "\u2aad", "\u2aad\ufe00",
+		"\u290c", "\u2772", "{", "[", "\u298b", "\u298f", "\u298d", "\u013e",
+		"\u013c", "\u043b", "\u2936", "\u2967", "\u294b", "\u21b2", "\u2264", "\u21c7",
+		"\u22cb", "\u2aa8", "\u2a7f", "\u2a81", "\u2a83", "\u22da\ufe00", "\u2a93", "\u22d6",
+		"\u297c", "\U0001d529", "\u2a91", "\u296a", "\u2584", "\u0459", "\u296b", "\u25fa",
+		"\u0140", "\u23b0", "\u2268", "\u2a89", "\u2a87", "\u22e6", "\u27ec", "\u21fd",
+		"\u27fc", "\u21ac", "\u2985", "\U0001d55d", "\u2a2d", "\u2a34", "\u2217", "\u25ca",
+		"(", "\u2993", "\u296d", "\u200e", "\u22bf", "\u2039", "\U0001d4c1", "\u2a8d",
+		"\u2a8f", "\u201a", "\u0142", "\u2aa6", "\u2a79", "\u22c9", "\u2976", "\u2a7b",
+		"\u2996", "\u25c3", "\u294a", "\u2966", "\u2268\ufe00", "\u223a", "\u00af", "\u2642",
+		"\u2720", "\u25ae", "\u2a29", "\u043c", "\u2014", "\U0001d52a", "\u2127", "\u00b5",
+		"\u2af0", "\u2212", "\u2a2a", "\u2adb", "\u22a7", "\U0001d55e", "\U0001d4c2", "\u03bc",
+		"\u22b8", "\u22d9\u0338", "\u226b\u20d2", "\u21cd", "\u21ce", "\u22d8\u0338", "\u226a\u20d2", "\u21cf",
+		"\u22af", "\u22ae", "\u0144", "\u2220\u20d2", "\u2a70\u0338", "\u224b\u0338", "\u0149", "\u266e",
+		"\u2a43", "\u0148", "\u0146", "\u2a6d\u0338", "\u2a42", "\u043d", "\u2013", "\u21d7",
+		"\u2924", "\u2250\u0338", "\u2928", "\U0001d52b", "\u21ae", "\u2af2", "\u22fc", "\u22fa",
+		"\u045a", "\u2266\u0338", "\u219a", "\u2025", "\U0001d55f", "\u00ac", "\u22f9\u0338", "\u22f5\u0338",
+		"\u22f7", "\u22f6", "\u22fe", "\u22fd", "\u2afd\u20e5", "\u2202\u0338", "\u2a14", "\u219b",
+		"\u2933\u0338", "\u219d\u0338", "\U0001d4c3", "\u2284", "\u2ac5\u0338", "\u2285", "\u2ac6\u0338", "\u00f1",
+		"\u03bd", "#", "\u2116", "\u2007", "\u22ad", "\u2904", "\u224d\u20d2", "\u22ac",
+		"\u2265\u20d2", ">\u20d2", "\u29de", "\u2902", "\u2264\u20d2", "<\u20d2", "\u22b4\u20d2", "\u2903",
+		"\u22b5\u20d2", "\u223c\u20d2", "\u21d6", "\u2923", "\u2927", "\u00f3", "\u00f4", "\u043e",
+		"\u0151", "\u2a38", "\u29bc", "\u0153", "\u29bf", "\U0001d52c", "\u02db", "\u00f2",
+		"\u29c1", "\u29b5", "\u29be", "\u29bb", "\u29c0", "\u014d", "\u03c9", "\u03bf",
+		"\u29b6", "\U0001d560", "\u29b7", "\u29b9", "\u2228", "\u2a5d", "\u2134", "\u00aa",
+		"\u00ba", "\u22b6", "\u2a56", "\u2a57", "\u2a5b", "\u00f8", "\u2298", "\u00f5",
+		"\u2a36", "\u00f6", "\u233d", "\u00b6", "\u2af3", "\u2afd", "\u043f", "%",
+		".", "\u2030", "\u2031", "\U0001d52d", "\u03c6", "\u03d5", "\u260e", "\u03c0",
+		"\u03d6", "\u210e", "+", "\u2a23", "\u2a22", "\u2a25", "\u2a72", "\u2a26",
+		"\u2a27", "\u2a15", "\U0001d561", "\u00a3", "\u2ab3", "\u2ab7", "\u2ab9", "\u2ab5",
+		"\u22e8", "\u2032", "\u232e", "\u2312", "\u2313", "\u22b0", "\U0001d4c5", "\u03c8",
+		"\u2008", "\U0001d52e", "\U0001d562", "\u2057", "\U0001d4c6", "\u2a16", "?", "\u291c",
+		"\u2964", "\u223d\u0331", "\u0155", "\u29b3", "\u2992", "\u29a5", "\u00bb", "\u2975",
+		"\u2920", "\u2933", "\u291e", "\u2945", "\u2974", "\u21a3", "\u219d", "\u291a",
+		"\u2236", "\u2773", "}", "]", "\u298c", "\u298e", "\u2990", "\u0159",
+		"\u0157", "\u0440", "\u2937", "\u2969", "\u21b3", "\u25ad", "\u297d", "\U0001d52f",
+		"\u296c", "\u03c1", "\u03f1", "\u21c9", "\u22cc", "\u02da", "\u200f", "\u23b1",
+		"\u2aee", "\u27ed", "\u21fe", "\u2986", "\U0001d563", "\u2a2e", "\u2a35", ")",
+		"\u2994", "\u2a12", "\u203a", "\U0001d4c7", "\u22ca", "\u25b9", "\u29ce", "\u2968",
+		"\u211e", "\u015b", "\u2ab4", "\u2ab8", "\u0161", "\u015f", "\u015d", "\u2ab6",
+		"\u2aba", "\u22e9", "\u2a13", "\u0441", "\u22c5", "\u2a66", "\u21d8", "\u00a7",
+		";", "\u2929", "\u2736", "\U0001d530", "\u266f", "\u0449", "\u0448", "\u00ad",
+		"\u03c3", "\u03c2", "\u2a6a", "\u2a9e", "\u2aa0", "\u2a9d", "\u2a9f", "\u2246",
+		"\u2a24", "\u2972", "\u2a33", "\u29e4", "\u2323", "\u2aaa", "\u2aac", "\u2aac\ufe00",
+		"\u044c", "/", "\u29c4", "\u233f", "\U0001d564", "\u2660", "\u2293\ufe00", "\u2294\ufe00",
+		"\U0001d4c8", "\u2606", "\u2282", "\u2ac5", "\u2abd", "\u2ac3", "\u2ac1", "\u2acb",
+		"\u228a", "\u2abf", "\u2979", "\u2ac7", "\u2ad5", "\u2ad3", "\u266a", "\u00b9",
+		"\u00b2", "\u00b3", "\u2ac6", "\u2abe", "\u2ad8", "\u2ac4", "\u27c9", "\u2ad7",
+		"\u297b", "\u2ac2", "\u2acc", "\u228b", "\u2ac0", "\u2ac8", "\u2ad4", "\u2ad6",
+		"\u21d9", "\u292a", "\u00df", "\u2316", "\u03c4", "\u0165", "\u0163", "\u0442",
+		"\u2315", "\U0001d531", "\u03b8", "\u03d1", "\u00fe", "\u00d7", "\u2a31", "\u2a30",
+		"\u2336", "\u2af1", "\U0001d565", "\u2ada", "\u2034", "\u25b5", "\u225c", "\u25ec",
+		"\u2a3a", "\u2a39", "\u29cd", "\u2a3b", "\u23e2", "\U0001d4c9", "\u0446", "\u045b",
+		"\u0167", "\u2963", "\u00fa", "\u045e", "\u016d", "\u00fb", "\u0443", "\u0171",
+		"\u297e", "\U0001d532", "\u00f9", "\u2580", "\u231c", "\u230f", "\u25f8", "\u016b",
+		"\u0173", "\U0001d566", "\u03c5", "\u21c8", "\u231d", "\u230e", "\u016f", "\u25f9",
+		"\U0001d4ca", "\u22f0", "\u0169", "\u00fc", "\u29a7", "\u2ae8", "\u2ae9", "\u299c",
+		"\u228a\ufe00", "\u2acb\ufe00", "\u228b\ufe00", "\u2acc\ufe00", "\u0432", "\u22bb", "\u225a", "\u22ee",
+		"\U0001d533", "\U0001d567", "\U0001d4cb", "\u299a", "\u0175", "\u2a5f", "\u2259", "\u2118",
+		"\U0001d534", "\U0001d568", "\U0001d4cc", "\U0001d535", "\u03be", "\u22fb", "\U0001d569", "\U0001d4cd",
+		"\u00fd", "\u044f", "\u0177", "\u044b", "\u00a5", "\U0001d536", "\u0457", "\U0001d56a",
+		"\U0001d4ce", "\u044e", "\u00ff", "\u017a", "\u017e", "\u0437", "\u017c", "\u03b6",
+		"\U0001d537", "\u0436", "\u21dd", "\U0001d56b", "\U0001d4cf", "\u200d", "\u200c",
+	},
+	names: map[string]string{
+		"\u00c6":       "AElig;",
+		"&":            "amp;",
+		"\u00c1":       "Aacute;",
+		"\u0102":       "Abreve;",
+		"\u00c2":       "Acirc;",
+		"\u0410":       "Acy;",
+		"\U0001d504":   "Afr;",
+		"\u00c0":       "Agrave;",
+		"\u0391":       "Alpha;",
+		"\u0100":       "Amacr;",
+		"\u2a53":       "And;",
+		"\u0104":       "Aogon;",
+		"\U0001d538":   "Aopf;",
+		"\u2061":       "af;",
+		"\u00c5":       "angst;",
+		"\U0001d49c":   "Ascr;",
+		"\u2254":       "colone;",
+		"\u00c3":       "Atilde;",
+		"\u00c4":       "Auml;",
+		"\u2216":       "setmn;",
+		"\u2ae7":       "Barv;",
+		"\u2306":       "Barwed;",
+		"\u0411":       "Bcy;",
+		"\u2235":       "becaus;",
+		"\u212c":       "Bscr;",
+		"\u0392":       "Beta;",
+		"\U0001d505":   "Bfr;",
+		"\U0001d539":   "Bopf;",
+		"\u02d8":       "breve;",
+		"\u224e":       "bump;",
+		"\u0427":       "CHcy;",
+		"\u00a9":       "copy;",
+		"\u0106":       "Cacute;",
+		"\u22d2":       "Cap;",
+		"\u2145":       "DD;",
+		"\u212d":       "Cfr;",
+		"\u010c":       "Ccaron;",
+		"\u00c7":       "Ccedil;",
+		"\u0108":       "Ccirc;",
+		"\u2230":       "Cconint;",
+		"\u010a":       "Cdot;",
+		"\u00b8":       "cedil;",
+		"\u00b7":       "middot;",
+		"\u03a7":       "Chi;",
+		"\u2299":       "odot;",
+		"\u2296":       "ominus;",
+		"\u2295":       "oplus;",
+		"\u2297":       "otimes;",
+		"\u2232":       "cwconint;",
+		"\u201d":       "rdquo;",
+		"\u2019":       "rsquo;",
+		"\u2237":       "Colon;",
+		"\u2a74":       "Colone;",
+		"\u2261":       "equiv;",
+		"\u222f":       "Conint;",
+		"\u222e":       "oint;",
+		"\u2102":       "Copf;",
+		"\u2210":       "coprod;",
+		"\u2233":       "awconint;",
+		"\u2a2f":       "Cross;",
+		"\U0001d49e":   "Cscr;",
+		"\u22d3":       "Cup;",
+		"\u224d":       "CupCap;",
+		"\u2911":       "DDotrahd;",
+		"\u0402":       "DJcy;",
+		"\u0405":       "DScy;",
+		"\u040f":       "DZcy;",
+		"\u2021":       "Dagger;",
+		"\u21a1":       "Darr;",
+		"\u2ae4":       "Dashv;",
+		"\u010e":       "Dcaron;",
+		"\u0414":       "Dcy;",
+		"\u2207":       "Del;",
+		"\u0394":       "Delta;",
+		"\U0001d507":   "Dfr;",
+		"\u00b4":       "acute;",
+		"\u02d9":       "dot;",
+		"\u02dd":       "dblac;",
+		"`":            "grave;",
+		"\u02dc":       "tilde;",
+		"\u22c4":       "diam;",
+		"\u2146":       "dd;",
+		"\U0001d53b":   "Dopf;",
+		"\u00a8":       "die;",
+		"\u20dc":       "DotDot;",
+		"\u2250":       "doteq;",
+		"\u21d3":       "dArr;",
+		"\u21d0":       "lArr;",
+		"\u21d4":       "iff;",
+		"\u27f8":       "xlArr;",
+		"\u27fa":       "xhArr;",
+		"\u27f9":       "xrArr;",
+		"\u21d2":       "rArr;",
+		"\u22a8":       "vDash;",
+		"\u21d1":       "uArr;",
+		"\u21d5":       "vArr;",
+		"\u2225":       "par;",
+		"\u2193":       "darr;",
+		"\u2913":       "DownArrowBar;",
+		"\u21f5":       "duarr;",
+		"\u0311":       "DownBreve;",
+		"\u2950":       "DownLeftRightVector;",
+		"\u295e":       "DownLeftTeeVector;",
+		"\u21bd":       "lhard;",
+		"\u2956":       "DownLeftVectorBar;",
+		"\u295f":       "DownRightTeeVector;",
+		"\u21c1":       "rhard;",
+		"\u2957":       "DownRightVectorBar;",
+		"\u22a4":       "top;",
+		"\u21a7":       "mapstodown;",
+		"\U0001d49f":   "Dscr;",
+		"\u0110":       "Dstrok;",
+		"\u014a":       "ENG;",
+		"\u00d0":       "ETH;",
+		"\u00c9":       "Eacute;",
+		"\u011a":       "Ecaron;",
+		"\u00ca":       "Ecirc;",
+		"\u042d":       "Ecy;",
+		"\u0116":       "Edot;",
+		"\U0001d508":   "Efr;",
+		"\u00c8":       "Egrave;",
+		"\u2208":       "in;",
+		"\u0112":       "Emacr;",
+		"\u25fb":       "EmptySmallSquare;",
+		"\u25ab":       "EmptyVerySmallSquare;",
+		"\u0118":       "Eogon;",
+		"\U0001d53c":   "Eopf;",
+		"\u0395":       "Epsilon;",
+		"\u2a75":       "Equal;",
+		"\u2242":       "esim;",
+		"\u21cc":       "rlhar;",
+		"\u2130":       "Escr;",
+		"\u2a73":       "Esim;",
+		"\u0397":       "Eta;",
+		"\u00cb":       "Euml;",
+		"\u2203":       "exist;",
+		"\u2147":       "ee;",
+		"\u0424":       "Fcy;",
+		"\U0001d509":   "Ffr;",
+		"\u25fc":       "FilledSmallSquare;",
+		"\u25aa":       "squf;",
+		"\U0001d53d":   "Fopf;",
+		"\u2200":       "forall;",
+		"\u2131":       "Fscr;",
+		"\u0403":       "GJcy;",
+		">":            "gt;",
+		"\u0393":       "Gamma;",
+		"\u03dc":       "Gammad;",
+		"\u011e":       "Gbreve;",
+		"\u0122":       "Gcedil;",
+		"\u011c":       "Gcirc;",
+		"\u0413":       "Gcy;",
+		"\u0120":       "Gdot;",
+		"\U0001d50a":   "Gfr;",
+		"\u22d9":       "Gg;",
+		"\U0001d53e":   "Gopf;",
+		"\u2265":       "ge;",
+		"\u22db":       "gel;",
+		"\u2267":       "gE;",
+		"\u2aa2":       "GreaterGreater;",
+		"\u2277":       "gl;",
+		"\u2a7e":       "ges;",
+		"\u2273":       "gsim;",
+		"\U0001d4a2":   "Gscr;",
+		"\u226b":       "gg;",
+		"\u042a":       "HARDcy;",
+		"\u02c7":       "caron;",
+		"^":            "Hat;",
+		"\u0124":       "Hcirc;",
+		"\u210c":       "Hfr;",
+		"\u210b":       "Hscr;",
+		"\u210d":       "Hopf;",
+		"\u2500":       "boxh;",
+		"\u0126":       "Hstrok;",
+		"\u224f":       "bumpe;",
+		"\u0415":       "IEcy;",
+		"\u0132":       "IJlig;",
+		"\u0401":       "IOcy;",
+		"\u00cd":       "Iacute;",
+		"\u00ce":       "Icirc;",
+		"\u0418":       "Icy;",
+		"\u0130":       "Idot;",
+		"\u2111":       "Im;",
+		"\u00cc":       "Igrave;",
+		"\u012a":       "Imacr;",
+		"\u2148":       "ii;",
+		"\u222c":       "Int;",
+		"\u222b":       "int;",
+		"\u22c2":       "xcap;",
+		"\u2063":       "ic;",
+		"\u2062":       "it;",
+		"\u012e":       "Iogon;",
+		"\U0001d540":   "Iopf;",
+		"\u0399":       "Iota;",
+		"\u2110":       "Iscr;",
+		"\u0128":       "Itilde;",
+		"\u0406":       "Iukcy;",
+		"\u00cf":       "Iuml;",
+		"\u0134":       "Jcirc;",
+		"\u0419":       "Jcy;",
+		"\U0001d50d":   "Jfr;",
+		"\U0001d541":   "Jopf;",
+		"\U0001d4a5":   "Jscr;",
+		"\u0408":       "Jsercy;",
+		"\u0404":       "Jukcy;",
+		"\u0425":       "KHcy;",
+		"\u040c":       "KJcy;",
+		"\u039a":       "Kappa;",
+		"\u0136":       "Kcedil;",
+		"\u041a":       "Kcy;",
+		"\U0001d50e":   "Kfr;",
+		"\U0001d542":   "Kopf;",
+		"\U0001d4a6":   "Kscr;",
+		"\u0409":       "LJcy;",
+		"<":            "lt;",
+		"\u0139":       "Lacute;",
+		"\u039b":       "Lambda;",
+		"\u27ea":       "Lang;",
+		"\u2112":       "Lscr;",
+		"\u219e":       "Larr;",
+		"\u013d":       "Lcaron;",
+		"\u013b":       "Lcedil;",
+		"\u041b":       "Lcy;",
+		"\u27e8":       "lang;",
+		"\u2190":       "larr;",
+		"\u21e4":       "larrb;",
+		"\u21c6":       "lrarr;",
+		"\u2308":       "lceil;",
+		"\u27e6":       "lobrk;",
+		"\u2961":       "LeftDownTeeVector;",
+		"\u21c3":       "dharl;",
+		"\u2959":       "LeftDownVectorBar;",
+		"\u230a":       "lfloor;",
+		"\u2194":       "harr;",
+		"\u294e":       "LeftRightVector;",
+		"\u22a3":       "dashv;",
+		"\u21a4":       "mapstoleft;",
+		"\u295a":       "LeftTeeVector;",
+		"\u22b2":       "vltri;",
+		"\u29cf":       "LeftTriangleBar;",
+		"\u22b4":       "ltrie;",
+		"\u2951":       "LeftUpDownVector;",
+		"\u2960":       "LeftUpTeeVector;",
+		"\u21bf":       "uharl;",
+		"\u2958":       "LeftUpVectorBar;",
+		"\u21bc":       "lharu;",
+		"\u2952":       "LeftVectorBar;",
+		"\u22da":       "leg;",
+		"\u2266":       "lE;",
+		"\u2276":       "lg;",
+		"\u2aa1":       "LessLess;",
+		"\u2a7d":       "les;",
+		"\u2272":       "lsim;",
+		"\U0001d50f":   "Lfr;",
+		"\u22d8":       "Ll;",
+		"\u21da":       "lAarr;",
+		"\u013f":       "Lmidot;",
+		"\u27f5":       "xlarr;",
+		"\u27f7":       "xharr;",
+		"\u27f6":       "xrarr;",
+		"\U0001d543":   "Lopf;",
+		"\u2199":       "swarr;",
+		"\u2198":       "searr;",
+		"\u21b0":       "lsh;",
+		"\u0141":       "Lstrok;",
+		"\u226a":       "ll;",
+		"\u2905":       "Map;",
+		"\u041c":       "Mcy;",
+		"\u205f":       "MediumSpace;",
+		"\u2133":       "Mscr;",
+		"\U0001d510":   "Mfr;",
+		"\u2213":       "mp;",
+		"\U0001d544":   "Mopf;",
+		"\u039c":       "Mu;",
+		"\u040a":       "NJcy;",
+		"\u0143":       "Nacute;",
+		"\u0147":       "Ncaron;",
+		"\u0145":       "Ncedil;",
+		"\u041d":       "Ncy;",
+		"\u200b":       "ZeroWidthSpace;",
+		"\n":           "NewLine;",
+		"\U0001d511":   "Nfr;",
+		"\u2060":       "NoBreak;",
+		"\u00a0":       "nbsp;",
+		"\u2115":       "Nopf;",
+		"\u2aec":       "Not;",
+		"\u2262":       "nequiv;",
+		"\u226d":       "NotCupCap;",
+		"\u2226":       "npar;",
+		"\u2209":       "notin;",
+		"\u2260":       "ne;",
+		"\u2242\u0338": "nesim;",
+		"\u2204":       "nexist;",
+		"\u226f":       "ngt;",
+		"\u2271":       "nge;",
+		"\u2267\u0338": "ngE;",
+		"\u226b\u0338": "nGtv;",
+		"\u2279":       "ntgl;",
+		"\u2a7e\u0338": "nges;",
+		"\u2275":       "ngsim;",
+		"\u224e\u0338": "nbump;",
+		"\u224f\u0338": "nbumpe;",
+		"\u22ea":       "nltri;",
+		"\u29cf\u0338": "NotLeftTriangleBar;",
+		"\u22ec":       "nltrie;",
+		"\u226e":       "nlt;",
+		"\u2270":       "nle;",
+		"\u2278":       "ntlg;",
+		"\u226a\u0338": "nLtv;",
+		"\u2a7d\u0338": "nles;",
+		"\u2274":       "nlsim;",
+		"\u2aa2\u0338": "NotNestedGreaterGreater;",
+		"\u2aa1\u0338": "NotNestedLessLess;",
+		"\u2280":       "npr;",
+		"\u2aaf\u0338": "npre;",
+		"\u22e0":       "nprcue;",
+		"\u220c":       "notni;",
+		"\u22eb":       "nrtri;",
+		"\u29d0\u0338": "NotRightTriangleBar;",
+		"\u22ed":       "nrtrie;",
+		"\u228f\u0338": "NotSquareSubset;",
+		"\u22e2":       "nsqsube;",
+		"\u2290\u0338": "NotSquareSuperset;",
+		"\u22e3":       "nsqsupe;",
+		"\u2282\u20d2": "vnsub;",
+		"\u2288":       "nsube;",
+		"\u2281":       "nsc;",
+		"\u2ab0\u0338": "nsce;",
+		"\u22e1":       "nsccue;",
+		"\u227f\u0338": "NotSucceedsTilde;",
+		"\u2283\u20d2": "vnsup;",
+		"\u2289":       "nsupe;",
+		"\u2241":       "nsim;",
+		"\u2244":       "nsime;",
+		"\u2247":       "ncong;",
+		"\u2249":       "nap;",
+		"\u2224":       "nmid;",
+		"\U0001d4a9":   "Nscr;",
+		"\u00d1":       "Ntilde;",
+		"\u039d":       "Nu;",
+		"\u0152":       "OElig;",
+		"\u00d3":       "Oacute;",
+		"\u00d4":       "Ocirc;",
+		"\u041e":       "Ocy;",
+		"\u0150":       "Odblac;",
+		"\U0001d512":   "Ofr;",
+		"\u00d2":       "Ograve;",
+		"\u014c":       "Omacr;",
+		"\u03a9":       "ohm;",
+		"\u039f":       "Omicron;",
+		"\U0001d546":   "Oopf;",
+		"\u201c":       "ldquo;",
+		"\u2018":       "lsquo;",
+		"\u2a54":       "Or;",
+		"\U0001d4aa":   "Oscr;",
+		"\u00d8":       "Oslash;",
+		"\u00d5":       "Otilde;",
+		"\u2a37":       "Otimes;",
+		"\u00d6":       "Ouml;",
+		"\u203e":       "oline;",
+		"\u23de":       "OverBrace;",
+		"\u23b4":       "tbrk;",
+		"\u23dc":       "OverParenthesis;",
+		"\u2202":       "part;",
+		"\u041f":       "Pcy;",
+		"\U0001d513":   "Pfr;",
+		"\u03a6":       "Phi;",
+		"\u03a0":       "Pi;",
+		"\u00b1":       "pm;",
+		"\u2119":       "Popf;",
+		"\u2abb":       "Pr;",
+		"\u227a":       "pr;",
+		"\u2aaf":       "pre;",
+		"\u227c":       "prcue;",
+		"\u227e":       "prsim;",
+		"\u2033":       "Prime;",
+		"\u220f":       "prod;",
+		"\u221d":       "prop;",
+		"\U0001d4ab":   "Pscr;",
+		"\u03a8":       "Psi;",
+		"\"":           "quot;",
+		"\U0001d514":   "Qfr;",
+		"\u211a":       "Qopf;",
+		"\U0001d4ac":   "Qscr;",
+		"\u2910":       "RBarr;",
+		"\u00ae":       "reg;",
+		"\u0154":       "Racute;",
+		"\u27eb":       "Rang;",
+		"\u21a0":       "Rarr;",
+		"\u2916":       "Rarrtl;",
+		"\u0158":       "Rcaron;",
+		"\u0156":       "Rcedil;",
+		"\u0420":       "Rcy;",
+		"\u211c":       "Re;",
+		"\u220b":       "ni;",
+		"\u21cb":       "lrhar;",
+		"\u296f":       "duhar;",
+		"\u03a1":       "Rho;",
+		"\u27e9":       "rang;",
+		"\u2192":       "rarr;",
+		"\u21e5":       "rarrb;",
+		"\u21c4":       "rlarr;",
+		"\u2309":       "rceil;",
+		"\u27e7":       "robrk;",
+		"\u295d":       "RightDownTeeVector;",
+		"\u21c2":       "dharr;",
+		"\u2955":       "RightDownVectorBar;",
+		"\u230b":       "rfloor;",
+		"\u22a2":       "vdash;",
+		"\u21a6":       "map;",
+		"\u295b":       "RightTeeVector;",
+		"\u22b3":       "vrtri;",
+		"\u29d0":       "RightTriangleBar;",
+		"\u22b5":       "rtrie;",
+		"\u294f":       "RightUpDownVector;",
+		"\u295c":       "RightUpTeeVector;",
+		"\u21be":       "uharr;",
+		"\u2954":       "RightUpVectorBar;",
+		"\u21c0":       "rharu;",
+		"\u2953":       "RightVectorBar;",
+		"\u211d":       "Ropf;",
+		"\u2970":       "RoundImplies;",
+		"\u21db":       "rAarr;",
+		"\u211b":       "Rscr;",
+		"\u21b1":       "rsh;",
+		"\u29f4":       "RuleDelayed;",
+		"\u0429":       "SHCHcy;",
+		"\u0428":       "SHcy;",
+		"\u042c":       "SOFTcy;",
+		"\u015a":       "Sacute;",
+		"\u2abc":       "Sc;",
+		"\u0160":       "Scaron;",
+		"\u015e":       "Scedil;",
+		"\u015c":       "Scirc;",
+		"\u0421":       "Scy;",
+		"\U0001d516":   "Sfr;",
+		"\u2191":       "uarr;",
+		"\u03a3":       "Sigma;",
+		"\u2218":       "compfn;",
+		"\U0001d54a":   "Sopf;",
+		"\u221a":       "Sqrt;",
+		"\u25a1":       "squ;",
+		"\u2293":       "sqcap;",
+		"\u228f":       "sqsub;",
+		"\u2291":       "sqsube;",
+		"\u2290":       "sqsup;",
+		"\u2292":       "sqsupe;",
+		"\u2294":       "sqcup;",
+		"\U0001d4ae":   "Sscr;",
+		"\u22c6":       "Star;",
+		"\u22d0":       "Sub;",
+		"\u2286":       "sube;",
+		"\u227b":       "sc;",
+		"\u2ab0":       "sce;",
+		"\u227d":       "sccue;",
+		"\u227f":       "scsim;",
+		"\u2211":       "sum;",
+		"\u22d1":       "Sup;",
+		"\u2283":       "sup;",
+		"\u2287":       "supe;",
+		"\u00de":       "THORN;",
+		"\u2122":       "trade;",
+		"\u040b":       "TSHcy;",
+		"\u0426":       "TScy;",
+		"\t":           "Tab;",
+		"\u03a4":       "Tau;",
+		"\u0164":       "Tcaron;",
+		"\u0162":       "Tcedil;",
+		"\u0422":       "Tcy;",
+		"\U0001d517":   "Tfr;",
+		"\u2234":       "there4;",
+		"\u0398":       "Theta;",
+		"\u205f\u200a": "ThickSpace;",
+		"\u2009":       "thinsp;",
+		"\u223c":       "sim;",
+		"\u2243":       "sime;",
+		"\u2245":       "cong;",
+		"\u2248":       "ap;",
+		"\U0001d54b":   "Topf;",
+		"\u20db":       "tdot;",
+		"\U0001d4af":   "Tscr;",
+		"\u0166":       "Tstrok;",
+		"\u00da":       "Uacute;",
+		"\u219f":       "Uarr;",
+		"\u2949":       "Uarrocir;",
+		"\u040e":       "Ubrcy;",
+		"\u016c":       "Ubreve;",
+		"\u00db":       "Ucirc;",
+		"\u0423":       "Ucy;",
+		"\u0170":       "Udblac;",
+		"\U0001d518":   "Ufr;",
+		"\u00d9":       "Ugrave;",
+		"\u016a":       "Umacr;",
+		"_":            "lowbar;",
+		"\u23df":       "UnderBrace;",
+		"\u23b5":       "bbrk;",
+		"\u23dd":       "UnderParenthesis;",
+		"\u22c3":       "xcup;",
+		"\u228e":       "uplus;",
+		"\u0172":       "Uogon;",
+		"\U0001d54c":   "Uopf;",
+		"\u2912":       "UpArrowBar;",
+		"\u21c5":       "udarr;",
+		"\u2195":       "varr;",
+		"\u296e":       "udhar;",
+		"\u22a5":       "bot;",
+		"\u21a5":       "mapstoup;",
+		"\u2196":       "nwarr;",
+		"\u2197":       "nearr;",
+		"\u03d2":       "Upsi;",
+		"\u03a5":       "Upsilon;",
+		"\u016e":       "Uring;",
+		"\U0001d4b0":   "Uscr;",
+		"\u0168":       "Utilde;",
+		"\u00dc":       "Uuml;",
+		"\u22ab":       "VDash;",
+		"\u2aeb":       "Vbar;",
+		"\u0412":       "Vcy;",
+		"\u22a9":       "Vdash;",
+		"\u2ae6":       "Vdashl;",
+		"\u22c1":       "Vee;",
+		"\u2016":       "Vert;",
+		"\u2223":       "mid;",
+		"|":            "vert;",
+		"\u2758":       "VerticalSeparator;",
+		"\u2240":       "wr;",
+		"\u200a":       "hairsp;",
+		"\U0001d519":   "Vfr;",
+		"\U0001d54d":   "Vopf;",
+		"\U0001d4b1":   "Vscr;",
+		"\u22aa":       "Vvdash;",
+		"\u0174":       "Wcirc;",
+		"\u22c0":       "Wedge;",
+		"\U0001d51a":   "Wfr;",
+		"\U0001d54e":   "Wopf;",
+		"\U0001d4b2":   "Wscr;",
+		"\U0001d51b":   "Xfr;",
+		"\u039e":       "Xi;",
+		"\U0001d54f":   "Xopf;",
+		"\U0001d4b3":   "Xscr;",
+		"\u042f":       "YAcy;",
+		"\u0407":       "YIcy;",
+		"\u042e":       "YUcy;",
+		"\u00dd":       "Yacute;",
+		"\u0176":       "Ycirc;",
+		"\u042b":       "Ycy;",
+		"\U0001d51c":   "Yfr;",
+		"\U0001d550":   "Yopf;",
+		"\U0001d4b4":   "Yscr;",
+		"\u0178":       "Yuml;",
+		"\u0416":       "ZHcy;",
+		"\u0179":       "Zacute;",
+		"\u017d":       "Zcaron;",
+		"\u0417":       "Zcy;",
+		"\u017b":       "Zdot;",
+		"\u0396":       "Zeta;",
+		"\u2128":       "Zfr;",
+		"\u2124":       "Zopf;",
+		"\U0001d4b5":   "Zscr;",
+		"\u00e1":       "aacute;",
+		"\u0103":       "abreve;",
+		"\u223e":       "ac;",
+		"\u223e\u0333": "acE;",
+		"\u223f":       "acd;",
+		"\u00e2":       "acirc;",
+		"\u0430":       "acy;",
+		"\u00e6":       "aelig;",
+		"\U0001d51e":   "afr;",
+		"\u00e0":       "agrave;",
+		"\u2135":       "aleph;",
+		"\u03b1":       "alpha;",
+		"\u0101":       "amacr;",
+		"\u2a3f":       "amalg;",
+		"\u2227":       "and;",
+		"\u2a55":       "andand;",
+		"\u2a5c":       "andd;",
+		"\u2a58":       "andslope;",
+		"\u2a5a":       "andv;",
+		"\u2220":       "ang;",
+		"\u29a4":       "ange;",
+		"\u2221":       "angmsd;",
+		"\u29a8":       "angmsdaa;",
+		"\u29a9":       "angmsdab;",
+		"\u29aa":       "angmsdac;",
+		"\u29ab":       "angmsdad;",
+		"\u29ac":       "angmsdae;",
+		"\u29ad":       "angmsdaf;",
+		"\u29ae":       "angmsdag;",
+		"\u29af":       "angmsdah;",
+		"\u221f":       "angrt;",
+		"\u22be":       "angrtvb;",
+		"\u299d":       "angrtvbd;",
+		"\u2222":       "angsph;",
+		"\u237c":       "angzarr;",
+		"\u0105":       "aogon;",
+		"\U0001d552":   "aopf;",
+		"\u2a70":       "apE;",
+		"\u2a6f":       "apacir;",
+		"\u224a":       "ape;",
+		"\u224b":       "apid;",
+		"'":            "apos;",
+		"\u00e5":       "aring;",
+		"\U0001d4b6":   "ascr;",
+		"*":            "ast;",
+		"\u00e3":       "atilde;",
+		"\u00e4":       "auml;",
+		"\u2a11":       "awint;",
+		"\u2aed":       "bNot;",
+		"\u224c":       "bcong;",
+		"\u03f6":       "bepsi;",
+		"\u2035":       "bprime;",
+		"\u223d":       "bsim;",
+		"\u22cd":       "bsime;",
+		"\u22bd":       "barvee;",
+		"\u2305":       "barwed;",
+		"\u23b6":       "bbrktbrk;",
+		"\u0431":       "bcy;",
+		"\u201e":       "bdquo;",
+		"\u29b0":       "bemptyv;",
+		"\u03b2":       "beta;",
+		"\u2136":       "beth;",
+		"\u226c":       "twixt;",
+		"\U0001d51f":   "bfr;",
+		"\u25ef":       "xcirc;",
+		"\u2a00":       "xodot;",
+		"\u2a01":       "xoplus;",
+		"\u2a02":       "xotime;",
+		"\u2a06":       "xsqcup;",
+		"\u2605":       "starf;",
+		"\u25bd":       "xdtri;",
+		"\u25b3":       "xutri;",
+		"\u2a04":       "xuplus;",
+		"\u290d":       "rbarr;",
+		"\u29eb":       "lozf;",
+		"\u25b4":       "utrif;",
+		"\u25be":       "dtrif;",
+		"\u25c2":       "ltrif;",
+		"\u25b8":       "rtrif;",
+		"\u2423":       "blank;",
+		"\u2592":       "blk12;",
+		"\u2591":       "blk14;",
+		"\u2593":       "blk34;",
+		"\u2588":       "block;",
+		"=\u20e5":      "bne;",
+		"\u2261\u20e5": "bnequiv;",
+		"\u2310":       "bnot;",
+		"\U0001d553":   "bopf;",
+		"\u22c8":       "bowtie;",
+		"\u2557":       "boxDL;",
+		"\u2554":       "boxDR;",
+		"\u2556":       "boxDl;",
+		"\u2553":       "boxDr;",
+		"\u2550":       "boxH;",
+		"\u2566":       "boxHD;",
+		"\u2569":       "boxHU;",
+		"\u2564":       "boxHd;",
+		"\u2567":       "boxHu;",
+		"\u255d":       "boxUL;",
+		"\u255a":       "boxUR;",
+		"\u255c":       "boxUl;",
+		"\u2559":       "boxUr;",
+		"\u2551":       "boxV;",
+		"\u256c":       "boxVH;",
+		"\u2563":       "boxVL;",
+		"\u2560":       "boxVR;",
+		"\u256b":       "boxVh;",
+		"\u2562":       "boxVl;",
+		"\u255f":       "boxVr;",
+		"\u29c9":       "boxbox;",
+		"\u2555":       "boxdL;",
+		"\u2552":       "boxdR;",
+		"\u2510":       "boxdl;",
+		"\u250c":       "boxdr;",
+		"\u2565":       "boxhD;",
+		"\u2568":       "boxhU;",
+		"\u252c":       "boxhd;",
+		"\u2534":       "boxhu;",
+		"\u229f":       "minusb;",
+		"\u229e":       "plusb;",
+		"\u22a0":       "timesb;",
+		"\u255b":       "boxuL;",
+		"\u2558":       "boxuR;",
+		"\u2518":       "boxul;",
+		"\u2514":       "boxur;",
+		"\u2502":       "boxv;",
+		"\u256a":       "boxvH;",
+		"\u2561":       "boxvL;",
+		"\u255e":       "boxvR;",
+		"\u253c":       "boxvh;",
+		"\u2524":       "boxvl;",
+		"\u251c":       "boxvr;",
+		"\u00a6":       "brvbar;",
+		"\U0001d4b7":   "bscr;",
+		"\u204f":       "bsemi;",
+		"\\":           "bsol;",
+		"\u29c5":       "bsolb;",
+		"\u27c8":       "bsolhsub;",
+		"\u2022":       "bull;",
+		"\u2aae":       "bumpE;",
+		"\u0107":       "cacute;",
+		"\u2229":       "cap;",
+		"\u2a44":       "capand;",
+		"\u2a49":       "capbrcup;",
+		"\u2a4b":       "capcap;",
+		"\u2a47":       "capcup;",
+		"\u2a40":       "capdot;",
+		"\u2229\ufe00": "caps;",
+		"\u2041":       "caret;",
+		"\u2a4d":       "ccaps;",
+		"\u010d":       "ccaron;",
+		"\u00e7":       "ccedil;",
+		"\u0109":       "ccirc;",
+		"\u2a4c":       "ccups;",
+		"\u2a50":       "ccupssm;",
+		"\u010b":       "cdot;",
+		"\u29b2":       "cemptyv;",
+		"\u00a2":       "cent;",
+		"\U0001d520":   "cfr;",
+		"\u0447":       "chcy;",
+		"\u2713":       "check;",
+		"\u03c7":       "chi;",
+		"\u25cb":       "cir;",
+		"\u29c3":       "cirE;",
+		"\u02c6":       "circ;",
+		"\u2257":       "cire;",
+		"\u21ba":       "olarr;",
+		"\u21bb":       "orarr;",
+		"\u24c8":       "oS;",
+		"\u229b":       "oast;",
+		"\u229a":       "ocir;",
+		"\u229d":       "odash;",
+		"\u2a10":       "cirfnint;",
+		"\u2aef":       "cirmid;",
+		"\u29c2":       "cirscir;",
+		"\u2663":       "clubs;",
+		":":            "colon;",
+		",":            "comma;",
+		"@":            "commat;",
+		"\u2201":       "comp;",
+		"\u2a6d":       "congdot;",
+		"\U0001d554":   "copf;",
+		"\u2117":       "copysr;",
+		"\u21b5":       "crarr;",
+		"\u2717":       "cross;",
+		"\U0001d4b8":   "cscr;",
+		"\u2acf":       "csub;",
+		"\u2ad1":       "csube;",
+		"\u2ad0":       "csup;",
+		"\u2ad2":       "csupe;",
+		"\u22ef":       "ctdot;",
+		"\u2938":       "cudarrl;",
+		"\u2935":       "cudarrr;",
+		"\u22de":       "cuepr;",
+		"\u22df":       "cuesc;",
+		"\u21b6":       "cularr;",
+		"\u293d":       "cularrp;",
+		"\u222a":       "cup;",
+		"\u2a48":       "cupbrcap;",
+		"\u2a46":       "cupcap;",
+		"\u2a4a":       "cupcup;",
+		"\u228d":       "cupdot;",
+		"\u2a45":       "cupor;",
+		"\u222a\ufe00": "cups;",
+		"\u21b7":       "curarr;",
+		"\u293c":       "curarrm;",
+		"\u22ce":       "cuvee;",
+		"\u22cf":       "cuwed;",
+		"\u00a4":       "curren;",
+		"\u2231":       "cwint;",
+		"\u232d":       "cylcty;",
+		"\u2965":       "dHar;",
+		"\u2020":       "dagger;",
+		"\u2138":       "daleth;",
+		"\u2010":       "dash;",
+		"\u290f":       "rBarr;",
+		"\u010f":       "dcaron;",
+		"\u0434":       "dcy;",
+		"\u21ca":       "ddarr;",
+		"\u2a77":       "eDDot;",
+		"\u00b0":       "deg;",
+		"\u03b4":       "delta;",
+		"\u29b1":       "demptyv;",
+		"\u297f":       "dfisht;",
+		"\U0001d521":   "dfr;",
+		"\u2666":       "diams;",
+		"\u03dd":       "gammad;",
+		"\u22f2":       "disin;",
+		"\u00f7":       "div;",
+		"\u22c7":       "divonx;",
+		"\u0452":       "djcy;",
+		"\u231e":       "dlcorn;",
+		"\u230d":       "dlcrop;",
+		"$":            "dollar;",
+		"\U0001d555":   "dopf;",
+		"\u2251":       "eDot;",
+		"\u2238":       "minusd;",
+		"\u2214":       "plusdo;",
+		"\u22a1":       "sdotb;",
+		"\u231f":       "drcorn;",
+		"\u230c":       "drcrop;",
+		"\U0001d4b9":   "dscr;",
+		"\u0455":       "dscy;",
+		"\u29f6":       "dsol;",
+		"\u0111":       "dstrok;",
+		"\u22f1":       "dtdot;",
+		"\u25bf":       "dtri;",
+		"\u29a6":       "dwangle;",
+		"\u045f":       "dzcy;",
+		"\u27ff":       "dzigrarr;",
+		"\u00e9":       "eacute;",
+		"\u2a6e":       "easter;",
+		"\u011b":       "ecaron;",
+		"\u2256":       "ecir;",
+		"\u00ea":       "ecirc;",
+		"\u2255":       "ecolon;",
+		"\u044d":       "ecy;",
+		"\u0117":       "edot;",
+		"\u2252":       "efDot;",
+		"\U0001d522":   "efr;",
+		"\u2a9a":       "eg;",
+		"\u00e8":       "egrave;",
+		"\u2a96":       "egs;",
+		"\u2a98":       "egsdot;",
+		"\u2a99":       "el;",
+		"\u23e7":       "elinters;",
+		"\u2113":       "ell;",
+		"\u2a95":       "els;",
+		"\u2a97":       "elsdot;",
+		"\u0113":       "emacr;",
+		"\u2205":       "empty;",
+		"\u2004":       "emsp13;",
+		"\u2005":       "emsp14;",
+		"\u2003":       "emsp;",
+		"\u014b":       "eng;",
+		"\u2002":       "ensp;",
+		"\u0119":       "eogon;",
+		"\U0001d556":   "eopf;",
+		"\u22d5":       "epar;",
+		"\u29e3":       "eparsl;",
+		"\u2a71":       "eplus;",
+		"\u03b5":       "epsi;",
+		"\u03f5":       "epsiv;",
+		"=":            "equals;",
+		"\u225f":       "equest;",
+		"\u2a78":       "equivDD;",
+		"\u29e5":       "eqvparsl;",
+		"\u2253":       "erDot;",
+		"\u2971":       "erarr;",
+		"\u212f":       "escr;",
+		"\u03b7":       "eta;",
+		"\u00f0":       "eth;",
+		"\u00eb":       "euml;",
+		"\u20ac":       "euro;",
+		"!":            "excl;",
+		"\u0444":       "fcy;",
+		"\u2640":       "female;",
+		"\ufb03":       "ffilig;",
+		"\ufb00":       "fflig;",
+		"\ufb04":       "ffllig;",
+		"\U0001d523":   "ffr;",
+		"\ufb01":       "filig;",
+		"fj":           "fjlig;",
+		"\u266d":       "flat;",
+		"\ufb02":       "fllig;",
+		"\u25b1":       "fltns;",
+		"\u0192":       "fnof;",
+		"\U0001d557":   "fopf;",
+		"\u22d4":       "fork;",
+		"\u2ad9":       "forkv;",
+		"\u2a0d":       "fpartint;",
+		"\u00bd":       "half;",
+		"\u2153":       "frac13;",
+		"\u00bc":       "frac14;",
+		"\u2155":       "frac15;",
+		"\u2159":       "frac16;",
+		"\u215b":       "frac18;",
+		"\u2154":       "frac23;",
+		"\u2156":       "frac25;",
+		"\u00be":       "frac34;",
+		"\u2157":       "frac35;",
+		"\u215c":       "frac38;",
+		"\u2158":       "frac45;",
+		"\u215a":       "frac56;",
+		"\u215d":       "frac58;",
+		"\u215e":       "frac78;",
+		"\u2044":       "frasl;",
+		"\u2322":       "frown;",
+		"\U0001d4bb":   "fscr;",
+		"\u2a8c":       "gEl;",
+		"\u01f5":       "gacute;",
+		"\u03b3":       "gamma;",
+		"\u2a86":       "gap;",
+		"\u011f":       "gbreve;",
+		"\u011d":       "gcirc;",
+		"\u0433":       "gcy;",
+		"\u0121":       "gdot;",
+		"\u2aa9":       "gescc;",
+		"\u2a80":       "gesdot;",
+		"\u2a82":       "gesdoto;",
+		"\u2a84":       "gesdotol;",
+		"\u22db\ufe00": "gesl;",
+		"\u2a94":       "gesles;",
+		"\U0001d524":   "gfr;",
+		"\u2137":       "gimel;",
+		"\u0453":       "gjcy;",
+		"\u2a92":       "glE;",
+		"\u2aa5":       "gla;",
+		"\u2aa4":       "glj;",
+		"\u2269":       "gnE;",
+		"\u2a8a":       "gnap;",
+		"\u2a88":       "gne;",
+		"\u22e7":       "gnsim;",
+		"\U0001d558":   "gopf;",
+		"\u210a":       "gscr;",
+		"\u2a8e":       "gsime;",
+		"\u2a90":       "gsiml;",
+		"\u2aa7":       "gtcc;",
+		"\u2a7a":       "gtcir;",
+		"\u22d7":       "gtdot;",
+		"\u2995":       "gtlPar;",
+		"\u2a7c":       "gtquest;",
+		"\u2978":       "gtrarr;",
+		"\u2269\ufe00": "gvnE;",
+		"\u044a":       "hardcy;",
+		"\u2948":       "harrcir;",
+		"\u21ad":       "harrw;",
+		"\u210f":       "hbar;",
+		"\u0125":       "hcirc;",
+		"\u2665":       "hearts;",
+		"\u2026":       "mldr;",
+		"\u22b9":       "hercon;",
+		"\U0001d525":   "hfr;",
+		"\u2925":       "searhk;",
+		"\u2926":       "swarhk;",
+		"\u21ff":       "hoarr;",
+		"\u223b":       "homtht;",
+		"\u21a9":       "larrhk;",
+		"\u21aa":       "rarrhk;",
+		"\U0001d559":   "hopf;",
+		"\u2015":       "horbar;",
+		"\U0001d4bd":   "hscr;",
+		"\u0127":       "hstrok;",
+		"\u2043":       "hybull;",
+		"\u00ed":       "iacute;",
+		"\u00ee":       "icirc;",
+		"\u0438":       "icy;",
+		"\u0435":       "iecy;",
+		"\u00a1":       "iexcl;",
+		"\U0001d526":   "ifr;",
+		"\u00ec":       "igrave;",
+		"\u2a0c":       "qint;",
+		"\u222d":       "tint;",
+		"\u29dc":       "iinfin;",
+		"\u2129":       "iiota;",
+		"\u0133":       "ijlig;",
+		"\u012b":       "imacr;",
+		"\u0131":       "imath;",
+		"\u22b7":       "imof;",
+		"\u01b5":       "imped;",
+		"\u2105":       "incare;",
+		"\u221e":       "infin;",
+		"\u29dd":       "infintie;",
+		"\u22ba":       "intcal;",
+		"\u2a17":       "intlarhk;",
+		"\u2a3c":       "iprod;",
+		"\u0451":       "iocy;",
+		"\u012f":       "iogon;",
+		"\U0001d55a":   "iopf;",
+		"\u03b9":       "iota;",
+		"\u00bf":       "iquest;",
+		"\U0001d4be":   "iscr;",
+		"\u22f9":       "isinE;",
+		"\u22f5":       "isindot;",
+		"\u22f4":       "isins;",
+		"\u22f3":       "isinsv;",
+		"\u0129":       "itilde;",
+		"\u0456":       "iukcy;",
+		"\u00ef":       "iuml;",
+		"\u0135":       "jcirc;",
+		"\u0439":       "jcy;",
+		"\U0001d527":   "jfr;",
+		"\u0237":       "jmath;",
+		"\U0001d55b":   "jopf;",
+		"\U0001d4bf":   "jscr;",
+		"\u0458":       "jsercy;",
+		"\u0454":       "jukcy;",
+		"\u03ba":       "kappa;",
+		"\u03f0":       "kappav;",
+		"\u0137":       "kcedil;",
+		"\u043a":       "kcy;",
+		"\U0001d528":   "kfr;",
+		"\u0138":       "kgreen;",
+		"\u0445":       "khcy;",
+		"\u045c":       "kjcy;",
+		"\U0001d55c":   "kopf;",
+		"\U0001d4c0":   "kscr;",
+		"\u291b":       "lAtail;",
+		"\u290e":       "lBarr;",
+		"\u2a8b":       "lEg;",
+		"\u2962":       "lHar;",
+		"\u013a":       "lacute;",
+		"\u29b4":       "laemptyv;",
+		"\u03bb":       "lambda;",
+		"\u2991":       "langd;",
+		"\u2a85":       "lap;",
+		"\u00ab":       "laquo;",
+		"\u291f":       "larrbfs;",
+		"\u291d":       "larrfs;",
+		"\u21ab":       "larrlp;",
+		"\u2939":       "larrpl;",
+		"\u2973":       "larrsim;",
+		"\u21a2":       "larrtl;",
+		"\u2aab":       "lat;",
+		"\u2919":       "latail;",
+		"\u2aad":       "late;",
+		"\u2aad\ufe00": "lates;",
+		"\u290c":       "lbarr;",
+		"\u2772":       "lbbrk;",
+		"{":            "lcub;",
+		"[":            "lsqb;",
+		"\u298b":       "lbrke;",
+		"\u298f":       "lbrksld;",
+		"\u298d":       "lbrkslu;",
+		"\u013e":       "lcaron;",
+		"\u013c":       "lcedil;",
+		"\u043b":       "lcy;",
+		"\u2936":       "ldca;",
+		"\u2967":       "ldrdhar;",
+		"\u294b":       "ldrushar;",
+		"\u21b2":       "ldsh;",
+		"\u2264":       "le;",
+		"\u21c7":       "llarr;",
+		"\u22cb":       "lthree;",
+		"\u2aa8":       "lescc;",
+		"\u2a7f":       "lesdot;",
+		"\u2a81":       "lesdoto;",
+		"\u2a83":       "lesdotor;",
+		"\u22da\ufe00": "lesg;",
+		"\u2a93":       "lesges;",
+		"\u22d6":       "ltdot;",
+		"\u297c":       "lfisht;",
+		"\U0001d529":   "lfr;",
+		"\u2a91":       "lgE;",
+		"\u296a":       "lharul;",
+		"\u2584":       "lhblk;",
+		"\u0459":       "ljcy;",
+		"\u296b":       "llhard;",
+		"\u25fa":       "lltri;",
+		"\u0140":       "lmidot;",
+		"\u23b0":       "lmoust;",
+		"\u2268":       "lnE;",
+		"\u2a89":       "lnap;",
+		"\u2a87":       "lne;",
+		"\u22e6":       "lnsim;",
+		"\u27ec":       "loang;",
+		"\u21fd":       "loarr;",
+		"\u27fc":       "xmap;",
+		"\u21ac":       "rarrlp;",
+		"\u2985":       "lopar;",
+		"\U0001d55d":   "lopf;",
+		"\u2a2d":       "loplus;",
+		"\u2a34":       "lotimes;",
+		"\u2217":       "lowast;",
+		"\u25ca":       "loz;",
+		"(":            "lpar;",
+		"\u2993":       "lparlt;",
+		"\u296d":       "lrhard;",
+		"\u200e":       "lrm;",
+		"\u22bf":       "lrtri;",
+		"\u2039":       "lsaquo;",
+		"\U0001d4c1":   "lscr;",
+		"\u2a8d":       "lsime;",
+		"\u2a8f":       "lsimg;",
+		"\u201a":       "sbquo;",
+		"\u0142":       "lstrok;",
+		"\u2aa6":       "ltcc;",
+		"\u2a79":       "ltcir;",
+		"\u22c9":       "ltimes;",
+		"\u2976":       "ltlarr;",
+		"\u2a7b":       "ltquest;",
+		"\u2996":       "ltrPar;",
+		"\u25c3":       "ltri;",
+		"\u294a":       "lurdshar;",
+		"\u2966":       "luruhar;",
+		"\u2268\ufe00": "lvnE;",
+		"\u223a":       "mDDot;",
+		"\u00af":       "macr;",
+		"\u2642":       "male;",
+		"\u2720":       "malt;",
+		"\u25ae":       "marker;",
+		"\u2a29":       "mcomma;",
+		"\u043c":       "mcy;",
+		"\u2014":       "mdash;",
+		"\U0001d52a":   "mfr;",
+		"\u2127":       "mho;",
+		"\u00b5":       "micro;",
+		"\u2af0":       "midcir;",
+		"\u2212":       "minus;",
+		"\u2a2a":       "minusdu;",
+		"\u2adb":       "mlcp;",
+		"\u22a7":       "models;",
+		"\U0001d55e":   "mopf;",
+		"\U0001d4c2":   "mscr;",
+		"\u03bc":       "mu;",
+		"\u22b8":       "mumap;",
+		"\u22d9\u0338": "nGg;",
+		"\u226b\u20d2": "nGt;",
+		"\u21cd":       "nlArr;",
+		"\u21ce":       "nhArr;",
+		"\u22d8\u0338": "nLl;",
+		"\u226a\u20d2": "nLt;",
+		"\u21cf":       "nrArr;",
+		"\u22af":       "nVDash;",
+		"\u22ae":       "nVdash;",
+		"\u0144":       "nacute;",
+		"\u2220\u20d2": "nang;",
+		"\u2a70\u0338": "napE;",
+		"\u224b\u0338": "napid;",
+		"\u0149":       "napos;",
+		"\u266e":       "natur;",
+		"\u2a43":       "ncap;",
+		"\u0148":       "ncaron;",
+		"\u0146":       "ncedil;",
+		"\u2a6d\u0338": "ncongdot;",
+		"\u2a42":       "ncup;",
+		"\u043d":       "ncy;",
+		"\u2013":       "ndash;",
+		"\u21d7":       "neArr;",
+		"\u2924":       "nearhk;",
+		"\u2250\u0338": "nedot;",
+		"\u2928":       "toea;",
+		"\U0001d52b":   "nfr;",
+		"\u21ae":       "nharr;",
+		"\u2af2":       "nhpar;",
+		"\u22fc":       "nis;",
+		"\u22fa":       "nisd;",
+		"\u045a":       "njcy;",
+		"\u2266\u0338": "nlE;",
+		"\u219a":       "nlarr;",
+		"\u2025":       "nldr;",
+		"\U0001d55f":   "nopf;",
+		"\u00ac":       "not;",
+		"\u22f9\u0338": "notinE;",
+		"\u22f5\u0338": "notindot;",
+		"\u22f7":       "notinvb;",
+		"\u22f6":       "notinvc;",
+		"\u22fe":       "notnivb;",
+		"\u22fd":       "notnivc;",
+		"\u2afd\u20e5": "nparsl;",
+		"\u2202\u0338": "npart;",
+		"\u2a14":       "npolint;",
+		"\u219b":       "nrarr;",
+		"\u2933\u0338": "nrarrc;",
+		"\u219d\u0338": "nrarrw;",
+		"\U0001d4c3":   "nscr;",
+		"\u2284":       "nsub;",
+		"\u2ac5\u0338": "nsubE;",
+		"\u2285":       "nsup;",
+		"\u2ac6\u0338": "nsupE;",
+		"\u00f1":       "ntilde;",
+		"\u03bd":       "nu;",
+		"#":            "num;",
+		"\u2116":       "numero;",
+		"\u2007":       "numsp;",
+		"\u22ad":       "nvDash;",
+		"\u2904":       "nvHarr;",
+		"\u224d\u20d2": "nvap;",
+		"\u22ac":       "nvdash;",
+		"\u2265\u20d2": "nvge;",
+		">\u20d2":      "nvgt;",
+		"\u29de":       "nvinfin;",
+		"\u2902":       "nvlArr;",
+		"\u2264\u20d2": "nvle;",
+		"<\u20d2":      "nvlt;",
+		"\u22b4\u20d2": "nvltrie;",
+		"\u2903":       "nvrArr;",
+		"\u22b5\u20d2": "nvrtrie;",
+		"\u223c\u20d2": "nvsim;",
+		"\u21d6":       "nwArr;",
+		"\u2923":       "nwarhk;",
+		"\u2927":       "nwnear;",
+		"\u00f3":       "oacute;",
+		"\u00f4":       "ocirc;",
+		"\u043e":       "ocy;",
+		"\u0151":       "odblac;",
+		"\u2a38":       "odiv;",
+		"\u29bc":       "odsold;",
+		"\u0153":       "oelig;",
+		"\u29bf":       "ofcir;",
+		"\U0001d52c":   "ofr;",
+		"\u02db":       "ogon;",
+		"\u00f2":       "ograve;",
+		"\u29c1":       "ogt;",
+		"\u29b5":       "ohbar;",
+		"\u29be":       "olcir;",
+		"\u29bb":       "olcross;",
+		"\u29c0":       "olt;",
+		"\u014d":       "omacr;",
+		"\u03c9":       "omega;",
+		"\u03bf":       "omicron;",
+		"\u29b6":       "omid;",
+		"\U0001d560":   "oopf;",
+		"\u29b7":       "opar;",
+		"\u29b9":       "operp;",
+		"\u2228":       "or;",
+		"\u2a5d":       "ord;",
+		"\u2134":       "oscr;",
+		"\u00aa":       "ordf;",
+		"\u00ba":       "ordm;",
+		"\u22b6":       "origof;",
+		"\u2a56":       "oror;",
+		"\u2a57":       "orslope;",
+		"\u2a5b":       "orv;",
+		"\u00f8":       "oslash;",
+		"\u2298":       "osol;",
+		"\u00f5":       "otilde;",
+		"\u2a36":       "otimesas;",
+		"\u00f6":       "ouml;",
+		"\u233d":       "ovbar;",
+		"\u00b6":       "para;",
+		"\u2af3":       "parsim;",
+		"\u2afd":       "parsl;",
+		"\u043f":       "pcy;",
+		"%":            "percnt;",
+		".":            "period;",
+		"\u2030":       "permil;",
+		"\u2031":       "pertenk;",
+		"\U0001d52d":   "pfr;",
+		"\u03c6":       "phi;",
+		"\u03d5":       "phiv;",
+		"\u260e":       "phone;",
+		"\u03c0":       "pi;",
+		"\u03d6":       "piv;",
+		"\u210e":       "planckh;",
+		"+":            "plus;",
+		"\u2a23":       "plusacir;",
+		"\u2a22":       "pluscir;",
+		"\u2a25":       "plusdu;",
+		"\u2a72":       "pluse;",
+		"\u2a26":       "plussim;",
+		"\u2a27":       "plustwo;",
+		"\u2a15":       "pointint;",
+		"\U0001d561":   "popf;",
+		"\u00a3":       "pound;",
+		"\u2ab3":       "prE;",
+		"\u2ab7":       "prap;",
+		"\u2ab9":       "prnap;",
+		"\u2ab5":       "prnE;",
+		"\u22e8":       "prnsim;",
+		"\u2032":       "prime;",
+		"\u232e":       "profalar;",
+		"\u2312":       "profline;",
+		"\u2313":       "profsurf;",
+		"\u22b0":       "prurel;",
+		"\U0001d4c5":   "pscr;",
+		"\u03c8":       "psi;",
+		"\u2008":       "puncsp;",
+		"\U0001d52e":   "qfr;",
+		"\U0001d562":   "qopf;",
+		"\u2057":       "qprime;",
+		"\U0001d4c6":   "qscr;",
+		"\u2a16":       "quatint;",
+		"?":            "quest;",
+		"\u291c":       "rAtail;",
+		"\u2964":       "rHar;",
+		"\u223d\u0331": "race;",
+		"\u0155":       "racute;",
+		"\u29b3":       "raemptyv;",
+		"\u2992":       "rangd;",
+		"\u29a5":       "range;",
+		"\u00bb":       "raquo;",
+		"\u2975":       "rarrap;",
+		"\u2920":       "rarrbfs;",
+		"\u2933":       "rarrc;",
+		"\u291e":       "rarrfs;",
+		"\u2945":       "rarrpl;",
+		"\u2974":       "rarrsim;",
+		"\u21a3":       "rarrtl;",
+		"\u219d":       "rarrw;",
+		"\u291a":       "ratail;",
+		"\u2236":       "ratio;",
+		"\u2773":       "rbbrk;",
+		"}":            "rcub;",
+		"]":            "rsqb;",
+		"\u298c":       "rbrke;",
+		"\u298e":       "rbrksld;",
+		"\u2990":       "rbrkslu;",
+		"\u0159":       "rcaron;",
+		"\u0157":       "rcedil;",
+		"\u0440":       "rcy;",
+		"\u2937":       "rdca;",
+		"\u2969":       "rdldhar;",
+		"\u21b3":       "rdsh;",
+		"\u25ad":       "rect;",
+		"\u297d":       "rfisht;",
+		"\U0001d52f":   "rfr;",
+		"\u296c":       "rharul;",
+		"\u03c1":       "rho;",
+		"\u03f1":       "rhov;",
+		"\u21c9":       "rrarr;",
+		"\u22cc":       "rthree;",
+		"\u02da":       "ring;",
+		"\u200f":       "rlm;",
+		"\u23b1":       "rmoust;",
+		"\u2aee":       "rnmid;",
+		"\u27ed":       "roang;",
+		"\u21fe":       "roarr;",
+		"\u2986":       "ropar;",
+		"\U0001d563":   "ropf;",
+		"\u2a2e":       "roplus;",
+		"\u2a35":       "rotimes;",
+		")":            "rpar;",
+		"\u2994":       "rpargt;",
+		"\u2a12":       "rppolint;",
+		"\u203a":       "rsaquo;",
+		"\U0001d4c7":   "rscr;",
+		"\u22ca":       "rtimes;",
+		"\u25b9":       "rtri;",
+		"\u29ce":       "rtriltri;",
+		"\u2968":       "ruluhar;",
+		"\u211e":       "rx;",
+		"\u015b":       "sacute;",
+		"\u2ab4":       "scE;",
+		"\u2ab8":       "scap;",
+		"\u0161":       "scaron;",
+		"\u015f":       "scedil;",
+		"\u015d":       "scirc;",
+		"\u2ab6":       "scnE;",
+		"\u2aba":       "scnap;",
+		"\u22e9":       "scnsim;",
+		"\u2a13":       "scpolint;",
+		"\u0441":       "scy;",
+		"\u22c5":       "sdot;",
+		"\u2a66":       "sdote;",
+		"\u21d8":       "seArr;",
+		"\u00a7":       "sect;",
+		";":            "semi;",
+		"\u2929":       "tosa;",
+		"\u2736":       "sext;",
+		"\U0001d530":   "sfr;",
+		"\u266f":       "sharp;",
+		"\u0449":       "shchcy;",
+		"\u0448":       "shcy;",
+		"\u00ad":       "shy;",
+		"\u03c3":       "sigma;",
+		"\u03c2":       "sigmaf;",
+		"\u2a6a":       "simdot;",
+		"\u2a9e":       "simg;",
+		"\u2aa0":       "simgE;",
+		"\u2a9d":       "siml;",
+		"\u2a9f":       "simlE;",
+		"\u2246":       "simne;",
+		"\u2a24":       "simplus;",
+		"\u2972":       "simrarr;",
+		"\u2a33":       "smashp;",
+		"\u29e4":       "smeparsl;",
+		"\u2323":       "smile;",
+		"\u2aaa":       "smt;",
+		"\u2aac":       "smte;",
+		"\u2aac\ufe00": "smtes;",
+		"\u044c":       "softcy;",
+		"/":            "sol;",
+		"\u29c4":       "solb;",
+		"\u233f":       "solbar;",
+		"\U0001d564":   "sopf;",
+		"\u2660":       "spades;",
+		"\u2293\ufe00": "sqcaps;",
+		"\u2294\ufe00": "sqcups;",
+		"\U0001d4c8":   "sscr;",
+		"\u2606":       "star;",
+		"\u2282":       "sub;",
+		"\u2ac5":       "subE;",
+		"\u2abd":       "subdot;",
+		"\u2ac3":       "subedot;",
+		"\u2ac1":       "submult;",
+		"\u2acb":       "subnE;",
+		"\u228a":       "subne;",
+		"\u2abf":       "subplus;",
+		"\u2979":       "subrarr;",
+		"\u2ac7":       "subsim;",
+		"\u2ad5":       "subsub;",
+		"\u2ad3":       "subsup;",
+		"\u266a":       "sung;",
+		"\u00b9":       "sup1;",
+		"\u00b2":       "sup2;",
+		"\u00b3":       "sup3;",
+		"\u2ac6":       "supE;",
+		"\u2abe":       "supdot;",
+		"\u2ad8":       "supdsub;",
+		"\u2ac4":       "supedot;",
+		"\u27c9":       "suphsol;",
+		"\u2ad7":       "suphsub;",
+		"\u297b":       "suplarr;",
+		"\u2ac2":       "supmult;",
+		"\u2acc":       "supnE;",
+		"\u228b":       "supne;",
+		"\u2ac0":       "supplus;",
+		"\u2ac8":       "supsim;",
+		"\u2ad4":       "supsub;",
+		"\u2ad6":       "supsup;",
+		"\u21d9":       "swArr;",
+		"\u292a":       "swnwar;",
+		"\u00df":       "szlig;",
+		"\u2316":       "target;",
+		"\u03c4":       "tau;",
+		"\u0165":       "tcaron;",
+		"\u0163":       "tcedil;",
+		"\u0442":       "tcy;",
+		"\u2315":       "telrec;",
+		"\U0001d531":   "tfr;",
+		"\u03b8":       "theta;",
+		"\u03d1":       "thetav;",
+		"\u00fe":       "thorn;",
+		"\u00d7":       "times;",
+		"\u2a31":       "timesbar;",
+		"\u2a30":       "timesd;",
+		"\u2336":       "topbot;",
+		"\u2af1":       "topcir;",
+		"\U0001d565":   "topf;",
+		"\u2ada":       "topfork;",
+		"\u2034":       "tprime;",
+		"\u25b5":       "utri;",
+		"\u225c":       "trie;",
+		"\u25ec":       "tridot;",
+		"\u2a3a":       "triminus;",
+		"\u2a39":       "triplus;",
+		"\u29cd":       "trisb;",
+		"\u2a3b":       "tritime;",
+		"\u23e2":       "trpezium;",
+		"\U0001d4c9":   "tscr;",
+		"\u0446":       "tscy;",
+		"\u045b":       "tshcy;",
+		"\u0167":       "tstrok;",
+		"\u2963":       "uHar;",
+		"\u00fa":       "uacute;",
+		"\u045e":       "ubrcy;",
+		"\u016d":       "ubreve;",
+		"\u00fb":       "ucirc;",
+		"\u0443":       "ucy;",
+		"\u0171":       "udblac;",
+		"\u297e":       "ufisht;",
+		"\U0001d532":   "ufr;",
+		"\u00f9":       "ugrave;",
+		"\u2580":       "uhblk;",
+		"\u231c":       "ulcorn;",
+		"\u230f":       "ulcrop;",
+		"\u25f8":       "ultri;",
+		"\u016b":       "umacr;",
+		"\u0173":       "uogon;",
+		"\U0001d566":   "uopf;",
+		"\u03c5":       "upsi;",
+		"\u21c8":       "uuarr;",
+		"\u231d":       "urcorn;",
+		"\u230e":       "urcrop;",
+		"\u016f":       "uring;",
+		"\u25f9":       "urtri;",
+		"\U0001d4ca":   "uscr;",
+		"\u22f0":       "utdot;",
+		"\u0169":       "utilde;",
+		"\u00fc":       "uuml;",
+		"\u29a7":       "uwangle;",
+		"\u2ae8":       "vBar;",
+		"\u2ae9":       "vBarv;",
+		"\u299c":       "vangrt;",
+		"\u228a\ufe00": "vsubne;",
+		"\u2acb\ufe00": "vsubnE;",
+		"\u228b\ufe00": "vsupne;",
+		"\u2acc\ufe00": "vsupnE;",
+		"\u0432":       "vcy;",
+		"\u22bb":       "veebar;",
+		"\u225a":       "veeeq;",
+		"\u22ee":       "vellip;",
+		"\U0001d533":   "vfr;",
+		"\U0001d567":   "vopf;",
+		"\U0001d4cb":   "vscr;",
+		"\u299a":       "vzigzag;",
+		"\u0175":       "wcirc;",
+		"\u2a5f":       "wedbar;",
+		"\u2259":       "wedgeq;",
+		"\u2118":       "wp;",
+		"\U0001d534":   "wfr;",
+		"\U0001d568":   "wopf;",
+		"\U0001d4cc":   "wscr;",
+		"\U0001d535":   "xfr;",
+		"\u03be":       "xi;",
+		"\u22fb":       "xnis;",
+		"\U0001d569":   "xopf;",
+		"\U0001d4cd":   "xscr;",
+		"\u00fd":       "yacute;",
+		"\u044f":       "yacy;",
+		"\u0177":       "ycirc;",
+		"\u044b":       "ycy;",
+		"\u00a5":       "yen;",
+		"\U0001d536":   "yfr;",
+		"\u0457":       "yicy;",
+		"\U0001d56a":   "yopf;",
+		"\U0001d4ce":   "yscr;",
+		"\u044e":       "yucy;",
+		"\u00ff":       "yuml;",
+		"\u017a":       "zacute;",
+		"\u017e":       "zcaron;",
+		"\u0437":       "zcy;",
+		"\u017c":       "zdot;",
+		"\u03b6":       "zeta;",
+		"\U0001d537":   "zfr;",
+		"\u0436":       "zhcy;",
+		"\u21dd":       "zigrarr;",
+		"\U0001d56b":   "zopf;",
+		"\U0001d4cf":   "zscr;",
+		"\u200d":       "zwj;",
+		"\u200c":       "zwnj;",
+	},
+}
+
+// XMLEntities are the predefined entities of XML, see https://www.w3.org/TR/xml/#sec-predefined-ent.
+var XMLEntities = &EntityTable{
+	nodes: []entityNode{
+		{0, 4, 0}, {4, 2, 0}, {6, 1, 0}, {7, 1, 0}, {8, 1, 0}, {9, 1, 0}, {10, 1, 0}, {11, 1, 0},
+		{12, 1, 0}, {13, 1, 0}, {14, 1, 0}, {15, 1, 0}, {16, 0, 3}, {16, 0, 4}, {16, 1, 0}, {17, 0, 1},
+		{17, 1, 0}, {18, 1, 0}, {19, 0, 2}, {19, 0, 5},
+	},
+	edges: []entityEdge{
+		{'a', 1}, {'g', 2}, {'l', 3}, {'q', 4}, {'m', 5}, {'p', 6}, {'t', 7}, {'t', 8},
+		{'u', 9}, {'p', 10}, {'o', 11}, {';', 12}, {';', 13}, {'o', 14}, {';', 15}, {'s', 16},
+		{'t', 17}, {';', 18}, {';', 19},
+	},
+	values: []string{
+		"&", "'", ">", "<", "\"",
+	},
+	names: map[string]string{
+		"&":  "amp;",
+		"'":  "apos;",
+		">":  "gt;",
+		"<":  "lt;",
+		"\"": "quot;",
+	},
+}
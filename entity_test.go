@@ -0,0 +1,127 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestEntityTableLookup(t *testing.T) {
+	var tests = []struct {
+		table *EntityTable
+		b     string
+		value string
+		n     int
+	}{
+		{HTMLEntities, "amp;", "&", 4},
+		{HTMLEntities, "amp", "&", 3},
+		{HTMLEntities, "ampx", "&", 3},
+		{HTMLEntities, "AMP;", "&", 4},
+		{HTMLEntities, "notin; x", "∉", 6},
+		{HTMLEntities, "notit;", "¬", 3},
+		{HTMLEntities, "nbsp;", " ", 5},
+		{HTMLEntities, "NotEqualTilde;", "≂̸", 14},
+		{HTMLEntities, "Afr;", "\U0001D504", 4},
+		{HTMLEntities, "colon", "", 0},
+		{HTMLEntities, "xyz;", "", 0},
+		{HTMLEntities, "", "", 0},
+		{XMLEntities, "quot;", "\"", 5},
+		{XMLEntities, "amp", "", 0},
+		{XMLEntities, "nbsp;", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			value, n := tt.table.Lookup([]byte(tt.b))
+			test.String(t, value, tt.value)
+			test.T(t, n, tt.n)
+		})
+	}
+
+	value, ok := HTMLEntities.Get([]byte("lt;"))
+	test.That(t, ok)
+	test.String(t, value, "<")
+	_, ok = HTMLEntities.Get([]byte("lt;x"))
+	test.That(t, !ok)
+	_, ok = XMLEntities.Get([]byte("lt"))
+	test.That(t, !ok)
+}
+
+func TestEntityTableName(t *testing.T) {
+	var tests = []struct {
+		table *EntityTable
+		s     string
+		name  string
+	}{
+		{HTMLEntities, "&", "amp;"},
+		{HTMLEntities, "<", "lt;"},
+		{HTMLEntities, " ", "nbsp;"},
+		{HTMLEntities, "≂̸", "nesim;"},
+		{HTMLEntities, "a", ""},
+		{XMLEntities, "'", "apos;"},
+		{XMLEntities, " ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			name, ok := tt.table.Name(tt.s)
+			test.T(t, ok, tt.name != "")
+			test.String(t, name, tt.name)
+		})
+	}
+}
+
+func TestEntityTableRoundtrip(t *testing.T) {
+	for _, table := range []*EntityTable{HTMLEntities, XMLEntities} {
+		for value, name := range table.names {
+			decoded, ok := table.Get([]byte(name))
+			test.That(t, ok, name)
+			test.String(t, decoded, value, name)
+		}
+	}
+}
+
+func TestDecodeEntity(t *testing.T) {
+	var tests = []struct {
+		b      string
+		inAttr bool
+		value  string
+		n      int
+	}{
+		{"&amp;", false, "&", 5},
+		{"&amp", false, "&", 4},
+		{"&ampx", false, "&", 4},
+		{"&ampx", true, "", 0},
+		{"&amp=", true, "", 0},
+		{"&amp-", true, "&", 4},
+		{"&amp;x", true, "&", 5},
+		{"&notin;", false, "∉", 7},
+		{"&notit;", false, "¬", 4},
+		{"&notit;", true, "", 0},
+		{"&NotEqualTilde;", false, "≂̸", 15},
+		{"&nLt;", true, "≪⃒", 5},
+		{"&colon", false, "", 0},
+		{"&unknown;", false, "", 0},
+		{"&#65;", false, "A", 5},
+		{"&#65a", false, "A", 4},
+		{"&#x41;", false, "A", 6},
+		{"&#X4a", false, "J", 5},
+		{"&#0;", false, "�", 4},
+		{"&#xD800;", false, "�", 8},
+		{"&#x110000;", false, "�", 10},
+		{"&#99999999999999999999;", false, "�", 23},
+		{"&#x80;", false, "€", 6},
+		{"&#x81;", false, "\u0081", 6},
+		{"&#159;", false, "Ÿ", 6},
+		{"&#;", false, "", 0},
+		{"&#x;", false, "", 0},
+		{"&#", false, "", 0},
+		{"&", false, "", 0},
+		{"amp;", false, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			value, n := DecodeEntity([]byte(tt.b), tt.inAttr)
+			test.String(t, value, tt.value)
+			test.T(t, n, tt.n)
+		})
+	}
+}
@@ -4,35 +4,121 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strconv"
 )
 
+// Severity is the severity of an Error, for linters that report warnings and hints besides errors.
+type Severity int
+
+// Severity values, which follow the order of the Language Server Protocol.
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+// String returns the string representation of a Severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "information"
+	case SeverityHint:
+		return "hint"
+	}
+	return "Invalid(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Range is the range of source text to which an Error applies, where End is exclusive. Both are equal for errors at a single point.
+type Range struct {
+	Start, End SourcePosition
+}
+
 // Error is a parsing error returned by parser. It contains a message and an offset at which the error occurred.
 type Error struct {
 	Message string
 	Line    int
 	Column  int
 	Context string
+	File    *SourceFile // file in which the error occurred, set by SourceSet.NewError
+
+	Range    Range    // range of the error, where Range.Start has the same line and column as Line and Column
+	Code     string   // stable machine-readable code, such as unexpected-token, empty if not set
+	Severity Severity // zero for errors
 }
 
-// NewError creates a new error
+// NewError creates a new error. When r is an Input returned by NewInputCharset, the offset of its range is mapped to the original bytes, see SourceOffset.
 func NewError(r io.Reader, offset int, message string, a ...interface{}) *Error {
 	line, column, context := Position(r, offset)
 	if 0 < len(a) {
 		message = fmt.Sprintf(message, a...)
 	}
+	pos := SourcePosition{Offset: offset, Line: line, Column: column}
+	if z, ok := r.(*Input); ok {
+		pos.Offset = z.SourceOffset(offset)
+	}
 	return &Error{
 		Message: message,
 		Line:    line,
 		Column:  column,
 		Context: context,
+		Range:   Range{pos, pos},
 	}
 }
 
+// NewErrorRange creates a new error for the range from offset start up to end, such as the span of a token, which linters can underline instead of a single point.
+func NewErrorRange(r io.Reader, start, end int, message string, a ...interface{}) *Error {
+	b := NewInput(r).Bytes()
+	err := NewError(bytes.NewReader(b), start, message, a...)
+	if start < end {
+		line, column, _ := Position(bytes.NewReader(b), end)
+		err.Range.End = SourcePosition{Offset: end, Line: line, Column: column}
+	}
+	if z, ok := r.(*Input); ok {
+		err.Range.Start.Offset = z.SourceOffset(err.Range.Start.Offset)
+		err.Range.End.Offset = z.SourceOffset(err.Range.End.Offset)
+	}
+	return err
+}
+
 // NewErrorLexer creates a new error from an active Lexer.
 func NewErrorLexer(l *Input, message string, a ...interface{}) *Error {
 	r := bytes.NewBuffer(l.Bytes())
-	offset := l.Offset()
-	return NewError(r, offset, message, a...)
+	offset := l.Offset() - l.Freed()
+	err := NewError(r, offset, message, a...)
+	if 0 < l.Freed() {
+		err.Line, err.Column = l.Position() // the buffer of a streaming Input does not start at the first line
+	}
+	pos := SourcePosition{Offset: l.Offset(), Line: err.Line, Column: err.Column}
+	err.Range = Range{pos, pos}
+	return err
+}
+
+// NewErrorLexerRange creates a new error from an active Lexer for the range from offset start, such as the start of the current token, up to the current offset. Offsets are those of Input.Offset.
+func NewErrorLexerRange(l *Input, start int, message string, a ...interface{}) *Error {
+	end := l.Offset()
+	if start < l.Freed() {
+		start = l.Freed()
+	} else if end < start {
+		start = end
+	}
+	err := NewError(bytes.NewBuffer(l.Bytes()), start-l.Freed(), message, a...)
+	if 0 < l.Freed() {
+		err.Line, err.Column = l.PositionAt(start) // the buffer of a streaming Input does not start at the first line
+	}
+	endLine, endColumn, _ := Position(bytes.NewBuffer(l.Bytes()), end-l.Freed())
+	if 0 < l.Freed() {
+		endLine, endColumn = l.PositionAt(end)
+	}
+	err.Range = Range{
+		Start: SourcePosition{Offset: start, Line: err.Line, Column: err.Column},
+		End:   SourcePosition{Offset: end, Line: endLine, Column: endColumn},
+	}
+	return err
 }
 
 // Position returns the line, column, and context of the error.
@@ -41,7 +127,14 @@ func (e *Error) Position() (int, int, string) {
 	return e.Line, e.Column, e.Context
 }
 
-// Error returns the error string, containing the context and line + column number.
+// Error returns the error string, containing the context and line + column number, and the file name and include stack if File is set.
 func (e *Error) Error() string {
-	return fmt.Sprintf("%s on line %d and column %d\n%s", e.Message, e.Line, e.Column, e.Context)
+	if e.File == nil {
+		return fmt.Sprintf("%s on line %d and column %d\n%s", e.Message, e.Line, e.Column, e.Context)
+	}
+	s := fmt.Sprintf("%s in %s on line %d and column %d", e.Message, e.File.Name, e.Line, e.Column)
+	for f := e.File; f.Parent != nil; f = f.Parent {
+		s += "\n\tincluded from " + f.Parent.Position(f.IncludeOffset).String()
+	}
+	return s + "\n" + e.Context
 }
@@ -2,7 +2,9 @@ package parse
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/tdewolff/test"
 )
@@ -35,3 +37,45 @@ func TestErrorMessages(t *testing.T) {
 	err := NewError(bytes.NewBufferString("buffer"), 3, "message %d", 5)
 	test.T(t, err.Error(), "message 5 on line 1 and column 4\n    1: buffer\n          ^", "error")
 }
+
+func TestErrorRange(t *testing.T) {
+	err := NewError(bytes.NewBufferString("buffer"), 3, "message")
+	test.T(t, err.Range.Start, SourcePosition{Offset: 3, Line: 1, Column: 4})
+	test.T(t, err.Range.End, err.Range.Start)
+	test.T(t, err.Severity, SeverityError)
+	test.String(t, err.Code, "")
+
+	err = NewErrorRange(bytes.NewBufferString("a {\n  colr: red;\n}"), 6, 10, "unknown property")
+	err.Code = "unknown-property"
+	err.Severity = SeverityWarning
+	test.T(t, err.Line, 2)
+	test.T(t, err.Column, 3)
+	test.T(t, err.Range.Start, SourcePosition{Offset: 6, Line: 2, Column: 3})
+	test.T(t, err.Range.End, SourcePosition{Offset: 10, Line: 2, Column: 7})
+	test.String(t, err.Severity.String(), "warning")
+	test.T(t, err.Error(), "unknown property on line 2 and column 3\n    2:   colr: red;\n         ^")
+}
+
+func TestErrorLexerRange(t *testing.T) {
+	l := NewInputString("ab\ncd ef")
+	l.Move(4)
+	err := NewErrorLexer(l, "message")
+	test.T(t, err.Range.Start, SourcePosition{Offset: 4, Line: 2, Column: 2})
+	test.T(t, err.Range.End, err.Range.Start)
+
+	err = NewErrorLexerRange(l, 1, "message")
+	test.T(t, err.Line, 1)
+	test.T(t, err.Column, 2)
+	test.T(t, err.Range.Start, SourcePosition{Offset: 1, Line: 1, Column: 2})
+	test.T(t, err.Range.End, SourcePosition{Offset: 4, Line: 2, Column: 2})
+
+	// streaming input with freed data
+	l = NewStreamInputSize(iotest.OneByteReader(strings.NewReader("ab\ncd ef")), 2)
+	l.Move(5)
+	l.Skip()
+	l.Move(2)
+	l.Peek(2)
+	err = NewErrorLexerRange(l, 5, "message")
+	test.T(t, err.Range.Start, SourcePosition{Offset: 5, Line: 2, Column: 3})
+	test.T(t, err.Range.End, SourcePosition{Offset: 7, Line: 2, Column: 5})
+}
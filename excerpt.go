@@ -0,0 +1,103 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Excerpt is a range of source lines around an offset, used to render error messages with a caret under the offending character.
+type Excerpt struct {
+	Lines  [][]byte // source lines without line terminators
+	First  int      // line number of the first line
+	Line   int      // line number of the offset
+	Column int      // column number of the offset, see ColumnMode
+
+	caret []byte // text before the offset on its line
+}
+
+// LineAt returns the text of the line that contains the offset, without its line terminator, and the column number of the offset as returned by PositionAt. For a streaming Input, only the data that has not been freed is available.
+func (z *Input) LineAt(offset int) ([]byte, int) {
+	b := z.Bytes()
+	i := z.clampOffset(offset)
+	start := bytes.LastIndexByte(b[:i], '\n') + 1
+	end := len(b)
+	if j := bytes.IndexByte(b[i:], '\n'); j != -1 {
+		end = i + j
+	}
+	_, col := z.PositionAt(z.offset + i)
+	return bytes.TrimSuffix(b[start:end], []byte("\r")), col
+}
+
+// Excerpt returns the line that contains the offset and up to context lines before and after it. Lines are separated by \n only, as for Position.
+func (z *Input) Excerpt(offset, context int) Excerpt {
+	b := z.Bytes()
+	i := z.clampOffset(offset)
+	line, col := z.PositionAt(z.offset + i)
+
+	start := bytes.LastIndexByte(b[:i], '\n') + 1
+	caret := b[start:i]
+	for n := 0; n < context && 0 < start; n++ {
+		start = bytes.LastIndexByte(b[:start-1], '\n') + 1
+	}
+	end := i
+	for n := 0; n <= context; n++ {
+		j := bytes.IndexByte(b[end:], '\n')
+		if j == -1 {
+			end = len(b)
+			break
+		} else if n == context {
+			end += j
+		} else {
+			end += j + 1
+		}
+	}
+
+	lines := bytes.Split(b[start:end], []byte("\n"))
+	for k := range lines {
+		lines[k] = bytes.TrimSuffix(lines[k], []byte("\r"))
+	}
+	return Excerpt{
+		Lines:  lines,
+		First:  line - bytes.Count(b[start:i], []byte("\n")),
+		Line:   line,
+		Column: col,
+		caret:  caret,
+	}
+}
+
+// clampOffset returns the offset into Bytes for an offset in the stream.
+func (z *Input) clampOffset(offset int) int {
+	offset -= z.offset
+	if offset < 0 {
+		return 0
+	} else if n := len(z.buf) - 1; n < offset {
+		return n
+	}
+	return offset
+}
+
+// String renders the lines prefixed by their line number, with a caret below the offset. Tabs before the offset are repeated in the caret line so that the caret stays aligned.
+func (e Excerpt) String() string {
+	sb := strings.Builder{}
+	for k, text := range e.Lines {
+		line := e.First + k
+		if k != 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%5d: %s", line, text)
+		if line == e.Line {
+			sb.WriteString("\n       ")
+			for _, c := range e.caret {
+				if c == '\t' {
+					sb.WriteByte('\t')
+				} else if c < 0x80 || utf8.RuneStart(c) {
+					sb.WriteByte(' ')
+				}
+			}
+			sb.WriteString("^")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,49 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestInputLineAt(t *testing.T) {
+	var tests = []struct {
+		offset int
+		line   string
+		col    int
+	}{
+		{0, "a {", 1},
+		{3, "a {", 4},
+		{5, "\tcolor: réd;", 1},
+		{13, "\tcolor: réd;", 9},
+		{19, "}", 1},
+		{99, "}", 2},
+	}
+	z := NewInputString("a {\r\n\tcolor: réd;\n}")
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.offset), func(t *testing.T) {
+			line, col := z.LineAt(tt.offset)
+			test.String(t, string(line), tt.line)
+			test.T(t, col, tt.col)
+		})
+	}
+}
+
+func TestInputExcerpt(t *testing.T) {
+	z := NewInputString("one\ntwo\n\tthree\nfour\nfive")
+	e := z.Excerpt(9, 1)
+	test.T(t, e.First, 2)
+	test.T(t, e.Line, 3)
+	test.T(t, e.Column, 2)
+	test.T(t, len(e.Lines), 3)
+	test.String(t, e.String(), "    2: two\n    3: \tthree\n       \t^\n    4: four")
+
+	e = z.Excerpt(1, 2)
+	test.T(t, e.First, 1)
+	test.String(t, e.String(), "    1: one\n        ^\n    2: two\n    3: \tthree")
+
+	e = z.Excerpt(22, 1)
+	test.T(t, e.First, 4)
+	test.String(t, e.String(), "    4: four\n    5: five\n         ^")
+}
@@ -0,0 +1,163 @@
+package parse
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// LSPPosition is a zero-based position of the Language Server Protocol. Characters are counted in Unicode code points, which corresponds to the utf-32 position encoding of LSP 3.17.
+type LSPPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// LSPRange is a range of the Language Server Protocol, where End is exclusive.
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// LSPDiagnostic is a Diagnostic of the Language Server Protocol, see https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#diagnostic.
+type LSPDiagnostic struct {
+	Range    LSPRange `json:"range"`
+	Severity int      `json:"severity"`       // 1 for errors, 2 for warnings, 3 for information, and 4 for hints
+	Code     string   `json:"code,omitempty"` // code of the Error
+	Source   string   `json:"source,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// LSP returns the error as an LSP diagnostic, where source is the name of the tool that reports it, such as css.
+func (e *Error) LSP(source string) LSPDiagnostic {
+	r := e.span()
+	return LSPDiagnostic{
+		Range: LSPRange{
+			Start: LSPPosition{r.Start.Line - 1, r.Start.Column - 1},
+			End:   LSPPosition{r.End.Line - 1, r.End.Column - 1},
+		},
+		Severity: int(e.Severity) + 1,
+		Code:     e.Code,
+		Source:   source,
+		Message:  e.Message,
+	}
+}
+
+// LSP returns the collected errors as LSP diagnostics, see Error.LSP. Omitted errors are not included.
+func (d *Diagnostics) LSP(source string) []LSPDiagnostic {
+	diagnostics := make([]LSPDiagnostic, 0, len(d.Errors))
+	for _, err := range d.Errors {
+		diagnostics = append(diagnostics, err.LSP(source))
+	}
+	return diagnostics
+}
+
+// WriteLSP writes the collected errors as a JSON array of LSP diagnostics, such as for the diagnostics of a textDocument/publishDiagnostics notification.
+func (d *Diagnostics) WriteLSP(w io.Writer, source string) error {
+	return json.NewEncoder(w).Encode(d.LSP(source))
+}
+
+////////////////////////////////////////////////////////////////
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name string `json:"name"`
+	} `json:"driver"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri,omitempty"`
+		} `json:"artifactLocation"`
+		Region sarifRegion `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+	CharOffset  int `json:"charOffset"`
+	CharLength  int `json:"charLength"`
+}
+
+// WriteSARIF writes the collected errors as a SARIF 2.1.0 log with a single run of the named tool, so that they can be uploaded to code scanning services. The URI of each result is the file name of the error if it was created by a SourceSet, and uri otherwise. Warnings have the warning level, and information and hints the note level. Omitted errors are not included.
+func (d *Diagnostics) WriteSARIF(w io.Writer, tool, uri string) error {
+	run := sarifRun{
+		ColumnKind: "unicodeCodePoints",
+		Results:    make([]sarifResult, 0, len(d.Errors)),
+	}
+	run.Tool.Driver.Name = tool
+	for _, err := range d.Errors {
+		r := err.span()
+		loc := sarifLocation{}
+		loc.PhysicalLocation.ArtifactLocation.URI = uri
+		if r.Start.Filename != "" {
+			loc.PhysicalLocation.ArtifactLocation.URI = r.Start.Filename
+		}
+		loc.PhysicalLocation.Region = sarifRegion{
+			StartLine:   r.Start.Line,
+			StartColumn: r.Start.Column,
+			EndLine:     r.End.Line,
+			EndColumn:   r.End.Column,
+			CharOffset:  r.Start.Offset,
+			CharLength:  r.End.Offset - r.Start.Offset,
+		}
+
+		level := "error"
+		if err.Severity == SeverityWarning {
+			level = "warning"
+		} else if err.Severity != SeverityError {
+			level = "note"
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    err.Code,
+			Level:     level,
+			Message:   sarifMessage{err.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+	return json.NewEncoder(w).Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// span returns the range of the error, or the position of Line and Column if the range is not set, such as for errors that are not created by NewError.
+func (e *Error) span() Range {
+	if e.Range.Start.Line == 0 {
+		pos := SourcePosition{Line: e.Line, Column: e.Column}
+		if pos.Line < 1 {
+			pos.Line = 1
+		}
+		if pos.Column < 1 {
+			pos.Column = 1
+		}
+		return Range{pos, pos}
+	}
+	return e.Range
+}
@@ -0,0 +1,60 @@
+package parse
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestErrorLSP(t *testing.T) {
+	err := NewErrorRange(bytes.NewBufferString("a {\n  colr: red;\n}"), 6, 10, "unknown property")
+	err.Code = "unknown-property"
+	err.Severity = SeverityWarning
+	test.T(t, err.LSP("css"), LSPDiagnostic{
+		Range:    LSPRange{LSPPosition{1, 2}, LSPPosition{1, 6}},
+		Severity: 2,
+		Code:     "unknown-property",
+		Source:   "css",
+		Message:  "unknown property",
+	})
+
+	err = &Error{Message: "message", Line: 3, Column: 4}
+	test.T(t, err.LSP("").Range, LSPRange{LSPPosition{2, 3}, LSPPosition{2, 3}})
+	err = &Error{Message: "message"}
+	test.T(t, err.LSP("").Range, LSPRange{LSPPosition{0, 0}, LSPPosition{0, 0}})
+}
+
+func TestDiagnosticsLSP(t *testing.T) {
+	d := NewDiagnostics(0)
+	buf := &bytes.Buffer{}
+	test.Error(t, d.WriteLSP(buf, "css"))
+	test.String(t, buf.String(), "[]\n")
+
+	err := NewErrorRange(bytes.NewBufferString("ab\ncd"), 3, 5, "message")
+	err.Code = "code"
+	d.Add(err)
+	d.Add(NewError(bytes.NewBufferString("ab"), 1, "second"))
+	buf.Reset()
+	test.Error(t, d.WriteLSP(buf, "css"))
+	test.String(t, buf.String(), `[{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":2}},"severity":1,"code":"code","source":"css","message":"message"},{"range":{"start":{"line":0,"character":1},"end":{"line":0,"character":1}},"severity":1,"source":"css","message":"second"}]`+"\n")
+}
+
+func TestDiagnosticsSARIF(t *testing.T) {
+	s := NewSourceSet()
+	s.AddFile("a.css", NewInputString("a{}"))
+	b := s.AddFile("b.css", NewInputString("b{\ncolr:red}"))
+
+	d := NewDiagnostics(0)
+	err := s.NewErrorRange(b.Offset(3), b.Offset(7), "unknown property")
+	err.Code = "unknown-property"
+	err.Severity = SeverityHint
+	d.Add(err)
+	d.Add(NewError(bytes.NewBufferString("ab"), 1, "message"))
+
+	buf := &bytes.Buffer{}
+	test.Error(t, d.WriteSARIF(buf, "lint", "input.css"))
+	test.String(t, buf.String(), `{"version":"2.1.0","$schema":"https://json.schemastore.org/sarif-2.1.0.json","runs":[{"tool":{"driver":{"name":"lint"}},"columnKind":"unicodeCodePoints","results":[`+
+		`{"ruleId":"unknown-property","level":"note","message":{"text":"unknown property"},"locations":[{"physicalLocation":{"artifactLocation":{"uri":"b.css"},"region":{"startLine":2,"startColumn":1,"endLine":2,"endColumn":5,"charOffset":3,"charLength":4}}}]},`+
+		`{"level":"error","message":{"text":"message"},"locations":[{"physicalLocation":{"artifactLocation":{"uri":"input.css"},"region":{"startLine":1,"startColumn":2,"endLine":1,"endColumn":2,"charOffset":1,"charLength":0}}}]}]}]}`+"\n")
+}
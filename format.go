@@ -0,0 +1,137 @@
+package parse
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiBlue   = "\x1b[1;34m"
+	ansiCyan   = "\x1b[1;36m"
+)
+
+// FormatError renders an error for a terminal in the style of compiler diagnostics: the severity, code, and message, the location as file:line:column, and the source line from input with the range of the error underlined. Errors created by a SourceSet are rendered from the input of their file and list the files that include it, so that input may be nil. The errors of a Diagnostics are rendered one after another. Other errors are rendered by their Error method. If color is set, ANSI escape codes highlight the severity and the underline.
+func FormatError(err error, input *Input, color bool) string {
+	switch e := err.(type) {
+	case *Error:
+		sb := strings.Builder{}
+		e.format(&sb, input, color)
+		return sb.String()
+	case *Diagnostics:
+		sb := strings.Builder{}
+		for i, err := range e.Errors {
+			if i != 0 {
+				sb.WriteString("\n\n")
+			}
+			err.format(&sb, input, color)
+		}
+		if 0 < e.Omitted {
+			if 0 < len(e.Errors) {
+				sb.WriteString("\n\n")
+			}
+			sb.WriteString("and ")
+			sb.WriteString(strconv.Itoa(e.Omitted))
+			sb.WriteString(" more errors")
+		}
+		return sb.String()
+	}
+	return err.Error()
+}
+
+func (e *Error) format(sb *strings.Builder, input *Input, color bool) {
+	style := func(code, s string) {
+		if color {
+			sb.WriteString(code)
+			sb.WriteString(s)
+			sb.WriteString(ansiReset)
+		} else {
+			sb.WriteString(s)
+		}
+	}
+
+	severityColor := ansiRed
+	if e.Severity == SeverityWarning {
+		severityColor = ansiYellow
+	} else if e.Severity != SeverityError {
+		severityColor = ansiCyan
+	}
+	header := e.Severity.String()
+	if e.Code != "" {
+		header += "[" + e.Code + "]"
+	}
+	style(severityColor, header)
+	style(ansiBold, ": "+e.Message)
+
+	r := e.span()
+	if e.File != nil {
+		input = e.File.Input
+	}
+	var line []byte
+	if input != nil && e.Range.Start.Line != 0 {
+		line, _ = input.LineAt(r.Start.Offset)
+	}
+	lineNumber := strconv.Itoa(r.Start.Line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+
+	sb.WriteString("\n")
+	sb.WriteString(gutter)
+	style(ansiBlue, "--> ")
+	if e.File != nil {
+		sb.WriteString(e.File.Name)
+		sb.WriteString(":")
+	}
+	sb.WriteString(strconv.Itoa(r.Start.Line))
+	sb.WriteString(":")
+	sb.WriteString(strconv.Itoa(r.Start.Column))
+	if line != nil {
+		sb.WriteString("\n")
+		sb.WriteString(gutter)
+		style(ansiBlue, " |")
+		sb.WriteString("\n")
+		style(ansiBlue, lineNumber+" |")
+		if 0 < len(line) {
+			sb.WriteString(" ")
+			sb.Write(line)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(gutter)
+		style(ansiBlue, " |")
+		sb.WriteString(" ")
+
+		// underline from the start of the range up to its end or the end of the line, repeating tabs to stay aligned
+		col, n := 1, 0
+		for _, c := range string(line) {
+			if col == r.Start.Column {
+				break
+			} else if c == '\t' {
+				sb.WriteByte('\t')
+			} else {
+				sb.WriteByte(' ')
+			}
+			col++
+		}
+		if r.End.Line == r.Start.Line {
+			n = r.End.Column - r.Start.Column
+		} else if r.Start.Line < r.End.Line {
+			n = utf8.RuneCount(line) - r.Start.Column + 1
+		}
+		if n < 1 {
+			n = 1
+		}
+		style(severityColor, strings.Repeat("^", n))
+	}
+	if e.File != nil {
+		for f := e.File; f.Parent != nil; f = f.Parent {
+			sb.WriteString("\n")
+			sb.WriteString(gutter)
+			style(ansiBlue, " = ")
+			sb.WriteString("included from ")
+			sb.WriteString(f.Parent.Position(f.IncludeOffset).String())
+		}
+	}
+}
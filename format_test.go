@@ -0,0 +1,53 @@
+package parse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestFormatError(t *testing.T) {
+	src := "a {\n\tcolr: red;\n}"
+	r := NewInputString(src)
+	err := NewErrorRange(strings.NewReader(src), 5, 9, "unknown property %s", "colr")
+	err.Code = "unknown-property"
+	test.String(t, FormatError(err, r, false), "error[unknown-property]: unknown property colr\n --> 2:2\n  |\n2 | \tcolr: red;\n  | \t^^^^")
+
+	// point errors, warnings, and multi-line ranges
+	err = NewError(strings.NewReader(src), 2, "unexpected brace")
+	err.Severity = SeverityWarning
+	test.String(t, FormatError(err, r, false), "warning: unexpected brace\n --> 1:3\n  |\n1 | a {\n  |   ^")
+	err = NewErrorRange(strings.NewReader(src), 2, 16, "block")
+	test.String(t, FormatError(err, r, false), "error: block\n --> 1:3\n  |\n1 | a {\n  |   ^")
+	err = NewErrorRange(strings.NewReader("ab\nc"), 0, 4, "block")
+	test.String(t, FormatError(err, NewInputString("ab\nc"), false), "error: block\n --> 1:1\n  |\n1 | ab\n  | ^^")
+
+	// without input or range
+	err = NewError(strings.NewReader(src), 2, "unexpected brace")
+	test.String(t, FormatError(err, nil, false), "error: unexpected brace\n --> 1:3")
+	test.String(t, FormatError(&Error{Message: "message"}, nil, false), "error: message\n --> 1:1")
+	test.String(t, FormatError(errors.New("message"), r, false), "message")
+
+	// color
+	err = NewError(strings.NewReader("a"), 0, "message")
+	test.String(t, FormatError(err, NewInputString("a"), true), "\x1b[1;31merror\x1b[0m\x1b[1m: message\x1b[0m\n \x1b[1;34m--> \x1b[0m1:1\n \x1b[1;34m |\x1b[0m\n\x1b[1;34m1 |\x1b[0m a\n \x1b[1;34m |\x1b[0m \x1b[1;31m^\x1b[0m")
+}
+
+func TestFormatErrorSourceSet(t *testing.T) {
+	s := NewSourceSet()
+	main := s.AddFile("main.css", NewInputString("b{}\n@import 'a.css';"))
+	a := s.AddInclude("a.css", NewInputString("a{color:red"), main, 4)
+
+	err := s.NewErrorRange(a.Offset(2), a.Offset(7), "unknown property")
+	test.String(t, FormatError(err, nil, false), "error: unknown property\n --> a.css:1:3\n  |\n1 | a{color:red\n  |   ^^^^^\n  = included from main.css:2:1")
+}
+
+func TestFormatErrorDiagnostics(t *testing.T) {
+	src := "ab"
+	d := NewDiagnostics(1)
+	d.Add(NewError(strings.NewReader(src), 0, "first"))
+	d.Add(NewError(strings.NewReader(src), 1, "second"))
+	test.String(t, FormatError(d, NewInputString(src), false), "error: first\n --> 1:1\n  |\n1 | ab\n  | ^\n\nand 1 more errors")
+}
@@ -0,0 +1,623 @@
+package html
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// ariaValueType is the value type of an ARIA state or property, see https://www.w3.org/TR/wai-aria-1.2/#propcharacteristic_value.
+type ariaValueType uint32
+
+const (
+	ariaString ariaValueType = iota
+	ariaTrueFalse
+	ariaTrueFalseUndefined
+	ariaTristate
+	ariaIDRef
+	ariaIDRefs
+	ariaInteger
+	ariaNumber
+	ariaToken
+	ariaTokenList
+)
+
+// ariaAttr is an ARIA state or property with the roles that support it.
+type ariaAttr struct {
+	typ    ariaValueType
+	values string // space-separated allowed values of tokens
+	roles  string // space-separated supported roles, empty for global states and properties
+}
+
+// ariaAttrs are the states and properties of WAI-ARIA 1.2, see https://www.w3.org/TR/wai-aria-1.2/#state_prop_def.
+var ariaAttrs = map[string]ariaAttr{
+	// global
+	"aria-atomic":                 {ariaTrueFalse, "", ""},
+	"aria-braillelabel":           {ariaString, "", ""},
+	"aria-brailleroledescription": {ariaString, "", ""},
+	"aria-busy":                   {ariaTrueFalse, "", ""},
+	"aria-controls":               {ariaIDRefs, "", ""},
+	"aria-current":                {ariaToken, "page step location date time true false", ""},
+	"aria-describedby":            {ariaIDRefs, "", ""},
+	"aria-description":            {ariaString, "", ""},
+	"aria-details":                {ariaIDRef, "", ""},
+	"aria-disabled":               {ariaTrueFalse, "", ""},
+	"aria-dropeffect":             {ariaTokenList, "copy execute link move none popup", ""},
+	"aria-errormessage":           {ariaIDRef, "", ""},
+	"aria-flowto":                 {ariaIDRefs, "", ""},
+	"aria-grabbed":                {ariaTrueFalseUndefined, "", ""},
+	"aria-haspopup":               {ariaToken, "false true menu listbox tree grid dialog", ""},
+	"aria-hidden":                 {ariaTrueFalseUndefined, "", ""},
+	"aria-invalid":                {ariaToken, "grammar false spelling true", ""},
+	"aria-keyshortcuts":           {ariaString, "", ""},
+	"aria-label":                  {ariaString, "", ""},
+	"aria-labelledby":             {ariaIDRefs, "", ""},
+	"aria-live":                   {ariaToken, "assertive off polite", ""},
+	"aria-owns":                   {ariaIDRefs, "", ""},
+	"aria-relevant":               {ariaTokenList, "additions all removals text", ""},
+	"aria-roledescription":        {ariaString, "", ""},
+
+	// widget and relationship attributes
+	"aria-activedescendant": {ariaIDRef, "", "application combobox grid group listbox menu menubar radiogroup searchbox tablist textbox toolbar tree treegrid"},
+	"aria-autocomplete":     {ariaToken, "inline list both none", "combobox searchbox textbox"},
+	"aria-checked":          {ariaTristate, "", "checkbox menuitemcheckbox menuitemradio option radio switch treeitem"},
+	"aria-colcount":         {ariaInteger, "", "grid table treegrid"},
+	"aria-colindex":         {ariaInteger, "", "cell columnheader gridcell row rowheader"},
+	"aria-colindextext":     {ariaString, "", "cell columnheader gridcell row rowheader"},
+	"aria-colspan":          {ariaInteger, "", "cell columnheader gridcell rowheader"},
+	"aria-expanded":         {ariaTrueFalseUndefined, "", "application button checkbox columnheader combobox gridcell link listbox menuitem menuitemcheckbox menuitemradio row rowheader switch tab treeitem"},
+	"aria-level":            {ariaInteger, "", "comment heading row treeitem"},
+	"aria-modal":            {ariaTrueFalse, "", "alertdialog dialog"},
+	"aria-multiline":        {ariaTrueFalse, "", "searchbox textbox"},
+	"aria-multiselectable":  {ariaTrueFalse, "", "grid listbox tablist tree treegrid"},
+	"aria-orientation":      {ariaToken, "horizontal undefined vertical", "listbox menu menubar radiogroup scrollbar separator slider tablist toolbar tree treegrid"},
+	"aria-placeholder":      {ariaString, "", "searchbox textbox"},
+	"aria-posinset":         {ariaInteger, "", "article comment listitem menuitem menuitemcheckbox menuitemradio option radio row tab treeitem"},
+	"aria-pressed":          {ariaTristate, "", "button"},
+	"aria-readonly":         {ariaTrueFalse, "", "checkbox columnheader combobox grid gridcell listbox menuitemcheckbox menuitemradio radiogroup rowheader searchbox slider spinbutton switch textbox treegrid"},
+	"aria-required":         {ariaTrueFalse, "", "checkbox columnheader combobox gridcell listbox radiogroup rowheader searchbox spinbutton switch textbox tree treegrid"},
+	"aria-rowcount":         {ariaInteger, "", "grid table treegrid"},
+	"aria-rowindex":         {ariaInteger, "", "cell columnheader gridcell row rowheader"},
+	"aria-rowindextext":     {ariaString, "", "cell columnheader gridcell row rowheader"},
+	"aria-rowspan":          {ariaInteger, "", "cell columnheader gridcell rowheader"},
+	"aria-selected":         {ariaTrueFalseUndefined, "", "columnheader gridcell option row rowheader tab treeitem"},
+	"aria-setsize":          {ariaInteger, "", "article comment listitem menuitem menuitemcheckbox menuitemradio option radio row tab treeitem"},
+	"aria-sort":             {ariaToken, "ascending descending none other", "columnheader rowheader"},
+	"aria-valuemax":         {ariaNumber, "", "meter progressbar scrollbar separator slider spinbutton"},
+	"aria-valuemin":         {ariaNumber, "", "meter progressbar scrollbar separator slider spinbutton"},
+	"aria-valuenow":         {ariaNumber, "", "meter progressbar scrollbar separator slider spinbutton"},
+	"aria-valuetext":        {ariaString, "", "meter progressbar scrollbar separator slider spinbutton"},
+}
+
+// ariaRoles are the non-abstract roles of WAI-ARIA 1.2 with their required states and properties, see https://www.w3.org/TR/wai-aria-1.2/#role_definitions.
+var ariaRoles = map[string]string{
+	"alert":            "",
+	"alertdialog":      "",
+	"application":      "",
+	"article":          "",
+	"banner":           "",
+	"blockquote":       "",
+	"button":           "",
+	"caption":          "",
+	"cell":             "",
+	"checkbox":         "aria-checked",
+	"code":             "",
+	"columnheader":     "",
+	"combobox":         "aria-expanded",
+	"comment":          "",
+	"complementary":    "",
+	"contentinfo":      "",
+	"definition":       "",
+	"deletion":         "",
+	"dialog":           "",
+	"directory":        "",
+	"document":         "",
+	"emphasis":         "",
+	"feed":             "",
+	"figure":           "",
+	"form":             "",
+	"generic":          "",
+	"grid":             "",
+	"gridcell":         "",
+	"group":            "",
+	"heading":          "aria-level",
+	"img":              "",
+	"insertion":        "",
+	"link":             "",
+	"list":             "",
+	"listbox":          "",
+	"listitem":         "",
+	"log":              "",
+	"main":             "",
+	"mark":             "",
+	"marquee":          "",
+	"math":             "",
+	"menu":             "",
+	"menubar":          "",
+	"menuitem":         "",
+	"menuitemcheckbox": "aria-checked",
+	"menuitemradio":    "aria-checked",
+	"meter":            "aria-valuenow",
+	"navigation":       "",
+	"none":             "",
+	"note":             "",
+	"option":           "",
+	"paragraph":        "",
+	"presentation":     "",
+	"progressbar":      "",
+	"radio":            "aria-checked",
+	"radiogroup":       "",
+	"region":           "",
+	"row":              "",
+	"rowgroup":         "",
+	"rowheader":        "",
+	"scrollbar":        "aria-controls aria-valuenow",
+	"search":           "",
+	"searchbox":        "",
+	"separator":        "",
+	"slider":           "aria-valuenow",
+	"spinbutton":       "",
+	"status":           "",
+	"strong":           "",
+	"subscript":        "",
+	"suggestion":       "",
+	"superscript":      "",
+	"switch":           "aria-checked",
+	"tab":              "",
+	"table":            "",
+	"tablist":          "",
+	"tabpanel":         "",
+	"term":             "",
+	"textbox":          "",
+	"time":             "",
+	"timer":            "",
+	"toolbar":          "",
+	"tooltip":          "",
+	"tree":             "",
+	"treegrid":         "",
+	"treeitem":         "",
+}
+
+// ariaAbstractRoles are the abstract roles, which must not be used in content.
+var ariaAbstractRoles = map[string]bool{
+	"command":     true,
+	"composite":   true,
+	"input":       true,
+	"landmark":    true,
+	"range":       true,
+	"roletype":    true,
+	"section":     true,
+	"sectionhead": true,
+	"select":      true,
+	"structure":   true,
+	"widget":      true,
+	"window":      true,
+}
+
+// ariaElementRoles are the roles allowed on HTML elements, see https://www.w3.org/TR/html-aria/#docconformance. Elements with an empty list allow no role, and elements that are not listed allow any role. Elements whose allowed roles depend on their attributes are listed with those attributes, such as a[href] and input[type=checkbox].
+var ariaElementRoles = map[string]string{
+	"a[href]":                    "button checkbox menuitem menuitemcheckbox menuitemradio option radio switch tab treeitem",
+	"area":                       "",
+	"article":                    "application document feed main none presentation region",
+	"aside":                      "feed none note presentation region search",
+	"audio":                      "application",
+	"base":                       "",
+	"body":                       "",
+	"br":                         "none presentation",
+	"button":                     "checkbox combobox gridcell link menuitem menuitemcheckbox menuitemradio option radio separator slider switch tab treeitem",
+	"caption":                    "",
+	"col":                        "",
+	"colgroup":                   "",
+	"datalist":                   "",
+	"dd":                         "",
+	"details":                    "",
+	"dialog":                     "alertdialog",
+	"dl":                         "group list none presentation",
+	"dt":                         "listitem",
+	"embed":                      "application document img none presentation",
+	"fieldset":                   "group none presentation radiogroup",
+	"figcaption":                 "group none presentation",
+	"footer":                     "group none presentation",
+	"form":                       "none presentation search",
+	"h1":                         "none presentation tab",
+	"h2":                         "none presentation tab",
+	"h3":                         "none presentation tab",
+	"h4":                         "none presentation tab",
+	"h5":                         "none presentation tab",
+	"h6":                         "none presentation tab",
+	"head":                       "",
+	"header":                     "group none presentation",
+	"hr":                         "none presentation",
+	"html":                       "",
+	"iframe":                     "application document img none presentation",
+	"img":                        "button checkbox link menuitem menuitemcheckbox menuitemradio meter option progressbar radio scrollbar separator slider switch tab treeitem",
+	"img[alt=]":                  "none presentation",
+	"input[type=button]":         "checkbox combobox link menuitem menuitemcheckbox menuitemradio option radio switch tab",
+	"input[type=checkbox]":       "button menuitemcheckbox option switch",
+	"input[type=color]":          "",
+	"input[type=date]":           "",
+	"input[type=datetime-local]": "",
+	"input[type=email]":          "",
+	"input[type=file]":           "",
+	"input[type=hidden]":         "",
+	"input[type=image]":          "button checkbox link menuitem menuitemcheckbox menuitemradio radio switch",
+	"input[type=month]":          "",
+	"input[type=number]":         "",
+	"input[type=password]":       "",
+	"input[type=radio]":          "menuitemradio",
+	"input[type=range]":          "",
+	"input[type=reset]":          "",
+	"input[type=search]":         "combobox searchbox",
+	"input[type=submit]":         "",
+	"input[type=tel]":            "",
+	"input[type=text]":           "combobox searchbox spinbutton",
+	"input[type=time]":           "",
+	"input[type=url]":            "",
+	"input[type=week]":           "",
+	"label":                      "",
+	"legend":                     "",
+	"link":                       "",
+	"main":                       "",
+	"map":                        "",
+	"math":                       "",
+	"menu":                       "directory group listbox menu menubar none presentation radiogroup tablist toolbar tree",
+	"meta":                       "",
+	"meter":                      "",
+	"nav":                        "menu menubar none presentation tablist",
+	"noscript":                   "",
+	"object":                     "application document img",
+	"ol":                         "directory group listbox menu menubar none presentation radiogroup tablist toolbar tree",
+	"optgroup":                   "",
+	"option":                     "",
+	"param":                      "",
+	"picture":                    "",
+	"progress":                   "",
+	"script":                     "",
+	"search":                     "form group none presentation region search",
+	"section":                    "alert alertdialog application banner complementary contentinfo dialog document feed group log main marquee navigation none note presentation search status tabpanel",
+	"select":                     "menu",
+	"select[multiple]":           "",
+	"slot":                       "",
+	"source":                     "",
+	"style":                      "",
+	"summary":                    "",
+	"template":                   "",
+	"textarea":                   "",
+	"title":                      "",
+	"track":                      "",
+	"ul":                         "directory group listbox menu menubar none presentation radiogroup tablist toolbar tree",
+	"video":                      "application",
+	"wbr":                        "none presentation",
+}
+
+// ariaImplicitRoles are the implicit roles of HTML elements, see https://www.w3.org/TR/html-aria/#docconformance. Keys follow ariaElementRoles.
+var ariaImplicitRoles = map[string]string{
+	"a[href]":              "link",
+	"area":                 "link",
+	"article":              "article",
+	"aside":                "complementary",
+	"blockquote":           "blockquote",
+	"button":               "button",
+	"caption":              "caption",
+	"code":                 "code",
+	"datalist":             "listbox",
+	"del":                  "deletion",
+	"details":              "group",
+	"dfn":                  "term",
+	"dialog":               "dialog",
+	"em":                   "emphasis",
+	"fieldset":             "group",
+	"figure":               "figure",
+	"footer":               "contentinfo",
+	"form":                 "form",
+	"h1":                   "heading",
+	"h2":                   "heading",
+	"h3":                   "heading",
+	"h4":                   "heading",
+	"h5":                   "heading",
+	"h6":                   "heading",
+	"header":               "banner",
+	"hr":                   "separator",
+	"html":                 "document",
+	"img":                  "img",
+	"img[alt=]":            "presentation",
+	"input[type=button]":   "button",
+	"input[type=checkbox]": "checkbox",
+	"input[type=email]":    "textbox",
+	"input[type=image]":    "button",
+	"input[type=number]":   "spinbutton",
+	"input[type=radio]":    "radio",
+	"input[type=range]":    "slider",
+	"input[type=reset]":    "button",
+	"input[type=search]":   "searchbox",
+	"input[type=submit]":   "button",
+	"input[type=tel]":      "textbox",
+	"input[type=text]":     "textbox",
+	"input[type=url]":      "textbox",
+	"ins":                  "insertion",
+	"li":                   "listitem",
+	"main":                 "main",
+	"mark":                 "mark",
+	"math":                 "math",
+	"menu":                 "list",
+	"meter":                "meter",
+	"nav":                  "navigation",
+	"ol":                   "list",
+	"optgroup":             "group",
+	"option":               "option",
+	"output":               "status",
+	"p":                    "paragraph",
+	"progress":             "progressbar",
+	"search":               "search",
+	"section":              "region",
+	"select":               "combobox",
+	"select[multiple]":     "listbox",
+	"strong":               "strong",
+	"sub":                  "subscript",
+	"sup":                  "superscript",
+	"table":                "table",
+	"tbody":                "rowgroup",
+	"td":                   "cell",
+	"textarea":             "textbox",
+	"tfoot":                "rowgroup",
+	"th":                   "columnheader",
+	"thead":                "rowgroup",
+	"time":                 "time",
+	"tr":                   "row",
+	"ul":                   "list",
+}
+
+// ariaSupported maps roles to the non-global states and properties they support.
+var ariaSupported = map[string]map[string]bool{}
+
+func init() {
+	for name, attr := range ariaAttrs {
+		for _, role := range bytes.Fields([]byte(attr.roles)) {
+			if ariaSupported[string(role)] == nil {
+				ariaSupported[string(role)] = map[string]bool{}
+			}
+			ariaSupported[string(role)][name] = true
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// ARIADiagnostic is an invalid use of ARIA roles, states, or properties.
+type ARIADiagnostic struct {
+	Tag     []byte // lowercase tag name
+	Attr    []byte // lowercase attribute name, or nil for diagnostics of the element
+	Message string
+
+	Start, End int // byte offsets of the attribute, or of the start tag
+}
+
+// ariaElementAttr is an attribute of the current element.
+type ariaElementAttr struct {
+	key, val   []byte // lowercase name and unquoted value
+	start, end int
+}
+
+// ARIAChecker checks the ARIA roles, states, and properties of elements while streaming over lexer tokens.
+type ARIAChecker struct {
+	tag   []byte
+	start int
+	attrs []ariaElementAttr
+}
+
+// NewARIAChecker returns a new ARIAChecker.
+func NewARIAChecker() *ARIAChecker {
+	return &ARIAChecker{}
+}
+
+// Next processes the current token of the lexer and returns the diagnostics of an element once its start tag ends. It checks that roles exist and are allowed on the element per ARIA in HTML, that the states and properties exist, are supported by the role of the element, and have a valid value, and that the states and properties required by an explicit role are present.
+func (c *ARIAChecker) Next(l *Lexer, tt TokenType) []ARIADiagnostic {
+	switch tt {
+	case StartTagToken:
+		c.tag = l.Text()
+		c.start = l.TokenStart()
+		c.attrs = c.attrs[:0]
+	case AttributeToken:
+		if c.tag == nil {
+			break
+		}
+		val, _ := unquoteAttrVal(l.AttrVal())
+		c.attrs = append(c.attrs, ariaElementAttr{
+			key:   l.AttrKey(),
+			val:   unescapeCharRefs(val),
+			start: l.TokenStart(),
+			end:   l.TokenEnd(),
+		})
+	case StartTagCloseToken, StartTagVoidToken:
+		if c.tag == nil {
+			break
+		}
+		diags := c.check(l.TokenEnd())
+		c.tag = nil
+		return diags
+	}
+	return nil
+}
+
+// CheckARIA returns the diagnostics of all elements in document order, see ARIAChecker.
+func CheckARIA(r *parse.Input) ([]ARIADiagnostic, error) {
+	diags := []ARIADiagnostic{}
+	l := NewLexer(r)
+	c := NewARIAChecker()
+	for {
+		tt, _ := l.Next()
+		if tt == ErrorToken {
+			if l.Err() != io.EOF {
+				return diags, l.Err()
+			}
+			return diags, nil
+		}
+		diags = append(diags, c.Next(l, tt)...)
+	}
+}
+
+func (c *ARIAChecker) attr(name string) *ariaElementAttr {
+	for i := range c.attrs {
+		if string(c.attrs[i].key) == name {
+			return &c.attrs[i]
+		}
+	}
+	return nil
+}
+
+// elementKey returns the key into ariaElementRoles and ariaImplicitRoles for the element.
+func (c *ARIAChecker) elementKey() string {
+	switch tag := string(c.tag); tag {
+	case "a":
+		if c.attr("href") != nil {
+			return "a[href]"
+		}
+	case "img":
+		if alt := c.attr("alt"); alt != nil && len(alt.val) == 0 {
+			return "img[alt=]"
+		}
+	case "input":
+		typ := "text"
+		if attr := c.attr("type"); attr != nil {
+			if _, ok := ariaElementRoles["input[type="+string(parse.ToLower(parse.Copy(attr.val)))+"]"]; ok {
+				typ = string(parse.ToLower(parse.Copy(attr.val)))
+			}
+		}
+		return "input[type=" + typ + "]"
+	case "select":
+		if c.attr("multiple") != nil {
+			return "select[multiple]"
+		} else if size := c.attr("size"); size != nil {
+			if n, err := strconv.Atoi(string(bytes.TrimSpace(size.val))); err == nil && 1 < n {
+				return "select[multiple]"
+			}
+		}
+	}
+	return string(c.tag)
+}
+
+func (c *ARIAChecker) check(end int) []ARIADiagnostic {
+	diags := []ARIADiagnostic{}
+	key := c.elementKey()
+	implicit := ariaImplicitRoles[key]
+
+	// the first known role is used, the others are fallbacks
+	role := ""
+	if attr := c.attr("role"); attr != nil {
+		for _, token := range bytes.Fields(parse.ToLower(parse.Copy(attr.val))) {
+			name := string(token)
+			if _, ok := ariaRoles[name]; ok || isExtensionRole(name) {
+				if role == "" {
+					role = name
+				}
+			} else if ariaAbstractRoles[name] {
+				diags = append(diags, c.diagnostic(attr, "abstract role "+strconv.Quote(name)+" must not be used"))
+			} else {
+				diags = append(diags, c.diagnostic(attr, "unknown role "+strconv.Quote(name)))
+			}
+		}
+		if allowed, ok := ariaElementRoles[key]; role != "" && role != implicit && ok && !isExtensionRole(role) && !hasField(allowed, role) {
+			diags = append(diags, c.diagnostic(attr, "role "+strconv.Quote(role)+" is not allowed on <"+string(c.tag)+">"))
+		}
+	}
+
+	effective := role
+	if effective == "" {
+		effective = implicit
+	}
+	for i := range c.attrs {
+		attr := &c.attrs[i]
+		if !bytes.HasPrefix(attr.key, []byte("aria-")) {
+			continue
+		}
+		def, ok := ariaAttrs[string(attr.key)]
+		if !ok {
+			diags = append(diags, c.diagnostic(attr, "unknown attribute "+string(attr.key)))
+			continue
+		} else if def.roles != "" && !ariaSupported[effective][string(attr.key)] {
+			if effective == "" {
+				diags = append(diags, c.diagnostic(attr, string(attr.key)+" is not supported on <"+string(c.tag)+"> without a role"))
+			} else {
+				diags = append(diags, c.diagnostic(attr, string(attr.key)+" is not supported by role "+strconv.Quote(effective)))
+			}
+		}
+		if !validARIAValue(def, attr.val) {
+			diags = append(diags, c.diagnostic(attr, "bad value "+strconv.Quote(string(attr.val))+" for "+string(attr.key)))
+		}
+	}
+
+	// native elements provide the required states and properties of their implicit role
+	if role != "" && role != implicit && string(c.tag) != "input" {
+		for _, required := range bytes.Fields([]byte(ariaRoles[role])) {
+			if c.attr(string(required)) == nil {
+				diags = append(diags, ARIADiagnostic{
+					Tag:     c.tag,
+					Message: "role " + strconv.Quote(role) + " requires " + string(required),
+					Start:   c.start,
+					End:     end,
+				})
+			}
+		}
+	}
+	return diags
+}
+
+func (c *ARIAChecker) diagnostic(attr *ariaElementAttr, msg string) ARIADiagnostic {
+	return ARIADiagnostic{
+		Tag:     c.tag,
+		Attr:    attr.key,
+		Message: msg,
+		Start:   attr.start,
+		End:     attr.end,
+	}
+}
+
+// isExtensionRole returns true for the roles of the DPUB-ARIA and Graphics ARIA modules, which are not checked.
+func isExtensionRole(role string) bool {
+	return 4 < len(role) && role[:4] == "doc-" || 9 < len(role) && role[:9] == "graphics-"
+}
+
+func hasField(list, field string) bool {
+	for _, f := range bytes.Fields([]byte(list)) {
+		if string(f) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// validARIAValue returns true if the value is valid for the state or property. Empty values are valid since they are treated as if the attribute is absent.
+func validARIAValue(def ariaAttr, val []byte) bool {
+	val = bytes.TrimSpace(val)
+	if len(val) == 0 {
+		return true
+	}
+	lower := string(parse.ToLower(parse.Copy(val)))
+	switch def.typ {
+	case ariaTrueFalse:
+		return lower == "true" || lower == "false"
+	case ariaTrueFalseUndefined:
+		return lower == "true" || lower == "false" || lower == "undefined"
+	case ariaTristate:
+		return lower == "true" || lower == "false" || lower == "mixed" || lower == "undefined"
+	case ariaIDRef:
+		return len(bytes.Fields(val)) == 1
+	case ariaInteger:
+		_, err := strconv.Atoi(string(val))
+		return err == nil
+	case ariaNumber:
+		_, err := strconv.ParseFloat(string(val), 64)
+		return err == nil
+	case ariaToken:
+		return hasField(def.values, lower)
+	case ariaTokenList:
+		for _, token := range bytes.Fields([]byte(lower)) {
+			if !hasField(def.values, string(token)) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package html
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestCheckARIA(t *testing.T) {
+	var tests = []struct {
+		html     string
+		expected []string
+	}{
+		{`<div role="button" aria-pressed="true">`, []string{}},
+		{`<button aria-expanded=false aria-haspopup=menu>`, []string{}},
+		{`<div role="doc-chapter">`, []string{}},
+		{`<div role="foo button">`, []string{`role: unknown role "foo"`}},
+		{`<div role="widget">`, []string{`role: abstract role "widget" must not be used`}},
+		{`<div aria-foo="x">`, []string{`aria-foo: unknown attribute aria-foo`}},
+		{`<div aria-hidden="yes">`, []string{`aria-hidden: bad value "yes" for aria-hidden`}},
+		{`<div aria-live="POLITE" aria-relevant="additions text">`, []string{}},
+		{`<div aria-relevant="additions deletions">`, []string{`aria-relevant: bad value "additions deletions" for aria-relevant`}},
+		{`<div role=slider aria-valuenow=1.5e2 aria-valuemin="x">`, []string{`aria-valuemin: bad value "x" for aria-valuemin`}},
+		{`<div role=heading aria-level=two>`, []string{`aria-level: bad value "two" for aria-level`}},
+		{`<div aria-checked=true>`, []string{`aria-checked: aria-checked is not supported on <div> without a role`}},
+		{`<a href="#" aria-checked=true>`, []string{`aria-checked: aria-checked is not supported by role "link"`}},
+		{`<span role=checkbox>`, []string{`role "checkbox" requires aria-checked`}},
+		{`<span role=scrollbar aria-controls=main>`, []string{`role "scrollbar" requires aria-valuenow`}},
+		{`<input type=checkbox role=switch>`, []string{}},
+		{`<h2 role=heading>`, []string{}},
+		{`<h2 role=button>`, []string{`role: role "button" is not allowed on <h2>`}},
+		{`<a href=/ role=tab aria-selected=true>`, []string{}},
+		{`<a role=heading aria-level=2>`, []string{}},
+		{`<img alt="" role=img>`, []string{`role: role "img" is not allowed on <img>`}},
+		{`<img alt="logo" role=img>`, []string{}},
+		{`<input role=combobox aria-expanded=false>`, []string{}},
+		{`<input type=range role=button>`, []string{`role: role "button" is not allowed on <input>`}},
+		{`<select role=menu>`, []string{}},
+		{`<select size=4 role=menu>`, []string{`role: role "menu" is not allowed on <select>`}},
+		{`<select multiple aria-multiselectable=true>`, []string{}},
+		{`<ul role=none aria-required=true>`, []string{`aria-required: aria-required is not supported by role "none"`}},
+		{`<p aria-label="x" aria-describedby="a b">`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			diags, err := CheckARIA(parse.NewInputString(tt.html))
+			test.Error(t, err)
+			messages := []string{}
+			for _, diag := range diags {
+				if diag.Attr != nil {
+					messages = append(messages, fmt.Sprintf("%s: %s", diag.Attr, diag.Message))
+				} else {
+					messages = append(messages, diag.Message)
+				}
+			}
+			test.T(t, messages, tt.expected)
+		})
+	}
+}
+
+func TestCheckARIASpans(t *testing.T) {
+	html := `<p>text</p><span  role="checkbox" aria-checked='maybe'/>`
+	diags, err := CheckARIA(parse.NewInputString(html))
+	test.Error(t, err)
+	test.T(t, len(diags), 1)
+	test.String(t, string(diags[0].Tag), "span")
+	test.String(t, html[diags[0].Start:diags[0].End], "aria-checked='maybe'")
+
+	html = `<div><span role="checkbox"></span></div>`
+	diags, err = CheckARIA(parse.NewInputString(html))
+	test.Error(t, err)
+	test.T(t, len(diags), 1)
+	test.String(t, html[diags[0].Start:diags[0].End], `<span role="checkbox">`)
+}
+
+func TestARIATables(t *testing.T) {
+	for name, attr := range ariaAttrs {
+		for _, role := range strings.Fields(attr.roles) {
+			_, ok := ariaRoles[role]
+			test.That(t, ok, name, "supported by unknown role", role)
+		}
+		if attr.typ == ariaToken || attr.typ == ariaTokenList {
+			test.That(t, attr.values != "", name, "has no values")
+		}
+	}
+	for role, required := range ariaRoles {
+		for _, name := range strings.Fields(required) {
+			_, ok := ariaAttrs[name]
+			test.That(t, ok, role, "requires unknown attribute", name)
+		}
+	}
+	for key, roles := range ariaElementRoles {
+		for _, role := range strings.Fields(roles) {
+			_, ok := ariaRoles[role]
+			test.That(t, ok, key, "allows unknown role", role)
+		}
+	}
+	for key, role := range ariaImplicitRoles {
+		_, ok := ariaRoles[role]
+		test.That(t, ok, key, "has unknown implicit role", role)
+	}
+}
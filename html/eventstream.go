@@ -0,0 +1,145 @@
+package html
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Event is a block of fields of a text/event-stream, which is terminated by a blank line, see https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation. A block without data fields does not dispatch an event in EventSource, but may still set the last event ID and the reconnection time.
+type Event struct {
+	Type  []byte // value of the last event field, nil if omitted which implies message
+	Data  []byte // values of the data fields joined by newlines, nil if there are no data fields
+	ID    []byte // value of the last id field, nil if omitted
+	Retry int    // reconnection time in milliseconds of the last valid retry field, -1 if omitted
+
+	Start, End int // byte offsets of the block in the stream, excluding the terminating blank line
+}
+
+// EventStream reads the events of a text/event-stream, such as a response of a server-sent events endpoint. It reads the input line by line, so that it works with a streaming Input for long-lived connections.
+type EventStream struct {
+	r     *parse.Input
+	first bool
+}
+
+// NewEventStream returns a new EventStream for a given parse.Input.
+func NewEventStream(r *parse.Input) *EventStream {
+	return &EventStream{
+		r:     r,
+		first: true,
+	}
+}
+
+// ParseEventStream returns all events of a text/event-stream, see EventStream.
+func ParseEventStream(r *parse.Input) ([]Event, error) {
+	events := []Event{}
+	s := NewEventStream(r)
+	for {
+		ev, err := s.Next()
+		if err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return events, err
+		}
+		events = append(events, ev)
+	}
+}
+
+// Next returns the next block that contains at least one field, skipping blocks of comments only. It returns io.EOF at the end of the stream, where an unterminated block is discarded as it would not be dispatched by EventSource, or the error of the Input.
+func (s *EventStream) Next() (Event, error) {
+	if s.first {
+		if s.r.Peek(0) == 0xEF && s.r.Peek(1) == 0xBB && s.r.Peek(2) == 0xBF {
+			s.r.Move(3)
+			s.r.Skip()
+		}
+		s.first = false
+	}
+
+	ev := Event{Retry: -1, Start: -1}
+	hasFields := false
+	for {
+		start := s.r.Offset()
+		line, ok := s.line()
+		if !ok {
+			if err := s.r.Err(); err != io.EOF {
+				return Event{}, err
+			}
+			return Event{}, io.EOF
+		} else if len(line) == 0 {
+			if hasFields {
+				if ev.Data != nil {
+					ev.Data = ev.Data[:len(ev.Data)-1]
+				}
+				return ev, nil
+			}
+			ev.Start = -1
+			continue
+		}
+
+		if ev.Start == -1 {
+			ev.Start = start
+		}
+		ev.End = start + len(line)
+		if line[0] == ':' {
+			continue // comment
+		}
+		hasFields = true
+
+		field, value := line, line[len(line):]
+		if i := bytes.IndexByte(line, ':'); i != -1 {
+			field, value = line[:i], line[i+1:]
+			if 0 < len(value) && value[0] == ' ' {
+				value = value[1:]
+			}
+		}
+		switch string(field) {
+		case "event":
+			ev.Type = value
+		case "data":
+			ev.Data = append(append(ev.Data, value...), '\n')
+		case "id":
+			if bytes.IndexByte(value, 0) == -1 {
+				ev.ID = value
+			}
+		case "retry":
+			if retry, ok := parseDigits(value); ok {
+				ev.Retry = retry
+			}
+		}
+	}
+}
+
+// line returns the next line without its terminator, which is CRLF, LF, or CR. It returns false at the end of the stream if the line is not terminated.
+func (s *EventStream) line() ([]byte, bool) {
+	for {
+		c := s.r.Peek(0)
+		if c == '\n' || c == '\r' {
+			break
+		} else if c == 0 && s.r.Err() != nil {
+			return s.r.Shift(), false
+		}
+		s.r.Move(1)
+	}
+	line := s.r.Shift()
+	if s.r.Peek(0) == '\r' && s.r.Peek(1) == '\n' {
+		s.r.Move(2)
+	} else {
+		s.r.Move(1)
+	}
+	s.r.Skip()
+	return line, true
+}
+
+// parseDigits parses a non-empty sequence of ASCII digits, clamping the value to avoid overflow.
+func parseDigits(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || '9' < c {
+			return 0, false
+		} else if n < 1e9 {
+			n = n*10 + int(c-'0')
+		}
+	}
+	return n, 0 < len(b)
+}
@@ -0,0 +1,65 @@
+package html
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestEventStream(t *testing.T) {
+	var tests = []struct {
+		stream   string
+		expected string
+	}{
+		{"data: hello\n\n", `<nil> "hello" <nil> -1 0-11`},
+		{"data:a\ndata\ndata: b\r\n\r\n", `<nil> "a\n\nb" <nil> -1 0-19`},
+		{"event: add\rdata: 1\rid: 7\rretry: 300\r\r", `"add" "1" "7" 300 0-35`},
+		{": ping\n\ndata: x\n\n", `<nil> "x" <nil> -1 8-15`},
+		{": ping\ndata: x\n\n", `<nil> "x" <nil> -1 0-14`},
+		{"id\n\n", `<nil> <nil> "" -1 0-2`},
+		{"id: a\x00b\nretry: 1s\nunknown: x\n\n", `<nil> <nil> <nil> -1 0-28`},
+		{"data:  two spaces\n\n", `<nil> " two spaces" <nil> -1 0-17`},
+		{"data: a\n\n\n\ndata: b\n\n", `<nil> "a" <nil> -1 0-7; <nil> "b" <nil> -1 11-18`},
+		{"\xEF\xBB\xBFdata: bom\n\n", `<nil> "bom" <nil> -1 3-12`},
+		{"data: unterminated\n", ``},
+		{"data: unterminated", ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stream, func(t *testing.T) {
+			events, err := ParseEventStream(parse.NewInputString(tt.stream))
+			test.Error(t, err)
+			s := []string{}
+			for _, ev := range events {
+				s = append(s, fmt.Sprintf("%s %s %s %d %d-%d", quoteOrNil(ev.Type), quoteOrNil(ev.Data), quoteOrNil(ev.ID), ev.Retry, ev.Start, ev.End))
+			}
+			test.String(t, strings.Join(s, "; "), tt.expected)
+		})
+	}
+}
+
+func TestEventStreamStreaming(t *testing.T) {
+	r := iotest.OneByteReader(strings.NewReader("data: a\n\nevent: b\ndata: c\n\n"))
+	s := NewEventStream(parse.NewStreamInputSize(r, 2))
+	ev, err := s.Next()
+	test.Error(t, err)
+	test.String(t, string(ev.Data), "a")
+	ev, err = s.Next()
+	test.Error(t, err)
+	test.String(t, string(ev.Type), "b")
+	test.String(t, string(ev.Data), "c")
+	test.T(t, ev.Start, 9)
+	_, err = s.Next()
+	test.T(t, err, io.EOF)
+}
+
+func quoteOrNil(b []byte) string {
+	if b == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%q", b)
+}
@@ -0,0 +1,396 @@
+package html
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Form is a form element with its form-associated fields and submit buttons, which is the model that is submitted and validated by browsers.
+type Form struct {
+	ID, Name   []byte
+	Action     []byte // URL as written, with character references decoded and surrounding whitespace removed
+	Method     []byte // lowercase get, post, or dialog, get by default
+	Enctype    []byte // lowercase application/x-www-form-urlencoded (default), multipart/form-data, or text/plain
+	NoValidate bool
+
+	Fields     []FormField
+	Submitters []FormSubmitter
+
+	Start, End int // byte offsets of the start tag
+}
+
+// FormField is an input, select, or textarea element of a form, excluding buttons. Attribute values have their character references decoded.
+type FormField struct {
+	Tag  []byte // lowercase tag name
+	Type []byte // lowercase type of input elements, text by default, and select, select-multiple, or textarea otherwise
+	Name []byte
+	// Value is the value attribute of input elements, or the text of textarea elements.
+	Value []byte
+	// Options are the values of the options of select elements, which is their value attribute or else their text with whitespace collapsed.
+	Options [][]byte
+
+	Required, Checked, Multiple bool
+	// Disabled is set for disabled fields and for fields in a disabled fieldset that are not in its first legend, which are neither submitted nor validated.
+	Disabled bool
+	// ReadOnly is set for readonly fields, which are submitted but not validated.
+	ReadOnly bool
+
+	Pattern              []byte
+	Min, Max, Step       []byte
+	MinLength, MaxLength int // -1 when absent or invalid
+	Accept               []byte
+
+	Start, End int // byte offsets of the start tag
+}
+
+// FormSubmitter is a button or input element that submits its form, whose formaction, formmethod, formenctype, and formnovalidate attributes override those of the form.
+type FormSubmitter struct {
+	Tag         []byte // lowercase tag name
+	Type        []byte // lowercase submit or image
+	Name, Value []byte
+
+	FormAction     []byte // URL as written, with character references decoded and surrounding whitespace removed, nil when absent
+	FormMethod     []byte // lowercase, nil when absent or invalid
+	FormEnctype    []byte // lowercase, nil when absent or invalid
+	FormNoValidate bool
+	Disabled       bool
+
+	Start, End int // byte offsets of the start tag
+}
+
+var (
+	getBytes            = []byte("get")
+	urlencodedBytes     = []byte("application/x-www-form-urlencoded")
+	textBytes           = []byte("text")
+	submitBytes         = []byte("submit")
+	selectBytes         = []byte("select")
+	selectMultipleBytes = []byte("select-multiple")
+	textareaBytes       = []byte("textarea")
+)
+
+// inputTypes are the valid types of input elements, see https://html.spec.whatwg.org/multipage/input.html#attr-input-type.
+var inputTypes = map[string]bool{
+	"hidden":         true,
+	"text":           true,
+	"search":         true,
+	"tel":            true,
+	"url":            true,
+	"email":          true,
+	"password":       true,
+	"date":           true,
+	"month":          true,
+	"week":           true,
+	"time":           true,
+	"datetime-local": true,
+	"number":         true,
+	"range":          true,
+	"color":          true,
+	"checkbox":       true,
+	"radio":          true,
+	"file":           true,
+	"submit":         true,
+	"image":          true,
+	"reset":          true,
+	"button":         true,
+}
+
+// formAttr is an attribute of the current element.
+type formAttr struct {
+	key, val []byte // lowercase name and decoded value
+}
+
+// formControl is a field or submitter with its form owner.
+type formControl struct {
+	field     *FormField
+	submitter *FormSubmitter
+	form      int    // index of the parent form, or -1
+	formID    []byte // value of the form attribute, which takes precedence over the parent form
+	hasFormID bool
+}
+
+// formFieldset is an open fieldset element.
+type formFieldset struct {
+	disabled   bool
+	legendSeen bool
+}
+
+// Forms returns the forms of the document in document order with their fields and submit buttons. Fields belong to their form owner as browsers determine it: the form referenced by their form attribute, or else the form they are in. Nested forms are ignored as in browsers, and fields without a form owner are not returned.
+func Forms(r *parse.Input) ([]Form, error) {
+	forms := []Form{}
+	controls := []formControl{}
+	fieldsets := []formFieldset{}
+	form := -1 // index of the open form
+	legend := 0
+	var option *[]byte // text of the open option
+	var selectField *FormField
+	var textareaField *FormField
+
+	l := NewLexer(r)
+	var tag []byte
+	var start int
+	attrs := []formAttr{}
+	for {
+		tt, data := l.Next()
+		switch tt {
+		case ErrorToken:
+			for _, c := range controls {
+				owner := c.form
+				if c.hasFormID {
+					owner = -1
+					for i := range forms {
+						if string(forms[i].ID) == string(c.formID) {
+							owner = i
+							break
+						}
+					}
+				}
+				if owner == -1 {
+					continue
+				} else if c.field != nil {
+					forms[owner].Fields = append(forms[owner].Fields, *c.field)
+				} else {
+					forms[owner].Submitters = append(forms[owner].Submitters, *c.submitter)
+				}
+			}
+			if l.Err() != io.EOF {
+				return forms, l.Err()
+			}
+			return forms, nil
+		case StartTagToken:
+			tag = l.Text()
+			start = l.TokenStart()
+			attrs = attrs[:0]
+		case AttributeToken:
+			val, _ := unquoteAttrVal(l.AttrVal())
+			attrs = append(attrs, formAttr{l.AttrKey(), unescapeCharRefs(val)})
+		case TextToken:
+			if textareaField != nil {
+				textareaField.Value = append(textareaField.Value, unescapeCharRefs(data)...)
+			} else if option != nil {
+				*option = append(*option, unescapeCharRefs(data)...)
+			}
+		case EndTagToken:
+			switch string(l.Text()) {
+			case "form":
+				form = -1
+			case "fieldset":
+				if 0 < len(fieldsets) {
+					fieldsets = fieldsets[:len(fieldsets)-1]
+				}
+				if len(fieldsets) < legend {
+					legend = 0
+				}
+			case "legend":
+				legend = 0
+			case "option", "optgroup":
+				closeOption(&option)
+			case "select":
+				closeOption(&option)
+				selectField = nil
+			case "textarea":
+				textareaField = nil
+			}
+		case StartTagCloseToken, StartTagVoidToken:
+			if tag == nil {
+				break
+			}
+			end := l.TokenEnd()
+			disabled := isFieldsetDisabled(fieldsets, legend)
+			if _, ok := formAttrVal(attrs, "disabled"); ok {
+				disabled = true
+			}
+			c := formControl{form: form}
+			c.formID, c.hasFormID = formAttrVal(attrs, "form")
+
+			switch string(tag) {
+			case "form":
+				if form != -1 {
+					break // nested forms are ignored
+				}
+				f := Form{
+					Method:  getBytes,
+					Enctype: urlencodedBytes,
+					Start:   start,
+					End:     end,
+				}
+				f.ID, _ = formAttrVal(attrs, "id")
+				f.Name, _ = formAttrVal(attrs, "name")
+				if action, ok := formAttrVal(attrs, "action"); ok {
+					f.Action = stripURLWhitespace(action)
+				}
+				if method := formMethod(attrs, "method"); method != nil {
+					f.Method = method
+				}
+				if enctype := formEnctype(attrs, "enctype"); enctype != nil {
+					f.Enctype = enctype
+				}
+				_, f.NoValidate = formAttrVal(attrs, "novalidate")
+				forms = append(forms, f)
+				form = len(forms) - 1
+			case "fieldset":
+				fieldsets = append(fieldsets, formFieldset{disabled: disabled})
+			case "legend":
+				if 0 < len(fieldsets) && !fieldsets[len(fieldsets)-1].legendSeen {
+					fieldsets[len(fieldsets)-1].legendSeen = true
+					legend = len(fieldsets)
+				}
+			case "input", "button":
+				typ, _ := formAttrVal(attrs, "type")
+				typ = parse.ToLower(parse.Copy(typ))
+				if string(tag) == "button" {
+					if string(typ) == "reset" || string(typ) == "button" {
+						break
+					}
+					typ = submitBytes // buttons with a missing or invalid type are submit buttons
+				} else if !inputTypes[string(typ)] {
+					typ = textBytes
+				}
+				if string(typ) == "submit" || string(typ) == "image" {
+					s := &FormSubmitter{
+						Tag:         tag,
+						Type:        typ,
+						FormMethod:  formMethod(attrs, "formmethod"),
+						FormEnctype: formEnctype(attrs, "formenctype"),
+						Disabled:    disabled,
+						Start:       start,
+						End:         end,
+					}
+					s.Name, _ = formAttrVal(attrs, "name")
+					s.Value, _ = formAttrVal(attrs, "value")
+					if action, ok := formAttrVal(attrs, "formaction"); ok {
+						s.FormAction = stripURLWhitespace(action)
+					}
+					_, s.FormNoValidate = formAttrVal(attrs, "formnovalidate")
+					c.submitter = s
+					controls = append(controls, c)
+				} else if string(typ) != "reset" && string(typ) != "button" {
+					f := newFormField(tag, typ, attrs, disabled, start, end)
+					f.Value, _ = formAttrVal(attrs, "value")
+					_, f.Checked = formAttrVal(attrs, "checked")
+					c.field = f
+					controls = append(controls, c)
+				}
+			case "select":
+				typ := selectBytes
+				if _, ok := formAttrVal(attrs, "multiple"); ok {
+					typ = selectMultipleBytes
+				}
+				c.field = newFormField(tag, typ, attrs, disabled, start, end)
+				c.field.Options = [][]byte{}
+				controls = append(controls, c)
+				if tt == StartTagCloseToken {
+					selectField = c.field
+				}
+			case "option":
+				closeOption(&option)
+				if selectField != nil {
+					if value, ok := formAttrVal(attrs, "value"); ok {
+						selectField.Options = append(selectField.Options, value)
+					} else if tt == StartTagCloseToken {
+						selectField.Options = append(selectField.Options, []byte{})
+						option = &selectField.Options[len(selectField.Options)-1]
+					}
+				}
+			case "optgroup", "hr":
+				closeOption(&option)
+			case "textarea":
+				c.field = newFormField(tag, textareaBytes, attrs, disabled, start, end)
+				c.field.Value = []byte{}
+				controls = append(controls, c)
+				if tt == StartTagCloseToken {
+					textareaField = c.field
+				}
+			}
+			tag = nil
+		}
+	}
+}
+
+// newFormField returns a field with the attributes that are common to input, select, and textarea elements.
+func newFormField(tag, typ []byte, attrs []formAttr, disabled bool, start, end int) *FormField {
+	f := &FormField{
+		Tag:       tag,
+		Type:      typ,
+		Disabled:  disabled,
+		MinLength: formAttrInt(attrs, "minlength"),
+		MaxLength: formAttrInt(attrs, "maxlength"),
+		Start:     start,
+		End:       end,
+	}
+	f.Name, _ = formAttrVal(attrs, "name")
+	f.Pattern, _ = formAttrVal(attrs, "pattern")
+	f.Min, _ = formAttrVal(attrs, "min")
+	f.Max, _ = formAttrVal(attrs, "max")
+	f.Step, _ = formAttrVal(attrs, "step")
+	f.Accept, _ = formAttrVal(attrs, "accept")
+	_, f.Required = formAttrVal(attrs, "required")
+	_, f.Multiple = formAttrVal(attrs, "multiple")
+	_, f.ReadOnly = formAttrVal(attrs, "readonly")
+	return f
+}
+
+// closeOption collapses the whitespace of the text of the open option.
+func closeOption(option **[]byte) {
+	if *option != nil {
+		**option = parse.CollapseWhitespace(**option, parse.ASCIIWhitespace)
+		*option = nil
+	}
+}
+
+// isFieldsetDisabled returns true when a field is in a disabled fieldset and not in the first legend of that fieldset. Legend is the number of open fieldsets when the open legend was opened, or zero.
+func isFieldsetDisabled(fieldsets []formFieldset, legend int) bool {
+	for i, fieldset := range fieldsets {
+		if fieldset.disabled && legend != i+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// formAttrVal returns the value of the first attribute with the given name.
+func formAttrVal(attrs []formAttr, name string) ([]byte, bool) {
+	for _, attr := range attrs {
+		if string(attr.key) == name {
+			return attr.val, true
+		}
+	}
+	return nil, false
+}
+
+// formAttrInt returns the value of a non-negative integer attribute, or -1.
+func formAttrInt(attrs []formAttr, name string) int {
+	val, _ := formAttrVal(attrs, name)
+	val = parse.TrimWhitespace(val)
+	if len(val) == 0 || val[0] < '0' || '9' < val[0] {
+		return -1
+	}
+	i, err := strconv.Atoi(string(val))
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+// formMethod returns the lowercase value of a method attribute, or nil when it is absent or invalid.
+func formMethod(attrs []formAttr, name string) []byte {
+	val, _ := formAttrVal(attrs, name)
+	val = parse.ToLower(parse.Copy(val))
+	switch string(val) {
+	case "get", "post", "dialog":
+		return val
+	}
+	return nil
+}
+
+// formEnctype returns the lowercase value of an enctype attribute, or nil when it is absent or invalid.
+func formEnctype(attrs []formAttr, name string) []byte {
+	val, _ := formAttrVal(attrs, name)
+	val = parse.ToLower(parse.Copy(val))
+	switch string(val) {
+	case "application/x-www-form-urlencoded", "multipart/form-data", "text/plain":
+		return val
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package html
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func formString(f Form) string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "%s %s %s", f.Method, f.Enctype, f.Action)
+	if f.NoValidate {
+		sb.WriteString(" novalidate")
+	}
+	for _, field := range f.Fields {
+		fmt.Fprintf(&sb, "; %s:%s=%s", field.Type, field.Name, field.Value)
+		if field.Options != nil {
+			fmt.Fprintf(&sb, "%q", field.Options)
+		}
+		if field.Required {
+			sb.WriteString(" required")
+		}
+		if field.Disabled {
+			sb.WriteString(" disabled")
+		}
+		if field.ReadOnly {
+			sb.WriteString(" readonly")
+		}
+		if field.Checked {
+			sb.WriteString(" checked")
+		}
+		if field.Pattern != nil {
+			fmt.Fprintf(&sb, " pattern=%s", field.Pattern)
+		}
+		if field.MinLength != -1 || field.MaxLength != -1 {
+			fmt.Fprintf(&sb, " length=%d-%d", field.MinLength, field.MaxLength)
+		}
+		if field.Min != nil || field.Max != nil || field.Step != nil {
+			fmt.Fprintf(&sb, " range=%s-%s/%s", field.Min, field.Max, field.Step)
+		}
+	}
+	for _, s := range f.Submitters {
+		fmt.Fprintf(&sb, "; %s %s:%s=%s", s.Tag, s.Type, s.Name, s.Value)
+		if s.FormAction != nil || s.FormMethod != nil || s.FormEnctype != nil {
+			fmt.Fprintf(&sb, " %s %s %s", s.FormMethod, s.FormEnctype, s.FormAction)
+		}
+		if s.FormNoValidate {
+			sb.WriteString(" formnovalidate")
+		}
+		if s.Disabled {
+			sb.WriteString(" disabled")
+		}
+	}
+	return sb.String()
+}
+
+func TestForms(t *testing.T) {
+	var tests = []struct {
+		html     string
+		expected []string
+	}{
+		{`<p>no form</p><input name=x>`, []string{}},
+		{`<form action=" /login " method=POST><input name=user required maxlength=32><input type=password name=pass pattern="[a-z]{8,}"><button>Log in</button></form>`, []string{
+			"post application/x-www-form-urlencoded /login; text:user= required length=-1-32; password:pass= pattern=[a-z]{8,}; button submit:=",
+		}},
+		{`<FORM method=put enctype=Multipart/Form-Data novalidate><INPUT TYPE=Number name=n min=1 max=10 step=2 value="5"><input type=bogus name=b></form>`, []string{
+			"get multipart/form-data  novalidate; number:n=5 range=1-10/2; text:b=",
+		}},
+		{`<form><input type=checkbox name=c value=1 checked><input type=radio name=r><input type=hidden name=h value="a&amp;b"><input type=reset><input type=button><button type=reset></button><button type=button></button></form>`, []string{
+			"get application/x-www-form-urlencoded ; checkbox:c=1 checked; radio:r=; hidden:h=a&b",
+		}},
+		{`<form><input type=submit name=a value=A formaction="/a" formmethod=post formenctype=text/plain formnovalidate><input type=image name=i><button type=bogus name=b value=B disabled></form>`, []string{
+			"get application/x-www-form-urlencoded ; input submit:a=A post text/plain /a formnovalidate; input image:i=; button submit:b=B disabled",
+		}},
+		{`<form><select name=s required><option>  One   &amp; two </option><option value="2">Two<optgroup><option>Three</select><select name=m multiple><option value=x selected></select></form>`, []string{
+			`get application/x-www-form-urlencoded ; select:s=["One & two" "2" "Three"] required; select-multiple:m=["x"]`,
+		}},
+		{`<form><textarea name=t minlength=2 maxlength=x readonly>a &lt; <b></textarea></form>`, []string{
+			"get application/x-www-form-urlencoded ; textarea:t=a < <b> readonly length=2--1",
+		}},
+		{`<form><fieldset disabled><legend><input name=a></legend><input name=b><legend><input name=c></legend><fieldset><input name=d></fieldset></fieldset><input name=e></form>`, []string{
+			"get application/x-www-form-urlencoded ; text:a=; text:b= disabled; text:c= disabled; text:d= disabled; text:e=",
+		}},
+
+		// form owners
+		{`<input name=a form=f><form id=f><input name=b></form><form id=g><input name=c form=f><input name=d form=h></form>`, []string{
+			"get application/x-www-form-urlencoded ; text:a=; text:b=; text:c=",
+			"get application/x-www-form-urlencoded ",
+		}},
+		{`<form><form action=x><input name=a></form><input name=b>`, []string{
+			"get application/x-www-form-urlencoded ; text:a=",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			forms, err := Forms(parse.NewInputString(tt.html))
+			test.Error(t, err)
+			s := []string{}
+			for _, f := range forms {
+				s = append(s, formString(f))
+			}
+			test.T(t, s, tt.expected)
+		})
+	}
+}
+
+func TestFormsOffsets(t *testing.T) {
+	s := `<form id=f><input name=a></form>`
+	forms, err := Forms(parse.NewInputString(s))
+	test.Error(t, err)
+	test.T(t, len(forms), 1)
+	test.String(t, s[forms[0].Start:forms[0].End], `<form id=f>`)
+	test.String(t, s[forms[0].Fields[0].Start:forms[0].Fields[0].End], `<input name=a>`)
+}
@@ -10,19 +10,21 @@ type Hash uint32
 const (
 	Iframe    Hash = 0x6    // iframe
 	Math      Hash = 0x604  // math
-	Plaintext Hash = 0x2109 // plaintext
-	Script    Hash = 0xa06  // script
-	Style     Hash = 0x1405 // style
-	Svg       Hash = 0x1903 // svg
-	Textarea  Hash = 0x2608 // textarea
-	Title     Hash = 0xf05  // title
-	Xml       Hash = 0x1c03 // xml
-	Xmp       Hash = 0x1f03 // xmp
+	Noscript  Hash = 0xa08  // noscript
+	Plaintext Hash = 0x2309 // plaintext
+	Script    Hash = 0xc06  // script
+	Style     Hash = 0x1605 // style
+	Svg       Hash = 0x1b03 // svg
+	Textarea  Hash = 0x2808 // textarea
+	Title     Hash = 0x1105 // title
+	Xml       Hash = 0x1e03 // xml
+	Xmp       Hash = 0x2103 // xmp
 )
 
 //var HashMap = map[string]Hash{
 //	"iframe": Iframe,
 //	"math": Math,
+//	"noscript": Noscript,
 //	"plaintext": Plaintext,
 //	"script": Script,
 //	"style": Style,
@@ -83,21 +85,22 @@ NEXT:
 	return 0
 }
 
-const _Hash_hash0 = 0xb4b790b3
+const _Hash_hash0 = 0x94a23b12
 const _Hash_maxLen = 9
 
 var _Hash_text = []byte("" +
-	"iframemathscriptitlestylesvgxmlxmplaintextarea")
+	"iframemathnoscriptitlestylesvgxmlxmplaintextarea")
 
 var _Hash_table = [1 << 4]Hash{
-	0x2: 0xa06,  // script
-	0x3: 0xf05,  // title
-	0x4: 0x1405, // style
-	0x5: 0x604,  // math
-	0x6: 0x6,    // iframe
-	0x8: 0x1c03, // xml
-	0x9: 0x2608, // textarea
-	0xc: 0x1f03, // xmp
-	0xe: 0x2109, // plaintext
-	0xf: 0x1903, // svg
+	0x0: 0x2808, // textarea
+	0x2: 0x6,    // iframe
+	0x3: 0x1e03, // xml
+	0x4: 0x1105, // title
+	0x5: 0x2309, // plaintext
+	0x7: 0x1605, // style
+	0x8: 0x604,  // math
+	0x9: 0xc06,  // script
+	0xb: 0x2103, // xmp
+	0xe: 0xa08,  // noscript
+	0xf: 0x1b03, // svg
 }
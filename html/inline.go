@@ -0,0 +1,174 @@
+package html
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// InlineScriptType determines the kind of inline script surface.
+type InlineScriptType uint32
+
+// InlineScriptType values.
+const (
+	EventHandlerScript InlineScriptType = iota // onclick="..."
+	JavaScriptURL                              // href="javascript:..."
+	DataURL                                    // src="data:..."
+)
+
+// String returns the string representation of an InlineScriptType.
+func (t InlineScriptType) String() string {
+	switch t {
+	case EventHandlerScript:
+		return "EventHandler"
+	case JavaScriptURL:
+		return "JavaScriptURL"
+	case DataURL:
+		return "DataURL"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// InlineScript is an inline event handler attribute, or an attribute containing a javascript: or data: URL.
+type InlineScript struct {
+	Type InlineScriptType
+	Tag  []byte // lowercase tag name
+	Attr []byte // lowercase attribute name
+
+	Start, End int // byte offsets of the attribute value without quotes
+
+	// Script is the decoded script body that can be passed to js.Parse: character references are decoded, and for URLs the scheme is removed and the remainder is percent-decoded. For data: URLs it holds the decoded data.
+	Script []byte
+	// Mediatype is the mediatype of data: URLs.
+	Mediatype []byte
+}
+
+// urlAttrs are attributes that hold a URL that is navigated to or fetched.
+var urlAttrs = map[string]bool{
+	"action":     true,
+	"background": true,
+	"cite":       true,
+	"data":       true,
+	"formaction": true,
+	"href":       true,
+	"poster":     true,
+	"src":        true,
+	"xlink:href": true,
+}
+
+var (
+	javascriptSchemeBytes = []byte("javascript:")
+	dataSchemeBytes       = []byte("data:")
+)
+
+// InlineScripts returns all inline event handler attributes (such as onclick) and all attributes with javascript: or data: URLs, in document order. This is the inventory of inline script surfaces to remove or hash when migrating to a Content Security Policy.
+func InlineScripts(r *parse.Input) ([]InlineScript, error) {
+	scripts := []InlineScript{}
+	l := NewLexer(r)
+	var tag []byte
+	for {
+		tt, _ := l.Next()
+		switch tt {
+		case ErrorToken:
+			if l.Err() != io.EOF {
+				return scripts, l.Err()
+			}
+			return scripts, nil
+		case StartTagToken:
+			tag = l.Text()
+		case AttributeToken:
+			val := l.AttrVal()
+			if val == nil {
+				continue
+			}
+			start := l.AttrValStart()
+			val, quote := unquoteAttrVal(val)
+			if quote != 0 {
+				start++
+			}
+			attr := l.AttrKey()
+			script := InlineScript{
+				Tag:   tag,
+				Attr:  attr,
+				Start: start,
+				End:   start + len(val),
+			}
+			if 2 < len(attr) && attr[0] == 'o' && attr[1] == 'n' {
+				script.Type = EventHandlerScript
+				script.Script = unescapeCharRefs(val)
+			} else if urlAttrs[string(attr)] {
+				url := stripURLWhitespace(unescapeCharRefs(val))
+				if hasSchemeFold(url, javascriptSchemeBytes) {
+					script.Type = JavaScriptURL
+					script.Script = parse.DecodeURL(url[len(javascriptSchemeBytes):])
+				} else if hasSchemeFold(url, dataSchemeBytes) {
+					script.Type = DataURL
+					url = append(parse.ToLower(url[:len(dataSchemeBytes)]), url[len(dataSchemeBytes):]...)
+					mediatype, data, err := parse.DataURI(url)
+					if err != nil {
+						continue
+					}
+					script.Mediatype = mediatype
+					script.Script = data
+				} else {
+					continue
+				}
+			} else {
+				continue
+			}
+			scripts = append(scripts, script)
+		}
+	}
+}
+
+// stripURLWhitespace removes leading and trailing C0 control characters and spaces, and all tabs and newlines, as the URL parser does.
+func stripURLWhitespace(b []byte) []byte {
+	for 0 < len(b) && b[0] <= ' ' {
+		b = b[1:]
+	}
+	for 0 < len(b) && b[len(b)-1] <= ' ' {
+		b = b[:len(b)-1]
+	}
+	if bytes.IndexAny(b, "\t\n\r") == -1 {
+		return b
+	}
+	j := 0
+	for _, c := range b {
+		if c != '\t' && c != '\n' && c != '\r' {
+			b[j] = c
+			j++
+		}
+	}
+	return b[:j]
+}
+
+func hasSchemeFold(b, scheme []byte) bool {
+	return len(scheme) <= len(b) && parse.EqualFold(b[:len(scheme)], scheme)
+}
+
+// unescapeCharRefs returns a copy of b with character references decoded as in attribute values, including those used to obfuscate URL schemes such as &colon;, see parse.DecodeEntity.
+func unescapeCharRefs(b []byte) []byte {
+	return appendCharRefs(make([]byte, 0, len(b)), b, true)
+}
+
+// appendCharRefs appends b to dst with character references decoded as in attribute values or in text, see parse.DecodeEntity.
+func appendCharRefs(dst, b []byte, inAttr bool) []byte {
+	for {
+		i := bytes.IndexByte(b, '&')
+		if i == -1 {
+			return append(dst, b...)
+		}
+		dst = append(dst, b[:i]...)
+		b = b[i:]
+
+		if value, n := parse.DecodeEntity(b, inAttr); n != 0 {
+			dst = append(dst, value...)
+			b = b[n:]
+		} else {
+			dst = append(dst, '&')
+			b = b[1:]
+		}
+	}
+}
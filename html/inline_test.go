@@ -0,0 +1,92 @@
+package html
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestInlineScripts(t *testing.T) {
+	var tests = []struct {
+		html      string
+		typ       InlineScriptType
+		tag       string
+		attr      string
+		script    string
+		mediatype string
+	}{
+		{`<a onclick="alert(1)">`, EventHandlerScript, "a", "onclick", "alert(1)", ""},
+		{`<body ONLOAD='init(&quot;x&quot;)'>`, EventHandlerScript, "body", "onload", `init("x")`, ""},
+		{`<a href=javascript:void(0)>`, JavaScriptURL, "a", "href", "void(0)", ""},
+		{`<a href=" JaVaScRiPt:alert(%22x%22)">`, JavaScriptURL, "a", "href", `alert("x")`, ""},
+		{`<a href="java&#x09;script&colon;x()">`, JavaScriptURL, "a", "href", "x()", ""},
+		{`<form action="javascript:go()">`, JavaScriptURL, "form", "action", "go()", ""},
+		{`<script src="data:text/javascript,alert(1)">`, DataURL, "script", "src", "alert(1)", "text/javascript"},
+		{`<img src="DATA:image/png;base64,eA==">`, DataURL, "img", "src", "x", "image/png"},
+		{`<a onclick= >x</a>`, EventHandlerScript, "a", "onclick", "", ""},
+		{`<a onclick=`, EventHandlerScript, "a", "onclick", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			scripts, err := InlineScripts(parse.NewInputString(tt.html))
+			test.Error(t, err)
+			test.T(t, len(scripts), 1)
+			test.T(t, scripts[0].Type, tt.typ)
+			test.String(t, string(scripts[0].Tag), tt.tag)
+			test.String(t, string(scripts[0].Attr), tt.attr)
+			test.String(t, string(scripts[0].Script), tt.script)
+			test.String(t, string(scripts[0].Mediatype), tt.mediatype)
+		})
+	}
+
+	var noScriptTests = []string{
+		`<a href="https://example.com/">`,
+		`<a title="javascript:x()">`,
+		`<a on="x">`,
+		`<input checked>`,
+		`<img src="data:">`,
+		`<a href= >x</a>`,
+	}
+	for _, tt := range noScriptTests {
+		t.Run(tt, func(t *testing.T) {
+			scripts, err := InlineScripts(parse.NewInputString(tt))
+			test.Error(t, err)
+			test.T(t, len(scripts), 0)
+		})
+	}
+}
+
+func TestInlineScriptsSpans(t *testing.T) {
+	s := `<p>text</p><button onclick='f()' type=button><a href=javascript:g()>`
+	scripts, err := InlineScripts(parse.NewInputString(s))
+	test.Error(t, err)
+	test.T(t, len(scripts), 2)
+	test.String(t, s[scripts[0].Start:scripts[0].End], "f()")
+	test.String(t, s[scripts[1].Start:scripts[1].End], "javascript:g()")
+
+	test.T(t, JavaScriptURL.String(), "JavaScriptURL")
+	test.T(t, InlineScriptType(100).String(), "Invalid(100)")
+}
+
+func TestUnescapeCharRefs(t *testing.T) {
+	var tests = []struct {
+		b        string
+		expected string
+	}{
+		{"a&amp;b", "a&b"},
+		{"java&Tab;script&colon;", "java\tscript:"},
+		{"&#106;&#x61;&#X76;a", "java"},
+		{"&#0;&#x;", "�&#x;"},
+		{"&#128;&#x110000;", "€�"},
+		{"&nLt;&notin;&notit;&not-", "≪⃒∉&notit;¬-"},
+		{"?a=1&copy=2&copy;&copy", "?a=1&copy=2©©"},
+		{"&ampx &amp x", "&ampx & x"},
+		{"&colon &unknown;", "&colon &unknown;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			test.String(t, string(unescapeCharRefs([]byte(tt.b))), tt.expected)
+		})
+	}
+}
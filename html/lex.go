@@ -2,6 +2,7 @@
 package html
 
 import (
+	"bytes"
 	"strconv"
 
 	"github.com/politepixels/tdewolff-parse/v2"
@@ -62,6 +63,11 @@ func (tt TokenType) String() string {
 
 ////////////////////////////////////////////////////////////////
 
+var (
+	preBytes     = []byte("pre")
+	listingBytes = []byte("listing")
+)
+
 var GoTemplate = [2]string{"{{", "}}"}
 var HandlebarsTemplate = [2]string{"{{", "}}"}
 var MustacheTemplate = [2]string{"{{", "}}"}
@@ -76,19 +82,31 @@ type Lexer struct {
 	tmplEnd   []byte
 	err       error
 
-	rawTag Hash
-	inTag  bool
+	rawTag    Hash
+	inTag     bool
+	scripting bool
+
+	preLevel   int    // number of open pre and listing elements
+	whitespace []byte // last TextToken if it may be inter-element whitespace, see IsWhitespace
 
 	text    []byte
 	attrVal []byte
 	hasTmpl bool
+	raw     []byte // raw bytes of the token when they differ from the data
 
+	rawStart   int
 	tokenStart int
 	tokenEnd   int
 	tokenLine  int
 	tokenCol   int
 
 	attrValStartOffset int
+
+	maxDepth int
+	maxAttrs int
+	open     [][]byte // tag names of the open elements when maxDepth is set
+	opened   bool     // the current start tag opened an element
+	attrs    int      // number of attributes of the current start tag
 }
 
 // NewLexer returns a new Lexer for a given io.Reader.
@@ -106,6 +124,44 @@ func NewTemplateLexer(r *parse.Input, tmpl [2]string) *Lexer {
 	}
 }
 
+// Reset resets the lexer to lex r, while keeping its template delimiters, scripting, and limits, and its allocated buffers.
+func (l *Lexer) Reset(r *parse.Input) {
+	*l = Lexer{
+		r:         r,
+		tmplBegin: l.tmplBegin,
+		tmplEnd:   l.tmplEnd,
+		scripting: l.scripting,
+		maxDepth:  l.maxDepth,
+		maxAttrs:  l.maxAttrs,
+		open:      l.open[:0],
+	}
+	if r != nil {
+		l.tokenEnd = r.Offset()
+	}
+}
+
+// SetScripting sets whether the input is tokenized as by a browser with scripting enabled, in which case the contents of <noscript> are returned as a single TextToken instead of being tokenized as markup. Sanitizers should enable scripting so that they see the same tokens as browsers do.
+func (l *Lexer) SetScripting(scripting bool) {
+	l.scripting = scripting
+}
+
+// Error codes of the errors returned when the limits of SetLimits are exceeded.
+const (
+	MaxDepthCode = "max-depth"
+	MaxAttrsCode = "max-attributes"
+)
+
+// SetLimits sets the maximum nesting depth of elements and the maximum number of attributes per element, where zero means no limit. When a limit is exceeded, Next returns ErrorToken and Err returns a *parse.Error with code MaxDepthCode or MaxAttrsCode and the range of the offending tag or attribute, so that servers can reject pathological inputs while streaming. The depth is tracked as by SelectorMatcher, where void elements and self-closing tags are not opened and start tags implicitly close elements such as p and li.
+func (l *Lexer) SetLimits(maxDepth, maxAttrs int) {
+	l.maxDepth = maxDepth
+	l.maxAttrs = maxAttrs
+}
+
+// Depth returns the number of open elements, including the element of the current start tag once it is closed. It is only tracked when a maximum depth is set with SetLimits.
+func (l *Lexer) Depth() int {
+	return len(l.open)
+}
+
 // Err returns the error encountered during lexing, this is often io.EOF but also other errors can be returned.
 func (l *Lexer) Err() error {
 	if l.err != nil {
@@ -134,8 +190,52 @@ func (l *Lexer) HasTemplate() bool {
 	return l.hasTmpl
 }
 
+// IsWhitespace returns true if the last TextToken consists only of inter-element whitespace, that is of spaces, tabs, line feeds, form feeds, and carriage returns. It returns false for the contents of raw text elements such as textarea and for text inside pre and listing elements, where whitespace is significant.
+func (l *Lexer) IsWhitespace() bool {
+	return l.whitespace != nil && parse.IsAllWhitespace(l.whitespace)
+}
+
 // Next returns the next Token. It returns ErrorToken when an error was encountered. Using Err() one can retrieve the error message.
 func (l *Lexer) Next() (TokenType, []byte) {
+	if l.err != nil && (0 < l.maxDepth || 0 < l.maxAttrs) {
+		l.rawStart, l.raw = l.tokenEnd, nil
+		return ErrorToken, nil
+	}
+	rawText := l.rawTag != 0 && !l.inTag
+	l.rawStart, l.raw = l.tokenEnd, nil
+	tt, data := l.next()
+	l.tokenEnd = l.r.Offset()
+	if l.raw == nil {
+		l.raw = data
+	}
+	if (0 < l.maxDepth || 0 < l.maxAttrs) && !l.checkLimits(tt) {
+		return ErrorToken, nil
+	}
+	l.whitespace = nil
+	if tt == TextToken {
+		if !rawText && l.preLevel == 0 {
+			l.whitespace = data
+		}
+	} else if tt == StartTagToken && isPreTag(l.text) {
+		l.preLevel++
+	} else if tt == EndTagToken && 0 < l.preLevel && isPreTag(l.text) {
+		l.preLevel--
+	}
+	return tt, data
+}
+
+// isPreTag returns true for the tag names of pre and listing elements, in which whitespace is significant.
+func isPreTag(name []byte) bool {
+	switch len(name) {
+	case 3:
+		return parse.EqualFold(name, preBytes)
+	case 7:
+		return parse.EqualFold(name, listingBytes)
+	}
+	return false
+}
+
+func (l *Lexer) next() (TokenType, []byte) {
 	l.text = nil
 	l.hasTmpl = false
 	l.attrValStartOffset = -1
@@ -158,30 +258,27 @@ func (l *Lexer) Next() (TokenType, []byte) {
 		l.tokenLine, l.tokenCol = l.r.Position()
 
 		if c == 0 && l.r.Err() != nil {
-			l.tokenEnd = l.r.Offset()
+			l.raw = l.r.Shift()
 			return ErrorToken, nil
 		} else if c != '>' && (c != '/' || l.r.Peek(1) != '>') {
-			tt, data := AttributeToken, l.shiftAttribute()
-			l.tokenEnd = l.r.Offset()
-			return tt, data
+			return AttributeToken, l.shiftAttribute()
 		}
-		l.r.Skip()
+		ws := l.r.Pos()
 		l.inTag = false
 		if c == '/' {
 			l.r.Move(2)
-			l.tokenEnd = l.r.Offset()
-			return StartTagVoidToken, l.r.Shift()
+			l.raw = l.r.Shift()
+			return StartTagVoidToken, l.raw[ws:]
 		}
 		l.r.Move(1)
-		l.tokenEnd = l.r.Offset()
-		return StartTagCloseToken, l.r.Shift()
+		l.raw = l.r.Shift()
+		return StartTagCloseToken, l.raw[ws:]
 	}
 
 	if l.rawTag != 0 {
 		if rawText := l.shiftRawText(); 0 < len(rawText) {
 			l.text = rawText
 			l.rawTag = 0
-			l.tokenEnd = l.r.Offset()
 			return TextToken, rawText
 		}
 		l.rawTag = 0
@@ -192,13 +289,11 @@ func (l *Lexer) Next() (TokenType, []byte) {
 		if 0 < len(l.tmplBegin) && l.at(l.tmplBegin...) {
 			if 0 < l.r.Pos() {
 				l.text = l.r.Shift()
-				l.tokenEnd = l.r.Offset()
 				return TextToken, l.text
 			}
 			l.r.Move(len(l.tmplBegin))
 			l.moveTemplate()
 			l.hasTmpl = true
-			l.tokenEnd = l.r.Offset()
 			return TemplateToken, l.r.Shift()
 		} else if c == '<' {
 			c = l.r.Peek(1)
@@ -209,16 +304,13 @@ func (l *Lexer) Next() (TokenType, []byte) {
 			} else if 0 < l.r.Pos() {
 				// return currently buffered texttoken so that we can return tag next iteration
 				l.text = l.r.Shift()
-				l.tokenEnd = l.r.Offset()
 				return TextToken, l.text
 			} else if isEndTag {
 				l.r.Move(2)
 				// only endtags that are not followed by > or EOF arrive here
 				if c = l.r.Peek(0); !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
-					l.tokenEnd = l.r.Offset()
 					return CommentToken, l.shiftBogusComment()
 				}
-				l.tokenEnd = l.r.Offset()
 				return EndTagToken, l.shiftEndTag()
 			} else if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
 				l.r.Move(1)
@@ -229,16 +321,13 @@ func (l *Lexer) Next() (TokenType, []byte) {
 				return l.readMarkup()
 			} else if c == '?' {
 				l.r.Move(1)
-				l.tokenEnd = l.r.Offset()
 				return CommentToken, l.shiftBogusComment()
 			}
 		} else if c == 0 && l.r.Err() != nil {
 			if 0 < l.r.Pos() {
 				l.text = l.r.Shift()
-				l.tokenEnd = l.r.Offset()
 				return TextToken, l.text
 			}
-			l.tokenEnd = l.r.Offset()
 			return ErrorToken, nil
 		} else {
 			l.r.Move(1)
@@ -335,16 +424,15 @@ func (l *Lexer) readMarkup() (TokenType, []byte) {
 		l.r.Move(2)
 		for {
 			if l.r.Peek(0) == 0 && l.r.Err() != nil {
-				l.text = l.r.Lexeme()[4:]
-				return CommentToken, l.r.Shift()
+				return CommentToken, l.shiftText(4, l.r.Pos())
 			} else if l.at('-', '-', '>') {
-				l.text = l.r.Lexeme()[4:]
+				end := l.r.Pos()
 				l.r.Move(3)
-				return CommentToken, l.r.Shift()
+				return CommentToken, l.shiftText(4, end)
 			} else if l.at('-', '-', '!', '>') {
-				l.text = l.r.Lexeme()[4:]
+				end := l.r.Pos()
 				l.r.Move(4)
-				return CommentToken, l.r.Shift()
+				return CommentToken, l.shiftText(4, end)
 			}
 			l.r.Move(1)
 		}
@@ -352,12 +440,11 @@ func (l *Lexer) readMarkup() (TokenType, []byte) {
 		l.r.Move(7)
 		for {
 			if l.r.Peek(0) == 0 && l.r.Err() != nil {
-				l.text = l.r.Lexeme()[9:]
-				return TextToken, l.r.Shift()
+				return TextToken, l.shiftText(9, l.r.Pos())
 			} else if l.at(']', ']', '>') {
-				l.text = l.r.Lexeme()[9:]
+				end := l.r.Pos()
 				l.r.Move(3)
-				return TextToken, l.r.Shift()
+				return TextToken, l.shiftText(9, end)
 			}
 			l.r.Move(1)
 		}
@@ -369,11 +456,11 @@ func (l *Lexer) readMarkup() (TokenType, []byte) {
 			}
 			for {
 				if c := l.r.Peek(0); c == '>' || c == 0 && l.r.Err() != nil {
-					l.text = l.r.Lexeme()[9:]
+					end := l.r.Pos()
 					if c == '>' {
 						l.r.Move(1)
 					}
-					return DoctypeToken, l.r.Shift()
+					return DoctypeToken, l.shiftText(9, end)
 				}
 				l.r.Move(1)
 			}
@@ -386,17 +473,23 @@ func (l *Lexer) shiftBogusComment() []byte {
 	for {
 		c := l.r.Peek(0)
 		if c == '>' {
-			l.text = l.r.Lexeme()[2:]
+			end := l.r.Pos()
 			l.r.Move(1)
-			return l.r.Shift()
+			return l.shiftText(2, end)
 		} else if c == 0 && l.r.Err() != nil {
-			l.text = l.r.Lexeme()[2:]
-			return l.r.Shift()
+			return l.shiftText(2, l.r.Pos())
 		}
 		l.r.Move(1)
 	}
 }
 
+// shiftText shifts the selection and sets the text to the bytes of the shifted token from start up to end, so that the text is copied along with the token when the Input has an arena.
+func (l *Lexer) shiftText(start, end int) []byte {
+	data := l.r.Shift()
+	l.text = data[start:end]
+	return data
+}
+
 func (l *Lexer) shiftStartTag() (TokenType, []byte) {
 	for {
 		// spec says only a-zA-Z0-9, but we're lenient here
@@ -405,13 +498,16 @@ func (l *Lexer) shiftStartTag() (TokenType, []byte) {
 		}
 		l.r.Move(1)
 	}
-	l.text = parse.ToLower(l.r.Lexeme()[1:])
-	if h := ToHash(l.text); h == Textarea || h == Title || h == Style || h == Xmp || h == Iframe || h == Script || h == Plaintext || h == Svg || h == Math || h == Xml {
+	n := l.r.Pos()
+	l.text = lowerCopy(l.r.Lexeme()[1:])
+	if h := ToHash(l.text); h == Textarea || h == Title || h == Style || h == Xmp || h == Iframe || h == Script || h == Plaintext || h == Svg || h == Math || h == Xml || h == Noscript && l.scripting {
 		if h == Svg || h == Math || h == Xml {
 			data := l.shiftXML(h)
 			if l.err != nil {
+				l.raw = data
 				return ErrorToken, nil
 			}
+			data = l.lower(data, 1, n)
 
 			l.inTag = false
 			if h == Svg {
@@ -423,7 +519,9 @@ func (l *Lexer) shiftStartTag() (TokenType, []byte) {
 		}
 		l.rawTag = h
 	}
-	return StartTagToken, l.r.Shift()
+	data := l.lower(l.r.Shift(), 1, n)
+	l.text = data[1:n]
+	return StartTagToken, data
 }
 
 func (l *Lexer) shiftAttribute() []byte {
@@ -504,11 +602,15 @@ func (l *Lexer) shiftAttribute() []byte {
 		l.moveTemplate()
 		l.hasTmpl = true
 	}
-	l.text = l.r.Lexeme()[nameStart:nameEnd]
+	data := l.r.Shift()
 	if !nameHasTmpl {
-		l.text = parse.ToLower(l.text)
+		data = l.lower(data, nameStart, nameEnd)
 	}
-	return l.r.Shift()
+	l.text = data[nameStart:nameEnd]
+	if l.attrVal != nil {
+		l.attrVal = data[len(data)-len(l.attrVal):]
+	}
+	return data
 }
 
 func (l *Lexer) shiftEndTag() []byte {
@@ -533,8 +635,9 @@ func (l *Lexer) shiftEndTag() []byte {
 		}
 		break
 	}
-	l.text = l.text[:end]
-	return parse.ToLower(l.r.Shift())
+	data := l.lower(l.r.Shift(), 0, -1)
+	l.text = data[2 : 2+end]
+	return data
 }
 
 // shiftXML parses the content of a svg or math tag according to the XML 1.1 specifications, including the tag itself.
@@ -613,6 +716,32 @@ func (l *Lexer) moveTemplate() {
 	}
 }
 
+// lower returns data with the bytes from start to end lowercased, or to the end of data if end is negative. If any were uppercase, data is copied and the original is kept as the raw bytes of the token so that the input is not changed, see Raw.
+func (l *Lexer) lower(data []byte, start, end int) []byte {
+	if end < 0 {
+		end = len(data)
+	}
+	for _, c := range data[start:end] {
+		if 'A' <= c && c <= 'Z' {
+			l.raw = data
+			data = parse.Copy(data)
+			parse.ToLower(data[start:end])
+			return data
+		}
+	}
+	return data
+}
+
+// lowerCopy returns b lowercased, which is copied if b has uppercase letters.
+func lowerCopy(b []byte) []byte {
+	for _, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			return parse.ToLower(parse.Copy(b))
+		}
+	}
+	return b
+}
+
 ////////////////////////////////////////////////////////////////
 
 func (l *Lexer) at(b ...byte) bool {
@@ -638,6 +767,16 @@ func (l *Lexer) Position() (int, int) {
 	return l.r.PositionAt(l.tokenStart)
 }
 
+// Raw returns the bytes of the input from which the current token was lexed, including delimiters such as angle brackets, quotes, and the markup of comments. It differs from the data returned by Next in that tag and attribute names keep their case, and in that the raw bytes of attributes and of the end of start tags include the whitespace that precedes them, as do those of an ErrorToken at the end of the input inside a tag. The raw bytes of consecutive tokens are adjacent, so that concatenating them reproduces the input byte for byte up to an error, and rewriters can replace the bytes of a token without reserializing its neighbours, see RawStart.
+func (l *Lexer) Raw() []byte {
+	return l.raw
+}
+
+// RawStart returns the byte offset of the raw bytes of the current token, which is the TokenEnd of the previous token. The raw bytes end at TokenEnd.
+func (l *Lexer) RawStart() int {
+	return l.rawStart
+}
+
 // TokenStart returns the byte offset of the current token.
 func (l *Lexer) TokenStart() int {
 	return l.tokenStart
@@ -662,3 +801,55 @@ func (l *Lexer) TokenCol() int {
 func (l *Lexer) AttrValStart() int {
 	return l.attrValStartOffset
 }
+
+// checkLimits updates the open elements and the attribute count for a token, and returns false if a limit of SetLimits is exceeded.
+func (l *Lexer) checkLimits(tt TokenType) bool {
+	switch tt {
+	case StartTagToken, SVGToken, MathToken, XMLToken:
+		l.attrs = 0
+		l.opened = false
+		if l.maxDepth <= 0 {
+			break
+		}
+		tag := l.text
+		if tt == StartTagToken {
+			for 0 < len(l.open) && containsString(impliedEndTags[string(l.open[len(l.open)-1])], string(tag)) {
+				l.open = l.open[:len(l.open)-1]
+			}
+		}
+		if l.maxDepth <= len(l.open) {
+			l.err = parse.NewErrorLexerRange(l.r, l.tokenStart, "maximum element depth of %d exceeded", l.maxDepth)
+			l.err.(*parse.Error).Code = MaxDepthCode
+			return false
+		} else if tt == StartTagToken && !voidElements[string(tag)] {
+			if len(l.open) < cap(l.open) {
+				// reuse the tag name buffer of a previously open element
+				l.open = l.open[:len(l.open)+1]
+				l.open[len(l.open)-1] = append(l.open[len(l.open)-1][:0], tag...)
+			} else {
+				l.open = append(l.open, parse.Copy(tag))
+			}
+			l.opened = true
+		}
+	case AttributeToken:
+		l.attrs++
+		if 0 < l.maxAttrs && l.maxAttrs < l.attrs {
+			l.err = parse.NewErrorLexerRange(l.r, l.tokenStart, "maximum of %d attributes per element exceeded", l.maxAttrs)
+			l.err.(*parse.Error).Code = MaxAttrsCode
+			return false
+		}
+	case StartTagVoidToken:
+		if l.opened {
+			l.open = l.open[:len(l.open)-1] // self-closing tag
+			l.opened = false
+		}
+	case EndTagToken:
+		for i := len(l.open) - 1; 0 <= i; i-- {
+			if bytes.Equal(l.open[i], l.text) {
+				l.open = l.open[:i]
+				break
+			}
+		}
+	}
+	return true
+}
@@ -3,7 +3,9 @@ package html
 import (
 	"fmt"
 	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/politepixels/tdewolff-parse/v2"
 	"github.com/tdewolff/test"
@@ -215,6 +217,73 @@ func TestTemplates(t *testing.T) {
 	}
 }
 
+func TestScripting(t *testing.T) {
+	var tests = []struct {
+		html      string
+		scripting bool
+		expected  []TokenType
+	}{
+		{"<noscript><img src=x></noscript>", false, TTs{StartTagToken, StartTagCloseToken, StartTagToken, AttributeToken, StartTagCloseToken, EndTagToken}},
+		{"<noscript><img src=x></noscript>", true, TTs{StartTagToken, StartTagCloseToken, TextToken, EndTagToken}},
+		{"<NOSCRIPT><p title='</noscript><img src=x onerror=y>'></NoScript>", true, TTs{StartTagToken, StartTagCloseToken, TextToken, EndTagToken, StartTagToken, AttributeToken, AttributeToken, StartTagCloseToken, TextToken, EndTagToken}},
+		{"<noscript><!--</noscript>-->", true, TTs{StartTagToken, StartTagCloseToken, TextToken, EndTagToken, TextToken}},
+		{"<noscript><!--</noscript>-->", false, TTs{StartTagToken, StartTagCloseToken, CommentToken}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			l := NewLexer(parse.NewInputString(tt.html))
+			l.SetScripting(tt.scripting)
+			tokens := []TokenType{}
+			for {
+				token, _ := l.Next()
+				if token == ErrorToken {
+					test.T(t, l.Err(), io.EOF)
+					break
+				}
+				tokens = append(tokens, token)
+			}
+			test.T(t, tokens, tt.expected, "token types must match")
+		})
+	}
+}
+
+func TestWhitespace(t *testing.T) {
+	var tests = []struct {
+		html     string
+		expected []bool
+	}{
+		{"<p> \t\n\r\f</p>", []bool{true}},
+		{"<p> a </p>", []bool{false}},
+		{"<p>\v</p>", []bool{false}},
+		{"\n<ul>\n  <li>a</li>\n</ul>\n", []bool{true, true, false, true, true}},
+		{"<pre>\n</pre> ", []bool{false, true}},
+		{"<PRE><pre> </pre> </Pre> ", []bool{false, false, true}},
+		{"<listing> </listing>", []bool{false}},
+		{"</pre> <p> ", []bool{true, true}},
+		{"<textarea> </textarea> ", []bool{false, true}},
+		{"<script> </script><style>\n</style>", []bool{false, false}},
+		{"<p> {{.}} </p>", []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			l := NewTemplateLexer(parse.NewInputString(tt.html), GoTemplate)
+			whitespace := []bool{}
+			for {
+				token, _ := l.Next()
+				if token == ErrorToken {
+					test.T(t, l.Err(), io.EOF)
+					break
+				} else if token == TextToken {
+					whitespace = append(whitespace, l.IsWhitespace())
+				} else {
+					test.That(t, !l.IsWhitespace())
+				}
+			}
+			test.T(t, whitespace, tt.expected)
+		})
+	}
+}
+
 func TestErrors(t *testing.T) {
 	var tests = []struct {
 		html string
@@ -244,6 +313,57 @@ func TestErrors(t *testing.T) {
 	}
 }
 
+func TestLimits(t *testing.T) {
+	var tests = []struct {
+		html     string
+		maxDepth int
+		maxAttrs int
+		code     string
+		col      int
+	}{
+		{"<div><div><div>", 2, 0, MaxDepthCode, 11},
+		{"<div><div></div><div></div></div><div><br><img/><p>a<p>b</div>", 2, 0, "", 0},
+		{"<ul><li>a<li>b<li><b>c</b></ul>", 3, 0, "", 0},
+		{"<ul><li>a<li><p>b<p><b>c</ul>", 3, 0, MaxDepthCode, 21},
+		{"<div/><div/><div><svg></svg></div>", 2, 0, "", 0},
+		{"<div><svg></svg></div>", 1, 0, MaxDepthCode, 6},
+		{"<a b c>", 0, 2, "", 0},
+		{"<a b c d><e f>", 0, 2, MaxAttrsCode, 8},
+		{"<a b c><d e f><g h i>", 0, 2, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			l := NewLexer(parse.NewInputString(tt.html))
+			l.SetLimits(tt.maxDepth, tt.maxAttrs)
+			for {
+				token, _ := l.Next()
+				if token == ErrorToken {
+					if tt.code == "" {
+						test.T(t, l.Err(), io.EOF)
+					} else if perr, ok := l.Err().(*parse.Error); ok {
+						test.String(t, perr.Code, tt.code)
+						test.T(t, perr.Column, tt.col)
+					} else {
+						test.Fail(t, "bad error:", l.Err())
+					}
+					break
+				}
+			}
+		})
+	}
+
+	// the lexer stops at the error
+	l := NewLexer(parse.NewInputString("<div><div>"))
+	l.SetLimits(1, 0)
+	for tt, _ := l.Next(); tt != ErrorToken; tt, _ = l.Next() {
+	}
+	test.T(t, l.Depth(), 1)
+	tt, _ := l.Next()
+	test.T(t, tt, ErrorToken)
+	perr := l.Err().(*parse.Error)
+	test.T(t, perr.Range.End.Offset-perr.Range.Start.Offset, 4)
+}
+
 func TestTextAndAttrVal(t *testing.T) {
 	l := NewLexer(parse.NewInputString(`<div attr="val" >text<!--comment--><!DOCTYPE doctype><![CDATA[cdata]]><script>js</script><svg>image</svg>`))
 	_, data := l.Next()
@@ -323,6 +443,99 @@ func TestOffset(t *testing.T) {
 	test.T(t, z.Offset(), 26) // </div>
 }
 
+func TestRaw(t *testing.T) {
+	var tests = []string{
+		`<div attr="val">text</div>`,
+		`<DIV Class = 'A'  ID=b  >Text</DIV >`,
+		"<a\n\thref=x\f/>\r\n<br />",
+		`<!DOCTYPE html><!--c--><!--x--!><![CDATA[d]]><?pi x?></ x><!x>`,
+		`<TITLE>x <b></TITLE><SCRIPT>if (a<b) {}</SCRIPT><TextArea>t</textarea>`,
+		`<SVG ViewBox="0 0 1 1"><Path/></SVG><math><mi>x</mi></math>`,
+		`<plaintext>a</plaintext>`,
+		`<a x`,
+		`<a x='`,
+		`<a `,
+		`<!--`,
+		`</a`,
+		`a < b <1`,
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			for _, stream := range []bool{false, true} {
+				z := parse.NewInputString(tt)
+				if stream {
+					z = parse.NewStreamInputSize(iotest.OneByteReader(strings.NewReader(tt)), 4)
+				}
+				l := NewLexer(z)
+				raw := ""
+				for {
+					tt2, _ := l.Next()
+					test.String(t, string(l.Raw()), tt[l.RawStart():l.TokenEnd()])
+					raw += string(l.Raw())
+					if tt2 == ErrorToken {
+						break
+					}
+				}
+				test.String(t, raw, tt)
+			}
+
+			// the input is not changed
+			z := parse.NewInputString(tt)
+			l := NewLexer(z)
+			for {
+				if tt2, _ := l.Next(); tt2 == ErrorToken {
+					break
+				}
+			}
+			test.String(t, string(z.Bytes()), tt)
+		})
+	}
+}
+
+func TestRawTemplate(t *testing.T) {
+	s := `<A {{if .X}}Href="{{.URL}}"{{end}} >{{.Text}}</A>`
+	l := NewTemplateLexer(parse.NewInputString(s), GoTemplate)
+	raw := ""
+	for {
+		tt, _ := l.Next()
+		raw += string(l.Raw())
+		if tt == ErrorToken {
+			break
+		}
+	}
+	test.String(t, raw, s)
+}
+
+func TestRawLowercase(t *testing.T) {
+	l := NewLexer(parse.NewInputString(`<DIV Class='A' >x</DIV>`))
+	tt, data := l.Next()
+	test.T(t, tt, StartTagToken)
+	test.String(t, string(data), "<div")
+	test.String(t, string(l.Text()), "div")
+	test.String(t, string(l.Raw()), "<DIV")
+	test.T(t, l.RawStart(), 0)
+	tt, data = l.Next()
+	test.T(t, tt, AttributeToken)
+	test.String(t, string(data), " class='A'")
+	test.String(t, string(l.AttrKey()), "class")
+	test.String(t, string(l.AttrVal()), "'A'")
+	test.String(t, string(l.Raw()), " Class='A'")
+	test.T(t, l.RawStart(), 4)
+	test.T(t, l.TokenStart(), 5)
+	tt, data = l.Next()
+	test.T(t, tt, StartTagCloseToken)
+	test.String(t, string(data), ">")
+	test.String(t, string(l.Raw()), " >")
+	_, _ = l.Next()
+	tt, data = l.Next()
+	test.T(t, tt, EndTagToken)
+	test.String(t, string(data), "</div>")
+	test.String(t, string(l.Text()), "div")
+	test.String(t, string(l.Raw()), "</DIV>")
+	test.T(t, l.RawStart(), 17)
+	test.T(t, l.TokenEnd(), 23)
+}
+
 ////////////////////////////////////////////////////////////////
 
 var J int
@@ -382,6 +595,69 @@ func BenchmarkWhitespace3(b *testing.B) {
 	}
 }
 
+var lexerBenchmarkDoc = strings.Repeat(`<div class="item">
+	<h2>Title</h2>
+	<p>Some <b>bold</b> and <i>italic</i> text with a <a href="/link?a=1&amp;b=2">link</a>.</p>
+	<pre>  preformatted
+	  text  </pre>
+	<ul>
+		<li>one</li>
+		<li>two</li>
+	</ul>
+</div>
+`, 100)
+
+func BenchmarkLexer(b *testing.B) {
+	b.SetBytes(int64(len(lexerBenchmarkDoc)))
+	for i := 0; i < b.N; i++ {
+		l := NewLexer(parse.NewInputString(lexerBenchmarkDoc))
+		for {
+			if tt, _ := l.Next(); tt == ErrorToken {
+				break
+			}
+		}
+	}
+}
+
+func FuzzLexer(f *testing.F) {
+	f.Add("<!doctype html><p class=a>b</p>")
+	f.Add("<script>if (a<b) {}</script><!-- c -->")
+	f.Add("<svg><![CDATA[d]]></svg>&amp;")
+	f.Fuzz(func(t *testing.T, s string) {
+		z := parse.NewInputString(s)
+		peeked := false
+		z.SetHooks(&parse.Hooks{
+			OnMove: func(from, to int) {
+				test.That(t, to <= len(s), "must not move beyond the input")
+			},
+			OnRewind: func(from, to int) {
+				test.That(t, to <= from, "must not rewind forward")
+			},
+			OnPeekEOF: func(offset int) {
+				peeked = true
+			},
+		})
+		l := NewLexer(z)
+		raw := []byte{}
+		for i := 0; i <= len(s); i++ {
+			offset := z.Offset()
+			tt, _ := l.Next()
+			test.T(t, l.RawStart(), len(raw), "raw bytes must be adjacent")
+			raw = append(raw, l.Raw()...)
+			if tt == ErrorToken {
+				if l.Err() == io.EOF {
+					test.T(t, z.Offset(), len(s), "must consume the input")
+					test.That(t, peeked, "must peek at EOF")
+					test.String(t, string(raw), s, "raw bytes must reproduce the input")
+				}
+				return
+			}
+			test.That(t, offset < z.Offset(), "must make progress")
+		}
+		t.Fatal("must stop")
+	})
+}
+
 ////////////////////////////////////////////////////////////////
 
 func ExampleNewLexer() {
@@ -397,3 +673,20 @@ func ExampleNewLexer() {
 	fmt.Println(out)
 	// Output: <span class='user'>John Doe</span>
 }
+
+func TestLexerStream(t *testing.T) {
+	s := `<!doctype html><title>x &amp; y</title><script>var a = "</div>";</script><body class="a b" id=c><!-- comment --><textarea>raw <b></textarea><svg><path d="M0"/></svg>`
+	l := NewLexer(parse.NewInputString(s))
+	ls := NewLexer(parse.NewStreamInputSize(iotest.OneByteReader(strings.NewReader(s)), 4))
+	for {
+		tt, data := l.Next()
+		tts, datas := ls.Next()
+		test.T(t, tts, tt)
+		test.String(t, string(datas), string(data))
+		test.String(t, string(ls.AttrVal()), string(l.AttrVal()))
+		if tt == ErrorToken {
+			break
+		}
+	}
+	test.T(t, ls.Err(), io.EOF)
+}
@@ -0,0 +1,127 @@
+package html
+
+import (
+	"bytes"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// RobotsDirective is a directive of the content of a robots meta tag or of an X-Robots-Tag header, such as noindex or max-snippet:50.
+type RobotsDirective struct {
+	Name  []byte // lowercase directive name
+	Value []byte // value after the colon without surrounding whitespace, nil if there is no colon
+
+	Start, End int // byte offsets of the directive in the content
+}
+
+// Refresh is the content of a refresh meta tag or of a Refresh header, such as 5; url=/home.
+type Refresh struct {
+	Time int    // seconds before the refresh
+	URL  []byte // URL to navigate to without quotes, nil when the document itself is reloaded
+
+	Start, End int // byte offsets of the URL in the content
+}
+
+// ParseRobots parses the comma-separated directives of the content of a robots meta tag, such as noindex, nofollow, max-image-preview:large. Empty directives are skipped, and values may contain colons such as the date of unavailable_after.
+func ParseRobots(content []byte) []RobotsDirective {
+	directives := []RobotsDirective{}
+	for start := 0; start <= len(content); {
+		end := bytes.IndexByte(content[start:], ',')
+		if end == -1 {
+			end = len(content)
+		} else {
+			end += start
+		}
+
+		i, j := trimASCIIWhitespace(content, start, end)
+		if i < j {
+			d := RobotsDirective{Start: i, End: j}
+			if colon := bytes.IndexByte(content[i:j], ':'); colon != -1 {
+				k, l := trimASCIIWhitespace(content, i+colon+1, j)
+				d.Value = content[k:l]
+				_, j = trimASCIIWhitespace(content, i, i+colon)
+			}
+			d.Name = parse.ToLower(parse.Copy(content[i:j]))
+			directives = append(directives, d)
+		}
+		start = end + 1
+	}
+	return directives
+}
+
+// ParseRefresh parses the content of a refresh meta tag following the shared declarative refresh steps of https://html.spec.whatwg.org/multipage/semantics.html#shared-declarative-refresh-steps, which accept for example 5, 0;url=/home, and 0; URL='/home'. It returns false if the content is invalid and ignored by browsers.
+func ParseRefresh(content []byte) (Refresh, bool) {
+	i, _ := trimASCIIWhitespace(content, 0, len(content))
+	start := i
+	for i < len(content) && '0' <= content[i] && content[i] <= '9' {
+		i++
+	}
+	if i == start && (i == len(content) || content[i] != '.') {
+		return Refresh{}, false
+	}
+	time, _ := parseDigits(content[start:i])
+	for i < len(content) && ('0' <= content[i] && content[i] <= '9' || content[i] == '.') {
+		i++
+	}
+
+	refresh := Refresh{Time: time}
+	if i < len(content) {
+		if c := content[i]; c != ';' && c != ',' && !isASCIIWhitespace(c) {
+			return Refresh{}, false
+		}
+		i, _ = trimASCIIWhitespace(content, i, len(content))
+		if i < len(content) && (content[i] == ';' || content[i] == ',') {
+			i++
+		}
+		i, _ = trimASCIIWhitespace(content, i, len(content))
+	}
+	if i == len(content) {
+		return refresh, true
+	}
+
+	quotes := true
+	if j := skipURLPrefix(content, i); j != i {
+		i = j
+	} else if content[i] == 'u' || content[i] == 'U' {
+		quotes = false // a partial url= prefix is part of the URL
+	}
+	end := len(content)
+	if quotes && i < len(content) && (content[i] == '"' || content[i] == '\'') {
+		if k := bytes.IndexByte(content[i+1:], content[i]); k != -1 {
+			end = i + 1 + k
+		}
+		i++
+	}
+	_, end = trimASCIIWhitespace(content, i, end)
+	refresh.URL = content[i:end]
+	refresh.Start, refresh.End = i, end
+	return refresh, true
+}
+
+// skipURLPrefix returns the index after url= and its surrounding whitespace, or i if the prefix is not present.
+func skipURLPrefix(b []byte, i int) int {
+	if len(b) < i+3 || !parse.EqualFold(b[i:i+3], []byte("url")) {
+		return i
+	}
+	j, _ := trimASCIIWhitespace(b, i+3, len(b))
+	if j == len(b) || b[j] != '=' {
+		return i
+	}
+	j, _ = trimASCIIWhitespace(b, j+1, len(b))
+	return j
+}
+
+// trimASCIIWhitespace returns the range of b[start:end] without leading and trailing ASCII whitespace.
+func trimASCIIWhitespace(b []byte, start, end int) (int, int) {
+	for start < end && isASCIIWhitespace(b[start]) {
+		start++
+	}
+	for start < end && isASCIIWhitespace(b[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+func isASCIIWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
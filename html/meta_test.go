@@ -0,0 +1,68 @@
+package html
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseRobots(t *testing.T) {
+	var tests = []struct {
+		content  string
+		expected string
+	}{
+		{"noindex, nofollow", `noindex 0-7; nofollow 9-17`},
+		{" NoIndex ,, max-snippet : 50 ", `noindex 1-8; max-snippet="50" 12-28`},
+		{"unavailable_after: 2025-06-25 15:00:00 PST", `unavailable_after="2025-06-25 15:00:00 PST" 0-42`},
+		{"max-image-preview:", `max-image-preview="" 0-18`},
+		{"", ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			s := []string{}
+			for _, d := range ParseRobots([]byte(tt.content)) {
+				if d.Value != nil {
+					s = append(s, fmt.Sprintf("%s=%q %d-%d", d.Name, d.Value, d.Start, d.End))
+				} else {
+					s = append(s, fmt.Sprintf("%s %d-%d", d.Name, d.Start, d.End))
+				}
+			}
+			test.String(t, strings.Join(s, "; "), tt.expected)
+		})
+	}
+}
+
+func TestParseRefresh(t *testing.T) {
+	var tests = []struct {
+		content  string
+		expected string
+	}{
+		{"5", `5 <nil>`},
+		{" 0;url=/home", `0 "/home" 7-12`},
+		{"0; URL = '/a b' x", `0 "/a b" 10-14`},
+		{"1, \"/q\"", `1 "/q" 4-6`},
+		{"2.5 /next ", `2 "/next" 4-9`},
+		{".5;url=/x", `0 "/x" 7-9`},
+		{"0; urn:x", `0 "urn:x" 3-8`},
+		{"0; u'x'", `0 "u'x'" 3-7`},
+		{"0; 'unterminated", `0 "unterminated" 4-16`},
+		{"0;url=", `0 "" 6-6`},
+		{"", ``},
+		{"x", ``},
+		{"5x", ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			refresh, ok := ParseRefresh([]byte(tt.content))
+			s := ""
+			if ok && refresh.URL == nil {
+				s = fmt.Sprintf("%d <nil>", refresh.Time)
+			} else if ok {
+				s = fmt.Sprintf("%d %q %d-%d", refresh.Time, refresh.URL, refresh.Start, refresh.End)
+			}
+			test.String(t, s, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package html
+
+import (
+	"sync"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var lexerPool = sync.Pool{
+	New: func() interface{} {
+		return &Lexer{}
+	},
+}
+
+// AcquireLexer returns a lexer for r like NewLexer, taken from a pool of lexers that were released with ReleaseLexer so that their buffers are reused. This saves allocations when lexing many small documents, especially when limits are set with SetLimits.
+func AcquireLexer(r *parse.Input) *Lexer {
+	l := lexerPool.Get().(*Lexer)
+	*l = Lexer{
+		r:    r,
+		open: l.open[:0],
+	}
+	return l
+}
+
+// ReleaseLexer returns a lexer to the pool. Neither the lexer nor the slices returned by it may be used afterwards.
+func ReleaseLexer(l *Lexer) {
+	l.Reset(nil)
+	lexerPool.Put(l)
+}
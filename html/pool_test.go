@@ -0,0 +1,60 @@
+package html
+
+import (
+	"io"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func lexTokens(l *Lexer) []TokenType {
+	tts := []TokenType{}
+	for {
+		tt, _ := l.Next()
+		if tt == ErrorToken {
+			return tts
+		}
+		tts = append(tts, tt)
+	}
+}
+
+func TestPool(t *testing.T) {
+	l := AcquireLexer(parse.NewInputString("<div><p>"))
+	l.SetScripting(true)
+	l.SetLimits(2, 0)
+	test.T(t, len(lexTokens(l)), 4)
+	test.T(t, l.Depth(), 2)
+	ReleaseLexer(l)
+
+	// acquired lexers have the default options, so noscript is tokenized and there is no maximum depth
+	for i := 0; i < 3; i++ {
+		l = AcquireLexer(parse.NewInputString("<noscript><b>a</b></noscript><div><div><div>"))
+		test.T(t, lexTokens(l), []TokenType{StartTagToken, StartTagCloseToken, StartTagToken, StartTagCloseToken, TextToken, EndTagToken, EndTagToken, StartTagToken, StartTagCloseToken, StartTagToken, StartTagCloseToken, StartTagToken, StartTagCloseToken})
+		test.T(t, l.Err(), io.EOF)
+		ReleaseLexer(l)
+	}
+}
+
+func TestLexerReset(t *testing.T) {
+	l := NewLexer(parse.NewInputString("<a><b><c>"))
+	l.SetLimits(2, 0)
+	_ = lexTokens(l)
+	test.That(t, l.Err() != io.EOF, "maximum depth exceeded")
+
+	// the limits are kept
+	l.Reset(parse.NewInputString("<x><y></y></x><z>"))
+	test.T(t, len(lexTokens(l)), 8)
+	test.T(t, l.Err(), io.EOF)
+	test.T(t, l.Depth(), 1)
+}
+
+func BenchmarkPool(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		l := AcquireLexer(parse.NewInputString(`<div class="a"><p>Hello <b>world</b></p><img src="a.png"></div>`))
+		l.SetLimits(100, 100)
+		for tt, _ := l.Next(); tt != ErrorToken; tt, _ = l.Next() {
+		}
+		ReleaseLexer(l)
+	}
+}
@@ -0,0 +1,110 @@
+package html
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/css"
+	"github.com/politepixels/tdewolff-parse/v2/js"
+	"github.com/politepixels/tdewolff-parse/v2/json"
+	"github.com/politepixels/tdewolff-parse/v2/xml"
+)
+
+var (
+	javascriptBytes = []byte("javascript")
+	ecmascriptBytes = []byte("ecmascript")
+	moduleBytes     = []byte("module")
+	jsonBytes       = []byte("json")
+)
+
+// Redact returns a copy of src where text, comments, and attribute values are replaced by placeholders of the same length, see parse.Redact. Character references are kept so that the result tokenizes the same as src with all tokens at the same offsets. Scripts, style sheets, style attributes, and event handler attributes are redacted with js.Redact, json.Redact, and css.Redact, and inline SVG and MathML with xml.Redact. Tag and attribute names, type attributes, the doctype, and templates are kept.
+func Redact(src []byte) []byte {
+	dst := parse.Copy(src)
+	l := NewLexer(parse.NewInputBytes(parse.Copy(src)))
+	var scriptType []byte
+	prevEnd := 0
+	for {
+		rawTag := Hash(0)
+		if !l.inTag {
+			rawTag = l.rawTag
+		}
+		tt, data := l.Next()
+		if tt == ErrorToken {
+			if l.Err() != io.EOF {
+				// redact the remainder that could not be tokenized
+				parse.Redact(dst[prevEnd:], nil)
+			}
+			break
+		}
+
+		end := l.r.Offset()
+		b := dst[end-len(data) : end]
+		prevEnd = end
+		switch tt {
+		case StartTagToken:
+			scriptType = nil
+		case AttributeToken:
+			val := l.AttrVal()
+			if val == nil || l.HasTemplate() {
+				break
+			}
+			start := l.AttrValStart()
+			val, quote := unquoteAttrVal(val)
+			if quote != 0 {
+				start++
+			}
+			b = dst[start : start+len(val)]
+
+			key := l.AttrKey()
+			if parse.EqualFold(key, []byte("type")) {
+				// types determine how the contents and values are parsed
+				scriptType = parse.ToLower(parse.Copy(b))
+			} else if parse.EqualFold(key, []byte("style")) {
+				copy(b, css.Redact(b))
+			} else if 2 < len(key) && parse.EqualFold(key[:2], []byte("on")) {
+				copy(b, js.Redact(b))
+			} else {
+				parse.Redact(b, redactSkipCharRef)
+			}
+		case TextToken:
+			if l.HasTemplate() {
+				break
+			} else if rawTag == Script {
+				if len(scriptType) == 0 || bytes.Contains(scriptType, javascriptBytes) || bytes.Contains(scriptType, ecmascriptBytes) || bytes.Equal(scriptType, moduleBytes) {
+					copy(b, js.Redact(b))
+				} else if bytes.Contains(scriptType, jsonBytes) {
+					copy(b, json.Redact(b))
+				} else {
+					parse.Redact(b, nil)
+				}
+			} else if rawTag == Style {
+				copy(b, css.Redact(b))
+			} else if rawTag != 0 && rawTag != Textarea && rawTag != Title {
+				parse.Redact(b, nil)
+			} else {
+				parse.Redact(b, redactSkipCharRef)
+			}
+		case CommentToken:
+			parse.Redact(b, nil)
+		case SVGToken, MathToken, XMLToken:
+			copy(b, xml.Redact(b))
+		}
+	}
+	return dst
+}
+
+// redactSkipCharRef returns the length of a character reference at the start of b.
+func redactSkipCharRef(b []byte) int {
+	if b[0] != '&' {
+		return 0
+	}
+	for i := 1; i < len(b); i++ {
+		if b[i] == ';' {
+			return i + 1
+		} else if c := b[i]; c != '#' && (c < '0' || '9' < c) && (c < 'a' || 'z' < c) && (c < 'A' || 'Z' < c) {
+			break
+		}
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package html
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		html     string
+		expected string
+	}{
+		{`<!DOCTYPE html><p class="Note">Hello Bob &amp; Eve&#33;</p>`, `<!DOCTYPE html><p class="Xxxx">Xxxxx Xxx &amp; Xxx&#33;</p>`},
+		{`<a href='/Users/42' title=Secret>Link</a><!-- TODO -->`, `<a href='/Xxxxx/00' title=Xxxxxx>Xxxx</a><!-- XXXX -->`},
+		{`<script>var a = "Secret";</script>`, `<script>var a = "Xxxxxx";</script>`},
+		{`<script type="application/ld+json">{"name": "Acme"}</script>`, `<script type="application/ld+json">{"name": "Xxxx"}</script>`},
+		{`<script type="text/template"><p>Secret</p></script>`, `<script type="text/template"><x>Xxxxxx</x></script>`},
+		{`<style>a::after{content:"Secret"}</style><p style="font-family:'Acme'" onclick="alert('Hi')">`, `<style>a::after{content:"Xxxxxx"}</style><p style="font-family:'Xxxx'" onclick="alert('Xx')">`},
+		{`<textarea>Secret &lt;b&gt;</textarea><title>Title</title>`, `<textarea>Xxxxxx &lt;x&gt;</textarea><title>Xxxxx</title>`},
+		{`<svg><text x="1">Secret</text></svg>`, `<svg><text x="0">Xxxxxx</text></svg>`},
+		{`<p>café €</p>`, `<p>xxxé €</p>`},
+		{`<a href= >Link</a><a title=`, `<a href= >Xxxx</a><a title=`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			test.String(t, string(Redact([]byte(tt.html))), tt.expected)
+		})
+	}
+}
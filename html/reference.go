@@ -0,0 +1,176 @@
+package html
+
+import (
+	"io"
+	"net/url"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Reference is an attribute that holds a URL that is navigated to or fetched, such as the href of a link or the src of an image.
+type Reference struct {
+	Tag  []byte // lowercase tag name
+	Attr []byte // lowercase attribute name
+	URL  []byte // URL as written, with character references decoded and surrounding whitespace removed
+
+	// Resolved is the URL resolved against the base URL of the document, which is the href of the first base element resolved against the document URL. It is relative only when neither of those is absolute, and it is empty when the URL cannot be parsed.
+	Resolved string
+	// Target is the browsing context of hyperlinks and forms, from the target attribute of a, area, and form elements or else from the target of the first base element with a target.
+	Target []byte
+
+	Start, End int  // byte offsets of the attribute value without quotes
+	Quote      byte // quote of the attribute value, or zero when unquoted
+}
+
+// References returns all attributes with URLs in document order, excluding the href of base elements. URLs are resolved against the first base element with an href, regardless of whether references precede it, and documentURL is the URL of the document itself which may be empty.
+func References(r *parse.Input, documentURL string) ([]Reference, error) {
+	refs := []Reference{}
+	base, err := url.Parse(documentURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+	var baseTarget []byte
+	hasBaseHref := false
+
+	l := NewLexer(r)
+	var tag, target []byte
+	elemRefs := 0 // index of the first reference of the current element
+	for {
+		tt, _ := l.Next()
+		switch tt {
+		case ErrorToken:
+			for i := range refs {
+				if refs[i].Target == nil && hasReferenceTarget(refs[i].Tag) {
+					refs[i].Target = baseTarget
+				}
+				if ref, err := url.Parse(string(refs[i].URL)); err == nil {
+					refs[i].Resolved = base.ResolveReference(ref).String()
+				}
+			}
+			if l.Err() != io.EOF {
+				return refs, l.Err()
+			}
+			return refs, nil
+		case StartTagToken:
+			tag = l.Text()
+			target = nil
+			elemRefs = len(refs)
+		case StartTagCloseToken, StartTagVoidToken:
+			if target != nil && hasReferenceTarget(tag) {
+				for i := elemRefs; i < len(refs); i++ {
+					refs[i].Target = target
+				}
+			}
+		case AttributeToken:
+			val := l.AttrVal()
+			if val == nil {
+				continue
+			}
+			start := l.AttrValStart()
+			val, quote := unquoteAttrVal(val)
+			if quote != 0 {
+				start++
+			}
+
+			attr := l.AttrKey()
+			if string(tag) == "base" {
+				if string(attr) == "href" && !hasBaseHref {
+					// the first base element with an href sets the base URL of the document
+					if href, err := url.Parse(string(stripURLWhitespace(unescapeCharRefs(val)))); err == nil {
+						base = base.ResolveReference(href)
+						hasBaseHref = true
+					}
+				} else if string(attr) == "target" && baseTarget == nil {
+					baseTarget = unescapeCharRefs(val)
+				}
+				continue
+			} else if string(attr) == "target" {
+				target = unescapeCharRefs(val)
+				continue
+			} else if !urlAttrs[string(attr)] || string(attr) == "data" && string(tag) != "object" {
+				continue
+			}
+			refs = append(refs, Reference{
+				Tag:   tag,
+				Attr:  attr,
+				URL:   stripURLWhitespace(unescapeCharRefs(val)),
+				Start: start,
+				End:   start + len(val),
+				Quote: quote,
+			})
+		}
+	}
+}
+
+// RewriteReferences writes the document to w with the URLs of its references replaced, see References. The replacement URL of each reference is returned by f, such as its Resolved URL to make all URLs absolute, and references for which f returns nil are copied unchanged. Replacement URLs are escaped for their attribute value, and unquoted attribute values are quoted.
+func RewriteReferences(w io.Writer, r *parse.Input, documentURL string, f func(Reference) []byte) error {
+	_, err := ReferenceRewriter{r, documentURL, f}.WriteTo(w)
+	return err
+}
+
+// ReferenceRewriter rewrites the references of a document as RewriteReferences does. WriteTo lexes the input, so that a new input is needed for each write.
+type ReferenceRewriter struct {
+	Input       *parse.Input
+	DocumentURL string
+	Rewrite     func(Reference) []byte // returns the replacement URL of a reference, or nil to copy it unchanged
+}
+
+// WriteTo writes the document to w with the URLs of its references replaced, see RewriteReferences, and returns the number of bytes written. It implements io.WriterTo.
+func (rw ReferenceRewriter) WriteTo(w io.Writer) (int64, error) {
+	refs, err := References(rw.Input, rw.DocumentURL)
+	if err != nil {
+		return 0, err
+	}
+	b := rw.Input.Bytes()
+	prev := 0
+	var n int64
+	var buf []byte // reused for the escaped replacements, as writers must not retain the slice
+	for _, ref := range refs {
+		repl := rw.Rewrite(ref)
+		if repl == nil {
+			continue
+		}
+		m, err := w.Write(b[prev:ref.Start])
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+		if ref.Quote == 0 {
+			buf = append(appendEscapedReference(append(buf[:0], '"'), repl, '"'), '"')
+		} else {
+			buf = appendEscapedReference(buf[:0], repl, ref.Quote)
+		}
+		m, err = w.Write(buf)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+		prev = ref.End
+	}
+	m, err := w.Write(b[prev:])
+	return n + int64(m), err
+}
+
+// appendEscapedReference appends a URL to dst with its ampersands and quote characters escaped.
+func appendEscapedReference(dst, b []byte, quote byte) []byte {
+	for _, c := range b {
+		if c == '&' {
+			dst = append(dst, "&amp;"...)
+		} else if c == quote {
+			dst = append(dst, "&#"...)
+			dst = append(dst, byte('0'+quote/10), byte('0'+quote%10), ';')
+		} else {
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// hasReferenceTarget returns true for elements that navigate a browsing context.
+func hasReferenceTarget(tag []byte) bool {
+	switch string(tag) {
+	case "a", "area", "form":
+		return true
+	}
+	return false
+}
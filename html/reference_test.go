@@ -0,0 +1,96 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestReferences(t *testing.T) {
+	var tests = []struct {
+		html     string
+		docURL   string
+		expected []string
+	}{
+		{`<a href="b.html">`, "https://example.com/a/index.html", []string{"a href https://example.com/a/b.html"}},
+		{`<img src=x.png><base href="/static/"><base href="/other/"><script src="s.js">`, "https://example.com/a/", []string{"img src https://example.com/static/x.png", "script src https://example.com/static/s.js"}},
+		{`<base href="https://cdn.example.org/v1/"><link href="../a.css"><object data="o.swf" id=x>`, "", []string{"link href https://cdn.example.org/a.css", "object data https://cdn.example.org/v1/o.swf"}},
+		{`<base href="/root/"><a href="x">`, "", []string{"a href /root/x"}},
+		{`<base target="_blank"><base target="_self"><a href="/a"><a href="/b" target=frame><form action="/f"><img src="/i">`, "http://h/", []string{"a href http://h/a _blank", "a href http://h/b frame", "form action http://h/f _blank", "img src http://h/i"}},
+		{`<a href=" /a&amp;b?c=&quot;d&quot;#e ">`, "http://h/", []string{"a href http://h/a&b?c=\"d\"#e"}},
+		{`<div data="x" title="y"><blockquote cite="//other.com/q"><button formaction=go>`, "https://h/", []string{"blockquote cite https://other.com/q", "button formaction https://h/go"}},
+		{`<a href="http://[::1">`, "https://h/", []string{"a href "}},
+		{`<a href= >x</a>`, "https://h/", []string{"a href https://h/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			refs, err := References(parse.NewInputString(tt.html), tt.docURL)
+			test.Error(t, err)
+			list := []string{}
+			for _, ref := range refs {
+				s := fmt.Sprintf("%s %s %s", ref.Tag, ref.Attr, ref.Resolved)
+				if ref.Target != nil {
+					s += " " + string(ref.Target)
+				}
+				list = append(list, s)
+			}
+			test.T(t, list, tt.expected)
+		})
+	}
+}
+
+func TestReferencesSpans(t *testing.T) {
+	html := `<a href="/a" title=x><img src=/i.png alt=''>`
+	refs, err := References(parse.NewInputString(html), "")
+	test.Error(t, err)
+	test.T(t, len(refs), 2)
+	test.String(t, html[refs[0].Start:refs[0].End], "/a")
+	test.T(t, refs[0].Quote, byte('"'))
+	test.String(t, html[refs[1].Start:refs[1].End], "/i.png")
+	test.T(t, refs[1].Quote, byte(0))
+}
+
+func TestRewriteReferences(t *testing.T) {
+	html := `<base href="https://example.com/docs/"><a href='guide.html' target=_top>Guide</a><img src=logo.png alt="logo"><a href="#top">`
+	w := &bytes.Buffer{}
+	err := RewriteReferences(w, parse.NewInputString(html), "", func(ref Reference) []byte {
+		if ref.Attr[0] == 'h' && ref.URL[0] == '#' {
+			return nil
+		}
+		return []byte(ref.Resolved + "?a=1&b='2'")
+	})
+	test.Error(t, err)
+	test.String(t, w.String(), `<base href="https://example.com/docs/"><a href='https://example.com/docs/guide.html?a=1&amp;b=&#39;2&#39;' target=_top>Guide</a><img src="https://example.com/docs/logo.png?a=1&amp;b='2'" alt="logo"><a href="#top">`)
+}
+
+func TestReferenceRewriter(t *testing.T) {
+	html := `<a href=a.html>A</a><img src="b.png">`
+	rw := ReferenceRewriter{parse.NewInputString(html), "https://h/", func(ref Reference) []byte {
+		return []byte(ref.Resolved)
+	}}
+	w := &bytes.Buffer{}
+	var wt io.WriterTo = rw
+	n, err := wt.WriteTo(w)
+	test.Error(t, err)
+	test.String(t, w.String(), `<a href="https://h/a.html">A</a><img src="https://h/b.png">`)
+	test.T(t, n, int64(w.Len()))
+
+	rw.Input = parse.NewInputString(html)
+	n, err = rw.WriteTo(test.NewErrorWriter(2))
+	test.T(t, err, test.ErrPlain)
+	test.T(t, n, int64(len(`<a href=`)+len(`"https://h/a.html"`)))
+}
+
+func TestRewriteReferencesEmptyValue(t *testing.T) {
+	html := `<a href= >x</a><img src=`
+	w := &bytes.Buffer{}
+	err := RewriteReferences(w, parse.NewInputString(html), "https://h/", func(ref Reference) []byte {
+		return nil
+	})
+	test.Error(t, err)
+	test.String(t, w.String(), html)
+}
@@ -0,0 +1,398 @@
+package html
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/buffer"
+	"github.com/politepixels/tdewolff-parse/v2/css/selector"
+)
+
+// SelectorMatch is an element whose start tag matches one or more selectors of a SelectorMatcher.
+type SelectorMatch struct {
+	Selectors  []int  // indices of the matching complex selectors in the selector list
+	Tag        []byte // tag name in lowercase
+	Start, End int    // byte offsets of the start tag
+}
+
+// SelectorMatcher matches a compiled list of CSS selectors against the start tags of elements while streaming over lexer tokens, without building a tree. Only selectors that can be decided by the time the start tag ends are supported, that is the descendant and child combinators, type, universal, ID, class, and attribute selectors, and the pseudo-classes :not(), :is(), :where(), :matches(), :root, :scope, :first-child, :nth-child() without "of S", :first-of-type, :nth-of-type(), :lang(), :link, :any-link, :checked, :disabled, :enabled, :required, and :optional. Sibling combinators, :has(), pseudo-classes that depend on following siblings or children, and pseudo-elements are rejected.
+//
+// The element tree is approximated from the tokens: void elements and self-closing tags have no children, end tags close all elements up to the matching start tag, and start tags implicitly close elements such as p, li, and td according to the HTML specification. Elements are not reparented, and implicit html, head, and body elements are not inserted. Inline SVG and MathML are returned as a single token by the lexer and are counted as siblings but never matched.
+type SelectorMatcher struct {
+	list  selector.List
+	stack []*selectorElement // open elements, where the first is the document
+	cur   *selectorElement   // element of the start tag being read
+}
+
+type selectorElement struct {
+	tag     []byte
+	attrs   []selectorAttr
+	lang    []byte // inherited language
+	start   int
+	index   int // 1-based index among the element siblings
+	ofType  int // 1-based index among the element siblings of the same type
+	void    bool
+	counter int            // number of child elements
+	types   map[string]int // number of child elements per type
+}
+
+type selectorAttr struct {
+	key, val []byte
+}
+
+var selectorPseudoClasses = map[string]bool{
+	"not":           true,
+	"is":            true,
+	"where":         true,
+	"matches":       true,
+	"root":          true,
+	"scope":         true,
+	"first-child":   true,
+	"nth-child":     true,
+	"first-of-type": true,
+	"nth-of-type":   true,
+	"lang":          true,
+	"link":          true,
+	"any-link":      true,
+	"checked":       true,
+	"disabled":      true,
+	"enabled":       true,
+	"required":      true,
+	"optional":      true,
+}
+
+// impliedEndTags are elements with the start tags that implicitly close them.
+var impliedEndTags = map[string][]string{
+	"p":        {"address", "article", "aside", "blockquote", "details", "div", "dl", "fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup", "hr", "main", "menu", "nav", "ol", "p", "pre", "section", "table", "ul"},
+	"li":       {"li"},
+	"dt":       {"dt", "dd"},
+	"dd":       {"dt", "dd"},
+	"option":   {"option", "optgroup"},
+	"optgroup": {"optgroup"},
+	"tr":       {"tr", "tbody", "thead", "tfoot"},
+	"td":       {"td", "th", "tr", "tbody", "thead", "tfoot"},
+	"th":       {"td", "th", "tr", "tbody", "thead", "tfoot"},
+	"thead":    {"tbody", "tfoot"},
+	"tbody":    {"tbody", "tfoot"},
+	"rt":       {"rt", "rp"},
+	"rp":       {"rt", "rp"},
+}
+
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// CompileSelectors parses a comma-separated list of CSS selectors and returns a SelectorMatcher. It returns an error if the list contains selectors that cannot be matched while streaming.
+func CompileSelectors(selectors string) (*SelectorMatcher, error) {
+	b := []byte(selectors)
+	list, err := selector.Parse(parse.NewInputBytes(parse.Copy(b)))
+	if err != nil {
+		return nil, err
+	} else if err := checkStreamable(list, b); err != nil {
+		return nil, err
+	}
+	return &SelectorMatcher{
+		list:  list,
+		stack: []*selectorElement{{}},
+	}, nil
+}
+
+// checkStreamable returns an error for the first selector that cannot be matched while streaming, with b the input of the selectors for the error position.
+func checkStreamable(list selector.List, b []byte) error {
+	for _, complex := range list {
+		for _, compound := range complex.Compounds {
+			if compound.Combinator != selector.NoCombinator && compound.Combinator != selector.DescendantCombinator && compound.Combinator != selector.ChildCombinator {
+				return parse.NewError(buffer.NewReader(b), compound.Start, "unsupported '%s' combinator for streaming", compound.Combinator.String())
+			}
+			for _, s := range compound.Selectors {
+				if s.Type == selector.NestingSelector || s.Type == selector.PseudoElementSelector {
+					return parse.NewError(buffer.NewReader(b), s.Start, "unsupported selector '%s' for streaming", s.String())
+				} else if s.Type == selector.PseudoClassSelector {
+					if !selectorPseudoClasses[string(s.Name)] || string(s.Name) == "nth-child" && s.Selectors != nil {
+						return parse.NewError(buffer.NewReader(b), s.Start, "unsupported pseudo-class '%s' for streaming", s.String())
+					} else if err := checkStreamable(s.Selectors, b); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Next processes the current token of the lexer and returns the match of an element once its start tag ends.
+func (m *SelectorMatcher) Next(l *Lexer, tt TokenType) (SelectorMatch, bool) {
+	switch tt {
+	case StartTagToken:
+		tag := l.Text()
+		for 1 < len(m.stack) && containsString(impliedEndTags[string(m.top().tag)], string(tag)) {
+			m.stack = m.stack[:len(m.stack)-1]
+		}
+		m.cur = m.newElement(tag)
+		m.cur.start = l.TokenStart()
+		m.cur.void = voidElements[string(tag)]
+	case AttributeToken:
+		if m.cur == nil {
+			break
+		}
+		val, _ := unquoteAttrVal(l.AttrVal())
+		key := parse.ToLower(parse.Copy(l.AttrKey()))
+		val = unescapeCharRefs(val)
+		if (string(key) == "lang" || string(key) == "xml:lang") && selectorAttrVal(m.cur.attrs, "lang") == nil && selectorAttrVal(m.cur.attrs, "xml:lang") == nil {
+			m.cur.lang = val
+		}
+		m.cur.attrs = append(m.cur.attrs, selectorAttr{key, val})
+	case StartTagCloseToken, StartTagVoidToken:
+		if m.cur == nil {
+			break
+		}
+		cur := m.cur
+		m.cur = nil
+		if tt == StartTagVoidToken {
+			cur.void = true
+		}
+		m.stack = append(m.stack, cur)
+		match := SelectorMatch{Tag: cur.tag, Start: cur.start, End: l.TokenEnd()}
+		for i, complex := range m.list {
+			if m.matchComplex(complex.Compounds, len(m.stack)-1) {
+				match.Selectors = append(match.Selectors, i)
+			}
+		}
+		if cur.void {
+			m.stack = m.stack[:len(m.stack)-1]
+		}
+		return match, match.Selectors != nil
+	case EndTagToken:
+		tag := l.Text()
+		for i := len(m.stack) - 1; 0 < i; i-- {
+			if bytes.Equal(m.stack[i].tag, tag) {
+				m.stack = m.stack[:i]
+				break
+			}
+		}
+	case SVGToken, MathToken, XMLToken:
+		tag := svgBytes
+		if tt == MathToken {
+			tag = mathBytes
+		} else if tt == XMLToken {
+			tag = xmlBytes
+		}
+		m.newElement(tag)
+	}
+	return SelectorMatch{}, false
+}
+
+var (
+	svgBytes  = []byte("svg")
+	mathBytes = []byte("math")
+	xmlBytes  = []byte("xml")
+)
+
+// MatchSelectors returns all elements in document order that match any of the comma-separated CSS selectors, see SelectorMatcher.
+func MatchSelectors(r *parse.Input, selectors string) ([]SelectorMatch, error) {
+	m, err := CompileSelectors(selectors)
+	if err != nil {
+		return nil, err
+	}
+	matches := []SelectorMatch{}
+	l := NewLexer(r)
+	for {
+		tt, _ := l.Next()
+		if tt == ErrorToken {
+			if l.Err() != io.EOF {
+				return matches, l.Err()
+			}
+			return matches, nil
+		} else if match, ok := m.Next(l, tt); ok {
+			matches = append(matches, match)
+		}
+	}
+}
+
+func (m *SelectorMatcher) top() *selectorElement {
+	return m.stack[len(m.stack)-1]
+}
+
+// newElement returns a new child element of the top of the stack and updates the sibling counters of its parent.
+func (m *SelectorMatcher) newElement(tag []byte) *selectorElement {
+	parent := m.top()
+	if parent.types == nil {
+		parent.types = map[string]int{}
+	}
+	parent.counter++
+	parent.types[string(tag)]++
+	return &selectorElement{
+		tag:    tag,
+		lang:   parent.lang,
+		index:  parent.counter,
+		ofType: parent.types[string(tag)],
+	}
+}
+
+// matchComplex returns true if the element at index i of the stack matches the compound selectors, whose combinators relate them to its ancestors.
+func (m *SelectorMatcher) matchComplex(compounds []selector.Compound, i int) bool {
+	last := compounds[len(compounds)-1]
+	if !m.matchCompound(last, i) {
+		return false
+	} else if len(compounds) == 1 {
+		return true
+	} else if last.Combinator == selector.ChildCombinator {
+		return 1 < i && m.matchComplex(compounds[:len(compounds)-1], i-1)
+	}
+	for j := i - 1; 0 < j; j-- {
+		if m.matchComplex(compounds[:len(compounds)-1], j) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SelectorMatcher) matchList(list selector.List, i int) bool {
+	for _, complex := range list {
+		if m.matchComplex(complex.Compounds, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SelectorMatcher) matchCompound(compound selector.Compound, i int) bool {
+	for _, s := range compound.Selectors {
+		if !m.matchSimple(s, i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *SelectorMatcher) matchSimple(s selector.Simple, i int) bool {
+	el := m.stack[i]
+	tag := string(el.tag)
+	switch s.Type {
+	case selector.TypeSelector:
+		return (s.Namespace == nil || len(s.Namespace) == 1 && s.Namespace[0] == '*') && parse.EqualFold(s.Name, el.tag)
+	case selector.UniversalSelector:
+		return s.Namespace == nil || len(s.Namespace) == 1 && s.Namespace[0] == '*'
+	case selector.IDSelector:
+		return bytes.Equal(selectorAttrVal(el.attrs, "id"), s.Name)
+	case selector.ClassSelector:
+		for _, class := range bytes.Fields(selectorAttrVal(el.attrs, "class")) {
+			if bytes.Equal(class, s.Name) {
+				return true
+			}
+		}
+		return false
+	case selector.AttributeSelector:
+		return matchAttribute(s, el.attrs)
+	case selector.PseudoClassSelector:
+		switch string(s.Name) {
+		case "not":
+			return !m.matchList(s.Selectors, i)
+		case "is", "where", "matches":
+			return m.matchList(s.Selectors, i)
+		case "root", "scope":
+			return i == 1
+		case "first-child":
+			return el.index == 1
+		case "nth-child":
+			return s.Nth.Matches(el.index)
+		case "first-of-type":
+			return el.ofType == 1
+		case "nth-of-type":
+			return s.Nth.Matches(el.ofType)
+		case "lang":
+			return el.lang != nil && s.MatchesLang(el.lang)
+		case "link", "any-link":
+			return (tag == "a" || tag == "area") && selectorAttrVal(el.attrs, "href") != nil
+		case "checked":
+			if tag == "input" {
+				typ := selectorAttrVal(el.attrs, "type")
+				return (parse.EqualFold(typ, []byte("checkbox")) || parse.EqualFold(typ, []byte("radio"))) && selectorAttrVal(el.attrs, "checked") != nil
+			}
+			return tag == "option" && selectorAttrVal(el.attrs, "selected") != nil
+		case "disabled", "enabled":
+			if tag != "button" && tag != "input" && tag != "select" && tag != "textarea" && tag != "optgroup" && tag != "option" && tag != "fieldset" {
+				return false
+			}
+			return (selectorAttrVal(el.attrs, "disabled") != nil) == (string(s.Name) == "disabled")
+		case "required", "optional":
+			if tag != "input" && tag != "select" && tag != "textarea" {
+				return false
+			}
+			return (selectorAttrVal(el.attrs, "required") != nil) == (string(s.Name) == "required")
+		}
+	}
+	return false
+}
+
+func matchAttribute(s selector.Simple, attrs []selectorAttr) bool {
+	if s.Namespace != nil && (len(s.Namespace) != 1 || s.Namespace[0] != '*') {
+		return false
+	}
+	val := selectorAttrVal(attrs, string(parse.ToLower(parse.Copy(s.Name))))
+	if val == nil {
+		return false
+	} else if s.Matcher == selector.ExistsMatcher {
+		return true
+	}
+	target := s.Value
+	if s.Modifier == 'i' || s.Modifier == 'I' {
+		val = parse.ToLower(parse.Copy(val))
+		target = parse.ToLower(parse.Copy(target))
+	}
+	switch s.Matcher {
+	case selector.EqualMatcher:
+		return bytes.Equal(val, target)
+	case selector.IncludeMatcher:
+		for _, field := range bytes.Fields(val) {
+			if bytes.Equal(field, target) {
+				return true
+			}
+		}
+	case selector.DashMatcher:
+		return bytes.Equal(val, target) || bytes.HasPrefix(val, target) && len(target) < len(val) && val[len(target)] == '-'
+	case selector.PrefixMatcher:
+		return 0 < len(target) && bytes.HasPrefix(val, target)
+	case selector.SuffixMatcher:
+		return 0 < len(target) && bytes.HasSuffix(val, target)
+	case selector.SubstringMatcher:
+		return 0 < len(target) && bytes.Contains(val, target)
+	}
+	return false
+}
+
+// selectorAttrVal returns the value of the first attribute with the given lowercase name, or nil if absent. Attributes without a value return an empty slice.
+func selectorAttrVal(attrs []selectorAttr, name string) []byte {
+	for _, attr := range attrs {
+		if string(attr.key) == name {
+			if attr.val == nil {
+				return []byte{}
+			}
+			return attr.val
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package html
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestMatchSelectors(t *testing.T) {
+	var tests = []struct {
+		selectors string
+		html      string
+		expected  []string
+	}{
+		{`p`, `<div><p>a</p><P>b</div>`, []string{"p[0]", "p[0]"}},
+		{`div p, .x`, `<p class="x y"><div><span><p></span></div>`, []string{"p[1]", "p[0]"}},
+		{`div > p`, `<div><p></p><span><p></span></div>`, []string{"p[0]"}},
+		{`#main a[href^="https:" i]`, `<main id=main><a href="HTTPS://x"><a href="/x"></main><a href="https://y">`, []string{"a[0]"}},
+		{`[lang|=en], [data-x~="b"], [title*=c], [title$=d]`, `<p lang=en-US><i data-x="a b"><b title="xcx"><u title=d>`, []string{"p[0]", "i[1]", "b[2]", "u[3]"}},
+		{`li:first-child, li:nth-child(2n+3)`, `<ul><li>a<li>b<li>c<li>d<li>e</ul>`, []string{"li[0]", "li[1]", "li[1]"}},
+		{`span:first-of-type, b:nth-of-type(2)`, `<p><b></b><span></span><b></b><span></span></p>`, []string{"span[0]", "b[1]"}},
+		{`:root, :not(div):not(:root)`, `<html><div><img></div></html>`, []string{"html[0]", "img[1]"}},
+		{`:is(ul, ol) > li:where(.a)`, `<ul><li class=a><ol><li class=a></ol></ul><li class=a>`, []string{"li[0]", "li[0]"}},
+		{`:lang(fr)`, `<div lang=fr-CA><p>a</p><p lang=en>b</p></div>`, []string{"div[0]", "p[0]"}},
+		{`:checked, :disabled, :required, a:link`, `<input type=checkbox checked><input checked><select required disabled><option selected></select><a href=x><a>`, []string{"input[0]", "select[1 2]", "option[0]", "a[3]"}},
+		{`p > b`, `<p><b></b><div><b></b></div>`, []string{"b[0]"}},
+		{`td`, `<table><tr><td>a<td>b<tr><td>c</table>`, []string{"td[0]", "td[0]", "td[0]"}},
+		{`br + *, svg ~ p`, ``, nil},
+		{`p:nth-child(2)`, `<div><svg><p></p></svg><p></p></div>`, []string{"p[0]"}},
+		{`img:first-child`, `<p><img><img/><img></p>`, []string{"img[0]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selectors, func(t *testing.T) {
+			matches, err := MatchSelectors(parse.NewInputString(tt.html), tt.selectors)
+			if tt.expected == nil {
+				test.That(t, err != nil, "must fail")
+				return
+			}
+			test.Error(t, err)
+			s := []string{}
+			for _, match := range matches {
+				s = append(s, fmt.Sprintf("%s%v", match.Tag, match.Selectors))
+			}
+			test.T(t, s, tt.expected)
+		})
+	}
+}
+
+func TestMatchSelectorsErrors(t *testing.T) {
+	var tests = []struct {
+		selectors string
+		err       string
+	}{
+		{`a + b`, "unsupported '+' combinator for streaming"},
+		{`a ~ b`, "unsupported '~' combinator for streaming"},
+		{`div:has(p)`, "unsupported pseudo-class ':has(p)' for streaming"},
+		{`li:last-child`, "unsupported pseudo-class ':last-child' for streaming"},
+		{`li:nth-child(2 of .a)`, "unsupported pseudo-class ':nth-child(2 of .a)' for streaming"},
+		{`:not(a > b + c)`, "unsupported '+' combinator for streaming"},
+		{`p::before`, "unsupported selector '::before' for streaming"},
+		{`p[`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selectors, func(t *testing.T) {
+			_, err := CompileSelectors(tt.selectors)
+			test.That(t, err != nil, "must fail")
+			if perr, ok := err.(*parse.Error); ok && tt.err != "" {
+				test.String(t, perr.Message, tt.err)
+			}
+		})
+	}
+}
+
+func TestSelectorMatcherOffsets(t *testing.T) {
+	s := `<div><p class=a>x</p></div>`
+	matches, err := MatchSelectors(parse.NewInputString(s), `.a`)
+	test.Error(t, err)
+	test.T(t, len(matches), 1)
+	test.String(t, s[matches[0].Start:matches[0].End], `<p class=a>`)
+}
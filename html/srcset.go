@@ -0,0 +1,174 @@
+package html
+
+import (
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// ImageCandidate is an image candidate string of a srcset attribute, which is a URL with an optional width or pixel density descriptor. A candidate without descriptors has a pixel density of 1 in browsers.
+type ImageCandidate struct {
+	URL     []byte
+	Width   int     // w descriptor, or zero if absent
+	Density float64 // x descriptor, or zero if absent
+	Height  int     // h descriptor, which is reserved by the specification and only valid together with a w descriptor, or zero if absent
+
+	Start, End int // byte offsets of the URL
+}
+
+// ParseSrcset parses the value of a srcset attribute, with its character references decoded, into image candidates as by browsers, see https://html.spec.whatwg.org/multipage/images.html#parsing-a-srcset-attribute. URLs are separated from their descriptors by whitespace, so that commas within URLs such as data:image/png;base64,... are kept, while trailing commas of a URL end the candidate. Candidates with invalid or conflicting descriptors, such as 1x 100w, are dropped. The byte offsets of URLs are relative to b.
+func ParseSrcset(b []byte) []ImageCandidate {
+	candidates := []ImageCandidate{}
+	i := 0
+	for {
+		for i < len(b) && (parse.IsWhitespace(b[i]) || b[i] == ',') {
+			i++
+		}
+		if len(b) <= i {
+			return candidates
+		}
+		start := i
+		for i < len(b) && !parse.IsWhitespace(b[i]) {
+			i++
+		}
+		end := i
+		for start < end && b[end-1] == ',' {
+			end--
+		}
+
+		var descriptors [][]byte
+		if end == i {
+			descriptors, i = tokenizeSrcsetDescriptors(b, i)
+		}
+		if candidate, ok := parseSrcsetDescriptors(descriptors); ok {
+			candidate.URL = b[start:end]
+			candidate.Start, candidate.End = start, end
+			candidates = append(candidates, candidate)
+		}
+	}
+}
+
+// tokenizeSrcsetDescriptors returns the descriptors following a URL at position i and the position after the comma that ends the candidate. Descriptors are separated by whitespace, except within parentheses.
+func tokenizeSrcsetDescriptors(b []byte, i int) ([][]byte, int) {
+	for i < len(b) && parse.IsWhitespace(b[i]) {
+		i++
+	}
+	descriptors := [][]byte{}
+	start := i
+	parens := false
+	for ; i < len(b); i++ {
+		c := b[i]
+		if parens {
+			parens = c != ')'
+		} else if c == '(' {
+			parens = true
+		} else if c == ',' || parse.IsWhitespace(c) {
+			if start < i {
+				descriptors = append(descriptors, b[start:i])
+			}
+			if c == ',' {
+				return descriptors, i + 1
+			}
+			start = i + 1
+		}
+	}
+	if start < i {
+		descriptors = append(descriptors, b[start:i])
+	}
+	return descriptors, i
+}
+
+// parseSrcsetDescriptors returns the candidate with the values of the descriptors, and false if they are invalid.
+func parseSrcsetDescriptors(descriptors [][]byte) (ImageCandidate, bool) {
+	candidate := ImageCandidate{}
+	for _, descriptor := range descriptors {
+		n := len(descriptor) - 1
+		switch descriptor[n] {
+		case 'w':
+			width, ok := parseSrcsetInt(descriptor[:n])
+			if !ok || candidate.Width != 0 || candidate.Density != 0 {
+				return candidate, false
+			}
+			candidate.Width = width
+		case 'x':
+			if !isSrcsetFloat(descriptor[:n]) || candidate.Width != 0 || candidate.Density != 0 || candidate.Height != 0 {
+				return candidate, false
+			}
+			density, err := strconv.ParseFloat(string(descriptor[:n]), 64)
+			if err != nil || density <= 0 {
+				// a density of zero is valid per specification but cannot be represented, and browsers ignore it
+				return candidate, false
+			}
+			candidate.Density = density
+		case 'h':
+			height, ok := parseSrcsetInt(descriptor[:n])
+			if !ok || candidate.Height != 0 || candidate.Density != 0 {
+				return candidate, false
+			}
+			candidate.Height = height
+		default:
+			return candidate, false
+		}
+	}
+	if candidate.Height != 0 && candidate.Width == 0 {
+		return candidate, false
+	}
+	return candidate, true
+}
+
+// parseSrcsetInt parses a positive integer of ASCII digits.
+func parseSrcsetInt(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || '9' < c {
+			return 0, false
+		}
+		if n < 1<<30 {
+			n = n*10 + int(c-'0')
+		}
+	}
+	return n, n != 0
+}
+
+// isSrcsetFloat returns true if b is a valid floating-point number, see https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-floating-point-number.
+func isSrcsetFloat(b []byte) bool {
+	i := 0
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	digits := 0
+	for ; i < len(b) && '0' <= b[i] && b[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(b) && b[i] == '.' {
+		i++
+		fraction := 0
+		for ; i < len(b) && '0' <= b[i] && b[i] <= '9'; i++ {
+			fraction++
+		}
+		if fraction == 0 {
+			return false
+		}
+		digits += fraction
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '-' || b[i] == '+') {
+			i++
+		}
+		exponent := 0
+		for ; i < len(b) && '0' <= b[i] && b[i] <= '9'; i++ {
+			exponent++
+		}
+		if exponent == 0 {
+			return false
+		}
+	}
+	return i == len(b)
+}
@@ -0,0 +1,49 @@
+package html
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseSrcset(t *testing.T) {
+	var tests = []struct {
+		srcset   string
+		expected string
+	}{
+		{"", ""},
+		{" , ,", ""},
+		{"a.png", "a.png 0w 0x"},
+		{"a.png 1x, b.png 2x", "a.png 0w 1x, b.png 0w 2x"},
+		{"a.png 100w,b.png 200w", "a.png 100w 0x, b.png 200w 0x"},
+		{"a.png, b.png 1.5x", "a.png 0w 0x, b.png 0w 1.5x"},
+		{"a.png,b.png 1.5x", "a.png,b.png 0w 1.5x"},
+		{"a.png,,, b.png", "a.png 0w 0x, b.png 0w 0x"},
+		{"data:image/png;base64,iVBOR= 2x, b.png", "data:image/png;base64,iVBOR= 0w 2x, b.png 0w 0x"},
+		{"a,b.png 1x", "a,b.png 0w 1x"},
+		{"a.png\t\n1e1x ,b.png  .5x", "a.png 0w 10x, b.png 0w 0.5x"},
+		{"a.png 100w 50h", "a.png 100w 0x 50h"},
+		{"a.png 50h, b.png 1x", "b.png 0w 1x"},
+		{"a.png 1x 100w, b.png 0w, c.png 0x, d.png -1x, e.png 1.x, f.png 1", ""},
+		{"a.png 1x 2x, b.png 10W, c.png +1x", ""},
+		{"a.png (max-width: 100px) 1x, b.png 2x", "b.png 0w 2x"},
+		{"a.png foo(1, 2), b.png", "b.png 0w 0x"},
+		{"a.png 1x (", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.srcset, func(t *testing.T) {
+			list := []string{}
+			for _, c := range ParseSrcset([]byte(tt.srcset)) {
+				test.String(t, tt.srcset[c.Start:c.End], string(c.URL))
+				s := fmt.Sprintf("%s %dw %gx", c.URL, c.Width, c.Density)
+				if c.Height != 0 {
+					s += fmt.Sprintf(" %dh", c.Height)
+				}
+				list = append(list, s)
+			}
+			test.String(t, strings.Join(list, ", "), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package html
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/xml"
+)
+
+// Namespaces of inline SVG elements and attributes, which are implied in HTML.
+const (
+	SVGNamespace   = xml.SVGNamespace
+	XLinkNamespace = "http://www.w3.org/1999/xlink"
+)
+
+// InlineSVG is an inline SVG element of an HTML document.
+type InlineSVG struct {
+	Start, End int       // offsets of the SVG element in the HTML document
+	Root       *xml.Node // svg element, nil if it could not be parsed
+	Err        error     // XML parse error, if any
+}
+
+// ParseSVG parses the data of an SVGToken that was just returned by the lexer into an XML tree and returns its svg element, so that inline SVG can be handed to an SVG optimizer while streaming an HTML document. Elements without namespace declarations are in the SVG namespace and the xlink prefix is bound to the XLink namespace, as implied in HTML. The Start and End offsets of all nodes, and the position of the returned *parse.Error, are translated to the HTML document. Byte slices of the nodes point into a copy of data.
+func ParseSVG(l *Lexer, data []byte) (*xml.Node, error) {
+	start := l.r.Offset() - len(data)
+	d := xml.NewDecoder(parse.NewInputBytes(parse.Copy(data)))
+	d.Bind("", SVGNamespace)
+	d.Bind("xlink", XLinkNamespace)
+
+	var root *xml.Node
+	var err error
+	for root == nil && err == nil {
+		switch et, _ := d.Next(); et {
+		case xml.StartElementEvent:
+			root, err = d.Subtree()
+		case xml.ErrorEvent:
+			err = d.Err()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+	}
+	if err != nil {
+		if perr, ok := err.(*parse.Error); ok {
+			offset := start + offsetAt(data, perr.Line, perr.Column) - l.r.Freed()
+			herr := parse.NewError(l.r, offset, perr.Message)
+			if 0 < l.r.Freed() {
+				herr.Line, herr.Column = l.r.PositionAt(offset + l.r.Freed()) // the buffer of a streaming Input does not start at the first line
+			}
+			err = herr
+		}
+		return nil, err
+	}
+
+	root.Parent = nil
+	root.Walk(func(n *xml.Node) bool {
+		n.Start += start
+		n.End += start
+		return true
+	})
+	return root, nil
+}
+
+// InlineSVGs returns all inline SVG elements of an HTML document in document order, see ParseSVG. It returns an error only when the HTML could not be lexed, while errors of individual SVG elements are returned in their Err field.
+func InlineSVGs(r *parse.Input) ([]InlineSVG, error) {
+	svgs := []InlineSVG{}
+	l := NewLexer(r)
+	for {
+		tt, data := l.Next()
+		switch tt {
+		case ErrorToken:
+			if l.Err() != io.EOF {
+				return svgs, l.Err()
+			}
+			return svgs, nil
+		case SVGToken:
+			root, err := ParseSVG(l, data)
+			svgs = append(svgs, InlineSVG{
+				Start: r.Offset() - len(data),
+				End:   r.Offset(),
+				Root:  root,
+				Err:   err,
+			})
+		}
+	}
+}
+
+// offsetAt returns the offset of a line and column in b as returned by parse.Position.
+func offsetAt(b []byte, line, col int) int {
+	i := 0
+	for ; 1 < line && i < len(b); i++ {
+		if b[i] == '\n' || b[i] == '\r' && (i+1 == len(b) || b[i+1] != '\n') {
+			line--
+		} else if r, n := utf8.DecodeRune(b[i:]); r == '\u2028' || r == '\u2029' {
+			line--
+			i += n - 1
+		}
+	}
+	for ; 1 < col && i < len(b); col-- {
+		_, n := utf8.DecodeRune(b[i:])
+		i += n
+	}
+	return i
+}
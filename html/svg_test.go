@@ -0,0 +1,40 @@
+package html
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/xml"
+	"github.com/tdewolff/test"
+)
+
+func TestInlineSVGs(t *testing.T) {
+	s := "<p>icon:</p>\n<svg viewBox=\"0 0 8 8\"><use xlink:href=\"#a\"/><path d=\"M0 0\"></path></svg><svg><g></svg>"
+	svgs, err := InlineSVGs(parse.NewInputString(s))
+	test.Error(t, err)
+	test.T(t, len(svgs), 2)
+
+	svg := svgs[0]
+	test.Error(t, svg.Err)
+	test.String(t, s[svg.Start:svg.End], `<svg viewBox="0 0 8 8"><use xlink:href="#a"/><path d="M0 0"></path></svg>`)
+	test.T(t, svg.Root.Start, svg.Start)
+	test.T(t, svg.Root.End, svg.End)
+	test.T(t, svg.Root.Parent, (*xml.Node)(nil))
+	test.String(t, string(svg.Root.Space), SVGNamespace)
+	test.String(t, string(svg.Root.Local), "svg")
+
+	use := svg.Root.Children[0]
+	test.String(t, s[use.Start:use.End], `<use xlink:href="#a"/>`)
+	href, ok := use.AttrVal(XLinkNamespace, "href")
+	test.That(t, ok)
+	test.String(t, string(href), "#a")
+	test.T(t, len(svg.Root.Elements(SVGNamespace, "path")), 1)
+
+	svg = svgs[1]
+	test.T(t, svg.Root, (*xml.Node)(nil))
+	test.That(t, svg.Err != nil)
+	perr := svg.Err.(*parse.Error)
+	test.T(t, perr.Line, 2)
+	test.T(t, perr.Column, 82)
+	test.String(t, perr.Message, "unexpected end tag </svg>")
+}
@@ -0,0 +1,234 @@
+package html
+
+import (
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// TextOptions are the options for ExtractText.
+type TextOptions struct {
+	// Normalize is applied to the extracted text, such as norm.NFC.Bytes of golang.org/x/text/unicode/norm to normalize the text to Unicode NFC so that canonically equivalent text is indexed the same. It may modify its argument.
+	Normalize func([]byte) []byte
+}
+
+// textBlockElements are the elements that are displayed as blocks by default, with the number of line breaks that separate them from the surrounding text as for innerText, see https://html.spec.whatwg.org/multipage/dom.html#the-innertext-idl-attribute.
+var textBlockElements = map[string]int{
+	"address":    1,
+	"article":    1,
+	"aside":      1,
+	"blockquote": 1,
+	"body":       1,
+	"caption":    1,
+	"center":     1,
+	"dd":         1,
+	"details":    1,
+	"dialog":     1,
+	"dir":        1,
+	"div":        1,
+	"dl":         1,
+	"dt":         1,
+	"fieldset":   1,
+	"figcaption": 1,
+	"figure":     1,
+	"footer":     1,
+	"form":       1,
+	"h1":         1,
+	"h2":         1,
+	"h3":         1,
+	"h4":         1,
+	"h5":         1,
+	"h6":         1,
+	"header":     1,
+	"hgroup":     1,
+	"hr":         1,
+	"html":       1,
+	"legend":     1,
+	"li":         1,
+	"listing":    1,
+	"main":       1,
+	"menu":       1,
+	"nav":        1,
+	"ol":         1,
+	"p":          2,
+	"plaintext":  1,
+	"pre":        1,
+	"search":     1,
+	"section":    1,
+	"summary":    1,
+	"table":      1,
+	"tr":         1,
+	"ul":         1,
+	"xmp":        1,
+}
+
+// textSkipElements are the elements whose contents are not rendered as text.
+var textSkipElements = map[string]bool{
+	"head":     true,
+	"iframe":   true,
+	"noembed":  true,
+	"noframes": true,
+	"noscript": true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+}
+
+// textExtractor accumulates text and the separator that is pending until the next text.
+type textExtractor struct {
+	text   []byte
+	breaks int  // number of pending line breaks
+	sep    byte // pending space or tab when there are no pending line breaks
+}
+
+func (e *textExtractor) lineBreaks(n int) {
+	if e.breaks < n {
+		e.breaks = n
+	}
+}
+
+// flush writes the pending separator, except at the start of the text.
+func (e *textExtractor) flush() {
+	if 0 < len(e.text) {
+		if 0 < e.breaks {
+			for i := 0; i < e.breaks; i++ {
+				e.text = append(e.text, '\n')
+			}
+		} else if e.sep != 0 {
+			e.text = append(e.text, e.sep)
+		}
+	}
+	e.breaks, e.sep = 0, 0
+}
+
+// ExtractText returns the text of the document as rendered by browsers with the default styles, for search indexing and readability tools. Whitespace is collapsed as for the CSS white-space property set to normal, except in pre and listing elements, and block elements are separated by a newline and paragraphs by an empty line, while br elements are line breaks and table cells are separated by tabs, similar to innerText. Character references are decoded. The contents of the head, script, style, template, form controls, and elements with the hidden attribute are skipped, as are inline SVG and MathML.
+func ExtractText(r *parse.Input, o TextOptions) ([]byte, error) {
+	e := &textExtractor{text: []byte{}}
+	var tag, skip []byte // skip is the tag name of the skipped element
+	skipLevel := 0       // number of open elements named skip
+	hidden, dropNewline := false, false
+	pre, cells := 0, 0
+
+	l := NewLexer(r)
+	for {
+		tt, data := l.Next()
+		if tt != TextToken {
+			dropNewline = false
+		}
+		switch tt {
+		case ErrorToken:
+			if l.Err() != io.EOF {
+				return nil, l.Err()
+			}
+			if o.Normalize != nil {
+				e.text = o.Normalize(e.text)
+			}
+			return e.text, nil
+		case StartTagToken:
+			tag = l.Text()
+			hidden = false
+		case AttributeToken:
+			if string(l.AttrKey()) == "hidden" {
+				hidden = true
+			}
+		case StartTagCloseToken, StartTagVoidToken:
+			if skip != nil {
+				if tt == StartTagCloseToken && string(tag) == string(skip) {
+					skipLevel++
+				}
+				break
+			} else if voidElements[string(tag)] || tt == StartTagVoidToken {
+				if string(tag) == "br" {
+					e.flush()
+					e.text = append(e.text, '\n')
+				} else if string(tag) == "hr" && !hidden {
+					e.lineBreaks(1)
+				}
+				break
+			} else if hidden || textSkipElements[string(tag)] {
+				skip, skipLevel = tag, 1
+				break
+			}
+
+			switch string(tag) {
+			case "pre", "listing":
+				pre++
+				dropNewline = true // the newline after the start tag is ignored by the HTML parser
+			case "tr":
+				cells = 0
+			case "td", "th":
+				if 0 < cells && e.breaks == 0 {
+					e.sep = '\t'
+				}
+				cells++
+			}
+			e.lineBreaks(textBlockElements[string(tag)])
+		case EndTagToken:
+			name := l.Text()
+			if skip != nil {
+				if string(name) == string(skip) {
+					if skipLevel--; skipLevel == 0 {
+						skip = nil
+					}
+				}
+				break
+			} else if (string(name) == "pre" || string(name) == "listing") && 0 < pre {
+				pre--
+			} else if string(name) == "br" {
+				// </br> is parsed as <br>
+				e.flush()
+				e.text = append(e.text, '\n')
+			}
+			e.lineBreaks(textBlockElements[string(name)])
+		case TextToken:
+			if skip != nil {
+				break
+			}
+			text := appendCharRefs(nil, data, false)
+			if 0 < pre {
+				text = normalizeNewlines(text)
+				if dropNewline && 0 < len(text) && text[0] == '\n' {
+					text = text[1:]
+				}
+				dropNewline = false
+				if 0 < len(text) {
+					e.flush()
+					e.text = append(e.text, text...)
+				}
+				break
+			}
+			for i := 0; i < len(text); {
+				if n := parse.ASCIIWhitespace.Len(text[i:]); n != 0 {
+					if e.sep == 0 {
+						e.sep = ' '
+					}
+					i += n
+					continue
+				}
+				e.flush()
+				e.text = append(e.text, text[i])
+				i++
+			}
+		}
+	}
+}
+
+// normalizeNewlines replaces CRLF and CR by LF as the HTML parser does.
+func normalizeNewlines(b []byte) []byte {
+	j := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\r' {
+			b[j] = '\n'
+			if i+1 < len(b) && b[i+1] == '\n' {
+				i++
+			}
+		} else {
+			b[j] = b[i]
+		}
+		j++
+	}
+	return b[:j]
+}
@@ -0,0 +1,59 @@
+package html
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestExtractText(t *testing.T) {
+	var tests = []struct {
+		html     string
+		expected string
+	}{
+		{"", ""},
+		{"  a  b\n\tc  ", "a b c"},
+		{"a<b> b </b>c", "a b c"},
+		{"a<b>b</b>c", "abc"},
+		{"a&amp;b&nbsp;c &lt;d&gt; &copy &unknown;", "a&b\u00a0c <d> \u00a9 &unknown;"},
+		{"<p>a</p> <p> b </p>", "a\n\nb"},
+		{"<div>a<div>b</div>c</div>", "a\nb\nc"},
+		{"<h1>Title</h1>text", "Title\ntext"},
+		{"<ul><li>a</li><li>b</li></ul>", "a\nb"},
+		{"a<br>b<br/>c</br>d", "a\nb\nc\nd"},
+		{"<p>a<br></p><p>b</p>", "a\n\n\nb"},
+		{"a<hr>b", "a\nb"},
+		{"<table><tr><td>a</td><td>b</td></tr><tr><th>c</th> <td>d</td></tr></table>", "a\tb\nc\td"},
+		{"<pre>\n  a\n  b</pre>c", "  a\n  b\nc"},
+		{"<pre>\r\na\r\nb\rc</pre>", "a\nb\nc"},
+		{"<pre><b>a</b>  <i>b</i></pre>", "a  b"},
+		{"<head><title>t</title><style>a{}</style></head><body>a</body>", "a"},
+		{"a<script>var b = '<p>';</script>c", "ac"},
+		{"a <template><p>b</p></template> c", "a c"},
+		{"a<textarea>b</textarea><select><option>c</select>d", "ad"},
+		{"a<div hidden>b<div>c</div>d</div>e", "ae"},
+		{"a<span hidden>b<span>c</span>d</span>e", "ae"},
+		{"a<svg><text>b</text></svg>c", "ac"},
+		{"<!DOCTYPE html><!-- comment --><html><body><main><p>a</p></main></body></html>", "a"},
+		{"x\u00a0  \u00a0y", "x\u00a0 \u00a0y"}, // non-breaking spaces are not collapsed
+	}
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			text, err := ExtractText(parse.NewInputString(tt.html), TextOptions{})
+			test.Error(t, err)
+			test.String(t, string(text), tt.expected)
+		})
+	}
+}
+
+func TestExtractTextNormalize(t *testing.T) {
+	// compose e and a combining acute accent, which are split over a character reference
+	nfc := func(b []byte) []byte {
+		return bytes.Replace(b, []byte("e\u0301"), []byte("\u00e9"), -1)
+	}
+	text, err := ExtractText(parse.NewInputString("<p>caf&#101;&#x301;</p>"), TextOptions{Normalize: nfc})
+	test.Error(t, err)
+	test.String(t, string(text), "caf\u00e9")
+}
@@ -5,6 +5,18 @@ var (
 	doubleQuoteEntityBytes = []byte("&#34;")
 )
 
+// unquoteAttrVal returns an attribute value from Lexer.AttrVal without its quotes, and the quote character or zero if it is unquoted. The closing quote may be missing at the end of the input.
+func unquoteAttrVal(val []byte) ([]byte, byte) {
+	if len(val) == 0 || val[0] != '"' && val[0] != '\'' {
+		return val, 0
+	}
+	quote := val[0]
+	if 1 < len(val) && val[len(val)-1] == quote {
+		return val[1 : len(val)-1], quote
+	}
+	return val[1:], quote
+}
+
 // EscapeAttrVal returns the escaped attribute value bytes with quotes. Either single or double quotes are used, whichever is shorter. If there are no quotes present in the value and the value is in HTML (not XML), it will return the value without quotes.
 func EscapeAttrVal(buf *[]byte, b []byte, origQuote byte, mustQuote bool) []byte {
 	singles := 0
@@ -6,6 +6,30 @@ import (
 	"github.com/tdewolff/test"
 )
 
+func TestUnquoteAttrVal(t *testing.T) {
+	var unquoteAttrValTests = []struct {
+		attrVal  string
+		expected string
+		quote    byte
+	}{
+		{`xyz`, `xyz`, 0},
+		{``, ``, 0},
+		{`"xyz"`, `xyz`, '"'},
+		{`'xyz'`, `xyz`, '\''},
+		{`"x'z'`, `x'z'`, '"'},
+		{`"xyz`, `xyz`, '"'},
+		{`"`, ``, '"'},
+		{`''`, ``, '\''},
+	}
+	for _, tt := range unquoteAttrValTests {
+		t.Run(tt.attrVal, func(t *testing.T) {
+			val, quote := unquoteAttrVal([]byte(tt.attrVal))
+			test.String(t, string(val), tt.expected)
+			test.T(t, quote, tt.quote)
+		})
+	}
+}
+
 func TestEscapeAttrVal(t *testing.T) {
 	var escapeAttrValTests = []struct {
 		attrVal  string
@@ -28,14 +52,7 @@ func TestEscapeAttrVal(t *testing.T) {
 	var buf []byte
 	for _, tt := range escapeAttrValTests {
 		t.Run(tt.attrVal, func(t *testing.T) {
-			b := []byte(tt.attrVal)
-			var quote byte
-			if 0 < len(b) && (b[0] == '\'' || b[0] == '"') {
-				quote = b[0]
-			}
-			if len(b) > 1 && (b[0] == '"' || b[0] == '\'') && b[0] == b[len(b)-1] {
-				b = b[1 : len(b)-1]
-			}
+			b, quote := unquoteAttrVal([]byte(tt.attrVal))
 			val := EscapeAttrVal(&buf, b, quote, false)
 			test.String(t, string(val), tt.expected)
 		})
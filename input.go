@@ -2,12 +2,17 @@ package parse
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"unicode/utf8"
+
+	"github.com/politepixels/tdewolff-parse/v2/buffer"
 )
 
 var nullBuffer = []byte{0}
 
+const defaultStreamSize = 4096
+
 // Input is a buffered reader that allows peeking forward and shifting, taking an io.Input.
 // It keeps data in-memory until Free, taking a byte length, is called to move beyond the data.
 type Input struct {
@@ -17,10 +22,26 @@ type Input struct {
 	err   error
 
 	restore func()
+	close   func() error
+	arena   *buffer.Arena
+	runs    []charsetRun // offset mapping to the source when transcoded
+	bom     int          // length of the skipped byte order mark
+	hooks   *Hooks
 
 	line        int // current line number (1-based)
 	col         int // current column number (1-based, in runes)
 	lastNewline int // byte offset of the last newline character
+	lazy        bool
+	columnMode  ColumnMode
+	tabWidth    int
+	tracked     int // index in buf up to which the line and column numbers are valid when lazy
+
+	// streaming mode
+	r          io.Reader // nil when everything has been read
+	size       int       // chunk size
+	offset     int       // offset of buf in the stream
+	anchorLine int       // line number at the start of buf
+	anchorCol  int       // column number at the start of buf
 }
 
 // NewInput returns a new Input for a given io.Input and uses io.ReadAll to read it into a byte slice.
@@ -37,10 +58,13 @@ func NewInput(r io.Reader) *Input {
 			b, err = io.ReadAll(r)
 			if err != nil {
 				return &Input{
-					buf:  nullBuffer,
-					err:  err,
-					line: 1,
-					col:  1,
+					buf:         nullBuffer,
+					err:         err,
+					line:        1,
+					col:         1,
+					lastNewline: -1,
+					anchorLine:  1,
+					anchorCol:   1,
 				}
 			}
 		}
@@ -48,6 +72,154 @@ func NewInput(r io.Reader) *Input {
 	return NewInputBytes(b)
 }
 
+// NewStreamInput returns a new Input that reads from an io.Reader in chunks of 4kB, see NewStreamInputSize.
+func NewStreamInput(r io.Reader) *Input {
+	return NewStreamInputSize(r, defaultStreamSize)
+}
+
+// NewStreamInputSize returns a new Input that reads from an io.Reader in chunks of the given size while it is peeked at. Data before the current selection is freed when reading the next chunk, so that lexers run in memory proportional to the chunk size and the longest token. Slices returned by Lexeme and Shift remain valid as the freed data is not overwritten. Offset returns the offset in the stream, while Bytes and Len only cover the data that has not been freed yet, which starts at offset Freed. If the io.Reader implements Bytes, that is used instead.
+func NewStreamInputSize(r io.Reader, size int) *Input {
+	if _, ok := r.(interface {
+		Bytes() []byte
+	}); ok || r == nil {
+		return NewInput(r)
+	} else if size < 1 {
+		size = defaultStreamSize
+	}
+	return &Input{
+		buf:         nullBuffer,
+		line:        1,
+		col:         1,
+		lastNewline: -1,
+		r:           r,
+		size:        size,
+		anchorLine:  1,
+		anchorCol:   1,
+	}
+}
+
+// ColumnMode is the unit in which column numbers are counted.
+type ColumnMode int
+
+// ColumnMode values.
+const (
+	RuneColumns  ColumnMode = iota // Unicode code points, invalid UTF-8 bytes count as one
+	ByteColumns                    // bytes
+	UTF16Columns                   // UTF-16 code units, as used by the Language Server Protocol
+)
+
+// InputOptions are the options for NewInputBytesOptions.
+type InputOptions struct {
+	// LazyPosition disables tracking the line and column numbers on every move, so that they are only computed when Position is called, continuing from the previous call. This speeds up lexers that never report positions.
+	LazyPosition bool
+
+	// ColumnMode is the unit of the column numbers returned by Position and PositionAt, which are runes by default.
+	ColumnMode ColumnMode
+
+	// TabWidth makes a tab advance the column numbers to the next tab stop, that is the next multiple of TabWidth plus one, so that columns match those displayed by editors and terminals. A tab counts as a single column when zero.
+	TabWidth int
+
+	// SkipBOM skips a leading UTF-8 byte order mark, which CSS, JS, and JSON require to be ignored, so that it is not returned as part of the first token. Offsets are relative to the data after the byte order mark, see BOM and SourceOffset.
+	SkipBOM bool
+}
+
+// NewInputBytesOptions returns a new Input for a given byte slice like NewInputBytes, using the given options.
+func NewInputBytesOptions(b []byte, o InputOptions) *Input {
+	bom := 0
+	if o.SkipBOM && bytes.HasPrefix(b, utf8BOM) {
+		bom = len(utf8BOM)
+	}
+	z := NewInputBytes(b[bom:])
+	if bom != 0 {
+		z.bom = bom
+		z.runs = []charsetRun{{0, bom, 1, 1}}
+	}
+	z.lazy = o.LazyPosition
+	z.columnMode = o.ColumnMode
+	z.tabWidth = o.TabWidth
+	return z
+}
+
+// BOM returns the length of the byte order mark that was skipped by NewInputBytesOptions or NewInputCharset, or zero if there was none. Adding it to an offset gives the offset in the original data, which SourceOffset also does for transcoded inputs.
+func (z *Input) BOM() int {
+	return z.bom
+}
+
+// Hooks are instrumentation callbacks of an Input, with which fuzzing harnesses can measure how consumers such as lexers explore their inputs, for example to check invariants or to guide the fuzzer to inputs that reach new states. Offsets are in the stream, see Offset. Callbacks that are nil are not called.
+type Hooks struct {
+	// OnMove is called by Move, and by the functions that move the position such as MoveRune, Read, and Seek, with the offsets before and after moving.
+	OnMove func(from, to int)
+
+	// OnRewind is called by Rewind and RestoreCheckpoint with the offsets before and after rewinding.
+	OnRewind func(from, to int)
+
+	// OnPeekEOF is called when Peek, or PeekRune, peeks at or beyond the end of the input, with the offset that was peeked at.
+	OnPeekEOF func(offset int)
+}
+
+// SetHooks sets the instrumentation callbacks, or removes them when nil. Clones share the callbacks of the Input.
+func (z *Input) SetHooks(h *Hooks) {
+	z.hooks = h
+}
+
+// SetColumnMode sets the unit of the column numbers returned by Position and PositionAt. It should be called before reading from a streaming Input, as the column at the start of the buffer is not recounted.
+func (z *Input) SetColumnMode(mode ColumnMode) {
+	if z.columnMode != mode {
+		z.columnMode = mode
+		z.position()
+	}
+}
+
+// SetTabWidth sets the distance between tab stops for the column numbers returned by Position and PositionAt, see InputOptions. It should be called before reading from a streaming Input, as the column at the start of the buffer is not recounted.
+func (z *Input) SetTabWidth(width int) {
+	if z.tabWidth != width {
+		z.tabWidth = width
+		z.position()
+	}
+}
+
+// column returns the column number after b when starting at column col, taking into account tab stops.
+func (z *Input) column(col int, b []byte) int {
+	if z.tabWidth <= 0 {
+		return col + z.columns(b)
+	}
+	for {
+		i := bytes.IndexByte(b, '\t')
+		if i == -1 {
+			return col + z.columns(b)
+		}
+		col += z.columns(b[:i])
+		col += z.tabWidth - (col-1)%z.tabWidth
+		b = b[i+1:]
+	}
+}
+
+// columns returns the number of columns spanned by b.
+func (z *Input) columns(b []byte) int {
+	switch z.columnMode {
+	case ByteColumns:
+		if len(z.runs) != 0 {
+			// b is a subslice of the buffer, count its bytes in the original data when transcoded
+			start := cap(z.buf) - cap(b)
+			return z.SourceOffset(start+len(b)) - z.SourceOffset(start)
+		}
+		return len(b)
+	case UTF16Columns:
+		n := 0
+		for 0 < len(b) {
+			r, size := utf8.DecodeRune(b)
+			if 0x10000 <= r {
+				n += 2 // surrogate pair
+			} else {
+				n++
+			}
+			b = b[size:]
+		}
+		return n
+	}
+	return utf8.RuneCount(b)
+}
+
 // NewInputString returns a new Input for a given string and appends NULL at the end.
 func NewInputString(s string) *Input {
 	return NewInputBytes([]byte(s))
@@ -61,6 +233,8 @@ func NewInputBytes(b []byte) *Input {
 		line:        1,
 		col:         1,
 		lastNewline: -1,
+		anchorLine:  1,
+		anchorCol:   1,
 	}
 
 	n := len(b)
@@ -93,6 +267,18 @@ func (z *Input) Restore() {
 	}
 }
 
+// Close releases the memory-mapped file of an Input returned by NewInputFile, after which the Input and all slices returned from it must not be used anymore. It does nothing for other inputs.
+func (z *Input) Close() error {
+	if z.close == nil {
+		return nil
+	}
+	close := z.close
+	z.close = nil
+	z.buf = nullBuffer
+	z.start, z.pos = 0, 0
+	return close()
+}
+
 // Err returns the error returned from io.Input or io.EOF when the end has been reached.
 func (z *Input) Err() error {
 	return z.PeekErr(0)
@@ -100,6 +286,9 @@ func (z *Input) Err() error {
 
 // PeekErr returns the error at position pos. When pos is zero, this is the same as calling Err().
 func (z *Input) PeekErr(pos int) error {
+	if z.r != nil && len(z.buf)-1 <= z.pos+pos {
+		z.read(z.pos + pos)
+	}
 	if z.err != nil {
 		return z.err
 	} else if len(z.buf)-1 <= z.pos+pos {
@@ -112,11 +301,73 @@ func (z *Input) PeekErr(pos int) error {
 // Peek returns 0 when an error has occurred, Err returns the erroz.
 func (z *Input) Peek(pos int) byte {
 	pos += z.pos
+	if len(z.buf)-1 <= pos {
+		return z.peekEnd(pos)
+	}
 	return z.buf[pos]
 }
 
+// peekEnd returns the byte at pos at or beyond the end of the buffer, which reads more data for a streaming Input.
+func (z *Input) peekEnd(pos int) byte {
+	offset := z.offset + pos
+	var c byte
+	if z.r != nil {
+		c = z.read(pos)
+	} else {
+		c = z.buf[pos]
+	}
+	if z.hooks != nil && z.hooks.OnPeekEOF != nil && z.offset+len(z.buf)-1 <= offset {
+		z.hooks.OnPeekEOF(offset)
+	}
+	return c
+}
+
+// read reads chunks until the byte at pos is buffered or the reader is exhausted, and returns that byte. The data before the selection is freed by moving the remainder to a new buffer, which leaves previously returned slices intact.
+func (z *Input) read(pos int) byte {
+	size := z.size
+	if p := pos - z.start + 1; size < 2*p {
+		size = 2 * p
+	}
+	d := len(z.buf) - 1 - z.start
+	buf := make([]byte, d, size+1)
+	copy(buf, z.buf[z.start:len(z.buf)-1])
+	for d <= pos-z.start && z.r != nil {
+		n, err := z.r.Read(buf[d:size])
+		d += n
+		if err != nil {
+			if err != io.EOF {
+				z.err = err
+			}
+			z.r = nil
+		}
+	}
+
+	// move the anchor of line and column numbers to the new start of the buffer
+	freed := z.buf[:z.start]
+	if i := bytes.LastIndexByte(freed, '\n'); i != -1 {
+		z.anchorLine += bytes.Count(freed, []byte{'\n'})
+		z.anchorCol = z.column(1, freed[i+1:])
+	} else {
+		z.anchorCol = z.column(z.anchorCol, freed)
+	}
+	z.offset += z.start
+	z.lastNewline -= z.start
+	z.pos -= z.start
+	pos -= z.start
+	z.start = 0
+	z.buf = append(buf[:d], 0)
+	if pos < d {
+		return z.buf[pos]
+	}
+	return 0
+}
+
 // PeekRune returns the rune and rune length of the ith byte relative to the end position.
 func (z *Input) PeekRune(pos int) (rune, int) {
+	if z.r != nil && len(z.buf)-1 < z.pos+pos+4 {
+		z.read(z.pos + pos + 3)
+	}
+
 	// from unicode/utf8
 	c := z.Peek(pos)
 	if c < 0xC0 || len(z.buf)-1-z.pos < 2 {
@@ -134,32 +385,49 @@ func (z *Input) Move(n int) {
 	if n == 0 {
 		return
 	}
+	if z.r != nil && len(z.buf)-1 < z.pos+n {
+		z.read(z.pos + n - 1)
+	}
 	end := z.pos + n
 	if end < 0 {
 		end = 0
 	} else if end > len(z.buf)-1 {
 		end = len(z.buf) - 1
 	}
+	if z.hooks != nil && z.hooks.OnMove != nil {
+		z.hooks.OnMove(z.offset+z.pos, z.offset+end)
+	}
 
-	if n > 0 {
-		// Scan only the moved segment for newlines and runes.
-		movedBytes := z.buf[z.pos:end]
-		newlines := bytes.Count(movedBytes, []byte{'\n'})
-		if newlines > 0 {
-			z.line += newlines
-			z.lastNewline = z.pos + bytes.LastIndexByte(movedBytes, '\n')
-			z.col = utf8.RuneCount(z.buf[z.lastNewline+1:end]) + 1
-		} else {
-			z.col += utf8.RuneCount(movedBytes)
-		}
+	if z.lazy {
+		z.pos = end
+		return
+	} else if n > 0 {
+		z.advance(z.pos, end)
 		z.pos = end
 		return
 	}
 
 	// n < 0, recompute line/col from start up to new position
 	z.pos = end
-	z.line = 1
-	z.col = 1
+	z.position()
+}
+
+// advance updates the line and column numbers by scanning only the moved segment for newlines and runes.
+func (z *Input) advance(start, end int) {
+	movedBytes := z.buf[start:end]
+	newlines := bytes.Count(movedBytes, []byte{'\n'})
+	if newlines > 0 {
+		z.line += newlines
+		z.lastNewline = start + bytes.LastIndexByte(movedBytes, '\n')
+		z.col = z.column(1, z.buf[z.lastNewline+1:end])
+	} else {
+		z.col = z.column(z.col, movedBytes)
+	}
+}
+
+// position recomputes the line and column numbers from the start of the buffer up to the position.
+func (z *Input) position() {
+	z.line = z.anchorLine
 	z.lastNewline = -1
 	for i, c := range z.buf[:z.pos] {
 		if c == '\n' {
@@ -167,7 +435,12 @@ func (z *Input) Move(n int) {
 			z.lastNewline = i
 		}
 	}
-	z.col = utf8.RuneCount(z.buf[z.lastNewline+1:z.pos]) + 1
+	if z.lastNewline == -1 {
+		z.col = z.column(z.anchorCol, z.buf[:z.pos])
+	} else {
+		z.col = z.column(1, z.buf[z.lastNewline+1:z.pos])
+	}
+	z.tracked = z.pos
 }
 
 // MoveRune advances the position by the length of the current rune.
@@ -189,21 +462,127 @@ func (z *Input) Rewind(pos int) {
 	} else if newPos > len(z.buf)-1 {
 		newPos = len(z.buf) - 1
 	}
+	if z.hooks != nil && z.hooks.OnRewind != nil {
+		z.hooks.OnRewind(z.offset+z.pos, z.offset+newPos)
+	}
 	// Recompute line/col based on the new position
 	z.pos = newPos
-	z.line = 1
-	z.col = 1
-	z.lastNewline = -1
-	for i, c := range z.buf[:z.pos] {
-		if c == '\n' {
-			z.line++
-			z.lastNewline = i
+	if !z.lazy {
+		z.position()
+	}
+}
+
+// Checkpoint is a saved selection and position of an Input, see Input.Checkpoint.
+type Checkpoint struct {
+	start, pos  int // offsets in the stream
+	line, col   int
+	lastNewline int // offset in the stream
+	tracked     int // offset in the stream
+}
+
+// Checkpoint returns a mark of the current selection and of the line and column numbers, which can be restored with RestoreCheckpoint. Unlike Pos, it remains valid after Skip and Shift, which makes it suited for speculative parsing.
+func (z *Input) Checkpoint() Checkpoint {
+	return Checkpoint{
+		start:       z.offset + z.start,
+		pos:         z.offset + z.pos,
+		line:        z.line,
+		col:         z.col,
+		lastNewline: z.offset + z.lastNewline,
+		tracked:     z.offset + z.tracked,
+	}
+}
+
+// RestoreCheckpoint restores the selection and the line and column numbers to those of a checkpoint. It returns false and leaves the Input unchanged if the selection of the checkpoint has been freed by a streaming Input.
+func (z *Input) RestoreCheckpoint(cp Checkpoint) bool {
+	if cp.start < z.offset || len(z.buf)-1 < cp.pos-z.offset {
+		return false
+	}
+	if z.hooks != nil && z.hooks.OnRewind != nil {
+		z.hooks.OnRewind(z.offset+z.pos, cp.pos)
+	}
+	z.start = cp.start - z.offset
+	z.pos = cp.pos - z.offset
+	z.line, z.col = cp.line, cp.col
+	z.lastNewline = cp.lastNewline - z.offset
+	z.tracked = cp.tracked - z.offset
+	if z.tracked < 0 {
+		z.position()
+	}
+	return true
+}
+
+// Clone returns a cursor that shares the underlying buffer but has its own selection and line and column numbers, starting at those of z, so that a speculative sub-parser or another goroutine can read ahead without disturbing z. The clone never reads from the io.Reader of a streaming Input, so that it only sees the data buffered so far and reaches EOF at its end. It does not copy into the arena of z, and Restore and Close of z must not be called while the clone is in use.
+func (z *Input) Clone() *Input {
+	c := *z
+	c.r = nil
+	c.restore = nil
+	c.close = nil
+	c.arena = nil
+	return &c
+}
+
+// ErrSeekFreed is returned by Seek when seeking to data that has been freed by a streaming Input.
+var ErrSeekFreed = errors.New("seek to freed data")
+
+// Read reads bytes from the position and advances it, updating the line and column numbers, so that the remainder of the Input can be handed to a consumer of io.Reader such as encoding/json without copying. The selection start is not moved, so that a streaming Input keeps the data read since the start of the selection until Skip or Shift is called.
+func (z *Input) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	} else if err := z.PeekErr(0); err != nil {
+		return 0, err
+	}
+	z.PeekErr(len(b) - 1)
+	n := copy(b, z.buf[z.pos:len(z.buf)-1])
+	z.Move(n)
+	return n, nil
+}
+
+// ReadRune reads the UTF-8 encoded rune at the position and advances it, see Read. Invalid encodings return utf8.RuneError with a size of one.
+func (z *Input) ReadRune() (rune, int, error) {
+	if err := z.PeekErr(0); err != nil {
+		return 0, 0, err
+	}
+	z.PeekErr(utf8.UTFMax - 1)
+	r, n := utf8.DecodeRune(z.buf[z.pos : len(z.buf)-1])
+	z.Move(n)
+	return r, n, nil
+}
+
+// Seek sets the position to an offset in the stream, see Offset, updating the line and column numbers. The selection start is moved back if the position would be before it. Seeking past the end moves the position to the end, and seeking into data that has been freed by a streaming Input returns ErrSeekFreed.
+func (z *Input) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(z.Offset())
+	case io.SeekEnd:
+		for z.r != nil {
+			z.PeekErr(len(z.buf) - 1 - z.pos)
 		}
+		offset += int64(z.offset + len(z.buf) - 1)
+	default:
+		return 0, errors.New("parse.Input.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("parse.Input.Seek: negative position")
+	} else if offset < int64(z.offset) {
+		return 0, ErrSeekFreed
+	}
+
+	if z.r != nil && len(z.buf)-1 < int(offset)-z.offset {
+		z.PeekErr(int(offset) - z.offset - z.pos) // may free data and move the buffer
+	}
+	pos := int(offset) - z.offset
+	if len(z.buf)-1 < pos {
+		pos = len(z.buf) - 1
+	}
+	if pos < z.start {
+		z.start = pos
 	}
-	z.col = utf8.RuneCount(z.buf[z.lastNewline+1:z.pos]) + 1
+	z.Move(pos - z.pos)
+	return int64(z.Offset()), nil
 }
 
-// Lexeme returns the bytes of the current selection.
+// Lexeme returns the bytes of the current selection, which is a slice of the underlying buffer also when an arena is set, so that lexers may modify the selection in place before calling Shift.
 func (z *Input) Lexeme() []byte {
 	return z.buf[z.start:z.pos:z.pos]
 }
@@ -213,16 +592,29 @@ func (z *Input) Skip() {
 	z.start = z.pos
 }
 
-// Shift returns the bytes of the current selection and collapses the position to the end of the selection.
+// Shift returns the bytes of the current selection and collapses the position to the end of the selection. When an arena is set, the selection is copied into the arena, see SetArena.
 func (z *Input) Shift() []byte {
 	b := z.buf[z.start:z.pos:z.pos]
+	if z.arena != nil {
+		b = z.arena.Copy(b)
+	}
 	z.start = z.pos
 	return b
 }
 
+// SetArena makes Shift return copies stored in the arena instead of slices of the underlying buffer, so that tokens remain valid after Restore or when the caller reuses the buffer. Each token is copied once when it is shifted, including the changes that a lexer made to its Lexeme, while Lexeme keeps returning the buffer. Passing nil disables copying.
+func (z *Input) SetArena(a *buffer.Arena) {
+	z.arena = a
+}
+
 // Offset returns the character position in the buffez.
 func (z *Input) Offset() int {
-	return z.pos
+	return z.offset + z.pos
+}
+
+// Freed returns the number of bytes that have been freed by a streaming Input, which is the offset of the start of Bytes.
+func (z *Input) Freed() int {
+	return z.offset
 }
 
 // Bytes returns the underlying buffez.
@@ -235,30 +627,42 @@ func (z *Input) Len() int {
 	return len(z.buf) - 1
 }
 
-// Reset resets position to the underlying buffez.
+// Reset resets position to the underlying buffez. For a streaming Input that is the first byte that has not been freed.
 func (z *Input) Reset() {
 	z.start = 0
 	z.pos = 0
-	z.line = 1
-	z.col = 1
+	z.line = z.anchorLine
+	z.col = z.anchorCol
 	z.lastNewline = -1
+	z.tracked = 0
 }
 
 // Position returns the current line and column number.
 func (z *Input) Position() (line, col int) {
+	if z.lazy && z.tracked < z.pos {
+		z.advance(z.tracked, z.pos)
+		z.tracked = z.pos
+	} else if z.lazy && z.pos < z.tracked {
+		z.position()
+	}
 	return z.line, z.col
 }
 
-// PositionAt returns the line and column number for an arbitrary offset.
+// PositionAt returns the line and column number for an arbitrary offset. For a streaming Input, offsets before Freed return the position of the first byte that has not been freed.
 func (z *Input) PositionAt(offset int) (line, col int) {
-	if offset > len(z.buf) {
+	offset -= z.offset
+	if offset < 0 {
+		offset = 0
+	} else if offset > len(z.buf) {
 		offset = len(z.buf)
 	}
 
 	lastNewline := bytes.LastIndexByte(z.buf[:offset], '\n')
 
-	line = bytes.Count(z.buf[:lastNewline+1], []byte{'\n'}) + 1
-
-	col = utf8.RuneCount(z.buf[lastNewline+1:offset]) + 1
+	line = bytes.Count(z.buf[:lastNewline+1], []byte{'\n'}) + z.anchorLine
+	if lastNewline == -1 {
+		return line, z.column(z.anchorCol, z.buf[:offset])
+	}
+	col = z.column(1, z.buf[lastNewline+1:offset])
 	return line, col
 }
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package parse
+
+import (
+	"os"
+)
+
+// NewInputFile returns a new Input for the given file. Memory-mapping is not supported on this platform, so the file is read into memory.
+func NewInputFile(filename string) (*Input, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewInputBytes(b), nil
+}
@@ -1,7 +1,9 @@
 package parse
 
 import (
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/tdewolff/test"
 )
@@ -222,3 +224,110 @@ func TestInputPositionConsistencyWithPositionAt(t *testing.T) {
 	test.T(t, lPos >= 1, true)
 	test.T(t, cPos >= 1, true)
 }
+
+func TestInputLazyPosition(t *testing.T) {
+	s := "α\nβγ\r\nδ\n\nεζ"
+	eager := NewInputString(s)
+	lazy := NewInputBytesOptions([]byte(s), InputOptions{LazyPosition: true})
+
+	moves := []int{2, 1, 2, 2, -4, 6, 0, -9, 12, 100, -4, 2}
+	for i, n := range moves {
+		eager.Move(n)
+		lazy.Move(n)
+		if i%3 == 1 {
+			continue // skip some calls so that lazy tracking catches up over several moves
+		}
+		line, col := eager.Position()
+		lazyLine, lazyCol := lazy.Position()
+		test.T(t, lazyLine, line, "line")
+		test.T(t, lazyCol, col, "col")
+	}
+
+	eager.Rewind(3)
+	lazy.Rewind(3)
+	line, col := eager.Position()
+	lazyLine, lazyCol := lazy.Position()
+	test.T(t, lazyLine, line, "line after rewind")
+	test.T(t, lazyCol, col, "col after rewind")
+
+	lazy.Reset()
+	lazyLine, lazyCol = lazy.Position()
+	test.T(t, lazyLine, 1, "line after reset")
+	test.T(t, lazyCol, 1, "col after reset")
+}
+
+func TestInputColumnMode(t *testing.T) {
+	// é (2 bytes, 1 code unit), 😀 (4 bytes, 2 code units)
+	s := "a\né😀b"
+	var tests = []struct {
+		mode ColumnMode
+		cols []int // columns at offsets 2, 4, 8, and 9
+	}{
+		{RuneColumns, []int{1, 2, 3, 4}},
+		{ByteColumns, []int{1, 3, 7, 8}},
+		{UTF16Columns, []int{1, 2, 4, 5}},
+	}
+	for _, tt := range tests {
+		z := NewInputBytesOptions([]byte(s), InputOptions{ColumnMode: tt.mode})
+		for i, offset := range []int{2, 4, 8, 9} {
+			line, col := z.PositionAt(offset)
+			test.T(t, line, 2, "PositionAt line")
+			test.T(t, col, tt.cols[i], "PositionAt col")
+
+			z.Move(offset - z.Pos())
+			line, col = z.Position()
+			test.T(t, line, 2, "Position line")
+			test.T(t, col, tt.cols[i], "Position col")
+		}
+	}
+
+	z := NewInputString(s)
+	z.Move(8)
+	z.SetColumnMode(UTF16Columns)
+	_, col := z.Position()
+	test.T(t, col, 4, "column after SetColumnMode")
+}
+
+func TestInputTabWidth(t *testing.T) {
+	s := "\ta\tbc\td\n\tx"
+	z := NewInputBytesOptions([]byte(s), InputOptions{TabWidth: 4})
+	var tests = []struct {
+		offset int
+		col    int
+	}{
+		{1, 5},
+		{2, 6},
+		{3, 9},
+		{5, 11},
+		{6, 13},
+		{7, 14},
+	}
+	for _, tt := range tests {
+		_, col := z.PositionAt(tt.offset)
+		test.T(t, col, tt.col, "PositionAt col")
+
+		z.Move(tt.offset - z.Pos())
+		_, col = z.Position()
+		test.T(t, col, tt.col, "Position col")
+	}
+	line, col := z.PositionAt(9)
+	test.T(t, line, 2)
+	test.T(t, col, 5)
+
+	// streaming input with the anchor in the middle of a line
+	z = NewStreamInputSize(iotest.OneByteReader(strings.NewReader(s)), 2)
+	z.SetTabWidth(4)
+	for z.Offset() < 6 {
+		z.Move(1)
+		z.Skip()
+		z.Peek(2)
+	}
+	_, col = z.Position()
+	test.T(t, col, 13)
+
+	z = NewInputString(s)
+	z.Move(3)
+	z.SetTabWidth(8)
+	_, col = z.Position()
+	test.T(t, col, 17, "column after SetTabWidth")
+}
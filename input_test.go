@@ -1,10 +1,19 @@
 package parse
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf8"
 
+	"github.com/politepixels/tdewolff-parse/v2/buffer"
 	"github.com/tdewolff/test"
 )
 
@@ -111,3 +120,375 @@ func TestInputRestore(t *testing.T) {
 	z.Restore()
 	test.Bytes(t, b, []byte{'a', 'b', 'c', 'd'}, "terminating NULL has been restored")
 }
+
+func TestInputCheckpoint(t *testing.T) {
+	z := NewInputString("a {\n\tcolor: red;\n}")
+	z.Move(4)
+	z.Skip()
+	cp := z.Checkpoint()
+
+	z.Move(6)
+	test.String(t, string(z.Shift()), "\tcolor")
+	z.Move(7)
+	line, col := z.Position()
+	test.T(t, line, 3)
+	test.T(t, col, 1)
+
+	test.That(t, z.RestoreCheckpoint(cp), "restore after shift")
+	test.T(t, z.Offset(), 4)
+	test.String(t, string(z.Lexeme()), "")
+	line, col = z.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 1)
+	z.Move(6)
+	test.String(t, string(z.Lexeme()), "\tcolor")
+	line, col = z.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 7)
+
+	// lazy positions
+	z = NewInputBytesOptions([]byte("ab\ncd"), InputOptions{LazyPosition: true})
+	cp = z.Checkpoint()
+	z.Move(4)
+	z.Shift()
+	line, col = z.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 2)
+	test.That(t, z.RestoreCheckpoint(cp), "restore lazy")
+	z.Move(1)
+	line, col = z.Position()
+	test.T(t, line, 1)
+	test.T(t, col, 2)
+
+	// freed data cannot be restored
+	z = NewStreamInputSize(iotest.OneByteReader(strings.NewReader("abcdefgh")), 2)
+	z.Move(1)
+	cp = z.Checkpoint()
+	z.Move(2)
+	z.Skip()
+	z.Peek(4)
+	test.That(t, !z.RestoreCheckpoint(cp), "restore freed data")
+	test.T(t, z.Offset(), 3)
+}
+
+func TestInputClone(t *testing.T) {
+	z := NewInputString("ab\ncd")
+	z.Move(1)
+	c := z.Clone()
+	c.Move(3)
+	test.String(t, string(c.Lexeme()), "ab\nc")
+	line, col := c.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 2)
+
+	test.String(t, string(z.Lexeme()), "a")
+	line, col = z.Position()
+	test.T(t, line, 1)
+	test.T(t, col, 2)
+
+	// concurrent cursors
+	done := make(chan string)
+	c = z.Clone()
+	go func(c *Input) {
+		c.Skip()
+		for c.Peek(0) != 0 {
+			c.Move(1)
+		}
+		done <- string(c.Lexeme())
+	}(c)
+	z.Move(2)
+	test.String(t, string(z.Lexeme()), "ab\n")
+	test.String(t, <-done, "b\ncd")
+
+	// a clone of a streaming Input only sees buffered data
+	z = NewStreamInputSize(strings.NewReader("abcdefgh"), 4)
+	z.Move(2)
+	c = z.Clone()
+	c.Move(2)
+	test.T(t, c.Err(), io.EOF)
+	test.T(t, z.Peek(5), byte('h'))
+	test.String(t, string(c.Lexeme()), "abcd")
+}
+
+func TestInputReader(t *testing.T) {
+	z := NewInputString("obj {\"a\": [1, 2]}\nx")
+	z.Move(4)
+	var v struct{ A []int }
+	test.Error(t, json.NewDecoder(io.LimitReader(z, 13)).Decode(&v))
+	test.T(t, len(v.A), 2)
+	test.T(t, z.Offset(), 17)
+	line, col := z.Position()
+	test.T(t, line, 1)
+	test.T(t, col, 18)
+
+	s := bufio.NewScanner(z)
+	test.That(t, s.Scan())
+	test.String(t, s.Text(), "")
+	test.That(t, s.Scan())
+	test.String(t, s.Text(), "x")
+	test.That(t, !s.Scan())
+	line, col = z.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 2)
+
+	n, err := z.Read(make([]byte, 1))
+	test.T(t, n, 0)
+	test.T(t, err, io.EOF)
+
+	// streaming
+	z = NewStreamInputSize(iotest.OneByteReader(strings.NewReader("abcdefgh")), 2)
+	z.Move(1)
+	b, err := io.ReadAll(z)
+	test.Error(t, err)
+	test.String(t, string(b), "bcdefgh")
+}
+
+func TestInputReadRune(t *testing.T) {
+	z := NewInputString("a\u00e9\xff\n\u20ac")
+	runes := []rune{}
+	for {
+		r, n, err := z.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if r == utf8.RuneError {
+			test.T(t, n, 1)
+		} else {
+			test.T(t, n, utf8.RuneLen(r))
+		}
+		runes = append(runes, r)
+	}
+	test.T(t, string(runes), "a\u00e9\ufffd\n\u20ac")
+	line, col := z.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 2)
+}
+
+func TestInputSeek(t *testing.T) {
+	z := NewInputString("ab\ncd")
+	z.Move(4)
+	z.Skip()
+
+	offset, err := z.Seek(1, io.SeekStart)
+	test.Error(t, err)
+	test.T(t, offset, int64(1))
+	test.String(t, string(z.Lexeme()), "", "start moved back")
+	line, col := z.Position()
+	test.T(t, line, 1)
+	test.T(t, col, 2)
+
+	offset, err = z.Seek(2, io.SeekCurrent)
+	test.Error(t, err)
+	test.T(t, offset, int64(3))
+	test.String(t, string(z.Lexeme()), "b\n")
+	line, col = z.Position()
+	test.T(t, line, 2)
+	test.T(t, col, 1)
+
+	offset, err = z.Seek(-1, io.SeekEnd)
+	test.Error(t, err)
+	test.T(t, offset, int64(4))
+	offset, err = z.Seek(10, io.SeekStart)
+	test.Error(t, err)
+	test.T(t, offset, int64(5))
+	_, err = z.Seek(-1, io.SeekStart)
+	test.That(t, err != nil, "negative position")
+
+	// streaming
+	z = NewStreamInputSize(iotest.OneByteReader(strings.NewReader("abcdefgh")), 2)
+	offset, err = z.Seek(5, io.SeekStart)
+	test.Error(t, err)
+	test.T(t, offset, int64(5))
+	test.T(t, z.Peek(0), byte('f'))
+	z.Skip()
+	z.Peek(4)
+	_, err = z.Seek(1, io.SeekStart)
+	test.T(t, err, ErrSeekFreed)
+	offset, err = z.Seek(0, io.SeekEnd)
+	test.Error(t, err)
+	test.T(t, offset, int64(8))
+	line, col = z.Position()
+	test.T(t, col, 9)
+}
+
+func TestInputSkipBOM(t *testing.T) {
+	z := NewInputBytesOptions([]byte("\xEF\xBB\xBFa{}"), InputOptions{SkipBOM: true})
+	test.T(t, z.BOM(), 3)
+	test.T(t, z.Peek(0), byte('a'))
+	z.Move(1)
+	test.T(t, z.Offset(), 1)
+	test.T(t, z.SourceOffset(z.Offset()), 4)
+	line, col := z.Position()
+	test.T(t, line, 1)
+	test.T(t, col, 2)
+
+	z = NewInputBytesOptions([]byte("\xEF\xBB\xBFa{}"), InputOptions{})
+	test.T(t, z.BOM(), 0)
+	test.T(t, z.Peek(0), byte(0xEF))
+
+	z = NewInputBytesOptions([]byte("a{}"), InputOptions{SkipBOM: true})
+	test.T(t, z.BOM(), 0)
+	test.T(t, z.SourceOffset(1), 1)
+
+	z, err := NewInputCharset(strings.NewReader("\xFF\xFEa\x00"), "")
+	test.Error(t, err)
+	test.T(t, z.BOM(), 2)
+}
+
+func TestInputArena(t *testing.T) {
+	b := []byte{'a', 'b', 'c', 'd'}
+	z := NewInputBytes(b[:3])
+	z.SetArena(buffer.NewArena(0))
+
+	z.Move(2)
+	lexeme := z.Lexeme()
+	lexeme[1] = 'B'
+	z.Move(1)
+	shifted := z.Shift()
+	z.Restore()
+	b[0], b[2] = 'x', 'y'
+	test.Bytes(t, lexeme, []byte("xB"), "lexeme must be the buffer")
+	test.Bytes(t, shifted, []byte("aBc"), "shift must outlive the buffer and keep changes to the lexeme")
+}
+
+func TestInputFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, n := range []int{0, 5, os.Getpagesize() - 1, os.Getpagesize(), os.Getpagesize() + 1} {
+		b := bytes.Repeat([]byte("ab\n"), n/3+1)[:n]
+		filename := filepath.Join(dir, "input")
+		test.Error(t, os.WriteFile(filename, b, 0644))
+
+		z, err := NewInputFile(filename)
+		test.Error(t, err)
+		test.Bytes(t, z.Bytes(), b)
+		test.T(t, z.Peek(n), byte(0), "must have terminating NULL")
+		z.Move(n)
+		test.T(t, z.Err(), io.EOF)
+		test.T(t, z.Offset(), n)
+
+		if 0 < n {
+			z.Bytes()[0] = 'x'
+		}
+		test.Error(t, z.Close())
+		test.Error(t, z.Close())
+
+		written, err := os.ReadFile(filename)
+		test.Error(t, err)
+		test.Bytes(t, written, b, "file must not be changed")
+	}
+
+	_, err := NewInputFile(filepath.Join(dir, "missing"))
+	test.That(t, os.IsNotExist(err))
+}
+
+func TestStreamInput(t *testing.T) {
+	s := strings.Repeat("lorem ipsum dolor\nsit amet ", 100)
+	z := NewStreamInputSize(iotest.OneByteReader(strings.NewReader(s)), 8)
+
+	words := [][]byte{}
+	maxLen := 0
+	for {
+		for c := z.Peek(0); c != ' ' && c != '\n' && c != 0; c = z.Peek(0) {
+			z.Move(1)
+		}
+		if z.Peek(0) == 0 {
+			break
+		}
+		words = append(words, z.Shift())
+		z.Move(1)
+		z.Skip()
+		if maxLen < len(z.buf) {
+			maxLen = len(z.buf)
+		}
+	}
+	test.T(t, z.Err(), io.EOF)
+	test.T(t, z.Offset(), len(s))
+	test.T(t, len(words), 500)
+	test.String(t, string(bytes.Join(words, []byte(" "))), strings.Join(strings.Fields(s), " "), "shifted slices must remain valid")
+	test.That(t, maxLen <= 2*8+1, "buffer must stay bounded")
+	test.That(t, 0 < z.Freed(), "data must be freed")
+}
+
+func TestStreamInputPosition(t *testing.T) {
+	s := "ab\ncd\néf gh\nij"
+	z := NewStreamInputSize(iotest.OneByteReader(strings.NewReader(s)), 2)
+	z.Move(6)
+	z.Skip()
+	z.Move(2)
+	z.Skip() // 'é' is freed at the next read
+	z.Move(3)
+	test.T(t, z.Offset(), 11)
+	line, col := z.Position()
+	test.T(t, line, 3)
+	test.T(t, col, 5)
+	test.That(t, 0 < z.Freed(), "data must be freed")
+
+	z.Rewind(1)
+	line, col = z.Position()
+	test.T(t, line, 3)
+	test.T(t, col, 3)
+	z.Peek(4)
+	line, col = z.PositionAt(13)
+	test.T(t, line, 4)
+	test.T(t, col, 1)
+
+	err := NewErrorLexer(z, "error")
+	test.T(t, err.Line, 3)
+	test.T(t, err.Column, 3)
+}
+
+func TestStreamInputError(t *testing.T) {
+	z := NewStreamInput(test.NewErrorReader(0))
+	test.That(t, z.Peek(0) == 0, "first character must yield error")
+	test.T(t, z.Err(), test.ErrPlain, "error must be ErrPlain")
+
+	z = NewStreamInput(bytes.NewBufferString("abc"))
+	test.Bytes(t, z.Bytes(), []byte("abc"), "buffer must be used directly")
+}
+
+func TestInputHooks(t *testing.T) {
+	moves, rewinds, eofs := []int{}, []int{}, []int{}
+	z := NewInputString("abc")
+	z.SetHooks(&Hooks{
+		OnMove: func(from, to int) {
+			moves = append(moves, from, to)
+		},
+		OnRewind: func(from, to int) {
+			rewinds = append(rewinds, from, to)
+		},
+		OnPeekEOF: func(offset int) {
+			eofs = append(eofs, offset)
+		},
+	})
+
+	test.T(t, z.Peek(2), byte('c'))
+	z.Move(2)
+	cp := z.Checkpoint()
+	z.Move(5)
+	test.T(t, z.Peek(0), byte(0))
+	z.Rewind(1)
+	z.RestoreCheckpoint(cp)
+	z.Move(-1)
+	test.T(t, fmt.Sprint(moves), "[0 2 2 3 2 1]")
+	test.T(t, fmt.Sprint(rewinds), "[3 1 1 2]")
+	test.T(t, fmt.Sprint(eofs), "[3]")
+
+	// streaming
+	eofs = eofs[:0]
+	z = NewStreamInputSize(iotest.OneByteReader(strings.NewReader("abc")), 2)
+	z.SetHooks(&Hooks{
+		OnPeekEOF: func(offset int) {
+			eofs = append(eofs, offset)
+		},
+	})
+	test.T(t, z.Peek(2), byte('c'))
+	test.T(t, z.Peek(4), byte(0))
+	test.T(t, fmt.Sprint(eofs), "[4]")
+
+	// nil callbacks
+	z = NewInputString("abc")
+	z.SetHooks(&Hooks{})
+	z.Move(4)
+	z.Rewind(0)
+	test.T(t, z.Peek(3), byte(0))
+}
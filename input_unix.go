@@ -0,0 +1,45 @@
+//go:build unix
+
+package parse
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// NewInputFile returns a new Input for the given file, which is memory-mapped so that its contents are not copied into memory. The mapping is private, so that changes to the buffer are not written to the file, and the NULL at the end is taken from the zero-filled remainder of the last page. Files that cannot be mapped, that are empty, or whose size is a multiple of the page size are read into memory instead. Call Close to release the mapping when done.
+func NewInputFile(filename string) (*Input, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if size := info.Size(); info.Mode().IsRegular() && 0 < size && size%int64(os.Getpagesize()) != 0 && size+1 == int64(int(size+1)) {
+		if data, err := syscall.Mmap(int(f.Fd()), 0, int(size)+1, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE); err == nil {
+			z := &Input{
+				buf:         data,
+				line:        1,
+				col:         1,
+				lastNewline: -1,
+				anchorLine:  1,
+				anchorCol:   1,
+			}
+			z.close = func() error {
+				return syscall.Munmap(data)
+			}
+			return z, nil
+		}
+	}
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewInputBytes(b), nil
+}
@@ -0,0 +1,207 @@
+// Command entitygen generates the HTMLEntities and XMLEntities tables of the parse package from the named character references of the HTML specification and the predefined entities of the XML specification.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+const htmlEntitiesURL = "https://html.spec.whatwg.org/entities.json"
+
+// xmlEntities are the predefined entities of XML, see https://www.w3.org/TR/xml/#sec-predefined-ent.
+var xmlEntities = map[string]string{
+	"amp;":  "&",
+	"apos;": "'",
+	"gt;":   ">",
+	"lt;":   "<",
+	"quot;": "\"",
+}
+
+func main() {
+	input := flag.String("html", htmlEntitiesURL, "URL or file of the HTML named character references in JSON")
+	output := flag.String("o", "entity_table.go", "output file")
+	flag.Parse()
+
+	if err := run(*input, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "entitygen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, output string) error {
+	htmlEntities, err := readHTMLEntities(input)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by entitygen from %s; DO NOT EDIT.\n\npackage parse\n\n", htmlEntitiesURL)
+	if err := writeTable(buf, "HTMLEntities", "HTMLEntities are the named character references of HTML, see https://html.spec.whatwg.org/multipage/named-characters.html.", htmlEntities); err != nil {
+		return err
+	}
+	if err := writeTable(buf, "XMLEntities", "XMLEntities are the predefined entities of XML, see https://www.w3.org/TR/xml/#sec-predefined-ent.", xmlEntities); err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
+
+// readHTMLEntities reads the JSON of the HTML named character references from a URL or a file, and returns the replacement text of each name without the leading ampersand.
+func readHTMLEntities(input string) (map[string]string, error) {
+	var r io.Reader
+	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		resp, err := http.Get(input)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", input, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var entities map[string]struct {
+		Codepoints []rune `json:"codepoints"`
+	}
+	if err := json.NewDecoder(r).Decode(&entities); err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(entities))
+	for name, entity := range entities {
+		if !strings.HasPrefix(name, "&") || len(name) == 1 || len(entity.Codepoints) == 0 {
+			return nil, fmt.Errorf("invalid entity %q", name)
+		}
+		m[name[1:]] = string(entity.Codepoints)
+	}
+	return m, nil
+}
+
+type node struct {
+	children map[byte]*node
+	value    int // index plus one of the replacement text
+	index    int
+}
+
+// writeTable writes the EntityTable variable for the entities, where the trie nodes are numbered in breadth-first order so that the edges of each node are contiguous.
+func writeTable(w io.Writer, name, doc string, entities map[string]string) error {
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := []string{}
+	valueIndex := map[string]int{}
+	root := &node{children: map[byte]*node{}}
+	for _, name := range names {
+		value := entities[name]
+		if _, ok := valueIndex[value]; !ok {
+			values = append(values, value)
+			valueIndex[value] = len(values)
+		}
+		n := root
+		for i := 0; i < len(name); i++ {
+			child, ok := n.children[name[i]]
+			if !ok {
+				child = &node{children: map[byte]*node{}}
+				n.children[name[i]] = child
+			}
+			n = child
+		}
+		n.value = valueIndex[value]
+	}
+
+	queue := []*node{root}
+	for i := 0; i < len(queue); i++ {
+		queue[i].index = i
+		for _, c := range sortedKeys(queue[i].children) {
+			queue = append(queue, queue[i].children[c])
+		}
+	}
+	if 1<<16 <= len(queue) || 1<<16 <= len(values) {
+		return fmt.Errorf("%s: too many nodes or values", name)
+	}
+
+	fmt.Fprintf(w, "// %s\nvar %s = &EntityTable{\n\tnodes: []entityNode{", doc, name)
+	edge := 0
+	for i, n := range queue {
+		if i%8 == 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "{%d, %d, %d}, ", edge, len(n.children), n.value)
+		edge += len(n.children)
+	}
+	fmt.Fprintf(w, "\n},\n\tedges: []entityEdge{")
+	edge = 0
+	for _, n := range queue {
+		for _, c := range sortedKeys(n.children) {
+			if edge%8 == 0 {
+				fmt.Fprintf(w, "\n")
+			}
+			fmt.Fprintf(w, "{'%c', %d}, ", c, n.children[c].index)
+			edge++
+		}
+	}
+	fmt.Fprintf(w, "\n},\n\tvalues: []string{")
+	for i, value := range values {
+		if i%8 == 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "%+q, ", value)
+	}
+	fmt.Fprintf(w, "\n},\n\tnames: map[string]string{\n")
+	preferred := map[string]string{}
+	for _, name := range names {
+		value := entities[name]
+		if name[len(name)-1] == ';' && (preferred[value] == "" || preferredName(name, preferred[value])) {
+			preferred[value] = name
+		}
+	}
+	for _, value := range values {
+		if name, ok := preferred[value]; ok {
+			fmt.Fprintf(w, "%+q: %q,\n", value, name)
+		}
+	}
+	fmt.Fprintf(w, "},\n}\n\n")
+	return nil
+}
+
+// preferredName returns true if a is preferred over b as the name of a replacement text, that is if it is shorter, or lowercase if b is not, or sorts first.
+func preferredName(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	} else if lowerA, lowerB := a == strings.ToLower(a), b == strings.ToLower(b); lowerA != lowerB {
+		return lowerA
+	}
+	return a < b
+}
+
+func sortedKeys(m map[byte]*node) []byte {
+	keys := make([]byte, 0, len(m))
+	for c := range m {
+		keys = append(keys, c)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,260 @@
+// Package abstract implements the abstract operations of ECMAScript on numbers and strings, see https://tc39.es/ecma262/#sec-abstract-operations, and the semantics of the operators on numbers. Minifiers and other tools that fold constant expressions must produce exactly what JS engines do, where the conversions between numbers and strings and the integer conversions of the bitwise operators differ from those of Go.
+package abstract
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/politepixels/tdewolff-parse/v2/js"
+)
+
+// IsStrWhiteSpace returns true for the whitespace and line terminators that are trimmed by ToNumber, which includes U+FEFF and the Unicode space separators, see https://tc39.es/ecma262/#prod-StrWhiteSpaceChar.
+func IsStrWhiteSpace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', 0x00A0, 0x1680, 0x2028, 0x2029, 0x202F, 0x205F, 0x3000, 0xFEFF:
+		return true
+	}
+	return 0x2000 <= r && r <= 0x200A
+}
+
+// ToNumber returns the number value of a string, as Number(s) and unary + do, see https://tc39.es/ecma262/#sec-stringtonumber. Surrounding whitespace is ignored and an empty string is 0. Unlike numeric literals, the string may have a sign and be Infinity, but hexadecimal, octal, and binary integers cannot have a sign, decimal integers with leading zeros are not octal, and numeric separators and BigInt suffixes are not allowed. It returns NaN if the string is not a number.
+func ToNumber(s string) float64 {
+	s = strings.TrimFunc(s, IsStrWhiteSpace)
+	if s == "" {
+		return 0
+	}
+
+	if 2 < len(s) && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			for _, c := range []byte(s[2:]) {
+				if base <= digitValue(c) {
+					return math.NaN()
+				}
+			}
+			// round to the nearest double once instead of accumulating rounding errors
+			i, _ := new(big.Int).SetString(s[2:], base)
+			f, _ := new(big.Float).SetInt(i).Float64()
+			return f
+		}
+	}
+
+	i := 0
+	if s[0] == '+' || s[0] == '-' {
+		i++
+	}
+	if s[i:] == "Infinity" {
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	digits := 0
+	for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		}
+		if i == start {
+			return math.NaN()
+		}
+	}
+	if i != len(s) {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+		return math.NaN()
+	}
+	return f // infinite or zero when out of range
+}
+
+func digitValue(c byte) int {
+	if '0' <= c && c <= '9' {
+		return int(c - '0')
+	} else if 'a' <= c && c <= 'z' {
+		return int(c-'a') + 10
+	} else if 'A' <= c && c <= 'Z' {
+		return int(c-'A') + 10
+	}
+	return 36
+}
+
+// ToString returns the string value of a number, as String(f) does, see https://tc39.es/ecma262/#sec-numeric-types-number-tostring. It uses the shortest digits that round to f, as all JS engines do, in decimal notation for magnitudes from 1e-6 up to but excluding 1e21 and in exponent notation such as 1e+21 and 1.5e-7 otherwise. Negative zero is 0.
+func ToString(f float64) string {
+	if math.IsNaN(f) {
+		return "NaN"
+	} else if f == 0 {
+		return "0"
+	} else if math.IsInf(f, 1) {
+		return "Infinity"
+	} else if math.IsInf(f, -1) {
+		return "-Infinity"
+	}
+
+	// b is of the form -d.ddde+dd
+	b := strconv.AppendFloat(make([]byte, 0, 32), f, 'e', -1, 64)
+	neg := b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+	e := 0
+	for i := len(b) - 1; 0 <= i; i-- {
+		if b[i] == 'e' {
+			e, _ = strconv.Atoi(string(b[i+1:]))
+			b = b[:i]
+			break
+		}
+	}
+	digits := b[:1]
+	if 1 < len(b) {
+		digits = append(digits, b[2:]...) // remove the decimal point
+	}
+	k, n := len(digits), e+1
+
+	s := make([]byte, 0, 32)
+	if neg {
+		s = append(s, '-')
+	}
+	if k <= n && n <= 21 {
+		s = append(s, digits...)
+		for i := k; i < n; i++ {
+			s = append(s, '0')
+		}
+	} else if 0 < n && n <= 21 {
+		s = append(s, digits[:n]...)
+		s = append(s, '.')
+		s = append(s, digits[n:]...)
+	} else if -6 < n && n <= 0 {
+		s = append(s, '0', '.')
+		for i := n; i < 0; i++ {
+			s = append(s, '0')
+		}
+		s = append(s, digits...)
+	} else {
+		s = append(s, digits[0])
+		if 1 < k {
+			s = append(s, '.')
+			s = append(s, digits[1:]...)
+		}
+		s = append(s, 'e')
+		if 0 < n {
+			s = append(s, '+')
+		}
+		s = strconv.AppendInt(s, int64(n-1), 10)
+	}
+	return string(s)
+}
+
+// ToUint32 returns the number modulo 2^32 after truncating its fraction, as used by the >>> operator, see https://tc39.es/ecma262/#sec-touint32. NaN and infinities are 0.
+func ToUint32(f float64) uint32 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	m := math.Mod(math.Trunc(f), 1<<32)
+	if m < 0 {
+		m += 1 << 32
+	}
+	return uint32(m)
+}
+
+// ToInt32 returns the number modulo 2^32 after truncating its fraction as a signed integer, as used by the bitwise operators, see https://tc39.es/ecma262/#sec-toint32. NaN and infinities are 0.
+func ToInt32(f float64) int32 {
+	return int32(ToUint32(f))
+}
+
+// Unary returns the result of a unary operator on a number, which is one of js.PosToken, js.NegToken, or js.BitNotToken. It returns false for other operators.
+func Unary(op js.TokenType, a float64) (float64, bool) {
+	switch op {
+	case js.PosToken:
+		return a, true
+	case js.NegToken:
+		return -a, true
+	case js.BitNotToken:
+		return float64(^ToInt32(a)), true
+	}
+	return 0, false
+}
+
+// Binary returns the result of an arithmetic or bitwise operator on two numbers, such as js.AddToken, js.ModToken, js.ExpToken, or js.GtGtGtToken, see https://tc39.es/ecma262/#sec-numeric-types-number. It returns false for other operators, including the assignment operators.
+func Binary(op js.TokenType, a, b float64) (float64, bool) {
+	switch op {
+	case js.AddToken:
+		return a + b, true
+	case js.SubToken:
+		return a - b, true
+	case js.MulToken:
+		return a * b, true
+	case js.DivToken:
+		return a / b, true
+	case js.ModToken:
+		// math.Mod follows the sign of the dividend and returns NaN and a for the same operands as JS
+		return math.Mod(a, b), true
+	case js.ExpToken:
+		return exponentiate(a, b), true
+	case js.LtLtToken:
+		return float64(ToInt32(a) << (ToUint32(b) & 31)), true
+	case js.GtGtToken:
+		return float64(ToInt32(a) >> (ToUint32(b) & 31)), true
+	case js.GtGtGtToken:
+		return float64(ToUint32(a) >> (ToUint32(b) & 31)), true
+	case js.BitAndToken:
+		return float64(ToInt32(a) & ToInt32(b)), true
+	case js.BitOrToken:
+		return float64(ToInt32(a) | ToInt32(b)), true
+	case js.BitXorToken:
+		return float64(ToInt32(a) ^ ToInt32(b)), true
+	}
+	return 0, false
+}
+
+// exponentiate returns a**b, which is NaN when b is NaN or when a is 1 or -1 and b is infinite, see https://tc39.es/ecma262/#sec-numeric-types-number-exponentiate, whereas math.Pow returns 1 in those cases.
+func exponentiate(a, b float64) float64 {
+	if math.IsNaN(b) || math.IsInf(b, 0) && (a == 1 || a == -1) {
+		return math.NaN()
+	}
+	return math.Pow(a, b)
+}
+
+// Compare returns the result of a relational or equality operator on two numbers, which is one of js.LtToken, js.LtEqToken, js.GtToken, js.GtEqToken, js.EqEqToken, js.EqEqEqToken, js.NotEqToken, or js.NotEqEqToken. Comparisons with NaN are false except for inequality, and 0 equals -0. It returns false as its second value for other operators.
+func Compare(op js.TokenType, a, b float64) (bool, bool) {
+	switch op {
+	case js.LtToken:
+		return a < b, true
+	case js.LtEqToken:
+		return a <= b, true
+	case js.GtToken:
+		return a > b, true
+	case js.GtEqToken:
+		return a >= b, true
+	case js.EqEqToken, js.EqEqEqToken:
+		return a == b, true
+	case js.NotEqToken, js.NotEqEqToken:
+		return a != b, true
+	}
+	return false, false
+}
@@ -0,0 +1,211 @@
+package abstract
+
+import (
+	"math"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2/js"
+	"github.com/tdewolff/test"
+)
+
+func TestToNumber(t *testing.T) {
+	var tests = []struct {
+		s        string
+		expected float64
+	}{
+		{"", 0},
+		{" \n\t", 0},
+		{"12", 12},
+		{"  12  ", 12},
+		{"\u00a0\ufeff12 \u3000", 12},
+		{"+12", 12},
+		{"-12.5", -12.5},
+		{".5", 0.5},
+		{"5.", 5},
+		{"-.5e1", -5},
+		{"1E+2", 100},
+		{"00017", 17},
+		{"0x1F", 31},
+		{"0X1f", 31},
+		{"0o17", 15},
+		{"0b101", 5},
+		{"0x20000000000001", 9007199254740992},
+		{"0x20000000000003", 9007199254740996},
+		{"9007199254740993", 9007199254740992},
+		{"Infinity", math.Inf(1)},
+		{"+Infinity", math.Inf(1)},
+		{"-Infinity", math.Inf(-1)},
+		{"1e1000", math.Inf(1)},
+		{"-1e1000", math.Inf(-1)},
+		{"1e-1000", 0},
+		{"5e-324", 5e-324},
+		{"1.7976931348623157e308", math.MaxFloat64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			test.T(t, ToNumber(tt.s), tt.expected)
+		})
+	}
+
+	test.That(t, math.Signbit(ToNumber("-0")), "-0")
+	test.That(t, !math.Signbit(ToNumber("0")), "0")
+
+	nans := []string{".", "e1", "1e", "1e+", "+", "-", "1 2", "1_000", "1n", "0x", "0x1g", "-0x1", "+0b1", "0b2", "0o8", "inf", "infinity", "NaN", "INFINITY", "Infinity1", "0x1p3", "1,5", "\x00", "\u0661"}
+	for _, s := range nans {
+		t.Run(s, func(t *testing.T) {
+			test.That(t, math.IsNaN(ToNumber(s)), "must be NaN")
+		})
+	}
+}
+
+func TestToString(t *testing.T) {
+	var tests = []struct {
+		f        float64
+		expected string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "Infinity"},
+		{math.Inf(-1), "-Infinity"},
+		{1, "1"},
+		{-1, "-1"},
+		{12.5, "12.5"},
+		{0.30000000000000004, "0.30000000000000004"},
+		{100, "100"},
+		{123456789012345680000, "123456789012345680000"},
+		{1e21, "1e+21"},
+		{1.5e21, "1.5e+21"},
+		{-1e21, "-1e+21"},
+		{0.000001, "0.000001"},
+		{0.0000012, "0.0000012"},
+		{1e-7, "1e-7"},
+		{1.5e-7, "1.5e-7"},
+		{-1.5e-7, "-1.5e-7"},
+		{5e-324, "5e-324"},
+		{math.MaxFloat64, "1.7976931348623157e+308"},
+		{9007199254740993, "9007199254740992"},
+		{1.0 / 3, "0.3333333333333333"},
+		{123.456, "123.456"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			s := ToString(tt.f)
+			test.String(t, s, tt.expected)
+			if !math.IsNaN(tt.f) {
+				test.T(t, ToNumber(s), tt.f+0, "roundtrip")
+			}
+		})
+	}
+}
+
+func TestToInt32(t *testing.T) {
+	var tests = []struct {
+		f      float64
+		int32  int32
+		uint32 uint32
+	}{
+		{0, 0, 0},
+		{3.9, 3, 3},
+		{-3.9, -3, 4294967293},
+		{-0.5, 0, 0},
+		{-1, -1, 4294967295},
+		{2147483647, 2147483647, 2147483647},
+		{2147483648, -2147483648, 2147483648},
+		{4294967296, 0, 0},
+		{4294967297, 1, 1},
+		{-4294967297, -1, 4294967295},
+		{1e20, 1661992960, 1661992960},
+		{math.NaN(), 0, 0},
+		{math.Inf(1), 0, 0},
+		{math.Inf(-1), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(ToString(tt.f), func(t *testing.T) {
+			test.T(t, ToInt32(tt.f), tt.int32)
+			test.T(t, ToUint32(tt.f), tt.uint32)
+		})
+	}
+}
+
+func TestOperators(t *testing.T) {
+	var tests = []struct {
+		op       js.TokenType
+		a, b     float64
+		expected string
+	}{
+		{js.AddToken, 0.1, 0.2, "0.30000000000000004"},
+		{js.SubToken, 1, 3, "-2"},
+		{js.MulToken, 1e300, 1e10, "Infinity"},
+		{js.DivToken, 1, 0, "Infinity"},
+		{js.DivToken, -1, 0, "-Infinity"},
+		{js.DivToken, 0, 0, "NaN"},
+		{js.ModToken, 5.5, 2, "1.5"},
+		{js.ModToken, -5, 3, "-2"},
+		{js.ModToken, 5, -3, "2"},
+		{js.ModToken, 5, 0, "NaN"},
+		{js.ModToken, math.Inf(1), 3, "NaN"},
+		{js.ModToken, 5, math.Inf(1), "5"},
+		{js.ExpToken, 2, 10, "1024"},
+		{js.ExpToken, 2, -1, "0.5"},
+		{js.ExpToken, math.NaN(), 0, "1"},
+		{js.ExpToken, 1, math.NaN(), "NaN"},
+		{js.ExpToken, 1, math.Inf(1), "NaN"},
+		{js.ExpToken, -1, math.Inf(-1), "NaN"},
+		{js.ExpToken, -8, 1.0 / 3, "NaN"},
+		{js.LtLtToken, 1, 31, "-2147483648"},
+		{js.LtLtToken, 1, 32, "1"},
+		{js.LtLtToken, 1, -1, "-2147483648"},
+		{js.GtGtToken, -8, 1, "-4"},
+		{js.GtGtGtToken, -8, 1, "2147483644"},
+		{js.GtGtGtToken, -1, 0, "4294967295"},
+		{js.BitAndToken, 4294967295, -1, "-1"},
+		{js.BitOrToken, 2147483648, 0, "-2147483648"},
+		{js.BitXorToken, 5, 3, "6"},
+		{js.BitOrToken, math.NaN(), 1.9, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op.String(), func(t *testing.T) {
+			f, ok := Binary(tt.op, tt.a, tt.b)
+			test.That(t, ok)
+			test.String(t, ToString(f), tt.expected)
+		})
+	}
+	_, ok := Binary(js.AddEqToken, 1, 2)
+	test.That(t, !ok)
+
+	f, ok := Unary(js.NegToken, 0)
+	test.That(t, ok && math.Signbit(f))
+	f, _ = Unary(js.BitNotToken, 4294967295)
+	test.T(t, f, 0.0)
+	f, _ = Unary(js.BitNotToken, math.NaN())
+	test.T(t, f, -1.0)
+	f, _ = Unary(js.PosToken, 2)
+	test.T(t, f, 2.0)
+	_, ok = Unary(js.NotToken, 1)
+	test.That(t, !ok)
+
+	var comparisons = []struct {
+		op       js.TokenType
+		a, b     float64
+		expected bool
+	}{
+		{js.LtToken, 1, 2, true},
+		{js.LtEqToken, 2, 2, true},
+		{js.GtToken, math.NaN(), 1, false},
+		{js.GtEqToken, math.Inf(1), math.Inf(1), true},
+		{js.EqEqToken, 0, math.Copysign(0, -1), true},
+		{js.EqEqEqToken, math.NaN(), math.NaN(), false},
+		{js.NotEqToken, math.NaN(), math.NaN(), true},
+		{js.NotEqEqToken, 1, 1, false},
+	}
+	for _, tt := range comparisons {
+		t.Run(tt.op.String(), func(t *testing.T) {
+			b, ok := Compare(tt.op, tt.a, tt.b)
+			test.That(t, ok)
+			test.T(t, b, tt.expected)
+		})
+	}
+	_, ok = Compare(js.AddToken, 1, 2)
+	test.That(t, !ok)
+}
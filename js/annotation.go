@@ -0,0 +1,117 @@
+package js
+
+import (
+	"bytes"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Names of the built-in annotations.
+const (
+	PureAnnotation          = "pure"            // @__PURE__ or #__PURE__ before a call or new expression
+	NoSideEffectsAnnotation = "no-side-effects" // @__NO_SIDE_EFFECTS__ or #__NO_SIDE_EFFECTS__ before a function declaration or expression
+	KeepAnnotation          = "keep"            // parse:keep before a declaration, import, or export that must be preserved
+)
+
+// AnnotationRegistry maps the words of comments, such as @__PURE__, to the names of annotations, such as PureAnnotation.
+type AnnotationRegistry struct {
+	words map[string]string
+}
+
+// NewAnnotationRegistry returns a registry with the built-in annotations PureAnnotation, NoSideEffectsAnnotation, and KeepAnnotation.
+func NewAnnotationRegistry() *AnnotationRegistry {
+	registry := &AnnotationRegistry{map[string]string{}}
+	registry.Register(PureAnnotation, "@__PURE__", "#__PURE__")
+	registry.Register(NoSideEffectsAnnotation, "@__NO_SIDE_EFFECTS__", "#__NO_SIDE_EFFECTS__")
+	registry.Register(KeepAnnotation, "parse:keep")
+	return registry
+}
+
+// Register adds an annotation by name that is read from comments containing any of the words, where words are separated by whitespace. A word that was registered before is reassigned to name.
+func (r *AnnotationRegistry) Register(name string, words ...string) {
+	for _, word := range words {
+		r.words[word] = name
+	}
+}
+
+// Annotation is an annotation read from a comment at Span. Node is the outermost annotatable node that starts at the token following the comment, which is a CallExpr, NewExpr, FuncDecl, ArrowFunc, ClassDecl, VarDecl, ImportStmt, or ExportStmt, or nil if there is no such node.
+type Annotation struct {
+	Name string
+	Span
+	Node INode
+}
+
+// Annotations is a list of annotations in source order.
+type Annotations []Annotation
+
+// Get returns the annotations of node.
+func (as Annotations) Get(node INode) []Annotation {
+	var list []Annotation
+	for _, a := range as {
+		if a.Node == node {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// Has returns true if node has an annotation by name.
+func (as Annotations) Has(node INode, name string) bool {
+	for _, a := range as {
+		if a.Node == node && a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAnnotations parses the input and returns the AST with the annotations read from its comments, so that optimizers such as minifiers and tree shakers honor the same annotations. A nil registry reads the built-in annotations only.
+func ParseAnnotations(r *parse.Input, o Options, registry *AnnotationRegistry) (*AST, Annotations, error) {
+	if registry == nil {
+		registry = NewAnnotationRegistry()
+	}
+	p := newParser(r, o)
+	p.annotations = registry
+	ast, err := p.parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	annotations := make(Annotations, len(p.annotated))
+	for i, a := range p.annotated {
+		annotations[i] = a.Annotation
+	}
+	return ast, annotations, nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// pendingAnnotation is an annotation that is attached to nodes starting at offset at, or -1 until the token following its comment is known.
+type pendingAnnotation struct {
+	Annotation
+	at int
+}
+
+// readAnnotations records the annotations of the current comment token.
+func (p *Parser) readAnnotations() {
+	body := p.data
+	if bytes.HasPrefix(body, []byte("/*")) {
+		body = bytes.TrimSuffix(body[2:], []byte("*/"))
+	} else if bytes.HasPrefix(body, []byte("//")) {
+		body = body[2:]
+	}
+	start := p.l.r.Offset() - len(p.data)
+	for _, word := range bytes.Fields(body) {
+		if name, ok := p.annotations.words[string(word)]; ok {
+			p.annotated = append(p.annotated, pendingAnnotation{Annotation{name, Span{start, start + len(p.data)}, nil}, -1})
+		}
+	}
+}
+
+// annotate attaches the annotations before the token at offset start to node. Nodes that enclose node and start at the same offset are annotated later, so that the outermost node is attached.
+func (p *Parser) annotate(start int, node INode) {
+	for i := len(p.annotated) - 1; 0 <= i && start <= p.annotated[i].at; i-- {
+		if p.annotated[i].at == start {
+			p.annotated[i].Node = node
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package js
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestAnnotations(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"f()", ""},
+		{"/* @__PURE__ */ f()", "pure CallExpr"},
+		{"/*#__PURE__*/ f() + 1", "pure CallExpr"},
+		{"x = /* @__PURE__ */ a.b(c)(d)", "pure CallExpr"},
+		{"/* @__PURE__ */ a?.b()", "pure CallExpr"},
+		{"var a = /* @__PURE__ */ new A()", "pure NewExpr"},
+		{"var a = /* @__PURE__ */ new A", "pure NewExpr"},
+		{"/* @__PURE__ */ (() => {})()", "pure CallExpr"},
+		{"/* @__PURE__ */ async(a)", "pure CallExpr"},
+		{"/* @__PURE__ */ import('a')", "pure CallExpr"},
+		{"// @__PURE__\nf()", "pure CallExpr"},
+		{"/* @__NO_SIDE_EFFECTS__ */ function f() {}", "no-side-effects FuncDecl"},
+		{"/* @__NO_SIDE_EFFECTS__ */ async function f() {}", "no-side-effects FuncDecl"},
+		{"const f = /* #__NO_SIDE_EFFECTS__ */ () => {}", "no-side-effects ArrowFunc"},
+		{"const f = /* #__NO_SIDE_EFFECTS__ */ a => a", "no-side-effects ArrowFunc"},
+		{"const f = /* #__NO_SIDE_EFFECTS__ */ async a => a", "no-side-effects ArrowFunc"},
+		{"const f = /* #__NO_SIDE_EFFECTS__ */ async (a) => a", "no-side-effects ArrowFunc"},
+		{"/* parse:keep */ const a = 1", "keep VarDecl"},
+		{"/* parse:keep */ class A {}", "keep ClassDecl"},
+		{"/* parse:keep */ export const a = 1", "keep ExportStmt"},
+		{"/* parse:keep */ export default function() {}", "keep ExportStmt"},
+		{"/* parse:keep */ import 'a'", "keep ImportStmt"},
+		{"/* parse:keep @__PURE__ */ f()", "keep CallExpr pure CallExpr"},
+		{"/* @__PURE__ */ a + b()", "pure nil"},
+		{"f() /* @__PURE__ */", "pure nil"},
+		{"/* PURE */ f()", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			_, annotations, err := ParseAnnotations(parse.NewInputString(tt.js), Options{}, nil)
+			test.Error(t, err)
+			test.String(t, annotationsString(annotations), tt.expected)
+		})
+	}
+}
+
+func TestAnnotationRegistry(t *testing.T) {
+	registry := NewAnnotationRegistry()
+	registry.Register("inline", "@__INLINE__")
+	src := "/* @__INLINE__ */ function f() {} /* @__PURE__ */ f()"
+	ast, annotations, err := ParseAnnotations(parse.NewInputString(src), Options{}, registry)
+	test.Error(t, err)
+	test.String(t, annotationsString(annotations), "inline FuncDecl pure CallExpr")
+	test.T(t, annotations[0].Span, Span{0, 17})
+
+	funcDecl := ast.List[0].(*FuncDecl)
+	test.That(t, annotations.Has(funcDecl, "inline"))
+	test.That(t, !annotations.Has(funcDecl, PureAnnotation))
+	test.T(t, len(annotations.Get(funcDecl)), 1)
+	test.T(t, len(annotations.Get(nil)), 0)
+}
+
+func annotationsString(annotations Annotations) string {
+	list := []string{}
+	for _, a := range annotations {
+		node := "nil"
+		if a.Node != nil {
+			node = strings.TrimPrefix(fmt.Sprintf("%T", a.Node), "*js.")
+		}
+		list = append(list, a.Name+" "+node)
+	}
+	return strings.Join(list, " ")
+}
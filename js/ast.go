@@ -52,6 +52,30 @@ func (ast AST) JSString() string {
 	return sb.String()
 }
 
+// WriteTo writes JavaScript to w like JS and returns the number of bytes written and the first write error, after which writing stops. It implements io.WriterTo, so that the output can be written directly to, for example, an http.ResponseWriter or a gzip.Writer.
+func (ast AST) WriteTo(w io.Writer) (int64, error) {
+	ew := &errWriter{w: w}
+	ast.JS(ew)
+	return ew.n, ew.err
+}
+
+// errWriter counts the bytes written to w and drops writes after the first error.
+type errWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
 // JSON writes JSON to writer.
 func (ast AST) JSON(w io.Writer) error {
 	if 1 < len(ast.List) {
@@ -518,7 +542,7 @@ func (n ExprStmt) JS(w io.Writer) {
 	n.Value.JS(wb)
 	expr := buf.Bytes()
 
-	group := bytes.HasPrefix(expr, []byte("let "))
+	group := bytes.HasPrefix(expr, []byte("let ")) || bytes.HasPrefix(expr, []byte("do ")) // do expression
 	if group {
 		w.Write([]byte("("))
 	}
@@ -896,10 +920,16 @@ func (n ThrowStmt) JS(w io.Writer) {
 
 // TryStmt is a try statement.
 type TryStmt struct {
-	Body    *BlockStmt
-	Binding IBinding   // can be nil
-	Catch   *BlockStmt // can be nil
-	Finally *BlockStmt // can be nil
+	Body        *BlockStmt
+	Binding     IBinding   // can be nil
+	BindingSpan Span       // span of the catch parameter
+	Catch       *BlockStmt // can be nil
+	Finally     *BlockStmt // can be nil
+}
+
+// OptionalCatchBinding returns true if the try statement has a catch clause without a parameter, as in try {} catch {}.
+func (n TryStmt) OptionalCatchBinding() bool {
+	return n.Catch != nil && n.Binding == nil
 }
 
 func (n TryStmt) String() string {
@@ -1210,10 +1240,16 @@ func (n PropertyName) JS(w io.Writer) {
 	w.Write(n.Literal.Data)
 }
 
+// Span is a range of byte offsets in the input. It is zero when unknown.
+type Span struct {
+	Start, End int
+}
+
 // BindingArray is an array binding pattern.
 type BindingArray struct {
-	List []BindingElement
-	Rest IBinding // can be nil
+	List     []BindingElement
+	Rest     IBinding // can be nil
+	RestSpan Span     // span of Rest without the ellipsis
 }
 
 func (n BindingArray) String() string {
@@ -1290,8 +1326,9 @@ func (n BindingObjectItem) JS(w io.Writer) {
 
 // BindingObject is an object binding pattern.
 type BindingObject struct {
-	List []BindingObjectItem
-	Rest *Var // can be nil
+	List     []BindingObjectItem
+	Rest     IBinding // can be nil, a *Var in declarations or also a *DotExpr or *IndexExpr in assignment patterns
+	RestSpan Span     // span of Rest without the ellipsis
 }
 
 func (n BindingObject) String() string {
@@ -1306,7 +1343,7 @@ func (n BindingObject) String() string {
 		if len(n.List) != 0 {
 			s += ","
 		}
-		s += " ...Binding(" + string(n.Rest.Data) + ")"
+		s += " ...Binding(" + n.Rest.String() + ")"
 	}
 	return s + " }"
 }
@@ -1325,7 +1362,7 @@ func (n BindingObject) JS(w io.Writer) {
 			w.Write([]byte(", "))
 		}
 		w.Write([]byte("..."))
-		w.Write(n.Rest.Data)
+		n.Rest.JS(w)
 	}
 	w.Write([]byte("}"))
 }
@@ -1334,6 +1371,9 @@ func (n BindingObject) JS(w io.Writer) {
 type BindingElement struct {
 	Binding IBinding // can be nil (in case of ellision)
 	Default IExpr    // can be nil
+
+	Span        Span // span of the binding and default value, without the property name for object binding patterns
+	DefaultSpan Span // span of Default
 }
 
 func (n BindingElement) String() string {
@@ -1363,6 +1403,8 @@ func (n BindingElement) JS(w io.Writer) {
 func (v *Var) bindingNode()          {}
 func (n BindingArray) bindingNode()  {}
 func (n BindingObject) bindingNode() {}
+func (n DotExpr) bindingNode()       {} // only in assignment patterns
+func (n IndexExpr) bindingNode()     {} // only in assignment patterns
 
 ////////////////////////////////////////////////////////////////
 
@@ -1396,8 +1438,9 @@ func (n VarDecl) JS(w io.Writer) {
 
 // Params is a list of parameters for functions, methods, and arrow function.
 type Params struct {
-	List []BindingElement
-	Rest IBinding // can be nil
+	List     []BindingElement
+	Rest     IBinding // can be nil
+	RestSpan Span     // span of Rest without the ellipsis
 }
 
 func (n Params) String() string {
@@ -1704,6 +1747,7 @@ func (n MethodDecl) exprNode() {} // not a real IExpr, used for ObjectExpression
 type LiteralExpr struct {
 	TokenType
 	Data []byte
+	Span Span // span of Data in the input
 }
 
 func (n LiteralExpr) String() string {
@@ -1743,10 +1787,29 @@ func (n LiteralExpr) JSON(w io.Writer) error {
 	return fmt.Errorf("%v: literal expression is not valid JSON: %v", ErrInvalidJSON, js.String())
 }
 
+// RegExpSpans returns the spans of the pattern between the slashes and of the flags of a regular expression literal. It returns false for other literals.
+func (n LiteralExpr) RegExpSpans() (Span, Span, bool) {
+	end := bytes.LastIndexByte(n.Data, '/')
+	if n.TokenType != RegExpToken || end < 1 {
+		return Span{}, Span{}, false
+	}
+	body := Span{n.Span.Start + 1, n.Span.Start + end}
+	flags := Span{n.Span.Start + end + 1, n.Span.Start + len(n.Data)}
+	return body, flags, true
+}
+
+// EscapeSpans returns the spans of the escape sequences in a string or regular expression literal, or in an identifier, such as \n, \x41, \u{1F600}, a legacy octal escape, or a line continuation, so that tools can point at or remove a single escape sequence.
+func (n LiteralExpr) EscapeSpans() []Span {
+	return escapeSpans(n.TokenType, n.Data, n.Span.Start)
+}
+
 // Element is an array literal element.
 type Element struct {
 	Value  IExpr // can be nil
 	Spread bool
+
+	Span        Span // span of Value without the ellipsis
+	DefaultSpan Span // span of the right-hand side when Value is an assignment, as in [a = 1] = b
 }
 
 func (n Element) String() string {
@@ -1846,6 +1909,9 @@ type Property struct {
 	Spread bool
 	Value  IExpr
 	Init   IExpr // can be nil
+
+	Span        Span // span of Value and Init without the property name and ellipsis
+	DefaultSpan Span // span of Init, or of the right-hand side when Value is an assignment, as in {a: b = 1} = c
 }
 
 func (n Property) String() string {
@@ -1955,8 +2021,10 @@ func (n ObjectExpr) JSON(w io.Writer) error {
 
 // TemplatePart is a template head or middle.
 type TemplatePart struct {
-	Value []byte
-	Expr  IExpr
+	Value    []byte
+	Expr     IExpr
+	Span     Span // span of Value including the backquote or closing brace and the ${
+	ExprSpan Span // span of Expr
 }
 
 func (n TemplatePart) String() string {
@@ -1969,6 +2037,11 @@ func (n TemplatePart) JS(w io.Writer) {
 	n.Expr.JS(w)
 }
 
+// EscapeSpans returns the spans of the escape sequences in Value, see LiteralExpr.EscapeSpans.
+func (n TemplatePart) EscapeSpans() []Span {
+	return escapeSpans(TemplateToken, n.Value, n.Span.Start)
+}
+
 // TemplateExpr is a template literal or member/call expression, super property, or optional chain with template literal.
 type TemplateExpr struct {
 	Tag      IExpr // can be nil
@@ -1976,6 +2049,7 @@ type TemplateExpr struct {
 	Tail     []byte
 	Prec     OpPrec
 	Optional bool
+	TailSpan Span // span of Tail including the backquote or closing brace and the closing backquote
 }
 
 func (n TemplateExpr) String() string {
@@ -2009,6 +2083,11 @@ func (n TemplateExpr) JS(w io.Writer) {
 	w.Write(n.Tail)
 }
 
+// TailEscapeSpans returns the spans of the escape sequences in Tail, see LiteralExpr.EscapeSpans.
+func (n TemplateExpr) TailEscapeSpans() []Span {
+	return escapeSpans(TemplateToken, n.Tail, n.TailSpan.Start)
+}
+
 // JSON writes JSON to writer.
 func (n TemplateExpr) JSON(w io.Writer) error {
 	if wi, ok := w.(parse.Indenter); ok {
@@ -2137,6 +2216,9 @@ func (n ImportMetaExpr) JS(w io.Writer) {
 type Arg struct {
 	Value IExpr
 	Rest  bool
+
+	Span        Span // span of Value without the ellipsis
+	DefaultSpan Span // span of the right-hand side when Value is an assignment
 }
 
 func (n Arg) String() string {
@@ -1,8 +1,10 @@
 package js
 
 import (
+	"fmt"
 	"io"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/politepixels/tdewolff-parse/v2"
@@ -143,6 +145,132 @@ func TestJS(t *testing.T) {
 	}
 }
 
+type typeCollector map[string]bool
+
+func (v typeCollector) Enter(n INode) IVisitor {
+	v[fmt.Sprintf("%T", n)[4:]] = true // strip *js.
+	return v
+}
+
+func (v typeCollector) Exit(n INode) {}
+
+func TestSyntaxCoverage(t *testing.T) {
+	// one or more productions per line, the output of JS must parse to the same output
+	var productions = []string{
+		"{}; ;",
+		"a;",
+		"if (a) b; else c;",
+		"do a; while (b);",
+		"while (a) b;",
+		"for (var a = 0; a < b; a++) c;",
+		"for (let a in b) c; for (a.b in c);",
+		"for (const a of b) c; for await (a of b);",
+		"switch (a) { case b: break; default: continue; }",
+		"function f() { return a; }",
+		"with (a) b;",
+		"a: for (;;) break a;",
+		"throw new Error('x', { cause: a });",
+		"try { a; } catch (e) { b; } finally { c; }",
+		"try { a; } catch { b; }",
+		"try { a; } catch ({ message, cause = null }) { b; }",
+		"debugger;",
+		"import a, { b as c } from 'd'; import * as e from 'f';",
+		"export { a as b }; export * from 'c'; export default a;",
+		"export const a = 1;",
+		"'use strict';",
+		"var [a, , b = 1, ...c] = d, { e, f: g = 2, [h]: i, ...j } = k;",
+		"function* f(a, b = 1, ...[c]) { yield a; yield* b; }",
+		"async function f() { await a; }",
+		"class A extends B { #a = 1; static b; static { c; } get d() {} set d(v) {} *e() {} async f() {} constructor() { super(); new.target; } }",
+		"a = [1, , ...b]; a = { b, c: 1, [d]: 2, ...e, f() {}, get g() {}, set g(v) {} };",
+		"a = `b${c}d`; a = e`f${g}`;",
+		"a = (b, c); a = b[c]; a = b.c; a = b?.c; a = b?.[c]; a = b?.(c);",
+		"a = import.meta; import('a');",
+		"a = new B(...c); a = b(c, ...d);",
+		"a = -b; a = !b; a = typeof b; a = void b; a = delete b.c; a = ++b; a = b--;",
+		"a = b ** c + d * e - f / g % h << i >> j >>> k & l | m ^ n && o || p; a = b ?? c;",
+		"a = b < c <= d > e >= f == g != h === i !== j instanceof k in l;",
+		"a = b ? c : d; a += b; a ||= b; a ??= b; a **= b;",
+		"a = async (b) => c; a = (b, { c }, [d] = e) => { f; };",
+		"a = /b/g; a = 1n; a = 0x1; a = null; a = true; a = this;",
+		"[a, b.c, ...d[0]] = e; ({ a, b: c.d = 1 } = e);",
+	}
+
+	types := typeCollector{}
+	for _, js := range productions {
+		t.Run(js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(js), Options{})
+			test.Error(t, err)
+			Walk(types, ast)
+
+			src := ast.JSString()
+			ast2, err := Parse(parse.NewInputString(src), Options{})
+			test.Error(t, err, src)
+			test.String(t, ast2.JSString(), src)
+		})
+	}
+
+	for _, typ := range []string{
+		"AST", "Var", "BlockStmt", "EmptyStmt", "ExprStmt", "IfStmt", "DoWhileStmt", "WhileStmt", "ForStmt", "ForInStmt", "ForOfStmt", "CaseClause", "SwitchStmt", "BranchStmt", "ReturnStmt", "WithStmt", "LabelledStmt", "ThrowStmt", "TryStmt", "DebuggerStmt", "Alias", "ImportStmt", "ExportStmt", "DirectivePrologueStmt",
+		"PropertyName", "BindingArray", "BindingObjectItem", "BindingObject", "BindingElement", "VarDecl", "Params", "FuncDecl", "MethodDecl", "Field", "ClassDecl",
+		"LiteralExpr", "Element", "ArrayExpr", "Property", "ObjectExpr", "TemplatePart", "TemplateExpr", "GroupExpr", "IndexExpr", "DotExpr", "NewTargetExpr", "ImportMetaExpr", "Arg", "Args", "NewExpr", "CallExpr", "UnaryExpr", "BinaryExpr", "CondExpr", "YieldExpr", "ArrowFunc", "CommaExpr",
+	} {
+		test.That(t, types[typ], "production for "+typ+" not covered")
+	}
+}
+
+func TestCatchBinding(t *testing.T) {
+	var tests = []struct {
+		js       string
+		optional bool
+		binding  string
+	}{
+		{"try {} finally {}", false, ""},
+		{"try {} catch {}", true, ""},
+		{"try {} catch ( e ) {}", false, "e"},
+		{"try {} catch ({ message, cause = null }) {}", false, "{ message, cause = null }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			try := ast.List[0].(*TryStmt)
+			test.T(t, try.OptionalCatchBinding(), tt.optional)
+			test.String(t, tt.js[try.BindingSpan.Start:try.BindingSpan.End], tt.binding)
+		})
+	}
+}
+
+func TestErrorCause(t *testing.T) {
+	js := `throw new Error("x", { cause: err, ...rest })`
+	ast, err := Parse(parse.NewInputString(js), Options{})
+	test.Error(t, err)
+
+	args := ast.List[0].(*ThrowStmt).Value.(*NewExpr).Args
+	test.T(t, len(args.List), 2)
+	test.String(t, js[args.List[0].Span.Start:args.List[0].Span.End], `"x"`)
+	test.String(t, js[args.List[1].Span.Start:args.List[1].Span.End], `{ cause: err, ...rest }`)
+
+	object := args.List[1].Value.(*ObjectExpr)
+	test.String(t, js[object.List[0].Span.Start:object.List[0].Span.End], `err`)
+	test.String(t, js[object.List[1].Span.Start:object.List[1].Span.End], `rest`)
+	test.String(t, ast.JSString(), `throw new Error("x", {cause: err, ...rest});`)
+}
+
+func TestASTWriteTo(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("var a = 1; f(a)"), Options{})
+	test.Error(t, err)
+
+	w := &strings.Builder{}
+	n, err := ast.WriteTo(w)
+	test.Error(t, err)
+	test.String(t, w.String(), ast.JSString())
+	test.T(t, n, int64(len(w.String())))
+
+	_, err = ast.WriteTo(test.NewErrorWriter(1))
+	test.T(t, err, test.ErrPlain)
+}
+
 func TestJSON(t *testing.T) {
 	input := `[{"key": [2.5, '\r'], '"': -2E+9}, null, false, true, 5.0e-6, "string", 'stri"ng']`
 	ast, err := Parse(parse.NewInputString(input), Options{})
@@ -352,6 +352,14 @@ func BenchmarkMap(b *testing.B) {
 	}
 }
 
+func BenchmarkKeyword(b *testing.B) {
+	for k := 0; k < b.N; k++ {
+		for _, ident := range identifiers {
+			_ = Keyword(ident)
+		}
+	}
+}
+
 func BenchmarkCompareCase1(b *testing.B) {
 	v := helperCaseBytes()
 	b.ResetTimer()
@@ -0,0 +1,469 @@
+package js
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// EdgeKind is the kind of a control-flow edge.
+type EdgeKind uint8
+
+// EdgeKind values.
+const (
+	NormalEdge    EdgeKind = iota // sequential flow, and break, continue, and return
+	TrueEdge                      // condition is truthy, or a loop has a next iteration
+	FalseEdge                     // condition is falsy, or a loop is done
+	CaseEdge                      // switch discriminant matches a case or the default clause
+	ExceptionEdge                 // exception is thrown
+)
+
+// String returns the string representation of an EdgeKind.
+func (kind EdgeKind) String() string {
+	switch kind {
+	case NormalEdge:
+		return "Normal"
+	case TrueEdge:
+		return "True"
+	case FalseEdge:
+		return "False"
+	case CaseEdge:
+		return "Case"
+	case ExceptionEdge:
+		return "Exception"
+	}
+	return "Invalid(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// Edge is a control-flow edge to a basic block.
+type Edge struct {
+	Kind EdgeKind
+	To   *BasicBlock
+}
+
+// BasicBlock is a sequence of statements that are executed in order. Control statements such as if, loop, and switch statements are the last statement of the block that evaluates their condition, while their bodies are in successor blocks. Blocks without predecessors other than the entry block are unreachable.
+type BasicBlock struct {
+	Index int // index in CFG.Blocks
+	Stmts []IStmt
+	Succs []Edge
+	Preds []*BasicBlock
+}
+
+// CFG is the control-flow graph of the body of a function, method, arrow function, class static block, or program.
+type CFG struct {
+	Func   INode         // *AST, *FuncDecl, *MethodDecl, *ArrowFunc, or *BlockStmt for class static blocks
+	Blocks []*BasicBlock // in source order, followed by Exit and Throw
+	Entry  *BasicBlock
+	Exit   *BasicBlock // reached by return statements and at the end of the body
+	Throw  *BasicBlock // reached by uncaught exceptions
+}
+
+// BuildCFG returns the control-flow graph of a function body. Exceptional edges are added from every block within a try or catch clause to the catch or finally clause that handles its exceptions, and from throw statements, but not from statements outside of try statements that may throw. A finally clause is shared by all paths that run through it, so that its last block has edges to all the targets of those paths. Conditions are not evaluated, except that loops with a literal true condition are never done.
+func BuildCFG(fn INode, body *BlockStmt) *CFG {
+	b := &cfgBuilder{
+		g: &CFG{
+			Func:  fn,
+			Exit:  &BasicBlock{},
+			Throw: &BasicBlock{},
+		},
+	}
+	b.g.Entry = &BasicBlock{}
+	b.start(b.g.Entry)
+	b.stmts(body.List)
+	b.edge(b.cur, b.g.Exit, NormalEdge)
+	b.start(b.g.Exit)
+	b.start(b.g.Throw)
+	return b.g
+}
+
+// FuncCFGs returns the control-flow graphs of the program and of all functions, methods, arrow functions, and class static blocks in source order.
+func FuncCFGs(ast *AST) []*CFG {
+	v := &cfgVisitor{}
+	v.cfgs = append(v.cfgs, BuildCFG(ast, &ast.BlockStmt))
+	Walk(v, ast)
+	return v.cfgs
+}
+
+type cfgVisitor struct {
+	cfgs []*CFG
+}
+
+func (v *cfgVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *FuncDecl:
+		v.cfgs = append(v.cfgs, BuildCFG(n, &n.Body))
+	case *MethodDecl:
+		v.cfgs = append(v.cfgs, BuildCFG(n, &n.Body))
+	case *ArrowFunc:
+		v.cfgs = append(v.cfgs, BuildCFG(n, &n.Body))
+	case *ClassDecl:
+		for _, item := range n.List {
+			if item.StaticBlock != nil {
+				v.cfgs = append(v.cfgs, BuildCFG(item.StaticBlock, item.StaticBlock))
+			}
+		}
+	}
+	return v
+}
+
+func (v *cfgVisitor) Exit(n INode) {}
+
+// Reachable returns for each block whether it is reachable from the entry block.
+func (g *CFG) Reachable() []bool {
+	reachable := make([]bool, len(g.Blocks))
+	stack := []*BasicBlock{g.Entry}
+	reachable[g.Entry.Index] = true
+	for 0 < len(stack) {
+		block := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, edge := range block.Succs {
+			if !reachable[edge.To.Index] {
+				reachable[edge.To.Index] = true
+				stack = append(stack, edge.To)
+			}
+		}
+	}
+	return reachable
+}
+
+// Unreachable returns the statements of unreachable blocks in source order, which is dead code.
+func (g *CFG) Unreachable() []IStmt {
+	stmts := []IStmt{}
+	for i, reachable := range g.Reachable() {
+		if !reachable {
+			stmts = append(stmts, g.Blocks[i].Stmts...)
+		}
+	}
+	return stmts
+}
+
+// String returns the successors of each block, such as "0: 1 2f; 1: 3" where the kinds of non-normal edges are indicated by the first letter.
+func (g *CFG) String() string {
+	sb := bytes.Buffer{}
+	for i, block := range g.Blocks {
+		if i != 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(strconv.Itoa(block.Index))
+		sb.WriteString(":")
+		for _, edge := range block.Succs {
+			sb.WriteString(" ")
+			sb.WriteString(strconv.Itoa(edge.To.Index))
+			if edge.Kind != NormalEdge {
+				sb.WriteByte(edge.Kind.String()[0] + 'a' - 'A')
+			}
+		}
+	}
+	return sb.String()
+}
+
+////////////////////////////////////////////////////////////////
+
+// cfgTarget is the target of break and continue statements.
+type cfgTarget struct {
+	label    []byte      // can be nil
+	brk      *BasicBlock // block after the statement
+	cont     *BasicBlock // nil for statements other than loops
+	isSwitch bool
+	depth    int // number of enclosing try statements
+}
+
+// cfgJump is a break, continue, return, or throw that runs through a finally clause.
+type cfgJump struct {
+	kind   EdgeKind
+	target *BasicBlock // nil for exceptions
+	depth  int
+}
+
+// cfgTry is a try statement of which the try or catch clause is being built.
+type cfgTry struct {
+	catch   *BasicBlock // nil when building the catch clause or without catch clause
+	finally *BasicBlock // can be nil
+	blocks  []*BasicBlock
+	jumps   []cfgJump
+}
+
+type cfgBuilder struct {
+	g       *CFG
+	cur     *BasicBlock
+	targets []cfgTarget
+	tries   []*cfgTry
+	label   []byte // label of the next statement
+}
+
+// start adds a block to the graph and continues building in it.
+func (b *cfgBuilder) start(block *BasicBlock) {
+	block.Index = len(b.g.Blocks)
+	b.g.Blocks = append(b.g.Blocks, block)
+	if 0 < len(b.tries) {
+		try := b.tries[len(b.tries)-1]
+		try.blocks = append(try.blocks, block)
+	}
+	b.cur = block
+}
+
+func (b *cfgBuilder) edge(from, to *BasicBlock, kind EdgeKind) {
+	for _, edge := range from.Succs {
+		if edge.To == to && edge.Kind == kind {
+			return
+		}
+	}
+	from.Succs = append(from.Succs, Edge{kind, to})
+	to.Preds = append(to.Preds, from)
+}
+
+// jump adds an edge to the target, or to the innermost catch clause for exceptions when target is nil, running through the finally clauses of the try statements that are left.
+func (b *cfgBuilder) jump(from *BasicBlock, kind EdgeKind, target *BasicBlock, depth int) {
+	for i := len(b.tries) - 1; depth <= i; i-- {
+		try := b.tries[i]
+		if target == nil && try.catch != nil {
+			b.edge(from, try.catch, ExceptionEdge)
+			return
+		} else if try.finally != nil {
+			b.edge(from, try.finally, kind)
+			jump := cfgJump{kind, target, depth}
+			for _, prev := range try.jumps {
+				if prev == jump {
+					return
+				}
+			}
+			try.jumps = append(try.jumps, jump)
+			return
+		}
+	}
+	if target == nil {
+		target = b.g.Throw
+	}
+	b.edge(from, target, kind)
+}
+
+func (b *cfgBuilder) stmts(list []IStmt) {
+	for _, item := range list {
+		b.stmt(item)
+	}
+}
+
+func (b *cfgBuilder) stmt(istmt IStmt) {
+	label := b.label
+	b.label = nil
+	switch stmt := istmt.(type) {
+	case *BlockStmt:
+		b.stmts(stmt.List)
+	case *IfStmt:
+		cond := b.cur
+		cond.Stmts = append(cond.Stmts, stmt)
+		after := &BasicBlock{}
+		b.start(&BasicBlock{})
+		b.edge(cond, b.cur, TrueEdge)
+		b.stmt(stmt.Body)
+		b.edge(b.cur, after, NormalEdge)
+		if stmt.Else != nil {
+			b.start(&BasicBlock{})
+			b.edge(cond, b.cur, FalseEdge)
+			b.stmt(stmt.Else)
+			b.edge(b.cur, after, NormalEdge)
+		} else {
+			b.edge(cond, after, FalseEdge)
+		}
+		b.start(after)
+	case *WhileStmt:
+		b.loop(stmt, stmt.Cond, stmt.Body, label)
+	case *ForStmt:
+		b.loop(stmt, stmt.Cond, stmt.Body, label)
+	case *ForInStmt:
+		b.loop(stmt, nil, stmt.Body, label)
+	case *ForOfStmt:
+		b.loop(stmt, nil, stmt.Body, label)
+	case *DoWhileStmt:
+		body, cond, after := &BasicBlock{}, &BasicBlock{}, &BasicBlock{}
+		b.edge(b.cur, body, NormalEdge)
+		b.start(body)
+		b.targets = append(b.targets, cfgTarget{label, after, cond, false, len(b.tries)})
+		b.stmt(stmt.Body)
+		b.targets = b.targets[:len(b.targets)-1]
+		b.edge(b.cur, cond, NormalEdge)
+		b.start(cond)
+		cond.Stmts = append(cond.Stmts, stmt)
+		b.edge(cond, body, TrueEdge)
+		if !isTrueLiteral(stmt.Cond) {
+			b.edge(cond, after, FalseEdge)
+		}
+		b.start(after)
+	case *SwitchStmt:
+		discriminant := b.cur
+		discriminant.Stmts = append(discriminant.Stmts, stmt)
+		after := &BasicBlock{}
+		b.targets = append(b.targets, cfgTarget{label, after, nil, true, len(b.tries)})
+		hasDefault := false
+		for _, clause := range stmt.List {
+			prev := b.cur
+			b.start(&BasicBlock{})
+			if prev != discriminant {
+				// fall through
+				b.edge(prev, b.cur, NormalEdge)
+			}
+			b.edge(discriminant, b.cur, CaseEdge)
+			hasDefault = hasDefault || clause.Cond == nil
+			b.stmts(clause.List)
+		}
+		b.targets = b.targets[:len(b.targets)-1]
+		if b.cur != discriminant {
+			b.edge(b.cur, after, NormalEdge)
+		}
+		if !hasDefault {
+			b.edge(discriminant, after, FalseEdge)
+		}
+		b.start(after)
+	case *BranchStmt:
+		b.cur.Stmts = append(b.cur.Stmts, stmt)
+		for i := len(b.targets) - 1; 0 <= i; i-- {
+			target := b.targets[i]
+			if stmt.Label != nil && !bytes.Equal(stmt.Label, target.label) || stmt.Label == nil && target.cont == nil && (stmt.Type == ContinueToken || !target.isSwitch) {
+				continue
+			}
+			if stmt.Type == ContinueToken {
+				b.jump(b.cur, NormalEdge, target.cont, target.depth)
+			} else {
+				b.jump(b.cur, NormalEdge, target.brk, target.depth)
+			}
+			break
+		}
+		b.start(&BasicBlock{})
+	case *ReturnStmt:
+		b.cur.Stmts = append(b.cur.Stmts, stmt)
+		b.jump(b.cur, NormalEdge, b.g.Exit, 0)
+		b.start(&BasicBlock{})
+	case *ThrowStmt:
+		b.cur.Stmts = append(b.cur.Stmts, stmt)
+		b.jump(b.cur, ExceptionEdge, nil, 0)
+		b.start(&BasicBlock{})
+	case *LabelledStmt:
+		switch stmt.Value.(type) {
+		case *WhileStmt, *ForStmt, *ForInStmt, *ForOfStmt, *DoWhileStmt, *SwitchStmt:
+			b.label = stmt.Label
+			b.stmt(stmt.Value)
+		default:
+			after := &BasicBlock{}
+			b.targets = append(b.targets, cfgTarget{stmt.Label, after, nil, false, len(b.tries)})
+			b.stmt(stmt.Value)
+			b.targets = b.targets[:len(b.targets)-1]
+			if 0 < len(after.Preds) {
+				b.edge(b.cur, after, NormalEdge)
+				b.start(after)
+			}
+		}
+	case *WithStmt:
+		b.cur.Stmts = append(b.cur.Stmts, stmt)
+		b.stmt(stmt.Body)
+	case *TryStmt:
+		b.try(stmt)
+	default:
+		b.cur.Stmts = append(b.cur.Stmts, istmt)
+	}
+}
+
+// loop builds a while, for, for-in, or for-of loop, where cond is nil for for-in and for-of loops.
+func (b *cfgBuilder) loop(stmt IStmt, cond IExpr, body IStmt, label []byte) {
+	head, after := &BasicBlock{}, &BasicBlock{}
+	b.edge(b.cur, head, NormalEdge)
+	b.start(head)
+	head.Stmts = append(head.Stmts, stmt)
+	b.start(&BasicBlock{})
+	b.edge(head, b.cur, TrueEdge)
+	b.targets = append(b.targets, cfgTarget{label, after, head, false, len(b.tries)})
+	b.stmt(body)
+	b.targets = b.targets[:len(b.targets)-1]
+	b.edge(b.cur, head, NormalEdge)
+	if _, isFor := stmt.(*ForStmt); !(isFor && cond == nil) && !isTrueLiteral(cond) {
+		b.edge(head, after, FalseEdge)
+	}
+	b.start(after)
+}
+
+func (b *cfgBuilder) try(stmt *TryStmt) {
+	after := &BasicBlock{}
+	try := &cfgTry{}
+	if stmt.Catch != nil {
+		try.catch = &BasicBlock{}
+	}
+	if stmt.Finally != nil {
+		try.finally = &BasicBlock{}
+	}
+	completes := false // try or catch clause completes normally
+
+	prev := b.cur
+	b.tries = append(b.tries, try)
+	b.start(&BasicBlock{})
+	b.edge(prev, b.cur, NormalEdge)
+	b.stmts(stmt.Body.List)
+	b.clause(try, after, &completes)
+	if stmt.Catch != nil {
+		catch := try.catch
+		try.catch = nil
+		try.blocks = try.blocks[:0]
+		b.start(catch)
+		b.stmts(stmt.Catch.List)
+		b.clause(try, after, &completes)
+	}
+	b.tries = b.tries[:len(b.tries)-1]
+
+	if stmt.Finally != nil {
+		b.start(try.finally)
+		b.stmts(stmt.Finally.List)
+		end := b.cur
+		if completes {
+			b.edge(end, after, NormalEdge)
+		}
+		for _, jump := range try.jumps {
+			b.jump(end, jump.kind, jump.target, jump.depth)
+		}
+	}
+	b.start(after)
+}
+
+// clause finishes the try or catch clause by adding the exceptional edges from its blocks and the edge at its end.
+func (b *cfgBuilder) clause(try *cfgTry, after *BasicBlock, completes *bool) {
+	for _, block := range try.blocks {
+		if b.handled() && mayThrow(block) {
+			b.jump(block, ExceptionEdge, nil, 0)
+		}
+	}
+	if 0 < len(b.cur.Preds) {
+		*completes = true
+		if try.finally != nil {
+			b.edge(b.cur, try.finally, NormalEdge)
+		} else {
+			b.edge(b.cur, after, NormalEdge)
+		}
+	}
+}
+
+// handled returns true if an exception is caught by a catch clause or runs through a finally clause of an enclosing try statement.
+func (b *cfgBuilder) handled() bool {
+	for _, try := range b.tries {
+		if try.catch != nil || try.finally != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mayThrow returns true if the block has statements other than break, continue, empty, and return statements without value.
+func mayThrow(block *BasicBlock) bool {
+	for _, stmt := range block.Stmts {
+		switch stmt.(type) {
+		case *BranchStmt, *EmptyStmt:
+		case *ReturnStmt:
+			if stmt.(*ReturnStmt).Value != nil {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func isTrueLiteral(expr IExpr) bool {
+	lit, ok := expr.(*LiteralExpr)
+	return ok && lit.TokenType == TrueToken
+}
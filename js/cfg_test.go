@@ -0,0 +1,89 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func funcCFG(t *testing.T, js string) *CFG {
+	ast, err := Parse(parse.NewInputString("function f() {"+js+"}"), Options{})
+	test.Error(t, err)
+	f := ast.List[0].(*FuncDecl)
+	return BuildCFG(f, &f.Body)
+}
+
+func TestBuildCFG(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{`a; b`, "0: 1; 1:; 2:"},
+		{`if (a) b; else c; d`, "0: 1t 2f; 1: 3; 2: 3; 3: 4; 4:; 5:"},
+		{`if (a) return; b`, "0: 1t 3f; 1: 4; 2: 3; 3: 4; 4:; 5:"},
+		{`while (a) { if (b) break; c } d`, "0: 1; 1: 2t 6f; 2: 3t 5f; 3: 6; 4: 5; 5: 1; 6: 7; 7:; 8:"},
+		{`while (true) a; b`, "0: 1; 1: 2t; 2: 1; 3: 4; 4:; 5:"},
+		{`for (;;) { continue; a }`, "0: 1; 1: 2t; 2: 1; 3: 1; 4: 5; 5:; 6:"},
+		{`do a; while (b)`, "0: 1; 1: 2; 2: 1t 3f; 3: 4; 4:; 5:"},
+		{`for (x of y) a`, "0: 1; 1: 2t 3f; 2: 1; 3: 4; 4:; 5:"},
+		{`switch (a) { case 1: b; case 2: c; break; default: d }`, "0: 1c 2c 4c; 1: 2; 2: 5; 3: 4; 4: 5; 5: 6; 6:; 7:"},
+		{`switch (a) { case 1: b }`, "0: 1c 2f; 1: 2; 2: 3; 3:; 4:"},
+		{`a: { if (b) break a; c } d`, "0: 1t 3f; 1: 4; 2: 3; 3: 4; 4: 5; 5:; 6:"},
+		{`a: while (b) { while (c) continue a }`, "0: 1; 1: 2t 7f; 2: 3; 3: 4t 6f; 4: 1; 5: 3; 6: 1; 7: 8; 8:; 9:"},
+		{`throw a; b`, "0: 3e; 1: 2; 2:; 3:"},
+		{`try { a } catch { b } c`, "0: 1; 1: 2e 3; 2: 3; 3: 4; 4:; 5:"},
+		{`try { throw a } catch { b }`, "0: 1; 1: 3e; 2:; 3: 4; 4: 5; 5:; 6:"},
+		{`try { a } finally { b } c`, "0: 1; 1: 2e 2; 2: 3 5e; 3: 4; 4:; 5:"},
+		{`try { return } finally { b } c`, "0: 1; 1: 3; 2:; 3: 5; 4: 5; 5:; 6:"},
+		{`try { a } catch { throw b } finally { c }`, "0: 1; 1: 2e 4; 2: 4e; 3:; 4: 5 7e; 5: 6; 6:; 7:"},
+		{`while (a) { try { break } finally { b } }`, "0: 1; 1: 2t 7f; 2: 3; 3: 5; 4:; 5: 7; 6: 1; 7: 8; 8:; 9:"},
+		{`try { try { throw a } finally { b } } catch { c }`, "0: 1; 1: 2; 2: 4e; 3:; 4: 6e; 5:; 6: 7; 7: 8; 8:; 9:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			test.String(t, funcCFG(t, tt.js).String(), tt.expected)
+		})
+	}
+}
+
+func TestCFGUnreachable(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{`a; return; b; c`, "Stmt(b) Stmt(c)"},
+		{`if (a) return; else throw b; c`, "Stmt(c)"},
+		{`while (true) { a } b`, "Stmt(b)"},
+		{`for (;;) { if (a) break } b`, ""},
+		{`try { return } finally { a } b`, "Stmt(b)"},
+		{`try { a } catch { b }`, ""},
+		{`switch (a) { default: return } b`, "Stmt(b)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			s := ""
+			for i, stmt := range funcCFG(t, tt.js).Unreachable() {
+				if i != 0 {
+					s += " "
+				}
+				s += stmt.String()
+			}
+			test.String(t, s, tt.expected)
+		})
+	}
+}
+
+func TestFuncCFGs(t *testing.T) {
+	ast, err := Parse(parse.NewInputString(`function f() { return; a } var g = () => b; class C { static { c } m() { throw d } }`), Options{})
+	test.Error(t, err)
+
+	cfgs := FuncCFGs(ast)
+	test.T(t, len(cfgs), 5)
+	test.T(t, cfgs[0].Func, INode(ast))
+	test.String(t, cfgs[1].String(), "0: 2; 1: 2; 2:; 3:")
+	test.T(t, len(cfgs[1].Unreachable()), 1)
+	test.String(t, cfgs[2].String(), "0: 2; 1: 2; 2:; 3:")
+	test.String(t, cfgs[3].String(), "0: 1; 1:; 2:")
+	test.String(t, cfgs[4].String(), "0: 3e; 1: 2; 2:; 3:")
+}
@@ -0,0 +1,297 @@
+package js
+
+import (
+	"bytes"
+)
+
+// StringChain is a chain of + operators with at least one string operand, such as "a" + b + "c", or an untagged template literal with substitutions, such as `a${b}c`, which can be converted into each other.
+type StringChain struct {
+	Expr        IExpr  // *BinaryExpr of the outermost + operator, or *TemplateExpr
+	Replacement []byte // equivalent template literal for a concatenation, or equivalent concatenation for a template literal
+	Shorter     bool   // Replacement is shorter than Expr as written by its JS method
+
+	// Primitive is true when all substituted operands are known to evaluate to primitive values. Otherwise the conversion may change behavior, as concatenation converts objects with valueOf before toString while template literals call toString only.
+	Primitive bool
+}
+
+// StringChainsVisitor is a visitor that collects string concatenation chains and template literals that can be converted into each other, see StringChains.
+type StringChainsVisitor struct {
+	chains []StringChain
+}
+
+// StringChains returns the chains of string concatenations in n that can be written as template literals, and the template literals that can be written as concatenations, in source order. Operands before the first string operand of a chain are added numerically and are kept together as a single substitution, and operands of a concatenation are parenthesized when needed to keep their precedence. Chains with legacy octal escapes in their strings are skipped, since these are not allowed in template literals.
+func StringChains(n INode) []StringChain {
+	v := &StringChainsVisitor{}
+	Walk(v, n)
+	return v.Chains()
+}
+
+// Chains returns the collected chains.
+func (v *StringChainsVisitor) Chains() []StringChain {
+	return v.chains
+}
+
+// Enter implements IVisitor.
+func (v *StringChainsVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		if n.Op != AddToken {
+			break
+		}
+		operands := concatOperands(n)
+		if len(operands) == 0 {
+			break
+		}
+		if template, ok := concatToTemplate(operands); ok {
+			v.chains = append(v.chains, StringChain{
+				Expr:        n,
+				Replacement: template,
+				Shorter:     len(template) < len(exprJS(n)),
+				Primitive:   allPrimitive(operands),
+			})
+		}
+		for _, operand := range operands {
+			if template, ok := operand.(*TemplateExpr); ok && template.Tag == nil {
+				for _, part := range template.List {
+					Walk(v, part.Expr) // the template is merged into the chain
+				}
+			} else {
+				Walk(v, operand)
+			}
+		}
+		return nil
+	case *TemplateExpr:
+		if n.Tag != nil || len(n.List) == 0 {
+			break
+		}
+		concat := templateToConcat(n)
+		primitive := true
+		for _, part := range n.List {
+			primitive = primitive && isPrimitiveExpr(part.Expr)
+		}
+		v.chains = append(v.chains, StringChain{
+			Expr:        n,
+			Replacement: concat,
+			Shorter:     len(concat) < len(exprJS(n)),
+			Primitive:   primitive,
+		})
+	}
+	return v
+}
+
+// Exit implements IVisitor.
+func (v *StringChainsVisitor) Exit(n INode) {}
+
+// concatOperands returns the operands of a chain of + operators from left to right, or nil if none of the operands is a string. Parentheses around the left operand are removed as + is left-associative. Operands before the first string are combined into their + expression, since they are added numerically.
+func concatOperands(n *BinaryExpr) []IExpr {
+	spine := []*BinaryExpr{n}
+	for {
+		x := spine[len(spine)-1].X
+		for {
+			if group, ok := x.(*GroupExpr); ok {
+				x = group.X
+			} else {
+				break
+			}
+		}
+		if binary, ok := x.(*BinaryExpr); ok && binary.Op == AddToken {
+			spine = append(spine, binary)
+		} else {
+			break
+		}
+	}
+
+	operands := []IExpr{spine[len(spine)-1].X}
+	for i := len(spine) - 1; 0 <= i; i-- {
+		operands = append(operands, spine[i].Y)
+	}
+	for k, operand := range operands {
+		if isStringExpr(operand) {
+			if 1 < k {
+				// spine[len(spine)-k+1] is the + expression of operands[:k]
+				operands = append([]IExpr{spine[len(spine)-k+1]}, operands[k:]...)
+			}
+			return operands
+		}
+	}
+	return nil
+}
+
+// concatToTemplate returns the template literal of the operands of a concatenation.
+func concatToTemplate(operands []IExpr) ([]byte, bool) {
+	template := []byte{'`'}
+	for _, operand := range operands {
+		switch operand := operand.(type) {
+		case *LiteralExpr:
+			if operand.TokenType == StringToken {
+				var ok bool
+				if template, ok = appendStringAsTemplate(template, operand.Data[1:len(operand.Data)-1]); !ok {
+					return nil, false
+				}
+				continue
+			}
+		case *TemplateExpr:
+			if operand.Tag == nil {
+				for _, part := range operand.List {
+					template = append(template, part.Value[1:]...)
+					template = append(template, exprJS(part.Expr)...)
+					template = append(template, '}')
+				}
+				template = append(template, operand.Tail[1:len(operand.Tail)-1]...)
+				continue
+			}
+		}
+		template = append(template, "${"...)
+		template = append(template, exprJS(operand)...)
+		template = append(template, '}')
+	}
+	return append(template, '`'), true
+}
+
+// templateToConcat returns the concatenation of an untagged template literal with substitutions. It starts with a string, which may be empty, so that the substitutions are concatenated rather than added.
+func templateToConcat(n *TemplateExpr) []byte {
+	concat := []byte{}
+	for i, part := range n.List {
+		if lit := part.Value[1 : len(part.Value)-2]; i == 0 || 0 < len(lit) {
+			if i != 0 {
+				concat = append(concat, " + "...)
+			}
+			concat = appendTemplateAsString(concat, lit)
+		}
+		concat = append(concat, " + "...)
+		if isAdditiveOperand(part.Expr) {
+			concat = append(concat, exprJS(part.Expr)...)
+		} else {
+			concat = append(concat, '(')
+			concat = append(concat, exprJS(part.Expr)...)
+			concat = append(concat, ')')
+		}
+	}
+	if lit := n.Tail[1 : len(n.Tail)-1]; 0 < len(lit) {
+		concat = append(concat, " + "...)
+		concat = appendTemplateAsString(concat, lit)
+	}
+	return concat
+}
+
+// appendStringAsTemplate appends the contents of a string literal without quotes as the contents of a template literal. It returns false for legacy octal escapes and \8 and \9, which are not allowed in template literals.
+func appendStringAsTemplate(dst, b []byte) ([]byte, bool) {
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; c {
+		case '\\':
+			if i+1 < len(b) {
+				if d := b[i+1]; '1' <= d && d <= '9' || d == '0' && i+2 < len(b) && '0' <= b[i+2] && b[i+2] <= '9' {
+					return nil, false
+				}
+				dst = append(dst, c, b[i+1])
+				i++
+				continue
+			}
+		case '`':
+			dst = append(dst, '\\')
+		case '$':
+			if i+1 < len(b) && b[i+1] == '{' {
+				dst = append(dst, '\\')
+			}
+		}
+		dst = append(dst, b[i])
+	}
+	return dst, true
+}
+
+// appendTemplateAsString appends the contents of a template literal as a double-quoted string literal, where line terminators are escaped and CRLF becomes LF as in the cooked template value.
+func appendTemplateAsString(dst, b []byte) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; c {
+		case '\\':
+			if i+1 < len(b) {
+				dst = append(dst, c, b[i+1])
+				i++
+				continue
+			}
+		case '"':
+			dst = append(dst, '\\')
+		case '\r':
+			if i+1 < len(b) && b[i+1] == '\n' {
+				i++
+			}
+			dst = append(dst, `\n`...)
+			continue
+		case '\n':
+			dst = append(dst, `\n`...)
+			continue
+		}
+		dst = append(dst, b[i])
+	}
+	return append(dst, '"')
+}
+
+// isStringExpr returns true for string literals and untagged template literals.
+func isStringExpr(n IExpr) bool {
+	switch n := n.(type) {
+	case *LiteralExpr:
+		return n.TokenType == StringToken
+	case *TemplateExpr:
+		return n.Tag == nil
+	}
+	return false
+}
+
+// isAdditiveOperand returns true if n can be the right operand of + without parentheses, that is if its precedence is higher than that of +.
+func isAdditiveOperand(n IExpr) bool {
+	switch n := n.(type) {
+	case *Var, *LiteralExpr, *GroupExpr, *ArrayExpr, *ObjectExpr, *TemplateExpr, *IndexExpr, *DotExpr, *CallExpr, *NewExpr, *UnaryExpr:
+		return true
+	case *BinaryExpr:
+		return n.Op == MulToken || n.Op == DivToken || n.Op == ModToken || n.Op == ExpToken
+	}
+	return false
+}
+
+// isPrimitiveExpr returns true if n is known to evaluate to a primitive value, so that the conversion to a string does not depend on valueOf or toString methods.
+func isPrimitiveExpr(n IExpr) bool {
+	switch n := n.(type) {
+	case *LiteralExpr:
+		return n.TokenType != RegExpToken
+	case *TemplateExpr:
+		return n.Tag == nil
+	case *GroupExpr:
+		return isPrimitiveExpr(n.X)
+	case *UnaryExpr:
+		return n.Op != AwaitToken
+	case *CondExpr:
+		return isPrimitiveExpr(n.X) && isPrimitiveExpr(n.Y)
+	case *BinaryExpr:
+		switch n.Op {
+		case AddToken:
+			return true // results in a string or a number
+		case SubToken, MulToken, DivToken, ModToken, ExpToken, LtLtToken, GtGtToken, GtGtGtToken, BitAndToken, BitOrToken, BitXorToken:
+			return true
+		case EqEqToken, NotEqToken, EqEqEqToken, NotEqEqToken, LtToken, GtToken, LtEqToken, GtEqToken, InToken, InstanceofToken:
+			return true
+		case AndToken, OrToken, NullishToken:
+			return isPrimitiveExpr(n.X) && isPrimitiveExpr(n.Y)
+		case CommaToken:
+			return isPrimitiveExpr(n.Y)
+		}
+	}
+	return false
+}
+
+// allPrimitive returns true if all operands that are substituted are primitive.
+func allPrimitive(operands []IExpr) bool {
+	for _, operand := range operands {
+		if !isPrimitiveExpr(operand) {
+			return false
+		}
+	}
+	return true
+}
+
+// exprJS returns the JavaScript of an expression.
+func exprJS(n IExpr) []byte {
+	buf := &bytes.Buffer{}
+	n.JS(buf)
+	return buf.Bytes()
+}
@@ -0,0 +1,70 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestStringChains(t *testing.T) {
+	var tests = []struct {
+		js          string
+		replacement string
+		shorter     bool
+		primitive   bool
+	}{
+		{`"a" + b`, "`a${b}`", false, false},
+		{`"a" + b + "c"`, "`a${b}c`", true, false},
+		{`'a' + 1 + 'b' + 2`, "`a${1}b${2}`", true, true},
+		{`a + b + "c" + d`, "`${a + b}c${d}`", false, false},
+		{`(x + "a") + y`, "`${x}a${y}`", true, false},
+		{`"a" + (b + c)`, "`a${(b + c)}`", false, true},
+		{`"a" + b * 2`, "`a${b * 2}`", false, true},
+		{"\"a`${\" + typeof b", "`a\\`\\${${typeof b}`", false, true},
+		{`"a\"b\n" + c`, "`a\\\"b\\n${c}`", false, false},
+		{"\"a\" + `b${c}d` + e", "`ab${c}d${e}`", true, false},
+		{"`a${b}`", `"a" + b`, false, false},
+		{"`a${b}c`", `"a" + b + "c"`, false, false},
+		{"`${a}${b + c}`", `"" + a + (b + c)`, false, false},
+		{"`${a ? 1 : 2}\"\r\n`", `"" + (a ? 1 : 2) + "\"\n"`, false, true},
+		{"`${x}abc${y}`", `"" + x + "abc" + y`, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			chains := StringChains(ast)
+			test.T(t, len(chains), 1)
+			test.String(t, string(chains[0].Replacement), tt.replacement)
+			test.T(t, chains[0].Shorter, tt.shorter, "shorter")
+			test.T(t, chains[0].Primitive, tt.primitive, "primitive")
+		})
+	}
+
+	var skipTests = []string{
+		`a + b`,
+		`1 + 2`,
+		`"\01" + a`,
+		`"\8" + a`,
+		"f`a${b}`",
+		"`abc`",
+	}
+	for _, tt := range skipTests {
+		t.Run(tt, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt), Options{})
+			test.Error(t, err)
+			test.T(t, len(StringChains(ast)), 0)
+		})
+	}
+}
+
+func TestStringChainsNested(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("x = \"a\" + f(\"b\" + c) + `d${\"e\" + g}`"), Options{})
+	test.Error(t, err)
+	chains := StringChains(ast)
+	test.T(t, len(chains), 3)
+	test.String(t, string(chains[0].Replacement), "`a${f(\"b\" + c)}d${\"e\" + g}`")
+	test.String(t, string(chains[1].Replacement), "`b${c}`")
+	test.String(t, string(chains[2].Replacement), "`e${g}`")
+}
@@ -0,0 +1,365 @@
+package js
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// EscapeOptions determines how code points are written by EscapeString, EscapeIdentifier, and EscapeRegExp.
+type EscapeOptions struct {
+	// ASCIIOnly escapes all non-ASCII code points, for output that is served or stored with a charset that may mangle non-ASCII bytes. Otherwise code points are written as raw UTF-8 whenever that is shorter and allowed.
+	ASCIIOnly bool
+	// CodePointEscapes allows \u{...} escapes from ES2015 for code points outside of the BMP, which are otherwise written as surrogate pairs in strings and regular expressions.
+	CodePointEscapes bool
+}
+
+// EscapeString returns a string literal including its quotes with the shortest representation of each code point that is written as a \x or \u escape or as a raw non-ASCII character. Escapes of printable ASCII characters are replaced by the character, control characters use their single character escape when they have one, and non-ASCII characters are written as raw UTF-8 unless ASCIIOnly is set. U+2028, U+2029, and lone surrogates are always escaped. Other escapes are kept as is.
+func EscapeString(b []byte, o EscapeOptions) []byte {
+	if len(b) < 2 {
+		return b
+	}
+	quote := b[0]
+	body := b[1 : len(b)-1]
+	dst := make([]byte, 0, len(b))
+	dst = append(dst, quote)
+	for i := 0; i < len(body); {
+		c := body[i]
+		if c == '\\' && i+1 < len(body) {
+			if r, n, ok := decodeUnicodeEscape(body[i:], true, true); ok {
+				i += n
+				dst = appendStringRune(dst, r, quote, i < len(body) && '0' <= body[i] && body[i] <= '9', o)
+				continue
+			}
+			r, n := utf8.DecodeRune(body[i+1:])
+			if r == '\u2028' || r == '\u2029' {
+				i += 1 + n // line continuation
+			} else if 0x80 <= r && r != utf8.RuneError {
+				i += 1 + n // escaped non-ASCII characters are the characters themselves
+				dst = appendStringRune(dst, r, quote, false, o)
+			} else {
+				dst = append(dst, body[i:i+1+n]...)
+				i += 1 + n
+			}
+			continue
+		} else if c < 0x80 {
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(body[i:])
+		if r == utf8.RuneError && n == 1 {
+			dst = append(dst, c)
+		} else {
+			dst = appendStringRune(dst, r, quote, false, o)
+		}
+		i += n
+	}
+	return append(dst, quote)
+}
+
+// EscapeIdentifier returns an identifier name with escapes of ASCII characters replaced by the characters, and with non-ASCII characters written as raw UTF-8, or as \u escapes when ASCIIOnly is set. It returns false when a code point outside of the BMP must be escaped without CodePointEscapes, as identifiers cannot contain surrogate pairs, in which case that character is kept raw. Escapes are kept when replacing them would turn the identifier into a keyword.
+func EscapeIdentifier(b []byte, o EscapeOptions) ([]byte, bool) {
+	dst := make([]byte, 0, len(b))
+	ok := true
+	for i := 0; i < len(b); {
+		r, n := rune(b[i]), 1
+		if b[i] == '\\' {
+			var valid bool
+			if r, n, valid = decodeUnicodeEscape(b[i:], false, true); !valid {
+				dst = append(dst, b[i])
+				i++
+				continue
+			}
+		} else if 0x80 <= b[i] {
+			r, n = utf8.DecodeRune(b[i:])
+		}
+		i += n
+
+		if r < 0x80 {
+			dst = append(dst, byte(r))
+		} else if !o.ASCIIOnly || r == utf8.RuneError {
+			dst = utf8.AppendRune(dst, r)
+		} else if r <= 0xFFFF {
+			dst = appendEscape(dst, r, 4)
+		} else if o.CodePointEscapes {
+			dst = appendCodePointEscape(dst, r)
+		} else {
+			dst = utf8.AppendRune(dst, r)
+			ok = false
+		}
+	}
+	if Keyword(dst) != IdentifierToken && !bytes.Equal(dst, b) {
+		return b, ok // escaped keywords are not keywords
+	}
+	return dst, ok
+}
+
+// EscapeRegExp returns a regular expression literal including its slashes and flags with non-ASCII characters written as raw UTF-8, or as escapes when ASCIIOnly is set. Escapes of ASCII characters are kept as they may have a special meaning, and \u{...} escapes are only used and replaced with the u or v flag.
+func EscapeRegExp(b []byte, o EscapeOptions) []byte {
+	end := bytes.LastIndexByte(b, '/')
+	if len(b) < 2 || b[0] != '/' || end < 1 {
+		return b
+	}
+	unicodeMode := bytes.IndexByte(b[end+1:], 'u') != -1 || bytes.IndexByte(b[end+1:], 'v') != -1
+	body := b[1:end]
+	dst := make([]byte, 0, len(b))
+	dst = append(dst, '/')
+	for i := 0; i < len(body); {
+		c := body[i]
+		if c == '\\' && i+1 < len(body) {
+			if r, n, ok := decodeUnicodeEscape(body[i:], true, unicodeMode); ok && 0x80 <= r {
+				dst = appendRegExpRune(dst, r, unicodeMode, o)
+				i += n
+				continue
+			}
+			r, n := utf8.DecodeRune(body[i+1:])
+			if 0x80 <= r && r != utf8.RuneError && !unicodeMode && r != '\u2028' && r != '\u2029' {
+				dst = appendRegExpRune(dst, r, unicodeMode, o) // identity escape
+			} else {
+				dst = append(dst, body[i:i+1+n]...)
+			}
+			i += 1 + n
+			continue
+		} else if c < 0x80 {
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(body[i:])
+		if r == utf8.RuneError && n == 1 {
+			dst = append(dst, c)
+		} else {
+			dst = appendRegExpRune(dst, r, unicodeMode, o)
+		}
+		i += n
+	}
+	return append(dst, b[end:]...)
+}
+
+// escapeSpans returns the spans of the escape sequences in a string, template, identifier, or regular expression that starts at offset start in the input.
+func escapeSpans(tt TokenType, b []byte, start int) []Span {
+	unicodeMode := false
+	if tt == RegExpToken {
+		if end := bytes.LastIndexByte(b, '/'); 0 < end {
+			unicodeMode = bytes.IndexByte(b[end+1:], 'u') != -1 || bytes.IndexByte(b[end+1:], 'v') != -1
+			b = b[:end] // a backslash cannot precede the closing slash
+		}
+	}
+
+	spans := []Span{}
+	for i := 0; i < len(b); i++ {
+		if b[i] != '\\' {
+			continue
+		}
+		n := 0
+		if tt == RegExpToken {
+			n = regExpEscapeLen(b[i:], unicodeMode)
+		} else {
+			n = stringEscapeLen(b[i:], tt == StringToken)
+		}
+		spans = append(spans, Span{start + i, start + i + n})
+		i += n - 1
+	}
+	return spans
+}
+
+// stringEscapeLen returns the length of the escape sequence at the start of b in a string, template, or identifier, where legacy octal escapes are only allowed in strings.
+func stringEscapeLen(b []byte, octal bool) int {
+	if _, n, ok := decodeUnicodeEscape(b, true, true); ok {
+		return n
+	} else if len(b) < 2 {
+		return len(b)
+	} else if b[1] == '\r' && 2 < len(b) && b[2] == '\n' {
+		return 3 // line continuation
+	} else if octal && '0' <= b[1] && b[1] <= '7' {
+		end := 3 // \0 to \377
+		if '3' < b[1] {
+			end = 2
+		}
+		n := 2
+		for n <= end && n < len(b) && '0' <= b[n] && b[n] <= '7' {
+			n++
+		}
+		return n
+	}
+	_, n := utf8.DecodeRune(b[1:])
+	return 1 + n
+}
+
+// regExpEscapeLen returns the length of the escape sequence at the start of b in a regular expression, where \u{...} and \p{...} escapes are only recognized in unicode mode.
+func regExpEscapeLen(b []byte, unicodeMode bool) int {
+	if _, n, ok := decodeUnicodeEscape(b, true, unicodeMode); ok {
+		return n
+	} else if len(b) < 2 {
+		return len(b)
+	} else if b[1] == 'c' && 2 < len(b) && ('a' <= b[2] && b[2] <= 'z' || 'A' <= b[2] && b[2] <= 'Z') {
+		return 3
+	} else if (b[1] == 'p' || b[1] == 'P') && unicodeMode && 2 < len(b) && b[2] == '{' {
+		if end := bytes.IndexByte(b, '}'); end != -1 {
+			return end + 1
+		}
+	} else if b[1] == 'k' && 2 < len(b) && b[2] == '<' {
+		if end := bytes.IndexByte(b, '>'); end != -1 {
+			return end + 1
+		}
+	}
+	_, n := utf8.DecodeRune(b[1:])
+	return 1 + n
+}
+
+// decodeUnicodeEscape decodes a \uHHHH escape, a \x escape when hex is set, and a \u{...} escape when codePoint is set at the start of b. Escaped surrogate pairs are combined into a single code point.
+func decodeUnicodeEscape(b []byte, hex, codePoint bool) (rune, int, bool) {
+	if len(b) < 2 || b[0] != '\\' {
+		return 0, 0, false
+	} else if b[1] == 'x' && hex {
+		if r, ok := parseHex(b[2:], 2); ok {
+			return r, 4, true
+		}
+		return 0, 0, false
+	} else if b[1] != 'u' {
+		return 0, 0, false
+	} else if 2 < len(b) && b[2] == '{' {
+		end := bytes.IndexByte(b, '}')
+		if !codePoint || end < 4 {
+			return 0, 0, false
+		}
+		if r, ok := parseHex(b[3:end], end-3); ok && r <= utf8.MaxRune {
+			return r, end + 1, true
+		}
+		return 0, 0, false
+	}
+	r, ok := parseHex(b[2:], 4)
+	if !ok {
+		return 0, 0, false
+	} else if 0xD800 <= r && r < 0xDC00 && 12 <= len(b) && b[6] == '\\' && b[7] == 'u' {
+		if r2, ok := parseHex(b[8:], 4); ok && 0xDC00 <= r2 && r2 < 0xE000 {
+			return 0x10000 + (r-0xD800)<<10 + (r2 - 0xDC00), 12, true
+		}
+	}
+	return r, 6, true
+}
+
+// parseHex parses n hexadecimal digits at the start of b.
+func parseHex(b []byte, n int) (rune, bool) {
+	if len(b) < n || n == 0 || 8 < n {
+		return 0, false
+	}
+	r := rune(0)
+	for _, c := range b[:n] {
+		if '0' <= c && c <= '9' {
+			r = r<<4 | rune(c-'0')
+		} else if 'a' <= c && c <= 'f' {
+			r = r<<4 | rune(c-'a'+10)
+		} else if 'A' <= c && c <= 'F' {
+			r = r<<4 | rune(c-'A'+10)
+		} else {
+			return 0, false
+		}
+	}
+	return r, true
+}
+
+// appendStringRune appends the shortest representation of a code point in a string literal. The zero character is not written as \0 when it is followed by a digit.
+func appendStringRune(dst []byte, r rune, quote byte, digitFollows bool, o EscapeOptions) []byte {
+	if r < 0x80 {
+		switch c := byte(r); c {
+		case quote, '\\':
+			return append(dst, '\\', c)
+		case '\b':
+			return append(dst, '\\', 'b')
+		case '\t':
+			return append(dst, '\\', 't')
+		case '\n':
+			return append(dst, '\\', 'n')
+		case '\v':
+			return append(dst, '\\', 'v')
+		case '\f':
+			return append(dst, '\\', 'f')
+		case '\r':
+			return append(dst, '\\', 'r')
+		case 0:
+			if !digitFollows {
+				return append(dst, '\\', '0')
+			}
+		default:
+			if '0' <= c && c <= '9' && endsWithOctalEscape(dst) {
+				break // digits would continue the octal escape
+			} else if 0x20 <= c && c < 0x7F {
+				return append(dst, c)
+			}
+		}
+		return appendEscape(dst, r, 2)
+	} else if !o.ASCIIOnly && !isSurrogate(r) && r != '\u2028' && r != '\u2029' {
+		return utf8.AppendRune(dst, r)
+	}
+	return appendAstralEscape(dst, r, o.CodePointEscapes)
+}
+
+// appendRegExpRune appends the shortest representation of a non-ASCII code point in a regular expression.
+func appendRegExpRune(dst []byte, r rune, unicodeMode bool, o EscapeOptions) []byte {
+	if !o.ASCIIOnly && !isSurrogate(r) && r != '\u2028' && r != '\u2029' {
+		return utf8.AppendRune(dst, r)
+	}
+	return appendAstralEscape(dst, r, unicodeMode)
+}
+
+// appendAstralEscape appends a \x escape, a \u escape, a \u{...} escape when codePoint is set, or an escaped surrogate pair.
+func appendAstralEscape(dst []byte, r rune, codePoint bool) []byte {
+	if r <= 0xFF {
+		return appendEscape(dst, r, 2)
+	} else if r <= 0xFFFF {
+		return appendEscape(dst, r, 4)
+	} else if codePoint {
+		return appendCodePointEscape(dst, r)
+	}
+	r -= 0x10000
+	dst = appendEscape(dst, 0xD800+r>>10, 4)
+	return appendEscape(dst, 0xDC00+r&0x3FF, 4)
+}
+
+// appendEscape appends a \x escape with two hexadecimal digits or a \u escape with four.
+func appendEscape(dst []byte, r rune, n int) []byte {
+	if n == 2 {
+		dst = append(dst, '\\', 'x')
+	} else {
+		dst = append(dst, '\\', 'u')
+	}
+	for i := n - 1; 0 <= i; i-- {
+		dst = append(dst, hexDigits[(r>>(4*uint(i)))&0xF])
+	}
+	return dst
+}
+
+func appendCodePointEscape(dst []byte, r rune) []byte {
+	dst = append(dst, '\\', 'u', '{')
+	n := 1
+	for r>>(4*uint(n)) != 0 {
+		n++
+	}
+	for i := n - 1; 0 <= i; i-- {
+		dst = append(dst, hexDigits[(r>>(4*uint(i)))&0xF])
+	}
+	return append(dst, '}')
+}
+
+// endsWithOctalEscape returns true when b ends with a legacy octal escape such as \0 or \12, which would be continued by a digit.
+func endsWithOctalEscape(b []byte) bool {
+	i := len(b) - 1
+	for 0 <= i && len(b)-3 <= i && '0' <= b[i] && b[i] <= '7' {
+		i--
+	}
+	if i == len(b)-1 {
+		return false
+	}
+	backslashes := 0
+	for ; 0 <= i && b[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
+
+func isSurrogate(r rune) bool {
+	return 0xD800 <= r && r < 0xE000
+}
@@ -0,0 +1,97 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestEscapeString(t *testing.T) {
+	utf8 := EscapeOptions{}
+	ascii := EscapeOptions{ASCIIOnly: true}
+	es2015 := EscapeOptions{ASCIIOnly: true, CodePointEscapes: true}
+	var tests = []struct {
+		s        string
+		o        EscapeOptions
+		expected string
+	}{
+		{`"abc"`, utf8, `"abc"`},
+		{`"é\xe9\u{e9}"`, utf8, `"ééé"`},
+		{`"\x41B\u{43}"`, utf8, `"ABC"`},
+		{`"\x22\x27"`, utf8, `"\"'"`},
+		{`'\x22\x27'`, utf8, `'"\''`},
+		{`"\x5c\x0a\x09\x00\x7f\x01"`, utf8, `"\\\n\t\0\x7f\x01"`},
+		{`"\x001\0\x31\12\x33"`, utf8, `"\x001\0\x31\12\x33"`},
+		{`"\\\x31"`, utf8, `"\\1"`},
+		{`"\n\'\"\\"`, utf8, `"\n\'\"\\"`},
+		{`"😀\u{1F600}😀"`, utf8, `"😀😀😀"`},
+		{`"\uD83D!\uDE00"`, utf8, `"\ud83d!\ude00"`},
+		{"\"\u2028\\u2029\"", utf8, `"\u2028\u2029"`},
+		{"\"a\\ b\"", utf8, `"ab"`},
+		{`"\é"`, utf8, `"é"`},
+		{"\"\xff\"", utf8, "\"\xff\""},
+
+		{`"éé"`, ascii, `"\xe9\xe9"`},
+		{`"€"`, ascii, `"\u20ac"`},
+		{`"😀"`, ascii, `"\ud83d\ude00"`},
+		{`"😀"`, es2015, `"\u{1f600}"`},
+		{`"\é"`, ascii, `"\xe9"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			test.String(t, string(EscapeString([]byte(tt.s), tt.o)), tt.expected)
+		})
+	}
+}
+
+func TestEscapeIdentifier(t *testing.T) {
+	var tests = []struct {
+		s        string
+		o        EscapeOptions
+		expected string
+		ok       bool
+	}{
+		{`abc`, EscapeOptions{}, `abc`, true},
+		{`ab\u{63}`, EscapeOptions{}, `abc`, true},
+		{`café`, EscapeOptions{}, `café`, true},
+		{`café`, EscapeOptions{ASCIIOnly: true}, `caf\u00e9`, true},
+		{`\u{10480}`, EscapeOptions{}, "\U00010480", true},
+		{"\U00010480", EscapeOptions{ASCIIOnly: true, CodePointEscapes: true}, `\u{10480}`, true},
+		{"\U00010480", EscapeOptions{ASCIIOnly: true}, "\U00010480", false},
+		{`\u0069f`, EscapeOptions{}, `\u0069f`, true},
+		{`l\u0065t`, EscapeOptions{}, `l\u0065t`, true},
+		{`\u0069fs`, EscapeOptions{}, `ifs`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			b, ok := EscapeIdentifier([]byte(tt.s), tt.o)
+			test.String(t, string(b), tt.expected)
+			test.T(t, ok, tt.ok)
+		})
+	}
+}
+
+func TestEscapeRegExp(t *testing.T) {
+	var tests = []struct {
+		s        string
+		o        EscapeOptions
+		expected string
+	}{
+		{`/abc/g`, EscapeOptions{}, `/abc/g`},
+		{`/é\xe9[à-ÿ]/`, EscapeOptions{}, `/éé[à-ÿ]/`},
+		{`/.\x2A\//`, EscapeOptions{}, `/.\x2A\//`},
+		{`/\u{e9}/`, EscapeOptions{}, `/\u{e9}/`},
+		{`/\u{e9}/u`, EscapeOptions{}, `/é/u`},
+		{`/😀/`, EscapeOptions{}, `/😀/`},
+		{`/\é/`, EscapeOptions{}, `/é/`},
+		{`/é/i`, EscapeOptions{ASCIIOnly: true}, `/\xe9/i`},
+		{`/[€😀]/`, EscapeOptions{ASCIIOnly: true}, `/[\u20ac\ud83d\ude00]/`},
+		{`/[€😀]/u`, EscapeOptions{ASCIIOnly: true}, `/[\u20ac\u{1f600}]/u`},
+		{`/\\é/`, EscapeOptions{ASCIIOnly: true}, `/\\\xe9/`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			test.String(t, string(EscapeRegExp([]byte(tt.s), tt.o)), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,563 @@
+package js
+
+import (
+	"bytes"
+	"io"
+)
+
+// Experiments is a set of flags that enable parsing of ECMAScript proposals that are not part of the standard, see https://github.com/tc39/proposals. Experimental syntax is parsed into its own AST nodes, such as PipelineExpr, DoExpr, and MatchExpr, which may change as the proposals evolve.
+type Experiments uint32
+
+// Experiments values.
+const (
+	PipelineExperiment        Experiments = 1 << iota // Hack-style pipeline operator a |> f(%), stage 2
+	DoExpressionsExperiment                           // do expressions do { ... }, stage 1
+	PatternMatchingExperiment                         // match expressions match (a) { when p: b }, stage 1
+)
+
+// String returns the string representation of the experiments, separated by |.
+func (e Experiments) String() string {
+	s := ""
+	for _, experiment := range []struct {
+		Experiments
+		name string
+	}{
+		{PipelineExperiment, "Pipeline"},
+		{DoExpressionsExperiment, "DoExpressions"},
+		{PatternMatchingExperiment, "PatternMatching"},
+	} {
+		if e&experiment.Experiments != 0 {
+			if s != "" {
+				s += "|"
+			}
+			s += experiment.name
+		}
+	}
+	return s
+}
+
+////////////////////////////////////////////////////////////////
+
+// PipelineExpr is an experimental pipeline expression, such as a |> f(%), where the body refers to the piped value with the topic reference, see PipelineExperiment. It has the same precedence as assignments and is left-associative.
+type PipelineExpr struct {
+	X    IExpr // piped value
+	Body IExpr // body containing one or more TopicRefExpr
+}
+
+func (n PipelineExpr) String() string {
+	return "(" + n.X.String() + " |> " + n.Body.String() + ")"
+}
+
+// JS writes JavaScript to writer.
+func (n PipelineExpr) JS(w io.Writer) {
+	n.X.JS(w)
+	w.Write([]byte(" |> "))
+	n.Body.JS(w)
+}
+
+// TopicRefExpr is the topic reference % in the body of a pipeline expression, which refers to the piped value of the innermost enclosing pipeline expression.
+type TopicRefExpr struct{}
+
+func (n TopicRefExpr) String() string {
+	return "%"
+}
+
+// JS writes JavaScript to writer.
+func (n TopicRefExpr) JS(w io.Writer) {
+	w.Write([]byte("%"))
+}
+
+// DoExpr is an experimental do expression, such as do { if (a) { b; } else { c; } }, of which the value is the completion value of its block, see DoExpressionsExperiment.
+type DoExpr struct {
+	Body BlockStmt
+}
+
+func (n DoExpr) String() string {
+	return "(do " + n.Body.String() + ")"
+}
+
+// JS writes JavaScript to writer.
+func (n DoExpr) JS(w io.Writer) {
+	w.Write([]byte("do "))
+	n.Body.JS(w)
+}
+
+// MatchExpr is an experimental match expression, such as match (a) { when 1: b; default: c }, which evaluates to the body of the first clause of which the pattern matches the subject, see PatternMatchingExperiment.
+type MatchExpr struct {
+	Subject IExpr
+	Clauses []*MatchClause
+}
+
+func (n MatchExpr) String() string {
+	s := "(match " + n.Subject.String() + " {"
+	for _, clause := range n.Clauses {
+		s += " " + clause.String()
+	}
+	return s + " })"
+}
+
+// JS writes JavaScript to writer.
+func (n MatchExpr) JS(w io.Writer) {
+	w.Write([]byte("match ("))
+	n.Subject.JS(w)
+	w.Write([]byte(") {"))
+	for _, clause := range n.Clauses {
+		w.Write([]byte(" "))
+		clause.JS(w)
+		w.Write([]byte(";"))
+	}
+	w.Write([]byte(" }"))
+}
+
+// MatchClause is a when clause of a match expression, or the default clause if Pattern is nil. The bindings of the pattern are declared in the scope of the clause.
+type MatchClause struct {
+	Pattern *MatchPattern // can be nil
+	Body    IExpr
+	Scope
+}
+
+func (n MatchClause) String() string {
+	if n.Pattern == nil {
+		return "Clause(default: " + n.Body.String() + ")"
+	}
+	return "Clause(when " + n.Pattern.String() + ": " + n.Body.String() + ")"
+}
+
+// JS writes JavaScript to writer.
+func (n MatchClause) JS(w io.Writer) {
+	if n.Pattern == nil {
+		w.Write([]byte("default: "))
+	} else {
+		w.Write([]byte("when "))
+		n.Pattern.JS(w)
+		w.Write([]byte(": "))
+	}
+	n.Body.JS(w)
+}
+
+// MatchPatternType determines the type of a MatchPattern.
+type MatchPatternType uint16
+
+// MatchPatternType values.
+const (
+	ValueMatchPattern      MatchPatternType = iota // literal or custom matcher, such as 1, "a", or Option.Some
+	RelationalMatchPattern                         // comparison with a value, such as < 10
+	BindingMatchPattern                            // let, const, or var binding, such as let x
+	ArrayMatchPattern                              // [p, q, ...let r]
+	ObjectMatchPattern                             // { a: p, b, ...let r }
+	AndMatchPattern                                // p and q
+	OrMatchPattern                                 // p or q
+	NotMatchPattern                                // not p
+)
+
+// MatchPattern is a pattern of a match expression.
+type MatchPattern struct {
+	Type  MatchPatternType
+	Op    TokenType       // comparison operator of a relational pattern, or LetToken, ConstToken, or VarToken of a binding
+	X     IExpr           // value of a value or relational pattern, or the *Var of a binding
+	List  []MatchPattern  // elements of an array pattern, or the operands of and, or, and not
+	Props []MatchProperty // properties of an object pattern
+	Rest  *MatchPattern   // binding of the rest element of an array or object pattern, can be nil
+	// HasRest is set if an array or object pattern ends with an ellipsis, which may be followed by the binding in Rest.
+	HasRest bool
+}
+
+// MatchProperty is a property of an object pattern, which only tests that the property exists if Value is nil.
+type MatchProperty struct {
+	Name  PropertyName
+	Value *MatchPattern // can be nil
+}
+
+func (n MatchPattern) String() string {
+	switch n.Type {
+	case ValueMatchPattern:
+		return n.X.String()
+	case RelationalMatchPattern:
+		return n.Op.String() + " " + n.X.String()
+	case BindingMatchPattern:
+		return n.Op.String() + " " + n.X.String()
+	case ArrayMatchPattern, ObjectMatchPattern:
+		s := "["
+		if n.Type == ObjectMatchPattern {
+			s = "{"
+		}
+		for i, item := range n.List {
+			if i != 0 {
+				s += ", "
+			}
+			s += item.String()
+		}
+		for i, prop := range n.Props {
+			if i != 0 {
+				s += ", "
+			}
+			s += prop.Name.String()
+			if prop.Value != nil {
+				s += ": " + prop.Value.String()
+			}
+		}
+		if n.HasRest {
+			if 0 < len(n.List) || 0 < len(n.Props) {
+				s += ", "
+			}
+			s += "..."
+			if n.Rest != nil {
+				s += n.Rest.String()
+			}
+		}
+		if n.Type == ObjectMatchPattern {
+			return s + "}"
+		}
+		return s + "]"
+	case AndMatchPattern, OrMatchPattern:
+		op := " and "
+		if n.Type == OrMatchPattern {
+			op = " or "
+		}
+		s := "("
+		for i, item := range n.List {
+			if i != 0 {
+				s += op
+			}
+			s += item.String()
+		}
+		return s + ")"
+	case NotMatchPattern:
+		return "(not " + n.List[0].String() + ")"
+	}
+	return ""
+}
+
+// JS writes JavaScript to writer.
+func (n MatchPattern) JS(w io.Writer) {
+	switch n.Type {
+	case ValueMatchPattern:
+		n.X.JS(w)
+	case RelationalMatchPattern, BindingMatchPattern:
+		w.Write(n.Op.Bytes())
+		w.Write([]byte(" "))
+		n.X.JS(w)
+	case ArrayMatchPattern, ObjectMatchPattern:
+		if n.Type == ObjectMatchPattern {
+			w.Write([]byte("{ "))
+		} else {
+			w.Write([]byte("["))
+		}
+		for i, item := range n.List {
+			if i != 0 {
+				w.Write([]byte(", "))
+			}
+			item.JS(w)
+		}
+		for i, prop := range n.Props {
+			if i != 0 {
+				w.Write([]byte(", "))
+			}
+			prop.Name.JS(w)
+			if prop.Value != nil {
+				w.Write([]byte(": "))
+				prop.Value.JS(w)
+			}
+		}
+		if n.HasRest {
+			if 0 < len(n.List) || 0 < len(n.Props) {
+				w.Write([]byte(", "))
+			}
+			w.Write([]byte("..."))
+			if n.Rest != nil {
+				n.Rest.JS(w)
+			}
+		}
+		if n.Type == ObjectMatchPattern {
+			w.Write([]byte(" }"))
+		} else {
+			w.Write([]byte("]"))
+		}
+	case AndMatchPattern, OrMatchPattern:
+		for i, item := range n.List {
+			if i != 0 {
+				if n.Type == AndMatchPattern {
+					w.Write([]byte(" and "))
+				} else {
+					w.Write([]byte(" or "))
+				}
+			}
+			item.operandJS(w)
+		}
+	case NotMatchPattern:
+		w.Write([]byte("not "))
+		n.List[0].operandJS(w)
+	}
+}
+
+// operandJS writes the pattern as an operand of and, or, or not, which parenthesizes combined patterns.
+func (n MatchPattern) operandJS(w io.Writer) {
+	if n.Type == AndMatchPattern || n.Type == OrMatchPattern {
+		w.Write([]byte("("))
+		n.JS(w)
+		w.Write([]byte(")"))
+		return
+	}
+	n.JS(w)
+}
+
+func (n PipelineExpr) exprNode() {}
+func (n TopicRefExpr) exprNode() {}
+func (n DoExpr) exprNode()       {}
+func (n MatchExpr) exprNode()    {}
+
+////////////////////////////////////////////////////////////////
+
+// isContextualKeyword returns true if the current token is the identifier name, such as when or and in match expressions.
+func (p *Parser) isContextualKeyword(name string) bool {
+	return p.tt == IdentifierToken && bytes.Equal(p.data, []byte(name))
+}
+
+// parsePipelineBody parses the body of a pipeline expression after |>, which must contain the topic reference.
+func (p *Parser) parsePipelineBody() IExpr {
+	prevTopicUses := p.topicUses
+	p.topicLevel++
+	p.topicUses = 0
+	body := p.parseExpression(OpCoalesce)
+	if p.topicUses == 0 {
+		p.failMessage("pipeline body must contain the topic reference %%")
+	}
+	p.topicLevel--
+	p.topicUses = prevTopicUses
+	return body
+}
+
+// parseDoExpr parses a do expression, where the current token is do.
+func (p *Parser) parseDoExpr() IExpr {
+	p.next()
+	if p.tt != OpenBraceToken {
+		p.fail("do expression", OpenBraceToken)
+		return nil
+	}
+	doExpr := &DoExpr{}
+	prevIn := p.in
+	p.in = true
+	parent := p.enterScope(&doExpr.Body.Scope, false)
+	doExpr.Body.List = p.parseStmtList("do expression")
+	p.exitScope(parent)
+	p.in = prevIn
+	return doExpr
+}
+
+// parseMatchExpr parses a match expression after the identifier match, which starts at offset start. The identifier is an identifier reference or a call, such as match(a), if it is not followed by a parenthesized subject and the clauses on the same line.
+func (p *Parser) parseMatchExpr(start int, prec OpPrec, name []byte) IExpr {
+	if p.tt != OpenParenToken || p.prevLT {
+		return p.parseExpressionSuffix(p.scope.Use(name), start, prec, OpPrimary)
+	}
+	prevIn := p.in
+	p.in = true
+	args := p.parseArguments()
+	p.in = prevIn
+	if p.tt != OpenBraceToken || p.prevLT || len(args.List) != 1 || args.List[0].Rest {
+		call := &CallExpr{p.scope.Use(name), args, false}
+		p.annotate(start, call)
+		return p.parseExpressionSuffix(call, start, prec, OpCall)
+	}
+	p.next()
+
+	matchExpr := &MatchExpr{Subject: args.List[0].Value}
+	for p.tt != CloseBraceToken {
+		clause := &MatchClause{}
+		parent := p.enterScope(&clause.Scope, false)
+		if p.tt == DefaultToken {
+			p.next()
+		} else if p.isContextualKeyword("when") {
+			p.next()
+			clause.Pattern = p.parseMatchPattern()
+		} else {
+			p.fail("match expression", DefaultToken)
+			return nil
+		}
+		if !p.consume("match expression", ColonToken) {
+			return nil
+		}
+		prevIn := p.in
+		p.in = true
+		clause.Body = p.parseExpression(OpAssign)
+		p.in = prevIn
+		p.exitScope(parent)
+		if p.err != nil {
+			return nil
+		}
+		matchExpr.Clauses = append(matchExpr.Clauses, clause)
+
+		if p.tt == SemicolonToken {
+			p.next()
+		} else if p.tt != CloseBraceToken {
+			p.fail("match expression", SemicolonToken, CloseBraceToken)
+			return nil
+		}
+	}
+	p.next()
+	return p.parseExpressionSuffix(matchExpr, start, prec, OpPrimary)
+}
+
+// parseMatchPattern parses a pattern of a match expression with its and, or, and not combinators, where and and or cannot be mixed without parentheses.
+func (p *Parser) parseMatchPattern() *MatchPattern {
+	p.exprLevel++
+	defer func() { p.exprLevel-- }()
+	if NestedExprLimit < p.exprLevel {
+		p.failMessage("too many nested patterns")
+		return nil
+	}
+
+	pattern := p.parseUnaryMatchPattern()
+	if pattern == nil {
+		return nil
+	}
+	for _, combinator := range []MatchPatternType{AndMatchPattern, OrMatchPattern} {
+		name := "and"
+		if combinator == OrMatchPattern {
+			name = "or"
+		}
+		if !p.isContextualKeyword(name) {
+			continue
+		}
+		combined := &MatchPattern{Type: combinator, List: []MatchPattern{*pattern}}
+		for p.isContextualKeyword(name) {
+			p.next()
+			operand := p.parseUnaryMatchPattern()
+			if operand == nil {
+				return nil
+			}
+			combined.List = append(combined.List, *operand)
+		}
+		if p.isContextualKeyword("and") || p.isContextualKeyword("or") {
+			p.failMessage("and and or patterns must be parenthesized when combined")
+			return nil
+		}
+		return combined
+	}
+	return pattern
+}
+
+// parseUnaryMatchPattern parses a not pattern or a primary pattern.
+func (p *Parser) parseUnaryMatchPattern() *MatchPattern {
+	if p.isContextualKeyword("not") {
+		p.next()
+		operand := p.parseUnaryMatchPattern()
+		if operand == nil {
+			return nil
+		}
+		return &MatchPattern{Type: NotMatchPattern, List: []MatchPattern{*operand}}
+	}
+	return p.parsePrimaryMatchPattern()
+}
+
+// parsePrimaryMatchPattern parses a parenthesized, binding, array, object, relational, or value pattern.
+func (p *Parser) parsePrimaryMatchPattern() *MatchPattern {
+	switch tt := p.tt; tt {
+	case OpenParenToken:
+		p.next()
+		pattern := p.parseMatchPattern()
+		if pattern == nil || !p.consume("match pattern", CloseParenToken) {
+			return nil
+		}
+		return pattern
+	case LetToken, ConstToken, VarToken:
+		return p.parseMatchBinding()
+	case OpenBracketToken:
+		p.next()
+		pattern := &MatchPattern{Type: ArrayMatchPattern}
+		for p.tt != CloseBracketToken {
+			if p.tt == EllipsisToken {
+				if !p.parseMatchRest(pattern) || !p.consume("array pattern", CloseBracketToken) {
+					return nil
+				}
+				return pattern
+			}
+			element := p.parseMatchPattern()
+			if element == nil {
+				return nil
+			}
+			pattern.List = append(pattern.List, *element)
+			if p.tt == CommaToken {
+				p.next()
+			} else if p.tt != CloseBracketToken {
+				p.fail("array pattern", CommaToken, CloseBracketToken)
+				return nil
+			}
+		}
+		p.next()
+		return pattern
+	case OpenBraceToken:
+		p.next()
+		pattern := &MatchPattern{Type: ObjectMatchPattern}
+		for p.tt != CloseBraceToken {
+			if p.tt == EllipsisToken {
+				if !p.parseMatchRest(pattern) || !p.consume("object pattern", CloseBraceToken) {
+					return nil
+				}
+				return pattern
+			}
+			prop := MatchProperty{Name: p.parsePropertyName("object pattern")}
+			if p.err != nil {
+				return nil
+			} else if p.tt == ColonToken {
+				p.next()
+				if prop.Value = p.parseMatchPattern(); prop.Value == nil {
+					return nil
+				}
+			}
+			pattern.Props = append(pattern.Props, prop)
+			if p.tt == CommaToken {
+				p.next()
+			} else if p.tt != CloseBraceToken {
+				p.fail("object pattern", CommaToken, CloseBraceToken)
+				return nil
+			}
+		}
+		p.next()
+		return pattern
+	case LtToken, LtEqToken, GtToken, GtEqToken, EqEqToken, EqEqEqToken, NotEqToken, NotEqEqToken:
+		p.next()
+		x := p.parseExpression(OpShift)
+		if x == nil {
+			return nil
+		}
+		return &MatchPattern{Type: RelationalMatchPattern, Op: tt, X: x}
+	}
+	x := p.parseExpression(OpShift)
+	if x == nil {
+		return nil
+	}
+	return &MatchPattern{Type: ValueMatchPattern, X: x}
+}
+
+// parseMatchBinding parses a let, const, or var binding of a pattern, which is declared in the scope of the clause.
+func (p *Parser) parseMatchBinding() *MatchPattern {
+	tt := p.tt
+	p.next()
+	if !p.isIdentifierReference(p.tt) {
+		p.fail("match binding", IdentifierToken)
+		return nil
+	}
+	decl := LexicalDecl
+	if tt == VarToken {
+		decl = VariableDecl
+	}
+	v, ok := p.scope.Declare(decl, p.data)
+	if !ok {
+		p.failMessage("identifier %s has already been declared", string(p.data))
+		return nil
+	}
+	p.next()
+	return &MatchPattern{Type: BindingMatchPattern, Op: tt, X: v}
+}
+
+// parseMatchRest parses the rest element of an array or object pattern, which is an ellipsis optionally followed by a binding.
+func (p *Parser) parseMatchRest(pattern *MatchPattern) bool {
+	p.next()
+	pattern.HasRest = true
+	if p.tt == LetToken || p.tt == ConstToken || p.tt == VarToken {
+		if pattern.Rest = p.parseMatchBinding(); pattern.Rest == nil {
+			return false
+		}
+	}
+	return true
+}
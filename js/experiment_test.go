@@ -0,0 +1,126 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseExperiments(t *testing.T) {
+	all := PipelineExperiment | DoExpressionsExperiment | PatternMatchingExperiment
+	var tests = []struct {
+		js          string
+		experiments Experiments
+		expected    string
+	}{
+		// pipeline
+		{"a |> f(%)", PipelineExperiment, "Stmt(a |> (f(%)))"},
+		{"a |> f(%) |> % + 1", PipelineExperiment, "Stmt((a |> (f(%))) |> (%+1))"},
+		{"x = a || b |> % ?? c", PipelineExperiment, "Stmt(x=((a||b) |> (%??c)))"},
+		{"a |> (% |> %.b)", PipelineExperiment, "Stmt(a |> ((% |> (%.b))))"},
+		{"a |> % % %", PipelineExperiment, "Stmt(a |> (%%%))"},
+		{"a |> (() => %)", PipelineExperiment, "Stmt(a |> ((Params() => Stmt({ Stmt(return %) }))))"},
+		{"f(a |> [%], b)", PipelineExperiment, "Stmt(f((a |> [%]), b))"},
+
+		// do expressions
+		{"x = do { if (a) 1; else 2 }", DoExpressionsExperiment, "Stmt(x=(do Stmt({ Stmt(if a Stmt(1) else Stmt(2)) })))"},
+		{"x = do { let a = 1; a }", DoExpressionsExperiment, "Stmt(x=(do Stmt({ Decl(let Binding(a = 1)) Stmt(a) })))"},
+		{"do { a } while (b)", DoExpressionsExperiment, "Stmt(do Stmt({ Stmt(a) }) while b)"},
+
+		// pattern matching
+		{"match (x) { when 1: a; default: b }", PatternMatchingExperiment, "Stmt(match x { Clause(when 1: a) Clause(default: b) })"},
+		{"y = match (x) { when let y: y; }", PatternMatchingExperiment, "Stmt(y=(match x { Clause(when let y: y) }))"},
+		{"match (x) { when 1 or 2 or -3: a }", PatternMatchingExperiment, "Stmt(match x { Clause(when (1 or 2 or (-3)): a) })"},
+		{"match (x) { when not (< 0 and > 10): a }", PatternMatchingExperiment, "Stmt(match x { Clause(when (not (< 0 and > 10)): a) })"},
+		{"match (x) { when [1, let y, ...]: y }", PatternMatchingExperiment, "Stmt(match x { Clause(when [1, let y, ...]: y) })"},
+		{"match (x) { when [...let rest]: rest }", PatternMatchingExperiment, "Stmt(match x { Clause(when [...let rest]: rest) })"},
+		{"match (x) { when { a: 'b', c, [d]: const e, ...let f }: e }", PatternMatchingExperiment, "Stmt(match x { Clause(when {a: 'b', c, [d]: const e, ...let f}: e) })"},
+		{"match (x) { when Option.Some: a }", PatternMatchingExperiment, "Stmt(match x { Clause(when (Option.Some): a) })"},
+		{"match (a, b)", PatternMatchingExperiment, "Stmt(match(a, b))"},
+		{"match (a)\n{}", PatternMatchingExperiment, "Stmt(match(a)) Stmt({ })"},
+		{"match\n(a)", PatternMatchingExperiment, "Stmt(match(a))"},
+		{"match.a", PatternMatchingExperiment, "Stmt(match.a)"},
+		{"new match(a)", PatternMatchingExperiment, "Stmt(new match(a))"},
+		{"match (a) { when 1: b }", 0, ""},
+
+		// combined
+		{"x = match (a |> f(%)) { default: do { b } }", all, "Stmt(x=(match (a |> (f(%))) { Clause(default: (do Stmt({ Stmt(b) }))) }))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Experiments: tt.experiments})
+			if tt.expected == "" {
+				test.That(t, err != nil, "must fail without experiment")
+				return
+			}
+			test.Error(t, err)
+			test.String(t, ast.String(), tt.expected)
+		})
+	}
+}
+
+func TestParseExperimentsError(t *testing.T) {
+	all := PipelineExperiment | DoExpressionsExperiment | PatternMatchingExperiment
+	var tests = []struct {
+		js          string
+		experiments Experiments
+		err         string
+	}{
+		{"a |> f(%)", 0, "unexpected |> in expression"},
+		{"a |> f(x)", all, "pipeline body must contain the topic reference %"},
+		{"a |> (b |> f(%))", all, "pipeline body must contain the topic reference %"},
+		{"a |> % ? b : c", all, "unexpected ? in expression"},
+		{"a |> x = %", all, "pipeline body must contain the topic reference %"},
+		{"x = %", all, "unexpected % in expression"},
+		{"x = do { a }", 0, "unexpected do in expression"},
+		{"x = do a", all, "expected { instead of a in do expression"},
+		{"match (a) { b }", all, "expected default instead of b in match expression"},
+		{"match (a) { when 1 a }", all, "expected : instead of a in match expression"},
+		{"match (a) { when 1: b c }", all, "expected ; or } instead of c in match expression"},
+		{"match (a) { when 1 and 2 or 3: b }", all, "and and or patterns must be parenthesized when combined"},
+		{"match (a) { when [let b, let b]: b }", all, "identifier b has already been declared"},
+		{"match (a) { when let 1: b }", all, "expected Identifier instead of 1 in match binding"},
+		{"match (a) { when [..., 1]: b }", all, "expected ] instead of , in array pattern"},
+		{"match (a) { when {b c}: b }", all, "expected , or } instead of c in object pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.js), Options{Experiments: tt.experiments})
+			test.That(t, err != nil, "must give error")
+			if perr, ok := err.(*parse.Error); ok {
+				test.String(t, perr.Message, tt.err)
+			}
+		})
+	}
+}
+
+func TestExperimentsJS(t *testing.T) {
+	// the output of JS must parse to the same output
+	var productions = []string{
+		"a = b |> f(%, 1) |> % + 1;",
+		"a = do { if (b) { c; } else { d; } };",
+		"(do { a; });",
+		"a = match (b) { when 1 or (2 and not 3): c; when [let d, ...]: d; when { e: < 5, f, ...let g }: g; default: h; };",
+	}
+
+	types := typeCollector{}
+	o := Options{Experiments: PipelineExperiment | DoExpressionsExperiment | PatternMatchingExperiment}
+	for _, js := range productions {
+		t.Run(js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(js), o)
+			test.Error(t, err)
+			Walk(types, ast)
+
+			src := ast.JSString()
+			ast2, err := Parse(parse.NewInputString(src), o)
+			test.Error(t, err, src)
+			test.String(t, ast2.JSString(), src)
+		})
+	}
+
+	for _, typ := range []string{"PipelineExpr", "TopicRefExpr", "DoExpr", "MatchExpr", "MatchClause", "MatchPattern"} {
+		test.That(t, types[typ], "production for "+typ+" not covered")
+	}
+	test.String(t, (PipelineExperiment | PatternMatchingExperiment).String(), "Pipeline|PatternMatching")
+}
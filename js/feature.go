@@ -0,0 +1,205 @@
+package js
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Feature is a syntactic feature of ECMAScript that was introduced after ES5.
+type Feature uint32
+
+// Feature values, grouped by the edition of ECMAScript that introduced them.
+const (
+	ErrorFeature Feature = iota
+	// ES2015
+	ModuleFeature              // import and export declarations
+	LetConstFeature            // let and const declarations
+	ArrowFunctionFeature       // arrow functions
+	ClassFeature               // class declarations and expressions
+	TemplateLiteralFeature     // template literals and tagged templates
+	SpreadFeature              // spread elements and arguments, and rest parameters and elements
+	DestructuringFeature       // array and object binding patterns
+	ForOfFeature               // for-of statements
+	GeneratorFeature           // generator functions and methods
+	BinaryOctalLiteralFeature  // 0b and 0o numeric literals
+	NewTargetFeature           // new.target
+	RegExpUnicodeStickyFeature // u and y regular expression flags
+	// ES2016
+	ExponentiationFeature // ** and **= operators
+	// ES2017
+	AsyncFunctionFeature // async functions, methods, and arrow functions
+	// ES2018
+	AsyncIterationFeature   // for-await-of statements and async generators
+	ObjectRestSpreadFeature // spread properties and rest properties
+	RegExpES2018Feature     // s flag, named capture groups, and lookbehind assertions in regular expressions
+	// ES2019
+	OptionalCatchBindingFeature // catch clauses without a binding
+	// ES2020
+	OptionalChainingFeature  // ?. operator
+	NullishCoalescingFeature // ?? operator
+	BigIntFeature            // BigInt literals
+	DynamicImportFeature     // import() calls
+	ImportMetaFeature        // import.meta
+	// ES2021
+	LogicalAssignmentFeature // &&=, ||=, and ??= operators
+	NumericSeparatorFeature  // underscores in numeric literals
+	// ES2022
+	ClassFieldFeature    // public and private class fields
+	PrivateMemberFeature // private methods, fields, and member accesses
+	PrivateInFeature     // #x in obj
+	StaticBlockFeature   // class static blocks
+	TopLevelAwaitFeature // await outside of functions in modules
+	RegExpIndicesFeature // d regular expression flag
+	// ES2023
+	HashbangFeature // #! comment at the start of the source
+	// ES2024
+	RegExpUnicodeSetsFeature // v regular expression flag
+)
+
+var featureNames = []string{"Error", "Module", "LetConst", "ArrowFunction", "Class", "TemplateLiteral", "Spread", "Destructuring", "ForOf", "Generator", "BinaryOctalLiteral", "NewTarget", "RegExpUnicodeSticky", "Exponentiation", "AsyncFunction", "AsyncIteration", "ObjectRestSpread", "RegExpES2018", "OptionalCatchBinding", "OptionalChaining", "NullishCoalescing", "BigInt", "DynamicImport", "ImportMeta", "LogicalAssignment", "NumericSeparator", "ClassField", "PrivateMember", "PrivateIn", "StaticBlock", "TopLevelAwait", "RegExpIndices", "Hashbang", "RegExpUnicodeSets"}
+
+// String returns the string representation of a Feature.
+func (f Feature) String() string {
+	if int(f) < len(featureNames) {
+		return featureNames[f]
+	}
+	return "Invalid(" + strconv.Itoa(int(f)) + ")"
+}
+
+// Version returns the year of the ECMAScript edition that introduced the feature, such as 2015 for ES6.
+func (f Feature) Version() int {
+	switch {
+	case f == ErrorFeature:
+		return 0
+	case f <= RegExpUnicodeStickyFeature:
+		return 2015
+	case f <= ExponentiationFeature:
+		return 2016
+	case f <= AsyncFunctionFeature:
+		return 2017
+	case f <= RegExpES2018Feature:
+		return 2018
+	case f <= OptionalCatchBindingFeature:
+		return 2019
+	case f <= ImportMetaFeature:
+		return 2020
+	case f <= NumericSeparatorFeature:
+		return 2021
+	case f <= RegExpIndicesFeature:
+		return 2022
+	case f <= HashbangFeature:
+		return 2023
+	}
+	return 2024
+}
+
+// FeatureUse is the use of a Feature at a span of the source, which usually covers the token that introduces the feature, such as the ?. operator or the async keyword.
+type FeatureUse struct {
+	Feature
+	Span
+}
+
+// Features parses the input and returns the ECMAScript features it uses in source order, so that build tools can decide whether and to which version a script must be transpiled. Destructuring is reported for binding patterns and arrow function parameters, but not for assignment patterns such as [a, b] = c, which are parsed as array and object literals.
+func Features(r *parse.Input, o Options) ([]FeatureUse, error) {
+	p := newParser(r, o)
+	p.reportFeatures = true
+	if _, err := p.parse(r); err != nil {
+		return nil, err
+	}
+	features := p.features
+	sort.SliceStable(features, func(i, j int) bool {
+		return features[i].Start < features[j].Start
+	})
+	return features, nil
+}
+
+// MinimumVersion returns the year of the oldest ECMAScript edition that supports all features used, or 0 if only ES5 features are used.
+func MinimumVersion(uses []FeatureUse) int {
+	version := 0
+	for _, use := range uses {
+		if v := use.Version(); version < v {
+			version = v
+		}
+	}
+	return version
+}
+
+////////////////////////////////////////////////////////////////
+
+// generatorFeature records a generator at the current * token, which is an async generator if async is set.
+func (p *Parser) generatorFeature(async bool) {
+	if async {
+		p.feature(AsyncIterationFeature)
+	} else {
+		p.feature(GeneratorFeature)
+	}
+}
+
+// methodFeatures records the async and generator features of a method, where the spans are those of its async and * tokens.
+func (p *Parser) methodFeatures(method *MethodDecl, asyncSpan, generatorSpan Span) {
+	if method.Async {
+		p.featureSpan(AsyncFunctionFeature, asyncSpan)
+	}
+	if method.Generator {
+		if method.Async {
+			p.featureSpan(AsyncIterationFeature, generatorSpan)
+		} else {
+			p.featureSpan(GeneratorFeature, generatorSpan)
+		}
+	}
+}
+
+// numericFeatures records the features of the current numeric literal.
+func (p *Parser) numericFeatures() {
+	if !p.reportFeatures {
+		return
+	}
+	if p.tt == BinaryToken || p.tt == OctalToken {
+		p.feature(BinaryOctalLiteralFeature)
+	}
+	if p.data[len(p.data)-1] == 'n' {
+		p.feature(BigIntFeature)
+	}
+	if bytes.IndexByte(p.data, '_') != -1 {
+		p.feature(NumericSeparatorFeature)
+	}
+}
+
+// regExpFeatures records the features of the flags and pattern of the current regular expression literal.
+func (p *Parser) regExpFeatures() {
+	if !p.reportFeatures {
+		return
+	}
+	i := bytes.LastIndexByte(p.data, '/')
+	for _, c := range p.data[i+1:] {
+		switch c {
+		case 'u', 'y':
+			p.feature(RegExpUnicodeStickyFeature)
+		case 's':
+			p.feature(RegExpES2018Feature)
+		case 'd':
+			p.feature(RegExpIndicesFeature)
+		case 'v':
+			p.feature(RegExpUnicodeSetsFeature)
+		}
+	}
+
+	// named capture groups and lookbehind assertions
+	body := p.data[1:i]
+	inClass := false
+	for j := 0; j < len(body); j++ {
+		if body[j] == '\\' {
+			j++
+		} else if inClass {
+			inClass = body[j] != ']'
+		} else if body[j] == '[' {
+			inClass = true
+		} else if body[j] == '(' && j+2 < len(body) && body[j+1] == '?' && body[j+2] == '<' {
+			p.feature(RegExpES2018Feature)
+			return
+		}
+	}
+}
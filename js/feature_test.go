@@ -0,0 +1,94 @@
+package js
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestFeatures(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"var a = function(b) { return b + 1 }", ""},
+		{"import a from 'b'; export {a}", "Module Module"},
+		{"let a = 1; const b = 2", "LetConst LetConst"},
+		{"a => a", "ArrowFunction"},
+		{"class A {}", "Class"},
+		{"`a${b}`", "TemplateLiteral"},
+		{"f(...a, [...b])", "Spread Spread"},
+		{"function f(...a) {}", "Spread"},
+		{"var [a, {b}] = c", "Destructuring Destructuring"},
+		{"([a], {b}) => a", "Destructuring Destructuring ArrowFunction"},
+		{"for (a of b);", "ForOf"},
+		{"function* f() {}", "Generator"},
+		{"0b11 + 0o7 + 0x1", "BinaryOctalLiteral BinaryOctalLiteral"},
+		{"function f() { new.target }", "NewTarget"},
+		{"/a/uy", "RegExpUnicodeSticky RegExpUnicodeSticky"},
+		{"a ** b; a **= b", "Exponentiation Exponentiation"},
+		{"async function f() { await a }", "AsyncFunction"},
+		{"async a => a; async (a) => a", "AsyncFunction ArrowFunction AsyncFunction ArrowFunction"},
+		{"async(a)", ""},
+		{"({async a() {}, async *b() {}, *c() {}})", "AsyncFunction AsyncFunction AsyncIteration Generator"},
+		{"async function* f() { for await (a of b); }", "AsyncFunction AsyncIteration AsyncIteration ForOf"},
+		{"({...a}); var {...b} = c", "ObjectRestSpread Destructuring ObjectRestSpread"},
+		{"/(?<a>b)/s; /(?<=a)/; /[(?<]/", "RegExpES2018 RegExpES2018 RegExpES2018"},
+		{"try {} catch {}", "OptionalCatchBinding"},
+		{"a?.b ?? c", "OptionalChaining NullishCoalescing"},
+		{"1n + 1_000", "BigInt NumericSeparator"},
+		{"import('a'); import.meta", "DynamicImport ImportMeta"},
+		{"a &&= b; a ||= b; a ??= b", "LogicalAssignment LogicalAssignment LogicalAssignment"},
+		{"class A { a = 1; #b; static c; m() { this.#b; #b in this } }", "Class ClassField PrivateMember ClassField ClassField PrivateMember PrivateIn"},
+		{"class A { static {} }", "Class StaticBlock"},
+		{"await a; for await (b of c);", "TopLevelAwait AsyncIteration TopLevelAwait ForOf"},
+		{"async function f() { await a } class A { static { await a } }", "AsyncFunction Class StaticBlock"},
+		{"/a/d; /a/v", "RegExpIndices RegExpUnicodeSets"},
+		{"#!node\na", "Hashbang"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			uses, err := Features(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			names := []string{}
+			for _, use := range uses {
+				names = append(names, use.Feature.String())
+			}
+			test.String(t, strings.Join(names, " "), tt.expected)
+		})
+	}
+}
+
+func TestFeatureSpans(t *testing.T) {
+	js := "async function f() { return a?.b }\nconst {c} = d"
+	uses, err := Features(parse.NewInputString(js), Options{})
+	test.Error(t, err)
+	test.T(t, len(uses), 4)
+	spans := []string{"async", "?.", "const", "{"}
+	for i, use := range uses {
+		if i < len(spans) {
+			test.String(t, js[use.Start:use.End], spans[i], use.Feature.String())
+		}
+	}
+	test.T(t, MinimumVersion(uses), 2020)
+	test.T(t, MinimumVersion(nil), 0)
+
+	_, err = Features(parse.NewInputString("a?."), Options{})
+	test.That(t, err != nil)
+}
+
+func TestFeatureVersion(t *testing.T) {
+	test.T(t, LetConstFeature.Version(), 2015)
+	test.T(t, ExponentiationFeature.Version(), 2016)
+	test.T(t, AsyncFunctionFeature.Version(), 2017)
+	test.T(t, ObjectRestSpreadFeature.Version(), 2018)
+	test.T(t, OptionalCatchBindingFeature.Version(), 2019)
+	test.T(t, BigIntFeature.Version(), 2020)
+	test.T(t, NumericSeparatorFeature.Version(), 2021)
+	test.T(t, TopLevelAwaitFeature.Version(), 2022)
+	test.T(t, HashbangFeature.Version(), 2023)
+	test.T(t, RegExpUnicodeSetsFeature.Version(), 2024)
+	test.String(t, Feature(100).String(), "Invalid(100)")
+}
@@ -0,0 +1,171 @@
+//go:build ignore
+
+// This program generates keyword.go, a perfect hash table for the JS keywords. Run with `go generate`.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// keywords maps the reserved words, strict mode reserved words, and contextual keywords to their TokenType.
+var keywords = map[string]string{
+	// reserved
+	"await":      "AwaitToken",
+	"break":      "BreakToken",
+	"case":       "CaseToken",
+	"catch":      "CatchToken",
+	"class":      "ClassToken",
+	"const":      "ConstToken",
+	"continue":   "ContinueToken",
+	"debugger":   "DebuggerToken",
+	"default":    "DefaultToken",
+	"delete":     "DeleteToken",
+	"do":         "DoToken",
+	"else":       "ElseToken",
+	"enum":       "EnumToken",
+	"export":     "ExportToken",
+	"extends":    "ExtendsToken",
+	"false":      "FalseToken",
+	"finally":    "FinallyToken",
+	"for":        "ForToken",
+	"function":   "FunctionToken",
+	"if":         "IfToken",
+	"import":     "ImportToken",
+	"in":         "InToken",
+	"instanceof": "InstanceofToken",
+	"new":        "NewToken",
+	"null":       "NullToken",
+	"return":     "ReturnToken",
+	"super":      "SuperToken",
+	"switch":     "SwitchToken",
+	"this":       "ThisToken",
+	"throw":      "ThrowToken",
+	"true":       "TrueToken",
+	"try":        "TryToken",
+	"typeof":     "TypeofToken",
+	"var":        "VarToken",
+	"void":       "VoidToken",
+	"while":      "WhileToken",
+	"with":       "WithToken",
+	"yield":      "YieldToken",
+
+	// strict mode
+	"let":        "LetToken",
+	"static":     "StaticToken",
+	"implements": "ImplementsToken",
+	"interface":  "InterfaceToken",
+	"package":    "PackageToken",
+	"private":    "PrivateToken",
+	"protected":  "ProtectedToken",
+	"public":     "PublicToken",
+
+	// extra
+	"as":     "AsToken",
+	"async":  "AsyncToken",
+	"from":   "FromToken",
+	"get":    "GetToken",
+	"meta":   "MetaToken",
+	"of":     "OfToken",
+	"set":    "SetToken",
+	"target": "TargetToken",
+}
+
+func hash(h uint32, s string) uint32 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
+
+func main() {
+	names := []string{}
+	minLen, maxLen := 255, 0
+	for name := range keywords {
+		names = append(names, name)
+		if len(name) < minLen {
+			minLen = len(name)
+		}
+		if maxLen < len(name) {
+			maxLen = len(name)
+		}
+	}
+	sort.Strings(names)
+
+	// find the smallest table and seed for which every keyword is found by one of two probes
+	r := rand.New(rand.NewSource(1))
+	for bits := 1; ; bits++ {
+		size := uint32(1) << uint(bits)
+		if size < uint32(len(names)) {
+			continue
+		}
+	SEED:
+		for try := 0; try < 1000000; try++ {
+			hash0 := r.Uint32()
+			table := make([]string, size)
+			for _, name := range names {
+				h := hash(hash0, name)
+				if i := h & (size - 1); table[i] == "" {
+					table[i] = name
+				} else if j := (h >> 16) & (size - 1); table[j] == "" {
+					table[j] = name
+				} else {
+					continue SEED
+				}
+			}
+			write(hash0, bits, minLen, maxLen, table)
+			return
+		}
+	}
+}
+
+func write(hash0 uint32, bits, minLen, maxLen int, table []string) {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "package js\n\n")
+	fmt.Fprintf(b, "// Code generated by gen_keyword.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "//go:generate go run gen_keyword.go\n\n")
+	fmt.Fprintf(b, "// Keyword returns the TokenType for a reserved word, a strict mode reserved word, or a contextual keyword such as async, of, get, and set. It returns IdentifierToken if the bytes are not a keyword. The lookup uses a perfect hash table and does not allocate, and is much faster than using the Keywords map.\n")
+	fmt.Fprintf(b, `func Keyword(b []byte) TokenType {
+	if len(b) < _Keyword_minLen || _Keyword_maxLen < len(b) {
+		return IdentifierToken
+	}
+	h := uint32(_Keyword_hash0)
+	for i := 0; i < len(b); i++ {
+		h ^= uint32(b[i])
+		h *= 16777619
+	}
+	if e := _Keyword_table[h&uint32(len(_Keyword_table)-1)]; e.name == string(b) {
+		return e.tt
+	} else if e := _Keyword_table[(h>>16)&uint32(len(_Keyword_table)-1)]; e.name == string(b) {
+		return e.tt
+	}
+	return IdentifierToken
+}
+
+`)
+	fmt.Fprintf(b, "const _Keyword_hash0 = 0x%x\n", hash0)
+	fmt.Fprintf(b, "const _Keyword_minLen = %d\n", minLen)
+	fmt.Fprintf(b, "const _Keyword_maxLen = %d\n\n", maxLen)
+	fmt.Fprintf(b, "var _Keyword_table = [1 << %d]struct {\n\tname string\n\ttt   TokenType\n}{\n", bits)
+	for i, name := range table {
+		if name != "" {
+			fmt.Fprintf(b, "\t0x%x: {%q, %s},\n", i, name, keywords[name])
+		}
+	}
+	fmt.Fprintf(b, "}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile("keyword.go", src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,87 @@
+package js
+
+// Code generated by gen_keyword.go; DO NOT EDIT.
+
+//go:generate go run gen_keyword.go
+
+// Keyword returns the TokenType for a reserved word, a strict mode reserved word, or a contextual keyword such as async, of, get, and set. It returns IdentifierToken if the bytes are not a keyword. The lookup uses a perfect hash table and does not allocate, and is much faster than using the Keywords map.
+func Keyword(b []byte) TokenType {
+	if len(b) < _Keyword_minLen || _Keyword_maxLen < len(b) {
+		return IdentifierToken
+	}
+	h := uint32(_Keyword_hash0)
+	for i := 0; i < len(b); i++ {
+		h ^= uint32(b[i])
+		h *= 16777619
+	}
+	if e := _Keyword_table[h&uint32(len(_Keyword_table)-1)]; e.name == string(b) {
+		return e.tt
+	} else if e := _Keyword_table[(h>>16)&uint32(len(_Keyword_table)-1)]; e.name == string(b) {
+		return e.tt
+	}
+	return IdentifierToken
+}
+
+const _Keyword_hash0 = 0xce615472
+const _Keyword_minLen = 2
+const _Keyword_maxLen = 10
+
+var _Keyword_table = [1 << 7]struct {
+	name string
+	tt   TokenType
+}{
+	0x1:  {"for", ForToken},
+	0x2:  {"set", SetToken},
+	0x3:  {"typeof", TypeofToken},
+	0x6:  {"async", AsyncToken},
+	0xa:  {"package", PackageToken},
+	0xd:  {"var", VarToken},
+	0xe:  {"try", TryToken},
+	0x11: {"private", PrivateToken},
+	0x12: {"true", TrueToken},
+	0x17: {"return", ReturnToken},
+	0x19: {"false", FalseToken},
+	0x1b: {"continue", ContinueToken},
+	0x1e: {"get", GetToken},
+	0x20: {"static", StaticToken},
+	0x23: {"enum", EnumToken},
+	0x25: {"catch", CatchToken},
+	0x26: {"function", FunctionToken},
+	0x2b: {"public", PublicToken},
+	0x2c: {"with", WithToken},
+	0x2d: {"finally", FinallyToken},
+	0x33: {"null", NullToken},
+	0x36: {"if", IfToken},
+	0x37: {"do", DoToken},
+	0x3d: {"in", InToken},
+	0x3f: {"throw", ThrowToken},
+	0x40: {"instanceof", InstanceofToken},
+	0x42: {"this", ThisToken},
+	0x48: {"protected", ProtectedToken},
+	0x49: {"interface", InterfaceToken},
+	0x4c: {"else", ElseToken},
+	0x4d: {"delete", DeleteToken},
+	0x4f: {"import", ImportToken},
+	0x50: {"export", ExportToken},
+	0x53: {"of", OfToken},
+	0x56: {"from", FromToken},
+	0x57: {"extends", ExtendsToken},
+	0x59: {"break", BreakToken},
+	0x5a: {"switch", SwitchToken},
+	0x5c: {"void", VoidToken},
+	0x5f: {"meta", MetaToken},
+	0x60: {"implements", ImplementsToken},
+	0x61: {"debugger", DebuggerToken},
+	0x62: {"class", ClassToken},
+	0x64: {"await", AwaitToken},
+	0x67: {"const", ConstToken},
+	0x6e: {"as", AsToken},
+	0x71: {"super", SuperToken},
+	0x73: {"target", TargetToken},
+	0x75: {"while", WhileToken},
+	0x7b: {"let", LetToken},
+	0x7c: {"new", NewToken},
+	0x7d: {"yield", YieldToken},
+	0x7e: {"case", CaseToken},
+	0x7f: {"default", DefaultToken},
+}
@@ -51,6 +51,15 @@ func NewLexer(r *parse.Input) *Lexer {
 	}
 }
 
+// Reset resets the lexer to lex r, while keeping its allocated buffers.
+func (l *Lexer) Reset(r *parse.Input) {
+	*l = Lexer{
+		r:                  r,
+		prevLineTerminator: true,
+		templateLevels:     l.templateLevels[:0],
+	}
+}
+
 // Err returns the error encountered during lexing, this is often io.EOF but also other errors can be returned.
 func (l *Lexer) Err() error {
 	if l.err != nil {
@@ -197,10 +206,9 @@ func (l *Lexer) Next() (TokenType, []byte) {
 			if prevNumericLiteral {
 				l.err = parse.NewErrorLexer(l.r, "unexpected identifier after number")
 				return ErrorToken, nil
-			} else if keyword, ok := Keywords[string(l.r.Lexeme())]; ok {
-				return keyword, l.r.Shift()
 			}
-			return IdentifierToken, l.r.Shift()
+			tt := Keyword(l.r.Lexeme())
+			return tt, l.r.Shift()
 		}
 		if 0xC0 <= c {
 			if l.consumeWhitespace() {
@@ -470,6 +478,9 @@ func (l *Lexer) consumeOperatorToken() TokenType {
 	} else if c == '=' && l.r.Peek(0) == '>' {
 		l.r.Move(1)
 		return ArrowToken
+	} else if c == '|' && l.r.Peek(0) == '>' {
+		l.r.Move(1)
+		return PipelineToken
 	} else if c == '>' && l.r.Peek(0) == '>' {
 		l.r.Move(1)
 		if l.r.Peek(0) == '>' {
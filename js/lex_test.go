@@ -35,6 +35,7 @@ func TestTokens(t *testing.T) {
 		{"&&= ||= ??=", TTs{AndEqToken, OrEqToken, NullishEqToken}},
 		{"?.5", TTs{QuestionToken, DecimalToken}},
 		{"?.a", TTs{OptChainToken, IdentifierToken}},
+		{"a|>b", TTs{IdentifierToken, PipelineToken, IdentifierToken}},
 		{"await break case catch class const continue", TTs{AwaitToken, BreakToken, CaseToken, CatchToken, ClassToken, ConstToken, ContinueToken}},
 		{"debugger default delete do else enum export extends", TTs{DebuggerToken, DefaultToken, DeleteToken, DoToken, ElseToken, EnumToken, ExportToken, ExtendsToken}},
 		{"false finally for function if import in instanceof", TTs{FalseToken, FinallyToken, ForToken, FunctionToken, IfToken, ImportToken, InToken, InstanceofToken}},
@@ -287,6 +288,55 @@ func TestLexerErrors(t *testing.T) {
 	test.T(t, string(data), "a")
 }
 
+func TestKeyword(t *testing.T) {
+	for name, tt := range Keywords {
+		t.Run(name, func(t *testing.T) {
+			test.T(t, Keyword([]byte(name)), tt)
+		})
+	}
+
+	var tests = []string{"", "a", "x", "fo", "fork", "If", "IF", "awaits", "instanceofs", "interfaces", "constructor", "undefined"}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.T(t, Keyword([]byte(name)), IdentifierToken)
+		})
+	}
+}
+
+func FuzzLexer(f *testing.F) {
+	f.Add("var a = b /c/ 2;")
+	f.Add("x = `a${b}c` + /re/g")
+	f.Add("if (a) { return 0x1fn } // comment")
+	f.Fuzz(func(t *testing.T, s string) {
+		z := parse.NewInputString(s)
+		peeked := false
+		z.SetHooks(&parse.Hooks{
+			OnMove: func(from, to int) {
+				test.That(t, to <= len(s), "must not move beyond the input")
+			},
+			OnRewind: func(from, to int) {
+				test.That(t, to <= from, "must not rewind forward")
+			},
+			OnPeekEOF: func(offset int) {
+				peeked = true
+			},
+		})
+		l := NewLexer(z)
+		for i := 0; i <= len(s); i++ {
+			offset := z.Offset()
+			if tt, _ := l.Next(); tt == ErrorToken {
+				if l.Err() == io.EOF {
+					test.T(t, z.Offset(), len(s), "must consume the input")
+					test.That(t, peeked, "must peek at EOF")
+				}
+				return
+			}
+			test.That(t, offset < z.Offset(), "must make progress")
+		}
+		t.Fatal("must stop")
+	})
+}
+
 ////////////////////////////////////////////////////////////////
 
 func ExampleNewLexer() {
@@ -0,0 +1,467 @@
+package js
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strconv"
+)
+
+var (
+	defaultBytes = []byte("default")
+	starBytes    = []byte("*")
+)
+
+// ImportBinding is a binding imported by a module.
+type ImportBinding struct {
+	Module  []byte // module specifier without quotes
+	Name    []byte // imported name, default for default imports and * for namespace imports
+	Binding []byte // local name
+}
+
+// ExportBinding is a name exported by a module.
+type ExportBinding struct {
+	Name    []byte   // exported name, default for default exports and * for export * from
+	Binding []byte   // local name, nil for re-exports and anonymous default exports
+	Module  []byte   // module specifier without quotes for re-exports, nil otherwise
+	Import  []byte   // name imported from Module for re-exports, * for export * from and export * as ns from
+	Decl    DeclType // declaration type of the local binding, LexicalDecl for default exported expressions
+}
+
+// ModuleInfo is the import and export information of an ES module.
+type ModuleInfo struct {
+	Requests [][]byte // module specifiers without quotes of import and export from statements in source order, without duplicates
+	Imports  []ImportBinding
+	Exports  []ExportBinding
+
+	// EvalUses are the local names of imported bindings that are read while the module body is evaluated. Reads inside functions, methods, and non-static class fields are excluded as they are deferred.
+	EvalUses [][]byte
+}
+
+// NewModuleInfo extracts the imports and exports of a module.
+func NewModuleInfo(ast *AST) *ModuleInfo {
+	m := &ModuleInfo{}
+	for _, item := range ast.List {
+		switch stmt := item.(type) {
+		case *ImportStmt:
+			m.addImport(stmt)
+		case *ExportStmt:
+			m.addExport(stmt, &ast.BlockStmt.Scope)
+		}
+	}
+	if 0 < len(m.Imports) {
+		Walk(&evalUsesVisitor{m: m}, ast)
+	}
+	return m
+}
+
+func unquoteSpecifier(b []byte) []byte {
+	if 2 <= len(b) && (b[0] == '"' || b[0] == '\'') {
+		return b[1 : len(b)-1]
+	}
+	return b
+}
+
+func (m *ModuleInfo) addRequest(specifier []byte) {
+	for _, request := range m.Requests {
+		if bytes.Equal(request, specifier) {
+			return
+		}
+	}
+	m.Requests = append(m.Requests, specifier)
+}
+
+func (m *ModuleInfo) addImport(stmt *ImportStmt) {
+	module := unquoteSpecifier(stmt.Module)
+	m.addRequest(module)
+	if stmt.Default != nil {
+		m.Imports = append(m.Imports, ImportBinding{module, defaultBytes, stmt.Default})
+	}
+	for _, alias := range stmt.List {
+		if alias.Binding == nil {
+			continue // trailing comma
+		}
+		name := alias.Name
+		if name == nil {
+			name = alias.Binding
+		}
+		m.Imports = append(m.Imports, ImportBinding{module, name, alias.Binding})
+	}
+}
+
+func (m *ModuleInfo) addExport(stmt *ExportStmt, scope *Scope) {
+	if stmt.Module != nil {
+		module := unquoteSpecifier(stmt.Module)
+		m.addRequest(module)
+		for _, alias := range stmt.List {
+			if alias.Binding == nil {
+				continue // trailing comma
+			} else if alias.Name == nil && bytes.Equal(alias.Binding, starBytes) {
+				m.Exports = append(m.Exports, ExportBinding{Name: starBytes, Module: module, Import: starBytes})
+			} else if alias.Name == nil {
+				m.Exports = append(m.Exports, ExportBinding{Name: alias.Binding, Module: module, Import: alias.Binding})
+			} else {
+				m.Exports = append(m.Exports, ExportBinding{Name: alias.Binding, Module: module, Import: alias.Name})
+			}
+		}
+		return
+	} else if stmt.Decl == nil {
+		for _, alias := range stmt.List {
+			if alias.Binding == nil {
+				continue // trailing comma
+			}
+			local := alias.Binding
+			if alias.Name != nil {
+				local = alias.Name
+			}
+			m.Exports = append(m.Exports, ExportBinding{Name: alias.Binding, Binding: local, Decl: declTypeOf(scope, local)})
+		}
+		return
+	}
+
+	switch decl := stmt.Decl.(type) {
+	case *VarDecl:
+		for _, item := range decl.List {
+			for _, v := range bindingVars(item.Binding) {
+				m.Exports = append(m.Exports, ExportBinding{Name: v.Data, Binding: v.Data, Decl: v.Decl})
+			}
+		}
+	case *FuncDecl:
+		export := ExportBinding{Decl: FunctionDecl}
+		if decl.Name != nil {
+			export.Name, export.Binding = decl.Name.Data, decl.Name.Data
+		}
+		if stmt.Default {
+			export.Name = defaultBytes
+		}
+		m.Exports = append(m.Exports, export)
+	case *ClassDecl:
+		export := ExportBinding{Decl: LexicalDecl}
+		if decl.Name != nil {
+			export.Name, export.Binding = decl.Name.Data, decl.Name.Data
+		}
+		if stmt.Default {
+			export.Name = defaultBytes
+		}
+		m.Exports = append(m.Exports, export)
+	default:
+		m.Exports = append(m.Exports, ExportBinding{Name: defaultBytes, Decl: LexicalDecl})
+	}
+}
+
+// declTypeOf returns the declaration type of a variable declared in the module scope.
+func declTypeOf(scope *Scope, name []byte) DeclType {
+	for _, v := range scope.Declared {
+		if bytes.Equal(v.Data, name) {
+			return v.Decl
+		}
+	}
+	return NoDecl
+}
+
+// bindingVars returns the variables bound by a binding pattern.
+func bindingVars(binding IBinding) []*Var {
+	switch b := binding.(type) {
+	case *Var:
+		return []*Var{b}
+	case *BindingArray:
+		vars := []*Var{}
+		for _, item := range b.List {
+			vars = append(vars, bindingVars(item.Binding)...)
+		}
+		return append(vars, bindingVars(b.Rest)...)
+	case *BindingObject:
+		vars := []*Var{}
+		for _, item := range b.List {
+			vars = append(vars, bindingVars(item.Value.Binding)...)
+		}
+		return append(vars, bindingVars(b.Rest)...)
+	}
+	return nil
+}
+
+func (m *ModuleInfo) importOf(binding []byte) *ImportBinding {
+	for i, imp := range m.Imports {
+		if bytes.Equal(imp.Binding, binding) {
+			return &m.Imports[i]
+		}
+	}
+	return nil
+}
+
+type evalUsesVisitor struct {
+	m *ModuleInfo
+}
+
+func (v *evalUsesVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *FuncDecl, *MethodDecl, *ArrowFunc:
+		return nil
+	case *Field:
+		if !n.Static {
+			return nil
+		}
+	case *Var:
+		for n.Link != nil {
+			n = n.Link
+		}
+		if n.Decl == NoDecl && v.m.importOf(n.Data) != nil {
+			for _, use := range v.m.EvalUses {
+				if bytes.Equal(use, n.Data) {
+					return v
+				}
+			}
+			v.m.EvalUses = append(v.m.EvalUses, n.Data)
+		}
+	}
+	return v
+}
+
+func (v *evalUsesVisitor) Exit(n INode) {}
+
+////////////////////////////////////////////////////////////////
+
+// EarlyAccessType determines how an imported binding that is read before it is initialized behaves.
+type EarlyAccessType uint32
+
+// EarlyAccessType values.
+const (
+	TDZAccess       EarlyAccessType = iota // let, const, class, or default exported expression: throws a ReferenceError
+	UndefinedAccess                        // var: evaluates to undefined
+)
+
+// String returns the string representation of an EarlyAccessType.
+func (t EarlyAccessType) String() string {
+	switch t {
+	case TDZAccess:
+		return "TDZ"
+	case UndefinedAccess:
+		return "Undefined"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// EarlyAccess is an imported binding that is read while its module is evaluated, before the module that declares the binding has been evaluated. This happens only for import cycles.
+type EarlyAccess struct {
+	Type    EarlyAccessType
+	Module  string // module that reads the binding
+	Binding []byte // local name in Module
+
+	DeclModule  string // module that declares the binding
+	DeclBinding []byte // local name in DeclModule, nil for default exported expressions
+}
+
+// ModuleGraph is a set of modules linked by their import and export from statements.
+type ModuleGraph struct {
+	// Resolve returns the path of a module specifier imported from the module at the given path. By default, specifiers starting with ./ or ../ are joined with the directory of the importing module, and other specifiers are returned as is.
+	Resolve func(from, specifier string) string
+
+	paths   []string
+	modules map[string]*ModuleInfo
+}
+
+// NewModuleGraph returns a new empty module graph.
+func NewModuleGraph() *ModuleGraph {
+	return &ModuleGraph{
+		modules: map[string]*ModuleInfo{},
+	}
+}
+
+// Add adds a parsed module to the graph under the given path.
+func (g *ModuleGraph) Add(path string, ast *AST) *ModuleInfo {
+	m := NewModuleInfo(ast)
+	if _, ok := g.modules[path]; !ok {
+		g.paths = append(g.paths, path)
+	}
+	g.modules[path] = m
+	return m
+}
+
+// Module returns the module information of the module at the given path, or nil if it was not added.
+func (g *ModuleGraph) Module(path string) *ModuleInfo {
+	return g.modules[path]
+}
+
+func (g *ModuleGraph) resolve(from string, specifier []byte) string {
+	if g.Resolve != nil {
+		return g.Resolve(from, string(specifier))
+	} else if bytes.HasPrefix(specifier, []byte("./")) || bytes.HasPrefix(specifier, []byte("../")) {
+		return path.Join(path.Dir(from), string(specifier))
+	}
+	return string(specifier)
+}
+
+// dependencies returns the paths of the requested modules that are in the graph.
+func (g *ModuleGraph) dependencies(from string) []string {
+	deps := []string{}
+	for _, request := range g.modules[from].Requests {
+		if dep := g.resolve(from, request); g.modules[dep] != nil {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// Cycles returns the import cycles as sets of modules that (indirectly) import each other, including modules that import themselves. Modules within a cycle are in the order they were added.
+func (g *ModuleGraph) Cycles() [][]string {
+	// Tarjan's strongly connected components algorithm
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	cycles := [][]string{}
+
+	var connect func(string)
+	connect = func(v string) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		selfLoop := false
+		for _, w := range g.dependencies(v) {
+			if w == v {
+				selfLoop = true
+			}
+			if _, ok := index[w]; !ok {
+				connect(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] && index[w] < lowlink[v] {
+				lowlink[v] = index[w]
+			}
+		}
+
+		if lowlink[v] == index[v] {
+			i := len(stack) - 1
+			for stack[i] != v {
+				i--
+			}
+			cycle := append([]string{}, stack[i:]...)
+			stack = stack[:i]
+			for _, w := range cycle {
+				onStack[w] = false
+			}
+			if 1 < len(cycle) || selfLoop {
+				cycles = append(cycles, cycle)
+			}
+		}
+	}
+
+	order := map[string]int{}
+	for i, path := range g.paths {
+		order[path] = i
+	}
+	for _, path := range g.paths {
+		if _, ok := index[path]; !ok {
+			connect(path)
+		}
+	}
+	for _, cycle := range cycles {
+		sort.Slice(cycle, func(i, j int) bool { return order[cycle[i]] < order[cycle[j]] })
+	}
+	sort.Slice(cycles, func(i, j int) bool { return order[cycles[i][0]] < order[cycles[j][0]] })
+	return cycles
+}
+
+// EvaluationOrder returns the order in which modules are evaluated when the entry modules are evaluated in order. If no entries are given, all modules are used as entries in the order they were added.
+func (g *ModuleGraph) EvaluationOrder(entries ...string) []string {
+	if len(entries) == 0 {
+		entries = g.paths
+	}
+	visited := map[string]bool{}
+	order := []string{}
+	var evaluate func(string)
+	evaluate = func(v string) {
+		visited[v] = true
+		for _, w := range g.dependencies(v) {
+			if !visited[w] {
+				evaluate(w)
+			}
+		}
+		order = append(order, v)
+	}
+	for _, entry := range entries {
+		if !visited[entry] && g.modules[entry] != nil {
+			evaluate(entry)
+		}
+	}
+	return order
+}
+
+// resolveExport returns the module and export that declares the exported name, following re-exports and exported imports.
+func (g *ModuleGraph) resolveExport(from string, name []byte, visited map[string]bool) (string, *ExportBinding) {
+	m := g.modules[from]
+	if m == nil || visited[from] {
+		return "", nil
+	}
+	visited[from] = true
+	defer delete(visited, from)
+
+	for i, export := range m.Exports {
+		if !bytes.Equal(export.Name, name) {
+			continue
+		} else if export.Module != nil {
+			if bytes.Equal(export.Import, starBytes) {
+				return from, &m.Exports[i] // namespace object
+			}
+			return g.resolveExport(g.resolve(from, export.Module), export.Import, visited)
+		} else if imp := m.importOf(export.Binding); imp != nil {
+			if bytes.Equal(imp.Name, starBytes) {
+				return "", nil
+			}
+			return g.resolveExport(g.resolve(from, imp.Module), imp.Name, visited)
+		}
+		return from, &m.Exports[i]
+	}
+	if !bytes.Equal(name, defaultBytes) {
+		for _, export := range m.Exports {
+			if export.Module != nil && bytes.Equal(export.Name, starBytes) {
+				if path, decl := g.resolveExport(g.resolve(from, export.Module), name, visited); decl != nil {
+					return path, decl
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// EarlyAccesses returns the imported bindings that are read before they are initialized when the entry modules are evaluated in order, see EvaluationOrder. Bindings declared by function declarations are hoisted and never reported.
+func (g *ModuleGraph) EarlyAccesses(entries ...string) []EarlyAccess {
+	order := g.EvaluationOrder(entries...)
+	position := map[string]int{}
+	for i, path := range order {
+		position[path] = i
+	}
+
+	accesses := []EarlyAccess{}
+	for _, path := range order {
+		m := g.modules[path]
+		for _, use := range m.EvalUses {
+			imp := m.importOf(use)
+			if bytes.Equal(imp.Name, starBytes) {
+				continue
+			}
+			declPath, export := g.resolveExport(g.resolve(path, imp.Module), imp.Name, map[string]bool{})
+			if export == nil || position[declPath] <= position[path] {
+				continue
+			}
+			access := EarlyAccess{
+				Module:      path,
+				Binding:     use,
+				DeclModule:  declPath,
+				DeclBinding: export.Binding,
+			}
+			if export.Decl == VariableDecl {
+				access.Type = UndefinedAccess
+			} else if export.Decl == LexicalDecl {
+				access.Type = TDZAccess
+			} else {
+				continue
+			}
+			accesses = append(accesses, access)
+		}
+	}
+	return accesses
+}
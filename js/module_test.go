@@ -0,0 +1,143 @@
+package js
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func mustParseModule(t *testing.T, js string) *AST {
+	t.Helper()
+	ast, err := Parse(parse.NewInputString(js), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ast
+}
+
+func TestModuleInfo(t *testing.T) {
+	var tests = []struct {
+		js       string
+		requests string
+		imports  string
+		exports  string
+		evalUses string
+	}{
+		{`import "./a"`, "./a", "", "", ""},
+		{`import a, {b, c as d} from './a'; import * as ns from "./b"`, "./a ./b", "default=a@./a b=b@./a c=d@./a *=ns@./b", "", ""},
+		{`import {a} from './a'; import {b} from './a'`, "./a", "a=a@./a b=b@./a", "", ""},
+		{`export var a = 1, {b, c: [d]} = {}; export let e; export const f = 2`, "", "", "a=a:VariableDecl b=b:VariableDecl d=d:VariableDecl e=e:LexicalDecl f=f:LexicalDecl", ""},
+		{`export function f(){}; export class C{}`, "", "", "f=f:FunctionDecl C=C:LexicalDecl", ""},
+		{`export default function(){}`, "", "", "default=:FunctionDecl", ""},
+		{`export default class C{}`, "", "", "default=C:LexicalDecl", ""},
+		{`export default 5`, "", "", "default=:LexicalDecl", ""},
+		{`var a; function b(){}; export {a, b as c}`, "", "", "a=a:VariableDecl c=b:FunctionDecl", ""},
+		{`export * from './a'; export * as ns from './b'; export {x as y, z} from './c'`, "./a ./b ./c", "", "*=*@./a ns=*@./b y=x@./c z=z@./c", ""},
+		{`import {a, b, c, d, e} from './a'; a(); function f(){ b }; const g = () => c; class C { x = d; static y = e }`, "./a", "a=a@./a b=b@./a c=c@./a d=d@./a e=e@./a", "", "a e"},
+		{`import {a} from './a'; { let a; a }`, "./a", "a=a@./a", "", ""},
+		{`import {a} from './a'; export default a + a`, "./a", "a=a@./a", "default=:LexicalDecl", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			m := NewModuleInfo(mustParseModule(t, tt.js))
+
+			requests := []string{}
+			for _, request := range m.Requests {
+				requests = append(requests, string(request))
+			}
+			test.String(t, strings.Join(requests, " "), tt.requests, "requests")
+
+			imports := []string{}
+			for _, imp := range m.Imports {
+				imports = append(imports, string(imp.Name)+"="+string(imp.Binding)+"@"+string(imp.Module))
+			}
+			test.String(t, strings.Join(imports, " "), tt.imports, "imports")
+
+			exports := []string{}
+			for _, export := range m.Exports {
+				if export.Module != nil {
+					exports = append(exports, string(export.Name)+"="+string(export.Import)+"@"+string(export.Module))
+				} else {
+					exports = append(exports, string(export.Name)+"="+string(export.Binding)+":"+export.Decl.String())
+				}
+			}
+			test.String(t, strings.Join(exports, " "), tt.exports, "exports")
+
+			uses := []string{}
+			for _, use := range m.EvalUses {
+				uses = append(uses, string(use))
+			}
+			test.String(t, strings.Join(uses, " "), tt.evalUses, "evaluation uses")
+		})
+	}
+}
+
+func newTestModuleGraph(t *testing.T, modules ...string) *ModuleGraph {
+	g := NewModuleGraph()
+	for i := 0; i < len(modules); i += 2 {
+		g.Add(modules[i], mustParseModule(t, modules[i+1]))
+	}
+	return g
+}
+
+func TestModuleGraphCycles(t *testing.T) {
+	g := newTestModuleGraph(t,
+		"src/main.js", `import "./a.js"; import "./lib/c.js"; import "lodash"`,
+		"src/a.js", `export * from "./b.js"`,
+		"src/b.js", `import {x} from "./a.js"`,
+		"src/lib/c.js", `import "./c.js"`,
+		"src/lib/d.js", `import "../main.js"`,
+	)
+	test.T(t, g.Module("src/a.js") != nil, true)
+	test.T(t, g.Module("lodash") == nil, true)
+	test.T(t, fmt.Sprint(g.Cycles()), "[[src/a.js src/b.js] [src/lib/c.js]]")
+	test.T(t, fmt.Sprint(g.EvaluationOrder("src/main.js")), "[src/b.js src/a.js src/lib/c.js src/main.js]")
+	test.T(t, fmt.Sprint(g.EvaluationOrder()), "[src/b.js src/a.js src/lib/c.js src/main.js src/lib/d.js]")
+
+	g.Resolve = func(from, specifier string) string {
+		return strings.TrimPrefix(specifier, "./")
+	}
+	test.T(t, fmt.Sprint(g.Cycles()), "[]")
+}
+
+func TestModuleGraphEarlyAccesses(t *testing.T) {
+	g := newTestModuleGraph(t,
+		"main.js", `import {A} from "./a.js"; console.log(A)`,
+		"a.js", `import {B, b, f, later} from "./b.js"; export class A extends B {}; console.log(b, f()); export function g() { return later }`,
+		"b.js", `import {A} from "./a.js"; export const B = class {}; export var b = 1; export function f() {}; export let later; export {A as Again}`,
+	)
+	accesses := g.EarlyAccesses("main.js")
+	test.T(t, len(accesses), 0) // b.js evaluates before a.js
+
+	accesses = g.EarlyAccesses("b.js")
+	test.T(t, len(accesses), 2)
+	test.T(t, accesses[0].Type, TDZAccess)
+	test.String(t, accesses[0].Module, "a.js")
+	test.String(t, string(accesses[0].Binding), "B")
+	test.String(t, accesses[0].DeclModule, "b.js")
+	test.String(t, string(accesses[0].DeclBinding), "B")
+	test.T(t, accesses[1].Type, UndefinedAccess)
+	test.String(t, string(accesses[1].Binding), "b")
+
+	// re-exports and export * are followed to the declaring module
+	g = newTestModuleGraph(t,
+		"a.js", `import {x, y} from "./b.js"; x; y`,
+		"b.js", `import "./a.js"; export * from "./c.js"; export {z as y} from "./c.js"`,
+		"c.js", `import "./a.js"; export let x, z`,
+	)
+	accesses = g.EarlyAccesses("c.js")
+	test.T(t, len(accesses), 2)
+	test.String(t, accesses[0].DeclModule, "c.js")
+	test.String(t, string(accesses[0].DeclBinding), "x")
+	test.String(t, string(accesses[1].DeclBinding), "z")
+
+	// coverage
+	for i := 0; ; i++ {
+		if EarlyAccessType(i).String() == fmt.Sprintf("Invalid(%d)", i) {
+			break
+		}
+	}
+}
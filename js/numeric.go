@@ -0,0 +1,215 @@
+package js
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberLiteral returns the value of a Number literal, such as 1e3, .5, 0x3e8, or 1_000, following the rounding of JS. Values that are too large are infinite. It returns false for BigInt literals, legacy octal literals, and invalid literals.
+func NumberLiteral(b []byte) (float64, bool) {
+	if base, digits, bigint := splitNumericLiteral(b); bigint || digits == nil {
+		return 0, false
+	} else if base != 10 {
+		x, _ := new(big.Int).SetString(string(digits), base)
+		f, _ := new(big.Float).SetInt(x).Float64() // round to nearest, ties to even
+		return f, true
+	} else {
+		f, err := strconv.ParseFloat(string(digits), 64)
+		if err != nil && !math.IsInf(f, 0) {
+			return 0, false
+		}
+		return f, true
+	}
+}
+
+// BigIntLiteral returns the value of a BigInt literal, such as 1000n or 0x3e8n. It returns false for Number literals and invalid literals.
+func BigIntLiteral(b []byte) (*big.Int, bool) {
+	base, digits, bigint := splitNumericLiteral(b)
+	if !bigint || digits == nil {
+		return nil, false
+	}
+	return new(big.Int).SetString(string(digits), base)
+}
+
+// CompareNumericLiterals compares the mathematical values of two Number or BigInt literals, so that 1e3, 1000, 0x3e8, and 1000n are all equal. It returns -1, 0, or +1, and false if either literal is invalid.
+func CompareNumericLiterals(a, b []byte) (int, bool) {
+	x, xinf, ok := numericValue(a)
+	if !ok {
+		return 0, false
+	}
+	y, yinf, ok := numericValue(b)
+	if !ok {
+		return 0, false
+	} else if xinf || yinf {
+		if xinf == yinf {
+			return 0, true
+		} else if xinf {
+			return 1, true
+		}
+		return -1, true
+	}
+	return x.Cmp(y), true
+}
+
+// EqualNumericLiterals returns true if both literals are of the same type, Number or BigInt, and have the same value, i.e. they are interchangeable in JS.
+func EqualNumericLiterals(a, b []byte) bool {
+	_, _, abig := splitNumericLiteral(a)
+	_, _, bbig := splitNumericLiteral(b)
+	cmp, ok := CompareNumericLiterals(a, b)
+	return ok && abig == bbig && cmp == 0
+}
+
+// CanonicalNumericLiteral returns the shortest literal with the same type and value, see AppendNumber and AppendBigInt. It returns false for invalid literals.
+func CanonicalNumericLiteral(b []byte) ([]byte, bool) {
+	if x, ok := BigIntLiteral(b); ok {
+		return AppendBigInt(nil, x), true
+	} else if f, ok := NumberLiteral(b); ok {
+		return AppendNumber(nil, f), true
+	}
+	return nil, false
+}
+
+// AppendNumber appends the shortest JS literal that evaluates to f, choosing between decimal notation without a leading zero (.5), exponent notation (1e3), and hexadecimal notation for integers (0x1f4e8). Ties prefer decimal over exponent over hexadecimal notation. Infinity is written as 1e999 and NaN as NaN, while negative numbers are prefixed by a minus sign.
+func AppendNumber(dst []byte, f float64) []byte {
+	if math.IsNaN(f) {
+		return append(dst, "NaN"...)
+	} else if math.Signbit(f) {
+		dst = append(dst, '-')
+		f = -f
+	}
+	if math.IsInf(f, 0) {
+		return append(dst, "1e999"...)
+	} else if f == 0 {
+		return append(dst, '0')
+	}
+
+	// f = digits × 10^exp
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(s, 'e')
+	digits := strings.Replace(s[:i], ".", "", 1)
+	exp, _ := strconv.Atoi(s[i+1:])
+	exp -= len(digits) - 1
+
+	var num string
+	if 0 <= exp {
+		num = digits + strings.Repeat("0", exp)
+	} else if -exp < len(digits) {
+		num = digits[:len(digits)+exp] + "." + digits[len(digits)+exp:]
+	} else {
+		num = "." + strings.Repeat("0", -exp-len(digits)) + digits
+	}
+	if exp != 0 {
+		if e := digits + "e" + strconv.Itoa(exp); len(e) < len(num) {
+			num = e
+		}
+	}
+	if 0 <= exp {
+		x, _ := new(big.Float).SetFloat64(f).Int(nil)
+		if hex := "0x" + x.Text(16); len(hex) < len(num) {
+			num = hex
+		}
+	}
+	return append(dst, num...)
+}
+
+// AppendBigInt appends the shortest BigInt literal that evaluates to x, in decimal or hexadecimal notation. Negative numbers are prefixed by a minus sign.
+func AppendBigInt(dst []byte, x *big.Int) []byte {
+	if x.Sign() < 0 {
+		dst = append(dst, '-')
+		x = new(big.Int).Neg(x)
+	}
+	num := x.Text(10)
+	if hex := "0x" + x.Text(16); len(hex) < len(num) {
+		num = hex
+	}
+	return append(append(dst, num...), 'n')
+}
+
+// numericValue returns the exact value of a Number or BigInt literal, or true if it is an infinite Number.
+func numericValue(b []byte) (*big.Rat, bool, bool) {
+	if x, ok := BigIntLiteral(b); ok {
+		return new(big.Rat).SetInt(x), false, true
+	} else if f, ok := NumberLiteral(b); !ok {
+		return nil, false, false
+	} else if math.IsInf(f, 0) {
+		return nil, true, true
+	} else {
+		return new(big.Rat).SetFloat64(f), false, true
+	}
+}
+
+// splitNumericLiteral returns the base and the digits of a numeric literal without prefix, suffix, and separators, and whether it is a BigInt. Digits is nil for invalid literals.
+func splitNumericLiteral(b []byte) (int, []byte, bool) {
+	base := 10
+	if 2 < len(b) && b[0] == '0' {
+		switch b[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			b = b[2:]
+		}
+	}
+	bigint := 0 < len(b) && b[len(b)-1] == 'n'
+	if bigint {
+		b = b[:len(b)-1]
+	}
+
+	i := scanDigits(b, 0, base)
+	if base == 10 {
+		if 1 < i && b[0] == '0' {
+			return base, nil, bigint // legacy octal and leading zeros
+		}
+		intEnd := i
+		if i < len(b) && b[i] == '.' && !bigint {
+			i = scanDigits(b, i+1, base)
+			if intEnd == 0 && i == 1 {
+				return base, nil, bigint
+			}
+		} else if intEnd == 0 {
+			return base, nil, bigint
+		}
+		if i < len(b) && (b[i] == 'e' || b[i] == 'E') && !bigint {
+			i++
+			if i < len(b) && (b[i] == '+' || b[i] == '-') {
+				i++
+			}
+			expStart := i
+			if i = scanDigits(b, i, base); i == expStart {
+				return base, nil, bigint
+			}
+		}
+	}
+	if i == 0 || i != len(b) {
+		return base, nil, bigint
+	}
+	return base, bytes.ReplaceAll(b, []byte("_"), nil), bigint
+}
+
+// scanDigits returns the index after the digits and numeric separators starting at i, where separators must be between digits.
+func scanDigits(b []byte, i, base int) int {
+	start := i
+	for i < len(b) && (isDigit(b[i], base) || b[i] == '_' && start < i && i+1 < len(b) && isDigit(b[i+1], base)) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte, base int) bool {
+	switch base {
+	case 2:
+		return c == '0' || c == '1'
+	case 8:
+		return '0' <= c && c <= '7'
+	case 16:
+		return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+	}
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,162 @@
+package js
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestNumberLiteral(t *testing.T) {
+	var tests = []struct {
+		lit      string
+		expected float64
+		ok       bool
+	}{
+		{"0", 0, true},
+		{"1000", 1000, true},
+		{"1e3", 1000, true},
+		{"1E+3", 1000, true},
+		{"0x3e8", 1000, true},
+		{"0X3E8", 1000, true},
+		{"0o1750", 1000, true},
+		{"0b1111101000", 1000, true},
+		{"1_000", 1000, true},
+		{".5", 0.5, true},
+		{"5.", 5, true},
+		{"5e-1", 0.5, true},
+		{"0.1", 0.1, true},
+		{"1e999", math.Inf(1), true},
+		{"0x20000000000001", 9007199254740992, true}, // ties to even
+		{"1000n", 0, false},
+		{"017", 0, false},
+		{"08", 0, false},
+		{"1__0", 0, false},
+		{"1_", 0, false},
+		{"_1", 0, false},
+		{"1_.5", 0, false},
+		{"0x_1", 0, false},
+		{"0x", 0, false},
+		{".", 0, false},
+		{"1e", 0, false},
+		{"1e+", 0, false},
+		{"0b2", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			f, ok := NumberLiteral([]byte(tt.lit))
+			test.T(t, ok, tt.ok)
+			test.T(t, f, tt.expected)
+		})
+	}
+}
+
+func TestBigIntLiteral(t *testing.T) {
+	var tests = []struct {
+		lit      string
+		expected string
+	}{
+		{"0n", "0"},
+		{"1000n", "1000"},
+		{"0x3e8n", "1000"},
+		{"1_000n", "1000"},
+		{"0xffffffffffffffffn", "18446744073709551615"},
+		{"1000", ""},
+		{"1.5n", ""},
+		{"1e3n", ""},
+		{"01n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			x, ok := BigIntLiteral([]byte(tt.lit))
+			if tt.expected == "" {
+				test.That(t, !ok, "invalid")
+			} else {
+				test.That(t, ok, "valid")
+				test.String(t, x.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareNumericLiterals(t *testing.T) {
+	var tests = []struct {
+		a, b     string
+		expected int
+		equal    bool
+	}{
+		{"1e3", "1000", 0, true},
+		{"1000", "0x3e8", 0, true},
+		{"1000n", "0x3e8n", 0, true},
+		{"1000", "1000n", 0, false},
+		{"0.1", "1n", -1, false},
+		{"9007199254740993", "9007199254740992n", 0, false}, // rounded Number
+		{"9007199254740993n", "9007199254740992", 1, false},
+		{"1e999", "1e400", 0, true},
+		{"1e999", "1000000n", 1, false},
+		{"1n", "1e999", -1, false},
+		{"2", "1_0", -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			cmp, ok := CompareNumericLiterals([]byte(tt.a), []byte(tt.b))
+			test.That(t, ok, "valid")
+			test.T(t, cmp, tt.expected)
+			test.T(t, EqualNumericLiterals([]byte(tt.a), []byte(tt.b)), tt.equal)
+		})
+	}
+
+	_, ok := CompareNumericLiterals([]byte("1"), []byte("01"))
+	test.That(t, !ok, "invalid")
+}
+
+func TestCanonicalNumericLiteral(t *testing.T) {
+	var tests = []struct {
+		lit      string
+		expected string
+	}{
+		{"0", "0"},
+		{"0.0", "0"},
+		{"1", "1"},
+		{"100", "100"},
+		{"1000", "1e3"},
+		{"0x3e8", "1e3"},
+		{"1_000_000", "1e6"},
+		{"1234500", "1234500"},
+		{"0.5", ".5"},
+		{"0.05", ".05"},
+		{"0.005", ".005"},
+		{"0.0005", "5e-4"},
+		{"1.50", "1.5"},
+		{"12e-1", "1.2"},
+		{"0.1e-5", "1e-6"},
+		{"1e999", "1e999"},
+		{"1e21", "1e21"},
+		{"123456789012", "123456789012"},
+		{"0xffffffff", "4294967295"},
+		{"0x1fffffffffffff", "9007199254740991"},
+		{"281474976710655", "0xffffffffffff"},
+		{"0x10000000000000000", "18446744073709552e3"},
+		{"1000n", "1000n"},
+		{"0x3e8n", "1000n"},
+		{"0xffffffffffffffffn", "0xffffffffffffffffn"},
+		{"100000000000000000000n", "0x56bc75e2d63100000n"},
+		{"017", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			b, ok := CanonicalNumericLiteral([]byte(tt.lit))
+			test.T(t, ok, tt.expected != "")
+			test.String(t, string(b), tt.expected)
+		})
+	}
+}
+
+func TestAppendNumber(t *testing.T) {
+	test.String(t, string(AppendNumber(nil, -0.5)), "-.5")
+	test.String(t, string(AppendNumber(nil, math.Inf(-1))), "-1e999")
+	test.String(t, string(AppendNumber(nil, math.NaN())), "NaN")
+	test.String(t, string(AppendNumber([]byte("x="), 1e100)), "x=1e100")
+	test.String(t, string(AppendBigInt(nil, big.NewInt(-255))), "-255n")
+}
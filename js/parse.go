@@ -7,22 +7,24 @@ import (
 	"io"
 
 	"github.com/politepixels/tdewolff-parse/v2"
-	"github.com/politepixels/tdewolff-parse/v2/buffer"
 )
 
 var NestedStmtLimit = 1000
 var NestedExprLimit = 1000
 
 type Options struct {
-	WhileToFor bool
-	Inline     bool
+	WhileToFor  bool
+	Inline      bool
+	Experiments Experiments        // experimental syntax of ECMAScript proposals
+	Diagnostics *parse.Diagnostics // recover from parse errors and collect them, see Parse
 }
 
 // Parser is the state for the parser.
 type Parser struct {
-	l   *Lexer
-	o   Options
-	err error
+	l     *Lexer
+	o     Options
+	err   error
+	errTT TokenType // token at which the parser failed
 
 	data                           []byte
 	tt                             TokenType
@@ -35,21 +37,46 @@ type Parser struct {
 	stmtLevel int
 	exprLevel int
 
-	scope *Scope
+	topicLevel int // number of enclosing pipeline bodies
+	topicUses  int // number of topic references in the innermost pipeline body
+
+	start       int // offset of the current token
+	prevStart   int // offset of the previous token
+	prevEnd     int // offset of the end of the previous token
+	assignStart int // offset of the right-hand side of the last parsed assignment
+
+	scope     *Scope
+	funcLevel int // number of enclosing function bodies and class static blocks
+
+	braces int // number of open braces before the current token, including the current token
+	resync int // offset of the token at which the last recovery from an error stopped
+
+	reportFeatures bool
+	features       []FeatureUse
+
+	annotations *AnnotationRegistry
+	annotated   []pendingAnnotation
 }
 
-// Parse returns a JS AST tree of.
+// Parse returns a JS AST tree of. When o.Diagnostics is set, the parser recovers from a parse error by reporting it and skipping the failed statement up to its end, which is the next semicolon, the next line, or the closing brace of the enclosing block, and parsing continues with the next statement. The returned AST then leaves out the failed statements and the error is nil.
 func Parse(r *parse.Input, o Options) (*AST, error) {
-	ast := &AST{}
-	p := &Parser{
-		l:     NewLexer(r),
-		o:     o,
-		tt:    WhitespaceToken, // trick so that next() works
-		in:    true,
-		await: true,
+	return newParser(r, o).parse(r)
+}
+
+func newParser(r *parse.Input, o Options) *Parser {
+	return &Parser{
+		l:      NewLexer(r),
+		o:      o,
+		tt:     WhitespaceToken, // trick so that next() works
+		in:     true,
+		await:  true,
+		resync: -1,
 	}
+}
 
-	if o.Inline {
+func (p *Parser) parse(r *parse.Input) (*AST, error) {
+	ast := &AST{}
+	if p.o.Inline {
 		p.next()
 		p.retrn = true
 		p.allowDirectivePrologue = true
@@ -58,7 +85,14 @@ func Parse(r *parse.Input, o Options) (*AST, error) {
 			if p.tt == ErrorToken {
 				break
 			}
-			ast.BlockStmt.List = append(ast.BlockStmt.List, p.parseStmt(true))
+			state := p.state()
+			stmt := p.parseStmt(true)
+			p.assertProgress(state.start, "statement")
+			if p.err != nil {
+				p.recover(state)
+				continue
+			}
+			ast.BlockStmt.List = append(ast.BlockStmt.List, stmt)
 		}
 	} else {
 		// catch shebang in first line
@@ -67,6 +101,7 @@ func Parse(r *parse.Input, o Options) (*AST, error) {
 			r.Move(2)
 			p.l.consumeSingleLineComment() // consume till end-of-line
 			shebang = r.Shift()
+			p.featureSpan(HashbangFeature, Span{0, len(shebang)})
 		}
 
 		// parse JS module
@@ -79,8 +114,10 @@ func Parse(r *parse.Input, o Options) (*AST, error) {
 	}
 
 	if p.err != nil {
-		offset := p.l.r.Offset() - len(p.data)
-		return nil, parse.NewError(buffer.NewReader(p.l.r.Bytes()), offset, p.err.Error())
+		if p.o.Diagnostics == nil {
+			return nil, p.error()
+		}
+		p.o.Diagnostics.Add(p.error())
 	} else if p.l.Err() != nil && p.l.Err() != io.EOF {
 		return nil, p.l.Err()
 	}
@@ -90,7 +127,12 @@ func Parse(r *parse.Input, o Options) (*AST, error) {
 ////////////////////////////////////////////////////////////////
 
 func (p *Parser) next() {
+	if p.err != nil {
+		return // keep ErrorToken after a failure so that all loops terminate
+	}
 	p.prevLT = false
+	p.prevStart = p.start
+	p.prevEnd = p.l.r.Offset()
 	p.tt, p.data = p.l.Next()
 Loop:
 	for {
@@ -103,6 +145,9 @@ Loop:
 			if 2 < len(p.data) && p.data[2] == '!' {
 				p.comments = append(p.comments, &Comment{p.data})
 			}
+			if p.annotations != nil {
+				p.readAnnotations()
+			}
 			if p.tt == CommentLineTerminatorToken {
 				p.prevLT = true
 			}
@@ -111,15 +156,56 @@ Loop:
 		}
 		p.tt, p.data = p.l.Next()
 	}
+	if p.tt == OpenBraceToken {
+		p.braces++
+	} else if p.tt == CloseBraceToken {
+		p.braces--
+	}
+	p.start = p.l.r.Offset() - len(p.data)
+	for i := len(p.annotated) - 1; 0 <= i && p.annotated[i].at == -1; i-- {
+		p.annotated[i].at = p.start
+	}
+}
+
+// feature records the use of a feature by the current token when features are reported.
+func (p *Parser) feature(f Feature) {
+	if p.reportFeatures {
+		p.features = append(p.features, FeatureUse{f, Span{p.start, p.start + len(p.data)}})
+	}
+}
+
+// featureSpan records the use of a feature by a span when features are reported.
+func (p *Parser) featureSpan(f Feature, span Span) {
+	if p.reportFeatures {
+		p.features = append(p.features, FeatureUse{f, span})
+	}
+}
+
+// literal returns a literal expression of the given type for the current token.
+func (p *Parser) literal(tt TokenType) LiteralExpr {
+	return LiteralExpr{tt, p.data, Span{p.start, p.start + len(p.data)}}
+}
+
+// prevSpan returns the span of the previous token.
+func (p *Parser) prevSpan() Span {
+	return Span{p.prevStart, p.prevEnd}
 }
 
 func (p *Parser) failMessage(msg string, args ...interface{}) {
 	if p.err == nil {
 		p.err = fmt.Errorf(msg, args...)
+		p.errTT = p.tt
 		p.tt = ErrorToken
 	}
 }
 
+// assertProgress fails if the parser has not moved past the token at offset start, so that loops over malformed input cannot run forever.
+func (p *Parser) assertProgress(start int, in string) {
+	if p.tt != ErrorToken && p.start == start {
+		p.fail(in)
+	}
+}
+
 func (p *Parser) fail(in string, expected ...TokenType) {
 	if p.err == nil {
 		msg := "unexpected"
@@ -155,10 +241,76 @@ func (p *Parser) fail(in string, expected ...TokenType) {
 		}
 
 		p.err = errors.New(msg)
+		p.errTT = p.tt
 		p.tt = ErrorToken
 	}
 }
 
+// error returns the parse error at the current token.
+func (p *Parser) error() *parse.Error {
+	offset := p.l.r.Offset() - len(p.data)
+	return parse.NewError(p.l.r, offset, p.err.Error())
+}
+
+// parserState is the state of the parser before a statement, which is restored when recovering from an error in that statement.
+type parserState struct {
+	start, braces, comments                                int
+	scope                                                  *Scope
+	in, await, yield, deflt, retrn, assumeArrowFunc        bool
+	stmtLevel, exprLevel, topicLevel, topicUses, funcLevel int
+}
+
+func (p *Parser) state() parserState {
+	braces := p.braces
+	if p.tt == OpenBraceToken {
+		braces--
+	}
+	return parserState{
+		start:           p.start,
+		braces:          braces,
+		comments:        len(p.comments),
+		scope:           p.scope,
+		in:              p.in,
+		await:           p.await,
+		yield:           p.yield,
+		deflt:           p.deflt,
+		retrn:           p.retrn,
+		assumeArrowFunc: p.assumeArrowFunc,
+		stmtLevel:       p.stmtLevel,
+		exprLevel:       p.exprLevel,
+		topicLevel:      p.topicLevel,
+		topicUses:       p.topicUses,
+		funcLevel:       p.funcLevel,
+	}
+}
+
+// recover recovers from an error in the statement that started with state s when collecting diagnostics, after the failed statement has been dropped. It reports the error, restores the state, and skips tokens up to the end of the statement: past the next semicolon, or up to the next token on a new line or the closing brace of the enclosing block. An error at the token where the last recovery stopped is not reported again, as it is the same problem. Otherwise, the error stops the parser.
+func (p *Parser) recover(s parserState) {
+	if p.o.Diagnostics == nil || p.errTT == ErrorToken || p.o.Diagnostics.Full() {
+		return
+	}
+	if p.start != p.resync {
+		p.o.Diagnostics.Add(p.error())
+	}
+	p.err = nil
+	p.tt = p.errTT
+	p.comments = p.comments[:s.comments]
+	p.scope = s.scope
+	p.in, p.await, p.yield, p.deflt, p.retrn, p.assumeArrowFunc = s.in, s.await, s.yield, s.deflt, s.retrn, s.assumeArrowFunc
+	p.stmtLevel, p.exprLevel, p.topicLevel, p.topicUses, p.funcLevel = s.stmtLevel, s.exprLevel, s.topicLevel, s.topicUses, s.funcLevel
+	for p.tt != ErrorToken {
+		if p.start != s.start && (p.braces < s.braces || p.braces == s.braces && p.prevLT && p.tt != CloseBraceToken) {
+			break
+		}
+		semicolon := p.tt == SemicolonToken && p.braces == s.braces
+		p.next()
+		if semicolon {
+			break
+		}
+	}
+	p.resync = p.start
+}
+
 func (p *Parser) consume(in string, tt TokenType) bool {
 	if p.tt != tt {
 		p.fail(in, tt)
@@ -192,44 +344,62 @@ func (p *Parser) parseModule() (module BlockStmt) {
 	p.enterScope(&module.Scope, true)
 	p.allowDirectivePrologue = true
 	for {
+		state := p.state()
+		n := len(module.List)
 		switch p.tt {
 		case ErrorToken:
 			if 0 < len(p.comments) {
 				module.List = append(p.comments, module.List...)
-				p.comments = p.comments[:0]
+				p.comments = nil // owned by the module
 			}
 			return
 		case ImportToken:
+			start := p.start
 			p.next()
 			if p.tt == OpenParenToken {
 				// could be an import call expression
-				left := &LiteralExpr{ImportToken, []byte("import")}
+				p.featureSpan(DynamicImportFeature, p.prevSpan())
+				left := &LiteralExpr{ImportToken, []byte("import"), p.prevSpan()}
 				p.exprLevel++
-				expr := p.parseExpressionSuffix(left, OpExpr, OpCall)
+				expr := p.parseExpressionSuffix(left, start, OpExpr, OpCall)
 				p.exprLevel--
 				module.List = append(module.List, &ExprStmt{expr})
 				if !p.prevLT && p.tt == SemicolonToken {
 					p.next()
 				}
 			} else if p.tt == DotToken {
+				span := p.prevSpan()
 				p.next()
+				span.End = p.start + len(p.data)
 				if !p.consume("import.meta expression", MetaToken) {
-					return module
+					break
 				}
+				p.featureSpan(ImportMetaFeature, span)
 				left := &ImportMetaExpr{}
 				p.exprLevel++
-				expr := p.parseExpressionSuffix(left, OpExpr, OpMember)
+				expr := p.parseExpressionSuffix(left, start, OpExpr, OpMember)
 				p.exprLevel--
 				module.List = append(module.List, &ExprStmt{expr})
 			} else {
+				p.featureSpan(ModuleFeature, p.prevSpan())
 				importStmt := p.parseImportStmt()
 				module.List = append(module.List, &importStmt)
+				p.annotate(start, &importStmt)
 			}
 		case ExportToken:
+			p.feature(ModuleFeature)
+			start := p.start
 			exportStmt := p.parseExportStmt()
 			module.List = append(module.List, &exportStmt)
+			p.annotate(start, &exportStmt)
 		default:
+			start := p.start
 			module.List = append(module.List, p.parseStmt(true))
+			p.assertProgress(start, "statement")
+		}
+		if p.err != nil {
+			module.List = module.List[:n]
+			p.recover(state)
 		}
 	}
 }
@@ -361,6 +531,10 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		p.next()
 		await := p.await && p.tt == AwaitToken
 		if await {
+			p.feature(AsyncIterationFeature)
+			if p.funcLevel == 0 {
+				p.feature(TopLevelAwaitFeature)
+			}
 			p.next()
 		}
 		if !p.consume("for statement", OpenParenToken) {
@@ -420,6 +594,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			}
 			stmt = &ForInStmt{init, value, body}
 		} else if isLHSExpr && p.tt == OfToken {
+			p.feature(ForOfFeature)
 			p.next()
 			value := p.parseExpression(OpAssign)
 			if !p.consume("for statement", CloseParenToken) {
@@ -523,7 +698,14 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 
 			var stmts []IStmt
 			for p.tt != CaseToken && p.tt != DefaultToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
-				stmts = append(stmts, p.parseStmt(true))
+				state := p.state()
+				stmt := p.parseStmt(true)
+				p.assertProgress(state.start, "switch statement")
+				if p.err != nil {
+					p.recover(state)
+					continue
+				}
+				stmts = append(stmts, stmt)
 			}
 			switchStmt.List = append(switchStmt.List, CaseClause{clause, list, stmts})
 		}
@@ -569,14 +751,20 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		p.next()
 		body := p.parseBlockStmt("try statement")
 		var binding IBinding
+		var bindingSpan Span
 		var catch, finally *BlockStmt
 		if p.tt == CatchToken {
 			p.next()
+			if p.tt != OpenParenToken {
+				p.featureSpan(OptionalCatchBindingFeature, p.prevSpan())
+			}
 			catch = &BlockStmt{}
 			parent := p.enterScope(&catch.Scope, false)
 			if p.tt == OpenParenToken {
 				p.next()
+				bindingSpan.Start = p.start
 				binding = p.parseBinding(CatchDecl) // local to block scope of catch
+				bindingSpan.End = p.prevEnd
 				if !p.consume("try-catch statement", CloseParenToken) {
 					return
 				}
@@ -591,7 +779,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			p.next()
 			finally = p.parseBlockStmt("try-finally statement")
 		}
-		stmt = &TryStmt{body, binding, catch, finally}
+		stmt = &TryStmt{Body: body, Binding: binding, BindingSpan: bindingSpan, Catch: catch, Finally: finally}
 	case DebuggerToken:
 		stmt = &DebuggerStmt{}
 		p.next()
@@ -661,7 +849,14 @@ func (p *Parser) parseStmtList(in string) (list []IStmt) {
 			p.next()
 			break
 		}
-		list = append(list, p.parseStmt(true))
+		state := p.state()
+		stmt := p.parseStmt(true)
+		p.assertProgress(state.start, "statement")
+		if p.err != nil {
+			p.recover(state)
+			continue
+		}
+		list = append(list, stmt)
 	}
 	if comments < len(p.comments) {
 		list2 := make([]IStmt, 0, len(p.comments)-comments+len(list))
@@ -878,8 +1073,11 @@ func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl
 		TokenType: tt,
 		Scope:     p.scope,
 	}
+	defer p.annotate(p.prevStart, varDecl)
 	declType := LexicalDecl
-	if tt == VarToken {
+	if tt != VarToken {
+		p.featureSpan(LetConstFeature, p.prevSpan())
+	} else {
 		declType = VariableDecl
 		if canBeHoisted {
 			p.scope.Func.VarDecls = append(p.scope.Func.VarDecls, varDecl)
@@ -888,10 +1086,13 @@ func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl
 	for {
 		// binding element, var declaration in for-in or for-of can never have a default
 		var bindingElement BindingElement
+		bindingElement.Span.Start = p.start
 		bindingElement.Binding = p.parseBinding(declType)
 		if p.tt == EqToken {
 			p.next()
+			bindingElement.DefaultSpan.Start = p.start
 			bindingElement.Default = p.parseExpression(OpAssign)
+			bindingElement.DefaultSpan.End = p.prevEnd
 		} else if _, ok := bindingElement.Binding.(*Var); !ok && (p.in || 0 < len(varDecl.List)) {
 			p.fail("var statement", EqToken)
 			return
@@ -899,6 +1100,7 @@ func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl
 			p.fail("const statement", EqToken)
 		}
 
+		bindingElement.Span.End = p.prevEnd
 		varDecl.List = append(varDecl.List, bindingElement)
 		if p.tt == CommaToken {
 			p.next()
@@ -918,8 +1120,11 @@ func (p *Parser) parseFuncParams(in string) (params Params) {
 	for p.tt != CloseParenToken && p.tt != ErrorToken {
 		if p.tt == EllipsisToken {
 			// binding rest element
+			p.feature(SpreadFeature)
 			p.next()
+			params.RestSpan.Start = p.start
 			params.Rest = p.parseBinding(ArgumentDecl)
+			params.RestSpan.End = p.prevEnd
 			p.consume(in, CloseParenToken)
 			return
 		}
@@ -958,11 +1163,18 @@ func (p *Parser) parseAsyncFuncExpr() (funcDecl *FuncDecl) {
 
 func (p *Parser) parseFunc(async, expr bool) (funcDecl *FuncDecl) {
 	// assume we're at function
+	start := p.start
+	if async {
+		start = p.prevStart
+		p.featureSpan(AsyncFunctionFeature, p.prevSpan())
+	}
 	p.next()
 	funcDecl = &FuncDecl{}
+	defer p.annotate(start, funcDecl)
 	funcDecl.Async = async
 	funcDecl.Generator = p.tt == MulToken
 	if funcDecl.Generator {
+		p.generatorFeature(async)
 		p.next()
 	}
 	var ok bool
@@ -987,6 +1199,7 @@ func (p *Parser) parseFunc(async, expr bool) (funcDecl *FuncDecl) {
 	parent := p.enterScope(&funcDecl.Body.Scope, true)
 	prevAwait, prevYield, prevRetrn := p.await, p.yield, p.retrn
 	p.await, p.yield, p.retrn = funcDecl.Async, funcDecl.Generator, true
+	p.funcLevel++
 
 	if expr && name != nil {
 		funcDecl.Name, _ = p.scope.Declare(ExprDecl, name) // cannot fail
@@ -998,6 +1211,7 @@ func (p *Parser) parseFunc(async, expr bool) (funcDecl *FuncDecl) {
 	funcDecl.Body.List = p.parseStmtList("function declaration")
 	p.allowDirectivePrologue = prevAllowDirectivePrologue
 
+	p.funcLevel--
 	p.await, p.yield, p.retrn = prevAwait, prevYield, prevRetrn
 	p.exitScope(parent)
 	return
@@ -1013,8 +1227,11 @@ func (p *Parser) parseClassExpr() (classDecl *ClassDecl) {
 
 func (p *Parser) parseAnyClass(expr bool) (classDecl *ClassDecl) {
 	// assume we're at class
+	p.feature(ClassFeature)
+	start := p.start
 	p.next()
 	classDecl = &ClassDecl{}
+	defer p.annotate(start, classDecl)
 	if IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken {
 		if !expr {
 			var ok bool
@@ -1053,7 +1270,9 @@ func (p *Parser) parseAnyClass(expr bool) (classDecl *ClassDecl) {
 			break
 		}
 
+		start := p.start
 		classDecl.List = append(classDecl.List, p.parseClassElement())
+		p.assertProgress(start, "class declaration")
 	}
 	p.exitScope(parent)
 	return
@@ -1061,29 +1280,37 @@ func (p *Parser) parseAnyClass(expr bool) (classDecl *ClassDecl) {
 
 func (p *Parser) parseClassElement() ClassElement {
 	method := &MethodDecl{}
+	start := p.start
 	var data []byte // either static, async, get, or set
+	var asyncSpan, generatorSpan Span
 	if p.tt == StaticToken {
 		method.Static = true
 		data = p.data
 		p.next()
 		if p.tt == OpenBraceToken {
+			p.featureSpan(StaticBlockFeature, p.prevSpan())
 			prevYield, prevAwait, prevRetrn := p.yield, p.await, p.retrn
 			p.yield, p.await, p.retrn = false, true, false
+			p.funcLevel++
 			elem := ClassElement{StaticBlock: p.parseBlockStmt("class static block")}
+			p.funcLevel--
 			p.yield, p.await, p.retrn = prevYield, prevAwait, prevRetrn
 			return elem
 		}
 	}
 	if p.tt == MulToken {
 		method.Generator = true
+		generatorSpan = Span{p.start, p.start + 1}
 		p.next()
 	} else if p.tt == AsyncToken {
 		data = p.data
+		asyncSpan = Span{p.start, p.start + len(p.data)}
 		p.next()
 		if !p.prevLT {
 			method.Async = true
 			if p.tt == MulToken {
 				method.Generator = true
+				generatorSpan = Span{p.start, p.start + 1}
 				data = nil
 				p.next()
 			}
@@ -1101,7 +1328,7 @@ func (p *Parser) parseClassElement() ClassElement {
 	isField := false
 	if data != nil && p.tt == OpenParenToken {
 		// (static) method name is: static, async, get, or set
-		method.Name.Literal = LiteralExpr{IdentifierToken, data}
+		method.Name.Literal = LiteralExpr{IdentifierToken, data, p.prevSpan()}
 		if method.Async || method.Get || method.Set {
 			method.Async = false
 			method.Get = false
@@ -1111,13 +1338,14 @@ func (p *Parser) parseClassElement() ClassElement {
 		}
 	} else if data != nil && (p.tt == EqToken || p.tt == SemicolonToken || p.tt == CloseBraceToken) {
 		// (static) field name is: static, async, get, or set
-		method.Name.Literal = LiteralExpr{IdentifierToken, data}
+		method.Name.Literal = LiteralExpr{IdentifierToken, data, p.prevSpan()}
 		if !method.Async && !method.Get && !method.Set {
 			method.Static = false
 		}
 		isField = true
 	} else {
 		if p.tt == PrivateIdentifierToken {
+			p.feature(PrivateMemberFeature)
 			var ok bool
 			method.Name.Private, ok = p.scope.Declare(PrivateDecl, p.data)
 			if !ok {
@@ -1139,12 +1367,15 @@ func (p *Parser) parseClassElement() ClassElement {
 			p.next()
 			init = p.parseExpression(OpAssign)
 		}
+		p.featureSpan(ClassFieldFeature, Span{start, p.prevEnd})
 		return ClassElement{Field: Field{Static: method.Static, Name: method.Name, Init: init}}
 	}
+	p.methodFeatures(method, asyncSpan, generatorSpan)
 
 	parent := p.enterScope(&method.Body.Scope, true)
 	prevAwait, prevYield, prevRetrn := p.await, p.yield, p.retrn
 	p.await, p.yield, p.retrn = method.Async, method.Generator, true
+	p.funcLevel++
 
 	method.Params = p.parseFuncParams("method definition")
 
@@ -1153,6 +1384,7 @@ func (p *Parser) parseClassElement() ClassElement {
 	method.Body.List = p.parseStmtList("method function")
 	p.allowDirectivePrologue = prevAllowDirectivePrologue
 
+	p.funcLevel--
 	p.await, p.yield, p.retrn = prevAwait, prevYield, prevRetrn
 	p.exitScope(parent)
 	return ClassElement{Method: method}
@@ -1160,20 +1392,20 @@ func (p *Parser) parseClassElement() ClassElement {
 
 func (p *Parser) parsePropertyName(in string) (propertyName PropertyName) {
 	if IsIdentifierName(p.tt) {
-		propertyName.Literal = LiteralExpr{IdentifierToken, p.data}
+		propertyName.Literal = p.literal(IdentifierToken)
 		p.next()
 	} else if p.tt == StringToken {
 		// reinterpret string as identifier or number if we can, except for empty strings
 		if isIdent := AsIdentifierName(p.data[1 : len(p.data)-1]); isIdent {
-			propertyName.Literal = LiteralExpr{IdentifierToken, p.data[1 : len(p.data)-1]}
+			propertyName.Literal = LiteralExpr{IdentifierToken, p.data[1 : len(p.data)-1], Span{p.start + 1, p.start + len(p.data) - 1}}
 		} else if isNum := AsDecimalLiteral(p.data[1 : len(p.data)-1]); isNum {
-			propertyName.Literal = LiteralExpr{DecimalToken, p.data[1 : len(p.data)-1]}
+			propertyName.Literal = LiteralExpr{DecimalToken, p.data[1 : len(p.data)-1], Span{p.start + 1, p.start + len(p.data) - 1}}
 		} else {
-			propertyName.Literal = LiteralExpr{p.tt, p.data}
+			propertyName.Literal = p.literal(p.tt)
 		}
 		p.next()
 	} else if IsNumeric(p.tt) {
-		propertyName.Literal = LiteralExpr{p.tt, p.data}
+		propertyName.Literal = p.literal(p.tt)
 		p.next()
 	} else if p.tt == OpenBracketToken {
 		p.next()
@@ -1190,16 +1422,26 @@ func (p *Parser) parsePropertyName(in string) (propertyName PropertyName) {
 
 func (p *Parser) parseBindingElement(decl DeclType) (bindingElement BindingElement) {
 	// BindingElement
+	bindingElement.Span.Start = p.start
 	bindingElement.Binding = p.parseBinding(decl)
 	if p.tt == EqToken {
 		p.next()
+		bindingElement.DefaultSpan.Start = p.start
 		bindingElement.Default = p.parseExpression(OpAssign)
+		bindingElement.DefaultSpan.End = p.prevEnd
 	}
+	bindingElement.Span.End = p.prevEnd
 	return
 }
 
 func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 	// BindingIdentifier, BindingPattern
+	p.exprLevel++
+	defer func() { p.exprLevel-- }()
+	if NestedExprLimit < p.exprLevel {
+		p.failMessage("too many nested bindings")
+		return
+	}
 	if p.isIdentifierReference(p.tt) {
 		var ok bool
 		binding, ok = p.scope.Declare(decl, p.data)
@@ -1209,6 +1451,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 		}
 		p.next()
 	} else if p.tt == OpenBracketToken {
+		p.feature(DestructuringFeature)
 		p.next()
 		array := BindingArray{}
 		if p.tt == CommaToken {
@@ -1225,8 +1468,11 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			}
 			// binding rest element
 			if p.tt == EllipsisToken {
+				p.feature(SpreadFeature)
 				p.next()
+				array.RestSpan.Start = p.start
 				array.Rest = p.parseBinding(decl)
+				array.RestSpan.End = p.prevEnd
 				if p.tt != CloseBracketToken {
 					p.fail("array binding pattern", CloseBracketToken)
 					return
@@ -1248,22 +1494,25 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 		p.next() // always CloseBracketToken
 		binding = &array
 	} else if p.tt == OpenBraceToken {
+		p.feature(DestructuringFeature)
 		p.next()
 		object := BindingObject{}
 		for p.tt != CloseBraceToken {
 			// binding rest property
 			if p.tt == EllipsisToken {
+				p.feature(ObjectRestSpreadFeature)
 				p.next()
 				if !p.isIdentifierReference(p.tt) {
 					p.fail("object binding pattern", IdentifierToken)
 					return
 				}
-				var ok bool
-				object.Rest, ok = p.scope.Declare(decl, p.data)
+				rest, ok := p.scope.Declare(decl, p.data)
 				if !ok {
 					p.failMessage("identifier %s has already been declared", string(p.data))
 					return
 				}
+				object.Rest = rest
+				object.RestSpan = Span{p.start, p.start + len(p.data)}
 				p.next()
 				if p.tt != CloseBraceToken {
 					p.fail("object binding pattern", CloseBraceToken)
@@ -1275,7 +1524,8 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			item := BindingObjectItem{}
 			if p.isIdentifierReference(p.tt) {
 				name := p.data
-				item.Key = &PropertyName{LiteralExpr{IdentifierToken, p.data}, nil}
+				start := p.start
+				item.Key = &PropertyName{p.literal(IdentifierToken), nil}
 				p.next()
 				if p.tt == ColonToken {
 					// property name + : + binding element
@@ -1292,8 +1542,11 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 					}
 					if p.tt == EqToken {
 						p.next()
+						item.Value.DefaultSpan.Start = p.start
 						item.Value.Default = p.parseExpression(OpAssign)
+						item.Value.DefaultSpan.End = p.prevEnd
 					}
+					item.Value.Span = Span{start, p.prevEnd}
 				}
 			} else {
 				propertyName := p.parsePropertyName("object binding pattern")
@@ -1341,9 +1594,15 @@ func (p *Parser) parseArrayLiteral() (array ArrayExpr) {
 		} else {
 			spread := p.tt == EllipsisToken
 			if spread {
+				p.feature(SpreadFeature)
 				p.next()
 			}
-			array.List = append(array.List, Element{p.parseAssignExprOrParam(), spread})
+			element := Element{Spread: spread}
+			element.Span.Start = p.start
+			element.Value = p.parseAssignExprOrParam()
+			element.Span.End = p.prevEnd
+			element.DefaultSpan = p.defaultSpan(element.Value)
+			array.List = append(array.List, element)
 			prevComma = false
 			if spread && p.tt != CloseBracketToken {
 				p.assumeArrowFunc = false
@@ -1366,32 +1625,40 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 		}
 
 		property := Property{}
+		property.Span.Start = p.start
 		if p.tt == EllipsisToken {
+			p.feature(ObjectRestSpreadFeature)
 			p.next()
 			property.Spread = true
+			property.Span.Start = p.start
 			property.Value = p.parseAssignExprOrParam()
+			property.Span.End = p.prevEnd
 			if _, isIdent := property.Value.(*Var); !isIdent || p.tt != CloseBraceToken {
 				p.assumeArrowFunc = false
 			}
 		} else {
 			// try to parse as MethodDefinition, otherwise fall back to PropertyName:AssignExpr or IdentifierReference
 			var data []byte
+			var asyncSpan, generatorSpan Span
 			method := MethodDecl{}
 			if p.tt == MulToken {
+				generatorSpan = Span{p.start, p.start + 1}
 				p.next()
 				method.Generator = true
 			} else if p.tt == AsyncToken {
 				data = p.data
+				asyncSpan = Span{p.start, p.start + len(p.data)}
 				p.next()
 				if !p.prevLT {
 					method.Async = true
 					if p.tt == MulToken {
+						generatorSpan = Span{p.start, p.start + 1}
 						p.next()
 						method.Generator = true
 						data = nil
 					}
 				} else {
-					method.Name.Literal = LiteralExpr{IdentifierToken, data}
+					method.Name.Literal = LiteralExpr{IdentifierToken, data, p.prevSpan()}
 					data = nil
 				}
 			} else if p.tt == GetToken {
@@ -1406,7 +1673,7 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 
 			// PropertyName
 			if data != nil && !method.Generator && (p.tt == EqToken || p.tt == CommaToken || p.tt == CloseBraceToken || p.tt == ColonToken || p.tt == OpenParenToken) {
-				method.Name.Literal = LiteralExpr{IdentifierToken, data}
+				method.Name.Literal = LiteralExpr{IdentifierToken, data, p.prevSpan()}
 				method.Async = false
 				method.Get = false
 				method.Set = false
@@ -1419,13 +1686,16 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 
 			if p.tt == OpenParenToken {
 				// MethodDefinition
+				p.methodFeatures(&method, asyncSpan, generatorSpan)
 				parent := p.enterScope(&method.Body.Scope, true)
 				prevAwait, prevYield, prevRetrn := p.await, p.yield, p.retrn
 				p.await, p.yield, p.retrn = method.Async, method.Generator, true
+				p.funcLevel++
 
 				method.Params = p.parseFuncParams("method definition")
 				method.Body.List = p.parseStmtList("method definition")
 
+				p.funcLevel--
 				p.await, p.yield, p.retrn = prevAwait, prevYield, prevRetrn
 				p.exitScope(parent)
 				property.Value = &method
@@ -1434,7 +1704,10 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 				// PropertyName : AssignmentExpression
 				p.next()
 				property.Name = &method.Name.PropertyName
+				property.Span.Start = p.start
 				property.Value = p.parseAssignExprOrParam()
+				property.Span.End = p.prevEnd
+				property.DefaultSpan = p.defaultSpan(property.Value)
 			} else if method.Name.IsComputed() || !p.isIdentifierReference(method.Name.Literal.TokenType) {
 				p.fail("object literal", ColonToken, OpenParenToken)
 				return
@@ -1457,9 +1730,12 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 					p.next()
 					prevAssumeArrowFunc := p.assumeArrowFunc
 					p.assumeArrowFunc = false
+					property.DefaultSpan.Start = p.start
 					property.Init = p.parseExpression(OpAssign)
+					property.DefaultSpan.End = p.prevEnd
 					p.assumeArrowFunc = prevAssumeArrowFunc
 				}
+				property.Span.End = p.prevEnd
 			}
 		}
 		object.List = append(object.List, property)
@@ -1475,20 +1751,25 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 
 func (p *Parser) parseTemplateLiteral(precLeft OpPrec) (template TemplateExpr) {
 	// assume we're on 'Template' or 'TemplateStart'
+	p.feature(TemplateLiteralFeature)
 	template.Prec = OpMember
 	if precLeft < OpMember {
 		template.Prec = OpCall
 	}
 	for p.tt == TemplateStartToken || p.tt == TemplateMiddleToken {
-		tpl := p.data
+		part := TemplatePart{Value: p.data, Span: Span{p.start, p.start + len(p.data)}}
 		p.next()
-		template.List = append(template.List, TemplatePart{tpl, p.parseExpression(OpExpr)})
+		part.ExprSpan.Start = p.start
+		part.Expr = p.parseExpression(OpExpr)
+		part.ExprSpan.End = p.prevEnd
+		template.List = append(template.List, part)
 	}
 	if p.tt != TemplateToken && p.tt != TemplateEndToken {
 		p.fail("template literal", TemplateToken)
 		return
 	}
 	template.Tail = p.data
+	template.TailSpan = Span{p.start, p.start + len(p.data)}
 	p.next() // TemplateEndToken
 	return
 }
@@ -1500,12 +1781,14 @@ func (p *Parser) parseArguments() (args Args) {
 	for p.tt != CloseParenToken && p.tt != ErrorToken {
 		rest := p.tt == EllipsisToken
 		if rest {
+			p.feature(SpreadFeature)
 			p.next()
 		}
-		args.List = append(args.List, Arg{
-			Value: p.parseExpression(OpAssign),
-			Rest:  rest,
-		})
+		arg := Arg{Rest: rest}
+		arg.Span.Start = p.start
+		arg.Value = p.parseExpression(OpAssign)
+		arg.Span.End = p.prevEnd
+		args.List = append(args.List, arg)
 		if p.tt != CloseParenToken {
 			if p.tt != CommaToken {
 				p.fail("arguments", CommaToken, CloseParenToken)
@@ -1521,15 +1804,18 @@ func (p *Parser) parseArguments() (args Args) {
 
 func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
 	// expect we're at Identifier or Yield or (
+	p.featureSpan(AsyncFunctionFeature, p.prevSpan())
 	arrowFunc = &ArrowFunc{}
+	defer p.annotate(p.prevStart, arrowFunc)
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
 	prevAwait, prevYield := p.await, p.yield
 	p.await, p.yield = true, false
 
 	if IsIdentifier(p.tt) || !prevYield && p.tt == YieldToken {
 		ref, _ := p.scope.Declare(ArgumentDecl, p.data) // cannot fail
+		span := Span{p.start, p.start + len(p.data)}
 		p.next()
-		arrowFunc.Params.List = []BindingElement{{Binding: ref}}
+		arrowFunc.Params.List = []BindingElement{{Binding: ref, Span: span}}
 	} else {
 		arrowFunc.Params = p.parseFuncParams("arrow function")
 		// CallExpression of 'async(params)' already handled
@@ -1546,6 +1832,7 @@ func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
 func (p *Parser) parseIdentifierArrowFunc(v *Var) (arrowFunc *ArrowFunc) {
 	// expect we're at =>
 	arrowFunc = &ArrowFunc{}
+	defer p.annotate(p.prevEnd-len(v.Data), arrowFunc)
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
 	prevAwait, prevYield := p.await, p.yield
 	p.await, p.yield = false, false
@@ -1560,7 +1847,8 @@ func (p *Parser) parseIdentifierArrowFunc(v *Var) (arrowFunc *ArrowFunc) {
 		p.scope.Declared = append(p.scope.Declared, v)
 	}
 
-	arrowFunc.Params.List = []BindingElement{{v, nil}}
+	// we're at => so the previous token is the parameter
+	arrowFunc.Params.List = []BindingElement{{Binding: v, Span: Span{p.prevEnd - len(v.Data), p.prevEnd}}}
 	arrowFunc.Body.List = p.parseArrowFuncBody()
 
 	p.await, p.yield = prevAwait, prevYield
@@ -1577,7 +1865,10 @@ func (p *Parser) parseArrowFuncBody() (list []IStmt) {
 		p.fail("expression")
 		return
 	}
+	p.feature(ArrowFunctionFeature)
 	p.next()
+	p.funcLevel++
+	defer func() { p.funcLevel-- }()
 
 	// mark undeclared vars as arguments in `function f(a=b){var b}` where the b's are different vars
 	p.scope.MarkFuncArgs()
@@ -1599,15 +1890,19 @@ func (p *Parser) parseArrowFuncBody() (list []IStmt) {
 }
 
 func (p *Parser) parseIdentifierExpression(prec OpPrec, ident []byte) IExpr {
+	if p.o.Experiments&PatternMatchingExperiment != 0 && prec <= OpCall && bytes.Equal(ident, []byte("match")) {
+		return p.parseMatchExpr(p.prevStart, prec, ident)
+	}
 	var left IExpr
 	left = p.scope.Use(ident)
-	return p.parseExpressionSuffix(left, prec, OpPrimary)
+	return p.parseExpressionSuffix(left, p.prevStart, prec, OpPrimary)
 }
 
 func (p *Parser) parseAsyncExpression(prec OpPrec, async []byte) IExpr {
 	// IdentifierReference, AsyncFunctionExpression, AsyncGeneratorExpression
 	// CoverCallExpressionAndAsyncArrowHead, AsyncArrowFunction
 	// assume we're at a token after async
+	start := p.prevStart
 	var left IExpr
 	precLeft := OpPrimary
 	if !p.prevLT && p.tt == FunctionToken {
@@ -1627,11 +1922,12 @@ func (p *Parser) parseAsyncExpression(prec OpPrec, async []byte) IExpr {
 		left = p.scope.Use(async)
 	}
 	// can be async(args), async => ..., or e.g. async + ...
-	return p.parseExpressionSuffix(left, prec, precLeft)
+	return p.parseExpressionSuffix(left, start, prec, precLeft)
 }
 
 // parseExpression parses an expression that has a precedence of prec or higher.
 func (p *Parser) parseExpression(prec OpPrec) IExpr {
+	start := p.start
 	p.exprLevel++
 	if NestedExprLimit < p.exprLevel {
 		p.failMessage("too many nested expressions")
@@ -1650,23 +1946,35 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 	var left IExpr
 	precLeft := OpPrimary
 
-	if IsIdentifier(p.tt) && p.tt != AsyncToken {
+	if p.o.Experiments&PatternMatchingExperiment != 0 && p.isContextualKeyword("match") {
+		ident := p.data
+		p.next()
+		suffix := p.parseIdentifierExpression(prec, ident)
+		p.exprLevel--
+		return suffix
+	} else if IsIdentifier(p.tt) && p.tt != AsyncToken {
 		left = p.scope.Use(p.data)
 		p.next()
-		suffix := p.parseExpressionSuffix(left, prec, precLeft)
+		suffix := p.parseExpressionSuffix(left, start, prec, precLeft)
 		p.exprLevel--
 		return suffix
 	} else if IsNumeric(p.tt) {
-		left = &LiteralExpr{p.tt, p.data}
+		p.numericFeatures()
+		lit := p.literal(p.tt)
+		left = &lit
 		p.next()
-		suffix := p.parseExpressionSuffix(left, prec, precLeft)
+		suffix := p.parseExpressionSuffix(left, start, prec, precLeft)
 		p.exprLevel--
 		return suffix
 	}
 
 	switch tt := p.tt; tt {
 	case StringToken, ThisToken, NullToken, TrueToken, FalseToken, RegExpToken:
-		left = &LiteralExpr{p.tt, p.data}
+		if p.tt == RegExpToken {
+			p.regExpFeatures()
+		}
+		lit := p.literal(p.tt)
+		left = &lit
 		p.next()
 	case OpenBracketToken:
 		prevIn := p.in
@@ -1740,6 +2048,9 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 	case AwaitToken:
 		// either accepted as IdentifierReference or as AwaitExpression
 		if p.await && prec <= OpUnary {
+			if p.funcLevel == 0 {
+				p.feature(TopLevelAwaitFeature)
+			}
 			p.next()
 			left = &UnaryExpr{tt, p.parseExpression(OpUnary)}
 			precLeft = OpUnary
@@ -1753,10 +2064,13 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 	case NewToken:
 		p.next()
 		if p.tt == DotToken {
+			span := p.prevSpan()
 			p.next()
+			span.End = p.start + len(p.data)
 			if !p.consume("new.target expression", TargetToken) {
 				return nil
 			}
+			p.featureSpan(NewTargetFeature, span)
 			left = &NewTargetExpr{}
 			precLeft = OpMember
 		} else {
@@ -1770,19 +2084,28 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			} else {
 				precLeft = OpNew
 			}
+			p.annotate(start, newExpr)
 			left = newExpr
 		}
 	case ImportToken:
 		// OpMember < prec does never happen
-		left = &LiteralExpr{p.tt, p.data}
+		lit := p.literal(p.tt)
+		left = &lit
 		p.next()
 		if p.tt == DotToken {
+			span := p.prevSpan()
 			p.next()
+			span.End = p.start + len(p.data)
 			if !p.consume("import.meta expression", MetaToken) {
 				return nil
 			}
+			p.featureSpan(ImportMetaFeature, span)
 			left = &ImportMetaExpr{}
 			precLeft = OpMember
+		} else if p.tt == OpenParenToken {
+			p.featureSpan(DynamicImportFeature, p.prevSpan())
+		}
+		if _, ok := left.(*ImportMetaExpr); ok {
 		} else if p.tt != OpenParenToken {
 			p.fail("import expression", OpenParenToken)
 			return nil
@@ -1794,7 +2117,8 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		}
 	case SuperToken:
 		// OpMember < prec does never happen
-		left = &LiteralExpr{p.tt, p.data}
+		lit := p.literal(p.tt)
+		left = &lit
 		p.next()
 		if OpCall < prec && p.tt != DotToken && p.tt != OpenBracketToken {
 			p.fail("super expression", OpenBracketToken, DotToken)
@@ -1855,11 +2179,26 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		template := p.parseTemplateLiteral(precLeft)
 		left = &template
 		p.in = prevIn
+	case ModToken:
+		if p.topicLevel == 0 {
+			p.fail("expression")
+			return nil
+		}
+		p.topicUses++
+		left = &TopicRefExpr{}
+		p.next()
+	case DoToken:
+		if p.o.Experiments&DoExpressionsExperiment == 0 {
+			p.fail("expression")
+			return nil
+		}
+		left = p.parseDoExpr()
 	case PrivateIdentifierToken:
 		if OpCompare < prec || !p.in {
 			p.fail("expression")
 			return nil
 		}
+		p.feature(PrivateInFeature)
 		left = p.scope.Use(p.data)
 		p.next()
 		if p.tt != InToken {
@@ -1870,12 +2209,13 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		p.fail("expression")
 		return nil
 	}
-	suffix := p.parseExpressionSuffix(left, prec, precLeft)
+	suffix := p.parseExpressionSuffix(left, start, prec, precLeft)
 	p.exprLevel--
 	return suffix
 }
 
-func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr {
+// parseExpressionSuffix parses the operators following left, which starts at offset start.
+func (p *Parser) parseExpressionSuffix(left IExpr, start int, prec, precLeft OpPrec) IExpr {
 	for i := 0; ; i++ {
 		if 1000 < p.exprLevel+i {
 			p.failMessage("too many nested expressions")
@@ -1890,8 +2230,15 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			if tt == ExpEqToken {
+				p.feature(ExponentiationFeature)
+			} else if tt == AndEqToken || tt == OrEqToken || tt == NullishEqToken {
+				p.feature(LogicalAssignmentFeature)
+			}
 			p.next()
+			start := p.start
 			left = &BinaryExpr{tt, left, p.parseExpression(OpAssign)}
+			p.assignStart = start // set after the right-hand side so that it refers to the outermost assignment
 			precLeft = OpAssign
 		case LtToken, LtEqToken, GtToken, GtEqToken, InToken, InstanceofToken:
 			if OpCompare < prec || !p.in && tt == InToken {
@@ -1942,6 +2289,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			p.feature(NullishCoalescingFeature)
 			p.next()
 			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr)}
 			precLeft = OpCoalesce
@@ -1961,9 +2309,10 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				exprPrec = OpCall
 			}
 			if p.tt == PrivateIdentifierToken {
+				p.feature(PrivateMemberFeature)
 				left = &DotExpr{left, p.scope.Use(p.data), exprPrec, false}
 			} else {
-				left = &DotExpr{left, LiteralExpr{IdentifierToken, p.data}, exprPrec, false}
+				left = &DotExpr{left, p.literal(IdentifierToken), exprPrec, false}
 			}
 			p.next()
 			if precLeft < OpMember {
@@ -2004,6 +2353,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			prevIn := p.in
 			p.in = true
 			left = &CallExpr{left, p.parseArguments(), false}
+			p.annotate(start, left)
 			precLeft = OpCall
 			p.in = prevIn
 		case TemplateToken, TemplateStartToken:
@@ -2030,9 +2380,11 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			p.feature(OptionalChainingFeature)
 			p.next()
 			if p.tt == OpenParenToken {
 				left = &CallExpr{left, p.parseArguments(), true}
+				p.annotate(start, left)
 			} else if p.tt == OpenBracketToken {
 				p.next()
 				left = &IndexExpr{left, p.parseExpression(OpExpr), OpCall, true}
@@ -2046,10 +2398,11 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				template.Optional = true
 				left = &template
 			} else if IsIdentifierName(p.tt) {
-				left = &DotExpr{left, LiteralExpr{IdentifierToken, p.data}, OpCall, true}
+				left = &DotExpr{left, p.literal(IdentifierToken), OpCall, true}
 				p.next()
 			} else if p.tt == PrivateIdentifierToken {
-				left = &DotExpr{left, LiteralExpr{p.tt, p.data}, OpCall, true}
+				p.feature(PrivateMemberFeature)
+				left = &DotExpr{left, p.literal(p.tt), OpCall, true}
 				p.next()
 			} else {
 				p.fail("optional chaining expression", IdentifierToken, OpenParenToken, OpenBracketToken, TemplateToken)
@@ -2083,6 +2436,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			p.feature(ExponentiationFeature)
 			p.next()
 			left = &BinaryExpr{tt, left, p.parseExpression(OpExp)}
 			precLeft = OpExp
@@ -2164,6 +2518,16 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			elseExpr := p.parseExpression(OpAssign)
 			left = &CondExpr{left, ifExpr, elseExpr}
 			precLeft = OpAssign
+		case PipelineToken:
+			if p.o.Experiments&PipelineExperiment == 0 || OpAssign < prec {
+				return left
+			} else if _, ok := left.(*PipelineExpr); !ok && precLeft < OpCoalesce {
+				p.fail("expression")
+				return nil
+			}
+			p.next()
+			left = &PipelineExpr{left, p.parsePipelineBody()}
+			precLeft = OpAssign
 		case CommaToken:
 			if OpExpr < prec {
 				return left
@@ -2211,12 +2575,20 @@ func (p *Parser) parseAssignExprOrParam() IExpr {
 			left, ok = p.scope.Declare(ArgumentDecl, data)
 			if ok {
 				p.assumeArrowFunc = false
-				left = p.parseExpressionSuffix(left, OpAssign, OpPrimary)
+				left = p.parseExpressionSuffix(left, p.prevStart, OpAssign, OpPrimary)
 				p.assumeArrowFunc = true
 				return left
 			}
 		}
 		p.assumeArrowFunc = false
+
+		// count as an expression level since parseExpression is bypassed, as in async(async(...))
+		p.exprLevel++
+		defer func() { p.exprLevel-- }()
+		if NestedExprLimit < p.exprLevel {
+			p.failMessage("too many nested expressions")
+			return nil
+		}
 		if tt == AsyncToken {
 			return p.parseAsyncExpression(OpAssign, data)
 		}
@@ -2233,6 +2605,11 @@ func (p *Parser) parseParenthesizedExpression(prec OpPrec, async []byte) IExpr {
 	precLeft := OpPrimary
 
 	// expect to be at (
+	asyncSpan := p.prevSpan()
+	start := p.start
+	if async != nil {
+		start = asyncSpan.Start
+	}
 	p.next()
 
 	isAsync := async != nil // prevLT is false before open parenthesis
@@ -2256,11 +2633,17 @@ func (p *Parser) parseParenthesizedExpression(prec OpPrec, async []byte) IExpr {
 
 		rest := p.tt == EllipsisToken
 		if rest {
+			p.feature(SpreadFeature)
 			p.next()
 			rests++
 		}
 
-		args.List = append(args.List, Arg{p.parseAssignExprOrParam(), rest})
+		arg := Arg{Rest: rest}
+		arg.Span.Start = p.start
+		arg.Value = p.parseAssignExprOrParam()
+		arg.Span.End = p.prevEnd
+		arg.DefaultSpan = p.defaultSpan(arg.Value)
+		args.List = append(args.List, arg)
 		if p.tt != CommaToken {
 			break
 		}
@@ -2280,13 +2663,21 @@ func (p *Parser) parseParenthesizedExpression(prec OpPrec, async []byte) IExpr {
 		p.await, p.yield = isAsync, false
 
 		// arrow function
+		if isAsync {
+			p.featureSpan(AsyncFunctionFeature, asyncSpan)
+		}
 		arrowFunc.Async = isAsync
 		arrowFunc.Params = Params{List: make([]BindingElement, 0, len(args.List)-rests)}
 		for _, arg := range args.List {
+			switch arg.Value.(type) {
+			case *ArrayExpr, *ObjectExpr:
+				p.featureSpan(DestructuringFeature, arg.Span)
+			}
 			if arg.Rest {
 				arrowFunc.Params.Rest = p.exprToBinding(arg.Value)
+				arrowFunc.Params.RestSpan = arg.Span
 			} else {
-				arrowFunc.Params.List = append(arrowFunc.Params.List, p.exprToBindingElement(arg.Value)) // can not fail when assumArrowFunc is set
+				arrowFunc.Params.List = append(arrowFunc.Params.List, p.exprToBindingElement(arg.Value, arg.Span, arg.DefaultSpan)) // can not fail when assumArrowFunc is set
 			}
 		}
 		arrowFunc.Body.List = p.parseArrowFuncBody()
@@ -2294,6 +2685,7 @@ func (p *Parser) parseParenthesizedExpression(prec OpPrec, async []byte) IExpr {
 		p.await, p.yield = prevAwait, prevYield
 		p.exitScope(parent)
 
+		p.annotate(start, arrowFunc)
 		left = arrowFunc
 		precLeft = OpAssign
 	} else if !isAsync && (len(args.List) == 0 || hasLastRest) {
@@ -2312,6 +2704,7 @@ func (p *Parser) parseParenthesizedExpression(prec OpPrec, async []byte) IExpr {
 			// call expression
 			left = p.scope.Use(async)
 			left = &CallExpr{left, args, false}
+			p.annotate(start, left)
 			precLeft = OpCall
 		} else {
 			// parenthesized expression
@@ -2326,60 +2719,126 @@ func (p *Parser) parseParenthesizedExpression(prec OpPrec, async []byte) IExpr {
 			}
 		}
 	}
-	return p.parseExpressionSuffix(left, prec, precLeft)
+	return p.parseExpressionSuffix(left, start, prec, precLeft)
 }
 
 // exprToBindingElement and exprToBinding convert a CoverParenthesizedExpressionAndArrowParameterList into FormalParameters.
 // Any unbound variables of the parameters (Initializer, ComputedPropertyName) are kept in the parent scope
-func (p *Parser) exprToBindingElement(expr IExpr) (bindingElement BindingElement) {
-	if assign, ok := expr.(*BinaryExpr); ok && assign.Op == EqToken {
-		bindingElement.Binding = p.exprToBinding(assign.X)
+func (p *Parser) exprToBindingElement(expr IExpr, span, defaultSpan Span) BindingElement {
+	bindingElement, ok := toBindingElement(expr, span, defaultSpan, false)
+	if !ok {
+		p.failMessage("invalid parameters in arrow function")
+	}
+	return bindingElement
+}
+
+func (p *Parser) exprToBinding(expr IExpr) IBinding {
+	binding, ok := toBinding(expr, false)
+	if !ok {
+		p.failMessage("invalid parameters in arrow function")
+	}
+	return binding
+}
+
+// AssignmentPattern converts the left-hand side of a destructuring assignment or of a for-in/of statement with an expression head, which is parsed as an array or object literal, into a binding pattern. Unlike in declarations, the targets may be member expressions (DotExpr and IndexExpr) besides variables. It returns false if the expression is not a valid assignment target.
+func AssignmentPattern(expr IExpr) (IBinding, bool) {
+	return toBinding(expr, true)
+}
+
+func toBindingElement(expr IExpr, span, defaultSpan Span, member bool) (bindingElement BindingElement, ok bool) {
+	bindingElement.Span = span
+	if assign, isAssign := expr.(*BinaryExpr); isAssign && assign.Op == EqToken {
+		bindingElement.Binding, ok = toBinding(assign.X, member)
 		bindingElement.Default = assign.Y
+		bindingElement.DefaultSpan = defaultSpan
 	} else {
-		bindingElement.Binding = p.exprToBinding(expr)
+		bindingElement.Binding, ok = toBinding(expr, member)
 	}
 	return
 }
 
-func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
-	if expr == nil {
-		// no-op
-	} else if v, ok := expr.(*Var); ok {
-		binding = v
-	} else if array, ok := expr.(*ArrayExpr); ok {
+func toBinding(expr IExpr, member bool) (IBinding, bool) {
+	if member {
+		for {
+			group, ok := expr.(*GroupExpr)
+			if !ok {
+				break
+			}
+			expr = group.X
+		}
+	}
+	switch expr := expr.(type) {
+	case nil:
+		return nil, true
+	case *Var:
+		return expr, true
+	case *DotExpr:
+		return expr, member
+	case *IndexExpr:
+		return expr, member
+	case *ArrayExpr:
 		bindingArray := BindingArray{}
-		for _, item := range array.List {
+		for _, item := range expr.List {
 			if item.Spread {
 				// can only BindingIdentifier or BindingPattern
-				bindingArray.Rest = p.exprToBinding(item.Value)
+				rest, ok := toBinding(item.Value, member)
+				if !ok {
+					return nil, false
+				}
+				bindingArray.Rest = rest
+				bindingArray.RestSpan = item.Span
 				break
 			}
-			var bindingElement BindingElement
-			bindingElement = p.exprToBindingElement(item.Value)
+			bindingElement, ok := toBindingElement(item.Value, item.Span, item.DefaultSpan, member)
+			if !ok {
+				return nil, false
+			}
 			bindingArray.List = append(bindingArray.List, bindingElement)
 		}
-		binding = &bindingArray
-	} else if object, ok := expr.(*ObjectExpr); ok {
+		return &bindingArray, true
+	case *ObjectExpr:
 		bindingObject := BindingObject{}
-		for _, item := range object.List {
+		for _, item := range expr.List {
 			if item.Spread {
-				// can only be BindingIdentifier
-				bindingObject.Rest = item.Value.(*Var)
+				// can only be BindingIdentifier, or a member expression in assignments
+				rest, ok := toBinding(item.Value, member)
+				switch rest.(type) {
+				case *Var, *DotExpr, *IndexExpr:
+				default:
+					ok = false
+				}
+				if !ok {
+					return nil, false
+				}
+				bindingObject.Rest = rest
+				bindingObject.RestSpan = item.Span
 				break
 			}
 
-			bindingElement := p.exprToBindingElement(item.Value)
+			bindingElement, ok := toBindingElement(item.Value, item.Span, item.DefaultSpan, member)
+			if !ok {
+				return nil, false
+			}
 			if v, ok := item.Value.(*Var); item.Name == nil || (ok && item.Name.IsIdent(v.Data)) {
 				// IdentifierReference : Initializer
-				bindingElement.Default = item.Init
+				if item.Init != nil {
+					bindingElement.Default = item.Init
+					bindingElement.DefaultSpan = item.DefaultSpan
+				}
 			}
 			bindingObject.List = append(bindingObject.List, BindingObjectItem{Key: item.Name, Value: bindingElement})
 		}
-		binding = &bindingObject
-	} else {
-		p.failMessage("invalid parameters in arrow function")
+		return &bindingObject, true
 	}
-	return
+	return nil, false
+}
+
+// defaultSpan returns the span of the right-hand side of an assignment expression that was just parsed, which is the initializer of an element in a cover grammar.
+func (p *Parser) defaultSpan(expr IExpr) Span {
+	if assign, ok := expr.(*BinaryExpr); ok && assign.Op == EqToken {
+		return Span{p.assignStart, p.prevEnd}
+	}
+	return Span{}
 }
 
 func (p *Parser) isIdentifierReference(tt TokenType) bool {
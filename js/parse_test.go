@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/politepixels/tdewolff-parse/v2"
 	"github.com/tdewolff/test"
@@ -651,6 +652,7 @@ func TestParseError(t *testing.T) {
 		{"x = ([5]) =>", "unexpected => in expression"},
 		{"x = ({...a, b}) =>", "unexpected => in expression"},
 		{"x = ({...5}) =>", "unexpected => in expression"},
+		{"x = ({...a.b}) => a", "unexpected => in expression"},
 		{"x = ({5: 5}) =>", "unexpected => in expression"},
 		{"x = ({[4+5]: 5}) =>", "unexpected => in expression"},
 		{"x = (a, a) =>", "unexpected => in expression"},
@@ -1093,6 +1095,246 @@ func TestScope(t *testing.T) {
 	test.T(t, ast.List[4].(*BlockStmt).List[0].(*BlockStmt).Scope.String(), "Scope{Declared: [], Undeclared: [Var{NoDecl d 1 2}]}")
 }
 
+func bindingSpans(js string, binding IBinding) []string {
+	spans := []string{}
+	var bindingElementSpans func(BindingElement)
+	bindingElementSpans = func(element BindingElement) {
+		s := js[element.Span.Start:element.Span.End]
+		if element.Default != nil {
+			s += "|" + js[element.DefaultSpan.Start:element.DefaultSpan.End]
+		}
+		spans = append(spans, s)
+		spans = append(spans, bindingSpans(js, element.Binding)...)
+	}
+	switch b := binding.(type) {
+	case *BindingArray:
+		for _, item := range b.List {
+			if item.Binding != nil {
+				bindingElementSpans(item)
+			}
+		}
+		if b.Rest != nil {
+			spans = append(spans, "..."+js[b.RestSpan.Start:b.RestSpan.End])
+			spans = append(spans, bindingSpans(js, b.Rest)...)
+		}
+	case *BindingObject:
+		for _, item := range b.List {
+			bindingElementSpans(item.Value)
+		}
+		if b.Rest != nil {
+			spans = append(spans, "..."+js[b.RestSpan.Start:b.RestSpan.End])
+		}
+	}
+	return spans
+}
+
+func paramsSpans(js string, params Params) []string {
+	spans := []string{}
+	for _, item := range params.List {
+		s := js[item.Span.Start:item.Span.End]
+		if item.Default != nil {
+			s += "|" + js[item.DefaultSpan.Start:item.DefaultSpan.End]
+		}
+		spans = append(spans, s)
+		spans = append(spans, bindingSpans(js, item.Binding)...)
+	}
+	if params.Rest != nil {
+		spans = append(spans, "..."+js[params.RestSpan.Start:params.RestSpan.End])
+		spans = append(spans, bindingSpans(js, params.Rest)...)
+	}
+	return spans
+}
+
+func TestBindingSpans(t *testing.T) {
+	var tests = []struct {
+		js    string
+		spans string
+	}{
+		{"function f(a, b = 1, ...c){}", "a, b = 1|1, ...c"},
+		{"function f([a = x+1, , ...[b]], { c, d: e = 2, f = 3, ...g }){}", "[a = x+1, , ...[b]], a = x+1|x+1, ...[b], b, { c, d: e = 2, f = 3, ...g }, c, e = 2|2, f = 3|3, ...g"},
+		{"var [a = 1] = b, {c: {d} = {}} = e", "[a = 1] = b|b, a = 1|1, {c: {d} = {}} = e|e, {d} = {}|{}, d"},
+		{"for (const [a, b = 1] of c);", "[a, b = 1], a, b = 1|1"},
+		{"try {} catch ({a = 1, b: [c]}) {}", "a = 1|1, [c], c"},
+		{"([a = 1, ...b], {c = 2, d: e = f = 3}) => 0", "[a = 1, ...b], a = 1|1, ...b, {c = 2, d: e = f = 3}, c = 2|2, e = f = 3|f = 3"},
+		{"(a = b, ...[c]) => 0", "a = b|b, ...[c], c"},
+		{"async (a) => 0", "a"},
+		{"x => 0", "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			var spans []string
+			switch n := ast.List[0].(type) {
+			case *FuncDecl:
+				spans = paramsSpans(tt.js, n.Params)
+			case *VarDecl:
+				for _, item := range n.List {
+					spans = append(spans, tt.js[item.Span.Start:item.Span.End]+"|"+tt.js[item.DefaultSpan.Start:item.DefaultSpan.End])
+					spans = append(spans, bindingSpans(tt.js, item.Binding)...)
+				}
+			case *ForOfStmt:
+				spans = bindingSpans(tt.js, n.Init.(*VarDecl).List[0].Binding)
+				spans = append([]string{tt.js[n.Init.(*VarDecl).List[0].Span.Start:n.Init.(*VarDecl).List[0].Span.End]}, spans...)
+			case *TryStmt:
+				spans = bindingSpans(tt.js, n.Binding)
+			case *ExprStmt:
+				spans = paramsSpans(tt.js, n.Value.(*ArrowFunc).Params)
+			}
+			test.String(t, strings.Join(spans, ", "), tt.spans)
+		})
+	}
+}
+
+type literalSpans struct {
+	js    string
+	spans []string
+}
+
+func (v *literalSpans) add(span Span, escapes []Span) {
+	s := v.js[span.Start:span.End]
+	for _, escape := range escapes {
+		s += "|" + v.js[escape.Start:escape.End]
+	}
+	v.spans = append(v.spans, s)
+}
+
+func (v *literalSpans) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *LiteralExpr:
+		v.add(n.Span, n.EscapeSpans())
+		if body, flags, ok := n.RegExpSpans(); ok {
+			v.spans = append(v.spans, v.js[body.Start:body.End]+"/"+v.js[flags.Start:flags.End])
+		}
+	case *DotExpr:
+		if y, ok := n.Y.(LiteralExpr); ok {
+			v.add(y.Span, nil)
+		}
+	case *TemplateExpr:
+		for _, item := range n.List {
+			v.add(item.Span, item.EscapeSpans())
+			v.spans = append(v.spans, "${"+v.js[item.ExprSpan.Start:item.ExprSpan.End]+"}")
+		}
+		v.add(n.TailSpan, n.TailEscapeSpans())
+	}
+	return v
+}
+
+func (v *literalSpans) Exit(n INode) {}
+
+func TestLiteralSpans(t *testing.T) {
+	var tests = []struct {
+		js    string
+		spans string
+	}{
+		{"x = 'a\\n\\x41\\u0041\\u{1F600}b'", "'a\\n\\x41\\u0041\\u{1F600}b'|\\n|\\x41|\\u0041|\\u{1F600}"},
+		{"'\\0\\101\\477\\8\\\r\nx'", "'\\0\\101\\477\\8\\\r\nx'|\\0|\\101|\\47|\\8|\\\r\n"},
+		{"x = /a\\/b[\\]]\\cJ\\k<n>/gi", "/a\\/b[\\]]\\cJ\\k<n>/gi|\\/|\\]|\\cJ|\\k<n>, a\\/b[\\]]\\cJ\\k<n>/gi"},
+		{"/\\p{L}\\u{41}/u; /\\p{L}\\u{41}/", "/\\p{L}\\u{41}/u|\\p{L}|\\u{41}, \\p{L}\\u{41}/u, /\\p{L}\\u{41}/|\\p|\\u, \\p{L}\\u{41}/"},
+		{"f(/=a/)", "/=a/, =a/"},
+		{"x = `a${ b }\\n${c}d\\u0041`", "`a${, ${b}, }\\n${|\\n, ${c}, }d\\u0041`|\\u0041"},
+		{"tag`\\`x`", "`\\`x`|\\`"},
+		{"({a: 1, 'b': 2, 'c d': 3, 4: 5, get e() {}, async: 6})", "a, 1, b, 2, 'c d', 3, 4, 5, async, 6"},
+		{"a.b?.c; import('x')", "c, b, 'x', import"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			v := &literalSpans{js: tt.js}
+			Walk(v, ast)
+			test.String(t, strings.Join(v.spans, ", "), tt.spans)
+		})
+	}
+}
+
+func TestAssignmentPattern(t *testing.T) {
+	var tests = []struct {
+		js    string
+		spans string
+	}{
+		{"[a, b.c = 1, d[0], ...e.f] = g", "a, b.c = 1|1, d[0], ...e.f"},
+		{"({a = 1, b: c.d = e = 2, ...f} = g)", "a = 1|1, c.d = e = 2|e = 2, ...f"},
+		{"[(a.b), [c = 1]] = d", "(a.b), [c = 1], c = 1|1"},
+		{"for ([a, b = 1] of c);", "a, b = 1|1"},
+		{"for ({a: x.y} in c);", "x.y"},
+		{"[a + b] = c", ""},
+		{"({...a.b} = c)", "...a.b"},
+		{"({...(a)} = c)", "...(a)"},
+		{"({a, ...b[0]} = c)", "a, ...b[0]"},
+		{"({...[a]} = c)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			var expr IExpr
+			switch n := ast.List[0].(type) {
+			case *ExprStmt:
+				expr = n.Value
+				if group, ok := expr.(*GroupExpr); ok {
+					expr = group.X
+				}
+				expr = expr.(*BinaryExpr).X
+			case *ForOfStmt:
+				expr = n.Init
+			case *ForInStmt:
+				expr = n.Init
+			}
+			binding, ok := AssignmentPattern(expr)
+			test.T(t, ok, tt.spans != "")
+			if ok {
+				test.String(t, strings.Join(bindingSpans(tt.js, binding), ", "), tt.spans)
+			}
+		})
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		errs     string
+	}{
+		{"a = ;\nb = 1;\nc = )\nd", "Stmt(b=1) Stmt(d)", "unexpected ; in expression on line 1 and column 5\nunexpected ) in expression on line 3 and column 5"},
+		{"a = 1; b = 2 3; c", "Stmt(a=1) Stmt(c)", "unexpected 3 in expression on line 1 and column 14"},
+		{"a = \n)\nb", "Stmt(b)", "unexpected ) in expression on line 2 and column 1"},
+		{"}\na", "Stmt(a)", "unexpected } in expression on line 1 and column 1"},
+		{"function f() {\n  x = ;\n  y\n}\nz", "Decl(function f Params() Stmt({ Stmt(y) })) Stmt(z)", "unexpected ; in expression on line 2 and column 7"},
+		{"if (a) {\n  b = c +\n}\nd", "Stmt(if a Stmt({ })) Stmt(d)", "unexpected } in expression on line 3 and column 1"},
+		{"f(function () { a = ; b });\ng", "Stmt(f(Decl(function Params() Stmt({ Stmt(b) })))) Stmt(g)", "unexpected ; in expression on line 1 and column 21"},
+		{"switch (a) {\ncase 1: x =\ncase 2: y\n}", "Stmt(switch a Clause(case 1) Clause(case 2 Stmt(y)))", "unexpected case in expression on line 3 and column 1"},
+		{"import {a from 'b'\nc", "", "expected Identifier or String instead of c in import statement on line 2 and column 1"},
+		{"a;\nb = (", "Stmt(a)", "unexpected EOF in expression on line 2 and column 6"},
+		{"a;\nb = 'c\nd", "Stmt(a)", "unterminated string literal in expression on line 2 and column 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			d := parse.NewDiagnostics(0)
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Diagnostics: d})
+			test.Error(t, err)
+			test.String(t, ast.String(), tt.expected)
+			test.String(t, d.Error(), tt.errs)
+		})
+	}
+
+	// stop at the maximum number of errors
+	d := parse.NewDiagnostics(1)
+	_, err := Parse(parse.NewInputString("a = ;\nb = ;\nc = ;"), Options{Diagnostics: d})
+	test.Error(t, err)
+	test.String(t, d.Error(), "unexpected ; in expression on line 1 and column 5\nand 1 more errors")
+
+	// inline scripts recover as well
+	d = parse.NewDiagnostics(0)
+	ast, err := Parse(parse.NewInputString("return ;\na = ;\nreturn b"), Options{Inline: true, Diagnostics: d})
+	test.Error(t, err)
+	test.String(t, ast.String(), "Stmt(return) Stmt(return b)")
+	test.String(t, d.Error(), "unexpected ; in expression on line 2 and column 5")
+}
+
 func TestParseInputError(t *testing.T) {
 	_, err := Parse(parse.NewInput(test.NewErrorReader(0)), Options{})
 	test.T(t, err, test.ErrPlain)
@@ -1100,3 +1342,92 @@ func TestParseInputError(t *testing.T) {
 	_, err = Parse(parse.NewInput(test.NewErrorReader(1)), Options{})
 	test.T(t, err, test.ErrPlain)
 }
+
+func TestParseNesting(t *testing.T) {
+	var tests = []struct {
+		prefix, repeat string
+		err            string
+	}{
+		{"", "(", "too many nested expressions"},
+		{"var ", "[", "too many nested bindings"},
+		{"var ", "{a:", "too many nested bindings"},
+		{"function f(", "[", "too many nested bindings"},
+		{"try{}catch(", "[", "too many nested bindings"},
+		{"", "async(", "too many nested expressions"},
+		{"", "if(a)", "too many nested statements"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix+tt.repeat, func(t *testing.T) {
+			// the nesting is deep enough to overflow the stack without limits
+			src := tt.prefix + strings.Repeat(tt.repeat, 3000000) + "a"
+			_, err := Parse(parse.NewInputString(src), Options{})
+			test.That(t, err != nil)
+			test.That(t, strings.HasPrefix(err.Error(), tt.err), err.Error())
+		})
+	}
+}
+
+// TestParseAdversarial parses mutations of valid programs, such as deleted, inserted, or truncated tokens, and asserts that the parser returns instead of panicking or hanging.
+func TestParseAdversarial(t *testing.T) {
+	programs := []string{
+		"var a = [1, , ...b], {c, d: [e = 1], ...f} = g;",
+		"async function* f(a, [b], {c} = {}, ...d) { for await (const x of y) yield* x; }",
+		"class A extends B { static #a = 1; static { this.#a++ } get [c]() { return super.c?.(#a in this) } }",
+		"label: for (let i = 0; i < 10; i++) { if (i) continue label; else break; }",
+		"switch (a) { case 1: b; default: { c } }",
+		"try { throw new Error(`a${b}c`) } catch ({message}) {} finally { d ??= e ** 2 }",
+		"export default (a, b) => ({a, b}); import x, * as y from 'z'; import('w').then(m => m)",
+		"a = async (b) => await b, c = async d => d, e = async(f)",
+		"x = /[/]+(?<a>b)/gu.test(y) ? z?.[0] : new.target || import.meta;",
+		"do a--; while (b) with (c) { d = function () { 'use strict'; return this } }",
+	}
+	tokens := strings.Fields("( ) [ ] { } , ; : => ... ? ?. = async await yield function class let new ` ${ /a/ 'b' #c \n")
+	seed := uint32(1)
+	random := func(n int) int {
+		seed ^= seed << 13
+		seed ^= seed >> 17
+		seed ^= seed << 5
+		return int(seed % uint32(n))
+	}
+	for i := 0; i < 20000; i++ {
+		src := programs[random(len(programs))]
+		for k := 0; k < 1+random(3); k++ {
+			pos := random(len(src) + 1)
+			switch random(4) {
+			case 0:
+				end := pos + random(5)
+				if len(src) < end {
+					end = len(src)
+				}
+				src = src[:pos] + src[end:]
+			case 1:
+				src = src[:pos] + tokens[random(len(tokens))] + src[pos:]
+			case 2:
+				src = src[:pos] + programs[random(len(programs))] + src[pos:]
+			case 3:
+				src = src[:pos]
+			}
+		}
+
+		done := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				done <- recover()
+			}()
+			if ast, err := Parse(parse.NewInputString(src), Options{}); err == nil {
+				_ = ast.JSString()
+			}
+			if ast, err := Parse(parse.NewInputString(src), Options{Diagnostics: parse.NewDiagnostics(0)}); err == nil {
+				_ = ast.JSString()
+			}
+		}()
+		select {
+		case r := <-done:
+			if r != nil {
+				t.Fatalf("panic on %q: %v", src, r)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout on %q", src)
+		}
+	}
+}
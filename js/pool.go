@@ -0,0 +1,56 @@
+package js
+
+import (
+	"sync"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var parserPool = sync.Pool{
+	New: func() interface{} {
+		return &Parser{
+			l: NewLexer(nil),
+		}
+	},
+}
+
+// AcquireParser returns a parser for r like Parse, taken from a pool of parsers that were released with ReleaseParser so that their buffers are reused. Call its Parse method to parse the input. This saves allocations when parsing many small scripts, such as event handler attributes.
+func AcquireParser(r *parse.Input, o Options) *Parser {
+	p := parserPool.Get().(*Parser)
+	p.l.Reset(r)
+	*p = Parser{
+		l:         p.l,
+		o:         o,
+		tt:        WhitespaceToken, // trick so that next() works
+		in:        true,
+		await:     true,
+		comments:  p.comments[:0],
+		annotated: p.annotated[:0],
+		resync:    -1,
+	}
+	return p
+}
+
+// ReleaseParser returns a parser to the pool. The parser may not be used afterwards, but the AST it returned remains valid as it does not share memory with the parser.
+func ReleaseParser(p *Parser) {
+	comments := p.comments[:cap(p.comments)]
+	for i := range comments {
+		comments[i] = nil // don't retain the AST
+	}
+	annotated := p.annotated[:cap(p.annotated)]
+	for i := range annotated {
+		annotated[i] = pendingAnnotation{}
+	}
+	p.l.Reset(nil)
+	*p = Parser{
+		l:         p.l,
+		comments:  comments[:0],
+		annotated: annotated[:0],
+	}
+	parserPool.Put(p)
+}
+
+// Parse parses the input of a parser from AcquireParser and returns its AST, like the Parse function. It may be called once per acquired parser.
+func (p *Parser) Parse() (*AST, error) {
+	return p.parse(p.l.r)
+}
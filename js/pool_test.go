@@ -0,0 +1,34 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParserPool(t *testing.T) {
+	// pooled parsers are reused, which must not change previously returned ASTs
+	p := AcquireParser(parse.NewInputString("/*! a */ x; /*! b */ /*! c */"), Options{})
+	ast, err := p.Parse()
+	test.Error(t, err)
+	ReleaseParser(p)
+	for i := 0; i < 3; i++ {
+		p = AcquireParser(parse.NewInputString("`${`${c}`}`; /*! d */ y; /*! e */ z; /*! f */"), Options{})
+		_, err = p.Parse()
+		test.Error(t, err)
+		ReleaseParser(p)
+
+		p = AcquireParser(parse.NewInputString("x = "), Options{})
+		_, err = p.Parse()
+		test.That(t, err != nil)
+		ReleaseParser(p)
+
+		p = AcquireParser(parse.NewInputString("return a"), Options{Inline: true})
+		inline, err := p.Parse()
+		test.Error(t, err)
+		ReleaseParser(p)
+		test.String(t, inline.JSString(), "return a;")
+	}
+	test.String(t, ast.JSString(), "/*! a */\n/*! b */\n/*! c */\nx;")
+}
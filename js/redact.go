@@ -0,0 +1,53 @@
+package js
+
+import (
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Redact returns a copy of src where the contents of strings, templates, and comments are replaced by placeholders of the same length, see parse.Redact. Escape sequences are kept so that the result tokenizes and parses the same as src with all tokens at the same offsets. Identifiers, keywords, numbers, and regular expressions are kept, as redacting them may change the meaning of the program. The result can be shrunk further with Reduce.
+func Redact(src []byte) []byte {
+	dst := parse.Copy(src)
+	l := NewLexer(parse.NewInputBytes(parse.Copy(src)))
+	prevTT := ErrorToken
+	prevEnd := 0
+	for {
+		tt, data := l.Next()
+		if (tt == DivToken || tt == DivEqToken) && !isExprEnd(prevTT) {
+			tt, data = l.RegExp()
+		}
+		if tt == ErrorToken && len(data) == 0 {
+			if l.Err() != io.EOF {
+				// redact the remainder that could not be tokenized
+				parse.Redact(dst[prevEnd:], nil)
+			}
+			break
+		}
+		if tt != WhitespaceToken && tt != LineTerminatorToken && tt != CommentToken && tt != CommentLineTerminatorToken {
+			prevTT = tt
+		}
+
+		end := l.r.Offset()
+		b := dst[end-len(data) : end]
+		prevEnd = end
+		switch tt {
+		case StringToken, TemplateToken, TemplateStartToken, TemplateMiddleToken, TemplateEndToken, ErrorToken:
+			// unterminated strings and templates are returned as errors
+			parse.Redact(b, redactSkipEscape)
+		case CommentToken, CommentLineTerminatorToken:
+			parse.Redact(b, nil)
+		}
+	}
+	return dst
+}
+
+// redactSkipEscape returns the length of an escape sequence at the start of b.
+func redactSkipEscape(b []byte) int {
+	if b[0] != '\\' || len(b) < 2 {
+		return 0
+	} else if _, n, ok := decodeUnicodeEscape(b, true, true); ok {
+		return n
+	}
+	return 2
+}
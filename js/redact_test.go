@@ -0,0 +1,30 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{`var a = "Secret 42";`, `var a = "Xxxxxx 00";`},
+		{`f('it\'s', "\x41\u{1F600}\n")`, `f('xx\'x', "\x41\u{1F600}\n")`},
+		{"x = `Hi ${name}, you owe $${sum}`", "x = `Xx ${name}, xxx xxx $${sum}`"},
+		{"// TODO fix\n/* Copyright 2024 */ a", "// XXXX xxx\n/* Xxxxxxxxx 0000 */ a"},
+		{`a = /"[a-z]+"/g; b = 4 / 2 / "c"`, `a = /"[a-z]+"/g; b = 4 / 2 / "x"`},
+		{`s = "café €"`, `s = "xxxé €"`},
+		{`a = 1 # "Secret"`, `a = 1 # "Xxxxxx"`},
+		{`a = "Unterminated secret`, `a = "Xxxxxxxxxxxx xxxxxx`},
+		{"a = `Unterminated ${b} secret", "a = `Xxxxxxxxxxxx ${b} xxxxxx"},
+		{"/* Unterminated secret", "/* Xxxxxxxxxxxx xxxxxx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			test.String(t, string(Redact([]byte(tt.js))), tt.expected)
+		})
+	}
+}
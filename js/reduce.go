@@ -0,0 +1,218 @@
+package js
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// reduceNode is a token or a bracketed group of tokens of the input to reduce.
+type reduceNode struct {
+	start, end int  // byte offsets of the token, or of the opening token for groups
+	newline    bool // preceded by a line terminator
+	children   []*reduceNode
+	close      *reduceNode // closing token of a group, can be nil
+	removed    bool
+	unwrapped  bool // group delimiters are removed but not its children
+}
+
+// reduceTokens tokenizes src and nests tokens between brackets, braces, parentheses, and template delimiters.
+func reduceTokens(src []byte) []*reduceNode {
+	l := NewLexer(parse.NewInputBytes(parse.Copy(src)))
+	root := &reduceNode{}
+	stack := []*reduceNode{root}
+	prevTT := ErrorToken
+	newline := false
+	for {
+		tt, data := l.Next()
+		if tt == WhitespaceToken || tt == CommentToken {
+			continue
+		} else if tt == LineTerminatorToken || tt == CommentLineTerminatorToken {
+			newline = true
+			continue
+		} else if (tt == DivToken || tt == DivEqToken) && !isExprEnd(prevTT) {
+			tt, data = l.RegExp()
+		}
+
+		end := l.r.Offset()
+		if tt == ErrorToken && len(data) == 0 {
+			// end of input or unrecoverable lexer error, keep the rest of the input as a single token
+			start := l.r.Offset()
+			if start < len(src) && len(bytes.TrimSpace(src[start:])) != 0 {
+				root.children = append(root.children, &reduceNode{start: start, end: len(src), newline: newline})
+			}
+			break
+		}
+		n := &reduceNode{start: end - len(data), end: end, newline: newline}
+		newline = false
+		prevTT = tt
+
+		parent := stack[len(stack)-1]
+		switch tt {
+		case OpenParenToken, OpenBracketToken, OpenBraceToken, TemplateStartToken:
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case CloseParenToken, CloseBracketToken, CloseBraceToken, TemplateEndToken:
+			if 1 < len(stack) {
+				parent.close = n
+				stack = stack[:len(stack)-1]
+			} else {
+				parent.children = append(parent.children, n)
+			}
+		default:
+			parent.children = append(parent.children, n)
+		}
+	}
+	return root.children
+}
+
+// isExprEnd returns true if a slash after the token is a division rather than a regular expression.
+func isExprEnd(tt TokenType) bool {
+	return IsIdentifier(tt) || IsNumeric(tt) || tt == StringToken || tt == RegExpToken || tt == TemplateToken || tt == TemplateEndToken || tt == CloseParenToken || tt == CloseBracketToken || tt == CloseBraceToken || tt == ThisToken || tt == SuperToken || tt == NullToken || tt == TrueToken || tt == FalseToken || tt == IncrToken || tt == DecrToken
+}
+
+func renderReduced(src []byte, nodes []*reduceNode) []byte {
+	b := []byte{}
+	prevEnd := 0
+	var render func([]*reduceNode)
+	emit := func(n *reduceNode) {
+		if 0 < len(b) && prevEnd != n.start {
+			// tokens were not adjacent, keep them separated
+			if n.newline {
+				b = append(b, '\n')
+			} else {
+				b = append(b, ' ')
+			}
+		}
+		b = append(b, src[n.start:n.end]...)
+		prevEnd = n.end
+	}
+	render = func(nodes []*reduceNode) {
+		for _, n := range nodes {
+			if n.removed {
+				continue
+			} else if n.unwrapped {
+				render(n.children)
+				continue
+			}
+			emit(n)
+			render(n.children)
+			if n.close != nil {
+				emit(n.close)
+			}
+		}
+	}
+	render(nodes)
+	return b
+}
+
+// Reduce returns a minimal version of src for which interesting still returns true, using hierarchical delta debugging on the tokens of src. Tokens between brackets, braces, parentheses, and template delimiters are removed together with their delimiters before their contents are reduced, so that statements and subtrees are removed at once, after which it tries to remove only the delimiters. This is useful to obtain a minimal reproduction of an input that crashes or fails to parse, see Failure.
+func Reduce(src []byte, interesting func([]byte) bool) []byte {
+	nodes := reduceTokens(src)
+	for {
+		changed := false
+		level := nodes
+		for 0 < len(level) {
+			if reduceLevel(src, nodes, level, interesting) {
+				changed = true
+			}
+			for _, n := range level {
+				// try to remove the delimiters of groups
+				if !n.removed && !n.unwrapped && (0 < len(n.children) || n.close != nil) {
+					n.unwrapped = true
+					if interesting(renderReduced(src, nodes)) {
+						changed = true
+					} else {
+						n.unwrapped = false
+					}
+				}
+			}
+			next := []*reduceNode{}
+			for _, n := range level {
+				if !n.removed {
+					next = append(next, n.children...)
+				}
+			}
+			level = next
+		}
+		if !changed {
+			break
+		}
+	}
+	return renderReduced(src, nodes)
+}
+
+// reduceLevel removes as many nodes of one level of the tree as possible using the ddmin algorithm.
+func reduceLevel(src []byte, nodes, level []*reduceNode, interesting func([]byte) bool) bool {
+	kept := []*reduceNode{}
+	for _, n := range level {
+		if !n.removed {
+			kept = append(kept, n)
+		}
+	}
+
+	changed := false
+	granularity := 2
+	for 0 < len(kept) {
+		if len(kept) < granularity {
+			granularity = len(kept)
+		}
+		removed := false
+		size := (len(kept) + granularity - 1) / granularity
+		for i := 0; i < len(kept); i += size {
+			j := i + size
+			if len(kept) < j {
+				j = len(kept)
+			}
+			for _, n := range kept[i:j] {
+				n.removed = true
+			}
+			if interesting(renderReduced(src, nodes)) {
+				kept = append(kept[:i:i], kept[j:]...)
+				removed = true
+				break
+			}
+			for _, n := range kept[i:j] {
+				n.removed = false
+			}
+		}
+		if removed {
+			changed = true
+			if 2 < granularity {
+				granularity--
+			}
+		} else if granularity < len(kept) {
+			granularity *= 2
+		} else {
+			break
+		}
+	}
+	return changed
+}
+
+// Failure returns the error message or panic of parsing src, or an empty string if it parses successfully.
+func Failure(src []byte, o Options) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint("panic: ", r)
+		}
+	}()
+
+	_, err := Parse(parse.NewInputBytes(parse.Copy(src)), o)
+	if err == nil || err == io.EOF {
+		return ""
+	} else if perr, ok := err.(*parse.Error); ok {
+		return perr.Message
+	}
+	return err.Error()
+}
+
+// SameFailure returns a predicate for Reduce that returns true for inputs that fail to parse with the same error message or panic as src.
+func SameFailure(src []byte, o Options) func([]byte) bool {
+	msg := Failure(src, o)
+	return func(b []byte) bool {
+		return msg != "" && Failure(b, o) == msg
+	}
+}
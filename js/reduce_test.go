@@ -0,0 +1,50 @@
+package js
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestReduce(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"var a = 1;\nfunction f(b, c) {\n\treturn b + c;\n}\nlet x = {a: 1, b: [1, 2, 3]};\nif (a) { f(a, /re/g) }\nconst y = 5 +;\nconsole.log(x)", "+;"},
+		{"a = `x${b + c}y`; d(e, f) }", "}"},
+		{"class A { m() { let x = 1; let x = 2; } }", "class A { m() { let x ; let x } }"},
+		{"var s = 'unterminated", "s = 'unterminated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			src := []byte(tt.js)
+			interesting := SameFailure(src, Options{})
+			test.That(t, interesting(src))
+
+			reduced := Reduce(src, interesting)
+			test.That(t, interesting(reduced))
+			test.String(t, string(reduced), tt.expected)
+		})
+	}
+}
+
+func TestReducePredicate(t *testing.T) {
+	// reduce to the tokens needed for a custom predicate
+	src := []byte("if (a) {\n\tfoo(bar, [1, 2, 3]);\n\treturn baz\n}")
+	reduced := Reduce(src, func(b []byte) bool {
+		return bytes.Contains(b, []byte("2")) && bytes.Contains(b, []byte("baz"))
+	})
+	test.String(t, string(reduced), "2 baz")
+
+	// tokens are kept apart when separated in the input
+	reduced = Reduce([]byte("return /* */ x"), func(b []byte) bool {
+		return bytes.Contains(b, []byte("return")) && bytes.Contains(b, []byte("x"))
+	})
+	test.String(t, string(reduced), "return x")
+
+	// successful parses are never interesting
+	test.That(t, !SameFailure([]byte("a"), Options{})([]byte("a")))
+	test.String(t, Failure([]byte("a"), Options{}), "")
+}
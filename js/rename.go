@@ -0,0 +1,125 @@
+package js
+
+import (
+	"sort"
+)
+
+var (
+	renameStartChars    = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$")
+	renameContinueChars = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$0123456789")
+)
+
+// Rename renames the variables that are declared in function and block scopes to the shortest available names, where the most used variables of a scope get the shortest names. Variables in the global scope are not renamed since they may be accessed by other scripts or exported, and neither are the variables in keep. A new name never shadows a variable of an outer scope that is used within the scope, and is never a reserved word. Scopes with a with statement or a direct call to eval, and all scopes that contain them, are not renamed as their variables may be accessed by name.
+func Rename(ast *AST, keep ...string) {
+	keepNames := map[string]bool{}
+	for _, name := range keep {
+		keepNames[name] = true
+	}
+
+	v := &renameVisitor{unsafe: map[*Scope]bool{}}
+	Walk(v, ast)
+	for _, scope := range v.scopes[1:] { // skip the global scope
+		if !v.unsafe[scope] {
+			renameScope(scope, keepNames)
+		}
+	}
+}
+
+// renameScope renames the declared variables of a scope. Its outer scopes must have been renamed before.
+func renameScope(scope *Scope, keep map[string]bool) {
+	taken := map[string]bool{}
+	for _, v := range scope.Undeclared {
+		taken[string(v.Name())] = true
+	}
+	vars := VarArray{}
+	for _, v := range scope.Declared {
+		if keep[string(v.Data)] {
+			taken[string(v.Data)] = true
+		} else if 0 < len(v.Data) && v.Data[0] != '#' {
+			vars = append(vars, v)
+		}
+	}
+	sort.Stable(VarsByUses(vars))
+
+	i := 0
+	for _, v := range vars {
+		for {
+			name := renameName(i)
+			i++
+			if !taken[string(name)] && !keep[string(name)] && !isRenameReserved(name) {
+				v.Data = name
+				break
+			}
+		}
+	}
+}
+
+// renameName returns the i-th shortest identifier.
+func renameName(i int) []byte {
+	name := []byte{renameStartChars[i%len(renameStartChars)]}
+	i /= len(renameStartChars)
+	for 0 < i {
+		i--
+		name = append(name, renameContinueChars[i%len(renameContinueChars)])
+		i /= len(renameContinueChars)
+	}
+	return name
+}
+
+// isRenameReserved returns true for names that cannot be used as a variable name or that have a special meaning for variables.
+func isRenameReserved(name []byte) bool {
+	if tt := Keyword(name); IsReservedWord(tt) || tt == LetToken || tt == StaticToken || tt == YieldToken || tt == AwaitToken {
+		return true
+	}
+	switch string(name) {
+	case "arguments", "eval", "enum", "implements", "interface", "package", "private", "protected", "public", "undefined", "NaN", "Infinity":
+		return true
+	}
+	return false
+}
+
+// renameVisitor collects the scopes of blocks and switch statements in pre-order, and marks the scopes that contain a with statement or a direct call to eval.
+type renameVisitor struct {
+	scopes []*Scope
+	stack  []*Scope
+	unsafe map[*Scope]bool
+}
+
+func (v *renameVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *BlockStmt:
+		v.push(&n.Scope)
+	case *SwitchStmt:
+		v.push(&n.Scope)
+	case *WithStmt:
+		v.markUnsafe()
+	case *CallExpr:
+		if x, ok := n.X.(*Var); ok {
+			for x.Link != nil {
+				x = x.Link
+			}
+			if x.Decl == NoDecl && string(x.Data) == "eval" {
+				v.markUnsafe()
+			}
+		}
+	}
+	return v
+}
+
+func (v *renameVisitor) Exit(n INode) {
+	switch n.(type) {
+	case *BlockStmt, *SwitchStmt:
+		v.stack = v.stack[:len(v.stack)-1]
+	}
+}
+
+func (v *renameVisitor) push(scope *Scope) {
+	v.scopes = append(v.scopes, scope)
+	v.stack = append(v.stack, scope)
+}
+
+func (v *renameVisitor) markUnsafe() {
+	for _, scope := range v.stack {
+		v.unsafe[scope] = true
+	}
+}
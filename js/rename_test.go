@@ -0,0 +1,68 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestRename(t *testing.T) {
+	var tests = []struct {
+		js       string
+		keep     []string
+		expected string
+	}{
+		{"var x = 1; function f(y) { return y }", nil, "var x = 1; function f(a) { return a; }"},
+		{"function f(p, q) { q(); q(); return p + q }", nil, "function f(b, a) { a(); a(); return b + a; }"},
+		{"function f(x) { return a + x }", nil, "function f(b) { return a + b; }"},
+		{"function f(x) { return function (y) { return x + y } }", nil, "function f(a) { return function (b) { return a + b; }; }"},
+		{"function f(x) { { let y = 1; g(y) } return x }", nil, "function f(a) { { let a = 1; g(a); } return a; }"}, // shadowing is harmless
+		{"function f(x) { { let y = 1; g(y, x) } }", nil, "function f(a) { { let b = 1; g(b, a); } }"},
+		{"function f(x, keepMe) { return x + keepMe }", []string{"keepMe"}, "function f(a, keepMe) { return a + keepMe; }"},
+		{"function f(x) { with (o) { x } }", nil, "function f(x) { with (o) { x; } }"},
+		{"function f(x) { eval('x'); function g(y) { return y } }", nil, "function f(x) { eval('x'); function g(a) { return a; } }"},
+		{"function f(x) { var eval = g; eval(x) }", nil, "function f(a) { var b = g; b(a); }"},
+		{"const o = {p}; function f(p) { return {p} }", nil, "const o = {p}; function f(a) { return {p: a}; }"},
+		{"let f = (u) => { switch (u) { case 1: let z = u; return z } }", nil, "let f = (a) => { switch (a) { case 1: let b = a; return b; } }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			Rename(ast, tt.keep...)
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			test.Error(t, err)
+			test.String(t, ast.JSString(), expected.JSString())
+		})
+	}
+}
+
+func TestRenameReserved(t *testing.T) {
+	// a scope with more variables than single-character names skips reserved words such as do, if, and in
+	js := "function f() {"
+	for i := 0; i < 54*20; i++ {
+		js += " var v_" + string(renameName(i)) + ";"
+	}
+	js += " }"
+	ast, err := Parse(parse.NewInputString(js), Options{})
+	test.Error(t, err)
+	Rename(ast)
+	names := map[string]bool{}
+	for _, v := range ast.List[0].(*FuncDecl).Body.Declared {
+		test.That(t, !isRenameReserved(v.Data), string(v.Data))
+		test.That(t, !names[string(v.Data)], "duplicate", string(v.Data))
+		names[string(v.Data)] = true
+	}
+	_, err = Parse(parse.NewInputString(ast.JSString()), Options{})
+	test.Error(t, err)
+}
+
+func TestRenameName(t *testing.T) {
+	test.String(t, string(renameName(0)), "a")
+	test.String(t, string(renameName(53)), "$")
+	test.String(t, string(renameName(54)), "aa")
+	test.String(t, string(renameName(55)), "ba")
+	test.String(t, string(renameName(54*64+53)), "$9")
+	test.String(t, string(renameName(54*65)), "aaa")
+}
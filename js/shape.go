@@ -0,0 +1,242 @@
+package js
+
+import (
+	"bytes"
+	"sort"
+)
+
+// ObjectShape is a set of object literals with the same static property names in the same order, such as {a: 1, b: 2} and {a: x, b: y}. Repeated shapes are candidates for deduplication or for a factory function.
+type ObjectShape struct {
+	Keys     [][]byte      // property names, prefixed by get or set for accessors
+	Objects  []*ObjectExpr // object literals of this shape in source order
+	Constant int           // number of objects whose property values are all constant
+}
+
+// ArrayLiteral is a large array literal.
+type ArrayLiteral struct {
+	Array    *ArrayExpr
+	Constant bool // all elements are constant, so that the array can be hoisted
+}
+
+// LiteralStats are the shape statistics of the object and array literals in a program.
+type LiteralStats struct {
+	Objects, Arrays int // number of object and array literals, excluding assignment patterns
+
+	Shapes      []ObjectShape  // shapes that occur more than once, by descending number of objects
+	LargeArrays []ArrayLiteral // array literals with at least LargeArrayLen elements in source order
+
+	ObjectSpreads int // spread properties in object literals, as in {...a}
+	ArraySpreads  int // spread elements in array literals, as in [...a]
+	ArgSpreads    int // spread arguments in calls, as in f(...a)
+}
+
+// LiteralStatsVisitor is an IVisitor that collects the shapes of object and array literals, see AnalyzeLiterals. Array and object literals that are assignment targets, as in [a, b] = c, are not counted.
+type LiteralStatsVisitor struct {
+	LargeArrayLen int // minimum number of elements of large array literals
+
+	stats  LiteralStats
+	shapes map[string]int // index into stats.Shapes
+}
+
+// NewLiteralStatsVisitor returns a new LiteralStatsVisitor that reports array literals with at least largeArrayLen elements.
+func NewLiteralStatsVisitor(largeArrayLen int) *LiteralStatsVisitor {
+	return &LiteralStatsVisitor{
+		LargeArrayLen: largeArrayLen,
+		shapes:        map[string]int{},
+	}
+}
+
+// AnalyzeLiterals returns the shape statistics of the object and array literals in a node, reporting array literals with at least largeArrayLen elements.
+func AnalyzeLiterals(n INode, largeArrayLen int) LiteralStats {
+	v := NewLiteralStatsVisitor(largeArrayLen)
+	Walk(v, n)
+	return v.Stats()
+}
+
+// Stats returns the statistics of the visited nodes.
+func (v *LiteralStatsVisitor) Stats() LiteralStats {
+	stats := v.stats
+	stats.Shapes = []ObjectShape{}
+	for _, shape := range v.stats.Shapes {
+		if 1 < len(shape.Objects) {
+			stats.Shapes = append(stats.Shapes, shape)
+		}
+	}
+	sort.SliceStable(stats.Shapes, func(i, j int) bool {
+		return len(stats.Shapes[i].Objects) > len(stats.Shapes[j].Objects)
+	})
+	return stats
+}
+
+// Enter implements IVisitor.
+func (v *LiteralStatsVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		if n.Op == EqToken && isLiteralPattern(n.X) {
+			v.walkPattern(n.X)
+			Walk(v, n.Y)
+			return nil
+		}
+	case *ForInStmt:
+		if isLiteralPattern(n.Init) {
+			v.walkPattern(n.Init)
+			Walk(v, n.Value)
+			Walk(v, n.Body)
+			return nil
+		}
+	case *ForOfStmt:
+		if isLiteralPattern(n.Init) {
+			v.walkPattern(n.Init)
+			Walk(v, n.Value)
+			Walk(v, n.Body)
+			return nil
+		}
+	case *ObjectExpr:
+		v.stats.Objects++
+		v.addShape(n)
+	case *ArrayExpr:
+		v.stats.Arrays++
+		for _, item := range n.List {
+			if item.Spread {
+				v.stats.ArraySpreads++
+			}
+		}
+		if 0 < v.LargeArrayLen && v.LargeArrayLen <= len(n.List) {
+			v.stats.LargeArrays = append(v.stats.LargeArrays, ArrayLiteral{n, isConstantExpr(n)})
+		}
+	case *Args:
+		for _, arg := range n.List {
+			if arg.Rest {
+				v.stats.ArgSpreads++
+			}
+		}
+	}
+	return v
+}
+
+// Exit implements IVisitor.
+func (v *LiteralStatsVisitor) Exit(n INode) {}
+
+// walkPattern walks the default values and computed property names of an assignment pattern without counting its array and object literals.
+func (v *LiteralStatsVisitor) walkPattern(n IExpr) {
+	switch n := n.(type) {
+	case *ArrayExpr:
+		for _, item := range n.List {
+			v.walkPattern(item.Value)
+		}
+	case *ObjectExpr:
+		for _, item := range n.List {
+			if item.Name != nil && item.Name.IsComputed() {
+				Walk(v, item.Name.Computed)
+			}
+			v.walkPattern(item.Value)
+			Walk(v, item.Init)
+		}
+	case *BinaryExpr:
+		if n.Op == EqToken {
+			v.walkPattern(n.X)
+			Walk(v, n.Y)
+		} else {
+			Walk(v, n)
+		}
+	default:
+		Walk(v, n)
+	}
+}
+
+func (v *LiteralStatsVisitor) addShape(n *ObjectExpr) {
+	keys := [][]byte{}
+	for _, item := range n.List {
+		if item.Spread {
+			v.stats.ObjectSpreads++
+			return
+		}
+		key, ok := propertyKey(item)
+		if !ok {
+			return
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	id := string(bytes.Join(keys, []byte{0}))
+	i, ok := v.shapes[id]
+	if !ok {
+		i = len(v.stats.Shapes)
+		v.shapes[id] = i
+		v.stats.Shapes = append(v.stats.Shapes, ObjectShape{Keys: keys})
+	}
+	v.stats.Shapes[i].Objects = append(v.stats.Shapes[i].Objects, n)
+	if isConstantExpr(n) {
+		v.stats.Shapes[i].Constant++
+	}
+}
+
+// propertyKey returns the static name of a property, where string names are unquoted so that {a: 1} and {"a": 1} have the same shape. It returns false for spread properties and computed names.
+func propertyKey(item Property) ([]byte, bool) {
+	name := item.Name
+	prefix := []byte{}
+	if name == nil {
+		if method, ok := item.Value.(*MethodDecl); ok {
+			name = &method.Name.PropertyName
+			if method.Get {
+				prefix = []byte("get ")
+			} else if method.Set {
+				prefix = []byte("set ")
+			}
+		} else if v, ok := item.Value.(*Var); ok {
+			return v.Data, true // shorthand property
+		} else {
+			return nil, false
+		}
+	}
+	if name.IsComputed() {
+		return nil, false
+	}
+	data := name.Literal.Data
+	if name.Literal.TokenType == StringToken && 2 <= len(data) && bytes.IndexByte(data, '\\') == -1 {
+		data = data[1 : len(data)-1]
+	}
+	return append(prefix, data...), true
+}
+
+// isLiteralPattern returns true if the expression is an array or object literal used as an assignment pattern.
+func isLiteralPattern(n IExpr) bool {
+	switch n.(type) {
+	case *ArrayExpr, *ObjectExpr:
+		return true
+	}
+	return false
+}
+
+// isConstantExpr returns true if the expression is a primitive literal, or an array or object literal of constant values with static property names. Regular expressions are not constant since each evaluation creates a new object.
+func isConstantExpr(n IExpr) bool {
+	switch n := n.(type) {
+	case *LiteralExpr:
+		return n.TokenType != RegExpToken
+	case *UnaryExpr:
+		x, ok := n.X.(*LiteralExpr)
+		return ok && x.TokenType != RegExpToken && (n.Op == NegToken || n.Op == PosToken || n.Op == NotToken)
+	case *TemplateExpr:
+		return n.Tag == nil && len(n.List) == 0
+	case *GroupExpr:
+		return isConstantExpr(n.X)
+	case *ArrayExpr:
+		for _, item := range n.List {
+			if item.Spread || item.Value != nil && !isConstantExpr(item.Value) {
+				return false
+			}
+		}
+		return true
+	case *ObjectExpr:
+		for _, item := range n.List {
+			if item.Spread || item.Name == nil || item.Name.IsComputed() || !isConstantExpr(item.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
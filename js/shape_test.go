@@ -0,0 +1,52 @@
+package js
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestAnalyzeLiterals(t *testing.T) {
+	js := `
+	var points = [{x: 1, y: 2}, {"x": 3, y: 4}, {x: a, y}, {y: 5, x: 6}];
+	var cfg = {get x() {}, y: 1}, other = {x: 1, get y() {}};
+	var big = [1, 2, -3, "four", [5], {six: 6}], regexps = [/a/, /b/, /c/, /d/, /e/, /f/];
+	var copy = {...cfg, z: 1}, all = [...points, ...big];
+	[a, {b}] = [1, {c: 2}];
+	for ({x, y} of points) f(...all);
+	var computed = {[k]: 1}, shorthand = {x, y};`
+	ast, err := Parse(parse.NewInputString(js), Options{})
+	test.Error(t, err)
+
+	stats := AnalyzeLiterals(ast, 6)
+	test.T(t, stats.Objects, 11, "objects")
+	test.T(t, stats.Arrays, 6, "arrays")
+	test.T(t, stats.ObjectSpreads, 1, "object spreads")
+	test.T(t, stats.ArraySpreads, 2, "array spreads")
+	test.T(t, stats.ArgSpreads, 1, "argument spreads")
+
+	test.T(t, len(stats.Shapes), 1, "shapes")
+	test.String(t, string(bytes.Join(stats.Shapes[0].Keys, []byte(","))), "x,y")
+	test.T(t, len(stats.Shapes[0].Objects), 4, "objects of shape")
+	test.T(t, stats.Shapes[0].Constant, 2, "constant objects of shape")
+
+	test.T(t, len(stats.LargeArrays), 2, "large arrays")
+	test.That(t, stats.LargeArrays[0].Constant, "big is constant")
+	test.That(t, !stats.LargeArrays[1].Constant, "regexps is not constant")
+}
+
+func TestLiteralStatsVisitor(t *testing.T) {
+	ast, err := Parse(parse.NewInputString(`f({a: 1}); g({a: 2})`), Options{})
+	test.Error(t, err)
+
+	v := NewLiteralStatsVisitor(0)
+	for _, stmt := range ast.List {
+		Walk(v, stmt)
+	}
+	stats := v.Stats()
+	test.T(t, len(stats.Shapes), 1)
+	test.T(t, len(stats.Shapes[0].Objects), 2)
+	test.T(t, len(stats.LargeArrays), 0)
+}
@@ -0,0 +1,501 @@
+package js
+
+// Snapshot is an immutable copy of an AST that can be shared between goroutines, such as to run several analysis passes in parallel over the same parse result. The snapshot owns all its memory, including the identifier and literal data that otherwise points into the input buffer, and its nodes keep the same addresses for the lifetime of the snapshot. Passes that transform the AST must work on a copy obtained from Thaw.
+type Snapshot struct {
+	ast *AST
+}
+
+// Freeze returns a snapshot of the AST. The AST and its input buffer may be modified or reused afterwards without affecting the snapshot.
+func Freeze(ast *AST) *Snapshot {
+	return &Snapshot{newCloner().ast(ast)}
+}
+
+// AST returns the AST of the snapshot. It is shared by all callers and must not be modified, including its scopes and variables, but it may be read and walked concurrently.
+func (s *Snapshot) AST() *AST {
+	return s.ast
+}
+
+// Thaw returns a deep copy of the AST of the snapshot that may be modified freely, such as by a transformation pass, without affecting the snapshot or other copies. Every call copies all nodes, which takes time and memory linear in the size of the AST, so passes that only read the AST should use AST instead.
+func (s *Snapshot) Thaw() *AST {
+	return newCloner().ast(s.ast)
+}
+
+// cloner makes deep copies of AST nodes. Variables, scopes, and variable declarations that are referenced from multiple places are copied once, and the references between them are remapped to the copies.
+type cloner struct {
+	buf      []byte
+	vars     map[*Var]*Var
+	scopes   map[*Scope]*Scope
+	varDecls map[*VarDecl]*VarDecl
+
+	newScopes   []*Scope   // copied scopes whose Parent, Func, and VarDecls are remapped afterwards
+	newVarDecls []*VarDecl // copied variable declarations whose Scope is remapped afterwards
+}
+
+func newCloner() *cloner {
+	return &cloner{
+		vars:     map[*Var]*Var{},
+		scopes:   map[*Scope]*Scope{},
+		varDecls: map[*VarDecl]*VarDecl{},
+	}
+}
+
+func (c *cloner) ast(n *AST) *AST {
+	if n == nil {
+		return nil
+	}
+	m := &AST{}
+	c.blockStmt(&m.BlockStmt, &n.BlockStmt)
+
+	// remap references to scopes and variable declarations after all nodes have been copied, which may copy more scopes and declarations
+	for i, j := 0, 0; i < len(c.newScopes) || j < len(c.newVarDecls); {
+		if i < len(c.newScopes) {
+			s := c.newScopes[i]
+			s.Parent = c.scopeRef(s.Parent)
+			s.Func = c.scopeRef(s.Func)
+			if s.VarDecls != nil {
+				varDecls := make([]*VarDecl, len(s.VarDecls))
+				for k, varDecl := range s.VarDecls {
+					varDecls[k] = c.varDecl(varDecl)
+				}
+				s.VarDecls = varDecls
+			}
+			i++
+		} else {
+			c.newVarDecls[j].Scope = c.scopeRef(c.newVarDecls[j].Scope)
+			j++
+		}
+	}
+	return m
+}
+
+// bytes copies b into memory owned by the cloner. The capacity of the copy is limited to its length so that appending to it does not overwrite other data.
+func (c *cloner) bytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	if cap(c.buf)-len(c.buf) < len(b) {
+		size := 4096
+		if size < len(b) {
+			size = len(b)
+		}
+		c.buf = make([]byte, 0, size)
+	}
+	start := len(c.buf)
+	c.buf = append(c.buf, b...)
+	return c.buf[start:len(c.buf):len(c.buf)]
+}
+
+func (c *cloner) v(v *Var) *Var {
+	if v == nil {
+		return nil
+	} else if w, ok := c.vars[v]; ok {
+		return w
+	}
+	w := &Var{}
+	*w = *v
+	c.vars[v] = w
+	w.Data = c.bytes(v.Data)
+	w.Link = c.v(v.Link)
+	return w
+}
+
+func (c *cloner) varArray(vs VarArray) VarArray {
+	if vs == nil {
+		return nil
+	}
+	ws := make(VarArray, len(vs))
+	for i, v := range vs {
+		ws[i] = c.v(v)
+	}
+	return ws
+}
+
+func (c *cloner) scope(dst, src *Scope) {
+	*dst = *src
+	dst.Declared = c.varArray(src.Declared)
+	dst.Undeclared = c.varArray(src.Undeclared)
+	c.scopes[src] = dst
+	c.newScopes = append(c.newScopes, dst)
+}
+
+// scopeRef returns the copy of a referenced scope. Scopes that are not part of the tree, such as the scope of the module while it was being parsed, are copied separately.
+func (c *cloner) scopeRef(s *Scope) *Scope {
+	if s == nil {
+		return nil
+	} else if t, ok := c.scopes[s]; ok {
+		return t
+	}
+	t := &Scope{}
+	c.scope(t, s)
+	return t
+}
+
+func (c *cloner) blockStmt(dst, src *BlockStmt) {
+	dst.List = c.stmts(src.List)
+	c.scope(&dst.Scope, &src.Scope)
+}
+
+func (c *cloner) block(n *BlockStmt) *BlockStmt {
+	if n == nil {
+		return nil
+	}
+	m := &BlockStmt{}
+	c.blockStmt(m, n)
+	return m
+}
+
+func (c *cloner) varDecl(n *VarDecl) *VarDecl {
+	if n == nil {
+		return nil
+	} else if m, ok := c.varDecls[n]; ok {
+		return m
+	}
+	m := &VarDecl{}
+	*m = *n
+	c.varDecls[n] = m
+	c.newVarDecls = append(c.newVarDecls, m)
+	m.List = c.bindingElements(n.List)
+	return m
+}
+
+func (c *cloner) stmts(list []IStmt) []IStmt {
+	if list == nil {
+		return nil
+	}
+	m := make([]IStmt, len(list))
+	for i, item := range list {
+		m[i] = c.stmt(item)
+	}
+	return m
+}
+
+func (c *cloner) stmt(n IStmt) IStmt {
+	if n == nil {
+		return nil
+	}
+	return c.node(n).(IStmt)
+}
+
+func (c *cloner) expr(n IExpr) IExpr {
+	if n == nil {
+		return nil
+	}
+	return c.node(n).(IExpr)
+}
+
+func (c *cloner) binding(n IBinding) IBinding {
+	if n == nil {
+		return nil
+	}
+	return c.node(n).(IBinding)
+}
+
+func (c *cloner) propertyName(n PropertyName) PropertyName {
+	n.Literal.Data = c.bytes(n.Literal.Data)
+	n.Computed = c.expr(n.Computed)
+	return n
+}
+
+func (c *cloner) classElementName(n ClassElementName) ClassElementName {
+	n.PropertyName = c.propertyName(n.PropertyName)
+	n.Private = c.v(n.Private)
+	return n
+}
+
+func (c *cloner) bindingElements(list []BindingElement) []BindingElement {
+	if list == nil {
+		return nil
+	}
+	m := make([]BindingElement, len(list))
+	for i, item := range list {
+		m[i] = item
+		m[i].Binding = c.binding(item.Binding)
+		m[i].Default = c.expr(item.Default)
+	}
+	return m
+}
+
+func (c *cloner) params(n Params) Params {
+	n.List = c.bindingElements(n.List)
+	n.Rest = c.binding(n.Rest)
+	return n
+}
+
+func (c *cloner) aliases(list []Alias) []Alias {
+	if list == nil {
+		return nil
+	}
+	m := make([]Alias, len(list))
+	for i, item := range list {
+		m[i] = Alias{c.bytes(item.Name), c.bytes(item.Binding)}
+	}
+	return m
+}
+
+func (c *cloner) args(n Args) Args {
+	if n.List != nil {
+		list := make([]Arg, len(n.List))
+		for i, item := range n.List {
+			list[i] = item
+			list[i].Value = c.expr(item.Value)
+		}
+		n.List = list
+	}
+	return n
+}
+
+func (c *cloner) methodDecl(n *MethodDecl) *MethodDecl {
+	if n == nil {
+		return nil
+	}
+	m := &MethodDecl{}
+	*m = *n
+	m.Name = c.classElementName(n.Name)
+	m.Params = c.params(n.Params)
+	c.blockStmt(&m.Body, &n.Body)
+	return m
+}
+
+func (c *cloner) matchPattern(n *MatchPattern) *MatchPattern {
+	if n == nil {
+		return nil
+	}
+	m := &MatchPattern{}
+	*m = c.matchPatternValue(*n)
+	return m
+}
+
+func (c *cloner) matchPatternValue(n MatchPattern) MatchPattern {
+	n.X = c.expr(n.X)
+	if n.List != nil {
+		list := make([]MatchPattern, len(n.List))
+		for i, item := range n.List {
+			list[i] = c.matchPatternValue(item)
+		}
+		n.List = list
+	}
+	if n.Props != nil {
+		props := make([]MatchProperty, len(n.Props))
+		for i, item := range n.Props {
+			props[i] = MatchProperty{c.propertyName(item.Name), c.matchPattern(item.Value)}
+		}
+		n.Props = props
+	}
+	n.Rest = c.matchPattern(n.Rest)
+	return n
+}
+
+func (c *cloner) matchClause(n *MatchClause) *MatchClause {
+	if n == nil {
+		return nil
+	}
+	m := &MatchClause{Pattern: c.matchPattern(n.Pattern), Body: c.expr(n.Body)}
+	c.scope(&m.Scope, &n.Scope)
+	return m
+}
+
+func (c *cloner) node(n INode) INode {
+	switch n := n.(type) {
+	case *AST:
+		return c.ast(n)
+	case *Var:
+		return c.v(n)
+	case *BlockStmt:
+		return c.block(n)
+	case *Comment:
+		return &Comment{c.bytes(n.Value)}
+	case *EmptyStmt:
+		return &EmptyStmt{}
+	case *ExprStmt:
+		return &ExprStmt{c.expr(n.Value)}
+	case *IfStmt:
+		return &IfStmt{c.expr(n.Cond), c.stmt(n.Body), c.stmt(n.Else)}
+	case *DoWhileStmt:
+		return &DoWhileStmt{c.expr(n.Cond), c.stmt(n.Body)}
+	case *WhileStmt:
+		return &WhileStmt{c.expr(n.Cond), c.stmt(n.Body)}
+	case *ForStmt:
+		return &ForStmt{c.expr(n.Init), c.expr(n.Cond), c.expr(n.Post), c.block(n.Body)}
+	case *ForInStmt:
+		return &ForInStmt{c.expr(n.Init), c.expr(n.Value), c.block(n.Body)}
+	case *ForOfStmt:
+		return &ForOfStmt{n.Await, c.expr(n.Init), c.expr(n.Value), c.block(n.Body)}
+	case *SwitchStmt:
+		m := &SwitchStmt{Init: c.expr(n.Init)}
+		if n.List != nil {
+			m.List = make([]CaseClause, len(n.List))
+			for i, clause := range n.List {
+				m.List[i] = CaseClause{clause.TokenType, c.expr(clause.Cond), c.stmts(clause.List)}
+			}
+		}
+		c.scope(&m.Scope, &n.Scope)
+		return m
+	case *BranchStmt:
+		return &BranchStmt{n.Type, c.bytes(n.Label)}
+	case *ReturnStmt:
+		return &ReturnStmt{c.expr(n.Value)}
+	case *WithStmt:
+		return &WithStmt{c.expr(n.Cond), c.stmt(n.Body)}
+	case *LabelledStmt:
+		return &LabelledStmt{c.bytes(n.Label), c.stmt(n.Value)}
+	case *ThrowStmt:
+		return &ThrowStmt{c.expr(n.Value)}
+	case *TryStmt:
+		return &TryStmt{c.block(n.Body), c.binding(n.Binding), n.BindingSpan, c.block(n.Catch), c.block(n.Finally)}
+	case *DebuggerStmt:
+		return &DebuggerStmt{}
+	case *ImportStmt:
+		return &ImportStmt{c.aliases(n.List), c.bytes(n.Default), c.bytes(n.Module)}
+	case *ExportStmt:
+		return &ExportStmt{c.aliases(n.List), c.bytes(n.Module), n.Default, c.expr(n.Decl)}
+	case *DirectivePrologueStmt:
+		return &DirectivePrologueStmt{c.bytes(n.Value)}
+	case *BindingArray:
+		return &BindingArray{c.bindingElements(n.List), c.binding(n.Rest), n.RestSpan}
+	case *BindingObject:
+		m := &BindingObject{Rest: c.binding(n.Rest), RestSpan: n.RestSpan}
+		if n.List != nil {
+			m.List = make([]BindingObjectItem, len(n.List))
+			for i, item := range n.List {
+				if item.Key != nil {
+					key := c.propertyName(*item.Key)
+					m.List[i].Key = &key
+				}
+				m.List[i].Value = c.bindingElements([]BindingElement{item.Value})[0]
+			}
+		}
+		return m
+	case *VarDecl:
+		return c.varDecl(n)
+	case *FuncDecl:
+		m := &FuncDecl{}
+		*m = *n
+		m.Name = c.v(n.Name)
+		m.Params = c.params(n.Params)
+		c.blockStmt(&m.Body, &n.Body)
+		return m
+	case *MethodDecl:
+		return c.methodDecl(n)
+	case *ClassDecl:
+		m := &ClassDecl{Name: c.v(n.Name), Extends: c.expr(n.Extends)}
+		if n.List != nil {
+			m.List = make([]ClassElement, len(n.List))
+			for i, item := range n.List {
+				m.List[i] = item
+				m.List[i].StaticBlock = c.block(item.StaticBlock)
+				m.List[i].Method = c.methodDecl(item.Method)
+				m.List[i].Field.Name = c.classElementName(item.Field.Name)
+				m.List[i].Field.Init = c.expr(item.Field.Init)
+			}
+		}
+		c.scope(&m.Scope, &n.Scope)
+		return m
+	case *LiteralExpr:
+		return &LiteralExpr{n.TokenType, c.bytes(n.Data), n.Span}
+	case LiteralExpr:
+		return LiteralExpr{n.TokenType, c.bytes(n.Data), n.Span} // property name of a DotExpr
+	case *ArrayExpr:
+		m := &ArrayExpr{}
+		if n.List != nil {
+			m.List = make([]Element, len(n.List))
+			for i, item := range n.List {
+				m.List[i] = item
+				m.List[i].Value = c.expr(item.Value)
+			}
+		}
+		return m
+	case *ObjectExpr:
+		m := &ObjectExpr{}
+		if n.List != nil {
+			m.List = make([]Property, len(n.List))
+			for i, item := range n.List {
+				m.List[i] = item
+				if item.Name != nil {
+					name := c.propertyName(*item.Name)
+					m.List[i].Name = &name
+				}
+				m.List[i].Value = c.expr(item.Value)
+				m.List[i].Init = c.expr(item.Init)
+			}
+		}
+		return m
+	case *TemplateExpr:
+		m := &TemplateExpr{}
+		*m = *n
+		m.Tag = c.expr(n.Tag)
+		if n.List != nil {
+			m.List = make([]TemplatePart, len(n.List))
+			for i, item := range n.List {
+				m.List[i] = item
+				m.List[i].Value = c.bytes(item.Value)
+				m.List[i].Expr = c.expr(item.Expr)
+			}
+		}
+		m.Tail = c.bytes(n.Tail)
+		return m
+	case *GroupExpr:
+		return &GroupExpr{c.expr(n.X)}
+	case *IndexExpr:
+		return &IndexExpr{c.expr(n.X), c.expr(n.Y), n.Prec, n.Optional}
+	case *DotExpr:
+		return &DotExpr{c.expr(n.X), c.expr(n.Y), n.Prec, n.Optional}
+	case *NewTargetExpr:
+		return &NewTargetExpr{}
+	case *ImportMetaExpr:
+		return &ImportMetaExpr{}
+	case *NewExpr:
+		m := &NewExpr{X: c.expr(n.X)}
+		if n.Args != nil {
+			args := c.args(*n.Args)
+			m.Args = &args
+		}
+		return m
+	case *CallExpr:
+		return &CallExpr{c.expr(n.X), c.args(n.Args), n.Optional}
+	case *UnaryExpr:
+		return &UnaryExpr{n.Op, c.expr(n.X)}
+	case *BinaryExpr:
+		return &BinaryExpr{n.Op, c.expr(n.X), c.expr(n.Y)}
+	case *CondExpr:
+		return &CondExpr{c.expr(n.Cond), c.expr(n.X), c.expr(n.Y)}
+	case *YieldExpr:
+		return &YieldExpr{n.Generator, c.expr(n.X)}
+	case *ArrowFunc:
+		m := &ArrowFunc{Async: n.Async, Params: c.params(n.Params)}
+		c.blockStmt(&m.Body, &n.Body)
+		return m
+	case *CommaExpr:
+		m := &CommaExpr{}
+		if n.List != nil {
+			m.List = make([]IExpr, len(n.List))
+			for i, item := range n.List {
+				m.List[i] = c.expr(item)
+			}
+		}
+		return m
+	case *CommentedExpr:
+		return &CommentedExpr{c.bytes(n.Comment), c.expr(n.Expr)}
+	case *PipelineExpr:
+		return &PipelineExpr{c.expr(n.X), c.expr(n.Body)}
+	case *TopicRefExpr:
+		return &TopicRefExpr{}
+	case *DoExpr:
+		m := &DoExpr{}
+		c.blockStmt(&m.Body, &n.Body)
+		return m
+	case *MatchExpr:
+		m := &MatchExpr{Subject: c.expr(n.Subject)}
+		if n.Clauses != nil {
+			m.Clauses = make([]*MatchClause, len(n.Clauses))
+			for i, clause := range n.Clauses {
+				m.Clauses[i] = c.matchClause(clause)
+			}
+		}
+		return m
+	case *MatchClause:
+		return c.matchClause(n)
+	case *MatchPattern:
+		return c.matchPattern(n)
+	}
+	return n
+}
@@ -0,0 +1,175 @@
+package js
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+// nodeVisitor collects all nodes and the scopes of blocks, functions, and variable declarations.
+type nodeVisitor struct {
+	nodes  []INode
+	scopes []*Scope
+	vars   []*Var
+}
+
+func (v *nodeVisitor) Enter(n INode) IVisitor {
+	v.nodes = append(v.nodes, n)
+	switch n := n.(type) {
+	case *BlockStmt:
+		v.scopes = append(v.scopes, &n.Scope)
+		v.vars = append(v.vars, n.Scope.Declared...)
+		v.vars = append(v.vars, n.Scope.Undeclared...)
+	case *VarDecl:
+		v.scopes = append(v.scopes, n.Scope)
+	case *MatchClause:
+		v.scopes = append(v.scopes, &n.Scope)
+		v.vars = append(v.vars, n.Scope.Declared...)
+	case *Var:
+		v.vars = append(v.vars, n)
+	}
+	return v
+}
+
+func (v *nodeVisitor) Exit(n INode) {}
+
+func TestSnapshot(t *testing.T) {
+	js := "/*! license */ 'use strict'; import a, {b as c} from 'm'; export default class K extends a { #p = 1; static { this.x = `t${c}u`; } get q() { return this.#p } }\n" +
+		"function f(x, [y = 2] = [], {z, ...r}, ...rest) { if (x) { var v = y } else for (let i of z) try { throw i } catch ({e}) {} finally { debugger } return v }\n" +
+		"switch (f?.(1, ...[2])) { case 1: lbl: while (0) break lbl; default: new f; }\n" +
+		"const g = async (u) => { for (var w in u) yield_(w); }, o = {k, [c]: 1, m() {}, ...a}; (g, o.k, o[c] ? -1 : +2, /re/g);"
+	src := []byte(js)
+	ast, err := Parse(parse.NewInputBytes(src), Options{})
+	test.Error(t, err)
+	expected := ast.JSString()
+
+	s := Freeze(ast)
+	test.String(t, s.AST().JSString(), expected)
+	test.T(t, s.AST(), s.AST(), "pointer stable")
+
+	// the snapshot does not share memory with the original AST and input
+	for i := range src {
+		src[i] = '?'
+	}
+	ast.List = nil
+	test.String(t, s.AST().JSString(), expected)
+
+	// thawed copies do not share nodes or variables with the snapshot
+	thawed := s.Thaw()
+	test.String(t, thawed.JSString(), expected)
+	testSnapshotUnshared(t, s.AST(), thawed)
+
+	// modifying a thawed copy does not affect the snapshot
+	thawed.List = thawed.List[:1]
+	thawed.Declared[0].Data[0] = 'Z'
+	test.String(t, s.AST().JSString(), expected)
+}
+
+// testSnapshotUnshared tests that the copy does not share nodes, scopes, or variables with the original AST.
+func testSnapshotUnshared(t *testing.T, ast, thawed *AST) {
+	t.Helper()
+	orig, copied := &nodeVisitor{}, &nodeVisitor{}
+	Walk(orig, ast)
+	Walk(copied, thawed)
+	test.T(t, len(copied.nodes), len(orig.nodes))
+	shared := map[interface{}]bool{}
+	for _, n := range orig.nodes {
+		if reflect.TypeOf(n).Kind() == reflect.Ptr && reflect.TypeOf(n).Elem().Size() != 0 {
+			shared[n] = true
+		}
+	}
+	for _, scope := range orig.scopes {
+		shared[scope] = true
+	}
+	for _, v := range orig.vars {
+		shared[v] = true
+	}
+	for _, n := range copied.nodes {
+		if reflect.TypeOf(n).Kind() == reflect.Ptr {
+			test.That(t, !shared[n], "shared node", n)
+		}
+	}
+	for _, scope := range copied.scopes {
+		test.That(t, !shared[scope], "shared scope")
+		test.That(t, !shared[scope.Parent] && !shared[scope.Func], "shared parent scope")
+		for _, varDecl := range scope.VarDecls {
+			test.That(t, !shared[varDecl], "shared variable declaration")
+		}
+	}
+	for _, v := range copied.vars {
+		test.That(t, !shared[v], "shared variable", string(v.Data))
+	}
+}
+
+func TestSnapshotScopes(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("function f(a) { { var b = a } return b }"), Options{})
+	test.Error(t, err)
+	thawed := Freeze(ast).Thaw()
+
+	f := thawed.List[0].(*FuncDecl)
+	test.That(t, f.Body.Scope.Parent != nil)
+	test.T(t, f.Body.Scope.Func, &f.Body.Scope)
+	inner := f.Body.List[0].(*BlockStmt)
+	test.T(t, inner.Scope.Parent, &f.Body.Scope)
+	test.T(t, len(f.Body.Scope.VarDecls), 1)
+	varDecl := inner.List[0].(*VarDecl)
+	test.T(t, f.Body.Scope.VarDecls[0], varDecl)
+	test.T(t, varDecl.Scope, &inner.Scope)
+	test.T(t, varDecl.List[0].Binding.(*Var), f.Body.Scope.Declared[1])
+	test.T(t, inner.Scope.Undeclared[1], f.Body.Scope.Declared[0], "a")
+}
+
+func TestSnapshotExperiments(t *testing.T) {
+	js := "x = match (a |> f(%, 'p')) { when { k: let y, ...let r }: do { let z = y; z + r.q }; when [1, ...]: 'b'; default: a }"
+	src := []byte(js)
+	ast, err := Parse(parse.NewInputBytes(src), Options{Experiments: PipelineExperiment | DoExpressionsExperiment | PatternMatchingExperiment})
+	test.Error(t, err)
+	expected := ast.JSString()
+
+	s := Freeze(ast)
+	testSnapshotUnshared(t, ast, s.AST())
+	for i := range src {
+		src[i] = '?'
+	}
+	test.String(t, s.AST().JSString(), expected)
+
+	thawed := s.Thaw()
+	test.String(t, thawed.JSString(), expected)
+	testSnapshotUnshared(t, s.AST(), thawed)
+
+	match := thawed.List[0].(*ExprStmt).Value.(*BinaryExpr).Y.(*MatchExpr)
+	clause := match.Clauses[0]
+	test.T(t, clause.Pattern.Props[0].Value.X.(*Var), clause.Scope.Declared[0], "y")
+	do := clause.Body.(*DoExpr)
+	test.T(t, do.Body.Scope.Parent, &clause.Scope)
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("var a = [1, {b: 2}]; function f(c) { return a[c] + c }"), Options{})
+	test.Error(t, err)
+	expected := ast.JSString()
+	f, err := Parse(parse.NewInputString("function f(c) { return a[c] + c }"), Options{})
+	test.Error(t, err)
+
+	s := Freeze(ast)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			stats := AnalyzeLiterals(s.AST(), 2)
+			test.T(t, stats.Objects, 1)
+			test.String(t, s.AST().JSString(), expected)
+		}()
+		go func() {
+			defer wg.Done()
+			thawed := s.Thaw()
+			thawed.List = thawed.List[1:]
+			test.String(t, thawed.JSString(), f.JSString())
+		}()
+	}
+	wg.Wait()
+}
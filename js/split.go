@@ -0,0 +1,206 @@
+package js
+
+import (
+	"bytes"
+)
+
+// Chunk is a group of consecutive top-level statements of a module, see SplitModule.
+type Chunk struct {
+	List []IStmt // statements of the chunk, a subslice of the list of the AST
+	Size int     // size in bytes of the statements written as JavaScript
+
+	Declared [][]byte      // bindings of the module scope declared by the chunk, in source order
+	Imports  []ChunkImport // bindings declared by other chunks that are used by the chunk, in the order in which Walk finds them
+	Exports  [][]byte      // bindings declared by the chunk that are used by other chunks, in source order
+}
+
+// ChunkImport is a binding that a chunk uses from another chunk, which the other chunk must export.
+type ChunkImport struct {
+	Chunk   int    // index of the chunk that declares the binding
+	Binding []byte // name of the binding
+	// Eval is set when the binding is read while the chunk is evaluated, rather than only inside functions, methods, and non-static class fields. When the declaring chunk comes later, such reads throw or see undefined unless the chunks are reordered.
+	Eval bool
+}
+
+// SplitModule partitions the top-level statements of a module into chunks of consecutive statements of at most budget bytes when written as JavaScript, and computes the bindings each chunk must import from and export to the other chunks, as a primitive for code splitting. Statements are never split, so that a statement larger than the budget forms a chunk of its own. Bindings of import declarations belong to the chunk of the import declaration, so that other chunks re-import them from that chunk. Unresolved global variables are not considered.
+func SplitModule(ast *AST, budget int) []Chunk {
+	scope := &ast.BlockStmt.Scope
+	chunks := []Chunk{}
+	buf := &bytes.Buffer{}
+	for i, stmt := range ast.List {
+		buf.Reset()
+		stmt.JS(buf)
+		size := buf.Len()
+		if len(chunks) == 0 || 0 < len(chunks[len(chunks)-1].List) && budget < chunks[len(chunks)-1].Size+size {
+			chunks = append(chunks, Chunk{List: ast.List[i:i]})
+		}
+		chunk := &chunks[len(chunks)-1]
+		chunk.List = chunk.List[:len(chunk.List)+1]
+		chunk.Size += size
+	}
+
+	// declarations of the module scope
+	declaredBy := map[*Var]int{}
+	for i := range chunks {
+		for _, stmt := range chunks[i].List {
+			for _, name := range stmtDeclared(stmt) {
+				if v := moduleVar(scope, name); v != nil {
+					if _, ok := declaredBy[v]; !ok {
+						declaredBy[v] = i
+						chunks[i].Declared = append(chunks[i].Declared, v.Data)
+					}
+				}
+			}
+		}
+	}
+
+	// uses of bindings declared by other chunks
+	uses := &chunkUses{scope: scope, declaredBy: declaredBy, exported: map[*Var]bool{}}
+	for i := range chunks {
+		uses.chunk, uses.index, uses.imports = i, map[*Var]int{}, nil
+		for _, stmt := range chunks[i].List {
+			Walk(&chunkUsesVisitor{uses, true}, stmt)
+		}
+		chunks[i].Imports = uses.imports
+	}
+	for i := range chunks {
+		for _, name := range chunks[i].Declared {
+			if v := moduleVar(scope, name); v != nil && uses.exported[v] {
+				chunks[i].Exports = append(chunks[i].Exports, name)
+			}
+		}
+	}
+	return chunks
+}
+
+// moduleVar returns the variable of a binding in the module scope, where bindings of import declarations are undeclared variables.
+func moduleVar(scope *Scope, name []byte) *Var {
+	if v := scope.findDeclared(name, false); v != nil {
+		return v
+	}
+	for _, v := range scope.Undeclared {
+		if bytes.Equal(v.Data, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// stmtDeclared returns the names of the bindings that a top-level statement may declare in the module scope, including var declarations nested in blocks but not those in functions.
+func stmtDeclared(stmt IStmt) [][]byte {
+	switch stmt := stmt.(type) {
+	case *ImportStmt:
+		names := [][]byte{}
+		if stmt.Default != nil {
+			names = append(names, stmt.Default)
+		}
+		for _, alias := range stmt.List {
+			if alias.Binding != nil {
+				names = append(names, alias.Binding)
+			}
+		}
+		return names
+	case *ExportStmt:
+		if stmt.Decl == nil {
+			return nil
+		} else if decl, ok := stmt.Decl.(IStmt); ok {
+			return stmtDeclared(decl)
+		}
+		return nil
+	}
+	v := &declaredVisitor{}
+	Walk(v, stmt)
+	return v.names
+}
+
+type declaredVisitor struct {
+	names [][]byte
+}
+
+func (v *declaredVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *VarDecl:
+		for _, item := range n.List {
+			for _, w := range bindingVars(item.Binding) {
+				v.names = append(v.names, w.Data)
+			}
+		}
+	case *FuncDecl:
+		if n.Name != nil {
+			v.names = append(v.names, n.Name.Data)
+		}
+		return nil
+	case *ClassDecl:
+		if n.Name != nil {
+			v.names = append(v.names, n.Name.Data)
+		}
+		return nil
+	case *ArrowFunc, *MethodDecl:
+		return nil
+	}
+	return v
+}
+
+func (v *declaredVisitor) Exit(n INode) {}
+
+type chunkUses struct {
+	scope      *Scope
+	declaredBy map[*Var]int
+	exported   map[*Var]bool
+	chunk      int
+	index      map[*Var]int // index into imports
+	imports    []ChunkImport
+}
+
+type chunkUsesVisitor struct {
+	*chunkUses
+	eval bool
+}
+
+func (v *chunkUsesVisitor) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *FuncDecl, *MethodDecl, *ArrowFunc:
+		if v.eval {
+			return &chunkUsesVisitor{v.chunkUses, false}
+		}
+	case *Field:
+		if !n.Static && v.eval {
+			return &chunkUsesVisitor{v.chunkUses, false}
+		}
+	case *ExportStmt:
+		if n.Module == nil && n.Decl == nil {
+			// export {a as b} exports the live binding without reading it
+			for _, alias := range n.List {
+				local := alias.Binding
+				if alias.Name != nil {
+					local = alias.Name
+				}
+				if local != nil {
+					if w := moduleVar(v.scope, local); w != nil {
+						v.use(w, false)
+					}
+				}
+			}
+		}
+	case *Var:
+		for n.Link != nil {
+			n = n.Link
+		}
+		v.use(n, v.eval)
+	}
+	return v
+}
+
+func (v *chunkUses) use(w *Var, eval bool) {
+	if j, ok := v.declaredBy[w]; ok && j != v.chunk {
+		if i, ok := v.index[w]; ok {
+			v.imports[i].Eval = v.imports[i].Eval || eval
+		} else {
+			v.index[w] = len(v.imports)
+			v.imports = append(v.imports, ChunkImport{j, w.Data, eval})
+			v.exported[w] = true
+		}
+	}
+}
+
+func (v *chunkUsesVisitor) Exit(n INode) {}
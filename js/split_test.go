@@ -0,0 +1,72 @@
+package js
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func chunksString(chunks []Chunk) string {
+	ss := []string{}
+	for _, chunk := range chunks {
+		s := strconv.Itoa(len(chunk.List))
+		for _, name := range chunk.Declared {
+			s += " " + string(name)
+		}
+		for _, imp := range chunk.Imports {
+			s += " <" + string(imp.Binding) + "@" + strconv.Itoa(imp.Chunk)
+			if imp.Eval {
+				s += "!"
+			}
+		}
+		for _, name := range chunk.Exports {
+			s += " >" + string(name)
+		}
+		ss = append(ss, s)
+	}
+	return strings.Join(ss, " | ")
+}
+
+func TestSplitModule(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{``, ""},
+		{`var a = 1; var b = a + 1`, "1 a >a | 1 b <a@0!"},
+		{`let a = 1; function f(){ return a }`, "1 a >a | 1 f <a@0"},
+		{`f(); function f(){}`, "1 <f@1! | 1 f >f"},
+		{`const a = 1; const b = () => a; a`, "1 a >a | 1 b <a@0 | 1 <a@0!"},
+		{`class A {}; class B extends A { x = A; static y = A }`, "1 A >A | 1 B <A@0!"},
+		{`import {a, b as c} from './a'; import d from './d'; c(a, d)`, "1 a c >a >c | 1 d >d | 1 <a@0! <d@1! <c@0!"},
+		{`export const a = 1, {b} = {}; export function f(){ return b }; export {a as x}`, "1 a b >a >b | 1 f <b@0 | 1 <a@0"},
+		{`if (x) { var a = 1; let b = 2 } a`, "1 a >a | 1 <a@0!"},
+		{`for (var i = 0; i < 1; i++) {} i`, "1 i >i | 1 <i@0!"},
+		{`var a; { let a; a } a`, "1 a >a | 1 | 1 <a@0!"},
+		{`var a = 1; function f(a){ return a }`, "1 a | 1 f"},
+		{`var a; a = b`, "1 a >a | 1 <a@0!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			chunks := SplitModule(mustParseModule(t, tt.js), 0)
+			test.String(t, chunksString(chunks), tt.expected)
+		})
+	}
+}
+
+func TestSplitModuleBudget(t *testing.T) {
+	ast := mustParseModule(t, `var a = 1; var b = 2; var c = a + b; function f(){ return c }`)
+	chunks := SplitModule(ast, 20)
+	test.String(t, chunksString(chunks), "2 a b >a >b | 1 c <a@0! <b@0! >c | 1 f <c@1")
+	buf := &bytes.Buffer{}
+	ast.List[0].JS(buf)
+	ast.List[1].JS(buf)
+	test.T(t, chunks[0].Size, buf.Len())
+	test.That(t, &chunks[0].List[0] == &ast.List[0], "chunks must refer to the list of the AST")
+
+	chunks = SplitModule(ast, 1000)
+	test.String(t, chunksString(chunks), "4 a b c f")
+}
@@ -78,7 +78,7 @@ func (prec OpPrec) String() string {
 	return "Invalid(" + strconv.Itoa(int(prec)) + ")"
 }
 
-// Keywords is a map of reserved, strict, and other keywords
+// Keywords is a map of reserved, strict, and other keywords. Use Keyword for fast lookups.
 var Keywords = map[string]TokenType{
 	// reserved
 	"await":      AwaitToken,
@@ -96,6 +96,7 @@ const (
 	OrEqToken                // ||=
 	NullishEqToken           // ??=
 	OptChainToken            // ?.
+	PipelineToken            // |>, see PipelineExperiment
 
 	// unused in lexer
 	PosToken      // +a
@@ -254,6 +255,7 @@ var operatorBytes = [][]byte{
 	[]byte("||="),
 	[]byte("??="),
 	[]byte("?."),
+	[]byte("|>"),
 	[]byte("+"),
 	[]byte("-"),
 	[]byte("++"),
@@ -282,6 +282,38 @@ func Walk(v IVisitor, n INode) {
 		for _, item := range n.List {
 			Walk(v, item)
 		}
+	case *PipelineExpr:
+		Walk(v, n.X)
+		Walk(v, n.Body)
+	case *TopicRefExpr:
+		return
+	case *DoExpr:
+		Walk(v, &n.Body)
+	case *MatchExpr:
+		Walk(v, n.Subject)
+		for _, clause := range n.Clauses {
+			Walk(v, clause)
+		}
+	case *MatchClause:
+		if n.Pattern != nil {
+			Walk(v, n.Pattern)
+		}
+
+		Walk(v, n.Body)
+	case *MatchPattern:
+		Walk(v, n.X)
+		for i := 0; i < len(n.List); i++ {
+			Walk(v, &n.List[i])
+		}
+		for i := 0; i < len(n.Props); i++ {
+			Walk(v, &n.Props[i].Name)
+			if n.Props[i].Value != nil {
+				Walk(v, n.Props[i].Value)
+			}
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
 	default:
 		return
 	}
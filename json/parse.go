@@ -83,6 +83,7 @@ type Parser struct {
 	r     *parse.Input
 	state []State
 	err   error
+	diag  *parse.Diagnostics
 
 	needComma bool
 }
@@ -103,6 +104,11 @@ func (p *Parser) Err() error {
 	return p.r.Err()
 }
 
+// SetDiagnostics sets a collector to which all parse errors are added, so that a single pass over a document reports every problem. The parser recovers from a parse error by skipping the offending value or delimiter, and Next returns ErrorGrammar only at the end of the input, for read errors, or when the maximum number of errors has been reached.
+func (p *Parser) SetDiagnostics(d *parse.Diagnostics) {
+	p.diag = d
+}
+
 // State returns the state the parser is currently in (ie. which token is expected).
 func (p *Parser) State() State {
 	return p.state[len(p.state)-1]
@@ -110,6 +116,48 @@ func (p *Parser) State() State {
 
 // Next returns the next Grammar. It returns ErrorGrammar when an error was encountered. Using Err() one can retrieve the error message.
 func (p *Parser) Next() (GrammarType, []byte) {
+	for {
+		gt, data := p.next()
+		if gt != ErrorGrammar || p.err == nil || p.diag == nil || !p.diag.Add(p.err.(*parse.Error)) {
+			return gt, data
+		}
+		p.err = nil
+		p.recover()
+	}
+}
+
+// recover skips the input after a parse error. A comma, closing bracket, or NULL character at which the error occurred is skipped by itself, otherwise the input is skipped up to the next comma or closing bracket of the enclosing array or object.
+func (p *Parser) recover() {
+	if c := p.r.Peek(0); c == ',' || c == '}' || c == ']' || c == 0 && p.r.Err() == nil {
+		p.r.Move(1)
+	} else {
+		level := 0
+		for {
+			c := p.r.Peek(0)
+			if c == 0 && p.r.Err() != nil {
+				break
+			} else if c == '"' {
+				if !p.consumeStringToken() {
+					break
+				}
+				continue
+			} else if c == '{' || c == '[' {
+				level++
+			} else if c == '}' || c == ']' || c == ',' {
+				if level == 0 {
+					break
+				} else if c != ',' {
+					level--
+				}
+			}
+			p.r.Move(1)
+		}
+		p.needComma = true
+	}
+	p.r.Skip()
+}
+
+func (p *Parser) next() (GrammarType, []byte) {
 	p.moveWhitespace()
 	c := p.r.Peek(0)
 	state := p.state[len(p.state)-1]
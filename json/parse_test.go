@@ -3,6 +3,7 @@ package json
 import (
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/politepixels/tdewolff-parse/v2"
@@ -130,6 +131,52 @@ func TestGrammarsError(t *testing.T) {
 	}
 }
 
+func TestDiagnostics(t *testing.T) {
+	var diagnosticsTests = []struct {
+		json     string
+		expected string
+		errs     string
+	}{
+		{"[1 2, 3]", "[ 1 3 ]", "expected comma character or an array or object ending on line 1 and column 4"},
+		{"{0: 1, \"a\": 2}", "{ \"a\" 2 }", "expected object key to be a quoted string on line 1 and column 2"},
+		{"{\"a\" 1, \"b\": [x, {\"c\": y}]}", "{ \"b\" [ { \"c\" } ] }", "expected colon character after object key on line 1 and column 6\nunexpected character 'x' on line 1 and column 15\nunexpected character 'y' on line 1 and column 24"},
+		{"{\"a\":, 1}", "{ \"a\" 1 }", "unexpected comma character on line 1 and column 6"},
+		{"[1}, 2]", "[ 1 2 ]", "unexpected right brace character on line 1 and column 3"},
+		{"[true, \x00]", "[ true ]", "unexpected NULL character on line 1 and column 8"},
+		{"[{\"a\": \"}\" ] }]", "[ { \"a\" \"}\" } ]", "unexpected right bracket character on line 1 and column 12"},
+	}
+	for _, tt := range diagnosticsTests {
+		t.Run(tt.json, func(t *testing.T) {
+			d := parse.NewDiagnostics(0)
+			p := NewParser(parse.NewInputString(tt.json))
+			p.SetDiagnostics(d)
+			data := []string{}
+			for {
+				grammar, text := p.Next()
+				if grammar == ErrorGrammar {
+					test.T(t, p.Err(), io.EOF)
+					break
+				}
+				data = append(data, string(text))
+			}
+			test.String(t, strings.Join(data, " "), tt.expected)
+			test.String(t, d.Error(), tt.errs)
+		})
+	}
+
+	// stop at the maximum number of errors
+	d := parse.NewDiagnostics(1)
+	p := NewParser(parse.NewInputString("[a, b, c]"))
+	p.SetDiagnostics(d)
+	for {
+		if grammar, _ := p.Next(); grammar == ErrorGrammar {
+			break
+		}
+	}
+	test.That(t, p.Err() != io.EOF)
+	test.String(t, d.Error(), "unexpected character 'a' on line 1 and column 2\nand 1 more errors")
+}
+
 func TestStates(t *testing.T) {
 	var stateTests = []struct {
 		json     string
@@ -0,0 +1,42 @@
+package json
+
+import (
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Redact returns a copy of src where string values are replaced by placeholders of the same length, see parse.Redact. Escape sequences are kept so that the result parses the same as src with all values at the same offsets. Object keys, numbers, and literals are kept as they describe the structure of the document. Input after a syntax error is redacted entirely.
+func Redact(src []byte) []byte {
+	dst := parse.Copy(src)
+	r := parse.NewInputBytes(parse.Copy(src))
+	p := NewParser(r)
+	prevEnd := 0
+	for {
+		state := p.State()
+		gt, data := p.Next()
+		if gt == ErrorGrammar {
+			if p.Err() != io.EOF {
+				parse.Redact(dst[prevEnd:], nil)
+			}
+			break
+		}
+
+		end := r.Offset()
+		prevEnd = end
+		if gt == StringGrammar && state != ObjectKeyState {
+			parse.Redact(dst[end-len(data):end], redactSkipEscape)
+		}
+	}
+	return dst
+}
+
+// redactSkipEscape returns the length of an escape sequence at the start of b.
+func redactSkipEscape(b []byte) int {
+	if b[0] != '\\' || len(b) < 2 {
+		return 0
+	} else if b[1] == 'u' && 6 <= len(b) {
+		return 6
+	}
+	return 2
+}
@@ -0,0 +1,24 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		json     string
+		expected string
+	}{
+		{`{"name": "Alice Smith", "age": 42, "tags": ["VIP", null, true]}`, `{"name": "Xxxxx Xxxxx", "age": 42, "tags": ["XXX", null, true]}`},
+		{`["a\"bé\n", "café"]`, `["x\"xé\n", "xxxé"]`},
+		{`{"a": "Secret"} "Trailing"`, `{"a": "Xxxxxx"} "Xxxxxxxx"`},
+		{`{"a": "Secret", }`, `{"a": "Xxxxxx", }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			test.String(t, string(Redact([]byte(tt.json))), tt.expected)
+		})
+	}
+}
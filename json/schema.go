@@ -0,0 +1,298 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Type is a set of JSON value types observed at a path of a Schema.
+type Type uint8
+
+// Type values, where IntegerType is a number without fraction or exponent and NumberType any other number.
+const (
+	NullType Type = 1 << iota
+	BooleanType
+	IntegerType
+	NumberType
+	StringType
+	ArrayType
+	ObjectType
+)
+
+var typeNames = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+// String returns the names of the types separated by a vertical bar, such as string|null.
+func (t Type) String() string {
+	s := ""
+	for i, name := range typeNames {
+		if t&(1<<uint(i)) != 0 {
+			if s != "" {
+				s += "|"
+			}
+			s += name
+		}
+	}
+	return s
+}
+
+// Schema is the structure of the values at a path of sample documents, as inferred by SchemaInferrer.
+type Schema struct {
+	Path     string // JSONPath of the values, such as $.users[*].name
+	Type     Type   // types of the values
+	Count    int    // number of values
+	Optional bool   // the property is missing from some of the objects that contain its parent path
+
+	Min, Max             float64  // range of the numbers
+	MinLength, MaxLength int      // range of the lengths of the strings, counting escape sequences as one character
+	MinItems, MaxItems   int      // range of the lengths of the arrays
+	Enum                 [][]byte // distinct strings without quotes in order of appearance, nil if there are more than the maximum number of enum candidates
+
+	Properties []*Schema // properties of the objects in order of appearance
+	Items      *Schema   // elements of the arrays, nil if all arrays are empty
+
+	objects   int // number of objects
+	noEnum    bool
+	propIndex map[string]*Schema
+}
+
+// Property returns the schema of an object property by its key as written between the quotes, or nil if it was not observed.
+func (s *Schema) Property(key string) *Schema {
+	return s.propIndex[key]
+}
+
+// Paths returns the schema and the schemas of all its properties and items in depth-first order.
+func (s *Schema) Paths() []*Schema {
+	paths := []*Schema{s}
+	for _, prop := range s.Properties {
+		paths = append(paths, prop.Paths()...)
+	}
+	if s.Items != nil {
+		paths = append(paths, s.Items.Paths()...)
+	}
+	return paths
+}
+
+func (s *Schema) observeNumber(data []byte) {
+	t := IntegerType
+	for _, c := range data {
+		if c == '.' || c == 'e' || c == 'E' {
+			t = NumberType
+			break
+		}
+	}
+	f, _ := strconv.ParseFloat(string(data), 64)
+	if s.Type&(IntegerType|NumberType) == 0 || f < s.Min {
+		s.Min = f
+	}
+	if s.Type&(IntegerType|NumberType) == 0 || s.Max < f {
+		s.Max = f
+	}
+	s.Type |= t
+}
+
+func (s *Schema) observeString(data []byte, maxEnum int) {
+	str := data[1 : len(data)-1]
+	n := stringLength(str)
+	if s.Type&StringType == 0 || n < s.MinLength {
+		s.MinLength = n
+	}
+	if s.Type&StringType == 0 || s.MaxLength < n {
+		s.MaxLength = n
+	}
+	s.Type |= StringType
+
+	if s.noEnum {
+		return
+	}
+	for _, value := range s.Enum {
+		if bytes.Equal(value, str) {
+			return
+		}
+	}
+	if maxEnum <= len(s.Enum) {
+		s.Enum = nil
+		s.noEnum = true
+		return
+	}
+	s.Enum = append(s.Enum, parse.Copy(str))
+}
+
+func (s *Schema) observeArray(n int) {
+	if s.Type&ArrayType == 0 || n < s.MinItems {
+		s.MinItems = n
+	}
+	if s.Type&ArrayType == 0 || s.MaxItems < n {
+		s.MaxItems = n
+	}
+	s.Type |= ArrayType
+}
+
+func (s *Schema) property(key []byte) *Schema {
+	name := string(key[1 : len(key)-1])
+	if prop, ok := s.propIndex[name]; ok {
+		return prop
+	}
+	if s.propIndex == nil {
+		s.propIndex = map[string]*Schema{}
+	}
+	prop := &Schema{Path: s.Path + pathKey(key)}
+	s.propIndex[name] = prop
+	s.Properties = append(s.Properties, prop)
+	return prop
+}
+
+func (s *Schema) items() *Schema {
+	if s.Items == nil {
+		s.Items = &Schema{Path: s.Path + "[*]"}
+	}
+	return s.Items
+}
+
+// setOptional sets Optional for all properties that are missing in some objects.
+func (s *Schema) setOptional() {
+	for _, prop := range s.Properties {
+		prop.Optional = prop.Count < s.objects
+		prop.setOptional()
+	}
+	if s.Items != nil {
+		s.Items.setOptional()
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// SchemaInferrer infers a Schema from a stream of sample documents, such as responses of an API, by recording the types, optionality, and ranges of the values at each path.
+type SchemaInferrer struct {
+	root    *Schema
+	maxEnum int
+}
+
+type schemaFrame struct {
+	s     *Schema
+	n     int     // number of array elements
+	value *Schema // schema of the next object value
+}
+
+// NewSchemaInferrer returns a new SchemaInferrer that keeps at most maxEnum distinct strings per path as enum candidates.
+func NewSchemaInferrer(maxEnum int) *SchemaInferrer {
+	return &SchemaInferrer{
+		root:    &Schema{Path: "$"},
+		maxEnum: maxEnum,
+	}
+}
+
+// InferSchema returns the schema of the documents in r, keeping at most maxEnum distinct strings per path as enum candidates, see SchemaInferrer.
+func InferSchema(r *parse.Input, maxEnum int) (*Schema, error) {
+	inferrer := NewSchemaInferrer(maxEnum)
+	if err := inferrer.Add(r); err != nil {
+		return nil, err
+	}
+	return inferrer.Schema(), nil
+}
+
+// Add adds the documents in r to the schema, which may be a single document or a sequence of documents separated by whitespace such as newline-delimited JSON. It returns the error of the parser, or io.ErrUnexpectedEOF if the last document is incomplete, in which case the values before the error have already been added.
+func (si *SchemaInferrer) Add(r *parse.Input) error {
+	p := NewParser(r)
+	stack := []schemaFrame{}
+	for {
+		state := p.State()
+		gt, data := p.Next()
+		if gt == ErrorGrammar {
+			if err := p.Err(); err != io.EOF {
+				return err
+			} else if 0 < len(stack) {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		} else if gt == WhitespaceGrammar {
+			continue
+		}
+
+		if gt == EndObjectGrammar || gt == EndArrayGrammar {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if gt == EndArrayGrammar {
+				top.s.observeArray(top.n)
+			}
+		} else if gt == StringGrammar && state == ObjectKeyState {
+			top := &stack[len(stack)-1]
+			top.value = top.s.property(data)
+			continue
+		} else {
+			s := si.root
+			if 0 < len(stack) {
+				top := &stack[len(stack)-1]
+				if top.value != nil {
+					s = top.value
+					top.value = nil
+				} else {
+					s = top.s.items()
+					top.n++
+				}
+			}
+			s.Count++
+
+			switch gt {
+			case LiteralGrammar:
+				if data[0] == 'n' {
+					s.Type |= NullType
+				} else {
+					s.Type |= BooleanType
+				}
+			case NumberGrammar:
+				s.observeNumber(data)
+			case StringGrammar:
+				s.observeString(data, si.maxEnum)
+			case StartObjectGrammar:
+				s.Type |= ObjectType
+				s.objects++
+				stack = append(stack, schemaFrame{s: s})
+			case StartArrayGrammar:
+				stack = append(stack, schemaFrame{s: s})
+			}
+		}
+		if len(stack) == 0 {
+			p = NewParser(r) // start of the next document
+		}
+	}
+}
+
+// Schema returns the schema of the documents added so far. The schema is updated by subsequent calls to Add.
+func (si *SchemaInferrer) Schema() *Schema {
+	si.root.setOptional()
+	return si.root
+}
+
+// pathKey returns the JSONPath selector of an object key, which is in bracket notation unless the key is an identifier.
+func pathKey(key []byte) string {
+	str := key[1 : len(key)-1]
+	if len(str) == 0 {
+		return "[" + string(key) + "]"
+	}
+	for i, c := range str {
+		if !(c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || 0 < i && '0' <= c && c <= '9') {
+			return "[" + string(key) + "]"
+		}
+	}
+	return "." + string(str)
+}
+
+// stringLength returns the number of characters of the contents of a string, where escape sequences count as one character.
+func stringLength(b []byte) int {
+	n := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) {
+			if b[i+1] == 'u' {
+				i += 4
+			}
+			i++
+		} else if b[i]&0xC0 == 0x80 {
+			continue // UTF-8 continuation byte
+		}
+		n++
+	}
+	return n
+}
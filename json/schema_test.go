@@ -0,0 +1,94 @@
+package json
+
+import (
+	"io"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestInferSchema(t *testing.T) {
+	src := `{"id": 1, "name": "Alice", "role": "admin", "tags": ["a", "b"], "score": 2.5}
+{"id": 20, "name": "Böb", "role": "user", "tags": [], "score": null, "a b": {"c": true}}
+{"id": 3, "name": "Carol", "role": "user", "tags": ["c"], "score": -1}`
+	schema, err := InferSchema(parse.NewInputString(src), 2)
+	test.Error(t, err)
+
+	var tests = []struct {
+		path     string
+		typ      string
+		count    int
+		optional bool
+	}{
+		{"$", "object", 3, false},
+		{"$.id", "integer", 3, false},
+		{"$.name", "string", 3, false},
+		{"$.role", "string", 3, false},
+		{"$.tags", "array", 3, false},
+		{"$.tags[*]", "string", 3, false},
+		{"$.score", "null|integer|number", 3, false},
+		{`$["a b"]`, "object", 1, true},
+		{`$["a b"].c`, "boolean", 1, false},
+	}
+	paths := schema.Paths()
+	test.T(t, len(paths), len(tests))
+	for i, tt := range tests {
+		if i < len(paths) {
+			test.String(t, paths[i].Path, tt.path)
+			test.String(t, paths[i].Type.String(), tt.typ, tt.path)
+			test.T(t, paths[i].Count, tt.count, tt.path)
+			test.T(t, paths[i].Optional, tt.optional, tt.path)
+		}
+	}
+
+	id := schema.Property("id")
+	test.T(t, id.Min, 1.0)
+	test.T(t, id.Max, 20.0)
+	score := schema.Property("score")
+	test.T(t, score.Min, -1.0)
+	test.T(t, score.Max, 2.5)
+
+	name := schema.Property("name")
+	test.T(t, name.MinLength, 3)
+	test.T(t, name.MaxLength, 5)
+	test.T(t, len(name.Enum), 0, "more than two distinct names")
+	role := schema.Property("role")
+	test.T(t, len(role.Enum), 2)
+	test.String(t, string(role.Enum[0]), "admin")
+	test.String(t, string(role.Enum[1]), "user")
+
+	tags := schema.Property("tags")
+	test.T(t, tags.MinItems, 0)
+	test.T(t, tags.MaxItems, 2)
+	test.That(t, schema.Property("missing") == nil)
+}
+
+func TestSchemaInferrer(t *testing.T) {
+	si := NewSchemaInferrer(0)
+	test.Error(t, si.Add(parse.NewInputString(`[1, "a"]`)))
+	test.Error(t, si.Add(parse.NewInputString(`{"a": 1}`)))
+	schema := si.Schema()
+	test.String(t, schema.Type.String(), "array|object")
+	test.String(t, schema.Items.Type.String(), "integer|string")
+	test.T(t, len(schema.Items.Enum), 0)
+	test.T(t, schema.Property("a").Optional, false)
+
+	// the property is missing in the second object
+	test.Error(t, si.Add(parse.NewInputString(`{}`)))
+	test.T(t, si.Schema().Property("a").Optional, true)
+
+	// syntax errors
+	err := si.Add(parse.NewInputString(`{"a": 1 "b": 2}`))
+	test.That(t, err != nil)
+	_, err = InferSchema(parse.NewInputString(`[1,`), 10)
+	test.T(t, err, io.ErrUnexpectedEOF)
+	_, err = InferSchema(parse.NewInputString(`[1] ]`), 10)
+	test.That(t, err != nil)
+}
+
+func TestSchemaType(t *testing.T) {
+	test.String(t, Type(0).String(), "")
+	test.String(t, (NullType | StringType).String(), "null|string")
+	test.String(t, (ObjectType | ArrayType | BooleanType).String(), "boolean|array|object")
+}
@@ -0,0 +1,485 @@
+package parse
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// extensions maps file extensions to mimetypes, the first extension of a mimetype is its preferred extension.
+var extensions = []struct {
+	ext, mimetype string
+}{
+	{".html", "text/html"},
+	{".htm", "text/html"},
+	{".xhtml", "application/xhtml+xml"},
+	{".xml", "text/xml"},
+	{".xsl", "text/xml"},
+	{".svg", "image/svg+xml"},
+	{".svgz", "image/svg+xml"},
+	{".css", "text/css"},
+	{".js", "text/javascript"},
+	{".mjs", "text/javascript"},
+	{".cjs", "text/javascript"},
+	{".json", "application/json"},
+	{".map", "application/json"},
+	{".jsonld", "application/ld+json"},
+	{".webmanifest", "application/manifest+json"},
+	{".txt", "text/plain"},
+	{".text", "text/plain"},
+	{".csv", "text/csv"},
+	{".md", "text/markdown"},
+	{".markdown", "text/markdown"},
+	{".ics", "text/calendar"},
+	{".vtt", "text/vtt"},
+	{".rss", "application/rss+xml"},
+	{".atom", "application/atom+xml"},
+	{".wasm", "application/wasm"},
+	{".pdf", "application/pdf"},
+	{".ps", "application/postscript"},
+	{".eps", "application/postscript"},
+	{".zip", "application/zip"},
+	{".gz", "application/gzip"},
+	{".rar", "application/vnd.rar"},
+	{".bin", "application/octet-stream"},
+	{".png", "image/png"},
+	{".apng", "image/apng"},
+	{".jpg", "image/jpeg"},
+	{".jpeg", "image/jpeg"},
+	{".gif", "image/gif"},
+	{".webp", "image/webp"},
+	{".avif", "image/avif"},
+	{".bmp", "image/bmp"},
+	{".ico", "image/x-icon"},
+	{".mp3", "audio/mpeg"},
+	{".ogg", "application/ogg"},
+	{".oga", "audio/ogg"},
+	{".wav", "audio/wav"},
+	{".aif", "audio/aiff"},
+	{".aiff", "audio/aiff"},
+	{".mid", "audio/midi"},
+	{".midi", "audio/midi"},
+	{".mp4", "video/mp4"},
+	{".m4a", "audio/mp4"},
+	{".ogv", "video/ogg"},
+	{".webm", "video/webm"},
+	{".avi", "video/avi"},
+	{".woff", "font/woff"},
+	{".woff2", "font/woff2"},
+	{".ttf", "font/ttf"},
+	{".otf", "font/otf"},
+	{".eot", "application/vnd.ms-fontobject"},
+}
+
+// ExtensionMimetype returns the mimetype for a file extension, with or without the leading dot, or an empty string if unknown. Extensions are matched case-insensitively.
+func ExtensionMimetype(ext []byte) string {
+	if 0 < len(ext) && ext[0] == '.' {
+		ext = ext[1:]
+	}
+	for _, e := range extensions {
+		if len(ext) == len(e.ext)-1 && EqualFold(ext, []byte(e.ext[1:])) {
+			return e.mimetype
+		}
+	}
+	return ""
+}
+
+// MimetypeExtension returns the preferred file extension including the leading dot for a mediatype, or an empty string if unknown. Parameters of the mediatype are ignored.
+func MimetypeExtension(mediatype []byte) string {
+	mimetype, _ := Mediatype(mediatype)
+	mimetype = ToLower(Copy(mimetype))
+	for _, e := range extensions {
+		if string(mimetype) == e.mimetype {
+			return e.ext
+		}
+	}
+	return ""
+}
+
+////////////////////////////////////////////////////////////////
+
+// sniffLen is the length of the resource header used for sniffing, see https://mimesniff.spec.whatwg.org/#reading-the-resource-header.
+const sniffLen = 1445
+
+// sniffPattern is a byte pattern with a mask, see https://mimesniff.spec.whatwg.org/#matching-a-mime-type-pattern.
+type sniffPattern struct {
+	pattern, mask []byte
+	skipWS        bool // ignore leading whitespace
+	mimetype      string
+}
+
+func (sp sniffPattern) match(b []byte) bool {
+	if sp.skipWS {
+		for 0 < len(b) && isSniffWhitespace(b[0]) {
+			b = b[1:]
+		}
+	}
+	if len(b) < len(sp.pattern) {
+		return false
+	}
+	for i, c := range sp.pattern {
+		mask := byte(0xFF)
+		if sp.mask != nil {
+			mask = sp.mask[i]
+		}
+		if b[i]&mask != c {
+			return false
+		}
+	}
+	return true
+}
+
+// htmlSniffPattern matches a case-insensitive HTML tag followed by a tag-terminating byte.
+type htmlSniffPattern []byte
+
+func (sp htmlSniffPattern) match(b []byte) bool {
+	for 0 < len(b) && isSniffWhitespace(b[0]) {
+		b = b[1:]
+	}
+	return len(sp) < len(b) && EqualFold(b[:len(sp)], sp) && (b[len(sp)] == ' ' || b[len(sp)] == '>')
+}
+
+var htmlSniffPatterns = []htmlSniffPattern{
+	htmlSniffPattern("<!doctype html"),
+	htmlSniffPattern("<html"),
+	htmlSniffPattern("<head"),
+	htmlSniffPattern("<script"),
+	htmlSniffPattern("<iframe"),
+	htmlSniffPattern("<h1"),
+	htmlSniffPattern("<div"),
+	htmlSniffPattern("<font"),
+	htmlSniffPattern("<table"),
+	htmlSniffPattern("<a"),
+	htmlSniffPattern("<style"),
+	htmlSniffPattern("<title"),
+	htmlSniffPattern("<b"),
+	htmlSniffPattern("<body"),
+	htmlSniffPattern("<br"),
+	htmlSniffPattern("<p"),
+	htmlSniffPattern("<!--"),
+}
+
+// sniffPatterns are the patterns of the scriptable, non-scriptable, image, audio and video, and archive types, in order.
+var sniffPatterns = []sniffPattern{
+	{pattern: []byte("<?xml"), skipWS: true, mimetype: "text/xml"},
+	{pattern: []byte("%PDF-"), mimetype: "application/pdf"},
+	{pattern: []byte("%!PS-Adobe-"), mimetype: "application/postscript"},
+	{pattern: []byte("\xFE\xFF\x00\x00"), mask: []byte("\xFF\xFF\x00\x00"), mimetype: "text/plain"}, // UTF-16BE BOM
+	{pattern: []byte("\xFF\xFE\x00\x00"), mask: []byte("\xFF\xFF\x00\x00"), mimetype: "text/plain"}, // UTF-16LE BOM
+	{pattern: []byte("\xEF\xBB\xBF\x00"), mask: []byte("\xFF\xFF\xFF\x00"), mimetype: "text/plain"}, // UTF-8 BOM
+	{pattern: []byte("\x00\x00\x01\x00"), mimetype: "image/x-icon"},
+	{pattern: []byte("\x00\x00\x02\x00"), mimetype: "image/x-icon"},
+	{pattern: []byte("BM"), mimetype: "image/bmp"},
+	{pattern: []byte("GIF87a"), mimetype: "image/gif"},
+	{pattern: []byte("GIF89a"), mimetype: "image/gif"},
+	{pattern: []byte("RIFF\x00\x00\x00\x00WEBPVP"), mask: []byte("\xFF\xFF\xFF\xFF\x00\x00\x00\x00\xFF\xFF\xFF\xFF\xFF\xFF"), mimetype: "image/webp"},
+	{pattern: []byte("\x89PNG\r\n\x1A\n"), mimetype: "image/png"},
+	{pattern: []byte("\xFF\xD8\xFF"), mimetype: "image/jpeg"},
+	{pattern: []byte("FORM\x00\x00\x00\x00AIFF"), mask: []byte("\xFF\xFF\xFF\xFF\x00\x00\x00\x00\xFF\xFF\xFF\xFF"), mimetype: "audio/aiff"},
+	{pattern: []byte("ID3"), mimetype: "audio/mpeg"},
+	{pattern: []byte("OggS\x00"), mimetype: "application/ogg"},
+	{pattern: []byte("MThd\x00\x00\x00\x06"), mimetype: "audio/midi"},
+	{pattern: []byte("RIFF\x00\x00\x00\x00AVI "), mask: []byte("\xFF\xFF\xFF\xFF\x00\x00\x00\x00\xFF\xFF\xFF\xFF"), mimetype: "video/avi"},
+	{pattern: []byte("RIFF\x00\x00\x00\x00WAVE"), mask: []byte("\xFF\xFF\xFF\xFF\x00\x00\x00\x00\xFF\xFF\xFF\xFF"), mimetype: "audio/wave"},
+	{pattern: []byte("\x1F\x8B\x08"), mimetype: "application/x-gzip"},
+	{pattern: []byte("PK\x03\x04"), mimetype: "application/zip"},
+	{pattern: []byte("Rar!\x1A\x07\x00"), mimetype: "application/x-rar-compressed"},
+}
+
+func isSniffWhitespace(c byte) bool {
+	return c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+// SniffMimetype returns the mimetype of a resource with an unknown or missing mimetype by matching the first bytes of b against the signatures of the MIME Sniffing Standard, see https://mimesniff.spec.whatwg.org/#rules-for-identifying-an-unknown-mime-type. It follows the rules with the sniff-scriptable flag set, so that it may return text/html, text/xml, or application/pdf. When no signature matches, it returns text/plain if the resource header contains no binary data bytes and application/octet-stream otherwise.
+func SniffMimetype(b []byte) string {
+	if sniffLen < len(b) {
+		b = b[:sniffLen]
+	}
+	for _, sp := range htmlSniffPatterns {
+		if sp.match(b) {
+			return "text/html"
+		}
+	}
+	for _, sp := range sniffPatterns {
+		if sp.match(b) {
+			return sp.mimetype
+		}
+	}
+	if isMP4(b) {
+		return "video/mp4"
+	} else if isWebM(b) {
+		return "video/webm"
+	}
+
+	for _, c := range b {
+		if c <= 0x08 || c == 0x0B || 0x0E <= c && c <= 0x1A || 0x1C <= c && c <= 0x1F {
+			return "application/octet-stream"
+		}
+	}
+	return "text/plain"
+}
+
+// isMP4 matches the signature for MP4, see https://mimesniff.spec.whatwg.org/#signature-for-mp4.
+func isMP4(b []byte) bool {
+	if len(b) < 12 {
+		return false
+	}
+	boxSize := int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	if len(b) < boxSize || boxSize%4 != 0 || boxSize < 12 || !bytes.Equal(b[4:8], []byte("ftyp")) {
+		return false
+	} else if bytes.Equal(b[8:11], []byte("mp4")) {
+		return true
+	}
+	for i := 16; i+3 <= boxSize; i += 4 {
+		// compatible brands
+		if bytes.Equal(b[i:i+3], []byte("mp4")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebM matches the signature for WebM, see https://mimesniff.spec.whatwg.org/#signature-for-webm.
+func isWebM(b []byte) bool {
+	if len(b) < 4 || !bytes.Equal(b[:4], []byte("\x1A\x45\xDF\xA3")) {
+		return false
+	}
+	for i := 4; i < len(b) && i < 38; i++ {
+		if b[i] == 0x42 && i+1 < len(b) && b[i+1] == 0x82 {
+			// DocType element followed by its variable-length size
+			i += 2
+			if len(b) <= i {
+				return false
+			}
+			n := 1
+			for mask := byte(0x80); n <= 8 && b[i]&mask == 0; mask >>= 1 {
+				n++
+			}
+			i += n
+			return i+4 <= len(b) && bytes.Equal(b[i:i+4], []byte("webm"))
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////
+
+// MediatypeParam is a parameter of a mediatype.
+type MediatypeParam struct {
+	Key   string // lowercase name without the section number and the asterisk of extended values
+	Value string // unquoted and decoded value
+}
+
+// MediatypeParams are the parameters of a mediatype in order.
+type MediatypeParams []MediatypeParam
+
+// Get returns the value of the parameter with the lowercase key and whether it exists.
+func (params MediatypeParams) Get(key string) (string, bool) {
+	for _, param := range params {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+	return "", false
+}
+
+// mediatypeSection is a section of a parameter value split by RFC 2231 continuations.
+type mediatypeSection struct {
+	value    string
+	extended bool
+}
+
+// ParseMediatype parses a mediatype, such as a Content-Type header, and returns its lowercase mimetype and its parameters in order, see RFC 9110 and RFC 2231. Unlike Mediatype, quoted values are unquoted and unescaped, continuations such as title*0 and title*1 are joined, extended values such as title*=UTF-8'en'%E2%82%AC are percent-decoded and transcoded to UTF-8 for UTF-8 and windows-1252 charsets, and the charset parameter is canonicalized as by DetectCharset or lowercased if unknown. Common malformed forms are accepted: stray semicolons and parameters without a name are skipped, unquoted values may contain spaces, and an unterminated quoted value runs to the end. If a key occurs more than once the first value is kept, except that an extended value takes precedence over a regular value as in RFC 6266.
+func ParseMediatype(b []byte) ([]byte, MediatypeParams) {
+	s := string(TrimWhitespace(b))
+	i := 0
+	for i < len(s) && s[i] != ';' && !IsWhitespace(s[i]) {
+		i++
+	}
+	mimetype := ToLower([]byte(s[:i]))
+	semicolon := strings.IndexByte(s[i:], ';')
+	if semicolon == -1 {
+		return mimetype, nil
+	}
+	i += semicolon
+
+	var params MediatypeParams
+	extended := map[string]bool{}                     // parameters with an extended or continued value
+	sections := map[string]map[int]mediatypeSection{} // sections of continued parameters
+	for i < len(s) {
+		i++ // semicolon
+		for i < len(s) && IsWhitespace(s[i]) {
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ';' && !IsWhitespace(s[i]) {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		for i < len(s) && IsWhitespace(s[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && IsWhitespace(s[i]) {
+				i++
+			}
+			if i < len(s) && s[i] == '"' {
+				value, i = unquoteMediatypeValue(s, i)
+			} else {
+				start = i
+				for i < len(s) && s[i] != ';' {
+					i++
+				}
+				value = string(TrimWhitespace([]byte(s[start:i])))
+			}
+		}
+		for i < len(s) && s[i] != ';' {
+			i++ // skip garbage after a quoted value or a key without a value
+		}
+		if key == "" || key[0] == '*' {
+			continue
+		}
+
+		name, isExtended, section := key, false, -1
+		if star := strings.IndexByte(key, '*'); star != -1 {
+			name, isExtended = key[:star], strings.HasSuffix(key, "*")
+			if digits := strings.TrimSuffix(key[star+1:], "*"); digits != "" {
+				n, err := strconv.Atoi(digits)
+				if err != nil || n < 0 || digits != "0" && digits[0] == '0' {
+					continue
+				}
+				section = n
+			} else if !isExtended {
+				continue
+			}
+		}
+
+		j := len(params)
+		for k, param := range params {
+			if param.Key == name {
+				j = k
+				break
+			}
+		}
+		exists := j < len(params)
+		if section != -1 {
+			if exists && extended[name] && sections[name] == nil {
+				continue // sections after an extended value
+			} else if sections[name] == nil {
+				sections[name] = map[int]mediatypeSection{}
+			}
+			if _, ok := sections[name][section]; !ok {
+				sections[name][section] = mediatypeSection{value, isExtended}
+			}
+		} else if isExtended {
+			if exists && extended[name] {
+				continue // duplicate
+			}
+			value = decodeMediatypeValue([]mediatypeSection{{value, true}})
+		} else if exists {
+			continue // duplicate or regular value after an extended value
+		}
+		if !exists {
+			params = append(params, MediatypeParam{Key: name})
+		}
+		if section == -1 {
+			params[j].Value = value
+		}
+		extended[name] = extended[name] || isExtended || section != -1
+	}
+
+	for name, m := range sections {
+		list := []mediatypeSection{}
+		for n := 0; ; n++ {
+			section, ok := m[n]
+			if !ok {
+				break
+			}
+			list = append(list, section)
+		}
+		if len(list) == 0 {
+			continue
+		}
+		value := decodeMediatypeValue(list)
+		for j := range params {
+			if params[j].Key == name {
+				params[j].Value = value
+			}
+		}
+	}
+	for j := range params {
+		if params[j].Key == "charset" {
+			charset := strings.ToLower(params[j].Value)
+			if canonical, ok := charsetLabels[charset]; ok {
+				charset = canonical
+			}
+			params[j].Value = charset
+		}
+	}
+	return mimetype, params
+}
+
+// unquoteMediatypeValue returns the value of the quoted string starting at s[i] with backslash escapes removed, and the index after the closing quote. An unterminated quoted string runs to the end of s.
+func unquoteMediatypeValue(s string, i int) (string, int) {
+	i++ // opening quote
+	var value []byte
+	start := i
+	for i < len(s) && s[i] != '"' {
+		if s[i] == '\\' && i+1 < len(s) {
+			value = append(value, s[start:i]...)
+			start = i + 1
+			i++
+		}
+		i++
+	}
+	value = append(value, s[start:i]...)
+	if i < len(s) {
+		i++ // closing quote
+	}
+	return string(value), i
+}
+
+// decodeMediatypeValue joins the sections of an RFC 2231 parameter value, where extended sections are percent-encoded and the first extended section starts with the charset and language separated by single quotes.
+func decodeMediatypeValue(sections []mediatypeSection) string {
+	charset := ""
+	var value []byte
+	for k, section := range sections {
+		if !section.extended {
+			value = append(value, section.value...)
+			continue
+		}
+		v := section.value
+		if k == 0 {
+			if quote := strings.IndexByte(v, '\''); quote != -1 {
+				charset = strings.ToLower(v[:quote])
+				v = v[quote+1:]
+				if quote = strings.IndexByte(v, '\''); quote != -1 {
+					v = v[quote+1:] // language
+				}
+			}
+		}
+		for i := 0; i < len(v); i++ {
+			if v[i] == '%' && i+2 < len(v) && hexDigit(v[i+1]) < 16 && hexDigit(v[i+2]) < 16 {
+				value = append(value, byte(hexDigit(v[i+1])<<4|hexDigit(v[i+2])))
+				i += 2
+			} else {
+				value = append(value, v[i])
+			}
+		}
+	}
+	if charsetLabels[charset] == Windows1252 {
+		var buf []byte
+		for _, c := range value {
+			if 0x80 <= c && c < 0xA0 {
+				buf = utf8.AppendRune(buf, windows1252[c-0x80])
+			} else {
+				buf = utf8.AppendRune(buf, rune(c))
+			}
+		}
+		value = buf
+	}
+	return string(value)
+}
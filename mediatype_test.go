@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestExtensionMimetype(t *testing.T) {
+	var tests = []struct {
+		ext      string
+		expected string
+	}{
+		{".html", "text/html"},
+		{"HTM", "text/html"},
+		{".js", "text/javascript"},
+		{".mjs", "text/javascript"},
+		{".SVG", "image/svg+xml"},
+		{".woff2", "font/woff2"},
+		{".", ""},
+		{"", ""},
+		{".unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			test.String(t, ExtensionMimetype([]byte(tt.ext)), tt.expected)
+		})
+	}
+}
+
+func TestMimetypeExtension(t *testing.T) {
+	var tests = []struct {
+		mediatype string
+		expected  string
+	}{
+		{"text/html", ".html"},
+		{"text/html; charset=utf-8", ".html"},
+		{"Image/JPEG", ".jpg"},
+		{"text/javascript", ".js"},
+		{"application/x-unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mediatype, func(t *testing.T) {
+			test.String(t, MimetypeExtension([]byte(tt.mediatype)), tt.expected)
+		})
+	}
+
+	// every mimetype maps back to itself through its preferred extension
+	for _, e := range extensions {
+		test.String(t, ExtensionMimetype([]byte(MimetypeExtension([]byte(e.mimetype)))), e.mimetype, e.ext)
+	}
+}
+
+func TestSniffMimetype(t *testing.T) {
+	var tests = []struct {
+		b        string
+		expected string
+	}{
+		{"", "text/plain"},
+		{"plain text", "text/plain"},
+		{"  <!DOCTYPE html><html>", "text/html"},
+		{"\n<HTML>", "text/html"},
+		{"<p>text", "text/html"},
+		{"<!-- x -->", "text/html"},
+		{"<pre>", "text/plain"},
+		{"<br/>", "text/plain"},
+		{"\t<?xml version=\"1.0\"?>", "text/xml"},
+		{"%PDF-1.7", "application/pdf"},
+		{"%!PS-Adobe-3.0", "application/postscript"},
+		{"\xEF\xBB\xBFtext", "text/plain"},
+		{"\xFF\xFEt\x00", "text/plain"},
+		{"\x89PNG\r\n\x1A\n\x00\x00", "image/png"},
+		{"\xFF\xD8\xFF\xE0", "image/jpeg"},
+		{"GIF89a\x01\x00", "image/gif"},
+		{"RIFF\x10\x00\x00\x00WEBPVP8 ", "image/webp"},
+		{"RIFF\x10\x00\x00\x00WAVEfmt ", "audio/wave"},
+		{"BM\x00\x00", "image/bmp"},
+		{"\x00\x00\x01\x00", "image/x-icon"},
+		{"ID3\x04", "audio/mpeg"},
+		{"OggS\x00\x02", "application/ogg"},
+		{"\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isommp41", "video/mp4"},
+		{"\x00\x00\x00\x10ftypmp42\x00\x00\x00\x00", "video/mp4"},
+		{"\x00\x00\x00\x10ftypqt  \x00\x00\x00\x00", "application/octet-stream"},
+		{"\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm", "video/webm"},
+		{"\x1F\x8B\x08\x00", "application/x-gzip"},
+		{"PK\x03\x04", "application/zip"},
+		{"Rar!\x1A\x07\x00", "application/x-rar-compressed"},
+		{"\x00\x01\x02", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			test.String(t, SniffMimetype([]byte(tt.b)), tt.expected)
+		})
+	}
+}
+
+func TestParseMediatype(t *testing.T) {
+	var tests = []struct {
+		mediatype string
+		mimetype  string
+		params    string
+	}{
+		{"text/plain", "text/plain", ""},
+		{" Text/HTML ; Charset=UTF8 ", "text/html", "charset=utf-8"},
+		{"text/html;charset=latin1", "text/html", "charset=windows-1252"},
+		{"text/html;charset=x-unknown", "text/html", "charset=x-unknown"},
+		{"text/plain a;b=c", "text/plain", "b=c"},
+		{`attachment; filename="a; \"b\".txt"; size=3`, "attachment", `filename=a; "b".txt size=3`},
+		{`a/b; name="unterminated`, "a/b", "name=unterminated"},
+		{`a/b; name="x" garbage; y=z`, "a/b", "name=x y=z"},
+		{"a/b;; ;x=1;;y = my file.txt ;", "a/b", "x=1 y=my file.txt"},
+		{"a/b; =x; flag; c=d", "a/b", "flag= c=d"},
+		{"a/b; x=1; X=2", "a/b", "x=1"},
+		{"a/b; title*=UTF-8''%E2%82%AC%20rates", "a/b", "title=€ rates"},
+		{"a/b; title*=iso-8859-1'en'%A3%80", "a/b", "title=£€"},
+		{"a/b; title*0=\"foo \"; title*2=baz; title*1=bar", "a/b", "title=foo barbaz"},
+		{"a/b; title*0*=UTF-8''%E2%82; title*1*=%AC; title*2=%", "a/b", "title=€%"},
+		{"a/b; title*1=bar", "a/b", "title="},
+		{"a/b; title*01=x; title*x=y; *=z", "a/b", ""},
+		{`a/b; filename="a.txt"; filename*=UTF-8''%C3%A9.txt`, "a/b", "filename=é.txt"},
+		{`a/b; filename*=UTF-8''%C3%A9.txt; filename="a.txt"`, "a/b", "filename=é.txt"},
+		{`a/b; filename="a.txt"; filename*0=b; filename*1=.txt`, "a/b", "filename=b.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mediatype, func(t *testing.T) {
+			mimetype, params := ParseMediatype([]byte(tt.mediatype))
+			test.String(t, string(mimetype), tt.mimetype)
+			s := []string{}
+			for _, param := range params {
+				s = append(s, param.Key+"="+param.Value)
+			}
+			test.String(t, strings.Join(s, " "), tt.params)
+		})
+	}
+
+	_, params := ParseMediatype([]byte("text/plain; charset=utf-8; format=flowed"))
+	value, ok := params.Get("format")
+	test.That(t, ok)
+	test.String(t, value, "flowed")
+	_, ok = params.Get("delsp")
+	test.That(t, !ok)
+}
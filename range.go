@@ -0,0 +1,114 @@
+package parse
+
+import (
+	"container/list"
+	"io"
+)
+
+// RangeFetcher fetches the bytes of a remote resource starting at offset into b, such as with an HTTP Range request or an object-storage read, with the semantics of io.ReaderAt. It returns io.EOF when fewer bytes are returned because the end of the resource has been reached.
+type RangeFetcher func(b []byte, offset int64) (int, error)
+
+// RangeReader reads a remote resource in chunks that are fetched on demand, keeping the most recently used chunks in memory. It implements io.Reader and io.ReaderAt and is not safe for concurrent use.
+type RangeReader struct {
+	fetch     RangeFetcher
+	chunkSize int
+	capacity  int
+
+	chunks map[int64]*list.Element
+	lru    *list.List // most recently used chunk at the front
+	offset int64      // offset of the next Read
+}
+
+type rangeChunk struct {
+	index int64
+	data  []byte
+	eof   bool // chunk contains the end of the resource
+}
+
+// NewRangeReader returns a RangeReader that fetches chunks of chunkSize bytes and keeps at most capacity chunks in memory.
+func NewRangeReader(fetch RangeFetcher, chunkSize, capacity int) *RangeReader {
+	if chunkSize < 1 {
+		chunkSize = defaultStreamSize
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RangeReader{
+		fetch:     fetch,
+		chunkSize: chunkSize,
+		capacity:  capacity,
+		chunks:    map[int64]*list.Element{},
+		lru:       list.New(),
+	}
+}
+
+// NewRangeInput returns a streaming Input that reads a remote resource through a RangeReader, so that the head of a huge remote asset can be parsed without downloading it entirely, see NewStreamInputSize.
+func NewRangeInput(fetch RangeFetcher, chunkSize, capacity int) *Input {
+	r := NewRangeReader(fetch, chunkSize, capacity)
+	return NewStreamInputSize(r, r.chunkSize)
+}
+
+// Read reads the bytes following the previous Read.
+func (r *RangeReader) Read(b []byte) (int, error) {
+	n, err := r.ReadAt(b, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && 0 < n {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt reads the bytes at offset, fetching the chunks that are not in memory. It returns io.EOF if the end of the resource is reached before b is filled.
+func (r *RangeReader) ReadAt(b []byte, offset int64) (int, error) {
+	n := 0
+	for n < len(b) {
+		off := offset + int64(n)
+		chunk, err := r.chunk(off / int64(r.chunkSize))
+		if err != nil {
+			return n, err
+		}
+		i := int(off % int64(r.chunkSize))
+		if len(chunk.data) <= i {
+			return n, io.EOF
+		}
+		m := copy(b[n:], chunk.data[i:])
+		n += m
+		if chunk.eof && i+m == len(chunk.data) && n < len(b) {
+			return n, io.EOF
+		}
+	}
+	return n, nil
+}
+
+// chunk returns the chunk with the given index, fetching it if it is not in memory and evicting the least recently used chunk when at capacity.
+func (r *RangeReader) chunk(index int64) (*rangeChunk, error) {
+	if e, ok := r.chunks[index]; ok {
+		r.lru.MoveToFront(e)
+		return e.Value.(*rangeChunk), nil
+	}
+
+	chunk := &rangeChunk{index: index}
+	b := make([]byte, r.chunkSize)
+	n := 0
+	for n < len(b) {
+		m, err := r.fetch(b[n:], index*int64(r.chunkSize)+int64(n))
+		n += m
+		if err == io.EOF {
+			chunk.eof = true
+			break
+		} else if err != nil {
+			return nil, err
+		} else if m == 0 {
+			return nil, io.ErrNoProgress
+		}
+	}
+	chunk.data = b[:n]
+
+	if r.capacity <= r.lru.Len() {
+		e := r.lru.Back()
+		delete(r.chunks, e.Value.(*rangeChunk).index)
+		r.lru.Remove(e)
+	}
+	r.chunks[index] = r.lru.PushFront(chunk)
+	return chunk, nil
+}
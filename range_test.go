@@ -0,0 +1,80 @@
+package parse
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRangeReader(t *testing.T) {
+	src := strings.NewReader("abcdefghij")
+	fetches := []int64{}
+	fetch := func(b []byte, offset int64) (int, error) {
+		fetches = append(fetches, offset)
+		return src.ReadAt(b, offset)
+	}
+
+	r := NewRangeReader(fetch, 4, 2)
+	b := make([]byte, 3)
+	n, err := r.ReadAt(b, 2)
+	test.Error(t, err)
+	test.String(t, string(b[:n]), "cde")
+	test.T(t, len(fetches), 2, "fetch two chunks")
+
+	n, err = r.ReadAt(b, 1)
+	test.Error(t, err)
+	test.String(t, string(b[:n]), "bcd")
+	test.T(t, len(fetches), 2, "cached chunk")
+
+	n, err = r.ReadAt(b, 8)
+	test.T(t, err, io.EOF)
+	test.String(t, string(b[:n]), "ij")
+	test.T(t, len(fetches), 3)
+
+	n, err = r.ReadAt(b, 0)
+	test.Error(t, err)
+	test.String(t, string(b[:n]), "abc")
+	test.T(t, len(fetches), 3, "cached chunk")
+
+	n, err = r.ReadAt(b, 4)
+	test.Error(t, err)
+	test.String(t, string(b[:n]), "efg")
+	test.T(t, len(fetches), 4, "evicted least recently used chunk")
+	test.T(t, fetches[3], int64(4))
+
+	n, err = r.ReadAt(b, 10)
+	test.T(t, err, io.EOF)
+	test.T(t, n, 0)
+
+	// Read
+	r = NewRangeReader(fetch, 4, 2)
+	s, err := io.ReadAll(r)
+	test.Error(t, err)
+	test.String(t, string(s), "abcdefghij")
+
+	// errors are not cached
+	fail := errors.New("fail")
+	r = NewRangeReader(func(b []byte, offset int64) (int, error) {
+		return 0, fail
+	}, 4, 2)
+	_, err = r.ReadAt(b, 0)
+	test.T(t, err, fail)
+}
+
+func TestRangeInput(t *testing.T) {
+	src := strings.NewReader("<html><head><title>x</title></head>" + strings.Repeat(" ", 1000))
+	fetched := 0
+	z := NewRangeInput(func(b []byte, offset int64) (int, error) {
+		fetched += len(b)
+		return src.ReadAt(b, offset)
+	}, 16, 4)
+
+	for z.Peek(0) != 'x' {
+		z.Move(1)
+	}
+	test.T(t, z.Offset(), 19)
+	test.That(t, fetched < 64, "only the head is fetched")
+}
@@ -0,0 +1,150 @@
+package parse
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+)
+
+// SourceFile is a named Input registered in a SourceSet. It occupies the global offsets from Base up to and including Base+Size, where the last offset is the end of the file.
+type SourceFile struct {
+	Name  string
+	Base  int
+	Size  int
+	Input *Input
+
+	Parent        *SourceFile // file that includes this file, nil for top-level files
+	IncludeOffset int         // global offset of the include in Parent
+}
+
+// SourcePosition is a position in a named source file.
+type SourcePosition struct {
+	Filename string
+	Offset   int // offset in the file, starting at 0
+	Line     int // line number, starting at 1
+	Column   int // column number, starting at 1, see ColumnMode
+}
+
+// String returns the position as filename:line:column.
+func (p SourcePosition) String() string {
+	s := p.Filename
+	if s == "" {
+		s = "-"
+	}
+	return s + ":" + strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Position returns the position of a global offset in the file.
+func (f *SourceFile) Position(offset int) SourcePosition {
+	local := f.local(offset)
+	line, col := f.Input.PositionAt(local)
+	return SourcePosition{
+		Filename: f.Name,
+		Offset:   local,
+		Line:     line,
+		Column:   col,
+	}
+}
+
+// Offset returns the global offset of an offset in the file.
+func (f *SourceFile) Offset(local int) int {
+	if local < 0 {
+		local = 0
+	} else if f.Size < local {
+		local = f.Size
+	}
+	return f.Base + local
+}
+
+func (f *SourceFile) local(offset int) int {
+	offset -= f.Base
+	if offset < 0 {
+		return 0
+	} else if f.Size < offset {
+		return f.Size
+	}
+	return offset
+}
+
+// SourceSet maps global offsets to positions in multiple named inputs, similar to go/token.FileSet, for tools that inline imported stylesheets or concatenate scripts. Each added file gets a distinct range of global offsets, and included files record where they were included so that errors can report the include stack. The inputs must be read completely, as from NewInput, as the size of a streaming Input is not known in advance.
+type SourceSet struct {
+	files []*SourceFile
+	base  int
+}
+
+// NewSourceSet returns a new empty SourceSet.
+func NewSourceSet() *SourceSet {
+	return &SourceSet{}
+}
+
+// AddFile registers a top-level file and returns it. Its global offsets follow those of the previously added file.
+func (s *SourceSet) AddFile(name string, r *Input) *SourceFile {
+	return s.AddInclude(name, r, nil, 0)
+}
+
+// AddInclude registers a file that is included by parent at the global offset, such as the offset of an @import rule, and returns it. A nil parent adds a top-level file.
+func (s *SourceSet) AddInclude(name string, r *Input, parent *SourceFile, offset int) *SourceFile {
+	f := &SourceFile{
+		Name:   name,
+		Base:   s.base,
+		Size:   r.Len(),
+		Input:  r,
+		Parent: parent,
+	}
+	if parent != nil {
+		f.IncludeOffset = parent.Base + parent.local(offset)
+	}
+	s.files = append(s.files, f)
+	s.base += f.Size + 1 // the end of a file is not the start of the next
+	return f
+}
+
+// Files returns the registered files in the order they were added.
+func (s *SourceSet) Files() []*SourceFile {
+	return s.files
+}
+
+// File returns the file that contains the global offset, or nil if the offset is out of range.
+func (s *SourceSet) File(offset int) *SourceFile {
+	i := sort.Search(len(s.files), func(i int) bool { return offset < s.files[i].Base }) - 1
+	if i < 0 || s.files[i].Base+s.files[i].Size < offset {
+		return nil
+	}
+	return s.files[i]
+}
+
+// Position returns the position of a global offset, or the zero SourcePosition if the offset is out of range.
+func (s *SourceSet) Position(offset int) SourcePosition {
+	if f := s.File(offset); f != nil {
+		return f.Position(offset)
+	}
+	return SourcePosition{}
+}
+
+// Stack returns the position of a global offset followed by the positions at which its file was included, from the innermost to the top-level file.
+func (s *SourceSet) Stack(offset int) []SourcePosition {
+	stack := []SourcePosition{}
+	for f := s.File(offset); f != nil; f = f.Parent {
+		stack = append(stack, f.Position(offset))
+		offset = f.IncludeOffset
+	}
+	return stack
+}
+
+// NewError creates a new error at a global offset, with the line, column, and context taken from the file that contains it, and with File set to that file.
+func (s *SourceSet) NewError(offset int, message string, a ...interface{}) *Error {
+	return s.NewErrorRange(offset, offset, message, a...)
+}
+
+// NewErrorRange creates a new error for the range between two global offsets in the same file, see NewError. The end is clamped to the end of the file that contains start.
+func (s *SourceSet) NewErrorRange(start, end int, message string, a ...interface{}) *Error {
+	f := s.File(start)
+	if f == nil {
+		return NewError(bytes.NewReader(nil), 0, message, a...)
+	}
+	err := NewErrorRange(bytes.NewReader(f.Input.Bytes()), f.local(start), f.local(end), message, a...)
+	err.File = f
+	err.Range.Start.Filename = f.Name
+	err.Range.End.Filename = f.Name
+	return err
+}
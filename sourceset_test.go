@@ -0,0 +1,66 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestSourceSet(t *testing.T) {
+	s := NewSourceSet()
+	main := s.AddFile("main.css", NewInputString("@import 'a.css';\nb{}"))
+	a := s.AddInclude("a.css", NewInputString("a{\ncolor:red}"), main, 0)
+	test.T(t, main.Base, 0)
+	test.T(t, a.Base, 21)
+	test.T(t, len(s.Files()), 2)
+
+	var tests = []struct {
+		offset   int
+		expected string
+	}{
+		{-1, "-:0:0"},
+		{0, "main.css:1:1"},
+		{18, "main.css:2:2"},
+		{20, "main.css:2:4"},
+		{21, "a.css:1:1"},
+		{24, "a.css:2:1"},
+		{34, "a.css:2:11"},
+		{35, "-:0:0"},
+	}
+	for _, tt := range tests {
+		test.String(t, s.Position(tt.offset).String(), tt.expected)
+	}
+	test.T(t, s.File(20), main)
+	test.T(t, s.File(21), a)
+	test.T(t, s.Position(a.Offset(4)).Offset, 4)
+
+	stack := s.Stack(a.Offset(9))
+	test.T(t, len(stack), 2)
+	test.String(t, stack[0].String(), "a.css:2:7")
+	test.String(t, stack[1].String(), "main.css:1:1")
+}
+
+func TestSourceSetError(t *testing.T) {
+	s := NewSourceSet()
+	main := s.AddFile("main.css", NewInputString("b{}\n@import 'a.css';"))
+	a := s.AddInclude("a.css", NewInputString("a{color:red"), main, 4)
+
+	err := s.NewError(a.Offset(2), "message")
+	test.T(t, err.File, a)
+	test.T(t, err.Line, 1)
+	test.T(t, err.Column, 3)
+	test.T(t, err.Error(), "message in a.css on line 1 and column 3\n\tincluded from main.css:2:1\n    1: a{color:red\n         ^")
+
+	err = s.NewError(main.Offset(1), "message")
+	test.T(t, err.Error(), "message in main.css on line 1 and column 2\n    1: b{}\n        ^")
+}
+
+func TestSourceSetErrorRange(t *testing.T) {
+	s := NewSourceSet()
+	s.AddFile("main.css", NewInputString("a{}"))
+	b := s.AddFile("b.css", NewInputString("b{\ncolr:red}"))
+
+	err := s.NewErrorRange(b.Offset(3), b.Offset(7), "unknown property")
+	test.T(t, err.Range.Start, SourcePosition{Filename: "b.css", Offset: 3, Line: 2, Column: 1})
+	test.T(t, err.Range.End, SourcePosition{Filename: "b.css", Offset: 7, Line: 2, Column: 5})
+}
@@ -8,5 +8,6 @@ import "github.com/politepixels/tdewolff-parse/v2"
 // Fuzz is a fuzz test.
 func Fuzz(data []byte) int {
 	_, _ = parse.Mediatype(data)
+	_, _ = parse.ParseMediatype(data)
 	return 1
 }
@@ -0,0 +1,247 @@
+package parse
+
+import (
+	"math"
+	"strconv"
+)
+
+// UnitCategory is the category of a Unit, which determines the units it can be converted to.
+type UnitCategory uint8
+
+// UnitCategory values.
+const (
+	NoCategory UnitCategory = iota // no unit or an unknown unit
+	PercentageCategory
+	LengthCategory
+	AngleCategory
+	TimeCategory
+	FrequencyCategory
+	ResolutionCategory
+	FlexCategory
+)
+
+// String returns the string representation of a UnitCategory.
+func (c UnitCategory) String() string {
+	switch c {
+	case NoCategory:
+		return "none"
+	case PercentageCategory:
+		return "percentage"
+	case LengthCategory:
+		return "length"
+	case AngleCategory:
+		return "angle"
+	case TimeCategory:
+		return "time"
+	case FrequencyCategory:
+		return "frequency"
+	case ResolutionCategory:
+		return "resolution"
+	case FlexCategory:
+		return "flex"
+	}
+	return "Invalid(" + strconv.Itoa(int(c)) + ")"
+}
+
+// Unit is a CSS unit, see https://www.w3.org/TR/css-values-4/#units.
+type Unit uint8
+
+// Unit values, where the units of a category are contiguous.
+const (
+	NoUnit      Unit = iota // number without a unit
+	UnknownUnit             // unit that is not a CSS unit
+	Percent
+	// absolute lengths
+	Px
+	Cm
+	Mm
+	Q
+	In
+	Pt
+	Pc
+	// font-relative lengths
+	Em
+	Rem
+	Ex
+	Rex
+	Cap
+	Rcap
+	Ch
+	Rch
+	Ic
+	Ric
+	Lh
+	Rlh
+	// viewport-relative lengths
+	Vw
+	Vh
+	Vi
+	Vb
+	Vmin
+	Vmax
+	Svw
+	Svh
+	Svi
+	Svb
+	Svmin
+	Svmax
+	Lvw
+	Lvh
+	Lvi
+	Lvb
+	Lvmin
+	Lvmax
+	Dvw
+	Dvh
+	Dvi
+	Dvb
+	Dvmin
+	Dvmax
+	// container-relative lengths
+	Cqw
+	Cqh
+	Cqi
+	Cqb
+	Cqmin
+	Cqmax
+	// angles
+	Deg
+	Grad
+	Rad
+	Turn
+	// times
+	S
+	Ms
+	// frequencies
+	Hz
+	KHz
+	// resolutions
+	Dpi
+	Dpcm
+	Dppx
+	X
+	// flexible lengths
+	Fr
+)
+
+var unitNames = []string{"", "", "%", "px", "cm", "mm", "Q", "in", "pt", "pc", "em", "rem", "ex", "rex", "cap", "rcap", "ch", "rch", "ic", "ric", "lh", "rlh", "vw", "vh", "vi", "vb", "vmin", "vmax", "svw", "svh", "svi", "svb", "svmin", "svmax", "lvw", "lvh", "lvi", "lvb", "lvmin", "lvmax", "dvw", "dvh", "dvi", "dvb", "dvmin", "dvmax", "cqw", "cqh", "cqi", "cqb", "cqmin", "cqmax", "deg", "grad", "rad", "turn", "s", "ms", "Hz", "kHz", "dpi", "dpcm", "dppx", "x", "fr"}
+
+var unitMap = map[string]Unit{}
+
+func init() {
+	for i, name := range unitNames {
+		if Unit(i) != NoUnit && Unit(i) != UnknownUnit {
+			unitMap[string(ToLower([]byte(name)))] = Unit(i)
+		}
+	}
+}
+
+// ToUnit returns the Unit of its case-insensitive name, such as px or %, or UnknownUnit if it is not a CSS unit.
+func ToUnit(b []byte) Unit {
+	var buf [8]byte
+	if len(buf) < len(b) {
+		return UnknownUnit
+	}
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	if unit, ok := unitMap[string(buf[:len(b)])]; ok {
+		return unit
+	}
+	return UnknownUnit
+}
+
+// String returns the canonical name of the unit, such as px, Q, or kHz, or the empty string for NoUnit and UnknownUnit.
+func (u Unit) String() string {
+	if int(u) < len(unitNames) {
+		return unitNames[u]
+	}
+	return "Invalid(" + strconv.Itoa(int(u)) + ")"
+}
+
+// Category returns the category of the unit.
+func (u Unit) Category() UnitCategory {
+	switch {
+	case u == Percent:
+		return PercentageCategory
+	case Px <= u && u <= Cqmax:
+		return LengthCategory
+	case Deg <= u && u <= Turn:
+		return AngleCategory
+	case S <= u && u <= Ms:
+		return TimeCategory
+	case Hz <= u && u <= KHz:
+		return FrequencyCategory
+	case Dpi <= u && u <= X:
+		return ResolutionCategory
+	case u == Fr:
+		return FlexCategory
+	}
+	return NoCategory
+}
+
+// IsAbsolute returns true for units that convert to the canonical unit of their category without context, that is all units except percentages, font-relative, viewport-relative, and container-relative lengths, and flexible lengths.
+func (u Unit) IsAbsolute() bool {
+	return Px <= u && u <= Pc || Deg <= u && u <= X
+}
+
+// Factor returns the factor that converts a value of an absolute unit to the canonical unit of its category, that is px, deg, s, Hz, and dppx, or 0 if the unit is not absolute. For example, 1in is 96px and 1turn is 360deg.
+func (u Unit) Factor() float64 {
+	switch u {
+	case Px, Deg, S, Hz, Dppx, X:
+		return 1.0
+	case Cm:
+		return 96.0 / 2.54
+	case Mm:
+		return 96.0 / 25.4
+	case Q:
+		return 96.0 / 101.6
+	case In:
+		return 96.0
+	case Pt:
+		return 96.0 / 72.0
+	case Pc:
+		return 16.0
+	case Grad:
+		return 0.9
+	case Rad:
+		return 180.0 / math.Pi
+	case Turn:
+		return 360.0
+	case Ms:
+		return 0.001
+	case KHz:
+		return 1000.0
+	case Dpi:
+		return 1.0 / 96.0
+	case Dpcm:
+		return 2.54 / 96.0
+	}
+	return 0.0
+}
+
+// Convert converts a value of unit u to unit to, such as 1in to 2.54cm. It returns false if either unit is not absolute or if they are of different categories.
+func (u Unit) Convert(value float64, to Unit) (float64, bool) {
+	if !u.IsAbsolute() || !to.IsAbsolute() || u.Category() != to.Category() {
+		return 0.0, false
+	} else if u == to {
+		return value, true
+	}
+	return value * u.Factor() / to.Factor(), true
+}
+
+// DimensionValue parses a dimension, percentage, or number such as 1.5em, see Dimension. It returns the length of the number and its unit, the value of the number, and the unit, which is UnknownUnit if the unit is not a CSS unit and NoUnit if there is none.
+func DimensionValue(b []byte) (int, float64, Unit) {
+	num, unit := Dimension(b)
+	if num == 0 {
+		return 0, 0.0, NoUnit
+	}
+	_, f, _ := NumberValue(b[:num], 0)
+	if unit == 0 {
+		return num, f, NoUnit
+	}
+	return num + unit, f, ToUnit(b[num : num+unit])
+}
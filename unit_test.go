@@ -0,0 +1,87 @@
+package parse
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestDimensionValue(t *testing.T) {
+	var tests = []struct {
+		dimension string
+		n         int
+		value     float64
+		unit      Unit
+		category  UnitCategory
+	}{
+		{"5px", 3, 5.0, Px, LengthCategory},
+		{"-1.5EM;", 6, -1.5, Em, LengthCategory},
+		{"50%", 3, 50.0, Percent, PercentageCategory},
+		{"10dvmax", 7, 10.0, Dvmax, LengthCategory},
+		{"2q", 2, 2.0, Q, LengthCategory},
+		{".5turn", 6, 0.5, Turn, AngleCategory},
+		{"300ms", 5, 300.0, Ms, TimeCategory},
+		{"1kHz", 4, 1.0, KHz, FrequencyCategory},
+		{"2x", 2, 2.0, X, ResolutionCategory},
+		{"1fr", 3, 1.0, Fr, FlexCategory},
+		{"3", 1, 3.0, NoUnit, NoCategory},
+		{"3foo", 4, 3.0, UnknownUnit, NoCategory},
+		{"3abcdefghi", 10, 3.0, UnknownUnit, NoCategory},
+		{"px", 0, 0.0, NoUnit, NoCategory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dimension, func(t *testing.T) {
+			n, value, unit := DimensionValue([]byte(tt.dimension))
+			test.T(t, n, tt.n)
+			test.T(t, value, tt.value)
+			test.T(t, unit, tt.unit)
+			test.T(t, unit.Category(), tt.category)
+		})
+	}
+}
+
+func TestUnit(t *testing.T) {
+	test.T(t, ToUnit([]byte("KHZ")), KHz)
+	test.T(t, ToUnit([]byte("Q")), Q)
+	test.T(t, ToUnit([]byte("")), UnknownUnit)
+	test.String(t, KHz.String(), "kHz")
+	test.String(t, Dvmin.String(), "dvmin")
+	test.String(t, Unit(200).String(), "Invalid(200)")
+	test.String(t, ResolutionCategory.String(), "resolution")
+	for i := Percent; i <= Fr; i++ {
+		test.T(t, ToUnit([]byte(i.String())), i)
+		test.That(t, i.Category() != NoCategory, i.String())
+		test.T(t, i.IsAbsolute(), i.Factor() != 0.0, i.String())
+	}
+}
+
+func TestUnitConvert(t *testing.T) {
+	var tests = []struct {
+		value    float64
+		from, to Unit
+		expected float64
+		ok       bool
+	}{
+		{1.0, In, Cm, 2.54, true},
+		{1.0, In, Px, 96.0, true},
+		{12.0, Pt, Pc, 1.0, true},
+		{40.0, Q, Mm, 10.0, true},
+		{math.Pi, Rad, Deg, 180.0, true},
+		{100.0, Grad, Turn, 0.25, true},
+		{1.5, S, Ms, 1500.0, true},
+		{1.0, KHz, Hz, 1000.0, true},
+		{96.0, Dpi, Dppx, 1.0, true},
+		{2.0, X, X, 2.0, true},
+		{1.0, Em, Px, 0.0, false},
+		{1.0, Px, Deg, 0.0, false},
+		{1.0, Percent, Percent, 0.0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from.String()+"-"+tt.to.String(), func(t *testing.T) {
+			value, ok := tt.from.Convert(tt.value, tt.to)
+			test.T(t, ok, tt.ok)
+			test.Float(t, value, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package parse
+
+// URL is a URL reference split into its components, such as the value of an href or src attribute or of a CSS url(). The components are subslices of the reference, so that splitting does not allocate, and a component is nil when it is absent and empty when only its delimiter is present, so that the reference can be reassembled as written.
+type URL struct {
+	Scheme   []byte // scheme without the colon, such as https
+	Host     []byte // authority without the leading //, including the userinfo and port
+	Path     []byte
+	Query    []byte // query without the ?
+	Fragment []byte // fragment without the #
+}
+
+// ParseURL splits a URL reference into its components, see RFC 3986. Unlike net/url, it does not validate or unescape the components, so that any reference written in a document can be split and reassembled unchanged. Surrounding whitespace should be removed by the caller.
+func ParseURL(b []byte) URL {
+	u := URL{}
+	if b == nil {
+		b = []byte{}
+	}
+	if i := urlSchemeLen(b); 0 < i {
+		u.Scheme = b[:i]
+		b = b[i+1:]
+	}
+	if 1 < len(b) && b[0] == '/' && b[1] == '/' {
+		i := 2
+		for i < len(b) && b[i] != '/' && b[i] != '?' && b[i] != '#' {
+			i++
+		}
+		u.Host = b[2:i]
+		b = b[i:]
+	}
+	i := 0
+	for i < len(b) && b[i] != '?' && b[i] != '#' {
+		i++
+	}
+	u.Path = b[:i]
+	if i < len(b) && b[i] == '?' {
+		j := i + 1
+		for j < len(b) && b[j] != '#' {
+			j++
+		}
+		u.Query = b[i+1 : j]
+		i = j
+	}
+	if i < len(b) {
+		u.Fragment = b[i+1:]
+	}
+	return u
+}
+
+// urlSchemeLen returns the length of the scheme at the start of b when it is followed by a colon, or zero otherwise.
+func urlSchemeLen(b []byte) int {
+	if len(b) == 0 || !('a' <= b[0] && b[0] <= 'z' || 'A' <= b[0] && b[0] <= 'Z') {
+		return 0
+	}
+	for i := 1; i < len(b); i++ {
+		c := b[i]
+		if c == ':' {
+			return i
+		} else if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.') {
+			return 0
+		}
+	}
+	return 0
+}
+
+// IsAbsolute returns true if the URL has a scheme.
+func (u URL) IsAbsolute() bool {
+	return u.Scheme != nil
+}
+
+// IsProtocolRelative returns true if the URL has a host but no scheme, such as //cdn.example.com/a.js, which takes the scheme of the document.
+func (u URL) IsProtocolRelative() bool {
+	return u.Scheme == nil && u.Host != nil
+}
+
+// IsFragmentOnly returns true if the URL consists of a fragment only, such as #top or #, which refers to the document itself.
+func (u URL) IsFragmentOnly() bool {
+	return u.Scheme == nil && u.Host == nil && len(u.Path) == 0 && u.Query == nil && u.Fragment != nil
+}
+
+// Len returns the length of the reassembled URL.
+func (u URL) Len() int {
+	n := len(u.Path)
+	if u.Scheme != nil {
+		n += len(u.Scheme) + 1
+	}
+	if u.Host != nil {
+		n += len(u.Host) + 2
+	}
+	if u.Query != nil {
+		n += len(u.Query) + 1
+	}
+	if u.Fragment != nil {
+		n += len(u.Fragment) + 1
+	}
+	return n
+}
+
+// AppendTo appends the reassembled URL to b.
+func (u URL) AppendTo(b []byte) []byte {
+	if u.Scheme != nil {
+		b = append(append(b, u.Scheme...), ':')
+	}
+	if u.Host != nil {
+		b = append(append(b, '/', '/'), u.Host...)
+	}
+	b = append(b, u.Path...)
+	if u.Query != nil {
+		b = append(append(b, '?'), u.Query...)
+	}
+	if u.Fragment != nil {
+		b = append(append(b, '#'), u.Fragment...)
+	}
+	return b
+}
+
+// Bytes returns the reassembled URL.
+func (u URL) Bytes() []byte {
+	return u.AppendTo(make([]byte, 0, u.Len()))
+}
+
+// String returns the reassembled URL.
+func (u URL) String() string {
+	return string(u.Bytes())
+}
+
+// Resolve returns the reference ref resolved against the URL as its base, see RFC 3986 section 5.2.2. Protocol-relative references take the scheme of the base, and fragment-only references keep the path and query of the base. Dot segments are removed from merged paths, while components that are taken as is are not copied.
+func (u URL) Resolve(ref URL) URL {
+	if ref.Scheme != nil {
+		ref.Path = removeDotSegments(ref.Path)
+		return ref
+	}
+	ref.Scheme = u.Scheme
+	if ref.Host != nil {
+		ref.Path = removeDotSegments(ref.Path)
+		return ref
+	}
+	ref.Host = u.Host
+	if len(ref.Path) == 0 {
+		ref.Path = u.Path
+		if ref.Query == nil {
+			ref.Query = u.Query
+		}
+	} else if ref.Path[0] == '/' {
+		ref.Path = removeDotSegments(ref.Path)
+	} else {
+		// merge with the directory of the base path
+		var path []byte
+		if u.Host != nil && len(u.Path) == 0 {
+			path = []byte{'/'}
+		} else {
+			for i := len(u.Path) - 1; 0 <= i; i-- {
+				if u.Path[i] == '/' {
+					path = u.Path[:i+1]
+					break
+				}
+			}
+		}
+		ref.Path = removeDotSegments(append(Copy(path), ref.Path...))
+	}
+	return ref
+}
+
+// Normalize returns the URL with its scheme and host in lowercase and the dot segments removed from its path if the path is absolute or the URL has a scheme or host, see RFC 3986 section 6.2.2. Relative paths such as ../a.png are kept, as they depend on the base they are resolved against.
+func (u URL) Normalize() URL {
+	if u.Scheme != nil {
+		u.Scheme = ToLower(Copy(u.Scheme))
+	}
+	if u.Host != nil {
+		u.Host = ToLower(Copy(u.Host))
+	}
+	if u.Scheme != nil || u.Host != nil || 0 < len(u.Path) && u.Path[0] == '/' {
+		u.Path = removeDotSegments(u.Path)
+	}
+	return u
+}
+
+// ResolveURL resolves the URL reference ref against base, see URL.Resolve.
+func ResolveURL(base, ref []byte) []byte {
+	return ParseURL(base).Resolve(ParseURL(ref)).Bytes()
+}
+
+// removeDotSegments removes the . and .. segments of a path, see RFC 3986 section 5.2.4. It returns the path itself if it has no dot segments, and a new slice otherwise.
+func removeDotSegments(path []byte) []byte {
+	hasDots := false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' && (i == 0 || path[i-1] == '/') && (i+1 == len(path) || path[i+1] == '/' || path[i+1] == '.' && (i+2 == len(path) || path[i+2] == '/')) {
+			hasDots = true
+			break
+		}
+	}
+	if !hasDots {
+		return path
+	}
+
+	dst := make([]byte, 0, len(path))
+	for i := 0; i < len(path); {
+		// the segment ends at the next slash, excluding it
+		j := i
+		if path[j] == '/' {
+			j++
+		}
+		for j < len(path) && path[j] != '/' {
+			j++
+		}
+		segment := path[i:j]
+		slash := 0 < len(segment) && segment[0] == '/'
+		name := segment
+		if slash {
+			name = segment[1:]
+		}
+		if string(name) == "." || string(name) == ".." {
+			if string(name) == ".." && slash {
+				// remove the last segment of the output
+				k := len(dst) - 1
+				for 0 <= k && dst[k] != '/' {
+					k--
+				}
+				if k < 0 {
+					k = 0
+				}
+				dst = dst[:k]
+			}
+			if slash && j == len(path) {
+				dst = append(dst, '/')
+			} else if !slash && j < len(path) {
+				j++ // remove the prefix ./ or ../ of a relative path
+			}
+		} else {
+			dst = append(dst, segment...)
+		}
+		i = j
+	}
+	return dst
+}
@@ -0,0 +1,145 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseURL(t *testing.T) {
+	var tests = []struct {
+		url                                 string
+		scheme, host, path, query, fragment string
+	}{
+		{"", "<nil>", "<nil>", "", "<nil>", "<nil>"},
+		{"https://example.com/a/b?c=d#e", "https", "example.com", "/a/b", "c=d", "e"},
+		{"HTTP://user:pw@Example.com:8080", "HTTP", "user:pw@Example.com:8080", "", "<nil>", "<nil>"},
+		{"//cdn.example.com/a.js", "<nil>", "cdn.example.com", "/a.js", "<nil>", "<nil>"},
+		{"#top", "<nil>", "<nil>", "", "<nil>", "top"},
+		{"#", "<nil>", "<nil>", "", "<nil>", ""},
+		{"?", "<nil>", "<nil>", "", "", "<nil>"},
+		{"../img/a.png?v=1", "<nil>", "<nil>", "../img/a.png", "v=1", "<nil>"},
+		{"a:b", "a", "<nil>", "b", "<nil>", "<nil>"},
+		{"./a:b", "<nil>", "<nil>", "./a:b", "<nil>", "<nil>"},
+		{"1a:b", "<nil>", "<nil>", "1a:b", "<nil>", "<nil>"},
+		{"data:image/png;base64,iVBOR#x", "data", "<nil>", "image/png;base64,iVBOR", "<nil>", "x"},
+		{"mailto:a@example.com", "mailto", "<nil>", "a@example.com", "<nil>", "<nil>"},
+		{"file:///etc/hosts", "file", "", "/etc/hosts", "<nil>", "<nil>"},
+		{"a?b#c?d#e", "<nil>", "<nil>", "a", "b", "c?d#e"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u := ParseURL([]byte(tt.url))
+			test.String(t, urlComponent(u.Scheme), tt.scheme, "scheme")
+			test.String(t, urlComponent(u.Host), tt.host, "host")
+			test.String(t, string(u.Path), tt.path, "path")
+			test.String(t, urlComponent(u.Query), tt.query, "query")
+			test.String(t, urlComponent(u.Fragment), tt.fragment, "fragment")
+			test.String(t, u.String(), tt.url)
+			test.T(t, u.Len(), len(tt.url))
+		})
+	}
+}
+
+func TestURLKind(t *testing.T) {
+	var tests = []struct {
+		url                                      string
+		absolute, protocolRelative, fragmentOnly bool
+	}{
+		{"https://a/b", true, false, false},
+		{"//a/b", false, true, false},
+		{"#a", false, false, true},
+		{"#", false, false, true},
+		{"?a#b", false, false, false},
+		{"b#a", false, false, false},
+		{"", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u := ParseURL([]byte(tt.url))
+			test.T(t, u.IsAbsolute(), tt.absolute, "absolute")
+			test.T(t, u.IsProtocolRelative(), tt.protocolRelative, "protocol-relative")
+			test.T(t, u.IsFragmentOnly(), tt.fragmentOnly, "fragment-only")
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	// examples of RFC 3986 section 5.4
+	var tests = []struct {
+		ref      string
+		expected string
+	}{
+		{"g:h", "g:h"},
+		{"g", "http://a/b/c/g"},
+		{"./g", "http://a/b/c/g"},
+		{"g/", "http://a/b/c/g/"},
+		{"/g", "http://a/g"},
+		{"//g", "http://g"},
+		{"?y", "http://a/b/c/d;p?y"},
+		{"g?y", "http://a/b/c/g?y"},
+		{"#s", "http://a/b/c/d;p?q#s"},
+		{"g?y#s", "http://a/b/c/g?y#s"},
+		{";x", "http://a/b/c/;x"},
+		{"", "http://a/b/c/d;p?q"},
+		{".", "http://a/b/c/"},
+		{"./", "http://a/b/c/"},
+		{"..", "http://a/b/"},
+		{"../", "http://a/b/"},
+		{"../g", "http://a/b/g"},
+		{"../..", "http://a/"},
+		{"../../g", "http://a/g"},
+		{"../../../g", "http://a/g"},
+		{"../../../../g", "http://a/g"},
+		{"/./g", "http://a/g"},
+		{"/../g", "http://a/g"},
+		{"g.", "http://a/b/c/g."},
+		{".g", "http://a/b/c/.g"},
+		{"g..", "http://a/b/c/g.."},
+		{"..g", "http://a/b/c/..g"},
+		{"./../g", "http://a/b/g"},
+		{"./g/.", "http://a/b/c/g/"},
+		{"g/./h", "http://a/b/c/g/h"},
+		{"g/../h", "http://a/b/c/h"},
+		{"g;x=1/./y", "http://a/b/c/g;x=1/y"},
+		{"g;x=1/../y", "http://a/b/c/y"},
+		{"g?y/./x", "http://a/b/c/g?y/./x"},
+		{"g#s/../x", "http://a/b/c/g#s/../x"},
+		{"http:g", "http:g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			test.String(t, string(ResolveURL([]byte("http://a/b/c/d;p?q"), []byte(tt.ref))), tt.expected)
+		})
+	}
+
+	test.String(t, string(ResolveURL([]byte("https://example.com"), []byte("a.png"))), "https://example.com/a.png")
+	test.String(t, string(ResolveURL([]byte("/css/main.css"), []byte("../img/a.png"))), "/img/a.png")
+	test.String(t, string(ResolveURL([]byte("css/main.css"), []byte("./a.png"))), "css/a.png")
+	test.String(t, string(ResolveURL([]byte(""), []byte("../a.png"))), "a.png")
+}
+
+func TestNormalizeURL(t *testing.T) {
+	var tests = []struct {
+		url      string
+		expected string
+	}{
+		{"HTTPS://Example.COM/a/./b/../c?Q#F", "https://example.com/a/c?Q#F"},
+		{"/a/../b", "/b"},
+		{"../a/./b", "../a/./b"},
+		{"//CDN.example.com/./a", "//cdn.example.com/a"},
+		{"#Top", "#Top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			test.String(t, ParseURL([]byte(tt.url)).Normalize().String(), tt.expected)
+		})
+	}
+}
+
+func urlComponent(b []byte) string {
+	if b == nil {
+		return "<nil>"
+	}
+	return string(b)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Copy returns a copy of the given byte slice.
@@ -175,6 +176,36 @@ func TrimWhitespace(b []byte) []byte {
 	return b[start:end]
 }
 
+// redactPlaceholders are characters to replace non-ASCII characters with, indexed by their encoded length, so that both byte offsets and character counts are kept.
+var redactPlaceholders = [5]string{"", "x", "\u00e9", "\u20ac", "\U00010000"}
+
+// Redact replaces the contents of b in place by placeholders of the same length so that inputs can be shared without revealing their text. ASCII letters are replaced by x or X, digits by 0, and valid non-ASCII characters by a placeholder character of the same encoded length. Whitespace, punctuation, and invalid UTF-8 bytes are kept, so that the structure and all offsets stay intact. If skip is not nil, it returns the number of bytes at the start of its argument to keep as is, such as escape sequences whose letters are significant. This is useful to share reproductions of parser bugs for proprietary inputs, see the Redact functions of the css, html, js, json, and xml packages.
+func Redact(b []byte, skip func([]byte) int) {
+	for i := 0; i < len(b); {
+		if skip != nil {
+			if n := skip(b[i:]); 0 < n {
+				i += n
+				continue
+			}
+		}
+		c := b[i]
+		if 'a' <= c && c <= 'z' {
+			b[i] = 'x'
+		} else if 'A' <= c && c <= 'Z' {
+			b[i] = 'X'
+		} else if '0' <= c && c <= '9' {
+			b[i] = '0'
+		} else if 0x80 <= c {
+			if r, n := utf8.DecodeRune(b[i:]); r != utf8.RuneError || n != 1 {
+				copy(b[i:], redactPlaceholders[n])
+				i += n
+				continue
+			}
+		}
+		i++
+	}
+}
+
 type Indenter struct {
 	io.Writer
 	b []byte
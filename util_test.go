@@ -71,6 +71,34 @@ func TestTrim(t *testing.T) {
 	test.Bytes(t, TrimWhitespace([]byte(" ")), []byte(""))
 }
 
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		s        string
+		expected string
+	}{
+		{"Hello, World 42!", "Xxxxx, Xxxxx 00!"},
+		{"caf\u00e9 \u20ac5 \U0001f600", "xxx\u00e9 \u20ac0 \U00010000"},
+		{"a\tb\nc \"d\"", "x\tx\nx \"x\""},
+		{"\xffa\xc3", "\xffx\xc3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			b := []byte(tt.s)
+			Redact(b, nil)
+			test.String(t, string(b), tt.expected)
+		})
+	}
+
+	b := []byte(`ab\ncd\x41`)
+	Redact(b, func(b []byte) int {
+		if b[0] == '\\' {
+			return 2
+		}
+		return 0
+	})
+	test.String(t, string(b), `xx\nxx\x00`)
+}
+
 func TestPrintable(t *testing.T) {
 	var tests = []struct {
 		s         string
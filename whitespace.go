@@ -0,0 +1,116 @@
+package parse
+
+import (
+	"unicode/utf8"
+)
+
+// WhitespaceClass is the set of characters that are whitespace for the functions of WhitespaceClass and for CollapseWhitespace.
+type WhitespaceClass int
+
+// WhitespaceClass values.
+const (
+	ASCIIWhitespace       WhitespaceClass = iota // space, \t, \n, \f, and \r, as in HTML and CSS, see IsWhitespace
+	UnicodeWhitespace                            // characters with the Unicode White_Space property, except the non-breaking spaces U+00A0, U+2007, and U+202F
+	UnicodeNBSPWhitespace                        // all characters with the Unicode White_Space property, including the non-breaking spaces
+)
+
+// Is returns true if r is whitespace.
+func (class WhitespaceClass) Is(r rune) bool {
+	if r < utf8.RuneSelf {
+		if class == ASCIIWhitespace {
+			return IsWhitespace(byte(r))
+		}
+		return IsWhitespace(byte(r)) || r == '\v'
+	} else if class == ASCIIWhitespace {
+		return false
+	}
+	switch r {
+	case 0x00A0, 0x2007, 0x202F:
+		return class == UnicodeNBSPWhitespace
+	case 0x0085, 0x1680, 0x2028, 0x2029, 0x205F, 0x3000:
+		return true
+	}
+	return 0x2000 <= r && r <= 0x200A
+}
+
+// Len returns the length in bytes of the whitespace character at the start of b, or zero if b does not start with whitespace.
+func (class WhitespaceClass) Len(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	} else if b[0] < utf8.RuneSelf || class == ASCIIWhitespace {
+		if class.Is(rune(b[0])) {
+			return 1
+		}
+		return 0
+	}
+	r, n := utf8.DecodeRune(b)
+	if class.Is(r) {
+		return n
+	}
+	return 0
+}
+
+// lastLen returns the length in bytes of the whitespace character at the end of b, or zero if b does not end with whitespace.
+func (class WhitespaceClass) lastLen(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	} else if b[len(b)-1] < utf8.RuneSelf || class == ASCIIWhitespace {
+		if class.Is(rune(b[len(b)-1])) {
+			return 1
+		}
+		return 0
+	}
+	r, n := utf8.DecodeLastRune(b)
+	if class.Is(r) {
+		return n
+	}
+	return 0
+}
+
+// IsAll returns true if b consists of whitespace only.
+func (class WhitespaceClass) IsAll(b []byte) bool {
+	for i := 0; i < len(b); {
+		n := class.Len(b[i:])
+		if n == 0 {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+// Trim removes leading and trailing whitespace.
+func (class WhitespaceClass) Trim(b []byte) []byte {
+	for n := class.Len(b); n != 0; n = class.Len(b) {
+		b = b[n:]
+	}
+	for n := class.lastLen(b); n != 0; n = class.lastLen(b) {
+		b = b[:len(b)-n]
+	}
+	return b
+}
+
+// CollapseWhitespace collapses whitespace in place as HTML renders text with the CSS white-space property set to normal, that is it removes leading and trailing whitespace and replaces other series of whitespace, including newlines, by a single space. Unlike ReplaceMultipleWhitespace, which keeps a newline to minify text without changing its rendering, the result is the rendered text, such as for the label of an option element or for text extraction. Pass ASCIIWhitespace for HTML, which keeps non-breaking spaces.
+func CollapseWhitespace(b []byte, class WhitespaceClass) []byte {
+	dst := b[:0]
+	space := false
+	for i := 0; i < len(b); {
+		if n := class.Len(b[i:]); n != 0 {
+			space = true
+			i += n
+			continue
+		} else if space && 0 < len(dst) {
+			dst = append(dst, ' ')
+		}
+		space = false
+		if b[i] < utf8.RuneSelf || class == ASCIIWhitespace {
+			dst = append(dst, b[i])
+			i++
+		} else {
+			_, n := utf8.DecodeRune(b[i:])
+			dst = append(dst, b[i:i+n]...)
+			i += n
+		}
+	}
+	return dst
+}
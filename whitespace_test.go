@@ -0,0 +1,94 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestWhitespaceClass(t *testing.T) {
+	var tests = []struct {
+		r                    rune
+		ascii, unicode, nbsp bool
+	}{
+		{' ', true, true, true},
+		{'\t', true, true, true},
+		{'\n', true, true, true},
+		{'\f', true, true, true},
+		{'\r', true, true, true},
+		{'\v', false, true, true},
+		{'a', false, false, false},
+		{0x0085, false, true, true},
+		{0x00A0, false, false, true},
+		{0x1680, false, true, true},
+		{0x2000, false, true, true},
+		{0x2007, false, false, true},
+		{0x200A, false, true, true},
+		{0x200B, false, false, false},
+		{0x2028, false, true, true},
+		{0x202F, false, false, true},
+		{0x3000, false, true, true},
+		{0xFEFF, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.r), func(t *testing.T) {
+			test.T(t, ASCIIWhitespace.Is(tt.r), tt.ascii, "ascii")
+			test.T(t, UnicodeWhitespace.Is(tt.r), tt.unicode, "unicode")
+			test.T(t, UnicodeNBSPWhitespace.Is(tt.r), tt.nbsp, "nbsp")
+		})
+	}
+
+	test.T(t, UnicodeWhitespace.Len([]byte("\u3000a")), 3)
+	test.T(t, ASCIIWhitespace.Len([]byte("\u3000a")), 0)
+	test.T(t, UnicodeWhitespace.Len([]byte("\xe3")), 0)
+	test.T(t, UnicodeWhitespace.Len(nil), 0)
+	test.That(t, UnicodeWhitespace.IsAll([]byte("  \n")))
+	test.That(t, !ASCIIWhitespace.IsAll([]byte("  \n")))
+	test.That(t, !UnicodeWhitespace.IsAll([]byte("\u00a0")))
+	test.That(t, UnicodeNBSPWhitespace.IsAll([]byte("\u00a0")))
+}
+
+func TestTrimWhitespaceClass(t *testing.T) {
+	var tests = []struct {
+		class    WhitespaceClass
+		b        string
+		expected string
+	}{
+		{ASCIIWhitespace, " \t a b \n", "a b"},
+		{ASCIIWhitespace, "\u00a0a\u00a0", "\u00a0a\u00a0"},
+		{UnicodeWhitespace, "\u3000 a b ", "a b"},
+		{UnicodeWhitespace, "\u00a0a\u00a0", "\u00a0a\u00a0"},
+		{UnicodeNBSPWhitespace, "\u00a0a ", "a"},
+		{UnicodeWhitespace, "\u3000 ", ""},
+		{UnicodeWhitespace, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			test.String(t, string(tt.class.Trim([]byte(tt.b))), tt.expected)
+		})
+	}
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	var tests = []struct {
+		class    WhitespaceClass
+		b        string
+		expected string
+	}{
+		{ASCIIWhitespace, "", ""},
+		{ASCIIWhitespace, "   ", ""},
+		{ASCIIWhitespace, "a", "a"},
+		{ASCIIWhitespace, "  a  b\n\n c\t", "a b c"},
+		{ASCIIWhitespace, "a\u00a0\u00a0b", "a\u00a0\u00a0b"},
+		{ASCIIWhitespace, "a \u3000 b", "a \u3000 b"},
+		{UnicodeWhitespace, "a \u3000 b ", "a b"},
+		{UnicodeWhitespace, " é\u00a0 ü", "é\u00a0 ü"},
+		{UnicodeNBSPWhitespace, "a\u00a0\u00a0b ", "a b"},
+		{UnicodeWhitespace, "a\xff  b", "a\xff b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.b, func(t *testing.T) {
+			test.String(t, string(CollapseWhitespace([]byte(tt.b), tt.class)), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// ErrNoStartElement is returned by Decoder.Subtree when the last event was not a StartElementEvent.
+var ErrNoStartElement = errors.New("subtree requested without start element")
+
+// EventType determines the type of event returned by the decoder.
+type EventType uint32
+
+// EventType values.
+const (
+	ErrorEvent        EventType = iota // extra event when errors occur
+	StartElementEvent                  // element without its children
+	EndElementEvent
+	NodeEvent // text, CDATA, comment, processing instruction, or DOCTYPE node
+)
+
+// String returns the string representation of an EventType.
+func (et EventType) String() string {
+	switch et {
+	case ErrorEvent:
+		return "Error"
+	case StartElementEvent:
+		return "StartElement"
+	case EndElementEvent:
+		return "EndElement"
+	case NodeEvent:
+		return "Node"
+	}
+	return "Invalid(" + strconv.Itoa(int(et)) + ")"
+}
+
+// Decoder streams the nodes of an XML document, resolving the namespaces of elements and attributes. Nodes are not appended to their parent so that memory use does not grow with the document size, but their Parent is set to the enclosing element. The subtree of an element can be materialized on demand using Subtree.
+type Decoder struct {
+	r    *parse.Input
+	l    *Lexer
+	err  error
+	diag *parse.Diagnostics
+
+	doc   *Node
+	open  *Node // innermost open element, or doc
+	ns    namespaces
+	nsLen []int
+
+	last       EventType
+	lastNode   *Node
+	pendingEnd bool // the last element was self-closing
+
+	entities map[string][]byte // internal entities declared in the DOCTYPE
+}
+
+// NewDecoder returns a new Decoder for a given parse.Input.
+func NewDecoder(r *parse.Input) *Decoder {
+	doc := &Node{Type: DocumentNode}
+	return &Decoder{
+		r:    r,
+		l:    NewLexer(r),
+		doc:  doc,
+		open: doc,
+
+		entities: map[string][]byte{},
+	}
+}
+
+// Bind binds a namespace prefix to a namespace URI for the whole document, or the default namespace for an empty prefix, as for fragments that are embedded in another document. It must be called before the first call to Next, and declarations in the document take precedence.
+func (d *Decoder) Bind(prefix, uri string) {
+	var p []byte
+	if prefix != "" {
+		p = []byte(prefix)
+	}
+	d.ns.bind(p, []byte(uri))
+}
+
+// SetDiagnostics sets a collector to which all well-formedness errors are added, so that a single pass over a document reports every problem. The decoder recovers from invalid names by keeping them, ignores unexpected end tags, and closes the open elements at the end of the input. Errors of the lexer, such as a NULL character, still stop the decoder, as does reaching the maximum number of errors.
+func (d *Decoder) SetDiagnostics(diag *parse.Diagnostics) {
+	d.diag = diag
+}
+
+// fail stops the decoder with an error, unless it is collecting diagnostics in which case the error is reported and fail returns true.
+func (d *Decoder) fail(err *parse.Error) bool {
+	if d.diag != nil && d.diag.Add(err) {
+		return true
+	}
+	d.err = err
+	return false
+}
+
+// Err returns the error encountered during decoding, this is often io.EOF but also other errors can be returned.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Document returns the document node, its End offset is set once the end of the input was reached.
+func (d *Decoder) Document() *Node {
+	return d.doc
+}
+
+// Edition returns the edition of XML declared by the XML declaration of the document, see Lexer.Edition.
+func (d *Decoder) Edition() Edition {
+	return d.l.Edition()
+}
+
+// Next returns the next event and its node. Self-closing elements return both a StartElementEvent and an EndElementEvent, and the EndElementEvent returns the same node as its StartElementEvent. It returns ErrorEvent when an error was encountered or the input ended, see Err.
+func (d *Decoder) Next() (EventType, *Node) {
+	d.last, d.lastNode = d.next()
+	return d.last, d.lastNode
+}
+
+func (d *Decoder) next() (EventType, *Node) {
+	if d.pendingEnd {
+		d.pendingEnd = false
+		return EndElementEvent, d.endElement()
+	} else if d.err != nil {
+		return ErrorEvent, nil
+	}
+
+	for {
+		start := d.r.Offset()
+		tt, data := d.l.Next()
+		switch tt {
+		case ErrorToken:
+			if d.l.Err() != io.EOF {
+				d.err = d.l.Err()
+			} else if d.open != d.doc {
+				if d.fail(parse.NewError(d.r, d.r.Offset(), "unexpected EOF, expected end tag </%s>", string(d.open.Name))) {
+					d.open.End = d.r.Offset()
+					return EndElementEvent, d.endElement()
+				}
+			} else {
+				d.doc.End = d.r.Offset()
+				d.err = io.EOF
+			}
+			return ErrorEvent, nil
+		case CommentToken:
+			return NodeEvent, &Node{Type: CommentNode, Data: d.l.Text(), Parent: d.open, Start: start, End: d.r.Offset()}
+		case DOCTYPEToken:
+			addEntities(d.entities, d.l.Text())
+			return NodeEvent, &Node{Type: DOCTYPENode, Data: d.l.Text(), Parent: d.open, Start: start, End: d.r.Offset()}
+		case CDATAToken:
+			return NodeEvent, &Node{Type: CDATANode, Data: d.l.Text(), Parent: d.open, Start: start, End: d.r.Offset()}
+		case TextToken:
+			return NodeEvent, &Node{Type: TextNode, Data: data, Parent: d.open, Start: start, End: d.r.Offset()}
+		case StartTagToken, StartTagPIToken:
+			n := &Node{Type: ElementNode, Name: d.l.Text(), Parent: d.open, Start: start}
+			valid, kind, nameStart := isQName(n.Name, d.l.Edition()), "element", start+1
+			if tt == StartTagPIToken {
+				n.Type = ProcInstNode
+				valid, kind, nameStart = IsName(n.Name, d.l.Edition()), "processing instruction", start+2
+			}
+			if !valid && !d.fail(parse.NewError(d.r, nameStart, "invalid %s name %q", kind, string(n.Name))) {
+				return ErrorEvent, nil
+			}
+			nsLen := d.ns.len()
+			for {
+				tt, data = d.l.Next()
+				if tt != AttributeToken {
+					break
+				}
+				attr := Attr{Name: d.l.Text(), Val: unquote(d.l.AttrVal())}
+				if n.Type == ElementNode && !isQName(attr.Name, d.l.Edition()) || !IsName(attr.Name, d.l.Edition()) {
+					if !d.fail(parse.NewError(d.r, d.r.Offset()-len(bytes.TrimLeft(data, " \t\r\n")), "invalid attribute name %q", string(attr.Name))) {
+						return ErrorEvent, nil
+					}
+				}
+				if n.Type == ElementNode {
+					if bytes.Equal(attr.Name, xmlnsPrefixBytes) {
+						d.ns.bind(nil, attr.Val)
+					} else if prefix, local := splitName(attr.Name); bytes.Equal(prefix, xmlnsPrefixBytes) {
+						d.ns.bind(local, attr.Val)
+					}
+				}
+				n.Attrs = append(n.Attrs, attr)
+			}
+			n.End = d.r.Offset()
+			if n.Type == ProcInstNode {
+				return NodeEvent, n
+			}
+			d.ns.resolve(n)
+			d.nsLen = append(d.nsLen, nsLen)
+			d.open = n
+			d.pendingEnd = tt != StartTagCloseToken
+			return StartElementEvent, n
+		case EndTagToken:
+			if d.open == d.doc || !bytes.Equal(d.open.Name, d.l.Text()) {
+				if d.fail(parse.NewError(d.r, start, "unexpected end tag </%s>", string(d.l.Text()))) {
+					continue
+				}
+				return ErrorEvent, nil
+			}
+			d.open.End = d.r.Offset()
+			return EndElementEvent, d.endElement()
+		}
+	}
+}
+
+func (d *Decoder) endElement() *Node {
+	n := d.open
+	d.open = n.Parent
+	d.ns.truncate(d.nsLen[len(d.nsLen)-1])
+	d.nsLen = d.nsLen[:len(d.nsLen)-1]
+	return n
+}
+
+// Subtree materializes the children of the element returned by the last StartElementEvent, consuming the input up to and including its end tag, and returns the element. Streaming continues after the element, and no EndElementEvent is returned for it.
+func (d *Decoder) Subtree() (*Node, error) {
+	if d.last != StartElementEvent {
+		return nil, ErrNoStartElement
+	}
+	n := d.lastNode
+	if err := d.build(n); err != nil {
+		return nil, err
+	}
+	d.last, d.lastNode = EndElementEvent, n
+	return n, nil
+}
+
+// build appends all nodes to their parents until the end of root.
+func (d *Decoder) build(root *Node) error {
+	for {
+		et, n := d.next()
+		switch et {
+		case ErrorEvent:
+			if d.err == io.EOF && root == d.doc {
+				return nil
+			}
+			return d.err
+		case StartElementEvent, NodeEvent:
+			n.Parent.appendChild(n)
+		case EndElementEvent:
+			if n == root {
+				return nil
+			}
+		}
+	}
+}
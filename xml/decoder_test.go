@@ -0,0 +1,179 @@
+package xml
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestDecoder(t *testing.T) {
+	s := `<?xml version="1.0"?><a xmlns:p="urn:p"><p:b x="1"/>text<!--c--></a>`
+	d := NewDecoder(parse.NewInputString(s))
+	events := []string{}
+	for {
+		et, n := d.Next()
+		if et == ErrorEvent {
+			test.T(t, d.Err(), io.EOF)
+			break
+		}
+		event := et.String()
+		if n.Type == ElementNode || n.Type == ProcInstNode {
+			event += ":" + string(n.Name)
+		} else {
+			event += ":" + n.Type.String()
+		}
+		events = append(events, event)
+		test.T(t, len(n.Children), 0)
+		if et == StartElementEvent && string(n.Local) == "b" {
+			test.String(t, string(n.Space), "urn:p")
+			test.String(t, string(n.Parent.Name), "a")
+			test.T(t, n.Parent.Parent, d.Document())
+		}
+	}
+	test.String(t, strings.Join(events, " "), "Node:xml StartElement:a StartElement:p:b EndElement:p:b Node:Text Node:Comment EndElement:a")
+	test.T(t, d.Document().End, len(s))
+	test.T(t, len(d.Document().Children), 0)
+
+	et, _ := d.Next()
+	test.T(t, et, ErrorEvent)
+}
+
+func TestDecoderSubtree(t *testing.T) {
+	s := `<config xmlns:x="urn:x"><log level="1"/><db><x:host>localhost</x:host><port>5432</port></db><cache/></config>`
+	d := NewDecoder(parse.NewInputString(s))
+
+	_, err := d.Subtree()
+	test.T(t, err, ErrNoStartElement)
+
+	events := []string{}
+	for {
+		et, n := d.Next()
+		if et == ErrorEvent {
+			test.T(t, d.Err(), io.EOF)
+			break
+		}
+		events = append(events, et.String()+":"+string(n.Name))
+		if et == StartElementEvent && string(n.Name) == "db" {
+			db, err := d.Subtree()
+			test.Error(t, err)
+			test.T(t, db, n)
+			test.T(t, len(db.Children), 2)
+			test.String(t, string(db.Children[0].Space), "urn:x")
+			test.String(t, string(db.Children[0].Children[0].Data), "localhost")
+			test.T(t, db.Children[1].Parent, db)
+			test.String(t, s[db.Start:db.End], "<db><x:host>localhost</x:host><port>5432</port></db>")
+
+			_, err = d.Subtree()
+			test.T(t, err, ErrNoStartElement)
+		} else if et == StartElementEvent && string(n.Name) == "cache" {
+			cache, err := d.Subtree() // self-closing
+			test.Error(t, err)
+			test.T(t, len(cache.Children), 0)
+		}
+	}
+	test.String(t, strings.Join(events, " "), "StartElement:config StartElement:log EndElement:log StartElement:db StartElement:cache EndElement:config")
+}
+
+func TestDecoderErrors(t *testing.T) {
+	var errorTests = []struct {
+		xml     string
+		subtree bool
+		err     string
+	}{
+		{"<a></b>", false, "unexpected end tag </b>"},
+		{"<a><b>", false, "unexpected EOF, expected end tag </b>"},
+		{"<a><b>", true, "unexpected EOF, expected end tag </b>"},
+		{"<a><b></a>", true, "unexpected end tag </a>"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.xml, func(t *testing.T) {
+			d := NewDecoder(parse.NewInputString(tt.xml))
+			var err error
+			for {
+				et, _ := d.Next()
+				if et == ErrorEvent {
+					err = d.Err()
+					break
+				} else if tt.subtree {
+					if _, err = d.Subtree(); err != nil {
+						break
+					}
+				}
+			}
+			test.That(t, err != nil)
+			test.T(t, err.(*parse.Error).Message, tt.err)
+		})
+	}
+
+	// coverage
+	for i := 0; ; i++ {
+		if EventType(i).String() == fmt.Sprintf("Invalid(%d)", i) {
+			break
+		}
+	}
+}
+
+func TestDecoderDiagnostics(t *testing.T) {
+	var diagnosticsTests = []struct {
+		xml    string
+		events string
+		errs   string
+	}{
+		{"<a></b></a>", "StartElement:a EndElement:a", "unexpected end tag </b> on line 1 and column 4"},
+		{"<a><b>text", "StartElement:a StartElement:b Node:Text EndElement:b EndElement:a", "unexpected EOF, expected end tag </b> on line 1 and column 11\nunexpected EOF, expected end tag </a> on line 1 and column 11"},
+		{"<1a 2b=\"c\" d=\"e\"/>", "StartElement:1a EndElement:1a", "invalid element name \"1a\" on line 1 and column 2\ninvalid attribute name \"2b\" on line 1 and column 5"},
+	}
+	for _, tt := range diagnosticsTests {
+		t.Run(tt.xml, func(t *testing.T) {
+			diag := parse.NewDiagnostics(0)
+			d := NewDecoder(parse.NewInputString(tt.xml))
+			d.SetDiagnostics(diag)
+			events := []string{}
+			for {
+				et, n := d.Next()
+				if et == ErrorEvent {
+					test.T(t, d.Err(), io.EOF)
+					break
+				}
+				event := et.String()
+				if n.Type == ElementNode {
+					event += ":" + string(n.Name)
+				} else {
+					event += ":" + n.Type.String()
+				}
+				events = append(events, event)
+			}
+			test.String(t, strings.Join(events, " "), tt.events)
+			test.String(t, diag.Error(), tt.errs)
+		})
+	}
+
+	// stop at the maximum number of errors
+	diag := parse.NewDiagnostics(1)
+	d := NewDecoder(parse.NewInputString("<a></b></c></a>"))
+	d.SetDiagnostics(diag)
+	for {
+		if et, _ := d.Next(); et == ErrorEvent {
+			break
+		}
+	}
+	test.T(t, d.Err().(*parse.Error).Message, "unexpected end tag </c>")
+	test.String(t, diag.Error(), "unexpected end tag </b> on line 1 and column 4\nand 1 more errors")
+}
+
+func TestDecoderBind(t *testing.T) {
+	d := NewDecoder(parse.NewInputString(`<svg><use xlink:href="#a"/><g xmlns="urn:g"/></svg>`))
+	d.Bind("", "http://www.w3.org/2000/svg")
+	d.Bind("xlink", "http://www.w3.org/1999/xlink")
+	_, svg := d.Next()
+	test.String(t, string(svg.Space), "http://www.w3.org/2000/svg")
+	_, use := d.Next()
+	test.String(t, string(use.Attrs[0].Space), "http://www.w3.org/1999/xlink")
+	d.Next()
+	_, g := d.Next()
+	test.String(t, string(g.Space), "urn:g")
+}
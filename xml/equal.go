@@ -0,0 +1,209 @@
+package xml
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// CompareOptions specifies which differences between trees are considered irrelevant, besides attribute order and namespace prefix names which are always ignored.
+type CompareOptions struct {
+	IgnoreComments     bool // ignore comment nodes
+	IgnoreProcInsts    bool // ignore processing instructions, including the XML declaration
+	IgnoreWhitespace   bool // ignore text that consists of whitespace only
+	KeepXMLDeclaration bool // keep the <?xml ...?> declaration, which is otherwise ignored
+}
+
+// DeepEqual returns true if both trees are equal, ignoring attribute order, namespace prefix names, namespace declarations, the difference between text and CDATA sections, and the way characters are escaped.
+func DeepEqual(a, b *Node) bool {
+	return Compare(a, b, CompareOptions{})
+}
+
+// Compare returns true if both trees are equal after canonicalization with the given options.
+func Compare(a, b *Node, o CompareOptions) bool {
+	return bytes.Equal(Canonical(a, o), Canonical(b, o))
+}
+
+// Canonical returns a canonical serialization of the tree. Element and attribute names are written in Clark notation ({namespace}local), attributes are sorted and namespace declarations are removed, adjacent text and CDATA are merged, and entities are decoded and re-encoded minimally. The result is suitable for comparison and hashing but is not namespace well-formed XML.
+func Canonical(n *Node, o CompareOptions) []byte {
+	w := &bytes.Buffer{}
+	_ = WriteCanonical(w, n, o)
+	return w.Bytes()
+}
+
+// WriteCanonical writes the canonical serialization of the tree to w, see Canonical.
+func WriteCanonical(w io.Writer, n *Node, o CompareOptions) error {
+	c := canonicalizer{o: o}
+	c.node(n)
+	_, err := w.Write(c.buf)
+	return err
+}
+
+type canonicalizer struct {
+	o    CompareOptions
+	buf  []byte
+	text []byte
+}
+
+func (c *canonicalizer) name(space, local []byte) {
+	if 0 < len(space) {
+		c.buf = append(c.buf, '{')
+		c.buf = append(c.buf, space...)
+		c.buf = append(c.buf, '}')
+	}
+	c.buf = append(c.buf, local...)
+}
+
+func (c *canonicalizer) flushText() {
+	if len(c.text) == 0 || c.o.IgnoreWhitespace && parse.IsAllWhitespace(c.text) {
+		c.text = c.text[:0]
+		return
+	}
+	c.buf = appendEscaped(c.buf, c.text, false)
+	c.text = c.text[:0]
+}
+
+func (c *canonicalizer) node(n *Node) {
+	if n == nil {
+		return
+	}
+	switch n.Type {
+	case DocumentNode:
+		c.children(n)
+	case ElementNode:
+		c.buf = append(c.buf, '<')
+		c.name(n.Space, n.Local)
+		attrs := make([]Attr, 0, len(n.Attrs))
+		for _, attr := range n.Attrs {
+			if !bytes.Equal(attr.Space, xmlnsNamespace) {
+				attrs = append(attrs, attr)
+			}
+		}
+		sort.Slice(attrs, func(i, j int) bool {
+			if cmp := bytes.Compare(attrs[i].Space, attrs[j].Space); cmp != 0 {
+				return cmp < 0
+			}
+			return bytes.Compare(attrs[i].Local, attrs[j].Local) < 0
+		})
+		for _, attr := range attrs {
+			c.buf = append(c.buf, ' ')
+			c.name(attr.Space, attr.Local)
+			c.buf = append(c.buf, '=', '"')
+			c.buf = appendEscaped(c.buf, appendUnescaped(nil, attr.Val), true)
+			c.buf = append(c.buf, '"')
+		}
+		c.buf = append(c.buf, '>')
+		c.children(n)
+		c.buf = append(c.buf, '<', '/')
+		c.name(n.Space, n.Local)
+		c.buf = append(c.buf, '>')
+	case TextNode:
+		c.text = appendUnescaped(c.text, n.Data)
+	case CDATANode:
+		c.text = append(c.text, n.Data...)
+	case CommentNode:
+		if !c.o.IgnoreComments {
+			c.buf = append(c.buf, "<!--"...)
+			c.buf = append(c.buf, n.Data...)
+			c.buf = append(c.buf, "-->"...)
+		}
+	case ProcInstNode:
+		if !c.o.IgnoreProcInsts && (c.o.KeepXMLDeclaration || !bytes.Equal(n.Name, xmlPrefixBytes)) {
+			c.buf = append(c.buf, '<', '?')
+			c.buf = append(c.buf, n.Name...)
+			for _, attr := range n.Attrs {
+				c.buf = append(c.buf, ' ')
+				c.buf = append(c.buf, attr.Name...)
+				c.buf = append(c.buf, '=', '"')
+				c.buf = appendEscaped(c.buf, appendUnescaped(nil, attr.Val), true)
+				c.buf = append(c.buf, '"')
+			}
+			c.buf = append(c.buf, '?', '>')
+		}
+	}
+}
+
+func (c *canonicalizer) children(n *Node) {
+	for _, child := range n.Children {
+		if child.Type != TextNode && child.Type != CDATANode {
+			if child.Type == CommentNode && c.o.IgnoreComments || child.Type == ProcInstNode && c.o.IgnoreProcInsts || child.Type == DOCTYPENode {
+				continue // don't break up text
+			}
+			c.flushText()
+		}
+		c.node(child)
+	}
+	c.flushText()
+}
+
+////////////////////////////////////////////////////////////////
+
+// appendUnescaped appends b to dst while decoding character references and the predefined entities. Unknown entities are kept as is.
+func appendUnescaped(dst, b []byte) []byte {
+	for {
+		i := bytes.IndexByte(b, '&')
+		if i == -1 {
+			break
+		}
+		dst = append(dst, b[:i]...)
+		b = b[i:]
+		j := bytes.IndexByte(b, ';')
+		if j == -1 {
+			break
+		}
+		if r, ok := decodeEntity(b[1 : j+1]); ok {
+			dst = utf8.AppendRune(dst, r)
+			b = b[j+1:]
+		} else {
+			dst = append(dst, '&')
+			b = b[1:]
+		}
+	}
+	return append(dst, b...)
+}
+
+// decodeEntity decodes the entity or character reference between & and ;, including the semicolon.
+func decodeEntity(ref []byte) (rune, bool) {
+	if value, ok := parse.XMLEntities.Get(ref); ok {
+		r, _ := utf8.DecodeRuneInString(value)
+		return r, true
+	} else if 2 < len(ref) && ref[0] == '#' {
+		base, digits := 10, ref[1:len(ref)-1]
+		if digits[0] == 'x' {
+			base, digits = 16, digits[1:]
+		}
+		if c, err := strconv.ParseUint(string(digits), base, 32); err == nil && utf8.ValidRune(rune(c)) {
+			return rune(c), true
+		}
+	}
+	return 0, false
+}
+
+// appendEscaped appends b to dst while escaping &, <, > and for attribute values also ", \t, \n, and \r.
+func appendEscaped(dst, b []byte, attr bool) []byte {
+	for _, c := range b {
+		switch {
+		case c == '&':
+			dst = append(dst, "&amp;"...)
+		case c == '<':
+			dst = append(dst, "&lt;"...)
+		case c == '>':
+			dst = append(dst, "&gt;"...)
+		case attr && c == '"':
+			dst = append(dst, "&quot;"...)
+		case attr && c == '\t':
+			dst = append(dst, "&#x9;"...)
+		case attr && c == '\n':
+			dst = append(dst, "&#xA;"...)
+		case c == '\r':
+			dst = append(dst, "&#xD;"...)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,52 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func mustParse(t *testing.T, s string) *Node {
+	doc, err := Parse(parse.NewInputString(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestDeepEqual(t *testing.T) {
+	var equalTests = []struct {
+		a, b  string
+		equal bool
+	}{
+		{`<a x="1" y="2"/>`, `<a y="2" x="1"></a>`, true},
+		{`<p:a xmlns:p="urn:x" p:b="1"/>`, `<q:a xmlns:q="urn:x" q:b="1"/>`, true},
+		{`<a xmlns="urn:x"/>`, `<p:a xmlns:p="urn:x"/>`, true},
+		{`<a>x &amp; y</a>`, `<a><![CDATA[x & y]]></a>`, true},
+		{`<a>&#65;&#x42;</a>`, `<a>AB</a>`, true},
+		{`<a x='"'/>`, `<a x="&quot;"/>`, true},
+		{`<?xml version="1.0"?><a/>`, `<a/>`, true},
+		{`<a x="1"/>`, `<a x="2"/>`, false},
+		{`<p:a xmlns:p="urn:x"/>`, `<p:a xmlns:p="urn:y"/>`, false},
+		{`<a><b/></a>`, `<a> <b/> </a>`, false},
+		{`<a><!--x--></a>`, `<a></a>`, false},
+	}
+	for _, tt := range equalTests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			test.T(t, DeepEqual(mustParse(t, tt.a), mustParse(t, tt.b)), tt.equal)
+		})
+	}
+}
+
+func TestCompareOptions(t *testing.T) {
+	o := CompareOptions{IgnoreComments: true, IgnoreWhitespace: true}
+	test.That(t, Compare(mustParse(t, "<a>\n\t<b/><!--x-->\n</a>"), mustParse(t, "<a><b/></a>"), o))
+	test.That(t, Compare(mustParse(t, "<a>x<!--y-->z</a>"), mustParse(t, "<a>xz</a>"), o))
+	test.That(t, !Compare(mustParse(t, "<?xml version='1.0'?><a/>"), mustParse(t, "<a/>"), CompareOptions{KeepXMLDeclaration: true}))
+}
+
+func TestCanonical(t *testing.T) {
+	doc := mustParse(t, `<?xml version="1.0"?><p:a xmlns:p="urn:x" xmlns="urn:y" z="&lt;" p:b="1"><c>a&gt;b<![CDATA[&]]></c></p:a>`)
+	test.String(t, string(Canonical(doc, CompareOptions{})), `<{urn:x}a z="&lt;" {urn:x}b="1"><{urn:y}c>a&gt;b&amp;</{urn:y}c></{urn:x}a>`)
+}
@@ -0,0 +1,400 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// XIncludeNamespace is the namespace URI of XInclude elements.
+const XIncludeNamespace = "http://www.w3.org/2001/XInclude"
+
+// Default limits of ResolveOptions.
+const (
+	DefaultMaxResolveDepth = 8
+	DefaultMaxResolveSize  = 1 << 20
+)
+
+// Errors returned by Resolve. Exceeding a limit or an inclusion loop is fatal, while other errors of an XInclude element fall back to its xi:fallback element when present.
+var (
+	ErrNoResolver      = errors.New("no resolver for external resources")
+	ErrResolveDepth    = errors.New("maximum depth of external resources exceeded")
+	ErrResolveSize     = errors.New("maximum size of external resources exceeded")
+	ErrResolveLoop     = errors.New("external resource includes itself")
+	ErrForbiddenURI    = errors.New("URI not allowed by resolver")
+	ErrXPointer        = errors.New("XInclude xpointer attribute is not supported")
+	ErrXIncludeHref    = errors.New("XInclude element without href attribute")
+	ErrXIncludeParse   = errors.New("XInclude parse attribute must be xml or text")
+	ErrXIncludeNotUTF8 = errors.New("XInclude text resource is not valid UTF-8")
+)
+
+// Resolver opens external resources, such as the targets of XInclude elements and external entities. The URI is relative to the base URI, which is the URI of the resource that references it. Resolve returns the URI of the opened resource, which is used as the base URI of its own references and to detect inclusion loops.
+type Resolver interface {
+	Resolve(base, uri string) (string, io.ReadCloser, error)
+}
+
+// ResolverFunc is a function that implements Resolver.
+type ResolverFunc func(base, uri string) (string, io.ReadCloser, error)
+
+// Resolve calls f(base, uri).
+func (f ResolverFunc) Resolve(base, uri string) (string, io.ReadCloser, error) {
+	return f(base, uri)
+}
+
+// DirResolver returns a Resolver that only opens files within dir. URIs are slash-separated paths relative to the base URI, and URIs with a scheme, query, or fragment, absolute paths, and paths that lead outside of dir return ErrForbiddenURI. Symbolic links within dir are followed.
+func DirResolver(dir string) Resolver {
+	return ResolverFunc(func(base, uri string) (string, io.ReadCloser, error) {
+		u, err := url.Parse(uri)
+		if err != nil || u.Scheme != "" || u.Opaque != "" || u.Host != "" || u.RawQuery != "" || u.Fragment != "" || path.IsAbs(u.Path) || strings.ContainsRune(u.Path, '\\') {
+			return "", nil, ErrForbiddenURI
+		}
+		p := path.Join(path.Dir(base), u.Path)
+		if p == ".." || strings.HasPrefix(p, "../") {
+			return "", nil, ErrForbiddenURI
+		}
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			return "", nil, err
+		}
+		return p, f, nil
+	})
+}
+
+// ResolveOptions enables the resolution of external resources. The zero value resolves nothing, so that documents from untrusted sources cannot read local files or make requests (XXE).
+type ResolveOptions struct {
+	Resolver Resolver
+	BaseURI  string // URI of the document
+
+	XInclude         bool // replace xi:include elements by their resource
+	ExternalEntities bool // replace references to external entities declared in the internal DTD subset by their resource
+
+	MaxDepth int // maximum nesting of external resources, DefaultMaxResolveDepth when zero
+	MaxSize  int // maximum total size in bytes of external resources, DefaultMaxResolveSize when zero
+}
+
+// Resolve replaces XInclude elements and references to external entities in the tree by the contents of their resource, as enabled by the options. XInclude elements with parse="xml" are replaced by the nodes of the included document except for its XML declaration and DOCTYPE, and those with parse="text" by a text node. External entities are replaced by the nodes of their content, whose element names are resolved in the namespace scope of the reference. Internal entities, external parameter entities, and the external DTD subset are never resolved. The included nodes have their byte offsets in the resource they were parsed from.
+func Resolve(doc *Node, o ResolveOptions) error {
+	if !o.XInclude && !o.ExternalEntities {
+		return nil
+	} else if o.Resolver == nil {
+		return ErrNoResolver
+	}
+	if o.MaxDepth == 0 {
+		o.MaxDepth = DefaultMaxResolveDepth
+	}
+	if o.MaxSize == 0 {
+		o.MaxSize = DefaultMaxResolveSize
+	}
+	r := &resolver{ResolveOptions: o}
+	if o.ExternalEntities {
+		r.entities = externalEntities(doc)
+	}
+	return r.children(doc, o.BaseURI)
+}
+
+type resolver struct {
+	ResolveOptions
+	entities map[string]string // system identifiers of declared entities, empty for internal entities
+	uris     []string          // URIs of the resources being resolved, the innermost last
+	size     int
+}
+
+// children resolves the children of a node.
+func (r *resolver) children(n *Node, base string) error {
+	children := make([]*Node, 0, len(n.Children))
+	for _, child := range n.Children {
+		nodes := []*Node{child}
+		var err error
+		if r.XInclude && child.Type == ElementNode && isXInclude(child, "include") {
+			nodes, err = r.include(child, base)
+		} else if r.entities != nil && child.Type == TextNode {
+			nodes, err = r.expand(child, base)
+		} else {
+			err = r.children(child, base)
+		}
+		if err != nil {
+			return err
+		}
+		for _, m := range nodes {
+			m.Parent = n
+		}
+		children = append(children, nodes...)
+	}
+	n.Children = children
+	return nil
+}
+
+// include returns the nodes that replace an XInclude element.
+func (r *resolver) include(n *Node, base string) ([]*Node, error) {
+	nodes, err := r.includeResource(n, base)
+	if err != nil && err != ErrResolveDepth && err != ErrResolveSize && err != ErrResolveLoop {
+		for _, child := range n.Children {
+			if child.Type == ElementNode && isXInclude(child, "fallback") {
+				if err := r.children(child, base); err != nil {
+					return nil, err
+				}
+				return child.Children, nil
+			}
+		}
+	}
+	return nodes, err
+}
+
+func (r *resolver) includeResource(n *Node, base string) ([]*Node, error) {
+	href, _ := n.AttrVal("", "href")
+	typ, _ := n.AttrVal("", "parse")
+	if _, ok := n.AttrVal("", "xpointer"); ok {
+		return nil, ErrXPointer
+	} else if len(href) == 0 {
+		return nil, ErrXIncludeHref
+	} else if len(typ) != 0 && string(typ) != "xml" && string(typ) != "text" {
+		return nil, ErrXIncludeParse
+	}
+
+	uri, b, err := r.open(base, string(appendUnescaped(nil, href)))
+	if err != nil {
+		return nil, err
+	}
+	if string(typ) == "text" {
+		if !utf8.Valid(b) {
+			return nil, ErrXIncludeNotUTF8
+		}
+		return []*Node{{Type: TextNode, Data: appendEscaped(nil, b, false), End: len(b)}}, nil
+	}
+
+	doc, err := Parse(parse.NewInputBytes(b))
+	if err != nil {
+		return nil, err
+	}
+	entities := r.entities
+	if r.ExternalEntities {
+		r.entities = externalEntities(doc) // entities are declared per document
+	}
+	r.uris = append(r.uris, uri)
+	err = r.children(doc, uri)
+	r.uris = r.uris[:len(r.uris)-1]
+	r.entities = entities
+	if err != nil {
+		return nil, err
+	}
+	return contentNodes(doc), nil
+}
+
+// expand returns the nodes that replace a text node, which are the text node itself when it has no references to external entities.
+func (r *resolver) expand(n *Node, base string) ([]*Node, error) {
+	nodes := []*Node{}
+	data := n.Data
+	start := 0 // start of the text that is not yet added
+	for i := 0; i < len(data); {
+		j := bytes.IndexByte(data[i:], '&')
+		if j == -1 {
+			break
+		}
+		j += i
+		k := bytes.IndexByte(data[j:], ';')
+		if k == -1 {
+			break
+		}
+		k += j
+		systemID := r.entities[string(data[j+1:k])]
+		if systemID == "" {
+			i = j + 1
+			continue
+		}
+
+		uri, b, err := r.open(base, systemID)
+		if err != nil {
+			return nil, err
+		}
+		content, err := Parse(parse.NewInputBytes(b))
+		if err != nil {
+			return nil, err
+		}
+		r.uris = append(r.uris, uri)
+		err = r.children(content, uri)
+		r.uris = r.uris[:len(r.uris)-1]
+		if err != nil {
+			return nil, err
+		}
+
+		if start < j {
+			nodes = append(nodes, &Node{Type: TextNode, Data: data[start:j], Start: n.Start + start, End: n.Start + j})
+		}
+		for _, m := range contentNodes(content) {
+			m.Parent = n.Parent
+			resolveNamespaces(m)
+			nodes = append(nodes, m)
+		}
+		i, start = k+1, k+1
+	}
+	if start == 0 {
+		return []*Node{n}, nil
+	} else if start < len(data) {
+		nodes = append(nodes, &Node{Type: TextNode, Data: data[start:], Start: n.Start + start, End: n.End})
+	}
+	return nodes, nil
+}
+
+// open reads an external resource while enforcing the limits.
+func (r *resolver) open(base, uri string) (string, []byte, error) {
+	if r.MaxDepth <= len(r.uris) {
+		return "", nil, ErrResolveDepth
+	}
+	uri, rc, err := r.Resolver.Resolve(base, uri)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rc.Close()
+	if uri == r.BaseURI && uri != "" {
+		return "", nil, ErrResolveLoop
+	}
+	for _, parent := range r.uris {
+		if uri == parent {
+			return "", nil, ErrResolveLoop
+		}
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(rc, int64(r.MaxSize-r.size+1)))
+	if err != nil {
+		return "", nil, err
+	}
+	r.size += len(b)
+	if r.MaxSize < r.size {
+		return "", nil, ErrResolveSize
+	}
+	return uri, b, nil
+}
+
+// contentNodes returns the children of a parsed resource without its XML or text declaration and DOCTYPE.
+func contentNodes(doc *Node) []*Node {
+	nodes := make([]*Node, 0, len(doc.Children))
+	for _, child := range doc.Children {
+		if child.Type == DOCTYPENode || child.Type == ProcInstNode && string(child.Name) == "xml" {
+			continue
+		}
+		nodes = append(nodes, child)
+	}
+	return nodes
+}
+
+// resolveNamespaces resolves the namespaces of an element and its descendants again after it has been moved into another tree.
+func resolveNamespaces(n *Node) {
+	n.Walk(func(m *Node) bool {
+		if m.Type == ElementNode {
+			prefix, _ := splitName(m.Name)
+			m.Space, _ = m.LookupNamespace(string(prefix))
+			for i, attr := range m.Attrs {
+				if prefix, _ := splitName(attr.Name); prefix != nil {
+					m.Attrs[i].Space, _ = m.LookupNamespace(string(prefix))
+				}
+			}
+		}
+		return true
+	})
+}
+
+func isXInclude(n *Node, local string) bool {
+	return string(n.Space) == XIncludeNamespace && string(n.Local) == local
+}
+
+// externalEntities returns the general entities declared in the internal DTD subset, with the system identifier of external parsed entities and an empty string for internal and unparsed entities.
+func externalEntities(doc *Node) map[string]string {
+	entities := map[string]string{}
+	for _, child := range doc.Children {
+		if child.Type == DOCTYPENode {
+			entityDecls(child.Data, func(name string, value []byte, systemID string) {
+				if _, ok := entities[name]; !ok {
+					entities[name] = systemID // the first declaration is binding
+				}
+			})
+		}
+	}
+	return entities
+}
+
+// entityDecls calls f for every general entity declared in the internal subset of the DOCTYPE text, with the replacement text of internal entities without quotes, or the system identifier of external parsed entities.
+func entityDecls(doctype []byte, f func(name string, value []byte, systemID string)) {
+	b := doctype
+	if i := bytes.IndexByte(b, '['); i != -1 {
+		b = b[i+1:]
+	} else {
+		b = nil
+	}
+	for 0 < len(b) {
+		if bytes.HasPrefix(b, []byte("<!--")) {
+			if i := bytes.Index(b[4:], []byte("-->")); i != -1 {
+				b = b[4+i+3:]
+				continue
+			}
+			break
+		} else if b[0] == '"' || b[0] == '\'' {
+			if i := bytes.IndexByte(b[1:], b[0]); i != -1 {
+				b = b[1+i+1:]
+				continue
+			}
+			break
+		} else if bytes.HasPrefix(b, []byte("<!ENTITY")) {
+			b = b[8:]
+			if name, value, systemID, ok := entityDecl(&b); ok {
+				f(name, value, systemID)
+			}
+			continue
+		}
+		b = b[1:]
+	}
+}
+
+// entityDecl parses a general entity declaration after <!ENTITY and advances b past the declaration. It returns the replacement text of internal entities, and the system identifier of external parsed entities.
+func entityDecl(b *[]byte) (string, []byte, string, bool) {
+	fields := [][]byte{}
+	for {
+		*b = bytes.TrimLeft(*b, " \t\r\n")
+		if len(*b) == 0 {
+			return "", nil, "", false
+		} else if (*b)[0] == '>' {
+			*b = (*b)[1:]
+			break
+		} else if (*b)[0] == '"' || (*b)[0] == '\'' {
+			i := bytes.IndexByte((*b)[1:], (*b)[0])
+			if i == -1 {
+				return "", nil, "", false
+			}
+			fields = append(fields, (*b)[:1+i+1])
+			*b = (*b)[1+i+1:]
+		} else {
+			i := bytes.IndexAny(*b, " \t\r\n>\"'")
+			if i == -1 {
+				i = len(*b)
+			}
+			fields = append(fields, (*b)[:i])
+			*b = (*b)[i:]
+		}
+	}
+
+	if len(fields) < 2 || string(fields[0]) == "%" {
+		return "", nil, "", false // parameter entity
+	}
+	name, systemID := string(fields[0]), ""
+	if string(fields[1]) == "SYSTEM" && 3 <= len(fields) {
+		systemID = string(unquote(fields[2]))
+		fields = fields[3:]
+	} else if string(fields[1]) == "PUBLIC" && 4 <= len(fields) {
+		systemID = string(unquote(fields[3]))
+		fields = fields[4:]
+	} else {
+		return name, unquote(fields[1]), "", true // internal entity
+	}
+	if 0 < len(fields) && string(fields[0]) == "NDATA" {
+		return name, nil, "", true // unparsed entity
+	}
+	return name, nil, systemID, true
+}
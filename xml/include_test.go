@@ -0,0 +1,148 @@
+package xml
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func mapResolver(files map[string]string) Resolver {
+	return ResolverFunc(func(base, uri string) (string, io.ReadCloser, error) {
+		s, ok := files[uri]
+		if !ok {
+			return "", nil, os.ErrNotExist
+		}
+		return uri, ioutil.NopCloser(strings.NewReader(s)), nil
+	})
+}
+
+func TestResolve(t *testing.T) {
+	files := map[string]string{
+		"a.xml":    `<?xml version="1.0"?><!DOCTYPE a><a>x<xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="b.txt" parse="text"/></a>`,
+		"b.txt":    `1 < 2 & 3`,
+		"loop.xml": `<l><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="loop.xml"/></l>`,
+		"self.xml": `<s/>`,
+		"ent.xml":  `<?xml encoding="UTF-8"?><y:e/>text`,
+		"bad.xml":  `<b>`,
+	}
+	var tests = []struct {
+		xml      string
+		o        ResolveOptions
+		expected string
+		err      error
+	}{
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml"/></r>`, ResolveOptions{}, `<r><{http://www.w3.org/2001/XInclude}include href="a.xml"></{http://www.w3.org/2001/XInclude}include></r>`, nil},
+		{`<r/>`, ResolveOptions{XInclude: true}, ``, ErrNoResolver},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml"/></r>`, ResolveOptions{XInclude: true}, `<r><a>x1 &lt; 2 &amp; 3</a></r>`, nil},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="b.txt" parse="text"/></r>`, ResolveOptions{XInclude: true}, `<r>1 &lt; 2 &amp; 3</r>`, nil},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="c.xml"><xi:fallback>no <f/></xi:fallback></xi:include></r>`, ResolveOptions{XInclude: true}, `<r>no <f></f></r>`, nil},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="bad.xml"><xi:fallback/></xi:include></r>`, ResolveOptions{XInclude: true}, `<r></r>`, nil},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml" xpointer="x"><xi:fallback/></xi:include></r>`, ResolveOptions{XInclude: true}, `<r></r>`, nil},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="c.xml"/></r>`, ResolveOptions{XInclude: true}, ``, os.ErrNotExist},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml" parse="html"/></r>`, ResolveOptions{XInclude: true}, ``, ErrXIncludeParse},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude"/></r>`, ResolveOptions{XInclude: true}, ``, ErrXIncludeHref},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="loop.xml"><xi:fallback/></xi:include></r>`, ResolveOptions{XInclude: true}, ``, ErrResolveLoop},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="self.xml"/></r>`, ResolveOptions{XInclude: true, BaseURI: "self.xml"}, ``, ErrResolveLoop},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml"/></r>`, ResolveOptions{XInclude: true, MaxDepth: 1}, ``, ErrResolveDepth},
+		{`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml"/></r>`, ResolveOptions{XInclude: true, MaxSize: 100}, ``, ErrResolveSize},
+
+		// external entities
+		{`<!DOCTYPE r [<!ENTITY e SYSTEM "ent.xml">]><r xmlns:y="urn:y">a&e;b&amp;</r>`, ResolveOptions{}, `<r>a&amp;e;b&amp;</r>`, nil},
+		{`<!DOCTYPE r [<!ENTITY e SYSTEM "ent.xml">]><r xmlns:y="urn:y">a&e;b&amp;</r>`, ResolveOptions{XInclude: true}, `<r>a&amp;e;b&amp;</r>`, nil},
+		{`<!DOCTYPE r [<!ENTITY e SYSTEM "ent.xml">]><r xmlns:y="urn:y">a&e;b&amp;</r>`, ResolveOptions{ExternalEntities: true}, `<r>a<{urn:y}e></{urn:y}e>textb&amp;</r>`, nil},
+		{`<!DOCTYPE r [<!ENTITY e PUBLIC "-//x" 'ent.xml'>]><r xmlns:y="urn:y">&e;&e;</r>`, ResolveOptions{ExternalEntities: true}, `<r><{urn:y}e></{urn:y}e>text<{urn:y}e></{urn:y}e>text</r>`, nil},
+		{`<!DOCTYPE r [<!ENTITY e "internal"><!ENTITY e SYSTEM "ent.xml">]><r>&e;</r>`, ResolveOptions{ExternalEntities: true}, `<r>&amp;e;</r>`, nil},
+		{`<!DOCTYPE r [<!-- <!ENTITY e SYSTEM "ent.xml"> --><!ENTITY % e SYSTEM "ent.xml"><!ENTITY n SYSTEM "ent.xml" NDATA gif>]><r>&e;&n;</r>`, ResolveOptions{ExternalEntities: true}, `<r>&amp;e;&amp;n;</r>`, nil},
+		{`<!DOCTYPE r SYSTEM "ent.xml"><r>&e;</r>`, ResolveOptions{ExternalEntities: true}, `<r>&amp;e;</r>`, nil},
+		{`<!DOCTYPE r [<!ENTITY e SYSTEM "bad.xml">]><r>&e;</r>`, ResolveOptions{ExternalEntities: true}, ``, nil},
+		{`<!DOCTYPE r [<!ENTITY e SYSTEM "c.xml">]><r>&e;</r>`, ResolveOptions{ExternalEntities: true}, ``, os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.xml, func(t *testing.T) {
+			doc, err := Parse(parse.NewInputString(tt.xml))
+			test.Error(t, err)
+			if tt.o.XInclude || tt.o.ExternalEntities {
+				if tt.err != ErrNoResolver {
+					tt.o.Resolver = mapResolver(files)
+				}
+			}
+			err = Resolve(doc, tt.o)
+			if tt.expected == "" {
+				test.That(t, err != nil, "must return error")
+				if tt.err != nil {
+					test.T(t, err, tt.err)
+				}
+				return
+			}
+			test.Error(t, err)
+			test.String(t, string(Canonical(doc, CompareOptions{IgnoreComments: true})), tt.expected)
+		})
+	}
+}
+
+func TestResolveParents(t *testing.T) {
+	files := map[string]string{
+		"a.xml": `<a><b/></a>`,
+		"e.xml": `<c/>`,
+	}
+	doc, err := Parse(parse.NewInputString(`<!DOCTYPE r [<!ENTITY e SYSTEM "e.xml">]><r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="a.xml"/>&e;</r>`))
+	test.Error(t, err)
+	test.Error(t, Resolve(doc, ResolveOptions{Resolver: mapResolver(files), XInclude: true, ExternalEntities: true}))
+	r := doc.Children[1]
+	test.T(t, len(r.Children), 2)
+	test.T(t, r.Children[0].Parent, r)
+	test.T(t, r.Children[0].Children[0].Parent, r.Children[0])
+	test.T(t, r.Children[1].Parent, r)
+	test.String(t, string(r.Children[1].Name), "c")
+}
+
+func TestDirResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xml")
+	test.Error(t, err)
+	defer os.RemoveAll(dir)
+	test.Error(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	test.Error(t, ioutil.WriteFile(filepath.Join(dir, "sub", "a.xml"), []byte(`<a><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="../b.xml"/></a>`), 0644))
+	test.Error(t, ioutil.WriteFile(filepath.Join(dir, "b.xml"), []byte(`<b/>`), 0644))
+
+	r := DirResolver(dir)
+	var tests = []struct {
+		base, uri string
+		expected  string
+		err       error
+	}{
+		{"", "b.xml", "b.xml", nil},
+		{"doc.xml", "sub/a.xml", "sub/a.xml", nil},
+		{"sub/a.xml", "../b.xml", "b.xml", nil},
+		{"sub/a.xml", "./../sub/a.xml", "sub/a.xml", nil},
+		{"", "../b.xml", "", ErrForbiddenURI},
+		{"sub/a.xml", "../../b.xml", "", ErrForbiddenURI},
+		{"", "/etc/passwd", "", ErrForbiddenURI},
+		{"", "file:///etc/passwd", "", ErrForbiddenURI},
+		{"", "http://example.com/b.xml", "", ErrForbiddenURI},
+		{"", "//example.com/b.xml", "", ErrForbiddenURI},
+		{"", "b.xml?x", "", ErrForbiddenURI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			uri, rc, err := r.Resolve(tt.base, tt.uri)
+			if tt.err != nil {
+				test.T(t, err, tt.err)
+				return
+			}
+			test.Error(t, err)
+			rc.Close()
+			test.String(t, uri, tt.expected)
+		})
+	}
+
+	doc, err := Parse(parse.NewInputString(`<r><xi:include xmlns:xi="http://www.w3.org/2001/XInclude" href="sub/a.xml"/></r>`))
+	test.Error(t, err)
+	test.Error(t, Resolve(doc, ResolveOptions{Resolver: r, XInclude: true}))
+	test.String(t, string(Canonical(doc, CompareOptions{})), `<r><a><b></b></a></r>`)
+}
@@ -2,6 +2,7 @@
 package xml
 
 import (
+	"bytes"
 	"strconv"
 
 	"github.com/politepixels/tdewolff-parse/v2"
@@ -64,7 +65,9 @@ type Lexer struct {
 	r   *parse.Input
 	err error
 
-	inTag bool
+	inTag   bool
+	xmlDecl bool // reading the attributes of the XML declaration
+	edition Edition
 
 	text    []byte
 	attrVal []byte
@@ -95,6 +98,11 @@ func (l *Lexer) AttrVal() []byte {
 	return l.attrVal
 }
 
+// Edition returns the edition of XML declared by the version of the XML declaration, which is XML10 until a version of 1.1 has been read.
+func (l *Lexer) Edition() Edition {
+	return l.edition
+}
+
 // Next returns the next Token. It returns ErrorToken when an error was encountered. Using Err() one can retrieve the error message.
 func (l *Lexer) Next() (TokenType, []byte) {
 	l.text = nil
@@ -114,10 +122,14 @@ func (l *Lexer) Next() (TokenType, []byte) {
 			}
 			return ErrorToken, nil
 		} else if c != '>' && (c != '/' && c != '?' || l.r.Peek(1) != '>') {
-			return AttributeToken, l.shiftAttribute()
+			data := l.shiftAttribute()
+			if l.xmlDecl && bytes.Equal(l.text, []byte("version")) {
+				l.edition = editionOf(l.attrVal)
+			}
+			return AttributeToken, data
 		}
 		l.r.Skip()
-		l.inTag = false
+		l.inTag, l.xmlDecl = false, false
 		if c == '/' {
 			l.r.Move(2)
 			return StartTagCloseVoidToken, l.r.Shift()
@@ -157,7 +169,9 @@ func (l *Lexer) Next() (TokenType, []byte) {
 			} else if c == '?' {
 				l.r.Move(2)
 				l.inTag = true
-				return StartTagPIToken, l.shiftStartTag()
+				data := l.shiftStartTag()
+				l.xmlDecl = bytes.Equal(l.text, []byte("xml"))
+				return StartTagPIToken, data
 			}
 			l.r.Move(1)
 			l.inTag = true
@@ -190,12 +204,14 @@ func (l *Lexer) shiftDOCTYPEText() []byte {
 		} else if (c == '[' || c == ']') && !inString {
 			inBrackets = (c == '[')
 		} else if c == '>' && !inString && !inBrackets {
-			l.text = l.r.Lexeme()[9:]
 			l.r.Move(1)
-			return l.r.Shift()
+			data := l.r.Shift()
+			l.text = data[9 : len(data)-1]
+			return data
 		} else if c == 0 {
-			l.text = l.r.Lexeme()[9:]
-			return l.r.Shift()
+			data := l.r.Shift()
+			l.text = data[9:]
+			return data
 		}
 		l.r.Move(1)
 	}
@@ -205,12 +221,14 @@ func (l *Lexer) shiftCDATAText() []byte {
 	for {
 		c := l.r.Peek(0)
 		if c == ']' && l.r.Peek(1) == ']' && l.r.Peek(2) == '>' {
-			l.text = l.r.Lexeme()[9:]
 			l.r.Move(3)
-			return l.r.Shift()
+			data := l.r.Shift()
+			l.text = data[9 : len(data)-3]
+			return data
 		} else if c == 0 {
-			l.text = l.r.Lexeme()[9:]
-			return l.r.Shift()
+			data := l.r.Shift()
+			l.text = data[9:]
+			return data
 		}
 		l.r.Move(1)
 	}
@@ -220,9 +238,10 @@ func (l *Lexer) shiftCommentText() []byte {
 	for {
 		c := l.r.Peek(0)
 		if c == '-' && l.r.Peek(1) == '-' && l.r.Peek(2) == '>' {
-			l.text = l.r.Lexeme()[4:]
 			l.r.Move(3)
-			return l.r.Shift()
+			data := l.r.Shift()
+			l.text = data[4 : len(data)-3]
+			return data
 		} else if c == 0 {
 			return l.r.Shift()
 		}
@@ -238,8 +257,9 @@ func (l *Lexer) shiftStartTag() []byte {
 		}
 		l.r.Move(1)
 	}
-	l.text = l.r.Lexeme()[nameStart:]
-	return l.r.Shift()
+	data := l.r.Shift()
+	l.text = data[nameStart:]
+	return data
 }
 
 func (l *Lexer) shiftAttribute() []byte {
@@ -293,39 +313,40 @@ func (l *Lexer) shiftAttribute() []byte {
 				l.r.Move(1)
 			}
 		}
-		l.attrVal = l.r.Lexeme()[attrPos:]
-	} else {
-		l.r.Rewind(nameEnd)
-		l.attrVal = nil
+		data := l.r.Shift()
+		l.text = data[nameStart:nameEnd]
+		l.attrVal = data[attrPos:]
+		return data
 	}
-	l.text = l.r.Lexeme()[nameStart:nameEnd]
-	return l.r.Shift()
+	l.r.Rewind(nameEnd)
+	l.attrVal = nil
+	data := l.r.Shift()
+	l.text = data[nameStart:nameEnd]
+	return data
 }
 
 func (l *Lexer) shiftEndTag() []byte {
 	for {
-		c := l.r.Peek(0)
-		if c == '>' {
-			l.text = l.r.Lexeme()[2:]
-			l.r.Move(1)
-			break
-		} else if c == 0 {
-			l.text = l.r.Lexeme()[2:]
+		if c := l.r.Peek(0); c == '>' || c == 0 {
 			break
 		}
 		l.r.Move(1)
 	}
 
-	end := len(l.text)
-	for end > 0 {
-		if c := l.text[end-1]; c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+	end := l.r.Pos()
+	for 2 < end {
+		if c := l.r.Lexeme()[end-1]; c == ' ' || c == '\t' || c == '\n' || c == '\r' {
 			end--
 			continue
 		}
 		break
 	}
-	l.text = l.text[:end]
-	return l.r.Shift()
+	if l.r.Peek(0) == '>' {
+		l.r.Move(1)
+	}
+	data := l.r.Shift()
+	l.text = data[2:end]
+	return data
 }
 
 ////////////////////////////////////////////////////////////////
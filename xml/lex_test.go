@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/politepixels/tdewolff-parse/v2/buffer"
 	"github.com/tdewolff/test"
 )
 
@@ -231,6 +232,48 @@ func TestTextAndAttrVal(t *testing.T) {
 	test.Bytes(t, l.AttrVal(), nil)
 }
 
+func TestLexerArena(t *testing.T) {
+	b := []byte("<a b=\"x\ny\">text</a >")
+	z := parse.NewInputBytes(b)
+	z.SetArena(buffer.NewArena(0))
+	l := NewLexer(z)
+
+	var data, text, attrVal [][]byte
+	for {
+		tt, d := l.Next()
+		if tt == ErrorToken {
+			break
+		}
+		data = append(data, d)
+		text = append(text, l.Text())
+		attrVal = append(attrVal, l.AttrVal())
+	}
+	z.Restore()
+	for i := range b {
+		b[i] = '-'
+	}
+
+	test.T(t, len(data), 5)
+	test.Bytes(t, data[1], []byte(" b=\"x y\""), "whitespace in attribute values must be normalized")
+	test.Bytes(t, text[1], []byte("b"))
+	test.Bytes(t, attrVal[1], []byte("\"x y\""))
+	test.Bytes(t, text[0], []byte("a"))
+	test.Bytes(t, text[3], []byte("text"))
+	test.Bytes(t, data[4], []byte("</a >"))
+	test.Bytes(t, text[4], []byte("a"))
+}
+
+func TestLexerEdition(t *testing.T) {
+	l := NewLexer(parse.NewInputString(`<?xml version="1.1"?><a version="1.0"/>`))
+	test.T(t, l.Edition(), XML10)
+	for {
+		if tt, _ := l.Next(); tt == ErrorToken {
+			break
+		}
+	}
+	test.T(t, l.Edition(), XML11)
+}
+
 func TestOffset(t *testing.T) {
 	z := parse.NewInputString(`<div attr="val">text</div>`)
 	l := NewLexer(z)
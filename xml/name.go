@@ -0,0 +1,106 @@
+package xml
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Edition is the edition of the XML specification whose character tables are used to validate characters and names.
+type Edition int
+
+// Edition values.
+const (
+	XML10 Edition = iota // XML 1.0 (fifth edition), which is the default for documents without version 1.1 in the XML declaration
+	XML11                // XML 1.1 (second edition)
+)
+
+// String returns the version number of an Edition, such as 1.0.
+func (e Edition) String() string {
+	if e == XML11 {
+		return "1.1"
+	}
+	return "1.0"
+}
+
+// documentEdition returns the edition declared by the XML declaration of the document that contains n.
+func documentEdition(n *Node) Edition {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	if n.Type == DocumentNode {
+		for _, child := range n.Children {
+			if child.Type == ProcInstNode && bytes.Equal(child.Name, xmlPrefixBytes) {
+				for _, attr := range child.Attrs {
+					if bytes.Equal(attr.Name, []byte("version")) {
+						return editionOf(attr.Val)
+					}
+				}
+			}
+		}
+	}
+	return XML10
+}
+
+// editionOf returns the edition for the version pseudo-attribute value of an XML declaration, with or without quotes.
+func editionOf(version []byte) Edition {
+	if 2 <= len(version) && (version[0] == '"' || version[0] == '\'') && version[len(version)-1] == version[0] {
+		version = version[1 : len(version)-1]
+	}
+	if bytes.Equal(version, []byte("1.1")) {
+		return XML11
+	}
+	return XML10
+}
+
+// IsChar returns true if r matches the Char production of the edition. XML 1.1 allows the control characters U+0001 to U+001F, but those and the C1 controls except U+0085 are restricted characters that may only appear as character references, see IsRestrictedChar.
+func IsChar(r rune, edition Edition) bool {
+	if edition == XML11 {
+		return 0x1 <= r && r <= 0xD7FF || 0xE000 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0x10FFFF
+	}
+	return r == 0x9 || r == 0xA || r == 0xD || 0x20 <= r && r <= 0xD7FF || 0xE000 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0x10FFFF
+}
+
+// IsRestrictedChar returns true if r matches the RestrictedChar production of XML 1.1, which are characters that must be written as character references. XML 1.0 has no restricted characters.
+func IsRestrictedChar(r rune, edition Edition) bool {
+	return edition == XML11 && (0x1 <= r && r <= 0x8 || 0xB <= r && r <= 0xC || 0xE <= r && r <= 0x1F || 0x7F <= r && r <= 0x84 || 0x86 <= r && r <= 0x9F)
+}
+
+// IsName returns true if b matches the Name production of the edition, which is used for element, attribute, processing instruction, and entity names. The Name productions of XML 1.0 (fifth edition) and XML 1.1 allow the same characters, contrary to earlier editions of XML 1.0 that listed the allowed characters of Unicode 2.0.
+func IsName(b []byte, edition Edition) bool {
+	return isName(b, edition, true)
+}
+
+// IsNCName returns true if b matches the NCName production of Namespaces in XML of the edition, which is a Name without colons as used for namespace prefixes and local names.
+func IsNCName(b []byte, edition Edition) bool {
+	return isName(b, edition, false)
+}
+
+// isQName returns true if b matches the QName production of Namespaces in XML, which is an NCName optionally preceded by an NCName prefix and a colon, as required for the names of elements and attributes of which the namespaces are resolved.
+func isQName(b []byte, edition Edition) bool {
+	if i := bytes.IndexByte(b, ':'); i != -1 {
+		return IsNCName(b[:i], edition) && IsNCName(b[i+1:], edition)
+	}
+	return IsNCName(b, edition)
+}
+
+func isName(b []byte, edition Edition, colon bool) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for i := 0; i < len(b); {
+		r, n := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && n == 1 || r == ':' && !colon || !isNameStartChar(r) && (i == 0 || !isNameChar(r)) {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+func isNameStartChar(r rune) bool {
+	return r == ':' || 'A' <= r && r <= 'Z' || r == '_' || 'a' <= r && r <= 'z' || 0xC0 <= r && r <= 0xD6 || 0xD8 <= r && r <= 0xF6 || 0xF8 <= r && r <= 0x2FF || 0x370 <= r && r <= 0x37D || 0x37F <= r && r <= 0x1FFF || 0x200C <= r && r <= 0x200D || 0x2070 <= r && r <= 0x218F || 0x2C00 <= r && r <= 0x2FEF || 0x3001 <= r && r <= 0xD7FF || 0xF900 <= r && r <= 0xFDCF || 0xFDF0 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0xEFFFF
+}
+
+func isNameChar(r rune) bool {
+	return r == '-' || r == '.' || '0' <= r && r <= '9' || r == 0xB7 || 0x300 <= r && r <= 0x36F || 0x203F <= r && r <= 0x2040
+}
@@ -0,0 +1,92 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestIsName(t *testing.T) {
+	var tests = []struct {
+		name   string
+		name10 bool
+		ncname bool
+	}{
+		{"a", true, true},
+		{"_a-b.c1", true, true},
+		{"a:b", true, false},
+		{":a", true, false},
+		{"", false, false},
+		{"1a", false, false},
+		{"-a", false, false},
+		{"a b", false, false},
+		{"\u00e9l\u00e9ment", true, true},
+		{"a\u00b7b", true, true},
+		{"\u00b7a", false, false},
+		{"\u0300a", false, false},
+		{"a\u0300", true, true},
+		{"\u037e", false, false}, // Greek question mark
+		{"\u2070", true, true},
+		{"a\u203f", true, true},
+		{"\u3000", false, false}, // ideographic space
+		{"\u4e2d\u6587", true, true},
+		{"\U00010000", true, true},
+		{"\U000f0000", false, false},
+		{"a\xff", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, edition := range []Edition{XML10, XML11} {
+				test.T(t, IsName([]byte(tt.name), edition), tt.name10, edition.String())
+				test.T(t, IsNCName([]byte(tt.name), edition), tt.ncname, edition.String())
+			}
+		})
+	}
+}
+
+func TestIsChar(t *testing.T) {
+	var tests = []struct {
+		r                    rune
+		char10, char11, rest bool
+	}{
+		{'a', true, true, false},
+		{0x0, false, false, false},
+		{0x1, false, true, true},
+		{0x9, true, true, false},
+		{0xB, false, true, true},
+		{0x7F, true, true, true},
+		{0x85, true, true, false},
+		{0x9F, true, true, true},
+		{0xD800, false, false, false},
+		{0xFFFE, false, false, false},
+		{0x10FFFF, true, true, false},
+	}
+	for _, tt := range tests {
+		test.T(t, IsChar(tt.r, XML10), tt.char10, tt.r)
+		test.T(t, IsChar(tt.r, XML11), tt.char11, tt.r)
+		test.T(t, IsRestrictedChar(tt.r, XML10), false, tt.r)
+		test.T(t, IsRestrictedChar(tt.r, XML11), tt.rest, tt.r)
+	}
+}
+
+func TestEdition(t *testing.T) {
+	var tests = []struct {
+		xml     string
+		edition Edition
+	}{
+		{`<a/>`, XML10},
+		{`<?xml version="1.0"?><a/>`, XML10},
+		{`<?xml version="1.1"?><a/>`, XML11},
+		{`<?xml version='1.1' encoding="UTF-8"?><a/>`, XML11},
+		{`<?pi version="1.1"?><a/>`, XML10},
+		{`<a version="1.1"/>`, XML10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.xml, func(t *testing.T) {
+			doc := mustParse(t, tt.xml)
+			test.T(t, documentEdition(doc.Children[len(doc.Children)-1]), tt.edition)
+		})
+	}
+	test.String(t, XML10.String(), "1.0")
+	test.String(t, XML11.String(), "1.1")
+}
@@ -0,0 +1,66 @@
+package xml
+
+import (
+	"io"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// Redact returns a copy of src where text, CDATA sections, comments, and attribute values are replaced by placeholders of the same length, see parse.Redact. Entity and character references are kept, as well as the DOCTYPE and the attributes of processing instructions such as the XML declaration, so that the result tokenizes the same as src with all tokens at the same offsets. Element and attribute names are kept.
+func Redact(src []byte) []byte {
+	dst := parse.Copy(src)
+	r := parse.NewInputBytes(parse.Copy(src))
+	l := NewLexer(r)
+	inPI := false
+	prevEnd := 0
+	for {
+		tt, data := l.Next()
+		if tt == ErrorToken {
+			if l.Err() != io.EOF {
+				// redact the remainder that could not be tokenized
+				parse.Redact(dst[prevEnd:], nil)
+			}
+			break
+		}
+
+		end := r.Offset()
+		b := dst[end-len(data) : end]
+		prevEnd = end
+		switch tt {
+		case StartTagToken:
+			inPI = false
+		case StartTagPIToken:
+			inPI = true
+		case AttributeToken:
+			if !inPI {
+				parse.Redact(b[len(b)-len(l.AttrVal()):], redactSkipReference)
+			}
+		case TextToken:
+			parse.Redact(b, redactSkipReference)
+		case CommentToken:
+			parse.Redact(b, nil)
+		case CDATAToken:
+			if 12 <= len(b) && b[len(b)-1] == '>' {
+				parse.Redact(b[9:len(b)-3], nil)
+			} else if 9 <= len(b) {
+				parse.Redact(b[9:], nil)
+			}
+		}
+	}
+	return dst
+}
+
+// redactSkipReference returns the length of an entity or character reference at the start of b.
+func redactSkipReference(b []byte) int {
+	if b[0] != '&' {
+		return 0
+	}
+	for i := 1; i < len(b); i++ {
+		if b[i] == ';' {
+			return i + 1
+		} else if c := b[i]; c != '#' && c != '_' && c != '-' && c != '.' && c != ':' && (c < '0' || '9' < c) && (c < 'a' || 'z' < c) && (c < 'A' || 'Z' < c) && c < 0x80 {
+			break
+		}
+	}
+	return 0
+}
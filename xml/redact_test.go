@@ -0,0 +1,25 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRedact(t *testing.T) {
+	var tests = []struct {
+		xml      string
+		expected string
+	}{
+		{`<?xml version="1.0" encoding="UTF-8"?><note id="N42">Call Bob &amp; Eve&#x21;</note>`, `<?xml version="1.0" encoding="UTF-8"?><note id="X00">Xxxx Xxx &amp; Xxx&#x21;</note>`},
+		{`<a x='Tab	Sep'><![CDATA[Raw <data>]]><!-- Secret --></a>`, `<a x='Xxx	Xxx'><![CDATA[Xxx <xxxx>]]><!-- Xxxxxx --></a>`},
+		{`<!DOCTYPE note [<!ENTITY e "Entity">]><note>&e; café</note>`, `<!DOCTYPE note [<!ENTITY e "Entity">]><note>&e; xxxé</note>`},
+		{`<a>A & B</a>`, `<a>X & X</a>`},
+		{"<a>Secret\x00 text</a>", "<a>Xxxxxx\x00 xxxx</x>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.xml, func(t *testing.T) {
+			test.String(t, string(Redact([]byte(tt.xml))), tt.expected)
+		})
+	}
+}
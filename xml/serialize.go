@@ -0,0 +1,303 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// ErrInvalidName is returned by Serialize when the name of an element, attribute, or processing instruction is invalid.
+var ErrInvalidName = errors.New("invalid name")
+
+// Namespaces of the standalone profiles.
+const (
+	SVGNamespace   = "http://www.w3.org/2000/svg"
+	XHTMLNamespace = "http://www.w3.org/1999/xhtml"
+)
+
+// Profile determines the output format of Serialize.
+type Profile int
+
+// Profile values.
+const (
+	XMLProfile   Profile = iota // XML as parsed, with missing namespace declarations added
+	SVGProfile                  // standalone SVG file, where elements without namespace are SVG elements
+	XHTMLProfile                // XHTML that is parsed the same as HTML, with a DOCTYPE, where elements without namespace are HTML elements
+)
+
+var xhtmlVoidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// Serialize writes the node and its descendants to w as a document of the given profile. Namespace declarations that are required by the namespace URIs of elements and attributes but missing in the subtree are added, so that a subtree of a document, such as an svg element returned by html.ParseSVG whose namespaces are implied by HTML, is written as a namespace well-formed document with the prefixes as written. The prefixes used by attributes are declared on the root element. It returns ErrInvalidName without writing when a name is invalid for the edition declared by the XML declaration of the document, where element and attribute names must be qualified names as for Parse.
+//
+// Text and attribute values are written as parsed, except for the SVG and XHTML profiles where references that are not defined in XML, such as &nbsp; and other HTML entities, are replaced by the characters they refer to, and stray ampersands are escaped. The XHTML profile follows the HTML compatibility guidelines: it writes <!DOCTYPE html> if the document has none, omits the XML declaration, writes void elements as <br /> and other empty elements with an end tag, and unwraps CDATA sections in HTML elements.
+func Serialize(w io.Writer, n *Node, profile Profile) error {
+	_, err := Serializer{n, profile}.WriteTo(w)
+	return err
+}
+
+// Serializer writes a node as a document of a profile as Serialize does.
+type Serializer struct {
+	Node    *Node
+	Profile Profile
+}
+
+// WriteTo writes the node and its descendants to w, see Serialize, and returns the number of bytes written. It implements io.WriterTo.
+func (ser Serializer) WriteTo(w io.Writer) (int64, error) {
+	n, profile := ser.Node, ser.Profile
+	buf := serializeBufferPool.Get().(*[]byte)
+	defer serializeBufferPool.Put(buf)
+
+	s := serializer{
+		buf:      (*buf)[:0],
+		profile:  profile,
+		edition:  documentEdition(n),
+		entities: map[string][]byte{},
+	}
+	switch profile {
+	case SVGProfile:
+		s.implied = []byte(SVGNamespace)
+	case XHTMLProfile:
+		s.implied = []byte(XHTMLNamespace)
+		if !hasDOCTYPE(n) {
+			s.buf = append(s.buf, "<!DOCTYPE html>\n"...)
+		}
+	}
+	s.node(n)
+	*buf = s.buf
+	if s.err != nil {
+		return 0, s.err
+	}
+	m, err := w.Write(s.buf)
+	return int64(m), err
+}
+
+// serializeBufferPool holds the output buffers of Serialize, which are reused after the output has been written as writers must not retain the slice.
+var serializeBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &[]byte{}
+	},
+}
+
+type serializer struct {
+	profile  Profile
+	implied  []byte // namespace of elements without namespace
+	buf      []byte
+	ns       namespaces
+	hoisted  bool
+	entities map[string][]byte // entities declared in a DOCTYPE that is written
+	edition  Edition
+	err      error
+}
+
+func hasDOCTYPE(n *Node) bool {
+	if n.Type == DOCTYPENode {
+		return true
+	} else if n.Type == DocumentNode {
+		for _, child := range n.Children {
+			if child.Type == DOCTYPENode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *serializer) node(n *Node) {
+	switch n.Type {
+	case DocumentNode:
+		for _, child := range n.Children {
+			s.node(child)
+		}
+	case ElementNode:
+		s.element(n)
+	case TextNode:
+		s.buf = s.appendData(s.buf, n.Data, false)
+	case CDATANode:
+		if s.profile == XHTMLProfile && s.isHTML(n.Parent) {
+			if parent := n.Parent; parent != nil && (string(parent.Local) == "script" || string(parent.Local) == "style") {
+				s.buf = append(s.buf, n.Data...) // raw text
+			} else {
+				s.buf = appendEscaped(s.buf, n.Data, false)
+			}
+		} else {
+			s.buf = append(s.buf, "<![CDATA["...)
+			s.buf = append(s.buf, n.Data...)
+			s.buf = append(s.buf, "]]>"...)
+		}
+	case CommentNode:
+		s.buf = append(s.buf, "<!--"...)
+		s.buf = append(s.buf, n.Data...)
+		s.buf = append(s.buf, "-->"...)
+	case ProcInstNode:
+		if s.profile == XHTMLProfile && bytes.Equal(n.Name, xmlPrefixBytes) {
+			return
+		}
+		s.checkName(n.Name, IsName)
+		s.buf = append(s.buf, '<', '?')
+		s.buf = append(s.buf, n.Name...)
+		for _, attr := range n.Attrs {
+			s.checkName(attr.Name, IsName)
+			s.attr(attr.Name, attr.Val)
+		}
+		s.buf = append(s.buf, '?', '>')
+	case DOCTYPENode:
+		addEntities(s.entities, n.Data)
+		s.buf = append(s.buf, "<!DOCTYPE"...)
+		s.buf = append(s.buf, n.Data...)
+		s.buf = append(s.buf, '>')
+	}
+}
+
+// space returns the namespace URI of an element, which is the implied namespace of the profile for elements without namespace and prefix.
+func (s *serializer) space(n *Node) []byte {
+	if len(n.Space) == 0 && bytes.IndexByte(n.Name, ':') == -1 {
+		return s.implied
+	}
+	return n.Space
+}
+
+func (s *serializer) isHTML(n *Node) bool {
+	return n != nil && n.Type == ElementNode && string(s.space(n)) == XHTMLNamespace
+}
+
+// checkName sets ErrInvalidName when name is invalid according to valid.
+func (s *serializer) checkName(name []byte, valid func([]byte, Edition) bool) {
+	if !valid(name, s.edition) {
+		s.err = ErrInvalidName
+	}
+}
+
+func (s *serializer) element(n *Node) {
+	s.checkName(n.Name, isQName)
+	mark := s.ns.len()
+	s.buf = append(s.buf, '<')
+	s.buf = append(s.buf, n.Name...)
+
+	// bind the declarations as written, then declare the namespaces that are missing
+	for _, attr := range n.Attrs {
+		if bytes.Equal(attr.Name, xmlnsPrefixBytes) {
+			s.ns.bind(nil, attr.Val)
+		} else if prefix, local := splitName(attr.Name); bytes.Equal(prefix, xmlnsPrefixBytes) {
+			s.ns.bind(local, attr.Val)
+		}
+	}
+	prefix, _ := splitName(n.Name)
+	s.declare(prefix, s.space(n))
+	if !s.hoisted {
+		s.hoisted = true
+		n.Walk(func(m *Node) bool {
+			for _, attr := range m.Attrs {
+				if prefix, _ := splitName(attr.Name); prefix != nil && !bytes.Equal(attr.Space, xmlnsNamespace) {
+					s.declare(prefix, attr.Space)
+				}
+			}
+			return true
+		})
+	}
+	for _, attr := range n.Attrs {
+		if prefix, _ := splitName(attr.Name); prefix != nil && !bytes.Equal(attr.Space, xmlnsNamespace) {
+			s.declare(prefix, attr.Space)
+		}
+	}
+	for _, attr := range n.Attrs {
+		s.checkName(attr.Name, isQName)
+		s.attr(attr.Name, attr.Val)
+	}
+
+	if len(n.Children) == 0 {
+		if s.profile != XHTMLProfile || !s.isHTML(n) {
+			s.buf = append(s.buf, '/', '>')
+			s.ns.truncate(mark)
+			return
+		} else if xhtmlVoidElements[string(n.Local)] {
+			s.buf = append(s.buf, ' ', '/', '>')
+			s.ns.truncate(mark)
+			return
+		}
+	}
+	s.buf = append(s.buf, '>')
+	for _, child := range n.Children {
+		s.node(child)
+	}
+	s.buf = append(s.buf, '<', '/')
+	s.buf = append(s.buf, n.Name...)
+	s.buf = append(s.buf, '>')
+	s.ns.truncate(mark)
+}
+
+// declare writes and binds a namespace declaration for the prefix if it is not bound to the namespace URI. Undeclaring a prefix other than the default namespace is not possible in XML 1.0 and is skipped.
+func (s *serializer) declare(prefix, space []byte) {
+	if uri, _ := s.ns.lookup(prefix); bytes.Equal(uri, space) || prefix != nil && len(space) == 0 {
+		return
+	}
+	if prefix == nil {
+		s.attr(xmlnsPrefixBytes, space)
+	} else {
+		s.buf = append(s.buf, " xmlns:"...)
+		s.buf = append(s.buf, prefix...)
+		s.buf = append(s.buf, '=', '"')
+		s.buf = s.appendData(s.buf, space, true)
+		s.buf = append(s.buf, '"')
+	}
+	s.ns.bind(prefix, space)
+}
+
+func (s *serializer) attr(name, val []byte) {
+	s.buf = append(s.buf, ' ')
+	s.buf = append(s.buf, name...)
+	s.buf = append(s.buf, '=', '"')
+	s.buf = s.appendData(s.buf, val, true)
+	s.buf = append(s.buf, '"')
+}
+
+// appendData appends text or an attribute value as parsed to dst, escaping < and, for attribute values, the double quote. For the standalone profiles, references that are not defined in XML are decoded as by HTML and other ampersands are escaped.
+func (s *serializer) appendData(dst, b []byte, attr bool) []byte {
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if c == '<' {
+			dst = append(dst, "&lt;"...)
+		} else if c == '"' && attr {
+			dst = append(dst, "&quot;"...)
+		} else if c == '&' && s.profile != XMLProfile && !s.isReference(b[i:]) {
+			if value, n := parse.DecodeEntity(b[i:], attr); n != 0 {
+				dst = appendEscaped(dst, []byte(value), attr)
+				i += n - 1
+			} else {
+				dst = append(dst, "&amp;"...)
+			}
+		} else {
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// isReference returns true if b starts with a character reference, a predefined entity, or an entity that has been declared.
+func (s *serializer) isReference(b []byte) bool {
+	j := bytes.IndexByte(b, ';')
+	if j < 2 {
+		return false
+	} else if _, ok := decodeEntity(b[1 : j+1]); ok {
+		return true
+	}
+	_, ok := s.entities[string(b[1:j])]
+	return ok
+}
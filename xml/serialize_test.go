@@ -0,0 +1,116 @@
+package xml
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func serialize(t *testing.T, n *Node, profile Profile) string {
+	w := &bytes.Buffer{}
+	if err := Serialize(w, n, profile); err != nil {
+		t.Fatal(err)
+	}
+	return w.String()
+}
+
+func TestSerialize(t *testing.T) {
+	var tests = []struct {
+		xml      string
+		expected string
+	}{
+		{`<?xml version="1.0"?><a/>`, `<?xml version="1.0"?><a/>`},
+		{`<!DOCTYPE a [<!ENTITY e "x">]><a>&e;</a>`, `<!DOCTYPE a [<!ENTITY e "x">]><a>&e;</a>`},
+		{`<a x='"' y='a&amp;b'>x &lt; y</a>`, `<a x="&quot;" y="a&amp;b">x &lt; y</a>`},
+		{`<a xmlns="urn:a" xmlns:b="urn:b"><b:c b:d="1"/></a>`, `<a xmlns="urn:a" xmlns:b="urn:b"><b:c b:d="1"/></a>`},
+		{`<a xmlns="urn:a"><b xmlns=""></b></a>`, `<a xmlns="urn:a"><b xmlns=""/></a>`},
+		{`<a><![CDATA[<x>]]><!--c--><?pi x="1"?></a>`, `<a><![CDATA[<x>]]><!--c--><?pi x="1"?></a>`},
+		{`<a>&nbsp;</a>`, `<a>&nbsp;</a>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.xml, func(t *testing.T) {
+			test.String(t, serialize(t, mustParse(t, tt.xml), XMLProfile), tt.expected)
+		})
+	}
+}
+
+func TestSerializeSubtree(t *testing.T) {
+	doc := mustParse(t, `<a xmlns="urn:a" xmlns:b="urn:b" xmlns:c="urn:c"><x><b:c c:d="1"><e/></b:c></x></a>`)
+	n := doc.Elements("urn:b", "c")[0]
+	test.String(t, serialize(t, n, XMLProfile), `<b:c xmlns:b="urn:b" xmlns:c="urn:c" c:d="1"><e xmlns="urn:a"/></b:c>`)
+
+	doc = mustParse(t, `<a xmlns="urn:a"><b xmlns=""><c/></b></a>`)
+	n = doc.Children[0].Children[0]
+	test.String(t, serialize(t, n, XMLProfile), `<b xmlns=""><c/></b>`)
+}
+
+func TestSerializeSVG(t *testing.T) {
+	// namespaces are implied as for inline SVG in HTML, see html.ParseSVG
+	d := NewDecoder(parse.NewInputString(`<svg viewBox="0 0 1 1"><use xlink:href="#a"/><text x='1"'>a&nbsp;b & c&#32;&amp;&lt;</text></svg>`))
+	d.Bind("", SVGNamespace)
+	d.Bind("xlink", "http://www.w3.org/1999/xlink")
+	et, _ := d.Next()
+	test.T(t, et, StartElementEvent)
+	svg, err := d.Subtree()
+	test.Error(t, err)
+
+	test.String(t, serialize(t, svg, SVGProfile), `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 1 1"><use xlink:href="#a"/><text x="1&quot;">a`+"\u00a0"+`b &amp; c&#32;&amp;&lt;</text></svg>`)
+
+	// elements without namespace are SVG elements
+	doc := mustParse(t, `<svg><!DOCTYPE x><path d="M0 0"/></svg>`)
+	test.String(t, serialize(t, doc, SVGProfile), `<svg xmlns="http://www.w3.org/2000/svg"><!DOCTYPE x><path d="M0 0"/></svg>`)
+
+	doc = mustParse(t, `<!DOCTYPE svg [<!ENTITY e "x">]><svg>&e;&copy;</svg>`)
+	test.String(t, serialize(t, doc, SVGProfile), `<!DOCTYPE svg [<!ENTITY e "x">]><svg xmlns="http://www.w3.org/2000/svg">&e;`+"\u00a9"+`</svg>`)
+}
+
+func TestSerializeXHTML(t *testing.T) {
+	doc := mustParse(t, `<?xml version="1.0"?><html><head><meta charset="utf-8"/><script><![CDATA[a<b]]></script></head><body><p/><br/><p><![CDATA[a<b]]></p><svg xmlns="http://www.w3.org/2000/svg"><rect/><![CDATA[a<b]]></svg>&copy;</body></html>`)
+	test.String(t, serialize(t, doc, XHTMLProfile), `<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><meta charset="utf-8" /><script>a<b</script></head><body><p></p><br /><p>a&lt;b</p><svg xmlns="http://www.w3.org/2000/svg"><rect/><![CDATA[a<b]]></svg>`+"\u00a9"+`</body></html>`)
+
+	doc = mustParse(t, `<!DOCTYPE html><html><body/></html>`)
+	test.String(t, serialize(t, doc, XHTMLProfile), `<!DOCTYPE html><html xmlns="http://www.w3.org/1999/xhtml"><body></body></html>`)
+}
+
+func TestSerializer(t *testing.T) {
+	doc := mustParse(t, `<a><b/></a>`)
+	w := &bytes.Buffer{}
+	var wt io.WriterTo = Serializer{doc, XMLProfile}
+	n, err := wt.WriteTo(w)
+	test.Error(t, err)
+	test.String(t, w.String(), `<a><b/></a>`)
+	test.T(t, n, int64(w.Len()))
+
+	n, err = Serializer{doc, XMLProfile}.WriteTo(test.NewErrorWriter(0))
+	test.T(t, err, test.ErrPlain)
+	test.T(t, n, int64(0))
+}
+
+func TestSerializeError(t *testing.T) {
+	doc := mustParse(t, `<a/>`)
+	test.T(t, Serialize(test.NewErrorWriter(0), doc, XMLProfile), test.ErrPlain)
+
+	// generated names
+	for _, name := range []string{"1a", "a b", "a:b:c", "\u037e"} {
+		doc = mustParse(t, `<a><b x="1"/><?pi y="1"?></a>`)
+		doc.Children[0].Children[0].Name = []byte(name)
+		test.T(t, Serialize(&bytes.Buffer{}, doc, XMLProfile), ErrInvalidName, name)
+
+		doc = mustParse(t, `<a><b x="1"/><?pi y="1"?></a>`)
+		doc.Children[0].Children[0].Attrs[0].Name = []byte(name)
+		test.T(t, Serialize(&bytes.Buffer{}, doc, XMLProfile), ErrInvalidName, name)
+
+		doc = mustParse(t, `<a><b x="1"/><?pi y="1"?></a>`)
+		doc.Children[0].Children[1].Name = []byte(name)
+		if name != "a:b:c" {
+			test.T(t, Serialize(&bytes.Buffer{}, doc, XMLProfile), ErrInvalidName, name)
+		}
+	}
+	doc = mustParse(t, `<a/>`)
+	doc.Children[0].Name = []byte("\u00e9l\u00e9ment")
+	test.String(t, serialize(t, doc, XMLProfile), "<\u00e9l\u00e9ment/>")
+}
@@ -0,0 +1,150 @@
+package xml
+
+import (
+	"bytes"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var (
+	spaceBytes    = []byte("space")
+	preserveBytes = []byte("preserve")
+	defaultBytes  = []byte("default")
+)
+
+// TextContent returns the decoded text of the node and its descendants in document order. Character references, predefined entities, and internal entities declared in the DOCTYPE of the document are resolved, and CDATA sections are unwrapped. Whitespace-only text nodes, such as the indentation between elements, are dropped unless xml:space="preserve" applies to their parent element. Nested entity references in the replacement text of internal entities and unknown entities are kept as is.
+func (n *Node) TextContent() []byte {
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	entities := map[string][]byte{}
+	if root.Type == DocumentNode {
+		for _, child := range root.Children {
+			if child.Type == DOCTYPENode {
+				addEntities(entities, child.Data)
+			}
+		}
+	}
+
+	text := []byte{}
+	var walk func(*Node, bool)
+	walk = func(n *Node, preserve bool) {
+		switch n.Type {
+		case TextNode:
+			if preserve || !parse.IsAllWhitespace(n.Data) {
+				text = appendText(text, n.Data, entities)
+			}
+		case CDATANode:
+			text = append(text, n.Data...)
+		case DocumentNode, ElementNode:
+			preserve = xmlSpace(n, preserve)
+			for _, child := range n.Children {
+				walk(child, preserve)
+			}
+		}
+	}
+	preserve := false
+	if n.Parent != nil {
+		preserve = inheritedXMLSpace(n.Parent)
+	}
+	walk(n, preserve)
+	return text
+}
+
+// TextContent consumes the element returned by the last StartElementEvent up to and including its end tag, and returns its decoded text, see Node.TextContent. Entities are resolved using the DOCTYPE that preceded the element. Streaming continues after the element, and no EndElementEvent is returned for it.
+func (d *Decoder) TextContent() ([]byte, error) {
+	if d.last != StartElementEvent {
+		return nil, ErrNoStartElement
+	}
+	root := d.lastNode
+	preserve := []bool{xmlSpace(root, inheritedXMLSpace(root.Parent))}
+	text := []byte{}
+	for {
+		et, n := d.next()
+		switch et {
+		case ErrorEvent:
+			return nil, d.err
+		case NodeEvent:
+			top := preserve[len(preserve)-1]
+			if n.Type == TextNode && (top || !parse.IsAllWhitespace(n.Data)) {
+				text = appendText(text, n.Data, d.entities)
+			} else if n.Type == CDATANode {
+				text = append(text, n.Data...)
+			}
+		case StartElementEvent:
+			preserve = append(preserve, xmlSpace(n, preserve[len(preserve)-1]))
+		case EndElementEvent:
+			if n == root {
+				d.last, d.lastNode = EndElementEvent, n
+				return text, nil
+			}
+			preserve = preserve[:len(preserve)-1]
+		}
+	}
+}
+
+// xmlSpace returns whether whitespace is preserved in the element, given whether it is preserved in its parent.
+func xmlSpace(n *Node, preserve bool) bool {
+	if n.Type == ElementNode {
+		for _, attr := range n.Attrs {
+			if bytes.Equal(attr.Space, xmlNamespace) && bytes.Equal(attr.Local, spaceBytes) {
+				if bytes.Equal(attr.Val, preserveBytes) {
+					return true
+				} else if bytes.Equal(attr.Val, defaultBytes) {
+					return false
+				}
+			}
+		}
+	}
+	return preserve
+}
+
+// inheritedXMLSpace returns whether whitespace is preserved in the element by the xml:space attribute of itself or its nearest ancestor that has one.
+func inheritedXMLSpace(n *Node) bool {
+	ancestors := []*Node{}
+	for ; n != nil; n = n.Parent {
+		ancestors = append(ancestors, n)
+	}
+	preserve := false
+	for i := len(ancestors) - 1; 0 <= i; i-- {
+		preserve = xmlSpace(ancestors[i], preserve)
+	}
+	return preserve
+}
+
+// addEntities adds the replacement texts of the internal entities declared in the DOCTYPE text to entities, where external and unparsed entities have a nil replacement text.
+func addEntities(entities map[string][]byte, doctype []byte) {
+	entityDecls(doctype, func(name string, value []byte, systemID string) {
+		if _, ok := entities[name]; !ok {
+			entities[name] = value // the first declaration is binding
+		}
+	})
+}
+
+// appendText appends text to dst while decoding character references, the predefined entities, and internal entities. Unknown and external entities are kept as is.
+func appendText(dst, b []byte, entities map[string][]byte) []byte {
+	for {
+		i := bytes.IndexByte(b, '&')
+		if i == -1 {
+			break
+		}
+		dst = append(dst, b[:i]...)
+		b = b[i:]
+		j := bytes.IndexByte(b, ';')
+		if j == -1 {
+			break
+		}
+		if _, ok := decodeEntity(b[1 : j+1]); ok {
+			dst = appendUnescaped(dst, b[:j+1])
+			b = b[j+1:]
+		} else if value := entities[string(b[1:j])]; value != nil {
+			dst = appendUnescaped(dst, value)
+			b = b[j+1:]
+		} else {
+			dst = append(dst, '&')
+			b = b[1:]
+		}
+	}
+	return append(dst, b...)
+}
@@ -0,0 +1,96 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+const textDoc = `<!DOCTYPE doc [
+  <!ENTITY co "Acme &amp; Co">
+  <!ENTITY lt "&#38;#60;">
+  <!ENTITY ext SYSTEM "ext.xml">
+]>
+<doc>
+  <title>&co; &lt;3 &#x263A;</title>
+  <body>
+    <p>a <![CDATA[<b>]]> c &unknown; &ext; & d</p>
+    <pre xml:space="preserve"> <i>x</i> <span xml:space="default"> </span></pre>
+  </body>
+</doc>`
+
+func TestTextContent(t *testing.T) {
+	var tests = []struct {
+		local    string
+		expected string
+	}{
+		{"title", "Acme & Co <3 ☺"},
+		{"p", "a <b> c &unknown; &ext; & d"},
+		{"pre", " x "},
+		{"span", ""},
+		{"body", "a <b> c &unknown; &ext; & d x "},
+	}
+
+	doc, err := Parse(parse.NewInputString(textDoc))
+	test.Error(t, err)
+	for _, tt := range tests {
+		t.Run(tt.local, func(t *testing.T) {
+			elems := doc.Elements("", tt.local)
+			test.T(t, len(elems), 1)
+			test.String(t, string(elems[0].TextContent()), tt.expected)
+		})
+	}
+	test.String(t, string(doc.Elements("", "i")[0].Children[0].TextContent()), "x")
+}
+
+func TestDecoderTextContent(t *testing.T) {
+	var tests = []struct {
+		local    string
+		expected string
+	}{
+		{"title", "Acme & Co <3 ☺"},
+		{"p", "a <b> c &unknown; &ext; & d"},
+		{"pre", " x "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.local, func(t *testing.T) {
+			d := NewDecoder(parse.NewInputString(textDoc))
+			for {
+				et, n := d.Next()
+				if et == ErrorEvent {
+					test.Fail(t, "element not found:", d.Err())
+					return
+				} else if et == StartElementEvent && string(n.Local) == tt.local {
+					break
+				}
+			}
+			text, err := d.TextContent()
+			test.Error(t, err)
+			test.String(t, string(text), tt.expected)
+
+			// streaming continues after the element
+			et, n := d.Next()
+			if et == StartElementEvent {
+				test.That(t, string(n.Local) != tt.local)
+			}
+		})
+	}
+
+	d := NewDecoder(parse.NewInputString(`<a><b/><c>x</c></a>`))
+	_, err := d.TextContent()
+	test.T(t, err, ErrNoStartElement)
+	d.Next()
+	d.Next()
+	text, err := d.TextContent()
+	test.Error(t, err)
+	test.String(t, string(text), "")
+	et, n := d.Next()
+	test.T(t, et, StartElementEvent)
+	test.String(t, string(n.Name), "c")
+
+	d = NewDecoder(parse.NewInputString(`<a>x<b>`))
+	d.Next()
+	_, err = d.TextContent()
+	test.That(t, err != nil)
+}
@@ -0,0 +1,215 @@
+package xml
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+var (
+	xmlPrefixBytes   = []byte("xml")
+	xmlnsPrefixBytes = []byte("xmlns")
+	xmlNamespace     = []byte("http://www.w3.org/XML/1998/namespace")
+	xmlnsNamespace   = []byte("http://www.w3.org/2000/xmlns/")
+)
+
+// NodeType determines the type of node in the tree.
+type NodeType uint32
+
+// NodeType values.
+const (
+	DocumentNode NodeType = iota
+	ElementNode
+	TextNode
+	CDATANode
+	CommentNode
+	ProcInstNode
+	DOCTYPENode
+)
+
+// String returns the string representation of a NodeType.
+func (nt NodeType) String() string {
+	switch nt {
+	case DocumentNode:
+		return "Document"
+	case ElementNode:
+		return "Element"
+	case TextNode:
+		return "Text"
+	case CDATANode:
+		return "CDATA"
+	case CommentNode:
+		return "Comment"
+	case ProcInstNode:
+		return "ProcInst"
+	case DOCTYPENode:
+		return "DOCTYPE"
+	}
+	return "Invalid(" + strconv.Itoa(int(nt)) + ")"
+}
+
+// Attr is an attribute of an element or processing instruction with its namespace resolved.
+type Attr struct {
+	Name  []byte // qualified name as written, such as xlink:href
+	Space []byte // namespace URI, empty when the attribute is not in a namespace
+	Local []byte // local name without prefix
+	Val   []byte // value without quotes, entities are not decoded
+}
+
+// Node is a node in the XML tree. All byte slices point into the input buffer.
+type Node struct {
+	Type  NodeType
+	Name  []byte // qualified name as written for elements, target for processing instructions
+	Space []byte // namespace URI of an element, empty when the element is not in a namespace
+	Local []byte // local name of an element without prefix
+	Attrs []Attr
+	Data  []byte // contents of text, CDATA, comment, and DOCTYPE nodes, entities are not decoded
+
+	Parent   *Node
+	Children []*Node
+
+	Start, End int // byte offsets of the node in the input
+}
+
+func (n *Node) appendChild(child *Node) {
+	child.Parent = n
+	n.Children = append(n.Children, child)
+}
+
+// Attr returns the attribute with the given namespace URI and local name, or nil if it does not exist. Use an empty namespace URI for attributes without a prefix.
+func (n *Node) Attr(space, local string) *Attr {
+	for i := range n.Attrs {
+		if string(n.Attrs[i].Local) == local && string(n.Attrs[i].Space) == space {
+			return &n.Attrs[i]
+		}
+	}
+	return nil
+}
+
+// AttrVal returns the value of the attribute with the given namespace URI and local name, and whether it exists, see Attr.
+func (n *Node) AttrVal(space, local string) ([]byte, bool) {
+	if attr := n.Attr(space, local); attr != nil {
+		return attr.Val, true
+	}
+	return nil, false
+}
+
+// LookupNamespace returns the namespace URI that is bound to the prefix in the scope of the element, or the default namespace for an empty prefix, and whether it is bound. This resolves prefixes in attribute values, such as xsi:type="xs:string". It also works for nodes returned by the Decoder, whose ancestors are retained.
+func (n *Node) LookupNamespace(prefix string) ([]byte, bool) {
+	for ; n != nil; n = n.Parent {
+		for _, attr := range n.Attrs {
+			if prefix == "" && string(attr.Name) == "xmlns" || prefix != "" && string(attr.Name) == "xmlns:"+prefix {
+				return attr.Val, len(attr.Val) != 0 // xmlns="" undeclares the default namespace
+			}
+		}
+	}
+	if prefix == string(xmlPrefixBytes) {
+		return xmlNamespace, true
+	} else if prefix == string(xmlnsPrefixBytes) {
+		return xmlnsNamespace, true
+	}
+	return nil, false
+}
+
+// Walk calls f for the node and its descendants in document order. The descendants of a node are skipped when f returns false.
+func (n *Node) Walk(f func(*Node) bool) {
+	if f(n) {
+		for _, child := range n.Children {
+			child.Walk(f)
+		}
+	}
+}
+
+// Elements returns the descendant elements with the given namespace URI and local name in document order. An empty local name matches all local names in the namespace.
+func (n *Node) Elements(space, local string) []*Node {
+	elems := []*Node{}
+	for _, child := range n.Children {
+		child.Walk(func(m *Node) bool {
+			if m.Type == ElementNode && string(m.Space) == space && (local == "" || string(m.Local) == local) {
+				elems = append(elems, m)
+			}
+			return true
+		})
+	}
+	return elems
+}
+
+// namespaces is a stack of prefix to namespace URI bindings.
+type namespaces struct {
+	prefixes [][]byte
+	uris     [][]byte
+}
+
+func (ns *namespaces) bind(prefix, uri []byte) {
+	ns.prefixes = append(ns.prefixes, prefix)
+	ns.uris = append(ns.uris, uri)
+}
+
+func (ns *namespaces) lookup(prefix []byte) ([]byte, bool) {
+	for i := len(ns.prefixes) - 1; 0 <= i; i-- {
+		if bytes.Equal(ns.prefixes[i], prefix) {
+			return ns.uris[i], true
+		}
+	}
+	if bytes.Equal(prefix, xmlPrefixBytes) {
+		return xmlNamespace, true
+	} else if bytes.Equal(prefix, xmlnsPrefixBytes) {
+		return xmlnsNamespace, true
+	}
+	return nil, false
+}
+
+func (ns *namespaces) len() int {
+	return len(ns.prefixes)
+}
+
+func (ns *namespaces) truncate(n int) {
+	ns.prefixes = ns.prefixes[:n]
+	ns.uris = ns.uris[:n]
+}
+
+// splitName splits a qualified name into its prefix and local name.
+func splitName(name []byte) ([]byte, []byte) {
+	if i := bytes.IndexByte(name, ':'); 0 < i && i+1 < len(name) {
+		return name[:i], name[i+1:]
+	}
+	return nil, name
+}
+
+func unquote(b []byte) []byte {
+	if 0 < len(b) && (b[0] == '"' || b[0] == '\'') {
+		if 1 < len(b) && b[len(b)-1] == b[0] {
+			return b[1 : len(b)-1]
+		}
+		return b[1:]
+	}
+	return b
+}
+
+// resolve resolves the namespaces of an element and its attributes, after its namespace declarations have been bound.
+func (ns *namespaces) resolve(n *Node) {
+	prefix, local := splitName(n.Name)
+	n.Local = local
+	n.Space, _ = ns.lookup(prefix) // the default namespace has an empty prefix
+	for i, attr := range n.Attrs {
+		prefix, local := splitName(attr.Name)
+		n.Attrs[i].Local = local
+		if prefix != nil {
+			n.Attrs[i].Space, _ = ns.lookup(prefix)
+		} else if bytes.Equal(attr.Name, xmlnsPrefixBytes) {
+			n.Attrs[i].Space = xmlnsNamespace
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// Parse parses an XML document into a tree and resolves the namespaces of elements and attributes. It returns the document node, or an error when the end tags do not match the start tags or when a name of an element, attribute, or processing instruction is invalid for the edition of the document, where element and attribute names must be qualified names of Namespaces in XML, see IsName and IsNCName.
+func Parse(r *parse.Input) (*Node, error) {
+	d := NewDecoder(r)
+	if err := d.build(d.doc); err != nil {
+		return nil, err
+	}
+	return d.doc, nil
+}
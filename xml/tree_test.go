@@ -0,0 +1,129 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestParseTree(t *testing.T) {
+	s := `<?xml version="1.0"?><a xmlns="urn:a" xmlns:b="urn:b"><b:c b:x='1' y="2">text</b:c><d xmlns="urn:d"/><![CDATA[<e>]]><!--f--></a>`
+	doc, err := Parse(parse.NewInputString(s))
+	test.Error(t, err)
+	test.T(t, len(doc.Children), 2)
+	test.T(t, doc.Children[0].Type, ProcInstNode)
+	test.String(t, string(doc.Children[0].Name), "xml")
+
+	a := doc.Children[1]
+	test.T(t, a.Type, ElementNode)
+	test.String(t, string(a.Space), "urn:a")
+	test.String(t, string(a.Local), "a")
+	test.T(t, len(a.Children), 4)
+	test.T(t, a.Start, 21)
+	test.T(t, a.End, len(s))
+
+	c := a.Children[0]
+	test.T(t, c.Parent, a)
+	test.String(t, string(c.Name), "b:c")
+	test.String(t, string(c.Space), "urn:b")
+	test.String(t, string(c.Local), "c")
+	test.String(t, string(c.Attrs[0].Space), "urn:b")
+	test.String(t, string(c.Attrs[0].Local), "x")
+	test.String(t, string(c.Attrs[0].Val), "1")
+	test.String(t, string(c.Attrs[1].Space), "")
+	test.String(t, string(c.Attrs[1].Val), "2")
+	test.String(t, string(c.Children[0].Data), "text")
+
+	d := a.Children[1]
+	test.String(t, string(d.Space), "urn:d")
+	test.T(t, len(d.Children), 0)
+	test.T(t, a.Children[2].Type, CDATANode)
+	test.String(t, string(a.Children[2].Data), "<e>")
+	test.T(t, a.Children[3].Type, CommentNode)
+	test.String(t, string(a.Children[3].Data), "f")
+}
+
+func TestParseTreeNamespaceScope(t *testing.T) {
+	doc, err := Parse(parse.NewInputString(`<a xmlns:p="urn:1"><p:b xmlns:p="urn:2"/><p:c xml:lang="en"/></a>`))
+	test.Error(t, err)
+	a := doc.Children[0]
+	test.String(t, string(a.Children[0].Space), "urn:2")
+	test.String(t, string(a.Children[1].Space), "urn:1")
+	test.String(t, string(a.Children[1].Attrs[0].Space), "http://www.w3.org/XML/1998/namespace")
+}
+
+func TestParseTreeErrors(t *testing.T) {
+	var errorTests = []struct {
+		xml string
+		err string
+	}{
+		{"<a></b>", "unexpected end tag </b>"},
+		{"</a>", "unexpected end tag </a>"},
+		{"<a><b></b>", "unexpected EOF, expected end tag </a>"},
+		{"<1a/>", "invalid element name \"1a\""},
+		{"<a:b:c/>", "invalid element name \"a:b:c\""},
+		{"<a -x=\"1\"/>", "invalid attribute name \"-x\""},
+		{"<a x:=\"1\"/>", "invalid attribute name \"x:\""},
+		{"<?1pi?><a/>", "invalid processing instruction name \"1pi\""},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.xml, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.xml))
+			test.That(t, err != nil)
+			test.T(t, err.(*parse.Error).Message, tt.err)
+		})
+	}
+
+	test.T(t, ElementNode.String(), "Element")
+	test.T(t, NodeType(100).String(), "Invalid(100)")
+}
+
+func TestNodeAttr(t *testing.T) {
+	doc, err := Parse(parse.NewInputString(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="urn:xsi" xmlns:xs="urn:xs"><use xlink:href="#a" href="#b"/><g xmlns="" xsi:type="xs:string"><use xlink:href="#c"/></g></svg>`))
+	test.Error(t, err)
+	svg := doc.Children[0]
+	use := svg.Children[0]
+
+	val, ok := use.AttrVal("http://www.w3.org/1999/xlink", "href")
+	test.That(t, ok)
+	test.String(t, string(val), "#a")
+	val, ok = use.AttrVal("", "href")
+	test.That(t, ok)
+	test.String(t, string(val), "#b")
+	_, ok = use.AttrVal("http://www.w3.org/2000/svg", "href")
+	test.That(t, !ok)
+	test.T(t, use.Attr("urn:other", "href"), (*Attr)(nil))
+	test.String(t, string(svg.Attr("http://www.w3.org/2000/xmlns/", "xlink").Val), "http://www.w3.org/1999/xlink")
+
+	// prefixes in attribute values
+	g := svg.Children[1]
+	typ, _ := g.AttrVal("urn:xsi", "type")
+	uri, ok := g.LookupNamespace(string(typ[:2]))
+	test.That(t, ok)
+	test.String(t, string(uri), "urn:xs")
+	_, ok = g.LookupNamespace("")
+	test.That(t, !ok, "default namespace undeclared")
+	uri, ok = use.LookupNamespace("")
+	test.That(t, ok)
+	test.String(t, string(uri), "http://www.w3.org/2000/svg")
+	uri, ok = use.LookupNamespace("xml")
+	test.That(t, ok)
+	test.String(t, string(uri), "http://www.w3.org/XML/1998/namespace")
+	_, ok = use.LookupNamespace("p")
+	test.That(t, !ok)
+
+	// document order
+	uses := doc.Elements("http://www.w3.org/2000/svg", "use")
+	test.T(t, len(uses), 1)
+	test.T(t, len(doc.Elements("", "use")), 1)
+	test.T(t, len(doc.Elements("http://www.w3.org/2000/svg", "")), 2)
+	hrefs := []string{}
+	doc.Walk(func(n *Node) bool {
+		if val, ok := n.AttrVal("http://www.w3.org/1999/xlink", "href"); ok {
+			hrefs = append(hrefs, string(val))
+		}
+		return n.Type != ElementNode || string(n.Local) != "g"
+	})
+	test.T(t, hrefs, []string{"#a"})
+}
@@ -0,0 +1,348 @@
+package xml
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+)
+
+// ErrorCode is the well-formedness constraint that is violated by a WFError.
+type ErrorCode uint32
+
+// ErrorCode values.
+const (
+	SyntaxError             ErrorCode = iota // unterminated tokens, unquoted attribute values, and lexer errors
+	MismatchedTagError                       // end tag does not match the start tag (WFC: Element Type Match)
+	UnclosedTagError                         // element is not closed at the end of the input
+	UnexpectedEndTagError                    // end tag without start tag
+	DuplicateAttributeError                  // attribute name appears twice in a tag (WFC: Unique Att Spec)
+	InvalidNameError                         // element, attribute, processing instruction, or entity name is not a valid Name
+	InvalidCharError                         // character is not allowed by the Char production or in attribute values (WFC: Legal Character, No < in Attribute Values)
+	InvalidReferenceError                    // entity or character reference is not terminated by a semicolon or has no name
+	UndeclaredEntityError                    // entity is not predefined nor declared in the internal subset (WFC: Entity Declared)
+)
+
+// String returns the documentation code of an ErrorCode, such as mismatched-tag.
+func (code ErrorCode) String() string {
+	switch code {
+	case SyntaxError:
+		return "syntax"
+	case MismatchedTagError:
+		return "mismatched-tag"
+	case UnclosedTagError:
+		return "unclosed-tag"
+	case UnexpectedEndTagError:
+		return "unexpected-end-tag"
+	case DuplicateAttributeError:
+		return "duplicate-attribute"
+	case InvalidNameError:
+		return "invalid-name"
+	case InvalidCharError:
+		return "invalid-char"
+	case InvalidReferenceError:
+		return "invalid-reference"
+	case UndeclaredEntityError:
+		return "undeclared-entity"
+	}
+	return "Invalid(" + strconv.Itoa(int(code)) + ")"
+}
+
+// WFError is a violation of a well-formedness constraint. Start and End are the offsets of the offending name, reference, character, or token. For MismatchedTagError and UnclosedTagError, OpenStart and OpenEnd are the offsets of the start tag of the element.
+type WFError struct {
+	*parse.Error
+	Code               ErrorCode
+	Start, End         int
+	OpenStart, OpenEnd int
+}
+
+// openTag is an element that has not been closed.
+type openTag struct {
+	name       []byte
+	start, end int
+}
+
+// Validator checks the tokens of a Lexer against the well-formedness constraints of XML 1.0, or XML 1.1 when declared by the XML declaration, while streaming, and collects all violations instead of stopping at the first. It does not check the structure of the DOCTYPE, nor whether there is exactly one root element.
+type Validator struct {
+	r    *parse.Input
+	l    *Lexer
+	errs []*WFError
+
+	open  []openTag
+	tag   *openTag // start tag whose attributes are being read
+	attrs [][]byte
+	inPI  bool // reading the attributes of a processing instruction
+	pi    bool // processing instruction is the XML declaration
+
+	entities   map[string]bool
+	external   bool // DOCTYPE has an external subset or parameter entity references
+	standalone bool
+}
+
+// NewValidator returns a new Validator for a given parse.Input.
+func NewValidator(r *parse.Input) *Validator {
+	return &Validator{
+		r:        r,
+		l:        NewLexer(r),
+		entities: map[string]bool{},
+	}
+}
+
+// Validate returns all well-formedness violations of an XML document.
+func Validate(r *parse.Input) []*WFError {
+	v := NewValidator(r)
+	for {
+		if tt, _ := v.Next(); tt == ErrorToken {
+			return v.Errors()
+		}
+	}
+}
+
+// Lexer returns the underlying lexer, which can be used to obtain the text and attribute values of the last token.
+func (v *Validator) Lexer() *Lexer {
+	return v.l
+}
+
+// Err returns the error of the underlying lexer, this is often io.EOF but also other errors can be returned.
+func (v *Validator) Err() error {
+	return v.l.Err()
+}
+
+// Errors returns the violations found so far in document order, except that unclosed elements are reported at the end of the input.
+func (v *Validator) Errors() []*WFError {
+	return v.errs
+}
+
+// Next returns the next token of the lexer like Lexer.Next and checks it, see Errors.
+func (v *Validator) Next() (TokenType, []byte) {
+	start := v.r.Offset()
+	tt, data := v.l.Next()
+	end := v.r.Offset()
+	if tt != ErrorToken {
+		start = end - len(data)
+	}
+	switch tt {
+	case ErrorToken:
+		v.eof()
+	case TextToken:
+		v.checkChars(data, start)
+		v.checkRefs(data, start)
+	case CommentToken:
+		if !bytes.HasSuffix(data, []byte("-->")) {
+			v.report(SyntaxError, start, end, "unterminated comment")
+		}
+		v.checkChars(data, start)
+	case CDATAToken:
+		if !bytes.HasSuffix(data, []byte("]]>")) {
+			v.report(SyntaxError, start, end, "unterminated CDATA section")
+		}
+		v.checkChars(data, start)
+	case DOCTYPEToken:
+		if !bytes.HasSuffix(data, []byte(">")) {
+			v.report(SyntaxError, start, end, "unterminated DOCTYPE")
+		}
+		v.checkChars(data, start)
+		v.doctype(v.l.Text(), start+9)
+	case StartTagToken:
+		name := v.l.Text()
+		v.checkName(name, start+1, "element")
+		v.tag = &openTag{name, start, end}
+		v.attrs = v.attrs[:0]
+	case StartTagPIToken:
+		v.checkName(v.l.Text(), start+2, "processing instruction")
+		v.pi = bytes.Equal(v.l.Text(), []byte("xml"))
+		v.inPI = true
+	case AttributeToken:
+		name := v.l.Text()
+		nameStart := end - len(bytes.TrimLeft(data, " \t\r\n"))
+		val := v.l.AttrVal()
+		if v.inPI {
+			// pseudo-attributes of the XML declaration
+			if v.pi && bytes.Equal(name, []byte("standalone")) && 2 < len(val) && bytes.Equal(val[1:len(val)-1], []byte("yes")) {
+				v.standalone = true
+			}
+			break
+		}
+
+		v.checkName(name, nameStart, "attribute")
+		for _, prev := range v.attrs {
+			if bytes.Equal(prev, name) {
+				v.report(DuplicateAttributeError, nameStart, nameStart+len(name), "duplicate attribute %s", string(name))
+				break
+			}
+		}
+		v.attrs = append(v.attrs, name)
+
+		if len(val) < 2 || val[0] != '"' && val[0] != '\'' || val[len(val)-1] != val[0] {
+			v.report(SyntaxError, nameStart, end, "attribute %s must have a quoted value", string(name))
+			break
+		}
+		valStart := end - len(val) + 1
+		val = val[1 : len(val)-1]
+		v.checkChars(val, valStart)
+		if i := bytes.IndexByte(val, '<'); i != -1 {
+			v.report(InvalidCharError, valStart+i, valStart+i+1, "'<' in attribute value")
+		}
+		v.checkRefs(val, valStart)
+	case StartTagCloseToken:
+		if v.tag != nil {
+			v.tag.end = end
+			v.open = append(v.open, *v.tag)
+		}
+		v.tag, v.inPI = nil, false
+	case StartTagCloseVoidToken, StartTagClosePIToken:
+		v.tag, v.inPI = nil, false
+	case EndTagToken:
+		name := v.l.Text()
+		if !bytes.HasSuffix(data, []byte(">")) {
+			v.report(SyntaxError, start, end, "unterminated end tag")
+		}
+		if len(v.open) == 0 {
+			v.report(UnexpectedEndTagError, start, end, "unexpected end tag </%s>", string(name))
+			break
+		}
+		open := v.open[len(v.open)-1]
+		if !bytes.Equal(open.name, name) {
+			// close the matching element if it is open, otherwise keep the open elements
+			i := len(v.open) - 1
+			for 0 <= i && !bytes.Equal(v.open[i].name, name) {
+				i--
+			}
+			err := v.report(MismatchedTagError, start, end, "end tag </%s> does not match start tag <%s>", string(name), string(open.name))
+			err.OpenStart, err.OpenEnd = open.start, open.end
+			if i == -1 {
+				break
+			}
+			v.open = v.open[:i+1]
+		}
+		v.open = v.open[:len(v.open)-1]
+	}
+	return tt, data
+}
+
+// eof reports lexer errors and unclosed elements at the end of the input.
+func (v *Validator) eof() {
+	end := v.r.Offset()
+	if err, ok := v.l.Err().(*parse.Error); ok {
+		v.errs = append(v.errs, &WFError{Error: err, Code: SyntaxError, Start: end, End: end})
+	} else if v.l.Err() == io.EOF && (v.tag != nil || v.inPI) {
+		v.report(SyntaxError, end, end, "unterminated start tag")
+	}
+	v.tag, v.inPI = nil, false
+	for i := len(v.open) - 1; 0 <= i; i-- {
+		open := v.open[i]
+		err := v.report(UnclosedTagError, end, end, "unexpected EOF, expected end tag </%s>", string(open.name))
+		err.OpenStart, err.OpenEnd = open.start, open.end
+	}
+	v.open = v.open[:0]
+}
+
+func (v *Validator) report(code ErrorCode, start, end int, message string, a ...interface{}) *WFError {
+	offset := start - v.r.Freed()
+	if offset < 0 {
+		offset = 0
+	}
+	perr := parse.NewError(v.r, offset, message, a...)
+	if 0 < v.r.Freed() {
+		perr.Line, perr.Column = v.r.PositionAt(start) // the buffer of a streaming Input does not start at the first line
+	}
+	err := &WFError{Error: perr, Code: code, Start: start, End: end}
+	v.errs = append(v.errs, err)
+	return err
+}
+
+// doctype collects the general entities declared in the internal subset of the DOCTYPE text, which starts at offset.
+func (v *Validator) doctype(b []byte, offset int) {
+	i := bytes.IndexByte(b, '[')
+	if i == -1 {
+		i = len(b)
+	}
+	if bytes.Contains(b[:i], []byte("SYSTEM")) || bytes.Contains(b[:i], []byte("PUBLIC")) {
+		v.external = true
+	}
+	for i < len(b) {
+		j := bytes.Index(b[i:], []byte("<!ENTITY"))
+		if j == -1 {
+			if bytes.IndexByte(b[i:], '%') != -1 {
+				v.external = true // parameter entity references may declare entities
+			}
+			break
+		} else if bytes.IndexByte(b[i:i+j], '%') != -1 {
+			v.external = true
+		}
+		i += j + 8
+		for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+			i++
+		}
+		if i < len(b) && b[i] == '%' {
+			continue // parameter entity declaration
+		}
+		nameStart := i
+		for i < len(b) && b[i] != ' ' && b[i] != '\t' && b[i] != '\n' && b[i] != '\r' && b[i] != '>' {
+			i++
+		}
+		name := b[nameStart:i]
+		v.checkName(name, offset+nameStart, "entity")
+		v.entities[string(name)] = true
+	}
+}
+
+// checkName reports an InvalidNameError when name does not match the Name production of the edition of the document.
+func (v *Validator) checkName(name []byte, offset int, kind string) {
+	if !IsName(name, v.l.Edition()) {
+		v.report(InvalidNameError, offset, offset+len(name), "invalid %s name %q", kind, string(name))
+	}
+}
+
+// checkChars reports an InvalidCharError for every character not matching the Char production of the edition of the document, or that is a restricted character.
+func (v *Validator) checkChars(b []byte, offset int) {
+	edition := v.l.Edition()
+	for i := 0; i < len(b); {
+		r, n := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && n == 1 {
+			v.report(InvalidCharError, offset+i, offset+i+1, "invalid UTF-8 byte 0x%02X", b[i])
+		} else if !IsChar(r, edition) {
+			v.report(InvalidCharError, offset+i, offset+i+n, "invalid character U+%04X", r)
+		} else if IsRestrictedChar(r, edition) {
+			v.report(InvalidCharError, offset+i, offset+i+n, "restricted character U+%04X must be a character reference", r)
+		}
+		i += n
+	}
+}
+
+// checkRefs checks the entity and character references in text or an attribute value.
+func (v *Validator) checkRefs(b []byte, offset int) {
+	for i := 0; i < len(b); i++ {
+		if b[i] != '&' {
+			continue
+		}
+		j := bytes.IndexAny(b[i+1:], "&; \t\r\n<")
+		if j == -1 || b[i+1+j] != ';' || j == 0 {
+			v.report(InvalidReferenceError, offset+i, offset+i+1, "'&' does not start a reference, use &amp;")
+			continue
+		}
+		ref := b[i+1 : i+1+j]
+		start, end := offset+i, offset+i+j+2
+		if ref[0] == '#' {
+			var r uint64
+			var err error
+			if 2 < len(ref) && ref[1] == 'x' {
+				r, err = strconv.ParseUint(string(ref[2:]), 16, 32)
+			} else {
+				r, err = strconv.ParseUint(string(ref[1:]), 10, 32)
+			}
+			if err != nil {
+				v.report(InvalidReferenceError, start, end, "invalid character reference &%s;", string(ref))
+			} else if !IsChar(rune(r), v.l.Edition()) {
+				v.report(InvalidCharError, start, end, "character reference &%s; to invalid character", string(ref))
+			}
+		} else if !IsName(ref, v.l.Edition()) {
+			v.report(InvalidNameError, start+1, end-1, "invalid entity name %q", string(ref))
+		} else if _, ok := parse.XMLEntities.Get(b[i+1 : i+2+j]); !ok && !v.entities[string(ref)] && (!v.external || v.standalone) {
+			v.report(UndeclaredEntityError, start, end, "undeclared entity &%s;", string(ref))
+		}
+		i += j + 1
+	}
+}
@@ -0,0 +1,68 @@
+package xml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/politepixels/tdewolff-parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		xml      string
+		expected string
+	}{
+		{`<?xml version="1.0"?><a x="1"><b/>text &amp; &#x41;<![CDATA[<]]></a>`, ``},
+		{`<a><b></a>`, `mismatched-tag 6-10 (3-6)`},
+		{`<a><b></c></b></a>`, `mismatched-tag 6-10 (3-6)`},
+		{`<a><b>`, `unclosed-tag 6-6 (3-6); unclosed-tag 6-6 (0-3)`},
+		{`</a>`, `unexpected-end-tag 0-4`},
+		{`<a x="1" y="2" x="3"/>`, `duplicate-attribute 15-16`},
+		{`<1a -x="1"/>`, `invalid-name 1-3; invalid-name 4-6`},
+		{"<a>\x01</a>", `invalid-char 3-4`},
+		{"<a>\xFF</a>", `invalid-char 3-4`},
+		{`<a x="<"/>`, `invalid-char 6-7`},
+		{`<a>&#0;</a>`, `invalid-char 3-7`},
+		{`<a>A & B</a>`, `invalid-reference 5-6`},
+		{`<a>&#xZ;</a>`, `invalid-reference 3-8`},
+		{`<a>&nbsp;</a>`, `undeclared-entity 3-9`},
+		{`<a x="&e;"/>`, `undeclared-entity 6-9`},
+		{`<!DOCTYPE a [<!ENTITY e "E">]><a>&e;</a>`, ``},
+		{`<!DOCTYPE a SYSTEM "a.dtd"><a>&e;</a>`, ``},
+		{`<?xml version="1.0" standalone="yes"?><!DOCTYPE a SYSTEM "a.dtd"><a>&e;</a>`, `undeclared-entity 68-71`},
+		{`<a x=1/>`, `syntax 3-6`},
+		{`<a><!-- x`, `syntax 3-9; unclosed-tag 9-9 (0-3)`},
+		{`<a x="1"`, `syntax 8-8`},
+		{`<a b=>c</a>`, `syntax 3-5`},
+		{`< =`, `invalid-name 1-1; invalid-name 2-2; syntax 2-3; syntax 3-3`},
+		{"<?xml version=\"1.1\"?><a>&#x1;\u0085</a>", ``},
+		{"<?xml version=\"1.1\"?><a>\x01&#x0;</a>", `invalid-char 24-25; invalid-char 25-30`},
+		{"<?xml version=\"1.1\"?><a x=\"\u0080\"/>", `invalid-char 27-29`},
+		{"<a>\u0080&#x1;</a>", `invalid-char 5-10`},
+		{"<?xml version=\"1.1\"?><\u00e9 \u00b7x=\"1\"/>", `invalid-name 25-28`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.xml, func(t *testing.T) {
+			errs := []string{}
+			for _, err := range Validate(parse.NewInputString(tt.xml)) {
+				s := fmt.Sprintf("%v %d-%d", err.Code, err.Start, err.End)
+				if err.Code == MismatchedTagError || err.Code == UnclosedTagError {
+					s += fmt.Sprintf(" (%d-%d)", err.OpenStart, err.OpenEnd)
+				}
+				errs = append(errs, s)
+			}
+			test.String(t, strings.Join(errs, "; "), tt.expected)
+		})
+	}
+}
+
+func TestValidatorError(t *testing.T) {
+	errs := Validate(parse.NewInputString("<a>\n<b></c></a>"))
+	test.T(t, len(errs), 2) // </a> does not match <b> either
+	test.T(t, errs[0].Code, MismatchedTagError)
+	test.T(t, errs[0].Line, 2)
+	test.T(t, errs[0].Column, 4)
+	test.String(t, errs[0].Message, "end tag </c> does not match start tag <b>")
+}